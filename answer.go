@@ -0,0 +1,239 @@
+package predicato
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
+	"github.com/soundprediction/go-predicato/pkg/search"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// Default values of AnswerOptions
+const (
+	DefaultAnswerNeighborhoodDepth = 1
+	DefaultAnswerMaxFacts          = 50
+)
+
+// citationPattern matches a [uuid] citation in an answer
+var citationPattern = regexp.MustCompile(`\[([^\[\]]+)\]`)
+
+// AnswerOptions holds options for answering a question from the graph.
+type AnswerOptions struct {
+	// SearchConfig finds the nodes and edges the answer starts from
+	// (default: the client's search configuration)
+	SearchConfig *types.SearchConfig
+	// NeighborhoodDepth is the number of hops around the found nodes whose
+	// edges are added as context (default: 1). Negative disables the
+	// neighborhood.
+	NeighborhoodDepth int
+	// MaxFacts limits the number of edges passed to the LLM (default: 50)
+	MaxFacts int
+}
+
+// Answer answers a question from the knowledge graph: it searches the graph,
+// adds the edges around the nodes found and asks the LLM to synthesize an
+// answer citing the nodes and edges it relies on by UUID.
+func (c *Client) Answer(ctx context.Context, question string, options *AnswerOptions) (*types.AnswerResult, error) {
+	if c.llm == nil {
+		return nil, fmt.Errorf("an LLM client is required to answer questions")
+	}
+	if options == nil {
+		options = &AnswerOptions{}
+	}
+	depth := options.NeighborhoodDepth
+	if depth == 0 {
+		depth = DefaultAnswerNeighborhoodDepth
+	}
+	maxFacts := options.MaxFacts
+	if maxFacts <= 0 {
+		maxFacts = DefaultAnswerMaxFacts
+	}
+
+	results, err := c.Search(ctx, question, options.SearchConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for question: %w", err)
+	}
+
+	nodes, edges, err := c.answerSubgraph(ctx, results, depth, maxFacts)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []types.Message{
+		llm.NewSystemMessage("You answer questions using only the facts and entities of a knowledge graph, citing the ones you use."),
+		llm.NewUserMessage(answerPrompt(question, nodes, edges)),
+	}
+	response, err := c.llm.Chat(usage.WithOperation(ctx, usage.OperationAnswer), messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to synthesize answer: %w", err)
+	}
+
+	answer := &types.AnswerResult{
+		Answer: strings.TrimSpace(response.Content),
+		Nodes:  nodes,
+		Edges:  edges,
+	}
+	answer.NodeUUIDs, answer.EdgeUUIDs = answerCitations(answer.Answer, nodes, edges)
+	return answer, nil
+}
+
+// answerSubgraph returns the nodes and edges found by the search together
+// with the edges within depth hops of the nodes and the endpoints of all
+// edges.
+func (c *Client) answerSubgraph(ctx context.Context, results *types.SearchResults, depth, maxFacts int) ([]*types.Node, []*types.Edge, error) {
+	nodes := make([]*types.Node, 0, len(results.Nodes)+len(results.Communities))
+	nodeSeen := make(map[string]bool)
+	addNode := func(node *types.Node) {
+		if node != nil && !nodeSeen[node.Uuid] {
+			nodeSeen[node.Uuid] = true
+			nodes = append(nodes, node)
+		}
+	}
+	edges := make([]*types.Edge, 0, maxFacts)
+	edgeSeen := make(map[string]bool)
+	addEdge := func(edge *types.Edge) {
+		if len(edges) < maxFacts && !edgeSeen[edge.Uuid] {
+			edgeSeen[edge.Uuid] = true
+			edges = append(edges, edge)
+		}
+	}
+
+	for _, node := range results.Nodes {
+		addNode(node)
+	}
+	for _, community := range results.Communities {
+		addNode(community)
+	}
+	for _, edge := range results.Edges {
+		addEdge(edge)
+	}
+
+	if depth > 0 && len(edges) < maxFacts {
+		var origins []string
+		for _, node := range nodes {
+			if node.Type != types.CommunityNodeType {
+				origins = append(origins, node.Uuid)
+			}
+		}
+		for _, edge := range edges {
+			origins = append(origins, edgeEndpoints(edge)...)
+		}
+
+		neighborhood, err := search.NewSearchUtilities(c.driver).EdgeBFSSearch(ctx, origins, &search.BFSSearchOptions{
+			MaxDepth: depth,
+			Limit:    maxFacts,
+			GroupIDs: []string{c.config.GroupID},
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get neighborhood of search results: %w", err)
+		}
+		for _, edge := range neighborhood {
+			addEdge(edge)
+		}
+	}
+
+	// Fetch the endpoints of the edges that were not found by the search
+	var missing []string
+	for _, edge := range edges {
+		for _, uuid := range edgeEndpoints(edge) {
+			if !nodeSeen[uuid] {
+				nodeSeen[uuid] = true
+				missing = append(missing, uuid)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		endpoints, err := c.driver.GetNodes(ctx, missing, c.config.GroupID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get edge endpoints: %w", err)
+		}
+		nodes = append(nodes, endpoints...)
+	}
+
+	return nodes, edges, nil
+}
+
+// edgeEndpoints returns the UUIDs of the nodes of edge, which drivers set
+// either as SourceNodeID and TargetNodeID or as SourceID and TargetID.
+func edgeEndpoints(edge *types.Edge) []string {
+	var endpoints []string
+	for _, uuid := range []string{edge.SourceNodeID, edge.TargetNodeID, edge.SourceID, edge.TargetID} {
+		if uuid != "" && !slices.Contains(endpoints, uuid) {
+			endpoints = append(endpoints, uuid)
+		}
+	}
+	return endpoints
+}
+
+// answerPrompt builds the prompt asking the LLM to answer question from the
+// subgraph.
+func answerPrompt(question string, nodes []*types.Node, edges []*types.Edge) string {
+	var prompt strings.Builder
+	prompt.WriteString("Answer the question using only the entities and facts below. ")
+	prompt.WriteString("Cite every entity and fact you use by writing its UUID in square brackets, e.g. [uuid], right after the statement it supports. ")
+	prompt.WriteString("Facts are valid between their dates. ")
+	prompt.WriteString("If the entities and facts do not answer the question, say so.\n\n")
+
+	prompt.WriteString("<ENTITIES>\n")
+	for _, node := range nodes {
+		fmt.Fprintf(&prompt, "[%s] %s", node.Uuid, node.Name)
+		if node.Summary != "" {
+			fmt.Fprintf(&prompt, ": %s", node.Summary)
+		}
+		prompt.WriteString("\n")
+	}
+	prompt.WriteString("</ENTITIES>\n\n<FACTS>\n")
+	for _, edge := range edges {
+		fact := edge.Fact
+		if fact == "" {
+			fact = edge.Summary
+		}
+		if fact == "" {
+			fact = edge.Name
+		}
+		fmt.Fprintf(&prompt, "[%s] %s (%s)\n", edge.Uuid, fact, search.FormatEdgeDateRange(edge))
+	}
+	prompt.WriteString("</FACTS>\n\n")
+	fmt.Fprintf(&prompt, "Question: %s", question)
+	return prompt.String()
+}
+
+// answerCitations returns the UUIDs of the nodes and of the edges cited in
+// answer, in order of first citation. Citations of UUIDs outside the subgraph
+// are ignored.
+func answerCitations(answer string, nodes []*types.Node, edges []*types.Edge) ([]string, []string) {
+	isNode := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		isNode[node.Uuid] = true
+	}
+	isEdge := make(map[string]bool, len(edges))
+	for _, edge := range edges {
+		isEdge[edge.Uuid] = true
+	}
+
+	nodeUUIDs := []string{}
+	edgeUUIDs := []string{}
+	cited := make(map[string]bool)
+	for _, match := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		// Several UUIDs may share one pair of brackets
+		for _, uuid := range strings.Split(match[1], ",") {
+			uuid = strings.TrimSpace(uuid)
+			if cited[uuid] {
+				continue
+			}
+			cited[uuid] = true
+			switch {
+			case isNode[uuid]:
+				nodeUUIDs = append(nodeUUIDs, uuid)
+			case isEdge[uuid]:
+				edgeUUIDs = append(edgeUUIDs, uuid)
+			}
+		}
+	}
+	return nodeUUIDs, edgeUUIDs
+}
@@ -0,0 +1,43 @@
+package predicato
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestAnswerCitations(t *testing.T) {
+	nodes := []*types.Node{{Uuid: "alice"}, {Uuid: "acme"}}
+	edges := []*types.Edge{
+		{BaseEdge: types.BaseEdge{Uuid: "works-at"}},
+		{BaseEdge: types.BaseEdge{Uuid: "founded"}},
+	}
+
+	answer := "Alice [alice] works at Acme [works-at, acme]. She is not the founder [unknown] [works-at]."
+	nodeUUIDs, edgeUUIDs := answerCitations(answer, nodes, edges)
+
+	if want := []string{"alice", "acme"}; !reflect.DeepEqual(nodeUUIDs, want) {
+		t.Errorf("node citations = %v, want %v", nodeUUIDs, want)
+	}
+	if want := []string{"works-at"}; !reflect.DeepEqual(edgeUUIDs, want) {
+		t.Errorf("edge citations = %v, want %v", edgeUUIDs, want)
+	}
+}
+
+func TestAnswerPrompt(t *testing.T) {
+	nodes := []*types.Node{{Uuid: "alice", Name: "Alice", Summary: "An engineer"}}
+	edges := []*types.Edge{{BaseEdge: types.BaseEdge{Uuid: "works-at"}, Fact: "Alice works at Acme"}}
+
+	prompt := answerPrompt("Where does Alice work?", nodes, edges)
+	for _, want := range []string{
+		"[alice] Alice: An engineer",
+		"[works-at] Alice works at Acme",
+		"Question: Where does Alice work?",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, prompt)
+		}
+	}
+}
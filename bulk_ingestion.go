@@ -0,0 +1,449 @@
+package predicato
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
+	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils"
+	"github.com/soundprediction/go-predicato/pkg/utils/maintenance"
+)
+
+// bulkEpisode holds the state of an episode of an AddBulk batch.
+type bulkEpisode struct {
+	episode          types.Episode
+	node             *types.Node
+	previousEpisodes []*types.Node
+	tuple            utils.EpisodeTuple
+	extractedNodes   []*types.Node
+	nodes            []*types.Node // resolved entities mentioned by the episode
+	edges            []*types.Edge // extracted edges first seen in the episode
+}
+
+// AddBulk adds episodes to the knowledge graph in a single pass, like the
+// Python add_episode_bulk: entities and relationships are extracted from all
+// the episodes concurrently, deduplicated against each other and the graph in
+// one pass, and written at once.
+//
+// Unlike Add, the entities and relationships of concurrently processed
+// episodes are deduplicated against each other. Episodes are not chunked, so
+// use Add for episodes longer than MaxCharacters. Existing episodes are
+// skipped, or replaced with OverwriteExisting, and communities are updated
+// once per group.
+func (c *Client) AddBulk(ctx context.Context, episodes []types.Episode, options *AddEpisodeOptions) (*types.AddBulkEpisodeResults, error) {
+	if options == nil {
+		options = &AddEpisodeOptions{}
+	}
+	if options.DeferGraphIngestion {
+		return nil, fmt.Errorf("AddBulk does not support deferred graph ingestion")
+	}
+	result := &types.AddBulkEpisodeResults{
+		Episodes:       []*types.Node{},
+		EpisodicEdges:  []*types.Edge{},
+		Nodes:          []*types.Node{},
+		Edges:          []*types.Edge{},
+		Communities:    []*types.Node{},
+		CommunityEdges: []*types.Edge{},
+	}
+
+	if err := utils.ValidateEntityTypes(options.EntityTypes); err != nil {
+		return nil, fmt.Errorf("invalid entity types: %w", err)
+	}
+
+	var batch []*bulkEpisode
+	seen := make(map[string]bool)
+	for _, episode := range episodes {
+		if err := utils.ValidateGroupID(episode.GroupID); err != nil {
+			return nil, fmt.Errorf("invalid group ID: %w", err)
+		}
+		if episode.GroupID == "" {
+			episode.GroupID = utils.GetDefaultGroupID(c.driver.Provider())
+		}
+		if seen[episode.ID] {
+			continue
+		}
+		seen[episode.ID] = true
+
+		content, err := c.redactContent(ctx, episode.Content)
+		if err != nil {
+			return nil, err
+		}
+		episode.Content = content
+
+		if options.OverwriteExisting {
+			if err := c.removeExistingEpisode(ctx, episode.ID, episode.GroupID); err != nil {
+				return nil, err
+			}
+		} else if existingNode, err := c.driver.GetNode(ctx, episode.ID, c.config.GroupID); err == nil && existingNode != nil {
+			c.logger.Debug("Skipping existing episode", "episode_id", episode.ID)
+			continue
+		}
+		batch = append(batch, &bulkEpisode{episode: episode})
+	}
+	if len(batch) == 0 {
+		c.logger.Info("No new episodes to add")
+		return result, nil
+	}
+
+	concurrency := utils.GetSemaphoreLimit()
+	if options.MaxConcurrentEpisodes > 1 {
+		concurrency = options.MaxConcurrentEpisodes
+	}
+	library, err := c.bulkPromptLibrary(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	nodeOps, edgeOps := c.newIngestionOperations(library, options)
+	now := time.Now()
+
+	c.logger.Info("Starting bulk episode ingestion",
+		"episodes", len(batch),
+		"episodes_skipped", len(episodes)-len(batch))
+
+	// STEP 1: Build the episode nodes and extract entities from every episode
+	err = c.forEachBulkEpisode(ctx, concurrency, batch, func(ctx context.Context, e *bulkEpisode) error {
+		previousEpisodes, err := c.getPreviousEpisodesForContext(ctx, e.episode, options)
+		if err != nil {
+			return err
+		}
+		e.previousEpisodes = previousEpisodes
+
+		e.node, err = c.newEpisodeNode(ctx, e.episode)
+		if err != nil {
+			return err
+		}
+
+		prevEps := make([]*types.Episode, len(previousEpisodes))
+		for i, prevNode := range previousEpisodes {
+			prevEps[i] = &types.Episode{
+				ID:        prevNode.Uuid,
+				Name:      prevNode.Name,
+				Content:   prevNode.Content,
+				Reference: prevNode.ValidFrom,
+				CreatedAt: prevNode.CreatedAt,
+				GroupID:   prevNode.GroupID,
+				Metadata:  prevNode.Metadata,
+			}
+		}
+		e.tuple = utils.EpisodeTuple{Episode: &e.episode, PreviousEpisodes: prevEps}
+
+		e.extractedNodes, err = nodeOps.ExtractNodes(ctx, e.node, previousEpisodes,
+			options.EntityTypes, options.ExcludedEntityTypes)
+		if err != nil {
+			return fmt.Errorf("failed to extract nodes: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// STEP 2: Deduplicate the entities of all episodes together
+	extractedNodesByEpisode := make([][]*types.Node, len(batch))
+	episodeTuples := make([]utils.EpisodeTuple, len(batch))
+	for i, e := range batch {
+		extractedNodesByEpisode[i] = e.extractedNodes
+		episodeTuples[i] = e.tuple
+	}
+	clients := &utils.Clients{
+		Driver:   c.driver,
+		LLM:      c.llm,
+		Embedder: c.embedder,
+		Prompts:  library,
+	}
+	dedupeResult, err := utils.DedupeNodesBulk(ctx, clients, extractedNodesByEpisode, episodeTuples,
+		options.EntityTypes, &nodeOpsWrapper{nodeOps})
+	if err != nil {
+		return nil, fmt.Errorf("failed to deduplicate nodes in bulk: %w", err)
+	}
+
+	var resolvedNodes []*types.Node
+	nodeOwners := make(map[string]*bulkEpisode)
+	for _, e := range batch {
+		for _, node := range dedupeResult.NodesByEpisode[e.episode.ID] {
+			if node == nil {
+				continue
+			}
+			e.nodes = append(e.nodes, node)
+			if nodeOwners[node.Uuid] == nil {
+				nodeOwners[node.Uuid] = e
+				resolvedNodes = append(resolvedNodes, node)
+			}
+		}
+	}
+
+	c.logger.Info("Bulk entity deduplication completed",
+		"episodes", len(batch),
+		"resolved_entities", len(resolvedNodes),
+		"uuid_mappings", len(dedupeResult.UUIDMap))
+
+	// STEP 3: Extract relationships from every episode
+	edgeTypeMap := edgeTypeMapFromOptions(options)
+	err = c.forEachBulkEpisode(ctx, concurrency, batch, func(ctx context.Context, e *bulkEpisode) error {
+		if len(e.nodes) == 0 {
+			return nil
+		}
+		edges, err := edgeOps.ExtractEdges(ctx, e.node, e.nodes, e.previousEpisodes,
+			edgeTypeMap, options.EdgeTypes, e.episode.GroupID)
+		if err != nil {
+			return fmt.Errorf("failed to extract edges: %w", err)
+		}
+		utils.ResolveEdgePointers(edges, dedupeResult.UUIDMap)
+		e.edges = edges
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// STEP 4: Deduplicate the relationships of all episodes together, then
+	// resolve them against the graph
+	dedupeEdgesAcrossEpisodes(batch)
+
+	resolvedByEpisode := make([][]*types.Edge, len(batch))
+	invalidatedByEpisode := make([][]*types.Edge, len(batch))
+	indexes := make(map[*bulkEpisode]int, len(batch))
+	for i, e := range batch {
+		indexes[e] = i
+	}
+	err = c.forEachBulkEpisode(ctx, concurrency, batch, func(ctx context.Context, e *bulkEpisode) error {
+		if len(e.edges) == 0 {
+			return nil
+		}
+		resolved, invalidated, err := edgeOps.ResolveExtractedEdges(ctx, e.edges, e.node,
+			resolvedNodes, options.GenerateEmbeddings, options.EdgeTypes)
+		if err != nil {
+			return fmt.Errorf("failed to resolve edges: %w", err)
+		}
+		// Carry over the episodes of the batch duplicates of each edge;
+		// negated edges are not resolved
+		extracted := slices.DeleteFunc(slices.Clone(e.edges), (*types.Edge).Negated)
+		for i, edge := range resolved {
+			if edge == extracted[i] {
+				continue
+			}
+			for _, episodeUUID := range extracted[i].Episodes {
+				maintenance.RecordEdgeProvenance(edge, episodeUUID, nil)
+			}
+		}
+		resolvedByEpisode[indexes[e]] = resolved
+		invalidatedByEpisode[indexes[e]] = invalidated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	resolvedEdges := mergeResolvedEdges(resolvedByEpisode)
+	var invalidatedEdges []*types.Edge
+	invalidated := make(map[string]bool)
+	for _, edges := range invalidatedByEpisode {
+		for _, edge := range edges {
+			if !invalidated[edge.Uuid] {
+				invalidated[edge.Uuid] = true
+				invalidatedEdges = append(invalidatedEdges, edge)
+			}
+		}
+	}
+
+	// STEP 5: Extract the attributes of each entity from the first episode
+	// mentioning it
+	hydrated := make(map[string]*types.Node, len(resolvedNodes))
+	hydratedByEpisode := make([][]*types.Node, len(batch))
+	err = c.forEachBulkEpisode(ctx, concurrency, batch, func(ctx context.Context, e *bulkEpisode) error {
+		var owned []*types.Node
+		for _, node := range e.nodes {
+			if nodeOwners[node.Uuid] == e {
+				owned = append(owned, node)
+			}
+		}
+		if len(owned) == 0 {
+			return nil
+		}
+		nodes, err := nodeOps.ExtractAttributesFromNodes(ctx, owned, e.node, e.previousEpisodes, options.EntityTypes)
+		if err != nil {
+			return fmt.Errorf("failed to extract attributes: %w", err)
+		}
+		hydratedByEpisode[indexes[e]] = nodes
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, nodes := range hydratedByEpisode {
+		for _, node := range nodes {
+			hydrated[node.Uuid] = node
+		}
+	}
+
+	// STEP 6: Link the entities to the episodes mentioning them
+	var episodeNodes []*types.Node
+	var episodicEdges []*types.Edge
+	var hydratedNodes []*types.Node
+	for _, node := range resolvedNodes {
+		if h, ok := hydrated[node.Uuid]; ok {
+			node = h
+		} else {
+			hydrated[node.Uuid] = node
+		}
+		hydratedNodes = append(hydratedNodes, node)
+	}
+	seenDuplicates := make(map[[2]string]bool)
+	for _, e := range batch {
+		// Link entities found to duplicate others without being merged
+		var duplicates []maintenance.NodePair
+		for _, pair := range dedupeResult.DuplicatePairs[e.episode.ID] {
+			key := [2]string{pair.Source.Uuid, pair.Target.Uuid}
+			if !seenDuplicates[key] {
+				seenDuplicates[key] = true
+				duplicates = append(duplicates, maintenance.NodePair(pair))
+			}
+		}
+		duplicateEdges, err := edgeOps.BuildDuplicateOfEdges(ctx, e.node, now, duplicates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build duplicate edges: %w", err)
+		}
+		resolvedEdges = append(resolvedEdges, duplicateEdges...)
+		e.node.EntityEdges = append(edgeUUIDs(resolvedByEpisode[indexes[e]]), edgeUUIDs(duplicateEdges)...)
+
+		episodeNodes = append(episodeNodes, e.node)
+		mentioned := make([]*types.Node, 0, len(e.nodes))
+		for _, node := range e.nodes {
+			node = hydrated[node.Uuid]
+			maintenance.RecordNodeProvenance(node, e.node.Uuid, nil)
+			mentioned = append(mentioned, node)
+		}
+		if len(mentioned) == 0 {
+			continue
+		}
+		edges, err := edgeOps.BuildEpisodicEdges(ctx, mentioned, e.node.Uuid, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build episodic edges: %w", err)
+		}
+		episodicEdges = append(episodicEdges, edges...)
+	}
+
+	// STEP 7: Write the batch at once
+	recordPromptVersion(library.Version(), slices.Concat(episodeNodes, hydratedNodes), resolvedEdges)
+	_, err = utils.AddNodesAndEdgesBulk(ctx, c.driver,
+		episodeNodes,
+		episodicEdges,
+		hydratedNodes,
+		append(resolvedEdges, invalidatedEdges...),
+		c.embedder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add episodes in bulk: %w", err)
+	}
+
+	result.Episodes = episodeNodes
+	result.EpisodicEdges = episodicEdges
+	result.Nodes = hydratedNodes
+	result.Edges = append(resolvedEdges, invalidatedEdges...)
+
+	// STEP 8: Update the communities of each group once
+	updatedGroups := make(map[string]bool)
+	for _, e := range batch {
+		if updatedGroups[e.episode.GroupID] {
+			continue
+		}
+		updatedGroups[e.episode.GroupID] = true
+
+		communities, communityEdges, err := c.updateAndPersistCommunities(ctx, e.episode.ID, e.episode.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		result.Communities = append(result.Communities, communities...)
+		result.CommunityEdges = append(result.CommunityEdges, communityEdges...)
+	}
+
+	c.logger.Info("Bulk episode ingestion completed",
+		"episodes", len(result.Episodes),
+		"total_entities", len(result.Nodes),
+		"total_relationships", len(result.Edges),
+		"total_episodic_edges", len(result.EpisodicEdges),
+		"total_communities", len(result.Communities))
+
+	return result, nil
+}
+
+// forEachBulkEpisode runs fn for the episodes of a batch, up to concurrency at
+// a time, attributing LLM usage to each episode. It returns the error of the
+// first failing episode.
+func (c *Client) forEachBulkEpisode(ctx context.Context, concurrency int, batch []*bulkEpisode, fn func(ctx context.Context, e *bulkEpisode) error) error {
+	functions := make([]func() (struct{}, error), len(batch))
+	for i, e := range batch {
+		functions[i] = func() (struct{}, error) {
+			ingestionSource := e.episode.Source
+			if ingestionSource == "" {
+				ingestionSource = fmt.Sprintf("episode:%s", e.episode.ID)
+			}
+			episodeCtx := context.WithValue(ctx, types.ContextKeyIngestionSource, ingestionSource)
+			episodeCtx = usage.WithEpisode(episodeCtx, e.episode.ID, e.episode.GroupID)
+			return struct{}{}, fn(episodeCtx, e)
+		}
+	}
+
+	_, errs := utils.SemaphoreGatherWithResults(ctx, concurrency, functions...)
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("failed to process episode %s: %w", batch[i].episode.ID, err)
+		}
+	}
+	return nil
+}
+
+// dedupeEdgesAcrossEpisodes keeps, for edges with the same endpoints and fact,
+// only the one of the first episode of the batch, recording the episodes of
+// the others on it and reinforcing it once per episode.
+func dedupeEdgesAcrossEpisodes(batch []*bulkEpisode) {
+	type edgeKey struct {
+		source, target, fact string
+	}
+	firstSeen := make(map[edgeKey]*types.Edge)
+	for _, e := range batch {
+		unique := e.edges[:0]
+		for _, edge := range e.edges {
+			key := edgeKey{edge.SourceID, edge.TargetID, utils.NormalizeStringExact(edge.Fact)}
+			if first, ok := firstSeen[key]; ok {
+				if !slices.Contains(first.Episodes, e.node.Uuid) {
+					first.Reinforce(edge.CreatedAt)
+				}
+				maintenance.RecordEdgeProvenance(first, e.node.Uuid, nil)
+				continue
+			}
+			firstSeen[key] = edge
+			unique = append(unique, edge)
+		}
+		e.edges = unique
+	}
+}
+
+// mergeResolvedEdges flattens the edges resolved for each episode, merging the
+// episodes and reinforcements of edges resolved to the same existing edge by
+// several episodes.
+func mergeResolvedEdges(edgesByEpisode [][]*types.Edge) []*types.Edge {
+	var merged []*types.Edge
+	byUUID := make(map[string]*types.Edge)
+	for _, edges := range edgesByEpisode {
+		for _, edge := range edges {
+			first, ok := byUUID[edge.Uuid]
+			if !ok {
+				byUUID[edge.Uuid] = edge
+				merged = append(merged, edge)
+				continue
+			}
+			if first == edge {
+				continue
+			}
+			for _, episodeUUID := range edge.Episodes {
+				if !slices.Contains(first.Episodes, episodeUUID) {
+					first.Reinforce(edge.ReinforcedAt())
+				}
+				maintenance.RecordEdgeProvenance(first, episodeUUID, nil)
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,63 @@
+package predicato
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func bulkEdge(uuid, source, target, fact, episode string) *types.Edge {
+	edge := types.NewEntityEdge(uuid, source, target, "g", "RELATES_TO", types.EntityEdgeType)
+	edge.Fact = fact
+	edge.Episodes = []string{episode}
+	edge.SourceIDs = []string{episode}
+	return edge
+}
+
+func TestDedupeEdgesAcrossEpisodes(t *testing.T) {
+	first := &bulkEpisode{
+		node:  &types.Node{Uuid: "ep1"},
+		edges: []*types.Edge{bulkEdge("e1", "alice", "acme", "Alice works at Acme", "ep1")},
+	}
+	second := &bulkEpisode{
+		node: &types.Node{Uuid: "ep2"},
+		edges: []*types.Edge{
+			bulkEdge("e2", "alice", "acme", "alice  works at acme", "ep2"),
+			bulkEdge("e3", "bob", "acme", "Bob works at Acme", "ep2"),
+		},
+	}
+
+	dedupeEdgesAcrossEpisodes([]*bulkEpisode{first, second})
+
+	if len(first.edges) != 1 || len(second.edges) != 1 || second.edges[0].Uuid != "e3" {
+		t.Fatalf("edges = %v, %v, want e1 and e3 only", first.edges, second.edges)
+	}
+	if want := []string{"ep1", "ep2"}; !reflect.DeepEqual(first.edges[0].Episodes, want) {
+		t.Errorf("Episodes = %v, want %v", first.edges[0].Episodes, want)
+	}
+	if got := first.edges[0].Reinforcements(); got != 1 {
+		t.Errorf("Reinforcements() = %d, want 1", got)
+	}
+}
+
+func TestMergeResolvedEdges(t *testing.T) {
+	// Two episodes resolved to copies of the same existing edge
+	existing := bulkEdge("old", "alice", "acme", "Alice works at Acme", "ep0")
+	copy1, copy2 := *existing, *existing
+	copy1.Episodes = []string{"ep0", "ep1"}
+	copy2.Episodes = []string{"ep0", "ep2"}
+	fresh := bulkEdge("new", "bob", "acme", "Bob works at Acme", "ep2")
+
+	merged := mergeResolvedEdges([][]*types.Edge{{&copy1}, {&copy2, fresh}})
+
+	if len(merged) != 2 || merged[0] != &copy1 || merged[1] != fresh {
+		t.Fatalf("mergeResolvedEdges() = %v, want the first copy and the new edge", merged)
+	}
+	if want := []string{"ep0", "ep1", "ep2"}; !reflect.DeepEqual(copy1.Episodes, want) {
+		t.Errorf("Episodes = %v, want %v", copy1.Episodes, want)
+	}
+	if got := copy1.Reinforcements(); got != 1 {
+		t.Errorf("Reinforcements() = %d, want 1 for ep2", got)
+	}
+}
@@ -2,20 +2,26 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"log/slog"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/firebase/genkit/go/genkit"
 	"github.com/soundprediction/go-predicato"
 	"github.com/soundprediction/go-predicato/pkg/driver"
 	"github.com/soundprediction/go-predicato/pkg/embedder"
 	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
 	predicatoLogger "github.com/soundprediction/go-predicato/pkg/logger"
+	"github.com/soundprediction/go-predicato/pkg/mcpserver"
+	"github.com/soundprediction/go-predicato/pkg/search/recipes"
 )
 
 // Default configuration values
@@ -23,9 +29,12 @@ const (
 	DefaultLLMModel       = "gpt-4o-mini"
 	DefaultSmallModel     = "gpt-4o-mini"
 	DefaultEmbedderModel  = "text-embedding-3-small"
-	DefaultSemaphoreLimit = 10
+	DefaultLLMConcurrency = 10
 )
 
+// version is the server version reported to MCP clients
+const version = "1.0.0"
+
 // EntityTypes represents custom entity types for extraction
 var EntityTypes = map[string]interface{}{
 	"Requirement": struct {
@@ -58,44 +67,49 @@ type Config struct {
 	DatabaseUser     string
 	DatabasePassword string
 
-	// MCP Server Configuration
-	GroupID           string
-	UseCustomEntities bool
-	DestroyGraph      bool
-	Transport         string
-	Host              string
-	Port              int
+	// Server is the configuration of the MCP server itself
+	mcpserver.Config
 
-	// Concurrency limits
-	SemaphoreLimit int
-}
+	// LLM rate limits shared by all extraction goroutines (zero disables a limit)
+	LLMMaxConcurrency    int
+	LLMRequestsPerMinute int
+	LLMTokensPerMinute   int
 
-// MCPServer wraps the Predicato client for MCP operations
-type MCPServer struct {
-	config *Config
-	client *predicato.Client
-	logger *slog.Logger
+	// LLMAuditLog is a JSONL file recording every LLM prompt and completion
+	LLMAuditLog string
 }
 
 // NewConfig creates a new configuration from environment variables and command line flags
 func NewConfig() *Config {
 	config := &Config{
-		LLMModel:          getEnv("MODEL_NAME", DefaultLLMModel),
-		SmallLLMModel:     getEnv("SMALL_MODEL_NAME", DefaultSmallModel),
-		LLMTemperature:    getEnvFloat("LLM_TEMPERATURE", 0.0),
-		OpenAIAPIKey:      getEnv("OPENAI_API_KEY", ""),
-		EmbedderModel:     getEnv("EMBEDDER_MODEL_NAME", DefaultEmbedderModel),
-		DatabaseDriver:    getEnv("DB_DRIVER", "ladybug"),
-		DatabaseURI:       getEnv("DB_URI", getEnv("ladybug_DB_PATH", "./ladybug_db")),
-		DatabaseUser:      getEnv("NEO4J_USER", ""),
-		DatabasePassword:  getEnv("NEO4J_PASSWORD", ""),
-		GroupID:           getEnv("GROUP_ID", "default"),
-		UseCustomEntities: getEnvBool("USE_CUSTOM_ENTITIES", false),
-		DestroyGraph:      getEnvBool("DESTROY_GRAPH", false),
-		Transport:         getEnv("MCP_TRANSPORT", "stdio"),
-		Host:              getEnv("MCP_HOST", "localhost"),
-		Port:              getEnvInt("MCP_PORT", 3000),
-		SemaphoreLimit:    getEnvInt("SEMAPHORE_LIMIT", DefaultSemaphoreLimit),
+		LLMModel:         getEnv("MODEL_NAME", DefaultLLMModel),
+		SmallLLMModel:    getEnv("SMALL_MODEL_NAME", DefaultSmallModel),
+		LLMTemperature:   getEnvFloat("LLM_TEMPERATURE", 0.0),
+		OpenAIAPIKey:     getEnv("OPENAI_API_KEY", ""),
+		EmbedderModel:    getEnv("EMBEDDER_MODEL_NAME", DefaultEmbedderModel),
+		DatabaseDriver:   getEnv("DB_DRIVER", "ladybug"),
+		DatabaseURI:      getEnv("DB_URI", getEnv("ladybug_DB_PATH", "./ladybug_db")),
+		DatabaseUser:     getEnv("NEO4J_USER", ""),
+		DatabasePassword: getEnv("NEO4J_PASSWORD", ""),
+		Config: mcpserver.Config{
+			Version:             version,
+			GroupID:             getEnv("GROUP_ID", "default"),
+			AllowedGroupIDs:     getEnvList("ALLOWED_GROUP_IDS"),
+			APIKeysFile:         getEnv("MCP_API_KEYS_FILE", ""),
+			UseCustomEntities:   getEnvBool("USE_CUSTOM_ENTITIES", false),
+			DestroyGraph:        getEnvBool("DESTROY_GRAPH", false),
+			Transport:           getEnv("MCP_TRANSPORT", "stdio"),
+			Host:                getEnv("MCP_HOST", "localhost"),
+			Port:                getEnvInt("MCP_PORT", 3000),
+			SearchRecipe:        getEnv("SEARCH_RECIPE", ""),
+			EpisodeQueueSize:    getEnvInt("EPISODE_QUEUE_SIZE", mcpserver.DefaultEpisodeQueueSize),
+			ReadyQueueThreshold: getEnvInt("READY_QUEUE_THRESHOLD", mcpserver.DefaultEpisodeQueueSize),
+		},
+		// SEMAPHORE_LIMIT is the former name of LLM_MAX_CONCURRENCY
+		LLMMaxConcurrency:    getEnvInt("LLM_MAX_CONCURRENCY", getEnvInt("SEMAPHORE_LIMIT", DefaultLLMConcurrency)),
+		LLMRequestsPerMinute: getEnvInt("LLM_REQUESTS_PER_MINUTE", 0),
+		LLMTokensPerMinute:   getEnvInt("LLM_TOKENS_PER_MINUTE", 0),
+		LLMAuditLog:          getEnv("LLM_AUDIT_LOG", ""),
 	}
 
 	return config
@@ -109,6 +123,21 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList parses a comma-separated list, skipping empty items
+func getEnvList(key string) []string {
+	return splitList(os.Getenv(key))
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getEnvFloat(key string, defaultValue float64) float64 {
 	if value := os.Getenv(key); value != "" {
 		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
@@ -137,7 +166,7 @@ func getEnvInt(key string, defaultValue int) int {
 }
 
 // NewMCPServer creates a new MCP server instance
-func NewMCPServer(config *Config) (*MCPServer, error) {
+func NewMCPServer(config *Config) (*mcpserver.Server, error) {
 	logger := slog.New(predicatoLogger.NewColorHandler(os.Stderr, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	}))
@@ -168,8 +197,38 @@ func NewMCPServer(config *Config) (*MCPServer, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to create LLM client: %w", err)
 		}
+		// Send deduplication judgments and community summaries to the small model
+		var routedLLMClient llm.Client = baseLLMClient
+		if config.SmallLLMModel != "" && config.SmallLLMModel != config.LLMModel {
+			smallLLMConfig := llmConfig
+			smallLLMConfig.Model = config.SmallLLMModel
+			smallLLMClient, err := llm.NewOpenAIClient(config.OpenAIAPIKey, smallLLMConfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create small LLM client: %w", err)
+			}
+			routedLLMClient = llm.NewRouter(baseLLMClient).Route(smallLLMClient,
+				usage.OperationDedupeNodes,
+				usage.OperationDedupeEdges,
+				usage.OperationInvalidateEdges,
+				usage.OperationBuildCommunities,
+			)
+		}
+		// Record every prompt and completion for debugging
+		if config.LLMAuditLog != "" {
+			auditSink, err := llm.NewJSONLAuditSink(config.LLMAuditLog)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open LLM audit log: %w", err)
+			}
+			routedLLMClient = llm.NewAuditClient(routedLLMClient, auditSink)
+		}
+		// Share one rate limit across all LLM calls, retries included
+		rateLimitedClient := llm.NewRateLimitedClient(routedLLMClient, &llm.RateLimitConfig{
+			MaxConcurrency:    config.LLMMaxConcurrency,
+			RequestsPerMinute: config.LLMRequestsPerMinute,
+			TokensPerMinute:   config.LLMTokensPerMinute,
+		})
 		// Wrap with retry client for automatic retry on errors
-		llmClient = llm.NewRetryClient(baseLLMClient, llm.DefaultRetryConfig())
+		llmClient = llm.NewRetryClient(rateLimitedClient, llm.DefaultRetryConfig())
 	}
 
 	// Create embedder client
@@ -189,124 +248,16 @@ func NewMCPServer(config *Config) (*MCPServer, error) {
 
 	client := predicato.NewClient(graphDriver, llmClient, embedderClient, predicatoConfig, logger)
 
-	return &MCPServer{
-		config: config,
-		client: client,
-		logger: logger,
-	}, nil
-}
-
-// Initialize sets up the MCP server and Predicato client
-func (s *MCPServer) Initialize(ctx context.Context) error {
-	s.logger.Info("Initializing Predicato MCP server...")
-
-	// Verify the client is ready
-	if s.client == nil {
-		return fmt.Errorf("predicato client not initialized")
-	}
-
-	// Initialize graph indices and constraints
-	s.logger.Info("Initializing graph indices and constraints...")
-	err := s.client.CreateIndices(ctx)
-	if err != nil {
-		s.logger.Error("Failed to initialize graph indices", "error", err)
-		return fmt.Errorf("failed to initialize graph indices: %w", err)
-	}
-	s.logger.Info("Graph indices and constraints initialized successfully")
-
-	// Clear graph if requested
-	if s.config.DestroyGraph {
-		s.logger.Warn("Graph destruction requested - clearing all data for group", "group_id", s.config.GroupID)
-
-		err := s.client.ClearGraph(ctx, s.config.GroupID)
-		if err != nil {
-			s.logger.Error("Failed to clear graph during initialization", "error", err)
-			return fmt.Errorf("failed to clear graph: %w", err)
-		}
-
-		s.logger.Info("Graph cleared successfully during initialization")
-	}
-
-	s.logger.Info("Predicato client initialized successfully")
-	s.logger.Info("MCP server configuration",
-		"llm_model", s.config.LLMModel,
-		"temperature", s.config.LLMTemperature,
-		"group_id", s.config.GroupID,
-		"custom_entities", s.config.UseCustomEntities,
-		"semaphore_limit", s.config.SemaphoreLimit,
-	)
-
-	return nil
-}
-
-// RegisterTools registers all MCP tools with Genkit
-func (s *MCPServer) RegisterTools(g *genkit.Genkit) {
-	// Register add_memory tool
-	genkit.DefineTool(g, "add_memory",
-		"Add an episode to memory. This is the primary way to add information to the graph.",
-		s.AddMemoryTool)
-
-	// Register search_memory_nodes tool
-	genkit.DefineTool(g, "search_memory_nodes",
-		"Search the graph memory for relevant node summaries.",
-		s.SearchMemoryNodesTool)
-
-	// Register search_memory_facts tool
-	genkit.DefineTool(g, "search_memory_facts",
-		"Search the graph memory for relevant facts.",
-		s.SearchMemoryFactsTool)
-
-	// Register delete_entity_edge tool
-	genkit.DefineTool(g, "delete_entity_edge",
-		"Delete an entity edge from the graph memory.",
-		s.DeleteEntityEdgeTool)
-
-	// Register delete_episode tool
-	genkit.DefineTool(g, "delete_episode",
-		"Delete an episode from the graph memory.",
-		s.DeleteEpisodeTool)
-
-	// Register get_entity_edge tool
-	genkit.DefineTool(g, "get_entity_edge",
-		"Get an entity edge from the graph memory by its UUID.",
-		s.GetEntityEdgeTool)
-
-	// Register get_episodes tool
-	genkit.DefineTool(g, "get_episodes",
-		"Get the most recent memory episodes for a specific group.",
-		s.GetEpisodesTool)
-
-	// Register clear_graph tool
-	genkit.DefineTool(g, "clear_graph",
-		"Clear all data from the graph memory.",
-		s.ClearGraphTool)
-}
-
-// Run starts the MCP server
-func (s *MCPServer) Run(ctx context.Context) error {
-	s.logger.Info("Starting Genkit MCP server", "transport", s.config.Transport)
-
-	// Initialize Genkit
-	g := genkit.Init(ctx)
-
-	// Register all tools
-	s.RegisterTools(g)
-
-	// Start the server (this would typically be handled by Genkit's runtime)
-	s.logger.Info("MCP server is ready to accept requests")
-
-	// Keep the server running
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	}
+	return mcpserver.New(client, &config.Config, logger), nil
 }
 
 func main() {
 	// Parse command line flags
 	var (
 		groupID           = flag.String("group-id", "", "Namespace for the graph")
-		transport         = flag.String("transport", "stdio", "Transport to use (stdio or sse)")
+		apiKeysFile       = flag.String("api-keys-file", "", "JSON file of the API keys required by the HTTP transport")
+		allowedGroupIDs   = flag.String("allowed-group-ids", "", "Comma-separated groups requests may name besides --group-id (default: any group)")
+		transport         = flag.String("transport", "stdio", "Transport to use (stdio, or sse for HTTP serving both streamable HTTP and SSE)")
 		model             = flag.String("model", "", fmt.Sprintf("Model name to use (default: %s)", DefaultLLMModel))
 		smallModel        = flag.String("small-model", "", fmt.Sprintf("Small model name to use (default: %s)", DefaultSmallModel))
 		temperature       = flag.Float64("temperature", -1, "Temperature setting for the LLM (0.0-2.0)")
@@ -314,6 +265,7 @@ func main() {
 		useCustomEntities = flag.Bool("use-custom-entities", false, "Enable entity extraction using predefined entity types")
 		host              = flag.String("host", "", "Host to bind the MCP server to")
 		port              = flag.Int("port", 0, "Port to bind the MCP server to")
+		searchRecipe      = flag.String("search-recipe", "", fmt.Sprintf("Default search recipe (%s)", strings.Join(recipes.Names(), ", ")))
 	)
 	flag.Parse()
 
@@ -324,6 +276,12 @@ func main() {
 	if *groupID != "" {
 		config.GroupID = *groupID
 	}
+	if *apiKeysFile != "" {
+		config.APIKeysFile = *apiKeysFile
+	}
+	if *allowedGroupIDs != "" {
+		config.AllowedGroupIDs = splitList(*allowedGroupIDs)
+	}
 	if *transport != "" {
 		config.Transport = *transport
 	}
@@ -348,12 +306,19 @@ func main() {
 	if *port != 0 {
 		config.Port = *port
 	}
+	if *searchRecipe != "" {
+		config.SearchRecipe = *searchRecipe
+	}
 
 	// Validate required configuration
 	if config.OpenAIAPIKey == "" && config.UseCustomEntities {
 		log.Fatal("OPENAI_API_KEY must be set when custom entities are enabled")
 	}
 
+	if err := config.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
 	// Validate database configuration based on driver type
 	if config.DatabaseURI == "" {
 		log.Fatal("Database URI/path must be set")
@@ -370,13 +335,14 @@ func main() {
 		log.Fatalf("Failed to create MCP server: %v", err)
 	}
 
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	if err := server.Initialize(ctx); err != nil {
 		log.Fatalf("Failed to initialize MCP server: %v", err)
 	}
 
-	// Run the server
-	if err := server.Run(ctx); err != nil {
+	// Run the server until it is interrupted
+	if err := server.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
 		log.Fatalf("MCP server error: %v", err)
 	}
 }
@@ -5,10 +5,10 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
-	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -16,9 +16,11 @@ import (
 	"github.com/soundprediction/go-predicato/pkg/driver"
 	"github.com/soundprediction/go-predicato/pkg/embedder"
 	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
 	predicatoLogger "github.com/soundprediction/go-predicato/pkg/logger"
+	"github.com/soundprediction/go-predicato/pkg/mcpserver"
+	"github.com/soundprediction/go-predicato/pkg/search/recipes"
 	"github.com/soundprediction/go-predicato/pkg/telemetry"
-	"github.com/soundprediction/go-predicato/pkg/types"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -28,7 +30,7 @@ const (
 	DefaultMCPLLMModel       = "gpt-4o-mini"
 	DefaultMCPSmallModel     = "gpt-4o-mini"
 	DefaultMCPEmbedderModel  = "text-embedding-3-small"
-	DefaultMCPSemaphoreLimit = 10
+	DefaultMCPLLMConcurrency = 10
 )
 
 var mcpCmd = &cobra.Command{
@@ -57,7 +59,15 @@ var (
 	mcpTemperature       float64
 	mcpUseCustomEntities bool
 	mcpDestroyGraph      bool
-	mcpSemaphoreLimit    int
+	mcpLLMConcurrency    int
+	mcpLLMRequestsPerMin int
+	mcpLLMTokensPerMin   int
+	mcpLLMAuditLog       string
+	mcpSearchRecipe      string
+	mcpAllowedGroupIDs   []string
+	mcpAPIKeysFile       string
+	mcpEpisodeQueueSize  int
+	mcpReadyQueueThresh  int
 )
 
 func init() {
@@ -86,11 +96,19 @@ func init() {
 	viper.BindEnv("mcp.temperature", "LLM_TEMPERATURE")
 	viper.BindEnv("mcp.use_custom_entities", "USE_CUSTOM_ENTITIES")
 	viper.BindEnv("mcp.destroy_graph", "DESTROY_GRAPH")
-	viper.BindEnv("mcp.semaphore_limit", "SEMAPHORE_LIMIT")
+	viper.BindEnv("mcp.llm_max_concurrency", "LLM_MAX_CONCURRENCY", "SEMAPHORE_LIMIT") // SEMAPHORE_LIMIT is the former name
+	viper.BindEnv("mcp.llm_requests_per_minute", "LLM_REQUESTS_PER_MINUTE")
+	viper.BindEnv("mcp.llm_tokens_per_minute", "LLM_TOKENS_PER_MINUTE")
+	viper.BindEnv("mcp.llm_audit_log", "LLM_AUDIT_LOG")
+	viper.BindEnv("mcp.search_recipe", "SEARCH_RECIPE")
+	viper.BindEnv("mcp.allowed_group_ids", "ALLOWED_GROUP_IDS")
+	viper.BindEnv("mcp.api_keys_file", "MCP_API_KEYS_FILE")
+	viper.BindEnv("mcp.episode_queue_size", "EPISODE_QUEUE_SIZE")
+	viper.BindEnv("mcp.ready_queue_threshold", "READY_QUEUE_THRESHOLD")
 
 	// MCP Server specific flags
 	mcpCmd.Flags().StringVar(&mcpGroupID, "group-id", "default", "Namespace for the graph")
-	mcpCmd.Flags().StringVar(&mcpTransport, "transport", "stdio", "Transport to use (stdio or sse)")
+	mcpCmd.Flags().StringVar(&mcpTransport, "transport", "stdio", "Transport to use (stdio, or sse for HTTP serving both streamable HTTP and SSE)")
 	mcpCmd.Flags().StringVar(&mcpHost, "host", "localhost", "Host to bind the MCP server to")
 	mcpCmd.Flags().IntVar(&mcpPort, "port", 3000, "Port to bind the MCP server to")
 	mcpCmd.Flags().StringVar(&mcpModel, "model", DefaultMCPLLMModel, "LLM model name")
@@ -98,7 +116,15 @@ func init() {
 	mcpCmd.Flags().Float64Var(&mcpTemperature, "temperature", 0.0, "Temperature setting for the LLM (0.0-2.0)")
 	mcpCmd.Flags().BoolVar(&mcpUseCustomEntities, "use-custom-entities", false, "Enable entity extraction using predefined entity types")
 	mcpCmd.Flags().BoolVar(&mcpDestroyGraph, "destroy-graph", false, "Destroy all Predicato graphs on startup")
-	mcpCmd.Flags().IntVar(&mcpSemaphoreLimit, "semaphore-limit", DefaultMCPSemaphoreLimit, "Concurrency limit for operations")
+	mcpCmd.Flags().IntVar(&mcpLLMConcurrency, "llm-max-concurrency", DefaultMCPLLMConcurrency, "Maximum concurrent LLM requests (0 for unlimited)")
+	mcpCmd.Flags().IntVar(&mcpLLMRequestsPerMin, "llm-requests-per-minute", 0, "LLM requests per minute shared by all operations (0 for unlimited)")
+	mcpCmd.Flags().IntVar(&mcpLLMTokensPerMin, "llm-tokens-per-minute", 0, "LLM tokens per minute shared by all operations (0 for unlimited)")
+	mcpCmd.Flags().StringVar(&mcpLLMAuditLog, "llm-audit-log", "", "JSONL file recording every LLM prompt and completion")
+	mcpCmd.Flags().StringVar(&mcpSearchRecipe, "search-recipe", "", fmt.Sprintf("Default search recipe of the search tools (%s)", strings.Join(recipes.Names(), ", ")))
+	mcpCmd.Flags().StringSliceVar(&mcpAllowedGroupIDs, "allowed-group-ids", nil, "Groups requests may name besides --group-id (default: any group)")
+	mcpCmd.Flags().StringVar(&mcpAPIKeysFile, "api-keys-file", "", "JSON file of the API keys required by the HTTP transport (required unless --host is a loopback address)")
+	mcpCmd.Flags().IntVar(&mcpEpisodeQueueSize, "episode-queue-size", mcpserver.DefaultEpisodeQueueSize, "Episodes a group can have waiting to be added before add_memory rejects more")
+	mcpCmd.Flags().IntVar(&mcpReadyQueueThresh, "ready-queue-threshold", mcpserver.DefaultEpisodeQueueSize, "Queued episodes from which /readyz reports the server not ready")
 
 	// Database flags
 	mcpCmd.Flags().String("db-driver", "ladybug", "Database driver (ladybug, neo4j, falkordb)")
@@ -129,7 +155,15 @@ func init() {
 	viper.BindPFlag("mcp.temperature", mcpCmd.Flags().Lookup("temperature"))
 	viper.BindPFlag("mcp.use_custom_entities", mcpCmd.Flags().Lookup("use-custom-entities"))
 	viper.BindPFlag("mcp.destroy_graph", mcpCmd.Flags().Lookup("destroy-graph"))
-	viper.BindPFlag("mcp.semaphore_limit", mcpCmd.Flags().Lookup("semaphore-limit"))
+	viper.BindPFlag("mcp.llm_max_concurrency", mcpCmd.Flags().Lookup("llm-max-concurrency"))
+	viper.BindPFlag("mcp.llm_requests_per_minute", mcpCmd.Flags().Lookup("llm-requests-per-minute"))
+	viper.BindPFlag("mcp.llm_tokens_per_minute", mcpCmd.Flags().Lookup("llm-tokens-per-minute"))
+	viper.BindPFlag("mcp.llm_audit_log", mcpCmd.Flags().Lookup("llm-audit-log"))
+	viper.BindPFlag("mcp.search_recipe", mcpCmd.Flags().Lookup("search-recipe"))
+	viper.BindPFlag("mcp.allowed_group_ids", mcpCmd.Flags().Lookup("allowed-group-ids"))
+	viper.BindPFlag("mcp.api_keys_file", mcpCmd.Flags().Lookup("api-keys-file"))
+	viper.BindPFlag("mcp.episode_queue_size", mcpCmd.Flags().Lookup("episode-queue-size"))
+	viper.BindPFlag("mcp.ready_queue_threshold", mcpCmd.Flags().Lookup("ready-queue-threshold"))
 
 	// Database configuration
 	viper.BindPFlag("database.uri", mcpCmd.Flags().Lookup("db-uri"))
@@ -171,112 +205,48 @@ type MCPConfig struct {
 	DatabasePassword string
 	DatabaseName     string
 
-	// MCP Server Configuration
-	GroupID           string
-	UseCustomEntities bool
-	DestroyGraph      bool
-	Transport         string
-	Host              string
-	Port              int
+	// Server is the configuration of the MCP server itself
+	mcpserver.Config
 
-	// Concurrency limits
-	SemaphoreLimit int
+	// LLM rate limits shared by all extraction goroutines (zero disables a limit)
+	LLMMaxConcurrency    int
+	LLMRequestsPerMinute int
+	LLMTokensPerMinute   int
+
+	// LLMAuditLog is a JSONL file recording every LLM prompt and completion
+	LLMAuditLog string
 
 	// Telemetry Configuration
 	TelemetryDuckDBPath string
 }
 
-// MCPServer wraps the Predicato client for MCP operations
-type MCPServer struct {
-	config *MCPConfig
-	client *predicato.Client
-	logger *slog.Logger
-}
-
-// EntityTypes represents custom entity types for extraction
-var EntityTypes = map[string]interface{}{
-	"Requirement": struct {
-		ProjectName string `json:"project_name" description:"The name of the project to which the requirement belongs."`
-		Description string `json:"description" description:"Description of the requirement. Only use information mentioned in the context to write this description."`
-	}{},
-	"Preference": struct {
-		Category    string `json:"category" description:"The category of the preference. (e.g., 'Brands', 'Food', 'Music')"`
-		Description string `json:"description" description:"Brief description of the preference. Only use information mentioned in the context to write this description."`
-	}{},
-	"Procedure": struct {
-		Description string `json:"description" description:"Brief description of the procedure. Only use information mentioned in the context to write this description."`
-	}{},
-}
-
-// MCP Tool request/response types
-
-// AddMemoryRequest represents the parameters for adding memory
-type AddMemoryRequest struct {
-	Name              string `json:"name"`
-	EpisodeBody       string `json:"episode_body"`
-	GroupID           string `json:"group_id,omitempty"`
-	Source            string `json:"source,omitempty"`
-	SourceDescription string `json:"source_description,omitempty"`
-	UUID              string `json:"uuid,omitempty"`
-}
-
-// SearchRequest represents search parameters
-type SearchRequest struct {
-	Query string `json:"query"`
-	Limit int    `json:"limit,omitempty"`
-}
-
-// GetEpisodesRequest represents parameters for retrieving episodes
-type GetEpisodesRequest struct {
-	GroupID string `json:"group_id,omitempty"`
-	LastN   int    `json:"last_n,omitempty"`
-}
-
-// ClearGraphRequest represents parameters for clearing the graph
-type ClearGraphRequest struct {
-	GroupID string `json:"group_id,omitempty"`
-	Confirm bool   `json:"confirm,omitempty"`
-}
-
-// UUIDRequest represents a simple UUID parameter
-type UUIDRequest struct {
-	UUID string `json:"uuid"`
-}
-
-// MCPToolResponse is a generic response wrapper
-type MCPToolResponse struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
-}
-
-// MCPTool represents a registered MCP tool
-type MCPTool struct {
-	Name        string      `json:"name"`
-	Description string      `json:"description"`
-	Schema      interface{} `json:"inputSchema"`
-}
-
-// MCPCapabilities represents the capabilities of the MCP server
-type MCPCapabilities struct {
-	Tools map[string]MCPTool `json:"tools"`
-}
-
 func runMCPServer(cmd *cobra.Command, args []string) error {
 	// Create configuration using viper (supports config files, env vars, and flags)
 	config := &MCPConfig{
 		// MCP Server configuration
-		GroupID:           getViperStringWithFallback("mcp.group_id", mcpGroupID),
-		Transport:         getViperStringWithFallback("mcp.transport", mcpTransport),
-		Host:              getViperStringWithFallback("mcp.host", mcpHost),
-		Port:              getViperIntWithFallback("mcp.port", mcpPort),
-		LLMModel:          getViperStringWithFallback("mcp.model", mcpModel),
-		SmallLLMModel:     getViperStringWithFallback("mcp.small_model", mcpSmallModel),
-		LLMTemperature:    getViperFloat64WithFallback("mcp.temperature", mcpTemperature),
-		UseCustomEntities: getViperBoolWithFallback("mcp.use_custom_entities", mcpUseCustomEntities),
-		DestroyGraph:      getViperBoolWithFallback("mcp.destroy_graph", mcpDestroyGraph),
-		SemaphoreLimit:    getViperIntWithFallback("mcp.semaphore_limit", mcpSemaphoreLimit),
+		Config: mcpserver.Config{
+			Version:             version,
+			GroupID:             getViperStringWithFallback("mcp.group_id", mcpGroupID),
+			AllowedGroupIDs:     getViperStringSliceWithFallback("mcp.allowed_group_ids", mcpAllowedGroupIDs),
+			APIKeysFile:         getViperStringWithFallback("mcp.api_keys_file", mcpAPIKeysFile),
+			Transport:           getViperStringWithFallback("mcp.transport", mcpTransport),
+			Host:                getViperStringWithFallback("mcp.host", mcpHost),
+			Port:                getViperIntWithFallback("mcp.port", mcpPort),
+			UseCustomEntities:   getViperBoolWithFallback("mcp.use_custom_entities", mcpUseCustomEntities),
+			DestroyGraph:        getViperBoolWithFallback("mcp.destroy_graph", mcpDestroyGraph),
+			SearchRecipe:        getViperStringWithFallback("mcp.search_recipe", mcpSearchRecipe),
+			EpisodeQueueSize:    getViperIntWithFallback("mcp.episode_queue_size", mcpEpisodeQueueSize),
+			ReadyQueueThreshold: getViperIntWithFallback("mcp.ready_queue_threshold", mcpReadyQueueThresh),
+		},
+		LLMModel:       getViperStringWithFallback("mcp.model", mcpModel),
+		SmallLLMModel:  getViperStringWithFallback("mcp.small_model", mcpSmallModel),
+		LLMTemperature: getViperFloat64WithFallback("mcp.temperature", mcpTemperature),
+
+		// LLM rate limits
+		LLMMaxConcurrency:    getViperIntWithFallback("mcp.llm_max_concurrency", mcpLLMConcurrency),
+		LLMRequestsPerMinute: getViperIntWithFallback("mcp.llm_requests_per_minute", mcpLLMRequestsPerMin),
+		LLMTokensPerMinute:   getViperIntWithFallback("mcp.llm_tokens_per_minute", mcpLLMTokensPerMin),
+		LLMAuditLog:          getViperStringWithFallback("mcp.llm_audit_log", mcpLLMAuditLog),
 
 		// Database configuration - viper handles env vars automatically
 		DatabaseDriver:   getViperStringWithFallback("database.driver", "ladybug"),
@@ -340,7 +310,8 @@ func runMCPServer(cmd *cobra.Command, args []string) error {
 		}
 		return nil
 	case sig := <-sigChan:
-		fmt.Printf("\nReceived signal: %v\n", sig)
+		// stdout carries the protocol over stdio
+		fmt.Fprintf(os.Stderr, "\nReceived signal: %v\n", sig)
 		cancel()
 
 		// Give server time to shutdown gracefully
@@ -348,14 +319,14 @@ func runMCPServer(cmd *cobra.Command, args []string) error {
 		case <-time.After(10 * time.Second):
 			return fmt.Errorf("server shutdown timeout")
 		case <-serverErrChan:
-			fmt.Println("MCP server stopped gracefully")
+			fmt.Fprintln(os.Stderr, "MCP server stopped gracefully")
 			return nil
 		}
 	}
 }
 
 // NewMCPServer creates a new MCP server instance
-func NewMCPServer(config *MCPConfig) (*MCPServer, error) {
+func NewMCPServer(config *MCPConfig) (*mcpserver.Server, error) {
 	logger := slog.New(predicatoLogger.NewColorHandler(os.Stderr, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	}))
@@ -392,8 +363,38 @@ func NewMCPServer(config *MCPConfig) (*MCPServer, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to create LLM client: %w", err)
 		}
+		// Send deduplication judgments and community summaries to the small model
+		var routedLLMClient llm.Client = baseLLMClient
+		if config.SmallLLMModel != "" && config.SmallLLMModel != config.LLMModel {
+			smallLLMConfig := llmConfig
+			smallLLMConfig.Model = config.SmallLLMModel
+			smallLLMClient, err := llm.NewOpenAIClient(apiKey, smallLLMConfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create small LLM client: %w", err)
+			}
+			routedLLMClient = llm.NewRouter(baseLLMClient).Route(smallLLMClient,
+				usage.OperationDedupeNodes,
+				usage.OperationDedupeEdges,
+				usage.OperationInvalidateEdges,
+				usage.OperationBuildCommunities,
+			)
+		}
+		// Record every prompt and completion for debugging
+		if config.LLMAuditLog != "" {
+			auditSink, err := llm.NewJSONLAuditSink(config.LLMAuditLog)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open LLM audit log: %w", err)
+			}
+			routedLLMClient = llm.NewAuditClient(routedLLMClient, auditSink)
+		}
+		// Share one rate limit across all LLM calls, retries included
+		rateLimitedClient := llm.NewRateLimitedClient(routedLLMClient, &llm.RateLimitConfig{
+			MaxConcurrency:    config.LLMMaxConcurrency,
+			RequestsPerMinute: config.LLMRequestsPerMinute,
+			TokensPerMinute:   config.LLMTokensPerMinute,
+		})
 		// Wrap with retry client for automatic retry on errors
-		retryClient := llm.NewRetryClient(baseLLMClient, llm.DefaultRetryConfig())
+		retryClient := llm.NewRetryClient(rateLimitedClient, llm.DefaultRetryConfig())
 
 		// Open DuckDB connection for telemetry (shared between token tracking and error logging)
 		trackingPath := config.TelemetryDuckDBPath
@@ -467,598 +468,13 @@ func NewMCPServer(config *MCPConfig) (*MCPServer, error) {
 
 	client := predicato.NewClient(graphDriver, llmClient, embedderClient, predicatoConfig, logger)
 
-	return &MCPServer{
-		config: config,
-		client: client,
-		logger: logger,
-	}, nil
-}
-
-// Initialize sets up the MCP server and Predicato client
-func (s *MCPServer) Initialize(ctx context.Context) error {
-	s.logger.Info("Initializing Predicato MCP server...")
-
-	// Verify the client is ready
-	if s.client == nil {
-		return fmt.Errorf("predicato client not initialized")
-	}
-
-	// Clear graph if requested
-	if s.config.DestroyGraph {
-		s.logger.Warn("Graph destruction requested - clearing all data for group", "group_id", s.config.GroupID)
-
-		err := s.client.ClearGraph(ctx, s.config.GroupID)
-		if err != nil {
-			s.logger.Error("Failed to clear graph during initialization", "error", err)
-			return fmt.Errorf("failed to clear graph: %w", err)
-		}
-
-		s.logger.Info("Graph cleared successfully during initialization")
-	}
-
-	s.logger.Info("Predicato client initialized successfully")
-	s.logger.Info("MCP server configuration",
-		"llm_model", s.config.LLMModel,
-		"temperature", s.config.LLMTemperature,
-		"group_id", s.config.GroupID,
-		"transport", s.config.Transport,
-		"custom_entities", s.config.UseCustomEntities,
-		"semaphore_limit", s.config.SemaphoreLimit,
-	)
-
-	return nil
-}
-
-// RegisterTools registers all MCP tools
-func (s *MCPServer) RegisterTools() error {
-	s.logger.Info("Registering MCP tools...")
-
-	// Create capabilities structure
-	capabilities := &MCPCapabilities{
-		Tools: make(map[string]MCPTool),
-	}
-
-	// Register add_memory tool
-	capabilities.Tools["add_memory"] = MCPTool{
-		Name:        "add_memory",
-		Description: "Add an episode to memory. This is the primary way to add information to the graph.",
-		Schema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"name": map[string]interface{}{
-					"type":        "string",
-					"description": "Name or title of the episode",
-				},
-				"episode_body": map[string]interface{}{
-					"type":        "string",
-					"description": "The content or body of the episode to be added",
-				},
-				"group_id": map[string]interface{}{
-					"type":        "string",
-					"description": "Group ID to associate the episode with (optional)",
-				},
-				"source": map[string]interface{}{
-					"type":        "string",
-					"description": "Source of the episode (optional)",
-				},
-				"source_description": map[string]interface{}{
-					"type":        "string",
-					"description": "Description of the source (optional)",
-				},
-				"uuid": map[string]interface{}{
-					"type":        "string",
-					"description": "Custom UUID for the episode (optional)",
-				},
-			},
-			"required": []string{"name", "episode_body"},
-		},
-	}
-
-	// Register search_memory_nodes tool
-	capabilities.Tools["search_memory_nodes"] = MCPTool{
-		Name:        "search_memory_nodes",
-		Description: "Search the graph memory for relevant node summaries.",
-		Schema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"query": map[string]interface{}{
-					"type":        "string",
-					"description": "Search query for finding relevant nodes",
-				},
-				"limit": map[string]interface{}{
-					"type":        "integer",
-					"description": "Maximum number of results to return (default: 10)",
-					"minimum":     1,
-					"maximum":     100,
-				},
-			},
-			"required": []string{"query"},
-		},
-	}
-
-	// Register search_memory_facts tool
-	capabilities.Tools["search_memory_facts"] = MCPTool{
-		Name:        "search_memory_facts",
-		Description: "Search the graph memory for relevant facts (relationships).",
-		Schema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"query": map[string]interface{}{
-					"type":        "string",
-					"description": "Search query for finding relevant facts",
-				},
-				"limit": map[string]interface{}{
-					"type":        "integer",
-					"description": "Maximum number of results to return (default: 10)",
-					"minimum":     1,
-					"maximum":     100,
-				},
-			},
-			"required": []string{"query"},
-		},
-	}
-
-	// Register get_episodes tool
-	capabilities.Tools["get_episodes"] = MCPTool{
-		Name:        "get_episodes",
-		Description: "Get the most recent memory episodes for a specific group.",
-		Schema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"group_id": map[string]interface{}{
-					"type":        "string",
-					"description": "Group ID to retrieve episodes from (optional)",
-				},
-				"last_n": map[string]interface{}{
-					"type":        "integer",
-					"description": "Number of recent episodes to retrieve (default: 10)",
-					"minimum":     1,
-					"maximum":     100,
-				},
-			},
-		},
-	}
-
-	// Register clear_graph tool
-	capabilities.Tools["clear_graph"] = MCPTool{
-		Name:        "clear_graph",
-		Description: "Clear all data from the graph memory. Requires confirmation.",
-		Schema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"group_id": map[string]interface{}{
-					"type":        "string",
-					"description": "Group ID to clear (optional, defaults to server group)",
-				},
-				"confirm": map[string]interface{}{
-					"type":        "boolean",
-					"description": "Confirmation flag - must be true to proceed with clearing",
-				},
-			},
-			"required": []string{"confirm"},
-		},
-	}
-
-	toolNames := make([]string, 0, len(capabilities.Tools))
-	for name := range capabilities.Tools {
-		toolNames = append(toolNames, name)
-	}
-
-	s.logger.Info("MCP tools registered successfully", "tools", toolNames, "count", len(capabilities.Tools))
-	return nil
-}
-
-// Tool implementations
-
-// AddMemoryTool handles adding episodes to memory
-func (s *MCPServer) AddMemoryTool(ctx context.Context, input *AddMemoryRequest) (*MCPToolResponse, error) {
-	// Validate required fields
-	if input.Name == "" {
-		return &MCPToolResponse{
-			Success: false,
-			Error:   "Name is required",
-		}, nil
-	}
-	if input.EpisodeBody == "" {
-		return &MCPToolResponse{
-			Success: false,
-			Error:   "EpisodeBody is required",
-		}, nil
-	}
-
-	// Set defaults
-	if input.Source == "" {
-		input.Source = "text"
-	}
-	if input.GroupID == "" {
-		input.GroupID = s.config.GroupID
-	}
-
-	// Create episode
-	episode := types.Episode{
-		ID:        input.UUID, // Will be generated if empty
-		Name:      input.Name,
-		Content:   input.EpisodeBody,
-		Reference: time.Now(),
-		CreatedAt: time.Now(),
-		GroupID:   input.GroupID,
-		Metadata: map[string]interface{}{
-			"source":             input.Source,
-			"source_description": input.SourceDescription,
-		},
-	}
-
-	// Add episode using Predicato client
-	_, err := s.client.Add(ctx, []types.Episode{episode}, nil)
-	if err != nil {
-		s.logger.Error("Failed to add episode", "error", err)
-		return &MCPToolResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to add episode: %v", err),
-		}, nil
-	}
-
-	s.logger.Info("Episode added successfully", "name", input.Name, "group_id", input.GroupID)
-	return &MCPToolResponse{
-		Success: true,
-		Message: fmt.Sprintf("Episode '%s' added successfully", input.Name),
-	}, nil
-}
-
-// SearchMemoryNodesTool handles searching for nodes
-func (s *MCPServer) SearchMemoryNodesTool(ctx context.Context, input *SearchRequest) (*MCPToolResponse, error) {
-	// Validate required fields
-	if input.Query == "" {
-		return &MCPToolResponse{
-			Success: false,
-			Error:   "Query is required",
-		}, nil
-	}
-
-	// Set defaults
-	if input.Limit <= 0 {
-		input.Limit = 10
-	}
-
-	// Create search configuration
-	searchConfig := &types.SearchConfig{
-		Limit:              input.Limit,
-		CenterNodeDistance: 2,
-		MinScore:           0.0,
-		IncludeEdges:       false,
-		Rerank:             true,
-		NodeConfig: &types.NodeSearchConfig{
-			SearchMethods: []string{"bm25", "cosine_similarity"},
-			Reranker:      "rrf",
-			MinScore:      0.0,
-		},
-	}
-
-	// Perform search
-	results, err := s.client.Search(ctx, input.Query, searchConfig)
-	if err != nil {
-		s.logger.Error("Failed to search nodes", "error", err)
-		return &MCPToolResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to search nodes: %v", err),
-		}, nil
-	}
-
-	if len(results.Nodes) == 0 {
-		return &MCPToolResponse{
-			Success: true,
-			Message: "No relevant nodes found",
-			Data:    []interface{}{},
-		}, nil
-	}
-
-	// Format results
-	nodeResults := make([]map[string]interface{}, len(results.Nodes))
-	for i, node := range results.Nodes {
-		nodeResults[i] = map[string]interface{}{
-			"uuid":       node.Uuid,
-			"name":       node.Name,
-			"summary":    node.Summary,
-			"type":       string(node.Type),
-			"group_id":   node.GroupID,
-			"created_at": node.CreatedAt.Format(time.RFC3339),
-			"metadata":   node.Metadata,
-		}
-	}
-
-	return &MCPToolResponse{
-		Success: true,
-		Message: "Nodes retrieved successfully",
-		Data:    nodeResults,
-	}, nil
-}
-
-// SearchMemoryFactsTool handles searching for facts (edges)
-func (s *MCPServer) SearchMemoryFactsTool(ctx context.Context, input *SearchRequest) (*MCPToolResponse, error) {
-	// Validate required fields
-	if input.Query == "" {
-		return &MCPToolResponse{
-			Success: false,
-			Error:   "Query is required",
-		}, nil
-	}
-
-	// Set defaults
-	if input.Limit <= 0 {
-		input.Limit = 10
-	}
-
-	// Create search configuration focused on edges
-	searchConfig := &types.SearchConfig{
-		Limit:              input.Limit,
-		CenterNodeDistance: 2,
-		MinScore:           0.0,
-		IncludeEdges:       true,
-		Rerank:             true,
-		EdgeConfig: &types.EdgeSearchConfig{
-			SearchMethods: []string{"bm25", "cosine_similarity"},
-			Reranker:      "rrf",
-			MinScore:      0.0,
-		},
-	}
-
-	// Perform search
-	results, err := s.client.Search(ctx, input.Query, searchConfig)
-	if err != nil {
-		s.logger.Error("Failed to search facts", "error", err)
-		return &MCPToolResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to search facts: %v", err),
-		}, nil
-	}
-
-	if len(results.Edges) == 0 {
-		return &MCPToolResponse{
-			Success: true,
-			Message: "No relevant facts found",
-			Data:    []interface{}{},
-		}, nil
-	}
-
-	// Format results
-	facts := make([]map[string]interface{}, len(results.Edges))
-	for i, edge := range results.Edges {
-		facts[i] = map[string]interface{}{
-			"uuid":       edge.Uuid,
-			"type":       string(edge.Type),
-			"source_id":  edge.SourceID,
-			"target_id":  edge.TargetID,
-			"name":       edge.Name,
-			"summary":    edge.Summary,
-			"strength":   edge.Strength,
-			"group_id":   edge.GroupID,
-			"created_at": edge.CreatedAt.Format(time.RFC3339),
-			"updated_at": edge.UpdatedAt.Format(time.RFC3339),
-			"valid_from": edge.ValidFrom.Format(time.RFC3339),
-			"metadata":   edge.Metadata,
-		}
-		if edge.ValidTo != nil {
-			facts[i]["valid_to"] = edge.ValidTo.Format(time.RFC3339)
-		}
-	}
-
-	return &MCPToolResponse{
-		Success: true,
-		Message: "Facts retrieved successfully",
-		Data:    facts,
-	}, nil
-}
-
-// GetEpisodesTool handles getting recent episodes
-func (s *MCPServer) GetEpisodesTool(ctx context.Context, input *GetEpisodesRequest) (*MCPToolResponse, error) {
-	s.logger.Info("Get episodes requested", "group_id", input.GroupID, "last_n", input.LastN)
-
-	// Set default values
-	groupID := input.GroupID
-	if groupID == "" {
-		groupID = s.config.GroupID // Use server's default group ID
-	}
-
-	limit := input.LastN
-	if limit <= 0 {
-		limit = 10 // Default to 10 episodes
-	}
-
-	// Use the Predicato client to retrieve episodes
-	episodeNodes, err := s.client.GetEpisodes(ctx, groupID, limit)
-	if err != nil {
-		s.logger.Error("Failed to retrieve episodes", "error", err)
-		return &MCPToolResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to retrieve episodes: %v", err),
-		}, nil
-	}
-
-	// Convert nodes to episode format
-	var episodes []map[string]interface{}
-	for _, node := range episodeNodes {
-		episode := map[string]interface{}{
-			"uuid":       node.Uuid,
-			"name":       node.Name,
-			"content":    node.Content,
-			"group_id":   node.GroupID,
-			"created_at": node.CreatedAt.Format(time.RFC3339),
-		}
-
-		// Add episode type if available
-		if node.EpisodeType != "" {
-			episode["episode_type"] = string(node.EpisodeType)
-		}
-
-		// Add reference time if available
-		if !node.Reference.IsZero() {
-			episode["reference"] = node.Reference.Format(time.RFC3339)
-		}
-
-		// Add metadata if available
-		if node.Metadata != nil {
-			episode["metadata"] = node.Metadata
-		}
-
-		episodes = append(episodes, episode)
-	}
-
-	s.logger.Info("Retrieved episodes", "count", len(episodes))
-
-	return &MCPToolResponse{
-		Success: true,
-		Message: fmt.Sprintf("Retrieved %d episodes", len(episodes)),
-		Data: map[string]interface{}{
-			"episodes": episodes,
-			"total":    len(episodes),
-			"group_id": groupID,
-		},
-	}, nil
-}
-
-// ClearGraphTool handles clearing the entire graph
-func (s *MCPServer) ClearGraphTool(ctx context.Context, input *ClearGraphRequest) (*MCPToolResponse, error) {
-	s.logger.Info("Clear graph requested", "group_id", input.GroupID, "confirm", input.Confirm)
-
-	// Safety check - require explicit confirmation
-	if !input.Confirm {
-		return &MCPToolResponse{
-			Success: false,
-			Error:   "Graph clearing requires explicit confirmation. Set 'confirm' to true to proceed.",
-		}, nil
-	}
-
-	// Set default group ID
-	groupID := input.GroupID
-	if groupID == "" {
-		groupID = s.config.GroupID // Use server's default group ID
-	}
-
-	// Warn about the destructive operation
-	s.logger.Warn("Clearing all data from graph", "group_id", groupID)
-
-	// Use the Predicato client to clear the graph
-	err := s.client.ClearGraph(ctx, groupID)
-	if err != nil {
-		s.logger.Error("Failed to clear graph", "error", err, "group_id", groupID)
-		return &MCPToolResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to clear graph: %v", err),
-		}, nil
-	}
-
-	s.logger.Info("Graph cleared successfully", "group_id", groupID)
-
-	return &MCPToolResponse{
-		Success: true,
-		Message: fmt.Sprintf("Graph cleared successfully for group '%s'", groupID),
-		Data: map[string]interface{}{
-			"group_id": groupID,
-			"cleared":  true,
-		},
-	}, nil
-}
-
-// Transport handlers
-
-// handleStdioTransport handles MCP protocol over stdio
-func (s *MCPServer) handleStdioTransport(ctx context.Context) error {
-	s.logger.Info("MCP server handling stdio transport")
-
-	// For stdio transport, we read from stdin and write to stdout
-	// This is a simplified implementation - a full MCP implementation would:
-	// 1. Parse JSON-RPC messages from stdin
-	// 2. Route them to appropriate tool handlers
-	// 3. Send responses via stdout
-
-	s.logger.Info("Stdio transport handler started. Waiting for MCP protocol messages...")
-
-	// Simple message loop - in a real implementation this would be a JSON-RPC message handler
-	for {
-		select {
-		case <-ctx.Done():
-			s.logger.Info("Stdio transport handler shutting down")
-			return ctx.Err()
-		default:
-			// In a real implementation, this would read and parse JSON-RPC messages
-			// and call the appropriate tool methods based on the message content
-			time.Sleep(100 * time.Millisecond)
-		}
-	}
-}
-
-// handleSSETransport handles MCP protocol over Server-Sent Events (HTTP)
-func (s *MCPServer) handleSSETransport(ctx context.Context) error {
-	s.logger.Info("MCP server handling SSE transport", "host", s.config.Host, "port", s.config.Port)
-
-	// For SSE transport, we start an HTTP server
-	// This is a simplified implementation - a full MCP implementation would:
-	// 1. Serve MCP protocol endpoints
-	// 2. Handle tool invocation requests
-	// 3. Stream responses back via SSE
-
-	address := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
-	s.logger.Info("Starting HTTP server for SSE transport", "address", address)
-
-	// Simple HTTP server setup - in a real implementation this would have proper MCP endpoints
-	// and handle the MCP protocol over HTTP/SSE
-	server := &http.Server{
-		Addr: address,
-		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"status": "MCP server running", "transport": "sse"}`))
-		}),
-	}
-
-	// Start server in goroutine
-	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			s.logger.Error("HTTP server error", "error", err)
-		}
-	}()
-
-	s.logger.Info("SSE transport handler started")
-
-	// Wait for context cancellation
-	<-ctx.Done()
-
-	// Shutdown server gracefully
-	s.logger.Info("SSE transport handler shutting down")
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	return server.Shutdown(shutdownCtx)
-}
-
-// Run starts the MCP server
-func (s *MCPServer) Run(ctx context.Context) error {
-	s.logger.Info("Starting MCP server", "transport", s.config.Transport)
-
-	// Register all tools
-	if err := s.RegisterTools(); err != nil {
-		return fmt.Errorf("failed to register MCP tools: %w", err)
-	}
-
-	s.logger.Info("MCP server is ready to accept requests")
-
-	// Handle different transport protocols
-	switch s.config.Transport {
-	case "stdio":
-		s.logger.Info("Starting MCP server with stdio transport")
-		return s.handleStdioTransport(ctx)
-	case "sse":
-		s.logger.Info("Starting MCP server with SSE transport", "host", s.config.Host, "port", s.config.Port)
-		return s.handleSSETransport(ctx)
-	default:
-		return fmt.Errorf("unsupported transport: %s", s.config.Transport)
-	}
+	return mcpserver.New(client, &config.Config, logger), nil
 }
 
 // Helper functions for configuration
 func validateMCPConfig(config *MCPConfig) error {
-	if config.GroupID == "" {
-		return fmt.Errorf("group ID is required")
+	if err := config.Validate(); err != nil {
+		return err
 	}
 
 	if config.DatabaseURI == "" {
@@ -1071,10 +487,6 @@ func validateMCPConfig(config *MCPConfig) error {
 		return fmt.Errorf("LLM API key is required when custom entities are enabled, unless using a custom base URL")
 	}
 
-	if config.Port <= 0 || config.Port > 65535 {
-		return fmt.Errorf("invalid port: %d", config.Port)
-	}
-
 	return nil
 }
 
@@ -1086,6 +498,23 @@ func getViperStringWithFallback(key, fallback string) string {
 	return fallback
 }
 
+// getViperStringSliceWithFallback also splits items on commas, since
+// environment variables such as ALLOWED_GROUP_IDS are comma-separated
+func getViperStringSliceWithFallback(key string, fallback []string) []string {
+	if !viper.IsSet(key) {
+		return fallback
+	}
+	var items []string
+	for _, value := range viper.GetStringSlice(key) {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
 func getViperIntWithFallback(key string, fallback int) int {
 	if viper.IsSet(key) {
 		return viper.GetInt(key)
@@ -0,0 +1,153 @@
+package predicato
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/soundprediction/go-predicato/pkg/config"
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	predicatoLogger "github.com/soundprediction/go-predicato/pkg/logger"
+	"github.com/soundprediction/go-predicato/pkg/utils/maintenance"
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Apply retention policies to the knowledge graph",
+	Long: `Apply retention policies to the knowledge graph:
+- Delete episodes older than a TTL, optionally archiving them to a JSONL file
+- Remove entities no remaining episode mentions
+- Expire edges not reinforced by an episode within a window
+- Expire edges extracted with a confidence below a minimum
+
+Policies are read from the "retention" section of the config file, with a
+default policy and optional per-group policies:
+
+  retention:
+    default:
+      episode_ttl: 8760h
+      remove_orphaned_entities: true
+    groups:
+      medical:
+        edge_window: 2160h
+
+Flags override the default policy.`,
+	RunE: runPrune,
+}
+
+var (
+	pruneGroups      []string
+	pruneArchivePath string
+)
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.Flags().StringSliceVar(&pruneGroups, "group", nil, "Group IDs to prune (default: all groups)")
+	pruneCmd.Flags().StringVar(&pruneArchivePath, "archive", "", "Append deleted episodes to this JSONL file")
+	pruneCmd.Flags().Duration("episode-ttl", 0, "Delete episodes older than this (0 keeps all)")
+	pruneCmd.Flags().Bool("remove-orphans", false, "Delete entities no episode mentions")
+	pruneCmd.Flags().Duration("edge-window", 0, "Expire edges not reinforced within this window (0 keeps all)")
+	pruneCmd.Flags().Float64("min-confidence", 0, "Expire edges with a confidence below this (0 keeps all)")
+
+	// Database flags
+	pruneCmd.Flags().String("db-driver", "ladybug", "Database driver (ladybug)")
+	pruneCmd.Flags().String("db-uri", "./ladybug_db", "Database URI/path")
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cmd.Flags().Changed("db-driver") {
+		cfg.Database.Driver, _ = cmd.Flags().GetString("db-driver")
+	}
+	if cmd.Flags().Changed("db-uri") {
+		cfg.Database.URI, _ = cmd.Flags().GetString("db-uri")
+	}
+
+	var graphDriver driver.GraphDriver
+	switch cfg.Database.Driver {
+	case "ladybug":
+		graphDriver, err = driver.NewLadybugDriver(cfg.Database.URI, 16)
+		if err != nil {
+			return fmt.Errorf("failed to create ladybug driver: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported database driver: %s", cfg.Database.Driver)
+	}
+	defer graphDriver.Close()
+
+	pruner := maintenance.NewPruner(graphDriver, pruneFlagsPolicy(cmd, cfg.Retention.Default))
+	pruner.SetLogger(slog.New(predicatoLogger.NewColorHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+	for groupID, policy := range cfg.Retention.Groups {
+		pruner.SetGroupPolicy(groupID, retentionPolicy(policy))
+	}
+
+	if pruneArchivePath != "" {
+		archive, err := os.OpenFile(pruneArchivePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer archive.Close()
+		pruner.SetArchive(archive)
+	}
+
+	ctx := context.Background()
+	var results []*maintenance.PruneResult
+	if len(pruneGroups) == 0 {
+		results, err = pruner.PruneAll(ctx)
+	} else {
+		for _, groupID := range pruneGroups {
+			var result *maintenance.PruneResult
+			result, err = pruner.Prune(ctx, groupID)
+			results = append(results, result)
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	for _, result := range results {
+		fmt.Printf("%s: %d episodes deleted, %d entities deleted, %d edges expired\n",
+			result.GroupID, result.EpisodesDeleted, result.EntitiesDeleted, result.EdgesExpired)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to prune: %w", err)
+	}
+	return nil
+}
+
+// pruneFlagsPolicy returns the default retention policy with the flags set on
+// the command applied.
+func pruneFlagsPolicy(cmd *cobra.Command, defaults config.RetentionPolicyConfig) maintenance.RetentionPolicy {
+	policy := retentionPolicy(defaults)
+	if cmd.Flags().Changed("episode-ttl") {
+		policy.EpisodeTTL, _ = cmd.Flags().GetDuration("episode-ttl")
+	}
+	if cmd.Flags().Changed("remove-orphans") {
+		policy.RemoveOrphanedEntities, _ = cmd.Flags().GetBool("remove-orphans")
+	}
+	if cmd.Flags().Changed("edge-window") {
+		policy.EdgeReinforcementWindow, _ = cmd.Flags().GetDuration("edge-window")
+	}
+	if cmd.Flags().Changed("min-confidence") {
+		policy.MinConfidence, _ = cmd.Flags().GetFloat64("min-confidence")
+	}
+	return policy
+}
+
+// retentionPolicy converts a configured retention policy.
+func retentionPolicy(policy config.RetentionPolicyConfig) maintenance.RetentionPolicy {
+	return maintenance.RetentionPolicy{
+		EpisodeTTL:              policy.EpisodeTTL,
+		RemoveOrphanedEntities:  policy.RemoveOrphanedEntities,
+		EdgeReinforcementWindow: policy.EdgeWindow,
+		MinConfidence:           policy.MinConfidence,
+	}
+}
@@ -0,0 +1,135 @@
+package predicato
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// GetDuplicates returns the entities linked to a node by IS_DUPLICATE_OF
+// edges, directly or through other duplicates.
+func (c *Client) GetDuplicates(ctx context.Context, nodeUUID string) ([]*types.Node, error) {
+	uuids, err := driver.DuplicateUUIDs(ctx, c.driver, c.config.GroupID, nodeUUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(uuids) == 0 {
+		return []*types.Node{}, nil
+	}
+	nodes, err := c.driver.GetNodes(ctx, uuids, c.config.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duplicates of %s: %w", nodeUUID, err)
+	}
+	return nodes, nil
+}
+
+// canonicalizeDuplicates replaces the entities of results that duplicate
+// another with their canonical entity, and points edges at the canonical
+// entities. Drivers that cannot traverse duplicates leave results unchanged.
+func (c *Client) canonicalizeDuplicates(ctx context.Context, results *types.SearchResults) error {
+	uuidsByGroup := make(map[string][]string)
+	for _, node := range results.Nodes {
+		if node.Type == types.EntityNodeType {
+			uuidsByGroup[node.GroupID] = append(uuidsByGroup[node.GroupID], node.Uuid)
+		}
+	}
+	for _, edge := range results.Edges {
+		source, target := searchEdgeEndpoints(edge)
+		uuidsByGroup[edge.GroupID] = append(uuidsByGroup[edge.GroupID], source, target)
+	}
+
+	canonical := make(map[string]string)
+	canonicalNodes := make(map[string]*types.Node)
+	for groupID, uuids := range uuidsByGroup {
+		groupCanonical, err := driver.CanonicalUUIDs(ctx, c.driver, groupID, uuids)
+		if errors.Is(err, driver.ErrDuplicatesUnsupported) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(groupCanonical) == 0 {
+			continue
+		}
+
+		var canonicalUUIDs []string
+		for duplicate, canonicalUUID := range groupCanonical {
+			canonical[duplicate] = canonicalUUID
+			canonicalUUIDs = append(canonicalUUIDs, canonicalUUID)
+		}
+		nodes, err := c.driver.GetNodes(ctx, canonicalUUIDs, groupID)
+		if err != nil {
+			return fmt.Errorf("failed to get canonical entities: %w", err)
+		}
+		for _, node := range nodes {
+			canonicalNodes[node.Uuid] = node
+		}
+	}
+
+	canonicalizeSearchResults(results, canonical, canonicalNodes)
+	return nil
+}
+
+// canonicalizeSearchResults replaces the nodes of results mapped by canonical
+// with the node of canonicalNodes they map to, keeping the rank of the first
+// of them, and maps the endpoints of edges. IS_DUPLICATE_OF edges between
+// entities mapped to the same canonical entity are dropped.
+func canonicalizeSearchResults(results *types.SearchResults, canonical map[string]string, canonicalNodes map[string]*types.Node) {
+	if len(canonical) == 0 {
+		return
+	}
+
+	nodes := make([]*types.Node, 0, len(results.Nodes))
+	seen := make(map[string]bool, len(results.Nodes))
+	for _, node := range results.Nodes {
+		if canonicalNode, ok := canonicalNodes[canonical[node.Uuid]]; ok {
+			node = canonicalNode
+		}
+		if !seen[node.Uuid] {
+			seen[node.Uuid] = true
+			nodes = append(nodes, node)
+		}
+	}
+	results.Nodes = nodes
+
+	edges := make([]*types.Edge, 0, len(results.Edges))
+	for _, edge := range results.Edges {
+		source, target := searchEdgeEndpoints(edge)
+		canonicalSource, sourceMapped := canonical[source]
+		canonicalTarget, targetMapped := canonical[target]
+		if !sourceMapped && !targetMapped {
+			edges = append(edges, edge)
+			continue
+		}
+		if !sourceMapped {
+			canonicalSource = source
+		}
+		if !targetMapped {
+			canonicalTarget = target
+		}
+		if canonicalSource == canonicalTarget && edge.Name == driver.DuplicateOfEdgeName {
+			continue
+		}
+
+		mapped := *edge
+		mapped.SourceNodeID, mapped.SourceID = canonicalSource, canonicalSource
+		mapped.TargetNodeID, mapped.TargetID = canonicalTarget, canonicalTarget
+		edges = append(edges, &mapped)
+	}
+	results.Edges = edges
+}
+
+// searchEdgeEndpoints returns the source and target node UUIDs of an edge.
+func searchEdgeEndpoints(edge *types.Edge) (string, string) {
+	source, target := edge.SourceNodeID, edge.TargetNodeID
+	if source == "" {
+		source = edge.SourceID
+	}
+	if target == "" {
+		target = edge.TargetID
+	}
+	return source, target
+}
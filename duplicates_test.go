@@ -0,0 +1,44 @@
+package predicato
+
+import (
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestCanonicalizeSearchResults(t *testing.T) {
+	acme := &types.Node{Uuid: "acme", Name: "Acme", Type: types.EntityNodeType}
+	results := &types.SearchResults{
+		Nodes: []*types.Node{
+			{Uuid: "acme-inc", Name: "Acme Inc", Type: types.EntityNodeType},
+			{Uuid: "alice", Name: "Alice", Type: types.EntityNodeType},
+			acme,
+		},
+		Edges: []*types.Edge{
+			types.NewEntityEdge("works", "alice", "acme-inc", "g", "WORKS_AT", types.EntityEdgeType),
+			types.NewEntityEdge("dup", "acme-inc", "acme", "g", "IS_DUPLICATE_OF", types.EntityEdgeType),
+			types.NewEntityEdge("knows", "alice", "bob", "g", "KNOWS", types.EntityEdgeType),
+		},
+	}
+	original := results.Edges[0]
+
+	canonicalizeSearchResults(results,
+		map[string]string{"acme-inc": "acme"},
+		map[string]*types.Node{"acme": acme})
+
+	if len(results.Nodes) != 2 || results.Nodes[0] != acme || results.Nodes[1].Uuid != "alice" {
+		t.Fatalf("Nodes = %v, want acme ranked first, then alice", results.Nodes)
+	}
+	if len(results.Edges) != 2 {
+		t.Fatalf("Edges = %v, want the duplicate edge dropped", results.Edges)
+	}
+	if works := results.Edges[0]; works.TargetNodeID != "acme" || works.TargetID != "acme" {
+		t.Errorf("WORKS_AT target = %s, want acme", works.TargetNodeID)
+	}
+	if original.TargetNodeID != "acme-inc" {
+		t.Errorf("canonicalizeSearchResults() modified the original edge")
+	}
+	if results.Edges[1].Uuid != "knows" {
+		t.Errorf("Edges[1] = %s, want knows", results.Edges[1].Uuid)
+	}
+}
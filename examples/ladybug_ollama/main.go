@@ -14,21 +14,21 @@ import (
 
 // Example demonstrating the combination of:
 // - Ladybug embedded graph database (local, no server required)
-// - Ollama local LLM inference via OpenAI-compatible API (local, no cloud API required)
-// - OpenAI embeddings (or could be replaced with local embeddings)
+// - Ollama local LLM inference via its native chat API (local, no cloud API required)
+// - Ollama local embeddings via its /api/embed endpoint
 //
-// This setup provides maximum privacy and minimal dependencies while
-// maintaining full Predicato functionality. Ollama's OpenAI-compatible API
-// allows seamless integration with existing OpenAI client code.
+// No cloud API is involved, so this setup provides maximum privacy and
+// minimal dependencies while maintaining full Predicato functionality. Ollama's native API constrains
+// structured output to JSON schemas, so extraction results parse reliably.
 
 func main() {
 	ctx := context.Background()
 
-	log.Println("🚀 Starting go-predicato example with Ladybug + Ollama (OpenAI-compatible)")
+	log.Println("🚀 Starting go-predicato example with Ladybug + Ollama (native API)")
 	log.Println("   This example demonstrates a fully local setup:")
 	log.Println("   - Ladybug: embedded graph database")
-	log.Println("   - Ollama: local LLM inference via OpenAI-compatible API")
-	log.Println("   - OpenAI: embeddings (could be replaced with local)")
+	log.Println("   - Ollama: local LLM inference via its native chat API")
+	log.Println("   - Ollama: local embeddings (nomic-embed-text)")
 
 	// ========================================
 	// 1. Create Ladybug Driver (Embedded Graph Database)
@@ -54,45 +54,34 @@ func main() {
 	// ========================================
 	log.Println("\n🧠 Setting up Ollama local LLM client...")
 
-	// Create Ollama client using OpenAI-compatible API
+	// Create Ollama client using Ollama's native chat API, which constrains
+	// structured output to the requested JSON schema
 	// Assumes Ollama is running locally with a model like llama2:7b
-	baseOllama, err := llm.NewOpenAIClient("", llm.Config{
-		BaseURL:     "http://localhost:11434", // Ollama's OpenAI-compatible endpoint
+	baseOllama := llm.NewOllamaClient(&llm.LLMConfig{
+		BaseURL:     "http://localhost:11434", // Ollama's default address
 		Model:       "llama2:7b",              // Popular 7B parameter model
-		Temperature: &[]float32{0.7}[0],       // Balanced creativity
-		MaxTokens:   &[]int{1000}[0],          // Reasonable response length
+		Temperature: 0.7,                      // Balanced creativity
+		MaxTokens:   1000,                     // Reasonable response length
 	})
-	if err != nil {
-		log.Fatalf("Failed to create Ollama client: %v", err)
-	}
 	// Wrap with retry client for automatic retry on errors
 	ollama := llm.NewRetryClient(baseOllama, llm.DefaultRetryConfig())
 	defer ollama.Close()
 
-	log.Println("   ✅ Ollama client created with retry support (using OpenAI-compatible API with llama2:7b)")
+	log.Println("   ✅ Ollama client created with retry support (using the native API with llama2:7b)")
 	log.Println("   💡 Make sure Ollama is running: `ollama serve`")
 	log.Println("   💡 Make sure model is available: `ollama pull llama2:7b`")
-	log.Println("   💡 Ollama exposes OpenAI-compatible API at /v1/chat/completions")
 
 	// ========================================
-	// 3. Create Embedder (OpenAI for now, could be local)
+	// 3. Create Ollama Embedder (Local Embeddings)
 	// ========================================
-	log.Println("\n🔤 Setting up embedding client...")
-
-	// For this example, we'll use OpenAI embeddings
-	// In a fully local setup, you could replace this with a local embedding service
-	embedderConfig := embedder.Config{
-		Model:     "text-embedding-3-small",
-		BatchSize: 50,
-	}
+	log.Println("\n🔤 Setting up Ollama embedding client...")
 
-	// Note: Requires OPENAI_API_KEY environment variable
-	// For fully local setup, replace with local embedding service
-	embedderClient := embedder.NewOpenAIEmbedder("", embedderConfig) // Empty string uses env var
+	// Embeddings come from the same Ollama server, so no API key is needed
+	embedderClient := embedder.NewOllamaEmbedder("http://localhost:11434", "nomic-embed-text")
 	defer embedderClient.Close()
 
-	log.Println("   ✅ OpenAI embedder created (text-embedding-3-small)")
-	log.Println("   💡 For fully local setup, replace with local embedding service")
+	log.Println("   ✅ Ollama embedder created (nomic-embed-text)")
+	log.Println("   💡 Make sure the embedding model is available: `ollama pull nomic-embed-text`")
 
 	// ========================================
 	// 4. Create Predicato Client
@@ -138,7 +127,7 @@ func main() {
 		{
 			ID:        "privacy-benefits-1",
 			Name:      "Privacy and Security Benefits",
-			Content:   "Local setup ensures all data remains on-premises. Graph data stored in local Ladybug database, LLM processing handled by local Ollama instance. Only embeddings require external API calls unless using local embedding service.",
+			Content:   "Local setup ensures all data remains on-premises. Graph data stored in local Ladybug database, LLM processing and embeddings handled by local Ollama instance.",
 			Reference: time.Now().Add(-30 * time.Minute),
 			CreatedAt: time.Now().Add(-30 * time.Minute),
 			GroupID:   "ladybug-ollama-example",
@@ -223,17 +212,17 @@ func main() {
 	// ========================================
 	log.Println("\n📋 Example Summary:")
 	log.Println("   ✅ Ladybug driver: Created (stub implementation)")
-	log.Println("   ✅ Ollama client: Created using OpenAI-compatible API and tested")
+	log.Println("   ✅ Ollama client: Created using the native API and tested")
+	log.Println("   ✅ Ollama embedder: Created (nomic-embed-text)")
 	log.Println("   ✅ Predicato integration: Demonstrated with modern API approach")
 	log.Println("\n🔮 Future State (when Ladybug library is available):")
 	log.Println("   🚀 Full local operation with no cloud dependencies")
 	log.Println("   📊 Embedded graph database for fast local queries")
-	log.Println("   🧠 Local LLM inference via standardized OpenAI-compatible API")
+	log.Println("   🧠 Local LLM inference and embeddings via Ollama")
 	log.Println("   🔒 All data remains on your local machine")
 	log.Println("\n💡 To achieve fully local setup:")
 	log.Println("   1. Wait for stable Ladybug Go library release")
-	log.Println("   2. Replace OpenAI embeddings with local alternative")
-	log.Println("   3. Enjoy complete data privacy and control!")
+	log.Println("   2. Enjoy complete data privacy and control!")
 	log.Println("\n🔧 OpenAI-Compatible API Benefits:")
 	log.Println("   ✅ Standardized interface across different LLM providers")
 	log.Println("   ✅ Easy switching between local and cloud LLM services")
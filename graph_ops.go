@@ -2,10 +2,18 @@ package predicato
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
+	"github.com/soundprediction/go-predicato/pkg/community"
 	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/embedder"
 	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils"
+	"github.com/soundprediction/go-predicato/pkg/utils/maintenance"
 )
 
 // ClearGraph removes all nodes and edges from the knowledge graph for a specific group.
@@ -30,6 +38,307 @@ func (c *Client) ClearGraph(ctx context.Context, groupID string) error {
 	return nil
 }
 
+// PurgeDeleted permanently removes nodes and edges soft-deleted more than olderThan ago.
+// It returns driver.ErrSoftDeleteUnsupported if the driver cannot tombstone records.
+func (c *Client) PurgeDeleted(ctx context.Context, olderThan time.Duration) error {
+	softDeleter, ok := driver.AsSoftDeleter(c.driver)
+	if !ok {
+		return driver.ErrSoftDeleteUnsupported
+	}
+	return softDeleter.PurgeDeleted(ctx, olderThan)
+}
+
+// RestoreDeleted restores the nodes and edges of a group that were soft-deleted at
+// or after since, for example to undo an accidental ClearGraph.
+// It returns driver.ErrSoftDeleteUnsupported if the driver cannot tombstone records.
+func (c *Client) RestoreDeleted(ctx context.Context, groupID string, since time.Time) error {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+
+	softDeleter, ok := driver.AsSoftDeleter(c.driver)
+	if !ok {
+		return driver.ErrSoftDeleteUnsupported
+	}
+	return softDeleter.RestoreDeleted(ctx, groupID, since)
+}
+
+// MergeNodes merges duplicate entities of a group into the canonical entity,
+// for example after manual curation: their edges and episode mentions move to
+// the canonical entity, their summaries and attributes are merged into it and
+// they are deleted, or tombstoned with soft deletes, all in one transaction.
+// An empty groupID uses the client's group. It returns
+// driver.ErrNodeMergeUnsupported if the driver cannot merge entities.
+func (c *Client) MergeNodes(ctx context.Context, groupID, canonicalUUID string, duplicateUUIDs []string) (*types.Node, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	nodeOps := maintenance.NewNodeOperations(c.driver, c.llm, c.embedder, c.config.Prompts)
+	nodeOps.SetLogger(c.logger)
+	nodeOps.SetNameIndexes(c.nameIndexes)
+	return nodeOps.MergeNodes(ctx, groupID, canonicalUUID, duplicateUUIDs)
+}
+
+// RefreshSummaries regenerates the summaries of the entities of a group from
+// all the episodes mentioning them, for example after many incremental
+// updates have drifted. Only entities updated since their last refresh are
+// reprocessed unless opts.Force is set. An empty groupID uses the client's
+// group. It returns the refreshed entities.
+func (c *Client) RefreshSummaries(ctx context.Context, groupID string, opts *maintenance.RefreshSummariesOptions) ([]*types.Node, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	nodeOps := maintenance.NewNodeOperations(c.driver, c.llm, c.embedder, c.config.Prompts)
+	nodeOps.SetLogger(c.logger)
+	nodeOps.SetSummaryOptions(c.config.SummaryOptions)
+	return nodeOps.RefreshSummaries(ctx, groupID, opts)
+}
+
+// ReclassifyEntities classifies the existing entities of a group against
+// entityTypes and saves the entities whose type changed, for example after
+// adding entity types, without re-ingesting episodes. Nil entityTypes use
+// the client's entity types and an empty groupID the client's group. It
+// returns the entities whose type changed.
+func (c *Client) ReclassifyEntities(ctx context.Context, groupID string, entityTypes map[string]interface{}, opts *maintenance.ReclassifyEntitiesOptions) ([]*types.Node, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	if entityTypes == nil {
+		entityTypes = c.config.EntityTypes
+	}
+	if err := utils.ValidateEntityTypes(entityTypes); err != nil {
+		return nil, fmt.Errorf("invalid entity types: %w", err)
+	}
+	nodeOps := maintenance.NewNodeOperations(c.driver, c.llm, c.embedder, c.config.Prompts)
+	nodeOps.SetLogger(c.logger)
+	return nodeOps.ReclassifyEntities(ctx, groupID, entityTypes, opts)
+}
+
+// RebuildCommunitiesProgress reports the progress of RebuildCommunities after
+// each group.
+type RebuildCommunitiesProgress struct {
+	GroupID string
+	// Done and Total count the groups rebuilt so far and to rebuild
+	Done  int
+	Total int
+	// Communities is the number of communities built for the group
+	Communities int
+}
+
+// RebuildCommunitiesOptions configures RebuildCommunities.
+type RebuildCommunitiesOptions struct {
+	// OnProgress, when set, is called after each group is rebuilt
+	OnProgress func(RebuildCommunitiesProgress)
+}
+
+// RebuildCommunities rebuilds the communities of groups from scratch, for
+// example after changing Config.CommunityResolution or after many
+// incremental updates: the communities of each group are built, then the
+// current memberships of the group are closed and the new communities saved,
+// see GetCommunityHistory. Groups are rebuilt one by one, so an error leaves
+// the earlier groups rebuilt. Empty groupIDs use the client's group. It returns
+// the communities built.
+func (c *Client) RebuildCommunities(ctx context.Context, groupIDs []string, opts *RebuildCommunitiesOptions) (*community.BuildCommunitiesResult, error) {
+	if len(groupIDs) == 0 {
+		groupIDs = []string{c.config.GroupID}
+	}
+	if opts == nil {
+		opts = &RebuildCommunitiesOptions{}
+	}
+
+	result := &community.BuildCommunitiesResult{
+		CommunityNodes: []*types.Node{},
+		CommunityEdges: []*types.Edge{},
+	}
+	for i, groupID := range groupIDs {
+		built, err := c.community.BuildCommunities(ctx, []string{groupID}, c.logger)
+		if err != nil {
+			return result, fmt.Errorf("failed to build communities of group %s: %w", groupID, err)
+		}
+		if err := c.persistCommunities(ctx, groupID, built.CommunityNodes, built.CommunityEdges); err != nil {
+			return result, err
+		}
+		c.saveCommunityMetrics(ctx, built.Metrics)
+		result.CommunityNodes = append(result.CommunityNodes, built.CommunityNodes...)
+		result.CommunityEdges = append(result.CommunityEdges, built.CommunityEdges...)
+		result.Metrics = append(result.Metrics, built.Metrics...)
+
+		c.logger.Info("Rebuilt communities",
+			"group_id", groupID,
+			"communities", len(built.CommunityNodes),
+			"community_edges", len(built.CommunityEdges))
+		if opts.OnProgress != nil {
+			opts.OnProgress(RebuildCommunitiesProgress{
+				GroupID:     groupID,
+				Done:        i + 1,
+				Total:       len(groupIDs),
+				Communities: len(built.CommunityNodes),
+			})
+		}
+	}
+	return result, nil
+}
+
+// GetCommunityMembers returns the current members of a community of a group.
+// An empty groupID uses the client's group. It returns
+// driver.ErrCommunityMembersUnsupported if the driver cannot traverse
+// community memberships.
+func (c *Client) GetCommunityMembers(ctx context.Context, groupID, communityUUID string) ([]*types.Node, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	members, err := driver.CommunityMembers(ctx, c.driver, groupID, []string{communityUUID})
+	if err != nil {
+		return nil, err
+	}
+	if len(members[communityUUID]) == 0 {
+		return []*types.Node{}, nil
+	}
+	nodes, err := c.driver.GetNodes(ctx, members[communityUUID], groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members of community %s: %w", communityUUID, err)
+	}
+	return nodes, nil
+}
+
+// GetCommunityHistory returns the communities an entity of a group belonged
+// to over time, oldest first, each valid from when its communities were built
+// until they were next built, so that the evolution of a topic cluster can be
+// followed. An empty groupID uses the client's group. It returns
+// driver.ErrCommunityHistoryUnsupported if the driver cannot keep the history.
+func (c *Client) GetCommunityHistory(ctx context.Context, groupID, entityUUID string) ([]driver.CommunityMembership, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	return driver.CommunityMembershipHistory(ctx, c.driver, groupID, entityUUID)
+}
+
+// GetCommunityMetrics returns the metrics recorded each time the communities
+// of a group were built, most recent first and at most limit, or all of them
+// when limit is 0. Comparing them shows when the topical structure of the
+// group changes. An empty groupID uses the client's group. It returns
+// driver.ErrCommunityMetricsUnsupported if the driver cannot store them.
+func (c *Client) GetCommunityMetrics(ctx context.Context, groupID string, limit int) ([]*driver.CommunityMetrics, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	return driver.ListCommunityMetrics(ctx, c.driver, groupID, limit)
+}
+
+// reembedBatchSize is the number of texts ReembedGraph embeds per request.
+const reembedBatchSize = 256
+
+// ReembedGraph recomputes the embeddings of every node and edge of a group with
+// newEmbedder and records its model and dimensions in the group's embedding
+// metadata, for switching embedding models. Episodes are embedded by content,
+// other nodes by name and edges by fact. All embeddings are computed before any
+// is written, so an embedding failure leaves the graph unchanged; ingestion
+// into the group should be paused while the new embeddings are written. The
+// client keeps its embedder, so create a new client with newEmbedder to ingest
+// and search with the new model.
+func (c *Client) ReembedGraph(ctx context.Context, groupID string, newEmbedder embedder.Client) error {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	if newEmbedder == nil {
+		return fmt.Errorf("new embedder must not be nil")
+	}
+
+	nodes, err := c.getAllNodesForGroup(ctx, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to get nodes for re-embedding: %w", err)
+	}
+	edges, err := c.driver.SearchEdges(ctx, "", groupID, &driver.SearchOptions{Limit: 100000})
+	if err != nil {
+		return fmt.Errorf("failed to get edges for re-embedding: %w", err)
+	}
+
+	// Collect the texts of the existing embeddings, with where each new
+	// embedding goes
+	var texts []string
+	var targets []*[]float32
+	add := func(text string, target *[]float32) {
+		if len(*target) > 0 && text != "" {
+			texts = append(texts, text)
+			targets = append(targets, target)
+		}
+	}
+	for _, node := range nodes {
+		if node.Type == types.EpisodicNodeType {
+			add(node.Content, &node.Embedding)
+		} else {
+			add(node.Name, &node.Embedding)
+		}
+		add(node.Name, &node.NameEmbedding)
+	}
+	for _, edge := range edges {
+		add(edge.Fact, &edge.FactEmbedding)
+		if edge.Summary != "" {
+			add(edge.Summary, &edge.Embedding)
+		} else {
+			add(edge.Fact, &edge.Embedding)
+		}
+	}
+
+	embeddings := make([][]float32, 0, len(texts))
+	for i := 0; i < len(texts); i += reembedBatchSize {
+		end := min(i+reembedBatchSize, len(texts))
+		batch, err := newEmbedder.Embed(ctx, texts[i:end])
+		if err != nil {
+			return fmt.Errorf("failed to re-embed texts %d-%d: %w", i, end, err)
+		}
+		if len(batch) != end-i {
+			return fmt.Errorf("expected %d embeddings, got %d", end-i, len(batch))
+		}
+		embeddings = append(embeddings, batch...)
+	}
+
+	dimensions := newEmbedder.Dimensions()
+	for i, target := range targets {
+		if dimensions == 0 {
+			dimensions = len(embeddings[i])
+		}
+		if len(embeddings[i]) != dimensions {
+			return fmt.Errorf("%w: new embedder returned %d and %d-dimensional embeddings",
+				driver.ErrEmbeddingDimensionMismatch, dimensions, len(embeddings[i]))
+		}
+		*target = embeddings[i]
+	}
+
+	// Write past the embedding guard, which still expects the old dimensions
+	graphDriver := c.driver
+	guard, guarded := c.driver.(*driver.EmbeddingGuard)
+	if guarded {
+		graphDriver = guard.Unwrap()
+	}
+	if err := graphDriver.UpsertNodes(ctx, nodes); err != nil {
+		return fmt.Errorf("failed to write re-embedded nodes: %w", err)
+	}
+	if err := graphDriver.UpsertEdges(ctx, edges); err != nil {
+		return fmt.Errorf("failed to write re-embedded edges: %w", err)
+	}
+
+	err = driver.SetEmbeddingMetadata(ctx, graphDriver, &driver.EmbeddingMetadata{
+		GroupID:    groupID,
+		Model:      embedder.ModelName(newEmbedder),
+		Dimensions: dimensions,
+	})
+	if err != nil && !errors.Is(err, driver.ErrEmbeddingMetadataUnsupported) {
+		return err
+	}
+	if guarded {
+		guard.Forget(groupID)
+	}
+
+	c.logger.Info("Re-embedded graph group",
+		"group_id", groupID,
+		"model", embedder.ModelName(newEmbedder),
+		"dimensions", dimensions,
+		"nodes", len(nodes),
+		"edges", len(edges))
+	return nil
+}
+
 // getAllNodesForGroup retrieves all nodes for a specific group
 func (c *Client) getAllNodesForGroup(ctx context.Context, groupID string) ([]*types.Node, error) {
 	// Search for all nodes with a high limit and no type filter
@@ -57,10 +366,9 @@ func (c *Client) RemoveEpisode(ctx context.Context, episodeUUID string) error {
 
 	// Find edges mentioned by the episode
 	// Equivalent to: edges = await EntityEdge.get_by_uuids(self.driver, episode.entity_edges)
-	wrapper := &driverWrapper{c.driver}
-	edges, err := types.GetEntityEdgesByUUIDs(ctx, wrapper, episode.EntityEdges)
+	edges, err := c.episodeEdges(ctx, episode)
 	if err != nil {
-		return fmt.Errorf("failed to get entity edges: %w", err)
+		return err
 	}
 
 	// We should only delete edges created by the episode
@@ -72,33 +380,11 @@ func (c *Client) RemoveEpisode(ctx context.Context, episodeUUID string) error {
 		}
 	}
 
-	// Find nodes mentioned by the episode
+	// We should delete all nodes that are only mentioned in the deleted episode
 	// Equivalent to: nodes = await get_mentioned_nodes(self.driver, [episode])
-	mentionedNodes, err := types.GetMentionedNodes(ctx, c.driver, []*types.Node{episode})
+	nodesToDelete, _, err := c.episodeMentions(ctx, episode)
 	if err != nil {
-		return fmt.Errorf("failed to get mentioned nodes: %w", err)
-	}
-
-	// We should delete all nodes that are only mentioned in the deleted episode
-	var nodesToDelete []*types.Node
-	for _, node := range mentionedNodes {
-		// Equivalent to: query: LiteralString = 'MATCH (e:Episodic)-[:MENTIONS]->(n:Entity {uuid: $uuid}) RETURN count(*) AS episode_count'
-		query := `MATCH (e:Episodic)-[:MENTIONS]->(n:Entity {uuid: $uuid}) RETURN count(*) AS episode_count`
-		records, _, _, err := c.driver.ExecuteQuery(query, map[string]interface{}{
-			"uuid": node.Uuid,
-		})
-		if err != nil {
-			continue // Skip on error, don't delete
-		}
-
-		// Check if only one episode mentions this node
-		if recordList, ok := records.([]map[string]interface{}); ok {
-			for _, record := range recordList {
-				if count, ok := record["episode_count"].(int64); ok && count == 1 {
-					nodesToDelete = append(nodesToDelete, node)
-				}
-			}
-		}
+		return err
 	}
 
 	// Delete edges first
@@ -108,7 +394,7 @@ func (c *Client) RemoveEpisode(ctx context.Context, episodeUUID string) error {
 		for i, edge := range edgesToDelete {
 			edgeUUIDs[i] = edge.Uuid
 		}
-		if err := types.DeleteEdgesByUUIDs(ctx, wrapper, edgeUUIDs); err != nil {
+		if err := types.DeleteEdgesByUUIDs(ctx, &driverWrapper{c.driver}, edgeUUIDs); err != nil {
 			return fmt.Errorf("failed to delete edges: %w", err)
 		}
 	}
@@ -134,8 +420,255 @@ func (c *Client) RemoveEpisode(ctx context.Context, episodeUUID string) error {
 	return nil
 }
 
+// RollbackEpisode undoes what adding an episode changed in the graph, from
+// the change log recorded on the episode: the validity windows of the edges
+// it invalidated are restored, the entities and edges it created are deleted
+// unless other episodes support them, the episode is removed from the
+// provenance of the others, and the episode itself is deleted. An empty
+// groupID uses the client's group.
+//
+// The rollback is not atomic: it is a sequence of writes, each of which is
+// idempotent, and the episode with its change log is only deleted by the
+// last one. A rollback failing part-way therefore leaves the episode in the
+// graph, partly rolled back, and calling RollbackEpisode again completes it.
+func (c *Client) RollbackEpisode(ctx context.Context, groupID, episodeUUID string) error {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	episode, err := c.retrieveAndValidateEpisode(ctx, episodeUUID, groupID)
+	if err != nil {
+		return err
+	}
+	changeLog, err := episode.ChangeLog()
+	if err != nil {
+		return err
+	}
+	if changeLog == nil {
+		return fmt.Errorf("episode %s has no change log to roll back", episodeUUID)
+	}
+
+	// Restore the edges the episode invalidated
+	validity := make(map[string]types.EdgeValidity, len(changeLog.InvalidatedEdges))
+	for _, v := range changeLog.InvalidatedEdges {
+		validity[v.Uuid] = v
+	}
+	invalidated, err := c.driver.GetEdges(ctx, slices.Collect(maps.Keys(validity)), episode.GroupID)
+	if err != nil {
+		return fmt.Errorf("failed to get invalidated edges: %w", err)
+	}
+	for _, edge := range invalidated {
+		maintenance.RestoreEdgeValidity(edge, validity[edge.Uuid], episode.Uuid)
+		if err := c.driver.UpsertEdge(ctx, edge); err != nil {
+			return fmt.Errorf("failed to restore edge %s: %w", edge.Uuid, err)
+		}
+	}
+
+	// Delete the edges only the episode supports
+	edges, err := c.driver.GetEdges(ctx, slices.Concat(changeLog.CreatedEdges, changeLog.ModifiedEdges), episode.GroupID)
+	if err != nil {
+		return fmt.Errorf("failed to get entity edges: %w", err)
+	}
+	var edgesToDelete []string
+	for _, edge := range edges {
+		maintenance.RemoveEdgeProvenance(edge, episode.Uuid)
+		if len(edge.Episodes) == 0 && slices.Contains(changeLog.CreatedEdges, edge.Uuid) {
+			edgesToDelete = append(edgesToDelete, edge.Uuid)
+			continue
+		}
+		if err := c.driver.UpsertEdge(ctx, edge); err != nil {
+			return fmt.Errorf("failed to update edge %s: %w", edge.Uuid, err)
+		}
+	}
+	if len(edgesToDelete) > 0 {
+		if err := types.DeleteEdgesByUUIDs(ctx, &driverWrapper{c.driver}, edgesToDelete); err != nil {
+			return fmt.Errorf("failed to delete edges: %w", err)
+		}
+	}
+
+	// Delete the entities no other episode mentions
+	nodeUUIDs := slices.Concat(changeLog.CreatedNodes, changeLog.ModifiedNodes)
+	nodes, err := c.driver.GetNodes(ctx, nodeUUIDs, episode.GroupID)
+	if err != nil {
+		return fmt.Errorf("failed to get entities: %w", err)
+	}
+	mentions, err := driver.CountEpisodeMentions(ctx, c.driver, nodeUUIDs, episode.GroupID)
+	if err != nil {
+		return err
+	}
+	var nodesToDelete []string
+	for _, node := range nodes {
+		if mentions[node.Uuid] <= 1 && slices.Contains(changeLog.CreatedNodes, node.Uuid) {
+			nodesToDelete = append(nodesToDelete, node.Uuid)
+			continue
+		}
+		maintenance.RemoveNodeProvenance(node, episode.Uuid)
+		if err := c.driver.UpsertNode(ctx, node); err != nil {
+			return fmt.Errorf("failed to update node %s: %w", node.Uuid, err)
+		}
+	}
+	if len(nodesToDelete) > 0 {
+		if err := types.DeleteNodesByUUIDs(ctx, c.driver, nodesToDelete); err != nil {
+			return fmt.Errorf("failed to delete nodes: %w", err)
+		}
+	}
+
+	if err := types.DeleteNode(ctx, c.driver, episode); err != nil {
+		return fmt.Errorf("failed to delete episode: %w", err)
+	}
+
+	c.logger.Info("Rolled back episode",
+		"episode_id", episode.Uuid,
+		"restored_edges", len(invalidated),
+		"deleted_edges", len(edgesToDelete),
+		"updated_edges", len(edges)-len(edgesToDelete),
+		"deleted_nodes", len(nodesToDelete),
+		"updated_nodes", len(nodes)-len(nodesToDelete))
+	return nil
+}
+
+// InvalidateFact retracts a fact of a group: the entity edge with UUID
+// edgeUUID is expired now, with reason recorded in its metadata, and kept for
+// point-in-time queries. An empty groupID uses the client's group.
+func (c *Client) InvalidateFact(ctx context.Context, groupID, edgeUUID, reason string) (*types.Edge, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	edge, err := c.driver.GetEdge(ctx, edgeUUID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edge: %w", err)
+	}
+	now := time.Now().UTC()
+	if edge.ValidTo != nil && !edge.ValidTo.After(now) {
+		return nil, fmt.Errorf("edge %s was already invalidated at %s", edgeUUID, edge.ValidTo.Format(time.RFC3339))
+	}
+
+	maintenance.RetractEdge(edge, reason, now)
+	if err := c.driver.UpsertEdge(ctx, edge); err != nil {
+		return nil, fmt.Errorf("failed to invalidate edge %s: %w", edgeUUID, err)
+	}
+	c.logger.Info("Invalidated fact", "edge_id", edgeUUID, "group_id", groupID, "reason", reason)
+	return edge, nil
+}
+
+// removeEpisodeVersion removes what an episode about to be overwritten
+// contributed to the graph: edges and entities no other episode supports are
+// deleted, the episode is removed from the provenance of the others, and the
+// episode itself is deleted.
+func (c *Client) removeEpisodeVersion(ctx context.Context, episode *types.Node) error {
+	edges, err := c.episodeEdges(ctx, episode)
+	if err != nil {
+		return err
+	}
+	var edgeUUIDs []string
+	for _, edge := range edges {
+		maintenance.RemoveEdgeProvenance(edge, episode.Uuid)
+		if len(edge.Episodes) == 0 {
+			edgeUUIDs = append(edgeUUIDs, edge.Uuid)
+			continue
+		}
+		if err := c.driver.UpsertEdge(ctx, edge); err != nil {
+			return fmt.Errorf("failed to update edge %s: %w", edge.Uuid, err)
+		}
+	}
+	if len(edgeUUIDs) > 0 {
+		if err := types.DeleteEdgesByUUIDs(ctx, &driverWrapper{c.driver}, edgeUUIDs); err != nil {
+			return fmt.Errorf("failed to delete edges: %w", err)
+		}
+	}
+
+	onlyMentioned, shared, err := c.episodeMentions(ctx, episode)
+	if err != nil {
+		return err
+	}
+	for _, node := range shared {
+		maintenance.RemoveNodeProvenance(node, episode.Uuid)
+		if err := c.driver.UpsertNode(ctx, node); err != nil {
+			return fmt.Errorf("failed to update node %s: %w", node.Uuid, err)
+		}
+	}
+	if len(onlyMentioned) > 0 {
+		nodeUUIDs := make([]string, len(onlyMentioned))
+		for i, node := range onlyMentioned {
+			nodeUUIDs[i] = node.Uuid
+		}
+		if err := types.DeleteNodesByUUIDs(ctx, c.driver, nodeUUIDs); err != nil {
+			return fmt.Errorf("failed to delete nodes: %w", err)
+		}
+	}
+
+	if err := types.DeleteNode(ctx, c.driver, episode); err != nil {
+		return fmt.Errorf("failed to delete episode: %w", err)
+	}
+
+	c.logger.Info("Removed previous version of episode",
+		"episode_id", episode.Uuid,
+		"deleted_edges", len(edgeUUIDs),
+		"updated_edges", len(edges)-len(edgeUUIDs),
+		"deleted_nodes", len(onlyMentioned),
+		"updated_nodes", len(shared))
+	return nil
+}
+
+// episodeEdges returns the entity edges of an episode: those recorded on it
+// and those listing it among their episodes.
+func (c *Client) episodeEdges(ctx context.Context, episode *types.Node) ([]*types.Edge, error) {
+	uuids := slices.Clone(episode.EntityEdges)
+	listing, err := driver.EpisodeEdgeUUIDs(ctx, c.driver, episode.GroupID, episode.Uuid)
+	if err != nil && !errors.Is(err, driver.ErrEpisodeEdgesUnsupported) {
+		return nil, err
+	}
+	uuids = append(uuids, listing...)
+	slices.Sort(uuids)
+	uuids = slices.Compact(uuids)
+	if len(uuids) == 0 {
+		return nil, nil
+	}
+
+	edges, err := types.GetEntityEdgesByUUIDs(ctx, &driverWrapper{c.driver}, uuids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entity edges: %w", err)
+	}
+	return edges, nil
+}
+
+// episodeMentions returns the entities mentioned by an episode, split into
+// those no other episode mentions and the others.
+func (c *Client) episodeMentions(ctx context.Context, episode *types.Node) ([]*types.Node, []*types.Node, error) {
+	mentionedNodes, err := types.GetMentionedNodes(ctx, c.driver, []*types.Node{episode})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get mentioned nodes: %w", err)
+	}
+	uuids := make([]string, len(mentionedNodes))
+	for i, node := range mentionedNodes {
+		uuids[i] = node.Uuid
+	}
+	mentions, err := driver.CountEpisodeMentions(ctx, c.driver, uuids, episode.GroupID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var onlyMentioned, shared []*types.Node
+	for _, node := range mentionedNodes {
+		if mentions[node.Uuid] <= 1 {
+			onlyMentioned = append(onlyMentioned, node)
+		} else {
+			shared = append(shared, node)
+		}
+	}
+	return onlyMentioned, shared, nil
+}
+
 // Close closes the client and all its connections.
 func (c *Client) Close(ctx context.Context) error {
+	c.stagingMu.Lock()
+	for path, queue := range c.stagingQueues {
+		if err := queue.Close(); err != nil {
+			c.logger.Warn("Failed to close deferred ingestion database", "path", path, "error", err)
+		}
+	}
+	c.stagingQueues = nil
+	c.stagingMu.Unlock()
+
 	return c.driver.Close()
 }
 
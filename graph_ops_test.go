@@ -0,0 +1,173 @@
+package predicato
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// rollbackDriver is an in-memory GraphDriver for rolling back episodes,
+// which fails to upsert the edge failEdge once.
+type rollbackDriver struct {
+	driver.GraphDriver
+	nodes    map[string]*types.Node
+	edges    map[string]*types.Edge
+	mentions map[string][]string
+	failEdge string
+}
+
+func (d *rollbackDriver) Provider() driver.GraphProvider {
+	return driver.GraphProviderNeo4j
+}
+
+func (d *rollbackDriver) GetNode(ctx context.Context, nodeID, groupID string) (*types.Node, error) {
+	node, ok := d.nodes[nodeID]
+	if !ok {
+		return nil, fmt.Errorf("node %s not found", nodeID)
+	}
+	return node, nil
+}
+
+func (d *rollbackDriver) GetNodes(ctx context.Context, nodeIDs []string, groupID string) ([]*types.Node, error) {
+	var nodes []*types.Node
+	for _, id := range nodeIDs {
+		if node, ok := d.nodes[id]; ok {
+			copied := *node
+			copied.SourceIDs = slices.Clone(node.SourceIDs)
+			nodes = append(nodes, &copied)
+		}
+	}
+	return nodes, nil
+}
+
+func (d *rollbackDriver) UpsertNode(ctx context.Context, node *types.Node) error {
+	d.nodes[node.Uuid] = node
+	return nil
+}
+
+func (d *rollbackDriver) GetEdges(ctx context.Context, edgeIDs []string, groupID string) ([]*types.Edge, error) {
+	var edges []*types.Edge
+	for _, id := range edgeIDs {
+		if edge, ok := d.edges[id]; ok {
+			copied := *edge
+			copied.Episodes = slices.Clone(edge.Episodes)
+			copied.SourceIDs = slices.Clone(edge.SourceIDs)
+			edges = append(edges, &copied)
+		}
+	}
+	return edges, nil
+}
+
+func (d *rollbackDriver) UpsertEdge(ctx context.Context, edge *types.Edge) error {
+	if edge.Uuid == d.failEdge {
+		d.failEdge = ""
+		return fmt.Errorf("connection reset")
+	}
+	d.edges[edge.Uuid] = edge
+	return nil
+}
+
+func (d *rollbackDriver) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	switch {
+	case strings.Contains(cypherQuery, "count(episode)"):
+		var records []map[string]interface{}
+		for _, id := range kwargs["node_uuids"].([]string) {
+			if episodes := d.mentions[id]; len(episodes) > 0 {
+				records = append(records, map[string]interface{}{"uuid": id, "mentions": int64(len(episodes))})
+			}
+		}
+		return records, nil, nil, nil
+	case strings.Contains(cypherQuery, "DELETE"):
+		uuids, _ := kwargs["uuids"].([]string)
+		if uuid, ok := kwargs["uuid"].(string); ok {
+			uuids = append(uuids, uuid)
+		}
+		for _, uuid := range uuids {
+			delete(d.nodes, uuid)
+			delete(d.edges, uuid)
+			delete(d.mentions, uuid)
+			for id, episodes := range d.mentions {
+				d.mentions[id] = slices.DeleteFunc(episodes, func(e string) bool { return e == uuid })
+			}
+		}
+	}
+	return []map[string]interface{}{}, nil, nil, nil
+}
+
+func TestRollbackEpisodeRetriesAfterPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	validTo := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	episode := &types.Node{Uuid: "ep1", Type: types.EpisodicNodeType, GroupID: "g"}
+	episode.SetChangeLog(&types.EpisodeChangeLog{
+		CreatedNodes:     []string{"created"},
+		ModifiedNodes:    []string{"shared"},
+		CreatedEdges:     []string{"new-fact"},
+		ModifiedEdges:    []string{"old-fact"},
+		InvalidatedEdges: []types.EdgeValidity{{Uuid: "contradicted"}},
+	})
+	newFact := types.NewEntityEdge("new-fact", "created", "shared", "g", "KNOWS", types.EntityEdgeType)
+	newFact.Episodes = []string{"ep1"}
+	oldFact := types.NewEntityEdge("old-fact", "shared", "other", "g", "WORKS_AT", types.EntityEdgeType)
+	oldFact.Episodes = []string{"ep0", "ep1"}
+	contradicted := types.NewEntityEdge("contradicted", "shared", "other", "g", "LIVES_IN", types.EntityEdgeType)
+	contradicted.ValidTo = &validTo
+
+	d := &rollbackDriver{
+		nodes: map[string]*types.Node{
+			"ep1":     episode,
+			"created": {Uuid: "created", Type: types.EntityNodeType, GroupID: "g", SourceIDs: []string{"ep1"}},
+			"shared":  {Uuid: "shared", Type: types.EntityNodeType, GroupID: "g", SourceIDs: []string{"ep0", "ep1"}},
+		},
+		edges: map[string]*types.Edge{
+			"new-fact":     newFact,
+			"old-fact":     oldFact,
+			"contradicted": contradicted,
+		},
+		mentions: map[string][]string{
+			"created": {"ep1"},
+			"shared":  {"ep0", "ep1"},
+		},
+		failEdge: "old-fact",
+	}
+	client := &Client{driver: d, config: &Config{GroupID: "g"}, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	if err := client.RollbackEpisode(ctx, "", "ep1"); err == nil {
+		t.Fatal("RollbackEpisode() error = nil, want the failed edge update")
+	}
+	if _, ok := d.nodes["ep1"]; !ok {
+		t.Fatal("a failed rollback deleted the episode")
+	}
+	if d.edges["contradicted"].ValidTo != nil {
+		t.Errorf("contradicted ValidTo = %v, want restored before the failure", d.edges["contradicted"].ValidTo)
+	}
+
+	if err := client.RollbackEpisode(ctx, "", "ep1"); err != nil {
+		t.Fatalf("retried RollbackEpisode() error = %v", err)
+	}
+	for _, uuid := range []string{"ep1", "created"} {
+		if _, ok := d.nodes[uuid]; ok {
+			t.Errorf("node %s was not deleted", uuid)
+		}
+	}
+	if _, ok := d.edges["new-fact"]; ok {
+		t.Error("edge new-fact was not deleted")
+	}
+	if got := d.nodes["shared"].SourceIDs; !slices.Equal(got, []string{"ep0"}) {
+		t.Errorf("shared SourceIDs = %v, want [ep0]", got)
+	}
+	if got := d.edges["old-fact"].Episodes; !slices.Equal(got, []string{"ep0"}) {
+		t.Errorf("old-fact Episodes = %v, want [ep0]", got)
+	}
+	if d.edges["contradicted"].ValidTo != nil {
+		t.Errorf("contradicted ValidTo = %v, want nil", d.edges["contradicted"].ValidTo)
+	}
+}
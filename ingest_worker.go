@@ -0,0 +1,303 @@
+package predicato
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils"
+)
+
+// stagingQueue returns the DuckDB database of deferred graph ingestion at
+// path, opening it on first use.
+func (c *Client) stagingQueue(path string) (*utils.DuckDBWriter, error) {
+	c.stagingMu.Lock()
+	defer c.stagingMu.Unlock()
+
+	if queue, ok := c.stagingQueues[path]; ok {
+		return queue, nil
+	}
+	queue, err := utils.NewDuckDBWriter(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open deferred ingestion database %s: %w", path, err)
+	}
+	if c.stagingQueues == nil {
+		c.stagingQueues = make(map[string]*utils.DuckDBWriter)
+	}
+	c.stagingQueues[path] = queue
+	return queue, nil
+}
+
+// stageEpisode queues the graph write of an episode in the DuckDB database at
+// path instead of writing it to the graph.
+func (c *Client) stageEpisode(ctx context.Context, path, source string, episodeNode *types.Node, nodes []*types.Node, edges []*types.Edge, episodicEdges []*types.Edge) error {
+	queue, err := c.stagingQueue(path)
+	if err != nil {
+		return err
+	}
+
+	staged := &utils.StagedEpisode{
+		Episode:       episodeNode,
+		Source:        source,
+		EpisodicEdges: episodicEdges,
+		Nodes:         nodes,
+		Edges:         edges,
+	}
+	if source != "" {
+		staged.SourceEdgeUUID = generateID()
+	}
+	if err := queue.StageEpisode(ctx, staged); err != nil {
+		return fmt.Errorf("failed to stage episode %s: %w", episodeNode.Uuid, err)
+	}
+
+	c.logger.Info("Staged episode for deferred graph ingestion",
+		"episode_id", episodeNode.Uuid,
+		"path", path,
+		"nodes", len(nodes),
+		"edges", len(edges),
+		"episodic_edges", len(episodicEdges))
+	return nil
+}
+
+// IngestWorker replays the episodes staged in DuckDB by AddEpisode with
+// DeferGraphIngestion into the graph.
+//
+// Each staged episode is written with upserts, so replaying an episode that
+// was partly written before a crash is safe. Failed replays are retried with
+// exponential backoff; the number of attempts is recorded in DuckDB, and
+// episodes that failed MaxAttempts times are left for RetryFailed.
+type IngestWorker struct {
+	client *Client
+	queue  ingestQueue
+
+	maxAttempts       int
+	batchSize         int
+	retryDelay        time.Duration
+	pollInterval      time.Duration
+	updateCommunities bool
+}
+
+// ingestQueue is the queue of staged episodes drained by an IngestWorker,
+// implemented by utils.DuckDBWriter.
+type ingestQueue interface {
+	PendingEpisodes(ctx context.Context, limit int) ([]*utils.StagedEpisode, error)
+	MarkIngested(ctx context.Context, episodeID string) error
+	MarkFailed(ctx context.Context, episodeID string, cause error, maxAttempts int) error
+	RetryFailed(ctx context.Context) (int64, error)
+}
+
+// IngestReport summarizes a drain of the ingestion queue.
+type IngestReport struct {
+	// Ingested are the UUIDs of the episodes written to the graph
+	Ingested []string
+	// Failed are the UUIDs of the episodes given up on after MaxAttempts
+	Failed []string
+	// Communities and CommunityEdges are the updated communities of the
+	// groups of the ingested episodes
+	Communities    []*types.Node
+	CommunityEdges []*types.Edge
+}
+
+// NewIngestWorker creates a worker draining the deferred ingestion database at
+// duckDBPath into the graph of client. By default an episode is attempted 3
+// times, 1s apart and then doubling, and communities are updated after each
+// drain.
+func NewIngestWorker(client *Client, duckDBPath string) (*IngestWorker, error) {
+	queue, err := client.stagingQueue(duckDBPath)
+	if err != nil {
+		return nil, err
+	}
+	return &IngestWorker{
+		client:            client,
+		queue:             queue,
+		maxAttempts:       3,
+		batchSize:         100,
+		retryDelay:        time.Second,
+		pollInterval:      30 * time.Second,
+		updateCommunities: true,
+	}, nil
+}
+
+// SetMaxAttempts sets the number of times an episode is attempted before it
+// is given up on.
+func (w *IngestWorker) SetMaxAttempts(maxAttempts int) {
+	if maxAttempts > 0 {
+		w.maxAttempts = maxAttempts
+	}
+}
+
+// SetBatchSize sets the number of episodes read from the queue at a time.
+func (w *IngestWorker) SetBatchSize(batchSize int) {
+	if batchSize > 0 {
+		w.batchSize = batchSize
+	}
+}
+
+// SetRetryDelay sets the delay before the first retry of a failed episode,
+// doubled on each further retry.
+func (w *IngestWorker) SetRetryDelay(delay time.Duration) {
+	w.retryDelay = delay
+}
+
+// SetPollInterval sets how often Run checks the queue for new episodes.
+func (w *IngestWorker) SetPollInterval(interval time.Duration) {
+	if interval > 0 {
+		w.pollInterval = interval
+	}
+}
+
+// SetUpdateCommunities sets whether communities are updated after a drain
+// ingests episodes.
+func (w *IngestWorker) SetUpdateCommunities(update bool) {
+	w.updateCommunities = update
+}
+
+// Drain replays the pending episodes of the queue until it is empty. An
+// episode that fails is retried until it succeeds or is given up on; Drain
+// only returns an error when the queue itself fails or ctx is done.
+func (w *IngestWorker) Drain(ctx context.Context) (*IngestReport, error) {
+	report := &IngestReport{}
+	ingestedGroups := make(map[string]string) // group ID -> an ingested episode
+
+	for {
+		pending, err := w.queue.PendingEpisodes(ctx, w.batchSize)
+		if err != nil {
+			return report, err
+		}
+		if len(pending) == 0 {
+			break
+		}
+
+		for _, staged := range pending {
+			ingested, err := w.ingest(ctx, staged)
+			if err != nil {
+				return report, err
+			}
+			if ingested {
+				report.Ingested = append(report.Ingested, staged.Episode.Uuid)
+				ingestedGroups[staged.Episode.GroupID] = staged.Episode.Uuid
+			} else {
+				report.Failed = append(report.Failed, staged.Episode.Uuid)
+			}
+		}
+	}
+
+	if w.updateCommunities {
+		for groupID, episodeID := range ingestedGroups {
+			communities, communityEdges, err := w.client.updateAndPersistCommunities(ctx, episodeID, groupID)
+			if err != nil {
+				w.client.logger.Warn("Failed to update communities after deferred ingestion",
+					"group_id", groupID,
+					"error", err)
+				continue
+			}
+			report.Communities = append(report.Communities, communities...)
+			report.CommunityEdges = append(report.CommunityEdges, communityEdges...)
+		}
+	}
+
+	if len(report.Ingested) > 0 || len(report.Failed) > 0 {
+		w.client.logger.Info("Drained deferred ingestion queue",
+			"ingested", len(report.Ingested),
+			"failed", len(report.Failed))
+	}
+	return report, nil
+}
+
+// Run drains the queue every poll interval until ctx is done.
+func (w *IngestWorker) Run(ctx context.Context) error {
+	for {
+		if _, err := w.Drain(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+// RetryFailed queues the episodes given up on again, and returns their number.
+func (w *IngestWorker) RetryFailed(ctx context.Context) (int64, error) {
+	return w.queue.RetryFailed(ctx)
+}
+
+// ingest replays a staged episode, retrying it until it succeeds or has been
+// attempted maxAttempts times, and reports whether it was ingested. An
+// episode already attempted maxAttempts times, as when MaxAttempts was
+// lowered since, is given up on without replaying it.
+func (w *IngestWorker) ingest(ctx context.Context, staged *utils.StagedEpisode) (bool, error) {
+	episodeID := staged.Episode.Uuid
+	if staged.Attempts >= w.maxAttempts {
+		cause := errors.New(staged.LastError)
+		if staged.LastError == "" {
+			cause = fmt.Errorf("attempted %d times", staged.Attempts)
+		}
+		return false, w.queue.MarkFailed(ctx, episodeID, cause, w.maxAttempts)
+	}
+	for attempt := staged.Attempts; attempt < w.maxAttempts; attempt++ {
+		if attempt > staged.Attempts {
+			select {
+			case <-ctx.Done():
+				return false, ctx.Err()
+			case <-time.After(w.retryDelay << (attempt - staged.Attempts - 1)):
+			}
+		}
+
+		err := w.replay(ctx, staged)
+		if err == nil {
+			return true, w.queue.MarkIngested(ctx, episodeID)
+		}
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+
+		w.client.logger.Warn("Failed to replay staged episode",
+			"episode_id", episodeID,
+			"attempt", attempt+1,
+			"max_attempts", w.maxAttempts,
+			"error", err)
+		if err := w.queue.MarkFailed(ctx, episodeID, err, w.maxAttempts); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// replay writes a staged episode to the graph.
+func (w *IngestWorker) replay(ctx context.Context, staged *utils.StagedEpisode) error {
+	c := w.client
+	ctx = context.WithValue(ctx, types.ContextKeyIngestionSource, fmt.Sprintf("episode:%s", staged.Episode.Uuid))
+
+	result, err := utils.AddNodesAndEdgesBulk(ctx, c.driver,
+		[]*types.Node{staged.Episode},
+		staged.EpisodicEdges,
+		staged.Nodes,
+		staged.Edges,
+		c.embedder)
+	if err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return errors.Join(result.Errors...)
+	}
+
+	if staged.Source != "" {
+		sourceNode, _, err := c.getOrCreateSourceNode(ctx, staged.Source, staged.Episode.GroupID)
+		if err != nil {
+			return err
+		}
+		edge := newSourceEdge(staged.SourceEdgeUUID, sourceNode, staged.Episode)
+		if err := c.driver.UpsertEdge(ctx, edge); err != nil {
+			return fmt.Errorf("failed to create source edge: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package predicato
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils"
+)
+
+// memoryIngestQueue is an in-memory ingestQueue with the semantics of the
+// DuckDB one.
+type memoryIngestQueue struct {
+	pending []*utils.StagedEpisode
+	failed  []*utils.StagedEpisode
+	queries int
+}
+
+func (q *memoryIngestQueue) PendingEpisodes(ctx context.Context, limit int) ([]*utils.StagedEpisode, error) {
+	q.queries++
+	return slices.Clone(q.pending[:min(limit, len(q.pending))]), nil
+}
+
+func (q *memoryIngestQueue) MarkIngested(ctx context.Context, episodeID string) error {
+	q.pending = slices.DeleteFunc(q.pending, func(s *utils.StagedEpisode) bool { return s.Episode.Uuid == episodeID })
+	return nil
+}
+
+func (q *memoryIngestQueue) MarkFailed(ctx context.Context, episodeID string, cause error, maxAttempts int) error {
+	for i, staged := range q.pending {
+		if staged.Episode.Uuid != episodeID {
+			continue
+		}
+		staged.Attempts++
+		staged.LastError = cause.Error()
+		if staged.Attempts >= maxAttempts {
+			q.failed = append(q.failed, staged)
+			q.pending = slices.Delete(q.pending, i, i+1)
+		}
+		return nil
+	}
+	return nil
+}
+
+func (q *memoryIngestQueue) RetryFailed(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// replayDriver is an in-memory GraphDriver recording the replayed writes.
+type replayDriver struct {
+	driver.GraphDriver
+	nodes map[string]*types.Node
+	edges map[string]*types.Edge
+}
+
+func (d *replayDriver) UpsertNode(ctx context.Context, node *types.Node) error {
+	d.nodes[node.Uuid] = node
+	return nil
+}
+
+func (d *replayDriver) UpsertEdge(ctx context.Context, edge *types.Edge) error {
+	d.edges[edge.Uuid] = edge
+	return nil
+}
+
+func (d *replayDriver) GetStats(ctx context.Context, groupID string) (*driver.GraphStats, error) {
+	return &driver.GraphStats{}, nil
+}
+
+func TestDrainReplaysStagedEpisode(t *testing.T) {
+	ctx := context.Background()
+	graph := &replayDriver{nodes: map[string]*types.Node{}, edges: map[string]*types.Edge{}}
+	client := &Client{driver: graph, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	path := filepath.Join(t.TempDir(), "queue.duckdb")
+
+	episode := &types.Node{Uuid: "ep1", Name: "episode", Type: types.EpisodicNodeType, GroupID: "g", Content: "Alice knows Bob"}
+	alice := &types.Node{Uuid: "n1", Name: "Alice", Type: types.EntityNodeType, GroupID: "g", Embedding: []float32{1, 0}}
+	bob := &types.Node{Uuid: "n2", Name: "Bob", Type: types.EntityNodeType, GroupID: "g", Embedding: []float32{0, 1}}
+	knows := types.NewEntityEdge("e1", "n1", "n2", "g", "KNOWS", types.EntityEdgeType)
+	knows.Embedding = []float32{1, 1}
+	if err := client.stageEpisode(ctx, path, "", episode, []*types.Node{alice, bob}, []*types.Edge{knows}, nil); err != nil {
+		t.Fatalf("stageEpisode() error = %v", err)
+	}
+
+	worker, err := NewIngestWorker(client, path)
+	if err != nil {
+		t.Fatalf("NewIngestWorker() error = %v", err)
+	}
+	defer client.stagingQueues[path].Close()
+	worker.SetUpdateCommunities(false)
+
+	report, err := worker.Drain(ctx)
+	if err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if !slices.Equal(report.Ingested, []string{"ep1"}) || len(report.Failed) != 0 {
+		t.Errorf("Drain() report = %+v, want ep1 ingested", report)
+	}
+	for _, id := range []string{"ep1", "n1", "n2"} {
+		if _, ok := graph.nodes[id]; !ok {
+			t.Errorf("node %s was not replayed", id)
+		}
+	}
+	if edge, ok := graph.edges["e1"]; !ok || edge.SourceID != "n1" || edge.TargetID != "n2" {
+		t.Errorf("edge e1 = %+v, want it replayed from n1 to n2", edge)
+	}
+
+	pending, err := client.stagingQueues[path].PendingEpisodes(ctx, 10)
+	if err != nil {
+		t.Fatalf("PendingEpisodes() error = %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("PendingEpisodes() = %d episodes after Drain(), want none", len(pending))
+	}
+}
+
+func TestDrainGivesUpOnExhaustedEpisodes(t *testing.T) {
+	queue := &memoryIngestQueue{pending: []*utils.StagedEpisode{{
+		Episode:   &types.Node{Uuid: "ep1", GroupID: "g"},
+		Attempts:  3,
+		LastError: "connection refused",
+	}}}
+	worker := &IngestWorker{
+		client:      &Client{logger: slog.New(slog.NewTextHandler(io.Discard, nil))},
+		queue:       queue,
+		maxAttempts: 2,
+		batchSize:   10,
+		retryDelay:  time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	report, err := worker.Drain(ctx)
+	if err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if !slices.Equal(report.Failed, []string{"ep1"}) || len(report.Ingested) != 0 {
+		t.Errorf("Drain() report = %+v, want ep1 failed", report)
+	}
+	if len(queue.pending) != 0 || len(queue.failed) != 1 {
+		t.Fatalf("queue pending = %d, failed = %d, want the episode marked failed", len(queue.pending), len(queue.failed))
+	}
+	if got := queue.failed[0].LastError; got != "connection refused" {
+		t.Errorf("LastError = %q, want the last replay error kept", got)
+	}
+	if queue.queries != 2 {
+		t.Errorf("Drain() read the queue %d times, want 2", queue.queries)
+	}
+}
@@ -3,13 +3,19 @@ package predicato
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	jsonrepair "github.com/kaptinlin/jsonrepair"
 	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
 	"github.com/soundprediction/go-predicato/pkg/prompts"
 	"github.com/soundprediction/go-predicato/pkg/search"
 	"github.com/soundprediction/go-predicato/pkg/types"
@@ -156,6 +162,10 @@ func (c *Client) Add(ctx context.Context, episodes []types.Episode, options *Add
 	var skippedCount int
 
 	for _, episode := range episodes {
+		if options != nil && options.OverwriteExisting {
+			newEpisodes = append(newEpisodes, episode)
+			continue
+		}
 		existingNode, err := c.driver.GetNode(ctx, episode.ID, c.config.GroupID)
 		if err == nil && existingNode != nil {
 			// Episode already exists, skip it
@@ -200,12 +210,12 @@ func (c *Client) Add(ctx context.Context, episodes []types.Episode, options *Add
 		CommunityEdges: []*types.Edge{},
 	}
 
-	for _, episode := range episodes {
-		episodeResult, err := c.AddEpisode(ctx, episode, options)
-		if err != nil {
-			return nil, fmt.Errorf("failed to process episode %s: %w", episode.ID, err)
-		}
+	episodeResults, err := c.addEpisodes(ctx, episodes, options)
+	if err != nil {
+		return nil, err
+	}
 
+	for _, episodeResult := range episodeResults {
 		// Aggregate results
 		if episodeResult.Episode != nil {
 			result.Episodes = append(result.Episodes, episodeResult.Episode)
@@ -220,6 +230,72 @@ func (c *Client) Add(ctx context.Context, episodes []types.Episode, options *Add
 	return result, nil
 }
 
+// addEpisodes adds episodes in order, or up to options.MaxConcurrentEpisodes at
+// a time. Results are returned in the order of episodes.
+func (c *Client) addEpisodes(ctx context.Context, episodes []types.Episode, options *AddEpisodeOptions) ([]*types.AddEpisodeResults, error) {
+	results := make([]*types.AddEpisodeResults, len(episodes))
+
+	concurrency := 1
+	if options != nil && options.MaxConcurrentEpisodes > 1 {
+		concurrency = options.MaxConcurrentEpisodes
+	}
+	if concurrency == 1 {
+		for i, episode := range episodes {
+			episodeResult, err := c.AddEpisode(ctx, episode, options)
+			if err != nil {
+				return nil, fmt.Errorf("failed to process episode %s: %w", episode.ID, err)
+			}
+			results[i] = episodeResult
+		}
+		return results, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	semaphore := make(chan struct{}, concurrency)
+	errs := make([]error, len(episodes))
+	var wg sync.WaitGroup
+	for i, episode := range episodes {
+		wg.Add(1)
+		go func(i int, episode types.Episode) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+
+			episodeResult, err := c.AddEpisode(ctx, episode, options)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to process episode %s: %w", episode.ID, err)
+				// Stop the remaining episodes on the first failure
+				cancel()
+				return
+			}
+			results[i] = episodeResult
+		}(i, episode)
+	}
+	wg.Wait()
+
+	// Report the failure that cancelled the others rather than a cancellation
+	var firstErr error
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
 // AddEpisode processes and adds a single episode to the knowledge graph.
 // This implementation uses bulk processing with sophisticated deduplication.
 // Content is automatically chunked if it exceeds MaxCharacters, but the same
@@ -236,6 +312,16 @@ func (c *Client) AddEpisode(ctx context.Context, episode types.Episode, options
 	}
 	ctx = context.WithValue(ctx, types.ContextKeyIngestionSource, ingestionSource)
 
+	if options.DeferGraphIngestion && options.DuckDBPath == "" {
+		return nil, fmt.Errorf("DuckDBPath is required to defer graph ingestion")
+	}
+
+	content, err := c.redactContent(ctx, episode.Content)
+	if err != nil {
+		return nil, err
+	}
+	episode.Content = content
+
 	maxCharacters := 2048
 	if options.MaxCharacters > 0 {
 		maxCharacters = options.MaxCharacters
@@ -246,6 +332,51 @@ func (c *Client) AddEpisode(ctx context.Context, episode types.Episode, options
 	return c.addEpisodeChunked(ctx, episode, options, maxCharacters)
 }
 
+// removeExistingEpisode removes the previous version of an episode about to
+// be overwritten, if there is one.
+func (c *Client) removeExistingEpisode(ctx context.Context, episodeID, groupID string) error {
+	existing, err := c.driver.GetNode(ctx, episodeID, groupID)
+	if errors.Is(err, driver.ErrNodeNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get episode %s: %w", episodeID, err)
+	}
+	if existing == nil || existing.Type != types.EpisodicNodeType {
+		return nil
+	}
+	if err := c.removeEpisodeVersion(ctx, existing); err != nil {
+		return fmt.Errorf("failed to remove previous version of episode %s: %w", episodeID, err)
+	}
+	return nil
+}
+
+// duplicateNodePairs returns the distinct duplicate entity pairs found while
+// deduplicating the entities of an episode.
+func duplicateNodePairs(dedupeResult *utils.DedupeNodesResult) []maintenance.NodePair {
+	var pairs []maintenance.NodePair
+	seen := make(map[[2]string]bool)
+	for _, episodePairs := range dedupeResult.DuplicatePairs {
+		for _, pair := range episodePairs {
+			key := [2]string{pair.Source.Uuid, pair.Target.Uuid}
+			if !seen[key] {
+				seen[key] = true
+				pairs = append(pairs, maintenance.NodePair(pair))
+			}
+		}
+	}
+	return pairs
+}
+
+// edgeUUIDs returns the UUIDs of edges.
+func edgeUUIDs(edges []*types.Edge) []string {
+	uuids := make([]string, len(edges))
+	for i, edge := range edges {
+		uuids[i] = edge.Uuid
+	}
+	return uuids
+}
+
 // addEpisodeChunked chunks long episode content and uses bulk deduplication
 // processing across all chunks to efficiently handle large episodes.
 func (c *Client) addEpisodeChunked(ctx context.Context, episode types.Episode, options *AddEpisodeOptions, maxCharacters int) (*types.AddEpisodeResults, error) {
@@ -257,6 +388,17 @@ func (c *Client) addEpisodeChunked(ctx context.Context, episode types.Episode, o
 		return nil, err
 	}
 
+	// Attribute LLM usage of this episode for GetUsage and the result
+	ctx = usage.WithEpisode(ctx, episode.ID, episode.GroupID)
+
+	// Remove what a previous version of the episode contributed, so that it
+	// is reprocessed from scratch
+	if options.OverwriteExisting {
+		if err := c.removeExistingEpisode(ctx, episode.ID, episode.GroupID); err != nil {
+			return nil, err
+		}
+	}
+
 	// STEP 2: Get previous episodes for context
 	previousEpisodes, err := c.getPreviousEpisodesForContext(ctx, episode, options)
 	if err != nil {
@@ -270,10 +412,11 @@ func (c *Client) addEpisodeChunked(ctx context.Context, episode types.Episode, o
 	}
 
 	// STEP 4: Initialize maintenance operations
-	nodeOps := maintenance.NewNodeOperations(c.driver, c.llm, c.embedder, prompts.NewLibrary())
-	nodeOps.SetLogger(c.logger)
-	edgeOps := maintenance.NewEdgeOperations(c.driver, c.llm, c.embedder, prompts.NewLibrary())
-	edgeOps.SetLogger(c.logger)
+	library, err := c.promptLibrary(ctx, episode.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	nodeOps, edgeOps := c.newIngestionOperations(library, options)
 
 	// STEP 5: Extract entities from all chunks
 	extractedNodesByChunk, err := c.extractEntitiesFromAllChunks(ctx, episode.ID, chunkData.chunkEpisodeNodes, chunkData.previousEpisodes, options, nodeOps)
@@ -284,6 +427,7 @@ func (c *Client) addEpisodeChunked(ctx context.Context, episode types.Episode, o
 	// OPTIMIZATION: Filter out chunks with no extracted entities
 	var filteredNodesByChunk [][]*types.Node
 	var filteredEpisodeTuples []utils.EpisodeTuple
+	var filteredChunkIndices []int
 	chunksWithEntities := 0
 	chunksWithoutEntities := 0
 
@@ -291,6 +435,7 @@ func (c *Client) addEpisodeChunked(ctx context.Context, episode types.Episode, o
 		if len(nodes) > 0 {
 			filteredNodesByChunk = append(filteredNodesByChunk, nodes)
 			filteredEpisodeTuples = append(filteredEpisodeTuples, chunkData.episodeTuples[i])
+			filteredChunkIndices = append(filteredChunkIndices, i)
 			chunksWithEntities++
 		} else {
 			chunksWithoutEntities++
@@ -311,10 +456,11 @@ func (c *Client) addEpisodeChunked(ctx context.Context, episode types.Episode, o
 	// Only process entities and relationships if we have chunks with entities
 	if chunksWithEntities > 0 {
 		// STEP 6: Deduplicate entities across chunks (only chunks with entities)
-		dedupeResult, allResolvedNodes, err := c.deduplicateEntitiesAcrossChunks(ctx, episode.ID, filteredNodesByChunk, filteredEpisodeTuples, options, nodeOps)
+		dedupeResult, allResolvedNodes, err := c.deduplicateEntitiesAcrossChunks(ctx, episode.ID, filteredNodesByChunk, filteredEpisodeTuples, options, library, nodeOps)
 		if err != nil {
 			return nil, err
 		}
+		nodeChunks := recordEntityProvenance(chunkData.mainEpisodeNode.Uuid, filteredNodesByChunk, filteredChunkIndices, dedupeResult, allResolvedNodes)
 
 		// STEP 7: Extract relationships
 		allExtractedEdges, err := c.extractRelationshipsFromChunks(ctx, episode.ID, chunkData.mainEpisodeNode, dedupeResult, chunkData.previousEpisodes, options, edgeOps)
@@ -322,11 +468,25 @@ func (c *Client) addEpisodeChunked(ctx context.Context, episode types.Episode, o
 			return nil, err
 		}
 
-		// STEP 8: Resolve and persist relationships
-		resolvedEdges, invalidatedEdges, err = c.resolveAndPersistRelationships(ctx, episode.ID, allExtractedEdges, chunkData.mainEpisodeNode, allResolvedNodes, options, edgeOps)
+		// STEP 8: Resolve and persist relationships, recording the changes so
+		// that the episode can be rolled back
+		changeLog := &types.EpisodeChangeLog{}
+		resolvedEdges, invalidatedEdges, err = c.resolveAndPersistRelationships(ctx, episode.ID, allExtractedEdges, chunkData.mainEpisodeNode, allResolvedNodes, options, edgeOps, changeLog)
 		if err != nil {
 			return nil, err
 		}
+		recordEpisodeChanges(changeLog, slices.Concat(filteredNodesByChunk...), allResolvedNodes, allExtractedEdges, resolvedEdges)
+		recordRelationshipProvenance(chunkData.mainEpisodeNode.Uuid, resolvedEdges, nodeChunks)
+
+		// Link entities found to duplicate others without being merged
+		duplicateEdges, err := edgeOps.BuildDuplicateOfEdges(ctx, chunkData.mainEpisodeNode, now, duplicateNodePairs(dedupeResult))
+		if err != nil {
+			return nil, fmt.Errorf("failed to build duplicate edges: %w", err)
+		}
+		resolvedEdges = append(resolvedEdges, duplicateEdges...)
+		chunkData.mainEpisodeNode.EntityEdges = edgeUUIDs(resolvedEdges)
+		changeLog.CreatedEdges = append(changeLog.CreatedEdges, edgeUUIDs(duplicateEdges)...)
+		chunkData.mainEpisodeNode.SetChangeLog(changeLog)
 
 		// STEP 9: Extract attributes
 		hydratedNodes, err = c.extractEntityAttributes(ctx, episode.ID, allResolvedNodes, chunkData.mainEpisodeNode, chunkData.previousEpisodes, options, nodeOps)
@@ -341,7 +501,12 @@ func (c *Client) addEpisodeChunked(ctx context.Context, episode types.Episode, o
 		}
 
 		// STEP 11: Perform final graph updates
-		if err := c.performFinalGraphUpdates(ctx, episode.ID, chunkData.mainEpisodeNode, hydratedNodes, resolvedEdges, invalidatedEdges, episodicEdges); err != nil {
+		recordPromptVersion(library.Version(), append(hydratedNodes, chunkData.mainEpisodeNode), resolvedEdges)
+		if options.DeferGraphIngestion {
+			if err := c.stageEpisode(ctx, options.DuckDBPath, episode.Source, chunkData.mainEpisodeNode, hydratedNodes, append(resolvedEdges, invalidatedEdges...), episodicEdges); err != nil {
+				return nil, err
+			}
+		} else if err := c.performFinalGraphUpdates(ctx, episode.ID, chunkData.mainEpisodeNode, hydratedNodes, resolvedEdges, invalidatedEdges, episodicEdges); err != nil {
 			return nil, err
 		}
 	} else {
@@ -349,7 +514,12 @@ func (c *Client) addEpisodeChunked(ctx context.Context, episode types.Episode, o
 			"episode_id", episode.ID)
 
 		// Still need to persist the episode node with its content
-		if err := c.driver.UpsertNode(ctx, chunkData.mainEpisodeNode); err != nil {
+		recordPromptVersion(library.Version(), []*types.Node{chunkData.mainEpisodeNode}, nil)
+		if options.DeferGraphIngestion {
+			if err := c.stageEpisode(ctx, options.DuckDBPath, episode.Source, chunkData.mainEpisodeNode, nil, nil, nil); err != nil {
+				return nil, err
+			}
+		} else if err := c.driver.UpsertNode(ctx, chunkData.mainEpisodeNode); err != nil {
 			return nil, fmt.Errorf("failed to persist episode node: %w", err)
 		}
 	}
@@ -364,39 +534,38 @@ func (c *Client) addEpisodeChunked(ctx context.Context, episode types.Episode, o
 		CommunityEdges: []*types.Edge{},
 	}
 
-	// STEP 13: Update communities
-	communities, communityEdges, err := c.UpdateCommunities(ctx, episode.ID, episode.GroupID)
-	if err != nil {
-		return nil, err
-	}
-	result.Communities = communities
-	result.CommunityEdges = communityEdges
-
-	// STEP 14: Persist community nodes and edges using bulk operation
-	if len(communities) > 0 || len(communityEdges) > 0 {
-		_, err = utils.AddNodesAndEdgesBulk(ctx, c.driver, communities, communityEdges, []*types.Node{}, []*types.Edge{}, c.embedder)
+	// STEP 13-14: Update communities and persist them, unless the graph
+	// write is deferred
+	if !options.DeferGraphIngestion {
+		communities, communityEdges, err := c.updateAndPersistCommunities(ctx, episode.ID, episode.GroupID)
 		if err != nil {
-			c.logger.Warn("Failed to persist community nodes and edges in bulk",
-				"episode_id", episode.ID,
-				"community_count", len(communities),
-				"community_edge_count", len(communityEdges),
-				"error", err)
-		} else {
-			c.logger.Info("Persisted community nodes and edges",
-				"episode_id", episode.ID,
-				"community_count", len(communities),
-				"community_edge_count", len(communityEdges))
+			return nil, err
 		}
+		result.Communities = communities
+		result.CommunityEdges = communityEdges
 	}
 
 	// STEP 15: Log final results
+	result.Usage = c.usage.EpisodeSummary(episode.ID)
 	c.logger.Info("Chunked episode processing completed with bulk deduplication",
 		"episode_id", episode.ID,
 		"total_chunks", len(chunks),
 		"total_entities", len(result.Nodes),
 		"total_relationships", len(result.Edges),
 		"total_episodic_edges", len(result.EpisodicEdges),
-		"total_communities", len(result.Communities))
+		"total_communities", len(result.Communities),
+		"llm_requests", result.Usage.Total.Requests,
+		"prompt_tokens", result.Usage.Total.PromptTokens,
+		"completion_tokens", result.Usage.Total.CompletionTokens,
+		"estimated_cost_usd", result.Usage.Total.CostUSD)
+	for operation, opUsage := range result.Usage.ByOperation {
+		c.logger.Debug("LLM usage by operation",
+			"episode_id", episode.ID,
+			"operation", operation,
+			"llm_requests", opUsage.Requests,
+			"total_tokens", opUsage.TotalTokens,
+			"estimated_cost_usd", opUsage.CostUSD)
+	}
 
 	// STEP 16: Report overall graph database statistics
 	stats, err := c.driver.GetStats(ctx, episode.GroupID)
@@ -419,6 +588,26 @@ func (c *Client) addEpisodeChunked(ctx context.Context, episode types.Episode, o
 	return result, nil
 }
 
+// newIngestionOperations creates the node and edge operations used to ingest
+// episodes with the prompts of library and options.
+func (c *Client) newIngestionOperations(library prompts.Library, options *AddEpisodeOptions) (*maintenance.NodeOperations, *maintenance.EdgeOperations) {
+	maxPromptTokens := llm.PromptTokenLimit(c.config.LLMContextWindow)
+	nodeOps := maintenance.NewNodeOperations(c.driver, c.llm, c.embedder, library)
+	nodeOps.SetLogger(c.logger)
+	nodeOps.SetMaxPromptTokens(maxPromptTokens)
+	nodeOps.SetNameIndexes(c.nameIndexes)
+	nodeOps.SetMergeReviewThreshold(c.config.DedupReviewThreshold)
+	nodeOps.SetSummaryOptions(c.config.SummaryOptions)
+	if options.MaxReflexionIterations > 0 {
+		nodeOps.SetMaxReflexionIterations(options.MaxReflexionIterations)
+	}
+	edgeOps := maintenance.NewEdgeOperations(c.driver, c.llm, c.embedder, library)
+	edgeOps.SetLogger(c.logger)
+	edgeOps.SetMaxPromptTokens(maxPromptTokens)
+	edgeOps.SetInvalidationPolicies(c.config.EdgeInvalidationPolicies)
+	return nodeOps, edgeOps
+}
+
 // createTempEpisodeForAdditionalContent creates a temporary episode structure with the additional content for processing.
 func (c *Client) createTempEpisodeForAdditionalContent(existingEpisode *types.Node, episodeID string, additionalContent string, groupID string) types.Episode {
 	return types.Episode{
@@ -484,6 +673,11 @@ func (c *Client) AddToEpisode(ctx context.Context, episodeID string, additionalC
 		return nil, err
 	}
 
+	additionalContent, err = c.redactContent(ctx, additionalContent)
+	if err != nil {
+		return nil, err
+	}
+
 	// 2. Create a temporary episode structure with the additional content for processing
 	tempEpisode := c.createTempEpisodeForAdditionalContent(existingEpisode, episodeID, additionalContent, groupID)
 
@@ -616,14 +810,15 @@ func (c *Client) createChunkEpisodeStructures(ctx context.Context, episode types
 
 		// Create temporary episode node for this chunk's extraction
 		chunkNode := &types.Node{
-			Uuid:      episode.ID,
-			Name:      episode.Name,
-			Type:      types.EpisodicNodeType,
-			Content:   chunk,
-			GroupID:   episode.GroupID,
-			Metadata:  episode.Metadata,
-			ValidFrom: episode.Reference,
-			CreatedAt: episode.CreatedAt,
+			Uuid:        episode.ID,
+			Name:        episode.Name,
+			Type:        types.EpisodicNodeType,
+			EpisodeType: episode.Type(),
+			Content:     chunk,
+			GroupID:     episode.GroupID,
+			Metadata:    episode.Metadata,
+			ValidFrom:   episode.Reference,
+			CreatedAt:   episode.CreatedAt,
 		}
 		data.chunkEpisodeNodes[i] = chunkNode
 
@@ -635,7 +830,11 @@ func (c *Client) createChunkEpisodeStructures(ctx context.Context, episode types
 		if i == 0 {
 			// Create the actual persisted episode node with first chunk
 			var err error
-			data.mainEpisodeNode, err = c.createEpisodeNode(ctx, chunkEpisode, options)
+			if options.DeferGraphIngestion {
+				data.mainEpisodeNode, err = c.newEpisodeNode(ctx, chunkEpisode)
+			} else {
+				data.mainEpisodeNode, err = c.createEpisodeNode(ctx, chunkEpisode, options)
+			}
 			if err != nil {
 				return nil, fmt.Errorf("failed to create episode node: %w", err)
 			}
@@ -645,10 +844,19 @@ func (c *Client) createChunkEpisodeStructures(ctx context.Context, episode types
 	// Update the main episode with full content
 	fullContent := strings.Join(chunks, "\n")
 	data.mainEpisodeNode.Content = fullContent
+	data.mainEpisodeNode.Metadata = maps.Clone(data.mainEpisodeNode.Metadata)
+	if data.mainEpisodeNode.Metadata == nil {
+		data.mainEpisodeNode.Metadata = make(map[string]interface{})
+	}
+	data.mainEpisodeNode.Metadata[driver.ChunkOffsetsKey] = maintenance.ChunkOffsets(chunks, "\n")
 	data.mainEpisodeNode.UpdatedAt = time.Now()
+	if options.TagCommunity {
+		c.tagEpisodeCommunity(ctx, data.mainEpisodeNode, options.CommunityTagMinScore)
+	}
 
-	// STEP: Create source node and edge if episode has a source
-	if episode.Source != "" {
+	// STEP: Create source node and edge if episode has a source. Deferred
+	// episodes are linked to their source on replay.
+	if episode.Source != "" && !options.DeferGraphIngestion {
 		sourceNode, isNew, err := c.getOrCreateSourceNode(ctx, episode.Source, episode.GroupID)
 		if err != nil {
 			c.logger.Warn("Failed to create source node", "source", episode.Source, "error", err)
@@ -672,6 +880,51 @@ func (c *Client) createChunkEpisodeStructures(ctx context.Context, episode types
 	return data, nil
 }
 
+// recordEntityProvenance records on each resolved node the chunks of the
+// episode its extracted duplicates came from, and returns the chunk indices
+// by resolved node UUID.
+func recordEntityProvenance(episodeUUID string, extractedNodesByChunk [][]*types.Node, chunkIndices []int, dedupeResult *utils.DedupeNodesResult, resolvedNodes []*types.Node) map[string][]int {
+	nodeChunks := make(map[string][]int)
+	for i, nodes := range extractedNodesByChunk {
+		for _, node := range nodes {
+			if node == nil {
+				continue
+			}
+			uuid := node.Uuid
+			if resolved, ok := dedupeResult.UUIDMap[uuid]; ok {
+				uuid = resolved
+			}
+			if !slices.Contains(nodeChunks[uuid], chunkIndices[i]) {
+				nodeChunks[uuid] = append(nodeChunks[uuid], chunkIndices[i])
+			}
+		}
+	}
+
+	for _, node := range resolvedNodes {
+		maintenance.RecordNodeProvenance(node, episodeUUID, nodeChunks[node.Uuid])
+	}
+	return nodeChunks
+}
+
+// recordRelationshipProvenance records on each resolved edge the chunks of the
+// episode mentioning both of its endpoints, or either of them when no chunk
+// mentions both.
+func recordRelationshipProvenance(episodeUUID string, edges []*types.Edge, nodeChunks map[string][]int) {
+	for _, edge := range edges {
+		sourceChunks, targetChunks := nodeChunks[edge.SourceID], nodeChunks[edge.TargetID]
+		var chunks []int
+		for _, index := range sourceChunks {
+			if slices.Contains(targetChunks, index) {
+				chunks = append(chunks, index)
+			}
+		}
+		if len(chunks) == 0 {
+			chunks = slices.Concat(sourceChunks, targetChunks)
+		}
+		maintenance.RecordEdgeProvenance(edge, episodeUUID, chunks)
+	}
+}
+
 // extractEntitiesFromAllChunks extracts entities from each chunk using the LLM.
 func (c *Client) extractEntitiesFromAllChunks(ctx context.Context, episodeID string, chunkEpisodeNodes []*types.Node, previousEpisodes []*types.Node, options *AddEpisodeOptions, nodeOps *maintenance.NodeOperations) ([][]*types.Node, error) {
 	c.logger.Info("Starting bulk entity extraction",
@@ -701,7 +954,7 @@ func (c *Client) extractEntitiesFromAllChunks(ctx context.Context, episodeID str
 }
 
 // deduplicateEntitiesAcrossChunks performs bulk entity deduplication across all chunks and persists them.
-func (c *Client) deduplicateEntitiesAcrossChunks(ctx context.Context, episodeID string, extractedNodesByChunk [][]*types.Node, episodeTuples []utils.EpisodeTuple, options *AddEpisodeOptions, nodeOps *maintenance.NodeOperations) (*utils.DedupeNodesResult, []*types.Node, error) {
+func (c *Client) deduplicateEntitiesAcrossChunks(ctx context.Context, episodeID string, extractedNodesByChunk [][]*types.Node, episodeTuples []utils.EpisodeTuple, options *AddEpisodeOptions, library prompts.Library, nodeOps *maintenance.NodeOperations) (*utils.DedupeNodesResult, []*types.Node, error) {
 	c.logger.Info("Starting bulk entity deduplication",
 		"episode_id", episodeID,
 		"num_chunks", len(extractedNodesByChunk))
@@ -710,7 +963,7 @@ func (c *Client) deduplicateEntitiesAcrossChunks(ctx context.Context, episodeID
 		Driver:   c.driver,
 		LLM:      c.llm,
 		Embedder: c.embedder,
-		Prompts:  prompts.NewLibrary(),
+		Prompts:  library,
 	}
 
 	dedupeResult, err := utils.DedupeNodesBulk(
@@ -746,6 +999,10 @@ func (c *Client) deduplicateEntitiesAcrossChunks(ctx context.Context, episodeID
 		}
 	}
 
+	if options.DeferGraphIngestion {
+		return dedupeResult, allResolvedNodes, nil
+	}
+
 	// EARLY WRITE: Persist deduplicated nodes
 	c.logger.Info("Persisting deduplicated nodes early",
 		"episode_id", episodeID,
@@ -868,22 +1125,12 @@ func (c *Client) extractRelationshipsFromChunks(ctx context.Context, episodeID s
 		"num_chunks", len(dedupeResult.NodesByEpisode))
 
 	var allExtractedEdges []*types.Edge
-	edgeTypeMap := make(map[string][][]string)
-	if options.EdgeTypeMap != nil {
-		for outerEntity, innerMap := range options.EdgeTypeMap {
-			for innerEntity, relationships := range innerMap {
-				for _, relation := range relationships {
-					edgeTypeMap[relation.(string)] = append(edgeTypeMap[relation.(string)], []string{outerEntity, innerEntity})
-				}
-			}
-		}
-	}
 
 	// Get nodes for the episode and extract edges
 	episodeNodes := dedupeResult.NodesByEpisode[mainEpisodeNode.Uuid]
 	if len(episodeNodes) > 0 {
 		extractedEdges, err := edgeOps.ExtractEdges(ctx, mainEpisodeNode, episodeNodes,
-			previousEpisodes, edgeTypeMap, options.EdgeTypes, mainEpisodeNode.GroupID)
+			previousEpisodes, edgeTypeMapFromOptions(options), options.EdgeTypes, mainEpisodeNode.GroupID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to extract edges: %w", err)
 		}
@@ -900,8 +1147,23 @@ func (c *Client) extractRelationshipsFromChunks(ctx context.Context, episodeID s
 	return allExtractedEdges, nil
 }
 
+// edgeTypeMapFromOptions inverts options.EdgeTypeMap into the entity type
+// pairs allowed for each edge type.
+func edgeTypeMapFromOptions(options *AddEpisodeOptions) map[string][][]string {
+	edgeTypeMap := make(map[string][][]string)
+	for outerEntity, innerMap := range options.EdgeTypeMap {
+		for innerEntity, relationships := range innerMap {
+			for _, relation := range relationships {
+				edgeTypeMap[relation.(string)] = append(edgeTypeMap[relation.(string)], []string{outerEntity, innerEntity})
+			}
+		}
+	}
+	return edgeTypeMap
+}
+
 // resolveAndPersistRelationships resolves extracted relationships and persists them to the graph.
-func (c *Client) resolveAndPersistRelationships(ctx context.Context, episodeID string, allExtractedEdges []*types.Edge, mainEpisodeNode *types.Node, allResolvedNodes []*types.Node, options *AddEpisodeOptions, edgeOps *maintenance.EdgeOperations) ([]*types.Edge, []*types.Edge, error) {
+// The validity windows of the existing edges it invalidates are recorded in changeLog.
+func (c *Client) resolveAndPersistRelationships(ctx context.Context, episodeID string, allExtractedEdges []*types.Edge, mainEpisodeNode *types.Node, allResolvedNodes []*types.Node, options *AddEpisodeOptions, edgeOps *maintenance.EdgeOperations, changeLog *types.EpisodeChangeLog) ([]*types.Edge, []*types.Edge, error) {
 	c.logger.Info("Starting bulk relationship resolution",
 		"episode_id", episodeID,
 		"relationships_to_resolve", len(allExtractedEdges))
@@ -923,6 +1185,18 @@ func (c *Client) resolveAndPersistRelationships(ctx context.Context, episodeID s
 		"resolved_relationships", len(resolvedEdges),
 		"invalidated_relationships", len(invalidatedEdges))
 
+	// Record the validity windows of the invalidated edges before overwriting
+	// them, or before the overwrite is staged
+	validity, err := c.priorEdgeValidity(ctx, mainEpisodeNode.GroupID, invalidatedEdges)
+	if err != nil {
+		return nil, nil, err
+	}
+	changeLog.InvalidatedEdges = validity
+
+	if options.DeferGraphIngestion {
+		return resolvedEdges, invalidatedEdges, nil
+	}
+
 	// EARLY WRITE: Persist resolved edges
 	c.logger.Info("Persisting resolved edges early",
 		"episode_id", episodeID,
@@ -945,6 +1219,51 @@ func (c *Client) resolveAndPersistRelationships(ctx context.Context, episodeID s
 	return resolvedEdges, invalidatedEdges, nil
 }
 
+// priorEdgeValidity returns the validity windows the existing edges among
+// edges have in the graph.
+func (c *Client) priorEdgeValidity(ctx context.Context, groupID string, edges []*types.Edge) ([]types.EdgeValidity, error) {
+	if len(edges) == 0 {
+		return nil, nil
+	}
+	existing, err := c.driver.GetEdges(ctx, edgeUUIDs(edges), groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invalidated edges: %w", err)
+	}
+	validity := make([]types.EdgeValidity, 0, len(existing))
+	for _, edge := range existing {
+		validity = append(validity, edge.Validity())
+	}
+	return validity, nil
+}
+
+// recordEpisodeChanges records in changeLog the resolved nodes and edges
+// created by an episode, which kept the UUID of an extracted one, and those
+// the episode was resolved to.
+func recordEpisodeChanges(changeLog *types.EpisodeChangeLog, extractedNodes, resolvedNodes []*types.Node, extractedEdges, resolvedEdges []*types.Edge) {
+	extracted := make(map[string]bool, len(extractedNodes)+len(extractedEdges))
+	for _, node := range extractedNodes {
+		extracted[node.Uuid] = true
+	}
+	for _, edge := range extractedEdges {
+		extracted[edge.Uuid] = true
+	}
+
+	for _, node := range resolvedNodes {
+		if extracted[node.Uuid] {
+			changeLog.CreatedNodes = append(changeLog.CreatedNodes, node.Uuid)
+		} else {
+			changeLog.ModifiedNodes = append(changeLog.ModifiedNodes, node.Uuid)
+		}
+	}
+	for _, edge := range resolvedEdges {
+		if extracted[edge.Uuid] {
+			changeLog.CreatedEdges = append(changeLog.CreatedEdges, edge.Uuid)
+		} else {
+			changeLog.ModifiedEdges = append(changeLog.ModifiedEdges, edge.Uuid)
+		}
+	}
+}
+
 // extractEntityAttributes extracts attributes for all resolved entities.
 func (c *Client) extractEntityAttributes(ctx context.Context, episodeID string, allResolvedNodes []*types.Node, mainEpisodeNode *types.Node, previousEpisodes []*types.Node, options *AddEpisodeOptions, nodeOps *maintenance.NodeOperations) ([]*types.Node, error) {
 	c.logger.Info("Starting bulk attribute extraction",
@@ -1012,6 +1331,33 @@ func (c *Client) performFinalGraphUpdates(ctx context.Context, episodeID string,
 	return nil
 }
 
+// updateAndPersistCommunities updates the communities of a group and persists
+// them. Failing to persist them is only logged; the persisted communities are
+// returned.
+func (c *Client) updateAndPersistCommunities(ctx context.Context, episodeID, groupID string) ([]*types.Node, []*types.Edge, error) {
+	communities, communityEdges, err := c.UpdateCommunities(ctx, episodeID, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(communities) == 0 && len(communityEdges) == 0 {
+		return communities, communityEdges, nil
+	}
+
+	if err := c.persistCommunities(ctx, groupID, communities, communityEdges); err != nil {
+		c.logger.Warn("Failed to persist community nodes and edges",
+			"episode_id", episodeID,
+			"community_count", len(communities),
+			"community_edge_count", len(communityEdges),
+			"error", err)
+		return []*types.Node{}, []*types.Edge{}, nil
+	}
+	c.logger.Info("Persisted community nodes and edges",
+		"episode_id", episodeID,
+		"community_count", len(communities),
+		"community_edge_count", len(communityEdges))
+	return communities, communityEdges, nil
+}
+
 // UpdateCommunities updates graph communities if requested in options.
 func (c *Client) UpdateCommunities(ctx context.Context, episodeID string, groupID string) ([]*types.Node, []*types.Edge, error) {
 
@@ -1023,6 +1369,7 @@ func (c *Client) UpdateCommunities(ctx context.Context, episodeID string, groupI
 	if err != nil && len(communityResult.CommunityNodes) == 0 {
 		return nil, nil, fmt.Errorf("failed to build communities: %w", err)
 	}
+	c.saveCommunityMetrics(ctx, communityResult.Metrics)
 
 	c.logger.Info("Community update completed",
 		"episode_id", episodeID,
@@ -1032,8 +1379,130 @@ func (c *Client) UpdateCommunities(ctx context.Context, episodeID string, groupI
 	return communityResult.CommunityNodes, communityResult.CommunityEdges, nil
 }
 
+// persistCommunities saves the communities built for a group and their
+// HAS_MEMBER edges. The current memberships of the group are closed first, so
+// that its earlier communities remain as history.
+func (c *Client) persistCommunities(ctx context.Context, groupID string, communities []*types.Node, communityEdges []*types.Edge) error {
+	err := driver.CloseCommunityMemberships(ctx, c.driver, groupID, time.Now())
+	if err != nil && !errors.Is(err, driver.ErrCommunityHistoryUnsupported) {
+		return fmt.Errorf("failed to close community memberships of group %s: %w", groupID, err)
+	}
+
+	for _, community := range communities {
+		if err := c.driver.UpsertNode(ctx, community); err != nil {
+			return fmt.Errorf("failed to save community %s: %w", community.Uuid, err)
+		}
+	}
+	for _, edge := range communityEdges {
+		if err := c.driver.UpsertCommunityEdge(ctx, edge.SourceID, edge.TargetID, edge.Uuid, edge.GroupID); err != nil {
+			return fmt.Errorf("failed to save membership of %s in community %s: %w", edge.TargetID, edge.SourceID, err)
+		}
+	}
+	return nil
+}
+
+// saveCommunityMetrics records the metrics of a community build. Failing to
+// record them does not fail the build.
+func (c *Client) saveCommunityMetrics(ctx context.Context, metrics []*driver.CommunityMetrics) {
+	for _, groupMetrics := range metrics {
+		c.logger.Info("Community metrics",
+			"group_id", groupMetrics.GroupID,
+			"communities", groupMetrics.Communities,
+			"modularity", groupMetrics.Modularity,
+			"inter_community_edges", groupMetrics.InterCommunityEdges)
+		err := driver.SaveCommunityMetrics(ctx, c.driver, groupMetrics)
+		if errors.Is(err, driver.ErrCommunityMetricsUnsupported) {
+			return
+		}
+		if err != nil {
+			c.logger.Warn("Failed to record community metrics",
+				"group_id", groupMetrics.GroupID,
+				"error", err)
+		}
+	}
+}
+
+// DefaultCommunityTagMinScore is the default cosine similarity an episode
+// needs with a community summary to be tagged with it.
+const DefaultCommunityTagMinScore = 0.5
+
+// tagEpisodeCommunity records the current community of the episode group whose
+// summary is most similar to the episode in the episode metadata. Failing to
+// find it does not fail the ingestion.
+func (c *Client) tagEpisodeCommunity(ctx context.Context, episode *types.Node, minScore float64) {
+	if len(episode.Embedding) == 0 {
+		return
+	}
+	if minScore <= 0 {
+		minScore = DefaultCommunityTagMinScore
+	}
+
+	members, err := driver.CommunityMembers(ctx, c.driver, episode.GroupID, nil)
+	if errors.Is(err, driver.ErrCommunityMembersUnsupported) || (err == nil && len(members) == 0) {
+		return
+	}
+	var communities []*types.Node
+	if err == nil {
+		communities, err = c.driver.GetNodes(ctx, slices.Sorted(maps.Keys(members)), episode.GroupID)
+	}
+	if err != nil {
+		c.logger.Warn("Failed to get communities to tag episode",
+			"episode_id", episode.Uuid,
+			"error", err)
+		return
+	}
+
+	community, score := closestCommunity(episode.Embedding, communities)
+	if community == nil || score < minScore {
+		return
+	}
+	episode.Metadata[types.EpisodeCommunityKey] = community.Uuid
+	episode.Metadata[types.EpisodeCommunityNameKey] = community.Name
+	c.logger.Debug("Tagged episode with community",
+		"episode_id", episode.Uuid,
+		"community", community.Name,
+		"score", score)
+}
+
+// closestCommunity returns the community whose summary embedding, or name
+// embedding when it has none, is most similar to embedding, with their
+// cosine similarity.
+func closestCommunity(embedding []float32, communities []*types.Node) (*types.Node, float64) {
+	var closest *types.Node
+	bestScore := 0.0
+	for _, community := range communities {
+		communityEmbedding := community.SummaryEmbedding()
+		if len(communityEmbedding) == 0 {
+			communityEmbedding = community.Embedding
+		}
+		if len(communityEmbedding) != len(embedding) {
+			continue
+		}
+		score := utils.CalculateCosineSimilarity(embedding, communityEmbedding)
+		if closest == nil || score > bestScore {
+			closest, bestScore = community, score
+		}
+	}
+	return closest, bestScore
+}
+
 // createEpisodeNode creates an episode node in the graph.
 func (c *Client) createEpisodeNode(ctx context.Context, episode types.Episode, options *AddEpisodeOptions) (*types.Node, error) {
+	episodeNode, err := c.newEpisodeNode(ctx, episode)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.driver.UpsertNode(ctx, episodeNode); err != nil {
+		return nil, fmt.Errorf("failed to create episode node: %w", err)
+	}
+
+	return episodeNode, nil
+}
+
+// newEpisodeNode builds the episode node of an episode, embedding its content
+// when it has no embedding.
+func (c *Client) newEpisodeNode(ctx context.Context, episode types.Episode) (*types.Node, error) {
 	now := time.Now()
 
 	// Use existing embedding or create new one if embedder is available
@@ -1044,7 +1513,7 @@ func (c *Client) createEpisodeNode(ctx context.Context, episode types.Episode, o
 	} else if c.embedder != nil {
 		// Generate embedding if not provided and embedder is available
 		var err error
-		embedding, err = c.embedder.EmbedSingle(ctx, episode.Content)
+		embedding, err = c.embedder.EmbedDocument(ctx, episode.Content)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create episode embedding: %w", err)
 		}
@@ -1057,18 +1526,13 @@ func (c *Client) createEpisodeNode(ctx context.Context, episode types.Episode, o
 		GroupID:     episode.GroupID,
 		CreatedAt:   now,
 		UpdatedAt:   now,
-		EpisodeType: types.ConversationEpisodeType, // Default to conversation type
+		EpisodeType: episode.Type(),
 		Content:     episode.Content,
 		Reference:   episode.Reference,
 		ValidFrom:   episode.Reference,
 		Embedding:   embedding,
 		Metadata:    episode.Metadata,
 	}
-
-	if err := c.driver.UpsertNode(ctx, episodeNode); err != nil {
-		return nil, fmt.Errorf("failed to create episode node: %w", err)
-	}
-
 	return episodeNode, nil
 }
 
@@ -1260,37 +1724,45 @@ func (c *Client) AddTriplet(ctx context.Context, sourceNode *types.Node, edge *t
 		return nil, fmt.Errorf("source node, edge, and target node must not be nil")
 	}
 
-	// Step 1: Generate name embeddings for nodes if missing (lines 1024-1027)
-	// Equivalent to: if source_node.name_embedding is None: await source_node.generate_name_embedding(self.embedder)
-	if len(sourceNode.NameEmbedding) == 0 && c.embedder != nil {
-		embedding, err := c.embedder.EmbedSingle(ctx, sourceNode.Name)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate name embedding for source node: %w", err)
+	// Steps 1-2: Generate missing name embeddings for the nodes and fact
+	// embedding for the edge (lines 1024-1029), in a single batched request
+	if c.embedder != nil {
+		var texts []string
+		var targets []*[]float32
+		if len(sourceNode.NameEmbedding) == 0 {
+			texts = append(texts, sourceNode.Name)
+			targets = append(targets, &sourceNode.NameEmbedding)
 		}
-		sourceNode.NameEmbedding = embedding
-	}
-
-	if len(targetNode.NameEmbedding) == 0 && c.embedder != nil {
-		embedding, err := c.embedder.EmbedSingle(ctx, targetNode.Name)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate name embedding for target node: %w", err)
+		if len(targetNode.NameEmbedding) == 0 {
+			texts = append(texts, targetNode.Name)
+			targets = append(targets, &targetNode.NameEmbedding)
+		}
+		if len(edge.FactEmbedding) == 0 {
+			texts = append(texts, edge.Fact)
+			targets = append(targets, &edge.FactEmbedding)
 		}
-		targetNode.NameEmbedding = embedding
-	}
 
-	// Step 2: Generate fact embedding for edge if missing (lines 1028-1029)
-	// Equivalent to: if edge.fact_embedding is None: await edge.generate_embedding(self.embedder)
-	if len(edge.FactEmbedding) == 0 && c.embedder != nil {
-		embedding, err := c.embedder.EmbedSingle(ctx, edge.Fact)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate fact embedding for edge: %w", err)
+		if len(texts) > 0 {
+			embeddings, err := c.embedder.Embed(ctx, texts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate embeddings for triplet: %w", err)
+			}
+			if len(embeddings) != len(texts) {
+				return nil, fmt.Errorf("expected %d embeddings for triplet, got %d", len(texts), len(embeddings))
+			}
+			for i, target := range targets {
+				*target = embeddings[i]
+			}
 		}
-		edge.FactEmbedding = embedding
 	}
 
 	// Step 3: Resolve extracted nodes (lines 1031-1034)
-	nodeOps := maintenance.NewNodeOperations(c.driver, c.llm, c.embedder, prompts.NewLibrary())
+	maxPromptTokens := llm.PromptTokenLimit(c.config.LLMContextWindow)
+	nodeOps := maintenance.NewNodeOperations(c.driver, c.llm, c.embedder, c.config.Prompts)
 	nodeOps.SetLogger(c.logger)
+	nodeOps.SetMaxPromptTokens(maxPromptTokens)
+	nodeOps.SetNameIndexes(c.nameIndexes)
+	nodeOps.SetMergeReviewThreshold(c.config.DedupReviewThreshold)
 	nodes, uuidMap, _, err := nodeOps.ResolveExtractedNodes(ctx, []*types.Node{sourceNode, targetNode}, nil, nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve extracted nodes: %w", err)
@@ -1301,9 +1773,10 @@ func (c *Client) AddTriplet(ctx context.Context, sourceNode *types.Node, edge *t
 	updatedEdge := edge // The edge is updated in-place
 
 	// Step 5: Get existing edges between nodes (lines 1038-1040)
-	edgeOps := maintenance.NewEdgeOperations(c.driver, c.llm, c.embedder, prompts.NewLibrary())
+	edgeOps := maintenance.NewEdgeOperations(c.driver, c.llm, c.embedder, c.config.Prompts)
 	edgeOps.SetLogger(c.logger)
-	validEdges, err := edgeOps.GetBetweenNodes(ctx, updatedEdge.SourceID, updatedEdge.TargetID)
+	edgeOps.SetMaxPromptTokens(maxPromptTokens)
+	validEdges, err := edgeOps.GetBetweenNodes(ctx, updatedEdge.SourceID, updatedEdge.TargetID, updatedEdge.GroupID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get edges between nodes: %w", err)
 	}
@@ -1398,8 +1871,10 @@ func (c *Client) AddTriplet(ctx context.Context, sourceNode *types.Node, edge *t
 // resolveExtractedEdgeExact is an exact translation of Python's resolve_extracted_edge function
 func (c *Client) resolveExtractedEdgeExact(ctx context.Context, extractedEdge *types.Edge, relatedEdges []*types.Edge, existingEdges []*types.Edge, episode *types.Node, createEmbeddings bool) (*types.Edge, []*types.Edge, error) {
 	// Use the EdgeOperations to resolve the edge exactly as in Python
-	edgeOps := maintenance.NewEdgeOperations(c.driver, c.llm, c.embedder, prompts.NewLibrary())
+	edgeOps := maintenance.NewEdgeOperations(c.driver, c.llm, c.embedder, c.config.Prompts)
 	edgeOps.SetLogger(c.logger)
+	edgeOps.SetMaxPromptTokens(llm.PromptTokenLimit(c.config.LLMContextWindow))
+	edgeOps.SetInvalidationPolicies(c.config.EdgeInvalidationPolicies)
 
 	// The Go implementation wraps the private resolveExtractedEdge method
 	// We'll use ResolveExtractedEdges which internally calls the same logic
@@ -1424,15 +1899,28 @@ func (c *Client) createEntityEdgeEmbeddings(ctx context.Context, edges []*types.
 		return nil
 	}
 
+	var pending []*types.Edge
+	var texts []string
 	for _, edge := range edges {
 		if edge.Type == types.EntityEdgeType && len(edge.Embedding) == 0 && edge.Summary != "" {
-			embedding, err := c.embedder.EmbedSingle(ctx, edge.Summary)
-			if err != nil {
-				return fmt.Errorf("failed to create embedding for edge %s: %w", edge.Uuid, err)
-			}
-			edge.Embedding = embedding
+			pending = append(pending, edge)
+			texts = append(texts, edge.Summary)
 		}
 	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	embeddings, err := c.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to create embeddings for %d edges: %w", len(pending), err)
+	}
+	if len(embeddings) != len(pending) {
+		return fmt.Errorf("expected %d edge embeddings, got %d", len(pending), len(embeddings))
+	}
+	for i, edge := range pending {
+		edge.Embedding = embeddings[i]
+	}
 
 	return nil
 }
@@ -1443,15 +1931,28 @@ func (c *Client) createEntityNodeEmbeddings(ctx context.Context, nodes []*types.
 		return nil
 	}
 
+	var pending []*types.Node
+	var texts []string
 	for _, node := range nodes {
 		if node.Type == types.EntityNodeType && len(node.Embedding) == 0 && node.Name != "" {
-			embedding, err := c.embedder.EmbedSingle(ctx, node.Name)
-			if err != nil {
-				return fmt.Errorf("failed to create embedding for node %s: %w", node.Uuid, err)
-			}
-			node.Embedding = embedding
+			pending = append(pending, node)
+			texts = append(texts, node.Name)
 		}
 	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	embeddings, err := c.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to create embeddings for %d nodes: %w", len(pending), err)
+	}
+	if len(embeddings) != len(pending) {
+		return fmt.Errorf("expected %d node embeddings, got %d", len(pending), len(embeddings))
+	}
+	for i, node := range pending {
+		node.Embedding = embeddings[i]
+	}
 
 	return nil
 }
@@ -1517,10 +2018,23 @@ func (c *Client) createSourceEdge(ctx context.Context, sourceNode *types.Node, e
 		return nil, nil
 	}
 
+	edge := newSourceEdge(generateID(), sourceNode, episodeNode)
+
+	// Persist the edge
+	if err := c.driver.UpsertEdge(ctx, edge); err != nil {
+		return nil, fmt.Errorf("failed to create source edge: %w", err)
+	}
+
+	c.logger.Debug("Created source edge", "source", sourceNode.Name, "episode", episodeNode.Uuid, "edge_id", edge.Uuid)
+	return edge, nil
+}
+
+// newSourceEdge builds the SOURCED_FROM edge linking an episode to its source.
+func newSourceEdge(uuid string, sourceNode *types.Node, episodeNode *types.Node) *types.Edge {
 	now := time.Now()
 	edge := &types.Edge{
 		BaseEdge: types.BaseEdge{
-			Uuid:         generateID(),
+			Uuid:         uuid,
 			GroupID:      episodeNode.GroupID,
 			SourceNodeID: sourceNode.Uuid,
 			TargetNodeID: episodeNode.Uuid,
@@ -1540,12 +2054,5 @@ func (c *Client) createSourceEdge(ctx context.Context, sourceNode *types.Node, e
 	// Sync backward compatibility fields
 	edge.SourceID = edge.SourceNodeID
 	edge.TargetID = edge.TargetNodeID
-
-	// Persist the edge
-	if err := c.driver.UpsertEdge(ctx, edge); err != nil {
-		return nil, fmt.Errorf("failed to create source edge: %w", err)
-	}
-
-	c.logger.Debug("Created source edge", "source", sourceNode.Name, "episode", episodeNode.Uuid, "edge_id", edge.Uuid)
-	return edge, nil
+	return edge
 }
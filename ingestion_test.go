@@ -0,0 +1,128 @@
+package predicato
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestRecordEpisodeChanges(t *testing.T) {
+	extractedNodes := []*types.Node{{Uuid: "alice"}, {Uuid: "acme-2"}}
+	resolvedNodes := []*types.Node{{Uuid: "alice"}, {Uuid: "acme"}}
+	extractedEdges := []*types.Edge{
+		types.NewEntityEdge("works", "alice", "acme-2", "g", "WORKS_AT", types.EntityEdgeType),
+		types.NewEntityEdge("knows-2", "alice", "bob", "g", "KNOWS", types.EntityEdgeType),
+	}
+	resolvedEdges := []*types.Edge{
+		extractedEdges[0],
+		types.NewEntityEdge("knows", "alice", "bob", "g", "KNOWS", types.EntityEdgeType),
+	}
+
+	changeLog := &types.EpisodeChangeLog{}
+	recordEpisodeChanges(changeLog, extractedNodes, resolvedNodes, extractedEdges, resolvedEdges)
+
+	want := &types.EpisodeChangeLog{
+		CreatedNodes:  []string{"alice"},
+		ModifiedNodes: []string{"acme"},
+		CreatedEdges:  []string{"works"},
+		ModifiedEdges: []string{"knows"},
+	}
+	if !reflect.DeepEqual(changeLog, want) {
+		t.Errorf("recordEpisodeChanges() = %+v, want %+v", changeLog, want)
+	}
+
+	// The change log is read back from the decoded JSON metadata of episodes
+	episode := &types.Node{Uuid: "ep", Metadata: map[string]interface{}{
+		types.EpisodeChangeLogKey: map[string]interface{}{
+			"created_nodes":     []interface{}{"alice"},
+			"invalidated_edges": []interface{}{map[string]interface{}{"uuid": "old", "valid_to": nil}},
+		},
+	}}
+	got, err := episode.ChangeLog()
+	if err != nil {
+		t.Fatalf("ChangeLog() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.CreatedNodes, []string{"alice"}) || len(got.InvalidatedEdges) != 1 ||
+		got.InvalidatedEdges[0].Uuid != "old" || got.InvalidatedEdges[0].ValidTo != nil {
+		t.Errorf("ChangeLog() = %+v", got)
+	}
+}
+
+// getNodeErrorDriver is a GraphDriver whose GetNode fails with err.
+type getNodeErrorDriver struct {
+	driver.GraphDriver
+	err error
+}
+
+func (d *getNodeErrorDriver) GetNode(ctx context.Context, nodeID, groupID string) (*types.Node, error) {
+	return nil, d.err
+}
+
+func TestRemoveExistingEpisodeLookupErrors(t *testing.T) {
+	ctx := context.Background()
+	client := &Client{driver: &getNodeErrorDriver{err: fmt.Errorf("episode ep1: %w", driver.ErrNodeNotFound)}}
+	if err := client.removeExistingEpisode(ctx, "ep1", "g"); err != nil {
+		t.Errorf("removeExistingEpisode() of a missing episode error = %v, want nil", err)
+	}
+
+	unavailable := errors.New("connection refused")
+	client = &Client{driver: &getNodeErrorDriver{err: unavailable}}
+	if err := client.removeExistingEpisode(ctx, "ep1", "g"); !errors.Is(err, unavailable) {
+		t.Errorf("removeExistingEpisode() error = %v, want the lookup error", err)
+	}
+}
+
+func TestClosestCommunity(t *testing.T) {
+	communities := []*types.Node{
+		// Summary embeddings are read back from the decoded JSON metadata
+		{Uuid: "sports", Embedding: []float32{0, 1}, Metadata: map[string]interface{}{
+			types.CommunitySummaryEmbeddingKey: []interface{}{1.0, 0.0},
+		}},
+		{Uuid: "music", Embedding: []float32{0, 1}},
+		{Uuid: "other", Embedding: []float32{1, 0, 0}},
+	}
+
+	community, score := closestCommunity([]float32{0.9, 0.1}, communities)
+	if community == nil || community.Uuid != "sports" || score < 0.9 {
+		t.Errorf("closestCommunity() = %v, %v, want sports", community, score)
+	}
+	community, _ = closestCommunity([]float32{0.1, 0.9}, communities)
+	if community == nil || community.Uuid != "music" {
+		t.Errorf("closestCommunity() = %v, want music", community)
+	}
+	if community, _ := closestCommunity([]float32{1}, communities); community != nil {
+		t.Errorf("closestCommunity() = %v, want nil", community)
+	}
+}
+
+func TestPreviewLLMRecordsRequests(t *testing.T) {
+	recorder := &previewLLM{}
+	ctx := usage.WithOperation(context.Background(), usage.OperationExtractNodes)
+	messages := []types.Message{llm.NewSystemMessage("system"), llm.NewUserMessage("user")}
+
+	if _, err := recorder.Chat(ctx, messages); !errors.Is(err, errPromptPreview) {
+		t.Errorf("Chat() error = %v, want errPromptPreview", err)
+	}
+	// Retries continuing the request with the previous response are not prompts
+	retry := append(slices.Clone(messages), types.Message{Role: llm.RoleAssistant, Content: "bad"})
+	if _, err := recorder.ChatWithStructuredOutput(ctx, retry, nil); !errors.Is(err, errPromptPreview) {
+		t.Errorf("ChatWithStructuredOutput() error = %v, want errPromptPreview", err)
+	}
+
+	previews := recorder.previews(2)
+	if len(previews) != 1 || previews[0].Stage != usage.OperationExtractNodes || previews[0].Chunk != 2 ||
+		len(previews[0].Messages) != 2 || previews[0].Tokens == 0 {
+		t.Errorf("previews() = %+v", previews)
+	}
+	if previews := recorder.previews(-1); len(previews) != 0 {
+		t.Errorf("previews() = %+v, want none after the last call", previews)
+	}
+}
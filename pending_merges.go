@@ -0,0 +1,51 @@
+package predicato
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// ListPendingMerges returns the duplicate pairs of a group awaiting review,
+// oldest first. An empty groupID uses the client's group.
+func (c *Client) ListPendingMerges(ctx context.Context, groupID string) ([]*driver.PendingMerge, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	return driver.ListPendingMerges(ctx, c.driver, groupID)
+}
+
+// ApproveMerge merges the node of the pending merge id into its canonical
+// node with MergeNodes and removes the pending merge. It returns the updated
+// canonical node.
+func (c *Client) ApproveMerge(ctx context.Context, groupID, id string) (*types.Node, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	merge, err := driver.GetPendingMerge(ctx, c.driver, groupID, id)
+	if err != nil {
+		return nil, err
+	}
+	if merge == nil {
+		return nil, fmt.Errorf("pending merge %q not found in group %q", id, groupID)
+	}
+
+	canonical, err := c.MergeNodes(ctx, groupID, merge.CanonicalUUID, []string{merge.NodeUUID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve merge %q: %w", id, err)
+	}
+	if err := driver.DeletePendingMerge(ctx, c.driver, groupID, id); err != nil {
+		return nil, err
+	}
+	return canonical, nil
+}
+
+// RejectMerge removes the pending merge id, keeping its nodes apart.
+func (c *Client) RejectMerge(ctx context.Context, groupID, id string) error {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	return driver.DeletePendingMerge(ctx, c.driver, groupID, id)
+}
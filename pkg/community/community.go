@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/soundprediction/go-predicato/pkg/driver"
 	"github.com/soundprediction/go-predicato/pkg/embedder"
 	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
 	"github.com/soundprediction/go-predicato/pkg/types"
 )
 
@@ -20,59 +22,107 @@ const (
 
 // Builder provides community building operations for knowledge graphs
 type Builder struct {
-	driver   driver.GraphDriver
-	llm      llm.Client
-	embedder embedder.Client
+	driver          driver.GraphDriver
+	llm             llm.Client
+	embedder        embedder.Client
+	resolution      float64
+	maxPromptTokens int
+	domainPreamble  string
 }
 
 // NewBuilder creates a new community builder
 func NewBuilder(driver driver.GraphDriver, llmClient llm.Client, embedderClient embedder.Client) *Builder {
 	return &Builder{
-		driver:   driver,
-		llm:      llmClient,
-		embedder: embedderClient,
+		driver:     driver,
+		llm:        llmClient,
+		embedder:   embedderClient,
+		resolution: DefaultResolution,
 	}
 }
 
+// SetResolution sets the resolution of the Leiden community detection, which
+// defaults to DefaultResolution. Higher resolutions give more, smaller
+// communities.
+func (b *Builder) SetResolution(resolution float64) {
+	b.resolution = resolution
+}
+
+// SetMaxPromptTokens bounds the estimated size of the prompts summarizing
+// communities, see llm.PromptTokenLimit. The member summaries and facts of
+// larger communities are summarized in chunks. 0 summarizes chunks of
+// DefaultSummaryChunkTokens.
+func (b *Builder) SetMaxPromptTokens(maxTokens int) {
+	b.maxPromptTokens = maxTokens
+}
+
+// SetDomainPreamble sets a preamble appended to the system message of the
+// prompts naming and summarizing communities, describing the domain of the
+// graph.
+func (b *Builder) SetDomainPreamble(preamble string) {
+	b.domainPreamble = strings.TrimSpace(preamble)
+}
+
+// systemMessage returns a system message of the given content followed by
+// the domain preamble.
+func (b *Builder) systemMessage(content string) types.Message {
+	if b.domainPreamble != "" {
+		content += "\n\n" + b.domainPreamble
+	}
+	return types.Message{Role: llm.RoleSystem, Content: content}
+}
+
 // BuildCommunitiesResult represents the result of community building
 type BuildCommunitiesResult struct {
 	CommunityNodes []*types.Node `json:"community_nodes"`
 	CommunityEdges []*types.Edge `json:"community_edges"`
+	// Metrics describe the communities of each group, see
+	// driver.SaveCommunityMetrics
+	Metrics []*driver.CommunityMetrics `json:"metrics,omitempty"`
 }
 
-// GetCommunityClusters detects community clusters using label propagation algorithm
+// GetCommunityClusters detects community clusters using the Leiden algorithm
+// over the entity edges of each group, for every driver.
 func (b *Builder) GetCommunityClusters(ctx context.Context, groupIDs []string) ([][]*types.Node, error) {
+	clusters, _, err := b.getCommunityClusters(ctx, groupIDs)
+	return clusters, err
+}
+
+// getCommunityClusters detects community clusters like GetCommunityClusters,
+// along with the metrics of the clusters of each group.
+func (b *Builder) getCommunityClusters(ctx context.Context, groupIDs []string) ([][]*types.Node, []*driver.CommunityMetrics, error) {
 	if len(groupIDs) == 0 {
 		// Get all group IDs if none specified
 		allGroupIDs, err := b.getAllGroupIDs(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get group IDs: %w", err)
+			return nil, nil, fmt.Errorf("failed to get group IDs: %w", err)
 		}
 		groupIDs = allGroupIDs
 	}
 	var allClusters [][]*types.Node
+	var allMetrics []*driver.CommunityMetrics
 
 	for _, groupID := range groupIDs {
 		// Get all entity nodes for this group
 		nodes, err := b.getEntityNodesByGroup(ctx, groupID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get entity nodes for group %s: %w", groupID, err)
+			return nil, nil, fmt.Errorf("failed to get entity nodes for group %s: %w", groupID, err)
 		}
 
 		// Build adjacency projection
 		projection, err := b.buildProjection(ctx, nodes, groupID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to build projection for group %s: %w", groupID, err)
+			return nil, nil, fmt.Errorf("failed to build projection for group %s: %w", groupID, err)
 		}
 
-		// Apply label propagation algorithm
-		clusterUUIDs := b.labelPropagation(projection)
+		// Apply the Leiden algorithm
+		clusterUUIDs := leiden(projection, b.resolution)
+		allMetrics = append(allMetrics, communityMetrics(groupID, projection, clusterUUIDs, time.Now().UTC()))
 
 		// Convert UUID clusters to node clusters
 		for _, cluster := range clusterUUIDs {
 			clusterNodes, err := b.getNodesByUUIDs(ctx, cluster, groupID)
 			if err != nil {
-				return nil, fmt.Errorf("failed to get nodes for cluster: %w", err)
+				return nil, nil, fmt.Errorf("failed to get nodes for cluster: %w", err)
 			}
 			if len(clusterNodes) > 0 {
 				allClusters = append(allClusters, clusterNodes)
@@ -80,13 +130,13 @@ func (b *Builder) GetCommunityClusters(ctx context.Context, groupIDs []string) (
 		}
 	}
 
-	return allClusters, nil
+	return allClusters, allMetrics, nil
 }
 
 // BuildCommunities builds communities from entity clusters
 func (b *Builder) BuildCommunities(ctx context.Context, groupIDs []string, logger *slog.Logger) (*BuildCommunitiesResult, error) {
 	// Get community clusters
-	clusters, err := b.GetCommunityClusters(ctx, groupIDs)
+	clusters, metrics, err := b.getCommunityClusters(ctx, groupIDs)
 	if logger != nil {
 		logger.Info("Clustering", "num_clusters", len(clusters), "num_groups", len(groupIDs))
 	}
@@ -130,15 +180,25 @@ func (b *Builder) BuildCommunities(ctx context.Context, groupIDs []string, logge
 
 	wg.Wait()
 
+	// Keep the UUIDs and names of the communities matching current ones
+	for _, groupMetrics := range metrics {
+		groupID := groupMetrics.GroupID
+		if err := b.stabilizeCommunities(ctx, groupID, allCommunityNodes, allCommunityEdges); err != nil {
+			buildErrors = append(buildErrors, fmt.Errorf("failed to match current communities of group %s: %w", groupID, err))
+		}
+	}
+
 	if len(buildErrors) > 0 {
 		return &BuildCommunitiesResult{
 			CommunityNodes: allCommunityNodes,
 			CommunityEdges: allCommunityEdges,
+			Metrics:        metrics,
 		}, fmt.Errorf("some errors arose during community building: %v", buildErrors)
 	}
 	return &BuildCommunitiesResult{
 		CommunityNodes: allCommunityNodes,
 		CommunityEdges: allCommunityEdges,
+		Metrics:        metrics,
 	}, nil
 }
 
@@ -148,14 +208,8 @@ func (b *Builder) buildCommunity(ctx context.Context, cluster []*types.Node) (*t
 		return nil, nil, fmt.Errorf("empty cluster")
 	}
 
-	// Extract summaries for hierarchical summarization
-	summaries := make([]string, len(cluster))
-	for i, node := range cluster {
-		summaries[i] = node.Summary
-	}
-
-	// Hierarchical summarization
-	finalSummary, err := b.hierarchicalSummarize(ctx, summaries)
+	// Map-reduce summarization of the member summaries and facts
+	finalSummary, err := b.summarizeCommunity(ctx, cluster)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to summarize cluster: %w", err)
 	}
@@ -191,83 +245,10 @@ func (b *Builder) buildCommunity(ctx context.Context, cluster []*types.Node) (*t
 	return communityNode, communityEdges, nil
 }
 
-// hierarchicalSummarize performs hierarchical summarization of node summaries
-func (b *Builder) hierarchicalSummarize(ctx context.Context, summaries []string) (string, error) {
-	if len(summaries) == 0 {
-		return "", fmt.Errorf("no summaries to process")
-	}
-
-	if len(summaries) == 1 {
-		return summaries[0], nil
-	}
-
-	currentSummaries := make([]string, len(summaries))
-	copy(currentSummaries, summaries)
-
-	for len(currentSummaries) > 1 {
-		var newSummaries []string
-		var oddOneOut string
-
-		// Handle odd number of summaries
-		if len(currentSummaries)%2 == 1 {
-			oddOneOut = currentSummaries[len(currentSummaries)-1]
-			currentSummaries = currentSummaries[:len(currentSummaries)-1]
-		}
-
-		// Process pairs concurrently
-		pairCount := len(currentSummaries) / 2
-		results := make([]string, pairCount)
-
-		var wg sync.WaitGroup
-		var mu sync.Mutex
-		var summarizeErrors []error
-
-		for i := 0; i < pairCount; i++ {
-			wg.Add(1)
-			go func(idx int) {
-				defer wg.Done()
-
-				left := currentSummaries[idx]
-				right := currentSummaries[idx+pairCount]
-
-				pairSummary, err := b.summarizePair(ctx, left, right)
-				if err != nil {
-					mu.Lock()
-					summarizeErrors = append(summarizeErrors, err)
-					mu.Unlock()
-					return
-				}
-
-				mu.Lock()
-				results[idx] = pairSummary
-				mu.Unlock()
-			}(i)
-		}
-
-		wg.Wait()
-
-		if len(summarizeErrors) > 0 {
-			return "", fmt.Errorf("failed to summarize pairs: %w", summarizeErrors[0])
-		}
-
-		newSummaries = results
-		if oddOneOut != "" {
-			newSummaries = append(newSummaries, oddOneOut)
-		}
-
-		currentSummaries = newSummaries
-	}
-
-	return currentSummaries[0], nil
-}
-
 // summarizePair summarizes two text summaries into one
 func (b *Builder) summarizePair(ctx context.Context, left, right string) (string, error) {
 	messages := []types.Message{
-		{
-			Role:    llm.RoleSystem,
-			Content: `You are an expert at synthesizing information. Given two entity summaries, create a single comprehensive summary that captures the key information from both. The summary should be concise (under 250 words) and maintain the most important details.`,
-		},
+		b.systemMessage(`You are an expert at synthesizing information. Given two entity summaries, create a single comprehensive summary that captures the key information from both. The summary should be concise (under 250 words) and maintain the most important details.`),
 		{
 			Role: llm.RoleUser,
 			Content: fmt.Sprintf(`Please summarize these two entity summaries into one comprehensive summary:
@@ -280,7 +261,7 @@ Provide a single summary that captures the essential information from both:`, le
 		},
 	}
 
-	response, err := b.llm.Chat(ctx, messages)
+	response, err := b.llm.Chat(usage.WithOperation(ctx, usage.OperationBuildCommunities), messages)
 	if err != nil {
 		return "", fmt.Errorf("failed to get LLM response for pair summarization: %w", err)
 	}
@@ -291,10 +272,7 @@ Provide a single summary that captures the essential information from both:`, le
 // generateCommunityName generates a descriptive name for a community based on its summary
 func (b *Builder) generateCommunityName(ctx context.Context, summary string) (string, error) {
 	messages := []types.Message{
-		{
-			Role:    llm.RoleSystem,
-			Content: `You are an expert at creating concise, descriptive names. Given a summary, create a brief descriptive name (1-5 words) that captures the essence of the content.`,
-		},
+		b.systemMessage(`You are an expert at creating concise, descriptive names. Given a summary, create a brief descriptive name (1-5 words) that captures the essence of the content.`),
 		{
 			Role: llm.RoleUser,
 			Content: fmt.Sprintf(`Based on this summary, provide a brief descriptive name (1-5 words):
@@ -305,22 +283,34 @@ Name:`, summary),
 		},
 	}
 
-	response, err := b.llm.Chat(ctx, messages)
+	response, err := b.llm.Chat(usage.WithOperation(ctx, usage.OperationBuildCommunities), messages)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate community name: %w", err)
 	}
 
-	return response.Content, nil
+	// Keep the short name only, without a label or quotes
+	name := strings.TrimSpace(response.Content)
+	if line, _, ok := strings.Cut(name, "\n"); ok {
+		name = line
+	}
+	name = strings.TrimSpace(strings.TrimPrefix(name, "Name:"))
+	return strings.Trim(name, `"'*`), nil
 }
 
-// generateCommunityEmbedding generates an embedding for the community name
+// generateCommunityEmbedding generates an embedding for the community name,
+// and one for its summary recorded in its metadata
 func (b *Builder) generateCommunityEmbedding(ctx context.Context, community *types.Node) error {
-	embedding, err := b.embedder.EmbedSingle(ctx, community.Name)
+	embedding, err := b.embedder.EmbedDocument(ctx, community.Name)
 	if err != nil {
 		return fmt.Errorf("failed to generate embedding: %w", err)
 	}
-
 	community.Embedding = embedding
+
+	summaryEmbedding, err := b.embedder.EmbedDocument(ctx, community.Summary)
+	if err != nil {
+		return fmt.Errorf("failed to generate summary embedding: %w", err)
+	}
+	community.Metadata[types.CommunitySummaryEmbeddingKey] = summaryEmbedding
 	return nil
 }
 
@@ -347,7 +337,8 @@ func (b *Builder) buildCommunityEdges(entityNodes []*types.Node, communityNode *
 	return edges
 }
 
-// RemoveCommunities removes all community nodes and edges from the graph
+// RemoveCommunities removes all community nodes and edges from the graph, for
+// every group. See driver.RemoveGroupCommunities to remove those of a group.
 func (b *Builder) RemoveCommunities(ctx context.Context) error {
 	return b.driver.RemoveCommunities(ctx)
 }
@@ -0,0 +1,345 @@
+package community
+
+import (
+	"sort"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// DefaultResolution is the default resolution of the Leiden community
+// detection. Higher resolutions give more, smaller communities.
+const DefaultResolution = 1.0
+
+// maxLeidenLevels bounds the aggregation levels of leiden.
+const maxLeidenLevels = 20
+
+// leidenEdge is an edge of a leidenGraph.
+type leidenEdge struct {
+	to     int
+	weight float64
+}
+
+// leidenGraph is a weighted undirected graph whose nodes aggregate the nodes
+// of the level below.
+type leidenGraph struct {
+	edges     [][]leidenEdge // edges to the other nodes, sorted by node
+	selfLoops []float64      // weight of the edges within each node
+	degrees   []float64      // weighted degrees, counting self loops twice
+	total     float64        // sum of the degrees
+}
+
+// newLeidenGraph creates a graph of n nodes from the weights of its edges,
+// keyed by their nodes.
+func newLeidenGraph(n int, weights map[[2]int]float64) *leidenGraph {
+	g := &leidenGraph{
+		edges:     make([][]leidenEdge, n),
+		selfLoops: make([]float64, n),
+		degrees:   make([]float64, n),
+	}
+	for pair, weight := range weights {
+		u, v := pair[0], pair[1]
+		if u == v {
+			g.selfLoops[u] += weight
+			g.degrees[u] += 2 * weight
+		} else {
+			g.edges[u] = append(g.edges[u], leidenEdge{to: v, weight: weight})
+			g.edges[v] = append(g.edges[v], leidenEdge{to: u, weight: weight})
+			g.degrees[u] += weight
+			g.degrees[v] += weight
+		}
+		g.total += 2 * weight
+	}
+	for _, edges := range g.edges {
+		sort.Slice(edges, func(i, j int) bool { return edges[i].to < edges[j].to })
+	}
+	return g
+}
+
+// projectionWeights returns the sorted node UUIDs of the projection and the
+// weights of the edges between them, keyed by the indexes of their nodes.
+func projectionWeights(projection map[string][]types.Neighbor) ([]string, map[[2]int]float64) {
+	uuids := make([]string, 0, len(projection))
+	for uuid := range projection {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	index := make(map[string]int, len(uuids))
+	for i, uuid := range uuids {
+		index[uuid] = i
+	}
+
+	// Neighbors may be listed from either end, count each pair once
+	weights := make(map[[2]int]float64)
+	for uuid, neighbors := range projection {
+		u := index[uuid]
+		for _, neighbor := range neighbors {
+			v, ok := index[neighbor.NodeUUID]
+			if !ok || v == u || neighbor.EdgeCount <= 0 {
+				continue
+			}
+			pair := [2]int{min(u, v), max(u, v)}
+			weights[pair] = max(weights[pair], float64(neighbor.EdgeCount))
+		}
+	}
+	return uuids, weights
+}
+
+// leiden detects communities in the projection with the Leiden algorithm,
+// maximizing modularity at the given resolution: nodes are moved between
+// communities while that improves modularity, each community is refined into
+// well-connected subcommunities, and the subcommunities are aggregated into
+// the nodes of the next level until no node moves. Clusters of a single node
+// are left out. The result only depends on the projection.
+func leiden(projection map[string][]types.Neighbor, resolution float64) [][]string {
+	if resolution <= 0 {
+		resolution = DefaultResolution
+	}
+
+	uuids, weights := projectionWeights(projection)
+	g := newLeidenGraph(len(uuids), weights)
+	if g.total == 0 {
+		return nil
+	}
+
+	// membership maps the projection nodes to the nodes of the current level
+	membership := make([]int, len(uuids))
+	partition := make([]int, len(uuids))
+	for i := range uuids {
+		membership[i] = i
+		partition[i] = i
+	}
+	for level := 0; level < maxLeidenLevels; level++ {
+		moveNodes(g, partition, resolution)
+		if countCommunities(partition) == len(partition) {
+			break
+		}
+
+		refined := refinePartition(g, partition, resolution)
+		refinedCount := countCommunities(refined)
+		if refinedCount == len(partition) {
+			break
+		}
+
+		// Aggregate the refined communities, starting from the communities
+		// they belong to
+		aggregateWeights := make(map[[2]int]float64)
+		aggregatePartition := make([]int, refinedCount)
+		for u, edges := range g.edges {
+			aggregatePartition[refined[u]] = partition[u]
+			if g.selfLoops[u] > 0 {
+				aggregateWeights[[2]int{refined[u], refined[u]}] += g.selfLoops[u]
+			}
+			for _, edge := range edges {
+				if u < edge.to {
+					ru, rv := refined[u], refined[edge.to]
+					aggregateWeights[[2]int{min(ru, rv), max(ru, rv)}] += edge.weight
+				}
+			}
+		}
+		for i := range membership {
+			membership[i] = refined[membership[i]]
+		}
+		g = newLeidenGraph(refinedCount, aggregateWeights)
+		partition = aggregatePartition
+	}
+
+	clusterMap := make(map[int][]string)
+	for i, uuid := range uuids {
+		community := partition[membership[i]]
+		clusterMap[community] = append(clusterMap[community], uuid)
+	}
+	var clusters [][]string
+	for _, cluster := range clusterMap {
+		if len(cluster) > 1 {
+			clusters = append(clusters, cluster)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i][0] < clusters[j][0] })
+	return clusters
+}
+
+// moveNodes moves the nodes of g between the communities of partition, in
+// place, while that improves modularity. Nodes are visited from a queue, and
+// the neighbors of a moved node outside its new community are queued again.
+func moveNodes(g *leidenGraph, partition []int, resolution float64) {
+	n := len(partition)
+	communityDegrees := make([]float64, n)
+	sizes := make([]int, n)
+	for v, community := range partition {
+		communityDegrees[community] += g.degrees[v]
+		sizes[community]++
+	}
+	var emptyCommunities []int
+	for community, size := range sizes {
+		if size == 0 {
+			emptyCommunities = append(emptyCommunities, community)
+		}
+	}
+
+	queue := make([]int, n)
+	queued := make([]bool, n)
+	for v := range queue {
+		queue[v] = v
+		queued[v] = true
+	}
+	weights := make(map[int]float64)
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		queued[v] = false
+
+		// Weights from v to the neighboring communities, in neighbor order
+		clear(weights)
+		var candidates []int
+		for _, edge := range g.edges[v] {
+			community := partition[edge.to]
+			if _, ok := weights[community]; !ok {
+				candidates = append(candidates, community)
+			}
+			weights[community] += edge.weight
+		}
+
+		current := partition[v]
+		degree := g.degrees[v]
+		communityDegrees[current] -= degree
+		sizes[current]--
+		if sizes[current] == 0 {
+			emptyCommunities = append(emptyCommunities, current)
+		}
+
+		// Compare the neighboring communities to staying, or to moving to an
+		// empty community when staying loses modularity
+		best := current
+		bestGain := weights[current] - resolution*degree*communityDegrees[current]/g.total
+		if sizes[current] > 0 && bestGain < 0 {
+			for sizes[emptyCommunities[len(emptyCommunities)-1]] > 0 {
+				emptyCommunities = emptyCommunities[:len(emptyCommunities)-1]
+			}
+			best, bestGain = emptyCommunities[len(emptyCommunities)-1], 0
+		}
+		for _, community := range candidates {
+			gain := weights[community] - resolution*degree*communityDegrees[community]/g.total
+			if gain > bestGain {
+				best, bestGain = community, gain
+			}
+		}
+
+		partition[v] = best
+		communityDegrees[best] += degree
+		sizes[best]++
+		if best == current {
+			continue
+		}
+		for _, edge := range g.edges[v] {
+			if !queued[edge.to] && partition[edge.to] != best {
+				queue = append(queue, edge.to)
+				queued[edge.to] = true
+			}
+		}
+	}
+	renumberCommunities(partition)
+}
+
+// refinePartition splits each community of partition into subcommunities:
+// starting from single nodes, each node well connected to the rest of its
+// community is merged into the well-connected subcommunity of its community
+// improving modularity the most. It returns the subcommunity of each node,
+// numbered from 0.
+func refinePartition(g *leidenGraph, partition []int, resolution float64) []int {
+	n := len(partition)
+	communityDegrees := make([]float64, n)
+	for v, community := range partition {
+		communityDegrees[community] += g.degrees[v]
+	}
+
+	refined := make([]int, n)
+	refinedDegrees := make([]float64, n)
+	sizes := make([]int, n)
+	// external is the weight from each subcommunity to the rest of its community
+	external := make([]float64, n)
+	for v := range refined {
+		refined[v] = v
+		refinedDegrees[v] = g.degrees[v]
+		sizes[v] = 1
+		for _, edge := range g.edges[v] {
+			if partition[edge.to] == partition[v] {
+				external[v] += edge.weight
+			}
+		}
+	}
+
+	// wellConnected reports whether a subcommunity of the given degree and
+	// external weight is well connected to the rest of its community
+	wellConnected := func(community int, degree, externalWeight float64) bool {
+		return externalWeight >= resolution*degree*(communityDegrees[community]-degree)/g.total
+	}
+
+	weights := make(map[int]float64)
+	for v := range refined {
+		community := partition[v]
+		degree := g.degrees[v]
+		if sizes[refined[v]] > 1 || !wellConnected(community, degree, external[v]) {
+			continue
+		}
+
+		clear(weights)
+		var candidates []int
+		for _, edge := range g.edges[v] {
+			if partition[edge.to] != community {
+				continue
+			}
+			subcommunity := refined[edge.to]
+			if _, ok := weights[subcommunity]; !ok {
+				candidates = append(candidates, subcommunity)
+			}
+			weights[subcommunity] += edge.weight
+		}
+
+		current := refined[v]
+		best, bestGain := current, 0.0
+		for _, subcommunity := range candidates {
+			if subcommunity == current || !wellConnected(community, refinedDegrees[subcommunity], external[subcommunity]) {
+				continue
+			}
+			gain := weights[subcommunity] - resolution*degree*refinedDegrees[subcommunity]/g.total
+			if gain > bestGain {
+				best, bestGain = subcommunity, gain
+			}
+		}
+		if best == current {
+			continue
+		}
+
+		external[best] += external[current] - 2*weights[best]
+		refinedDegrees[best] += degree
+		sizes[best]++
+		sizes[current]--
+		refined[v] = best
+	}
+	renumberCommunities(refined)
+	return refined
+}
+
+// renumberCommunities numbers the communities of partition from 0, in order
+// of first appearance.
+func renumberCommunities(partition []int) {
+	numbers := make(map[int]int)
+	for v, community := range partition {
+		number, ok := numbers[community]
+		if !ok {
+			number = len(numbers)
+			numbers[community] = number
+		}
+		partition[v] = number
+	}
+}
+
+// countCommunities returns the number of communities of a partition numbered
+// from 0.
+func countCommunities(partition []int) int {
+	count := 0
+	for _, community := range partition {
+		count = max(count, community+1)
+	}
+	return count
+}
@@ -0,0 +1,135 @@
+package community
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// testProjection builds a projection from undirected edges of weight 1,
+// listing each edge from both ends, and the given isolated nodes.
+func testProjection(edges [][2]string, isolated ...string) map[string][]types.Neighbor {
+	projection := make(map[string][]types.Neighbor)
+	for _, uuid := range isolated {
+		projection[uuid] = nil
+	}
+	for _, edge := range edges {
+		projection[edge[0]] = append(projection[edge[0]], types.Neighbor{NodeUUID: edge[1], EdgeCount: 1})
+		projection[edge[1]] = append(projection[edge[1]], types.Neighbor{NodeUUID: edge[0], EdgeCount: 1})
+	}
+	return projection
+}
+
+// clique returns the edges between all the given nodes.
+func clique(nodes ...string) [][2]string {
+	var edges [][2]string
+	for i := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+			edges = append(edges, [2]string{nodes[i], nodes[j]})
+		}
+	}
+	return edges
+}
+
+// cliqueRing returns n cliques of size nodes, each joined to the next by one
+// edge.
+func cliqueRing(n, size int) [][2]string {
+	var edges [][2]string
+	for c := 0; c < n; c++ {
+		nodes := make([]string, size)
+		for i := range nodes {
+			nodes[i] = fmt.Sprintf("c%d-%d", c, i)
+		}
+		edges = append(edges, clique(nodes...)...)
+		edges = append(edges, [2]string{nodes[0], fmt.Sprintf("c%d-1", (c+1)%n)})
+	}
+	return edges
+}
+
+func TestLeidenTwoCliquesJoinedByBridge(t *testing.T) {
+	edges := slices.Concat(clique("a1", "a2", "a3", "a4"), clique("b1", "b2", "b3", "b4"), [][2]string{{"a1", "b1"}})
+	got := leiden(testProjection(edges), DefaultResolution)
+	want := [][]string{{"a1", "a2", "a3", "a4"}, {"b1", "b2", "b3", "b4"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("leiden() = %v, want %v", got, want)
+	}
+}
+
+func TestLeidenDisconnectedGraph(t *testing.T) {
+	edges := slices.Concat(clique("x1", "x2", "x3"), clique("y1", "y2", "y3"), [][2]string{{"w1", "w2"}})
+	got := leiden(testProjection(edges, "z"), DefaultResolution)
+	want := [][]string{{"w1", "w2"}, {"x1", "x2", "x3"}, {"y1", "y2", "y3"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("leiden() = %v, want %v", got, want)
+	}
+
+	if got := leiden(testProjection(nil, "a", "b"), DefaultResolution); got != nil {
+		t.Errorf("leiden() of a graph without edges = %v, want nil", got)
+	}
+}
+
+func TestLeidenResolutionSweep(t *testing.T) {
+	projection := testProjection(cliqueRing(6, 4))
+	previous := 0
+	for _, resolution := range []float64{0.01, 0.1, 0.5, 1, 2, 4} {
+		clusters := leiden(projection, resolution)
+		if len(clusters) < previous {
+			t.Errorf("leiden() at resolution %g found %d communities, fewer than %d at a lower resolution", resolution, len(clusters), previous)
+		}
+		previous = len(clusters)
+
+		if resolution == DefaultResolution {
+			if len(clusters) != 6 {
+				t.Fatalf("leiden() at resolution 1 = %v, want the 6 cliques", clusters)
+			}
+			for _, cluster := range clusters {
+				if len(cluster) != 4 || cluster[0][:2] != cluster[3][:2] {
+					t.Errorf("leiden() at resolution 1 found %v, want a clique", cluster)
+				}
+			}
+		}
+	}
+	if clusters := leiden(projection, 0.01); len(clusters) != 1 {
+		t.Errorf("leiden() at resolution 0.01 = %d communities, want 1", len(clusters))
+	}
+}
+
+func TestLeidenIsDeterministic(t *testing.T) {
+	edges := slices.Concat(cliqueRing(5, 5), [][2]string{{"c0-2", "c2-3"}, {"c1-4", "c3-2"}})
+	want := leiden(testProjection(edges), DefaultResolution)
+	if len(want) == 0 {
+		t.Fatal("leiden() found no communities")
+	}
+	for run := 0; run < 20; run++ {
+		// Listing the edges in another order gives the same projection
+		reversed := slices.Clone(edges)
+		slices.Reverse(reversed)
+		if got := leiden(testProjection(reversed), DefaultResolution); !reflect.DeepEqual(got, want) {
+			t.Fatalf("leiden() run %d = %v, want %v", run, got, want)
+		}
+	}
+}
+
+func TestCommunityMetrics(t *testing.T) {
+	edges := slices.Concat(clique("a1", "a2", "a3", "a4"), clique("b1", "b2", "b3", "b4"), [][2]string{{"a1", "b1"}, {"b4", "c"}})
+	projection := testProjection(edges)
+	clusters := [][]string{{"a1", "a2", "a3", "a4"}, {"b1", "b2", "b3", "b4"}}
+
+	metrics := communityMetrics("g", projection, clusters, time.Now())
+	if metrics.Communities != 2 || !reflect.DeepEqual(metrics.Sizes, []int{4, 4}) {
+		t.Errorf("communities = %d, sizes = %v, want 2 of 4", metrics.Communities, metrics.Sizes)
+	}
+	if metrics.IntraCommunityEdges != 12 || metrics.InterCommunityEdges != 1 {
+		t.Errorf("intra = %d, inter = %d, want 12 and 1", metrics.IntraCommunityEdges, metrics.InterCommunityEdges)
+	}
+	// Modularity of {a}, {b} and {c} over 14 edges of degrees 13, 14 and 1
+	want := 12.0/14 - math.Pow(13.0/28, 2) - math.Pow(14.0/28, 2) - math.Pow(1.0/28, 2)
+	if math.Abs(metrics.Modularity-want) > 1e-9 {
+		t.Errorf("modularity = %f, want %f", metrics.Modularity, want)
+	}
+}
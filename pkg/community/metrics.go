@@ -0,0 +1,66 @@
+package community
+
+import (
+	"sort"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// communityMetrics computes the metrics of the clusters of a group over its
+// projection. The modularity is computed at resolution 1, so that it stays
+// comparable when the resolution changes, counting each entity outside the
+// clusters as a community of its own.
+func communityMetrics(groupID string, projection map[string][]types.Neighbor, clusters [][]string, builtAt time.Time) *driver.CommunityMetrics {
+	metrics := &driver.CommunityMetrics{
+		GroupID:     groupID,
+		BuiltAt:     builtAt,
+		Communities: len(clusters),
+		Sizes:       make([]int, len(clusters)),
+	}
+	clusterOf := make(map[string]int)
+	for i, cluster := range clusters {
+		metrics.Sizes[i] = len(cluster)
+		for _, uuid := range cluster {
+			clusterOf[uuid] = i
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(metrics.Sizes)))
+
+	uuids, weights := projectionWeights(projection)
+	// communities numbers the clusters from 0 and the other entities after them
+	communities := make([]int, len(uuids))
+	for i, uuid := range uuids {
+		if cluster, ok := clusterOf[uuid]; ok {
+			communities[i] = cluster
+		} else {
+			communities[i] = len(clusters) + i
+		}
+	}
+
+	total := 0.0
+	internal := make(map[int]float64)
+	degrees := make(map[int]float64)
+	for pair, weight := range weights {
+		cu, cv := communities[pair[0]], communities[pair[1]]
+		total += weight
+		degrees[cu] += weight
+		degrees[cv] += weight
+		switch {
+		case cu == cv:
+			internal[cu] += weight
+			metrics.IntraCommunityEdges++
+		case cu < len(clusters) && cv < len(clusters):
+			metrics.InterCommunityEdges++
+		}
+	}
+	if total == 0 {
+		return metrics
+	}
+	for community, degree := range degrees {
+		share := degree / (2 * total)
+		metrics.Modularity += internal[community]/total - share*share
+	}
+	return metrics
+}
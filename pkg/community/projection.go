@@ -8,103 +8,6 @@ import (
 	"github.com/soundprediction/go-predicato/pkg/types"
 )
 
-// labelPropagation implements the label propagation community detection algorithm
-func (b *Builder) labelPropagation(projection map[string][]types.Neighbor) [][]string {
-	if len(projection) == 0 {
-		return nil
-	}
-
-	// Initialize each node to its own community
-	communityMap := make(map[string]int)
-	nodeIndex := 0
-	for uuid := range projection {
-		communityMap[uuid] = nodeIndex
-		nodeIndex++
-	}
-
-	maxIterations := 100 // Prevent infinite loops
-	for iteration := 0; iteration < maxIterations; iteration++ {
-		noChange := true
-		newCommunityMap := make(map[string]int)
-
-		for uuid, neighbors := range projection {
-			currentCommunity := communityMap[uuid]
-
-			// Count community occurrences among neighbors, weighted by edge count
-			communityCandidates := make(map[int]int)
-			for _, neighbor := range neighbors {
-				if neighborCommunity, exists := communityMap[neighbor.NodeUUID]; exists {
-					communityCandidates[neighborCommunity] += neighbor.EdgeCount
-				}
-			}
-
-			// Find the community with highest weighted count
-			newCommunity := currentCommunity
-
-			// Convert to slice for sorting
-			type communityScore struct {
-				community int
-				count     int
-			}
-
-			var scores []communityScore
-			for community, count := range communityCandidates {
-				scores = append(scores, communityScore{community: community, count: count})
-			}
-
-			// Sort by count (descending), then by community ID for tie-breaking
-			for i := 0; i < len(scores); i++ {
-				for j := i + 1; j < len(scores); j++ {
-					if scores[j].count > scores[i].count ||
-						(scores[j].count == scores[i].count && scores[j].community > scores[i].community) {
-						scores[i], scores[j] = scores[j], scores[i]
-					}
-				}
-			}
-
-			if len(scores) > 0 {
-				topScore := scores[0]
-				if topScore.count > 1 { // Only change if there's significant support
-					newCommunity = topScore.community
-				} else {
-					// Keep the maximum of current and candidate
-					if topScore.community > currentCommunity {
-						newCommunity = topScore.community
-					}
-				}
-			}
-
-			newCommunityMap[uuid] = newCommunity
-
-			if newCommunity != currentCommunity {
-				noChange = false
-			}
-		}
-
-		if noChange {
-			break
-		}
-
-		communityMap = newCommunityMap
-	}
-
-	// Group nodes by community
-	communityClusterMap := make(map[int][]string)
-	for uuid, community := range communityMap {
-		communityClusterMap[community] = append(communityClusterMap[community], uuid)
-	}
-
-	// Convert to slice of clusters
-	var clusters [][]string
-	for _, cluster := range communityClusterMap {
-		if len(cluster) > 1 { // Only include clusters with more than one node
-			clusters = append(clusters, cluster)
-		}
-	}
-
-	return clusters
-}
-
 // buildProjection builds the neighbor projection for community detection
 func (b *Builder) buildProjection(ctx context.Context, nodes []*types.Node, groupID string) (map[string][]types.Neighbor, error) {
 	projection := make(map[string][]types.Neighbor)
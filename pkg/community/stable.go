@@ -0,0 +1,143 @@
+package community
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils"
+)
+
+const (
+	// StableCommunityMinOverlap is the Jaccard overlap of members above which
+	// a new community takes over the UUID and name of a current community.
+	StableCommunityMinOverlap = 0.5
+	// StableCommunityMinNameOverlap is the lower overlap sufficing when the
+	// names of the communities are similar.
+	StableCommunityMinNameOverlap = 0.25
+	// StableCommunityNameSimilarity is the cosine similarity of the name
+	// embeddings above which names are similar.
+	StableCommunityNameSimilarity = 0.85
+)
+
+// communityMatch is a candidate match of a new community to a current one.
+type communityMatch struct {
+	built      *types.Node
+	current    *types.Node
+	overlap    float64
+	similarity float64
+}
+
+// stabilizeCommunities matches the communities built for a group to its
+// current communities by member overlap and name embedding similarity, so
+// that communities keep their UUID and name across builds. Each matched
+// community takes over the UUID, name, name embedding and creation time of
+// the current community, and its edges are moved to the UUID; its summary is
+// kept.
+func (b *Builder) stabilizeCommunities(ctx context.Context, groupID string, communities []*types.Node, edges []*types.Edge) error {
+	currentMembers, err := driver.CommunityMembers(ctx, b.driver, groupID, nil)
+	if errors.Is(err, driver.ErrCommunityMembersUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(currentMembers) == 0 {
+		return nil
+	}
+
+	currentUUIDs := make([]string, 0, len(currentMembers))
+	for uuid := range currentMembers {
+		currentUUIDs = append(currentUUIDs, uuid)
+	}
+	sort.Strings(currentUUIDs)
+	current, err := b.driver.GetNodes(ctx, currentUUIDs, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to get current communities: %w", err)
+	}
+
+	builtMembers := make(map[string][]string)
+	for _, edge := range edges {
+		builtMembers[edge.SourceID] = append(builtMembers[edge.SourceID], edge.TargetID)
+	}
+
+	var candidates []communityMatch
+	for _, built := range communities {
+		if built.GroupID != groupID {
+			continue
+		}
+		for _, node := range current {
+			match := communityMatch{
+				built:      built,
+				current:    node,
+				overlap:    jaccard(builtMembers[built.Uuid], currentMembers[node.Uuid]),
+				similarity: utils.CalculateCosineSimilarity(built.Embedding, node.Embedding),
+			}
+			if match.overlap >= StableCommunityMinOverlap ||
+				(match.overlap >= StableCommunityMinNameOverlap && match.similarity >= StableCommunityNameSimilarity) {
+				candidates = append(candidates, match)
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].overlap != candidates[j].overlap {
+			return candidates[i].overlap > candidates[j].overlap
+		}
+		return candidates[i].similarity > candidates[j].similarity
+	})
+
+	// Match greedily, best candidates first, each community at most once
+	matched := make(map[*types.Node]*types.Node)
+	taken := make(map[string]bool)
+	for _, match := range candidates {
+		if matched[match.built] != nil || taken[match.current.Uuid] {
+			continue
+		}
+		matched[match.built] = match.current
+		taken[match.current.Uuid] = true
+	}
+
+	renamed := make(map[string]string)
+	for built, node := range matched {
+		renamed[built.Uuid] = node.Uuid
+		built.Uuid = node.Uuid
+		built.Name = node.Name
+		built.CreatedAt = node.CreatedAt
+		if len(node.Embedding) > 0 {
+			built.Embedding = node.Embedding
+		}
+	}
+	for _, edge := range edges {
+		if uuid, ok := renamed[edge.SourceID]; ok {
+			edge.SourceID = uuid
+			edge.SourceIDs = []string{uuid}
+		}
+	}
+	return nil
+}
+
+// jaccard returns the Jaccard index of two sets of UUIDs.
+func jaccard(a, b []string) float64 {
+	setA := make(map[string]bool, len(a))
+	for _, uuid := range a {
+		setA[uuid] = true
+	}
+	setB := make(map[string]bool, len(b))
+	intersection := 0
+	for _, uuid := range b {
+		if !setB[uuid] {
+			setB[uuid] = true
+			if setA[uuid] {
+				intersection++
+			}
+		}
+	}
+	union := len(setA) + len(setB) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
@@ -0,0 +1,154 @@
+package community
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// DefaultSummaryChunkTokens is the estimated size of the member summaries
+// and facts summarized in one prompt when no prompt limit is set.
+const DefaultSummaryChunkTokens = 4000
+
+// summaryPromptOverheadTokens is the estimated size of the instructions of
+// the summarization prompt.
+const summaryPromptOverheadTokens = 200
+
+// communityFactsPerMember is the number of facts of each member summarized.
+const communityFactsPerMember = 5
+
+// summarizeCommunity summarizes a cluster of entities with map-reduce: the
+// summaries and facts of the members are split into chunks fitting the
+// prompt budget, each chunk is summarized, and the partial summaries are
+// summarized again the same way until a single summary remains.
+func (b *Builder) summarizeCommunity(ctx context.Context, cluster []*types.Node) (string, error) {
+	items, err := b.communityItems(ctx, cluster)
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", fmt.Errorf("no summaries to process")
+	}
+
+	budget := DefaultSummaryChunkTokens
+	if b.maxPromptTokens > 0 {
+		budget = max(b.maxPromptTokens-summaryPromptOverheadTokens, 1)
+	}
+	for {
+		chunks := chunkItems(items, budget)
+		if len(chunks) == 1 {
+			return b.summarizeChunk(ctx, chunks[0])
+		}
+		if items, err = b.summarizeChunks(ctx, chunks); err != nil {
+			return "", err
+		}
+	}
+}
+
+// communityItems returns the summaries of the members of cluster and their
+// most recent facts, one per line.
+func (b *Builder) communityItems(ctx context.Context, cluster []*types.Node) ([]string, error) {
+	var items []string
+	uuids := make([]string, len(cluster))
+	for i, node := range cluster {
+		uuids[i] = node.Uuid
+		if node.Summary != "" {
+			items = append(items, fmt.Sprintf("%s: %s", node.Name, node.Summary))
+		} else {
+			items = append(items, node.Name)
+		}
+	}
+
+	facts, err := driver.EntityFacts(ctx, b.driver, cluster[0].GroupID, uuids, communityFactsPerMember)
+	if err != nil && !errors.Is(err, driver.ErrEntityFactsUnsupported) {
+		return nil, fmt.Errorf("failed to get community facts: %w", err)
+	}
+	// Facts between members are listed for both
+	seen := make(map[string]bool)
+	for _, uuid := range uuids {
+		for _, fact := range facts[uuid] {
+			if !seen[fact.Fact] {
+				seen[fact.Fact] = true
+				items = append(items, "Fact: "+fact.Fact)
+			}
+		}
+	}
+	return items, nil
+}
+
+// chunkItems splits items into chunks of at most budget estimated tokens.
+// Chunks hold at least two items, so that each round of summarization at
+// least halves the items.
+func chunkItems(items []string, budget int) [][]string {
+	var chunks [][]string
+	var chunk []string
+	tokens := 0
+	for _, item := range items {
+		itemTokens := llm.GetTokenCount(item)
+		if len(chunk) >= 2 && tokens+itemTokens > budget {
+			chunks = append(chunks, chunk)
+			chunk, tokens = nil, 0
+		}
+		chunk = append(chunk, item)
+		tokens += itemTokens
+	}
+	if len(chunk) == 1 && len(chunks) > 0 {
+		// Keep the last item with the previous chunk
+		chunks[len(chunks)-1] = append(chunks[len(chunks)-1], chunk[0])
+	} else if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// summarizeChunks summarizes chunks concurrently, returning their summaries
+// in order.
+func (b *Builder) summarizeChunks(ctx context.Context, chunks [][]string) ([]string, error) {
+	semaphore := make(chan struct{}, MaxCommunityBuildConcurrency)
+	summaries := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			summaries[i], errs[i] = b.summarizeChunk(ctx, chunk)
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("failed to summarize community chunks: %w", err)
+	}
+	return summaries, nil
+}
+
+// summarizeChunk summarizes a chunk of summaries and facts into one summary.
+func (b *Builder) summarizeChunk(ctx context.Context, chunk []string) (string, error) {
+	messages := []types.Message{
+		b.systemMessage(`You are an expert at synthesizing information. Given summaries of the entities of a community and facts about them, create a single comprehensive summary of the community that captures the key information. The summary should be concise (under 250 words) and maintain the most important details.`),
+		{
+			Role: llm.RoleUser,
+			Content: fmt.Sprintf(`Please summarize these summaries and facts into one comprehensive summary:
+
+%s
+
+Provide a single summary that captures the essential information from all of them:`, strings.Join(chunk, "\n")),
+		},
+	}
+
+	response, err := b.llm.Chat(usage.WithOperation(ctx, usage.OperationBuildCommunities), messages)
+	if err != nil {
+		return "", fmt.Errorf("failed to get LLM response for community summarization: %w", err)
+	}
+	return strings.TrimSpace(response.Content), nil
+}
@@ -23,7 +23,7 @@ type UpdateCommunityResult struct {
 // DetermineEntityCommunity determines which community an entity belongs to
 func (b *Builder) DetermineEntityCommunity(ctx context.Context, entity *types.Node) (*DetermineEntityCommunityResult, error) {
 	// First check if the entity is already part of a community
-	existingCommunity, err := b.getExistingCommunity(ctx, entity.Uuid)
+	existingCommunity, err := b.getExistingCommunity(ctx, entity.Uuid, entity.GroupID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check existing community: %w", err)
 	}
@@ -36,7 +36,7 @@ func (b *Builder) DetermineEntityCommunity(ctx context.Context, entity *types.No
 	}
 
 	// Find the most common community among connected entities
-	modalCommunity, err := b.findModalCommunity(ctx, entity.Uuid)
+	modalCommunity, err := b.findModalCommunity(ctx, entity.Uuid, entity.GroupID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find modal community: %w", err)
 	}
@@ -129,11 +129,11 @@ func (b *Builder) UpdateCommunity(ctx context.Context, entity *types.Node) (*Upd
 }
 
 // getExistingCommunity checks if an entity is already part of a community
-func (b *Builder) getExistingCommunity(ctx context.Context, entityUUID string) (*types.Node, error) {
-	return b.driver.GetExistingCommunity(ctx, entityUUID)
+func (b *Builder) getExistingCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
+	return b.driver.GetExistingCommunity(ctx, entityUUID, groupID)
 }
 
 // findModalCommunity finds the most common community among connected entities
-func (b *Builder) findModalCommunity(ctx context.Context, entityUUID string) (*types.Node, error) {
-	return b.driver.FindModalCommunity(ctx, entityUUID)
+func (b *Builder) findModalCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
+	return b.driver.FindModalCommunity(ctx, entityUUID, groupID)
 }
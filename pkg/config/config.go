@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -32,6 +33,25 @@ type Config struct {
 
 	// CircuitBreaker configuration
 	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+
+	// Retention configuration
+	Retention RetentionConfig `mapstructure:"retention"`
+}
+
+// RetentionConfig holds the retention policies applied by the prune command
+type RetentionConfig struct {
+	// Default applies to the groups without a policy in Groups
+	Default RetentionPolicyConfig            `mapstructure:"default"`
+	Groups  map[string]RetentionPolicyConfig `mapstructure:"groups"`
+}
+
+// RetentionPolicyConfig holds the retention policy of a group. Zero values
+// disable the corresponding step.
+type RetentionPolicyConfig struct {
+	EpisodeTTL             time.Duration `mapstructure:"episode_ttl"`
+	RemoveOrphanedEntities bool          `mapstructure:"remove_orphaned_entities"`
+	EdgeWindow             time.Duration `mapstructure:"edge_window"`
+	MinConfidence          float64       `mapstructure:"min_confidence"`
 }
 
 // AlertConfig holds configuration for alerting
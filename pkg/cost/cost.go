@@ -102,4 +102,8 @@ func (c *CostCalculator) loadDefaults() {
 
 	// DeepSeek
 	c.prices["deepseek-ai/deepseek-v3"] = PricingModel{InputPrice: 1.25, OutputPrice: 1.25}
+
+	// Groq Pricing - https://groq.com/pricing
+	c.prices["llama-3.3-70b-versatile"] = PricingModel{InputPrice: 0.59, OutputPrice: 0.79}
+	c.prices["llama-3.1-8b-instant"] = PricingModel{InputPrice: 0.05, OutputPrice: 0.08}
 }
@@ -51,7 +51,7 @@ func (c *EmbeddingRerankerClient) Rank(ctx context.Context, query string, passag
 	}
 
 	// Get query embedding
-	queryEmbedding, err := c.embedder.EmbedSingle(ctx, query)
+	queryEmbedding, err := c.embedder.EmbedQuery(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create query embedding: %w", err)
 	}
@@ -63,7 +63,7 @@ func (c *EmbeddingRerankerClient) Rank(ctx context.Context, query string, passag
 	// Get embeddings for all passages
 	passageEmbeddings := make([][]float32, len(passages))
 	for i, passage := range passages {
-		embedding, err := c.embedder.EmbedSingle(ctx, passage)
+		embedding, err := c.embedder.EmbedDocument(ctx, passage)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create embedding for passage %d: %w", i, err)
 		}
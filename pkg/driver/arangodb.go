@@ -0,0 +1,1571 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// ArangoDB collection names. Nodes are stored in document collections named after
+// their label and relationships in edge collections named after their type, so
+// RELATES_TO edges connect Entity documents directly (there is no intermediate
+// RelatesToNode_ as in the Ladybug schema).
+const (
+	arangoEntityCollection    = "Entity"
+	arangoEpisodicCollection  = "Episodic"
+	arangoCommunityCollection = "Community"
+	arangoRelatesToCollection = "RELATES_TO"
+	arangoMentionsCollection  = "MENTIONS"
+	arangoHasMemberCollection = "HAS_MEMBER"
+)
+
+var (
+	arangoNodeCollections = []string{arangoEntityCollection, arangoEpisodicCollection, arangoCommunityCollection}
+	arangoEdgeCollections = []string{arangoRelatesToCollection, arangoMentionsCollection, arangoHasMemberCollection}
+)
+
+// arangoErrDuplicateName is the ArangoDB error number for an object that already exists.
+const arangoErrDuplicateName = 1207
+
+// ArangoError is an error returned by the ArangoDB HTTP API.
+type ArangoError struct {
+	Code     int    `json:"code"`
+	ErrorNum int    `json:"errorNum"`
+	Message  string `json:"errorMessage"`
+}
+
+func (e *ArangoError) Error() string {
+	return fmt.Sprintf("arangodb error %d (HTTP %d): %s", e.ErrorNum, e.Code, e.Message)
+}
+
+// isArangoError reports whether err is an ArangoError with the given error number.
+func isArangoError(err error, errorNum int) bool {
+	var arangoErr *ArangoError
+	return errors.As(err, &arangoErr) && arangoErr.ErrorNum == errorNum
+}
+
+// ArangoDBDriverConfig holds configuration options for ArangoDBDriver
+type ArangoDBDriverConfig struct {
+	// Server endpoint (defaults to "http://localhost:8529")
+	Endpoint string
+
+	// Database name (defaults to "_system")
+	Database string
+
+	// Credentials for HTTP basic authentication (optional)
+	Username string
+	Password string
+
+	// ArangoSearch view used for BM25 text search (defaults to "predicato_search")
+	SearchView string
+
+	// Analyzer applied to the text fields in the search view (defaults to "text_en")
+	Analyzer string
+
+	// Number of results fetched per cursor round trip (defaults to 1000)
+	BatchSize int
+
+	// Timeout for each HTTP request (defaults to 1 minute)
+	RequestTimeout time.Duration
+
+	// HTTPClient overrides the HTTP client used to talk to the server (optional)
+	HTTPClient *http.Client
+}
+
+// DefaultArangoDBDriverConfig returns an ArangoDBDriverConfig with sensible defaults
+func DefaultArangoDBDriverConfig() *ArangoDBDriverConfig {
+	return &ArangoDBDriverConfig{
+		Endpoint:       "http://localhost:8529",
+		Database:       "_system",
+		SearchView:     "predicato_search",
+		Analyzer:       "text_en",
+		BatchSize:      1000,
+		RequestTimeout: 1 * time.Minute,
+	}
+}
+
+// ArangoDBDriver implements the GraphDriver interface for ArangoDB using AQL over
+// the HTTP API. Text search uses an ArangoSearch view ranked with BM25 and
+// embedding search uses the AQL vector similarity functions.
+//
+// ExecuteQuery accepts AQL rather than Cypher; kwargs are passed as bind parameters.
+type ArangoDBDriver struct {
+	config *ArangoDBDriverConfig
+	client *http.Client
+}
+
+// NewArangoDBDriver creates a new ArangoDB driver instance.
+func NewArangoDBDriver(endpoint, username, password, database string) (*ArangoDBDriver, error) {
+	config := DefaultArangoDBDriverConfig()
+	if endpoint != "" {
+		config.Endpoint = endpoint
+	}
+	if database != "" {
+		config.Database = database
+	}
+	config.Username = username
+	config.Password = password
+	return NewArangoDBDriverWithConfig(config)
+}
+
+// NewArangoDBDriverWithConfig creates a new ArangoDB driver instance with the given
+// configuration. Connections are established lazily; call HealthCheck to verify
+// the server is reachable and CreateIndices to create the collections.
+func NewArangoDBDriverWithConfig(config *ArangoDBDriverConfig) (*ArangoDBDriver, error) {
+	if config == nil {
+		config = DefaultArangoDBDriverConfig()
+	}
+
+	cfg := *config
+	defaults := DefaultArangoDBDriverConfig()
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = defaults.Endpoint
+	}
+	if cfg.Database == "" {
+		cfg.Database = defaults.Database
+	}
+	if cfg.SearchView == "" {
+		cfg.SearchView = defaults.SearchView
+	}
+	if cfg.Analyzer == "" {
+		cfg.Analyzer = defaults.Analyzer
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.RequestTimeout <= 0 {
+		cfg.RequestTimeout = defaults.RequestTimeout
+	}
+
+	if _, err := url.Parse(cfg.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid arangodb endpoint: %w", err)
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: cfg.RequestTimeout}
+	}
+
+	return &ArangoDBDriver{
+		config: &cfg,
+		client: client,
+	}, nil
+}
+
+// arangoCursorResponse is the response of the cursor API.
+type arangoCursorResponse struct {
+	Result  []json.RawMessage      `json:"result"`
+	HasMore bool                   `json:"hasMore"`
+	ID      string                 `json:"id"`
+	Extra   map[string]interface{} `json:"extra"`
+}
+
+// request sends an HTTP request to the given database and decodes the JSON response into out.
+func (a *ArangoDBDriver) request(ctx context.Context, database, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	endpoint := fmt.Sprintf("%s/_db/%s%s", a.config.Endpoint, url.PathEscape(database), path)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if a.config.Username != "" {
+		req.SetBasicAuth(a.config.Username, a.config.Password)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("arangodb request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		arangoErr := &ArangoError{Code: resp.StatusCode}
+		if err := json.Unmarshal(data, arangoErr); err != nil || arangoErr.Message == "" {
+			arangoErr.Message = strings.TrimSpace(string(data))
+		}
+		return arangoErr
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// query runs an AQL query and returns all result rows, following the cursor
+// until it is exhausted.
+func (a *ArangoDBDriver) query(ctx context.Context, aql string, bindVars map[string]interface{}) ([]json.RawMessage, map[string]interface{}, error) {
+	if bindVars == nil {
+		bindVars = map[string]interface{}{}
+	}
+
+	var cursor arangoCursorResponse
+	err := a.request(ctx, a.config.Database, http.MethodPost, "/_api/cursor", map[string]interface{}{
+		"query":     aql,
+		"bindVars":  bindVars,
+		"batchSize": a.config.BatchSize,
+	}, &cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := cursor.Result
+	for cursor.HasMore {
+		id := cursor.ID
+		next := arangoCursorResponse{}
+		if err := a.request(ctx, a.config.Database, http.MethodPut, "/_api/cursor/"+url.PathEscape(id), nil, &next); err != nil {
+			// Release the server-side cursor; it would otherwise live until its TTL expires
+			_ = a.request(context.Background(), a.config.Database, http.MethodDelete, "/_api/cursor/"+url.PathEscape(id), nil, nil)
+			return nil, nil, err
+		}
+		rows = append(rows, next.Result...)
+		cursor = next
+	}
+
+	return rows, cursor.Extra, nil
+}
+
+// ExecuteQuery executes an AQL query with kwargs as bind parameters. Rows are
+// returned as []map[string]interface{}; rows that are not objects are returned
+// under the key "value". The summary is the "extra" section of the cursor
+// response (statistics and warnings); keys are not available and returned as nil.
+func (a *ArangoDBDriver) ExecuteQuery(aqlQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	return a.executeQuery(context.Background(), aqlQuery, kwargs)
+}
+
+func (a *ArangoDBDriver) executeQuery(ctx context.Context, aqlQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	rows, extra, err := a.query(ctx, aqlQuery, kwargs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	records := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		var value interface{}
+		if err := json.Unmarshal(row, &value); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to decode result row: %w", err)
+		}
+		if record, ok := value.(map[string]interface{}); ok {
+			records = append(records, record)
+		} else {
+			records = append(records, map[string]interface{}{"value": value})
+		}
+	}
+
+	return records, extra, nil, nil
+}
+
+// Document mapping
+
+// arangoNodeDocument is the stored form of a node; the uuid doubles as the document key.
+type arangoNodeDocument struct {
+	types.Node
+	Key string `json:"_key"`
+}
+
+// arangoEdgeDocument is the stored form of an edge.
+type arangoEdgeDocument struct {
+	types.EntityEdge
+	Key  string `json:"_key,omitempty"`
+	From string `json:"_from"`
+	To   string `json:"_to"`
+}
+
+// arangoNodeCollection returns the collection that stores nodes of the given type.
+func arangoNodeCollection(nodeType types.NodeType) string {
+	switch nodeType {
+	case types.EpisodicNodeType:
+		return arangoEpisodicCollection
+	case types.CommunityNodeType:
+		return arangoCommunityCollection
+	default:
+		return arangoEntityCollection
+	}
+}
+
+// arangoEdgeCollection returns the edge collection and the source and target node
+// collections for edges of the given type.
+func arangoEdgeCollection(edgeType types.EdgeType) (collection, from, to string) {
+	switch edgeType {
+	case types.EpisodicEdgeType:
+		return arangoMentionsCollection, arangoEpisodicCollection, arangoEntityCollection
+	case types.CommunityEdgeType:
+		return arangoHasMemberCollection, arangoCommunityCollection, arangoEntityCollection
+	default:
+		return arangoRelatesToCollection, arangoEntityCollection, arangoEntityCollection
+	}
+}
+
+// arangoNodeIDs returns the candidate document IDs of a node in every node collection.
+func arangoNodeIDs(uuids ...string) []string {
+	ids := make([]string, 0, len(uuids)*len(arangoNodeCollections))
+	for _, uuid := range uuids {
+		for _, collection := range arangoNodeCollections {
+			ids = append(ids, collection+"/"+uuid)
+		}
+	}
+	return ids
+}
+
+// arangoEdgeIDs returns the candidate document IDs of an edge in every edge collection.
+func arangoEdgeIDs(uuids ...string) []string {
+	ids := make([]string, 0, len(uuids)*len(arangoEdgeCollections))
+	for _, uuid := range uuids {
+		for _, collection := range arangoEdgeCollections {
+			ids = append(ids, collection+"/"+uuid)
+		}
+	}
+	return ids
+}
+
+// arangoKeyFromID returns the document key of a document ID ("collection/key").
+func arangoKeyFromID(id string) string {
+	if i := strings.IndexByte(id, '/'); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
+
+// toArangoNodeDocument converts a node to its stored form.
+func toArangoNodeDocument(node *types.Node) arangoNodeDocument {
+	return arangoNodeDocument{Node: utcNode(node), Key: node.Uuid}
+}
+
+// toArangoEdgeDocument converts an edge to its stored form.
+func toArangoEdgeDocument(edge *types.Edge) (collection string, doc arangoEdgeDocument) {
+	collection, from, to := arangoEdgeCollection(edge.Type)
+	sourceID, targetID := edgeEndpoints(edge)
+
+	doc = arangoEdgeDocument{
+		EntityEdge: utcEdge(edge),
+		Key:        edge.Uuid,
+		From:       from + "/" + sourceID,
+		To:         to + "/" + targetID,
+	}
+	doc.SourceNodeID, doc.SourceID = sourceID, sourceID
+	doc.TargetNodeID, doc.TargetID = targetID, targetID
+	return collection, doc
+}
+
+// decodeArangoNodes decodes node documents.
+func decodeArangoNodes(rows []json.RawMessage) ([]*types.Node, error) {
+	nodes := make([]*types.Node, 0, len(rows))
+	for _, row := range rows {
+		if string(row) == "null" {
+			continue
+		}
+		var doc arangoNodeDocument
+		if err := json.Unmarshal(row, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decode node: %w", err)
+		}
+		node := doc.Node
+		if node.Uuid == "" {
+			node.Uuid = doc.Key
+		}
+		nodes = append(nodes, &node)
+	}
+	return nodes, nil
+}
+
+// decodeArangoEdges decodes edge documents, filling in the endpoints from _from and _to.
+func decodeArangoEdges(rows []json.RawMessage) ([]*types.Edge, error) {
+	edges := make([]*types.Edge, 0, len(rows))
+	for _, row := range rows {
+		if string(row) == "null" {
+			continue
+		}
+		var doc arangoEdgeDocument
+		if err := json.Unmarshal(row, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decode edge: %w", err)
+		}
+		edge := doc.EntityEdge
+		if edge.Uuid == "" {
+			edge.Uuid = doc.Key
+		}
+		if edge.SourceNodeID == "" {
+			edge.SourceNodeID = arangoKeyFromID(doc.From)
+		}
+		if edge.TargetNodeID == "" {
+			edge.TargetNodeID = arangoKeyFromID(doc.To)
+		}
+		if edge.SourceID == "" {
+			edge.SourceID = edge.SourceNodeID
+		}
+		if edge.TargetID == "" {
+			edge.TargetID = edge.TargetNodeID
+		}
+		edges = append(edges, &edge)
+	}
+	return edges, nil
+}
+
+// Node operations
+
+// GetNode retrieves a node by ID.
+func (a *ArangoDBDriver) GetNode(ctx context.Context, nodeID, groupID string) (*types.Node, error) {
+	nodes, err := a.queryNodes(ctx, `
+		FOR n IN DOCUMENT(@ids)
+			FILTER n.group_id == @group_id
+			LIMIT 1
+			RETURN n
+	`, map[string]interface{}{
+		"ids":      arangoNodeIDs(nodeID),
+		"group_id": groupID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, ErrNodeNotFound
+	}
+	return nodes[0], nil
+}
+
+// UpsertNode creates or replaces a node.
+func (a *ArangoDBDriver) UpsertNode(ctx context.Context, node *types.Node) error {
+	if node == nil {
+		return fmt.Errorf("node cannot be nil")
+	}
+	if err := a.upsertDocuments(ctx, arangoNodeCollection(node.Type), []interface{}{toArangoNodeDocument(node)}); err != nil {
+		return fmt.Errorf("failed to upsert node: %w", err)
+	}
+	return nil
+}
+
+// DeleteNode deletes a node and all of its edges.
+func (a *ArangoDBDriver) DeleteNode(ctx context.Context, nodeID, groupID string) error {
+	var removed []string
+	for _, collection := range arangoNodeCollections {
+		rows, _, err := a.query(ctx, `
+			FOR n IN @@collection
+				FILTER n._key == @uuid AND n.group_id == @group_id
+				REMOVE n IN @@collection
+				RETURN OLD._id
+		`, map[string]interface{}{
+			"@collection": collection,
+			"uuid":        nodeID,
+			"group_id":    groupID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete node: %w", err)
+		}
+		for _, row := range rows {
+			var id string
+			if err := json.Unmarshal(row, &id); err == nil {
+				removed = append(removed, id)
+			}
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	for _, collection := range arangoEdgeCollections {
+		_, _, err := a.query(ctx, `
+			FOR e IN @@collection
+				FILTER e._from IN @ids OR e._to IN @ids
+				REMOVE e IN @@collection
+		`, map[string]interface{}{
+			"@collection": collection,
+			"ids":         removed,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete edges of node: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetNodes retrieves multiple nodes by their IDs.
+func (a *ArangoDBDriver) GetNodes(ctx context.Context, nodeIDs []string, groupID string) ([]*types.Node, error) {
+	if len(nodeIDs) == 0 {
+		return []*types.Node{}, nil
+	}
+	return a.queryNodes(ctx, `
+		FOR n IN DOCUMENT(@ids)
+			FILTER n.group_id == @group_id
+			RETURN n
+	`, map[string]interface{}{
+		"ids":      arangoNodeIDs(nodeIDs...),
+		"group_id": groupID,
+	})
+}
+
+// Edge operations
+
+// GetEdge retrieves an edge by ID.
+func (a *ArangoDBDriver) GetEdge(ctx context.Context, edgeID, groupID string) (*types.Edge, error) {
+	edges, err := a.queryEdges(ctx, `
+		FOR e IN DOCUMENT(@ids)
+			FILTER e.group_id == @group_id
+			LIMIT 1
+			RETURN e
+	`, map[string]interface{}{
+		"ids":      arangoEdgeIDs(edgeID),
+		"group_id": groupID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(edges) == 0 {
+		return nil, ErrEdgeNotFound
+	}
+	return edges[0], nil
+}
+
+// UpsertEdge creates or replaces an edge.
+func (a *ArangoDBDriver) UpsertEdge(ctx context.Context, edge *types.Edge) error {
+	if edge == nil {
+		return fmt.Errorf("edge cannot be nil")
+	}
+	collection, doc := toArangoEdgeDocument(edge)
+	if err := a.upsertDocuments(ctx, collection, []interface{}{doc}); err != nil {
+		return fmt.Errorf("failed to upsert edge: %w", err)
+	}
+	return nil
+}
+
+// UpsertEpisodicEdge creates a MENTIONS edge from an episode to an entity if it does not exist.
+func (a *ArangoDBDriver) UpsertEpisodicEdge(ctx context.Context, episodeUUID, entityUUID, groupID string) error {
+	_, _, err := a.query(ctx, `
+		UPSERT { _from: @from, _to: @to }
+		INSERT { _from: @from, _to: @to, group_id: @group_id, type: @type, created_at: @created_at }
+		UPDATE {}
+		IN MENTIONS
+	`, map[string]interface{}{
+		"from":       arangoEpisodicCollection + "/" + episodeUUID,
+		"to":         arangoEntityCollection + "/" + entityUUID,
+		"group_id":   groupID,
+		"type":       string(types.EpisodicEdgeType),
+		"created_at": time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert episodic edge: %w", err)
+	}
+	return nil
+}
+
+// UpsertCommunityEdge creates a HAS_MEMBER edge from a community to an entity or
+// community if it does not exist.
+func (a *ArangoDBDriver) UpsertCommunityEdge(ctx context.Context, communityUUID, nodeUUID, uuid, groupID string) error {
+	_, _, err := a.query(ctx, `
+		LET member = FIRST(
+			FOR n IN DOCUMENT([CONCAT("Entity/", @node_uuid), CONCAT("Community/", @node_uuid)])
+				FILTER n.group_id == @group_id
+				RETURN n._id
+		)
+		FILTER member != null
+		UPSERT { _key: @uuid }
+		INSERT { _key: @uuid, _from: @from, _to: member, uuid: @uuid, group_id: @group_id, type: @type, created_at: @created_at }
+		UPDATE {}
+		IN HAS_MEMBER
+	`, map[string]interface{}{
+		"from":       arangoCommunityCollection + "/" + communityUUID,
+		"node_uuid":  nodeUUID,
+		"uuid":       uuid,
+		"group_id":   groupID,
+		"type":       string(types.CommunityEdgeType),
+		"created_at": time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert community edge: %w", err)
+	}
+	return nil
+}
+
+// DeleteEdge deletes an edge by ID.
+func (a *ArangoDBDriver) DeleteEdge(ctx context.Context, edgeID, groupID string) error {
+	for _, collection := range arangoEdgeCollections {
+		_, _, err := a.query(ctx, `
+			FOR e IN @@collection
+				FILTER e._key == @uuid AND e.group_id == @group_id
+				REMOVE e IN @@collection
+		`, map[string]interface{}{
+			"@collection": collection,
+			"uuid":        edgeID,
+			"group_id":    groupID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete edge: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetEdges retrieves multiple edges by their IDs.
+func (a *ArangoDBDriver) GetEdges(ctx context.Context, edgeIDs []string, groupID string) ([]*types.Edge, error) {
+	if len(edgeIDs) == 0 {
+		return []*types.Edge{}, nil
+	}
+	return a.queryEdges(ctx, `
+		FOR e IN DOCUMENT(@ids)
+			FILTER e.group_id == @group_id
+			RETURN e
+	`, map[string]interface{}{
+		"ids":      arangoEdgeIDs(edgeIDs...),
+		"group_id": groupID,
+	})
+}
+
+// Graph traversal operations
+
+// GetNeighbors retrieves the nodes within maxDistance hops of a node.
+func (a *ArangoDBDriver) GetNeighbors(ctx context.Context, nodeID, groupID string, maxDistance int) ([]*types.Node, error) {
+	if maxDistance <= 0 {
+		maxDistance = 1
+	}
+	return a.queryNodes(ctx, `
+		FOR start IN DOCUMENT(@ids)
+			FILTER start.group_id == @group_id
+			FOR v IN 1..@depth ANY start RELATES_TO, MENTIONS, HAS_MEMBER
+				OPTIONS { order: "bfs", uniqueVertices: "global" }
+				FILTER v.group_id == @group_id AND v._id != start._id
+				RETURN DISTINCT v
+	`, map[string]interface{}{
+		"ids":      arangoNodeIDs(nodeID),
+		"group_id": groupID,
+		"depth":    maxDistance,
+	})
+}
+
+// GetRelatedNodes retrieves the direct neighbors of a node, optionally restricted to edge types.
+func (a *ArangoDBDriver) GetRelatedNodes(ctx context.Context, nodeID, groupID string, edgeTypes []types.EdgeType) ([]*types.Node, error) {
+	collections := arangoEdgeCollections
+	if len(edgeTypes) > 0 {
+		collections = nil
+		seen := make(map[string]bool)
+		for _, edgeType := range edgeTypes {
+			collection, _, _ := arangoEdgeCollection(edgeType)
+			if !seen[collection] {
+				seen[collection] = true
+				collections = append(collections, collection)
+			}
+		}
+	}
+
+	// Edge collections cannot be bound as parameters in a traversal, but they
+	// come from the fixed set above.
+	query := fmt.Sprintf(`
+		FOR start IN DOCUMENT(@ids)
+			FILTER start.group_id == @group_id
+			FOR v IN 1..1 ANY start %s
+				FILTER v.group_id == @group_id AND v._id != start._id
+				RETURN DISTINCT v
+	`, strings.Join(collections, ", "))
+
+	return a.queryNodes(ctx, query, map[string]interface{}{
+		"ids":      arangoNodeIDs(nodeID),
+		"group_id": groupID,
+	})
+}
+
+// GetNodeNeighbors returns the entities connected to an entity through RELATES_TO
+// edges together with the number of connecting edges.
+func (a *ArangoDBDriver) GetNodeNeighbors(ctx context.Context, nodeUUID, groupID string) ([]types.Neighbor, error) {
+	rows, _, err := a.query(ctx, `
+		FOR start IN DOCUMENT([@id])
+			FILTER start.group_id == @group_id
+			FOR v IN 1..1 ANY start RELATES_TO
+				FILTER v.group_id == @group_id
+				COLLECT uuid = v._key WITH COUNT INTO count
+				RETURN { uuid: uuid, count: count }
+	`, map[string]interface{}{
+		"id":       arangoEntityCollection + "/" + nodeUUID,
+		"group_id": groupID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute neighbor query: %w", err)
+	}
+
+	neighbors := make([]types.Neighbor, 0, len(rows))
+	for _, row := range rows {
+		var record struct {
+			UUID  string `json:"uuid"`
+			Count int    `json:"count"`
+		}
+		if err := json.Unmarshal(row, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode neighbor: %w", err)
+		}
+		neighbors = append(neighbors, types.Neighbor{NodeUUID: record.UUID, EdgeCount: record.Count})
+	}
+	return neighbors, nil
+}
+
+// GetBetweenNodes retrieves the RELATES_TO edges of groupID between two entities in either direction.
+func (a *ArangoDBDriver) GetBetweenNodes(ctx context.Context, sourceNodeID, targetNodeID, groupID string) ([]*types.Edge, error) {
+	edges, err := a.queryEdges(ctx, `
+		FOR e IN RELATES_TO
+			FILTER e.group_id == @group_id
+			FILTER (e._from == @source AND e._to == @target) OR (e._from == @target AND e._to == @source)
+			RETURN e
+	`, map[string]interface{}{
+		"source":   arangoEntityCollection + "/" + sourceNodeID,
+		"target":   arangoEntityCollection + "/" + targetNodeID,
+		"group_id": groupID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GetBetweenNodes query: %w", err)
+	}
+	return edges, nil
+}
+
+// Search operations
+
+// SearchNodesByEmbedding finds the entities whose name embedding is most similar to embedding.
+func (a *ArangoDBDriver) SearchNodesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int) ([]*types.Node, error) {
+	return a.SearchNodesByVector(ctx, embedding, groupID, &VectorSearchOptions{
+		Limit:     limit,
+		NodeTypes: []types.NodeType{types.EntityNodeType},
+	})
+}
+
+// SearchEdgesByEmbedding finds the RELATES_TO edges whose fact embedding is most similar to embedding.
+func (a *ArangoDBDriver) SearchEdgesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int) ([]*types.Edge, error) {
+	return a.SearchEdgesByVector(ctx, embedding, groupID, &VectorSearchOptions{
+		Limit:     limit,
+		EdgeTypes: []types.EdgeType{types.EntityEdgeType},
+	})
+}
+
+// SearchNodes performs BM25-ranked full-text search on nodes using the ArangoSearch view.
+func (a *ArangoDBDriver) SearchNodes(ctx context.Context, query, groupID string, options *SearchOptions) ([]*types.Node, error) {
+	if options == nil {
+		options = &SearchOptions{}
+	}
+	filter := options.filter(groupID)
+	limit := options.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	collections := arangoNodeCollections
+	if len(options.NodeTypes) > 0 {
+		collections = nil
+		for _, nodeType := range options.NodeTypes {
+			collections = append(collections, arangoNodeCollection(nodeType))
+		}
+	}
+
+	params := map[string]interface{}{
+		"@view":       a.config.SearchView,
+		"query":       query,
+		"analyzer":    a.config.Analyzer,
+		"group_ids":   filter.groupIDs,
+		"collections": collections,
+		"limit":       limit,
+	}
+	filters := filter.arangoNodeFilters(params)
+
+	nodes, err := a.queryNodes(ctx, fmt.Sprintf(`
+		FOR doc IN @@view
+			SEARCH doc.group_id IN @group_ids AND ANALYZER(
+				doc.name IN TOKENS(@query, @analyzer) OR
+				doc.summary IN TOKENS(@query, @analyzer) OR
+				doc.content IN TOKENS(@query, @analyzer),
+				@analyzer)
+			OPTIONS { collections: @collections }
+			%s
+			SORT BM25(doc) DESC
+			LIMIT @limit
+			RETURN doc
+	`, filters), params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nodes: %w", err)
+	}
+	return nodes, nil
+}
+
+// SearchEdges performs BM25-ranked full-text search on edges using the ArangoSearch view.
+func (a *ArangoDBDriver) SearchEdges(ctx context.Context, query, groupID string, options *SearchOptions) ([]*types.Edge, error) {
+	if options == nil {
+		options = &SearchOptions{}
+	}
+	filter := options.filter(groupID)
+	limit := options.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	collections := []string{arangoRelatesToCollection}
+	if len(options.EdgeTypes) > 0 {
+		collections = nil
+		for _, edgeType := range options.EdgeTypes {
+			collection, _, _ := arangoEdgeCollection(edgeType)
+			collections = append(collections, collection)
+		}
+	}
+
+	params := map[string]interface{}{
+		"@view":       a.config.SearchView,
+		"query":       query,
+		"analyzer":    a.config.Analyzer,
+		"group_ids":   filter.groupIDs,
+		"collections": collections,
+		"limit":       limit,
+	}
+	filters := filter.arangoEdgeFilters(params)
+
+	edges, err := a.queryEdges(ctx, fmt.Sprintf(`
+		FOR doc IN @@view
+			SEARCH doc.group_id IN @group_ids AND ANALYZER(
+				doc.name IN TOKENS(@query, @analyzer) OR
+				doc.fact IN TOKENS(@query, @analyzer),
+				@analyzer)
+			OPTIONS { collections: @collections }
+			%s
+			SORT BM25(doc) DESC
+			LIMIT @limit
+			RETURN doc
+	`, filters), params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search edges: %w", err)
+	}
+	return edges, nil
+}
+
+// SearchNodesByVector finds the nodes whose name embedding (or generic embedding)
+// is most similar to vector by cosine similarity.
+func (a *ArangoDBDriver) SearchNodesByVector(ctx context.Context, vector []float32, groupID string, options *VectorSearchOptions) ([]*types.Node, error) {
+	if options == nil {
+		options = &VectorSearchOptions{}
+	}
+
+	collections := arangoNodeCollections
+	if len(options.NodeTypes) > 0 {
+		collections = nil
+		for _, nodeType := range options.NodeTypes {
+			collections = append(collections, arangoNodeCollection(nodeType))
+		}
+	}
+
+	filter := options.filter(groupID)
+	rows, err := a.vectorSearch(ctx, collections, "NOT_NULL(doc.name_embedding, doc.embedding)", vector, filter.groupIDs, filter.arangoNodeFilters, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nodes by vector: %w", err)
+	}
+	return decodeArangoNodes(rows)
+}
+
+// SearchEdgesByVector finds the edges whose fact embedding (or generic embedding)
+// is most similar to vector by cosine similarity.
+func (a *ArangoDBDriver) SearchEdgesByVector(ctx context.Context, vector []float32, groupID string, options *VectorSearchOptions) ([]*types.Edge, error) {
+	if options == nil {
+		options = &VectorSearchOptions{}
+	}
+
+	collections := []string{arangoRelatesToCollection}
+	if len(options.EdgeTypes) > 0 {
+		collections = nil
+		for _, edgeType := range options.EdgeTypes {
+			collection, _, _ := arangoEdgeCollection(edgeType)
+			collections = append(collections, collection)
+		}
+	}
+
+	filter := options.filter(groupID)
+	rows, err := a.vectorSearch(ctx, collections, "NOT_NULL(doc.fact_embedding, doc.embedding)", vector, filter.groupIDs, filter.arangoEdgeFilters, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search edges by vector: %w", err)
+	}
+	return decodeArangoEdges(rows)
+}
+
+// vectorSearch ranks the documents of groupIDs in each collection by
+// COSINE_SIMILARITY to vector and returns the best matches across all
+// collections. filters adds the AQL FILTER statements of the search filters.
+func (a *ArangoDBDriver) vectorSearch(ctx context.Context, collections []string, embeddingExpr string, vector []float32, groupIDs []string, filters func(map[string]interface{}) string, options *VectorSearchOptions) ([]json.RawMessage, error) {
+	limit := options.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	type scored struct {
+		Doc   json.RawMessage `json:"doc"`
+		Score float64         `json:"score"`
+	}
+	var matches []scored
+
+	for _, collection := range collections {
+		params := map[string]interface{}{
+			"@collection": collection,
+			"vector":      vector,
+			"group_ids":   groupIDs,
+			"min_score":   options.MinScore,
+			"limit":       limit,
+		}
+
+		rows, _, err := a.query(ctx, fmt.Sprintf(`
+			FOR doc IN @@collection
+				FILTER doc.group_id IN @group_ids
+				%s
+				LET embedding = %s
+				FILTER IS_ARRAY(embedding) AND LENGTH(embedding) == LENGTH(@vector)
+				LET score = COSINE_SIMILARITY(embedding, @vector)
+				FILTER score >= @min_score
+				SORT score DESC
+				LIMIT @limit
+				RETURN { doc: doc, score: score }
+		`, filters(params), embeddingExpr), params)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			var match scored
+			if err := json.Unmarshal(row, &match); err != nil {
+				return nil, fmt.Errorf("failed to decode search result: %w", err)
+			}
+			matches = append(matches, match)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	docs := make([]json.RawMessage, len(matches))
+	for i, match := range matches {
+		docs[i] = match.Doc
+	}
+	return docs, nil
+}
+
+// Bulk operations
+
+// UpsertNodes creates or replaces multiple nodes, one query per collection.
+func (a *ArangoDBDriver) UpsertNodes(ctx context.Context, nodes []*types.Node) error {
+	byCollection := make(map[string][]interface{})
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		collection := arangoNodeCollection(node.Type)
+		byCollection[collection] = append(byCollection[collection], toArangoNodeDocument(node))
+	}
+
+	for _, collection := range arangoNodeCollections {
+		if docs := byCollection[collection]; len(docs) > 0 {
+			if err := a.upsertDocuments(ctx, collection, docs); err != nil {
+				return fmt.Errorf("failed to upsert nodes: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// UpsertEdges creates or replaces multiple edges, one query per collection.
+func (a *ArangoDBDriver) UpsertEdges(ctx context.Context, edges []*types.Edge) error {
+	byCollection := make(map[string][]interface{})
+	for _, edge := range edges {
+		if edge == nil {
+			continue
+		}
+		collection, doc := toArangoEdgeDocument(edge)
+		byCollection[collection] = append(byCollection[collection], doc)
+	}
+
+	for _, collection := range arangoEdgeCollections {
+		if docs := byCollection[collection]; len(docs) > 0 {
+			if err := a.upsertDocuments(ctx, collection, docs); err != nil {
+				return fmt.Errorf("failed to upsert edges: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// upsertDocuments inserts documents into a collection, replacing existing documents with the same key.
+func (a *ArangoDBDriver) upsertDocuments(ctx context.Context, collection string, docs []interface{}) error {
+	_, _, err := a.query(ctx, `
+		FOR doc IN @docs
+			INSERT doc INTO @@collection OPTIONS { overwriteMode: "replace" }
+	`, map[string]interface{}{
+		"@collection": collection,
+		"docs":        docs,
+	})
+	return err
+}
+
+// Temporal operations
+
+// GetNodesInTimeRange retrieves the nodes created within a time range.
+func (a *ArangoDBDriver) GetNodesInTimeRange(ctx context.Context, start, end time.Time, groupID string) ([]*types.Node, error) {
+	var nodes []*types.Node
+	for _, collection := range arangoNodeCollections {
+		found, err := a.queryNodes(ctx, `
+			FOR n IN @@collection
+				FILTER n.group_id == @group_id AND n.created_at >= @start AND n.created_at <= @end
+				SORT n.created_at
+				RETURN n
+		`, map[string]interface{}{
+			"@collection": collection,
+			"group_id":    groupID,
+			"start":       start.UTC(),
+			"end":         end.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, found...)
+	}
+	return nodes, nil
+}
+
+// GetEdgesInTimeRange retrieves the edges created within a time range.
+func (a *ArangoDBDriver) GetEdgesInTimeRange(ctx context.Context, start, end time.Time, groupID string) ([]*types.Edge, error) {
+	var edges []*types.Edge
+	for _, collection := range arangoEdgeCollections {
+		found, err := a.queryEdges(ctx, `
+			FOR e IN @@collection
+				FILTER e.group_id == @group_id AND e.created_at >= @start AND e.created_at <= @end
+				SORT e.created_at
+				RETURN e
+		`, map[string]interface{}{
+			"@collection": collection,
+			"group_id":    groupID,
+			"start":       start.UTC(),
+			"end":         end.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, found...)
+	}
+	return edges, nil
+}
+
+// RetrieveEpisodes retrieves the most recent episodes valid at referenceTime,
+// returned in chronological order (oldest first).
+func (a *ArangoDBDriver) RetrieveEpisodes(
+	ctx context.Context,
+	referenceTime time.Time,
+	groupIDs []string,
+	limit int,
+	episodeType *types.EpisodeType,
+) ([]*types.Node, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	params := map[string]interface{}{
+		"reference_time": referenceTime.UTC(),
+		"num_episodes":   limit,
+	}
+
+	queryFilter := ""
+	if len(groupIDs) > 0 {
+		queryFilter += "\nFILTER e.group_id IN @group_ids"
+		params["group_ids"] = groupIDs
+	}
+	if episodeType != nil {
+		queryFilter += "\nFILTER e.episode_type == @source"
+		params["source"] = string(*episodeType)
+	}
+
+	episodes, err := a.queryNodes(ctx, fmt.Sprintf(`
+		FOR e IN Episodic
+			FILTER e.valid_from <= @reference_time
+			%s
+			SORT e.valid_from DESC
+			LIMIT @num_episodes
+			RETURN e
+	`, queryFilter), params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve episodes: %w", err)
+	}
+
+	// Reverse to return in chronological order (oldest first)
+	types.ReverseNodes(episodes)
+
+	return episodes, nil
+}
+
+// Community operations
+
+// GetCommunities retrieves the communities of a group at the given level.
+func (a *ArangoDBDriver) GetCommunities(ctx context.Context, groupID string, level int) ([]*types.Node, error) {
+	return a.queryNodes(ctx, `
+		FOR c IN Community
+			FILTER c.group_id == @group_id AND TO_NUMBER(c.level) == @level
+			RETURN c
+	`, map[string]interface{}{
+		"group_id": groupID,
+		"level":    level,
+	})
+}
+
+// BuildCommunities is a no-op; use community.Builder (pkg/community) for
+// LLM-powered community building.
+func (a *ArangoDBDriver) BuildCommunities(ctx context.Context, groupID string) error {
+	return nil
+}
+
+// GetExistingCommunity returns the community an entity belongs to, or nil.
+func (a *ArangoDBDriver) GetExistingCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
+	nodes, err := a.queryNodes(ctx, `
+		FOR c IN 1..1 INBOUND @entity HAS_MEMBER
+			LIMIT 1
+			RETURN c
+	`, map[string]interface{}{
+		"entity": arangoEntityCollection + "/" + entityUUID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query existing community: %w", err)
+	}
+	if len(nodes) > 0 {
+		return nodes[0], nil
+	}
+	return nil, nil
+}
+
+// FindModalCommunity returns the community most common among an entity's neighbors, or nil.
+func (a *ArangoDBDriver) FindModalCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
+	nodes, err := a.queryNodes(ctx, `
+		FOR m IN 1..1 ANY @entity RELATES_TO
+			FOR c IN 1..1 INBOUND m HAS_MEMBER
+				COLLECT community = c WITH COUNT INTO count
+				SORT count DESC
+				LIMIT 1
+				RETURN community
+	`, map[string]interface{}{
+		"entity": arangoEntityCollection + "/" + entityUUID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query modal community: %w", err)
+	}
+	if len(nodes) > 0 {
+		return nodes[0], nil
+	}
+	return nil, nil
+}
+
+// RemoveCommunities deletes all communities and their membership edges.
+func (a *ArangoDBDriver) RemoveCommunities(ctx context.Context) error {
+	if _, _, err := a.query(ctx, "FOR e IN HAS_MEMBER REMOVE e IN HAS_MEMBER", nil); err != nil {
+		return fmt.Errorf("failed to remove communities: %w", err)
+	}
+	if _, _, err := a.query(ctx, "FOR c IN Community REMOVE c IN Community", nil); err != nil {
+		return fmt.Errorf("failed to remove communities: %w", err)
+	}
+	return nil
+}
+
+// Database maintenance
+
+// CreateIndices creates the database, collections, persistent indexes, and the
+// ArangoSearch view used for BM25 search. It is idempotent.
+func (a *ArangoDBDriver) CreateIndices(ctx context.Context) error {
+	if a.config.Database != "_system" {
+		err := a.request(ctx, "_system", http.MethodPost, "/_api/database", map[string]interface{}{
+			"name": a.config.Database,
+		}, nil)
+		if err != nil && !isArangoError(err, arangoErrDuplicateName) {
+			return fmt.Errorf("failed to create database %s: %w", a.config.Database, err)
+		}
+	}
+
+	// Collection type 2 is a document collection, 3 an edge collection
+	collections := make(map[string]int)
+	for _, name := range arangoNodeCollections {
+		collections[name] = 2
+	}
+	for _, name := range arangoEdgeCollections {
+		collections[name] = 3
+	}
+	for _, name := range append(append([]string{}, arangoNodeCollections...), arangoEdgeCollections...) {
+		err := a.request(ctx, a.config.Database, http.MethodPost, "/_api/collection", map[string]interface{}{
+			"name": name,
+			"type": collections[name],
+		}, nil)
+		if err != nil && !isArangoError(err, arangoErrDuplicateName) {
+			return fmt.Errorf("failed to create collection %s: %w", name, err)
+		}
+	}
+
+	// Creating an index that already exists returns the existing index
+	indexes := [][]string{
+		{"group_id"},
+		{"group_id", "created_at"},
+	}
+	for _, name := range append(append([]string{}, arangoNodeCollections...), arangoEdgeCollections...) {
+		for _, fields := range indexes {
+			err := a.request(ctx, a.config.Database, http.MethodPost, "/_api/index?collection="+url.QueryEscape(name), map[string]interface{}{
+				"type":   "persistent",
+				"fields": fields,
+			}, nil)
+			if err != nil {
+				return fmt.Errorf("failed to create index on %s: %w", name, err)
+			}
+		}
+	}
+	err := a.request(ctx, a.config.Database, http.MethodPost, "/_api/index?collection="+arangoEpisodicCollection, map[string]interface{}{
+		"type":   "persistent",
+		"fields": []string{"valid_from"},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create index on %s: %w", arangoEpisodicCollection, err)
+	}
+
+	err = a.request(ctx, a.config.Database, http.MethodPost, "/_api/view", a.searchViewDefinition(), nil)
+	if err != nil && !isArangoError(err, arangoErrDuplicateName) {
+		return fmt.Errorf("failed to create search view %s: %w", a.config.SearchView, err)
+	}
+
+	return nil
+}
+
+// searchViewDefinition returns the ArangoSearch view linking the text fields of
+// nodes and RELATES_TO edges.
+func (a *ArangoDBDriver) searchViewDefinition() map[string]interface{} {
+	text := map[string]interface{}{"analyzers": []string{a.config.Analyzer}}
+	identity := map[string]interface{}{"analyzers": []string{"identity"}}
+
+	link := func(textFields ...string) map[string]interface{} {
+		fields := map[string]interface{}{
+			"group_id":   identity,
+			"created_at": identity,
+		}
+		for _, field := range textFields {
+			fields[field] = text
+		}
+		return map[string]interface{}{"includeAllFields": false, "fields": fields}
+	}
+
+	return map[string]interface{}{
+		"name": a.config.SearchView,
+		"type": "arangosearch",
+		"links": map[string]interface{}{
+			arangoEntityCollection:    link("name", "summary"),
+			arangoEpisodicCollection:  link("name", "content"),
+			arangoCommunityCollection: link("name", "summary"),
+			arangoRelatesToCollection: link("name", "fact"),
+		},
+	}
+}
+
+// GetStats returns graph statistics for a group, or for every group when groupID is empty.
+func (a *ArangoDBDriver) GetStats(ctx context.Context, groupID string) (*GraphStats, error) {
+	stats := newGraphStats()
+
+	// decode runs a query returning a single object and decodes it into out
+	decode := func(aql string, bindVars map[string]interface{}, out interface{}) error {
+		bindVars["group_id"] = groupID
+		rows, _, err := a.query(ctx, aql, bindVars)
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		return json.Unmarshal(rows[0], out)
+	}
+
+	countByGroup := func(collection string) (map[string]int64, error) {
+		counts := make(map[string]int64)
+		err := decode(`
+			RETURN MERGE(
+				FOR d IN @@collection
+					FILTER @group_id == "" OR d.group_id == @group_id
+					COLLECT group = d.group_id WITH COUNT INTO count
+					RETURN { [group]: count }
+			)
+		`, map[string]interface{}{"@collection": collection}, &counts)
+		return counts, err
+	}
+
+	for _, collection := range arangoNodeCollections {
+		counts, err := countByGroup(collection)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s nodes: %w", collection, err)
+		}
+		for group, n := range counts {
+			stats.NodesByType[collection] += n
+			stats.NodesByGroup[group] += n
+			stats.NodeCount += n
+		}
+	}
+	stats.CommunityCount = stats.NodesByType[arangoCommunityCollection]
+
+	for _, collection := range arangoEdgeCollections {
+		counts, err := countByGroup(collection)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s edges: %w", collection, err)
+		}
+		for group, n := range counts {
+			stats.EdgesByType[collection] += n
+			stats.EdgesByGroup[group] += n
+			stats.EdgeCount += n
+		}
+	}
+
+	var edgeStats struct {
+		Valid            int64 `json:"valid"`
+		Invalidated      int64 `json:"invalidated"`
+		WithoutEmbedding int64 `json:"without_embedding"`
+	}
+	err := decode(`
+		FOR d IN @@collection
+			FILTER @group_id == "" OR d.group_id == @group_id
+			COLLECT AGGREGATE
+				valid = SUM(d.invalid_at == null AND d.expired_at == null ? 1 : 0),
+				invalidated = SUM(d.invalid_at != null OR d.expired_at != null ? 1 : 0),
+				withoutEmbedding = SUM(LENGTH(d.fact_embedding) == 0 AND LENGTH(d.embedding) == 0 ? 1 : 0)
+			RETURN { valid, invalidated, without_embedding: withoutEmbedding }
+	`, map[string]interface{}{"@collection": arangoRelatesToCollection}, &edgeStats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count entity edges: %w", err)
+	}
+	stats.ValidEdgeCount = edgeStats.Valid
+	stats.InvalidatedEdgeCount = edgeStats.Invalidated
+	stats.EdgesWithoutEmbedding = edgeStats.WithoutEmbedding
+
+	for _, collection := range []string{arangoEntityCollection, arangoCommunityCollection} {
+		var n int64
+		err := decode(`
+			FOR d IN @@collection
+				FILTER @group_id == "" OR d.group_id == @group_id
+				FILTER LENGTH(d.name_embedding) == 0 AND LENGTH(d.embedding) == 0
+				COLLECT WITH COUNT INTO count
+				RETURN count
+		`, map[string]interface{}{"@collection": collection}, &n)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s nodes without embeddings: %w", collection, err)
+		}
+		stats.NodesWithoutEmbedding += n
+	}
+
+	// Timestamps are stored in UTC, so the first ten characters are the day.
+	// Unset times are serialized as the zero time and skipped.
+	err = decode(`
+		RETURN MERGE(
+			FOR d IN @@collection
+				FILTER @group_id == "" OR d.group_id == @group_id
+				LET reference = FIRST(
+					FOR t IN [d.reference, d.valid_from, d.created_at]
+						FILTER t != null AND !STARTS_WITH(t, "0001-")
+						RETURN t
+				)
+				FILTER reference != null
+				COLLECT day = LEFT(reference, 10) WITH COUNT INTO count
+				RETURN { [day]: count }
+		)
+	`, map[string]interface{}{"@collection": arangoEpisodicCollection}, &stats.EpisodesPerDay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count episodes per day: %w", err)
+	}
+
+	return stats, nil
+}
+
+// ParseNodesFromRecords parses the records returned by ExecuteQuery into nodes.
+func (a *ArangoDBDriver) ParseNodesFromRecords(records any) ([]*types.Node, error) {
+	recordSlice, ok := records.([]map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected records type: %T", records)
+	}
+
+	rows := make([]json.RawMessage, 0, len(recordSlice))
+	for _, record := range recordSlice {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode record: %w", err)
+		}
+		rows = append(rows, data)
+	}
+	return decodeArangoNodes(rows)
+}
+
+// GetEntityNodesByGroup retrieves all entity nodes of a group.
+func (a *ArangoDBDriver) GetEntityNodesByGroup(ctx context.Context, groupID string) ([]*types.Node, error) {
+	return a.queryNodes(ctx, `
+		FOR n IN Entity
+			FILTER n.group_id == @group_id
+			RETURN n
+	`, map[string]interface{}{
+		"group_id": groupID,
+	})
+}
+
+// GetAllGroupIDs returns the distinct group IDs of all nodes.
+func (a *ArangoDBDriver) GetAllGroupIDs(ctx context.Context) ([]string, error) {
+	rows, _, err := a.query(ctx, `
+		FOR group_id IN UNION_DISTINCT(
+			(FOR n IN Entity RETURN DISTINCT n.group_id),
+			(FOR n IN Episodic RETURN DISTINCT n.group_id),
+			(FOR n IN Community RETURN DISTINCT n.group_id)
+		)
+			FILTER group_id != null AND group_id != ""
+			SORT group_id
+			RETURN group_id
+	`, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group IDs: %w", err)
+	}
+
+	groupIDs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		var groupID string
+		if err := json.Unmarshal(row, &groupID); err != nil {
+			return nil, fmt.Errorf("failed to decode group ID: %w", err)
+		}
+		groupIDs = append(groupIDs, groupID)
+	}
+	return groupIDs, nil
+}
+
+// queryNodes runs an AQL query returning node documents.
+func (a *ArangoDBDriver) queryNodes(ctx context.Context, aql string, bindVars map[string]interface{}) ([]*types.Node, error) {
+	rows, _, err := a.query(ctx, aql, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	return decodeArangoNodes(rows)
+}
+
+// queryEdges runs an AQL query returning edge documents.
+func (a *ArangoDBDriver) queryEdges(ctx context.Context, aql string, bindVars map[string]interface{}) ([]*types.Edge, error) {
+	rows, _, err := a.query(ctx, aql, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	return decodeArangoEdges(rows)
+}
+
+// Session creates a new database session. ArangoDB's HTTP API is stateless, so
+// sessions simply run queries against the given database.
+func (a *ArangoDBDriver) Session(database *string) GraphDriverSession {
+	driver := a
+	if database != nil && *database != "" && *database != a.config.Database {
+		cfg := *a.config
+		cfg.Database = *database
+		driver = &ArangoDBDriver{config: &cfg, client: a.client}
+	}
+	return &ArangoDBDriverSession{driver: driver}
+}
+
+// DeleteAllIndexes drops the persistent indexes and the search view of the given database.
+func (a *ArangoDBDriver) DeleteAllIndexes(database string) {
+	ctx := context.Background()
+	if database == "" {
+		database = a.config.Database
+	}
+
+	for _, name := range append(append([]string{}, arangoNodeCollections...), arangoEdgeCollections...) {
+		var resp struct {
+			Indexes []struct {
+				ID   string `json:"id"`
+				Type string `json:"type"`
+			} `json:"indexes"`
+		}
+		if err := a.request(ctx, database, http.MethodGet, "/_api/index?collection="+url.QueryEscape(name), nil, &resp); err != nil {
+			continue
+		}
+		for _, index := range resp.Indexes {
+			// Primary and edge indexes are built in and cannot be dropped
+			if index.Type == "primary" || index.Type == "edge" {
+				continue
+			}
+			_ = a.request(ctx, database, http.MethodDelete, "/_api/index/"+index.ID, nil, nil)
+		}
+	}
+
+	_ = a.request(ctx, database, http.MethodDelete, "/_api/view/"+url.PathEscape(a.config.SearchView), nil, nil)
+}
+
+// Provider returns the provider type.
+func (a *ArangoDBDriver) Provider() GraphProvider {
+	return GraphProviderArangoDB
+}
+
+// GetAossClient returns nil for ArangoDB (Amazon OpenSearch not applicable).
+func (a *ArangoDBDriver) GetAossClient() interface{} {
+	return nil
+}
+
+// Close releases idle HTTP connections.
+func (a *ArangoDBDriver) Close() error {
+	a.client.CloseIdleConnections()
+	return nil
+}
+
+// HealthCheck verifies the server is reachable and runs a trivial query against the configured database.
+func (a *ArangoDBDriver) HealthCheck(ctx context.Context) error {
+	rows, _, err := a.query(ctx, "RETURN 1", nil)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	if len(rows) != 1 {
+		return fmt.Errorf("health check failed: unexpected result %v", rows)
+	}
+	return nil
+}
+
+// ArangoDBDriverSession implements GraphDriverSession for ArangoDB.
+type ArangoDBDriverSession struct {
+	driver *ArangoDBDriver
+}
+
+// Enter implements the context manager pattern.
+func (s *ArangoDBDriverSession) Enter(ctx context.Context) (GraphDriverSession, error) {
+	return s, nil
+}
+
+// Exit implements the context manager pattern.
+func (s *ArangoDBDriverSession) Exit(ctx context.Context, excType, excVal, excTb interface{}) error {
+	return nil
+}
+
+// Close closes the session.
+func (s *ArangoDBDriverSession) Close() error {
+	return nil
+}
+
+// Run executes an AQL query in this session.
+func (s *ArangoDBDriverSession) Run(ctx context.Context, query interface{}, kwargs map[string]interface{}) error {
+	queryStr, ok := query.(string)
+	if !ok {
+		return fmt.Errorf("query must be a string")
+	}
+	_, _, _, err := s.driver.executeQuery(ctx, queryStr, kwargs)
+	return err
+}
+
+// ExecuteWrite executes fn with this session. Queries run by fn are committed
+// individually; ArangoDB stream transactions are not used.
+func (s *ArangoDBDriverSession) ExecuteWrite(ctx context.Context, fn func(context.Context, GraphDriverSession, ...interface{}) (interface{}, error), args ...interface{}) (interface{}, error) {
+	return fn(ctx, s, args...)
+}
+
+// Provider returns the provider type.
+func (s *ArangoDBDriverSession) Provider() GraphProvider {
+	return GraphProviderArangoDB
+}
@@ -0,0 +1,200 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// fakeArangoServer serves the cursor API, returning rows in batches of one and
+// recording the queries it receives.
+type fakeArangoServer struct {
+	rows    []interface{}
+	queries []string
+	binds   []map[string]interface{}
+}
+
+func (f *fakeArangoServer) handler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/_db/testdb/_api/cursor":
+			var body struct {
+				Query    string                 `json:"query"`
+				BindVars map[string]interface{} `json:"bindVars"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			f.queries = append(f.queries, body.Query)
+			f.binds = append(f.binds, body.BindVars)
+
+			if strings.Contains(body.Query, "SYNTAX ERROR") {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"error": true, "code": 400, "errorNum": 1501, "errorMessage": "syntax error",
+				})
+				return
+			}
+			f.writeBatch(w, 0)
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/_db/testdb/_api/cursor/"):
+			index, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/_db/testdb/_api/cursor/"))
+			f.writeBatch(w, index)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func (f *fakeArangoServer) writeBatch(w http.ResponseWriter, index int) {
+	resp := map[string]interface{}{"result": []interface{}{}, "hasMore": false}
+	if index < len(f.rows) {
+		resp["result"] = []interface{}{f.rows[index]}
+		if index+1 < len(f.rows) {
+			resp["hasMore"] = true
+			resp["id"] = strconv.Itoa(index + 1)
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newTestArangoDriver(t *testing.T, fake *fakeArangoServer) *ArangoDBDriver {
+	server := httptest.NewServer(fake.handler(t))
+	t.Cleanup(server.Close)
+
+	config := DefaultArangoDBDriverConfig()
+	config.Endpoint = server.URL
+	config.Database = "testdb"
+	driver, err := NewArangoDBDriverWithConfig(config)
+	if err != nil {
+		t.Fatalf("failed to create driver: %v", err)
+	}
+	return driver
+}
+
+func TestArangoDBExecuteQueryFollowsCursor(t *testing.T) {
+	fake := &fakeArangoServer{rows: []interface{}{
+		map[string]interface{}{"name": "a"},
+		map[string]interface{}{"name": "b"},
+		42,
+	}}
+	driver := newTestArangoDriver(t, fake)
+
+	records, _, _, err := driver.ExecuteQuery("FOR d IN Entity RETURN d", map[string]interface{}{"x": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows := records.([]map[string]interface{})
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+	if rows[1]["name"] != "b" {
+		t.Errorf("expected second row name b, got %v", rows[1]["name"])
+	}
+	if rows[2]["value"] != float64(42) {
+		t.Errorf("expected scalar row under value, got %v", rows[2])
+	}
+}
+
+func TestArangoDBErrors(t *testing.T) {
+	driver := newTestArangoDriver(t, &fakeArangoServer{})
+
+	_, _, _, err := driver.ExecuteQuery("SYNTAX ERROR", nil)
+	if !isArangoError(err, 1501) {
+		t.Fatalf("expected ArangoError 1501, got %v", err)
+	}
+}
+
+func TestArangoDBGetNodeDecodesDocument(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fake := &fakeArangoServer{rows: []interface{}{
+		toArangoNodeDocument(&types.Node{
+			Uuid:      "n1",
+			Name:      "Alice",
+			Type:      types.EntityNodeType,
+			GroupID:   "g1",
+			CreatedAt: created,
+		}),
+	}}
+	driver := newTestArangoDriver(t, fake)
+
+	node, err := driver.GetNode(context.Background(), "n1", "g1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if node.Uuid != "n1" || node.Name != "Alice" || !node.CreatedAt.Equal(created) {
+		t.Errorf("unexpected node: %+v", node)
+	}
+
+	ids := fake.binds[0]["ids"].([]interface{})
+	if len(ids) != 3 || ids[0] != "Entity/n1" {
+		t.Errorf("expected candidate IDs in every node collection, got %v", ids)
+	}
+}
+
+func TestArangoDBGetNodeNotFound(t *testing.T) {
+	driver := newTestArangoDriver(t, &fakeArangoServer{})
+
+	if _, err := driver.GetNode(context.Background(), "missing", "g1"); err == nil {
+		t.Fatal("expected error for missing node")
+	}
+}
+
+func TestArangoDBEdgeDocument(t *testing.T) {
+	edge := &types.Edge{
+		BaseEdge: types.BaseEdge{Uuid: "e1", GroupID: "g1", SourceNodeID: "a", TargetNodeID: "b"},
+		Name:     "KNOWS",
+		Type:     types.EntityEdgeType,
+	}
+
+	collection, doc := toArangoEdgeDocument(edge)
+	if collection != arangoRelatesToCollection {
+		t.Errorf("expected RELATES_TO collection, got %s", collection)
+	}
+	if doc.From != "Entity/a" || doc.To != "Entity/b" || doc.Key != "e1" {
+		t.Errorf("unexpected edge document: %+v", doc)
+	}
+
+	data, err := json.Marshal(arangoEdgeDocument{Key: "e2", From: "Entity/x", To: "Entity/y"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	edges, err := decodeArangoEdges([]json.RawMessage{data})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if edges[0].Uuid != "e2" || edges[0].SourceNodeID != "x" || edges[0].TargetID != "y" {
+		t.Errorf("expected endpoints from _from/_to, got %+v", edges[0])
+	}
+}
+
+func TestArangoDBSearchNodesUsesBM25View(t *testing.T) {
+	fake := &fakeArangoServer{}
+	driver := newTestArangoDriver(t, fake)
+
+	_, err := driver.SearchNodes(context.Background(), "alice", "g1", &SearchOptions{
+		Limit:     5,
+		NodeTypes: []types.NodeType{types.EntityNodeType},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(fake.queries[0], "BM25(doc)") {
+		t.Errorf("expected BM25 ranking, got %s", fake.queries[0])
+	}
+	if fake.binds[0]["@view"] != "predicato_search" {
+		t.Errorf("expected default search view, got %v", fake.binds[0]["@view"])
+	}
+	collections := fake.binds[0]["collections"].([]interface{})
+	if len(collections) != 1 || collections[0] != "Entity" {
+		t.Errorf("expected Entity collection, got %v", collections)
+	}
+}
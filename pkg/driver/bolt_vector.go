@@ -0,0 +1,57 @@
+package driver
+
+import (
+	"sort"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
+)
+
+// boltVectorProperty stores embeddings as a native float list so that vector
+// indexes can use them. The JSON encoded embedding property is kept as is.
+const boltVectorProperty = "embedding_vector"
+
+// boltVectorOversample is the number of index candidates fetched per requested
+// result, since the group filter is applied after the index lookup.
+const boltVectorOversample = 10
+
+// boltNodeVectorIndexes maps node labels to the names of their vector indexes.
+var boltNodeVectorIndexes = map[string]string{
+	"Entity":    "entity_embedding_vector",
+	"Community": "community_embedding_vector",
+}
+
+// boltEdgeVectorIndex is the vector index on RELATES_TO relationships.
+const boltEdgeVectorIndex = "relates_to_embedding_vector"
+
+// vectorIndexSupport describes the vector index features of the server.
+type vectorIndexSupport struct {
+	// nodes reports node vector indexes (Neo4j 5.11+, Memgraph 2.15+)
+	nodes bool
+	// relationships reports relationship vector indexes (Neo4j 5.18+, Memgraph 3.1+)
+	relationships bool
+}
+
+// boltVectorList converts embedding to the native list stored under
+// boltVectorProperty. It returns nil when dimensions is zero or does not match
+// the embedding, keeping such records out of the vector indexes.
+func boltVectorList(embedding []float32, dimensions int) []float64 {
+	if dimensions <= 0 || len(embedding) != dimensions {
+		return nil
+	}
+	vector := make([]float64, len(embedding))
+	for i, v := range embedding {
+		vector[i] = float64(v)
+	}
+	return vector
+}
+
+// sortRecordsByScore orders records by their score column, highest first.
+func sortRecordsByScore(records []*db.Record) {
+	score := func(record *db.Record) float64 {
+		value, _ := record.AsMap()["score"].(float64)
+		return value
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return score(records[i]) > score(records[j])
+	})
+}
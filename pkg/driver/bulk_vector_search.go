@@ -0,0 +1,134 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// BulkVectorSearcher is implemented by drivers that can search the nodes
+// similar to many vectors in one database round trip (Neo4j and Memgraph).
+type BulkVectorSearcher interface {
+	// SearchNodesByEmbeddings returns, for each embedding, the nodes most
+	// similar to it as SearchNodesByVector would.
+	SearchNodesByEmbeddings(ctx context.Context, embeddings [][]float32, groupID string, options *VectorSearchOptions) ([][]*types.Node, error)
+}
+
+// AsBulkVectorSearcher returns the BulkVectorSearcher behind d, looking
+// through wrapping drivers such as WrappedDriver.
+func AsBulkVectorSearcher(d GraphDriver) (BulkVectorSearcher, bool) {
+	for d != nil {
+		if searcher, ok := d.(BulkVectorSearcher); ok {
+			return searcher, true
+		}
+		wrapper, ok := d.(interface{ Unwrap() GraphDriver })
+		if !ok {
+			break
+		}
+		d = wrapper.Unwrap()
+	}
+	return nil, false
+}
+
+// SearchNodesByEmbeddings returns, for each embedding, the nodes most similar
+// to it. Drivers implementing BulkVectorSearcher evaluate all embeddings in
+// one round trip; other drivers run one SearchNodesByVector per embedding.
+func SearchNodesByEmbeddings(ctx context.Context, d GraphDriver, embeddings [][]float32, groupID string, options *VectorSearchOptions) ([][]*types.Node, error) {
+	if len(embeddings) == 0 {
+		return [][]*types.Node{}, nil
+	}
+	if searcher, ok := AsBulkVectorSearcher(d); ok {
+		return searcher.SearchNodesByEmbeddings(ctx, embeddings, groupID, options)
+	}
+
+	results := make([][]*types.Node, len(embeddings))
+	for i, embedding := range embeddings {
+		nodes, err := d.SearchNodesByVector(ctx, embedding, groupID, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search nodes by embedding %d: %w", i, err)
+		}
+		results[i] = nodes
+	}
+	return results, nil
+}
+
+// bulkSearchLimits returns the limit and minimum score of options, with the
+// defaults of SearchNodesByVector.
+func bulkSearchLimits(options *VectorSearchOptions) (int, float64) {
+	limit := 10
+	minScore := 0.0
+	if options != nil {
+		if options.Limit > 0 {
+			limit = options.Limit
+		}
+		if options.MinScore > 0 {
+			minScore = options.MinScore
+		}
+	}
+	return limit, minScore
+}
+
+// scoredNode is a node found for a query vector with its similarity to it.
+type scoredNode struct {
+	query int
+	node  *types.Node
+	score float64
+}
+
+// groupScoredNodes returns, for each of count query vectors, its nodes by
+// descending score, at most limit and scoring at least minScore.
+func groupScoredNodes(hits []scoredNode, count, limit int, minScore float64) [][]*types.Node {
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].score > hits[j].score
+	})
+
+	results := make([][]*types.Node, count)
+	for i := range results {
+		results[i] = []*types.Node{}
+	}
+	for _, hit := range hits {
+		if hit.query < 0 || hit.query >= count || hit.score < minScore || len(results[hit.query]) >= limit {
+			continue
+		}
+		results[hit.query] = append(results[hit.query], hit.node)
+	}
+	return results
+}
+
+// scoreCandidates scores every candidate against every embedding, the
+// candidate embeddings being given in the same order as candidates.
+func scoreCandidates(embeddings [][]float32, candidates []*types.Node, candidateEmbeddings [][]float32) []scoredNode {
+	hits := make([]scoredNode, 0, len(embeddings)*len(candidates))
+	for query, embedding := range embeddings {
+		for i, candidate := range candidates {
+			hits = append(hits, scoredNode{
+				query: query,
+				node:  candidate,
+				score: cosineSimilarity(embedding, candidateEmbeddings[i]),
+			})
+		}
+	}
+	return hits
+}
+
+// cosineSimilarity returns the cosine similarity of a and b, or 0 when their
+// lengths differ or either is zero.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dotProduct, normA, normB float64
+	for i := range a {
+		dotProduct += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dotProduct / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,82 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// vectorSearchingDriver is a GraphDriver stub answering SearchNodesByVector
+// with the node named after the first vector component.
+type vectorSearchingDriver struct {
+	GraphDriver
+	calls int
+}
+
+func (d *vectorSearchingDriver) SearchNodesByVector(ctx context.Context, vector []float32, groupID string, options *VectorSearchOptions) ([]*types.Node, error) {
+	d.calls++
+	return []*types.Node{{Uuid: string(rune('a' + int(vector[0])))}}, nil
+}
+
+// bulkSearchingDriver is a GraphDriver stub implementing BulkVectorSearcher.
+type bulkSearchingDriver struct {
+	vectorSearchingDriver
+	bulkCalls int
+}
+
+func (d *bulkSearchingDriver) SearchNodesByEmbeddings(ctx context.Context, embeddings [][]float32, groupID string, options *VectorSearchOptions) ([][]*types.Node, error) {
+	d.bulkCalls++
+	return make([][]*types.Node, len(embeddings)), nil
+}
+
+func TestSearchNodesByEmbeddingsFallsBackToOneSearchPerEmbedding(t *testing.T) {
+	d := &vectorSearchingDriver{}
+
+	results, err := SearchNodesByEmbeddings(context.Background(), d, [][]float32{{0}, {1}}, "group", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.calls != 2 {
+		t.Errorf("expected 2 vector searches, got %d", d.calls)
+	}
+	if len(results) != 2 || results[0][0].Uuid != "a" || results[1][0].Uuid != "b" {
+		t.Errorf("expected results in embedding order, got %v", results)
+	}
+}
+
+func TestSearchNodesByEmbeddingsUsesBulkSearcher(t *testing.T) {
+	inner := &bulkSearchingDriver{}
+
+	results, err := SearchNodesByEmbeddings(context.Background(), Wrap(inner), [][]float32{{0}, {1}, {2}}, "group", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inner.bulkCalls != 1 || inner.calls != 0 {
+		t.Errorf("expected one bulk search and no vector search, got %d and %d", inner.bulkCalls, inner.calls)
+	}
+	if len(results) != 3 {
+		t.Errorf("expected 3 result lists, got %d", len(results))
+	}
+}
+
+func TestGroupScoredNodes(t *testing.T) {
+	embeddings := [][]float32{{1, 0}, {0, 1}}
+	candidates := []*types.Node{{Uuid: "x"}, {Uuid: "y"}, {Uuid: "xy"}}
+	candidateEmbeddings := [][]float32{{1, 0}, {0, 1}, {1, 1}}
+
+	hits := scoreCandidates(embeddings, candidates, candidateEmbeddings)
+	results := groupScoredNodes(hits, len(embeddings), 2, 0.5)
+
+	want := [][]string{{"x", "xy"}, {"y", "xy"}}
+	for query, nodes := range results {
+		if len(nodes) != len(want[query]) {
+			t.Fatalf("query %d: expected %v, got %d nodes", query, want[query], len(nodes))
+		}
+		for i, node := range nodes {
+			if node.Uuid != want[query][i] {
+				t.Errorf("query %d: expected %v at %d, got %s", query, want[query][i], i, node.Uuid)
+			}
+		}
+	}
+}
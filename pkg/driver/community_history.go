@@ -0,0 +1,149 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrCommunityHistoryUnsupported indicates the driver cannot keep the history
+// of community memberships.
+var ErrCommunityHistoryUnsupported = errors.New("driver does not support community history")
+
+// CommunityMembership is the membership of a node in a community. It is valid
+// from when the community was built until its group's communities were next
+// built, or is current when ValidTo is nil.
+type CommunityMembership struct {
+	CommunityUUID string
+	CommunityName string
+	MemberUUID    string
+	ValidFrom     time.Time
+	ValidTo       *time.Time
+}
+
+// closeMembershipsQueries set valid_to on the current HAS_MEMBER edges of a
+// group, by provider.
+var closeMembershipsQueries = map[GraphProvider]string{
+	GraphProviderNeo4j:    cypherCloseMembershipsQuery,
+	GraphProviderMemgraph: cypherCloseMembershipsQuery,
+	GraphProviderLadybug:  cypherCloseMembershipsQuery,
+	GraphProviderArangoDB: `
+		FOR e IN HAS_MEMBER
+			FILTER e.group_id == @group_id AND e.valid_to == null
+			UPDATE e WITH {valid_to: @at} IN HAS_MEMBER
+	`,
+	GraphProviderSurrealDB: `
+		UPDATE HAS_MEMBER SET valid_to = $at
+		WHERE group_id = $group_id AND valid_to IS NONE
+	`,
+}
+
+const cypherCloseMembershipsQuery = `
+	MATCH (:Community)-[e:HAS_MEMBER]->()
+	WHERE e.group_id = $group_id AND e.valid_to IS NULL
+	SET e.valid_to = $at
+`
+
+// membershipHistoryQueries return the HAS_MEMBER edges of a node, by
+// provider. The valid_from of a membership is the creation time of its edge.
+var membershipHistoryQueries = map[GraphProvider]string{
+	GraphProviderNeo4j:    cypherMembershipHistoryQuery,
+	GraphProviderMemgraph: cypherMembershipHistoryQuery,
+	GraphProviderLadybug:  cypherMembershipHistoryQuery,
+	GraphProviderArangoDB: `
+		FOR e IN HAS_MEMBER
+			FILTER e.group_id == @group_id AND PARSE_IDENTIFIER(e._to).key == @uuid
+			LET c = DOCUMENT(e._from)
+			RETURN {community: c._key, name: c.name, uuid: @uuid, valid_from: e.created_at, valid_to: e.valid_to}
+	`,
+	GraphProviderSurrealDB: `
+		SELECT in.uuid AS community, in.name AS name, out.uuid AS uuid, created_at AS valid_from, valid_to
+		FROM HAS_MEMBER
+		WHERE group_id = $group_id AND out.uuid = $uuid
+	`,
+}
+
+const cypherMembershipHistoryQuery = `
+	MATCH (c:Community)-[e:HAS_MEMBER]->(n)
+	WHERE n.uuid = $uuid AND e.group_id = $group_id
+	RETURN c.uuid AS community, c.name AS name, n.uuid AS uuid, e.created_at AS valid_from, e.valid_to AS valid_to
+`
+
+// CloseCommunityMemberships ends the current community memberships of a
+// group at the given time, before its communities are built again, so that
+// earlier communities are kept as history instead of being overwritten.
+func CloseCommunityMemberships(ctx context.Context, d GraphDriver, groupID string, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	query, ok := closeMembershipsQueries[d.Provider()]
+	if !ok {
+		return ErrCommunityHistoryUnsupported
+	}
+
+	params := map[string]interface{}{"group_id": groupID, "at": at.UTC()}
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph:
+		// The Bolt drivers store the creation time of memberships as a string
+		params["at"] = boltTimestamp(at)
+	}
+	if _, err := executeGroupQuery(d, groupID, query, params); err != nil {
+		return fmt.Errorf("failed to close community memberships: %w", err)
+	}
+	return nil
+}
+
+// CommunityMembershipHistory returns the current and past community
+// memberships of a node of a group, oldest first.
+func CommunityMembershipHistory(ctx context.Context, d GraphDriver, groupID, memberUUID string) ([]CommunityMembership, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query, ok := membershipHistoryQueries[d.Provider()]
+	if !ok {
+		return nil, ErrCommunityHistoryUnsupported
+	}
+
+	result, err := executeGroupQuery(d, groupID, query, map[string]interface{}{
+		"group_id": groupID,
+		"uuid":     memberUUID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get community history: %w", err)
+	}
+
+	var history []CommunityMembership
+	for _, record := range queryRecordMaps(result) {
+		membership := CommunityMembership{}
+		membership.CommunityUUID, _ = record["community"].(string)
+		membership.CommunityName, _ = record["name"].(string)
+		membership.MemberUUID, _ = record["uuid"].(string)
+		if membership.CommunityUUID == "" {
+			continue
+		}
+		membership.ValidFrom, _ = recordTime(record["valid_from"])
+		if validTo, ok := recordTime(record["valid_to"]); ok {
+			membership.ValidTo = &validTo
+		}
+		history = append(history, membership)
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].ValidFrom.Before(history[j].ValidFrom)
+	})
+	return history, nil
+}
+
+// recordTime converts a time read back from a query record, stored either as
+// a time or as an RFC 3339 string.
+func recordTime(value interface{}) (time.Time, bool) {
+	switch t := value.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		return parsed, err == nil
+	}
+	return time.Time{}, false
+}
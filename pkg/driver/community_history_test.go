@@ -0,0 +1,45 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommunityMembershipHistory(t *testing.T) {
+	at := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	d := &integrityDriver{
+		queryRecorder: queryRecorder{provider: GraphProviderMemgraph},
+		records: map[string][]map[string]interface{}{
+			"RETURN c.uuid AS community": {
+				{"community": "c2", "name": "Robotics", "uuid": "a", "valid_from": "2026-03-01T00:00:00Z"},
+				{"community": "c1", "name": "Research", "uuid": "a", "valid_from": "2026-01-01T00:00:00Z", "valid_to": "2026-03-01T00:00:00Z"},
+			},
+		},
+	}
+
+	if err := CloseCommunityMemberships(context.Background(), d, "g", at); err != nil {
+		t.Fatalf("CloseCommunityMemberships() error = %v", err)
+	}
+	if !strings.Contains(d.queries[0], "SET e.valid_to = $at") || d.params[0]["at"] != "2026-03-01T00:00:00Z" {
+		t.Errorf("query = %s, params = %v", d.queries[0], d.params[0])
+	}
+
+	history, err := CommunityMembershipHistory(context.Background(), d, "g", "a")
+	if err != nil {
+		t.Fatalf("CommunityMembershipHistory() error = %v", err)
+	}
+	if len(history) != 2 || history[0].CommunityUUID != "c1" || history[1].CommunityUUID != "c2" {
+		t.Fatalf("CommunityMembershipHistory() = %+v, want c1 then c2", history)
+	}
+	if history[0].ValidTo == nil || !history[0].ValidTo.Equal(at) || history[1].ValidTo != nil {
+		t.Errorf("CommunityMembershipHistory() = %+v, want c1 closed at %v and c2 current", history, at)
+	}
+
+	unsupported := &queryRecorder{provider: GraphProviderNeptune}
+	if err := CloseCommunityMemberships(context.Background(), unsupported, "g", at); !errors.Is(err, ErrCommunityHistoryUnsupported) {
+		t.Errorf("CloseCommunityMemberships() error = %v, want ErrCommunityHistoryUnsupported", err)
+	}
+}
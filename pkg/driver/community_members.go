@@ -0,0 +1,68 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCommunityMembersUnsupported is returned by CommunityMembers for drivers
+// that cannot traverse community memberships.
+var ErrCommunityMembersUnsupported = errors.New("driver does not support looking up the members of communities")
+
+// communityMembersQueries return the community and member UUIDs of the
+// current HAS_MEMBER edges of the communities with the given UUIDs, or of
+// all communities when $all is set, by provider.
+var communityMembersQueries = map[GraphProvider]string{
+	GraphProviderNeo4j:    cypherCommunityMembersQuery,
+	GraphProviderMemgraph: cypherCommunityMembersQuery,
+	GraphProviderLadybug:  cypherCommunityMembersQuery,
+	GraphProviderArangoDB: `
+		FOR e IN HAS_MEMBER
+			FILTER e.group_id == @group_id AND (@all OR PARSE_IDENTIFIER(e._from).key IN @uuids) AND e.valid_to == null
+			RETURN {community: PARSE_IDENTIFIER(e._from).key, uuid: PARSE_IDENTIFIER(e._to).key}
+	`,
+	GraphProviderSurrealDB: `
+		SELECT in.uuid AS community, out.uuid AS uuid FROM HAS_MEMBER
+		WHERE group_id = $group_id AND ($all OR in.uuid IN $uuids) AND valid_to IS NONE
+	`,
+}
+
+const cypherCommunityMembersQuery = `
+	MATCH (c:Community)-[e:HAS_MEMBER]->(n)
+	WHERE ($all OR c.uuid IN $uuids) AND c.group_id = $group_id AND e.valid_to IS NULL AND n.deleted_at IS NULL
+	RETURN c.uuid AS community, n.uuid AS uuid
+`
+
+// CommunityMembers returns the UUIDs of the current members of the
+// communities of groupID with the given UUIDs, or of all its communities when
+// communityUUIDs is nil, keyed by community UUID. Memberships closed by
+// CloseCommunityMemberships are left out.
+func CommunityMembers(ctx context.Context, d GraphDriver, groupID string, communityUUIDs []string) (map[string][]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	query, ok := communityMembersQueries[d.Provider()]
+	if !ok {
+		return nil, ErrCommunityMembersUnsupported
+	}
+
+	result, err := executeGroupQuery(d, groupID, query, map[string]interface{}{
+		"group_id": groupID,
+		"uuids":    append([]string{}, communityUUIDs...),
+		"all":      communityUUIDs == nil,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members of communities: %w", err)
+	}
+
+	members := make(map[string][]string)
+	for _, record := range queryRecordMaps(result) {
+		community, _ := record["community"].(string)
+		uuid, _ := record["uuid"].(string)
+		if community != "" && uuid != "" {
+			members[community] = append(members[community], uuid)
+		}
+	}
+	return members, nil
+}
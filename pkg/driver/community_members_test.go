@@ -0,0 +1,40 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCommunityMembers(t *testing.T) {
+	for provider := range communityMembersQueries {
+		d := &integrityDriver{
+			queryRecorder: queryRecorder{provider: provider},
+			records: map[string][]map[string]interface{}{
+				"HAS_MEMBER": {
+					{"community": "c1", "uuid": "a"},
+					{"community": "c1", "uuid": "b"},
+					{"community": "c2", "uuid": "a"},
+					{"community": "c2", "uuid": ""},
+				},
+			},
+		}
+		got, err := CommunityMembers(context.Background(), d, "g", []string{"c1", "c2"})
+		if err != nil {
+			t.Fatalf("%s: CommunityMembers() error = %v", provider, err)
+		}
+		want := map[string][]string{"c1": {"a", "b"}, "c2": {"a"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: CommunityMembers() = %v, want %v", provider, got, want)
+		}
+		if d.params[0]["group_id"] != "g" || d.params[0]["all"] != false {
+			t.Errorf("%s: params = %v", provider, d.params[0])
+		}
+	}
+
+	unsupported := &queryRecorder{provider: GraphProviderNeptune}
+	if _, err := CommunityMembers(context.Background(), unsupported, "g", []string{"c1"}); !errors.Is(err, ErrCommunityMembersUnsupported) {
+		t.Errorf("CommunityMembers() error = %v, want ErrCommunityMembersUnsupported", err)
+	}
+}
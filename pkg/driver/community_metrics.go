@@ -0,0 +1,138 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrCommunityMetricsUnsupported indicates the driver cannot store community
+// metrics in the graph.
+var ErrCommunityMetricsUnsupported = errors.New("driver does not support community metrics")
+
+// CommunityMetrics describes the communities of a group as they were built,
+// so that changes of the topical structure of the group can be tracked.
+type CommunityMetrics struct {
+	GroupID string
+	BuiltAt time.Time
+	// Communities is the number of communities and Sizes their number of
+	// members, largest first
+	Communities int
+	Sizes       []int
+	// Modularity is the modularity of the communities over the entity edges
+	Modularity float64
+	// IntraCommunityEdges and InterCommunityEdges count the pairs of connected
+	// entities within a community and across two communities
+	IntraCommunityEdges int
+	InterCommunityEdges int
+}
+
+// SaveCommunityMetrics records the metrics of a community build, keeping the
+// metrics of earlier builds. It returns ErrCommunityMetricsUnsupported for
+// drivers without Cypher support (Neo4j, Memgraph and Ladybug store them).
+func SaveCommunityMetrics(ctx context.Context, d GraphDriver, metrics *CommunityMetrics) error {
+	if !supportsCommunityMetrics(d) {
+		return ErrCommunityMetricsUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sizes, err := json.Marshal(metrics.Sizes)
+	if err != nil {
+		return fmt.Errorf("failed to encode community sizes: %w", err)
+	}
+	builtAt := metrics.BuiltAt.UTC()
+	_, err = executeGroupQuery(d, metrics.GroupID, `
+		MERGE (m:CommunityMetrics {id: $id})
+		SET m.group_id = $group_id, m.built_at = $built_at, m.communities = $communities,
+		    m.sizes = $sizes, m.modularity = $modularity,
+		    m.intra_community_edges = $intra_community_edges,
+		    m.inter_community_edges = $inter_community_edges
+	`, map[string]interface{}{
+		"id":                    metrics.GroupID + "\x00" + builtAt.Format(time.RFC3339Nano),
+		"group_id":              metrics.GroupID,
+		"built_at":              builtAt,
+		"communities":           int64(metrics.Communities),
+		"sizes":                 string(sizes),
+		"modularity":            metrics.Modularity,
+		"intra_community_edges": int64(metrics.IntraCommunityEdges),
+		"inter_community_edges": int64(metrics.InterCommunityEdges),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record community metrics: %w", err)
+	}
+	return nil
+}
+
+// ListCommunityMetrics returns the metrics of the community builds of a
+// group, most recent first and at most limit, or all of them when limit is 0.
+func ListCommunityMetrics(ctx context.Context, d GraphDriver, groupID string, limit int) ([]*CommunityMetrics, error) {
+	if !supportsCommunityMetrics(d) {
+		return nil, ErrCommunityMetricsUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err := executeGroupQuery(d, groupID, `
+		MATCH (m:CommunityMetrics)
+		WHERE m.group_id = $group_id
+		RETURN m.group_id AS group_id, m.built_at AS built_at, m.communities AS communities,
+		       m.sizes AS sizes, m.modularity AS modularity,
+		       m.intra_community_edges AS intra_community_edges,
+		       m.inter_community_edges AS inter_community_edges
+	`, map[string]interface{}{"group_id": groupID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list community metrics: %w", err)
+	}
+
+	var history []*CommunityMetrics
+	for _, record := range queryRecordMaps(result) {
+		metrics := &CommunityMetrics{}
+		metrics.GroupID, _ = record["group_id"].(string)
+		metrics.BuiltAt, _ = record["built_at"].(time.Time)
+		metrics.Communities = recordInt(record["communities"])
+		metrics.Modularity, _ = record["modularity"].(float64)
+		metrics.IntraCommunityEdges = recordInt(record["intra_community_edges"])
+		metrics.InterCommunityEdges = recordInt(record["inter_community_edges"])
+		if sizes, _ := record["sizes"].(string); sizes != "" {
+			if err := json.Unmarshal([]byte(sizes), &metrics.Sizes); err != nil {
+				return nil, fmt.Errorf("failed to decode community sizes: %w", err)
+			}
+		}
+		history = append(history, metrics)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].BuiltAt.After(history[j].BuiltAt)
+	})
+	if limit > 0 && len(history) > limit {
+		history = history[:limit]
+	}
+	return history, nil
+}
+
+// recordInt converts an integer read back from a query record.
+func recordInt(value interface{}) int {
+	switch n := value.(type) {
+	case int64:
+		return int(n)
+	case int:
+		return n
+	case float64:
+		return int(n)
+	}
+	return 0
+}
+
+// supportsCommunityMetrics reports whether d can store community metrics.
+func supportsCommunityMetrics(d GraphDriver) bool {
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph, GraphProviderLadybug:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// communityMetricsDriver is a GraphDriver stub storing community metrics in
+// memory.
+type communityMetricsDriver struct {
+	GraphDriver
+	provider GraphProvider
+	records  []map[string]interface{}
+}
+
+func (d *communityMetricsDriver) Provider() GraphProvider {
+	return d.provider
+}
+
+func (d *communityMetricsDriver) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	switch {
+	case strings.Contains(cypherQuery, "MERGE (m:CommunityMetrics"):
+		d.records = append(d.records, kwargs)
+		return nil, nil, nil, nil
+	case strings.Contains(cypherQuery, "MATCH (m:CommunityMetrics)"):
+		var records []map[string]interface{}
+		for _, record := range d.records {
+			if record["group_id"] == kwargs["group_id"] {
+				records = append(records, record)
+			}
+		}
+		return records, nil, nil, nil
+	}
+	return nil, nil, nil, errors.New("unexpected query")
+}
+
+func TestCommunityMetrics(t *testing.T) {
+	ctx := context.Background()
+	d := &communityMetricsDriver{provider: GraphProviderLadybug}
+
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, metrics := range []*CommunityMetrics{
+		{GroupID: "g", BuiltAt: first, Communities: 2, Sizes: []int{3, 2}, Modularity: 0.4, IntraCommunityEdges: 5, InterCommunityEdges: 1},
+		{GroupID: "g", BuiltAt: first.Add(time.Hour), Communities: 1, Sizes: []int{5}, Modularity: 0.1, IntraCommunityEdges: 6},
+		{GroupID: "other", BuiltAt: first, Communities: 1, Sizes: []int{2}},
+	} {
+		if err := SaveCommunityMetrics(ctx, d, metrics); err != nil {
+			t.Fatalf("SaveCommunityMetrics(%d) error = %v", i, err)
+		}
+	}
+
+	history, err := ListCommunityMetrics(ctx, d, "g", 0)
+	if err != nil {
+		t.Fatalf("ListCommunityMetrics() error = %v", err)
+	}
+	if len(history) != 2 || !history[0].BuiltAt.Equal(first.Add(time.Hour)) {
+		t.Fatalf("ListCommunityMetrics() = %v, want the two builds of g, most recent first", history)
+	}
+	want := &CommunityMetrics{GroupID: "g", BuiltAt: first, Communities: 2, Sizes: []int{3, 2}, Modularity: 0.4, IntraCommunityEdges: 5, InterCommunityEdges: 1}
+	if !reflect.DeepEqual(history[1], want) {
+		t.Errorf("ListCommunityMetrics()[1] = %+v, want %+v", history[1], want)
+	}
+
+	latest, err := ListCommunityMetrics(ctx, d, "g", 1)
+	if err != nil || len(latest) != 1 || latest[0].Communities != 1 {
+		t.Errorf("ListCommunityMetrics(limit 1) = %v, %v, want the latest build", latest, err)
+	}
+
+	unsupported := &communityMetricsDriver{provider: GraphProviderArangoDB}
+	if err := SaveCommunityMetrics(ctx, unsupported, want); !errors.Is(err, ErrCommunityMetricsUnsupported) {
+		t.Errorf("SaveCommunityMetrics() error = %v, want ErrCommunityMetricsUnsupported", err)
+	}
+}
@@ -2,7 +2,7 @@ package driver
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
 	"github.com/soundprediction/go-predicato/pkg/types"
@@ -12,11 +12,24 @@ import (
 type GraphProvider string
 
 const (
-	GraphProviderNeo4j    GraphProvider = "neo4j"
-	GraphProviderMemgraph GraphProvider = "memgraph"
-	GraphProviderFalkorDB GraphProvider = "falkordb"
-	GraphProviderLadybug  GraphProvider = "ladybug"
-	GraphProviderNeptune  GraphProvider = "neptune"
+	GraphProviderNeo4j     GraphProvider = "neo4j"
+	GraphProviderMemgraph  GraphProvider = "memgraph"
+	GraphProviderFalkorDB  GraphProvider = "falkordb"
+	GraphProviderLadybug   GraphProvider = "ladybug"
+	GraphProviderNeptune   GraphProvider = "neptune"
+	GraphProviderArangoDB  GraphProvider = "arangodb"
+	GraphProviderSurrealDB GraphProvider = "surrealdb"
+)
+
+// ErrDatabaseCorrupt indicates the database failed its health check because its
+// files are corrupted or only partially written.
+var ErrDatabaseCorrupt = errors.New("database is corrupt or incomplete")
+
+// ErrNodeNotFound and ErrEdgeNotFound are returned by GetNode and GetEdge when
+// there is no node or edge with the UUID in the group.
+var (
+	ErrNodeNotFound = errors.New("node not found")
+	ErrEdgeNotFound = errors.New("edge not found")
 )
 
 // GraphDriverSession defines the interface for database sessions (matching Python GraphDriverSession)
@@ -43,6 +56,9 @@ type GraphDriver interface {
 	DeleteAllIndexes(database string)
 	Provider() GraphProvider
 	GetAossClient() interface{}
+	// HealthCheck runs a trivial query to verify the database is reachable and readable.
+	// Embedded databases return ErrDatabaseCorrupt when they report damaged files.
+	HealthCheck(ctx context.Context) error
 
 	// Database-specific extensions (these can remain for compatibility)
 	// Node operations
@@ -61,10 +77,14 @@ type GraphDriver interface {
 
 	// Graph traversal operations
 	GetNeighbors(ctx context.Context, nodeID, groupID string, maxDistance int) ([]*types.Node, error)
+	// GetRelatedNodes retrieves the direct neighbors of a node over edges of
+	// edgeTypes, or over any edge if empty. Neo4j, Memgraph and Ladybug also
+	// accept relation names such as types.EdgeType("WORKS_AT").
 	GetRelatedNodes(ctx context.Context, nodeID, groupID string, edgeTypes []types.EdgeType) ([]*types.Node, error)
 	GetNodeNeighbors(ctx context.Context, nodeUUID, groupID string) ([]types.Neighbor, error)
-	// GetBetweenNodes retrieves edges between two specific nodes using the proper query pattern
-	GetBetweenNodes(ctx context.Context, sourceNodeID, targetNodeID string) ([]*types.Edge, error)
+	// GetBetweenNodes retrieves the entity edges of groupID between two nodes,
+	// in either direction.
+	GetBetweenNodes(ctx context.Context, sourceNodeID, targetNodeID, groupID string) ([]*types.Edge, error)
 
 	// Search operations
 	SearchNodesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int) ([]*types.Node, error)
@@ -86,8 +106,10 @@ type GraphDriver interface {
 	// Community operations
 	GetCommunities(ctx context.Context, groupID string, level int) ([]*types.Node, error)
 	BuildCommunities(ctx context.Context, groupID string) error
-	GetExistingCommunity(ctx context.Context, entityUUID string) (*types.Node, error)
-	FindModalCommunity(ctx context.Context, entityUUID string) (*types.Node, error)
+	// GetExistingCommunity and FindModalCommunity take the group of the
+	// entity to find it in drivers keeping groups in separate databases
+	GetExistingCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error)
+	FindModalCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error)
 	RemoveCommunities(ctx context.Context) error
 
 	// Database maintenance
@@ -103,7 +125,8 @@ type GraphDriver interface {
 	GetAllGroupIDs(ctx context.Context) ([]string, error)
 }
 
-// GraphStats holds statistics about the graph.
+// GraphStats holds statistics about the graph. GetStats with an empty group ID
+// reports on every group, including groups kept in their own Neo4j databases.
 type GraphStats struct {
 	NodeCount      int64            `json:"node_count"`
 	EdgeCount      int64            `json:"edge_count"`
@@ -111,6 +134,32 @@ type GraphStats struct {
 	EdgesByType    map[string]int64 `json:"edges_by_type"`
 	CommunityCount int64            `json:"community_count"`
 	LastUpdated    time.Time        `json:"last_updated"`
+
+	// NodesByGroup and EdgesByGroup count nodes and edges per group_id
+	NodesByGroup map[string]int64 `json:"nodes_by_group"`
+	EdgesByGroup map[string]int64 `json:"edges_by_group"`
+	// ValidEdgeCount and InvalidatedEdgeCount split the entity edges by whether
+	// they have been invalidated (invalid_at or expired_at set)
+	ValidEdgeCount       int64 `json:"valid_edge_count"`
+	InvalidatedEdgeCount int64 `json:"invalidated_edge_count"`
+	// EpisodesPerDay counts episodes by the day of their reference time (YYYY-MM-DD)
+	EpisodesPerDay map[string]int64 `json:"episodes_per_day"`
+	// NodesWithoutEmbedding counts entity and community nodes lacking a name embedding
+	NodesWithoutEmbedding int64 `json:"nodes_without_embedding"`
+	// EdgesWithoutEmbedding counts entity edges lacking a fact embedding
+	EdgesWithoutEmbedding int64 `json:"edges_without_embedding"`
+}
+
+// newGraphStats returns a GraphStats with its maps initialized.
+func newGraphStats() *GraphStats {
+	return &GraphStats{
+		NodesByType:    make(map[string]int64),
+		EdgesByType:    make(map[string]int64),
+		NodesByGroup:   make(map[string]int64),
+		EdgesByGroup:   make(map[string]int64),
+		EpisodesPerDay: make(map[string]int64),
+		LastUpdated:    time.Now(),
+	}
 }
 
 // QueryOptions holds options for database queries.
@@ -122,82 +171,46 @@ type QueryOptions struct {
 	Filters   map[string]interface{}
 }
 
-// SearchOptions holds options for text-based search operations.
+// SearchOptions holds options for text-based search operations. The filters
+// are applied by the database query, before Limit.
 type SearchOptions struct {
 	Limit       int              `json:"limit"`
 	UseFullText bool             `json:"use_fulltext"`
 	NodeTypes   []types.NodeType `json:"node_types,omitempty"`
 	EdgeTypes   []types.EdgeType `json:"edge_types,omitempty"`
-	TimeRange   *types.TimeRange `json:"time_range,omitempty"`
+	// TimeRange filters on creation time; a zero bound is open
+	TimeRange *types.TimeRange `json:"time_range,omitempty"`
+	// GroupIDs are searched instead of the group ID argument when set
+	GroupIDs []string `json:"group_ids,omitempty"`
+	// EntityTypes keeps nodes with one of these entity types or labels
+	EntityTypes []string `json:"entity_types,omitempty"`
+	// EdgeNames keeps edges with one of these relation names
+	EdgeNames []string `json:"edge_names,omitempty"`
+	// ValidAt keeps edges valid at some time in the range; a zero bound is open
+	ValidAt *types.TimeRange `json:"valid_at,omitempty"`
+	// AsOf keeps edges valid at that time, valid_from <= AsOf < valid_to;
+	// zero disables the filter
+	AsOf time.Time `json:"as_of,omitempty"`
 }
 
 // VectorSearchOptions holds options for vector similarity search operations.
+// The filters are applied by the database query, before Limit.
 type VectorSearchOptions struct {
 	Limit     int              `json:"limit"`
 	MinScore  float64          `json:"min_score"`
 	NodeTypes []types.NodeType `json:"node_types,omitempty"`
 	EdgeTypes []types.EdgeType `json:"edge_types,omitempty"`
+	// TimeRange filters on creation time; a zero bound is open
 	TimeRange *types.TimeRange `json:"time_range,omitempty"`
-}
-
-// convertRecordToEdge converts a database record to an Edge object
-func convertRecordToEdge(record map[string]interface{}) (*types.Edge, error) {
-	edge := &types.Edge{}
-
-	// Extract basic fields
-	if uuid, ok := record["uuid"].(string); ok {
-		edge.Uuid = uuid
-	} else {
-		return nil, fmt.Errorf("missing or invalid uuid field")
-	}
-
-	if name, ok := record["name"].(string); ok {
-		edge.Name = name
-	}
-
-	if fact, ok := record["fact"].(string); ok {
-		edge.Summary = fact
-	}
-
-	if groupID, ok := record["group_id"].(string); ok {
-		edge.GroupID = groupID
-	}
-
-	// Extract source and target IDs
-	if sourceID, ok := record["source_id"].(string); ok {
-		edge.SourceID = sourceID
-	}
-	if targetID, ok := record["target_id"].(string); ok {
-		edge.TargetID = targetID
-	}
-
-	// Extract timestamps
-	if createdAt, ok := record["created_at"].(time.Time); ok {
-		edge.CreatedAt = createdAt
-	}
-	if updatedAt, ok := record["updated_at"].(time.Time); ok {
-		edge.UpdatedAt = updatedAt
-	}
-	if validFrom, ok := record["valid_from"].(time.Time); ok {
-		edge.ValidFrom = validFrom
-	}
-	if validTo, ok := record["valid_to"].(time.Time); ok {
-		edge.ValidTo = &validTo
-	}
-
-	// Set edge type - assume EntityEdge for relationships from RelatesToNode_
-	edge.Type = types.EntityEdgeType
-
-	// Extract source IDs if present
-	if sourceIDs, ok := record["source_ids"].([]interface{}); ok {
-		strSourceIDs := make([]string, len(sourceIDs))
-		for i, id := range sourceIDs {
-			if strID, ok := id.(string); ok {
-				strSourceIDs[i] = strID
-			}
-		}
-		edge.SourceIDs = strSourceIDs
-	}
-
-	return edge, nil
+	// GroupIDs are searched instead of the group ID argument when set
+	GroupIDs []string `json:"group_ids,omitempty"`
+	// EntityTypes keeps nodes with one of these entity types or labels
+	EntityTypes []string `json:"entity_types,omitempty"`
+	// EdgeNames keeps edges with one of these relation names
+	EdgeNames []string `json:"edge_names,omitempty"`
+	// ValidAt keeps edges valid at some time in the range; a zero bound is open
+	ValidAt *types.TimeRange `json:"valid_at,omitempty"`
+	// AsOf keeps edges valid at that time, valid_from <= AsOf < valid_to;
+	// zero disables the filter
+	AsOf time.Time `json:"as_of,omitempty"`
 }
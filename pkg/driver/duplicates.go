@@ -0,0 +1,145 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// DuplicateOfEdgeName is the name of the entity edges linking a duplicate
+// entity to the entity it duplicates.
+const DuplicateOfEdgeName = "IS_DUPLICATE_OF"
+
+// maxDuplicateHops bounds the IS_DUPLICATE_OF chains followed from a node.
+const maxDuplicateHops = 10
+
+// ErrDuplicatesUnsupported is returned by DuplicateUUIDs, CanonicalUUIDs and
+// DuplicateOfPairs for drivers without Cypher support.
+var ErrDuplicatesUnsupported = errors.New("driver does not support traversing duplicate entities")
+
+// DuplicateUUIDs returns the UUIDs of the entities of groupID linked to a node
+// by a chain of IS_DUPLICATE_OF edges in either direction, sorted.
+func DuplicateUUIDs(ctx context.Context, d GraphDriver, groupID, nodeUUID string) ([]string, error) {
+	seen := map[string]bool{nodeUUID: true}
+	frontier := []string{nodeUUID}
+	for hop := 0; hop < maxDuplicateHops && len(frontier) > 0; hop++ {
+		pairs, err := DuplicateOfPairs(ctx, d, groupID, frontier)
+		if err != nil {
+			return nil, err
+		}
+		frontier = nil
+		for _, pair := range pairs {
+			for _, uuid := range pair {
+				if !seen[uuid] {
+					seen[uuid] = true
+					frontier = append(frontier, uuid)
+				}
+			}
+		}
+	}
+
+	delete(seen, nodeUUID)
+	uuids := make([]string, 0, len(seen))
+	for uuid := range seen {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	return uuids, nil
+}
+
+// CanonicalUUIDs maps the nodes of uuids that duplicate another entity to
+// their canonical entity, found by following IS_DUPLICATE_OF edges from
+// duplicate to duplicated. When an entity duplicates several, the smallest
+// UUID is followed. Nodes that are not duplicates are left out of the map.
+func CanonicalUUIDs(ctx context.Context, d GraphDriver, groupID string, uuids []string) (map[string]string, error) {
+	duplicateOf := make(map[string]string)
+	queried := make(map[string]bool)
+	frontier := uuids
+	for hop := 0; hop < maxDuplicateHops && len(frontier) > 0; hop++ {
+		pairs, err := DuplicateOfPairs(ctx, d, groupID, frontier)
+		if err != nil {
+			return nil, err
+		}
+		for _, uuid := range frontier {
+			queried[uuid] = true
+		}
+		frontier = nil
+		for _, pair := range pairs {
+			source, target := pair[0], pair[1]
+			if current, ok := duplicateOf[source]; ok && current <= target {
+				continue
+			}
+			duplicateOf[source] = target
+			if !queried[target] {
+				queried[target] = true
+				frontier = append(frontier, target)
+			}
+		}
+	}
+
+	canonical := make(map[string]string)
+	for _, uuid := range uuids {
+		current := uuid
+		visited := map[string]bool{current: true}
+		for {
+			next, ok := duplicateOf[current]
+			if !ok || visited[next] {
+				break
+			}
+			visited[next] = true
+			current = next
+		}
+		if current != uuid {
+			canonical[uuid] = current
+		}
+	}
+	return canonical, nil
+}
+
+// DuplicateOfPairs returns the (duplicate, duplicated) UUIDs of the
+// IS_DUPLICATE_OF edges of groupID touching uuids.
+func DuplicateOfPairs(ctx context.Context, d GraphDriver, groupID string, uuids []string) ([][2]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var query string
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph:
+		query = `
+			MATCH (n:Entity)-[e:RELATES_TO]->(m:Entity)
+			WHERE e.name = $name AND e.group_id = $group_id AND e.deleted_at IS NULL
+			  AND (n.uuid IN $uuids OR m.uuid IN $uuids)
+			RETURN DISTINCT n.uuid AS source_uuid, m.uuid AS target_uuid
+		`
+	case GraphProviderLadybug:
+		query = `
+			MATCH (n:Entity)-[:RELATES_TO]->(e:RelatesToNode_)-[:RELATES_TO]->(m:Entity)
+			WHERE e.name = $name AND e.group_id = $group_id AND e.deleted_at IS NULL
+			  AND (n.uuid IN $uuids OR m.uuid IN $uuids)
+			RETURN DISTINCT n.uuid AS source_uuid, m.uuid AS target_uuid
+		`
+	default:
+		return nil, ErrDuplicatesUnsupported
+	}
+
+	result, err := executeGroupQuery(d, groupID, query, map[string]interface{}{
+		"name":     DuplicateOfEdgeName,
+		"group_id": groupID,
+		"uuids":    uuids,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duplicate edges: %w", err)
+	}
+
+	var pairs [][2]string
+	for _, record := range queryRecordMaps(result) {
+		source, _ := record["source_uuid"].(string)
+		target, _ := record["target_uuid"].(string)
+		if source != "" && target != "" && source != target {
+			pairs = append(pairs, [2]string{source, target})
+		}
+	}
+	return pairs, nil
+}
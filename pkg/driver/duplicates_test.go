@@ -0,0 +1,66 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// duplicateGraph is a GraphDriver stub holding IS_DUPLICATE_OF edges.
+type duplicateGraph struct {
+	queryRecorder
+	edges [][2]string
+}
+
+func (d *duplicateGraph) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	d.queryRecorder.ExecuteQuery(cypherQuery, kwargs)
+	uuids := kwargs["uuids"].([]string)
+	records := []map[string]interface{}{}
+	for _, edge := range d.edges {
+		if slices.Contains(uuids, edge[0]) || slices.Contains(uuids, edge[1]) {
+			records = append(records, map[string]interface{}{"source_uuid": edge[0], "target_uuid": edge[1]})
+		}
+	}
+	return records, nil, nil, nil
+}
+
+func TestDuplicateUUIDs(t *testing.T) {
+	d := &duplicateGraph{
+		queryRecorder: queryRecorder{provider: GraphProviderNeo4j},
+		edges:         [][2]string{{"b", "a"}, {"c", "b"}, {"x", "y"}},
+	}
+	got, err := DuplicateUUIDs(context.Background(), d, "g", "a")
+	if err != nil {
+		t.Fatalf("DuplicateUUIDs() error = %v", err)
+	}
+	if want := []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DuplicateUUIDs() = %v, want %v", got, want)
+	}
+	if d.params[0]["name"] != DuplicateOfEdgeName {
+		t.Errorf("params = %v", d.params[0])
+	}
+
+	unsupported := &queryRecorder{provider: GraphProviderSurrealDB}
+	if _, err := DuplicateUUIDs(context.Background(), unsupported, "g", "a"); !errors.Is(err, ErrDuplicatesUnsupported) {
+		t.Errorf("DuplicateUUIDs() error = %v, want ErrDuplicatesUnsupported", err)
+	}
+}
+
+func TestCanonicalUUIDs(t *testing.T) {
+	d := &duplicateGraph{
+		queryRecorder: queryRecorder{provider: GraphProviderLadybug},
+		// c duplicates b which duplicates a; d duplicates both f and e; a
+		// cycle between x and y must terminate
+		edges: [][2]string{{"c", "b"}, {"b", "a"}, {"d", "f"}, {"d", "e"}, {"x", "y"}, {"y", "x"}},
+	}
+	got, err := CanonicalUUIDs(context.Background(), d, "g", []string{"a", "c", "d", "x", "z"})
+	if err != nil {
+		t.Fatalf("CanonicalUUIDs() error = %v", err)
+	}
+	want := map[string]string{"c": "a", "d": "e", "x": "y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CanonicalUUIDs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// EdgesBetween returns the entity edges of groupID between two entities in
+// either direction, from the database of the group. Drivers without Cypher
+// support answer with their GetBetweenNodes.
+func EdgesBetween(ctx context.Context, d GraphDriver, groupID, sourceNodeID, targetNodeID string) ([]*types.Edge, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var query string
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph:
+		query = `
+			MATCH (a:Entity {uuid: $source_uuid})-[rel:RELATES_TO]-(b:Entity {uuid: $target_uuid})
+			WHERE rel.group_id = $group_id AND rel.deleted_at IS NULL
+			RETURN DISTINCT rel.uuid AS uuid
+		`
+	case GraphProviderLadybug:
+		query = `
+			MATCH (a:Entity {uuid: $source_uuid})-[:RELATES_TO]-(rel:RelatesToNode_)-[:RELATES_TO]-(b:Entity {uuid: $target_uuid})
+			WHERE rel.group_id = $group_id AND rel.deleted_at IS NULL
+			RETURN DISTINCT rel.uuid AS uuid
+		`
+	default:
+		return d.GetBetweenNodes(ctx, sourceNodeID, targetNodeID, groupID)
+	}
+
+	result, err := executeGroupQuery(d, groupID, query, map[string]interface{}{
+		"source_uuid": sourceNodeID,
+		"target_uuid": targetNodeID,
+		"group_id":    groupID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GetBetweenNodes query: %w", err)
+	}
+
+	var uuids []string
+	for _, record := range queryRecordMaps(result) {
+		if uuid, _ := record["uuid"].(string); uuid != "" {
+			uuids = append(uuids, uuid)
+		}
+	}
+	if len(uuids) == 0 {
+		return []*types.Edge{}, nil
+	}
+	// The edges are read by the driver, which decodes their properties
+	edges, err := d.GetEdges(ctx, uuids, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edges between nodes: %w", err)
+	}
+	return edges, nil
+}
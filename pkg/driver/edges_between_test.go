@@ -0,0 +1,63 @@
+package driver
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// edgesGraph is an integrityDriver whose GetEdges returns its edges of a
+// group with the given UUIDs.
+type edgesGraph struct {
+	integrityDriver
+	edges []*types.Edge
+}
+
+func (d *edgesGraph) GetEdges(ctx context.Context, edgeIDs []string, groupID string) ([]*types.Edge, error) {
+	var edges []*types.Edge
+	for _, edge := range d.edges {
+		if edge.GroupID == groupID && slices.Contains(edgeIDs, edge.Uuid) {
+			edges = append(edges, edge)
+		}
+	}
+	return edges, nil
+}
+
+func TestEdgesBetween(t *testing.T) {
+	// Neo4j and Memgraph store entity edges as relationships, Ladybug as
+	// RelatesToNode_ nodes; the records only answer the provider's shape
+	for provider, shape := range map[GraphProvider]string{
+		GraphProviderNeo4j:    "(a:Entity {uuid: $source_uuid})-[rel:RELATES_TO]-(b:Entity {uuid: $target_uuid})",
+		GraphProviderMemgraph: "(a:Entity {uuid: $source_uuid})-[rel:RELATES_TO]-(b:Entity {uuid: $target_uuid})",
+		GraphProviderLadybug:  "-[:RELATES_TO]-(rel:RelatesToNode_)-[:RELATES_TO]-",
+	} {
+		d := &edgesGraph{
+			integrityDriver: integrityDriver{
+				queryRecorder: queryRecorder{provider: provider},
+				records:       map[string][]map[string]interface{}{shape: {{"uuid": "works"}, {"uuid": "employs"}}},
+			},
+			edges: []*types.Edge{
+				types.NewEntityEdge("works", "alice", "acme", "g", "WORKS_AT", types.EntityEdgeType),
+				types.NewEntityEdge("employs", "acme", "alice", "g", "EMPLOYS", types.EntityEdgeType),
+				types.NewEntityEdge("visits", "alice", "acme", "g", "VISITS", types.EntityEdgeType),
+			},
+		}
+
+		edges, err := EdgesBetween(context.Background(), d, "g", "alice", "acme")
+		if err != nil {
+			t.Fatalf("%s: EdgesBetween() error = %v", provider, err)
+		}
+		var uuids []string
+		for _, edge := range edges {
+			uuids = append(uuids, edge.Uuid)
+		}
+		if !slices.Equal(uuids, []string{"works", "employs"}) {
+			t.Errorf("%s: EdgesBetween() = %v, want the edges in both directions", provider, uuids)
+		}
+		if params := d.params[0]; params["group_id"] != "g" || params["source_uuid"] != "alice" || params["target_uuid"] != "acme" {
+			t.Errorf("%s: params = %v", provider, params)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// ErrEmbeddingDimensionMismatch indicates an embedding whose size differs from
+// the embeddings already stored for its group.
+var ErrEmbeddingDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// ErrEmbeddingMetadataUnsupported indicates the driver cannot store embedding
+// metadata in the graph.
+var ErrEmbeddingMetadataUnsupported = errors.New("driver does not support embedding metadata")
+
+// EmbeddingMetadata records which embedding model produced the vectors of a
+// group, so that vectors of different models are never mixed.
+type EmbeddingMetadata struct {
+	GroupID    string
+	Model      string
+	Dimensions int
+	UpdatedAt  time.Time
+}
+
+// GetEmbeddingMetadata returns the embedding metadata of a group, or nil if
+// none is recorded. It returns ErrEmbeddingMetadataUnsupported for drivers
+// without Cypher support (Neo4j, Memgraph and Ladybug store it).
+func GetEmbeddingMetadata(ctx context.Context, d GraphDriver, groupID string) (*EmbeddingMetadata, error) {
+	if !supportsEmbeddingMetadata(d) {
+		return nil, ErrEmbeddingMetadataUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err := executeGroupQuery(d, groupID, `
+		MATCH (m:EmbeddingMetadata {group_id: $group_id})
+		RETURN m.model AS model, m.dimensions AS dimensions, m.updated_at AS updated_at
+	`, map[string]interface{}{"group_id": groupID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding metadata: %w", err)
+	}
+
+	for _, record := range queryRecordMaps(result) {
+		metadata := &EmbeddingMetadata{GroupID: groupID}
+		metadata.Model, _ = record["model"].(string)
+		switch dims := record["dimensions"].(type) {
+		case int64:
+			metadata.Dimensions = int(dims)
+		case int:
+			metadata.Dimensions = dims
+		case float64:
+			metadata.Dimensions = int(dims)
+		}
+		metadata.UpdatedAt, _ = record["updated_at"].(time.Time)
+		return metadata, nil
+	}
+	return nil, nil
+}
+
+// SetEmbeddingMetadata records the embedding metadata of a group, replacing
+// any previous record.
+func SetEmbeddingMetadata(ctx context.Context, d GraphDriver, metadata *EmbeddingMetadata) error {
+	if !supportsEmbeddingMetadata(d) {
+		return ErrEmbeddingMetadataUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err := executeGroupQuery(d, metadata.GroupID, `
+		MERGE (m:EmbeddingMetadata {group_id: $group_id})
+		SET m.model = $model, m.dimensions = $dimensions, m.updated_at = $updated_at
+	`, map[string]interface{}{
+		"group_id":   metadata.GroupID,
+		"model":      metadata.Model,
+		"dimensions": int64(metadata.Dimensions),
+		"updated_at": time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record embedding metadata: %w", err)
+	}
+	return nil
+}
+
+// supportsEmbeddingMetadata reports whether d can store embedding metadata.
+func supportsEmbeddingMetadata(d GraphDriver) bool {
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph, GraphProviderLadybug:
+		return true
+	}
+	return false
+}
+
+// queryRecordMaps normalizes the records returned by ExecuteQuery: Ladybug
+// returns maps, the Bolt drivers return records.
+func queryRecordMaps(result interface{}) []map[string]interface{} {
+	switch records := result.(type) {
+	case []map[string]interface{}:
+		return records
+	case []*db.Record:
+		maps := make([]map[string]interface{}, 0, len(records))
+		for _, record := range records {
+			maps = append(maps, record.AsMap())
+		}
+		return maps
+	}
+	return nil
+}
+
+// EmbeddingGuard is a GraphDriver that rejects nodes and edges whose
+// embeddings do not have the dimensions of their group. The dimensions of a
+// group are read from its embedding metadata, or recorded with model on the
+// first write of an embedding. Drivers that cannot store metadata are checked
+// against the dimensions seen during the guard's lifetime.
+type EmbeddingGuard struct {
+	GraphDriver
+	model string
+
+	mu         sync.Mutex
+	dimensions map[string]int
+}
+
+// NewEmbeddingGuard wraps inner so that writes are checked against the
+// embedding dimensions of their group. model names the embedding model in the
+// metadata recorded for new groups.
+func NewEmbeddingGuard(inner GraphDriver, model string) *EmbeddingGuard {
+	return &EmbeddingGuard{
+		GraphDriver: inner,
+		model:       model,
+		dimensions:  make(map[string]int),
+	}
+}
+
+// Unwrap returns the inner driver.
+func (g *EmbeddingGuard) Unwrap() GraphDriver {
+	return g.GraphDriver
+}
+
+// ExecuteGroupQuery executes a Cypher query against the database holding
+// groupID in the inner driver.
+func (g *EmbeddingGuard) ExecuteGroupQuery(groupID, cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	if executor, ok := g.GraphDriver.(groupQueryExecutor); ok {
+		return executor.ExecuteGroupQuery(groupID, cypherQuery, kwargs)
+	}
+	return g.GraphDriver.ExecuteQuery(cypherQuery, kwargs)
+}
+
+// Forget drops the cached dimensions of a group, for example after its
+// embeddings were recomputed with another model.
+func (g *EmbeddingGuard) Forget(groupID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.dimensions, groupID)
+}
+
+// UpsertNode checks the node's embeddings before writing it.
+func (g *EmbeddingGuard) UpsertNode(ctx context.Context, node *types.Node) error {
+	if err := g.checkNode(ctx, node); err != nil {
+		return err
+	}
+	return g.GraphDriver.UpsertNode(ctx, node)
+}
+
+// UpsertNodes checks the nodes' embeddings before writing them.
+func (g *EmbeddingGuard) UpsertNodes(ctx context.Context, nodes []*types.Node) error {
+	for _, node := range nodes {
+		if err := g.checkNode(ctx, node); err != nil {
+			return err
+		}
+	}
+	return g.GraphDriver.UpsertNodes(ctx, nodes)
+}
+
+// UpsertEdge checks the edge's embeddings before writing it.
+func (g *EmbeddingGuard) UpsertEdge(ctx context.Context, edge *types.Edge) error {
+	if err := g.checkEdge(ctx, edge); err != nil {
+		return err
+	}
+	return g.GraphDriver.UpsertEdge(ctx, edge)
+}
+
+// UpsertEdges checks the edges' embeddings before writing them.
+func (g *EmbeddingGuard) UpsertEdges(ctx context.Context, edges []*types.Edge) error {
+	for _, edge := range edges {
+		if err := g.checkEdge(ctx, edge); err != nil {
+			return err
+		}
+	}
+	return g.GraphDriver.UpsertEdges(ctx, edges)
+}
+
+func (g *EmbeddingGuard) checkNode(ctx context.Context, node *types.Node) error {
+	if node == nil {
+		return nil
+	}
+	return g.check(ctx, node.GroupID, "node "+node.Uuid, node.Embedding, node.NameEmbedding)
+}
+
+func (g *EmbeddingGuard) checkEdge(ctx context.Context, edge *types.Edge) error {
+	if edge == nil {
+		return nil
+	}
+	return g.check(ctx, edge.GroupID, "edge "+edge.Uuid, edge.Embedding, edge.FactEmbedding)
+}
+
+// check verifies that every non-empty vector has the dimensions of the group.
+func (g *EmbeddingGuard) check(ctx context.Context, groupID, record string, vectors ...[]float32) error {
+	for _, vector := range vectors {
+		if len(vector) == 0 {
+			continue
+		}
+
+		dimensions, err := g.groupDimensions(ctx, groupID, len(vector))
+		if err != nil {
+			return err
+		}
+		if len(vector) != dimensions {
+			return fmt.Errorf("%w: %s has a %d-dimensional embedding but group %q stores %d-dimensional embeddings; use a matching embedder or re-embed the group with ReembedGraph",
+				ErrEmbeddingDimensionMismatch, record, len(vector), groupID, dimensions)
+		}
+	}
+	return nil
+}
+
+// groupDimensions returns the embedding dimensions of a group, recording
+// observed as the group's dimensions when none are known yet.
+func (g *EmbeddingGuard) groupDimensions(ctx context.Context, groupID string, observed int) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if dimensions, ok := g.dimensions[groupID]; ok {
+		return dimensions, nil
+	}
+
+	metadata, err := GetEmbeddingMetadata(ctx, g.GraphDriver, groupID)
+	if err != nil && !errors.Is(err, ErrEmbeddingMetadataUnsupported) {
+		return 0, err
+	}
+	if metadata != nil && metadata.Dimensions > 0 {
+		g.dimensions[groupID] = metadata.Dimensions
+		return metadata.Dimensions, nil
+	}
+
+	if err == nil {
+		if err := SetEmbeddingMetadata(ctx, g.GraphDriver, &EmbeddingMetadata{
+			GroupID:    groupID,
+			Model:      g.model,
+			Dimensions: observed,
+		}); err != nil {
+			return 0, err
+		}
+	}
+	g.dimensions[groupID] = observed
+	return observed, nil
+}
@@ -0,0 +1,92 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestEmbeddingGuardRecordsAndEnforcesDimensions(t *testing.T) {
+	ctx := context.Background()
+	inner := newRecordStore(GraphProviderLadybug)
+	guard := NewEmbeddingGuard(inner, "text-embedding-3-small")
+
+	node := &types.Node{Uuid: "n1", GroupID: "g", NameEmbedding: make([]float32, 1536)}
+	if err := guard.UpsertNode(ctx, node); err != nil {
+		t.Fatalf("UpsertNode() error = %v", err)
+	}
+
+	metadata, err := GetEmbeddingMetadata(ctx, inner, "g")
+	if err != nil || metadata == nil {
+		t.Fatalf("GetEmbeddingMetadata() = %v, %v, want recorded metadata", metadata, err)
+	}
+	if metadata.Model != "text-embedding-3-small" || metadata.Dimensions != 1536 {
+		t.Errorf("metadata = %+v, want the first embedding's model and dimensions", metadata)
+	}
+
+	edge := &types.Edge{FactEmbedding: make([]float32, 768)}
+	edge.Uuid = "e1"
+	edge.GroupID = "g"
+	err = guard.UpsertEdge(ctx, edge)
+	if !errors.Is(err, ErrEmbeddingDimensionMismatch) {
+		t.Fatalf("UpsertEdge() error = %v, want ErrEmbeddingDimensionMismatch", err)
+	}
+	if !strings.Contains(err.Error(), "768") || !strings.Contains(err.Error(), "1536") {
+		t.Errorf("error should name both dimensions, got %v", err)
+	}
+	if inner.upserts != 1 {
+		t.Errorf("mismatched edge should not be written, got %d writes", inner.upserts)
+	}
+	inner.assertGroupQueries(t, "g")
+
+	// Other groups keep their own dimensions
+	other := &types.Node{Uuid: "n2", GroupID: "other", Embedding: make([]float32, 768)}
+	if err := guard.UpsertNode(ctx, other); err != nil {
+		t.Errorf("UpsertNode() in another group error = %v", err)
+	}
+	if metadata, _ := GetEmbeddingMetadata(ctx, inner, "other"); metadata == nil || metadata.Dimensions != 768 {
+		t.Errorf("GetEmbeddingMetadata() of another group = %+v, want 768 dimensions", metadata)
+	}
+}
+
+func TestEmbeddingGuardReadsExistingMetadata(t *testing.T) {
+	ctx := context.Background()
+	inner := newRecordStore(GraphProviderLadybug)
+	if err := SetEmbeddingMetadata(ctx, inner, &EmbeddingMetadata{GroupID: "g", Model: "nomic-embed-text", Dimensions: 768}); err != nil {
+		t.Fatalf("SetEmbeddingMetadata() error = %v", err)
+	}
+
+	guard := NewEmbeddingGuard(inner, "text-embedding-3-small")
+	node := &types.Node{Uuid: "n1", GroupID: "g", Embedding: make([]float32, 1536)}
+	if err := guard.UpsertNode(ctx, node); !errors.Is(err, ErrEmbeddingDimensionMismatch) {
+		t.Errorf("UpsertNode() error = %v, want ErrEmbeddingDimensionMismatch", err)
+	}
+
+	// Nodes without embeddings are not checked
+	if err := guard.UpsertNode(ctx, &types.Node{Uuid: "n2", GroupID: "g"}); err != nil {
+		t.Errorf("UpsertNode() without embedding error = %v", err)
+	}
+}
+
+func TestEmbeddingGuardWithoutMetadataSupport(t *testing.T) {
+	ctx := context.Background()
+	inner := newRecordStore(GraphProviderArangoDB)
+	guard := NewEmbeddingGuard(inner, "")
+
+	if _, err := GetEmbeddingMetadata(ctx, inner, "g"); !errors.Is(err, ErrEmbeddingMetadataUnsupported) {
+		t.Errorf("GetEmbeddingMetadata() error = %v, want ErrEmbeddingMetadataUnsupported", err)
+	}
+
+	if err := guard.UpsertNode(ctx, &types.Node{Uuid: "n1", GroupID: "g", Embedding: make([]float32, 3)}); err != nil {
+		t.Fatalf("UpsertNode() error = %v", err)
+	}
+	if err := guard.UpsertNode(ctx, &types.Node{Uuid: "n2", GroupID: "g", Embedding: make([]float32, 4)}); !errors.Is(err, ErrEmbeddingDimensionMismatch) {
+		t.Errorf("UpsertNode() error = %v, want mismatches caught in memory", err)
+	}
+	if len(inner.queries) != 0 {
+		t.Errorf("guard ran %d queries on a driver without embedding metadata", len(inner.queries))
+	}
+}
@@ -0,0 +1,69 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrEntityFactsUnsupported is returned by EntityFacts for drivers without
+// Cypher support.
+var ErrEntityFactsUnsupported = errors.New("driver does not support looking up the facts of entities")
+
+// EntityFact is the fact of a valid entity edge of an entity, with the name
+// of the entity at its other end.
+type EntityFact struct {
+	Neighbor string
+	Fact     string
+}
+
+// EntityFacts returns the facts of the valid entity edges of the entities of
+// groupID with the given UUIDs, keyed by entity UUID, most recent first and
+// at most limit per entity.
+func EntityFacts(ctx context.Context, d GraphDriver, groupID string, uuids []string, limit int) (map[string][]EntityFact, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var query string
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph:
+		query = `
+			MATCH (n:Entity)-[e:RELATES_TO]-(m:Entity)
+			WHERE n.uuid IN $uuids AND e.group_id = $group_id AND n.uuid <> m.uuid
+			  AND e.deleted_at IS NULL AND e.expired_at IS NULL AND e.invalid_at IS NULL
+			RETURN n.uuid AS uuid, m.name AS neighbor, e.fact AS fact
+			ORDER BY e.created_at DESC
+		`
+	case GraphProviderLadybug:
+		query = `
+			MATCH (n:Entity)-[:RELATES_TO]-(e:RelatesToNode_)-[:RELATES_TO]-(m:Entity)
+			WHERE n.uuid IN $uuids AND e.group_id = $group_id AND n.uuid <> m.uuid
+			  AND e.deleted_at IS NULL AND e.expired_at IS NULL AND e.invalid_at IS NULL
+			RETURN n.uuid AS uuid, m.name AS neighbor, e.fact AS fact
+			ORDER BY e.created_at DESC
+		`
+	default:
+		return nil, ErrEntityFactsUnsupported
+	}
+
+	result, err := executeGroupQuery(d, groupID, query, map[string]interface{}{
+		"group_id": groupID,
+		"uuids":    uuids,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facts of entities: %w", err)
+	}
+
+	facts := make(map[string][]EntityFact)
+	for _, record := range queryRecordMaps(result) {
+		uuid, _ := record["uuid"].(string)
+		fact, _ := record["fact"].(string)
+		if uuid == "" || fact == "" || len(facts[uuid]) >= limit {
+			continue
+		}
+		neighbor, _ := record["neighbor"].(string)
+		facts[uuid] = append(facts[uuid], EntityFact{Neighbor: neighbor, Fact: fact})
+	}
+	return facts, nil
+}
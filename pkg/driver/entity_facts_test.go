@@ -0,0 +1,47 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEntityFacts(t *testing.T) {
+	for provider, want := range map[GraphProvider]string{
+		GraphProviderMemgraph: "(n:Entity)-[e:RELATES_TO]-(m:Entity)",
+		GraphProviderLadybug:  "(e:RelatesToNode_)",
+	} {
+		d := &integrityDriver{
+			queryRecorder: queryRecorder{provider: provider},
+			records: map[string][]map[string]interface{}{
+				"RELATES_TO": {
+					{"uuid": "a", "neighbor": "Acme", "fact": "Alice works at Acme"},
+					{"uuid": "a", "neighbor": "Bob", "fact": "Alice knows Bob"},
+					{"uuid": "a", "neighbor": "Paris", "fact": "Alice lives in Paris"},
+					{"uuid": "b", "neighbor": "Alice", "fact": ""},
+				},
+			},
+		}
+		got, err := EntityFacts(context.Background(), d, "g", []string{"a", "b"}, 2)
+		if err != nil {
+			t.Fatalf("%s: EntityFacts() error = %v", provider, err)
+		}
+		wantFacts := map[string][]EntityFact{"a": {
+			{Neighbor: "Acme", Fact: "Alice works at Acme"},
+			{Neighbor: "Bob", Fact: "Alice knows Bob"},
+		}}
+		if !reflect.DeepEqual(got, wantFacts) {
+			t.Errorf("%s: EntityFacts() = %v, want %v", provider, got, wantFacts)
+		}
+		if !strings.Contains(d.queries[0], want) {
+			t.Errorf("%s: query = %s", provider, d.queries[0])
+		}
+	}
+
+	unsupported := &queryRecorder{provider: GraphProviderSurrealDB}
+	if _, err := EntityFacts(context.Background(), unsupported, "g", []string{"a"}, 2); !errors.Is(err, ErrEntityFactsUnsupported) {
+		t.Errorf("EntityFacts() error = %v, want ErrEntityFactsUnsupported", err)
+	}
+}
@@ -0,0 +1,58 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RemoveEntityLabels removes the labels of the former entity types of
+// entities of groupID, given by node UUID, once they have been saved with
+// their new type. Only Neo4j and Memgraph keep the labels of former types, as
+// saving a node adds the label of its type without removing others; for the
+// other drivers it does nothing.
+func RemoveEntityLabels(ctx context.Context, d GraphDriver, groupID string, formerTypes map[string]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph:
+	default:
+		return nil
+	}
+
+	uuidsByLabel := make(map[string][]string)
+	for uuid, label := range formerTypes {
+		if label != "" && label != "Entity" {
+			uuidsByLabel[label] = append(uuidsByLabel[label], uuid)
+		}
+	}
+	labels := make([]string, 0, len(uuidsByLabel))
+	for label := range uuidsByLabel {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		uuids := uuidsByLabel[label]
+		sort.Strings(uuids)
+		query := fmt.Sprintf(`
+			MATCH (n:Entity)
+			WHERE n.group_id = $group_id AND n.uuid IN $uuids
+			REMOVE n:%s
+		`, quoteLabel(label))
+		if _, err := executeGroupQuery(d, groupID, query, map[string]interface{}{
+			"group_id": groupID,
+			"uuids":    uuids,
+		}); err != nil {
+			return fmt.Errorf("failed to remove label %s: %w", label, err)
+		}
+	}
+	return nil
+}
+
+// quoteLabel quotes a label for interpolation into a Cypher query.
+func quoteLabel(label string) string {
+	return "`" + strings.ReplaceAll(label, "`", "``") + "`"
+}
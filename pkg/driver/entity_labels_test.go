@@ -0,0 +1,35 @@
+package driver
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRemoveEntityLabels(t *testing.T) {
+	d := &queryRecorder{provider: GraphProviderMemgraph}
+	err := RemoveEntityLabels(context.Background(), d, "g", map[string]string{
+		"a": "Person",
+		"b": "Entity",
+		"c": "Person",
+		"d": "Lab`Result",
+	})
+	if err != nil {
+		t.Fatalf("RemoveEntityLabels() error = %v", err)
+	}
+	if len(d.queries) != 2 {
+		t.Fatalf("queries = %v, want one per former label other than Entity", d.queries)
+	}
+	if !strings.Contains(d.queries[0], "REMOVE n:`Lab``Result`") || !reflect.DeepEqual(d.params[0]["uuids"], []string{"d"}) {
+		t.Errorf("query = %s, params = %v", d.queries[0], d.params[0])
+	}
+	if !strings.Contains(d.queries[1], "REMOVE n:`Person`") || !reflect.DeepEqual(d.params[1]["uuids"], []string{"a", "c"}) {
+		t.Errorf("query = %s, params = %v", d.queries[1], d.params[1])
+	}
+
+	ladybug := &queryRecorder{provider: GraphProviderLadybug}
+	if err := RemoveEntityLabels(context.Background(), ladybug, "g", map[string]string{"a": "Person"}); err != nil || len(ladybug.queries) != 0 {
+		t.Errorf("RemoveEntityLabels() error = %v, queries = %v, want no queries", err, ladybug.queries)
+	}
+}
@@ -0,0 +1,55 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrEpisodeEdgesUnsupported is returned by EpisodeEdgeUUIDs for drivers
+// without Cypher support.
+var ErrEpisodeEdgesUnsupported = errors.New("driver does not support looking up the edges of an episode")
+
+// EpisodeEdgeUUIDs returns the UUIDs of the entity edges of groupID listing
+// an episode among their episodes, sorted.
+func EpisodeEdgeUUIDs(ctx context.Context, d GraphDriver, groupID, episodeUUID string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var query string
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph:
+		query = `
+			MATCH (:Entity)-[e:RELATES_TO]->(:Entity)
+			WHERE e.group_id = $group_id AND $episode_uuid IN e.episodes
+			RETURN DISTINCT e.uuid AS uuid
+		`
+	case GraphProviderLadybug:
+		query = `
+			MATCH (:Entity)-[:RELATES_TO]->(e:RelatesToNode_)-[:RELATES_TO]->(:Entity)
+			WHERE e.group_id = $group_id AND list_contains(e.episodes, $episode_uuid)
+			RETURN DISTINCT e.uuid AS uuid
+		`
+	default:
+		return nil, ErrEpisodeEdgesUnsupported
+	}
+
+	result, err := executeGroupQuery(d, groupID, query, map[string]interface{}{
+		"group_id":     groupID,
+		"episode_uuid": episodeUUID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edges of episode %s: %w", episodeUUID, err)
+	}
+
+	var uuids []string
+	for _, record := range queryRecordMaps(result) {
+		if uuid, ok := record["uuid"].(string); ok && uuid != "" {
+			uuids = append(uuids, uuid)
+		}
+	}
+	sort.Strings(uuids)
+	return uuids, nil
+}
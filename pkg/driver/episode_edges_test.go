@@ -0,0 +1,38 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEpisodeEdgeUUIDs(t *testing.T) {
+	for provider, want := range map[GraphProvider]string{
+		GraphProviderNeo4j:   "$episode_uuid IN e.episodes",
+		GraphProviderLadybug: "list_contains(e.episodes, $episode_uuid)",
+	} {
+		d := &integrityDriver{
+			queryRecorder: queryRecorder{provider: provider},
+			records: map[string][]map[string]interface{}{
+				"e.episodes": {{"uuid": "edge-2"}, {"uuid": "edge-1"}},
+			},
+		}
+		got, err := EpisodeEdgeUUIDs(context.Background(), d, "g", "ep")
+		if err != nil {
+			t.Fatalf("%s: EpisodeEdgeUUIDs() error = %v", provider, err)
+		}
+		if !reflect.DeepEqual(got, []string{"edge-1", "edge-2"}) {
+			t.Errorf("%s: EpisodeEdgeUUIDs() = %v", provider, got)
+		}
+		if !strings.Contains(d.queries[0], want) || d.params[0]["episode_uuid"] != "ep" {
+			t.Errorf("%s: query = %s, params = %v", provider, d.queries[0], d.params[0])
+		}
+	}
+
+	unsupported := &queryRecorder{provider: GraphProviderSurrealDB}
+	if _, err := EpisodeEdgeUUIDs(context.Background(), unsupported, "g", "ep"); !errors.Is(err, ErrEpisodeEdgesUnsupported) {
+		t.Errorf("EpisodeEdgeUUIDs() error = %v, want ErrEpisodeEdgesUnsupported", err)
+	}
+}
@@ -0,0 +1,64 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// CountEpisodeMentions returns the number of episodes of groupID that mention
+// each of the given entity nodes through MENTIONS edges. Nodes that no episode
+// mentions are left out. Cypher drivers count all nodes in one query; other
+// drivers look up the episodic neighbors of each node.
+func CountEpisodeMentions(ctx context.Context, d GraphDriver, nodeUUIDs []string, groupID string) (map[string]int, error) {
+	counts := make(map[string]int)
+	if len(nodeUUIDs) == 0 {
+		return counts, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph, GraphProviderLadybug:
+		result, err := executeGroupQuery(d, groupID, `
+			UNWIND $node_uuids AS node_uuid
+			MATCH (episode:Episodic)-[:MENTIONS]->(n:Entity {uuid: node_uuid})
+			WHERE n.group_id = $group_id
+			RETURN n.uuid AS uuid, count(episode) AS mentions
+		`, map[string]interface{}{
+			"node_uuids": nodeUUIDs,
+			"group_id":   groupID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count episode mentions: %w", err)
+		}
+
+		for _, record := range queryRecordMaps(result) {
+			uuid, _ := record["uuid"].(string)
+			switch mentions := record["mentions"].(type) {
+			case int64:
+				counts[uuid] = int(mentions)
+			case int:
+				counts[uuid] = mentions
+			case float64:
+				counts[uuid] = int(mentions)
+			}
+		}
+		return counts, nil
+	}
+
+	for _, uuid := range nodeUUIDs {
+		related, err := d.GetRelatedNodes(ctx, uuid, groupID, []types.EdgeType{types.EpisodicEdgeType})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count episode mentions of node %s: %w", uuid, err)
+		}
+		for _, node := range related {
+			if node.Type == types.EpisodicNodeType {
+				counts[uuid]++
+			}
+		}
+	}
+	return counts, nil
+}
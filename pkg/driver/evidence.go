@@ -0,0 +1,155 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	// EpisodeChunksKey is the metadata key recording, on entity nodes and
+	// edges, the indices of the chunks of each episode that support them, as
+	// a map from episode UUID to chunk indices.
+	EpisodeChunksKey = "episode_chunks"
+	// ChunkOffsetsKey is the metadata key recording, on episodes, the
+	// [start, end) byte offsets of the chunks of their content.
+	ChunkOffsetsKey = "chunk_offsets"
+)
+
+// Evidence is a passage of an episode supporting an edge.
+type Evidence struct {
+	EpisodeUUID string    `json:"episode_uuid"`
+	EpisodeName string    `json:"episode_name"`
+	ValidAt     time.Time `json:"valid_at"`
+	// ChunkIndex is the index of the chunk of the episode, -1 when the
+	// snippet is the whole episode
+	ChunkIndex int    `json:"chunk_index"`
+	Snippet    string `json:"snippet"`
+}
+
+// GetEvidence returns the passages of the episodes supporting an edge, oldest
+// episode first: the chunks recorded in its episode_chunks metadata, or the
+// whole content of episodes without recorded chunks. Episodes that no longer
+// exist are skipped.
+func GetEvidence(ctx context.Context, d GraphDriver, edgeUUID, groupID string) ([]*Evidence, error) {
+	edge, err := d.GetEdge(ctx, edgeUUID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edge %s: %w", edgeUUID, err)
+	}
+
+	chunks := EpisodeChunks(edge.Metadata)
+	episodeUUIDs := slices.Concat(edge.Episodes, edge.SourceIDs)
+	for uuid := range chunks {
+		episodeUUIDs = append(episodeUUIDs, uuid)
+	}
+	slices.Sort(episodeUUIDs)
+	episodeUUIDs = slices.Compact(episodeUUIDs)
+	if len(episodeUUIDs) == 0 {
+		return nil, nil
+	}
+
+	episodes, err := d.GetNodes(ctx, episodeUUIDs, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get episodes of edge %s: %w", edgeUUID, err)
+	}
+	sort.Slice(episodes, func(i, j int) bool {
+		if !episodes[i].ValidFrom.Equal(episodes[j].ValidFrom) {
+			return episodes[i].ValidFrom.Before(episodes[j].ValidFrom)
+		}
+		return episodes[i].Uuid < episodes[j].Uuid
+	})
+
+	var evidence []*Evidence
+	for _, episode := range episodes {
+		offsets := ChunkOffsets(episode.Metadata)
+		added := false
+		for _, index := range chunks[episode.Uuid] {
+			if index < 0 || index >= len(offsets) {
+				continue
+			}
+			start, end := offsets[index][0], offsets[index][1]
+			if start < 0 || end > len(episode.Content) || start > end {
+				continue
+			}
+			evidence = append(evidence, &Evidence{
+				EpisodeUUID: episode.Uuid,
+				EpisodeName: episode.Name,
+				ValidAt:     episode.ValidFrom,
+				ChunkIndex:  index,
+				Snippet:     episode.Content[start:end],
+			})
+			added = true
+		}
+		if !added {
+			evidence = append(evidence, &Evidence{
+				EpisodeUUID: episode.Uuid,
+				EpisodeName: episode.Name,
+				ValidAt:     episode.ValidFrom,
+				ChunkIndex:  -1,
+				Snippet:     episode.Content,
+			})
+		}
+	}
+	return evidence, nil
+}
+
+// EpisodeChunks returns the episode_chunks metadata of a node or edge, as
+// stored or as decoded from JSON.
+func EpisodeChunks(metadata map[string]interface{}) map[string][]int {
+	chunks := make(map[string][]int)
+	switch value := metadata[EpisodeChunksKey].(type) {
+	case map[string][]int:
+		for uuid, indices := range value {
+			chunks[uuid] = slices.Clone(indices)
+		}
+	case map[string]interface{}:
+		for uuid, indices := range value {
+			chunks[uuid] = intsFromJSON(indices)
+		}
+	}
+	return chunks
+}
+
+// ChunkOffsets returns the chunk_offsets metadata of an episode, as stored or
+// as decoded from JSON.
+func ChunkOffsets(metadata map[string]interface{}) [][2]int {
+	switch value := metadata[ChunkOffsetsKey].(type) {
+	case [][2]int:
+		return value
+	case []interface{}:
+		offsets := make([][2]int, 0, len(value))
+		for _, pair := range value {
+			bounds := intsFromJSON(pair)
+			if len(bounds) != 2 {
+				return nil
+			}
+			offsets = append(offsets, [2]int{bounds[0], bounds[1]})
+		}
+		return offsets
+	}
+	return nil
+}
+
+// intsFromJSON converts a list of numbers decoded from JSON.
+func intsFromJSON(value interface{}) []int {
+	switch v := value.(type) {
+	case []int:
+		return slices.Clone(v)
+	case []interface{}:
+		ints := make([]int, 0, len(v))
+		for _, item := range v {
+			switch n := item.(type) {
+			case float64:
+				ints = append(ints, int(n))
+			case int:
+				ints = append(ints, n)
+			case int64:
+				ints = append(ints, int(n))
+			}
+		}
+		return ints
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// evidenceStore is a GraphDriver stub holding an edge and its episodes.
+type evidenceStore struct {
+	GraphDriver
+	edge  *types.Edge
+	nodes map[string]*types.Node
+}
+
+func (d *evidenceStore) GetEdge(ctx context.Context, edgeID, groupID string) (*types.Edge, error) {
+	return d.edge, nil
+}
+
+func (d *evidenceStore) GetNodes(ctx context.Context, nodeIDs []string, groupID string) ([]*types.Node, error) {
+	var nodes []*types.Node
+	for _, id := range nodeIDs {
+		if node, ok := d.nodes[id]; ok {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+// jsonMetadata round-trips metadata through JSON, as drivers store it.
+func jsonMetadata(t *testing.T, metadata map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestGetEvidence(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.AddDate(0, 1, 0)
+	d := &evidenceStore{
+		edge: &types.Edge{
+			BaseEdge: types.BaseEdge{
+				Uuid: "edge",
+				Metadata: jsonMetadata(t, map[string]interface{}{
+					EpisodeChunksKey: map[string][]int{"chunked": {1}},
+				}),
+			},
+			Episodes: []string{"chunked", "whole", "deleted"},
+		},
+		nodes: map[string]*types.Node{
+			"chunked": {
+				Uuid:      "chunked",
+				Name:      "notes",
+				Content:   "Alice met Bob.\nBob works at Acme.",
+				ValidFrom: newer,
+				Metadata: jsonMetadata(t, map[string]interface{}{
+					ChunkOffsetsKey: [][2]int{{0, 14}, {15, 33}},
+				}),
+			},
+			"whole": {Uuid: "whole", Name: "email", Content: "Bob joined Acme.", ValidFrom: older},
+		},
+	}
+
+	got, err := GetEvidence(context.Background(), d, "edge", "g")
+	if err != nil {
+		t.Fatalf("GetEvidence() error = %v", err)
+	}
+	want := []*Evidence{
+		{EpisodeUUID: "whole", EpisodeName: "email", ValidAt: older, ChunkIndex: -1, Snippet: "Bob joined Acme."},
+		{EpisodeUUID: "chunked", EpisodeName: "notes", ValidAt: newer, ChunkIndex: 1, Snippet: "Bob works at Acme."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetEvidence() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ExpireEdges marks the entity edges of a group as expired at the given time,
+// keeping their other properties. Edges already invalid keep their
+// invalidation time. Cypher drivers update all edges in one query; other
+// drivers read and upsert each edge.
+func ExpireEdges(ctx context.Context, d GraphDriver, groupID string, edgeUUIDs []string, at time.Time) error {
+	if len(edgeUUIDs) == 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var query string
+	params := map[string]interface{}{
+		"uuids":    edgeUUIDs,
+		"group_id": groupID,
+	}
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph:
+		query = `
+			MATCH ()-[r:RELATES_TO]->()
+			WHERE r.uuid IN $uuids AND r.group_id = $group_id
+			SET r.expired_at = $at,
+			    r.valid_to = coalesce(r.valid_to, $at),
+			    r.invalid_at = coalesce(r.invalid_at, $at)
+		`
+		params["at"] = boltTimestamp(at)
+	case GraphProviderLadybug:
+		query = `
+			MATCH (e:RelatesToNode_)
+			WHERE e.uuid IN $uuids AND e.group_id = $group_id
+			SET e.expired_at = $at,
+			    e.invalid_at = coalesce(e.invalid_at, $at)
+		`
+		params["at"] = at.UTC()
+	}
+
+	if query != "" {
+		if _, err := executeGroupQuery(d, groupID, query, params); err != nil {
+			return fmt.Errorf("failed to expire edges: %w", err)
+		}
+		return nil
+	}
+
+	for _, uuid := range edgeUUIDs {
+		edge, err := d.GetEdge(ctx, uuid, groupID)
+		if err != nil {
+			return fmt.Errorf("failed to get edge %s: %w", uuid, err)
+		}
+		expiredAt := at.UTC()
+		edge.ExpiredAt = &expiredAt
+		if edge.ValidTo == nil {
+			edge.ValidTo = &expiredAt
+		}
+		if edge.InvalidAt == nil {
+			edge.InvalidAt = &expiredAt
+		}
+		if err := d.UpsertEdge(ctx, edge); err != nil {
+			return fmt.Errorf("failed to expire edge %s: %w", uuid, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package driver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// edgeStore is a GraphDriver stub without Cypher support holding edges.
+type edgeStore struct {
+	GraphDriver
+	edges map[string]*types.Edge
+}
+
+func (d *edgeStore) Provider() GraphProvider {
+	return GraphProviderSurrealDB
+}
+
+func (d *edgeStore) GetEdge(ctx context.Context, edgeID, groupID string) (*types.Edge, error) {
+	edge := *d.edges[edgeID]
+	return &edge, nil
+}
+
+func (d *edgeStore) UpsertEdge(ctx context.Context, edge *types.Edge) error {
+	d.edges[edge.Uuid] = edge
+	return nil
+}
+
+func TestExpireEdges(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for provider, want := range map[GraphProvider]interface{}{
+		GraphProviderNeo4j:   "2024-03-01T12:00:00Z",
+		GraphProviderLadybug: at,
+	} {
+		d := &queryRecorder{provider: provider}
+		if err := ExpireEdges(context.Background(), d, "g", []string{"e1", "e2"}, at); err != nil {
+			t.Fatalf("%s: ExpireEdges() error = %v", provider, err)
+		}
+		if len(d.queries) != 1 {
+			t.Fatalf("%s: ran %d queries, want 1", provider, len(d.queries))
+		}
+		if params := d.params[0]; params["at"] != want || params["group_id"] != "g" {
+			t.Errorf("%s: params = %v", provider, params)
+		}
+	}
+
+	invalidAt := at.Add(-time.Hour)
+	store := &edgeStore{edges: map[string]*types.Edge{
+		"e1": {BaseEdge: types.BaseEdge{Uuid: "e1"}},
+		"e2": {BaseEdge: types.BaseEdge{Uuid: "e2"}, InvalidAt: &invalidAt},
+	}}
+	if err := ExpireEdges(context.Background(), store, "g", []string{"e1", "e2"}, at); err != nil {
+		t.Fatalf("ExpireEdges() error = %v", err)
+	}
+	for uuid, edge := range store.edges {
+		if edge.ExpiredAt == nil || !edge.ExpiredAt.Equal(at) || edge.ValidTo == nil {
+			t.Errorf("edge %s was not expired: %+v", uuid, edge)
+		}
+	}
+	if !store.edges["e2"].InvalidAt.Equal(invalidAt) {
+		t.Errorf("InvalidAt = %v, want %v", store.edges["e2"].InvalidAt, invalidAt)
+	}
+}
@@ -0,0 +1,53 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrGroupCommunitiesUnsupported indicates the driver cannot remove the
+// communities of a single group.
+var ErrGroupCommunitiesUnsupported = errors.New("driver does not support removing the communities of a group")
+
+// Queries removing the communities of a group and their HAS_MEMBER edges,
+// by provider.
+var groupCommunitiesRemovalQueries = map[GraphProvider][]string{
+	GraphProviderNeo4j:    {cypherRemoveGroupCommunitiesQuery},
+	GraphProviderMemgraph: {cypherRemoveGroupCommunitiesQuery},
+	GraphProviderLadybug:  {cypherRemoveGroupCommunitiesQuery},
+	GraphProviderArangoDB: {
+		`FOR e IN HAS_MEMBER FILTER e.group_id == @group_id REMOVE e IN HAS_MEMBER`,
+		`FOR c IN Community FILTER c.group_id == @group_id REMOVE c IN Community`,
+	},
+	GraphProviderSurrealDB: {
+		`DELETE HAS_MEMBER WHERE group_id = $group_id; DELETE Community WHERE group_id = $group_id;`,
+	},
+}
+
+const cypherRemoveGroupCommunitiesQuery = `
+	MATCH (c:Community)
+	WHERE c.group_id = $group_id
+	DETACH DELETE c
+`
+
+// RemoveGroupCommunities removes the communities of a group and their
+// HAS_MEMBER edges. Unlike GraphDriver.RemoveCommunities, the communities of
+// other groups are kept.
+func RemoveGroupCommunities(ctx context.Context, d GraphDriver, groupID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	queries, ok := groupCommunitiesRemovalQueries[d.Provider()]
+	if !ok {
+		return ErrGroupCommunitiesUnsupported
+	}
+
+	params := map[string]interface{}{"group_id": groupID}
+	for _, query := range queries {
+		if _, err := executeGroupQuery(d, groupID, query, params); err != nil {
+			return fmt.Errorf("failed to remove communities of group %s: %w", groupID, err)
+		}
+	}
+	return nil
+}
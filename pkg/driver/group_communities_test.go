@@ -0,0 +1,29 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRemoveGroupCommunities(t *testing.T) {
+	for provider, queries := range groupCommunitiesRemovalQueries {
+		d := &queryRecorder{provider: provider}
+		if err := RemoveGroupCommunities(context.Background(), d, "g"); err != nil {
+			t.Fatalf("%s: RemoveGroupCommunities() error = %v", provider, err)
+		}
+		if len(d.queries) != len(queries) {
+			t.Fatalf("%s: ran %d queries, want %d", provider, len(d.queries), len(queries))
+		}
+		for _, params := range d.params {
+			if params["group_id"] != "g" {
+				t.Errorf("%s: params = %v, want group_id g", provider, params)
+			}
+		}
+	}
+
+	d := &queryRecorder{provider: GraphProviderNeptune}
+	if err := RemoveGroupCommunities(context.Background(), d, "g"); !errors.Is(err, ErrGroupCommunitiesUnsupported) {
+		t.Errorf("RemoveGroupCommunities() error = %v, want ErrGroupCommunitiesUnsupported", err)
+	}
+}
@@ -0,0 +1,23 @@
+package driver
+
+// groupQueryExecutor is implemented by drivers that keep groups in their own
+// databases (Neo4j with per-group databases), and by wrapping drivers that
+// forward the group to the driver they wrap.
+type groupQueryExecutor interface {
+	// ExecuteGroupQuery executes a Cypher query against the database
+	// holding groupID.
+	ExecuteGroupQuery(groupID, cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error)
+}
+
+// executeGroupQuery executes a query on the records of a group, in the
+// database of the group for drivers keeping groups in their own databases.
+// Helpers working on the records of a group run their queries through it
+// rather than ExecuteQuery, which only reaches the default database.
+func executeGroupQuery(d GraphDriver, groupID, query string, params map[string]interface{}) (interface{}, error) {
+	if executor, ok := d.(groupQueryExecutor); ok {
+		result, _, _, err := executor.ExecuteGroupQuery(groupID, query, params)
+		return result, err
+	}
+	result, _, _, err := d.ExecuteQuery(query, params)
+	return result, err
+}
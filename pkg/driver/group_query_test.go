@@ -0,0 +1,94 @@
+package driver
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// groupDatabaseDriver is a GraphDriver stub keeping groups in their own
+// databases, recording the database each query runs in.
+type groupDatabaseDriver struct {
+	GraphDriver
+	groupDatabases map[string]string
+	databases      []string
+}
+
+func (d *groupDatabaseDriver) Provider() GraphProvider {
+	return GraphProviderNeo4j
+}
+
+func (d *groupDatabaseDriver) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	d.databases = append(d.databases, "neo4j")
+	return []map[string]interface{}{}, nil, nil, nil
+}
+
+func (d *groupDatabaseDriver) ExecuteGroupQuery(groupID, cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	database, ok := d.groupDatabases[groupID]
+	if !ok {
+		database = "neo4j"
+	}
+	d.databases = append(d.databases, database)
+	return []map[string]interface{}{}, nil, nil, nil
+}
+
+func TestGroupHelpersRunInGroupDatabase(t *testing.T) {
+	ctx := context.Background()
+	helpers := []struct {
+		name string
+		call func(d GraphDriver, groupID string) error
+	}{
+		{"SaveSearch", func(d GraphDriver, groupID string) error {
+			return SaveSearch(ctx, d, &SavedSearch{GroupID: groupID, Name: "recent"})
+		}},
+		{"ListPendingMerges", func(d GraphDriver, groupID string) error {
+			_, err := ListPendingMerges(ctx, d, groupID)
+			return err
+		}},
+		{"SetPromptVersion", func(d GraphDriver, groupID string) error {
+			return SetPromptVersion(ctx, d, groupID, "v2")
+		}},
+		{"SetEmbeddingMetadata", func(d GraphDriver, groupID string) error {
+			return SetEmbeddingMetadata(ctx, d, &EmbeddingMetadata{GroupID: groupID, Model: "m", Dimensions: 3})
+		}},
+		{"ExpireEdges", func(d GraphDriver, groupID string) error {
+			return ExpireEdges(ctx, d, groupID, []string{"e1"}, time.Now())
+		}},
+		{"EdgesBetween", func(d GraphDriver, groupID string) error {
+			_, err := EdgesBetween(ctx, d, groupID, "alice", "acme")
+			return err
+		}},
+		{"DuplicateOfPairs", func(d GraphDriver, groupID string) error {
+			_, err := DuplicateOfPairs(ctx, d, groupID, []string{"alice"})
+			return err
+		}},
+	}
+	wrappers := []struct {
+		name string
+		wrap func(d GraphDriver) GraphDriver
+	}{
+		{"driver", func(d GraphDriver) GraphDriver { return d }},
+		{"WrappedDriver", func(d GraphDriver) GraphDriver { return Wrap(d, CacheMiddleware(time.Minute, 0)) }},
+		{"EmbeddingGuard", func(d GraphDriver) GraphDriver { return NewEmbeddingGuard(d, "m") }},
+	}
+
+	for _, helper := range helpers {
+		for _, wrapper := range wrappers {
+			for groupID, want := range map[string]string{"tenant-a": "tenanta", "other": "neo4j"} {
+				inner := &groupDatabaseDriver{groupDatabases: map[string]string{"tenant-a": "tenanta"}}
+				if err := helper.call(wrapper.wrap(inner), groupID); err != nil {
+					t.Fatalf("%s through %s: error = %v", helper.name, wrapper.name, err)
+				}
+				if len(inner.databases) == 0 {
+					t.Fatalf("%s through %s ran no query", helper.name, wrapper.name)
+				}
+				for _, database := range inner.databases {
+					if database != want {
+						t.Errorf("%s through %s for group %s ran in %v, want %s", helper.name, wrapper.name, groupID, inner.databases, want)
+						break
+					}
+				}
+			}
+		}
+	}
+}
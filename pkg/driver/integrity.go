@@ -0,0 +1,307 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrIntegrityCheckUnsupported indicates the driver cannot check the integrity
+// of the graph.
+var ErrIntegrityCheckUnsupported = errors.New("driver does not support integrity checks")
+
+// Mention identifies a MENTIONS edge by its endpoints.
+type Mention struct {
+	EpisodeUUID string
+	EntityUUID  string
+}
+
+// IntegrityIssues lists the records of a group that break the invariants of
+// the graph.
+type IntegrityIssues struct {
+	// DanglingEdges are the UUIDs of entity edges with a missing or deleted
+	// endpoint
+	DanglingEdges []string
+	// DanglingMentions are MENTIONS edges from a deleted episode or to a
+	// deleted entity
+	DanglingMentions []Mention
+	// MissingEmbeddings are the UUIDs of entity and community nodes without a
+	// name embedding
+	MissingEmbeddings []string
+	// DuplicateUUIDs are UUIDs shared by several nodes
+	DuplicateUUIDs []string
+}
+
+// Empty reports whether no issue was found.
+func (i *IntegrityIssues) Empty() bool {
+	return len(i.DanglingEdges) == 0 && len(i.DanglingMentions) == 0 &&
+		len(i.MissingEmbeddings) == 0 && len(i.DuplicateUUIDs) == 0
+}
+
+// integrityQueries are the statements of an integrity check. Each query
+// returns uuid columns, except mentions which returns episode_uuid and
+// entity_uuid.
+type integrityQueries struct {
+	danglingEdges     []string
+	danglingMentions  string
+	missingEmbeddings []string
+	// nodeUUIDs list the UUIDs of nodes, counted in Go for duplicates;
+	// duplicateUUIDs returns the duplicates directly
+	nodeUUIDs      []string
+	duplicateUUIDs string
+}
+
+var boltIntegrityQueries = integrityQueries{
+	danglingEdges: []string{`
+		MATCH (s)-[r:RELATES_TO]->(t)
+		WHERE r.group_id = $group_id AND r.deleted_at IS NULL
+		  AND (s.deleted_at IS NOT NULL OR t.deleted_at IS NOT NULL)
+		RETURN r.uuid AS uuid
+	`},
+	danglingMentions: `
+		MATCH (e:Episodic)-[m:MENTIONS]->(n:Entity)
+		WHERE n.group_id = $group_id AND m.deleted_at IS NULL
+		  AND (e.deleted_at IS NOT NULL OR n.deleted_at IS NOT NULL)
+		RETURN e.uuid AS episode_uuid, n.uuid AS entity_uuid
+	`,
+	missingEmbeddings: []string{`
+		MATCH (n)
+		WHERE (n:Entity OR n:Community) AND n.group_id = $group_id
+		  AND n.deleted_at IS NULL AND n.name_embedding IS NULL
+		RETURN n.uuid AS uuid
+	`},
+	duplicateUUIDs: `
+		MATCH (n)
+		WHERE n.group_id = $group_id AND n.deleted_at IS NULL
+		WITH n.uuid AS uuid, count(*) AS copies
+		WHERE copies > 1
+		RETURN uuid
+	`,
+}
+
+// Ladybug keys each table by uuid, so duplicates can only span tables.
+var ladybugIntegrityQueries = integrityQueries{
+	danglingEdges: []string{
+		`
+		MATCH (e:RelatesToNode_)
+		WHERE e.group_id = $group_id AND e.deleted_at IS NULL
+		  AND (NOT EXISTS { MATCH (:Entity)-[:RELATES_TO]->(e) } OR NOT EXISTS { MATCH (e)-[:RELATES_TO]->(:Entity) })
+		RETURN e.uuid AS uuid
+		`,
+		`
+		MATCH (s:Entity)-[:RELATES_TO]->(e:RelatesToNode_)-[:RELATES_TO]->(t:Entity)
+		WHERE e.group_id = $group_id AND e.deleted_at IS NULL
+		  AND (s.deleted_at IS NOT NULL OR t.deleted_at IS NOT NULL)
+		RETURN e.uuid AS uuid
+		`,
+	},
+	danglingMentions: `
+		MATCH (e:Episodic)-[m:MENTIONS]->(n:Entity)
+		WHERE n.group_id = $group_id
+		  AND (e.deleted_at IS NOT NULL OR n.deleted_at IS NOT NULL)
+		RETURN e.uuid AS episode_uuid, n.uuid AS entity_uuid
+	`,
+	missingEmbeddings: []string{
+		`
+		MATCH (n:Entity)
+		WHERE n.group_id = $group_id AND n.deleted_at IS NULL
+		  AND (n.name_embedding IS NULL OR size(n.name_embedding) = 0)
+		RETURN n.uuid AS uuid
+		`,
+		`
+		MATCH (n:Community)
+		WHERE n.group_id = $group_id AND n.deleted_at IS NULL
+		  AND (n.name_embedding IS NULL OR size(n.name_embedding) = 0)
+		RETURN n.uuid AS uuid
+		`,
+	},
+	nodeUUIDs: []string{
+		`MATCH (n:Entity) WHERE n.group_id = $group_id AND n.deleted_at IS NULL RETURN n.uuid AS uuid`,
+		`MATCH (n:Episodic) WHERE n.group_id = $group_id AND n.deleted_at IS NULL RETURN n.uuid AS uuid`,
+		`MATCH (n:Community) WHERE n.group_id = $group_id AND n.deleted_at IS NULL RETURN n.uuid AS uuid`,
+		`MATCH (n:RelatesToNode_) WHERE n.group_id = $group_id AND n.deleted_at IS NULL RETURN n.uuid AS uuid`,
+	},
+}
+
+// FindIntegrityIssues checks the records of a group for dangling entity
+// edges, dangling MENTIONS edges, nodes missing embeddings and duplicate
+// UUIDs. It returns ErrIntegrityCheckUnsupported for drivers without Cypher
+// support.
+func FindIntegrityIssues(ctx context.Context, d GraphDriver, groupID string) (*IntegrityIssues, error) {
+	var queries integrityQueries
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph:
+		queries = boltIntegrityQueries
+	case GraphProviderLadybug:
+		queries = ladybugIntegrityQueries
+	default:
+		return nil, ErrIntegrityCheckUnsupported
+	}
+
+	params := map[string]interface{}{"group_id": groupID}
+	uuids := func(statements []string) ([]string, error) {
+		seen := make(map[string]bool)
+		var found []string
+		for _, statement := range statements {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			result, err := executeGroupQuery(d, groupID, statement, params)
+			if err != nil {
+				return nil, err
+			}
+			for _, record := range queryRecordMaps(result) {
+				if uuid, _ := record["uuid"].(string); uuid != "" && !seen[uuid] {
+					seen[uuid] = true
+					found = append(found, uuid)
+				}
+			}
+		}
+		sort.Strings(found)
+		return found, nil
+	}
+
+	issues := &IntegrityIssues{}
+	var err error
+	if issues.DanglingEdges, err = uuids(queries.danglingEdges); err != nil {
+		return nil, fmt.Errorf("failed to find dangling edges: %w", err)
+	}
+	if issues.MissingEmbeddings, err = uuids(queries.missingEmbeddings); err != nil {
+		return nil, fmt.Errorf("failed to find nodes missing embeddings: %w", err)
+	}
+
+	result, err := executeGroupQuery(d, groupID, queries.danglingMentions, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dangling mentions: %w", err)
+	}
+	for _, record := range queryRecordMaps(result) {
+		mention := Mention{}
+		mention.EpisodeUUID, _ = record["episode_uuid"].(string)
+		mention.EntityUUID, _ = record["entity_uuid"].(string)
+		issues.DanglingMentions = append(issues.DanglingMentions, mention)
+	}
+
+	if queries.duplicateUUIDs != "" {
+		if issues.DuplicateUUIDs, err = uuids([]string{queries.duplicateUUIDs}); err != nil {
+			return nil, fmt.Errorf("failed to find duplicate UUIDs: %w", err)
+		}
+		return issues, nil
+	}
+	copies := make(map[string]int)
+	for _, statement := range queries.nodeUUIDs {
+		result, err := executeGroupQuery(d, groupID, statement, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find duplicate UUIDs: %w", err)
+		}
+		for _, record := range queryRecordMaps(result) {
+			if uuid, _ := record["uuid"].(string); uuid != "" {
+				copies[uuid]++
+			}
+		}
+	}
+	for uuid, count := range copies {
+		if count > 1 {
+			issues.DuplicateUUIDs = append(issues.DuplicateUUIDs, uuid)
+		}
+	}
+	sort.Strings(issues.DuplicateUUIDs)
+	return issues, nil
+}
+
+// RepairIntegrityIssues removes the dangling entity edges and MENTIONS edges
+// of issues, tombstoning them when the driver soft-deletes and the store
+// supports it. Missing embeddings and duplicate UUIDs need the embedder or a
+// decision about which node to keep, so they are left as they are.
+func RepairIntegrityIssues(ctx context.Context, d GraphDriver, groupID string, issues *IntegrityIssues) error {
+	softDeletes := false
+	if softDeleter, ok := AsSoftDeleter(d); ok {
+		softDeletes = softDeleter.SoftDeleteEnabled()
+	}
+
+	var edgesQuery, mentionsQuery string
+	params := map[string]interface{}{
+		"group_id": groupID,
+		"uuids":    issues.DanglingEdges,
+	}
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph:
+		edgesQuery = `
+			MATCH (s)-[r:RELATES_TO]->(t)
+			WHERE r.uuid IN $uuids AND r.group_id = $group_id
+			DELETE r
+		`
+		mentionsQuery = `
+			UNWIND $mentions AS mention
+			MATCH (e:Episodic {uuid: mention.episode_uuid})-[m:MENTIONS]->(n:Entity {uuid: mention.entity_uuid})
+			WHERE n.group_id = $group_id
+			DELETE m
+		`
+		if softDeletes {
+			// Tombstoning with the endpoint's time lets RestoreDeleted revive both
+			edgesQuery = `
+				MATCH (s)-[r:RELATES_TO]->(t)
+				WHERE r.uuid IN $uuids AND r.group_id = $group_id
+				SET r.deleted_at = coalesce(s.deleted_at, t.deleted_at, $deleted_at)
+			`
+			mentionsQuery = `
+				UNWIND $mentions AS mention
+				MATCH (e:Episodic {uuid: mention.episode_uuid})-[m:MENTIONS]->(n:Entity {uuid: mention.entity_uuid})
+				WHERE n.group_id = $group_id
+				SET m.deleted_at = coalesce(e.deleted_at, n.deleted_at, $deleted_at)
+			`
+		}
+		params["deleted_at"] = boltTimestamp(time.Now())
+	case GraphProviderLadybug:
+		edgesQuery = `
+			MATCH (e:RelatesToNode_)
+			WHERE e.uuid IN $uuids AND e.group_id = $group_id
+			DETACH DELETE e
+		`
+		if softDeletes {
+			edgesQuery = `
+				MATCH (e:RelatesToNode_)
+				WHERE e.uuid IN $uuids AND e.group_id = $group_id
+				SET e.deleted_at = $deleted_at
+			`
+		}
+		// MENTIONS rows have no tombstone column
+		mentionsQuery = `
+			UNWIND $mentions AS mention
+			MATCH (e:Episodic)-[m:MENTIONS]->(n:Entity)
+			WHERE e.uuid = mention.episode_uuid AND n.uuid = mention.entity_uuid AND n.group_id = $group_id
+			DELETE m
+		`
+		params["deleted_at"] = time.Now().UTC()
+	default:
+		return ErrIntegrityCheckUnsupported
+	}
+
+	if len(issues.DanglingEdges) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := executeGroupQuery(d, groupID, edgesQuery, params); err != nil {
+			return fmt.Errorf("failed to remove dangling edges: %w", err)
+		}
+	}
+
+	if len(issues.DanglingMentions) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		mentions := make([]map[string]interface{}, len(issues.DanglingMentions))
+		for i, mention := range issues.DanglingMentions {
+			mentions[i] = map[string]interface{}{
+				"episode_uuid": mention.EpisodeUUID,
+				"entity_uuid":  mention.EntityUUID,
+			}
+		}
+		params["mentions"] = mentions
+		if _, err := executeGroupQuery(d, groupID, mentionsQuery, params); err != nil {
+			return fmt.Errorf("failed to remove dangling mentions: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// integrityDriver is a GraphDriver stub returning the records of the first
+// pattern its query contains.
+type integrityDriver struct {
+	queryRecorder
+	records map[string][]map[string]interface{}
+}
+
+func (d *integrityDriver) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	d.queryRecorder.ExecuteQuery(cypherQuery, kwargs)
+	for pattern, records := range d.records {
+		if strings.Contains(cypherQuery, pattern) {
+			return records, nil, nil, nil
+		}
+	}
+	return []map[string]interface{}{}, nil, nil, nil
+}
+
+func TestFindIntegrityIssues(t *testing.T) {
+	d := &integrityDriver{
+		queryRecorder: queryRecorder{provider: GraphProviderLadybug},
+		records: map[string][]map[string]interface{}{
+			"NOT EXISTS":                {{"uuid": "edge-2"}, {"uuid": "edge-1"}},
+			"t.deleted_at IS NOT NULL":  {{"uuid": "edge-1"}},
+			"[m:MENTIONS]":              {{"episode_uuid": "ep", "entity_uuid": "ent"}},
+			"size(n.name_embedding)":    {{"uuid": "bare"}},
+			"MATCH (n:Entity) WHERE":    {{"uuid": "a"}, {"uuid": "b"}},
+			"MATCH (n:Episodic) WHERE":  {{"uuid": "a"}},
+			"MATCH (n:Community) WHERE": {{"uuid": "c"}},
+		},
+	}
+	got, err := FindIntegrityIssues(context.Background(), d, "g")
+	if err != nil {
+		t.Fatalf("FindIntegrityIssues() error = %v", err)
+	}
+	want := &IntegrityIssues{
+		DanglingEdges:     []string{"edge-1", "edge-2"},
+		DanglingMentions:  []Mention{{EpisodeUUID: "ep", EntityUUID: "ent"}},
+		MissingEmbeddings: []string{"bare"},
+		DuplicateUUIDs:    []string{"a"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindIntegrityIssues() = %+v, want %+v", got, want)
+	}
+
+	unsupported := &queryRecorder{provider: GraphProviderSurrealDB}
+	if _, err := FindIntegrityIssues(context.Background(), unsupported, "g"); !errors.Is(err, ErrIntegrityCheckUnsupported) {
+		t.Errorf("FindIntegrityIssues() error = %v, want ErrIntegrityCheckUnsupported", err)
+	}
+}
+
+func TestRepairIntegrityIssues(t *testing.T) {
+	d := &queryRecorder{provider: GraphProviderLadybug}
+	issues := &IntegrityIssues{
+		DanglingEdges:     []string{"edge-1"},
+		DanglingMentions:  []Mention{{EpisodeUUID: "ep", EntityUUID: "ent"}},
+		MissingEmbeddings: []string{"bare"},
+	}
+	if err := RepairIntegrityIssues(context.Background(), d, "g", issues); err != nil {
+		t.Fatalf("RepairIntegrityIssues() error = %v", err)
+	}
+	if len(d.queries) != 2 {
+		t.Fatalf("ran %d queries, want 2", len(d.queries))
+	}
+	if !strings.Contains(d.queries[0], "DETACH DELETE e") {
+		t.Errorf("edges query = %s", d.queries[0])
+	}
+	mentions, _ := d.params[1]["mentions"].([]map[string]interface{})
+	if len(mentions) != 1 || mentions[0]["episode_uuid"] != "ep" || mentions[0]["entity_uuid"] != "ent" {
+		t.Errorf("mentions = %v", d.params[1]["mentions"])
+	}
+
+	empty := &queryRecorder{provider: GraphProviderNeo4j}
+	if err := RepairIntegrityIssues(context.Background(), empty, "g", &IntegrityIssues{}); err != nil || len(empty.queries) != 0 {
+		t.Errorf("RepairIntegrityIssues() error = %v, queries = %d", err, len(empty.queries))
+	}
+}
@@ -0,0 +1,61 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidatedEdgesUnsupported indicates the driver cannot search edge
+// metadata for invalidations.
+var ErrInvalidatedEdgesUnsupported = errors.New("driver does not support listing invalidated edges")
+
+// ListInvalidatedEdgeMetadata returns the metadata of the entity edges of a
+// group whose metadata contains key, by edge UUID. Edge operations record the
+// contradicting edge of an invalidated edge under such a key. It returns
+// ErrInvalidatedEdgesUnsupported for drivers without Cypher support.
+func ListInvalidatedEdgeMetadata(ctx context.Context, d GraphDriver, groupID, key string) (map[string]map[string]interface{}, error) {
+	var query string
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph:
+		query = `
+			MATCH ()-[r:RELATES_TO]->()
+			WHERE r.group_id = $group_id AND r.metadata CONTAINS $marker
+			RETURN r.uuid AS uuid, r.metadata AS metadata
+		`
+	case GraphProviderLadybug:
+		query = `
+			MATCH (r:RelatesToNode_)
+			WHERE r.group_id = $group_id AND r.attributes CONTAINS $marker
+			RETURN r.uuid AS uuid, r.attributes AS metadata
+		`
+	default:
+		return nil, ErrInvalidatedEdgesUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err := executeGroupQuery(d, groupID, query, map[string]interface{}{
+		"group_id": groupID,
+		"marker":   `"` + key + `"`,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find invalidated edges: %w", err)
+	}
+
+	metadataByUUID := make(map[string]map[string]interface{})
+	for _, record := range queryRecordMaps(result) {
+		uuid, _ := record["uuid"].(string)
+		raw, _ := record["metadata"].(string)
+		var metadata map[string]interface{}
+		if uuid == "" || json.Unmarshal([]byte(raw), &metadata) != nil {
+			continue
+		}
+		if _, ok := metadata[key]; ok {
+			metadataByUUID[uuid] = metadata
+		}
+	}
+	return metadataByUUID, nil
+}
@@ -0,0 +1,48 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// recordsDriver is a GraphDriver stub returning fixed records.
+type recordsDriver struct {
+	queryRecorder
+	records []map[string]interface{}
+}
+
+func (d *recordsDriver) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	d.queryRecorder.ExecuteQuery(cypherQuery, kwargs)
+	return d.records, nil, nil, nil
+}
+
+func TestListInvalidatedEdgeMetadata(t *testing.T) {
+	d := &recordsDriver{
+		queryRecorder: queryRecorder{provider: GraphProviderLadybug},
+		records: []map[string]interface{}{
+			{"uuid": "old", "metadata": `{"invalidated_by":"new","invalidated_at":"2024-01-02T00:00:00Z"}`},
+			{"uuid": "fact", "metadata": `{"note":"mentions \"invalidated_by\" in a value"}`},
+			{"uuid": "broken", "metadata": `{`},
+		},
+	}
+	got, err := ListInvalidatedEdgeMetadata(context.Background(), d, "g", "invalidated_by")
+	if err != nil {
+		t.Fatalf("ListInvalidatedEdgeMetadata() error = %v", err)
+	}
+	want := map[string]map[string]interface{}{
+		"old": {"invalidated_by": "new", "invalidated_at": "2024-01-02T00:00:00Z"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListInvalidatedEdgeMetadata() = %v, want %v", got, want)
+	}
+	if params := d.params[0]; params["group_id"] != "g" || params["marker"] != `"invalidated_by"` {
+		t.Errorf("params = %v", params)
+	}
+
+	unsupported := &queryRecorder{provider: GraphProviderSurrealDB}
+	if _, err := ListInvalidatedEdgeMetadata(context.Background(), unsupported, "g", "invalidated_by"); !errors.Is(err, ErrInvalidatedEdgesUnsupported) {
+		t.Errorf("ListInvalidatedEdgeMetadata() error = %v, want ErrInvalidatedEdgesUnsupported", err)
+	}
+}
@@ -15,6 +15,7 @@ import (
 
 	ladybug "github.com/LadybugDB/go-ladybug"
 
+	"github.com/soundprediction/go-predicato/pkg/driver/migrations"
 	"github.com/soundprediction/go-predicato/pkg/types"
 )
 
@@ -88,6 +89,7 @@ const LadybugSchemaQueries = `
 
 // writeOperation represents a queued write operation
 type writeOperation struct {
+	ctx      context.Context
 	query    string
 	params   map[string]interface{}
 	resultCh chan writeResult
@@ -107,9 +109,11 @@ type LadybugDriver struct {
 	db           *ladybug.Database
 	client       *ladybug.Connection // Note: Python uses AsyncConnection, but Go ladybug doesn't have async
 	dbPath       string
-	tempDbPath   string     // If non-empty, this is a temp copy that should be cleaned up
-	originalPath string     // Original path before copying to temp
-	mu           sync.Mutex // Mutex to protect database operations from concurrent access
+	tempDbPath   string        // If non-empty, this is a temp copy that should be cleaned up
+	originalPath string        // Original path before copying to temp
+	mu           chan struct{} // Context-aware lock protecting database operations from concurrent access
+	queryTimeout time.Duration // Default statement timeout, zero or negative disables it
+	softDelete   bool          // DeleteNode and DeleteEdge set deleted_at instead of removing rows
 
 	// Write queue for transparent concurrency handling
 	writeQueue chan writeOperation
@@ -217,6 +221,15 @@ type LadybugDriverConfig struct {
 
 	// Maximum database size in bytes (defaults to 8TB)
 	MaxDbSize uint64
+
+	// Default statement timeout applied to every query (defaults to 10 minutes)
+	// Queries exceeding it are interrupted so they release the driver lock.
+	// A negative value disables the timeout.
+	QueryTimeout time.Duration
+
+	// Mark deleted nodes and edges with deleted_at instead of removing them (defaults to false)
+	// Tombstoned rows are removed by PurgeDeleted.
+	SoftDelete bool
 }
 
 // DefaultLadybugDriverConfig returns a LadybugDriverConfig with sensible defaults
@@ -228,6 +241,7 @@ func DefaultLadybugDriverConfig() *LadybugDriverConfig {
 		BufferPoolSize:       1024 * 1024 * 1024, // 1GB
 		EnableCompression:    true,
 		MaxDbSize:            1 << 43, // 8TB
+		QueryTimeout:         10 * time.Minute,
 	}
 }
 
@@ -267,6 +281,18 @@ func (c *LadybugDriverConfig) WithMaxDbSize(size uint64) *LadybugDriverConfig {
 	return c
 }
 
+// WithQueryTimeout sets the default statement timeout (negative disables it)
+func (c *LadybugDriverConfig) WithQueryTimeout(timeout time.Duration) *LadybugDriverConfig {
+	c.QueryTimeout = timeout
+	return c
+}
+
+// WithSoftDelete enables or disables soft deletes
+func (c *LadybugDriverConfig) WithSoftDelete(enable bool) *LadybugDriverConfig {
+	c.SoftDelete = enable
+	return c
+}
+
 // NewLadybugDriver creates a new Ladybug driver instance with exact same signature as Python
 // Parameters:
 //   - db: Database path (defaults to ":memory:" like Python)
@@ -313,11 +339,20 @@ func NewLadybugDriverWithConfig(config *LadybugDriverConfig) (*LadybugDriver, er
 	if config.MaxDbSize == 0 {
 		config.MaxDbSize = 1 << 43 // 8TB
 	}
+	if config.QueryTimeout == 0 {
+		config.QueryTimeout = 10 * time.Minute
+	}
 
 	originalPath := config.DBPath
 	tempDbPath := ""
 	db := config.DBPath
 
+	// Detect partially written databases before handing them to the C++ library,
+	// which may crash instead of returning an error
+	if err := checkLadybugFiles(db); err != nil {
+		return nil, err
+	}
+
 	// Create a SystemConfig manually to avoid version mismatch issues with DefaultSystemConfig()
 	// These are safe, conservative defaults that work with ladybug
 	systemConfig := ladybug.SystemConfig{
@@ -358,6 +393,9 @@ func NewLadybugDriverWithConfig(config *LadybugDriverConfig) (*LadybugDriver, er
 
 		db = tempDbPath // Use temp path for the rest of initialization
 	} else if err != nil {
+		if isCorruptionError(err) {
+			return nil, fmt.Errorf("%w: failed to open ladybug database %s: %w", ErrDatabaseCorrupt, db, err)
+		}
 		return nil, fmt.Errorf("failed to open ladybug database: %w", err)
 	}
 
@@ -367,17 +405,13 @@ func NewLadybugDriverWithConfig(config *LadybugDriverConfig) (*LadybugDriver, er
 		dbPath:       db,
 		tempDbPath:   tempDbPath,
 		originalPath: originalPath,
+		mu:           make(chan struct{}, 1),
+		queryTimeout: config.QueryTimeout,
+		softDelete:   config.SoftDelete,
 		writeQueue:   make(chan writeOperation, config.WriteQueueSize),
 		closeCh:      make(chan struct{}),
 	}
 
-	// Start the write worker goroutine
-	driver.writeWg.Add(1)
-	go driver.writeWorker()
-
-	// Setup schema exactly like Python
-	driver.setupSchema()
-
 	// Create connection - Go ladybug doesn't have AsyncConnection but we simulate the interface
 	client, err := ladybug.OpenConnection(database)
 	if err != nil {
@@ -386,6 +420,19 @@ func NewLadybugDriverWithConfig(config *LadybugDriverConfig) (*LadybugDriver, er
 	}
 	driver.client = client
 
+	// Start the write worker goroutine
+	driver.writeWg.Add(1)
+	go driver.writeWorker()
+
+	// Verify the database answers a trivial query before touching the schema
+	if err := driver.ping(context.Background()); err != nil {
+		driver.Close()
+		return nil, err
+	}
+
+	// Setup schema exactly like Python
+	driver.setupSchema()
+
 	// Load FTS extension for this connection
 	// Extensions must be loaded for each session (connection)
 	_, err = client.Query("LOAD EXTENSION FTS;")
@@ -393,6 +440,18 @@ func NewLadybugDriverWithConfig(config *LadybugDriverConfig) (*LadybugDriver, er
 		log.Printf("Warning: Failed to load FTS extension on main connection: %v", err)
 	}
 
+	// Verify the schema tables are readable now that they exist
+	if err := driver.HealthCheck(context.Background()); err != nil {
+		driver.Close()
+		return nil, err
+	}
+
+	// Apply pending schema migrations and refuse databases written by newer releases
+	if err := migrations.NewRunner(driver, migrations.ProviderLadybug).Migrate(context.Background()); err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("failed to migrate ladybug schema: %w", err)
+	}
+
 	return driver, nil
 }
 
@@ -400,7 +459,15 @@ func NewLadybugDriverWithConfig(config *LadybugDriverConfig) (*LadybugDriver, er
 // Returns (results, summary, keys) tuple like Python, though summary and keys are unused in Ladybug.
 // Write operations are automatically queued and executed sequentially for thread safety.
 // Read operations execute directly with mutex protection for better performance.
+// The configured default statement timeout applies; use ExecuteQueryWithContext for cancellation.
 func (k *LadybugDriver) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	return k.ExecuteQueryWithContext(context.Background(), cypherQuery, kwargs)
+}
+
+// ExecuteQueryWithContext executes a query like ExecuteQuery, but stops waiting and
+// interrupts the running statement when ctx is cancelled or the default statement
+// timeout elapses.
+func (k *LadybugDriver) ExecuteQueryWithContext(ctx context.Context, cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
 	// Check if driver is closed
 	k.closeMu.RLock()
 	if k.closed {
@@ -409,10 +476,18 @@ func (k *LadybugDriver) ExecuteQuery(cypherQuery string, kwargs map[string]inter
 	}
 	k.closeMu.RUnlock()
 
+	if err := ctx.Err(); err != nil {
+		return nil, nil, nil, fmt.Errorf("ladybug query cancelled: %w", err)
+	}
+
+	ctx, cancel := k.withQueryTimeout(ctx)
+	defer cancel()
+
 	// Route write operations to the queue for sequential execution
 	if k.isWriteQuery(cypherQuery) {
 		resultCh := make(chan writeResult, 1)
 		op := writeOperation{
+			ctx:      ctx,
 			query:    cypherQuery,
 			params:   kwargs,
 			resultCh: resultCh,
@@ -422,30 +497,49 @@ func (k *LadybugDriver) ExecuteQuery(cypherQuery string, kwargs map[string]inter
 		select {
 		case k.writeQueue <- op:
 			// Wait for result
-			result := <-resultCh
-			return result.result, result.cols, result.meta, result.err
+			select {
+			case result := <-resultCh:
+				return result.result, result.cols, result.meta, result.err
+			case <-ctx.Done():
+				return nil, nil, nil, fmt.Errorf("ladybug query cancelled: %w", ctx.Err())
+			}
+		case <-ctx.Done():
+			return nil, nil, nil, fmt.Errorf("ladybug query cancelled while queued: %w", ctx.Err())
 		case <-time.After(30 * time.Second):
 			return nil, nil, nil, fmt.Errorf("write queue timeout after 30s")
 		}
 	}
 
 	// Read operations execute directly with mutex protection
-	return k.executeQueryInternal(cypherQuery, kwargs)
+	return k.executeQueryInternal(ctx, cypherQuery, kwargs)
 }
 
-// isWriteQuery checks if a query is a write operation (CREATE, MERGE, SET, DELETE, etc.)
-func (k *LadybugDriver) isWriteQuery(query string) bool {
-	upperQuery := strings.ToUpper(strings.TrimSpace(query))
-	writeKeywords := []string{
-		"CREATE ", "MERGE ", "SET ", "DELETE ", "DETACH DELETE",
-		"REMOVE ", "DROP ", "INSERT ", "UPDATE ",
+// withQueryTimeout derives a context bounded by the default statement timeout.
+func (k *LadybugDriver) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if k.queryTimeout > 0 {
+		return context.WithTimeout(ctx, k.queryTimeout)
 	}
-	for _, keyword := range writeKeywords {
-		if strings.HasPrefix(upperQuery, keyword) || strings.Contains(upperQuery, " "+keyword) {
-			return true
-		}
+	return context.WithCancel(ctx)
+}
+
+// lock acquires the database lock, giving up when ctx is done.
+func (k *LadybugDriver) lock(ctx context.Context) error {
+	select {
+	case k.mu <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("ladybug query cancelled while waiting for lock: %w", ctx.Err())
 	}
-	return false
+}
+
+// unlock releases the database lock.
+func (k *LadybugDriver) unlock() {
+	<-k.mu
+}
+
+// isWriteQuery checks if a query is a write operation (CREATE, MERGE, SET, DELETE, etc.)
+func (k *LadybugDriver) isWriteQuery(query string) bool {
+	return isWriteQuery(query)
 }
 
 // writeWorker processes write operations sequentially from the queue
@@ -459,7 +553,7 @@ func (k *LadybugDriver) writeWorker() {
 			for {
 				select {
 				case op := <-k.writeQueue:
-					result, cols, meta, err := k.executeQueryInternal(op.query, op.params)
+					result, cols, meta, err := k.executeQueryInternal(op.ctx, op.query, op.params)
 					op.resultCh <- writeResult{result, cols, meta, err}
 					close(op.resultCh)
 				default:
@@ -467,18 +561,80 @@ func (k *LadybugDriver) writeWorker() {
 				}
 			}
 		case op := <-k.writeQueue:
-			result, cols, meta, err := k.executeQueryInternal(op.query, op.params)
+			result, cols, meta, err := k.executeQueryInternal(op.ctx, op.query, op.params)
 			op.resultCh <- writeResult{result, cols, meta, err}
 			close(op.resultCh)
 		}
 	}
 }
 
-// executeQueryInternal performs the actual query execution with mutex protection
-func (k *LadybugDriver) executeQueryInternal(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+// executeQueryInternal performs the actual query execution with mutex protection.
+// The running statement is interrupted when ctx is done.
+func (k *LadybugDriver) executeQueryInternal(ctx context.Context, cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
 	// Lock to prevent concurrent database access (ladybug C++ library is not thread-safe)
-	k.mu.Lock()
-	defer k.mu.Unlock()
+	if err := k.lock(ctx); err != nil {
+		return nil, nil, nil, err
+	}
+	defer k.unlock()
+
+	return k.executeLocked(ctx, cypherQuery, kwargs)
+}
+
+// executeTransaction runs the statements in order in a single write
+// transaction, rolling it back when one of them fails.
+func (k *LadybugDriver) executeTransaction(ctx context.Context, statements []graphStatement) error {
+	k.closeMu.RLock()
+	if k.closed {
+		k.closeMu.RUnlock()
+		return fmt.Errorf("driver is closed")
+	}
+	k.closeMu.RUnlock()
+
+	ctx, cancel := k.withQueryTimeout(ctx)
+	defer cancel()
+
+	// Holding the lock keeps queued writes and reads out of the transaction
+	if err := k.lock(ctx); err != nil {
+		return err
+	}
+	defer k.unlock()
+
+	if _, _, _, err := k.executeLocked(ctx, "BEGIN TRANSACTION", nil); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	for _, statement := range statements {
+		if _, _, _, err := k.executeLocked(ctx, statement.query, statement.params); err != nil {
+			if _, _, _, rollbackErr := k.executeLocked(context.Background(), "ROLLBACK", nil); rollbackErr != nil {
+				log.Printf("Warning: failed to roll back ladybug transaction: %v", rollbackErr)
+			}
+			return err
+		}
+	}
+	if _, _, _, err := k.executeLocked(ctx, "COMMIT", nil); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// executeLocked executes a query while the caller holds the database lock.
+// The running statement is interrupted when ctx is done.
+func (k *LadybugDriver) executeLocked(ctx context.Context, cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	// Interrupt the statement if ctx is cancelled. The watcher is stopped before
+	// the lock is released so a late interrupt cannot hit the next query.
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			k.client.Interrupt()
+		case <-stop:
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-stopped
+	}()
 
 	// Filter parameters exactly like Python implementation
 	params := make(map[string]any) // Use 'any' instead of 'interface{}' for go-ladybug compatibility
@@ -523,14 +679,14 @@ func (k *LadybugDriver) executeQueryInternal(cypherQuery string, kwargs map[stri
 				}
 			}
 			log.Printf("Error executing ladybug query: %v\nQuery: %s\nParams: %v", err, cypherQuery, truncatedParams)
-			return nil, nil, nil, err
+			return nil, nil, nil, queryError(ctx, err)
 		}
 	} else {
 		// Use simple Query for queries without parameters
 		results, err = k.client.Query(cypherQuery)
 		if err != nil {
 			log.Printf("Error executing ladybug query: %v\nQuery: %s", err, cypherQuery)
-			return nil, nil, nil, err
+			return nil, nil, nil, queryError(ctx, err)
 		}
 	}
 
@@ -546,6 +702,10 @@ func (k *LadybugDriver) executeQueryInternal(cypherQuery string, kwargs map[stri
 	// Convert results to list of dictionaries like Python
 	var dictResults []map[string]interface{}
 	for results.HasNext() {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, nil, fmt.Errorf("ladybug query cancelled: %w", err)
+		}
+
 		row, err := results.Next()
 		if err != nil {
 			continue
@@ -570,6 +730,14 @@ func (k *LadybugDriver) executeQueryInternal(cypherQuery string, kwargs map[stri
 	return dictResults, columnNames, nil, nil
 }
 
+// queryError reports a cancelled or timed-out context in place of the interrupt error.
+func queryError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("ladybug query cancelled: %w", ctxErr)
+	}
+	return err
+}
+
 // Session creates a new session exactly like Python implementation
 func (k *LadybugDriver) Session(database *string) GraphDriverSession {
 	return NewLadybugDriverSession(k)
@@ -657,6 +825,89 @@ func (k *LadybugDriver) setupSchema() {
 	}
 }
 
+// HealthCheck runs a trivial query and reads every base table, returning
+// ErrDatabaseCorrupt if the database reports an error reading them. Damage
+// that crashes the C++ library crashes the process instead.
+func (k *LadybugDriver) HealthCheck(ctx context.Context) error {
+	if err := k.ping(ctx); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"Episodic", "Entity", "Community", "RelatesToNode_"} {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		query := fmt.Sprintf("MATCH (n:%s) RETURN count(n) AS count", table)
+		if err := k.probe(ctx, query); err != nil {
+			return fmt.Errorf("%w: table %s is unreadable: %w", ErrDatabaseCorrupt, table, err)
+		}
+	}
+
+	return nil
+}
+
+// ping runs a trivial query to confirm the database engine is usable.
+func (k *LadybugDriver) ping(ctx context.Context) error {
+	if err := k.probe(ctx, "RETURN 1 AS ok"); err != nil {
+		return fmt.Errorf("%w: health check query failed: %w", ErrDatabaseCorrupt, err)
+	}
+	return nil
+}
+
+// probe executes a read query, converting Go panics raised by the bindings
+// into errors. Faults inside the C++ library, such as a segmentation fault on
+// damaged files, terminate the process and cannot be recovered here; the
+// files are checked by checkLadybugFiles before the database is opened.
+func (k *LadybugDriver) probe(ctx context.Context, query string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during query: %v", r)
+		}
+	}()
+
+	_, _, _, err = k.executeQueryInternal(ctx, query, nil)
+	return err
+}
+
+// checkLadybugFiles detects database files left behind by an interrupted write,
+// such as an empty database file or a write-ahead log without its database.
+func checkLadybugFiles(path string) error {
+	if path == ":memory:" {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		if _, walErr := os.Stat(path + ".wal"); walErr == nil {
+			return fmt.Errorf("%w: found write-ahead log %s.wal without database file", ErrDatabaseCorrupt, path)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat ladybug database: %w", err)
+	}
+
+	if !info.IsDir() && info.Size() == 0 {
+		return fmt.Errorf("%w: database file %s is empty", ErrDatabaseCorrupt, path)
+	}
+	return nil
+}
+
+// isCorruptionError checks if an error reported by ladybug indicates damaged database files
+func isCorruptionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	errStr := strings.ToLower(err.Error())
+	return strings.Contains(errStr, "corrupt") ||
+		strings.Contains(errStr, "checksum") ||
+		strings.Contains(errStr, "invalid database") ||
+		strings.Contains(errStr, "not a valid") ||
+		strings.Contains(errStr, "unexpected end of file") ||
+		strings.Contains(errStr, "wal file") ||
+		strings.Contains(errStr, "write-ahead log")
+}
+
 // Provider returns the graph provider type
 func (k *LadybugDriver) Provider() GraphProvider {
 	return k.provider
@@ -694,7 +945,7 @@ func (k *LadybugDriver) GetNode(ctx context.Context, nodeID, groupID string) (*t
 	for _, table := range tables {
 		query := fmt.Sprintf(`
 			MATCH (n:%s)
-			WHERE n.uuid = $uuid AND n.group_id = $group_id
+			WHERE n.uuid = $uuid AND n.group_id = $group_id AND n.deleted_at IS NULL
 			RETURN n.*
 		`, table)
 
@@ -703,7 +954,7 @@ func (k *LadybugDriver) GetNode(ctx context.Context, nodeID, groupID string) (*t
 			"group_id": groupID,
 		}
 
-		result, _, _, err := k.ExecuteQuery(query, params)
+		result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 		if err != nil {
 			continue
 		}
@@ -713,7 +964,7 @@ func (k *LadybugDriver) GetNode(ctx context.Context, nodeID, groupID string) (*t
 		}
 	}
 
-	return nil, fmt.Errorf("node not found")
+	return nil, ErrNodeNotFound
 }
 
 func (k *LadybugDriver) NodeExists(ctx context.Context, node *types.Node) bool {
@@ -736,7 +987,7 @@ func (k *LadybugDriver) NodeExists(ctx context.Context, node *types.Node) bool {
 		"group_id": node.GroupID,
 	}
 
-	result, _, _, err := k.ExecuteQuery(query, params)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		return false
 	}
@@ -771,7 +1022,7 @@ func (k *LadybugDriver) UpsertNode(ctx context.Context, node *types.Node) error
 
 	// Try to create first
 	if !k.NodeExists(ctx, node) {
-		err := k.executeNodeCreateQuery(node, tableName)
+		err := k.executeNodeCreateQuery(ctx, node, tableName)
 		if err != nil {
 			return fmt.Errorf("failed to create node %w", err)
 		}
@@ -779,7 +1030,7 @@ func (k *LadybugDriver) UpsertNode(ctx context.Context, node *types.Node) error
 
 	}
 
-	updateErr := k.executeNodeUpdateQuery(node, tableName)
+	updateErr := k.executeNodeUpdateQuery(ctx, node, tableName)
 	if updateErr != nil {
 		return fmt.Errorf("failed to update node %w", updateErr)
 	}
@@ -787,8 +1038,13 @@ func (k *LadybugDriver) UpsertNode(ctx context.Context, node *types.Node) error
 	return nil
 }
 
-// DeleteNode removes a node and its relationships from all tables.
+// DeleteNode removes a node and its relationships from all tables. With soft
+// deletes enabled the node and its entity edges are marked with deleted_at instead.
 func (k *LadybugDriver) DeleteNode(ctx context.Context, nodeID, groupID string) error {
+	if k.softDelete {
+		return k.softDeleteNode(ctx, nodeID, groupID)
+	}
+
 	// Delete from all possible tables
 	tables := []string{"Entity", "Episodic", "Community", "RelatesToNode_"}
 
@@ -800,7 +1056,7 @@ func (k *LadybugDriver) DeleteNode(ctx context.Context, nodeID, groupID string)
 			DELETE r
 		`, table, strings.ReplaceAll(nodeID, "'", "\\'"), strings.ReplaceAll(groupID, "'", "\\'"))
 
-		k.ExecuteQuery(deleteRelsQuery, nil) // Ignore errors for missing relationships
+		k.ExecuteQueryWithContext(ctx, deleteRelsQuery, nil) // Ignore errors for missing relationships
 
 		// Delete the node
 		deleteNodeQuery := fmt.Sprintf(`
@@ -809,7 +1065,7 @@ func (k *LadybugDriver) DeleteNode(ctx context.Context, nodeID, groupID string)
 			DELETE n
 		`, table, strings.ReplaceAll(nodeID, "'", "\\'"), strings.ReplaceAll(groupID, "'", "\\'"))
 
-		k.ExecuteQuery(deleteNodeQuery, nil) // Ignore errors for nodes not in this table
+		k.ExecuteQueryWithContext(ctx, deleteNodeQuery, nil) // Ignore errors for nodes not in this table
 	}
 
 	return nil
@@ -837,7 +1093,7 @@ func (k *LadybugDriver) GetEdge(ctx context.Context, edgeID, groupID string) (*t
 	// Query using the RelatesToNode_ pattern from Python implementation
 	query := `
 		MATCH (a:Entity)-[:RELATES_TO]->(rel:RelatesToNode_)-[:RELATES_TO]->(b:Entity)
-		WHERE rel.uuid = $uuid AND rel.group_id = $group_id
+		WHERE rel.uuid = $uuid AND rel.group_id = $group_id AND rel.deleted_at IS NULL
 		RETURN rel.uuid as uuid, rel.name as name, rel.fact as fact, rel.group_id as group_id, a.uuid AS source_id, b.uuid AS target_id
 	`
 
@@ -846,7 +1102,7 @@ func (k *LadybugDriver) GetEdge(ctx context.Context, edgeID, groupID string) (*t
 		"group_id": groupID,
 	}
 
-	result, _, _, err := k.ExecuteQuery(query, params)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query edge: %w", err)
 	}
@@ -855,7 +1111,7 @@ func (k *LadybugDriver) GetEdge(ctx context.Context, edgeID, groupID string) (*t
 		return k.mapToEdge(resultList[0])
 	}
 
-	return nil, fmt.Errorf("edge not found")
+	return nil, ErrEdgeNotFound
 }
 
 // UpsertEdge creates or updates an edge using the RelatesToNode_ pattern.
@@ -869,14 +1125,14 @@ func (k *LadybugDriver) UpsertEdge(ctx context.Context, edge *types.Edge) error
 	}
 
 	if !k.EdgeExists(ctx, edge) {
-		err := k.executeEdgeCreateQuery(edge)
+		err := k.executeEdgeCreateQuery(ctx, edge)
 		if err != nil {
 			return fmt.Errorf("failed to create edge %w", err)
 		}
 		return err
 	}
 
-	updateErr := k.executeEdgeUpdateQuery(edge)
+	updateErr := k.executeEdgeUpdateQuery(ctx, edge)
 	if updateErr != nil {
 		return fmt.Errorf("failed to update edge %w", updateErr)
 	}
@@ -897,7 +1153,7 @@ func (k *LadybugDriver) EdgeExists(ctx context.Context, edge *types.Edge) bool {
 		"group_id": edge.GroupID,
 	}
 
-	result, _, _, err := k.ExecuteQuery(query, params)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		return false
 	}
@@ -909,7 +1165,15 @@ func (k *LadybugDriver) EdgeExists(ctx context.Context, edge *types.Edge) bool {
 	return false
 }
 
-func (k *LadybugDriver) executeEdgeCreateQuery(edge *types.Edge) error {
+func (k *LadybugDriver) executeEdgeCreateQuery(ctx context.Context, edge *types.Edge) error {
+	statement := k.edgeCreateStatement(edge)
+	_, _, _, err := k.ExecuteQueryWithContext(ctx, statement.query, statement.params)
+	return err
+}
+
+// edgeCreateStatement returns the statement creating edge as a RelatesToNode_
+// row linked to its endpoints.
+func (k *LadybugDriver) edgeCreateStatement(edge *types.Edge) graphStatement {
 	var metadataJSON string
 	if edge.Metadata != nil {
 		if data, err := json.Marshal(edge.Metadata); err == nil {
@@ -982,11 +1246,10 @@ func (k *LadybugDriver) executeEdgeCreateQuery(edge *types.Edge) error {
 		params["invalid_at"] = nil
 	}
 
-	_, _, _, err := k.ExecuteQuery(query, params)
-	return err
+	return graphStatement{query: query, params: params}
 }
 
-func (k *LadybugDriver) executeEdgeUpdateQuery(edge *types.Edge) error {
+func (k *LadybugDriver) executeEdgeUpdateQuery(ctx context.Context, edge *types.Edge) error {
 	var metadataJSON string
 	if edge.Metadata != nil {
 		if data, err := json.Marshal(edge.Metadata); err == nil {
@@ -1049,7 +1312,7 @@ func (k *LadybugDriver) executeEdgeUpdateQuery(edge *types.Edge) error {
 		params["invalid_at"] = nil
 	}
 
-	_, _, _, err := k.ExecuteQuery(query, params)
+	_, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	return err
 }
 
@@ -1075,7 +1338,7 @@ func (k *LadybugDriver) UpsertEpisodicEdge(ctx context.Context, episodeUUID, ent
 		"uuid":         fmt.Sprintf("%s-%s", episodeUUID, entityUUID), // Generate consistent uuid
 	}
 
-	_, _, _, err := k.ExecuteQuery(query, params)
+	_, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		return fmt.Errorf("failed to upsert episodic edge: %w", err)
 	}
@@ -1103,7 +1366,7 @@ func (k *LadybugDriver) UpsertCommunityEdge(ctx context.Context, communityUUID,
 		"created_at":     time.Now(),
 	}
 
-	_, _, _, err := k.ExecuteQuery(query, params)
+	_, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		// Try Community target if Entity didn't work
 		query = `
@@ -1114,7 +1377,7 @@ func (k *LadybugDriver) UpsertCommunityEdge(ctx context.Context, communityUUID,
 			RETURN e
 		`
 
-		_, _, _, err = k.ExecuteQuery(query, params)
+		_, _, _, err = k.ExecuteQueryWithContext(ctx, query, params)
 		if err != nil {
 			return fmt.Errorf("failed to upsert community edge: %w", err)
 		}
@@ -1123,8 +1386,13 @@ func (k *LadybugDriver) UpsertCommunityEdge(ctx context.Context, communityUUID,
 	return nil
 }
 
-// DeleteEdge removes an edge.
+// DeleteEdge removes an edge. With soft deletes enabled the edge is marked with
+// deleted_at instead.
 func (k *LadybugDriver) DeleteEdge(ctx context.Context, edgeID, groupID string) error {
+	if k.softDelete {
+		return k.softDeleteEdge(ctx, edgeID, groupID)
+	}
+
 	// Delete using RelatesToNode_ pattern
 	deleteQuery := fmt.Sprintf(`
 		MATCH (a:Entity)-[:RELATES_TO]->(rel:RelatesToNode_)-[:RELATES_TO]->(b:Entity)
@@ -1132,7 +1400,7 @@ func (k *LadybugDriver) DeleteEdge(ctx context.Context, edgeID, groupID string)
 		DELETE rel
 	`, strings.ReplaceAll(edgeID, "'", "\\'"), strings.ReplaceAll(groupID, "'", "\\'"))
 
-	_, _, _, err := k.ExecuteQuery(deleteQuery, nil)
+	_, _, _, err := k.ExecuteQueryWithContext(ctx, deleteQuery, nil)
 	if err != nil {
 		return fmt.Errorf("failed to delete edge: %w", err)
 	}
@@ -1176,7 +1444,7 @@ func (k *LadybugDriver) GetNeighbors(ctx context.Context, nodeID, groupID string
 	`, maxDistance, strings.ReplaceAll(nodeID, "'", "\\'"),
 		strings.ReplaceAll(groupID, "'", "\\'"), strings.ReplaceAll(groupID, "'", "\\'"))
 
-	result, _, _, err := k.ExecuteQuery(query, nil)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, query, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query neighbors: %w", err)
 	}
@@ -1194,16 +1462,68 @@ func (k *LadybugDriver) GetNeighbors(ctx context.Context, nodeID, groupID string
 	return neighbors, nil
 }
 
-// GetRelatedNodes retrieves nodes related through specific edge types
+// GetRelatedNodes retrieves the direct neighbors of a node, optionally restricted
+// to edge types or relation names. Entity edges are followed through their
+// RelatesToNode_.
 func (k *LadybugDriver) GetRelatedNodes(ctx context.Context, nodeID, groupID string, edgeTypes []types.EdgeType) ([]*types.Node, error) {
-	// Simple implementation for now
-	return k.GetNeighbors(ctx, nodeID, groupID, 1)
+	filter := newRelatedEdgeFilter(edgeTypes)
+
+	seen := make(map[string]bool)
+	var related []*types.Node
+	for _, pattern := range ladybugRelatedPatterns {
+		if !filter.follows(pattern.edgeType) {
+			continue
+		}
+
+		params := map[string]interface{}{
+			"uuid":     nodeID,
+			"group_id": groupID,
+		}
+		nameCondition := ""
+		if names := filter.entityEdgeNames(); pattern.edgeType == types.EntityEdgeType && len(names) > 0 {
+			nameCondition = "AND e.name IN $edge_names"
+			params["edge_names"] = names
+		}
+
+		query := fmt.Sprintf(`
+			MATCH %s
+			WHERE start.uuid = $uuid AND start.group_id = $group_id
+			  AND n.group_id = $group_id AND n.uuid <> $uuid
+			  %s
+			RETURN DISTINCT n.*
+		`, pattern.match, nameCondition)
+
+		result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query related nodes: %w", err)
+		}
+
+		for _, row := range queryRecordMaps(result) {
+			node, err := k.mapToNode(row, pattern.related)
+			if err != nil || seen[node.Uuid] {
+				continue
+			}
+			seen[node.Uuid] = true
+			related = append(related, node)
+		}
+	}
+
+	return related, nil
 }
 
 // SearchNodesByEmbedding performs vector similarity search on node embeddings using cosine similarity.
 // This matches the Python implementation in search_utils.py:node_similarity_search()
 // For ladybug, it uses array_cosine_similarity function on name_embedding field.
 func (k *LadybugDriver) SearchNodesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int) ([]*types.Node, error) {
+	return k.searchNodesByEmbedding(ctx, embedding, limit, groupSearchFilter(groupID))
+}
+
+// searchNodesByEmbedding implements SearchNodesByEmbedding for the nodes
+// matching filter.
+func (k *LadybugDriver) searchNodesByEmbedding(ctx context.Context, embedding []float32, limit int, filter *searchFilter) ([]*types.Node, error) {
+	if !filter.allowsNodeType(types.EntityNodeType) {
+		return []*types.Node{}, nil
+	}
 	if limit <= 0 {
 		limit = 10
 	}
@@ -1216,9 +1536,14 @@ func (k *LadybugDriver) SearchNodesByEmbedding(ctx context.Context, embedding []
 
 	// Build the Cypher query matching Python's ladybug implementation
 	// From search_utils.py:node_similarity_search() for ladybug provider
+	params := map[string]interface{}{
+		"search_vector": embeddingF64,
+		"limit":         int64(limit),
+	}
 	query := `
 		MATCH (n:Entity)
-		WHERE n.group_id = $group_id
+		WHERE ` + filter.ladybugNodeConditions("n", params) + `
+		  AND n.deleted_at IS NULL
 		  AND size(n.name_embedding) > 0
 		WITH n, array_cosine_similarity(n.name_embedding, CAST($search_vector AS FLOAT[` + fmt.Sprintf("%d", len(embedding)) + `])) AS score
 		WHERE score > 0.0
@@ -1236,13 +1561,7 @@ func (k *LadybugDriver) SearchNodesByEmbedding(ctx context.Context, embedding []
 		LIMIT $limit
 	`
 
-	params := map[string]interface{}{
-		"group_id":      groupID,
-		"search_vector": embeddingF64,
-		"limit":         int64(limit),
-	}
-
-	result, _, _, err := k.ExecuteQuery(query, params)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute node embedding search: %w", err)
 	}
@@ -1309,6 +1628,15 @@ func (k *LadybugDriver) SearchNodesByEmbedding(ctx context.Context, embedding []
 // This matches the Python implementation in search_utils.py:edge_similarity_search()
 // For ladybug, edges are represented as RelatesToNode_ intermediate nodes with fact_embedding field.
 func (k *LadybugDriver) SearchEdgesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int) ([]*types.Edge, error) {
+	return k.searchEdgesByEmbedding(ctx, embedding, limit, groupSearchFilter(groupID))
+}
+
+// searchEdgesByEmbedding implements SearchEdgesByEmbedding for the edges
+// matching filter.
+func (k *LadybugDriver) searchEdgesByEmbedding(ctx context.Context, embedding []float32, limit int, filter *searchFilter) ([]*types.Edge, error) {
+	if !filter.allowsEdgeType(types.EntityEdgeType) {
+		return []*types.Edge{}, nil
+	}
 	if limit <= 0 {
 		limit = 10
 	}
@@ -1322,9 +1650,14 @@ func (k *LadybugDriver) SearchEdgesByEmbedding(ctx context.Context, embedding []
 	// Build the Cypher query matching Python's ladybug implementation for edges
 	// From search_utils.py:edge_similarity_search() for ladybug provider
 	// Uses RelatesToNode_ intermediate representation
+	params := map[string]interface{}{
+		"search_vector": embeddingF64,
+		"limit":         int64(limit),
+	}
 	query := `
 		MATCH (n:Entity)-[:RELATES_TO]->(e:RelatesToNode_)-[:RELATES_TO]->(m:Entity)
-		WHERE e.group_id = $group_id
+		WHERE ` + filter.ladybugEdgeConditions("e", params) + `
+		  AND e.deleted_at IS NULL
 		WITH DISTINCT e, n, m, array_cosine_similarity(e.fact_embedding, CAST($search_vector AS FLOAT[` + fmt.Sprintf("%d", len(embedding)) + `])) AS score
 		WHERE score > 0.0
 		RETURN
@@ -1345,13 +1678,7 @@ func (k *LadybugDriver) SearchEdgesByEmbedding(ctx context.Context, embedding []
 		LIMIT $limit
 	`
 
-	params := map[string]interface{}{
-		"group_id":      groupID,
-		"search_vector": embeddingF64,
-		"limit":         int64(limit),
-	}
-
-	result, _, _, err := k.ExecuteQuery(query, params)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute edge embedding search: %w", err)
 	}
@@ -1435,7 +1762,8 @@ func (k *LadybugDriver) SearchEdgesByEmbedding(ctx context.Context, embedding []
 
 // SearchNodes performs text-based search on nodes
 func (k *LadybugDriver) SearchNodes(ctx context.Context, query, groupID string, options *SearchOptions) ([]*types.Node, error) {
-	if strings.TrimSpace(query) == "" {
+	filter := options.filter(groupID)
+	if strings.TrimSpace(query) == "" || !filter.allowsNodeType(types.EntityNodeType) {
 		return []*types.Node{}, nil
 	}
 
@@ -1447,21 +1775,19 @@ func (k *LadybugDriver) SearchNodes(ctx context.Context, query, groupID string,
 	// BM25 fulltext search using QUERY_FTS_INDEX (matching Python implementation)
 	// From graph_queries.py get_nodes_query() and search_utils.py node_fulltext_search()
 	// For ladybug: CALL QUERY_FTS_INDEX('Entity', 'node_name_and_summary', query, TOP := limit)
+	params := map[string]interface{}{
+		"query": query,
+		"limit": int64(limit),
+	}
 	searchQuery := `
 		CALL QUERY_FTS_INDEX('Entity', 'node_name_and_summary', cast($query AS STRING), TOP := $limit)
 		WITH node AS n, score
-		WHERE n.group_id = $group_id
+		WHERE ` + filter.ladybugNodeConditions("n", params) + ` AND n.deleted_at IS NULL
 		RETURN n.*, score
 		ORDER BY score DESC
 	`
 
-	params := map[string]interface{}{
-		"query":    query,
-		"group_id": groupID,
-		"limit":    int64(limit),
-	}
-
-	result, _, _, err := k.ExecuteQuery(searchQuery, params)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, searchQuery, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search nodes: %w", err)
 	}
@@ -1481,7 +1807,8 @@ func (k *LadybugDriver) SearchNodes(ctx context.Context, query, groupID string,
 
 // SearchEdges performs text-based search on edges
 func (k *LadybugDriver) SearchEdges(ctx context.Context, query, groupID string, options *SearchOptions) ([]*types.Edge, error) {
-	if strings.TrimSpace(query) == "" {
+	filter := options.filter(groupID)
+	if strings.TrimSpace(query) == "" || !filter.allowsEdgeType(types.EntityEdgeType) {
 		return []*types.Edge{}, nil
 	}
 
@@ -1493,11 +1820,15 @@ func (k *LadybugDriver) SearchEdges(ctx context.Context, query, groupID string,
 	// BM25 fulltext search using QUERY_FTS_INDEX (matching Python implementation)
 	// From graph_queries.py get_relationships_query() and search_utils.py edge_fulltext_search()
 	// For ladybug edges (RelatesToNode_): CALL QUERY_FTS_INDEX('RelatesToNode_', 'edge_name_and_fact', query, TOP := limit)
+	params := map[string]interface{}{
+		"query": query,
+		"limit": int64(limit),
+	}
 	searchQuery := `
 		CALL QUERY_FTS_INDEX('RelatesToNode_', 'edge_name_and_fact', cast($query AS STRING), TOP := $limit)
 		YIELD node, score
 		MATCH (n:Entity)-[:RELATES_TO]->(e:RelatesToNode_ {uuid: node.uuid})-[:RELATES_TO]->(m:Entity)
-		WHERE e.group_id = $group_id
+		WHERE ` + filter.ladybugEdgeConditions("e", params) + ` AND e.deleted_at IS NULL
 		RETURN
 			e.uuid AS uuid,
 			e.group_id AS group_id,
@@ -1515,13 +1846,7 @@ func (k *LadybugDriver) SearchEdges(ctx context.Context, query, groupID string,
 		ORDER BY score DESC
 	`
 
-	params := map[string]interface{}{
-		"query":    query,
-		"group_id": groupID,
-		"limit":    int64(limit),
-	}
-
-	result, _, _, err := k.ExecuteQuery(searchQuery, params)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, searchQuery, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search edges: %w", err)
 	}
@@ -1550,9 +1875,8 @@ func (k *LadybugDriver) SearchNodesByVector(ctx context.Context, vector []float3
 		limit = options.Limit
 	}
 
-	// Use the existing SearchNodesByEmbedding method which already handles similarity scoring
 	// The ladybug query already includes the score in the results
-	nodes, err := k.SearchNodesByEmbedding(ctx, vector, groupID, limit)
+	nodes, err := k.searchNodesByEmbedding(ctx, vector, limit, options.filter(groupID))
 	if err != nil {
 		return nil, err
 	}
@@ -1579,9 +1903,8 @@ func (k *LadybugDriver) SearchEdgesByVector(ctx context.Context, vector []float3
 		limit = options.Limit
 	}
 
-	// Use the existing SearchEdgesByEmbedding method which already handles similarity scoring
 	// The ladybug query already includes the score in the results
-	edges, err := k.SearchEdgesByEmbedding(ctx, vector, groupID, limit)
+	edges, err := k.searchEdgesByEmbedding(ctx, vector, limit, options.filter(groupID))
 	if err != nil {
 		return nil, err
 	}
@@ -1622,6 +1945,7 @@ func (k *LadybugDriver) GetNodesInTimeRange(ctx context.Context, start, end time
 	query := `
 		MATCH (n:Entity)
 		WHERE n.group_id = $group_id
+		  AND n.deleted_at IS NULL
 		  AND n.created_at >= $start
 		  AND n.created_at <= $end
 		RETURN n.uuid AS uuid,
@@ -1638,7 +1962,7 @@ func (k *LadybugDriver) GetNodesInTimeRange(ctx context.Context, start, end time
 		"end":      end.Format(time.RFC3339),
 	}
 
-	result, _, _, err := k.ExecuteQuery(query, params)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute GetNodesInTimeRange query: %w", err)
 	}
@@ -1689,6 +2013,7 @@ func (k *LadybugDriver) GetEdgesInTimeRange(ctx context.Context, start, end time
 	query := `
 		MATCH (n:Entity)-[:RELATES_TO]->(e:RelatesToNode_)-[:RELATES_TO]->(m:Entity)
 		WHERE e.group_id = $group_id
+		  AND e.deleted_at IS NULL
 		  AND e.created_at >= $start
 		  AND e.created_at <= $end
 		RETURN DISTINCT e.uuid AS uuid,
@@ -1709,7 +2034,7 @@ func (k *LadybugDriver) GetEdgesInTimeRange(ctx context.Context, start, end time
 		"end":      end.Format(time.RFC3339),
 	}
 
-	result, _, _, err := k.ExecuteQuery(query, params)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute GetEdgesInTimeRange query: %w", err)
 	}
@@ -1831,7 +2156,7 @@ func (k *LadybugDriver) RetrieveEpisodes(
 	`, queryFilter)
 
 	// Execute query
-	result, _, _, err := k.ExecuteQuery(query, queryParams)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, query, queryParams)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve episodes: %w", err)
 	}
@@ -1923,7 +2248,7 @@ func (k *LadybugDriver) BuildCommunities(ctx context.Context, groupID string) er
 }
 
 // GetExistingCommunity checks if an entity is already part of a community
-func (k *LadybugDriver) GetExistingCommunity(ctx context.Context, entityUUID string) (*types.Node, error) {
+func (k *LadybugDriver) GetExistingCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
 	query := `
 		MATCH (c:Community)-[:HAS_MEMBER]->(n:Entity {uuid: $entity_uuid})
 		RETURN c.uuid AS uuid, c.name AS name, c.summary AS summary, c.created_at AS created_at
@@ -1934,7 +2259,7 @@ func (k *LadybugDriver) GetExistingCommunity(ctx context.Context, entityUUID str
 		"entity_uuid": entityUUID,
 	}
 
-	result, _, _, err := k.ExecuteQuery(query, params)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query existing community: %w", err)
 	}
@@ -1953,7 +2278,7 @@ func (k *LadybugDriver) GetExistingCommunity(ctx context.Context, entityUUID str
 }
 
 // FindModalCommunity finds the most common community among connected entities
-func (k *LadybugDriver) FindModalCommunity(ctx context.Context, entityUUID string) (*types.Node, error) {
+func (k *LadybugDriver) FindModalCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
 	query := `
 		MATCH (c:Community)-[:HAS_MEMBER]->(m:Entity)-[:RELATES_TO]-(e:RelatesToNode_)-[:RELATES_TO]-(n:Entity {uuid: $entity_uuid})
 		WITH c, count(*) AS count
@@ -1966,7 +2291,7 @@ func (k *LadybugDriver) FindModalCommunity(ctx context.Context, entityUUID strin
 		"entity_uuid": entityUUID,
 	}
 
-	result, _, _, err := k.ExecuteQuery(query, params)
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query modal community: %w", err)
 	}
@@ -2023,12 +2348,9 @@ func (k *LadybugDriver) parseCommunityNodesFromRecords(result interface{}) ([]*t
 // RemoveCommunities removes all community nodes and their relationships from the graph.
 // ladybug-specific implementation using DELETE.
 func (k *LadybugDriver) RemoveCommunities(ctx context.Context) error {
-	k.mu.Lock()
-	defer k.mu.Unlock()
-
 	query := "MATCH (c:Community) DETACH DELETE c"
 
-	_, _, _, err := k.ExecuteQuery(query, nil)
+	_, _, _, err := k.ExecuteQueryWithContext(ctx, query, nil)
 	if err != nil {
 		return fmt.Errorf("failed to remove communities: %w", err)
 	}
@@ -2044,27 +2366,33 @@ func (k *LadybugDriver) CreateIndices(ctx context.Context) error {
 	return nil
 }
 
-// GetStats returns graph statistics
+// GetStats returns graph statistics for a group, or for every group when groupID is empty.
+// Tombstoned rows are not counted.
 func (k *LadybugDriver) GetStats(ctx context.Context, groupID string) (*GraphStats, error) {
-	stats := &GraphStats{
-		NodesByType: make(map[string]int64),
-		EdgesByType: make(map[string]int64),
-		LastUpdated: time.Now(),
-	}
+	stats := newGraphStats()
+	params := map[string]interface{}{"group_id": groupID}
+	groupFilter := "($group_id = '' OR n.group_id = $group_id)"
+	nodeFilter := groupFilter + " AND n.deleted_at IS NULL"
 
-	// Get node counts by table
+	// Get node counts by table and group
 	nodeTables := []string{"Entity", "Episodic", "Community", "RelatesToNode_"}
 	for _, table := range nodeTables {
-		query := fmt.Sprintf("MATCH (n:%s) RETURN count(n) as count", table)
-		result, _, _, err := k.ExecuteQuery(query, nil)
+		query := fmt.Sprintf("MATCH (n:%s) WHERE %s RETURN n.group_id AS group_id, count(n) AS count", table, nodeFilter)
+		result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 		if err != nil {
 			continue
 		}
 
-		if resultList, ok := result.([]map[string]interface{}); ok && len(resultList) > 0 {
-			if count, ok := resultList[0]["count"].(int64); ok {
-				stats.NodesByType[table] = count
-				stats.NodeCount += count
+		for _, row := range ladybugRows(result) {
+			count, _ := row["count"].(int64)
+			rowGroupID, _ := row["group_id"].(string)
+			stats.NodesByType[table] += count
+			stats.NodeCount += count
+			if table == "RelatesToNode_" {
+				// Entity edges are stored as RelatesToNode_ rows
+				stats.EdgesByGroup[rowGroupID] += count
+			} else {
+				stats.NodesByGroup[rowGroupID] += count
 			}
 		}
 	}
@@ -2072,16 +2400,23 @@ func (k *LadybugDriver) GetStats(ctx context.Context, groupID string) (*GraphSta
 	// Get edge counts by relationship type
 	edgeTables := []string{"RELATES_TO", "MENTIONS", "HAS_MEMBER"}
 	for _, table := range edgeTables {
-		query := fmt.Sprintf("MATCH ()-[r:%s]->() RETURN count(r) as count", table)
-		result, _, _, err := k.ExecuteQuery(query, nil)
+		query := fmt.Sprintf("MATCH ()-[n:%s]->() WHERE %s RETURN n.group_id AS group_id, count(n) AS count", table, groupFilter)
+		if table == "RELATES_TO" {
+			// RELATES_TO carries no properties; filter on the RelatesToNode_ it points to
+			query = "MATCH ()-[r:RELATES_TO]->(n:RelatesToNode_) WHERE " + nodeFilter + " RETURN n.group_id AS group_id, count(r) AS count"
+		}
+		result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 		if err != nil {
 			continue
 		}
 
-		if resultList, ok := result.([]map[string]interface{}); ok && len(resultList) > 0 {
-			if count, ok := resultList[0]["count"].(int64); ok {
-				stats.EdgesByType[table] = count
-				stats.EdgeCount += count
+		for _, row := range ladybugRows(result) {
+			count, _ := row["count"].(int64)
+			rowGroupID, _ := row["group_id"].(string)
+			stats.EdgesByType[table] += count
+			stats.EdgeCount += count
+			if table != "RELATES_TO" {
+				stats.EdgesByGroup[rowGroupID] += count
 			}
 		}
 	}
@@ -2091,6 +2426,52 @@ func (k *LadybugDriver) GetStats(ctx context.Context, groupID string) (*GraphSta
 		stats.CommunityCount = communityCount
 	}
 
+	// Split entity edges by validity and embedding coverage
+	edgeQuery := `
+		MATCH (n:RelatesToNode_)
+		WHERE ` + nodeFilter + `
+		RETURN count(CASE WHEN n.invalid_at IS NULL AND n.expired_at IS NULL THEN 1 END) AS valid,
+		       count(CASE WHEN n.invalid_at IS NOT NULL OR n.expired_at IS NOT NULL THEN 1 END) AS invalidated,
+		       count(CASE WHEN n.fact_embedding IS NULL OR size(n.fact_embedding) = 0 THEN 1 END) AS without_embedding
+	`
+	result, _, _, err := k.ExecuteQueryWithContext(ctx, edgeQuery, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count entity edges: %w", err)
+	}
+	for _, row := range ladybugRows(result) {
+		stats.ValidEdgeCount, _ = row["valid"].(int64)
+		stats.InvalidatedEdgeCount, _ = row["invalidated"].(int64)
+		stats.EdgesWithoutEmbedding, _ = row["without_embedding"].(int64)
+	}
+
+	for _, table := range []string{"Entity", "Community"} {
+		query := fmt.Sprintf(`
+			MATCH (n:%s)
+			WHERE %s AND (n.name_embedding IS NULL OR size(n.name_embedding) = 0)
+			RETURN count(n) AS count
+		`, table, nodeFilter)
+		result, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s nodes without embeddings: %w", table, err)
+		}
+		for _, row := range ladybugRows(result) {
+			count, _ := row["count"].(int64)
+			stats.NodesWithoutEmbedding += count
+		}
+	}
+
+	// Bucket episodes by the day of their reference time
+	episodeQuery := "MATCH (n:Episodic) WHERE " + nodeFilter + " RETURN coalesce(n.valid_at, n.created_at) AS reference"
+	result, _, _, err = k.ExecuteQueryWithContext(ctx, episodeQuery, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list episode times: %w", err)
+	}
+	for _, row := range ladybugRows(result) {
+		if reference, ok := row["reference"].(time.Time); ok {
+			stats.EpisodesPerDay[statsDay(reference)]++
+		}
+	}
+
 	return stats, nil
 }
 
@@ -2255,7 +2636,7 @@ func (k *LadybugDriver) mapToEdge(data map[string]interface{}) (*types.Edge, err
 	return edge, nil
 }
 
-func (k *LadybugDriver) executeNodeCreateQuery(node *types.Node, tableName string) error {
+func (k *LadybugDriver) executeNodeCreateQuery(ctx context.Context, node *types.Node, tableName string) error {
 	// Defensive nil check for node
 	if node == nil {
 		return fmt.Errorf("cannot create nil node")
@@ -2390,22 +2771,33 @@ func (k *LadybugDriver) executeNodeCreateQuery(node *types.Node, tableName strin
 		return fmt.Errorf("unknown table: %s", tableName)
 	}
 
-	_, _, _, err := k.ExecuteQuery(query, params)
+	_, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	return err
 }
 
-func (k *LadybugDriver) executeNodeUpdateQuery(node *types.Node, tableName string) error {
+func (k *LadybugDriver) executeNodeUpdateQuery(ctx context.Context, node *types.Node, tableName string) error {
+	statement, err := k.nodeUpdateStatement(node, tableName)
+	if err != nil || statement.query == "" {
+		return err
+	}
+
+	_, _, _, err = k.ExecuteQueryWithContext(ctx, statement.query, statement.params)
+	return err
+}
+
+// nodeUpdateStatement returns the statement updating node in tableName, with
+// an empty query when there is nothing to update.
+func (k *LadybugDriver) nodeUpdateStatement(node *types.Node, tableName string) (graphStatement, error) {
 	// Defensive nil check for node
 	if node == nil {
-		return fmt.Errorf("cannot update nil node")
+		return graphStatement{}, fmt.Errorf("cannot update nil node")
 	}
 
 	var metadataJSON string
-	var err error
 	if len(node.Metadata) > 0 {
 		data, marshalErr := json.Marshal(node.Metadata)
 		if marshalErr != nil {
-			return fmt.Errorf("failed to marshal node metadata: %w", marshalErr)
+			return graphStatement{}, fmt.Errorf("failed to marshal node metadata: %w", marshalErr)
 		}
 		metadataJSON = string(data)
 	}
@@ -2510,12 +2902,12 @@ func (k *LadybugDriver) executeNodeUpdateQuery(node *types.Node, tableName strin
 		}
 
 	default:
-		return fmt.Errorf("unknown table: %s", tableName)
+		return graphStatement{}, fmt.Errorf("unknown table: %s", tableName)
 	}
 
 	// Only execute query if there are fields to update
 	if len(setClauses) == 0 {
-		return nil // Nothing to update
+		return graphStatement{}, nil // Nothing to update
 	}
 
 	query = fmt.Sprintf(`
@@ -2524,8 +2916,7 @@ func (k *LadybugDriver) executeNodeUpdateQuery(node *types.Node, tableName strin
 		SET %s
 	`, tableName, strings.Join(setClauses, ", "))
 
-	_, _, _, err = k.ExecuteQuery(query, params)
-	return err
+	return graphStatement{query: query, params: params}, nil
 }
 
 func convertToFloat32Slice(data interface{}) []float32 {
@@ -2608,7 +2999,7 @@ func (s *LadybugDriverSession) Run(ctx context.Context, query interface{}, kwarg
 				if !ok {
 					params = make(map[string]interface{})
 				}
-				_, _, _, err := s.driver.ExecuteQuery(cypher, params)
+				_, _, _, err := s.driver.ExecuteQueryWithContext(ctx, cypher, params)
 				if err != nil {
 					return err
 				}
@@ -2620,7 +3011,7 @@ func (s *LadybugDriverSession) Run(ctx context.Context, query interface{}, kwarg
 		if kwargs == nil {
 			kwargs = make(map[string]interface{})
 		}
-		_, _, _, err := s.driver.ExecuteQuery(cypherQuery, kwargs)
+		_, _, _, err := s.driver.ExecuteQueryWithContext(ctx, cypherQuery, kwargs)
 		if err != nil {
 			return err
 		}
@@ -2639,48 +3030,10 @@ func (s *LadybugDriverSession) Exit(ctx context.Context, excType, excVal, excTb
 	return nil
 }
 
-func (k *LadybugDriver) GetBetweenNodes(ctx context.Context, sourceNodeID, targetNodeID string) ([]*types.Edge, error) {
-	query := `
-		MATCH (a:Entity {uuid: $source_uuid})-[:RELATES_TO]->(rel:RelatesToNode_)-[:RELATES_TO]->(b:Entity {uuid: $target_uuid})
-		RETURN rel.uuid AS uuid, rel.name AS name, rel.fact AS fact, rel.group_id AS group_id,
-		       rel.created_at AS created_at, rel.valid_at AS valid_at, rel.invalid_at AS invalid_at,
-		       rel.expired_at AS expired_at, rel.episodes AS episodes, rel.attributes AS attributes,
-		       a.uuid AS source_id, b.uuid AS target_id
-		UNION
-		MATCH (a:Entity {uuid: $target_uuid})-[:RELATES_TO]->(rel:RelatesToNode_)-[:RELATES_TO]->(b:Entity {uuid: $source_uuid})
-		RETURN rel.uuid AS uuid, rel.name AS name, rel.fact AS fact, rel.group_id AS group_id,
-		       rel.created_at AS created_at, rel.valid_at AS valid_at, rel.invalid_at AS invalid_at,
-		       rel.expired_at AS expired_at, rel.episodes AS episodes, rel.attributes AS attributes,
-		       a.uuid AS source_id, b.uuid AS target_id
-	`
-
-	params := map[string]interface{}{
-		"source_uuid": sourceNodeID,
-		"target_uuid": targetNodeID,
-	}
-
-	result, _, _, err := k.ExecuteQuery(query, params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute GetBetweenNodes query: %w", err)
-	}
-
-	// Convert result to Edge objects
-	var edges []*types.Edge
-	recordSlice, ok := result.([]map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("unexpected query result type: %T", result)
-	}
-
-	for _, record := range recordSlice {
-		edge, err := convertRecordToEdge(record)
-		if err != nil {
-			log.Printf("Warning: failed to convert record to edge: %v", err)
-			continue
-		}
-		edges = append(edges, edge)
-	}
-
-	return edges, nil
+// GetBetweenNodes retrieves the entity edges of groupID between two entities
+// in either direction.
+func (k *LadybugDriver) GetBetweenNodes(ctx context.Context, sourceNodeID, targetNodeID, groupID string) ([]*types.Edge, error) {
+	return EdgesBetween(ctx, k, groupID, sourceNodeID, targetNodeID)
 }
 
 func (k *LadybugDriver) GetNodeNeighbors(ctx context.Context, nodeUUID, groupID string) ([]types.Neighbor, error) {
@@ -2695,7 +3048,7 @@ func (k *LadybugDriver) GetNodeNeighbors(ctx context.Context, nodeUUID, groupID
 		"group_id": groupID,
 	}
 
-	records, _, _, err := k.ExecuteQuery(query, params)
+	records, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute neighbor query: %w", err)
 	}
@@ -2755,13 +3108,14 @@ func (k *LadybugDriver) ParseNodesFromRecords(records interface{}) ([]*types.Nod
 func (k *LadybugDriver) GetEntityNodesByGroup(ctx context.Context, groupID string) ([]*types.Node, error) {
 	query := `
 		MATCH (n:Entity {group_id: $group_id})
+		WHERE n.deleted_at IS NULL
 		RETURN n.uuid AS uuid, n.name AS name, n.summary AS summary, n.created_at AS created_at
 	`
 	params := map[string]interface{}{
 		"group_id": groupID,
 	}
 
-	records, _, _, err := k.ExecuteQuery(query, params)
+	records, _, _, err := k.ExecuteQueryWithContext(ctx, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute entity nodes query: %w", err)
 	}
@@ -2802,7 +3156,7 @@ func (k *LadybugDriver) GetAllGroupIDs(ctx context.Context) ([]string, error) {
 		RETURN collect(DISTINCT n.group_id) AS group_ids
 	`
 
-	records, _, _, err := k.ExecuteQuery(query, nil)
+	records, _, _, err := k.ExecuteQueryWithContext(ctx, query, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute group IDs query: %w", err)
 	}
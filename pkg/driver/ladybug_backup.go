@@ -0,0 +1,272 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/driver/migrations"
+)
+
+// backupDirPrefix is the name prefix of the directories created by scheduled backups.
+const backupDirPrefix = "backup-"
+
+// Backup exports the database to destPath using EXPORT DATABASE. The export is a
+// consistent snapshot taken while the database stays open, unlike copying the
+// database directory. destPath must not exist or be an empty directory.
+func (k *LadybugDriver) Backup(ctx context.Context, destPath string) error {
+	if destPath == "" {
+		return fmt.Errorf("backup destination is required")
+	}
+
+	if entries, err := os.ReadDir(destPath); err == nil {
+		if len(entries) > 0 {
+			return fmt.Errorf("backup destination %s is not empty", destPath)
+		}
+		// EXPORT DATABASE creates the directory itself
+		if err := os.Remove(destPath); err != nil {
+			return fmt.Errorf("failed to prepare backup destination: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to check backup destination: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create backup parent directory: %w", err)
+	}
+
+	query := fmt.Sprintf("EXPORT DATABASE %s", cypherStringLiteral(destPath))
+	if _, _, _, err := k.ExecuteQueryWithContext(ctx, query, nil); err != nil {
+		// Do not leave a partial export behind that could be mistaken for a backup
+		os.RemoveAll(destPath)
+		return fmt.Errorf("failed to export database to %s: %w", destPath, err)
+	}
+	return nil
+}
+
+// Restore replaces the contents of the database with an export created by Backup,
+// using IMPORT DATABASE. The current contents are exported to a temporary
+// directory first and restored again if the import fails. Other queries must not
+// run while Restore is in progress.
+func (k *LadybugDriver) Restore(ctx context.Context, srcPath string) error {
+	if _, err := os.Stat(filepath.Join(srcPath, "schema.cypher")); err != nil {
+		return fmt.Errorf("%s is not a database export: %w", srcPath, err)
+	}
+
+	rollbackDir, err := os.MkdirTemp("", "ladybug_restore_*")
+	if err != nil {
+		return fmt.Errorf("failed to create rollback directory: %w", err)
+	}
+	defer os.RemoveAll(rollbackDir)
+
+	rollbackPath := filepath.Join(rollbackDir, "previous")
+	if err := k.Backup(ctx, rollbackPath); err != nil {
+		return fmt.Errorf("failed to save current database before restore: %w", err)
+	}
+
+	if err := k.importDatabase(ctx, srcPath); err != nil {
+		// Use a fresh context so the rollback is not cut short by the failed one
+		if rollbackErr := k.importDatabase(context.Background(), rollbackPath); rollbackErr != nil {
+			return fmt.Errorf("failed to restore from %s: %w (rollback also failed: %v)", srcPath, err, rollbackErr)
+		}
+		return fmt.Errorf("failed to restore from %s, previous contents were kept: %w", srcPath, err)
+	}
+
+	// Recreate base tables and fulltext indexes missing from older exports
+	k.setupSchema()
+
+	if err := k.HealthCheck(ctx); err != nil {
+		return fmt.Errorf("restored database failed health check: %w", err)
+	}
+
+	// Bring exports taken by older releases up to the current schema version
+	if err := migrations.NewRunner(k, migrations.ProviderLadybug).Migrate(ctx); err != nil {
+		return fmt.Errorf("failed to migrate restored database: %w", err)
+	}
+	return nil
+}
+
+// importDatabase drops every table and imports the export at srcPath.
+// IMPORT DATABASE only works on a database without tables.
+func (k *LadybugDriver) importDatabase(ctx context.Context, srcPath string) error {
+	if err := k.dropAllTables(ctx); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("IMPORT DATABASE %s", cypherStringLiteral(srcPath))
+	if _, _, _, err := k.ExecuteQueryWithContext(ctx, query, nil); err != nil {
+		return fmt.Errorf("failed to import database: %w", err)
+	}
+	return nil
+}
+
+// dropAllTables drops the fulltext indexes, then relationship tables, then node tables.
+func (k *LadybugDriver) dropAllTables(ctx context.Context) error {
+	result, _, _, err := k.ExecuteQueryWithContext(ctx,
+		"CALL SHOW_INDEXES() RETURN `table name` AS table_name, `index name` AS index_name", nil)
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+	for _, row := range ladybugRows(result) {
+		table, _ := row["table_name"].(string)
+		index, _ := row["index_name"].(string)
+		if table == "" || index == "" {
+			continue
+		}
+		query := fmt.Sprintf("CALL DROP_FTS_INDEX(%s, %s)", cypherStringLiteral(table), cypherStringLiteral(index))
+		if _, _, _, err := k.ExecuteQueryWithContext(ctx, query, nil); err != nil {
+			return fmt.Errorf("failed to drop index %s on %s: %w", index, table, err)
+		}
+	}
+
+	result, _, _, err = k.ExecuteQueryWithContext(ctx, "CALL SHOW_TABLES() RETURN name, type", nil)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	var relTables, nodeTables []string
+	for _, row := range ladybugRows(result) {
+		name, _ := row["name"].(string)
+		tableType, _ := row["type"].(string)
+		if name == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.ToUpper(tableType), "REL") {
+			relTables = append(relTables, name)
+		} else {
+			nodeTables = append(nodeTables, name)
+		}
+	}
+
+	for _, table := range append(relTables, nodeTables...) {
+		if _, _, _, err := k.ExecuteQueryWithContext(ctx, fmt.Sprintf("DROP TABLE %s", table), nil); err != nil {
+			return fmt.Errorf("failed to drop table %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// ladybugRows returns the rows of an ExecuteQuery result.
+func ladybugRows(result interface{}) []map[string]interface{} {
+	rows, _ := result.([]map[string]interface{})
+	return rows
+}
+
+// cypherStringLiteral quotes s as a single-quoted Cypher string.
+func cypherStringLiteral(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+// BackupSchedule configures periodic backups.
+type BackupSchedule struct {
+	// Dir receives one timestamped backup directory per run
+	Dir string
+	// Interval between backups (defaults to 24 hours)
+	Interval time.Duration
+	// Keep is the number of most recent backups to retain; zero keeps all
+	Keep int
+	// OnError is called when a backup or cleanup fails (defaults to logging)
+	OnError func(error)
+}
+
+// BackupScheduler runs periodic backups of a LadybugDriver.
+type BackupScheduler struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// ScheduleBackups starts backing up the database every schedule.Interval into
+// timestamped directories under schedule.Dir, pruning all but the newest
+// schedule.Keep backups. Backups stop when ctx is done or Stop is called.
+func (k *LadybugDriver) ScheduleBackups(ctx context.Context, schedule BackupSchedule) (*BackupScheduler, error) {
+	if schedule.Dir == "" {
+		return nil, fmt.Errorf("backup directory is required")
+	}
+	if schedule.Interval <= 0 {
+		schedule.Interval = 24 * time.Hour
+	}
+	if schedule.OnError == nil {
+		schedule.OnError = func(err error) {
+			log.Printf("Scheduled ladybug backup failed: %v", err)
+		}
+	}
+	if err := os.MkdirAll(schedule.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	scheduler := &BackupScheduler{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(scheduler.done)
+
+		ticker := time.NewTicker(schedule.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				dest := filepath.Join(schedule.Dir, backupDirPrefix+now.UTC().Format("20060102T150405Z"))
+				if err := k.Backup(ctx, dest); err != nil {
+					if ctx.Err() == nil {
+						schedule.OnError(err)
+					}
+					continue
+				}
+				if err := pruneBackups(schedule.Dir, schedule.Keep); err != nil {
+					schedule.OnError(err)
+				}
+			}
+		}
+	}()
+
+	return scheduler, nil
+}
+
+// Stop stops scheduling backups and waits for a running backup to finish.
+func (s *BackupScheduler) Stop() {
+	s.once.Do(s.cancel)
+	<-s.done
+}
+
+// pruneBackups removes all but the newest keep backup directories in dir.
+func pruneBackups(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), backupDirPrefix) {
+			backups = append(backups, entry.Name())
+		}
+	}
+	if len(backups) <= keep {
+		return nil
+	}
+
+	// Timestamped names sort chronologically
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-keep] {
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("failed to remove old backup %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package driver_test
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -42,6 +43,65 @@ func TestNewLadybugDriver(t *testing.T) {
 	})
 }
 
+func TestLadybugDriverHealthCheck(t *testing.T) {
+	t.Run("healthy database", func(t *testing.T) {
+		d, err := driver.NewLadybugDriver(createTempLadybugDB(t), 1)
+		require.NoError(t, err)
+		defer d.Close()
+
+		assert.NoError(t, d.HealthCheck(context.Background()))
+	})
+
+	t.Run("empty database file", func(t *testing.T) {
+		dbPath := createTempLadybugDB(t)
+		require.NoError(t, os.WriteFile(dbPath, nil, 0o644))
+
+		d, err := driver.NewLadybugDriver(dbPath, 1)
+		assert.Nil(t, d)
+		assert.ErrorIs(t, err, driver.ErrDatabaseCorrupt)
+	})
+
+	t.Run("orphaned write-ahead log", func(t *testing.T) {
+		dbPath := createTempLadybugDB(t)
+		require.NoError(t, os.WriteFile(dbPath+".wal", []byte("partial"), 0o644))
+
+		d, err := driver.NewLadybugDriver(dbPath, 1)
+		assert.Nil(t, d)
+		assert.ErrorIs(t, err, driver.ErrDatabaseCorrupt)
+	})
+}
+
+func TestLadybugDriverQueryCancellation(t *testing.T) {
+	config := driver.DefaultLadybugDriverConfig().
+		WithDBPath(createTempLadybugDB(t)).
+		WithQueryTimeout(time.Minute)
+	d, err := driver.NewLadybugDriverWithConfig(config)
+	require.NoError(t, err)
+	defer d.Close()
+
+	t.Run("completes with live context", func(t *testing.T) {
+		result, _, _, err := d.ExecuteQueryWithContext(context.Background(), "RETURN 1 AS ok", nil)
+		require.NoError(t, err)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, _, _, err := d.ExecuteQueryWithContext(ctx, "RETURN 1 AS ok", nil)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("cancelled write", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, _, _, err := d.ExecuteQueryWithContext(ctx, "MATCH (c:Community) DETACH DELETE c", nil)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
 // TestLadybugDriverStubImplementation is now deprecated since LadybugDriver is fully implemented
 // Kept as a placeholder to maintain test compatibility, but skipped
 func TestLadybugDriverStubImplementation(t *testing.T) {
@@ -445,3 +505,150 @@ func TestLadybugDriver_UpsertCommunityEdge(t *testing.T) {
 	err = d.UpsertCommunityEdge(ctx, communityNode.Uuid, entityNode.Uuid, edgeUUID, "test-group")
 	require.NoError(t, err, "Second UpsertCommunityEdge should succeed (idempotent)")
 }
+
+func TestLadybugDriverBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	d, err := driver.NewLadybugDriver(createTempLadybugDB(t), 1)
+	require.NoError(t, err)
+	defer d.Close()
+
+	now := time.Now()
+	kept := &types.Node{Uuid: "kept", Name: "Kept", Type: types.EntityNodeType, GroupID: "g", CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, d.UpsertNode(ctx, kept))
+
+	backupPath := filepath.Join(t.TempDir(), "backup")
+	require.NoError(t, d.Backup(ctx, backupPath))
+	assert.FileExists(t, filepath.Join(backupPath, "schema.cypher"))
+
+	t.Run("refuses non-empty destination", func(t *testing.T) {
+		assert.Error(t, d.Backup(ctx, backupPath))
+	})
+
+	added := &types.Node{Uuid: "added", Name: "Added", Type: types.EntityNodeType, GroupID: "g", CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, d.UpsertNode(ctx, added))
+
+	require.NoError(t, d.Restore(ctx, backupPath))
+
+	node, err := d.GetNode(ctx, "kept", "g")
+	require.NoError(t, err)
+	assert.Equal(t, "Kept", node.Name)
+
+	_, err = d.GetNode(ctx, "added", "g")
+	assert.Error(t, err, "nodes written after the backup should be gone")
+
+	t.Run("rejects directories that are not exports", func(t *testing.T) {
+		assert.Error(t, d.Restore(ctx, t.TempDir()))
+	})
+}
+
+func TestLadybugDriverScheduleBackups(t *testing.T) {
+	d, err := driver.NewLadybugDriver(createTempLadybugDB(t), 1)
+	require.NoError(t, err)
+	defer d.Close()
+
+	dir := t.TempDir()
+	scheduler, err := d.ScheduleBackups(context.Background(), driver.BackupSchedule{
+		Dir:      dir,
+		Interval: 1100 * time.Millisecond,
+		Keep:     1,
+		OnError:  func(err error) { t.Errorf("backup failed: %v", err) },
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		entries, _ := os.ReadDir(dir)
+		return len(entries) >= 1
+	}, 5*time.Second, 100*time.Millisecond)
+
+	time.Sleep(1500 * time.Millisecond)
+	scheduler.Stop()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "only the newest backup should be kept")
+}
+
+func TestLadybugDriverSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	config := driver.DefaultLadybugDriverConfig().
+		WithDBPath(createTempLadybugDB(t)).
+		WithSoftDelete(true)
+	d, err := driver.NewLadybugDriverWithConfig(config)
+	require.NoError(t, err)
+	defer d.Close()
+
+	now := time.Now()
+	node := &types.Node{Uuid: "tombstoned", Name: "Tombstoned", Type: types.EntityNodeType, GroupID: "g", CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, d.UpsertNode(ctx, node))
+
+	deletedAt := time.Now().Add(-time.Second)
+	require.NoError(t, d.DeleteNode(ctx, node.Uuid, "g"))
+
+	_, err = d.GetNode(ctx, node.Uuid, "g")
+	assert.Error(t, err, "tombstoned nodes should be hidden from reads")
+
+	result, _, _, err := d.ExecuteQuery("MATCH (n:Entity {uuid: 'tombstoned'}) RETURN n.deleted_at AS deleted_at", nil)
+	require.NoError(t, err)
+	rows := result.([]map[string]interface{})
+	require.Len(t, rows, 1, "soft delete should keep the row")
+	assert.NotNil(t, rows[0]["deleted_at"])
+
+	require.NoError(t, d.RestoreDeleted(ctx, "g", deletedAt))
+	restored, err := d.GetNode(ctx, node.Uuid, "g")
+	require.NoError(t, err)
+	assert.Equal(t, "Tombstoned", restored.Name)
+
+	require.NoError(t, d.DeleteNode(ctx, node.Uuid, "g"))
+	require.NoError(t, d.PurgeDeleted(ctx, time.Hour))
+	result, _, _, err = d.ExecuteQuery("MATCH (n:Entity {uuid: 'tombstoned'}) RETURN n.uuid AS uuid", nil)
+	require.NoError(t, err)
+	assert.Len(t, result.([]map[string]interface{}), 1, "recent tombstones should survive the purge")
+
+	require.NoError(t, d.PurgeDeleted(ctx, 0))
+	result, _, _, err = d.ExecuteQuery("MATCH (n:Entity {uuid: 'tombstoned'}) RETURN n.uuid AS uuid", nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.([]map[string]interface{}))
+}
+
+func TestLadybugDriverGetStatsBreakdowns(t *testing.T) {
+	ctx := context.Background()
+	d, err := driver.NewLadybugDriver(createTempLadybugDB(t), 1)
+	require.NoError(t, err)
+	defer d.Close()
+
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	nodes := []*types.Node{
+		{Uuid: "a", Name: "A", Type: types.EntityNodeType, GroupID: "g1", CreatedAt: day, NameEmbedding: []float32{0.1, 0.2}},
+		{Uuid: "b", Name: "B", Type: types.EntityNodeType, GroupID: "g1", CreatedAt: day},
+		{Uuid: "c", Name: "C", Type: types.EntityNodeType, GroupID: "g2", CreatedAt: day},
+		{Uuid: "ep", Name: "Episode", Type: types.EpisodicNodeType, GroupID: "g1", CreatedAt: day, ValidFrom: day, Reference: day},
+	}
+	for _, node := range nodes {
+		require.NoError(t, d.UpsertNode(ctx, node))
+	}
+
+	invalidAt := day.Add(time.Hour)
+	edges := []*types.Edge{
+		{BaseEdge: types.BaseEdge{Uuid: "e1", GroupID: "g1", SourceNodeID: "a", TargetNodeID: "b", CreatedAt: day}, Name: "KNOWS", Fact: "A knows B", Type: types.EntityEdgeType},
+		{BaseEdge: types.BaseEdge{Uuid: "e2", GroupID: "g1", SourceNodeID: "b", TargetNodeID: "a", CreatedAt: day}, Name: "KNEW", Fact: "B knew A", Type: types.EntityEdgeType, ValidTo: &invalidAt},
+	}
+	for _, edge := range edges {
+		require.NoError(t, d.UpsertEdge(ctx, edge))
+	}
+
+	stats, err := d.GetStats(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.NodesByGroup["g1"])
+	assert.Equal(t, int64(1), stats.NodesByGroup["g2"])
+	assert.Equal(t, int64(2), stats.EdgesByGroup["g1"])
+	assert.Equal(t, int64(1), stats.ValidEdgeCount)
+	assert.Equal(t, int64(1), stats.InvalidatedEdgeCount)
+	assert.Equal(t, int64(2), stats.EdgesWithoutEmbedding)
+	assert.Equal(t, int64(2), stats.NodesWithoutEmbedding)
+	assert.Equal(t, int64(1), stats.EpisodesPerDay["2024-05-01"])
+
+	groupStats, err := d.GetStats(ctx, "g2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), groupStats.NodeCount)
+	assert.Empty(t, groupStats.EdgesByGroup)
+}
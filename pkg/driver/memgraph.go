@@ -3,17 +3,20 @@ package driver
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+	"github.com/soundprediction/go-predicato/pkg/driver/migrations"
 	"github.com/soundprediction/go-predicato/pkg/types"
 )
 
@@ -22,11 +25,27 @@ import (
 type MemgraphDriver struct {
 	client   neo4j.DriverWithContext
 	database string
+	options  *Options
+
+	// vector caches the vector index capabilities probed from the server
+	vector       vectorIndexSupport
+	vectorProbed bool
+	vectorMu     sync.Mutex
 }
 
-// NewMemgraphDriver creates a new Memgraph driver instance.
+// NewMemgraphDriver creates a new Memgraph driver instance with default Options.
 func NewMemgraphDriver(uri, username, password, database string) (*MemgraphDriver, error) {
-	driver, err := neo4j.NewDriverWithContext(uri, neo4j.BasicAuth(username, password, ""))
+	return NewMemgraphDriverWithOptions(uri, username, password, database, nil)
+}
+
+// NewMemgraphDriverWithOptions creates a new Memgraph driver instance with the given
+// connection pooling and retry options. A nil opts uses DefaultOptions. It
+// returns migrations.ErrSchemaTooNew if a reachable server holds a schema
+// written by a newer release.
+func NewMemgraphDriverWithOptions(uri, username, password, database string, opts *Options) (*MemgraphDriver, error) {
+	opts = opts.withDefaults()
+
+	driver, err := neo4j.NewDriverWithContext(uri, neo4j.BasicAuth(username, password, ""), opts.configure)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create memgraph driver: %w", err)
 	}
@@ -35,10 +54,40 @@ func NewMemgraphDriver(uri, username, password, database string) (*MemgraphDrive
 		database = "memgraph"
 	}
 
-	return &MemgraphDriver{
+	m := &MemgraphDriver{
 		client:   driver,
 		database: database,
-	}, nil
+		options:  opts,
+	}
+
+	// Refuse databases written by newer releases, as Ladybug does on open
+	if err := m.checkSchemaVersion(); err != nil {
+		driver.Close(context.Background())
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// checkSchemaVersion returns migrations.ErrSchemaTooNew if the database was
+// migrated by a newer release. An unreachable server is only logged, leaving
+// the check to CreateIndexes.
+func (m *MemgraphDriver) checkSchemaVersion() error {
+	ctx, cancel := context.WithTimeout(context.Background(), schemaCheckTimeout)
+	defer cancel()
+	if err := m.client.VerifyConnectivity(ctx); err != nil {
+		log.Printf("Warning: skipping memgraph schema version check: %v", err)
+		return nil
+	}
+
+	err := migrations.NewRunner(m, migrations.ProviderMemgraph).Check(ctx)
+	if errors.Is(err, migrations.ErrSchemaTooNew) {
+		return fmt.Errorf("failed to check memgraph schema: %w", err)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to check memgraph schema version: %v", err)
+	}
+	return nil
 }
 
 // GetNode retrieves a node by ID.
@@ -49,6 +98,7 @@ func (m *MemgraphDriver) GetNode(ctx context.Context, nodeID, groupID string) (*
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (n {uuid: $nodeID, group_id: $groupID})
+			WHERE n.deleted_at IS NULL
 			RETURN n
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -62,7 +112,7 @@ func (m *MemgraphDriver) GetNode(ctx context.Context, nodeID, groupID string) (*
 		record, err := res.Single(ctx)
 		if err != nil {
 			if err.Error() == "Result contains no more records" {
-				return nil, fmt.Errorf("node not found")
+				return nil, ErrNodeNotFound
 			}
 			return nil, err
 		}
@@ -76,7 +126,7 @@ func (m *MemgraphDriver) GetNode(ctx context.Context, nodeID, groupID string) (*
 	record := result.(*db.Record)
 	nodeValue, found := record.Get("n")
 	if !found {
-		return nil, fmt.Errorf("node not found")
+		return nil, ErrNodeNotFound
 	}
 
 	node := nodeValue.(dbtype.Node)
@@ -147,47 +197,17 @@ func (m *MemgraphDriver) UpsertNode(ctx context.Context, node *types.Node) error
 		node.ValidFrom = node.CreatedAt
 	}
 
-	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
-	defer session.Close(ctx)
-
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		// Get base label for node type
-		baseLabel := m.getLabelForNodeType(node.Type)
-
-		// Build query with dynamic label support for Entity nodes
-		var query string
-		if node.Type == types.EntityNodeType && node.EntityType != "" {
-			// For Entity nodes with an EntityType, set both Entity label and specific type label
-			query = fmt.Sprintf(`
-				MERGE (n:%s {uuid: $uuid, group_id: $group_id})
-				SET n:%s
-				SET n += $properties
-				SET n.updated_at = $updated_at
-			`, baseLabel, node.EntityType)
-		} else {
-			// For other node types or entities without type, use base label only
-			query = fmt.Sprintf(`
-				MERGE (n:%s {uuid: $uuid, group_id: $group_id})
-				SET n += $properties
-				SET n.updated_at = $updated_at
-			`, baseLabel)
-		}
-
-		properties := m.nodeToProperties(node)
-		_, err := tx.Run(ctx, query, map[string]any{
-			"uuid":       node.Uuid,
-			"group_id":   node.GroupID,
-			"properties": properties,
-			"updated_at": time.Now().Format(time.RFC3339),
-		})
-		return nil, err
-	})
-
-	return err
+	statement := boltUpsertNodeStatement(m.getLabelForNodeType(node.Type), node, m.nodeToProperties(node))
+	return runBoltStatements(ctx, m.client, m.database, []graphStatement{statement})
 }
 
-// DeleteNode removes a node and its edges.
+// DeleteNode removes a node and its edges. With soft deletes enabled the node
+// and its edges are marked with deleted_at instead.
 func (m *MemgraphDriver) DeleteNode(ctx context.Context, nodeID, groupID string) error {
+	if m.options.SoftDelete {
+		return runBoltWrites(ctx, m.client, m.database, []string{boltSoftDeleteNodeEdgesQuery, boltSoftDeleteNodeQuery}, softDeleteParams(nodeID, groupID))
+	}
+
 	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
 	defer session.Close(ctx)
 
@@ -218,7 +238,7 @@ func (m *MemgraphDriver) GetNodes(ctx context.Context, nodeIDs []string, groupID
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (n {group_id: $groupID})
-			WHERE n.uuid IN $nodeIDs
+			WHERE n.uuid IN $nodeIDs AND n.deleted_at IS NULL
 			RETURN n
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -259,6 +279,7 @@ func (m *MemgraphDriver) GetEdge(ctx context.Context, edgeID, groupID string) (*
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (s)-[r {uuid: $edgeID, group_id: $groupID}]->(t)
+			WHERE r.deleted_at IS NULL
 			RETURN r, s.uuid as source_id, t.uuid as target_id
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -272,7 +293,7 @@ func (m *MemgraphDriver) GetEdge(ctx context.Context, edgeID, groupID string) (*
 		record, err := res.Single(ctx)
 		if err != nil {
 			if err.Error() == "Result contains no more records" {
-				return nil, fmt.Errorf("edge not found")
+				return nil, ErrEdgeNotFound
 			}
 			return nil, err
 		}
@@ -286,7 +307,7 @@ func (m *MemgraphDriver) GetEdge(ctx context.Context, edgeID, groupID string) (*
 	record := result.(*db.Record)
 	relationValue, found := record.Get("r")
 	if !found {
-		return nil, fmt.Errorf("edge not found")
+		return nil, ErrEdgeNotFound
 	}
 
 	relation := relationValue.(dbtype.Relationship)
@@ -345,33 +366,8 @@ func (m *MemgraphDriver) UpsertEdge(ctx context.Context, edge *types.Edge) error
 		edge.ValidFrom = edge.CreatedAt
 	}
 
-	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
-	defer session.Close(ctx)
-
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		query := `
-			MATCH (s {uuid: $source_id, group_id: $group_id})
-			MATCH (t {uuid: $target_id, group_id: $group_id})
-			MERGE (s)-[r:RELATES_TO {uuid: $uuid, group_id: $group_id}]->(t)
-			SET r += $properties
-			SET r.updated_at = $updated_at
-		`
-
-		properties := m.edgeToProperties(edge)
-		_, err := tx.Run(ctx, query, map[string]any{
-			"uuid":       edge.Uuid,
-			"source_id":  edge.SourceID,
-			"target_id":  edge.TargetID,
-			"group_id":   edge.GroupID,
-			"fact":       edge.Fact,
-			"name":       edge.Name,
-			"properties": properties,
-			"updated_at": time.Now().Format(time.RFC3339),
-		})
-		return nil, err
-	})
-
-	return err
+	statement := boltUpsertEdgeStatement(edge, m.edgeToProperties(edge))
+	return runBoltStatements(ctx, m.client, m.database, []graphStatement{statement})
 }
 
 // UpsertEpisodicEdge creates or updates a MENTIONS relationship between an Episodic node and an Entity node.
@@ -437,8 +433,13 @@ func (m *MemgraphDriver) UpsertCommunityEdge(ctx context.Context, communityUUID,
 	return nil
 }
 
-// DeleteEdge removes an edge.
+// DeleteEdge removes an edge. With soft deletes enabled the edge is marked with
+// deleted_at instead.
 func (m *MemgraphDriver) DeleteEdge(ctx context.Context, edgeID, groupID string) error {
+	if m.options.SoftDelete {
+		return runBoltWrites(ctx, m.client, m.database, []string{boltSoftDeleteEdgeQuery}, softDeleteParams(edgeID, groupID))
+	}
+
 	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
 	defer session.Close(ctx)
 
@@ -469,7 +470,7 @@ func (m *MemgraphDriver) GetEdges(ctx context.Context, edgeIDs []string, groupID
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (s)-[r {group_id: $groupID}]->(t)
-			WHERE r.uuid IN $edgeIDs
+			WHERE r.uuid IN $edgeIDs AND r.deleted_at IS NULL
 			RETURN r, s.uuid as source_id, t.uuid as target_id
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -548,43 +549,30 @@ func (m *MemgraphDriver) GetNeighbors(ctx context.Context, nodeID, groupID strin
 	return nodes, nil
 }
 
+// GetRelatedNodes retrieves the direct neighbors of a node, optionally restricted
+// to edge types or relation names.
 func (m *MemgraphDriver) GetRelatedNodes(ctx context.Context, nodeID, groupID string, edgeTypes []types.EdgeType) ([]*types.Node, error) {
 	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		var query string
 		params := map[string]any{
 			"nodeID":  nodeID,
 			"groupID": groupID,
 		}
-
-		if len(edgeTypes) == 0 {
-			// Get all related nodes regardless of edge type
-			query = `
-				MATCH (start {uuid: $nodeID, group_id: $groupID})
-				MATCH (start)-[r]-(related)
-				WHERE related.group_id = $groupID AND related.id <> $nodeID
-				RETURN DISTINCT related
-			`
-		} else {
-			// Filter by specific edge types
-			edgeTypeStrings := make([]string, len(edgeTypes))
-			for i, edgeType := range edgeTypes {
-				edgeTypeStrings[i] = string(edgeType)
-			}
-			params["edgeTypes"] = edgeTypeStrings
-
-			query = `
-				MATCH (start {uuid: $nodeID, group_id: $groupID})
-				MATCH (start)-[r]-(related)
-				WHERE related.group_id = $groupID
-				  AND related.id <> $nodeID
-				  AND r.type IN $edgeTypes
-				RETURN DISTINCT related
-			`
+		condition, ok := newRelatedEdgeFilter(edgeTypes).boltCondition("r", params)
+		if !ok {
+			return []*db.Record{}, nil
 		}
 
+		query := `
+			MATCH (start {uuid: $nodeID, group_id: $groupID})-[r]-(related)
+			WHERE related.group_id = $groupID
+			  AND related.uuid <> $nodeID
+			  AND ` + condition + `
+			RETURN DISTINCT related
+		`
+
 		res, err := tx.Run(ctx, query, params)
 		if err != nil {
 			return nil, err
@@ -612,24 +600,37 @@ func (m *MemgraphDriver) GetRelatedNodes(ctx context.Context, nodeID, groupID st
 	return nodes, nil
 }
 
+// SearchNodesByEmbedding returns the nodes most similar to embedding. It uses the
+// vector indexes when Options.VectorDimensions is set and the vector_search module
+// is available, and otherwise computes cosine similarity over every embedded node.
 func (m *MemgraphDriver) SearchNodesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int) ([]*types.Node, error) {
+	return m.searchNodesByEmbedding(ctx, embedding, groupID, limit, groupSearchFilter(groupID))
+}
+
+// searchNodesByEmbedding implements SearchNodesByEmbedding for the nodes
+// matching filter.
+func (m *MemgraphDriver) searchNodesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int, filter *searchFilter) ([]*types.Node, error) {
 	if len(embedding) == 0 {
 		return []*types.Node{}, nil
 	}
 
+	if nodes, ok := m.searchNodesByVectorIndex(ctx, embedding, groupID, limit, filter); ok {
+		return nodes, nil
+	}
+
 	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
 	defer session.Close(ctx)
 
 	// Get all nodes with embeddings and compute similarity in-memory
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		params := map[string]any{}
 		query := `
-			MATCH (n {group_id: $groupID})
-			WHERE n.embedding IS NOT NULL
+			MATCH (n)
+			WHERE ` + filter.boltNodeConditions("n", params) + `
+			  AND n.embedding IS NOT NULL AND n.deleted_at IS NULL
 			RETURN n
 		`
-		res, err := tx.Run(ctx, query, map[string]any{
-			"groupID": groupID,
-		})
+		res, err := tx.Run(ctx, query, params)
 		if err != nil {
 			return nil, err
 		}
@@ -689,24 +690,37 @@ func (m *MemgraphDriver) SearchNodesByEmbedding(ctx context.Context, embedding [
 	return nodes, nil
 }
 
+// SearchEdgesByEmbedding returns the RELATES_TO edges most similar to embedding
+// using the edge vector index when it is available, and otherwise computes cosine
+// similarity over every embedded edge.
 func (m *MemgraphDriver) SearchEdgesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int) ([]*types.Edge, error) {
+	return m.searchEdgesByEmbedding(ctx, embedding, groupID, limit, groupSearchFilter(groupID))
+}
+
+// searchEdgesByEmbedding implements SearchEdgesByEmbedding for the edges
+// matching filter.
+func (m *MemgraphDriver) searchEdgesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int, filter *searchFilter) ([]*types.Edge, error) {
 	if len(embedding) == 0 {
 		return []*types.Edge{}, nil
 	}
 
+	if edges, ok := m.searchEdgesByVectorIndex(ctx, embedding, groupID, limit, filter); ok {
+		return edges, nil
+	}
+
 	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
 	defer session.Close(ctx)
 
 	// Get all edges with embeddings and compute similarity in-memory
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		params := map[string]any{}
 		query := `
-			MATCH (s)-[r {group_id: $groupID}]->(t)
-			WHERE r.embedding IS NOT NULL
+			MATCH (s)-[r]->(t)
+			WHERE ` + filter.boltEdgeConditions("r", params) + `
+			  AND r.embedding IS NOT NULL AND r.deleted_at IS NULL
 			RETURN r, s.uuid as source_id, t.uuid as target_id
 		`
-		res, err := tx.Run(ctx, query, map[string]any{
-			"groupID": groupID,
-		})
+		res, err := tx.Run(ctx, query, params)
 		if err != nil {
 			return nil, err
 		}
@@ -888,7 +902,7 @@ func (m *MemgraphDriver) GetNodesInTimeRange(ctx context.Context, start, end tim
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (n {group_id: $groupID})
-			WHERE n.created_at >= $start AND n.created_at <= $end
+			WHERE n.created_at >= $start AND n.created_at <= $end AND n.deleted_at IS NULL
 			RETURN n
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -929,7 +943,7 @@ func (m *MemgraphDriver) GetEdgesInTimeRange(ctx context.Context, start, end tim
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (s)-[r {group_id: $groupID}]->(t)
-			WHERE r.created_at >= $start AND r.created_at <= $end
+			WHERE r.created_at >= $start AND r.created_at <= $end AND r.deleted_at IS NULL
 			RETURN r, s.uuid as source_id, t.uuid as target_id
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -1118,7 +1132,7 @@ func (m *MemgraphDriver) BuildCommunities(ctx context.Context, groupID string) e
 	return err
 }
 
-func (m *MemgraphDriver) GetExistingCommunity(ctx context.Context, entityUUID string) (*types.Node, error) {
+func (m *MemgraphDriver) GetExistingCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
 	query := `
 		MATCH (c:Community)-[:HAS_MEMBER]->(n:Entity {uuid: $entity_uuid})
 		RETURN c
@@ -1147,7 +1161,7 @@ func (m *MemgraphDriver) GetExistingCommunity(ctx context.Context, entityUUID st
 	return nil, nil
 }
 
-func (m *MemgraphDriver) FindModalCommunity(ctx context.Context, entityUUID string) (*types.Node, error) {
+func (m *MemgraphDriver) FindModalCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
 	query := `
 		MATCH (c:Community)-[:HAS_MEMBER]->(m:Entity)-[:RELATES_TO]-(n:Entity {uuid: $entity_uuid})
 		WITH c, count(*) AS count
@@ -1279,6 +1293,8 @@ func (m *MemgraphDriver) CreateIndices(ctx context.Context) error {
 		"CREATE INDEX ON :Episodic(created_at)",
 		"CREATE INDEX ON :Community(created_at)",
 	}
+	// Vector indexes need the vector_search module and are skipped without it
+	indices = append(indices, m.vectorIndexStatements(m.vectorSupport(ctx))...)
 
 	for _, indexQuery := range indices {
 		_, err := session.Run(ctx, indexQuery, nil)
@@ -1289,6 +1305,11 @@ func (m *MemgraphDriver) CreateIndices(ctx context.Context) error {
 		}
 	}
 
+	// Apply pending schema migrations and refuse databases written by newer releases
+	if err := migrations.NewRunner(m, migrations.ProviderMemgraph).Migrate(ctx); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	return nil
 }
 
@@ -1300,7 +1321,8 @@ func (m *MemgraphDriver) GetStats(ctx context.Context, groupID string) (*GraphSt
 		// Get node count by label (Entity, Episodic, Community)
 		// Note: In Neo4j/Memgraph, node types are labels, not properties
 		nodeQuery := `
-			MATCH (n {group_id: $groupID})
+			MATCH (n)
+			WHERE ` + boltStatsFilter("n") + `
 			UNWIND labels(n) AS label
 			WITH label, count(DISTINCT n) as node_count
 			WHERE label IN ['Entity', 'Episodic', 'Community']
@@ -1318,7 +1340,8 @@ func (m *MemgraphDriver) GetStats(ctx context.Context, groupID string) (*GraphSt
 
 		// Get total node count
 		totalNodeQuery := `
-			MATCH (n {group_id: $groupID})
+			MATCH (n)
+			WHERE ` + boltStatsFilter("n") + `
 			RETURN count(n) as total_nodes
 		`
 		totalNodeRes, err := tx.Run(ctx, totalNodeQuery, map[string]any{"groupID": groupID})
@@ -1332,7 +1355,8 @@ func (m *MemgraphDriver) GetStats(ctx context.Context, groupID string) (*GraphSt
 
 		// Get edge count by type
 		edgeQuery := `
-			MATCH ()-[r {group_id: $groupID}]-()
+			MATCH ()-[r]->()
+			WHERE ` + boltStatsFilter("r") + `
 			RETURN type(r) as edge_type, count(r) as edge_count
 			ORDER BY edge_type
 		`
@@ -1360,11 +1384,7 @@ func (m *MemgraphDriver) GetStats(ctx context.Context, groupID string) (*GraphSt
 	edgeRecords := data["edges"].([]*db.Record)
 	totalNodeRecord := data["total_nodes"].(*db.Record)
 
-	stats := &GraphStats{
-		NodesByType: make(map[string]int64),
-		EdgesByType: make(map[string]int64),
-		LastUpdated: time.Now(),
-	}
+	stats := newGraphStats()
 
 	// Get total node count
 	if totalNodes, found := totalNodeRecord.Get("total_nodes"); found {
@@ -1398,6 +1418,10 @@ func (m *MemgraphDriver) GetStats(ctx context.Context, groupID string) (*GraphSt
 		}
 	}
 
+	if err := collectBoltStatsBreakdown(ctx, session, groupID, stats); err != nil {
+		return nil, fmt.Errorf("failed to collect stats breakdown: %w", err)
+	}
+
 	return stats, nil
 }
 
@@ -1417,17 +1441,19 @@ func (m *MemgraphDriver) SearchNodes(ctx context.Context, query, groupID string,
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		// Basic text search using CONTAINS
+		params := map[string]any{
+			"query": query,
+			"limit": limit,
+		}
 		searchQuery := `
-			MATCH (n {group_id: $groupID})
-			WHERE n.name CONTAINS $query OR n.summary CONTAINS $query OR n.content CONTAINS $query
+			MATCH (n)
+			WHERE ` + options.filter(groupID).boltNodeConditions("n", params) + `
+			  AND (n.name CONTAINS $query OR n.summary CONTAINS $query OR n.content CONTAINS $query)
+			  AND n.deleted_at IS NULL
 			RETURN n
 			LIMIT $limit
 		`
-		res, err := tx.Run(ctx, searchQuery, map[string]any{
-			"groupID": groupID,
-			"query":   query,
-			"limit":   limit,
-		})
+		res, err := tx.Run(ctx, searchQuery, params)
 		if err != nil {
 			return nil, err
 		}
@@ -1470,17 +1496,19 @@ func (m *MemgraphDriver) SearchEdges(ctx context.Context, query, groupID string,
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		// Basic text search using CONTAINS
+		params := map[string]any{
+			"query": query,
+			"limit": limit,
+		}
 		searchQuery := `
-			MATCH (s)-[r {group_id: $groupID}]->(t)
-			WHERE r.name CONTAINS $query OR r.summary CONTAINS $query
+			MATCH (s)-[r]->(t)
+			WHERE ` + options.filter(groupID).boltEdgeConditions("r", params) + `
+			  AND (r.name CONTAINS $query OR r.summary CONTAINS $query)
+			  AND r.deleted_at IS NULL
 			RETURN r, s.uuid as source_id, t.uuid as target_id
 			LIMIT $limit
 		`
-		res, err := tx.Run(ctx, searchQuery, map[string]any{
-			"groupID": groupID,
-			"query":   query,
-			"limit":   limit,
-		})
+		res, err := tx.Run(ctx, searchQuery, params)
 		if err != nil {
 			return nil, err
 		}
@@ -1527,9 +1555,9 @@ func (m *MemgraphDriver) SearchNodesByVector(ctx context.Context, vector []float
 		}
 	}
 
-	// Use the existing SearchNodesByEmbedding method for compatibility
+	// Search the nodes matching the options filters
 	// Filter by minimum score if needed
-	nodes, err := m.SearchNodesByEmbedding(ctx, vector, groupID, limit)
+	nodes, err := m.searchNodesByEmbedding(ctx, vector, groupID, limit, options.filter(groupID))
 	if err != nil {
 		return nil, err
 	}
@@ -1551,6 +1579,68 @@ func (m *MemgraphDriver) SearchNodesByVector(ctx context.Context, vector []float
 	return nodes, nil
 }
 
+// SearchNodesByEmbeddings returns, for each embedding, the nodes most similar
+// to it. All embeddings are evaluated in one transaction, through the vector
+// indexes when they can be used and otherwise by scanning the embedded nodes
+// once.
+func (m *MemgraphDriver) SearchNodesByEmbeddings(ctx context.Context, embeddings [][]float32, groupID string, options *VectorSearchOptions) ([][]*types.Node, error) {
+	if len(embeddings) == 0 {
+		return [][]*types.Node{}, nil
+	}
+
+	limit, minScore := bulkSearchLimits(options)
+	filter := options.filter(groupID)
+	if results, ok := m.searchNodesByEmbeddingsIndex(ctx, embeddings, limit, minScore, filter); ok {
+		return results, nil
+	}
+
+	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		params := map[string]any{}
+		query := `
+			MATCH (n)
+			WHERE ` + filter.boltNodeConditions("n", params) + `
+			  AND n.embedding IS NOT NULL AND n.deleted_at IS NULL
+			RETURN n
+		`
+		res, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		return res.Collect(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nodes by embeddings: %w", err)
+	}
+
+	var candidates []*types.Node
+	var candidateEmbeddings [][]float32
+	for _, record := range result.([]*db.Record) {
+		nodeValue, found := record.Get("n")
+		if !found {
+			continue
+		}
+		dbNode := nodeValue.(dbtype.Node)
+
+		// Parse embedding from JSON
+		embeddingStr, ok := dbNode.Props["embedding"].(string)
+		if !ok {
+			continue
+		}
+		var nodeEmbedding []float32
+		if err := json.Unmarshal([]byte(embeddingStr), &nodeEmbedding); err != nil {
+			continue
+		}
+		candidates = append(candidates, m.nodeFromDBNode(dbNode))
+		candidateEmbeddings = append(candidateEmbeddings, nodeEmbedding)
+	}
+
+	hits := scoreCandidates(embeddings, candidates, candidateEmbeddings)
+	return groupScoredNodes(hits, len(embeddings), limit, minScore), nil
+}
+
 // SearchEdgesByVector performs vector similarity search on edges
 func (m *MemgraphDriver) SearchEdgesByVector(ctx context.Context, vector []float32, groupID string, options *VectorSearchOptions) ([]*types.Edge, error) {
 	if len(vector) == 0 {
@@ -1568,9 +1658,9 @@ func (m *MemgraphDriver) SearchEdgesByVector(ctx context.Context, vector []float
 		}
 	}
 
-	// Use the existing SearchEdgesByEmbedding method for compatibility
+	// Search the edges matching the options filters
 	// Filter by minimum score if needed
-	edges, err := m.SearchEdgesByEmbedding(ctx, vector, groupID, limit)
+	edges, err := m.searchEdgesByEmbedding(ctx, vector, groupID, limit, options.filter(groupID))
 	if err != nil {
 		return nil, err
 	}
@@ -1593,25 +1683,37 @@ func (m *MemgraphDriver) SearchEdgesByVector(ctx context.Context, vector []float
 }
 
 // ExecuteQuery executes a Cypher query and returns records, summary, and keys (matching Python interface).
+// Transient errors such as ServiceUnavailable or deadlocks are retried with exponential backoff
+// for read queries; writes are run once.
 func (m *MemgraphDriver) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
-	session := m.client.NewSession(context.Background(), neo4j.SessionConfig{DatabaseName: m.database})
-	defer session.Close(context.Background())
-
-	result, err := session.Run(context.Background(), cypherQuery, kwargs)
-	if err != nil {
-		return nil, nil, nil, err
-	}
+	ctx := context.Background()
+
+	var (
+		records []*neo4j.Record
+		summary neo4j.ResultSummary
+		keys    []string
+	)
+	err := m.options.withReadRetry(ctx, cypherQuery, func() error {
+		session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
+		defer session.Close(ctx)
+
+		result, err := session.Run(ctx, cypherQuery, kwargs)
+		if err != nil {
+			return err
+		}
 
-	records, err := result.Collect(context.Background())
-	if err != nil {
-		return nil, nil, nil, err
-	}
+		records, err = result.Collect(ctx)
+		if err != nil {
+			return err
+		}
 
-	summary, err := result.Consume(context.Background())
-	if err != nil {
-		return nil, nil, nil, err
-	}
-	keys, err := result.Keys()
+		summary, err = result.Consume(ctx)
+		if err != nil {
+			return err
+		}
+		keys, err = result.Keys()
+		return err
+	})
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -1678,6 +1780,25 @@ func (m *MemgraphDriver) VerifyConnectivity(ctx context.Context) error {
 	return m.client.VerifyConnectivity(ctx)
 }
 
+// HealthCheck verifies connectivity and runs a trivial query against the configured database.
+func (m *MemgraphDriver) HealthCheck(ctx context.Context) error {
+	if err := m.client.VerifyConnectivity(ctx); err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+
+	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, "RETURN 1 AS ok", nil)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	if _, err := result.Single(ctx); err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	return nil
+}
+
 // MemgraphDriverSession implements GraphDriverSession for Memgraph.
 type MemgraphDriverSession struct {
 	driver   *MemgraphDriver
@@ -1718,8 +1839,10 @@ func (s *MemgraphDriverSession) Run(ctx context.Context, query interface{}, kwar
 		return fmt.Errorf("query must be a string")
 	}
 
-	_, err := s.session.Run(ctx, queryStr, kwargs)
-	return err
+	return s.driver.options.withReadRetry(ctx, queryStr, func() error {
+		_, err := s.session.Run(ctx, queryStr, kwargs)
+		return err
+	})
 }
 
 // ExecuteWrite executes a write transaction.
@@ -1899,6 +2022,10 @@ func (m *MemgraphDriver) nodeToProperties(node *types.Node) map[string]any {
 		if embeddingJSON, err := json.Marshal(node.Embedding); err == nil {
 			props["embedding"] = string(embeddingJSON)
 		}
+		// Native list for the vector indexes
+		if vector := m.vectorProperty(node.Embedding); vector != nil {
+			props[boltVectorProperty] = vector
+		}
 	}
 
 	// Source tracking
@@ -2091,6 +2218,10 @@ func (m *MemgraphDriver) edgeToProperties(edge *types.Edge) map[string]any {
 		if embeddingJSON, err := json.Marshal(edge.Embedding); err == nil {
 			props["embedding"] = string(embeddingJSON)
 		}
+		// Native list for the vector index
+		if vector := m.vectorProperty(edge.Embedding); vector != nil {
+			props[boltVectorProperty] = vector
+		}
 	}
 
 	// Source tracking
@@ -2130,48 +2261,10 @@ func (m *MemgraphDriver) cosineSimilarity(a, b []float32) float32 {
 	return dotProduct / (float32(math.Sqrt(float64(normA))) * float32(math.Sqrt(float64(normB))))
 }
 
-func (k *MemgraphDriver) GetBetweenNodes(ctx context.Context, sourceNodeID, targetNodeID string) ([]*types.Edge, error) {
-	query := `
-		MATCH (a:Entity {uuid: $source_uuid})-[:RELATES_TO]->(rel:RelatesToNode_)-[:RELATES_TO]->(b:Entity {uuid: $target_uuid})
-		RETURN rel.uuid AS uuid, rel.name AS name, rel.fact AS fact, rel.group_id AS group_id,
-		       rel.created_at AS created_at, rel.valid_at AS valid_at, rel.invalid_at AS invalid_at,
-		       rel.expired_at AS expired_at, rel.episodes AS episodes, rel.attributes AS attributes,
-		       a.uuid AS source_id, b.uuid AS target_id
-		UNION
-		MATCH (a:Entity {uuid: $target_uuid})-[:RELATES_TO]->(rel:RelatesToNode_)-[:RELATES_TO]->(b:Entity {uuid: $source_uuid})
-		RETURN rel.uuid AS uuid, rel.name AS name, rel.fact AS fact, rel.group_id AS group_id,
-		       rel.created_at AS created_at, rel.valid_at AS valid_at, rel.invalid_at AS invalid_at,
-		       rel.expired_at AS expired_at, rel.episodes AS episodes, rel.attributes AS attributes,
-		       a.uuid AS source_id, b.uuid AS target_id
-	`
-
-	params := map[string]interface{}{
-		"source_uuid": sourceNodeID,
-		"target_uuid": targetNodeID,
-	}
-
-	result, _, _, err := k.ExecuteQuery(query, params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute GetBetweenNodes query: %w", err)
-	}
-
-	// Convert result to Edge objects
-	var edges []*types.Edge
-	recordSlice, ok := result.([]map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("unexpected query result type: %T", result)
-	}
-
-	for _, record := range recordSlice {
-		edge, err := convertRecordToEdge(record)
-		if err != nil {
-			log.Printf("Warning: failed to convert record to edge: %v", err)
-			continue
-		}
-		edges = append(edges, edge)
-	}
-
-	return edges, nil
+// GetBetweenNodes retrieves the entity edges of groupID between two entities
+// in either direction.
+func (k *MemgraphDriver) GetBetweenNodes(ctx context.Context, sourceNodeID, targetNodeID, groupID string) ([]*types.Edge, error) {
+	return EdgesBetween(ctx, k, groupID, sourceNodeID, targetNodeID)
 }
 
 func (m *MemgraphDriver) GetNodeNeighbors(ctx context.Context, nodeUUID, groupID string) ([]types.Neighbor, error) {
@@ -2313,6 +2406,7 @@ func (m *MemgraphDriver) GetEntityNodesByGroup(ctx context.Context, groupID stri
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (n:Entity {group_id: $group_id})
+			WHERE n.deleted_at IS NULL
 			RETURN n
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -0,0 +1,282 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// memgraphVectorCapacity is the capacity configured for Memgraph vector indexes.
+// Memgraph preallocates this many slots and grows the index when it fills up.
+const memgraphVectorCapacity = 10000
+
+// vectorSupportForProcedures returns the vector index features available given
+// the names of the query procedures loaded into Memgraph.
+func vectorSupportForProcedures(procedures []string) vectorIndexSupport {
+	var support vectorIndexSupport
+	for _, name := range procedures {
+		switch name {
+		case "vector_search.search":
+			support.nodes = true
+		case "vector_search.search_edges":
+			support.relationships = true
+		}
+	}
+	return support
+}
+
+// vectorSupport checks once for the vector_search procedures and reports which
+// vector indexes can be used. Vector indexes are disabled when
+// Options.VectorDimensions is zero.
+func (m *MemgraphDriver) vectorSupport(ctx context.Context) vectorIndexSupport {
+	if m.options.VectorDimensions <= 0 {
+		return vectorIndexSupport{}
+	}
+
+	m.vectorMu.Lock()
+	defer m.vectorMu.Unlock()
+	if m.vectorProbed {
+		return m.vector
+	}
+
+	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := tx.Run(ctx, "CALL mg.procedures() YIELD name RETURN name", nil)
+		if err != nil {
+			return nil, err
+		}
+		return res.Collect(ctx)
+	})
+	if err != nil {
+		// Probe again next time; the server may just be unreachable
+		return vectorIndexSupport{}
+	}
+
+	var procedures []string
+	for _, record := range result.([]*db.Record) {
+		if name, ok := record.AsMap()["name"].(string); ok {
+			procedures = append(procedures, name)
+		}
+	}
+	m.vector = vectorSupportForProcedures(procedures)
+	m.vectorProbed = true
+	return m.vector
+}
+
+// vectorIndexStatements returns the statements creating the supported vector indexes.
+func (m *MemgraphDriver) vectorIndexStatements(support vectorIndexSupport) []string {
+	config := fmt.Sprintf(`WITH CONFIG {"dimension": %d, "capacity": %d, "metric": "cos"}`, m.options.VectorDimensions, memgraphVectorCapacity)
+
+	var statements []string
+	if support.nodes {
+		labels := make([]string, 0, len(boltNodeVectorIndexes))
+		for label := range boltNodeVectorIndexes {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			statements = append(statements, fmt.Sprintf(
+				"CREATE VECTOR INDEX %s ON :%s(%s) %s",
+				boltNodeVectorIndexes[label], label, boltVectorProperty, config))
+		}
+	}
+	if support.relationships {
+		statements = append(statements, fmt.Sprintf(
+			"CREATE VECTOR EDGE INDEX %s ON :RELATES_TO(%s) %s",
+			boltEdgeVectorIndex, boltVectorProperty, config))
+	}
+	return statements
+}
+
+// vectorProperty returns embedding as the native list stored under
+// boltVectorProperty, or nil when vector indexes are disabled.
+func (m *MemgraphDriver) vectorProperty(embedding []float32) []float64 {
+	return boltVectorList(embedding, m.options.VectorDimensions)
+}
+
+// searchNodesByVectorIndex queries the node vector indexes through MAGE's
+// vector_search module. It reports false when the indexes cannot be used and the
+// caller should fall back to scanning.
+func (m *MemgraphDriver) searchNodesByVectorIndex(ctx context.Context, embedding []float32, groupID string, limit int, filter *searchFilter) ([]*types.Node, bool) {
+	if limit <= 0 || len(embedding) != m.options.VectorDimensions || !m.vectorSupport(ctx).nodes {
+		return nil, false
+	}
+
+	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		var records []*db.Record
+		for _, index := range boltNodeVectorIndexes {
+			params := map[string]any{
+				"index":      index,
+				"candidates": limit * boltVectorOversample,
+				"embedding":  m.vectorProperty(embedding),
+				"limit":      limit,
+			}
+			conditions := filter.boltNodeConditions("n", params)
+			res, err := tx.Run(ctx, `
+				CALL vector_search.search($index, $candidates, $embedding)
+				YIELD node, similarity
+				WITH node AS n, similarity AS score
+				WHERE `+conditions+` AND n.deleted_at IS NULL
+				RETURN n, score
+				ORDER BY score DESC
+				LIMIT $limit
+			`, params)
+			if err != nil {
+				return nil, err
+			}
+			indexRecords, err := res.Collect(ctx)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, indexRecords...)
+		}
+		return records, nil
+	})
+	if err != nil {
+		log.Printf("Memgraph vector index search failed, falling back to scanning: %v", err)
+		return nil, false
+	}
+
+	records := result.([]*db.Record)
+	sortRecordsByScore(records)
+	if len(records) > limit {
+		records = records[:limit]
+	}
+
+	nodes := make([]*types.Node, 0, len(records))
+	for _, record := range records {
+		if node, ok := record.AsMap()["n"].(dbtype.Node); ok {
+			nodes = append(nodes, m.nodeFromDBNode(node))
+		}
+	}
+	return nodes, true
+}
+
+// searchEdgesByVectorIndex queries the edge vector index through MAGE's
+// vector_search module. It reports false when the index cannot be used and the
+// caller should fall back to scanning.
+func (m *MemgraphDriver) searchEdgesByVectorIndex(ctx context.Context, embedding []float32, groupID string, limit int, filter *searchFilter) ([]*types.Edge, bool) {
+	if limit <= 0 || len(embedding) != m.options.VectorDimensions || !m.vectorSupport(ctx).relationships {
+		return nil, false
+	}
+
+	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		params := map[string]any{
+			"index":      boltEdgeVectorIndex,
+			"candidates": limit * boltVectorOversample,
+			"embedding":  m.vectorProperty(embedding),
+			"limit":      limit,
+		}
+		conditions := filter.boltEdgeConditions("r", params)
+		res, err := tx.Run(ctx, `
+			CALL vector_search.search_edges($index, $candidates, $embedding)
+			YIELD edge, similarity
+			WITH edge AS r, similarity AS score
+			WHERE `+conditions+` AND r.deleted_at IS NULL
+			RETURN r, startNode(r).uuid AS source_id, endNode(r).uuid AS target_id, score
+			ORDER BY score DESC
+			LIMIT $limit
+		`, params)
+		if err != nil {
+			return nil, err
+		}
+		return res.Collect(ctx)
+	})
+	if err != nil {
+		log.Printf("Memgraph vector index search failed, falling back to scanning: %v", err)
+		return nil, false
+	}
+
+	records := result.([]*db.Record)
+	edges := make([]*types.Edge, 0, len(records))
+	for _, record := range records {
+		values := record.AsMap()
+		relation, ok := values["r"].(dbtype.Relationship)
+		if !ok {
+			continue
+		}
+		sourceID, _ := values["source_id"].(string)
+		targetID, _ := values["target_id"].(string)
+		edges = append(edges, m.edgeFromDBRelation(relation, sourceID, targetID))
+	}
+	return edges, true
+}
+
+// searchNodesByEmbeddingsIndex queries the node vector indexes for every
+// embedding in one transaction. It reports false when the indexes cannot be
+// used and the caller should fall back to scanning.
+func (m *MemgraphDriver) searchNodesByEmbeddingsIndex(ctx context.Context, embeddings [][]float32, limit int, minScore float64, filter *searchFilter) ([][]*types.Node, bool) {
+	if limit <= 0 || !m.vectorSupport(ctx).nodes {
+		return nil, false
+	}
+	vectors := make([][]float64, len(embeddings))
+	for i, embedding := range embeddings {
+		if vectors[i] = m.vectorProperty(embedding); vectors[i] == nil {
+			return nil, false
+		}
+	}
+
+	session := m.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: m.database})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		var records []*db.Record
+		for _, index := range boltNodeVectorIndexes {
+			params := map[string]any{
+				"index":      index,
+				"candidates": limit * boltVectorOversample,
+				"embeddings": vectors,
+			}
+			conditions := filter.boltNodeConditions("n", params)
+			res, err := tx.Run(ctx, `
+				UNWIND range(0, size($embeddings) - 1) AS query
+				CALL vector_search.search($index, $candidates, $embeddings[query])
+				YIELD node, similarity
+				WITH query, node AS n, similarity AS score
+				WHERE `+conditions+` AND n.deleted_at IS NULL
+				RETURN query, n, score
+			`, params)
+			if err != nil {
+				return nil, err
+			}
+			indexRecords, err := res.Collect(ctx)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, indexRecords...)
+		}
+		return records, nil
+	})
+	if err != nil {
+		log.Printf("Memgraph vector index search failed, falling back to scanning: %v", err)
+		return nil, false
+	}
+
+	records := result.([]*db.Record)
+	hits := make([]scoredNode, 0, len(records))
+	for _, record := range records {
+		values := record.AsMap()
+		node, ok := values["n"].(dbtype.Node)
+		if !ok {
+			continue
+		}
+		query, _ := values["query"].(int64)
+		score, _ := values["score"].(float64)
+		hits = append(hits, scoredNode{query: int(query), node: m.nodeFromDBNode(node), score: score})
+	}
+	return groupScoredNodes(hits, len(embeddings), limit, minScore), true
+}
@@ -0,0 +1,53 @@
+package driver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestVectorSupportForProcedures(t *testing.T) {
+	support := vectorSupportForProcedures([]string{"mg.procedures", "vector_search.search"})
+	if !support.nodes || support.relationships {
+		t.Errorf("expected node vector search only, got %+v", support)
+	}
+
+	support = vectorSupportForProcedures([]string{"vector_search.search", "vector_search.search_edges"})
+	if !support.nodes || !support.relationships {
+		t.Errorf("expected node and edge vector search, got %+v", support)
+	}
+
+	if support := vectorSupportForProcedures(nil); support.nodes || support.relationships {
+		t.Errorf("expected no vector search without MAGE, got %+v", support)
+	}
+}
+
+func TestMemgraphVectorIndexStatements(t *testing.T) {
+	// The driver connects lazily, so no server is needed to build statements.
+	d, err := NewMemgraphDriverWithOptions("bolt://localhost:7687", "", "", "memgraph", &Options{
+		VectorDimensions: 4,
+	})
+	if err != nil {
+		t.Fatalf("failed to create driver: %v", err)
+	}
+	defer d.Close()
+
+	statements := d.vectorIndexStatements(vectorIndexSupport{nodes: true, relationships: true})
+	if len(statements) != len(boltNodeVectorIndexes)+1 {
+		t.Fatalf("expected node and edge index statements, got %v", statements)
+	}
+	for _, statement := range statements {
+		if !strings.Contains(statement, `"dimension": 4`) || !strings.Contains(statement, `"metric": "cos"`) {
+			t.Errorf("unexpected index config in %q", statement)
+		}
+	}
+	if !strings.HasPrefix(statements[len(statements)-1], "CREATE VECTOR EDGE INDEX "+boltEdgeVectorIndex) {
+		t.Errorf("expected the edge index last, got %q", statements[len(statements)-1])
+	}
+
+	props := d.nodeToProperties(&types.Node{Uuid: "n1", Embedding: []float32{1, 0, 0, 0}})
+	if _, ok := props[boltVectorProperty].([]float64); !ok {
+		t.Errorf("expected a vector property, got %v", props[boltVectorProperty])
+	}
+}
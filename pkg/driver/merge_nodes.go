@@ -0,0 +1,327 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// ErrNodeMergeUnsupported indicates the driver cannot redirect edges from one
+// node to another.
+var ErrNodeMergeUnsupported = errors.New("driver does not support merging nodes")
+
+// Cypher statements used by the Bolt drivers (Neo4j and Memgraph) to move the
+// RELATES_TO and MENTIONS edges of $from to $to. Edges stating a fact $to
+// already has with the same node are dropped rather than moved. Relationships
+// cannot change endpoints, so each is recreated with its properties and
+// deleted. Edges between the two nodes are left on $from.
+var boltRedirectEdgesQueries = []string{
+	`
+		MATCH (from:Entity {uuid: $from, group_id: $group_id})-[r:RELATES_TO]->(t:Entity)<-[existing:RELATES_TO]-(to:Entity {uuid: $to, group_id: $group_id})
+		WHERE NOT t.uuid IN [$from, $to] AND existing.deleted_at IS NULL
+		  AND existing.name = r.name AND existing.fact = r.fact
+		DELETE r
+	`,
+	`
+		MATCH (from:Entity {uuid: $from, group_id: $group_id})-[r:RELATES_TO]->(t:Entity)
+		WHERE NOT t.uuid IN [$from, $to]
+		MATCH (to:Entity {uuid: $to, group_id: $group_id})
+		CREATE (to)-[moved:RELATES_TO]->(t)
+		SET moved = properties(r)
+		DELETE r
+	`,
+	`
+		MATCH (s:Entity)-[r:RELATES_TO]->(from:Entity {uuid: $from, group_id: $group_id})
+		MATCH (s)-[existing:RELATES_TO]->(to:Entity {uuid: $to, group_id: $group_id})
+		WHERE NOT s.uuid IN [$from, $to] AND existing.deleted_at IS NULL
+		  AND existing.name = r.name AND existing.fact = r.fact
+		DELETE r
+	`,
+	`
+		MATCH (s:Entity)-[r:RELATES_TO]->(from:Entity {uuid: $from, group_id: $group_id})
+		WHERE NOT s.uuid IN [$from, $to]
+		MATCH (to:Entity {uuid: $to, group_id: $group_id})
+		CREATE (s)-[moved:RELATES_TO]->(to)
+		SET moved = properties(r)
+		DELETE r
+	`,
+	`
+		MATCH (episode:Episodic)-[m:MENTIONS]->(from:Entity {uuid: $from, group_id: $group_id})
+		MATCH (to:Entity {uuid: $to, group_id: $group_id})
+		MERGE (episode)-[moved:MENTIONS]->(to)
+		ON CREATE SET moved = properties(m)
+		DELETE m
+	`,
+}
+
+// Ladybug statements moving the edges of $from to $to. Entity edges are
+// RelatesToNode_ rows, so only the RELATES_TO link between the node and the row
+// moves; each move creates the new links before deleting the old ones. Rows
+// stating a fact $to already has with the same node are dropped.
+var ladybugRedirectEdgesQueries = []string{
+	`
+		MATCH (from:Entity)-[:RELATES_TO]->(e:RelatesToNode_)-[:RELATES_TO]->(t:Entity)<-[:RELATES_TO]-(existing:RelatesToNode_)<-[:RELATES_TO]-(to:Entity)
+		WHERE from.uuid = $from AND from.group_id = $group_id AND to.uuid = $to AND to.group_id = $group_id
+		  AND t.uuid <> $from AND t.uuid <> $to AND existing.deleted_at IS NULL
+		  AND existing.name = e.name AND existing.fact = e.fact
+		DETACH DELETE e
+	`,
+	`
+		MATCH (from:Entity)-[:RELATES_TO]->(e:RelatesToNode_)-[:RELATES_TO]->(t:Entity), (to:Entity)
+		WHERE from.uuid = $from AND from.group_id = $group_id AND to.uuid = $to AND to.group_id = $group_id
+		  AND t.uuid <> $from AND t.uuid <> $to
+		CREATE (to)-[:RELATES_TO]->(e)
+	`,
+	`
+		MATCH (from:Entity)-[r:RELATES_TO]->(e:RelatesToNode_)-[:RELATES_TO]->(t:Entity)
+		WHERE from.uuid = $from AND from.group_id = $group_id
+		  AND t.uuid <> $from AND t.uuid <> $to
+		DELETE r
+	`,
+	`
+		MATCH (s:Entity)-[:RELATES_TO]->(e:RelatesToNode_)-[:RELATES_TO]->(from:Entity),
+		      (s)-[:RELATES_TO]->(existing:RelatesToNode_)-[:RELATES_TO]->(to:Entity)
+		WHERE from.uuid = $from AND from.group_id = $group_id AND to.uuid = $to AND to.group_id = $group_id
+		  AND s.uuid <> $from AND s.uuid <> $to AND existing.deleted_at IS NULL
+		  AND existing.name = e.name AND existing.fact = e.fact
+		DETACH DELETE e
+	`,
+	`
+		MATCH (s:Entity)-[:RELATES_TO]->(e:RelatesToNode_)-[:RELATES_TO]->(from:Entity), (to:Entity)
+		WHERE from.uuid = $from AND from.group_id = $group_id AND to.uuid = $to AND to.group_id = $group_id
+		  AND s.uuid <> $from AND s.uuid <> $to
+		CREATE (e)-[:RELATES_TO]->(to)
+	`,
+	`
+		MATCH (s:Entity)-[:RELATES_TO]->(e:RelatesToNode_)-[r:RELATES_TO]->(from:Entity)
+		WHERE from.uuid = $from AND from.group_id = $group_id
+		  AND s.uuid <> $from AND s.uuid <> $to
+		DELETE r
+	`,
+	`
+		MATCH (episode:Episodic)-[m:MENTIONS]->(from:Entity), (to:Entity)
+		WHERE from.uuid = $from AND from.group_id = $group_id AND to.uuid = $to AND to.group_id = $group_id
+		  AND NOT EXISTS { MATCH (episode)-[:MENTIONS]->(to) }
+		CREATE (episode)-[:MENTIONS {uuid: m.uuid, group_id: m.group_id, created_at: m.created_at}]->(to)
+	`,
+	`
+		MATCH (:Episodic)-[m:MENTIONS]->(from:Entity)
+		WHERE from.uuid = $from AND from.group_id = $group_id
+		DELETE m
+	`,
+}
+
+// RedirectNodeEdges moves the RELATES_TO and MENTIONS edges of the entity
+// fromUUID to the entity toUUID, keeping their UUIDs and properties, for
+// merging duplicate entities. An edge stating the same relation and fact as
+// an edge toUUID already has with the same entity is dropped, edges between
+// the two entities stay on fromUUID, and an episode mentioning both keeps a
+// single mention. It returns ErrNodeMergeUnsupported for drivers without
+// Cypher support. Use NodeMerger to merge entities in a single transaction.
+func RedirectNodeEdges(ctx context.Context, d GraphDriver, groupID, fromUUID, toUUID string) error {
+	queries, ok := redirectEdgesQueries(d.Provider())
+	if !ok {
+		return ErrNodeMergeUnsupported
+	}
+	if fromUUID == toUUID {
+		return nil
+	}
+
+	for _, statement := range redirectEdgesStatements(queries, groupID, fromUUID, toUUID) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := executeGroupQuery(d, groupID, statement.query, statement.params); err != nil {
+			return fmt.Errorf("failed to redirect edges of node %s: %w", fromUUID, err)
+		}
+	}
+	return nil
+}
+
+// redirectEdgesQueries returns the queries moving the edges of a node on
+// provider, and false when it has none.
+func redirectEdgesQueries(provider GraphProvider) ([]string, bool) {
+	switch provider {
+	case GraphProviderNeo4j, GraphProviderMemgraph:
+		return boltRedirectEdgesQueries, true
+	case GraphProviderLadybug:
+		return ladybugRedirectEdgesQueries, true
+	}
+	return nil, false
+}
+
+// redirectEdgesStatements binds the redirect queries to the nodes to move
+// edges between.
+func redirectEdgesStatements(queries []string, groupID, fromUUID, toUUID string) []graphStatement {
+	params := map[string]interface{}{
+		"from":     fromUUID,
+		"to":       toUUID,
+		"group_id": groupID,
+	}
+	statements := make([]graphStatement, len(queries))
+	for i, query := range queries {
+		statements[i] = graphStatement{query: query, params: params}
+	}
+	return statements
+}
+
+// NodeMerger is implemented by drivers that merge duplicate entities in a
+// single write transaction (Neo4j, Memgraph and Ladybug).
+type NodeMerger interface {
+	// MergeNodes redirects the edges of the duplicates to canonical as
+	// RedirectNodeEdges does, writes canonical, and deletes the duplicates,
+	// or tombstones them when the driver soft-deletes. The provenance edges
+	// are created last. Either every change is applied or none is.
+	MergeNodes(ctx context.Context, canonical *types.Node, duplicateUUIDs []string, provenance []*types.Edge) error
+}
+
+// AsNodeMerger returns the NodeMerger behind d, looking through wrapping
+// drivers such as WrappedDriver. Its merges pass through the middleware
+// chains of the WrappedDrivers looked through.
+func AsNodeMerger(d GraphDriver) (NodeMerger, bool) {
+	var wrappers []*WrappedDriver
+	for d != nil {
+		if merger, ok := d.(NodeMerger); ok {
+			if len(wrappers) > 0 {
+				return &chainedNodeMerger{merger: merger, wrappers: wrappers}, true
+			}
+			return merger, true
+		}
+		if wrapped, ok := d.(*WrappedDriver); ok {
+			wrappers = append(wrappers, wrapped)
+		}
+		wrapper, ok := d.(interface{ Unwrap() GraphDriver })
+		if !ok {
+			break
+		}
+		d = wrapper.Unwrap()
+	}
+	return nil, false
+}
+
+// chainedNodeMerger is a NodeMerger behind WrappedDrivers, whose merges
+// pass through their middleware chains.
+type chainedNodeMerger struct {
+	merger   NodeMerger
+	wrappers []*WrappedDriver
+}
+
+// MergeNodes merges the duplicates into canonical through the middleware
+// chains.
+func (m *chainedNodeMerger) MergeNodes(ctx context.Context, canonical *types.Node, duplicateUUIDs []string, provenance []*types.Edge) error {
+	return writeThrough(ctx, m.wrappers, "MergeNodes", canonical.GroupID, func(ctx context.Context) error {
+		return m.merger.MergeNodes(ctx, canonical, duplicateUUIDs, provenance)
+	})
+}
+
+// mergeNodesStatements returns the statements of NodeMerger.MergeNodes: the
+// edge redirects of each duplicate, the canonical upsert, the deletes of each
+// duplicate and the provenance edges.
+func mergeNodesStatements(redirectQueries []string, canonical *types.Node, duplicateUUIDs []string, upsert graphStatement, deleteNode func(uuid string) []graphStatement, provenance []graphStatement) []graphStatement {
+	var statements []graphStatement
+	for _, uuid := range duplicateUUIDs {
+		statements = append(statements, redirectEdgesStatements(redirectQueries, canonical.GroupID, uuid, canonical.Uuid)...)
+	}
+	if upsert.query != "" {
+		statements = append(statements, upsert)
+	}
+	for _, uuid := range duplicateUUIDs {
+		statements = append(statements, deleteNode(uuid)...)
+	}
+	return append(statements, provenance...)
+}
+
+// boltDeleteEntityStatements returns the statements deleting the entity uuid
+// and its edges on the Bolt drivers, or tombstoning them with softDelete.
+func boltDeleteEntityStatements(uuid, groupID string, softDelete bool) []graphStatement {
+	if softDelete {
+		params := softDeleteParams(uuid, groupID)
+		return []graphStatement{
+			{query: boltSoftDeleteNodeEdgesQuery, params: params},
+			{query: boltSoftDeleteNodeQuery, params: params},
+		}
+	}
+	return []graphStatement{{
+		query: `
+			MATCH (n:Entity {uuid: $uuid, group_id: $group_id})
+			DETACH DELETE n
+		`,
+		params: map[string]interface{}{"uuid": uuid, "group_id": groupID},
+	}}
+}
+
+// MergeNodes merges the duplicate entities into canonical in a single write
+// transaction in the database of its group.
+func (n *Neo4jDriver) MergeNodes(ctx context.Context, canonical *types.Node, duplicateUUIDs []string, provenance []*types.Edge) error {
+	var provenanceStatements []graphStatement
+	for _, edge := range provenance {
+		provenanceStatements = append(provenanceStatements, boltUpsertEdgeStatement(edge, n.edgeToProperties(edge)))
+	}
+	statements := mergeNodesStatements(boltRedirectEdgesQueries, canonical, duplicateUUIDs,
+		boltUpsertNodeStatement(n.getLabelForNodeType(canonical.Type), canonical, n.nodeToProperties(canonical)),
+		func(uuid string) []graphStatement {
+			return boltDeleteEntityStatements(uuid, canonical.GroupID, n.options.SoftDelete)
+		},
+		provenanceStatements)
+
+	if err := runBoltStatements(ctx, n.client, n.databaseForGroup(canonical.GroupID), statements); err != nil {
+		return fmt.Errorf("failed to merge nodes into %s: %w", canonical.Uuid, err)
+	}
+	return nil
+}
+
+// MergeNodes merges the duplicate entities into canonical in a single write
+// transaction.
+func (m *MemgraphDriver) MergeNodes(ctx context.Context, canonical *types.Node, duplicateUUIDs []string, provenance []*types.Edge) error {
+	var provenanceStatements []graphStatement
+	for _, edge := range provenance {
+		provenanceStatements = append(provenanceStatements, boltUpsertEdgeStatement(edge, m.edgeToProperties(edge)))
+	}
+	statements := mergeNodesStatements(boltRedirectEdgesQueries, canonical, duplicateUUIDs,
+		boltUpsertNodeStatement(m.getLabelForNodeType(canonical.Type), canonical, m.nodeToProperties(canonical)),
+		func(uuid string) []graphStatement {
+			return boltDeleteEntityStatements(uuid, canonical.GroupID, m.options.SoftDelete)
+		},
+		provenanceStatements)
+
+	if err := runBoltStatements(ctx, m.client, m.database, statements); err != nil {
+		return fmt.Errorf("failed to merge nodes into %s: %w", canonical.Uuid, err)
+	}
+	return nil
+}
+
+// MergeNodes merges the duplicate entities into canonical in a single write
+// transaction.
+func (k *LadybugDriver) MergeNodes(ctx context.Context, canonical *types.Node, duplicateUUIDs []string, provenance []*types.Edge) error {
+	upsert, err := k.nodeUpdateStatement(canonical, "Entity")
+	if err != nil {
+		return err
+	}
+	var provenanceStatements []graphStatement
+	for _, edge := range provenance {
+		provenanceStatements = append(provenanceStatements, k.edgeCreateStatement(edge))
+	}
+	statements := mergeNodesStatements(ladybugRedirectEdgesQueries, canonical, duplicateUUIDs, upsert,
+		func(uuid string) []graphStatement {
+			if k.softDelete {
+				return k.softDeleteNodeStatements(uuid, canonical.GroupID)
+			}
+			params := map[string]interface{}{"uuid": uuid, "group_id": canonical.GroupID}
+			return []graphStatement{{
+				query: `
+					MATCH (n:Entity)
+					WHERE n.uuid = $uuid AND n.group_id = $group_id
+					DETACH DELETE n
+				`,
+				params: params,
+			}}
+		},
+		provenanceStatements)
+
+	if err := k.executeTransaction(ctx, statements); err != nil {
+		return fmt.Errorf("failed to merge nodes into %s: %w", canonical.Uuid, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// queryRecorder is a GraphDriver stub recording the queries it executes.
+type queryRecorder struct {
+	GraphDriver
+	provider GraphProvider
+	queries  []string
+	params   []map[string]interface{}
+}
+
+func (d *queryRecorder) Provider() GraphProvider {
+	return d.provider
+}
+
+func (d *queryRecorder) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	d.queries = append(d.queries, cypherQuery)
+	d.params = append(d.params, kwargs)
+	return nil, nil, nil, nil
+}
+
+func TestRedirectNodeEdges(t *testing.T) {
+	for provider, want := range map[GraphProvider][]string{
+		GraphProviderNeo4j:    boltRedirectEdgesQueries,
+		GraphProviderMemgraph: boltRedirectEdgesQueries,
+		GraphProviderLadybug:  ladybugRedirectEdgesQueries,
+	} {
+		d := &queryRecorder{provider: provider}
+		if err := RedirectNodeEdges(context.Background(), d, "g", "dup", "canon"); err != nil {
+			t.Fatalf("%s: RedirectNodeEdges() error = %v", provider, err)
+		}
+		if len(d.queries) != len(want) {
+			t.Fatalf("%s: ran %d queries, want %d", provider, len(d.queries), len(want))
+		}
+		for _, params := range d.params {
+			if params["from"] != "dup" || params["to"] != "canon" || params["group_id"] != "g" {
+				t.Errorf("%s: params = %v", provider, params)
+			}
+		}
+	}
+
+	d := &queryRecorder{provider: GraphProviderLadybug}
+	if err := RedirectNodeEdges(context.Background(), d, "g", "same", "same"); err != nil || len(d.queries) != 0 {
+		t.Errorf("redirecting a node to itself ran %d queries, error = %v", len(d.queries), err)
+	}
+
+	d = &queryRecorder{provider: GraphProviderSurrealDB}
+	if err := RedirectNodeEdges(context.Background(), d, "g", "dup", "canon"); !errors.Is(err, ErrNodeMergeUnsupported) {
+		t.Errorf("RedirectNodeEdges() error = %v, want ErrNodeMergeUnsupported", err)
+	}
+}
+
+func TestMergeNodesStatements(t *testing.T) {
+	canonical := &types.Node{Uuid: "canon", GroupID: "g"}
+	upsert := graphStatement{query: "UPSERT canon"}
+	provenance := []graphStatement{{query: "PROVENANCE dup1"}}
+	deleteNode := func(uuid string) []graphStatement {
+		return boltDeleteEntityStatements(uuid, "g", true)
+	}
+
+	statements := mergeNodesStatements(boltRedirectEdgesQueries, canonical, []string{"dup1", "dup2"}, upsert, deleteNode, provenance)
+	redirects := len(boltRedirectEdgesQueries)
+	if want := 2*redirects + 1 + 4 + 1; len(statements) != want {
+		t.Fatalf("got %d statements, want %d", len(statements), want)
+	}
+	for i, statement := range statements[:2*redirects] {
+		want := []string{"dup1", "dup2"}[i/redirects]
+		if statement.query != boltRedirectEdgesQueries[i%redirects] || statement.params["from"] != want || statement.params["to"] != "canon" {
+			t.Errorf("statement %d = %v, want redirect %d of %s", i, statement.params, i%redirects, want)
+		}
+	}
+	// The canonical node is written before the duplicates go, and the
+	// provenance edges after they are tombstoned
+	if statements[2*redirects].query != upsert.query {
+		t.Errorf("statement %d = %q, want the canonical upsert", 2*redirects, statements[2*redirects].query)
+	}
+	for i, statement := range statements[2*redirects+1 : len(statements)-1] {
+		want := []string{"dup1", "dup1", "dup2", "dup2"}[i]
+		if statement.params["uuid"] != want || statement.params["deleted_at"] == nil {
+			t.Errorf("delete statement %d = %v, want a tombstone of %s", i, statement.params, want)
+		}
+	}
+	if statements[len(statements)-1].query != "PROVENANCE dup1" {
+		t.Errorf("last statement = %q, want the provenance edge", statements[len(statements)-1].query)
+	}
+
+	// Ladybug has nothing to write when the canonical node is unchanged
+	statements = mergeNodesStatements(ladybugRedirectEdgesQueries, canonical, []string{"dup1"}, graphStatement{}, func(string) []graphStatement { return nil }, nil)
+	if len(statements) != len(ladybugRedirectEdgesQueries) {
+		t.Errorf("got %d statements, want only the redirects", len(statements))
+	}
+}
+
+func TestBoltDeleteEntityStatements(t *testing.T) {
+	statements := boltDeleteEntityStatements("dup", "g", false)
+	if len(statements) != 1 || !strings.Contains(statements[0].query, "DETACH DELETE") || statements[0].params["uuid"] != "dup" {
+		t.Errorf("hard delete statements = %v", statements)
+	}
+	statements = boltDeleteEntityStatements("dup", "g", true)
+	if len(statements) != 2 || statements[0].query != boltSoftDeleteNodeEdgesQuery || statements[1].query != boltSoftDeleteNodeQuery {
+		t.Errorf("soft delete statements = %v, want the edges tombstoned before the node", statements)
+	}
+}
+
+func TestAsNodeMerger(t *testing.T) {
+	if _, ok := AsNodeMerger(Wrap(&Neo4jDriver{}, LoggingMiddleware(nil))); !ok {
+		t.Error("AsNodeMerger() should find the Neo4j driver behind a wrapper")
+	}
+	if _, ok := AsNodeMerger(&queryRecorder{provider: GraphProviderSurrealDB}); ok {
+		t.Error("AsNodeMerger() should not find a merger in a driver without one")
+	}
+}
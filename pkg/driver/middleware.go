@@ -0,0 +1,394 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// Query is a Cypher query passing through the middleware chain.
+type Query struct {
+	Cypher string
+	Params map[string]interface{}
+	// GroupID is the group the query runs on, for queries run with
+	// ExecuteGroupQuery and typed methods taking a group; empty otherwise.
+	GroupID string
+	// Write marks changes to the graph made by a typed method of
+	// WrappedDriver, such as UpsertNode, whose Cypher is the method name.
+	Write bool
+
+	// run performs the typed method in place of the Cypher query
+	run func(ctx context.Context) error
+}
+
+// QueryResult holds the (records, summary, keys) tuple returned by ExecuteQuery.
+type QueryResult struct {
+	Records interface{}
+	Summary interface{}
+	Keys    interface{}
+}
+
+// QueryFunc executes a query.
+type QueryFunc func(ctx context.Context, query *Query) (*QueryResult, error)
+
+// Middleware wraps a QueryFunc to observe or alter queries, for example to add
+// logging, metrics, query rewriting, or caching.
+type Middleware func(next QueryFunc) QueryFunc
+
+// QueryStats describes a completed query.
+type QueryStats struct {
+	Cypher   string
+	Params   map[string]interface{}
+	Duration time.Duration
+	Err      error
+}
+
+// WrappedDriver is a GraphDriver whose raw queries pass through a middleware chain.
+//
+// ExecuteQuery and Run on sessions created by Session are intercepted. The typed
+// methods changing the graph (UpsertNode, DeleteEdge, ...) pass through the
+// chain as write queries named after the method, so that middlewares such as
+// CacheMiddleware see them, as do the writes of the capabilities returned by
+// AsNodeMerger and AsSoftDeleter; the other typed methods (GetNode,
+// SearchNodes, ...) are delegated to the inner driver unchanged.
+type WrappedDriver struct {
+	GraphDriver
+	middlewares []Middleware
+	execute     QueryFunc
+}
+
+// Wrap returns a driver that sends queries through the given middlewares.
+// Middlewares run in the order given: the first one sees the query first and
+// the result last.
+func Wrap(inner GraphDriver, middlewares ...Middleware) *WrappedDriver {
+	execute := func(ctx context.Context, query *Query) (*QueryResult, error) {
+		if query.run != nil {
+			return &QueryResult{}, query.run(ctx)
+		}
+		var (
+			records, summary, keys interface{}
+			err                    error
+		)
+		if executor, ok := inner.(groupQueryExecutor); ok && query.GroupID != "" {
+			records, summary, keys, err = executor.ExecuteGroupQuery(query.GroupID, query.Cypher, query.Params)
+		} else {
+			records, summary, keys, err = inner.ExecuteQuery(query.Cypher, query.Params)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &QueryResult{Records: records, Summary: summary, Keys: keys}, nil
+	}
+
+	return &WrappedDriver{
+		GraphDriver: inner,
+		middlewares: middlewares,
+		execute:     chain(execute, middlewares),
+	}
+}
+
+// Unwrap returns the inner driver.
+func (w *WrappedDriver) Unwrap() GraphDriver {
+	return w.GraphDriver
+}
+
+// ExecuteQuery executes a Cypher query through the middleware chain.
+func (w *WrappedDriver) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	result, err := w.execute(context.Background(), &Query{Cypher: cypherQuery, Params: kwargs})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return result.Records, result.Summary, result.Keys, nil
+}
+
+// ExecuteGroupQuery executes a Cypher query against the database holding
+// groupID through the middleware chain.
+func (w *WrappedDriver) ExecuteGroupQuery(groupID, cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	result, err := w.execute(context.Background(), &Query{Cypher: cypherQuery, Params: kwargs, GroupID: groupID})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return result.Records, result.Summary, result.Keys, nil
+}
+
+// Session creates a session whose Run calls pass through the middleware chain.
+func (w *WrappedDriver) Session(database *string) GraphDriverSession {
+	inner := w.GraphDriver.Session(database)
+	if inner == nil {
+		return nil
+	}
+	return &wrappedSession{GraphDriverSession: inner, driver: w}
+}
+
+// mutate runs a typed method changing the graph through the middleware chain
+// as a write query named after the method.
+func (w *WrappedDriver) mutate(ctx context.Context, method, groupID string, run func(ctx context.Context) error) error {
+	_, err := w.execute(ctx, &Query{Cypher: method, GroupID: groupID, Write: true, run: run})
+	return err
+}
+
+// writeThrough runs a write made past wrappers, such as a write of a driver
+// capability found with AsNodeMerger, through their middleware chains,
+// outermost first, as a write query named after method.
+func writeThrough(ctx context.Context, wrappers []*WrappedDriver, method, groupID string, run func(ctx context.Context) error) error {
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		wrapper, inner := wrappers[i], run
+		run = func(ctx context.Context) error {
+			return wrapper.mutate(ctx, method, groupID, inner)
+		}
+	}
+	return run(ctx)
+}
+
+// UpsertNode upserts a node through the middleware chain.
+func (w *WrappedDriver) UpsertNode(ctx context.Context, node *types.Node) error {
+	return w.mutate(ctx, "UpsertNode", "", func(ctx context.Context) error {
+		return w.GraphDriver.UpsertNode(ctx, node)
+	})
+}
+
+// DeleteNode deletes a node through the middleware chain.
+func (w *WrappedDriver) DeleteNode(ctx context.Context, nodeID, groupID string) error {
+	return w.mutate(ctx, "DeleteNode", groupID, func(ctx context.Context) error {
+		return w.GraphDriver.DeleteNode(ctx, nodeID, groupID)
+	})
+}
+
+// UpsertEdge upserts an edge through the middleware chain.
+func (w *WrappedDriver) UpsertEdge(ctx context.Context, edge *types.Edge) error {
+	return w.mutate(ctx, "UpsertEdge", "", func(ctx context.Context) error {
+		return w.GraphDriver.UpsertEdge(ctx, edge)
+	})
+}
+
+// UpsertEpisodicEdge upserts an episodic edge through the middleware chain.
+func (w *WrappedDriver) UpsertEpisodicEdge(ctx context.Context, episodeUUID, entityUUID, groupID string) error {
+	return w.mutate(ctx, "UpsertEpisodicEdge", groupID, func(ctx context.Context) error {
+		return w.GraphDriver.UpsertEpisodicEdge(ctx, episodeUUID, entityUUID, groupID)
+	})
+}
+
+// UpsertCommunityEdge upserts a community edge through the middleware chain.
+func (w *WrappedDriver) UpsertCommunityEdge(ctx context.Context, communityUUID, nodeUUID, uuid, groupID string) error {
+	return w.mutate(ctx, "UpsertCommunityEdge", groupID, func(ctx context.Context) error {
+		return w.GraphDriver.UpsertCommunityEdge(ctx, communityUUID, nodeUUID, uuid, groupID)
+	})
+}
+
+// DeleteEdge deletes an edge through the middleware chain.
+func (w *WrappedDriver) DeleteEdge(ctx context.Context, edgeID, groupID string) error {
+	return w.mutate(ctx, "DeleteEdge", groupID, func(ctx context.Context) error {
+		return w.GraphDriver.DeleteEdge(ctx, edgeID, groupID)
+	})
+}
+
+// UpsertNodes upserts nodes through the middleware chain.
+func (w *WrappedDriver) UpsertNodes(ctx context.Context, nodes []*types.Node) error {
+	return w.mutate(ctx, "UpsertNodes", "", func(ctx context.Context) error {
+		return w.GraphDriver.UpsertNodes(ctx, nodes)
+	})
+}
+
+// UpsertEdges upserts edges through the middleware chain.
+func (w *WrappedDriver) UpsertEdges(ctx context.Context, edges []*types.Edge) error {
+	return w.mutate(ctx, "UpsertEdges", "", func(ctx context.Context) error {
+		return w.GraphDriver.UpsertEdges(ctx, edges)
+	})
+}
+
+// BuildCommunities builds the communities of a group through the middleware
+// chain.
+func (w *WrappedDriver) BuildCommunities(ctx context.Context, groupID string) error {
+	return w.mutate(ctx, "BuildCommunities", groupID, func(ctx context.Context) error {
+		return w.GraphDriver.BuildCommunities(ctx, groupID)
+	})
+}
+
+// RemoveCommunities removes all communities through the middleware chain.
+func (w *WrappedDriver) RemoveCommunities(ctx context.Context) error {
+	return w.mutate(ctx, "RemoveCommunities", "", func(ctx context.Context) error {
+		return w.GraphDriver.RemoveCommunities(ctx)
+	})
+}
+
+// wrappedSession intercepts Run on an inner session.
+type wrappedSession struct {
+	GraphDriverSession
+	driver *WrappedDriver
+}
+
+// Enter implements the context manager pattern, keeping the session wrapped.
+func (s *wrappedSession) Enter(ctx context.Context) (GraphDriverSession, error) {
+	inner, err := s.GraphDriverSession.Enter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedSession{GraphDriverSession: inner, driver: s.driver}, nil
+}
+
+// Run executes a query through the middleware chain. Non-string queries
+// (such as Ladybug query lists) are passed through unchanged.
+func (s *wrappedSession) Run(ctx context.Context, query interface{}, kwargs map[string]interface{}) error {
+	cypher, ok := query.(string)
+	if !ok {
+		return s.GraphDriverSession.Run(ctx, query, kwargs)
+	}
+
+	run := func(ctx context.Context, q *Query) (*QueryResult, error) {
+		return &QueryResult{}, s.GraphDriverSession.Run(ctx, q.Cypher, q.Params)
+	}
+	_, err := chain(run, s.driver.middlewares)(ctx, &Query{Cypher: cypher, Params: kwargs})
+	return err
+}
+
+// chain composes middlewares around a final QueryFunc.
+func chain(final QueryFunc, middlewares []Middleware) QueryFunc {
+	execute := final
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		execute = middlewares[i](execute)
+	}
+	return execute
+}
+
+// LoggingMiddleware logs every query with its duration and error at debug level,
+// and failed queries at error level.
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return MetricsMiddleware(func(stats QueryStats) {
+		if stats.Err != nil {
+			logger.Error("graph query failed",
+				"cypher", stats.Cypher,
+				"duration", stats.Duration,
+				"error", stats.Err)
+			return
+		}
+		logger.Debug("graph query",
+			"cypher", stats.Cypher,
+			"params", len(stats.Params),
+			"duration", stats.Duration)
+	})
+}
+
+// MetricsMiddleware calls observe with the Cypher text, params, duration, and
+// error of every query.
+func MetricsMiddleware(observe func(QueryStats)) Middleware {
+	return func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, query *Query) (*QueryResult, error) {
+			start := time.Now()
+			result, err := next(ctx, query)
+			observe(QueryStats{
+				Cypher:   query.Cypher,
+				Params:   query.Params,
+				Duration: time.Since(start),
+				Err:      err,
+			})
+			return result, err
+		}
+	}
+}
+
+// RewriteMiddleware lets rewrite modify the query before it is executed.
+func RewriteMiddleware(rewrite func(query *Query)) Middleware {
+	return func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, query *Query) (*QueryResult, error) {
+			rewritten := *query
+			rewrite(&rewritten)
+			return next(ctx, &rewritten)
+		}
+	}
+}
+
+// cacheEntry is a cached read query result.
+type cacheEntry struct {
+	result    *QueryResult
+	expiresAt time.Time
+}
+
+// CacheMiddleware caches the results of read queries for ttl. Any write query,
+// including the typed methods of WrappedDriver changing the graph, clears the
+// cache. maxEntries bounds the cache size (default: 1000).
+func CacheMiddleware(ttl time.Duration, maxEntries int) Middleware {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+
+	var mu sync.Mutex
+	entries := make(map[string]cacheEntry)
+
+	return func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, query *Query) (*QueryResult, error) {
+			if query.Write || isWriteQuery(query.Cypher) {
+				flush := func() {
+					mu.Lock()
+					entries = make(map[string]cacheEntry)
+					mu.Unlock()
+				}
+				// Flush again afterwards, since reads running during the
+				// write may have cached what it replaced
+				flush()
+				defer flush()
+				return next(ctx, query)
+			}
+
+			key, err := cacheKey(query)
+			if err != nil {
+				// Params that cannot be serialized are not cached
+				return next(ctx, query)
+			}
+
+			mu.Lock()
+			entry, ok := entries[key]
+			mu.Unlock()
+			if ok && time.Now().Before(entry.expiresAt) {
+				return entry.result, nil
+			}
+
+			result, err := next(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+
+			mu.Lock()
+			if len(entries) >= maxEntries {
+				now := time.Now()
+				for k, e := range entries {
+					if now.After(e.expiresAt) {
+						delete(entries, k)
+					}
+				}
+				if len(entries) >= maxEntries {
+					entries = make(map[string]cacheEntry)
+				}
+			}
+			entries[key] = cacheEntry{result: result, expiresAt: time.Now().Add(ttl)}
+			mu.Unlock()
+
+			return result, nil
+		}
+	}
+}
+
+// cacheKey builds a cache key from the group, Cypher text and params.
+func cacheKey(query *Query) (string, error) {
+	params, err := json.Marshal(query.Params)
+	if err != nil {
+		return "", err
+	}
+	return query.GroupID + "\x00" + query.Cypher + "\x00" + string(params), nil
+}
+
+// writeClausePattern matches the Cypher clauses that change the graph, as
+// whole words anywhere in the query.
+var writeClausePattern = regexp.MustCompile(`(?i)\b(CREATE|MERGE|SET|DELETE|REMOVE|DROP|INSERT|UPDATE)\b`)
+
+// isWriteQuery checks if a query is a write operation (CREATE, MERGE, SET, DELETE, etc.)
+func isWriteQuery(query string) bool {
+	return writeClausePattern.MatchString(query)
+}
@@ -0,0 +1,277 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// countingDriver is a GraphDriver stub that counts ExecuteQuery calls.
+type countingDriver struct {
+	GraphDriver
+	calls   int
+	lastCyp string
+	fail    bool
+	writes  []string
+}
+
+func (d *countingDriver) UpsertNode(ctx context.Context, node *types.Node) error {
+	d.writes = append(d.writes, "UpsertNode")
+	return nil
+}
+
+func (d *countingDriver) DeleteEdge(ctx context.Context, edgeID, groupID string) error {
+	d.writes = append(d.writes, "DeleteEdge")
+	return nil
+}
+
+func (d *countingDriver) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	d.calls++
+	d.lastCyp = cypherQuery
+	if d.fail {
+		return nil, nil, nil, errors.New("query failed")
+	}
+	return []map[string]interface{}{{"n": d.calls}}, nil, []string{"n"}, nil
+}
+
+func TestWrapMiddlewareOrder(t *testing.T) {
+	var order []string
+	record := func(name string) Middleware {
+		return func(next QueryFunc) QueryFunc {
+			return func(ctx context.Context, query *Query) (*QueryResult, error) {
+				order = append(order, name)
+				return next(ctx, query)
+			}
+		}
+	}
+
+	inner := &countingDriver{}
+	wrapped := Wrap(inner, record("first"), record("second"))
+	if _, _, _, err := wrapped.ExecuteQuery("RETURN 1", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Join(order, ",") != "first,second" {
+		t.Errorf("expected middlewares to run in order, got %v", order)
+	}
+	if wrapped.Unwrap() != GraphDriver(inner) {
+		t.Error("expected Unwrap to return the inner driver")
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	var stats []QueryStats
+	inner := &countingDriver{fail: true}
+	wrapped := Wrap(inner, MetricsMiddleware(func(s QueryStats) {
+		stats = append(stats, s)
+	}))
+
+	_, _, _, err := wrapped.ExecuteQuery("MATCH (n) RETURN n", map[string]interface{}{"a": 1})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(stats) != 1 {
+		t.Fatalf("expected 1 observation, got %d", len(stats))
+	}
+	if stats[0].Cypher != "MATCH (n) RETURN n" || stats[0].Params["a"] != 1 || stats[0].Err == nil {
+		t.Errorf("unexpected stats: %+v", stats[0])
+	}
+}
+
+func TestRewriteMiddleware(t *testing.T) {
+	inner := &countingDriver{}
+	wrapped := Wrap(inner, RewriteMiddleware(func(q *Query) {
+		q.Cypher = "/* traced */ " + q.Cypher
+	}))
+
+	if _, _, _, err := wrapped.ExecuteQuery("RETURN 1", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inner.lastCyp != "/* traced */ RETURN 1" {
+		t.Errorf("expected rewritten query, got %q", inner.lastCyp)
+	}
+}
+
+func TestCacheMiddleware(t *testing.T) {
+	inner := &countingDriver{}
+	wrapped := Wrap(inner, CacheMiddleware(time.Minute, 10))
+
+	params := map[string]interface{}{"uuid": "abc"}
+	for i := 0; i < 3; i++ {
+		if _, _, _, err := wrapped.ExecuteQuery("MATCH (n {uuid: $uuid}) RETURN n", params); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("expected read query to be cached, got %d calls", inner.calls)
+	}
+
+	if _, _, _, err := wrapped.ExecuteQuery("MATCH (n {uuid: $uuid}) SET n.name = 'x'", params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, _, err := wrapped.ExecuteQuery("MATCH (n {uuid: $uuid}) RETURN n", params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inner.calls != 3 {
+		t.Errorf("expected write to invalidate the cache, got %d calls", inner.calls)
+	}
+}
+
+func TestCacheMiddlewareTypedWrites(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingDriver{}
+	var observed []QueryStats
+	wrapped := Wrap(inner,
+		MetricsMiddleware(func(s QueryStats) { observed = append(observed, s) }),
+		CacheMiddleware(time.Minute, 10))
+
+	read := func() {
+		t.Helper()
+		if _, _, _, err := wrapped.ExecuteQuery("MATCH (n) RETURN n", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	read()
+	read()
+	if inner.calls != 1 {
+		t.Fatalf("expected read query to be cached, got %d calls", inner.calls)
+	}
+
+	for i, write := range []func() error{
+		func() error { return wrapped.UpsertNode(ctx, &types.Node{Uuid: "n1"}) },
+		func() error { return wrapped.DeleteEdge(ctx, "e1", "g") },
+	} {
+		if err := write(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		read()
+		if inner.calls != i+2 {
+			t.Errorf("expected %s to invalidate the cache, got %d calls", inner.writes[i], inner.calls)
+		}
+	}
+
+	if len(inner.writes) != 2 {
+		t.Errorf("expected typed writes to reach the inner driver, got %v", inner.writes)
+	}
+	var methods []string
+	for _, s := range observed {
+		if s.Cypher == "UpsertNode" || s.Cypher == "DeleteEdge" {
+			methods = append(methods, s.Cypher)
+		}
+	}
+	if strings.Join(methods, ",") != "UpsertNode,DeleteEdge" {
+		t.Errorf("expected typed writes to pass through the middlewares, got %v", methods)
+	}
+}
+
+// mergingDriver is a countingDriver merging nodes and soft deleting records.
+type mergingDriver struct {
+	countingDriver
+}
+
+func (d *mergingDriver) Provider() GraphProvider {
+	return GraphProviderNeo4j
+}
+
+func (d *mergingDriver) MergeNodes(ctx context.Context, canonical *types.Node, duplicateUUIDs []string, provenance []*types.Edge) error {
+	d.writes = append(d.writes, "MergeNodes")
+	return nil
+}
+
+func (d *mergingDriver) SoftDeleteEnabled() bool {
+	return true
+}
+
+func (d *mergingDriver) PurgeDeleted(ctx context.Context, olderThan time.Duration) error {
+	d.writes = append(d.writes, "PurgeDeleted")
+	return nil
+}
+
+func (d *mergingDriver) RestoreDeleted(ctx context.Context, groupID string, since time.Time) error {
+	d.writes = append(d.writes, "RestoreDeleted")
+	return nil
+}
+
+func TestCacheMiddlewareHelperWrites(t *testing.T) {
+	ctx := context.Background()
+	inner := &mergingDriver{}
+	// The guard is looked through as well
+	wrapped := NewEmbeddingGuard(Wrap(inner, CacheMiddleware(time.Minute, 10)), "m")
+
+	read := func() int {
+		t.Helper()
+		before := inner.calls
+		if _, _, _, err := wrapped.ExecuteQuery("MATCH (n) RETURN n", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return inner.calls - before
+	}
+	read()
+	if read() != 0 {
+		t.Fatal("expected read query to be cached")
+	}
+
+	for name, write := range map[string]func() error{
+		"MergeNodes": func() error {
+			merger, ok := AsNodeMerger(wrapped)
+			if !ok {
+				t.Fatal("expected a NodeMerger")
+			}
+			return merger.MergeNodes(ctx, &types.Node{Uuid: "canon", GroupID: "g"}, []string{"dup"}, nil)
+		},
+		"PurgeDeleted": func() error {
+			softDeleter, _ := AsSoftDeleter(wrapped)
+			return softDeleter.PurgeDeleted(ctx, time.Hour)
+		},
+		"RestoreDeleted": func() error {
+			softDeleter, _ := AsSoftDeleter(wrapped)
+			return softDeleter.RestoreDeleted(ctx, "g", time.Now())
+		},
+		"RedirectNodeEdges": func() error {
+			return RedirectNodeEdges(ctx, wrapped, "g", "dup", "canon")
+		},
+		"SaveSearch": func() error {
+			return SaveSearch(ctx, wrapped, &SavedSearch{GroupID: "g", Name: "recent"})
+		},
+	} {
+		if err := write(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if read() != 1 {
+			t.Errorf("expected %s to invalidate the cache", name)
+		}
+	}
+	if !slices.Contains(inner.writes, "MergeNodes") || !slices.Contains(inner.writes, "RestoreDeleted") {
+		t.Errorf("expected the writes to reach the inner driver, got %v", inner.writes)
+	}
+}
+
+func TestIsWriteQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"MATCH (n) RETURN n", false},
+		{"MATCH (n) RETURN n.created_at, n.updated_at ORDER BY n.name SKIP 10", false},
+		{"MATCH (n) WHERE n.name = $name RETURN n.offset", false},
+		{"CREATE (n:Entity)", true},
+		{"MATCH (n)\nSET n.name = 'x'", true},
+		{"MATCH (n)\n\tDETACH DELETE n", true},
+		{"MATCH (n)\tDELETE n", true},
+		{"merge (n:Entity {uuid: $uuid})", true},
+		{"MATCH (n)\r\nREMOVE n:Stale", true},
+		{"MATCH (a), (b)\nMERGE (a)-[:RELATES_TO]->(b)", true},
+		{"MATCH (n) WITH n\nSET n.x = 1 RETURN n", true},
+	}
+	for _, tt := range tests {
+		if got := isWriteQuery(tt.query); got != tt.want {
+			t.Errorf("isWriteQuery(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+// Package migrations provides versioned schema migrations for the graph drivers.
+//
+// Each provider (Ladybug, Neo4j, Memgraph) has its own ordered list of migrations.
+// The applied schema version is recorded in the graph itself as a SchemaVersion
+// node, so a database written by a newer release of go-predicato is detected and
+// refused instead of being silently corrupted by an older binary.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Provider names, matching the driver.GraphProvider values.
+const (
+	ProviderNeo4j    = "neo4j"
+	ProviderMemgraph = "memgraph"
+	ProviderLadybug  = "ladybug"
+)
+
+// schemaVersionID is the key of the single SchemaVersion node in the graph.
+const schemaVersionID = "predicato"
+
+// ErrSchemaTooNew indicates the database was migrated by a newer release than this one.
+var ErrSchemaTooNew = errors.New("database schema is newer than supported by this version")
+
+// Executor is the subset of driver.GraphDriver needed to run migrations.
+type Executor interface {
+	ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error)
+}
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	// Version is the schema version reached after applying this migration
+	Version int
+	// Description explains the change
+	Description string
+	// Statements are executed in order
+	Statements []string
+	// IgnoreError reports whether a statement error can be tolerated
+	// (for example "already exists" on databases without IF NOT EXISTS support)
+	IgnoreError func(err error) bool
+}
+
+// registry holds the migrations for each provider, ordered by version.
+var registry = map[string][]Migration{
+	ProviderLadybug: {
+		{
+			// Base tables are created on open by LadybugDriver.setupSchema.
+			Version:     1,
+			Description: "baseline schema",
+		},
+		{
+			Version:     2,
+			Description: "deleted_at tombstones",
+			Statements: []string{
+				"ALTER TABLE Episodic ADD IF NOT EXISTS deleted_at TIMESTAMP",
+				"ALTER TABLE Entity ADD IF NOT EXISTS deleted_at TIMESTAMP",
+				"ALTER TABLE Community ADD IF NOT EXISTS deleted_at TIMESTAMP",
+				"ALTER TABLE RelatesToNode_ ADD IF NOT EXISTS deleted_at TIMESTAMP",
+			},
+		},
+		{
+			Version:     3,
+			Description: "embedding metadata",
+			Statements: []string{
+				"CREATE NODE TABLE IF NOT EXISTS EmbeddingMetadata (group_id STRING PRIMARY KEY, model STRING, dimensions INT64, updated_at TIMESTAMP)",
+			},
+		},
+		{
+			Version:     4,
+			Description: "saved searches",
+			Statements: []string{
+				"CREATE NODE TABLE IF NOT EXISTS SavedSearch (id STRING PRIMARY KEY, group_id STRING, name STRING, config STRING, updated_at TIMESTAMP)",
+			},
+		},
+		{
+			Version:     5,
+			Description: "pending merge review queue",
+			Statements: []string{
+				"CREATE NODE TABLE IF NOT EXISTS PendingMerge (id STRING PRIMARY KEY, group_id STRING, node_uuid STRING, node_name STRING, canonical_uuid STRING, canonical_name STRING, score DOUBLE, created_at TIMESTAMP)",
+			},
+		},
+		{
+			Version:     6,
+			Description: "community metrics",
+			Statements: []string{
+				"CREATE NODE TABLE IF NOT EXISTS CommunityMetrics (id STRING PRIMARY KEY, group_id STRING, built_at TIMESTAMP, communities INT64, sizes STRING, modularity DOUBLE, intra_community_edges INT64, inter_community_edges INT64)",
+			},
+		},
+		{
+			Version:     7,
+			Description: "community membership history",
+			Statements: []string{
+				"ALTER TABLE HAS_MEMBER ADD IF NOT EXISTS valid_to TIMESTAMP",
+			},
+		},
+		{
+			Version:     8,
+			Description: "prompt versions",
+			Statements: []string{
+				"CREATE NODE TABLE IF NOT EXISTS PromptVersion (group_id STRING PRIMARY KEY, version STRING, updated_at TIMESTAMP)",
+			},
+		},
+	},
+	ProviderNeo4j: {
+		{
+			Version:     1,
+			Description: "uuid uniqueness constraints",
+			Statements: []string{
+				"CREATE CONSTRAINT entity_uuid_unique IF NOT EXISTS FOR (n:Entity) REQUIRE n.uuid IS UNIQUE",
+				"CREATE CONSTRAINT episodic_uuid_unique IF NOT EXISTS FOR (n:Episodic) REQUIRE n.uuid IS UNIQUE",
+				"CREATE CONSTRAINT community_uuid_unique IF NOT EXISTS FOR (n:Community) REQUIRE n.uuid IS UNIQUE",
+			},
+			IgnoreError: isAlreadyExists,
+		},
+	},
+	ProviderMemgraph: {
+		{
+			Version:     1,
+			Description: "uuid uniqueness constraints",
+			Statements: []string{
+				"CREATE CONSTRAINT ON (n:Entity) ASSERT n.uuid IS UNIQUE",
+				"CREATE CONSTRAINT ON (n:Episodic) ASSERT n.uuid IS UNIQUE",
+				"CREATE CONSTRAINT ON (n:Community) ASSERT n.uuid IS UNIQUE",
+			},
+			IgnoreError: isAlreadyExists,
+		},
+	},
+}
+
+// bootstrapStatements create the storage for the schema version where the
+// provider requires an explicit schema.
+var bootstrapStatements = map[string][]string{
+	ProviderLadybug: {
+		"CREATE NODE TABLE IF NOT EXISTS SchemaVersion (id STRING PRIMARY KEY, version INT64, applied_at TIMESTAMP);",
+	},
+}
+
+// Register adds a migration for a provider. It is intended for applications that
+// extend the base schema; versions must be unique per provider.
+func Register(provider string, migration Migration) error {
+	for _, existing := range registry[provider] {
+		if existing.Version == migration.Version {
+			return fmt.Errorf("migration version %d already registered for provider %s", migration.Version, provider)
+		}
+	}
+	registry[provider] = append(registry[provider], migration)
+	sort.Slice(registry[provider], func(i, j int) bool {
+		return registry[provider][i].Version < registry[provider][j].Version
+	})
+	return nil
+}
+
+// ForProvider returns the migrations for a provider, ordered by version.
+func ForProvider(provider string) []Migration {
+	migrations := make([]Migration, len(registry[provider]))
+	copy(migrations, registry[provider])
+	return migrations
+}
+
+// LatestVersion returns the highest schema version known for a provider.
+func LatestVersion(provider string) int {
+	migrations := registry[provider]
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Runner applies pending migrations to a database.
+type Runner struct {
+	exec       Executor
+	provider   string
+	migrations []Migration
+}
+
+// NewRunner creates a new Runner for the given provider using the registered migrations.
+func NewRunner(exec Executor, provider string) *Runner {
+	return &Runner{
+		exec:       exec,
+		provider:   provider,
+		migrations: ForProvider(provider),
+	}
+}
+
+// CurrentVersion returns the schema version recorded in the database, or 0 if none.
+func (r *Runner) CurrentVersion(ctx context.Context) (int, error) {
+	if err := r.bootstrap(ctx); err != nil {
+		return 0, err
+	}
+
+	result, _, _, err := r.exec.ExecuteQuery(
+		"MATCH (s:SchemaVersion {id: $id}) RETURN s.version AS version",
+		map[string]interface{}{"id": schemaVersionID},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for _, record := range recordsToMaps(result) {
+		if version, ok := toInt(record["version"]); ok {
+			return version, nil
+		}
+	}
+	return 0, nil
+}
+
+// Check returns ErrSchemaTooNew if the database was migrated by a newer
+// release, without applying pending migrations.
+func (r *Runner) Check(ctx context.Context) error {
+	_, err := r.checkedVersion(ctx)
+	return err
+}
+
+// Migrate applies all pending migrations in order and records the resulting version.
+// It returns ErrSchemaTooNew if the database was migrated by a newer release.
+func (r *Runner) Migrate(ctx context.Context) error {
+	current, err := r.checkedVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range r.migrations {
+		if migration.Version <= current {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		for _, stmt := range migration.Statements {
+			if _, _, _, err := r.exec.ExecuteQuery(stmt, nil); err != nil {
+				if migration.IgnoreError != nil && migration.IgnoreError(err) {
+					continue
+				}
+				return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+			}
+		}
+
+		if err := r.setVersion(migration.Version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkedVersion returns the schema version recorded in the database, or
+// ErrSchemaTooNew if it is newer than the latest migration.
+func (r *Runner) checkedVersion(ctx context.Context) (int, error) {
+	current, err := r.CurrentVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	latest := 0
+	if len(r.migrations) > 0 {
+		latest = r.migrations[len(r.migrations)-1].Version
+	}
+	if current > latest {
+		return 0, fmt.Errorf("%w: database is at version %d, %s supports up to %d", ErrSchemaTooNew, current, r.provider, latest)
+	}
+	return current, nil
+}
+
+// bootstrap creates the schema version storage if the provider needs it.
+func (r *Runner) bootstrap(ctx context.Context) error {
+	for _, stmt := range bootstrapStatements[r.provider] {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, _, _, err := r.exec.ExecuteQuery(stmt, nil); err != nil && !isAlreadyExists(err) {
+			return fmt.Errorf("failed to create schema version table: %w", err)
+		}
+	}
+	return nil
+}
+
+// setVersion records the applied schema version in the graph.
+func (r *Runner) setVersion(version int) error {
+	_, _, _, err := r.exec.ExecuteQuery(`
+		MERGE (s:SchemaVersion {id: $id})
+		SET s.version = $version, s.applied_at = $applied_at
+	`, map[string]interface{}{
+		"id":         schemaVersionID,
+		"version":    int64(version),
+		"applied_at": time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record schema version %d: %w", version, err)
+	}
+	return nil
+}
+
+// recordsToMaps normalizes the records returned by the different drivers:
+// Ladybug returns []map[string]interface{}, the Bolt drivers return a slice of
+// records exposing AsMap.
+func recordsToMaps(result interface{}) []map[string]interface{} {
+	if records, ok := result.([]map[string]interface{}); ok {
+		return records
+	}
+
+	value := reflect.ValueOf(result)
+	if !value.IsValid() || value.Kind() != reflect.Slice {
+		return nil
+	}
+
+	maps := make([]map[string]interface{}, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		if m := recordToMap(value.Index(i).Interface()); m != nil {
+			maps = append(maps, m)
+		}
+	}
+	return maps
+}
+
+func recordToMap(record interface{}) map[string]interface{} {
+	switch r := record.(type) {
+	case map[string]interface{}:
+		return r
+	case interface{ AsMap() map[string]interface{} }:
+		return r.AsMap()
+	}
+	return nil
+}
+
+// isAlreadyExists reports whether err indicates that a schema object already exists.
+func isAlreadyExists(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already exists") || strings.Contains(msg, "an equivalent")
+}
+
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,129 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeExecutor records executed statements and stores the schema version in memory.
+type fakeExecutor struct {
+	version    int64
+	hasVersion bool
+	statements []string
+	failOn     string
+}
+
+func (f *fakeExecutor) ExecuteQuery(query string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	f.statements = append(f.statements, query)
+
+	if f.failOn != "" && strings.Contains(query, f.failOn) {
+		return nil, nil, nil, errors.New("boom")
+	}
+
+	switch {
+	case strings.Contains(query, "RETURN s.version"):
+		if !f.hasVersion {
+			return []map[string]interface{}{}, nil, nil, nil
+		}
+		return []map[string]interface{}{{"version": f.version}}, nil, nil, nil
+	case strings.Contains(query, "MERGE (s:SchemaVersion"):
+		f.version = kwargs["version"].(int64)
+		f.hasVersion = true
+	}
+	return []map[string]interface{}{}, nil, nil, nil
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	exec := &fakeExecutor{}
+	runner := NewRunner(exec, ProviderNeo4j)
+
+	if err := runner.Migrate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if int(exec.version) != LatestVersion(ProviderNeo4j) {
+		t.Errorf("expected version %d, got %d", LatestVersion(ProviderNeo4j), exec.version)
+	}
+
+	version, err := runner.CurrentVersion(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != LatestVersion(ProviderNeo4j) {
+		t.Errorf("expected current version %d, got %d", LatestVersion(ProviderNeo4j), version)
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	exec := &fakeExecutor{}
+	runner := NewRunner(exec, ProviderMemgraph)
+
+	if err := runner.Migrate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exec.statements = nil
+
+	if err := runner.Migrate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, stmt := range exec.statements {
+		if strings.Contains(stmt, "CREATE CONSTRAINT") {
+			t.Errorf("expected no migration statements on second run, got %q", stmt)
+		}
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	exec := &fakeExecutor{version: int64(LatestVersion(ProviderLadybug) + 1), hasVersion: true}
+	runner := NewRunner(exec, ProviderLadybug)
+
+	err := runner.Migrate(context.Background())
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("expected ErrSchemaTooNew, got %v", err)
+	}
+}
+
+func TestCheckDoesNotMigrate(t *testing.T) {
+	exec := &fakeExecutor{}
+	runner := NewRunner(exec, ProviderNeo4j)
+
+	if err := runner.Check(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, stmt := range exec.statements {
+		if strings.Contains(stmt, "CREATE CONSTRAINT") {
+			t.Errorf("expected Check not to apply migrations, got %q", stmt)
+		}
+	}
+
+	exec = &fakeExecutor{version: int64(LatestVersion(ProviderNeo4j) + 1), hasVersion: true}
+	if err := NewRunner(exec, ProviderNeo4j).Check(context.Background()); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("expected ErrSchemaTooNew, got %v", err)
+	}
+}
+
+func TestMigrateStopsOnFailure(t *testing.T) {
+	exec := &fakeExecutor{failOn: "CREATE CONSTRAINT"}
+	runner := NewRunner(exec, ProviderNeo4j)
+
+	if err := runner.Migrate(context.Background()); err == nil {
+		t.Fatal("expected migration error")
+	}
+	if exec.hasVersion {
+		t.Error("expected schema version not to be recorded after a failed migration")
+	}
+}
+
+func TestLadybugBootstrapsVersionTable(t *testing.T) {
+	exec := &fakeExecutor{}
+	runner := NewRunner(exec, ProviderLadybug)
+
+	if _, err := runner.CurrentVersion(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(exec.statements) == 0 || !strings.Contains(exec.statements[0], "CREATE NODE TABLE IF NOT EXISTS SchemaVersion") {
+		t.Errorf("expected SchemaVersion table to be created first, got %v", exec.statements)
+	}
+}
@@ -3,17 +3,20 @@ package driver
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+	"github.com/soundprediction/go-predicato/pkg/driver/migrations"
 	"github.com/soundprediction/go-predicato/pkg/types"
 )
 
@@ -21,11 +24,31 @@ import (
 type Neo4jDriver struct {
 	client   neo4j.DriverWithContext
 	database string
+	options  *Options
+
+	// groupDatabases routes group IDs to their own database (database-per-tenant)
+	groupDatabases map[string]string
+	groupMu        sync.RWMutex
+
+	// vector caches the vector index capabilities probed from the server
+	vector       vectorIndexSupport
+	vectorProbed bool
+	vectorMu     sync.Mutex
 }
 
-// NewNeo4jDriver creates a new Neo4j driver instance.
+// NewNeo4jDriver creates a new Neo4j driver instance with default Options.
 func NewNeo4jDriver(uri, username, password, database string) (*Neo4jDriver, error) {
-	driver, err := neo4j.NewDriverWithContext(uri, neo4j.BasicAuth(username, password, ""))
+	return NewNeo4jDriverWithOptions(uri, username, password, database, nil)
+}
+
+// NewNeo4jDriverWithOptions creates a new Neo4j driver instance with the given
+// connection pooling and retry options. A nil opts uses DefaultOptions. It
+// returns migrations.ErrSchemaTooNew if a reachable server holds a schema
+// written by a newer release.
+func NewNeo4jDriverWithOptions(uri, username, password, database string, opts *Options) (*Neo4jDriver, error) {
+	opts = opts.withDefaults()
+
+	driver, err := neo4j.NewDriverWithContext(uri, neo4j.BasicAuth(username, password, ""), opts.configure)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create neo4j driver: %w", err)
 	}
@@ -34,20 +57,60 @@ func NewNeo4jDriver(uri, username, password, database string) (*Neo4jDriver, err
 		database = "neo4j"
 	}
 
-	return &Neo4jDriver{
-		client:   driver,
-		database: database,
-	}, nil
+	n := &Neo4jDriver{
+		client:         driver,
+		database:       database,
+		options:        opts,
+		groupDatabases: make(map[string]string),
+	}
+	for groupID, groupDatabase := range opts.GroupDatabases {
+		if err := n.SetGroupDatabase(groupID, groupDatabase); err != nil {
+			driver.Close(context.Background())
+			return nil, err
+		}
+	}
+
+	// Refuse databases written by newer releases, as Ladybug does on open
+	if err := n.checkSchemaVersion(); err != nil {
+		driver.Close(context.Background())
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// checkSchemaVersion returns migrations.ErrSchemaTooNew if a database of the
+// driver was migrated by a newer release. An unreachable server is only
+// logged, leaving the check to CreateIndexes.
+func (n *Neo4jDriver) checkSchemaVersion() error {
+	ctx, cancel := context.WithTimeout(context.Background(), schemaCheckTimeout)
+	defer cancel()
+	if err := n.client.VerifyConnectivity(ctx); err != nil {
+		log.Printf("Warning: skipping neo4j schema version check: %v", err)
+		return nil
+	}
+
+	for _, database := range n.Databases() {
+		err := migrations.NewRunner(n.databaseExecutor(database), migrations.ProviderNeo4j).Check(ctx)
+		if errors.Is(err, migrations.ErrSchemaTooNew) {
+			return fmt.Errorf("failed to check schema of database %s: %w", database, err)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to check schema version of database %s: %v", database, err)
+		}
+	}
+	return nil
 }
 
 // GetNode retrieves a node by ID.
 func (n *Neo4jDriver) GetNode(ctx context.Context, nodeID, groupID string) (*types.Node, error) {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (n {uuid: $nodeID, group_id: $groupID})
+			WHERE n.deleted_at IS NULL
 			RETURN n
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -61,7 +124,7 @@ func (n *Neo4jDriver) GetNode(ctx context.Context, nodeID, groupID string) (*typ
 		record, err := res.Single(ctx)
 		if err != nil {
 			if err.Error() == "Result contains no more records" {
-				return nil, fmt.Errorf("node not found")
+				return nil, ErrNodeNotFound
 			}
 			return nil, err
 		}
@@ -75,7 +138,7 @@ func (n *Neo4jDriver) GetNode(ctx context.Context, nodeID, groupID string) (*typ
 	record := result.(*db.Record)
 	nodeValue, found := record.Get("n")
 	if !found {
-		return nil, fmt.Errorf("node not found")
+		return nil, ErrNodeNotFound
 	}
 
 	node := nodeValue.(dbtype.Node)
@@ -89,7 +152,7 @@ func (n *Neo4jDriver) NodeExists(ctx context.Context, node *types.Node) bool {
 		return false
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(node.GroupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -146,48 +209,18 @@ func (n *Neo4jDriver) UpsertNode(ctx context.Context, node *types.Node) error {
 		node.ValidFrom = node.CreatedAt
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
-	defer session.Close(ctx)
-
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		// Get base label for node type
-		baseLabel := n.getLabelForNodeType(node.Type)
-
-		// Build query with dynamic label support for Entity nodes
-		var query string
-		if node.Type == types.EntityNodeType && node.EntityType != "" {
-			// For Entity nodes with an EntityType, set both Entity label and specific type label
-			query = fmt.Sprintf(`
-				MERGE (n:%s {uuid: $uuid, group_id: $group_id})
-				SET n:%s
-				SET n += $properties
-				SET n.updated_at = $updated_at
-			`, baseLabel, node.EntityType)
-		} else {
-			// For other node types or entities without type, use base label only
-			query = fmt.Sprintf(`
-				MERGE (n:%s {uuid: $uuid, group_id: $group_id})
-				SET n += $properties
-				SET n.updated_at = $updated_at
-			`, baseLabel)
-		}
-
-		properties := n.nodeToProperties(node)
-		_, err := tx.Run(ctx, query, map[string]any{
-			"uuid":       node.Uuid,
-			"group_id":   node.GroupID,
-			"properties": properties,
-			"updated_at": time.Now().Format(time.RFC3339),
-		})
-		return nil, err
-	})
-
-	return err
+	statement := boltUpsertNodeStatement(n.getLabelForNodeType(node.Type), node, n.nodeToProperties(node))
+	return runBoltStatements(ctx, n.client, n.databaseForGroup(node.GroupID), []graphStatement{statement})
 }
 
-// DeleteNode removes a node and its edges.
+// DeleteNode removes a node and its edges. With soft deletes enabled the node
+// and its edges are marked with deleted_at instead.
 func (n *Neo4jDriver) DeleteNode(ctx context.Context, nodeID, groupID string) error {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	if n.options.SoftDelete {
+		return runBoltWrites(ctx, n.client, n.databaseForGroup(groupID), []string{boltSoftDeleteNodeEdgesQuery, boltSoftDeleteNodeQuery}, softDeleteParams(nodeID, groupID))
+	}
+
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -211,13 +244,13 @@ func (n *Neo4jDriver) GetNodes(ctx context.Context, nodeIDs []string, groupID st
 		return []*types.Node{}, nil
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (n {group_id: $groupID})
-			WHERE n.uuid IN $nodeIDs
+			WHERE n.uuid IN $nodeIDs AND n.deleted_at IS NULL
 			RETURN n
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -252,12 +285,13 @@ func (n *Neo4jDriver) GetNodes(ctx context.Context, nodeIDs []string, groupID st
 
 // GetEdge retrieves an edge by ID.
 func (n *Neo4jDriver) GetEdge(ctx context.Context, edgeID, groupID string) (*types.Edge, error) {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (s)-[r {uuid: $edgeID, group_id: $groupID}]->(t)
+			WHERE r.deleted_at IS NULL
 			RETURN r, s.uuid as source_id, t.uuid as target_id
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -271,7 +305,7 @@ func (n *Neo4jDriver) GetEdge(ctx context.Context, edgeID, groupID string) (*typ
 		record, err := res.Single(ctx)
 		if err != nil {
 			if err.Error() == "Result contains no more records" {
-				return nil, fmt.Errorf("edge not found")
+				return nil, ErrEdgeNotFound
 			}
 			return nil, err
 		}
@@ -285,7 +319,7 @@ func (n *Neo4jDriver) GetEdge(ctx context.Context, edgeID, groupID string) (*typ
 	record := result.(*db.Record)
 	relationValue, found := record.Get("r")
 	if !found {
-		return nil, fmt.Errorf("edge not found")
+		return nil, ErrEdgeNotFound
 	}
 
 	relation := relationValue.(dbtype.Relationship)
@@ -302,7 +336,7 @@ func (n *Neo4jDriver) EdgeExists(ctx context.Context, edge *types.Edge) bool {
 		return false
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(edge.GroupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -344,39 +378,14 @@ func (n *Neo4jDriver) UpsertEdge(ctx context.Context, edge *types.Edge) error {
 		edge.ValidFrom = edge.CreatedAt
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
-	defer session.Close(ctx)
-
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		query := `
-			MATCH (s {uuid: $source_id, group_id: $group_id})
-			MATCH (t {uuid: $target_id, group_id: $group_id})
-			MERGE (s)-[r:RELATES_TO {uuid: $uuid, group_id: $group_id}]->(t)
-			SET r += $properties
-			SET r.updated_at = $updated_at
-		`
-
-		properties := n.edgeToProperties(edge)
-		_, err := tx.Run(ctx, query, map[string]any{
-			"uuid":       edge.Uuid,
-			"source_id":  edge.SourceID,
-			"target_id":  edge.TargetID,
-			"group_id":   edge.GroupID,
-			"fact":       edge.Fact,
-			"name":       edge.Name,
-			"properties": properties,
-			"updated_at": time.Now().Format(time.RFC3339),
-		})
-		return nil, err
-	})
-
-	return err
+	statement := boltUpsertEdgeStatement(edge, n.edgeToProperties(edge))
+	return runBoltStatements(ctx, n.client, n.databaseForGroup(edge.GroupID), []graphStatement{statement})
 }
 
 // UpsertEpisodicEdge creates or updates a MENTIONS relationship between an Episodic node and an Entity node.
 // This matches Python's EpisodicEdge.save() method.
 func (n *Neo4jDriver) UpsertEpisodicEdge(ctx context.Context, episodeUUID, entityUUID, groupID string) error {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -406,7 +415,7 @@ func (n *Neo4jDriver) UpsertEpisodicEdge(ctx context.Context, episodeUUID, entit
 // UpsertCommunityEdge creates or updates a HAS_MEMBER relationship between a Community node and an Entity or Community node.
 // This matches Python's CommunityEdge.save() method.
 func (n *Neo4jDriver) UpsertCommunityEdge(ctx context.Context, communityUUID, nodeUUID, uuid, groupID string) error {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -436,9 +445,14 @@ func (n *Neo4jDriver) UpsertCommunityEdge(ctx context.Context, communityUUID, no
 	return nil
 }
 
-// DeleteEdge removes an edge.
+// DeleteEdge removes an edge. With soft deletes enabled the edge is marked with
+// deleted_at instead.
 func (n *Neo4jDriver) DeleteEdge(ctx context.Context, edgeID, groupID string) error {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	if n.options.SoftDelete {
+		return runBoltWrites(ctx, n.client, n.databaseForGroup(groupID), []string{boltSoftDeleteEdgeQuery}, softDeleteParams(edgeID, groupID))
+	}
+
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -462,13 +476,13 @@ func (n *Neo4jDriver) GetEdges(ctx context.Context, edgeIDs []string, groupID st
 		return []*types.Edge{}, nil
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (s)-[r {group_id: $groupID}]->(t)
-			WHERE r.uuid IN $edgeIDs
+			WHERE r.uuid IN $edgeIDs AND r.deleted_at IS NULL
 			RETURN r, s.uuid as source_id, t.uuid as target_id
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -506,7 +520,7 @@ func (n *Neo4jDriver) GetEdges(ctx context.Context, edgeIDs []string, groupID st
 
 // GetNeighbors retrieves neighboring nodes within a specified distance
 func (n *Neo4jDriver) GetNeighbors(ctx context.Context, nodeID, groupID string, maxDistance int) ([]*types.Node, error) {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -547,43 +561,30 @@ func (n *Neo4jDriver) GetNeighbors(ctx context.Context, nodeID, groupID string,
 	return nodes, nil
 }
 
+// GetRelatedNodes retrieves the direct neighbors of a node, optionally restricted
+// to edge types or relation names.
 func (n *Neo4jDriver) GetRelatedNodes(ctx context.Context, nodeID, groupID string, edgeTypes []types.EdgeType) ([]*types.Node, error) {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		var query string
 		params := map[string]any{
 			"nodeID":  nodeID,
 			"groupID": groupID,
 		}
-
-		if len(edgeTypes) == 0 {
-			// Get all related nodes regardless of edge type
-			query = `
-				MATCH (start {uuid: $nodeID, group_id: $groupID})
-				MATCH (start)-[r]-(related)
-				WHERE related.group_id = $groupID AND related.id <> $nodeID
-				RETURN DISTINCT related
-			`
-		} else {
-			// Filter by specific edge types
-			edgeTypeStrings := make([]string, len(edgeTypes))
-			for i, edgeType := range edgeTypes {
-				edgeTypeStrings[i] = string(edgeType)
-			}
-			params["edgeTypes"] = edgeTypeStrings
-
-			query = `
-				MATCH (start {uuid: $nodeID, group_id: $groupID})
-				MATCH (start)-[r]-(related)
-				WHERE related.group_id = $groupID
-				  AND related.id <> $nodeID
-				  AND r.type IN $edgeTypes
-				RETURN DISTINCT related
-			`
+		condition, ok := newRelatedEdgeFilter(edgeTypes).boltCondition("r", params)
+		if !ok {
+			return []*db.Record{}, nil
 		}
 
+		query := `
+			MATCH (start {uuid: $nodeID, group_id: $groupID})-[r]-(related)
+			WHERE related.group_id = $groupID
+			  AND related.uuid <> $nodeID
+			  AND ` + condition + `
+			RETURN DISTINCT related
+		`
+
 		res, err := tx.Run(ctx, query, params)
 		if err != nil {
 			return nil, err
@@ -611,24 +612,37 @@ func (n *Neo4jDriver) GetRelatedNodes(ctx context.Context, nodeID, groupID strin
 	return nodes, nil
 }
 
+// SearchNodesByEmbedding returns the nodes most similar to embedding. It uses the
+// vector indexes on Neo4j 5.11+ when Options.VectorDimensions is set and otherwise
+// computes cosine similarity over every embedded node of the group.
 func (n *Neo4jDriver) SearchNodesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int) ([]*types.Node, error) {
+	return n.searchNodesByEmbedding(ctx, embedding, groupID, limit, groupSearchFilter(groupID))
+}
+
+// searchNodesByEmbedding implements SearchNodesByEmbedding for the nodes
+// matching filter.
+func (n *Neo4jDriver) searchNodesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int, filter *searchFilter) ([]*types.Node, error) {
 	if len(embedding) == 0 {
 		return []*types.Node{}, nil
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	if nodes, ok := n.searchNodesByVectorIndex(ctx, embedding, groupID, limit, filter); ok {
+		return nodes, nil
+	}
+
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	// Get all nodes with embeddings and compute similarity in-memory
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		params := map[string]any{}
 		query := `
-			MATCH (n {group_id: $groupID})
-			WHERE n.embedding IS NOT NULL
+			MATCH (n)
+			WHERE ` + filter.boltNodeConditions("n", params) + `
+			  AND n.embedding IS NOT NULL AND n.deleted_at IS NULL
 			RETURN n
 		`
-		res, err := tx.Run(ctx, query, map[string]any{
-			"groupID": groupID,
-		})
+		res, err := tx.Run(ctx, query, params)
 		if err != nil {
 			return nil, err
 		}
@@ -688,24 +702,37 @@ func (n *Neo4jDriver) SearchNodesByEmbedding(ctx context.Context, embedding []fl
 	return nodes, nil
 }
 
+// SearchEdgesByEmbedding returns the RELATES_TO edges most similar to embedding
+// using the relationship vector index on Neo4j 5.18+ when Options.VectorDimensions
+// is set, and otherwise computes cosine similarity over every embedded edge.
 func (n *Neo4jDriver) SearchEdgesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int) ([]*types.Edge, error) {
+	return n.searchEdgesByEmbedding(ctx, embedding, groupID, limit, groupSearchFilter(groupID))
+}
+
+// searchEdgesByEmbedding implements SearchEdgesByEmbedding for the edges
+// matching filter.
+func (n *Neo4jDriver) searchEdgesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int, filter *searchFilter) ([]*types.Edge, error) {
 	if len(embedding) == 0 {
 		return []*types.Edge{}, nil
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	if edges, ok := n.searchEdgesByVectorIndex(ctx, embedding, groupID, limit, filter); ok {
+		return edges, nil
+	}
+
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	// Get all edges with embeddings and compute similarity in-memory
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		params := map[string]any{}
 		query := `
-			MATCH (s)-[r {group_id: $groupID}]->(t)
-			WHERE r.embedding IS NOT NULL
+			MATCH (s)-[r]->(t)
+			WHERE ` + filter.boltEdgeConditions("r", params) + `
+			  AND r.embedding IS NOT NULL AND r.deleted_at IS NULL
 			RETURN r, s.uuid as source_id, t.uuid as target_id
 		`
-		res, err := tx.Run(ctx, query, map[string]any{
-			"groupID": groupID,
-		})
+		res, err := tx.Run(ctx, query, params)
 		if err != nil {
 			return nil, err
 		}
@@ -772,7 +799,24 @@ func (n *Neo4jDriver) UpsertNodes(ctx context.Context, nodes []*types.Node) erro
 		return nil
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	// Nodes of different groups may live in different databases
+	batches := make(map[string][]*types.Node)
+	for _, node := range nodes {
+		database := n.databaseForGroup(node.GroupID)
+		batches[database] = append(batches[database], node)
+	}
+	for database, batch := range batches {
+		if err := n.upsertNodesInDatabase(ctx, database, batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// upsertNodesInDatabase bulk upserts nodes that all belong to the same database.
+func (n *Neo4jDriver) upsertNodesInDatabase(ctx context.Context, database string, nodes []*types.Node) error {
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: database})
 	defer session.Close(ctx)
 
 	// Use UNWIND for efficient bulk operations matching Python's approach
@@ -837,7 +881,24 @@ func (n *Neo4jDriver) UpsertEdges(ctx context.Context, edges []*types.Edge) erro
 		return nil
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	// Edges of different groups may live in different databases
+	batches := make(map[string][]*types.Edge)
+	for _, edge := range edges {
+		database := n.databaseForGroup(edge.GroupID)
+		batches[database] = append(batches[database], edge)
+	}
+	for database, batch := range batches {
+		if err := n.upsertEdgesInDatabase(ctx, database, batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// upsertEdgesInDatabase bulk upserts edges that all belong to the same database.
+func (n *Neo4jDriver) upsertEdgesInDatabase(ctx context.Context, database string, edges []*types.Edge) error {
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: database})
 	defer session.Close(ctx)
 
 	// Use UNWIND for efficient bulk operations matching Python's approach
@@ -881,13 +942,13 @@ func (n *Neo4jDriver) UpsertEdges(ctx context.Context, edges []*types.Edge) erro
 }
 
 func (n *Neo4jDriver) GetNodesInTimeRange(ctx context.Context, start, end time.Time, groupID string) ([]*types.Node, error) {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (n {group_id: $groupID})
-			WHERE n.created_at >= $start AND n.created_at <= $end
+			WHERE n.created_at >= $start AND n.created_at <= $end AND n.deleted_at IS NULL
 			RETURN n
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -922,13 +983,13 @@ func (n *Neo4jDriver) GetNodesInTimeRange(ctx context.Context, start, end time.T
 }
 
 func (n *Neo4jDriver) GetEdgesInTimeRange(ctx context.Context, start, end time.Time, groupID string) ([]*types.Edge, error) {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (s)-[r {group_id: $groupID}]->(t)
-			WHERE r.created_at >= $start AND r.created_at <= $end
+			WHERE r.created_at >= $start AND r.created_at <= $end AND r.deleted_at IS NULL
 			RETURN r, s.uuid as source_id, t.uuid as target_id
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -978,7 +1039,40 @@ func (n *Neo4jDriver) RetrieveEpisodes(
 		limit = 10
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	databases := n.databasesForGroups(groupIDs)
+	if len(databases) == 1 {
+		return n.retrieveEpisodesFromDatabase(ctx, databases[0], referenceTime, groupIDs, limit, episodeType)
+	}
+
+	// Group IDs spread over several databases: merge and keep the most recent episodes
+	var episodes []*types.Node
+	for _, database := range databases {
+		dbEpisodes, err := n.retrieveEpisodesFromDatabase(ctx, database, referenceTime, groupIDs, limit, episodeType)
+		if err != nil {
+			return nil, err
+		}
+		episodes = append(episodes, dbEpisodes...)
+	}
+	sort.SliceStable(episodes, func(i, j int) bool {
+		return episodes[i].ValidFrom.Before(episodes[j].ValidFrom)
+	})
+	if len(episodes) > limit {
+		episodes = episodes[len(episodes)-limit:]
+	}
+
+	return episodes, nil
+}
+
+// retrieveEpisodesFromDatabase retrieves episodes from a single database in chronological order.
+func (n *Neo4jDriver) retrieveEpisodesFromDatabase(
+	ctx context.Context,
+	database string,
+	referenceTime time.Time,
+	groupIDs []string,
+	limit int,
+	episodeType *types.EpisodeType,
+) ([]*types.Node, error) {
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: database})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -1047,7 +1141,7 @@ func (n *Neo4jDriver) RetrieveEpisodes(
 
 func (n *Neo4jDriver) GetCommunities(ctx context.Context, groupID string, level int) ([]*types.Node, error) {
 	// For basic implementation, return nodes grouped by a hypothetical community property
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -1088,7 +1182,7 @@ func (n *Neo4jDriver) GetCommunities(ctx context.Context, groupID string, level
 
 func (n *Neo4jDriver) BuildCommunities(ctx context.Context, groupID string) error {
 	// Basic implementation that assigns community IDs based on connected components
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -1117,7 +1211,7 @@ func (n *Neo4jDriver) BuildCommunities(ctx context.Context, groupID string) erro
 	return err
 }
 
-func (n *Neo4jDriver) GetExistingCommunity(ctx context.Context, entityUUID string) (*types.Node, error) {
+func (n *Neo4jDriver) GetExistingCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
 	query := `
 		MATCH (c:Community)-[:HAS_MEMBER]->(n:Entity {uuid: $entity_uuid})
 		RETURN c
@@ -1128,7 +1222,7 @@ func (n *Neo4jDriver) GetExistingCommunity(ctx context.Context, entityUUID strin
 		"entity_uuid": entityUUID,
 	}
 
-	result, _, _, err := n.ExecuteQuery(query, params)
+	result, _, _, err := n.ExecuteGroupQuery(groupID, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query existing community: %w", err)
 	}
@@ -1146,7 +1240,7 @@ func (n *Neo4jDriver) GetExistingCommunity(ctx context.Context, entityUUID strin
 	return nil, nil
 }
 
-func (n *Neo4jDriver) FindModalCommunity(ctx context.Context, entityUUID string) (*types.Node, error) {
+func (n *Neo4jDriver) FindModalCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
 	query := `
 		MATCH (c:Community)-[:HAS_MEMBER]->(m:Entity)-[:RELATES_TO]-(n:Entity {uuid: $entity_uuid})
 		WITH c, count(*) AS count
@@ -1159,7 +1253,7 @@ func (n *Neo4jDriver) FindModalCommunity(ctx context.Context, entityUUID string)
 		"entity_uuid": entityUUID,
 	}
 
-	result, _, _, err := n.ExecuteQuery(query, params)
+	result, _, _, err := n.ExecuteGroupQuery(groupID, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query modal community: %w", err)
 	}
@@ -1247,24 +1341,43 @@ func (n *Neo4jDriver) parseCommunityNodesFromRecords(result interface{}) ([]*typ
 // RemoveCommunities removes all community nodes and their relationships from the graph.
 // Neo4j-specific implementation using DETACH DELETE.
 func (n *Neo4jDriver) RemoveCommunities(ctx context.Context) error {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
-	defer session.Close(ctx)
+	for _, database := range n.Databases() {
+		session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: database})
 
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		query := "MATCH (c:Community) DETACH DELETE c"
-		_, err := tx.Run(ctx, query, nil)
-		return nil, err
-	})
+		_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			query := "MATCH (c:Community) DETACH DELETE c"
+			_, err := tx.Run(ctx, query, nil)
+			return nil, err
+		})
+		session.Close(ctx)
 
-	if err != nil {
-		return fmt.Errorf("failed to remove communities: %w", err)
+		if err != nil {
+			return fmt.Errorf("failed to remove communities from database %s: %w", database, err)
+		}
 	}
 
 	return nil
 }
 
+// CreateIndices creates any missing per-group databases, then creates indices and
+// applies schema migrations in every database the driver routes to.
 func (n *Neo4jDriver) CreateIndices(ctx context.Context) error {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	if err := n.ensureDatabases(ctx); err != nil {
+		return err
+	}
+
+	for _, database := range n.Databases() {
+		if err := n.createIndicesInDatabase(ctx, database); err != nil {
+			return fmt.Errorf("failed to create indices in database %s: %w", database, err)
+		}
+	}
+
+	return nil
+}
+
+// createIndicesInDatabase creates indices and applies migrations in a single database.
+func (n *Neo4jDriver) createIndicesInDatabase(ctx context.Context, database string) error {
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: database})
 	defer session.Close(ctx)
 
 	// Create indices for commonly queried properties
@@ -1277,6 +1390,8 @@ func (n *Neo4jDriver) CreateIndices(ctx context.Context) error {
 		"CREATE INDEX episodic_created_at IF NOT EXISTS FOR (n:Episodic) ON (n.created_at)",
 		"CREATE INDEX community_created_at IF NOT EXISTS FOR (n:Community) ON (n.created_at)",
 	}
+	// Vector indexes need Neo4j 5.11+ and are skipped on older servers
+	indices = append(indices, n.vectorIndexStatements(n.vectorSupport(ctx, database))...)
 
 	for _, indexQuery := range indices {
 		_, err := session.Run(ctx, indexQuery, nil)
@@ -1287,18 +1402,43 @@ func (n *Neo4jDriver) CreateIndices(ctx context.Context) error {
 		}
 	}
 
+	// Apply pending schema migrations and refuse databases written by newer releases
+	if err := migrations.NewRunner(n.databaseExecutor(database), migrations.ProviderNeo4j).Migrate(ctx); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	return nil
 }
 
+// GetStats reports on groupID in the database holding it. An empty groupID
+// sums the stats of every database, so per-group databases are included.
 func (n *Neo4jDriver) GetStats(ctx context.Context, groupID string) (*GraphStats, error) {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	if groupID != "" {
+		return n.statsInDatabase(ctx, n.databaseForGroup(groupID), groupID)
+	}
+
+	stats := newGraphStats()
+	for _, database := range n.Databases() {
+		databaseStats, err := n.statsInDatabase(ctx, database, groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stats of database %s: %w", database, err)
+		}
+		stats.add(databaseStats)
+	}
+	return stats, nil
+}
+
+// statsInDatabase reports on groupID, or every group, in database.
+func (n *Neo4jDriver) statsInDatabase(ctx context.Context, database, groupID string) (*GraphStats, error) {
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: database})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		// Get node count by label (Entity, Episodic, Community)
 		// Note: In Neo4j, node types are labels, not properties
 		nodeQuery := `
-			MATCH (n {group_id: $groupID})
+			MATCH (n)
+			WHERE ` + boltStatsFilter("n") + `
 			UNWIND labels(n) AS label
 			WITH label, count(DISTINCT n) as node_count
 			WHERE label IN ['Entity', 'Episodic', 'Community']
@@ -1316,7 +1456,8 @@ func (n *Neo4jDriver) GetStats(ctx context.Context, groupID string) (*GraphStats
 
 		// Get total node count
 		totalNodeQuery := `
-			MATCH (n {group_id: $groupID})
+			MATCH (n)
+			WHERE ` + boltStatsFilter("n") + `
 			RETURN count(n) as total_nodes
 		`
 		totalNodeRes, err := tx.Run(ctx, totalNodeQuery, map[string]any{"groupID": groupID})
@@ -1330,7 +1471,8 @@ func (n *Neo4jDriver) GetStats(ctx context.Context, groupID string) (*GraphStats
 
 		// Get edge count by type
 		edgeQuery := `
-			MATCH ()-[r {group_id: $groupID}]-()
+			MATCH ()-[r]->()
+			WHERE ` + boltStatsFilter("r") + `
 			RETURN type(r) as edge_type, count(r) as edge_count
 			ORDER BY edge_type
 		`
@@ -1358,11 +1500,7 @@ func (n *Neo4jDriver) GetStats(ctx context.Context, groupID string) (*GraphStats
 	edgeRecords := data["edges"].([]*db.Record)
 	totalNodeRecord := data["total_nodes"].(*db.Record)
 
-	stats := &GraphStats{
-		NodesByType: make(map[string]int64),
-		EdgesByType: make(map[string]int64),
-		LastUpdated: time.Now(),
-	}
+	stats := newGraphStats()
 
 	// Get total node count
 	if totalNodes, found := totalNodeRecord.Get("total_nodes"); found {
@@ -1396,6 +1534,10 @@ func (n *Neo4jDriver) GetStats(ctx context.Context, groupID string) (*GraphStats
 		}
 	}
 
+	if err := collectBoltStatsBreakdown(ctx, session, groupID, stats); err != nil {
+		return nil, fmt.Errorf("failed to collect stats breakdown: %w", err)
+	}
+
 	return stats, nil
 }
 
@@ -1410,22 +1552,24 @@ func (n *Neo4jDriver) SearchNodes(ctx context.Context, query, groupID string, op
 		limit = options.Limit
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		// Basic text search using CONTAINS
+		params := map[string]any{
+			"query": query,
+			"limit": limit,
+		}
 		searchQuery := `
-			MATCH (n {group_id: $groupID})
-			WHERE n.name CONTAINS $query OR n.summary CONTAINS $query OR n.content CONTAINS $query
+			MATCH (n)
+			WHERE ` + options.filter(groupID).boltNodeConditions("n", params) + `
+			  AND (n.name CONTAINS $query OR n.summary CONTAINS $query OR n.content CONTAINS $query)
+			  AND n.deleted_at IS NULL
 			RETURN n
 			LIMIT $limit
 		`
-		res, err := tx.Run(ctx, searchQuery, map[string]any{
-			"groupID": groupID,
-			"query":   query,
-			"limit":   limit,
-		})
+		res, err := tx.Run(ctx, searchQuery, params)
 		if err != nil {
 			return nil, err
 		}
@@ -1463,22 +1607,24 @@ func (n *Neo4jDriver) SearchEdges(ctx context.Context, query, groupID string, op
 		limit = options.Limit
 	}
 
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		// Basic text search using CONTAINS
+		params := map[string]any{
+			"query": query,
+			"limit": limit,
+		}
 		searchQuery := `
-			MATCH (s)-[r {group_id: $groupID}]->(t)
-			WHERE r.name CONTAINS $query OR r.summary CONTAINS $query
+			MATCH (s)-[r]->(t)
+			WHERE ` + options.filter(groupID).boltEdgeConditions("r", params) + `
+			  AND (r.name CONTAINS $query OR r.summary CONTAINS $query)
+			  AND r.deleted_at IS NULL
 			RETURN r, s.uuid as source_id, t.uuid as target_id
 			LIMIT $limit
 		`
-		res, err := tx.Run(ctx, searchQuery, map[string]any{
-			"groupID": groupID,
-			"query":   query,
-			"limit":   limit,
-		})
+		res, err := tx.Run(ctx, searchQuery, params)
 		if err != nil {
 			return nil, err
 		}
@@ -1525,9 +1671,9 @@ func (n *Neo4jDriver) SearchNodesByVector(ctx context.Context, vector []float32,
 		}
 	}
 
-	// Use the existing SearchNodesByEmbedding method for compatibility
+	// Search the nodes matching the options filters
 	// Filter by minimum score if needed
-	nodes, err := n.SearchNodesByEmbedding(ctx, vector, groupID, limit)
+	nodes, err := n.searchNodesByEmbedding(ctx, vector, groupID, limit, options.filter(groupID))
 	if err != nil {
 		return nil, err
 	}
@@ -1549,6 +1695,68 @@ func (n *Neo4jDriver) SearchNodesByVector(ctx context.Context, vector []float32,
 	return nodes, nil
 }
 
+// SearchNodesByEmbeddings returns, for each embedding, the nodes most similar
+// to it. All embeddings are evaluated in one transaction, through the vector
+// indexes when they can be used and otherwise by scanning the embedded nodes
+// once.
+func (n *Neo4jDriver) SearchNodesByEmbeddings(ctx context.Context, embeddings [][]float32, groupID string, options *VectorSearchOptions) ([][]*types.Node, error) {
+	if len(embeddings) == 0 {
+		return [][]*types.Node{}, nil
+	}
+
+	limit, minScore := bulkSearchLimits(options)
+	filter := options.filter(groupID)
+	if results, ok := n.searchNodesByEmbeddingsIndex(ctx, embeddings, groupID, limit, minScore, filter); ok {
+		return results, nil
+	}
+
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		params := map[string]any{}
+		query := `
+			MATCH (n)
+			WHERE ` + filter.boltNodeConditions("n", params) + `
+			  AND n.embedding IS NOT NULL AND n.deleted_at IS NULL
+			RETURN n
+		`
+		res, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		return res.Collect(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nodes by embeddings: %w", err)
+	}
+
+	var candidates []*types.Node
+	var candidateEmbeddings [][]float32
+	for _, record := range result.([]*db.Record) {
+		nodeValue, found := record.Get("n")
+		if !found {
+			continue
+		}
+		dbNode := nodeValue.(dbtype.Node)
+
+		// Parse embedding from JSON
+		embeddingStr, ok := dbNode.Props["embedding"].(string)
+		if !ok {
+			continue
+		}
+		var nodeEmbedding []float32
+		if err := json.Unmarshal([]byte(embeddingStr), &nodeEmbedding); err != nil {
+			continue
+		}
+		candidates = append(candidates, n.nodeFromDBNode(dbNode))
+		candidateEmbeddings = append(candidateEmbeddings, nodeEmbedding)
+	}
+
+	hits := scoreCandidates(embeddings, candidates, candidateEmbeddings)
+	return groupScoredNodes(hits, len(embeddings), limit, minScore), nil
+}
+
 // SearchEdgesByVector performs vector similarity search on edges
 func (n *Neo4jDriver) SearchEdgesByVector(ctx context.Context, vector []float32, groupID string, options *VectorSearchOptions) ([]*types.Edge, error) {
 	if len(vector) == 0 {
@@ -1566,9 +1774,9 @@ func (n *Neo4jDriver) SearchEdgesByVector(ctx context.Context, vector []float32,
 		}
 	}
 
-	// Use the existing SearchEdgesByEmbedding method for compatibility
+	// Search the edges matching the options filters
 	// Filter by minimum score if needed
-	edges, err := n.SearchEdgesByEmbedding(ctx, vector, groupID, limit)
+	edges, err := n.searchEdgesByEmbedding(ctx, vector, groupID, limit, options.filter(groupID))
 	if err != nil {
 		return nil, err
 	}
@@ -1591,25 +1799,42 @@ func (n *Neo4jDriver) SearchEdgesByVector(ctx context.Context, vector []float32,
 }
 
 // ExecuteQuery executes a Cypher query and returns records, summary, and keys (matching Python interface).
+// Transient errors such as ServiceUnavailable or deadlocks are retried with exponential backoff
+// for read queries; writes are run once.
 func (n *Neo4jDriver) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
-	session := n.client.NewSession(context.Background(), neo4j.SessionConfig{DatabaseName: n.database})
-	defer session.Close(context.Background())
+	return n.executeQueryInDatabase(n.database, cypherQuery, kwargs)
+}
 
-	result, err := session.Run(context.Background(), cypherQuery, kwargs)
-	if err != nil {
-		return nil, nil, nil, err
-	}
+// executeQueryInDatabase executes a Cypher query against a specific database.
+func (n *Neo4jDriver) executeQueryInDatabase(database, cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	ctx := context.Background()
 
-	records, err := result.Collect(context.Background())
-	if err != nil {
-		return nil, nil, nil, err
-	}
+	var (
+		records []*neo4j.Record
+		summary neo4j.ResultSummary
+		keys    []string
+	)
+	err := n.options.withReadRetry(ctx, cypherQuery, func() error {
+		session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: database})
+		defer session.Close(ctx)
 
-	summary, err := result.Consume(context.Background())
-	if err != nil {
-		return nil, nil, nil, err
-	}
-	keys, err := result.Keys()
+		result, err := session.Run(ctx, cypherQuery, kwargs)
+		if err != nil {
+			return err
+		}
+
+		records, err = result.Collect(ctx)
+		if err != nil {
+			return err
+		}
+
+		summary, err = result.Consume(ctx)
+		if err != nil {
+			return err
+		}
+		keys, err = result.Keys()
+		return err
+	})
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -1676,6 +1901,34 @@ func (n *Neo4jDriver) VerifyConnectivity(ctx context.Context) error {
 	return n.client.VerifyConnectivity(ctx)
 }
 
+// HealthCheck verifies connectivity and runs a trivial query against every
+// database the driver routes to, per-group databases included.
+func (n *Neo4jDriver) HealthCheck(ctx context.Context) error {
+	if err := n.client.VerifyConnectivity(ctx); err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+
+	for _, database := range n.Databases() {
+		if err := n.pingDatabase(ctx, database); err != nil {
+			return fmt.Errorf("health check of database %s failed: %w", database, err)
+		}
+	}
+	return nil
+}
+
+// pingDatabase runs a trivial query against database.
+func (n *Neo4jDriver) pingDatabase(ctx context.Context, database string) error {
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: database})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, "RETURN 1 AS ok", nil)
+	if err != nil {
+		return err
+	}
+	_, err = result.Single(ctx)
+	return err
+}
+
 // Neo4jDriverSession implements GraphDriverSession for Neo4j.
 type Neo4jDriverSession struct {
 	driver   *Neo4jDriver
@@ -1716,8 +1969,10 @@ func (s *Neo4jDriverSession) Run(ctx context.Context, query interface{}, kwargs
 		return fmt.Errorf("query must be a string")
 	}
 
-	_, err := s.session.Run(ctx, queryStr, kwargs)
-	return err
+	return s.driver.options.withReadRetry(ctx, queryStr, func() error {
+		_, err := s.session.Run(ctx, queryStr, kwargs)
+		return err
+	})
 }
 
 // ExecuteWrite executes a write transaction.
@@ -1897,6 +2152,10 @@ func (n *Neo4jDriver) nodeToProperties(node *types.Node) map[string]any {
 		if embeddingJSON, err := json.Marshal(node.Embedding); err == nil {
 			props["embedding"] = string(embeddingJSON)
 		}
+		// Native list for the vector indexes
+		if vector := n.vectorProperty(node.Embedding); vector != nil {
+			props[boltVectorProperty] = vector
+		}
 	}
 
 	// Source tracking
@@ -2089,6 +2348,10 @@ func (n *Neo4jDriver) edgeToProperties(edge *types.Edge) map[string]any {
 		if embeddingJSON, err := json.Marshal(edge.Embedding); err == nil {
 			props["embedding"] = string(embeddingJSON)
 		}
+		// Native list for the vector index
+		if vector := n.vectorProperty(edge.Embedding); vector != nil {
+			props[boltVectorProperty] = vector
+		}
 	}
 
 	// Source tracking
@@ -2128,48 +2391,10 @@ func (n *Neo4jDriver) cosineSimilarity(a, b []float32) float32 {
 	return dotProduct / (float32(math.Sqrt(float64(normA))) * float32(math.Sqrt(float64(normB))))
 }
 
-func (k *Neo4jDriver) GetBetweenNodes(ctx context.Context, sourceNodeID, targetNodeID string) ([]*types.Edge, error) {
-	query := `
-		MATCH (a:Entity {uuid: $source_uuid})-[:RELATES_TO]->(rel:RelatesToNode_)-[:RELATES_TO]->(b:Entity {uuid: $target_uuid})
-		RETURN rel.uuid AS uuid, rel.name AS name, rel.fact AS fact, rel.group_id AS group_id,
-		       rel.created_at AS created_at, rel.valid_at AS valid_at, rel.invalid_at AS invalid_at,
-		       rel.expired_at AS expired_at, rel.episodes AS episodes, rel.attributes AS attributes,
-		       a.uuid AS source_id, b.uuid AS target_id
-		UNION
-		MATCH (a:Entity {uuid: $target_uuid})-[:RELATES_TO]->(rel:RelatesToNode_)-[:RELATES_TO]->(b:Entity {uuid: $source_uuid})
-		RETURN rel.uuid AS uuid, rel.name AS name, rel.fact AS fact, rel.group_id AS group_id,
-		       rel.created_at AS created_at, rel.valid_at AS valid_at, rel.invalid_at AS invalid_at,
-		       rel.expired_at AS expired_at, rel.episodes AS episodes, rel.attributes AS attributes,
-		       a.uuid AS source_id, b.uuid AS target_id
-	`
-
-	params := map[string]interface{}{
-		"source_uuid": sourceNodeID,
-		"target_uuid": targetNodeID,
-	}
-
-	result, _, _, err := k.ExecuteQuery(query, params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute GetBetweenNodes query: %w", err)
-	}
-
-	// Convert result to Edge objects
-	var edges []*types.Edge
-	recordSlice, ok := result.([]map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("unexpected query result type: %T", result)
-	}
-
-	for _, record := range recordSlice {
-		edge, err := convertRecordToEdge(record)
-		if err != nil {
-			log.Printf("Warning: failed to convert record to edge: %v", err)
-			continue
-		}
-		edges = append(edges, edge)
-	}
-
-	return edges, nil
+// GetBetweenNodes retrieves the entity edges of groupID between two entities
+// in either direction, from the database of the group.
+func (k *Neo4jDriver) GetBetweenNodes(ctx context.Context, sourceNodeID, targetNodeID, groupID string) ([]*types.Edge, error) {
+	return EdgesBetween(ctx, k, groupID, sourceNodeID, targetNodeID)
 }
 
 func (n *Neo4jDriver) GetNodeNeighbors(ctx context.Context, nodeUUID, groupID string) ([]types.Neighbor, error) {
@@ -2184,7 +2409,7 @@ func (n *Neo4jDriver) GetNodeNeighbors(ctx context.Context, nodeUUID, groupID st
 		"group_id": groupID,
 	}
 
-	result, _, _, err := n.ExecuteQuery(query, params)
+	result, _, _, err := n.executeQueryInDatabase(n.databaseForGroup(groupID), query, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute neighbor query: %w", err)
 	}
@@ -2305,12 +2530,13 @@ func (n *Neo4jDriver) ParseNodesFromRecords(result interface{}) ([]*types.Node,
 
 // getEntityNodesByGroupNeo4j gets entity nodes for Neo4j
 func (n *Neo4jDriver) GetEntityNodesByGroup(ctx context.Context, groupID string) ([]*types.Node, error) {
-	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.database})
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (n:Entity {group_id: $group_id})
+			WHERE n.deleted_at IS NULL
 			RETURN n
 		`
 		res, err := tx.Run(ctx, query, map[string]any{
@@ -2357,12 +2583,27 @@ func (n *Neo4jDriver) GetAllGroupIDs(ctx context.Context) ([]string, error) {
 		RETURN collect(DISTINCT n.group_id) AS group_ids
 	`
 
-	result, _, _, err := n.ExecuteQuery(query, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute group IDs query: %w", err)
+	seen := make(map[string]bool)
+	groupIDs := []string{}
+	for _, database := range n.Databases() {
+		result, _, _, err := n.executeQueryInDatabase(database, query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute group IDs query: %w", err)
+		}
+
+		dbGroupIDs, err := n.parseGroupIDsFromRecords(result)
+		if err != nil {
+			return nil, err
+		}
+		for _, groupID := range dbGroupIDs {
+			if !seen[groupID] {
+				seen[groupID] = true
+				groupIDs = append(groupIDs, groupID)
+			}
+		}
 	}
 
-	return n.parseGroupIDsFromRecords(result)
+	return groupIDs, nil
 }
 
 // parseGroupIDsFromRecords parses group IDs from Neo4j records
@@ -0,0 +1,140 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// neo4jDatabaseNamePattern matches valid Neo4j database names: 3-63 characters,
+// starting with a letter, made of lowercase letters, digits, dots and dashes.
+var neo4jDatabaseNamePattern = regexp.MustCompile(`^[a-z][a-z0-9.\-]{2,62}$`)
+
+// SetGroupDatabase routes all queries for groupID to the given database.
+// The database is created on the next call to CreateIndices if it does not exist.
+func (n *Neo4jDriver) SetGroupDatabase(groupID, database string) error {
+	database = strings.ToLower(database)
+	if !neo4jDatabaseNamePattern.MatchString(database) {
+		return fmt.Errorf("invalid neo4j database name %q for group %q", database, groupID)
+	}
+
+	n.groupMu.Lock()
+	defer n.groupMu.Unlock()
+	n.groupDatabases[groupID] = database
+	return nil
+}
+
+// RemoveGroupDatabase routes groupID back to the default database.
+// The dedicated database itself is not dropped.
+func (n *Neo4jDriver) RemoveGroupDatabase(groupID string) {
+	n.groupMu.Lock()
+	defer n.groupMu.Unlock()
+	delete(n.groupDatabases, groupID)
+}
+
+// GroupDatabases returns a copy of the group ID to database mapping table.
+func (n *Neo4jDriver) GroupDatabases() map[string]string {
+	n.groupMu.RLock()
+	defer n.groupMu.RUnlock()
+
+	mapping := make(map[string]string, len(n.groupDatabases))
+	for groupID, database := range n.groupDatabases {
+		mapping[groupID] = database
+	}
+	return mapping
+}
+
+// Databases returns every database the driver routes to, default database first.
+func (n *Neo4jDriver) Databases() []string {
+	n.groupMu.RLock()
+	defer n.groupMu.RUnlock()
+
+	seen := map[string]bool{n.database: true}
+	var extra []string
+	for _, database := range n.groupDatabases {
+		if !seen[database] {
+			seen[database] = true
+			extra = append(extra, database)
+		}
+	}
+	sort.Strings(extra)
+
+	return append([]string{n.database}, extra...)
+}
+
+// databaseForGroup returns the database holding the given group.
+func (n *Neo4jDriver) databaseForGroup(groupID string) string {
+	n.groupMu.RLock()
+	defer n.groupMu.RUnlock()
+
+	if database, ok := n.groupDatabases[groupID]; ok {
+		return database
+	}
+	return n.database
+}
+
+// databasesForGroups returns the distinct databases holding the given groups.
+// An empty list of groups means every database.
+func (n *Neo4jDriver) databasesForGroups(groupIDs []string) []string {
+	if len(groupIDs) == 0 {
+		return n.Databases()
+	}
+
+	seen := make(map[string]bool)
+	var databases []string
+	for _, groupID := range groupIDs {
+		database := n.databaseForGroup(groupID)
+		if !seen[database] {
+			seen[database] = true
+			databases = append(databases, database)
+		}
+	}
+	return databases
+}
+
+// ensureDatabases creates the per-group databases that do not exist yet.
+// Creating databases requires Neo4j Enterprise Edition.
+func (n *Neo4jDriver) ensureDatabases(ctx context.Context) error {
+	databases := n.Databases()
+	if len(databases) == 1 {
+		return nil
+	}
+
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: "system"})
+	defer session.Close(ctx)
+
+	for _, database := range databases[1:] {
+		query := fmt.Sprintf("CREATE DATABASE `%s` IF NOT EXISTS WAIT", database)
+		if _, err := session.Run(ctx, query, nil); err != nil {
+			return fmt.Errorf("failed to create database %s: %w", database, err)
+		}
+	}
+
+	return nil
+}
+
+// ExecuteGroupQuery executes a Cypher query against the database holding
+// groupID.
+func (n *Neo4jDriver) ExecuteGroupQuery(groupID, cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	return n.executeQueryInDatabase(n.databaseForGroup(groupID), cypherQuery, kwargs)
+}
+
+// neo4jDatabaseExecutor runs ExecuteQuery against a fixed database.
+type neo4jDatabaseExecutor struct {
+	driver   *Neo4jDriver
+	database string
+}
+
+// ExecuteQuery executes a Cypher query against the executor's database.
+func (e neo4jDatabaseExecutor) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	return e.driver.executeQueryInDatabase(e.database, cypherQuery, kwargs)
+}
+
+// databaseExecutor returns an executor bound to the given database.
+func (n *Neo4jDriver) databaseExecutor(database string) neo4jDatabaseExecutor {
+	return neo4jDatabaseExecutor{driver: n, database: database}
+}
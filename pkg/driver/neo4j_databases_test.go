@@ -0,0 +1,55 @@
+package driver
+
+import (
+	"testing"
+)
+
+func TestNeo4jGroupDatabaseRouting(t *testing.T) {
+	// The driver connects lazily, so no server is needed to test routing.
+	d, err := NewNeo4jDriverWithOptions("bolt://localhost:7687", "neo4j", "password", "neo4j", &Options{
+		GroupDatabases: map[string]string{"tenant-a": "tenanta"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create driver: %v", err)
+	}
+	defer d.Close()
+
+	if got := d.databaseForGroup("tenant-a"); got != "tenanta" {
+		t.Errorf("expected tenant-a to route to tenanta, got %s", got)
+	}
+	if got := d.databaseForGroup("unmapped"); got != "neo4j" {
+		t.Errorf("expected unmapped group to use default database, got %s", got)
+	}
+
+	if err := d.SetGroupDatabase("tenant-b", "Tenant-B"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := d.databaseForGroup("tenant-b"); got != "tenant-b" {
+		t.Errorf("expected database names to be lowercased, got %s", got)
+	}
+
+	databases := d.Databases()
+	expected := []string{"neo4j", "tenant-b", "tenanta"}
+	if len(databases) != len(expected) {
+		t.Fatalf("expected databases %v, got %v", expected, databases)
+	}
+	for i := range expected {
+		if databases[i] != expected[i] {
+			t.Errorf("expected databases %v, got %v", expected, databases)
+			break
+		}
+	}
+
+	if got := d.databasesForGroups([]string{"tenant-a", "other", "another"}); len(got) != 2 {
+		t.Errorf("expected 2 distinct databases, got %v", got)
+	}
+
+	d.RemoveGroupDatabase("tenant-a")
+	if got := d.databaseForGroup("tenant-a"); got != "neo4j" {
+		t.Errorf("expected removed group to use default database, got %s", got)
+	}
+
+	if err := d.SetGroupDatabase("bad", "no spaces allowed"); err == nil {
+		t.Error("expected invalid database name to be rejected")
+	}
+}
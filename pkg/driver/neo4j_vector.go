@@ -0,0 +1,285 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// vectorSupportForVersion returns the vector index features of a Neo4j version
+// such as "5.18.1" or "2025.01.0".
+func vectorSupportForVersion(version string) vectorIndexSupport {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return vectorIndexSupport{}
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return vectorIndexSupport{}
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return vectorIndexSupport{}
+	}
+
+	atLeast := func(wantMajor, wantMinor int) bool {
+		return major > wantMajor || (major == wantMajor && minor >= wantMinor)
+	}
+	return vectorIndexSupport{
+		nodes:         atLeast(5, 11),
+		relationships: atLeast(5, 18),
+	}
+}
+
+// vectorSupport probes the server version once, in database, and reports which
+// vector indexes can be used. The version is that of the server, so it is
+// shared by every database. Vector indexes are disabled when
+// Options.VectorDimensions is zero.
+func (n *Neo4jDriver) vectorSupport(ctx context.Context, database string) vectorIndexSupport {
+	if n.options.VectorDimensions <= 0 {
+		return vectorIndexSupport{}
+	}
+
+	n.vectorMu.Lock()
+	defer n.vectorMu.Unlock()
+	if n.vectorProbed {
+		return n.vector
+	}
+
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: database})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := tx.Run(ctx, `
+			CALL dbms.components() YIELD name, versions
+			WHERE name = 'Neo4j Kernel'
+			RETURN versions[0] AS version
+		`, nil)
+		if err != nil {
+			return nil, err
+		}
+		return res.Single(ctx)
+	})
+	if err != nil {
+		// Probe again next time; the server may just be unreachable
+		return vectorIndexSupport{}
+	}
+
+	version, _ := result.(*db.Record).AsMap()["version"].(string)
+	n.vector = vectorSupportForVersion(version)
+	n.vectorProbed = true
+	return n.vector
+}
+
+// vectorIndexStatements returns the statements creating the supported vector indexes.
+func (n *Neo4jDriver) vectorIndexStatements(support vectorIndexSupport) []string {
+	options := fmt.Sprintf("OPTIONS {indexConfig: {`vector.dimensions`: %d, `vector.similarity_function`: 'cosine'}}", n.options.VectorDimensions)
+
+	var statements []string
+	if support.nodes {
+		labels := make([]string, 0, len(boltNodeVectorIndexes))
+		for label := range boltNodeVectorIndexes {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			statements = append(statements, fmt.Sprintf(
+				"CREATE VECTOR INDEX %s IF NOT EXISTS FOR (n:%s) ON (n.%s) %s",
+				boltNodeVectorIndexes[label], label, boltVectorProperty, options))
+		}
+	}
+	if support.relationships {
+		statements = append(statements, fmt.Sprintf(
+			"CREATE VECTOR INDEX %s IF NOT EXISTS FOR ()-[r:RELATES_TO]-() ON (r.%s) %s",
+			boltEdgeVectorIndex, boltVectorProperty, options))
+	}
+	return statements
+}
+
+// vectorProperty returns embedding as the native list stored under
+// boltVectorProperty, or nil when vector indexes are disabled.
+func (n *Neo4jDriver) vectorProperty(embedding []float32) []float64 {
+	return boltVectorList(embedding, n.options.VectorDimensions)
+}
+
+// searchNodesByVectorIndex queries the node vector indexes. It reports false when
+// the indexes cannot be used and the caller should fall back to scanning.
+func (n *Neo4jDriver) searchNodesByVectorIndex(ctx context.Context, embedding []float32, groupID string, limit int, filter *searchFilter) ([]*types.Node, bool) {
+	if limit <= 0 || len(embedding) != n.options.VectorDimensions || !n.vectorSupport(ctx, n.databaseForGroup(groupID)).nodes {
+		return nil, false
+	}
+
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		var records []*db.Record
+		for _, index := range boltNodeVectorIndexes {
+			params := map[string]any{
+				"index":      index,
+				"candidates": limit * boltVectorOversample,
+				"embedding":  n.vectorProperty(embedding),
+				"limit":      limit,
+			}
+			conditions := filter.boltNodeConditions("n", params)
+			res, err := tx.Run(ctx, `
+				CALL db.index.vector.queryNodes($index, $candidates, $embedding)
+				YIELD node AS n, score
+				WHERE `+conditions+` AND n.deleted_at IS NULL
+				RETURN n, score
+				ORDER BY score DESC
+				LIMIT $limit
+			`, params)
+			if err != nil {
+				return nil, err
+			}
+			indexRecords, err := res.Collect(ctx)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, indexRecords...)
+		}
+		return records, nil
+	})
+	if err != nil {
+		log.Printf("Neo4j vector index search failed, falling back to scanning: %v", err)
+		return nil, false
+	}
+
+	records := result.([]*db.Record)
+	sortRecordsByScore(records)
+	if len(records) > limit {
+		records = records[:limit]
+	}
+
+	nodes := make([]*types.Node, 0, len(records))
+	for _, record := range records {
+		if node, ok := record.AsMap()["n"].(dbtype.Node); ok {
+			nodes = append(nodes, n.nodeFromDBNode(node))
+		}
+	}
+	return nodes, true
+}
+
+// searchEdgesByVectorIndex queries the relationship vector index. It reports false
+// when the index cannot be used and the caller should fall back to scanning.
+func (n *Neo4jDriver) searchEdgesByVectorIndex(ctx context.Context, embedding []float32, groupID string, limit int, filter *searchFilter) ([]*types.Edge, bool) {
+	if limit <= 0 || len(embedding) != n.options.VectorDimensions || !n.vectorSupport(ctx, n.databaseForGroup(groupID)).relationships {
+		return nil, false
+	}
+
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		params := map[string]any{
+			"index":      boltEdgeVectorIndex,
+			"candidates": limit * boltVectorOversample,
+			"embedding":  n.vectorProperty(embedding),
+			"limit":      limit,
+		}
+		conditions := filter.boltEdgeConditions("r", params)
+		res, err := tx.Run(ctx, `
+			CALL db.index.vector.queryRelationships($index, $candidates, $embedding)
+			YIELD relationship AS r, score
+			WHERE `+conditions+` AND r.deleted_at IS NULL
+			RETURN r, startNode(r).uuid AS source_id, endNode(r).uuid AS target_id, score
+			ORDER BY score DESC
+			LIMIT $limit
+		`, params)
+		if err != nil {
+			return nil, err
+		}
+		return res.Collect(ctx)
+	})
+	if err != nil {
+		log.Printf("Neo4j vector index search failed, falling back to scanning: %v", err)
+		return nil, false
+	}
+
+	records := result.([]*db.Record)
+	edges := make([]*types.Edge, 0, len(records))
+	for _, record := range records {
+		values := record.AsMap()
+		relation, ok := values["r"].(dbtype.Relationship)
+		if !ok {
+			continue
+		}
+		sourceID, _ := values["source_id"].(string)
+		targetID, _ := values["target_id"].(string)
+		edges = append(edges, n.edgeFromDBRelation(relation, sourceID, targetID))
+	}
+	return edges, true
+}
+
+// searchNodesByEmbeddingsIndex queries the node vector indexes for every
+// embedding in one transaction. It reports false when the indexes cannot be
+// used and the caller should fall back to scanning.
+func (n *Neo4jDriver) searchNodesByEmbeddingsIndex(ctx context.Context, embeddings [][]float32, groupID string, limit int, minScore float64, filter *searchFilter) ([][]*types.Node, bool) {
+	if limit <= 0 || !n.vectorSupport(ctx, n.databaseForGroup(groupID)).nodes {
+		return nil, false
+	}
+	vectors := make([][]float64, len(embeddings))
+	for i, embedding := range embeddings {
+		if vectors[i] = n.vectorProperty(embedding); vectors[i] == nil {
+			return nil, false
+		}
+	}
+
+	session := n.client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: n.databaseForGroup(groupID)})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		var records []*db.Record
+		for _, index := range boltNodeVectorIndexes {
+			params := map[string]any{
+				"index":      index,
+				"candidates": limit * boltVectorOversample,
+				"embeddings": vectors,
+			}
+			conditions := filter.boltNodeConditions("n", params)
+			res, err := tx.Run(ctx, `
+				UNWIND range(0, size($embeddings) - 1) AS query
+				CALL db.index.vector.queryNodes($index, $candidates, $embeddings[query])
+				YIELD node AS n, score
+				WHERE `+conditions+` AND n.deleted_at IS NULL
+				RETURN query, n, score
+			`, params)
+			if err != nil {
+				return nil, err
+			}
+			indexRecords, err := res.Collect(ctx)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, indexRecords...)
+		}
+		return records, nil
+	})
+	if err != nil {
+		log.Printf("Neo4j vector index search failed, falling back to scanning: %v", err)
+		return nil, false
+	}
+
+	records := result.([]*db.Record)
+	hits := make([]scoredNode, 0, len(records))
+	for _, record := range records {
+		values := record.AsMap()
+		node, ok := values["n"].(dbtype.Node)
+		if !ok {
+			continue
+		}
+		query, _ := values["query"].(int64)
+		score, _ := values["score"].(float64)
+		hits = append(hits, scoredNode{query: int(query), node: n.nodeFromDBNode(node), score: score})
+	}
+	return groupScoredNodes(hits, len(embeddings), limit, minScore), true
+}
@@ -0,0 +1,89 @@
+package driver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestVectorSupportForVersion(t *testing.T) {
+	tests := []struct {
+		version       string
+		nodes         bool
+		relationships bool
+	}{
+		{"4.4.30", false, false},
+		{"5.10.0", false, false},
+		{"5.11.0", true, false},
+		{"5.17.0", true, false},
+		{"5.18.1", true, true},
+		{"5.26.0-aura", true, true},
+		{"2025.01.0", true, true},
+		{"", false, false},
+		{"dev", false, false},
+	}
+
+	for _, tt := range tests {
+		got := vectorSupportForVersion(tt.version)
+		if got.nodes != tt.nodes || got.relationships != tt.relationships {
+			t.Errorf("version %q: expected nodes=%v relationships=%v, got %+v", tt.version, tt.nodes, tt.relationships, got)
+		}
+	}
+}
+
+func TestNeo4jVectorProperties(t *testing.T) {
+	// The driver connects lazily, so no server is needed to build properties.
+	d, err := NewNeo4jDriverWithOptions("bolt://localhost:7687", "neo4j", "password", "neo4j", &Options{
+		VectorDimensions: 3,
+	})
+	if err != nil {
+		t.Fatalf("failed to create driver: %v", err)
+	}
+	defer d.Close()
+
+	props := d.nodeToProperties(&types.Node{Uuid: "n1", Embedding: []float32{0.1, 0.2, 0.3}})
+	vector, ok := props[boltVectorProperty].([]float64)
+	if !ok || len(vector) != 3 {
+		t.Fatalf("expected a 3 dimensional vector property, got %v", props[boltVectorProperty])
+	}
+	if _, ok := props["embedding"].(string); !ok {
+		t.Error("expected the JSON embedding to be kept")
+	}
+
+	props = d.edgeToProperties(&types.Edge{BaseEdge: types.BaseEdge{Uuid: "e1"}, Embedding: []float32{0.1, 0.2}})
+	if _, ok := props[boltVectorProperty]; ok {
+		t.Error("expected embeddings of the wrong size to be left out of the vector index")
+	}
+
+	statements := d.vectorIndexStatements(vectorIndexSupport{nodes: true})
+	if len(statements) != len(boltNodeVectorIndexes) {
+		t.Fatalf("expected %d node index statements, got %v", len(boltNodeVectorIndexes), statements)
+	}
+	for _, statement := range statements {
+		if !strings.Contains(statement, "`vector.dimensions`: 3") {
+			t.Errorf("expected dimensions in %q", statement)
+		}
+	}
+	if got := d.vectorIndexStatements(vectorIndexSupport{nodes: true, relationships: true}); len(got) != len(statements)+1 {
+		t.Errorf("expected a relationship index statement, got %v", got)
+	}
+}
+
+func TestNeo4jVectorIndexesDisabledByDefault(t *testing.T) {
+	d, err := NewNeo4jDriver("bolt://localhost:7687", "neo4j", "password", "neo4j")
+	if err != nil {
+		t.Fatalf("failed to create driver: %v", err)
+	}
+	defer d.Close()
+
+	props := d.nodeToProperties(&types.Node{Uuid: "n1", Embedding: []float32{0.1, 0.2, 0.3}})
+	if _, ok := props[boltVectorProperty]; ok {
+		t.Error("expected no vector property without VectorDimensions")
+	}
+	// Must not contact the server when vector indexes are disabled
+	if support := d.vectorSupport(context.Background(), "neo4j"); support.nodes || support.relationships {
+		t.Errorf("expected no vector support without VectorDimensions, got %+v", support)
+	}
+}
@@ -0,0 +1,207 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Options holds connection pooling and retry settings for the Bolt-based
+// drivers (Neo4j and Memgraph).
+type Options struct {
+	// MaxConnectionPoolSize is the maximum number of connections per host (default: 100)
+	MaxConnectionPoolSize int
+	// MaxConnectionLifetime is the maximum age of a pooled connection before it is closed (default: 1 hour)
+	MaxConnectionLifetime time.Duration
+	// ConnectionAcquisitionTimeout is how long to wait for a free connection from the pool (default: 1 minute)
+	ConnectionAcquisitionTimeout time.Duration
+	// ConnectionLivenessCheckTimeout is the idle time after which a pooled connection
+	// is health-checked before reuse. Zero disables the check.
+	ConnectionLivenessCheckTimeout time.Duration
+
+	// MaxRetries is the maximum number of retry attempts for transient errors (default: 3).
+	// A negative value disables retries.
+	MaxRetries int
+	// InitialRetryDelay is the delay before the first retry (default: 100 milliseconds)
+	InitialRetryDelay time.Duration
+	// MaxRetryDelay is the maximum delay between retries (default: 5 seconds)
+	MaxRetryDelay time.Duration
+	// BackoffMultiplier is the multiplier for exponential backoff (default: 2.0)
+	BackoffMultiplier float64
+	// MaxTransactionRetryTime bounds the retries performed by the underlying driver
+	// for managed read/write transactions (default: 30 seconds)
+	MaxTransactionRetryTime time.Duration
+
+	// GroupDatabases maps group IDs to dedicated databases (database-per-tenant).
+	// Groups without an entry use the driver's default database. Neo4j only.
+	GroupDatabases map[string]string
+
+	// SoftDelete makes DeleteNode and DeleteEdge set deleted_at instead of
+	// removing records. Tombstoned records are removed by PurgeDeleted.
+	SoftDelete bool
+
+	// VectorDimensions is the embedding size used for native vector indexes.
+	// When set, embeddings are also stored as float lists and similarity search
+	// uses the vector indexes on servers that support them. Zero keeps search
+	// in memory.
+	VectorDimensions int
+}
+
+// schemaCheckTimeout bounds how long the Bolt constructors wait for the server
+// before skipping the schema version check.
+const schemaCheckTimeout = 5 * time.Second
+
+// DefaultOptions returns Options with sensible defaults
+func DefaultOptions() *Options {
+	return &Options{
+		MaxConnectionPoolSize:        100,
+		MaxConnectionLifetime:        1 * time.Hour,
+		ConnectionAcquisitionTimeout: 1 * time.Minute,
+		MaxRetries:                   3,
+		InitialRetryDelay:            100 * time.Millisecond,
+		MaxRetryDelay:                5 * time.Second,
+		BackoffMultiplier:            2.0,
+		MaxTransactionRetryTime:      30 * time.Second,
+	}
+}
+
+// withDefaults returns a copy of the options with zero values replaced by defaults.
+func (o *Options) withDefaults() *Options {
+	defaults := DefaultOptions()
+	if o == nil {
+		return defaults
+	}
+
+	opts := *o
+	if opts.MaxConnectionPoolSize <= 0 {
+		opts.MaxConnectionPoolSize = defaults.MaxConnectionPoolSize
+	}
+	if opts.MaxConnectionLifetime <= 0 {
+		opts.MaxConnectionLifetime = defaults.MaxConnectionLifetime
+	}
+	if opts.ConnectionAcquisitionTimeout <= 0 {
+		opts.ConnectionAcquisitionTimeout = defaults.ConnectionAcquisitionTimeout
+	}
+	switch {
+	case opts.MaxRetries == 0:
+		opts.MaxRetries = defaults.MaxRetries
+	case opts.MaxRetries < 0:
+		opts.MaxRetries = 0
+	}
+	if opts.InitialRetryDelay <= 0 {
+		opts.InitialRetryDelay = defaults.InitialRetryDelay
+	}
+	if opts.MaxRetryDelay <= 0 {
+		opts.MaxRetryDelay = defaults.MaxRetryDelay
+	}
+	if opts.BackoffMultiplier <= 0 {
+		opts.BackoffMultiplier = defaults.BackoffMultiplier
+	}
+	if opts.MaxTransactionRetryTime <= 0 {
+		opts.MaxTransactionRetryTime = defaults.MaxTransactionRetryTime
+	}
+	return &opts
+}
+
+// configure applies the pool settings to the underlying Bolt driver configuration.
+func (o *Options) configure(config *neo4j.Config) {
+	config.MaxConnectionPoolSize = o.MaxConnectionPoolSize
+	config.MaxConnectionLifetime = o.MaxConnectionLifetime
+	config.ConnectionAcquisitionTimeout = o.ConnectionAcquisitionTimeout
+	config.ConnectionLivenessCheckTimeout = o.ConnectionLivenessCheckTimeout
+	config.MaxTransactionRetryTime = o.MaxTransactionRetryTime
+}
+
+// retryDelay calculates the delay for a given retry attempt using exponential backoff
+func (o *Options) retryDelay(attempt int) time.Duration {
+	delay := float64(o.InitialRetryDelay) * math.Pow(o.BackoffMultiplier, float64(attempt-1))
+	if delay > float64(o.MaxRetryDelay) {
+		delay = float64(o.MaxRetryDelay)
+	}
+	return time.Duration(delay)
+}
+
+// withRetry runs fn, retrying transient Bolt errors with exponential backoff.
+func (o *Options) withRetry(ctx context.Context, fn func() error) error {
+	if o == nil {
+		o = DefaultOptions()
+	}
+
+	var lastErr error
+
+	for attempt := 0; attempt <= o.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(o.retryDelay(attempt)):
+			case <-ctx.Done():
+				return fmt.Errorf("context cancelled during retry backoff: %w", ctx.Err())
+			}
+		}
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if !IsTransientError(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("failed after %d retries: %w", o.MaxRetries, lastErr)
+}
+
+// withReadRetry runs fn like withRetry when query only reads. Auto-commit
+// writes are run once, since a write whose connection drops may already have
+// been applied and running it again could apply it twice.
+func (o *Options) withReadRetry(ctx context.Context, query string, fn func() error) error {
+	if isWriteQuery(query) {
+		return fn()
+	}
+	return o.withRetry(ctx, fn)
+}
+
+// IsTransientError reports whether err is a transient Bolt error that is safe to
+// retry, such as ServiceUnavailable, session expiry, or a deadlock.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if neo4j.IsRetryable(err) {
+		return true
+	}
+
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) {
+		return strings.Contains(neo4jErr.Code, "TransientError") ||
+			strings.Contains(neo4jErr.Code, "ServiceUnavailable") ||
+			strings.Contains(neo4jErr.Code, "DeadlockDetected")
+	}
+
+	errMsg := strings.ToLower(err.Error())
+	retryablePatterns := []string{
+		"serviceunavailable",
+		"service unavailable",
+		"sessionexpired",
+		"deadlock",
+		"cannot resolve conflicting transactions",
+		"connection reset",
+		"broken pipe",
+	}
+	for _, pattern := range retryablePatterns {
+		if strings.Contains(errMsg, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,119 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOptionsWithDefaults(t *testing.T) {
+	var nilOpts *Options
+	opts := nilOpts.withDefaults()
+	if opts.MaxConnectionPoolSize != 100 {
+		t.Errorf("expected default pool size 100, got %d", opts.MaxConnectionPoolSize)
+	}
+
+	custom := &Options{MaxConnectionPoolSize: 10}
+	opts = custom.withDefaults()
+	if opts.MaxConnectionPoolSize != 10 {
+		t.Errorf("expected pool size 10, got %d", opts.MaxConnectionPoolSize)
+	}
+	if opts.MaxRetries != 3 {
+		t.Errorf("expected default retries 3, got %d", opts.MaxRetries)
+	}
+	if opts.InitialRetryDelay != 100*time.Millisecond {
+		t.Errorf("expected default initial delay, got %v", opts.InitialRetryDelay)
+	}
+
+	disabled := &Options{MaxRetries: -1}
+	if opts := disabled.withDefaults(); opts.MaxRetries != 0 {
+		t.Errorf("expected retries to be disabled, got %d", opts.MaxRetries)
+	}
+}
+
+func TestOptionsRetryDelay(t *testing.T) {
+	opts := &Options{
+		InitialRetryDelay: 10 * time.Millisecond,
+		MaxRetryDelay:     30 * time.Millisecond,
+		BackoffMultiplier: 2.0,
+	}
+
+	expected := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond}
+	for i, want := range expected {
+		if got := opts.retryDelay(i + 1); got != want {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+}
+
+func TestOptionsWithRetry(t *testing.T) {
+	opts := (&Options{MaxRetries: 2, InitialRetryDelay: time.Millisecond}).withDefaults()
+
+	t.Run("retries transient errors", func(t *testing.T) {
+		calls := 0
+		err := opts.withRetry(context.Background(), func() error {
+			calls++
+			if calls < 3 {
+				return errors.New("ServiceUnavailable: connection lost")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("does not retry permanent errors", func(t *testing.T) {
+		calls := 0
+		err := opts.withRetry(context.Background(), func() error {
+			calls++
+			return errors.New("syntax error")
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		calls := 0
+		err := opts.withRetry(context.Background(), func() error {
+			calls++
+			return errors.New("deadlock detected")
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+	})
+}
+
+func TestOptionsWithReadRetry(t *testing.T) {
+	opts := (&Options{MaxRetries: 2, InitialRetryDelay: time.Millisecond}).withDefaults()
+
+	for query, want := range map[string]int{
+		"MATCH (n:Entity) RETURN n":           3,
+		"CREATE (n:Entity {uuid: $uuid})":     1,
+		"MATCH (n:Entity) SET n.name = $name": 1,
+	} {
+		calls := 0
+		err := opts.withReadRetry(context.Background(), query, func() error {
+			calls++
+			return errors.New("connection reset by peer")
+		})
+		if err == nil {
+			t.Fatalf("%s: expected error", query)
+		}
+		if calls != want {
+			t.Errorf("%s: expected %d calls, got %d", query, want, calls)
+		}
+	}
+}
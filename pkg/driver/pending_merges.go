@@ -0,0 +1,174 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrPendingMergesUnsupported indicates the driver cannot store pending
+// merges in the graph.
+var ErrPendingMergesUnsupported = errors.New("driver does not support pending merges")
+
+// PendingMerge is a candidate duplicate pair awaiting human review: the LLM
+// judged NodeUUID a duplicate of CanonicalUUID with a confidence Score below
+// the review threshold, so the nodes were kept apart.
+type PendingMerge struct {
+	ID            string
+	GroupID       string
+	NodeUUID      string
+	NodeName      string
+	CanonicalUUID string
+	CanonicalName string
+	Score         float64
+	CreatedAt     time.Time
+}
+
+// AddPendingMerge stores a pending merge, replacing any pending merge of the
+// same pair. It sets the ID of merge and returns
+// ErrPendingMergesUnsupported for drivers without Cypher support (Neo4j,
+// Memgraph and Ladybug store them).
+func AddPendingMerge(ctx context.Context, d GraphDriver, merge *PendingMerge) error {
+	if !supportsPendingMerges(d) {
+		return ErrPendingMergesUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if merge.NodeUUID == "" || merge.CanonicalUUID == "" {
+		return fmt.Errorf("pending merge requires both node UUIDs")
+	}
+
+	merge.ID = pendingMergeID(merge.GroupID, merge.NodeUUID, merge.CanonicalUUID)
+	if merge.CreatedAt.IsZero() {
+		merge.CreatedAt = time.Now().UTC()
+	}
+	_, err := executeGroupQuery(d, merge.GroupID, `
+		MERGE (m:PendingMerge {id: $id})
+		SET m.group_id = $group_id, m.node_uuid = $node_uuid, m.node_name = $node_name,
+		    m.canonical_uuid = $canonical_uuid, m.canonical_name = $canonical_name,
+		    m.score = $score, m.created_at = $created_at
+	`, map[string]interface{}{
+		"id":             merge.ID,
+		"group_id":       merge.GroupID,
+		"node_uuid":      merge.NodeUUID,
+		"node_name":      merge.NodeName,
+		"canonical_uuid": merge.CanonicalUUID,
+		"canonical_name": merge.CanonicalName,
+		"score":          merge.Score,
+		"created_at":     merge.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add pending merge of %s into %s: %w", merge.NodeUUID, merge.CanonicalUUID, err)
+	}
+	return nil
+}
+
+// GetPendingMerge returns the pending merge of a group by ID, or nil if there
+// is none.
+func GetPendingMerge(ctx context.Context, d GraphDriver, groupID, id string) (*PendingMerge, error) {
+	if !supportsPendingMerges(d) {
+		return nil, ErrPendingMergesUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err := executeGroupQuery(d, groupID, `
+		MATCH (m:PendingMerge {id: $id})
+		WHERE m.group_id = $group_id
+		`+pendingMergeReturn, map[string]interface{}{"id": id, "group_id": groupID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending merge %s: %w", id, err)
+	}
+
+	for _, record := range queryRecordMaps(result) {
+		return pendingMergeFromRecord(record), nil
+	}
+	return nil, nil
+}
+
+// ListPendingMerges returns the pending merges of a group, oldest first.
+func ListPendingMerges(ctx context.Context, d GraphDriver, groupID string) ([]*PendingMerge, error) {
+	if !supportsPendingMerges(d) {
+		return nil, ErrPendingMergesUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err := executeGroupQuery(d, groupID, `
+		MATCH (m:PendingMerge)
+		WHERE m.group_id = $group_id
+		`+pendingMergeReturn, map[string]interface{}{"group_id": groupID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending merges: %w", err)
+	}
+
+	var merges []*PendingMerge
+	for _, record := range queryRecordMaps(result) {
+		merges = append(merges, pendingMergeFromRecord(record))
+	}
+	sort.Slice(merges, func(i, j int) bool {
+		if !merges[i].CreatedAt.Equal(merges[j].CreatedAt) {
+			return merges[i].CreatedAt.Before(merges[j].CreatedAt)
+		}
+		return merges[i].ID < merges[j].ID
+	})
+	return merges, nil
+}
+
+// DeletePendingMerge deletes the pending merge of a group by ID. Deleting a
+// missing pending merge is not an error.
+func DeletePendingMerge(ctx context.Context, d GraphDriver, groupID, id string) error {
+	if !supportsPendingMerges(d) {
+		return ErrPendingMergesUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err := executeGroupQuery(d, groupID, `
+		MATCH (m:PendingMerge {id: $id})
+		WHERE m.group_id = $group_id
+		DELETE m
+	`, map[string]interface{}{"id": id, "group_id": groupID})
+	if err != nil {
+		return fmt.Errorf("failed to delete pending merge %s: %w", id, err)
+	}
+	return nil
+}
+
+const pendingMergeReturn = `RETURN m.id AS id, m.group_id AS group_id, m.node_uuid AS node_uuid, m.node_name AS node_name,
+		       m.canonical_uuid AS canonical_uuid, m.canonical_name AS canonical_name,
+		       m.score AS score, m.created_at AS created_at`
+
+// pendingMergeID is the key of a pending merge, unique across groups and
+// pairs.
+func pendingMergeID(groupID, nodeUUID, canonicalUUID string) string {
+	return groupID + ":" + nodeUUID + ":" + canonicalUUID
+}
+
+func pendingMergeFromRecord(record map[string]interface{}) *PendingMerge {
+	merge := &PendingMerge{}
+	merge.ID, _ = record["id"].(string)
+	merge.GroupID, _ = record["group_id"].(string)
+	merge.NodeUUID, _ = record["node_uuid"].(string)
+	merge.NodeName, _ = record["node_name"].(string)
+	merge.CanonicalUUID, _ = record["canonical_uuid"].(string)
+	merge.CanonicalName, _ = record["canonical_name"].(string)
+	merge.Score, _ = record["score"].(float64)
+	merge.CreatedAt, _ = record["created_at"].(time.Time)
+	return merge
+}
+
+// supportsPendingMerges reports whether d can store pending merges.
+func supportsPendingMerges(d GraphDriver) bool {
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph, GraphProviderLadybug:
+		return true
+	}
+	return false
+}
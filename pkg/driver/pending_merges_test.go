@@ -0,0 +1,71 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPendingMerges(t *testing.T) {
+	ctx := context.Background()
+	d := newRecordStore(GraphProviderMemgraph)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := &PendingMerge{GroupID: "g", NodeUUID: "n1", NodeName: "Bob", CanonicalUUID: "c1", CanonicalName: "Robert", Score: 0.4, CreatedAt: created}
+	second := &PendingMerge{GroupID: "g", NodeUUID: "n2", CanonicalUUID: "c1", Score: 0.6, CreatedAt: created.Add(time.Minute)}
+	other := &PendingMerge{GroupID: "other", NodeUUID: "n1", CanonicalUUID: "c1", Score: 0.5}
+	for _, merge := range []*PendingMerge{second, first, other} {
+		if err := AddPendingMerge(ctx, d, merge); err != nil {
+			t.Fatalf("AddPendingMerge() error = %v", err)
+		}
+	}
+	if first.ID == "" || first.ID == other.ID {
+		t.Fatalf("pending merge IDs %q and %q should be set and differ across groups", first.ID, other.ID)
+	}
+	// Queuing the same pair again updates it
+	if err := AddPendingMerge(ctx, d, &PendingMerge{GroupID: "g", NodeUUID: "n1", NodeName: "Bob", CanonicalUUID: "c1", CanonicalName: "Robert", Score: 0.4, CreatedAt: created}); err != nil {
+		t.Fatalf("AddPendingMerge() error = %v", err)
+	}
+	if stored := len(d.nodes["PendingMerge"]); stored != 3 {
+		t.Errorf("stored %d pending merges, want 3", stored)
+	}
+	d.queries = nil
+
+	merges, err := ListPendingMerges(ctx, d, "g")
+	if err != nil {
+		t.Fatalf("ListPendingMerges() error = %v", err)
+	}
+	if len(merges) != 2 || merges[0].NodeUUID != "n1" || merges[1].NodeUUID != "n2" {
+		t.Fatalf("ListPendingMerges() = %v, want n1 then n2", merges)
+	}
+	if merges[0].CanonicalName != "Robert" || merges[0].Score != 0.4 {
+		t.Errorf("pending merge = %+v, want the stored pair", merges[0])
+	}
+
+	merge, err := GetPendingMerge(ctx, d, "g", first.ID)
+	if err != nil || merge == nil || merge.NodeName != "Bob" {
+		t.Fatalf("GetPendingMerge() = %v, %v", merge, err)
+	}
+	if merge, _ := GetPendingMerge(ctx, d, "g", other.ID); merge != nil {
+		t.Error("expected the pending merge of another group to be hidden")
+	}
+
+	if err := DeletePendingMerge(ctx, d, "g", first.ID); err != nil {
+		t.Fatalf("DeletePendingMerge() error = %v", err)
+	}
+	if merges, _ := ListPendingMerges(ctx, d, "g"); len(merges) != 1 || merges[0].NodeUUID != "n2" {
+		t.Errorf("ListPendingMerges() after delete = %v, want n2", merges)
+	}
+	d.assertGroupQueries(t, "g")
+}
+
+func TestPendingMergesUnsupported(t *testing.T) {
+	d := newRecordStore(GraphProviderArangoDB)
+	if err := AddPendingMerge(context.Background(), d, &PendingMerge{NodeUUID: "n", CanonicalUUID: "c"}); !errors.Is(err, ErrPendingMergesUnsupported) {
+		t.Errorf("AddPendingMerge() error = %v, want ErrPendingMergesUnsupported", err)
+	}
+	if len(d.queries) != 0 {
+		t.Errorf("AddPendingMerge() ran %d queries on a driver without pending merges", len(d.queries))
+	}
+}
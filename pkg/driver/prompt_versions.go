@@ -0,0 +1,70 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPromptVersionUnsupported indicates the driver cannot store the prompt
+// versions of groups.
+var ErrPromptVersionUnsupported = errors.New("driver does not support prompt versions")
+
+// GetPromptVersion returns the prompt version a group is pinned to, or "" if
+// it is not pinned. It returns ErrPromptVersionUnsupported for drivers
+// without Cypher support (Neo4j, Memgraph and Ladybug store it).
+func GetPromptVersion(ctx context.Context, d GraphDriver, groupID string) (string, error) {
+	if !supportsPromptVersions(d) {
+		return "", ErrPromptVersionUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	result, err := executeGroupQuery(d, groupID, `
+		MATCH (p:PromptVersion {group_id: $group_id})
+		RETURN p.version AS version
+	`, map[string]interface{}{"group_id": groupID})
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt version: %w", err)
+	}
+	for _, record := range queryRecordMaps(result) {
+		version, _ := record["version"].(string)
+		return version, nil
+	}
+	return "", nil
+}
+
+// SetPromptVersion pins a group to a prompt version, replacing any previous
+// version.
+func SetPromptVersion(ctx context.Context, d GraphDriver, groupID, version string) error {
+	if !supportsPromptVersions(d) {
+		return ErrPromptVersionUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err := executeGroupQuery(d, groupID, `
+		MERGE (p:PromptVersion {group_id: $group_id})
+		SET p.version = $version, p.updated_at = $updated_at
+	`, map[string]interface{}{
+		"group_id":   groupID,
+		"version":    version,
+		"updated_at": time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record prompt version: %w", err)
+	}
+	return nil
+}
+
+// supportsPromptVersions reports whether d can store prompt versions.
+func supportsPromptVersions(d GraphDriver) bool {
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph, GraphProviderLadybug:
+		return true
+	}
+	return false
+}
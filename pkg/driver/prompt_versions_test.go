@@ -0,0 +1,42 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPromptVersion(t *testing.T) {
+	ctx := context.Background()
+	d := newRecordStore(GraphProviderNeo4j)
+
+	if version, err := GetPromptVersion(ctx, d, "g"); err != nil || version != "" {
+		t.Fatalf("GetPromptVersion() = %q, %v, want unpinned", version, err)
+	}
+	if err := SetPromptVersion(ctx, d, "g", "v1"); err != nil {
+		t.Fatalf("SetPromptVersion() error = %v", err)
+	}
+	if version, err := GetPromptVersion(ctx, d, "g"); err != nil || version != "v1" {
+		t.Errorf("GetPromptVersion() = %q, %v, want v1", version, err)
+	}
+	if err := SetPromptVersion(ctx, d, "g", "v2"); err != nil {
+		t.Fatalf("SetPromptVersion() error = %v", err)
+	}
+	if version, _ := GetPromptVersion(ctx, d, "g"); version != "v2" || len(d.nodes["PromptVersion"]) != 1 {
+		t.Errorf("GetPromptVersion() = %q with %d versions stored, want v2 replacing v1", version, len(d.nodes["PromptVersion"]))
+	}
+	if version, _ := GetPromptVersion(ctx, d, "other"); version != "" {
+		t.Errorf("GetPromptVersion() of another group = %q, want unpinned", version)
+	}
+	d.queries = d.queries[:len(d.queries)-1]
+	d.assertGroupQueries(t, "g")
+
+	d.provider = GraphProviderSurrealDB
+	queries := len(d.queries)
+	if _, err := GetPromptVersion(ctx, d, "g"); !errors.Is(err, ErrPromptVersionUnsupported) {
+		t.Errorf("GetPromptVersion() error = %v, want ErrPromptVersionUnsupported", err)
+	}
+	if len(d.queries) != queries {
+		t.Error("GetPromptVersion() queried a driver without prompt versions")
+	}
+}
@@ -0,0 +1,165 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// recordStore is an in-memory GraphDriver running the single node queries of
+// the records helpers keep per group: MERGE with SET, MATCH with RETURN and
+// MATCH with DELETE, each matching on a key property and at most one WHERE
+// equality. It records the queries it runs and fails on queries of another
+// shape or using a missing parameter.
+type recordStore struct {
+	GraphDriver
+	provider GraphProvider
+	nodes    map[string][]map[string]interface{}
+	queries  []storeQuery
+	upserts  int
+}
+
+// storeQuery is a query run by a recordStore.
+type storeQuery struct {
+	cypher string
+	params map[string]interface{}
+}
+
+var (
+	storeNodePattern   = regexp.MustCompile(`^\s*(MERGE|MATCH) \((\w+):(\w+)(?: \{(\w+): \$(\w+)\})?\)`)
+	storeWherePattern  = regexp.MustCompile(`WHERE (\w+)\.(\w+) = \$(\w+)`)
+	storeSetPattern    = regexp.MustCompile(`(\w+)\.(\w+) = \$(\w+)`)
+	storeReturnPattern = regexp.MustCompile(`(\w+)\.(\w+) AS (\w+)`)
+)
+
+func newRecordStore(provider GraphProvider) *recordStore {
+	return &recordStore{provider: provider, nodes: make(map[string][]map[string]interface{})}
+}
+
+func (d *recordStore) Provider() GraphProvider {
+	return d.provider
+}
+
+func (d *recordStore) UpsertNode(ctx context.Context, node *types.Node) error {
+	d.upserts++
+	return nil
+}
+
+func (d *recordStore) UpsertEdge(ctx context.Context, edge *types.Edge) error {
+	d.upserts++
+	return nil
+}
+
+func (d *recordStore) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	d.queries = append(d.queries, storeQuery{cypher: cypherQuery, params: kwargs})
+
+	node := storeNodePattern.FindStringSubmatch(cypherQuery)
+	if node == nil {
+		return nil, nil, nil, fmt.Errorf("unexpected query: %s", cypherQuery)
+	}
+	param := func(name string) (interface{}, error) {
+		value, ok := kwargs[name]
+		if !ok {
+			return nil, fmt.Errorf("query uses missing parameter $%s", name)
+		}
+		return value, nil
+	}
+
+	label := node[3]
+	conditions := make(map[string]interface{})
+	if node[4] != "" {
+		value, err := param(node[5])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		conditions[node[4]] = value
+	}
+	if where := storeWherePattern.FindStringSubmatch(cypherQuery); where != nil {
+		value, err := param(where[3])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		conditions[where[2]] = value
+	}
+	matches := func(record map[string]interface{}) bool {
+		for property, value := range conditions {
+			if record[property] != value {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case node[1] == "MERGE":
+		set := strings.Index(cypherQuery, "SET ")
+		if set < 0 {
+			return nil, nil, nil, fmt.Errorf("MERGE without SET: %s", cypherQuery)
+		}
+		var record map[string]interface{}
+		for _, existing := range d.nodes[label] {
+			if matches(existing) {
+				record = existing
+			}
+		}
+		if record == nil {
+			record = make(map[string]interface{})
+			for property, value := range conditions {
+				record[property] = value
+			}
+			d.nodes[label] = append(d.nodes[label], record)
+		}
+		for _, assignment := range storeSetPattern.FindAllStringSubmatch(cypherQuery[set:], -1) {
+			value, err := param(assignment[3])
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			record[assignment[2]] = value
+		}
+		return nil, nil, nil, nil
+	case strings.Contains(cypherQuery, "DELETE "+node[2]):
+		kept := d.nodes[label][:0]
+		for _, record := range d.nodes[label] {
+			if !matches(record) {
+				kept = append(kept, record)
+			}
+		}
+		d.nodes[label] = kept
+		return nil, nil, nil, nil
+	case strings.Contains(cypherQuery, "RETURN "):
+		columns := storeReturnPattern.FindAllStringSubmatch(cypherQuery, -1)
+		records := []map[string]interface{}{}
+		for _, record := range d.nodes[label] {
+			if !matches(record) {
+				continue
+			}
+			row := make(map[string]interface{}, len(columns))
+			for _, column := range columns {
+				if value, ok := record[column[2]]; ok {
+					row[column[3]] = value
+				}
+			}
+			records = append(records, row)
+		}
+		return records, nil, nil, nil
+	}
+	return nil, nil, nil, fmt.Errorf("unexpected query: %s", cypherQuery)
+}
+
+// assertGroupQueries checks that every query of d names groupID as its
+// group_id parameter and filters on it.
+func (d *recordStore) assertGroupQueries(t *testing.T, groupID string) {
+	t.Helper()
+	for _, query := range d.queries {
+		if query.params["group_id"] != groupID {
+			t.Errorf("query %q has group_id %v, want %q", strings.TrimSpace(query.cypher), query.params["group_id"], groupID)
+		}
+		if !strings.Contains(query.cypher, "group_id") {
+			t.Errorf("query %q does not use the group", strings.TrimSpace(query.cypher))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package driver
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// relatedEdgeFilter is the edge type filter of GetRelatedNodes. Edge types
+// other than the structural types of types.EdgeType are relation names of
+// entity edges, so types.EdgeType("WORKS_AT") follows only WORKS_AT facts.
+type relatedEdgeFilter struct {
+	edgeTypes []types.EdgeType
+	edgeNames []string
+}
+
+// newRelatedEdgeFilter splits edgeTypes into structural edge types and
+// relation names.
+func newRelatedEdgeFilter(edgeTypes []types.EdgeType) *relatedEdgeFilter {
+	f := &relatedEdgeFilter{}
+	for _, edgeType := range edgeTypes {
+		switch edgeType {
+		case types.EntityEdgeType, types.EpisodicEdgeType, types.CommunityEdgeType, types.SourceEdgeType:
+			f.edgeTypes = append(f.edgeTypes, edgeType)
+		default:
+			f.edgeNames = append(f.edgeNames, string(edgeType))
+		}
+	}
+	return f
+}
+
+// all reports whether every edge is followed.
+func (f *relatedEdgeFilter) all() bool {
+	return len(f.edgeTypes) == 0 && len(f.edgeNames) == 0
+}
+
+// follows reports whether any edges of edgeType are followed.
+func (f *relatedEdgeFilter) follows(edgeType types.EdgeType) bool {
+	if f.all() || slices.Contains(f.edgeTypes, edgeType) {
+		return true
+	}
+	return edgeType == types.EntityEdgeType && len(f.edgeNames) > 0
+}
+
+// entityEdgeNames returns the relation names entity edges must have, or nil
+// if every entity edge is followed.
+func (f *relatedEdgeFilter) entityEdgeNames() []string {
+	if slices.Contains(f.edgeTypes, types.EntityEdgeType) {
+		return nil
+	}
+	return f.edgeNames
+}
+
+// boltCondition returns the Neo4j and Memgraph WHERE condition of the filter
+// on relationship variable r, adding its parameters to params. It returns
+// false if no relationship can match.
+func (f *relatedEdgeFilter) boltCondition(r string, params map[string]any) (string, bool) {
+	if f.all() {
+		return "true", true
+	}
+
+	var conditions []string
+	var relationships []string
+	for _, edgeType := range f.edgeTypes {
+		if relationship, ok := boltEdgeRelationships[edgeType]; ok {
+			relationships = append(relationships, relationship)
+		}
+	}
+	if len(relationships) > 0 {
+		conditions = append(conditions, "type("+r+") IN $related_relationships")
+		params["related_relationships"] = relationships
+	}
+	if names := f.entityEdgeNames(); len(names) > 0 {
+		conditions = append(conditions, "(type("+r+") = 'RELATES_TO' AND "+r+".name IN $related_edge_names)")
+		params["related_edge_names"] = names
+	}
+	if len(conditions) == 0 {
+		return "", false
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", true
+}
+
+// ladybugRelatedPattern is a Ladybug MATCH pattern from the node start to
+// related nodes n of table related over edges of edgeType. Entity edges pass
+// through the RelatesToNode_ e.
+type ladybugRelatedPattern struct {
+	edgeType types.EdgeType
+	related  string
+	match    string
+}
+
+// ladybugRelatedPatterns are the patterns of GetRelatedNodes for each pair of
+// node tables connected by a relationship table.
+var ladybugRelatedPatterns = []ladybugRelatedPattern{
+	{types.EntityEdgeType, "Entity", "(start:Entity)-[:RELATES_TO]-(e:RelatesToNode_)-[:RELATES_TO]-(n:Entity)"},
+	{types.EpisodicEdgeType, "Entity", "(start:Episodic)-[:MENTIONS]->(n:Entity)"},
+	{types.EpisodicEdgeType, "Episodic", "(start:Entity)<-[:MENTIONS]-(n:Episodic)"},
+	{types.CommunityEdgeType, "Entity", "(start:Community)-[:HAS_MEMBER]->(n:Entity)"},
+	{types.CommunityEdgeType, "Community", "(start:Entity)<-[:HAS_MEMBER]-(n:Community)"},
+	{types.CommunityEdgeType, "Community", "(start:Community)-[:HAS_MEMBER]-(n:Community)"},
+}
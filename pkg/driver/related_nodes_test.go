@@ -0,0 +1,97 @@
+package driver
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestRelatedEdgeFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		edgeTypes  []types.EdgeType
+		condition  string
+		ok         bool
+		params     map[string]any
+		follows    []types.EdgeType
+		entityOnly []string
+	}{
+		{
+			name:      "all edges",
+			condition: "true",
+			ok:        true,
+			params:    map[string]any{},
+			follows:   []types.EdgeType{types.EntityEdgeType, types.EpisodicEdgeType, types.CommunityEdgeType},
+		},
+		{
+			name:      "episodic",
+			edgeTypes: []types.EdgeType{types.EpisodicEdgeType},
+			condition: "(type(r) IN $related_relationships)",
+			ok:        true,
+			params:    map[string]any{"related_relationships": []string{"MENTIONS"}},
+			follows:   []types.EdgeType{types.EpisodicEdgeType},
+		},
+		{
+			name:       "relation names",
+			edgeTypes:  []types.EdgeType{"WORKS_AT", "TREATED_WITH"},
+			condition:  "((type(r) = 'RELATES_TO' AND r.name IN $related_edge_names))",
+			ok:         true,
+			params:     map[string]any{"related_edge_names": []string{"WORKS_AT", "TREATED_WITH"}},
+			follows:    []types.EdgeType{types.EntityEdgeType},
+			entityOnly: []string{"WORKS_AT", "TREATED_WITH"},
+		},
+		{
+			name:      "relation name and community",
+			edgeTypes: []types.EdgeType{types.CommunityEdgeType, "WORKS_AT"},
+			condition: "(type(r) IN $related_relationships OR (type(r) = 'RELATES_TO' AND r.name IN $related_edge_names))",
+			ok:        true,
+			params: map[string]any{
+				"related_relationships": []string{"HAS_MEMBER"},
+				"related_edge_names":    []string{"WORKS_AT"},
+			},
+			follows:    []types.EdgeType{types.EntityEdgeType, types.CommunityEdgeType},
+			entityOnly: []string{"WORKS_AT"},
+		},
+		{
+			name:      "entity edges include every relation name",
+			edgeTypes: []types.EdgeType{types.EntityEdgeType, "WORKS_AT"},
+			condition: "(type(r) IN $related_relationships)",
+			ok:        true,
+			params:    map[string]any{"related_relationships": []string{"RELATES_TO"}},
+			follows:   []types.EdgeType{types.EntityEdgeType},
+		},
+		{
+			name:      "no relationship",
+			edgeTypes: []types.EdgeType{types.SourceEdgeType},
+			params:    map[string]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := newRelatedEdgeFilter(tt.edgeTypes)
+			params := map[string]any{}
+			condition, ok := filter.boltCondition("r", params)
+			if condition != tt.condition || ok != tt.ok {
+				t.Errorf("boltCondition() = %q, %v, want %q, %v", condition, ok, tt.condition, tt.ok)
+			}
+			if !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("params = %v, want %v", params, tt.params)
+			}
+
+			var follows []types.EdgeType
+			for _, edgeType := range []types.EdgeType{types.EntityEdgeType, types.EpisodicEdgeType, types.CommunityEdgeType} {
+				if filter.follows(edgeType) {
+					follows = append(follows, edgeType)
+				}
+			}
+			if !reflect.DeepEqual(follows, tt.follows) {
+				t.Errorf("follows = %v, want %v", follows, tt.follows)
+			}
+			if names := filter.entityEdgeNames(); !reflect.DeepEqual(names, tt.entityOnly) {
+				t.Errorf("entityEdgeNames() = %v, want %v", names, tt.entityOnly)
+			}
+		})
+	}
+}
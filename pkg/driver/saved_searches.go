@@ -0,0 +1,165 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// ErrSavedSearchesUnsupported indicates the driver cannot store saved
+// searches in the graph.
+var ErrSavedSearchesUnsupported = errors.New("driver does not support saved searches")
+
+// SavedSearch is a named search configuration, including its filters, stored
+// in the graph for a group.
+type SavedSearch struct {
+	GroupID   string
+	Name      string
+	Config    *types.SearchConfig
+	UpdatedAt time.Time
+}
+
+// SaveSearch stores a saved search, replacing any saved search of the same
+// name in its group. It returns ErrSavedSearchesUnsupported for drivers
+// without Cypher support (Neo4j, Memgraph and Ladybug store them).
+func SaveSearch(ctx context.Context, d GraphDriver, saved *SavedSearch) error {
+	if !supportsSavedSearches(d) {
+		return ErrSavedSearchesUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if saved.Name == "" {
+		return fmt.Errorf("saved search name is required")
+	}
+
+	config, err := json.Marshal(saved.Config)
+	if err != nil {
+		return fmt.Errorf("failed to encode saved search %q: %w", saved.Name, err)
+	}
+
+	_, err = executeGroupQuery(d, saved.GroupID, `
+		MERGE (s:SavedSearch {id: $id})
+		SET s.group_id = $group_id, s.name = $name, s.config = $config, s.updated_at = $updated_at
+	`, map[string]interface{}{
+		"id":         savedSearchID(saved.GroupID, saved.Name),
+		"group_id":   saved.GroupID,
+		"name":       saved.Name,
+		"config":     string(config),
+		"updated_at": time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save search %q: %w", saved.Name, err)
+	}
+	return nil
+}
+
+// GetSavedSearch returns the saved search of a group by name, or nil if there
+// is none.
+func GetSavedSearch(ctx context.Context, d GraphDriver, groupID, name string) (*SavedSearch, error) {
+	if !supportsSavedSearches(d) {
+		return nil, ErrSavedSearchesUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err := executeGroupQuery(d, groupID, `
+		MATCH (s:SavedSearch {id: $id})
+		RETURN s.group_id AS group_id, s.name AS name, s.config AS config, s.updated_at AS updated_at
+	`, map[string]interface{}{"id": savedSearchID(groupID, name)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved search %q: %w", name, err)
+	}
+
+	for _, record := range queryRecordMaps(result) {
+		return savedSearchFromRecord(record)
+	}
+	return nil, nil
+}
+
+// ListSavedSearches returns the saved searches of a group ordered by name.
+func ListSavedSearches(ctx context.Context, d GraphDriver, groupID string) ([]*SavedSearch, error) {
+	if !supportsSavedSearches(d) {
+		return nil, ErrSavedSearchesUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err := executeGroupQuery(d, groupID, `
+		MATCH (s:SavedSearch)
+		WHERE s.group_id = $group_id
+		RETURN s.group_id AS group_id, s.name AS name, s.config AS config, s.updated_at AS updated_at
+	`, map[string]interface{}{"group_id": groupID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+
+	var searches []*SavedSearch
+	for _, record := range queryRecordMaps(result) {
+		saved, err := savedSearchFromRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, saved)
+	}
+	sort.Slice(searches, func(i, j int) bool {
+		return searches[i].Name < searches[j].Name
+	})
+	return searches, nil
+}
+
+// DeleteSavedSearch deletes the saved search of a group by name. Deleting a
+// missing saved search is not an error.
+func DeleteSavedSearch(ctx context.Context, d GraphDriver, groupID, name string) error {
+	if !supportsSavedSearches(d) {
+		return ErrSavedSearchesUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err := executeGroupQuery(d, groupID, `
+		MATCH (s:SavedSearch {id: $id})
+		DELETE s
+	`, map[string]interface{}{"id": savedSearchID(groupID, name)})
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search %q: %w", name, err)
+	}
+	return nil
+}
+
+// savedSearchID is the key of a saved search, unique across groups.
+func savedSearchID(groupID, name string) string {
+	return groupID + "\x00" + name
+}
+
+func savedSearchFromRecord(record map[string]interface{}) (*SavedSearch, error) {
+	saved := &SavedSearch{}
+	saved.GroupID, _ = record["group_id"].(string)
+	saved.Name, _ = record["name"].(string)
+	saved.UpdatedAt, _ = record["updated_at"].(time.Time)
+
+	config, _ := record["config"].(string)
+	if config != "" {
+		if err := json.Unmarshal([]byte(config), &saved.Config); err != nil {
+			return nil, fmt.Errorf("failed to decode saved search %q: %w", saved.Name, err)
+		}
+	}
+	return saved, nil
+}
+
+// supportsSavedSearches reports whether d can store saved searches.
+func supportsSavedSearches(d GraphDriver) bool {
+	switch d.Provider() {
+	case GraphProviderNeo4j, GraphProviderMemgraph, GraphProviderLadybug:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestSavedSearches(t *testing.T) {
+	ctx := context.Background()
+	d := newRecordStore(GraphProviderLadybug)
+
+	config := &types.SearchConfig{
+		Limit:   5,
+		Filters: &types.SearchFilters{EdgeNames: []string{"WORKS_AT"}},
+		EdgeConfig: &types.EdgeSearchConfig{
+			SearchMethods: []string{"bm25"},
+			Reranker:      "rrf",
+		},
+	}
+	for _, saved := range []*SavedSearch{
+		{GroupID: "g", Name: "employment", Config: config},
+		{GroupID: "g", Name: "broad", Config: &types.SearchConfig{Limit: 50}},
+		{GroupID: "other", Name: "employment", Config: &types.SearchConfig{Limit: 1}},
+	} {
+		if err := SaveSearch(ctx, d, saved); err != nil {
+			t.Fatalf("SaveSearch(%s) error = %v", saved.Name, err)
+		}
+	}
+
+	if stored := len(d.nodes["SavedSearch"]); stored != 3 {
+		t.Errorf("stored %d saved searches, want 3 with the same name in two groups", stored)
+	}
+	d.queries = nil
+
+	saved, err := GetSavedSearch(ctx, d, "g", "employment")
+	if err != nil {
+		t.Fatalf("GetSavedSearch() error = %v", err)
+	}
+	if saved == nil || saved.Config == nil {
+		t.Fatal("expected the saved search")
+	}
+	if saved.Config.Limit != 5 || saved.Config.Filters.EdgeNames[0] != "WORKS_AT" || saved.Config.EdgeConfig.Reranker != "rrf" {
+		t.Errorf("saved config = %+v, want the stored configuration", saved.Config)
+	}
+
+	searches, err := ListSavedSearches(ctx, d, "g")
+	if err != nil {
+		t.Fatalf("ListSavedSearches() error = %v", err)
+	}
+	if len(searches) != 2 || searches[0].Name != "broad" || searches[1].Name != "employment" {
+		t.Errorf("ListSavedSearches() = %v, want broad and employment", searches)
+	}
+
+	if err := DeleteSavedSearch(ctx, d, "g", "employment"); err != nil {
+		t.Fatalf("DeleteSavedSearch() error = %v", err)
+	}
+	if saved, _ := GetSavedSearch(ctx, d, "g", "employment"); saved != nil {
+		t.Error("expected the saved search to be deleted")
+	}
+	for _, query := range d.queries {
+		if query.params["group_id"] != "g" && query.params["id"] != savedSearchID("g", "employment") {
+			t.Errorf("query %v does not address group g", query.params)
+		}
+	}
+	if saved, _ := GetSavedSearch(ctx, d, "other", "employment"); saved == nil || saved.Config.Limit != 1 {
+		t.Error("expected the saved search of the other group to remain")
+	}
+}
+
+func TestSavedSearchesUnsupported(t *testing.T) {
+	d := newRecordStore(GraphProviderSurrealDB)
+	if err := SaveSearch(context.Background(), d, &SavedSearch{Name: "x"}); !errors.Is(err, ErrSavedSearchesUnsupported) {
+		t.Errorf("SaveSearch() error = %v, want ErrSavedSearchesUnsupported", err)
+	}
+	if len(d.queries) != 0 {
+		t.Errorf("SaveSearch() ran %d queries on a driver without saved searches", len(d.queries))
+	}
+}
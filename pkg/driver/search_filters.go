@@ -0,0 +1,347 @@
+package driver
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// searchFilter holds the filters of SearchOptions and VectorSearchOptions that
+// drivers push down into their search queries.
+type searchFilter struct {
+	groupIDs    []string
+	nodeTypes   []types.NodeType
+	edgeTypes   []types.EdgeType
+	entityTypes []string
+	edgeNames   []string
+	timeRange   *types.TimeRange
+	validAt     *types.TimeRange
+	asOf        time.Time
+}
+
+// groupSearchFilter returns a filter matching only groupID.
+func groupSearchFilter(groupID string) *searchFilter {
+	return &searchFilter{groupIDs: []string{groupID}}
+}
+
+// filter returns the filters of options, searching groupID unless
+// options.GroupIDs is set.
+func (o *SearchOptions) filter(groupID string) *searchFilter {
+	f := groupSearchFilter(groupID)
+	if o == nil {
+		return f
+	}
+	if len(o.GroupIDs) > 0 {
+		f.groupIDs = o.GroupIDs
+	}
+	f.nodeTypes = o.NodeTypes
+	f.edgeTypes = o.EdgeTypes
+	f.entityTypes = o.EntityTypes
+	f.edgeNames = o.EdgeNames
+	f.timeRange = o.TimeRange
+	f.validAt = o.ValidAt
+	f.asOf = o.AsOf
+	return f
+}
+
+// filter returns the filters of options, searching groupID unless
+// options.GroupIDs is set.
+func (o *VectorSearchOptions) filter(groupID string) *searchFilter {
+	f := groupSearchFilter(groupID)
+	if o == nil {
+		return f
+	}
+	if len(o.GroupIDs) > 0 {
+		f.groupIDs = o.GroupIDs
+	}
+	f.nodeTypes = o.NodeTypes
+	f.edgeTypes = o.EdgeTypes
+	f.entityTypes = o.EntityTypes
+	f.edgeNames = o.EdgeNames
+	f.timeRange = o.TimeRange
+	f.validAt = o.ValidAt
+	f.asOf = o.AsOf
+	return f
+}
+
+// boltNodeLabels are the labels of the Neo4j and Memgraph nodes of each type.
+var boltNodeLabels = map[types.NodeType]string{
+	types.EntityNodeType:    "Entity",
+	types.EpisodicNodeType:  "Episodic",
+	types.CommunityNodeType: "Community",
+	types.SourceNodeType:    "Source",
+}
+
+// boltEdgeRelationships are the relationship types of the Neo4j and Memgraph
+// edges of each type.
+var boltEdgeRelationships = map[types.EdgeType]string{
+	types.EntityEdgeType:    "RELATES_TO",
+	types.EpisodicEdgeType:  "MENTIONS",
+	types.CommunityEdgeType: "HAS_MEMBER",
+}
+
+// allowsNodeType reports whether nodes of nodeType pass the node type filter.
+func (f *searchFilter) allowsNodeType(nodeType types.NodeType) bool {
+	return len(f.nodeTypes) == 0 || slices.Contains(f.nodeTypes, nodeType)
+}
+
+// allowsEdgeType reports whether edges of edgeType pass the edge type filter.
+func (f *searchFilter) allowsEdgeType(edgeType types.EdgeType) bool {
+	return len(f.edgeTypes) == 0 || slices.Contains(f.edgeTypes, edgeType)
+}
+
+// boltFilterTime formats t the way Neo4j and Memgraph store timestamps.
+func boltFilterTime(t time.Time) any {
+	return t.Format(time.RFC3339)
+}
+
+// ladybugFilterTime passes t as a Ladybug TIMESTAMP.
+func ladybugFilterTime(t time.Time) any {
+	return t
+}
+
+// cypherCreatedAtConditions appends the time range conditions on the
+// created_at property of v, passing times as formatted by timeParam.
+func (f *searchFilter) cypherCreatedAtConditions(conditions []string, v string, timeParam func(time.Time) any, params map[string]any) []string {
+	if f.timeRange == nil {
+		return conditions
+	}
+	if !f.timeRange.Start.IsZero() {
+		conditions = append(conditions, v+".created_at >= $filter_start")
+		params["filter_start"] = timeParam(f.timeRange.Start)
+	}
+	if !f.timeRange.End.IsZero() {
+		conditions = append(conditions, v+".created_at <= $filter_end")
+		params["filter_end"] = timeParam(f.timeRange.End)
+	}
+	return conditions
+}
+
+// boltNodeConditions returns the Neo4j and Memgraph WHERE conditions of the
+// filter on node variable v, adding their parameters to params. Node types
+// match the type property or the label of the type, entity types the
+// entity_type property or any label.
+func (f *searchFilter) boltNodeConditions(v string, params map[string]any) string {
+	conditions := []string{v + ".group_id IN $filter_group_ids"}
+	params["filter_group_ids"] = f.groupIDs
+
+	if len(f.nodeTypes) > 0 {
+		nodeTypes := make([]string, 0, len(f.nodeTypes))
+		labels := make([]string, 0, len(f.nodeTypes))
+		for _, nodeType := range f.nodeTypes {
+			nodeTypes = append(nodeTypes, string(nodeType))
+			if label, ok := boltNodeLabels[nodeType]; ok {
+				labels = append(labels, label)
+			}
+		}
+		conditions = append(conditions, "("+v+".type IN $filter_node_types OR any(label IN labels("+v+") WHERE label IN $filter_node_labels))")
+		params["filter_node_types"] = nodeTypes
+		params["filter_node_labels"] = labels
+	}
+	if len(f.entityTypes) > 0 {
+		conditions = append(conditions, "("+v+".entity_type IN $filter_entity_types OR any(label IN labels("+v+") WHERE label IN $filter_entity_types))")
+		params["filter_entity_types"] = f.entityTypes
+	}
+	conditions = f.cypherCreatedAtConditions(conditions, v, boltFilterTime, params)
+
+	return strings.Join(conditions, " AND ")
+}
+
+// boltEdgeConditions returns the Neo4j and Memgraph WHERE conditions of the
+// filter on relationship variable v, adding their parameters to params. Edge
+// types match the type property or the relationship type of the edge type.
+func (f *searchFilter) boltEdgeConditions(v string, params map[string]any) string {
+	conditions := []string{v + ".group_id IN $filter_group_ids"}
+	params["filter_group_ids"] = f.groupIDs
+
+	if len(f.edgeTypes) > 0 {
+		edgeTypes := make([]string, 0, len(f.edgeTypes))
+		relationships := make([]string, 0, len(f.edgeTypes))
+		for _, edgeType := range f.edgeTypes {
+			edgeTypes = append(edgeTypes, string(edgeType))
+			if relationship, ok := boltEdgeRelationships[edgeType]; ok {
+				relationships = append(relationships, relationship)
+			}
+		}
+		conditions = append(conditions, "("+v+".type IN $filter_edge_types OR type("+v+") IN $filter_edge_relationships)")
+		params["filter_edge_types"] = edgeTypes
+		params["filter_edge_relationships"] = relationships
+	}
+	conditions = f.cypherEdgeConditions(conditions, v, "valid_from", "valid_to", boltFilterTime, params)
+
+	return strings.Join(conditions, " AND ")
+}
+
+// ladybugNodeConditions returns the Ladybug WHERE conditions of the filter on
+// Entity variable v, adding their parameters to params. Entity types match
+// the labels of the node.
+func (f *searchFilter) ladybugNodeConditions(v string, params map[string]any) string {
+	conditions := []string{v + ".group_id IN $filter_group_ids"}
+	params["filter_group_ids"] = f.groupIDs
+
+	if len(f.entityTypes) > 0 {
+		conditions = append(conditions, "any(label IN "+v+".labels WHERE label IN $filter_entity_types)")
+		params["filter_entity_types"] = f.entityTypes
+	}
+	conditions = f.cypherCreatedAtConditions(conditions, v, ladybugFilterTime, params)
+
+	return strings.Join(conditions, " AND ")
+}
+
+// ladybugEdgeConditions returns the Ladybug WHERE conditions of the filter on
+// RelatesToNode_ variable v, adding their parameters to params.
+func (f *searchFilter) ladybugEdgeConditions(v string, params map[string]any) string {
+	conditions := []string{v + ".group_id IN $filter_group_ids"}
+	params["filter_group_ids"] = f.groupIDs
+
+	conditions = f.cypherEdgeConditions(conditions, v, "valid_at", "invalid_at", ladybugFilterTime, params)
+
+	return strings.Join(conditions, " AND ")
+}
+
+// cypherEdgeConditions appends the edge name, time range, validity and as-of
+// conditions on v. Edges without a validity bound are valid on that side.
+func (f *searchFilter) cypherEdgeConditions(conditions []string, v, validFrom, validTo string, timeParam func(time.Time) any, params map[string]any) []string {
+	if len(f.edgeNames) > 0 {
+		conditions = append(conditions, v+".name IN $filter_edge_names")
+		params["filter_edge_names"] = f.edgeNames
+	}
+	conditions = f.cypherCreatedAtConditions(conditions, v, timeParam, params)
+
+	if f.validAt != nil {
+		if !f.validAt.End.IsZero() {
+			conditions = append(conditions, "("+v+"."+validFrom+" IS NULL OR "+v+"."+validFrom+" <= $filter_valid_end)")
+			params["filter_valid_end"] = timeParam(f.validAt.End)
+		}
+		if !f.validAt.Start.IsZero() {
+			conditions = append(conditions, "("+v+"."+validTo+" IS NULL OR "+v+"."+validTo+" >= $filter_valid_start)")
+			params["filter_valid_start"] = timeParam(f.validAt.Start)
+		}
+	}
+	if !f.asOf.IsZero() {
+		conditions = append(conditions,
+			"("+v+"."+validFrom+" IS NULL OR "+v+"."+validFrom+" <= $filter_as_of)",
+			"("+v+"."+validTo+" IS NULL OR "+v+"."+validTo+" > $filter_as_of)")
+		params["filter_as_of"] = timeParam(f.asOf)
+	}
+	return conditions
+}
+
+// arangoNodeFilters returns AQL FILTER statements for the entity type and time
+// range filters on doc, adding their bind parameters to params. Groups are
+// matched by the caller and node types select the collections searched.
+func (f *searchFilter) arangoNodeFilters(params map[string]interface{}) string {
+	var filters []string
+	if len(f.entityTypes) > 0 {
+		filters = append(filters, "FILTER doc.entity_type IN @filter_entity_types")
+		params["filter_entity_types"] = f.entityTypes
+	}
+	filters = append(filters, f.arangoCreatedAtFilters(params)...)
+	return strings.Join(filters, "\n")
+}
+
+// arangoEdgeFilters returns AQL FILTER statements for the edge name, time
+// range, validity and as-of filters on doc, adding their bind parameters to
+// params.
+func (f *searchFilter) arangoEdgeFilters(params map[string]interface{}) string {
+	var filters []string
+	if len(f.edgeNames) > 0 {
+		filters = append(filters, "FILTER doc.name IN @filter_edge_names")
+		params["filter_edge_names"] = f.edgeNames
+	}
+	filters = append(filters, f.arangoCreatedAtFilters(params)...)
+	if f.validAt != nil {
+		if !f.validAt.End.IsZero() {
+			filters = append(filters, "FILTER doc.valid_from == null OR doc.valid_from <= @filter_valid_end")
+			params["filter_valid_end"] = f.validAt.End.UTC()
+		}
+		if !f.validAt.Start.IsZero() {
+			filters = append(filters, "FILTER doc.valid_to == null OR doc.valid_to >= @filter_valid_start")
+			params["filter_valid_start"] = f.validAt.Start.UTC()
+		}
+	}
+	if !f.asOf.IsZero() {
+		filters = append(filters,
+			"FILTER doc.valid_from == null OR doc.valid_from <= @filter_as_of",
+			"FILTER doc.valid_to == null OR doc.valid_to > @filter_as_of")
+		params["filter_as_of"] = f.asOf.UTC()
+	}
+	return strings.Join(filters, "\n")
+}
+
+// arangoCreatedAtFilters returns the AQL FILTER statements of the time range.
+func (f *searchFilter) arangoCreatedAtFilters(params map[string]interface{}) []string {
+	if f.timeRange == nil {
+		return nil
+	}
+	var filters []string
+	if !f.timeRange.Start.IsZero() {
+		filters = append(filters, "FILTER doc.created_at >= @filter_start")
+		params["filter_start"] = f.timeRange.Start.UTC()
+	}
+	if !f.timeRange.End.IsZero() {
+		filters = append(filters, "FILTER doc.created_at <= @filter_end")
+		params["filter_end"] = f.timeRange.End.UTC()
+	}
+	return filters
+}
+
+// surrealNodeConditions returns a WHERE clause fragment for the entity type
+// and time range filters, adding their variables to vars. Groups are matched
+// by the caller and node types select the tables searched.
+func (f *searchFilter) surrealNodeConditions(vars map[string]interface{}) string {
+	var conditions []string
+	if len(f.entityTypes) > 0 {
+		conditions = append(conditions, "AND entity_type IN $filter_entity_types")
+		vars["filter_entity_types"] = f.entityTypes
+	}
+	conditions = append(conditions, f.surrealCreatedAtConditions(vars)...)
+	return strings.Join(conditions, " ")
+}
+
+// surrealEdgeConditions returns a WHERE clause fragment for the edge name,
+// time range, validity and as-of filters, adding their variables to vars.
+func (f *searchFilter) surrealEdgeConditions(vars map[string]interface{}) string {
+	var conditions []string
+	if len(f.edgeNames) > 0 {
+		conditions = append(conditions, "AND name IN $filter_edge_names")
+		vars["filter_edge_names"] = f.edgeNames
+	}
+	conditions = append(conditions, f.surrealCreatedAtConditions(vars)...)
+	if f.validAt != nil {
+		if !f.validAt.End.IsZero() {
+			conditions = append(conditions, "AND (valid_from IS NONE OR valid_from <= $filter_valid_end)")
+			vars["filter_valid_end"] = f.validAt.End.UTC()
+		}
+		if !f.validAt.Start.IsZero() {
+			conditions = append(conditions, "AND (valid_to IS NONE OR valid_to >= $filter_valid_start)")
+			vars["filter_valid_start"] = f.validAt.Start.UTC()
+		}
+	}
+	if !f.asOf.IsZero() {
+		conditions = append(conditions,
+			"AND (valid_from IS NONE OR valid_from <= $filter_as_of)",
+			"AND (valid_to IS NONE OR valid_to > $filter_as_of)")
+		vars["filter_as_of"] = f.asOf.UTC()
+	}
+	return strings.Join(conditions, " ")
+}
+
+// surrealCreatedAtConditions returns the WHERE clause fragments of the time range.
+func (f *searchFilter) surrealCreatedAtConditions(vars map[string]interface{}) []string {
+	if f.timeRange == nil {
+		return nil
+	}
+	var conditions []string
+	if !f.timeRange.Start.IsZero() {
+		conditions = append(conditions, "AND created_at >= $filter_start")
+		vars["filter_start"] = f.timeRange.Start.UTC()
+	}
+	if !f.timeRange.End.IsZero() {
+		conditions = append(conditions, "AND created_at <= $filter_end")
+		vars["filter_end"] = f.timeRange.End.UTC()
+	}
+	return conditions
+}
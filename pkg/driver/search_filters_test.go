@@ -0,0 +1,156 @@
+package driver
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestSearchOptionsFilterDefaultsToGroup(t *testing.T) {
+	var options *SearchOptions
+	if got := options.filter("g1").groupIDs; !reflect.DeepEqual(got, []string{"g1"}) {
+		t.Errorf("nil options groups = %v, want [g1]", got)
+	}
+
+	filter := (&VectorSearchOptions{GroupIDs: []string{"g2", "g3"}}).filter("g1")
+	if !reflect.DeepEqual(filter.groupIDs, []string{"g2", "g3"}) {
+		t.Errorf("groups = %v, want [g2 g3]", filter.groupIDs)
+	}
+}
+
+func TestBoltNodeConditions(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := (&SearchOptions{
+		NodeTypes:   []types.NodeType{types.EntityNodeType},
+		EntityTypes: []string{"Person"},
+		TimeRange:   &types.TimeRange{Start: start},
+	}).filter("g1")
+
+	params := map[string]any{}
+	conditions := filter.boltNodeConditions("n", params)
+
+	for _, want := range []string{
+		"n.group_id IN $filter_group_ids",
+		"label IN $filter_node_labels",
+		"n.entity_type IN $filter_entity_types",
+		"n.created_at >= $filter_start",
+	} {
+		if !strings.Contains(conditions, want) {
+			t.Errorf("conditions %q missing %q", conditions, want)
+		}
+	}
+	if strings.Contains(conditions, "$filter_end") {
+		t.Errorf("open end bound produced a condition: %q", conditions)
+	}
+	if got := params["filter_node_labels"]; !reflect.DeepEqual(got, []string{"Entity"}) {
+		t.Errorf("node labels = %v, want [Entity]", got)
+	}
+	if got := params["filter_start"]; got != "2024-01-01T00:00:00Z" {
+		t.Errorf("start = %v, want RFC3339 string", got)
+	}
+}
+
+func TestBoltEdgeConditions(t *testing.T) {
+	validAt := &types.TimeRange{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	filter := (&VectorSearchOptions{
+		EdgeTypes: []types.EdgeType{types.EntityEdgeType},
+		EdgeNames: []string{"WORKS_AT"},
+		ValidAt:   validAt,
+	}).filter("g1")
+
+	params := map[string]any{}
+	conditions := filter.boltEdgeConditions("r", params)
+
+	for _, want := range []string{
+		"type(r) IN $filter_edge_relationships",
+		"r.name IN $filter_edge_names",
+		"(r.valid_from IS NULL OR r.valid_from <= $filter_valid_end)",
+		"(r.valid_to IS NULL OR r.valid_to >= $filter_valid_start)",
+	} {
+		if !strings.Contains(conditions, want) {
+			t.Errorf("conditions %q missing %q", conditions, want)
+		}
+	}
+	if got := params["filter_edge_relationships"]; !reflect.DeepEqual(got, []string{"RELATES_TO"}) {
+		t.Errorf("relationships = %v, want [RELATES_TO]", got)
+	}
+}
+
+func TestLadybugEdgeConditionsUseTimestamps(t *testing.T) {
+	end := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	filter := (&SearchOptions{ValidAt: &types.TimeRange{End: end}}).filter("g1")
+
+	params := map[string]any{}
+	conditions := filter.ladybugEdgeConditions("e", params)
+
+	if !strings.Contains(conditions, "(e.valid_at IS NULL OR e.valid_at <= $filter_valid_end)") {
+		t.Errorf("conditions %q missing validity bound", conditions)
+	}
+	if strings.Contains(conditions, "invalid_at") {
+		t.Errorf("open start bound produced a condition: %q", conditions)
+	}
+	if got := params["filter_valid_end"]; got != end {
+		t.Errorf("valid end = %v, want %v", got, end)
+	}
+}
+
+func TestDocumentStoreFilters(t *testing.T) {
+	filter := (&SearchOptions{
+		EntityTypes: []string{"Person"},
+		EdgeNames:   []string{"WORKS_AT"},
+	}).filter("g1")
+
+	params := map[string]interface{}{}
+	if got := filter.arangoNodeFilters(params); got != "FILTER doc.entity_type IN @filter_entity_types" {
+		t.Errorf("arango node filters = %q", got)
+	}
+	if got := filter.arangoEdgeFilters(params); got != "FILTER doc.name IN @filter_edge_names" {
+		t.Errorf("arango edge filters = %q", got)
+	}
+
+	vars := map[string]interface{}{}
+	if got := filter.surrealNodeConditions(vars); got != "AND entity_type IN $filter_entity_types" {
+		t.Errorf("surreal node conditions = %q", got)
+	}
+	if got := filter.surrealEdgeConditions(vars); got != "AND name IN $filter_edge_names" {
+		t.Errorf("surreal edge conditions = %q", got)
+	}
+}
+
+func TestAsOfConditions(t *testing.T) {
+	asOf := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	filter := (&VectorSearchOptions{AsOf: asOf}).filter("g1")
+
+	params := map[string]any{}
+	conditions := filter.boltEdgeConditions("r", params)
+	for _, want := range []string{
+		"(r.valid_from IS NULL OR r.valid_from <= $filter_as_of)",
+		"(r.valid_to IS NULL OR r.valid_to > $filter_as_of)",
+	} {
+		if !strings.Contains(conditions, want) {
+			t.Errorf("conditions %q missing %q", conditions, want)
+		}
+	}
+	if got := params["filter_as_of"]; got != "2024-03-01T00:00:00Z" {
+		t.Errorf("as of = %v, want RFC3339 string", got)
+	}
+
+	params = map[string]any{}
+	conditions = filter.ladybugEdgeConditions("e", params)
+	if !strings.Contains(conditions, "(e.invalid_at IS NULL OR e.invalid_at > $filter_as_of)") {
+		t.Errorf("conditions %q missing as-of bound", conditions)
+	}
+	if got := params["filter_as_of"]; got != asOf {
+		t.Errorf("as of = %v, want %v", got, asOf)
+	}
+
+	if strings.Contains((&SearchOptions{}).filter("g1").boltEdgeConditions("r", map[string]any{}), "as_of") {
+		t.Error("zero as-of time produced a condition")
+	}
+}
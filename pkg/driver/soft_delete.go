@@ -0,0 +1,295 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ErrSoftDeleteUnsupported indicates the driver cannot tombstone records.
+var ErrSoftDeleteUnsupported = errors.New("driver does not support soft deletes")
+
+// SoftDeleter is implemented by drivers that can mark deleted records with a
+// deleted_at timestamp instead of removing them (Neo4j, Memgraph and Ladybug).
+//
+// When soft deletes are enabled, DeleteNode and DeleteEdge tombstone records and
+// the typed read helpers (GetNode, GetEdges, SearchNodes, ...) skip them. Raw
+// queries run through ExecuteQuery still see tombstoned records. Upserting a
+// tombstoned record does not revive it; use RestoreDeleted.
+type SoftDeleter interface {
+	// SoftDeleteEnabled reports whether DeleteNode and DeleteEdge tombstone records.
+	SoftDeleteEnabled() bool
+	// PurgeDeleted permanently removes records tombstoned more than olderThan ago.
+	PurgeDeleted(ctx context.Context, olderThan time.Duration) error
+	// RestoreDeleted clears the tombstones of the group's records deleted at or
+	// after since, undoing for example an accidental ClearGraph.
+	RestoreDeleted(ctx context.Context, groupID string, since time.Time) error
+}
+
+// AsSoftDeleter returns the SoftDeleter behind d, looking through wrapping
+// drivers such as WrappedDriver. Its purges and restores pass through the
+// middleware chains of the WrappedDrivers looked through.
+func AsSoftDeleter(d GraphDriver) (SoftDeleter, bool) {
+	var wrappers []*WrappedDriver
+	for d != nil {
+		if softDeleter, ok := d.(SoftDeleter); ok {
+			if len(wrappers) > 0 {
+				return &chainedSoftDeleter{SoftDeleter: softDeleter, wrappers: wrappers}, true
+			}
+			return softDeleter, true
+		}
+		if wrapped, ok := d.(*WrappedDriver); ok {
+			wrappers = append(wrappers, wrapped)
+		}
+		wrapper, ok := d.(interface{ Unwrap() GraphDriver })
+		if !ok {
+			break
+		}
+		d = wrapper.Unwrap()
+	}
+	return nil, false
+}
+
+// chainedSoftDeleter is a SoftDeleter behind WrappedDrivers, whose writes
+// pass through their middleware chains.
+type chainedSoftDeleter struct {
+	SoftDeleter
+	wrappers []*WrappedDriver
+}
+
+// PurgeDeleted purges the tombstoned records through the middleware chains.
+func (s *chainedSoftDeleter) PurgeDeleted(ctx context.Context, olderThan time.Duration) error {
+	return writeThrough(ctx, s.wrappers, "PurgeDeleted", "", func(ctx context.Context) error {
+		return s.SoftDeleter.PurgeDeleted(ctx, olderThan)
+	})
+}
+
+// RestoreDeleted restores the tombstoned records of a group through the
+// middleware chains.
+func (s *chainedSoftDeleter) RestoreDeleted(ctx context.Context, groupID string, since time.Time) error {
+	return writeThrough(ctx, s.wrappers, "RestoreDeleted", groupID, func(ctx context.Context) error {
+		return s.SoftDeleter.RestoreDeleted(ctx, groupID, since)
+	})
+}
+
+// Cypher statements used by the Bolt drivers (Neo4j and Memgraph) for soft deletes.
+// deleted_at is stored as an RFC 3339 UTC string so that tombstones compare in
+// chronological order.
+const (
+	boltSoftDeleteNodeEdgesQuery = `
+		MATCH (n {uuid: $uuid, group_id: $group_id})-[r]-()
+		WHERE r.deleted_at IS NULL
+		SET r.deleted_at = $deleted_at
+	`
+	boltSoftDeleteNodeQuery = `
+		MATCH (n {uuid: $uuid, group_id: $group_id})
+		WHERE n.deleted_at IS NULL
+		SET n.deleted_at = $deleted_at
+	`
+	boltSoftDeleteEdgeQuery = `
+		MATCH ()-[r {uuid: $uuid, group_id: $group_id}]-()
+		WHERE r.deleted_at IS NULL
+		SET r.deleted_at = $deleted_at
+	`
+	boltPurgeEdgesQuery = `
+		MATCH ()-[r]->()
+		WHERE r.deleted_at IS NOT NULL AND r.deleted_at < $cutoff
+		DELETE r
+	`
+	boltPurgeNodesQuery = `
+		MATCH (n)
+		WHERE n.deleted_at IS NOT NULL AND n.deleted_at < $cutoff
+		DETACH DELETE n
+	`
+	boltRestoreEdgesQuery = `
+		MATCH ()-[r {group_id: $group_id}]->()
+		WHERE r.deleted_at IS NOT NULL AND r.deleted_at >= $since
+		REMOVE r.deleted_at
+	`
+	boltRestoreNodesQuery = `
+		MATCH (n {group_id: $group_id})
+		WHERE n.deleted_at IS NOT NULL AND n.deleted_at >= $since
+		REMOVE n.deleted_at
+	`
+)
+
+// boltTimestamp formats t the way the Bolt drivers store tombstones.
+func boltTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// runBoltWrites runs the statements in order within a single write transaction.
+func runBoltWrites(ctx context.Context, client neo4j.DriverWithContext, database string, statements []string, params map[string]any) error {
+	session := client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: database})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		for _, statement := range statements {
+			if _, err := tx.Run(ctx, statement, params); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// softDeleteParams returns the parameters of the Bolt soft delete statements.
+func softDeleteParams(uuid, groupID string) map[string]any {
+	return map[string]any{
+		"uuid":       uuid,
+		"group_id":   groupID,
+		"deleted_at": boltTimestamp(time.Now()),
+	}
+}
+
+// SoftDeleteEnabled reports whether DeleteNode and DeleteEdge tombstone records.
+func (n *Neo4jDriver) SoftDeleteEnabled() bool {
+	return n.options.SoftDelete
+}
+
+// PurgeDeleted permanently removes records tombstoned more than olderThan ago
+// from every database the driver routes to.
+func (n *Neo4jDriver) PurgeDeleted(ctx context.Context, olderThan time.Duration) error {
+	params := map[string]any{"cutoff": boltTimestamp(time.Now().Add(-olderThan))}
+	for _, database := range n.Databases() {
+		if err := runBoltWrites(ctx, n.client, database, []string{boltPurgeEdgesQuery, boltPurgeNodesQuery}, params); err != nil {
+			return fmt.Errorf("failed to purge deleted records in database %s: %w", database, err)
+		}
+	}
+	return nil
+}
+
+// RestoreDeleted clears the tombstones of the group's records deleted at or after since.
+func (n *Neo4jDriver) RestoreDeleted(ctx context.Context, groupID string, since time.Time) error {
+	params := map[string]any{"group_id": groupID, "since": boltTimestamp(since)}
+	if err := runBoltWrites(ctx, n.client, n.databaseForGroup(groupID), []string{boltRestoreNodesQuery, boltRestoreEdgesQuery}, params); err != nil {
+		return fmt.Errorf("failed to restore deleted records: %w", err)
+	}
+	return nil
+}
+
+// SoftDeleteEnabled reports whether DeleteNode and DeleteEdge tombstone records.
+func (m *MemgraphDriver) SoftDeleteEnabled() bool {
+	return m.options.SoftDelete
+}
+
+// PurgeDeleted permanently removes records tombstoned more than olderThan ago.
+func (m *MemgraphDriver) PurgeDeleted(ctx context.Context, olderThan time.Duration) error {
+	params := map[string]any{"cutoff": boltTimestamp(time.Now().Add(-olderThan))}
+	if err := runBoltWrites(ctx, m.client, m.database, []string{boltPurgeEdgesQuery, boltPurgeNodesQuery}, params); err != nil {
+		return fmt.Errorf("failed to purge deleted records: %w", err)
+	}
+	return nil
+}
+
+// RestoreDeleted clears the tombstones of the group's records deleted at or after since.
+func (m *MemgraphDriver) RestoreDeleted(ctx context.Context, groupID string, since time.Time) error {
+	params := map[string]any{"group_id": groupID, "since": boltTimestamp(since)}
+	if err := runBoltWrites(ctx, m.client, m.database, []string{boltRestoreNodesQuery, boltRestoreEdgesQuery}, params); err != nil {
+		return fmt.Errorf("failed to restore deleted records: %w", err)
+	}
+	return nil
+}
+
+// ladybugTombstoneTables are the Ladybug tables carrying a deleted_at column.
+// Entity edges are RelatesToNode_ rows, so tombstoning them hides the edge.
+var ladybugTombstoneTables = []string{"Entity", "Episodic", "Community", "RelatesToNode_"}
+
+// SoftDeleteEnabled reports whether DeleteNode and DeleteEdge tombstone records.
+func (k *LadybugDriver) SoftDeleteEnabled() bool {
+	return k.softDelete
+}
+
+// softDeleteNode tombstones the node and, for entities, the edges attached to it.
+func (k *LadybugDriver) softDeleteNode(ctx context.Context, nodeID, groupID string) error {
+	statements := k.softDeleteNodeStatements(nodeID, groupID)
+	if _, _, _, err := k.ExecuteQueryWithContext(ctx, statements[0].query, statements[0].params); err != nil {
+		return fmt.Errorf("failed to mark edges of node %s deleted: %w", nodeID, err)
+	}
+	for _, statement := range statements[1:] {
+		if _, _, _, err := k.ExecuteQueryWithContext(ctx, statement.query, statement.params); err != nil {
+			return fmt.Errorf("failed to mark node %s deleted: %w", nodeID, err)
+		}
+	}
+	return nil
+}
+
+// softDeleteNodeStatements returns the statements of softDeleteNode: the
+// tombstoning of the entity edges of the node, then of the node in each table.
+func (k *LadybugDriver) softDeleteNodeStatements(nodeID, groupID string) []graphStatement {
+	params := map[string]interface{}{
+		"uuid":       nodeID,
+		"group_id":   groupID,
+		"deleted_at": time.Now().UTC(),
+	}
+
+	edgesQuery := `
+		MATCH (n:Entity)-[:RELATES_TO]-(e:RelatesToNode_)
+		WHERE n.uuid = $uuid AND n.group_id = $group_id AND e.deleted_at IS NULL
+		SET e.deleted_at = $deleted_at
+	`
+	statements := []graphStatement{{query: edgesQuery, params: params}}
+	for _, table := range ladybugTombstoneTables {
+		query := fmt.Sprintf(`
+			MATCH (n:%s)
+			WHERE n.uuid = $uuid AND n.group_id = $group_id AND n.deleted_at IS NULL
+			SET n.deleted_at = $deleted_at
+		`, table)
+		statements = append(statements, graphStatement{query: query, params: params})
+	}
+	return statements
+}
+
+// softDeleteEdge tombstones the RelatesToNode_ row representing the edge.
+func (k *LadybugDriver) softDeleteEdge(ctx context.Context, edgeID, groupID string) error {
+	query := `
+		MATCH (e:RelatesToNode_)
+		WHERE e.uuid = $uuid AND e.group_id = $group_id AND e.deleted_at IS NULL
+		SET e.deleted_at = $deleted_at
+	`
+	params := map[string]interface{}{
+		"uuid":       edgeID,
+		"group_id":   groupID,
+		"deleted_at": time.Now().UTC(),
+	}
+	if _, _, _, err := k.ExecuteQueryWithContext(ctx, query, params); err != nil {
+		return fmt.Errorf("failed to mark edge deleted: %w", err)
+	}
+	return nil
+}
+
+// PurgeDeleted permanently removes records tombstoned more than olderThan ago.
+func (k *LadybugDriver) PurgeDeleted(ctx context.Context, olderThan time.Duration) error {
+	params := map[string]interface{}{"cutoff": time.Now().UTC().Add(-olderThan)}
+	for _, table := range ladybugTombstoneTables {
+		query := fmt.Sprintf(`
+			MATCH (n:%s)
+			WHERE n.deleted_at IS NOT NULL AND n.deleted_at < $cutoff
+			DETACH DELETE n
+		`, table)
+		if _, _, _, err := k.ExecuteQueryWithContext(ctx, query, params); err != nil {
+			return fmt.Errorf("failed to purge deleted %s records: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// RestoreDeleted clears the tombstones of the group's records deleted at or after since.
+func (k *LadybugDriver) RestoreDeleted(ctx context.Context, groupID string, since time.Time) error {
+	params := map[string]interface{}{"group_id": groupID, "since": since.UTC()}
+	for _, table := range ladybugTombstoneTables {
+		query := fmt.Sprintf(`
+			MATCH (n:%s)
+			WHERE n.group_id = $group_id AND n.deleted_at IS NOT NULL AND n.deleted_at >= $since
+			SET n.deleted_at = NULL
+		`, table)
+		if _, _, _, err := k.ExecuteQueryWithContext(ctx, query, params); err != nil {
+			return fmt.Errorf("failed to restore deleted %s records: %w", table, err)
+		}
+	}
+	return nil
+}
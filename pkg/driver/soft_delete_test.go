@@ -0,0 +1,59 @@
+package driver
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// softDeletingDriver is a GraphDriver stub implementing SoftDeleter.
+type softDeletingDriver struct {
+	GraphDriver
+	purgedOlderThan time.Duration
+}
+
+func (d *softDeletingDriver) SoftDeleteEnabled() bool { return true }
+
+func (d *softDeletingDriver) PurgeDeleted(ctx context.Context, olderThan time.Duration) error {
+	d.purgedOlderThan = olderThan
+	return nil
+}
+
+func (d *softDeletingDriver) RestoreDeleted(ctx context.Context, groupID string, since time.Time) error {
+	return nil
+}
+
+func TestAsSoftDeleterUnwrapsMiddleware(t *testing.T) {
+	inner := &softDeletingDriver{}
+
+	softDeleter, ok := AsSoftDeleter(Wrap(inner, LoggingMiddleware(nil)))
+	if !ok {
+		t.Fatal("expected wrapped driver to expose the inner SoftDeleter")
+	}
+	if err := softDeleter.PurgeDeleted(context.Background(), time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inner.purgedOlderThan != time.Hour {
+		t.Errorf("expected purge to reach the inner driver, got %v", inner.purgedOlderThan)
+	}
+}
+
+func TestAsSoftDeleterUnsupported(t *testing.T) {
+	if _, ok := AsSoftDeleter(&countingDriver{}); ok {
+		t.Error("expected driver without soft delete support to be rejected")
+	}
+	if _, ok := AsSoftDeleter(nil); ok {
+		t.Error("expected nil driver to be rejected")
+	}
+}
+
+func TestBoltTimestampOrdersChronologically(t *testing.T) {
+	// 22:30 at UTC-2 is after 23:00 UTC, although its local form sorts first
+	local := time.FixedZone("UTC-2", -2*60*60)
+	earlier := boltTimestamp(time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC))
+	later := boltTimestamp(time.Date(2024, 1, 1, 22, 30, 0, 0, local))
+
+	if earlier >= later {
+		t.Errorf("expected %s to sort before %s", earlier, later)
+	}
+}
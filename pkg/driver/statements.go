@@ -0,0 +1,87 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// graphStatement is a query and its parameters, for running several queries
+// in a single write transaction.
+type graphStatement struct {
+	query  string
+	params map[string]interface{}
+}
+
+// runBoltStatements runs the statements in order within a single write
+// transaction.
+func runBoltStatements(ctx context.Context, client neo4j.DriverWithContext, database string, statements []graphStatement) error {
+	session := client.NewSession(ctx, neo4j.SessionConfig{DatabaseName: database})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		for _, statement := range statements {
+			if _, err := tx.Run(ctx, statement.query, statement.params); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// boltUpsertNodeStatement returns the statement upserting node under label
+// with properties on the Bolt drivers. Entities with an entity type also get
+// it as a label.
+func boltUpsertNodeStatement(label string, node *types.Node, properties map[string]any) graphStatement {
+	var query string
+	if node.Type == types.EntityNodeType && node.EntityType != "" {
+		// For Entity nodes with an EntityType, set both Entity label and specific type label
+		query = fmt.Sprintf(`
+			MERGE (n:%s {uuid: $uuid, group_id: $group_id})
+			SET n:%s
+			SET n += $properties
+			SET n.updated_at = $updated_at
+		`, label, node.EntityType)
+	} else {
+		// For other node types or entities without type, use base label only
+		query = fmt.Sprintf(`
+			MERGE (n:%s {uuid: $uuid, group_id: $group_id})
+			SET n += $properties
+			SET n.updated_at = $updated_at
+		`, label)
+	}
+
+	return graphStatement{query: query, params: map[string]any{
+		"uuid":       node.Uuid,
+		"group_id":   node.GroupID,
+		"properties": properties,
+		"updated_at": time.Now().Format(time.RFC3339),
+	}}
+}
+
+// boltUpsertEdgeStatement returns the statement upserting the RELATES_TO edge
+// with properties on the Bolt drivers.
+func boltUpsertEdgeStatement(edge *types.Edge, properties map[string]any) graphStatement {
+	query := `
+		MATCH (s {uuid: $source_id, group_id: $group_id})
+		MATCH (t {uuid: $target_id, group_id: $group_id})
+		MERGE (s)-[r:RELATES_TO {uuid: $uuid, group_id: $group_id}]->(t)
+		SET r += $properties
+		SET r.updated_at = $updated_at
+	`
+
+	return graphStatement{query: query, params: map[string]any{
+		"uuid":       edge.Uuid,
+		"source_id":  edge.SourceID,
+		"target_id":  edge.TargetID,
+		"group_id":   edge.GroupID,
+		"fact":       edge.Fact,
+		"name":       edge.Name,
+		"properties": properties,
+		"updated_at": time.Now().Format(time.RFC3339),
+	}}
+}
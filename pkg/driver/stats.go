@@ -0,0 +1,142 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/db"
+)
+
+// statsDayLayout is the key format of GraphStats.EpisodesPerDay.
+const statsDayLayout = "2006-01-02"
+
+// statsDay returns the GraphStats.EpisodesPerDay key for t.
+func statsDay(t time.Time) string {
+	return t.UTC().Format(statsDayLayout)
+}
+
+// firstNonZeroTime returns the first of times that is set.
+func firstNonZeroTime(times ...time.Time) time.Time {
+	for _, t := range times {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// add sums the counts of other into s, for stats gathered from several
+// databases.
+func (s *GraphStats) add(other *GraphStats) {
+	s.NodeCount += other.NodeCount
+	s.EdgeCount += other.EdgeCount
+	s.CommunityCount += other.CommunityCount
+	s.ValidEdgeCount += other.ValidEdgeCount
+	s.InvalidatedEdgeCount += other.InvalidatedEdgeCount
+	s.NodesWithoutEmbedding += other.NodesWithoutEmbedding
+	s.EdgesWithoutEmbedding += other.EdgesWithoutEmbedding
+
+	addCounts := func(counts, otherCounts map[string]int64) {
+		for key, count := range otherCounts {
+			counts[key] += count
+		}
+	}
+	addCounts(s.NodesByType, other.NodesByType)
+	addCounts(s.EdgesByType, other.EdgesByType)
+	addCounts(s.NodesByGroup, other.NodesByGroup)
+	addCounts(s.EdgesByGroup, other.EdgesByGroup)
+	addCounts(s.EpisodesPerDay, other.EpisodesPerDay)
+}
+
+// boltStatsFilter restricts a stats query on variable to $groupID, or to every
+// group when it is empty, skipping tombstoned records and ungrouped bookkeeping
+// nodes such as SchemaVersion.
+func boltStatsFilter(variable string) string {
+	return fmt.Sprintf("%[1]s.group_id IS NOT NULL AND ($groupID = '' OR %[1]s.group_id = $groupID) AND %[1]s.deleted_at IS NULL", variable)
+}
+
+// boltStatsBreakdownQueries compute the per-group, validity, episode and embedding
+// breakdowns of GraphStats on Neo4j and Memgraph. Timestamps are stored as
+// RFC 3339 strings, so the first ten characters are the episode day.
+var boltStatsBreakdownQueries = map[string]string{
+	"nodes_by_group": `
+		MATCH (n)
+		WHERE ` + boltStatsFilter("n") + `
+		RETURN n.group_id AS key, count(n) AS count
+	`,
+	"edges_by_group": `
+		MATCH ()-[r]->()
+		WHERE ` + boltStatsFilter("r") + `
+		RETURN r.group_id AS key, count(r) AS count
+	`,
+	"episodes_per_day": `
+		MATCH (n:Episodic)
+		WHERE ` + boltStatsFilter("n") + `
+		WITH substring(toString(coalesce(n.reference, n.valid_from, n.created_at)), 0, 10) AS key
+		RETURN key, count(*) AS count
+	`,
+	"entity_edges": `
+		MATCH (:Entity)-[r:RELATES_TO]->(:Entity)
+		WHERE ` + boltStatsFilter("r") + `
+		RETURN count(CASE WHEN r.invalid_at IS NULL AND r.expired_at IS NULL THEN 1 END) AS valid,
+		       count(CASE WHEN r.invalid_at IS NOT NULL OR r.expired_at IS NOT NULL THEN 1 END) AS invalidated,
+		       count(CASE WHEN r.fact_embedding IS NULL AND r.embedding IS NULL THEN 1 END) AS without_embedding
+	`,
+	"nodes_without_embedding": `
+		MATCH (n)
+		WHERE (n:Entity OR n:Community) AND ` + boltStatsFilter("n") + `
+		RETURN count(CASE WHEN n.name_embedding IS NULL AND n.embedding IS NULL THEN 1 END) AS without_embedding
+	`,
+}
+
+// collectBoltStatsBreakdown adds the breakdowns of boltStatsBreakdownQueries to stats.
+func collectBoltStatsBreakdown(ctx context.Context, session neo4j.SessionWithContext, groupID string, stats *GraphStats) error {
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records := make(map[string][]*db.Record, len(boltStatsBreakdownQueries))
+		for name, query := range boltStatsBreakdownQueries {
+			res, err := tx.Run(ctx, query, map[string]any{"groupID": groupID})
+			if err != nil {
+				return nil, fmt.Errorf("failed to query %s: %w", name, err)
+			}
+			if records[name], err = res.Collect(ctx); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+		}
+		return records, nil
+	})
+	if err != nil {
+		return err
+	}
+	records := result.(map[string][]*db.Record)
+
+	countsByKey := func(records []*db.Record, counts map[string]int64) {
+		for _, record := range records {
+			key, _ := record.AsMap()["key"].(string)
+			if key != "" {
+				counts[key] += recordInt64(record, "count")
+			}
+		}
+	}
+	countsByKey(records["nodes_by_group"], stats.NodesByGroup)
+	countsByKey(records["edges_by_group"], stats.EdgesByGroup)
+	countsByKey(records["episodes_per_day"], stats.EpisodesPerDay)
+
+	for _, record := range records["entity_edges"] {
+		stats.ValidEdgeCount = recordInt64(record, "valid")
+		stats.InvalidatedEdgeCount = recordInt64(record, "invalidated")
+		stats.EdgesWithoutEmbedding = recordInt64(record, "without_embedding")
+	}
+	for _, record := range records["nodes_without_embedding"] {
+		stats.NodesWithoutEmbedding = recordInt64(record, "without_embedding")
+	}
+
+	return nil
+}
+
+// recordInt64 returns the integer value of key in record, or 0.
+func recordInt64(record *db.Record, key string) int64 {
+	value, _ := record.AsMap()[key].(int64)
+	return value
+}
@@ -0,0 +1,45 @@
+package driver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGraphStatsAdd(t *testing.T) {
+	stats := newGraphStats()
+	for _, groupID := range []string{"a", "b"} {
+		databaseStats := newGraphStats()
+		databaseStats.NodeCount = 3
+		databaseStats.EdgeCount = 2
+		databaseStats.CommunityCount = 1
+		databaseStats.ValidEdgeCount = 1
+		databaseStats.InvalidatedEdgeCount = 1
+		databaseStats.NodesWithoutEmbedding = 1
+		databaseStats.NodesByType["Entity"] = 2
+		databaseStats.NodesByType["Community"] = 1
+		databaseStats.EdgesByType["RELATES_TO"] = 2
+		databaseStats.NodesByGroup[groupID] = 3
+		databaseStats.EdgesByGroup[groupID] = 2
+		databaseStats.EpisodesPerDay["2024-06-01"] = 1
+		stats.add(databaseStats)
+	}
+
+	if stats.NodeCount != 6 || stats.EdgeCount != 4 || stats.CommunityCount != 2 {
+		t.Errorf("counts = %d nodes, %d edges, %d communities, want 6, 4 and 2", stats.NodeCount, stats.EdgeCount, stats.CommunityCount)
+	}
+	if stats.ValidEdgeCount != 2 || stats.InvalidatedEdgeCount != 2 || stats.NodesWithoutEmbedding != 2 || stats.EdgesWithoutEmbedding != 0 {
+		t.Errorf("breakdown = %+v, want the counts of both databases summed", stats)
+	}
+	if want := map[string]int64{"Entity": 4, "Community": 2}; !reflect.DeepEqual(stats.NodesByType, want) {
+		t.Errorf("NodesByType = %v, want %v", stats.NodesByType, want)
+	}
+	if want := map[string]int64{"a": 3, "b": 3}; !reflect.DeepEqual(stats.NodesByGroup, want) {
+		t.Errorf("NodesByGroup = %v, want %v", stats.NodesByGroup, want)
+	}
+	if want := map[string]int64{"a": 2, "b": 2}; !reflect.DeepEqual(stats.EdgesByGroup, want) {
+		t.Errorf("EdgesByGroup = %v, want %v", stats.EdgesByGroup, want)
+	}
+	if stats.EdgesByType["RELATES_TO"] != 4 || stats.EpisodesPerDay["2024-06-01"] != 2 {
+		t.Errorf("EdgesByType = %v, EpisodesPerDay = %v, want both summed", stats.EdgesByType, stats.EpisodesPerDay)
+	}
+}
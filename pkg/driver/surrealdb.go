@@ -0,0 +1,1540 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// SurrealDB table names. Nodes are stored in normal tables named after their
+// label and relationships in relation tables created with RELATE, so graph
+// traversal (->RELATES_TO->Entity) works natively.
+const (
+	surrealEntityTable    = "Entity"
+	surrealEpisodicTable  = "Episodic"
+	surrealCommunityTable = "Community"
+	surrealRelatesToTable = "RELATES_TO"
+	surrealMentionsTable  = "MENTIONS"
+	surrealHasMemberTable = "HAS_MEMBER"
+)
+
+var (
+	surrealNodeTables = []string{surrealEntityTable, surrealEpisodicTable, surrealCommunityTable}
+	surrealEdgeTables = []string{surrealRelatesToTable, surrealMentionsTable, surrealHasMemberTable}
+
+	// surrealSearchFields lists the full-text indexed fields of each table.
+	surrealSearchFields = map[string][]string{
+		surrealEntityTable:    {"name", "summary"},
+		surrealEpisodicTable:  {"name", "content"},
+		surrealCommunityTable: {"name", "summary"},
+		surrealRelatesToTable: {"name", "fact"},
+	}
+)
+
+// SurrealError is an error returned by SurrealDB, either for the whole request
+// or for a single statement.
+type SurrealError struct {
+	Code    int
+	Message string
+}
+
+func (e *SurrealError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("surrealdb error %d: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("surrealdb error: %s", e.Message)
+}
+
+// SurrealDBDriverConfig holds configuration options for SurrealDBDriver
+type SurrealDBDriverConfig struct {
+	// Server endpoint (defaults to "http://localhost:8000")
+	Endpoint string
+
+	// Namespace (defaults to "predicato")
+	Namespace string
+
+	// Database name (defaults to "predicato")
+	Database string
+
+	// Credentials for HTTP basic authentication (optional)
+	Username string
+	Password string
+
+	// Name of the full-text analyzer defined by CreateIndices (defaults to "predicato")
+	Analyzer string
+
+	// Timeout for each HTTP request (defaults to 1 minute)
+	RequestTimeout time.Duration
+
+	// HTTPClient overrides the HTTP client used to talk to the server (optional)
+	HTTPClient *http.Client
+}
+
+// DefaultSurrealDBDriverConfig returns a SurrealDBDriverConfig with sensible defaults
+func DefaultSurrealDBDriverConfig() *SurrealDBDriverConfig {
+	return &SurrealDBDriverConfig{
+		Endpoint:       "http://localhost:8000",
+		Namespace:      "predicato",
+		Database:       "predicato",
+		Analyzer:       "predicato",
+		RequestTimeout: 1 * time.Minute,
+	}
+}
+
+// SurrealDBDriver implements the GraphDriver interface for SurrealDB (2.x) over
+// the HTTP RPC endpoint. Graph edges are SurrealDB relations, text search uses
+// BM25 full-text indexes, and embedding search uses vector::similarity::cosine,
+// so a single server covers both storage and retrieval.
+//
+// ExecuteQuery accepts SurrealQL rather than Cypher; kwargs are passed as query variables.
+type SurrealDBDriver struct {
+	config    *SurrealDBDriverConfig
+	client    *http.Client
+	requestID atomic.Int64
+}
+
+// NewSurrealDBDriver creates a new SurrealDB driver instance.
+func NewSurrealDBDriver(endpoint, username, password, namespace, database string) (*SurrealDBDriver, error) {
+	config := DefaultSurrealDBDriverConfig()
+	if endpoint != "" {
+		config.Endpoint = endpoint
+	}
+	if namespace != "" {
+		config.Namespace = namespace
+	}
+	if database != "" {
+		config.Database = database
+	}
+	config.Username = username
+	config.Password = password
+	return NewSurrealDBDriverWithConfig(config)
+}
+
+// NewSurrealDBDriverWithConfig creates a new SurrealDB driver instance with the given
+// configuration. Connections are established lazily; call HealthCheck to verify
+// the server is reachable and CreateIndices to define the tables and indexes.
+func NewSurrealDBDriverWithConfig(config *SurrealDBDriverConfig) (*SurrealDBDriver, error) {
+	if config == nil {
+		config = DefaultSurrealDBDriverConfig()
+	}
+
+	cfg := *config
+	defaults := DefaultSurrealDBDriverConfig()
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = defaults.Endpoint
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = defaults.Namespace
+	}
+	if cfg.Database == "" {
+		cfg.Database = defaults.Database
+	}
+	if cfg.Analyzer == "" {
+		cfg.Analyzer = defaults.Analyzer
+	}
+	if cfg.RequestTimeout <= 0 {
+		cfg.RequestTimeout = defaults.RequestTimeout
+	}
+
+	if _, err := url.Parse(cfg.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid surrealdb endpoint: %w", err)
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: cfg.RequestTimeout}
+	}
+
+	return &SurrealDBDriver{
+		config: &cfg,
+		client: client,
+	}, nil
+}
+
+// surrealStatementResult is the result of a single statement in a query.
+type surrealStatementResult struct {
+	Status string          `json:"status"`
+	Time   string          `json:"time"`
+	Result json.RawMessage `json:"result"`
+}
+
+// query runs SurrealQL with the given variables and returns the result of the
+// last statement. Any failed statement fails the whole query.
+func (s *SurrealDBDriver) query(ctx context.Context, surql string, vars map[string]interface{}) (json.RawMessage, error) {
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"id":     s.requestID.Add(1),
+		"method": "query",
+		"params": []interface{}{surql, vars},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.Endpoint+"/rpc", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Surreal-NS", s.config.Namespace)
+	req.Header.Set("Surreal-DB", s.config.Database)
+	if s.config.Username != "" {
+		req.SetBasicAuth(s.config.Username, s.config.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("surrealdb request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var rpc struct {
+		Result []surrealStatementResult `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &rpc); err != nil {
+		if resp.StatusCode >= 400 {
+			return nil, &SurrealError{Code: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if rpc.Error != nil {
+		return nil, &SurrealError{Code: rpc.Error.Code, Message: rpc.Error.Message}
+	}
+	if resp.StatusCode >= 400 {
+		return nil, &SurrealError{Code: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+
+	for _, statement := range rpc.Result {
+		if statement.Status != "OK" {
+			var message string
+			if err := json.Unmarshal(statement.Result, &message); err != nil {
+				message = string(statement.Result)
+			}
+			return nil, &SurrealError{Message: message}
+		}
+	}
+	if len(rpc.Result) == 0 {
+		return json.RawMessage("[]"), nil
+	}
+	return rpc.Result[len(rpc.Result)-1].Result, nil
+}
+
+// queryRows runs SurrealQL and returns the rows of the last statement. A
+// non-array result is returned as a single row.
+func (s *SurrealDBDriver) queryRows(ctx context.Context, surql string, vars map[string]interface{}) ([]json.RawMessage, error) {
+	result, err := s.query(ctx, surql, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(result)
+	if len(trimmed) == 0 || string(trimmed) == "null" {
+		return nil, nil
+	}
+	if trimmed[0] != '[' {
+		return []json.RawMessage{trimmed}, nil
+	}
+
+	var rows []json.RawMessage
+	if err := json.Unmarshal(trimmed, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode result: %w", err)
+	}
+	return rows, nil
+}
+
+// ExecuteQuery executes SurrealQL with kwargs as query variables. The rows of
+// the last statement are returned as []map[string]interface{}; rows that are not
+// objects are returned under the key "value". Summary and keys are nil.
+func (s *SurrealDBDriver) ExecuteQuery(surql string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	return s.executeQuery(context.Background(), surql, kwargs)
+}
+
+func (s *SurrealDBDriver) executeQuery(ctx context.Context, surql string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	rows, err := s.queryRows(ctx, surql, kwargs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	records := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		var value interface{}
+		if err := json.Unmarshal(row, &value); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to decode result row: %w", err)
+		}
+		if record, ok := value.(map[string]interface{}); ok {
+			records = append(records, record)
+		} else {
+			records = append(records, map[string]interface{}{"value": value})
+		}
+	}
+
+	return records, nil, nil, nil
+}
+
+// Record mapping
+
+// surrealNodeTable returns the table that stores nodes of the given type.
+func surrealNodeTable(nodeType types.NodeType) string {
+	switch nodeType {
+	case types.EpisodicNodeType:
+		return surrealEpisodicTable
+	case types.CommunityNodeType:
+		return surrealCommunityTable
+	default:
+		return surrealEntityTable
+	}
+}
+
+// surrealEdgeTable returns the relation table and the source and target node
+// tables for edges of the given type.
+func surrealEdgeTable(edgeType types.EdgeType) (table, from, to string) {
+	switch edgeType {
+	case types.EpisodicEdgeType:
+		return surrealMentionsTable, surrealEpisodicTable, surrealEntityTable
+	case types.CommunityEdgeType:
+		return surrealHasMemberTable, surrealCommunityTable, surrealEntityTable
+	default:
+		return surrealRelatesToTable, surrealEntityTable, surrealEntityTable
+	}
+}
+
+// surrealKeyFromID returns the key of a record ID such as "Entity:⟨uuid⟩" or "Entity:`uuid`".
+func surrealKeyFromID(id string) string {
+	if i := strings.IndexByte(id, ':'); i >= 0 {
+		id = id[i+1:]
+	}
+	id = strings.TrimPrefix(strings.TrimSuffix(id, "⟩"), "⟨")
+	return strings.Trim(id, "`")
+}
+
+// decodeSurrealNodes decodes node records.
+func decodeSurrealNodes(rows []json.RawMessage) ([]*types.Node, error) {
+	nodes := make([]*types.Node, 0, len(rows))
+	for _, row := range rows {
+		if string(row) == "null" {
+			continue
+		}
+		var record struct {
+			types.Node
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(row, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode node: %w", err)
+		}
+		node := record.Node
+		if node.Uuid == "" {
+			node.Uuid = surrealKeyFromID(record.ID)
+		}
+		nodes = append(nodes, &node)
+	}
+	return nodes, nil
+}
+
+// decodeSurrealEdges decodes relation records, filling in the endpoints from in and out.
+func decodeSurrealEdges(rows []json.RawMessage) ([]*types.Edge, error) {
+	edges := make([]*types.Edge, 0, len(rows))
+	for _, row := range rows {
+		if string(row) == "null" {
+			continue
+		}
+		var record struct {
+			types.EntityEdge
+			ID  string `json:"id"`
+			In  string `json:"in"`
+			Out string `json:"out"`
+		}
+		if err := json.Unmarshal(row, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode edge: %w", err)
+		}
+		edge := record.EntityEdge
+		if edge.Uuid == "" {
+			edge.Uuid = surrealKeyFromID(record.ID)
+		}
+		if edge.SourceNodeID == "" {
+			edge.SourceNodeID = surrealKeyFromID(record.In)
+		}
+		if edge.TargetNodeID == "" {
+			edge.TargetNodeID = surrealKeyFromID(record.Out)
+		}
+		if edge.SourceID == "" {
+			edge.SourceID = edge.SourceNodeID
+		}
+		if edge.TargetID == "" {
+			edge.TargetID = edge.TargetNodeID
+		}
+		edges = append(edges, &edge)
+	}
+	return edges, nil
+}
+
+// Node operations
+
+// GetNode retrieves a node by ID.
+func (s *SurrealDBDriver) GetNode(ctx context.Context, nodeID, groupID string) (*types.Node, error) {
+	nodes, err := s.queryNodes(ctx, `
+		SELECT * FROM Entity, Episodic, Community
+		WHERE uuid = $uuid AND group_id = $group_id
+		LIMIT 1
+	`, map[string]interface{}{
+		"uuid":     nodeID,
+		"group_id": groupID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, ErrNodeNotFound
+	}
+	return nodes[0], nil
+}
+
+// UpsertNode creates or replaces a node.
+func (s *SurrealDBDriver) UpsertNode(ctx context.Context, node *types.Node) error {
+	if node == nil {
+		return fmt.Errorf("node cannot be nil")
+	}
+	if err := s.upsertNodes(ctx, surrealNodeTable(node.Type), []interface{}{utcNode(node)}); err != nil {
+		return fmt.Errorf("failed to upsert node: %w", err)
+	}
+	return nil
+}
+
+// DeleteNode deletes a node; its relations are deleted with it.
+func (s *SurrealDBDriver) DeleteNode(ctx context.Context, nodeID, groupID string) error {
+	_, err := s.query(ctx, `
+		DELETE Entity, Episodic, Community WHERE uuid = $uuid AND group_id = $group_id
+	`, map[string]interface{}{
+		"uuid":     nodeID,
+		"group_id": groupID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete node: %w", err)
+	}
+	return nil
+}
+
+// GetNodes retrieves multiple nodes by their IDs.
+func (s *SurrealDBDriver) GetNodes(ctx context.Context, nodeIDs []string, groupID string) ([]*types.Node, error) {
+	if len(nodeIDs) == 0 {
+		return []*types.Node{}, nil
+	}
+	return s.queryNodes(ctx, `
+		SELECT * FROM Entity, Episodic, Community
+		WHERE uuid IN $uuids AND group_id = $group_id
+	`, map[string]interface{}{
+		"uuids":    nodeIDs,
+		"group_id": groupID,
+	})
+}
+
+// Edge operations
+
+// GetEdge retrieves an edge by ID.
+func (s *SurrealDBDriver) GetEdge(ctx context.Context, edgeID, groupID string) (*types.Edge, error) {
+	edges, err := s.queryEdges(ctx, `
+		SELECT * FROM RELATES_TO, MENTIONS, HAS_MEMBER
+		WHERE uuid = $uuid AND group_id = $group_id
+		LIMIT 1
+	`, map[string]interface{}{
+		"uuid":     edgeID,
+		"group_id": groupID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(edges) == 0 {
+		return nil, ErrEdgeNotFound
+	}
+	return edges[0], nil
+}
+
+// UpsertEdge creates or replaces an edge.
+func (s *SurrealDBDriver) UpsertEdge(ctx context.Context, edge *types.Edge) error {
+	if edge == nil {
+		return fmt.Errorf("edge cannot be nil")
+	}
+	if err := s.UpsertEdges(ctx, []*types.Edge{edge}); err != nil {
+		return fmt.Errorf("failed to upsert edge: %w", err)
+	}
+	return nil
+}
+
+// UpsertEpisodicEdge creates a MENTIONS relation from an episode to an entity if it does not exist.
+func (s *SurrealDBDriver) UpsertEpisodicEdge(ctx context.Context, episodeUUID, entityUUID, groupID string) error {
+	_, err := s.query(ctx, `
+		LET $episode = (SELECT VALUE id FROM Episodic WHERE uuid = $episode_uuid AND group_id = $group_id LIMIT 1)[0];
+		LET $entity = (SELECT VALUE id FROM Entity WHERE uuid = $entity_uuid AND group_id = $group_id LIMIT 1)[0];
+		IF $episode != NONE AND $entity != NONE AND count(SELECT id FROM MENTIONS WHERE in = $episode AND out = $entity) = 0 {
+			RELATE $episode->MENTIONS->$entity CONTENT {
+				group_id: $group_id,
+				type: $type,
+				created_at: $created_at
+			};
+		};
+	`, map[string]interface{}{
+		"episode_uuid": episodeUUID,
+		"entity_uuid":  entityUUID,
+		"group_id":     groupID,
+		"type":         string(types.EpisodicEdgeType),
+		"created_at":   time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert episodic edge: %w", err)
+	}
+	return nil
+}
+
+// UpsertCommunityEdge creates a HAS_MEMBER relation from a community to an entity
+// or community if it does not exist.
+func (s *SurrealDBDriver) UpsertCommunityEdge(ctx context.Context, communityUUID, nodeUUID, uuid, groupID string) error {
+	_, err := s.query(ctx, `
+		LET $community = (SELECT VALUE id FROM Community WHERE uuid = $community_uuid AND group_id = $group_id LIMIT 1)[0];
+		LET $member = (SELECT VALUE id FROM Entity, Community WHERE uuid = $node_uuid AND group_id = $group_id LIMIT 1)[0];
+		IF $community != NONE AND $member != NONE AND count(SELECT id FROM HAS_MEMBER WHERE uuid = $uuid) = 0 {
+			RELATE $community->HAS_MEMBER->$member CONTENT {
+				uuid: $uuid,
+				group_id: $group_id,
+				type: $type,
+				created_at: $created_at
+			};
+		};
+	`, map[string]interface{}{
+		"community_uuid": communityUUID,
+		"node_uuid":      nodeUUID,
+		"uuid":           uuid,
+		"group_id":       groupID,
+		"type":           string(types.CommunityEdgeType),
+		"created_at":     time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert community edge: %w", err)
+	}
+	return nil
+}
+
+// DeleteEdge deletes an edge by ID.
+func (s *SurrealDBDriver) DeleteEdge(ctx context.Context, edgeID, groupID string) error {
+	_, err := s.query(ctx, `
+		DELETE RELATES_TO, MENTIONS, HAS_MEMBER WHERE uuid = $uuid AND group_id = $group_id
+	`, map[string]interface{}{
+		"uuid":     edgeID,
+		"group_id": groupID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete edge: %w", err)
+	}
+	return nil
+}
+
+// GetEdges retrieves multiple edges by their IDs.
+func (s *SurrealDBDriver) GetEdges(ctx context.Context, edgeIDs []string, groupID string) ([]*types.Edge, error) {
+	if len(edgeIDs) == 0 {
+		return []*types.Edge{}, nil
+	}
+	return s.queryEdges(ctx, `
+		SELECT * FROM RELATES_TO, MENTIONS, HAS_MEMBER
+		WHERE uuid IN $uuids AND group_id = $group_id
+	`, map[string]interface{}{
+		"uuids":    edgeIDs,
+		"group_id": groupID,
+	})
+}
+
+// Graph traversal operations
+
+// GetNeighbors retrieves the nodes within maxDistance hops of a node, expanding
+// one hop per query.
+func (s *SurrealDBDriver) GetNeighbors(ctx context.Context, nodeID, groupID string, maxDistance int) ([]*types.Node, error) {
+	if maxDistance <= 0 {
+		maxDistance = 1
+	}
+
+	visited := map[string]bool{nodeID: true}
+	frontier := []string{nodeID}
+	var found []string
+
+	for depth := 0; depth < maxDistance && len(frontier) > 0; depth++ {
+		next, err := s.adjacentUUIDs(ctx, frontier, groupID, surrealEdgeTables)
+		if err != nil {
+			return nil, err
+		}
+		frontier = nil
+		for _, uuid := range next {
+			if !visited[uuid] {
+				visited[uuid] = true
+				frontier = append(frontier, uuid)
+				found = append(found, uuid)
+			}
+		}
+	}
+
+	return s.GetNodes(ctx, found, groupID)
+}
+
+// GetRelatedNodes retrieves the direct neighbors of a node, optionally restricted to edge types.
+func (s *SurrealDBDriver) GetRelatedNodes(ctx context.Context, nodeID, groupID string, edgeTypes []types.EdgeType) ([]*types.Node, error) {
+	tables := surrealEdgeTables
+	if len(edgeTypes) > 0 {
+		tables = nil
+		seen := make(map[string]bool)
+		for _, edgeType := range edgeTypes {
+			table, _, _ := surrealEdgeTable(edgeType)
+			if !seen[table] {
+				seen[table] = true
+				tables = append(tables, table)
+			}
+		}
+	}
+
+	uuids, err := s.adjacentUUIDs(ctx, []string{nodeID}, groupID, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	related := make([]string, 0, len(uuids))
+	for _, uuid := range uuids {
+		if uuid != nodeID {
+			related = append(related, uuid)
+		}
+	}
+	return s.GetNodes(ctx, related, groupID)
+}
+
+// adjacentUUIDs returns the distinct UUIDs of nodes one hop away from the given
+// nodes over the given relation tables, in either direction.
+func (s *SurrealDBDriver) adjacentUUIDs(ctx context.Context, uuids []string, groupID string, tables []string) ([]string, error) {
+	// Table names cannot be bound as variables in graph paths, but they come
+	// from the fixed set of relation tables.
+	paths := make([]string, 0, len(tables)*2)
+	for _, table := range tables {
+		paths = append(paths, fmt.Sprintf("->%s->?.uuid", table), fmt.Sprintf("<-%s<-?.uuid", table))
+	}
+
+	rows, err := s.queryRows(ctx, fmt.Sprintf(`
+		SELECT VALUE array::distinct(array::flatten([%s]))
+		FROM Entity, Episodic, Community
+		WHERE uuid IN $uuids AND group_id = $group_id
+	`, strings.Join(paths, ", ")), map[string]interface{}{
+		"uuids":    uuids,
+		"group_id": groupID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to traverse graph: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var adjacent []string
+	for _, row := range rows {
+		var ids []string
+		if err := json.Unmarshal(row, &ids); err != nil {
+			return nil, fmt.Errorf("failed to decode neighbors: %w", err)
+		}
+		for _, id := range ids {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				adjacent = append(adjacent, id)
+			}
+		}
+	}
+	return adjacent, nil
+}
+
+// GetNodeNeighbors returns the entities connected to an entity through RELATES_TO
+// relations together with the number of connecting relations.
+func (s *SurrealDBDriver) GetNodeNeighbors(ctx context.Context, nodeUUID, groupID string) ([]types.Neighbor, error) {
+	rows, err := s.queryRows(ctx, `
+		SELECT VALUE array::concat(
+			->RELATES_TO->(Entity WHERE group_id = $group_id).uuid,
+			<-RELATES_TO<-(Entity WHERE group_id = $group_id).uuid
+		)
+		FROM Entity
+		WHERE uuid = $uuid AND group_id = $group_id
+	`, map[string]interface{}{
+		"uuid":     nodeUUID,
+		"group_id": groupID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute neighbor query: %w", err)
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	for _, row := range rows {
+		var ids []string
+		if err := json.Unmarshal(row, &ids); err != nil {
+			return nil, fmt.Errorf("failed to decode neighbors: %w", err)
+		}
+		for _, id := range ids {
+			if counts[id] == 0 {
+				order = append(order, id)
+			}
+			counts[id]++
+		}
+	}
+
+	neighbors := make([]types.Neighbor, 0, len(order))
+	for _, id := range order {
+		neighbors = append(neighbors, types.Neighbor{NodeUUID: id, EdgeCount: counts[id]})
+	}
+	return neighbors, nil
+}
+
+// GetBetweenNodes retrieves the RELATES_TO relations of groupID between two entities in either direction.
+func (s *SurrealDBDriver) GetBetweenNodes(ctx context.Context, sourceNodeID, targetNodeID, groupID string) ([]*types.Edge, error) {
+	edges, err := s.queryEdges(ctx, `
+		SELECT * FROM RELATES_TO
+		WHERE group_id = $group_id
+			AND ((in.uuid = $source AND out.uuid = $target) OR (in.uuid = $target AND out.uuid = $source))
+	`, map[string]interface{}{
+		"source":   sourceNodeID,
+		"target":   targetNodeID,
+		"group_id": groupID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GetBetweenNodes query: %w", err)
+	}
+	return edges, nil
+}
+
+// Search operations
+
+// SearchNodesByEmbedding finds the entities whose name embedding is most similar to embedding.
+func (s *SurrealDBDriver) SearchNodesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int) ([]*types.Node, error) {
+	return s.SearchNodesByVector(ctx, embedding, groupID, &VectorSearchOptions{
+		Limit:     limit,
+		NodeTypes: []types.NodeType{types.EntityNodeType},
+	})
+}
+
+// SearchEdgesByEmbedding finds the RELATES_TO relations whose fact embedding is most similar to embedding.
+func (s *SurrealDBDriver) SearchEdgesByEmbedding(ctx context.Context, embedding []float32, groupID string, limit int) ([]*types.Edge, error) {
+	return s.SearchEdgesByVector(ctx, embedding, groupID, &VectorSearchOptions{
+		Limit:     limit,
+		EdgeTypes: []types.EdgeType{types.EntityEdgeType},
+	})
+}
+
+// SearchNodes performs BM25-ranked full-text search on nodes.
+func (s *SurrealDBDriver) SearchNodes(ctx context.Context, query, groupID string, options *SearchOptions) ([]*types.Node, error) {
+	if options == nil {
+		options = &SearchOptions{}
+	}
+
+	tables := surrealNodeTables
+	if len(options.NodeTypes) > 0 {
+		tables = nil
+		for _, nodeType := range options.NodeTypes {
+			tables = append(tables, surrealNodeTable(nodeType))
+		}
+	}
+
+	filter := options.filter(groupID)
+	rows, err := s.textSearch(ctx, tables, query, filter.groupIDs, filter.surrealNodeConditions, options.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nodes: %w", err)
+	}
+	return decodeSurrealNodes(rows)
+}
+
+// SearchEdges performs BM25-ranked full-text search on RELATES_TO relations.
+func (s *SurrealDBDriver) SearchEdges(ctx context.Context, query, groupID string, options *SearchOptions) ([]*types.Edge, error) {
+	if options == nil {
+		options = &SearchOptions{}
+	}
+
+	filter := options.filter(groupID)
+	if !filter.allowsEdgeType(types.EntityEdgeType) {
+		return []*types.Edge{}, nil
+	}
+	rows, err := s.textSearch(ctx, []string{surrealRelatesToTable}, query, filter.groupIDs, filter.surrealEdgeConditions, options.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search edges: %w", err)
+	}
+	return decodeSurrealEdges(rows)
+}
+
+// textSearch ranks the records of groupIDs in each table with the BM25 scores
+// of its full-text indexes and returns the best matches across all tables.
+// filters adds the WHERE clause fragment of the search filters.
+func (s *SurrealDBDriver) textSearch(ctx context.Context, tables []string, query string, groupIDs []string, filters func(map[string]interface{}) string, limit int) ([]json.RawMessage, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var matches []surrealScoredRecord
+	for _, table := range tables {
+		fields := surrealSearchFields[table]
+		if len(fields) == 0 {
+			continue
+		}
+
+		conditions := make([]string, len(fields))
+		scores := make([]string, len(fields))
+		for i, field := range fields {
+			conditions[i] = fmt.Sprintf("%s @%d@ $query", field, i)
+			scores[i] = fmt.Sprintf("search::score(%d)", i)
+		}
+
+		vars := map[string]interface{}{
+			"query":     query,
+			"group_ids": groupIDs,
+			"limit":     limit,
+		}
+		rows, err := s.queryRows(ctx, fmt.Sprintf(`
+			SELECT *, %s AS score FROM %s
+			WHERE group_id IN $group_ids AND (%s) %s
+			ORDER BY score DESC
+			LIMIT $limit
+		`, strings.Join(scores, " + "), table, strings.Join(conditions, " OR "), filters(vars)), vars)
+		if err != nil {
+			return nil, err
+		}
+
+		scored, err := decodeSurrealScores(rows)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, scored...)
+	}
+
+	return topSurrealRecords(matches, limit), nil
+}
+
+// SearchNodesByVector finds the nodes whose name embedding (or generic embedding)
+// is most similar to vector by cosine similarity.
+func (s *SurrealDBDriver) SearchNodesByVector(ctx context.Context, vector []float32, groupID string, options *VectorSearchOptions) ([]*types.Node, error) {
+	if options == nil {
+		options = &VectorSearchOptions{}
+	}
+
+	tables := surrealNodeTables
+	if len(options.NodeTypes) > 0 {
+		tables = nil
+		for _, nodeType := range options.NodeTypes {
+			tables = append(tables, surrealNodeTable(nodeType))
+		}
+	}
+
+	filter := options.filter(groupID)
+	rows, err := s.vectorSearch(ctx, tables, "name_embedding ?? embedding", vector, filter.groupIDs, filter.surrealNodeConditions, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nodes by vector: %w", err)
+	}
+	return decodeSurrealNodes(rows)
+}
+
+// SearchEdgesByVector finds the relations whose fact embedding (or generic
+// embedding) is most similar to vector by cosine similarity.
+func (s *SurrealDBDriver) SearchEdgesByVector(ctx context.Context, vector []float32, groupID string, options *VectorSearchOptions) ([]*types.Edge, error) {
+	if options == nil {
+		options = &VectorSearchOptions{}
+	}
+
+	tables := []string{surrealRelatesToTable}
+	if len(options.EdgeTypes) > 0 {
+		tables = nil
+		for _, edgeType := range options.EdgeTypes {
+			table, _, _ := surrealEdgeTable(edgeType)
+			tables = append(tables, table)
+		}
+	}
+
+	filter := options.filter(groupID)
+	rows, err := s.vectorSearch(ctx, tables, "fact_embedding ?? embedding", vector, filter.groupIDs, filter.surrealEdgeConditions, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search edges by vector: %w", err)
+	}
+	return decodeSurrealEdges(rows)
+}
+
+// vectorSearch ranks the records of groupIDs in each table by cosine similarity
+// to vector and returns the best matches across all tables. filters adds the
+// WHERE clause fragment of the search filters.
+func (s *SurrealDBDriver) vectorSearch(ctx context.Context, tables []string, embeddingExpr string, vector []float32, groupIDs []string, filters func(map[string]interface{}) string, options *VectorSearchOptions) ([]json.RawMessage, error) {
+	limit := options.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var matches []surrealScoredRecord
+	for _, table := range tables {
+		vars := map[string]interface{}{
+			"vector":    vector,
+			"group_ids": groupIDs,
+			"min_score": options.MinScore,
+			"limit":     limit,
+		}
+		rows, err := s.queryRows(ctx, fmt.Sprintf(`
+			SELECT *, vector::similarity::cosine(%[1]s, $vector) AS score FROM %[2]s
+			WHERE group_id IN $group_ids
+				AND type::is::array(%[1]s)
+				AND array::len(%[1]s) = array::len($vector)
+				AND vector::similarity::cosine(%[1]s, $vector) >= $min_score
+				%[3]s
+			ORDER BY score DESC
+			LIMIT $limit
+		`, embeddingExpr, table, filters(vars)), vars)
+		if err != nil {
+			return nil, err
+		}
+
+		scored, err := decodeSurrealScores(rows)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, scored...)
+	}
+
+	return topSurrealRecords(matches, limit), nil
+}
+
+// surrealScoredRecord is a search result with its relevance score.
+type surrealScoredRecord struct {
+	Record json.RawMessage
+	Score  float64
+}
+
+func decodeSurrealScores(rows []json.RawMessage) ([]surrealScoredRecord, error) {
+	scored := make([]surrealScoredRecord, 0, len(rows))
+	for _, row := range rows {
+		var score struct {
+			Score float64 `json:"score"`
+		}
+		if err := json.Unmarshal(row, &score); err != nil {
+			return nil, fmt.Errorf("failed to decode search result: %w", err)
+		}
+		scored = append(scored, surrealScoredRecord{Record: row, Score: score.Score})
+	}
+	return scored, nil
+}
+
+// topSurrealRecords returns the limit highest-scoring records.
+func topSurrealRecords(matches []surrealScoredRecord, limit int) []json.RawMessage {
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	records := make([]json.RawMessage, len(matches))
+	for i, match := range matches {
+		records[i] = match.Record
+	}
+	return records
+}
+
+// Bulk operations
+
+// UpsertNodes creates or replaces multiple nodes, one query per table.
+func (s *SurrealDBDriver) UpsertNodes(ctx context.Context, nodes []*types.Node) error {
+	byTable := make(map[string][]interface{})
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		table := surrealNodeTable(node.Type)
+		byTable[table] = append(byTable[table], utcNode(node))
+	}
+
+	for _, table := range surrealNodeTables {
+		if docs := byTable[table]; len(docs) > 0 {
+			if err := s.upsertNodes(ctx, table, docs); err != nil {
+				return fmt.Errorf("failed to upsert nodes: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// upsertNodes creates or replaces node records keyed by their uuid.
+func (s *SurrealDBDriver) upsertNodes(ctx context.Context, table string, docs []interface{}) error {
+	_, err := s.query(ctx, `
+		FOR $doc IN $docs {
+			UPSERT type::thing($table, $doc.uuid) CONTENT $doc;
+		};
+	`, map[string]interface{}{
+		"table": table,
+		"docs":  docs,
+	})
+	return err
+}
+
+// UpsertEdges creates or replaces multiple edges, one query per relation table.
+// Edges are recreated with RELATE so that graph traversal sees them.
+func (s *SurrealDBDriver) UpsertEdges(ctx context.Context, edges []*types.Edge) error {
+	type edgeBatch struct {
+		from, to string
+		docs     []interface{}
+	}
+	batches := make(map[string]*edgeBatch)
+
+	for _, edge := range edges {
+		if edge == nil {
+			continue
+		}
+		table, from, to := surrealEdgeTable(edge.Type)
+		doc := utcEdge(edge)
+		doc.SourceNodeID, doc.TargetNodeID = edgeEndpoints(edge)
+		doc.SourceID, doc.TargetID = doc.SourceNodeID, doc.TargetNodeID
+
+		if batches[table] == nil {
+			batches[table] = &edgeBatch{from: from, to: to}
+		}
+		batches[table].docs = append(batches[table].docs, doc)
+	}
+
+	for _, table := range surrealEdgeTables {
+		batch := batches[table]
+		if batch == nil {
+			continue
+		}
+		_, err := s.query(ctx, `
+			BEGIN TRANSACTION;
+			FOR $doc IN $docs {
+				LET $edge = type::thing($table, $doc.uuid);
+				LET $in = type::thing($from, $doc.source_node_uuid);
+				LET $out = type::thing($to, $doc.target_node_uuid);
+				DELETE $edge;
+				RELATE $in->$edge->$out CONTENT $doc;
+			};
+			COMMIT TRANSACTION;
+		`, map[string]interface{}{
+			"table": table,
+			"from":  batch.from,
+			"to":    batch.to,
+			"docs":  batch.docs,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upsert edges: %w", err)
+		}
+	}
+	return nil
+}
+
+// Temporal operations
+
+// GetNodesInTimeRange retrieves the nodes created within a time range.
+func (s *SurrealDBDriver) GetNodesInTimeRange(ctx context.Context, start, end time.Time, groupID string) ([]*types.Node, error) {
+	return s.queryNodes(ctx, `
+		SELECT * FROM Entity, Episodic, Community
+		WHERE group_id = $group_id AND created_at >= $start AND created_at <= $end
+		ORDER BY created_at
+	`, map[string]interface{}{
+		"group_id": groupID,
+		"start":    start.UTC(),
+		"end":      end.UTC(),
+	})
+}
+
+// GetEdgesInTimeRange retrieves the edges created within a time range.
+func (s *SurrealDBDriver) GetEdgesInTimeRange(ctx context.Context, start, end time.Time, groupID string) ([]*types.Edge, error) {
+	return s.queryEdges(ctx, `
+		SELECT * FROM RELATES_TO, MENTIONS, HAS_MEMBER
+		WHERE group_id = $group_id AND created_at >= $start AND created_at <= $end
+		ORDER BY created_at
+	`, map[string]interface{}{
+		"group_id": groupID,
+		"start":    start.UTC(),
+		"end":      end.UTC(),
+	})
+}
+
+// RetrieveEpisodes retrieves the most recent episodes valid at referenceTime,
+// returned in chronological order (oldest first).
+func (s *SurrealDBDriver) RetrieveEpisodes(
+	ctx context.Context,
+	referenceTime time.Time,
+	groupIDs []string,
+	limit int,
+	episodeType *types.EpisodeType,
+) ([]*types.Node, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	vars := map[string]interface{}{
+		"reference_time": referenceTime.UTC(),
+		"num_episodes":   limit,
+	}
+
+	queryFilter := ""
+	if len(groupIDs) > 0 {
+		queryFilter += "\nAND group_id IN $group_ids"
+		vars["group_ids"] = groupIDs
+	}
+	if episodeType != nil {
+		queryFilter += "\nAND episode_type = $source"
+		vars["source"] = string(*episodeType)
+	}
+
+	episodes, err := s.queryNodes(ctx, fmt.Sprintf(`
+		SELECT * FROM Episodic
+		WHERE valid_from <= $reference_time
+		%s
+		ORDER BY valid_from DESC
+		LIMIT $num_episodes
+	`, queryFilter), vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve episodes: %w", err)
+	}
+
+	// Reverse to return in chronological order (oldest first)
+	types.ReverseNodes(episodes)
+
+	return episodes, nil
+}
+
+// Community operations
+
+// GetCommunities retrieves the communities of a group at the given level.
+func (s *SurrealDBDriver) GetCommunities(ctx context.Context, groupID string, level int) ([]*types.Node, error) {
+	return s.queryNodes(ctx, `
+		SELECT * FROM Community
+		WHERE group_id = $group_id AND (level ?? 0) = $level
+	`, map[string]interface{}{
+		"group_id": groupID,
+		"level":    level,
+	})
+}
+
+// BuildCommunities is a no-op; use community.Builder (pkg/community) for
+// LLM-powered community building.
+func (s *SurrealDBDriver) BuildCommunities(ctx context.Context, groupID string) error {
+	return nil
+}
+
+// GetExistingCommunity returns the community an entity belongs to, or nil.
+func (s *SurrealDBDriver) GetExistingCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
+	nodes, err := s.queryNodes(ctx, `
+		SELECT * FROM Community
+		WHERE ->HAS_MEMBER->Entity.uuid CONTAINS $entity_uuid
+		LIMIT 1
+	`, map[string]interface{}{
+		"entity_uuid": entityUUID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query existing community: %w", err)
+	}
+	if len(nodes) > 0 {
+		return nodes[0], nil
+	}
+	return nil, nil
+}
+
+// FindModalCommunity returns the community most common among an entity's neighbors, or nil.
+func (s *SurrealDBDriver) FindModalCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
+	rows, err := s.queryRows(ctx, `
+		SELECT VALUE array::flatten([
+			->RELATES_TO->Entity<-HAS_MEMBER<-Community.uuid,
+			<-RELATES_TO<-Entity<-HAS_MEMBER<-Community.uuid
+		])
+		FROM Entity
+		WHERE uuid = $entity_uuid
+	`, map[string]interface{}{
+		"entity_uuid": entityUUID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query modal community: %w", err)
+	}
+
+	counts := make(map[string]int)
+	modal := ""
+	for _, row := range rows {
+		var ids []string
+		if err := json.Unmarshal(row, &ids); err != nil {
+			return nil, fmt.Errorf("failed to decode communities: %w", err)
+		}
+		for _, id := range ids {
+			counts[id]++
+			if modal == "" || counts[id] > counts[modal] {
+				modal = id
+			}
+		}
+	}
+	if modal == "" {
+		return nil, nil
+	}
+
+	nodes, err := s.queryNodes(ctx, `SELECT * FROM Community WHERE uuid = $uuid LIMIT 1`, map[string]interface{}{
+		"uuid": modal,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query modal community: %w", err)
+	}
+	if len(nodes) > 0 {
+		return nodes[0], nil
+	}
+	return nil, nil
+}
+
+// RemoveCommunities deletes all communities and their membership relations.
+func (s *SurrealDBDriver) RemoveCommunities(ctx context.Context) error {
+	if _, err := s.query(ctx, "DELETE HAS_MEMBER; DELETE Community;", nil); err != nil {
+		return fmt.Errorf("failed to remove communities: %w", err)
+	}
+	return nil
+}
+
+// Database maintenance
+
+// CreateIndices defines the namespace, database, tables, lookup indexes, and the
+// BM25 full-text indexes. It is idempotent.
+func (s *SurrealDBDriver) CreateIndices(ctx context.Context) error {
+	statements := []string{
+		fmt.Sprintf("DEFINE ANALYZER IF NOT EXISTS %s TOKENIZERS class FILTERS lowercase, snowball(english)", s.config.Analyzer),
+	}
+
+	for _, table := range surrealNodeTables {
+		statements = append(statements,
+			fmt.Sprintf("DEFINE TABLE IF NOT EXISTS %s TYPE NORMAL SCHEMALESS", table),
+		)
+	}
+	for _, table := range surrealEdgeTables {
+		statements = append(statements,
+			fmt.Sprintf("DEFINE TABLE IF NOT EXISTS %s TYPE RELATION SCHEMALESS", table),
+		)
+	}
+
+	for _, table := range append(append([]string{}, surrealNodeTables...), surrealEdgeTables...) {
+		lower := strings.ToLower(table)
+		statements = append(statements,
+			fmt.Sprintf("DEFINE INDEX IF NOT EXISTS %s_uuid ON %s FIELDS uuid", lower, table),
+			fmt.Sprintf("DEFINE INDEX IF NOT EXISTS %s_group_created ON %s FIELDS group_id, created_at", lower, table),
+		)
+		for _, field := range surrealSearchFields[table] {
+			statements = append(statements, fmt.Sprintf(
+				"DEFINE INDEX IF NOT EXISTS %s_%s_search ON %s FIELDS %s SEARCH ANALYZER %s BM25",
+				lower, field, table, field, s.config.Analyzer))
+		}
+	}
+
+	// Namespace and database must exist before anything can be defined in them
+	bootstrap := fmt.Sprintf("DEFINE NAMESPACE IF NOT EXISTS %s; USE NS %s; DEFINE DATABASE IF NOT EXISTS %s;",
+		surrealIdent(s.config.Namespace), surrealIdent(s.config.Namespace), surrealIdent(s.config.Database))
+	if _, err := s.query(ctx, bootstrap, nil); err != nil {
+		return fmt.Errorf("failed to define database: %w", err)
+	}
+
+	for _, stmt := range statements {
+		if _, err := s.query(ctx, stmt, nil); err != nil {
+			return fmt.Errorf("failed to create index (%s): %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// surrealIdent escapes an identifier for use in SurrealQL.
+func surrealIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+// GetStats returns graph statistics for a group, or for every group when groupID is empty.
+func (s *SurrealDBDriver) GetStats(ctx context.Context, groupID string) (*GraphStats, error) {
+	stats := newGraphStats()
+	vars := map[string]interface{}{"group_id": groupID}
+	groupFilter := "($group_id = '' OR group_id = $group_id)"
+
+	countByGroup := func(table string) (map[string]int64, error) {
+		rows, err := s.queryRows(ctx, fmt.Sprintf(
+			"SELECT group_id, count() AS count FROM %s WHERE %s GROUP BY group_id", table, groupFilter), vars)
+		if err != nil {
+			return nil, err
+		}
+		counts := make(map[string]int64, len(rows))
+		for _, row := range rows {
+			var result struct {
+				GroupID string `json:"group_id"`
+				Count   int64  `json:"count"`
+			}
+			if err := json.Unmarshal(row, &result); err != nil {
+				return nil, fmt.Errorf("failed to decode count: %w", err)
+			}
+			counts[result.GroupID] += result.Count
+		}
+		return counts, nil
+	}
+
+	for _, table := range surrealNodeTables {
+		counts, err := countByGroup(table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s nodes: %w", table, err)
+		}
+		for group, n := range counts {
+			stats.NodesByType[table] += n
+			stats.NodesByGroup[group] += n
+			stats.NodeCount += n
+		}
+	}
+	stats.CommunityCount = stats.NodesByType[surrealCommunityTable]
+
+	for _, table := range surrealEdgeTables {
+		counts, err := countByGroup(table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s edges: %w", table, err)
+		}
+		for group, n := range counts {
+			stats.EdgesByType[table] += n
+			stats.EdgesByGroup[group] += n
+			stats.EdgeCount += n
+		}
+	}
+
+	rows, err := s.queryRows(ctx, fmt.Sprintf(`
+		SELECT
+			count(!invalid_at AND !expired_at) AS valid,
+			count(invalid_at OR expired_at) AS invalidated,
+			count(array::len(fact_embedding ?? []) = 0 AND array::len(embedding ?? []) = 0) AS without_embedding
+		FROM %s WHERE %s GROUP ALL
+	`, surrealRelatesToTable, groupFilter), vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count entity edges: %w", err)
+	}
+	for _, row := range rows {
+		var result struct {
+			Valid            int64 `json:"valid"`
+			Invalidated      int64 `json:"invalidated"`
+			WithoutEmbedding int64 `json:"without_embedding"`
+		}
+		if err := json.Unmarshal(row, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode entity edge counts: %w", err)
+		}
+		stats.ValidEdgeCount = result.Valid
+		stats.InvalidatedEdgeCount = result.Invalidated
+		stats.EdgesWithoutEmbedding = result.WithoutEmbedding
+	}
+
+	for _, table := range []string{surrealEntityTable, surrealCommunityTable} {
+		rows, err := s.queryRows(ctx, fmt.Sprintf(`
+			SELECT count() AS count FROM %s
+			WHERE %s AND array::len(name_embedding ?? []) = 0 AND array::len(embedding ?? []) = 0
+			GROUP ALL
+		`, table, groupFilter), vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s nodes without embeddings: %w", table, err)
+		}
+		for _, row := range rows {
+			var result struct {
+				Count int64 `json:"count"`
+			}
+			if err := json.Unmarshal(row, &result); err != nil {
+				return nil, fmt.Errorf("failed to decode count: %w", err)
+			}
+			stats.NodesWithoutEmbedding += result.Count
+		}
+	}
+
+	rows, err = s.queryRows(ctx, fmt.Sprintf(
+		"SELECT reference, valid_from, created_at FROM %s WHERE %s", surrealEpisodicTable, groupFilter), vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list episode times: %w", err)
+	}
+	for _, row := range rows {
+		var episode struct {
+			Reference time.Time `json:"reference"`
+			ValidFrom time.Time `json:"valid_from"`
+			CreatedAt time.Time `json:"created_at"`
+		}
+		if err := json.Unmarshal(row, &episode); err != nil {
+			return nil, fmt.Errorf("failed to decode episode times: %w", err)
+		}
+		if reference := firstNonZeroTime(episode.Reference, episode.ValidFrom, episode.CreatedAt); !reference.IsZero() {
+			stats.EpisodesPerDay[statsDay(reference)]++
+		}
+	}
+
+	return stats, nil
+}
+
+// ParseNodesFromRecords parses the records returned by ExecuteQuery into nodes.
+func (s *SurrealDBDriver) ParseNodesFromRecords(records any) ([]*types.Node, error) {
+	recordSlice, ok := records.([]map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected records type: %T", records)
+	}
+
+	rows := make([]json.RawMessage, 0, len(recordSlice))
+	for _, record := range recordSlice {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode record: %w", err)
+		}
+		rows = append(rows, data)
+	}
+	return decodeSurrealNodes(rows)
+}
+
+// GetEntityNodesByGroup retrieves all entity nodes of a group.
+func (s *SurrealDBDriver) GetEntityNodesByGroup(ctx context.Context, groupID string) ([]*types.Node, error) {
+	return s.queryNodes(ctx, `SELECT * FROM Entity WHERE group_id = $group_id`, map[string]interface{}{
+		"group_id": groupID,
+	})
+}
+
+// GetAllGroupIDs returns the distinct group IDs of all nodes.
+func (s *SurrealDBDriver) GetAllGroupIDs(ctx context.Context) ([]string, error) {
+	rows, err := s.queryRows(ctx, `
+		SELECT VALUE group_id FROM Entity, Episodic, Community GROUP BY group_id
+	`, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group IDs: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	groupIDs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		var groupID string
+		if err := json.Unmarshal(row, &groupID); err != nil || groupID == "" || seen[groupID] {
+			continue
+		}
+		seen[groupID] = true
+		groupIDs = append(groupIDs, groupID)
+	}
+	sort.Strings(groupIDs)
+	return groupIDs, nil
+}
+
+// queryNodes runs SurrealQL returning node records.
+func (s *SurrealDBDriver) queryNodes(ctx context.Context, surql string, vars map[string]interface{}) ([]*types.Node, error) {
+	rows, err := s.queryRows(ctx, surql, vars)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSurrealNodes(rows)
+}
+
+// queryEdges runs SurrealQL returning relation records.
+func (s *SurrealDBDriver) queryEdges(ctx context.Context, surql string, vars map[string]interface{}) ([]*types.Edge, error) {
+	rows, err := s.queryRows(ctx, surql, vars)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSurrealEdges(rows)
+}
+
+// Session creates a new database session. Queries are sent over the stateless
+// HTTP RPC endpoint, so sessions simply run queries against the given database.
+func (s *SurrealDBDriver) Session(database *string) GraphDriverSession {
+	driver := s
+	if database != nil && *database != "" && *database != s.config.Database {
+		cfg := *s.config
+		cfg.Database = *database
+		driver = &SurrealDBDriver{config: &cfg, client: s.client}
+	}
+	return &SurrealDBDriverSession{driver: driver}
+}
+
+// DeleteAllIndexes removes the indexes defined by CreateIndices from the given database.
+func (s *SurrealDBDriver) DeleteAllIndexes(database string) {
+	driver := s
+	if database != "" && database != s.config.Database {
+		cfg := *s.config
+		cfg.Database = database
+		driver = &SurrealDBDriver{config: &cfg, client: s.client}
+	}
+
+	ctx := context.Background()
+	for _, table := range append(append([]string{}, surrealNodeTables...), surrealEdgeTables...) {
+		lower := strings.ToLower(table)
+		names := []string{lower + "_uuid", lower + "_group_created"}
+		for _, field := range surrealSearchFields[table] {
+			names = append(names, fmt.Sprintf("%s_%s_search", lower, field))
+		}
+		for _, name := range names {
+			_, _ = driver.query(ctx, fmt.Sprintf("REMOVE INDEX IF EXISTS %s ON %s", name, table), nil)
+		}
+	}
+}
+
+// Provider returns the provider type.
+func (s *SurrealDBDriver) Provider() GraphProvider {
+	return GraphProviderSurrealDB
+}
+
+// GetAossClient returns nil for SurrealDB (Amazon OpenSearch not applicable).
+func (s *SurrealDBDriver) GetAossClient() interface{} {
+	return nil
+}
+
+// Close releases idle HTTP connections.
+func (s *SurrealDBDriver) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// HealthCheck verifies the server is reachable and runs a trivial query against the configured database.
+func (s *SurrealDBDriver) HealthCheck(ctx context.Context) error {
+	result, err := s.query(ctx, "RETURN 1", nil)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	if strings.TrimSpace(string(result)) != "1" {
+		return fmt.Errorf("health check failed: unexpected result %s", result)
+	}
+	return nil
+}
+
+// SurrealDBDriverSession implements GraphDriverSession for SurrealDB.
+type SurrealDBDriverSession struct {
+	driver *SurrealDBDriver
+}
+
+// Enter implements the context manager pattern.
+func (s *SurrealDBDriverSession) Enter(ctx context.Context) (GraphDriverSession, error) {
+	return s, nil
+}
+
+// Exit implements the context manager pattern.
+func (s *SurrealDBDriverSession) Exit(ctx context.Context, excType, excVal, excTb interface{}) error {
+	return nil
+}
+
+// Close closes the session.
+func (s *SurrealDBDriverSession) Close() error {
+	return nil
+}
+
+// Run executes SurrealQL in this session.
+func (s *SurrealDBDriverSession) Run(ctx context.Context, query interface{}, kwargs map[string]interface{}) error {
+	queryStr, ok := query.(string)
+	if !ok {
+		return fmt.Errorf("query must be a string")
+	}
+	_, _, _, err := s.driver.executeQuery(ctx, queryStr, kwargs)
+	return err
+}
+
+// ExecuteWrite executes fn with this session. Queries run by fn are committed
+// individually.
+func (s *SurrealDBDriverSession) ExecuteWrite(ctx context.Context, fn func(context.Context, GraphDriverSession, ...interface{}) (interface{}, error), args ...interface{}) (interface{}, error) {
+	return fn(ctx, s, args...)
+}
+
+// Provider returns the provider type.
+func (s *SurrealDBDriverSession) Provider() GraphProvider {
+	return GraphProviderSurrealDB
+}
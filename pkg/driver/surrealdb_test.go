@@ -0,0 +1,192 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// fakeSurrealServer serves the RPC endpoint, answering every query with the
+// configured statement results and recording the requests it receives.
+type fakeSurrealServer struct {
+	results []interface{}
+	queries []string
+	vars    []map[string]interface{}
+	headers http.Header
+}
+
+func (f *fakeSurrealServer) handler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rpc" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.headers = r.Header.Clone()
+
+		var body struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		var query string
+		var vars map[string]interface{}
+		json.Unmarshal(body.Params[0], &query)
+		json.Unmarshal(body.Params[1], &vars)
+		f.queries = append(f.queries, query)
+		f.vars = append(f.vars, vars)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 1, "result": f.results})
+	})
+}
+
+func newTestSurrealDriver(t *testing.T, fake *fakeSurrealServer) *SurrealDBDriver {
+	server := httptest.NewServer(fake.handler(t))
+	t.Cleanup(server.Close)
+
+	config := DefaultSurrealDBDriverConfig()
+	config.Endpoint = server.URL
+	config.Namespace = "ns"
+	config.Database = "db"
+	driver, err := NewSurrealDBDriverWithConfig(config)
+	if err != nil {
+		t.Fatalf("failed to create driver: %v", err)
+	}
+	return driver
+}
+
+func TestSurrealDBExecuteQueryReturnsLastStatement(t *testing.T) {
+	fake := &fakeSurrealServer{results: []interface{}{
+		map[string]interface{}{"status": "OK", "result": nil},
+		map[string]interface{}{"status": "OK", "result": []interface{}{
+			map[string]interface{}{"name": "a"},
+			7,
+		}},
+	}}
+	driver := newTestSurrealDriver(t, fake)
+
+	records, _, _, err := driver.ExecuteQuery("LET $x = 1; SELECT * FROM Entity", map[string]interface{}{"x": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows := records.([]map[string]interface{})
+	if len(rows) != 2 || rows[0]["name"] != "a" || rows[1]["value"] != float64(7) {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	if fake.headers.Get("Surreal-NS") != "ns" || fake.headers.Get("Surreal-DB") != "db" {
+		t.Errorf("expected namespace and database headers, got %v", fake.headers)
+	}
+	if fake.vars[0]["x"] != float64(1) {
+		t.Errorf("expected kwargs to be sent as variables, got %v", fake.vars[0])
+	}
+}
+
+func TestSurrealDBStatementError(t *testing.T) {
+	fake := &fakeSurrealServer{results: []interface{}{
+		map[string]interface{}{"status": "ERR", "result": "Parse error"},
+	}}
+	driver := newTestSurrealDriver(t, fake)
+
+	_, _, _, err := driver.ExecuteQuery("SELEC", nil)
+	if err == nil || !strings.Contains(err.Error(), "Parse error") {
+		t.Fatalf("expected statement error, got %v", err)
+	}
+}
+
+func TestSurrealDBGetNodeNeighborsCountsEdges(t *testing.T) {
+	fake := &fakeSurrealServer{results: []interface{}{
+		map[string]interface{}{"status": "OK", "result": []interface{}{
+			[]interface{}{"b", "c", "b"},
+		}},
+	}}
+	driver := newTestSurrealDriver(t, fake)
+
+	neighbors, err := driver.GetNodeNeighbors(context.Background(), "a", "g1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(neighbors) != 2 || neighbors[0] != (types.Neighbor{NodeUUID: "b", EdgeCount: 2}) {
+		t.Errorf("unexpected neighbors: %v", neighbors)
+	}
+}
+
+func TestSurrealDBDecodeEdgeEndpoints(t *testing.T) {
+	rows := []json.RawMessage{json.RawMessage(`{"id": "RELATES_TO:⟨e-1⟩", "in": "Entity:⟨a-1⟩", "out": "Entity:b2", "name": "KNOWS"}`)}
+
+	edges, err := decodeSurrealEdges(rows)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	edge := edges[0]
+	if edge.Uuid != "e-1" || edge.SourceNodeID != "a-1" || edge.TargetID != "b2" || edge.Name != "KNOWS" {
+		t.Errorf("unexpected edge: %+v", edge)
+	}
+}
+
+func TestSurrealDBSearchNodesUsesBM25(t *testing.T) {
+	fake := &fakeSurrealServer{results: []interface{}{
+		map[string]interface{}{"status": "OK", "result": []interface{}{}},
+	}}
+	driver := newTestSurrealDriver(t, fake)
+
+	_, err := driver.SearchNodes(context.Background(), "alice", "g1", &SearchOptions{
+		NodeTypes: []types.NodeType{types.EntityNodeType},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(fake.queries) != 1 {
+		t.Fatalf("expected one query for the Entity table, got %d", len(fake.queries))
+	}
+	for _, want := range []string{"FROM Entity", "name @0@ $query", "summary @1@ $query", "search::score(0) + search::score(1)"} {
+		if !strings.Contains(fake.queries[0], want) {
+			t.Errorf("expected query to contain %q, got %s", want, fake.queries[0])
+		}
+	}
+}
+
+func TestSurrealDBGetStatsBreakdowns(t *testing.T) {
+	// Every statement receives the same row, which carries the fields of all stats queries
+	fake := &fakeSurrealServer{results: []interface{}{
+		map[string]interface{}{"status": "OK", "result": []interface{}{
+			map[string]interface{}{
+				"group_id":          "g1",
+				"count":             2,
+				"valid":             3,
+				"invalidated":       1,
+				"without_embedding": 4,
+				"reference":         "2024-05-01T10:00:00Z",
+			},
+		}},
+	}}
+	driver := newTestSurrealDriver(t, fake)
+
+	stats, err := driver.GetStats(context.Background(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stats.NodeCount != 6 || stats.NodesByGroup["g1"] != 6 || stats.EdgesByGroup["g1"] != 6 {
+		t.Errorf("unexpected group counts: %+v", stats)
+	}
+	if stats.ValidEdgeCount != 3 || stats.InvalidatedEdgeCount != 1 || stats.EdgesWithoutEmbedding != 4 {
+		t.Errorf("unexpected entity edge counts: %+v", stats)
+	}
+	if stats.NodesWithoutEmbedding != 4 {
+		t.Errorf("expected entity and community counts to be summed, got %d", stats.NodesWithoutEmbedding)
+	}
+	if stats.EpisodesPerDay["2024-05-01"] != 1 {
+		t.Errorf("unexpected episodes per day: %v", stats.EpisodesPerDay)
+	}
+	if fake.vars[0]["group_id"] != "" {
+		t.Errorf("expected empty group filter, got %v", fake.vars[0]["group_id"])
+	}
+}
@@ -3,6 +3,9 @@ package driver
 import (
 	"fmt"
 	"reflect"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
 )
 
 // convertNodeToMap converts a graph database node to a map of properties.
@@ -70,3 +73,49 @@ func convertNodeToMap(nodeInterface interface{}) (map[string]interface{}, error)
 
 	return result, nil
 }
+
+// utcNode returns a copy of node with all timestamps in UTC. Document stores keep
+// timestamps as RFC 3339 strings, which only sort chronologically in a single zone.
+func utcNode(node *types.Node) types.Node {
+	n := *node
+	n.CreatedAt = node.CreatedAt.UTC()
+	n.UpdatedAt = node.UpdatedAt.UTC()
+	n.Reference = node.Reference.UTC()
+	n.ValidFrom = node.ValidFrom.UTC()
+	n.ValidTo = utcTimePtr(node.ValidTo)
+	return n
+}
+
+// utcEdge returns a copy of edge with all timestamps in UTC.
+func utcEdge(edge *types.Edge) types.Edge {
+	e := *edge
+	e.CreatedAt = edge.CreatedAt.UTC()
+	e.UpdatedAt = edge.UpdatedAt.UTC()
+	e.ValidFrom = edge.ValidFrom.UTC()
+	e.ValidTo = utcTimePtr(edge.ValidTo)
+	e.ExpiredAt = utcTimePtr(edge.ExpiredAt)
+	e.ValidAt = utcTimePtr(edge.ValidAt)
+	e.InvalidAt = utcTimePtr(edge.InvalidAt)
+	return e
+}
+
+// edgeEndpoints returns the source and target node UUIDs of an edge, falling back
+// to the legacy SourceID/TargetID fields.
+func edgeEndpoints(edge *types.Edge) (sourceID, targetID string) {
+	sourceID, targetID = edge.SourceNodeID, edge.TargetNodeID
+	if sourceID == "" {
+		sourceID = edge.SourceID
+	}
+	if targetID == "" {
+		targetID = edge.TargetID
+	}
+	return sourceID, targetID
+}
+
+func utcTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
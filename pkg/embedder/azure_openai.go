@@ -7,35 +7,49 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
+// DefaultAzureOpenAIEmbeddingAPIVersion is the Azure OpenAI API version used
+// when none is given.
+const DefaultAzureOpenAIEmbeddingAPIVersion = "2024-10-21"
+
 // AzureOpenAIEmbedder implements the Client interface for Azure OpenAI embeddings.
 type AzureOpenAIEmbedder struct {
 	config       *AzureOpenAIConfig
 	httpClient   *http.Client
 	apiVersion   string
 	deploymentID string
+	tokens       *tokenCache
 }
 
-// AzureOpenAIConfig extends Config with Azure-specific settings.
+// AzureOpenAIConfig extends Config with Azure-specific settings. BaseURL is
+// the resource endpoint (https://{resource}.openai.azure.com).
 type AzureOpenAIConfig struct {
 	*Config
 	APIKey       string `json:"api_key"`
 	APIVersion   string `json:"api_version,omitempty"`
 	DeploymentID string `json:"deployment_id"`
+	// TokenProvider enables Microsoft Entra ID authentication instead of API keys
+	TokenProvider TokenProvider `json:"-"`
 }
 
 // NewAzureOpenAIEmbedder creates a new Azure OpenAI embedder.
 func NewAzureOpenAIEmbedder(config *AzureOpenAIConfig) *AzureOpenAIEmbedder {
+	if config.Config == nil {
+		config.Config = &Config{}
+	}
 	if config.APIVersion == "" {
-		config.APIVersion = "2024-02-15-preview"
+		config.APIVersion = DefaultAzureOpenAIEmbeddingAPIVersion
 	}
 	if config.BatchSize == 0 {
 		config.BatchSize = 100
 	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
 
-	return &AzureOpenAIEmbedder{
+	a := &AzureOpenAIEmbedder{
 		config:       config,
 		apiVersion:   config.APIVersion,
 		deploymentID: config.DeploymentID,
@@ -43,6 +57,43 @@ func NewAzureOpenAIEmbedder(config *AzureOpenAIConfig) *AzureOpenAIEmbedder {
 			Timeout: 30 * time.Second,
 		},
 	}
+	if config.TokenProvider != nil {
+		a.tokens = &tokenCache{provider: config.TokenProvider}
+	}
+	return a
+}
+
+// NewAzureOpenAIDeploymentEmbedder creates an embedder for the deploymentName
+// deployment of the Azure OpenAI resource at endpoint
+// (https://{resource}.openai.azure.com). An empty apiVersion uses
+// DefaultAzureOpenAIEmbeddingAPIVersion. Either config.APIKey or
+// config.TokenProvider must be set; config may omit the embedded Config.
+func NewAzureOpenAIDeploymentEmbedder(endpoint, deploymentName, apiVersion string, config *AzureOpenAIConfig) (*AzureOpenAIEmbedder, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required for Azure OpenAI")
+	}
+	if parsed, err := url.Parse(endpoint); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid endpoint %q: must be an http(s) URL", endpoint)
+	}
+	if deploymentName == "" {
+		return nil, fmt.Errorf("deployment name is required for Azure OpenAI")
+	}
+	if config == nil {
+		config = &AzureOpenAIConfig{}
+	}
+	if config.APIKey == "" && config.TokenProvider == nil {
+		return nil, fmt.Errorf("an API key or Entra ID token provider is required for Azure OpenAI")
+	}
+
+	if config.Config == nil {
+		config.Config = &Config{}
+	}
+	config.BaseURL = endpoint
+	config.DeploymentID = deploymentName
+	if apiVersion != "" {
+		config.APIVersion = apiVersion
+	}
+	return NewAzureOpenAIEmbedder(config), nil
 }
 
 // azureEmbeddingRequest represents the request structure for Azure OpenAI embeddings API.
@@ -80,8 +131,13 @@ type azureEmbeddingError struct {
 	Code    string `json:"code"`
 }
 
-// Embed generates embeddings for the given texts.
+// Embed generates embeddings for the given texts, with the document prefix.
 func (a *AzureOpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return a.embedTexts(ctx, withPrefix(a.config.DocumentPrefix, texts))
+}
+
+// embedTexts embeds texts as given.
+func (a *AzureOpenAIEmbedder) embedTexts(ctx context.Context, texts []string) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, fmt.Errorf("no texts provided")
 	}
@@ -124,16 +180,18 @@ func (a *AzureOpenAIEmbedder) embedBatch(ctx context.Context, texts []string) ([
 	}
 
 	// Azure OpenAI URL format: https://{resource-name}.openai.azure.com/openai/deployments/{deployment-id}/embeddings?api-version={api-version}
-	url := fmt.Sprintf("%s/openai/deployments/%s/embeddings?api-version=%s",
-		a.config.BaseURL, a.deploymentID, a.apiVersion)
+	endpoint := fmt.Sprintf("%s/openai/deployments/%s/embeddings?api-version=%s",
+		a.config.BaseURL, url.PathEscape(a.deploymentID), url.QueryEscape(a.apiVersion))
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("api-key", a.config.APIKey)
+	if err := a.authorize(ctx, httpReq); err != nil {
+		return nil, err
+	}
 
 	// Add any additional headers
 	for key, value := range a.config.Headers {
@@ -174,16 +232,34 @@ func (a *AzureOpenAIEmbedder) embedBatch(ctx context.Context, texts []string) ([
 
 // EmbedSingle generates an embedding for a single text.
 func (a *AzureOpenAIEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
-	embeddings, err := a.Embed(ctx, []string{text})
-	if err != nil {
-		return nil, err
-	}
+	return a.EmbedDocument(ctx, text)
+}
+
+// EmbedQuery generates an embedding for a search query, with the query prefix.
+func (a *AzureOpenAIEmbedder) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	return firstEmbedding(a.embedTexts(ctx, []string{a.config.QueryPrefix + query}))
+}
 
-	if len(embeddings) == 0 {
-		return nil, fmt.Errorf("no embeddings returned")
+// EmbedDocument generates an embedding for a text stored for retrieval, with
+// the document prefix.
+func (a *AzureOpenAIEmbedder) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	return firstEmbedding(a.embedTexts(ctx, []string{a.config.DocumentPrefix + text}))
+}
+
+// authorize sets the Entra ID bearer token, or the api-key header when no token
+// provider is configured.
+func (a *AzureOpenAIEmbedder) authorize(ctx context.Context, req *http.Request) error {
+	if a.tokens == nil {
+		req.Header.Set("api-key", a.config.APIKey)
+		return nil
 	}
 
-	return embeddings[0], nil
+	token, err := a.tokens.get(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get Entra ID token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
 }
 
 // Dimensions returns the number of dimensions in the embeddings.
@@ -191,6 +267,11 @@ func (a *AzureOpenAIEmbedder) Dimensions() int {
 	return a.config.Dimensions
 }
 
+// Model returns the name of the embedding model.
+func (a *AzureOpenAIEmbedder) Model() string {
+	return a.config.Model
+}
+
 // Close cleans up any resources.
 func (a *AzureOpenAIEmbedder) Close() error {
 	// Nothing to clean up for HTTP client
@@ -0,0 +1,53 @@
+package embedder_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/embedder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureOpenAIDeploymentEmbedder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/deployments/my-embeddings/embeddings", r.URL.Path)
+		assert.Equal(t, embedder.DefaultAzureOpenAIEmbeddingAPIVersion, r.URL.Query().Get("api-version"))
+		assert.Equal(t, "Bearer entra-token", r.Header.Get("Authorization"))
+		assert.Empty(t, r.Header.Get("api-key"))
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": []map[string]any{
+				{"index": 1, "embedding": []float32{0, 1}},
+				{"index": 0, "embedding": []float32{1, 0}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := embedder.NewAzureOpenAIDeploymentEmbedder(server.URL+"/", "my-embeddings", "", &embedder.AzureOpenAIConfig{
+		TokenProvider: embedder.TokenProviderFunc(func(ctx context.Context) (string, time.Time, error) {
+			return "entra-token", time.Now().Add(time.Hour), nil
+		}),
+	})
+	require.NoError(t, err)
+
+	embeddings, err := client.Embed(context.Background(), []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 0}, {0, 1}}, embeddings)
+}
+
+func TestNewAzureOpenAIDeploymentEmbedderValidation(t *testing.T) {
+	_, err := embedder.NewAzureOpenAIDeploymentEmbedder("", "deployment", "", &embedder.AzureOpenAIConfig{APIKey: "key"})
+	assert.Error(t, err)
+
+	_, err = embedder.NewAzureOpenAIDeploymentEmbedder("https://example.openai.azure.com", "", "", &embedder.AzureOpenAIConfig{APIKey: "key"})
+	assert.Error(t, err)
+
+	_, err = embedder.NewAzureOpenAIDeploymentEmbedder("https://example.openai.azure.com", "deployment", "", nil)
+	assert.Error(t, err, "credentials are required")
+}
@@ -0,0 +1,205 @@
+package embedder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatcherConfig configures a Batcher.
+type BatcherConfig struct {
+	// MaxBatchSize is the largest number of texts sent in one Embed request
+	MaxBatchSize int
+	// FlushInterval is how long the first queued text waits for others to
+	// join its batch
+	FlushInterval time.Duration
+}
+
+// DefaultBatcherConfig returns a batcher configuration suited to hosted
+// embedding APIs.
+func DefaultBatcherConfig() BatcherConfig {
+	return BatcherConfig{
+		MaxBatchSize:  64,
+		FlushInterval: 10 * time.Millisecond,
+	}
+}
+
+// Batcher wraps an embedding client and coalesces concurrent EmbedSingle and
+// EmbedDocument calls into batched Embed requests, saving round trips and rate limit budget
+// when many goroutines embed one text each. Embed calls are passed through as
+// they are already batched. Close must be called to stop the batcher.
+type Batcher struct {
+	client   Client
+	config   BatcherConfig
+	requests chan *batchRequest
+	done     chan struct{}
+
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// batchRequest is a text waiting in a Batcher.
+type batchRequest struct {
+	ctx    context.Context
+	text   string
+	result chan batchResult
+}
+
+// batchResult is the outcome of a batchRequest.
+type batchResult struct {
+	embedding []float32
+	err       error
+}
+
+// NewBatcher creates a batcher around client. Zero config values use the
+// defaults.
+func NewBatcher(client Client, config BatcherConfig) *Batcher {
+	defaults := DefaultBatcherConfig()
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = defaults.MaxBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaults.FlushInterval
+	}
+
+	b := &Batcher{
+		client:   client,
+		config:   config,
+		requests: make(chan *batchRequest),
+		done:     make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.run()
+	return b
+}
+
+// run collects queued texts into batches until the batcher is closed.
+func (b *Batcher) run() {
+	defer b.wg.Done()
+
+	for {
+		var batch []*batchRequest
+		select {
+		case req := <-b.requests:
+			batch = append(batch, req)
+		case <-b.done:
+			return
+		}
+
+		timer := time.NewTimer(b.config.FlushInterval)
+	collect:
+		for len(batch) < b.config.MaxBatchSize {
+			select {
+			case req := <-b.requests:
+				batch = append(batch, req)
+			case <-timer.C:
+				break collect
+			case <-b.done:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		// Embed in the background so the next batch can fill meanwhile
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			b.flush(batch)
+		}()
+	}
+}
+
+// flush embeds a batch and hands each request its embedding.
+func (b *Batcher) flush(batch []*batchRequest) {
+	pending := make([]*batchRequest, 0, len(batch))
+	for _, req := range batch {
+		if req.ctx.Err() == nil {
+			pending = append(pending, req)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	texts := make([]string, len(pending))
+	for i, req := range pending {
+		texts[i] = req.text
+	}
+
+	// The batch outlives any single caller, so it is not canceled with them
+	embeddings, err := b.client.Embed(context.WithoutCancel(pending[0].ctx), texts)
+	if err == nil && len(embeddings) != len(texts) {
+		err = fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embeddings))
+	}
+
+	for i, req := range pending {
+		if err != nil {
+			req.result <- batchResult{err: err}
+		} else {
+			req.result <- batchResult{embedding: embeddings[i]}
+		}
+	}
+}
+
+// Embed generates embeddings for the given texts.
+func (b *Batcher) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return b.client.Embed(ctx, texts)
+}
+
+// EmbedSingle generates an embedding for a single text, batched with the
+// texts of concurrent calls.
+func (b *Batcher) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	req := &batchRequest{
+		ctx:    ctx,
+		text:   text,
+		result: make(chan batchResult, 1),
+	}
+
+	select {
+	case b.requests <- req:
+	case <-b.done:
+		return nil, fmt.Errorf("embedding batcher is closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case result := <-req.result:
+		return result.embedding, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// EmbedQuery generates an embedding for a search query. Queries are not
+// batched, as searches are latency sensitive.
+func (b *Batcher) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	return b.client.EmbedQuery(ctx, query)
+}
+
+// EmbedDocument generates an embedding for a text stored for retrieval,
+// batched like EmbedSingle.
+func (b *Batcher) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	return b.EmbedSingle(ctx, text)
+}
+
+// Dimensions returns the number of dimensions in the embeddings.
+func (b *Batcher) Dimensions() int {
+	return b.client.Dimensions()
+}
+
+// Model returns the name of the wrapped client's embedding model.
+func (b *Batcher) Model() string {
+	return ModelName(b.client)
+}
+
+// Close stops the batcher, waits for in-flight batches and closes the wrapped
+// client.
+func (b *Batcher) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+	b.wg.Wait()
+	return b.client.Close()
+}
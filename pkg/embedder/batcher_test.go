@@ -0,0 +1,107 @@
+package embedder_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/embedder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder embeds each text as its length and records batch sizes.
+type countingEmbedder struct {
+	mu      sync.Mutex
+	batches []int
+	err     error
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	c.mu.Lock()
+	c.batches = append(c.batches, len(texts))
+	c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = []float32{float32(len(text))}
+	}
+	return embeddings, nil
+}
+
+func (c *countingEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := c.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (c *countingEmbedder) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	return c.EmbedSingle(ctx, query)
+}
+
+func (c *countingEmbedder) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	return c.EmbedSingle(ctx, text)
+}
+
+func (c *countingEmbedder) Dimensions() int { return 1 }
+
+func (c *countingEmbedder) Close() error { return nil }
+
+func TestBatcherCoalescesConcurrentCalls(t *testing.T) {
+	inner := &countingEmbedder{}
+	batcher := embedder.NewBatcher(inner, embedder.BatcherConfig{
+		MaxBatchSize:  4,
+		FlushInterval: time.Second,
+	})
+	defer batcher.Close()
+
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee", "ffffff", "ggggggg", "hhhhhhhh"}
+	results := make([][]float32, len(texts))
+	var wg sync.WaitGroup
+	for i, text := range texts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			embedding, err := batcher.EmbedSingle(context.Background(), text)
+			assert.NoError(t, err)
+			results[i] = embedding
+		}()
+	}
+	wg.Wait()
+
+	for i, text := range texts {
+		assert.Equal(t, []float32{float32(len(text))}, results[i], "embedding of %q", text)
+	}
+	// Full batches are sent without waiting for the flush interval
+	assert.Equal(t, []int{4, 4}, inner.batches)
+}
+
+func TestBatcherFlushesPartialBatches(t *testing.T) {
+	inner := &countingEmbedder{}
+	batcher := embedder.NewBatcher(inner, embedder.BatcherConfig{FlushInterval: 5 * time.Millisecond})
+	defer batcher.Close()
+
+	embedding, err := batcher.EmbedSingle(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{3}, embedding)
+	assert.Equal(t, []int{1}, inner.batches)
+}
+
+func TestBatcherErrors(t *testing.T) {
+	inner := &countingEmbedder{err: errors.New("rate limited")}
+	batcher := embedder.NewBatcher(inner, embedder.BatcherConfig{FlushInterval: time.Millisecond})
+
+	_, err := batcher.EmbedSingle(context.Background(), "text")
+	assert.ErrorContains(t, err, "rate limited")
+
+	require.NoError(t, batcher.Close())
+	_, err = batcher.EmbedSingle(context.Background(), "text")
+	assert.ErrorContains(t, err, "closed")
+}
@@ -2,9 +2,16 @@ package embedder
 
 import (
 	"context"
+	"fmt"
+	"strings"
 )
 
 // Client defines the interface for embedding operations.
+//
+// Retrieval models may embed search queries differently from the documents
+// they are matched against, through an input type or an instruction prefix.
+// Embed, EmbedSingle and EmbedDocument embed documents; EmbedQuery embeds
+// search queries.
 type Client interface {
 	// Embed generates embeddings for the given texts.
 	Embed(ctx context.Context, texts []string) ([][]float32, error)
@@ -12,6 +19,12 @@ type Client interface {
 	// EmbedSingle generates an embedding for a single text.
 	EmbedSingle(ctx context.Context, text string) ([]float32, error)
 
+	// EmbedQuery generates an embedding for a search query.
+	EmbedQuery(ctx context.Context, query string) ([]float32, error)
+
+	// EmbedDocument generates an embedding for a text stored for retrieval.
+	EmbedDocument(ctx context.Context, text string) ([]float32, error)
+
 	// Dimensions returns the number of dimensions in the embeddings.
 	Dimensions() int
 
@@ -27,4 +40,86 @@ type Config struct {
 	BaseURL    string            `json:"base_url,omitempty"` // Custom base URL for OpenAI-compatible services
 	Headers    map[string]string `json:"headers,omitempty"`  // Additional headers for requests
 	MaxRetries int               `json:"max_retries"`        // Maximum number of retry attempts
+	// QueryPrefix and DocumentPrefix are prepended to search queries and to
+	// stored texts, for models trained with instructions (see InstructionPrefixes)
+	QueryPrefix    string `json:"query_prefix,omitempty"`
+	DocumentPrefix string `json:"document_prefix,omitempty"`
+}
+
+// InputType tells retrieval-tuned models whether a text is stored for
+// retrieval or searched with.
+type InputType string
+
+const (
+	// InputTypeDocument marks texts that are indexed, such as node names and facts.
+	InputTypeDocument InputType = "document"
+	// InputTypeQuery marks search queries.
+	InputTypeQuery InputType = "query"
+)
+
+// bgeQueryInstruction is the query instruction of the BGE family and the
+// models trained like them.
+const bgeQueryInstruction = "Represent this sentence for searching relevant passages: "
+
+// InstructionPrefixes returns the query and document prefixes a model was
+// trained with, or empty strings for models that need none or are unknown.
+func InstructionPrefixes(model string) (queryPrefix, documentPrefix string) {
+	name := strings.ToLower(model)
+	// Drop the organization of Hugging Face names and the tag of Ollama names
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[:i]
+	}
+
+	switch {
+	case strings.HasPrefix(name, "nomic-embed-text"):
+		return "search_query: ", "search_document: "
+	case (strings.HasPrefix(name, "e5-") || strings.HasPrefix(name, "multilingual-e5-")) && !strings.Contains(name, "instruct"):
+		return "query: ", "passage: "
+	case strings.HasPrefix(name, "bge-") && !strings.HasPrefix(name, "bge-m3"),
+		strings.HasPrefix(name, "mxbai-embed-large"),
+		strings.HasPrefix(name, "snowflake-arctic-embed"):
+		return bgeQueryInstruction, ""
+	}
+	return "", ""
+}
+
+// withPrefix returns texts with prefix prepended.
+func withPrefix(prefix string, texts []string) []string {
+	if prefix == "" {
+		return texts
+	}
+	prefixed := make([]string, len(texts))
+	for i, text := range texts {
+		prefixed[i] = prefix + text
+	}
+	return prefixed
+}
+
+// firstEmbedding returns the only embedding of a single-text request.
+func firstEmbedding(embeddings [][]float32, err error) ([]float32, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embeddings returned")
+	}
+	return embeddings[0], nil
+}
+
+// ModelNamer is implemented by embedders that report their model name.
+type ModelNamer interface {
+	// Model returns the name of the embedding model.
+	Model() string
+}
+
+// ModelName returns the name of client's embedding model, or "" when it does
+// not report one.
+func ModelName(client Client) string {
+	if namer, ok := client.(ModelNamer); ok {
+		return namer.Model()
+	}
+	return ""
 }
@@ -0,0 +1,220 @@
+package embedder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// CohereEmbedder implements the Client interface for Cohere embeddings.
+type CohereEmbedder struct {
+	config     *CohereConfig
+	httpClient *http.Client
+}
+
+// CohereConfig extends Config with Cohere-specific settings.
+type CohereConfig struct {
+	*Config
+	APIKey string `json:"api_key"`
+	// InputType is the input type of Embed and EmbedSingle, InputTypeDocument
+	// by default. EmbedQuery always uses InputTypeQuery and EmbedDocument
+	// InputTypeDocument.
+	InputType InputType `json:"input_type,omitempty"`
+}
+
+// NewCohereEmbedder creates a new Cohere embedder. Texts are embedded as
+// search_document, and search queries with EmbedQuery as search_query, as
+// Cohere v3 models require an input type.
+func NewCohereEmbedder(config *CohereConfig) *CohereEmbedder {
+	if config.Config == nil {
+		config.Config = &Config{}
+	}
+	if config.Model == "" {
+		config.Model = "embed-english-v3.0"
+	}
+	if config.Dimensions == 0 {
+		config.Dimensions = 1024
+	}
+	if config.InputType == "" {
+		config.InputType = InputTypeDocument
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.cohere.com"
+	}
+	if config.BatchSize == 0 || config.BatchSize > 96 {
+		// Cohere accepts at most 96 texts per request
+		config.BatchSize = 96
+	}
+
+	return &CohereEmbedder{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// cohereEmbeddingRequest represents the request structure for Cohere embed API.
+type cohereEmbeddingRequest struct {
+	Model          string   `json:"model"`
+	Texts          []string `json:"texts"`
+	InputType      string   `json:"input_type"`
+	EmbeddingTypes []string `json:"embedding_types"`
+}
+
+// cohereEmbeddingResponse represents the response from Cohere embed API.
+type cohereEmbeddingResponse struct {
+	ID         string `json:"id"`
+	Embeddings struct {
+		Float [][]float32 `json:"float"`
+	} `json:"embeddings"`
+	Message string `json:"message,omitempty"`
+}
+
+// cohereInputType maps an input type to Cohere's name for it.
+func cohereInputType(inputType InputType) string {
+	switch inputType {
+	case InputTypeQuery:
+		return "search_query"
+	case InputTypeDocument:
+		return "search_document"
+	default:
+		// Cohere-specific types such as "classification" or "clustering"
+		return string(inputType)
+	}
+}
+
+// Embed generates embeddings for the given texts.
+func (c *CohereEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts provided")
+	}
+
+	var allEmbeddings [][]float32
+
+	// Process texts in batches
+	for i := 0; i < len(texts); i += c.config.BatchSize {
+		end := i + c.config.BatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		batch := texts[i:end]
+		embeddings, err := c.embedBatch(ctx, batch, c.config.InputType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed batch: %w", err)
+		}
+
+		allEmbeddings = append(allEmbeddings, embeddings...)
+	}
+
+	return allEmbeddings, nil
+}
+
+// embedBatch processes a batch of texts.
+func (c *CohereEmbedder) embedBatch(ctx context.Context, texts []string, inputType InputType) ([][]float32, error) {
+	req := cohereEmbeddingRequest{
+		Model:          c.config.Model,
+		Texts:          texts,
+		InputType:      cohereInputType(inputType),
+		EmbeddingTypes: []string{"float"},
+	}
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+"/v2/embed", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+
+	// Add any additional headers
+	for key, value := range c.config.Headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var cohereResp cohereEmbeddingResponse
+	if err := json.Unmarshal(body, &cohereResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(cohereResp.Embeddings.Float) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(cohereResp.Embeddings.Float))
+	}
+
+	return cohereResp.Embeddings.Float, nil
+}
+
+// EmbedSingle generates an embedding for a single text.
+func (c *CohereEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := c.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embeddings returned")
+	}
+
+	return embeddings[0], nil
+}
+
+// EmbedQuery generates an embedding for a search query.
+func (c *CohereEmbedder) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	embeddings, err := c.embedBatch(ctx, []string{query}, InputTypeQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	return embeddings[0], nil
+}
+
+// EmbedDocument generates an embedding for a text stored for retrieval.
+func (c *CohereEmbedder) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := c.embedBatch(ctx, []string{text}, InputTypeDocument)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed document: %w", err)
+	}
+
+	return embeddings[0], nil
+}
+
+// Dimensions returns the number of dimensions in the embeddings.
+func (c *CohereEmbedder) Dimensions() int {
+	return c.config.Dimensions
+}
+
+// Model returns the name of the embedding model.
+func (c *CohereEmbedder) Model() string {
+	return c.config.Model
+}
+
+// Close cleans up any resources.
+func (c *CohereEmbedder) Close() error {
+	// Nothing to clean up for HTTP client
+	return nil
+}
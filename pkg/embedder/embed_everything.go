@@ -31,8 +31,13 @@ func NewEmbedEverythingClient(config *EmbedEverythingConfig) (*EmbedEverythingCl
 	}, nil
 }
 
-// Embed generates embeddings for the given texts.
+// Embed generates embeddings for the given texts, with the document prefix.
 func (e *EmbedEverythingClient) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.embedTexts(ctx, withPrefix(e.config.DocumentPrefix, texts))
+}
+
+// embedTexts embeds texts as given.
+func (e *EmbedEverythingClient) embedTexts(ctx context.Context, texts []string) ([][]float32, error) {
 	// go-embedeverything does not support context yet
 	embeddings, err := e.client.Embed(texts)
 	if err != nil {
@@ -43,14 +48,18 @@ func (e *EmbedEverythingClient) Embed(ctx context.Context, texts []string) ([][]
 
 // EmbedSingle generates an embedding for a single text.
 func (e *EmbedEverythingClient) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
-	embeddings, err := e.Embed(ctx, []string{text})
-	if err != nil {
-		return nil, err
-	}
-	if len(embeddings) == 0 {
-		return nil, fmt.Errorf("no embeddings returned")
-	}
-	return embeddings[0], nil
+	return e.EmbedDocument(ctx, text)
+}
+
+// EmbedQuery generates an embedding for a search query, with the query prefix.
+func (e *EmbedEverythingClient) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	return firstEmbedding(e.embedTexts(ctx, []string{e.config.QueryPrefix + query}))
+}
+
+// EmbedDocument generates an embedding for a text stored for retrieval, with
+// the document prefix.
+func (e *EmbedEverythingClient) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	return firstEmbedding(e.embedTexts(ctx, []string{e.config.DocumentPrefix + text}))
 }
 
 // Dimensions returns the number of dimensions in the embeddings.
@@ -58,6 +67,11 @@ func (e *EmbedEverythingClient) Dimensions() int {
 	return e.config.Dimensions
 }
 
+// Model returns the name of the embedding model.
+func (e *EmbedEverythingClient) Model() string {
+	return e.config.Model
+}
+
 // Close cleans up any resources.
 func (e *EmbedEverythingClient) Close() error {
 	e.client.Close()
@@ -46,10 +46,17 @@ type geminiEmbeddingRequest struct {
 
 // geminiEmbedRequest represents a single embedding request.
 type geminiEmbedRequest struct {
-	Model   string             `json:"model"`
-	Content geminiEmbedContent `json:"content"`
+	Model    string             `json:"model"`
+	Content  geminiEmbedContent `json:"content"`
+	TaskType string             `json:"taskType,omitempty"`
 }
 
+// Gemini task types for retrieval.
+const (
+	geminiTaskRetrievalQuery    = "RETRIEVAL_QUERY"
+	geminiTaskRetrievalDocument = "RETRIEVAL_DOCUMENT"
+)
+
 // geminiEmbedContent represents the content to embed.
 type geminiEmbedContent struct {
 	Parts []geminiEmbedPart `json:"parts"`
@@ -94,7 +101,7 @@ func (g *GeminiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32
 		}
 
 		batch := texts[i:end]
-		embeddings, err := g.embedBatch(ctx, batch)
+		embeddings, err := g.embedBatch(ctx, batch, geminiTaskRetrievalDocument)
 		if err != nil {
 			return nil, fmt.Errorf("failed to embed batch: %w", err)
 		}
@@ -106,7 +113,7 @@ func (g *GeminiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32
 }
 
 // embedBatch processes a batch of texts.
-func (g *GeminiEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+func (g *GeminiEmbedder) embedBatch(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
 	// For batch requests, Gemini expects multiple requests
 	requests := make([]geminiEmbedRequest, len(texts))
 	for i, text := range texts {
@@ -115,6 +122,7 @@ func (g *GeminiEmbedder) embedBatch(ctx context.Context, texts []string) ([][]fl
 			Content: geminiEmbedContent{
 				Parts: []geminiEmbedPart{{Text: text}},
 			},
+			TaskType: taskType,
 		}
 	}
 
@@ -176,12 +184,27 @@ func (g *GeminiEmbedder) embedBatch(ctx context.Context, texts []string) ([][]fl
 
 // EmbedSingle generates an embedding for a single text.
 func (g *GeminiEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
-	// For single requests, use the single endpoint
+	return g.embedContent(ctx, text, geminiTaskRetrievalDocument)
+}
+
+// EmbedQuery generates an embedding for a search query.
+func (g *GeminiEmbedder) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	return g.embedContent(ctx, query, geminiTaskRetrievalQuery)
+}
+
+// EmbedDocument generates an embedding for a text stored for retrieval.
+func (g *GeminiEmbedder) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	return g.embedContent(ctx, text, geminiTaskRetrievalDocument)
+}
+
+// embedContent embeds a single text with the single endpoint.
+func (g *GeminiEmbedder) embedContent(ctx context.Context, text, taskType string) ([]float32, error) {
 	req := geminiEmbedRequest{
 		Model: g.config.Model,
 		Content: geminiEmbedContent{
 			Parts: []geminiEmbedPart{{Text: text}},
 		},
+		TaskType: taskType,
 	}
 
 	reqBody, err := json.Marshal(req)
@@ -192,7 +215,7 @@ func (g *GeminiEmbedder) EmbedSingle(ctx context.Context, text string) ([]float3
 	url := fmt.Sprintf("%s/v1beta/models/%s:embedContent?key=%s",
 		g.config.BaseURL, g.config.Model, g.config.APIKey)
 
-	httpReq, err := http.NewRequestWithContext(context.Background(), "POST", url, bytes.NewReader(reqBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -240,6 +263,11 @@ func (g *GeminiEmbedder) Dimensions() int {
 	return g.config.Dimensions
 }
 
+// Model returns the name of the embedding model.
+func (g *GeminiEmbedder) Model() string {
+	return g.config.Model
+}
+
 // Close cleans up any resources.
 func (g *GeminiEmbedder) Close() error {
 	// Nothing to clean up for HTTP client
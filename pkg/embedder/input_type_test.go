@@ -0,0 +1,125 @@
+package embedder_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/embedder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCohereEmbedderInputTypes(t *testing.T) {
+	var inputTypes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v2/embed", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+
+		var req struct {
+			Texts     []string `json:"texts"`
+			InputType string   `json:"input_type"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		inputTypes = append(inputTypes, req.InputType)
+
+		embeddings := make([][]float32, len(req.Texts))
+		for i := range embeddings {
+			embeddings[i] = []float32{1, 0}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"embeddings": map[string]any{"float": embeddings}})
+	}))
+	defer server.Close()
+
+	client := embedder.NewCohereEmbedder(&embedder.CohereConfig{
+		Config: &embedder.Config{BaseURL: server.URL},
+		APIKey: "test-key",
+	})
+
+	embeddings, err := client.Embed(context.Background(), []string{"Alice works at Acme", "Bob lives in Paris"})
+	require.NoError(t, err)
+	assert.Len(t, embeddings, 2)
+
+	_, err = client.EmbedQuery(context.Background(), "where does Alice work?")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"search_document", "search_query"}, inputTypes)
+	assert.Equal(t, 1024, client.Dimensions())
+}
+
+func TestVoyageEmbedderInputTypes(t *testing.T) {
+	var inputTypes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/embeddings", r.URL.Path)
+
+		var req struct {
+			Input     []string `json:"input"`
+			InputType string   `json:"input_type"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		inputTypes = append(inputTypes, req.InputType)
+
+		data := make([]map[string]any, len(req.Input))
+		for i := range data {
+			data[i] = map[string]any{"index": i, "embedding": []float32{0, 1}}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer server.Close()
+
+	client := embedder.NewVoyageEmbedder(&embedder.VoyageConfig{
+		Config: &embedder.Config{BaseURL: server.URL},
+		APIKey: "test-key",
+	})
+
+	_, err := client.EmbedSingle(context.Background(), "Alice works at Acme")
+	require.NoError(t, err)
+	_, err = client.EmbedQuery(context.Background(), "where does Alice work?")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"document", "query"}, inputTypes)
+}
+
+func TestInstructionPrefixes(t *testing.T) {
+	var inputs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		inputs = append(inputs, req.Input...)
+		embeddings := make([][]float32, len(req.Input))
+		for i := range embeddings {
+			embeddings[i] = []float32{0.5, 0.5}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"embeddings": embeddings})
+	}))
+	defer server.Close()
+
+	client := embedder.NewOllamaEmbedder(server.URL, "nomic-embed-text:latest")
+	ctx := context.Background()
+	_, err := client.EmbedQuery(ctx, "where does Alice work?")
+	require.NoError(t, err)
+	_, err = client.EmbedDocument(ctx, "Alice works at Acme")
+	require.NoError(t, err)
+	_, err = client.Embed(ctx, []string{"Bob"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"search_query: where does Alice work?",
+		"search_document: Alice works at Acme",
+		"search_document: Bob",
+	}, inputs)
+
+	query, document := embedder.InstructionPrefixes("intfloat/e5-large-v2")
+	assert.Equal(t, "query: ", query)
+	assert.Equal(t, "passage: ", document)
+	query, document = embedder.InstructionPrefixes("BAAI/bge-small-en-v1.5")
+	assert.NotEmpty(t, query)
+	assert.Empty(t, document)
+	query, document = embedder.InstructionPrefixes("text-embedding-3-small")
+	assert.Empty(t, query)
+	assert.Empty(t, document)
+}
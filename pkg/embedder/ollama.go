@@ -0,0 +1,241 @@
+package embedder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ollama defaults.
+const (
+	DefaultOllamaBaseURL        = "http://localhost:11434"
+	DefaultOllamaEmbeddingModel = "nomic-embed-text"
+)
+
+// OllamaEmbedder implements the Client interface using a local Ollama server,
+// so that a pipeline with an Ollama LLM needs no cloud API at all.
+type OllamaEmbedder struct {
+	config     *Config
+	httpClient *http.Client
+
+	mu sync.Mutex
+	// legacyAPI is set once the server turned out to lack /api/embed
+	legacyAPI bool
+}
+
+// NewOllamaEmbedder creates an embedder for model served by Ollama at baseURL.
+// An empty baseURL uses DefaultOllamaBaseURL and an empty model
+// DefaultOllamaEmbeddingModel. Texts are embedded in batches with /api/embed,
+// falling back to one /api/embeddings request per text on older servers.
+// Dimensions are learned from the first response, and the instruction prefixes
+// of known models (nomic-embed-text, e5, bge...) are applied.
+func NewOllamaEmbedder(baseURL, model string) *OllamaEmbedder {
+	if baseURL == "" {
+		baseURL = DefaultOllamaBaseURL
+	}
+	if model == "" {
+		model = DefaultOllamaEmbeddingModel
+	}
+
+	queryPrefix, documentPrefix := InstructionPrefixes(model)
+	return &OllamaEmbedder{
+		config: &Config{
+			Model:          model,
+			BatchSize:      32,
+			BaseURL:        strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/v1"),
+			QueryPrefix:    queryPrefix,
+			DocumentPrefix: documentPrefix,
+		},
+		httpClient: &http.Client{
+			// Local models can be slow, especially on first load
+			Timeout: 5 * time.Minute,
+		},
+	}
+}
+
+// ollamaEmbedRequest is the request of /api/embed.
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// ollamaEmbedResponse is the response of /api/embed.
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// ollamaEmbeddingsRequest is the request of the legacy /api/embeddings.
+type ollamaEmbeddingsRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+// ollamaEmbeddingsResponse is the response of the legacy /api/embeddings.
+type ollamaEmbeddingsResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+// errOllamaNotFound reports a 404, meaning the endpoint or the model is missing.
+type errOllamaNotFound struct {
+	body string
+}
+
+func (e *errOllamaNotFound) Error() string {
+	return fmt.Sprintf("API request failed with status 404: %s", e.body)
+}
+
+// Embed generates embeddings for the given texts, with the document prefix.
+func (o *OllamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return o.embedTexts(ctx, withPrefix(o.config.DocumentPrefix, texts))
+}
+
+// embedTexts embeds texts as given.
+func (o *OllamaEmbedder) embedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts provided")
+	}
+
+	allEmbeddings := make([][]float32, 0, len(texts))
+	for i := 0; i < len(texts); i += o.config.BatchSize {
+		end := min(i+o.config.BatchSize, len(texts))
+
+		embeddings, err := o.embedBatch(ctx, texts[i:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed batch: %w", err)
+		}
+		allEmbeddings = append(allEmbeddings, embeddings...)
+	}
+
+	if len(allEmbeddings) > 0 {
+		o.mu.Lock()
+		if o.config.Dimensions == 0 {
+			o.config.Dimensions = len(allEmbeddings[0])
+		}
+		o.mu.Unlock()
+	}
+
+	return allEmbeddings, nil
+}
+
+// embedBatch embeds a batch with /api/embed, or text by text on servers
+// without it.
+func (o *OllamaEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	o.mu.Lock()
+	legacy := o.legacyAPI
+	o.mu.Unlock()
+
+	if !legacy {
+		var resp ollamaEmbedResponse
+		err := o.post(ctx, "/api/embed", ollamaEmbedRequest{Model: o.config.Model, Input: texts}, &resp)
+		if err == nil {
+			if len(resp.Embeddings) != len(texts) {
+				return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+			}
+			return resp.Embeddings, nil
+		}
+
+		var notFound *errOllamaNotFound
+		// A missing model is also a 404, but its error mentions the model
+		if !errors.As(err, &notFound) || strings.Contains(notFound.body, "model") {
+			return nil, err
+		}
+		o.mu.Lock()
+		o.legacyAPI = true
+		o.mu.Unlock()
+	}
+
+	embeddings := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		var resp ollamaEmbeddingsResponse
+		if err := o.post(ctx, "/api/embeddings", ollamaEmbeddingsRequest{Model: o.config.Model, Prompt: text}, &resp); err != nil {
+			return nil, err
+		}
+		if len(resp.Embedding) == 0 {
+			return nil, fmt.Errorf("no embedding returned")
+		}
+		embeddings = append(embeddings, resp.Embedding)
+	}
+	return embeddings, nil
+}
+
+// post sends a JSON request to path and decodes the response into out.
+func (o *OllamaEmbedder) post(ctx context.Context, path string, in, out any) error {
+	reqBody, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.config.BaseURL+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for key, value := range o.config.Headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &errOllamaNotFound{body: string(body)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// EmbedSingle generates an embedding for a single text.
+func (o *OllamaEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	return o.EmbedDocument(ctx, text)
+}
+
+// EmbedQuery generates an embedding for a search query, with the query prefix.
+func (o *OllamaEmbedder) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	return firstEmbedding(o.embedTexts(ctx, []string{o.config.QueryPrefix + query}))
+}
+
+// EmbedDocument generates an embedding for a text stored for retrieval, with
+// the document prefix.
+func (o *OllamaEmbedder) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	return firstEmbedding(o.embedTexts(ctx, []string{o.config.DocumentPrefix + text}))
+}
+
+// Dimensions returns the number of dimensions in the embeddings, 0 until the
+// first embedding has been generated.
+func (o *OllamaEmbedder) Dimensions() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.config.Dimensions
+}
+
+// Model returns the name of the embedding model.
+func (o *OllamaEmbedder) Model() string {
+	return o.config.Model
+}
+
+// Close cleans up any resources.
+func (o *OllamaEmbedder) Close() error {
+	// Nothing to clean up for HTTP client
+	return nil
+}
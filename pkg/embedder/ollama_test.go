@@ -0,0 +1,87 @@
+package embedder_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/embedder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaEmbedderBatchAPI(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "/api/embed", r.URL.Path)
+
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "nomic-embed-text", req.Model)
+
+		embeddings := make([][]float32, len(req.Input))
+		for i := range embeddings {
+			embeddings[i] = []float32{float32(i), 0.5, 0.25}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"embeddings": embeddings})
+	}))
+	defer server.Close()
+
+	client := embedder.NewOllamaEmbedder(server.URL+"/v1", "nomic-embed-text")
+	var _ embedder.Client = client
+	assert.Equal(t, 0, client.Dimensions())
+
+	embeddings, err := client.Embed(context.Background(), []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Len(t, embeddings, 2)
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, 3, client.Dimensions())
+}
+
+func TestOllamaEmbedderLegacyAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embeddings" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		json.NewEncoder(w).Encode(map[string]any{"embedding": []float32{float32(len(req.Prompt)), 1}})
+	}))
+	defer server.Close()
+
+	client := embedder.NewOllamaEmbedder(server.URL, "all-minilm")
+
+	embeddings, err := client.Embed(context.Background(), []string{"a", "abc"})
+	require.NoError(t, err)
+	require.Len(t, embeddings, 2)
+	assert.Equal(t, float32(1), embeddings[0][0])
+	assert.Equal(t, float32(3), embeddings[1][0])
+
+	embedding, err := client.EmbedSingle(context.Background(), "ab")
+	require.NoError(t, err)
+	assert.Equal(t, float32(2), embedding[0])
+}
+
+func TestOllamaEmbedderMissingModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model \"missing\" not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	client := embedder.NewOllamaEmbedder(server.URL, "missing")
+
+	_, err := client.EmbedSingle(context.Background(), "text")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "try pulling it first")
+}
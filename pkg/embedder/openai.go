@@ -68,8 +68,13 @@ func NewOpenAIEmbedder(apiKey string, config Config) *OpenAIEmbedder {
 	}
 }
 
-// Embed generates embeddings for multiple texts.
+// Embed generates embeddings for the given texts, with the document prefix.
 func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.embedTexts(ctx, withPrefix(e.config.DocumentPrefix, texts))
+}
+
+// embedTexts embeds texts as given.
+func (e *OpenAIEmbedder) embedTexts(ctx context.Context, texts []string) ([][]float32, error) {
 	if len(texts) == 0 {
 		return [][]float32{}, nil
 	}
@@ -97,16 +102,18 @@ func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32
 
 // EmbedSingle generates an embedding for a single text.
 func (e *OpenAIEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
-	embeddings, err := e.Embed(ctx, []string{text})
-	if err != nil {
-		return nil, err
-	}
+	return e.EmbedDocument(ctx, text)
+}
 
-	if len(embeddings) == 0 {
-		return nil, fmt.Errorf("no embeddings returned")
-	}
+// EmbedQuery generates an embedding for a search query, with the query prefix.
+func (e *OpenAIEmbedder) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	return firstEmbedding(e.embedTexts(ctx, []string{e.config.QueryPrefix + query}))
+}
 
-	return embeddings[0], nil
+// EmbedDocument generates an embedding for a text stored for retrieval, with
+// the document prefix.
+func (e *OpenAIEmbedder) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	return firstEmbedding(e.embedTexts(ctx, []string{e.config.DocumentPrefix + text}))
 }
 
 // Dimensions returns the number of dimensions in the embeddings.
@@ -114,6 +121,11 @@ func (e *OpenAIEmbedder) Dimensions() int {
 	return e.config.Dimensions
 }
 
+// Model returns the name of the embedding model.
+func (e *OpenAIEmbedder) Model() string {
+	return e.config.Model
+}
+
 // Close cleans up resources (no-op for OpenAI embedder).
 func (e *OpenAIEmbedder) Close() error {
 	return nil
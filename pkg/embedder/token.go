@@ -0,0 +1,68 @@
+package embedder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin is how long before expiry a cached access token is refreshed.
+const tokenRefreshMargin = 5 * time.Minute
+
+// TokenProvider supplies OAuth2 access tokens for cloud embedders that
+// authenticate with an identity instead of an API key: Microsoft Entra ID
+// tokens for Azure OpenAI, Google Cloud tokens for Vertex AI. It has the
+// method of llm.AzureTokenProvider, so one provider serves both packages.
+type TokenProvider interface {
+	Token(ctx context.Context) (token string, expiresOn time.Time, err error)
+}
+
+// TokenProviderFunc adapts a function to the TokenProvider interface. A Google
+// Cloud token source can be wrapped as:
+//
+//	embedder.TokenProviderFunc(func(ctx context.Context) (string, time.Time, error) {
+//		token, err := tokenSource.Token()
+//		if err != nil {
+//			return "", time.Time{}, err
+//		}
+//		return token.AccessToken, token.Expiry, nil
+//	})
+type TokenProviderFunc func(ctx context.Context) (string, time.Time, error)
+
+// Token implements TokenProvider.
+func (f TokenProviderFunc) Token(ctx context.Context) (string, time.Time, error) {
+	return f(ctx)
+}
+
+// tokenCache caches the token of a TokenProvider until shortly before it expires.
+type tokenCache struct {
+	provider TokenProvider
+
+	mu        sync.Mutex
+	token     string
+	expiresOn time.Time
+}
+
+// get returns the cached token, refreshing it when it is about to expire.
+// Tokens without an expiry are requested every time.
+func (c *tokenCache) get(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Until(c.expiresOn) > tokenRefreshMargin {
+		return c.token, nil
+	}
+
+	token, expiresOn, err := c.provider.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		return "", fmt.Errorf("token provider returned an empty token")
+	}
+
+	c.token = token
+	c.expiresOn = expiresOn
+	return token, nil
+}
@@ -0,0 +1,118 @@
+package embedder
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// TruncatedEmbedder wraps an embedding client and shortens its embeddings to
+// a target size, renormalized to unit length. Models trained with Matryoshka
+// representation learning (text-embedding-3, nomic-embed-text v1.5,
+// mxbai-embed-large...) keep most of their retrieval quality when truncated,
+// so large graphs can store e.g. 256 instead of 1536 values per embedding and
+// compare them faster. Truncating embeddings of other models degrades them.
+type TruncatedEmbedder struct {
+	client     Client
+	dimensions int
+}
+
+// NewTruncatedEmbedder creates an embedder returning the first dimensions
+// values of client's embeddings, renormalized.
+func NewTruncatedEmbedder(client Client, dimensions int) (*TruncatedEmbedder, error) {
+	if dimensions <= 0 {
+		return nil, fmt.Errorf("target dimensions must be positive, got %d", dimensions)
+	}
+	if inner := client.Dimensions(); inner > 0 && dimensions > inner {
+		return nil, fmt.Errorf("target dimensions %d exceed the %d dimensions of the embedder", dimensions, inner)
+	}
+
+	return &TruncatedEmbedder{
+		client:     client,
+		dimensions: dimensions,
+	}, nil
+}
+
+// TruncateEmbedding returns the first dimensions values of embedding, scaled
+// to unit length so that cosine and dot product similarities agree.
+func TruncateEmbedding(embedding []float32, dimensions int) ([]float32, error) {
+	if len(embedding) < dimensions {
+		return nil, fmt.Errorf("cannot truncate a %d-dimensional embedding to %d dimensions", len(embedding), dimensions)
+	}
+
+	truncated := make([]float32, dimensions)
+	copy(truncated, embedding[:dimensions])
+
+	var norm float64
+	for _, value := range truncated {
+		norm += float64(value) * float64(value)
+	}
+	if norm == 0 {
+		return truncated, nil
+	}
+
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range truncated {
+		truncated[i] *= scale
+	}
+	return truncated, nil
+}
+
+// Embed generates truncated embeddings for the given texts.
+func (t *TruncatedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings, err := t.client.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	truncated := make([][]float32, len(embeddings))
+	for i, embedding := range embeddings {
+		if truncated[i], err = TruncateEmbedding(embedding, t.dimensions); err != nil {
+			return nil, err
+		}
+	}
+	return truncated, nil
+}
+
+// EmbedSingle generates a truncated embedding for a single text.
+func (t *TruncatedEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	embedding, err := t.client.EmbedSingle(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return TruncateEmbedding(embedding, t.dimensions)
+}
+
+// EmbedQuery generates a truncated embedding for a search query.
+func (t *TruncatedEmbedder) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	embedding, err := t.client.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return TruncateEmbedding(embedding, t.dimensions)
+}
+
+// EmbedDocument generates a truncated embedding for a text stored for
+// retrieval.
+func (t *TruncatedEmbedder) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	embedding, err := t.client.EmbedDocument(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return TruncateEmbedding(embedding, t.dimensions)
+}
+
+// Dimensions returns the target number of dimensions.
+func (t *TruncatedEmbedder) Dimensions() int {
+	return t.dimensions
+}
+
+// Model returns the name of the wrapped client's embedding model.
+func (t *TruncatedEmbedder) Model() string {
+	return ModelName(t.client)
+}
+
+// Close closes the wrapped client.
+func (t *TruncatedEmbedder) Close() error {
+	return t.client.Close()
+}
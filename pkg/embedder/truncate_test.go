@@ -0,0 +1,43 @@
+package embedder_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/embedder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateEmbedding(t *testing.T) {
+	truncated, err := embedder.TruncateEmbedding([]float32{3, 4, 12}, 2)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float32{0.6, 0.8}, truncated, 1e-6)
+
+	zero, err := embedder.TruncateEmbedding([]float32{0, 0, 1}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0, 0}, zero)
+
+	_, err = embedder.TruncateEmbedding([]float32{1}, 2)
+	assert.Error(t, err)
+}
+
+func TestTruncatedEmbedder(t *testing.T) {
+	_, err := embedder.NewTruncatedEmbedder(&countingEmbedder{}, 0)
+	assert.Error(t, err)
+	_, err = embedder.NewTruncatedEmbedder(&countingEmbedder{}, 2)
+	assert.Error(t, err, "target larger than the embedder's dimensions")
+
+	// countingEmbedder embeds a text as its length, a single dimension
+	truncated, err := embedder.NewTruncatedEmbedder(&countingEmbedder{}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, truncated.Dimensions())
+
+	embeddings, err := truncated.Embed(context.Background(), []string{"abc", "de"})
+	require.NoError(t, err)
+	for _, embedding := range embeddings {
+		require.Len(t, embedding, 1)
+		assert.InDelta(t, 1, math.Abs(float64(embedding[0])), 1e-6, "embeddings are renormalized")
+	}
+}
@@ -0,0 +1,268 @@
+package embedder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultVertexEmbeddingModel is the Vertex AI model used when none is given.
+	DefaultVertexEmbeddingModel = "text-embedding-005"
+	// DefaultVertexLocation is the Vertex AI region used when none is given.
+	DefaultVertexLocation = "us-central1"
+	// GoogleCloudPlatformScope is the OAuth2 scope of Vertex AI access tokens.
+	GoogleCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// VertexEmbedder implements the Client interface for Google Vertex AI text
+// embedding models.
+type VertexEmbedder struct {
+	config     *VertexConfig
+	httpClient *http.Client
+	tokens     *tokenCache
+}
+
+// VertexConfig extends Config with Vertex AI settings. BaseURL overrides the
+// regional endpoint (https://{location}-aiplatform.googleapis.com).
+type VertexConfig struct {
+	*Config
+	ProjectID string `json:"project_id"`
+	Location  string `json:"location,omitempty"`
+	// TokenProvider supplies Google Cloud access tokens for
+	// GoogleCloudPlatformScope, e.g. from application default credentials
+	TokenProvider TokenProvider `json:"-"`
+	// InputType is the input type of Embed and EmbedSingle, InputTypeDocument
+	// by default. EmbedQuery always uses InputTypeQuery and EmbedDocument
+	// InputTypeDocument.
+	InputType InputType `json:"input_type,omitempty"`
+}
+
+// NewVertexEmbedder creates an embedder for a Vertex AI text embedding model
+// of config.ProjectID. Texts are embedded with the RETRIEVAL_DOCUMENT task type
+// and search queries with EmbedQuery as RETRIEVAL_QUERY. config.Dimensions,
+// when set, asks the model for shorter embeddings.
+func NewVertexEmbedder(config *VertexConfig) (*VertexEmbedder, error) {
+	if config == nil || config.ProjectID == "" {
+		return nil, fmt.Errorf("project ID is required for Vertex AI")
+	}
+	if config.TokenProvider == nil {
+		return nil, fmt.Errorf("a token provider is required for Vertex AI")
+	}
+	if config.Config == nil {
+		config.Config = &Config{}
+	}
+	if config.Model == "" {
+		config.Model = DefaultVertexEmbeddingModel
+	}
+	if config.Dimensions == 0 {
+		switch {
+		case strings.HasPrefix(config.Model, "gemini-embedding"):
+			config.Dimensions = 3072
+		case strings.HasPrefix(config.Model, "text-embedding-"), strings.HasPrefix(config.Model, "text-multilingual-embedding-"):
+			config.Dimensions = 768
+		}
+	}
+	if config.Location == "" {
+		config.Location = DefaultVertexLocation
+	}
+	if config.InputType == "" {
+		config.InputType = InputTypeDocument
+	}
+	if config.BaseURL == "" {
+		if config.Location == "global" {
+			config.BaseURL = "https://aiplatform.googleapis.com"
+		} else {
+			config.BaseURL = fmt.Sprintf("https://%s-aiplatform.googleapis.com", config.Location)
+		}
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.BatchSize == 0 {
+		config.BatchSize = 100
+	}
+	if strings.HasPrefix(config.Model, "gemini-embedding") {
+		// Gemini embedding models accept a single text per request
+		config.BatchSize = 1
+	}
+
+	return &VertexEmbedder{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		tokens: &tokenCache{provider: config.TokenProvider},
+	}, nil
+}
+
+// vertexPredictRequest represents the request of the Vertex AI predict API.
+type vertexPredictRequest struct {
+	Instances  []vertexInstance  `json:"instances"`
+	Parameters *vertexParameters `json:"parameters,omitempty"`
+}
+
+// vertexInstance is a text to embed.
+type vertexInstance struct {
+	Content  string `json:"content"`
+	TaskType string `json:"task_type,omitempty"`
+}
+
+// vertexParameters holds the embedding parameters.
+type vertexParameters struct {
+	OutputDimensionality int `json:"outputDimensionality,omitempty"`
+}
+
+// vertexPredictResponse represents the response of the Vertex AI predict API.
+type vertexPredictResponse struct {
+	Predictions []struct {
+		Embeddings struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+	} `json:"predictions"`
+}
+
+// vertexTaskType maps an input type to the Vertex AI task type.
+func vertexTaskType(inputType InputType) string {
+	if inputType == InputTypeQuery {
+		return "RETRIEVAL_QUERY"
+	}
+	return "RETRIEVAL_DOCUMENT"
+}
+
+// Embed generates embeddings for the given texts.
+func (v *VertexEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts provided")
+	}
+
+	var allEmbeddings [][]float32
+
+	// Process texts in batches
+	for i := 0; i < len(texts); i += v.config.BatchSize {
+		end := min(i+v.config.BatchSize, len(texts))
+
+		embeddings, err := v.embedBatch(ctx, texts[i:end], v.config.InputType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed batch: %w", err)
+		}
+
+		allEmbeddings = append(allEmbeddings, embeddings...)
+	}
+
+	return allEmbeddings, nil
+}
+
+// embedBatch processes a batch of texts.
+func (v *VertexEmbedder) embedBatch(ctx context.Context, texts []string, inputType InputType) ([][]float32, error) {
+	req := vertexPredictRequest{
+		Instances: make([]vertexInstance, len(texts)),
+	}
+	for i, text := range texts {
+		req.Instances[i] = vertexInstance{Content: text, TaskType: vertexTaskType(inputType)}
+	}
+	if v.config.Dimensions > 0 {
+		req.Parameters = &vertexParameters{OutputDimensionality: v.config.Dimensions}
+	}
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/locations/%s/publishers/google/models/%s:predict",
+		v.config.BaseURL, url.PathEscape(v.config.ProjectID), url.PathEscape(v.config.Location), url.PathEscape(v.config.Model))
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	token, err := v.tokens.get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+
+	// Add any additional headers
+	for key, value := range v.config.Headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	resp, err := v.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var vertexResp vertexPredictResponse
+	if err := json.Unmarshal(body, &vertexResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(vertexResp.Predictions) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(vertexResp.Predictions))
+	}
+
+	embeddings := make([][]float32, len(vertexResp.Predictions))
+	for i, prediction := range vertexResp.Predictions {
+		embeddings[i] = prediction.Embeddings.Values
+	}
+
+	return embeddings, nil
+}
+
+// EmbedSingle generates an embedding for a single text.
+func (v *VertexEmbedder) EmbedSingle(ctx context.Context, text string) ([]float32, error) {
+	return firstEmbedding(v.Embed(ctx, []string{text}))
+}
+
+// EmbedQuery generates an embedding for a search query.
+func (v *VertexEmbedder) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	embeddings, err := v.embedBatch(ctx, []string{query}, InputTypeQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	return embeddings[0], nil
+}
+
+// EmbedDocument generates an embedding for a text stored for retrieval.
+func (v *VertexEmbedder) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := v.embedBatch(ctx, []string{text}, InputTypeDocument)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed document: %w", err)
+	}
+
+	return embeddings[0], nil
+}
+
+// Dimensions returns the number of dimensions in the embeddings.
+func (v *VertexEmbedder) Dimensions() int {
+	return v.config.Dimensions
+}
+
+// Model returns the name of the embedding model.
+func (v *VertexEmbedder) Model() string {
+	return v.config.Model
+}
+
+// Close cleans up any resources.
+func (v *VertexEmbedder) Close() error {
+	// Nothing to clean up for HTTP client
+	return nil
+}
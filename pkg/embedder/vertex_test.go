@@ -0,0 +1,74 @@
+package embedder_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/embedder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVertexEmbedder(t *testing.T) {
+	var taskTypes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/projects/my-project/locations/europe-west1/publishers/google/models/text-embedding-005:predict", r.URL.Path)
+		assert.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+
+		var req struct {
+			Instances []struct {
+				Content  string `json:"content"`
+				TaskType string `json:"task_type"`
+			} `json:"instances"`
+			Parameters struct {
+				OutputDimensionality int `json:"outputDimensionality"`
+			} `json:"parameters"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, 256, req.Parameters.OutputDimensionality)
+
+		predictions := make([]map[string]any, len(req.Instances))
+		for i, instance := range req.Instances {
+			taskTypes = append(taskTypes, instance.TaskType)
+			predictions[i] = map[string]any{"embeddings": map[string]any{"values": []float32{float32(len(instance.Content))}}}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"predictions": predictions})
+	}))
+	defer server.Close()
+
+	var tokenCalls int
+	client, err := embedder.NewVertexEmbedder(&embedder.VertexConfig{
+		Config:    &embedder.Config{BaseURL: server.URL, Dimensions: 256},
+		ProjectID: "my-project",
+		Location:  "europe-west1",
+		TokenProvider: embedder.TokenProviderFunc(func(ctx context.Context) (string, time.Time, error) {
+			tokenCalls++
+			return "token-1", time.Now().Add(time.Hour), nil
+		}),
+	})
+	require.NoError(t, err)
+	var _ embedder.Client = client
+
+	embeddings, err := client.Embed(context.Background(), []string{"a", "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {3}}, embeddings)
+
+	_, err = client.EmbedQuery(context.Background(), "query")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"RETRIEVAL_DOCUMENT", "RETRIEVAL_DOCUMENT", "RETRIEVAL_QUERY"}, taskTypes)
+	assert.Equal(t, 1, tokenCalls, "tokens are cached until they expire")
+	assert.Equal(t, 256, client.Dimensions())
+}
+
+func TestNewVertexEmbedderRequiresProjectAndToken(t *testing.T) {
+	_, err := embedder.NewVertexEmbedder(&embedder.VertexConfig{})
+	assert.Error(t, err)
+
+	_, err = embedder.NewVertexEmbedder(&embedder.VertexConfig{ProjectID: "my-project"})
+	assert.Error(t, err)
+}
@@ -20,10 +20,28 @@ type VoyageEmbedder struct {
 type VoyageConfig struct {
 	*Config
 	APIKey string `json:"api_key"`
+	// InputType is the input type of Embed and EmbedSingle, InputTypeDocument
+	// by default. EmbedQuery always uses InputTypeQuery and EmbedDocument
+	// InputTypeDocument.
+	InputType InputType `json:"input_type,omitempty"`
 }
 
-// NewVoyageEmbedder creates a new Voyage embedder.
+// NewVoyageEmbedder creates a new Voyage embedder. Texts are embedded as
+// documents, and search queries with EmbedQuery, as Voyage models are trained
+// to match queries against documents.
 func NewVoyageEmbedder(config *VoyageConfig) *VoyageEmbedder {
+	if config.Config == nil {
+		config.Config = &Config{}
+	}
+	if config.Model == "" {
+		config.Model = "voyage-3"
+	}
+	if config.Dimensions == 0 {
+		config.Dimensions = 1024
+	}
+	if config.InputType == "" {
+		config.InputType = InputTypeDocument
+	}
 	if config.BaseURL == "" {
 		config.BaseURL = "https://api.voyageai.com"
 	}
@@ -41,8 +59,9 @@ func NewVoyageEmbedder(config *VoyageConfig) *VoyageEmbedder {
 
 // voyageEmbeddingRequest represents the request structure for Voyage AI embeddings API.
 type voyageEmbeddingRequest struct {
-	Input []string `json:"input"`
-	Model string   `json:"model"`
+	Input     []string  `json:"input"`
+	Model     string    `json:"model"`
+	InputType InputType `json:"input_type,omitempty"`
 }
 
 // voyageEmbeddingResponse represents the response from Voyage AI embeddings API.
@@ -89,7 +108,7 @@ func (v *VoyageEmbedder) Embed(ctx context.Context, texts []string) ([][]float32
 		}
 
 		batch := texts[i:end]
-		embeddings, err := v.embedBatch(ctx, batch)
+		embeddings, err := v.embedBatch(ctx, batch, v.config.InputType)
 		if err != nil {
 			return nil, fmt.Errorf("failed to embed batch: %w", err)
 		}
@@ -101,10 +120,11 @@ func (v *VoyageEmbedder) Embed(ctx context.Context, texts []string) ([][]float32
 }
 
 // embedBatch processes a batch of texts.
-func (v *VoyageEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+func (v *VoyageEmbedder) embedBatch(ctx context.Context, texts []string, inputType InputType) ([][]float32, error) {
 	req := voyageEmbeddingRequest{
-		Input: texts,
-		Model: v.config.Model,
+		Input:     texts,
+		Model:     v.config.Model,
+		InputType: inputType,
 	}
 
 	reqBody, err := json.Marshal(req)
@@ -171,11 +191,40 @@ func (v *VoyageEmbedder) EmbedSingle(ctx context.Context, text string) ([]float3
 	return embeddings[0], nil
 }
 
+// EmbedQuery generates an embedding for a search query.
+func (v *VoyageEmbedder) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	embeddings, err := v.embedBatch(ctx, []string{query}, InputTypeQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embeddings returned")
+	}
+
+	return embeddings[0], nil
+}
+
+// EmbedDocument generates an embedding for a text stored for retrieval.
+func (v *VoyageEmbedder) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := v.embedBatch(ctx, []string{text}, InputTypeDocument)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed document: %w", err)
+	}
+
+	return embeddings[0], nil
+}
+
 // Dimensions returns the number of dimensions in the embeddings.
 func (v *VoyageEmbedder) Dimensions() int {
 	return v.config.Dimensions
 }
 
+// Model returns the name of the embedding model.
+func (v *VoyageEmbedder) Model() string {
+	return v.config.Model
+}
+
 // Close cleans up any resources.
 func (v *VoyageEmbedder) Close() error {
 	// Nothing to clean up for HTTP client
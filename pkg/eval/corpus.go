@@ -0,0 +1,86 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// Corpus is a set of episodes labeled with the entities and relationships
+// that should be extracted from them.
+type Corpus struct {
+	Episodes []CorpusEpisode `json:"episodes"`
+}
+
+// CorpusEpisode is an episode of a corpus with its expected entities and
+// relationships.
+type CorpusEpisode struct {
+	ID          string    `json:"id,omitempty"`
+	Name        string    `json:"name"`
+	Content     string    `json:"content"`
+	Source      string    `json:"source,omitempty"`
+	Reference   time.Time `json:"reference,omitempty"`
+	GroupID     string    `json:"group_id,omitempty"`
+	EpisodeType string    `json:"episode_type,omitempty"`
+
+	Entities []ExpectedEntity `json:"entities"`
+	Edges    []ExpectedEdge   `json:"edges"`
+}
+
+// ExpectedEntity is an entity expected in an episode. An empty Type is
+// DefaultEntityType.
+type ExpectedEntity struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// ExpectedEdge is a relationship expected in an episode, between the names
+// of its entities.
+type ExpectedEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+// LoadCorpus reads a corpus from a JSON file.
+func LoadCorpus(path string) (*Corpus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus: %w", err)
+	}
+	var corpus Corpus
+	if err := json.Unmarshal(data, &corpus); err != nil {
+		return nil, fmt.Errorf("failed to parse corpus %s: %w", path, err)
+	}
+	return &corpus, nil
+}
+
+// Episode returns the episode to ingest. Episodes without an ID or reference
+// time get one from their index in the corpus and the current time.
+func (e CorpusEpisode) Episode(index int) types.Episode {
+	episode := types.Episode{
+		ID:        e.ID,
+		Name:      e.Name,
+		Content:   e.Content,
+		Source:    e.Source,
+		Reference: e.Reference,
+		CreatedAt: time.Now(),
+		GroupID:   e.GroupID,
+	}
+	if episode.ID == "" {
+		episode.ID = fmt.Sprintf("eval-episode-%d", index)
+	}
+	if episode.Name == "" {
+		episode.Name = episode.ID
+	}
+	if episode.Reference.IsZero() {
+		episode.Reference = episode.CreatedAt
+	}
+	if e.EpisodeType != "" {
+		episode.Metadata = map[string]interface{}{types.EpisodeTypeMetadataKey: e.EpisodeType}
+	}
+	return episode
+}
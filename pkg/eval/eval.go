@@ -0,0 +1,120 @@
+// Package eval evaluates entity and relationship extraction against a corpus
+// of episodes labeled with the entities and relationships they contain. Each
+// episode is ingested with a Pipeline, and the precision, recall and F1 score
+// of the extraction are computed overall and for each entity and
+// relationship type. With an LLM, the eval prompts of the prompt library also
+// judge whether each extraction is worse than the labels.
+//
+//	corpus, err := eval.LoadCorpus("corpus.json")
+//	evaluator := eval.NewEvaluator(func(ctx context.Context, episode types.Episode) (*types.AddEpisodeResults, error) {
+//		return client.AddEpisode(ctx, episode, options)
+//	})
+//	report, err := evaluator.Run(ctx, corpus)
+//	fmt.Println(report.Markdown())
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// Pipeline ingests an episode and returns what was extracted from it, for
+// example with predicato.Client.AddEpisode.
+type Pipeline func(ctx context.Context, episode types.Episode) (*types.AddEpisodeResults, error)
+
+// Evaluator evaluates a Pipeline against labeled corpora.
+type Evaluator struct {
+	pipeline Pipeline
+	llm      llm.Client
+	prompts  prompts.Library
+	logger   *slog.Logger
+}
+
+// NewEvaluator creates an evaluator of pipeline.
+func NewEvaluator(pipeline Pipeline) *Evaluator {
+	return &Evaluator{
+		pipeline: pipeline,
+		prompts:  prompts.NewLibrary(),
+		logger:   slog.Default(),
+	}
+}
+
+// SetJudge makes the evaluator ask client whether the extraction of each
+// episode is worse than its labels, with the eval prompts of library or of
+// the default library when it is nil.
+func (e *Evaluator) SetJudge(client llm.Client, library prompts.Library) {
+	e.llm = client
+	if library != nil {
+		e.prompts = library
+	}
+}
+
+// SetLogger sets the logger of the evaluator.
+func (e *Evaluator) SetLogger(logger *slog.Logger) {
+	e.logger = logger
+}
+
+// Run ingests the episodes of corpus in order and scores what was extracted
+// from each against its labels.
+func (e *Evaluator) Run(ctx context.Context, corpus *Corpus) (*Report, error) {
+	report := newReport()
+	for i, corpusEpisode := range corpus.Episodes {
+		episode := corpusEpisode.Episode(i)
+		results, err := e.pipeline(ctx, episode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add episode %s: %w", episode.ID, err)
+		}
+
+		episodeReport := report.addEpisode(episode.Name, corpusEpisode, results)
+		if e.llm != nil {
+			judgment, err := e.judge(ctx, corpusEpisode, results)
+			if err != nil {
+				return nil, fmt.Errorf("failed to judge episode %s: %w", episode.ID, err)
+			}
+			episodeReport.Judgment = judgment
+			report.Judged++
+			if judgment.CandidateIsWorse {
+				report.WorseThanLabels++
+			}
+		}
+		e.logger.Debug("Evaluated episode",
+			"episode_id", episode.ID,
+			"entity_f1", episodeReport.Entities.F1,
+			"edge_f1", episodeReport.Edges.F1)
+	}
+	return report, nil
+}
+
+// judge asks the LLM whether what was extracted from an episode is worse
+// than its labels, with the labels as the baseline.
+func (e *Evaluator) judge(ctx context.Context, episode CorpusEpisode, results *types.AddEpisodeResults) (*prompts.EvalAddEpisodeResults, error) {
+	baseline := labeledGraph{Entities: episode.Entities, Edges: episode.Edges}
+	candidate := extractedGraph(results)
+
+	messages, err := e.prompts.Eval().EvalAddEpisodeResults().Call(map[string]interface{}{
+		"previous_messages": []string{},
+		"message":           episode.Content,
+		"baseline":          baseline,
+		"candidate":         candidate,
+		"logger":            e.logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create eval prompt: %w", err)
+	}
+	response, err := e.llm.ChatWithStructuredOutput(ctx, messages, &prompts.EvalAddEpisodeResults{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LLM response: %w", err)
+	}
+
+	var judgment prompts.EvalAddEpisodeResults
+	if err := json.Unmarshal([]byte(llm.ExtractJSONFromResponse(response.Content)), &judgment); err != nil {
+		return nil, fmt.Errorf("failed to parse eval response: %w", err)
+	}
+	return &judgment, nil
+}
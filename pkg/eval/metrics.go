@@ -0,0 +1,217 @@
+package eval
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// DefaultEntityType is the type of the entities labeled or extracted without
+// one.
+const DefaultEntityType = "Entity"
+
+// Metrics are the precision, recall and F1 score of an extraction against
+// the labels of a corpus.
+type Metrics struct {
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	FalseNegatives int     `json:"false_negatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+}
+
+// add adds the counts of other to m and recomputes its scores.
+func (m *Metrics) add(other Metrics) {
+	m.TruePositives += other.TruePositives
+	m.FalsePositives += other.FalsePositives
+	m.FalseNegatives += other.FalseNegatives
+	m.score()
+}
+
+// score computes the precision, recall and F1 score from the counts. They
+// are 0 when undefined.
+func (m *Metrics) score() {
+	m.Precision, m.Recall, m.F1 = 0, 0, 0
+	if predicted := m.TruePositives + m.FalsePositives; predicted > 0 {
+		m.Precision = float64(m.TruePositives) / float64(predicted)
+	}
+	if expected := m.TruePositives + m.FalseNegatives; expected > 0 {
+		m.Recall = float64(m.TruePositives) / float64(expected)
+	}
+	if m.Precision+m.Recall > 0 {
+		m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+	}
+}
+
+// label is an entity or relationship compared between the labels and the
+// extraction, with the type its metrics are counted under.
+type label struct {
+	key         string
+	description string
+	typ         string
+}
+
+// comparison is the result of comparing the labels of an episode to what was
+// extracted from it.
+type comparison struct {
+	metrics    Metrics
+	byType     map[string]Metrics
+	missing    []string
+	unexpected []string
+}
+
+// compare counts the labels of expected found in extracted by key, overall
+// and by type.
+func compare(expected, extracted []label) comparison {
+	result := comparison{byType: make(map[string]Metrics)}
+	count := func(typ string, update func(*Metrics)) {
+		metrics := result.byType[typ]
+		update(&metrics)
+		result.byType[typ] = metrics
+		update(&result.metrics)
+	}
+
+	extractedKeys := make(map[string]bool, len(extracted))
+	for _, l := range extracted {
+		extractedKeys[l.key] = true
+	}
+	expectedKeys := make(map[string]bool, len(expected))
+	for _, l := range expected {
+		if expectedKeys[l.key] {
+			continue
+		}
+		expectedKeys[l.key] = true
+		if extractedKeys[l.key] {
+			count(l.typ, func(m *Metrics) { m.TruePositives++ })
+		} else {
+			count(l.typ, func(m *Metrics) { m.FalseNegatives++ })
+			result.missing = append(result.missing, l.description)
+		}
+	}
+	seen := make(map[string]bool, len(extracted))
+	for _, l := range extracted {
+		if seen[l.key] || expectedKeys[l.key] {
+			continue
+		}
+		seen[l.key] = true
+		count(l.typ, func(m *Metrics) { m.FalsePositives++ })
+		result.unexpected = append(result.unexpected, l.description)
+	}
+
+	result.metrics.score()
+	for typ, metrics := range result.byType {
+		metrics.score()
+		result.byType[typ] = metrics
+	}
+	sort.Strings(result.missing)
+	sort.Strings(result.unexpected)
+	return result
+}
+
+// normalizeName normalizes an entity name for comparison, ignoring case and
+// spacing.
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// entityType returns the type an entity is counted under.
+func entityType(typ string) string {
+	if typ == "" {
+		return DefaultEntityType
+	}
+	return typ
+}
+
+// edgeType returns the type a relationship is counted under, ignoring case
+// and spacing as extracted relationship types are upper snake case.
+func edgeType(typ string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(typ)), "_")
+}
+
+// entityLabel returns the label of an entity of the given name and type.
+func entityLabel(name, typ string) label {
+	typ = entityType(typ)
+	return label{
+		key:         typ + "\x00" + normalizeName(name),
+		description: name + " (" + typ + ")",
+		typ:         typ,
+	}
+}
+
+// edgeLabel returns the label of a relationship of the given type between
+// the entities of the given names.
+func edgeLabel(source, target, typ string) label {
+	typ = edgeType(typ)
+	return label{
+		key:         normalizeName(source) + "\x00" + typ + "\x00" + normalizeName(target),
+		description: source + " -" + typ + "-> " + target,
+		typ:         typ,
+	}
+}
+
+// labeledGraph is the entities and relationships of an episode.
+type labeledGraph struct {
+	Entities []ExpectedEntity `json:"entities"`
+	Edges    []ExpectedEdge   `json:"edges"`
+}
+
+// expectedLabels returns the labels of the entities and relationships
+// expected in an episode.
+func expectedLabels(episode CorpusEpisode) (entities, edges []label) {
+	for _, entity := range episode.Entities {
+		entities = append(entities, entityLabel(entity.Name, entity.Type))
+	}
+	for _, edge := range episode.Edges {
+		edges = append(edges, edgeLabel(edge.Source, edge.Target, edge.Type))
+	}
+	return entities, edges
+}
+
+// extractedLabels returns the labels of the entities and relationships
+// extracted from an episode.
+func extractedLabels(results *types.AddEpisodeResults) (entities, edges []label) {
+	graph := extractedGraph(results)
+	for _, entity := range graph.Entities {
+		entities = append(entities, entityLabel(entity.Name, entity.Type))
+	}
+	for _, edge := range graph.Edges {
+		edges = append(edges, edgeLabel(edge.Source, edge.Target, edge.Type))
+	}
+	return entities, edges
+}
+
+// extractedGraph returns the entities and relationships of the results of
+// adding an episode, as they are labeled. Duplicate links between entities
+// are not relationships of the episode.
+func extractedGraph(results *types.AddEpisodeResults) labeledGraph {
+	var graph labeledGraph
+	if results == nil {
+		return graph
+	}
+	names := make(map[string]string, len(results.Nodes))
+	for _, node := range results.Nodes {
+		if node.Type != types.EntityNodeType {
+			continue
+		}
+		names[node.Uuid] = node.Name
+		graph.Entities = append(graph.Entities, ExpectedEntity{Name: node.Name, Type: entityType(node.EntityType)})
+	}
+	for _, edge := range results.Edges {
+		if edge.Type != types.EntityEdgeType || edge.Name == driver.DuplicateOfEdgeName {
+			continue
+		}
+		source, ok := names[edge.SourceNodeID]
+		if !ok {
+			continue
+		}
+		target, ok := names[edge.TargetNodeID]
+		if !ok {
+			continue
+		}
+		graph.Edges = append(graph.Edges, ExpectedEdge{Source: source, Target: target, Type: edge.Name})
+	}
+	return graph
+}
@@ -0,0 +1,67 @@
+package eval
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestReportAddEpisode(t *testing.T) {
+	episode := CorpusEpisode{
+		Name: "hiring",
+		Entities: []ExpectedEntity{
+			{Name: "Alice", Type: "Person"},
+			{Name: "Acme Corp", Type: "Organization"},
+			{Name: "Bob", Type: "Person"},
+		},
+		Edges: []ExpectedEdge{
+			{Source: "Alice", Target: "Acme Corp", Type: "works at"},
+			{Source: "Bob", Target: "Alice", Type: "KNOWS"},
+		},
+	}
+	results := &types.AddEpisodeResults{
+		Nodes: []*types.Node{
+			{Uuid: "alice", Name: "alice", Type: types.EntityNodeType, EntityType: "Person"},
+			{Uuid: "acme", Name: "Acme  Corp", Type: types.EntityNodeType, EntityType: "Organization"},
+			{Uuid: "london", Name: "London", Type: types.EntityNodeType},
+		},
+		Edges: []*types.Edge{
+			types.NewEntityEdge("works", "alice", "acme", "g", "WORKS_AT", types.EntityEdgeType),
+			types.NewEntityEdge("lives", "alice", "london", "g", "LIVES_IN", types.EntityEdgeType),
+			types.NewEntityEdge("dup", "acme", "london", "g", driver.DuplicateOfEdgeName, types.EntityEdgeType),
+		},
+	}
+
+	report := newReport()
+	episodeReport := report.addEpisode(episode.Name, episode, results)
+
+	if m := report.Entities; m.TruePositives != 2 || m.FalsePositives != 1 || m.FalseNegatives != 1 {
+		t.Errorf("Entities = %+v, want 2 TP, 1 FP, 1 FN", m)
+	}
+	if m := report.Edges; m.TruePositives != 1 || m.FalsePositives != 1 || m.FalseNegatives != 1 ||
+		math.Abs(m.F1-0.5) > 1e-9 {
+		t.Errorf("Edges = %+v, want 1 TP, 1 FP, 1 FN, F1 0.5", m)
+	}
+	if m := report.EntityTypes["Person"]; m.TruePositives != 1 || m.FalseNegatives != 1 || m.Recall != 0.5 {
+		t.Errorf("EntityTypes[Person] = %+v", m)
+	}
+	if m := report.EntityTypes[DefaultEntityType]; m.FalsePositives != 1 {
+		t.Errorf("EntityTypes[%s] = %+v", DefaultEntityType, m)
+	}
+	if m := report.EdgeTypes["WORKS_AT"]; m.TruePositives != 1 || m.F1 != 1 {
+		t.Errorf("EdgeTypes[WORKS_AT] = %+v", m)
+	}
+	if !reflect.DeepEqual(episodeReport.MissingEntities, []string{"Bob (Person)"}) ||
+		!reflect.DeepEqual(episodeReport.UnexpectedEdges, []string{"alice -LIVES_IN-> London"}) {
+		t.Errorf("episode report = %+v", episodeReport)
+	}
+
+	// Counts accumulate across episodes
+	report.addEpisode(episode.Name, episode, nil)
+	if m := report.Entities; m.TruePositives != 2 || m.FalseNegatives != 4 {
+		t.Errorf("Entities after a second episode = %+v, want 2 TP, 4 FN", m)
+	}
+}
@@ -0,0 +1,163 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// Report is the result of evaluating a pipeline against a corpus.
+type Report struct {
+	// Entities and Edges are the metrics of the entities and relationships of
+	// all episodes.
+	Entities Metrics `json:"entities"`
+	Edges    Metrics `json:"edges"`
+	// EntityTypes and EdgeTypes are the metrics of each entity and
+	// relationship type.
+	EntityTypes map[string]Metrics `json:"entity_types"`
+	EdgeTypes   map[string]Metrics `json:"edge_types"`
+	Episodes    []*EpisodeReport   `json:"episodes"`
+	// Judged is the number of episodes judged by the LLM, and
+	// WorseThanLabels the number of them whose extraction it judged worse
+	// than their labels.
+	Judged          int `json:"judged,omitempty"`
+	WorseThanLabels int `json:"worse_than_labels,omitempty"`
+}
+
+// EpisodeReport is the result of evaluating the extraction of an episode.
+type EpisodeReport struct {
+	Name     string  `json:"name"`
+	Entities Metrics `json:"entities"`
+	Edges    Metrics `json:"edges"`
+	// MissingEntities and MissingEdges are the labels that were not
+	// extracted, and UnexpectedEntities and UnexpectedEdges what was
+	// extracted without a label.
+	MissingEntities    []string                       `json:"missing_entities,omitempty"`
+	UnexpectedEntities []string                       `json:"unexpected_entities,omitempty"`
+	MissingEdges       []string                       `json:"missing_edges,omitempty"`
+	UnexpectedEdges    []string                       `json:"unexpected_edges,omitempty"`
+	Judgment           *prompts.EvalAddEpisodeResults `json:"judgment,omitempty"`
+}
+
+// newReport creates an empty report.
+func newReport() *Report {
+	return &Report{
+		EntityTypes: make(map[string]Metrics),
+		EdgeTypes:   make(map[string]Metrics),
+	}
+}
+
+// addEpisode scores what was extracted from an episode against its labels
+// and adds the result to the report.
+func (r *Report) addEpisode(name string, episode CorpusEpisode, results *types.AddEpisodeResults) *EpisodeReport {
+	expectedEntities, expectedEdges := expectedLabels(episode)
+	extractedEntities, extractedEdges := extractedLabels(results)
+	entities := compare(expectedEntities, extractedEntities)
+	edges := compare(expectedEdges, extractedEdges)
+
+	episodeReport := &EpisodeReport{
+		Name:               name,
+		Entities:           entities.metrics,
+		Edges:              edges.metrics,
+		MissingEntities:    entities.missing,
+		UnexpectedEntities: entities.unexpected,
+		MissingEdges:       edges.missing,
+		UnexpectedEdges:    edges.unexpected,
+	}
+	r.Episodes = append(r.Episodes, episodeReport)
+
+	r.Entities.add(entities.metrics)
+	r.Edges.add(edges.metrics)
+	addByType(r.EntityTypes, entities.byType)
+	addByType(r.EdgeTypes, edges.byType)
+	return episodeReport
+}
+
+// addByType adds the metrics of each type of other to metrics.
+func addByType(metrics, other map[string]Metrics) {
+	for typ, m := range other {
+		total := metrics[typ]
+		total.add(m)
+		metrics[typ] = total
+	}
+}
+
+// JSON returns the report as indented JSON.
+func (r *Report) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal report: %w", err)
+	}
+	return data, nil
+}
+
+// Markdown returns the report as markdown tables of the overall metrics,
+// the metrics of each type and the metrics of each episode.
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# Extraction evaluation\n\n")
+	writeMetricsTable(&sb, "", map[string]Metrics{"Entities": r.Entities, "Edges": r.Edges}, []string{"Entities", "Edges"})
+	if r.Judged > 0 {
+		fmt.Fprintf(&sb, "\nThe LLM judged %d of %d episodes worse than their labels.\n", r.WorseThanLabels, r.Judged)
+	}
+
+	sb.WriteString("\n## Entity types\n\n")
+	writeMetricsTable(&sb, "Type", r.EntityTypes, sortedKeys(r.EntityTypes))
+	sb.WriteString("\n## Edge types\n\n")
+	writeMetricsTable(&sb, "Type", r.EdgeTypes, sortedKeys(r.EdgeTypes))
+
+	sb.WriteString("\n## Episodes\n")
+	for _, episode := range r.Episodes {
+		fmt.Fprintf(&sb, "\n### %s\n\n", episode.Name)
+		writeMetricsTable(&sb, "", map[string]Metrics{"Entities": episode.Entities, "Edges": episode.Edges}, []string{"Entities", "Edges"})
+		writeList(&sb, "Missing entities", episode.MissingEntities)
+		writeList(&sb, "Unexpected entities", episode.UnexpectedEntities)
+		writeList(&sb, "Missing edges", episode.MissingEdges)
+		writeList(&sb, "Unexpected edges", episode.UnexpectedEdges)
+		if episode.Judgment != nil {
+			verdict := "not worse than"
+			if episode.Judgment.CandidateIsWorse {
+				verdict = "worse than"
+			}
+			fmt.Fprintf(&sb, "\nJudged %s the labels: %s\n", verdict, episode.Judgment.Reasoning)
+		}
+	}
+	return sb.String()
+}
+
+// writeMetricsTable writes the metrics of the given rows as a markdown table
+// whose first column has the given header.
+func writeMetricsTable(sb *strings.Builder, header string, metrics map[string]Metrics, rows []string) {
+	fmt.Fprintf(sb, "| %s | Precision | Recall | F1 | TP | FP | FN |\n", header)
+	sb.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, row := range rows {
+		m := metrics[row]
+		fmt.Fprintf(sb, "| %s | %.3f | %.3f | %.3f | %d | %d | %d |\n",
+			row, m.Precision, m.Recall, m.F1, m.TruePositives, m.FalsePositives, m.FalseNegatives)
+	}
+}
+
+// writeList writes a titled markdown list, if it has items.
+func writeList(sb *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "\n%s:\n", title)
+	for _, item := range items {
+		fmt.Fprintf(sb, "- %s\n", item)
+	}
+}
+
+// sortedKeys returns the keys of metrics, sorted.
+func sortedKeys(metrics map[string]Metrics) []string {
+	keys := make([]string, 0, len(metrics))
+	for key := range metrics {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
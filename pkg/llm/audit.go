@@ -0,0 +1,259 @@
+package llm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// AuditRecord is one LLM request and its raw completion.
+type AuditRecord struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	// Method is "chat", "structured_output" or "tools"
+	Method string `json:"method"`
+	// Task is the task hint of the request, such as extract_nodes
+	Task      string          `json:"task,omitempty"`
+	EpisodeID string          `json:"episode_id,omitempty"`
+	GroupID   string          `json:"group_id,omitempty"`
+	Messages  []types.Message `json:"messages"`
+	// Schema is the requested structured output schema or the offered tools, if any
+	Schema           json.RawMessage `json:"schema,omitempty"`
+	Model            string          `json:"model,omitempty"`
+	Response         string          `json:"response,omitempty"`
+	FinishReason     string          `json:"finish_reason,omitempty"`
+	Error            string          `json:"error,omitempty"`
+	LatencyMS        int64           `json:"latency_ms"`
+	PromptTokens     int             `json:"prompt_tokens,omitempty"`
+	CompletionTokens int             `json:"completion_tokens,omitempty"`
+	TotalTokens      int             `json:"total_tokens,omitempty"`
+}
+
+// AuditSink persists audit records.
+type AuditSink interface {
+	WriteAuditRecord(ctx context.Context, record *AuditRecord) error
+	Close() error
+}
+
+// JSONLAuditSink appends audit records to a file, one JSON object per line.
+type JSONLAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewJSONLAuditSink opens path for appending, creating it and its directory if needed.
+func NewJSONLAuditSink(path string) (*JSONLAuditSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &JSONLAuditSink{file: file}, nil
+}
+
+// WriteAuditRecord implements AuditSink.
+func (s *JSONLAuditSink) WriteAuditRecord(ctx context.Context, record *AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+// Close implements AuditSink.
+func (s *JSONLAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// SQLAuditSink stores audit records in the llm_audit_log table of a SQL
+// database, such as the DuckDB database used for token tracking.
+type SQLAuditSink struct {
+	db *sql.DB
+}
+
+// NewSQLAuditSink creates the llm_audit_log table if it does not exist.
+func NewSQLAuditSink(db *sql.DB) (*SQLAuditSink, error) {
+	query := `
+	CREATE TABLE IF NOT EXISTS llm_audit_log (
+		id VARCHAR,
+		timestamp TIMESTAMP,
+		method VARCHAR,
+		task VARCHAR,
+		episode_id VARCHAR,
+		group_id VARCHAR,
+		messages VARCHAR,
+		schema VARCHAR,
+		model VARCHAR,
+		response VARCHAR,
+		finish_reason VARCHAR,
+		error VARCHAR,
+		latency_ms BIGINT,
+		prompt_tokens INTEGER,
+		completion_tokens INTEGER,
+		total_tokens INTEGER
+	);
+	`
+	if _, err := db.Exec(query); err != nil {
+		return nil, fmt.Errorf("failed to create audit log table: %w", err)
+	}
+	return &SQLAuditSink{db: db}, nil
+}
+
+// WriteAuditRecord implements AuditSink.
+func (s *SQLAuditSink) WriteAuditRecord(ctx context.Context, record *AuditRecord) error {
+	messages, err := json.Marshal(record.Messages)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit messages: %w", err)
+	}
+
+	query := `
+	INSERT INTO llm_audit_log (
+		id, timestamp, method, task, episode_id, group_id, messages, schema, model,
+		response, finish_reason, error, latency_ms, prompt_tokens, completion_tokens, total_tokens
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+	`
+	_, err = s.db.ExecContext(ctx, query,
+		record.ID,
+		record.Timestamp,
+		record.Method,
+		record.Task,
+		record.EpisodeID,
+		record.GroupID,
+		string(messages),
+		string(record.Schema),
+		record.Model,
+		record.Response,
+		record.FinishReason,
+		record.Error,
+		record.LatencyMS,
+		record.PromptTokens,
+		record.CompletionTokens,
+		record.TotalTokens,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit record: %w", err)
+	}
+	return nil
+}
+
+// Close implements AuditSink. The database is owned by the caller and stays open.
+func (s *SQLAuditSink) Close() error {
+	return nil
+}
+
+// AuditClient wraps a Client and writes every request, with its raw completion
+// or error, latency and token usage, to an AuditSink. Failures to write the
+// audit log are logged and do not fail the request.
+type AuditClient struct {
+	client Client
+	sink   AuditSink
+	now    func() time.Time
+}
+
+// NewAuditClient creates an auditing wrapper writing to sink.
+func NewAuditClient(client Client, sink AuditSink) *AuditClient {
+	return &AuditClient{
+		client: client,
+		sink:   sink,
+		now:    time.Now,
+	}
+}
+
+// Chat implements the Client interface.
+func (c *AuditClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	start := c.now()
+	resp, err := c.client.Chat(ctx, messages)
+	c.audit(ctx, "chat", messages, nil, start, resp, err)
+	return resp, err
+}
+
+// ChatWithStructuredOutput implements the Client interface.
+func (c *AuditClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	start := c.now()
+	resp, err := c.client.ChatWithStructuredOutput(ctx, messages, schema)
+	c.audit(ctx, "structured_output", messages, schema, start, resp, err)
+	return resp, err
+}
+
+// ChatWithTools implements the Client interface. The offered tools are recorded as the schema.
+func (c *AuditClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	start := c.now()
+	resp, err := c.client.ChatWithTools(ctx, messages, tools)
+	c.audit(ctx, "tools", messages, tools, start, resp, err)
+	return resp, err
+}
+
+// audit writes the record of one request to the sink.
+func (c *AuditClient) audit(ctx context.Context, method string, messages []types.Message, schema any, start time.Time, resp *types.Response, callErr error) {
+	record := &AuditRecord{
+		ID:        uuid.New().String(),
+		Timestamp: start.UTC(),
+		Method:    method,
+		Task:      TaskFromContext(ctx),
+		Messages:  messages,
+		LatencyMS: c.now().Sub(start).Milliseconds(),
+	}
+	if v, ok := ctx.Value(types.ContextKeyEpisodeID).(string); ok {
+		record.EpisodeID = v
+	}
+	if v, ok := ctx.Value(types.ContextKeyGroupID).(string); ok {
+		record.GroupID = v
+	}
+	if schema != nil {
+		if data, err := json.Marshal(schema); err == nil {
+			record.Schema = data
+		}
+	}
+	if callErr != nil {
+		record.Error = callErr.Error()
+	}
+	if resp != nil {
+		record.Model = resp.Model
+		record.Response = resp.Content
+		if len(resp.ToolCalls) > 0 && resp.Content == "" {
+			if data, err := json.Marshal(resp.ToolCalls); err == nil {
+				record.Response = string(data)
+			}
+		}
+		record.FinishReason = resp.FinishReason
+		if resp.TokensUsed != nil {
+			record.PromptTokens = resp.TokensUsed.PromptTokens
+			record.CompletionTokens = resp.TokensUsed.CompletionTokens
+			record.TotalTokens = resp.TokensUsed.TotalTokens
+		}
+	}
+
+	// Write even if the request was cancelled, the record matters most then
+	if err := c.sink.WriteAuditRecord(context.WithoutCancel(ctx), record); err != nil {
+		log.Printf("Warning: failed to write LLM audit record: %v", err)
+	}
+}
+
+// Close closes the wrapped client and the audit sink.
+func (c *AuditClient) Close() error {
+	clientErr := c.client.Close()
+	if err := c.sink.Close(); err != nil {
+		return fmt.Errorf("failed to close audit sink: %w", err)
+	}
+	return clientErr
+}
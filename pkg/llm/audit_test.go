@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// auditedClient returns resp and err for every request.
+type auditedClient struct {
+	resp *types.Response
+	err  error
+}
+
+func (c *auditedClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return c.resp, c.err
+}
+
+func (c *auditedClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	return c.resp, c.err
+}
+
+func (c *auditedClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return c.resp, c.err
+}
+
+func (c *auditedClient) Close() error {
+	return nil
+}
+
+func readAuditRecords(t *testing.T, path string) []AuditRecord {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	var records []AuditRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid audit line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestAuditClientWritesJSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "llm.jsonl")
+	sink, err := NewJSONLAuditSink(path)
+	if err != nil {
+		t.Fatalf("NewJSONLAuditSink() error = %v", err)
+	}
+
+	base := &auditedClient{resp: &types.Response{
+		Content:      `{"entities":[]}`,
+		Model:        "gpt-4o-mini",
+		FinishReason: "stop",
+		TokensUsed:   &types.TokenUsage{PromptTokens: 12, CompletionTokens: 4, TotalTokens: 16},
+	}}
+	client := NewAuditClient(base, sink)
+	tick := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	client.now = func() time.Time {
+		tick = tick.Add(250 * time.Millisecond)
+		return tick
+	}
+
+	ctx := WithTask(context.Background(), "extract_nodes")
+	ctx = context.WithValue(ctx, types.ContextKeyEpisodeID, "ep-1")
+	messages := []types.Message{NewSystemMessage("extract"), NewUserMessage("Alice met Bob")}
+	if _, err := client.ChatWithStructuredOutput(ctx, messages, map[string]any{"type": "object"}); err != nil {
+		t.Fatalf("ChatWithStructuredOutput() error = %v", err)
+	}
+
+	base.resp, base.err = nil, errors.New("upstream timeout")
+	if _, err := client.Chat(context.Background(), messages); err == nil {
+		t.Fatal("Chat() should return the error of the wrapped client")
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	records := readAuditRecords(t, path)
+	if len(records) != 2 {
+		t.Fatalf("audit log has %d records, want 2", len(records))
+	}
+
+	first := records[0]
+	if first.Method != "structured_output" || first.Task != "extract_nodes" || first.EpisodeID != "ep-1" {
+		t.Errorf("first record = %+v, want structured_output extract_nodes request of ep-1", first)
+	}
+	if len(first.Messages) != 2 || first.Messages[1].Content != "Alice met Bob" {
+		t.Errorf("first record messages = %+v, want the prompt", first.Messages)
+	}
+	if string(first.Schema) != `{"type":"object"}` {
+		t.Errorf("first record schema = %s", first.Schema)
+	}
+	if first.Response != `{"entities":[]}` || first.Model != "gpt-4o-mini" || first.TotalTokens != 16 {
+		t.Errorf("first record response fields = %+v", first)
+	}
+	if first.LatencyMS != 250 {
+		t.Errorf("first record latency = %dms, want 250ms", first.LatencyMS)
+	}
+
+	second := records[1]
+	if second.Method != "chat" || second.Error != "upstream timeout" || second.Response != "" {
+		t.Errorf("second record = %+v, want the failed chat request", second)
+	}
+}
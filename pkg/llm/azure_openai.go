@@ -7,54 +7,152 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/soundprediction/go-predicato/pkg/types"
 )
 
-// AzureOpenAIClient implements the Client interface for Azure OpenAI models.
-type AzureOpenAIClient struct {
-	config       *LLMConfig
-	httpClient   *http.Client
-	apiVersion   string
-	deploymentID string
+// DefaultAzureOpenAIAPIVersion is the Azure OpenAI API version used when none is given.
+const DefaultAzureOpenAIAPIVersion = "2024-10-21"
+
+// AzureCognitiveServicesScope is the Microsoft Entra ID scope of Azure OpenAI tokens.
+const AzureCognitiveServicesScope = "https://cognitiveservices.azure.com/.default"
+
+// azureTokenRefreshMargin is how long before expiry a cached Entra ID token is refreshed.
+const azureTokenRefreshMargin = 5 * time.Minute
+
+// AzureTokenProvider supplies Microsoft Entra ID access tokens for
+// AzureCognitiveServicesScope. It can wrap an azidentity credential:
+//
+//	llm.AzureTokenProviderFunc(func(ctx context.Context) (string, time.Time, error) {
+//		token, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{llm.AzureCognitiveServicesScope}})
+//		return token.Token, token.ExpiresOn, err
+//	})
+type AzureTokenProvider interface {
+	Token(ctx context.Context) (token string, expiresOn time.Time, err error)
+}
+
+// AzureTokenProviderFunc adapts a function to the AzureTokenProvider interface.
+type AzureTokenProviderFunc func(ctx context.Context) (string, time.Time, error)
+
+// Token implements AzureTokenProvider.
+func (f AzureTokenProviderFunc) Token(ctx context.Context) (string, time.Time, error) {
+	return f(ctx)
 }
 
 // AzureOpenAIConfig extends LLMConfig with Azure-specific settings.
+// LLMConfig.APIKey is sent as the api-key header unless TokenProvider is set.
 type AzureOpenAIConfig struct {
 	*LLMConfig
-	APIVersion   string `json:"api_version,omitempty"`
-	DeploymentID string `json:"deployment_id,omitempty"`
+	// TokenProvider enables Microsoft Entra ID authentication instead of API keys
+	TokenProvider AzureTokenProvider `json:"-"`
+	// Timeout for each request (default: 30 seconds)
+	Timeout time.Duration `json:"timeout,omitempty"`
 }
 
-// NewAzureOpenAIClient creates a new Azure OpenAI client.
-func NewAzureOpenAIClient(config *AzureOpenAIConfig) *AzureOpenAIClient {
-	if config.APIVersion == "" {
-		config.APIVersion = "2024-02-15-preview"
+// AzureOpenAIClient implements the Client interface for Azure OpenAI models.
+// Requests are routed to a deployment rather than a model name.
+type AzureOpenAIClient struct {
+	config         *LLMConfig
+	httpClient     *http.Client
+	endpoint       string
+	deploymentName string
+	apiVersion     string
+
+	tokenProvider  AzureTokenProvider
+	tokenMu        sync.Mutex
+	token          string
+	tokenExpiresOn time.Time
+}
+
+// NewAzureOpenAIClient creates a client for the deploymentName deployment of the
+// Azure OpenAI resource at endpoint (https://{resource}.openai.azure.com).
+// An empty apiVersion uses DefaultAzureOpenAIAPIVersion. Either config.APIKey or
+// config.TokenProvider must be set.
+func NewAzureOpenAIClient(endpoint, deploymentName, apiVersion string, config *AzureOpenAIConfig) (*AzureOpenAIClient, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required for Azure OpenAI")
+	}
+	if err := validateBaseURL(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if deploymentName == "" {
+		return nil, fmt.Errorf("deployment name is required for Azure OpenAI")
+	}
+	if apiVersion == "" {
+		apiVersion = DefaultAzureOpenAIAPIVersion
+	}
+	if config == nil {
+		config = &AzureOpenAIConfig{}
+	}
+	if config.LLMConfig == nil {
+		config.LLMConfig = NewLLMConfig()
+	}
+	if config.APIKey == "" && config.TokenProvider == nil {
+		return nil, fmt.Errorf("an API key or Entra ID token provider is required for Azure OpenAI")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
 	}
 
 	return &AzureOpenAIClient{
-		config:       config.LLMConfig,
-		apiVersion:   config.APIVersion,
-		deploymentID: config.DeploymentID,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
+		config:         config.LLMConfig,
+		httpClient:     &http.Client{Timeout: config.Timeout},
+		endpoint:       strings.TrimRight(endpoint, "/"),
+		deploymentName: deploymentName,
+		apiVersion:     apiVersion,
+		tokenProvider:  config.TokenProvider,
+	}, nil
 }
 
 // azureOpenAIRequest represents the request structure for Azure OpenAI API.
 type azureOpenAIRequest struct {
-	Messages    []azureOpenAIMessage `json:"messages"`
-	MaxTokens   int                  `json:"max_tokens,omitempty"`
-	Temperature float64              `json:"temperature,omitempty"`
-	Stream      bool                 `json:"stream"`
+	Messages       []azureOpenAIMessage `json:"messages"`
+	MaxTokens      int                  `json:"max_tokens,omitempty"`
+	Temperature    float64              `json:"temperature,omitempty"`
+	TopP           float64              `json:"top_p,omitempty"`
+	ResponseFormat *azureResponseFormat `json:"response_format,omitempty"`
+	Tools          []azureTool          `json:"tools,omitempty"`
+	Stream         bool                 `json:"stream"`
+}
+
+// azureResponseFormat requests JSON output.
+type azureResponseFormat struct {
+	Type string `json:"type"`
 }
 
 // azureOpenAIMessage represents a message in Azure OpenAI format.
 type azureOpenAIMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string          `json:"role"`
+	Content    string          `json:"content"`
+	ToolCalls  []azureToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+}
+
+// azureTool represents a function tool offered to the model.
+type azureTool struct {
+	Type     string            `json:"type"`
+	Function azureToolFunction `json:"function"`
+}
+
+// azureToolFunction describes a function tool.
+type azureToolFunction struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+// azureToolCall represents a function call requested by the model.
+type azureToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
 }
 
 // azureOpenAIResponse represents the response from Azure OpenAI API.
@@ -64,6 +162,7 @@ type azureOpenAIResponse struct {
 	Created int64               `json:"created"`
 	Model   string              `json:"model"`
 	Choices []azureOpenAIChoice `json:"choices"`
+	Usage   *azureOpenAIUsage   `json:"usage,omitempty"`
 	Error   *azureOpenAIError   `json:"error,omitempty"`
 }
 
@@ -74,6 +173,13 @@ type azureOpenAIChoice struct {
 	FinishReason string             `json:"finish_reason"`
 }
 
+// azureOpenAIUsage represents token usage in the response.
+type azureOpenAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
 // azureOpenAIError represents an error response.
 type azureOpenAIError struct {
 	Message string `json:"message"`
@@ -82,101 +188,214 @@ type azureOpenAIError struct {
 }
 
 // Chat implements the Client interface for Azure OpenAI.
-func (a *AzureOpenAIClient) Chat(ctx context.Context, messages []types.Message) (string, error) {
-	if len(messages) == 0 {
-		return "", fmt.Errorf("no messages provided")
-	}
+func (a *AzureOpenAIClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return a.complete(ctx, messages, nil)
+}
 
-	if a.deploymentID == "" {
-		return "", fmt.Errorf("deployment ID is required for Azure OpenAI")
+// ChatWithStructuredOutput implements structured output for Azure OpenAI using
+// JSON mode, with the schema described in an additional user message.
+func (a *AzureOpenAIClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
 	}
 
-	// Convert messages to Azure OpenAI format
-	azureMessages := make([]azureOpenAIMessage, 0, len(messages))
-	for _, msg := range messages {
-		azureMessages = append(azureMessages, azureOpenAIMessage{
-			Role:    string(msg.Role),
-			Content: msg.Content,
-		})
+	modifiedMessages := make([]types.Message, len(messages), len(messages)+1)
+	copy(modifiedMessages, messages)
+	modifiedMessages = append(modifiedMessages, types.Message{
+		Role:    RoleUser,
+		Content: fmt.Sprintf("Please respond with valid JSON that matches this schema: %s", string(schemaBytes)),
+	})
+
+	return a.complete(ctx, modifiedMessages, &azureResponseFormat{Type: "json_object"})
+}
+
+// ChatWithTools implements the Client interface using function calling.
+func (a *AzureOpenAIClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return a.complete(ctx, messages, nil, tools...)
+}
+
+// complete sends a chat completion request to the deployment.
+func (a *AzureOpenAIClient) complete(ctx context.Context, messages []types.Message, format *azureResponseFormat, tools ...types.Tool) (*types.Response, error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no messages provided")
 	}
 
 	req := azureOpenAIRequest{
-		Messages:    azureMessages,
-		MaxTokens:   a.config.MaxTokens,
-		Temperature: float64(a.config.Temperature),
-		Stream:      false,
+		Messages:       toAzureMessages(messages),
+		MaxTokens:      a.config.MaxTokens,
+		Temperature:    float64(a.config.Temperature),
+		TopP:           float64(a.config.TopP),
+		ResponseFormat: format,
+		Tools:          toAzureTools(tools),
+		Stream:         false,
 	}
 
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Azure OpenAI URL format: https://{resource-name}.openai.azure.com/openai/deployments/{deployment-id}/chat/completions?api-version={api-version}
-	url := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
-		a.config.BaseURL, a.deploymentID, a.apiVersion)
-
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", a.chatCompletionsURL(), bytes.NewReader(reqBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("api-key", a.config.APIKey)
+	if err := a.authorize(ctx, httpReq); err != nil {
+		return nil, err
+	}
 
 	resp, err := a.httpClient.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, NewRateLimitError(fmt.Sprintf("azure openai rate limit exceeded: %s", string(body)))
+	}
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
 	var azureResp azureOpenAIResponse
 	if err := json.Unmarshal(body, &azureResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if azureResp.Error != nil {
-		return "", fmt.Errorf("API error: %s", azureResp.Error.Message)
+		return nil, fmt.Errorf("API error: %s", azureResp.Error.Message)
 	}
 
 	if len(azureResp.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
+		return nil, fmt.Errorf("no choices in response")
 	}
 
-	return azureResp.Choices[0].Message.Content, nil
+	choice := azureResp.Choices[0]
+	response := &types.Response{
+		Content:      choice.Message.Content,
+		FinishReason: choice.FinishReason,
+		Model:        azureResp.Model,
+		ToolCalls:    fromAzureToolCalls(choice.Message.ToolCalls),
+	}
+	if azureResp.Usage != nil && azureResp.Usage.TotalTokens > 0 {
+		response.TokensUsed = &types.TokenUsage{
+			PromptTokens:     azureResp.Usage.PromptTokens,
+			CompletionTokens: azureResp.Usage.CompletionTokens,
+			TotalTokens:      azureResp.Usage.TotalTokens,
+		}
+	}
+
+	return response, nil
 }
 
-// ChatWithStructuredOutput implements structured output for Azure OpenAI.
-// Azure OpenAI supports structured output similar to OpenAI.
-func (a *AzureOpenAIClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema interface{}) (*types.Response, error) {
-	// For now, use prompt engineering approach
-	schemaBytes, err := json.Marshal(schema)
+// toAzureMessages converts messages, including tool calls and results, to the
+// OpenAI wire format.
+func toAzureMessages(messages []types.Message) []azureOpenAIMessage {
+	azureMessages := make([]azureOpenAIMessage, 0, len(messages))
+	for _, msg := range messages {
+		azureMsg := azureOpenAIMessage{
+			Role:       string(msg.Role),
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+		}
+		for _, call := range msg.ToolCalls {
+			azureCall := azureToolCall{ID: call.ID, Type: "function"}
+			azureCall.Function.Name = call.Name
+			azureCall.Function.Arguments = call.Arguments
+			azureMsg.ToolCalls = append(azureMsg.ToolCalls, azureCall)
+		}
+		azureMessages = append(azureMessages, azureMsg)
+	}
+	return azureMessages
+}
+
+// toAzureTools converts tools to OpenAI function tools.
+func toAzureTools(tools []types.Tool) []azureTool {
+	if len(tools) == 0 {
+		return nil
+	}
+	azureTools := make([]azureTool, 0, len(tools))
+	for _, tool := range tools {
+		azureTools = append(azureTools, azureTool{
+			Type: "function",
+			Function: azureToolFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	return azureTools
+}
+
+// fromAzureToolCalls converts the tool calls of a response message.
+func fromAzureToolCalls(calls []azureToolCall) []types.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	toolCalls := make([]types.ToolCall, 0, len(calls))
+	for _, call := range calls {
+		toolCalls = append(toolCalls, types.ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return toolCalls
+}
+
+// chatCompletionsURL returns the chat completions URL of the deployment:
+// {endpoint}/openai/deployments/{deployment}/chat/completions?api-version={version}
+func (a *AzureOpenAIClient) chatCompletionsURL() string {
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		a.endpoint, url.PathEscape(a.deploymentName), url.QueryEscape(a.apiVersion))
+}
+
+// authorize sets the Entra ID bearer token, or the api-key header when no token
+// provider is configured.
+func (a *AzureOpenAIClient) authorize(ctx context.Context, req *http.Request) error {
+	if a.tokenProvider == nil {
+		req.Header.Set("api-key", a.config.APIKey)
+		return nil
+	}
+
+	token, err := a.entraToken(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+		return fmt.Errorf("failed to get Entra ID token: %w", err)
 	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
 
-	modifiedMessages := append(messages, types.Message{
-		Role:    RoleUser,
-		Content: fmt.Sprintf("Please respond with valid JSON that matches this schema: %s", string(schemaBytes)),
-	})
+// entraToken returns a cached Entra ID token, refreshing it shortly before it expires.
+func (a *AzureOpenAIClient) entraToken(ctx context.Context) (string, error) {
+	a.tokenMu.Lock()
+	defer a.tokenMu.Unlock()
+
+	if a.token != "" && time.Until(a.tokenExpiresOn) > azureTokenRefreshMargin {
+		return a.token, nil
+	}
 
-	content, err := a.Chat(ctx, modifiedMessages)
+	token, expiresOn, err := a.tokenProvider.Token(ctx)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	if token == "" {
+		return "", fmt.Errorf("token provider returned an empty token")
 	}
 
-	// AzureOpenAIClient.Chat currently only returns string, so we construct a minimal Response object
-	// TODO: Update AzureOpenAIClient.Chat to return *types.Response to capture token usage
-	return &types.Response{
-		Content: content,
-	}, nil
+	a.token = token
+	a.tokenExpiresOn = expiresOn
+	return token, nil
+}
+
+// Close implements the Client interface.
+func (a *AzureOpenAIClient) Close() error {
+	return nil
 }
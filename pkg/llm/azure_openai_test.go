@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestAzureOpenAIClientDeploymentRouting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/gpt-4o-prod/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("api-version"); got != "2024-06-01" {
+			t.Errorf("expected api-version 2024-06-01, got %s", got)
+		}
+		if got := r.Header.Get("api-key"); got != "secret" {
+			t.Errorf("expected api-key header, got %q", got)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"model":   "gpt-4o",
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": "hello"}, "finish_reason": "stop"}},
+			"usage":   map[string]int{"prompt_tokens": 3, "completion_tokens": 1, "total_tokens": 4},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewAzureOpenAIClient(server.URL+"/", "gpt-4o-prod", "2024-06-01", &AzureOpenAIConfig{
+		LLMConfig: NewLLMConfig().WithAPIKey("secret"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	resp, err := client.Chat(context.Background(), []types.Message{NewUserMessage("hi")})
+	if err != nil {
+		t.Fatalf("chat failed: %v", err)
+	}
+	if resp.Content != "hello" {
+		t.Errorf("expected hello, got %q", resp.Content)
+	}
+	if resp.TokensUsed == nil || resp.TokensUsed.TotalTokens != 4 {
+		t.Errorf("expected token usage to be reported, got %+v", resp.TokensUsed)
+	}
+}
+
+func TestAzureOpenAIClientEntraIDToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer entra-token" {
+			t.Errorf("expected bearer token, got %q", got)
+		}
+		if got := r.Header.Get("api-key"); got != "" {
+			t.Errorf("expected no api-key header with Entra ID auth, got %q", got)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": "{}"}}},
+		})
+	}))
+	defer server.Close()
+
+	calls := 0
+	provider := AzureTokenProviderFunc(func(ctx context.Context) (string, time.Time, error) {
+		calls++
+		return "entra-token", time.Now().Add(time.Hour), nil
+	})
+
+	client, err := NewAzureOpenAIClient(server.URL, "extraction", "", &AzureOpenAIConfig{TokenProvider: provider})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if client.apiVersion != DefaultAzureOpenAIAPIVersion {
+		t.Errorf("expected default api version, got %s", client.apiVersion)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.ChatWithStructuredOutput(context.Background(), []types.Message{NewUserMessage("hi")}, map[string]string{"type": "object"}); err != nil {
+			t.Fatalf("structured chat failed: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the token to be cached, provider called %d times", calls)
+	}
+}
+
+func TestNewAzureOpenAIClientValidation(t *testing.T) {
+	if _, err := NewAzureOpenAIClient("", "deployment", "", &AzureOpenAIConfig{LLMConfig: NewLLMConfig().WithAPIKey("k")}); err == nil {
+		t.Error("expected an error without an endpoint")
+	}
+	if _, err := NewAzureOpenAIClient("https://example.openai.azure.com", "", "", &AzureOpenAIConfig{LLMConfig: NewLLMConfig().WithAPIKey("k")}); err == nil {
+		t.Error("expected an error without a deployment")
+	}
+	if _, err := NewAzureOpenAIClient("https://example.openai.azure.com", "deployment", "", nil); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
@@ -81,6 +81,18 @@ func (c *CircuitBreakerClient) ChatWithStructuredOutput(ctx context.Context, mes
 	return resp.(*types.Response), nil
 }
 
+// ChatWithTools implements Client
+func (c *CircuitBreakerClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	resp, err := c.cb.Execute(func() (interface{}, error) {
+		return c.client.ChatWithTools(ctx, messages, tools)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*types.Response), nil
+}
+
 // Close implements Client
 func (c *CircuitBreakerClient) Close() error {
 	return c.client.Close()
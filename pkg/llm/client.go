@@ -14,6 +14,11 @@ type Client interface {
 	// ChatWithStructuredOutput sends a chat completion request with structured output.
 	ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error)
 
+	// ChatWithTools sends a chat completion request offering tools to the model.
+	// When the model calls tools, the response has ToolCalls; send their results
+	// back as tool messages to continue the conversation.
+	ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error)
+
 	// Close cleans up any resources.
 	Close() error
 }
@@ -25,6 +30,8 @@ const (
 	RoleUser types.Role = "user"
 	// RoleAssistant represents an assistant message.
 	RoleAssistant types.Role = "assistant"
+	// RoleTool represents the result of a tool call.
+	RoleTool types.Role = "tool"
 )
 
 // Config holds legacy configuration for LLM clients (deprecated, use LLMConfig)
@@ -62,3 +69,12 @@ func NewUserMessage(content string) types.Message {
 func NewAssistantMessage(content string) types.Message {
 	return NewMessage(RoleAssistant, content)
 }
+
+// NewToolMessage creates a message with the result of the tool call callID.
+func NewToolMessage(callID, content string) types.Message {
+	return types.Message{
+		Role:       RoleTool,
+		Content:    content,
+		ToolCallID: callID,
+	}
+}
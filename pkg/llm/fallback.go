@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// FallbackAttempt describes one provider call made by a FallbackClient.
+type FallbackAttempt struct {
+	// Index is the position of the provider in the chain, 0 for the primary
+	Index int
+	// Provider is the name of the provider
+	Provider string
+	// Method is "chat", "structured_output" or "tools"
+	Method  string
+	Latency time.Duration
+	// Err is nil when the provider served the call
+	Err error
+}
+
+// FallbackClient sends requests to a chain of providers, moving on to the
+// next one when a provider is unavailable: rate limited (429), failing with
+// 5xx or connection errors, or behind an open circuit breaker. Other errors,
+// such as invalid requests, are returned without trying other providers.
+type FallbackClient struct {
+	clients      []Client
+	names        []string
+	shouldFall   func(error) bool
+	onAttempt    func(ctx context.Context, attempt FallbackAttempt)
+	logFallbacks bool
+}
+
+// NewFallbackClient creates a fallback chain trying primary first, then each
+// fallback in order. Providers are named provider-0, provider-1... until
+// WithNames is called, and falling back is logged until OnAttempt is called.
+func NewFallbackClient(primary Client, fallbacks ...Client) *FallbackClient {
+	clients := append([]Client{primary}, fallbacks...)
+	names := make([]string, len(clients))
+	for i := range clients {
+		names[i] = fmt.Sprintf("provider-%d", i)
+	}
+
+	return &FallbackClient{
+		clients:      clients,
+		names:        names,
+		shouldFall:   shouldFallBack,
+		logFallbacks: true,
+	}
+}
+
+// WithNames names the providers in chain order, for hooks and logs. It returns
+// the client for chaining.
+func (c *FallbackClient) WithNames(names ...string) *FallbackClient {
+	for i, name := range names {
+		if i < len(c.names) {
+			c.names[i] = name
+		}
+	}
+	return c
+}
+
+// OnAttempt registers a hook called after every provider call, for example to
+// log or count which provider served each request. It replaces the default
+// fallback logging and returns the client for chaining.
+func (c *FallbackClient) OnAttempt(hook func(ctx context.Context, attempt FallbackAttempt)) *FallbackClient {
+	c.onAttempt = hook
+	c.logFallbacks = false
+	return c
+}
+
+// FallBackOn replaces the check deciding which errors move on to the next
+// provider. It returns the client for chaining.
+func (c *FallbackClient) FallBackOn(shouldFall func(error) bool) *FallbackClient {
+	c.shouldFall = shouldFall
+	return c
+}
+
+// Chat implements the Client interface.
+func (c *FallbackClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return c.call(ctx, "chat", func(client Client) (*types.Response, error) {
+		return client.Chat(ctx, messages)
+	})
+}
+
+// ChatWithStructuredOutput implements the Client interface.
+func (c *FallbackClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	return c.call(ctx, "structured_output", func(client Client) (*types.Response, error) {
+		return client.ChatWithStructuredOutput(ctx, messages, schema)
+	})
+}
+
+// ChatWithTools implements the Client interface.
+func (c *FallbackClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return c.call(ctx, "tools", func(client Client) (*types.Response, error) {
+		return client.ChatWithTools(ctx, messages, tools)
+	})
+}
+
+// call tries the providers in order until one serves the request or fails
+// with an error that does not warrant falling back.
+func (c *FallbackClient) call(ctx context.Context, method string, send func(Client) (*types.Response, error)) (*types.Response, error) {
+	var errs []error
+	for i, client := range c.clients {
+		start := time.Now()
+		resp, err := send(client)
+
+		attempt := FallbackAttempt{
+			Index:    i,
+			Provider: c.names[i],
+			Method:   method,
+			Latency:  time.Since(start),
+			Err:      err,
+		}
+		if c.onAttempt != nil {
+			c.onAttempt(ctx, attempt)
+		}
+		if err == nil {
+			if c.logFallbacks && i > 0 {
+				log.Printf("LLM request served by fallback provider %s", attempt.Provider)
+			}
+			return resp, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", attempt.Provider, err))
+		if ctx.Err() != nil || !c.shouldFall(err) {
+			return nil, err
+		}
+		if c.logFallbacks && i < len(c.clients)-1 {
+			log.Printf("Warning: LLM provider %s unavailable, falling back to %s: %v", attempt.Provider, c.names[i+1], err)
+		}
+	}
+
+	return nil, fmt.Errorf("all LLM providers failed: %w", errors.Join(errs...))
+}
+
+// shouldFallBack reports whether err means the provider is unavailable.
+func shouldFallBack(err error) bool {
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return true
+	}
+	return isRetryableError(err)
+}
+
+// Close closes every provider of the chain.
+func (c *FallbackClient) Close() error {
+	var errs []error
+	for i, client := range c.clients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.names[i], err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to close fallback providers: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sony/gobreaker"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// failingClient fails every request with err, or answers with its name.
+type failingClient struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (c *failingClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &types.Response{Content: c.name}, nil
+}
+
+func (c *failingClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	return c.Chat(ctx, messages)
+}
+
+func (c *failingClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return c.Chat(ctx, messages)
+}
+
+func (c *failingClient) Close() error {
+	return nil
+}
+
+func TestFallbackClientFallsBackOnOutages(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"rate limit", NewRateLimitError()},
+		{"server error", errors.New("status code: 503, service unavailable")},
+		{"open circuit breaker", gobreaker.ErrOpenState},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &failingClient{name: "openai", err: tt.err}
+			secondary := &failingClient{name: "groq"}
+
+			var attempts []FallbackAttempt
+			client := NewFallbackClient(primary, secondary).
+				WithNames("openai", "groq").
+				OnAttempt(func(ctx context.Context, attempt FallbackAttempt) {
+					attempts = append(attempts, attempt)
+				})
+
+			resp, err := client.ChatWithStructuredOutput(context.Background(), []types.Message{NewUserMessage("hi")}, nil)
+			if err != nil {
+				t.Fatalf("ChatWithStructuredOutput() error = %v", err)
+			}
+			if resp.Content != "groq" {
+				t.Errorf("response served by %q, want groq", resp.Content)
+			}
+			if len(attempts) != 2 || attempts[0].Provider != "openai" || attempts[0].Err == nil ||
+				attempts[1].Provider != "groq" || attempts[1].Err != nil || attempts[1].Method != "structured_output" {
+				t.Errorf("unexpected attempts %+v", attempts)
+			}
+		})
+	}
+}
+
+func TestFallbackClientReturnsRequestErrors(t *testing.T) {
+	primary := &failingClient{name: "openai", err: errors.New("status code: 400, invalid request")}
+	secondary := &failingClient{name: "groq"}
+
+	if _, err := NewFallbackClient(primary, secondary).Chat(context.Background(), nil); err == nil {
+		t.Fatal("Chat() should return the error of an invalid request")
+	}
+	if secondary.calls != 0 {
+		t.Error("invalid requests should not be sent to the fallback provider")
+	}
+}
+
+func TestFallbackClientAllProvidersFail(t *testing.T) {
+	client := NewFallbackClient(
+		&failingClient{err: NewRateLimitError()},
+		&failingClient{err: errors.New("connection refused")},
+	)
+
+	_, err := client.Chat(context.Background(), nil)
+	if err == nil {
+		t.Fatal("Chat() should fail when every provider fails")
+	}
+	if !strings.Contains(err.Error(), "provider-0") || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("error should name every failed provider, got %v", err)
+	}
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Errorf("error should wrap the provider errors, got %v", err)
+	}
+}
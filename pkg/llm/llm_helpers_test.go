@@ -132,6 +132,11 @@ func (m *mockLLMClient) ChatWithStructuredOutput(ctx context.Context, messages [
 	return nil, nil
 }
 
+func (m *mockLLMClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	// Not used in these tests
+	return nil, nil
+}
+
 func (m *mockLLMClient) Close() error {
 	// Nothing to close in mock
 	return nil
@@ -0,0 +1,290 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// DefaultOllamaBaseURL is the address of a local Ollama server.
+const DefaultOllamaBaseURL = "http://localhost:11434"
+
+// OllamaClient implements the Client interface using Ollama's native chat API.
+// Unlike the OpenAI-compatible endpoint, the native API supports constraining
+// generation to a JSON schema, so structured output always parses.
+type OllamaClient struct {
+	config     *LLMConfig
+	httpClient *http.Client
+}
+
+// NewOllamaClient creates a new Ollama client. An empty BaseURL uses
+// DefaultOllamaBaseURL; local models can be slow, so requests time out after
+// five minutes.
+func NewOllamaClient(config *LLMConfig) *OllamaClient {
+	if config == nil {
+		config = NewLLMConfig()
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultOllamaBaseURL
+	}
+	config.BaseURL = strings.TrimSuffix(strings.TrimRight(config.BaseURL, "/"), "/v1")
+
+	return &OllamaClient{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Minute,
+		},
+	}
+}
+
+// ollamaRequest represents the request structure for Ollama's /api/chat.
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	// Format is "json" or a JSON schema constraining the response
+	Format  any            `json:"format,omitempty"`
+	Options map[string]any `json:"options,omitempty"`
+	// Tools use the OpenAI function tool format
+	Tools []azureTool `json:"tools,omitempty"`
+}
+
+// ollamaMessage represents a message in Ollama format.
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	// ToolName names the tool whose result a tool message holds
+	ToolName string `json:"tool_name,omitempty"`
+}
+
+// ollamaToolCall represents a function call. Unlike OpenAI, Ollama sends the
+// arguments as an object and does not identify calls.
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+// ollamaResponse represents the response from Ollama's /api/chat.
+type ollamaResponse struct {
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error,omitempty"`
+}
+
+// Chat implements the Client interface for Ollama.
+func (o *OllamaClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return o.chat(ctx, messages, nil)
+}
+
+// ChatWithStructuredOutput constrains generation to schema when it is a JSON
+// schema (a map, json.RawMessage or JSON string), and to any JSON object
+// otherwise. The schema is also described in the prompt, as Ollama recommends.
+func (o *OllamaClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	format := ollamaFormat(schema)
+
+	modifiedMessages := make([]types.Message, len(messages), len(messages)+1)
+	copy(modifiedMessages, messages)
+	if schema != nil {
+		schemaBytes, err := json.Marshal(schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal schema: %w", err)
+		}
+		modifiedMessages = append(modifiedMessages, types.Message{
+			Role:    RoleUser,
+			Content: fmt.Sprintf("Please respond with valid JSON that matches this schema: %s", string(schemaBytes)),
+		})
+	}
+
+	return o.chat(ctx, modifiedMessages, format)
+}
+
+// ChatWithTools implements the Client interface using Ollama's tool support.
+// Ollama does not identify tool calls, so calls get sequential IDs and tool
+// results are matched to them by tool name.
+func (o *OllamaClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return o.chat(ctx, messages, nil, tools...)
+}
+
+// ollamaFormat returns the format parameter for schema: the schema itself when it
+// is a JSON schema object, and "json" otherwise.
+func ollamaFormat(schema any) any {
+	var raw []byte
+	switch s := schema.(type) {
+	case json.RawMessage:
+		raw = s
+	case []byte:
+		raw = s
+	case string:
+		raw = []byte(s)
+	case map[string]any:
+		if isJSONSchema(s) {
+			return s
+		}
+		return "json"
+	default:
+		return "json"
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(raw, &decoded); err != nil || !isJSONSchema(decoded) {
+		return "json"
+	}
+	return json.RawMessage(raw)
+}
+
+// isJSONSchema reports whether m looks like a JSON schema rather than an example value.
+func isJSONSchema(m map[string]any) bool {
+	_, hasType := m["type"].(string)
+	_, hasProperties := m["properties"].(map[string]any)
+	return hasType || hasProperties
+}
+
+// chat sends a non-streaming request to /api/chat.
+func (o *OllamaClient) chat(ctx context.Context, messages []types.Message, format any, tools ...types.Tool) (*types.Response, error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no messages provided")
+	}
+
+	req := ollamaRequest{
+		Model:    o.config.Model,
+		Messages: toOllamaMessages(messages),
+		Stream:   false,
+		Format:   format,
+		Options:  o.options(),
+		Tools:    toAzureTools(tools),
+	}
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.config.BaseURL+"/api/chat", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if o.config.APIKey != "" {
+		// Ollama itself has no auth, but reverse proxies in front of it often do
+		httpReq.Header.Set("Authorization", "Bearer "+o.config.APIKey)
+	}
+
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var ollamaResp ollamaResponse
+	if err := json.Unmarshal(body, &ollamaResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if ollamaResp.Error != "" {
+		return nil, fmt.Errorf("API error: %s", ollamaResp.Error)
+	}
+
+	if ollamaResp.Message.Content == "" && len(ollamaResp.Message.ToolCalls) == 0 {
+		return nil, NewEmptyResponseError("ollama returned an empty response")
+	}
+
+	response := &types.Response{
+		Content:      ollamaResp.Message.Content,
+		FinishReason: ollamaResp.DoneReason,
+		Model:        ollamaResp.Model,
+	}
+	for i, call := range ollamaResp.Message.ToolCalls {
+		response.ToolCalls = append(response.ToolCalls, types.ToolCall{
+			ID:        fmt.Sprintf("call_%d", i),
+			Name:      call.Function.Name,
+			Arguments: string(call.Function.Arguments),
+		})
+	}
+	if total := ollamaResp.PromptEvalCount + ollamaResp.EvalCount; total > 0 {
+		response.TokensUsed = &types.TokenUsage{
+			PromptTokens:     ollamaResp.PromptEvalCount,
+			CompletionTokens: ollamaResp.EvalCount,
+			TotalTokens:      total,
+		}
+	}
+
+	return response, nil
+}
+
+// toOllamaMessages converts messages to Ollama format, naming the tool of each
+// tool result after the call it answers.
+func toOllamaMessages(messages []types.Message) []ollamaMessage {
+	toolNames := make(map[string]string)
+	ollamaMessages := make([]ollamaMessage, 0, len(messages))
+	for _, msg := range messages {
+		ollamaMsg := ollamaMessage{
+			Role:     string(msg.Role),
+			Content:  msg.Content,
+			ToolName: toolNames[msg.ToolCallID],
+		}
+		for _, call := range msg.ToolCalls {
+			toolNames[call.ID] = call.Name
+			var ollamaCall ollamaToolCall
+			ollamaCall.Function.Name = call.Name
+			ollamaCall.Function.Arguments = json.RawMessage(call.Arguments)
+			if !json.Valid(ollamaCall.Function.Arguments) {
+				ollamaCall.Function.Arguments = json.RawMessage("{}")
+			}
+			ollamaMsg.ToolCalls = append(ollamaMsg.ToolCalls, ollamaCall)
+		}
+		ollamaMessages = append(ollamaMessages, ollamaMsg)
+	}
+	return ollamaMessages
+}
+
+// options maps the sampling settings of the config to Ollama model options.
+func (o *OllamaClient) options() map[string]any {
+	options := map[string]any{}
+	if o.config.Temperature > 0 {
+		options["temperature"] = o.config.Temperature
+	}
+	if o.config.MaxTokens > 0 {
+		options["num_predict"] = o.config.MaxTokens
+	}
+	if o.config.TopP > 0 {
+		options["top_p"] = o.config.TopP
+	}
+	if o.config.TopK > 0 {
+		options["top_k"] = o.config.TopK
+	}
+	if o.config.MinP > 0 {
+		options["min_p"] = o.config.MinP
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	return options
+}
+
+// Close implements the Client interface.
+func (o *OllamaClient) Close() error {
+	return nil
+}
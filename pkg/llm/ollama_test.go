@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestOllamaFormat(t *testing.T) {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"name": map[string]any{"type": "string"}},
+	}
+	if got, ok := ollamaFormat(schema).(map[string]any); !ok || got["type"] != "object" {
+		t.Errorf("expected map schemas to be passed through, got %v", ollamaFormat(schema))
+	}
+	if got, ok := ollamaFormat(`{"type": "object"}`).(json.RawMessage); !ok || string(got) != `{"type": "object"}` {
+		t.Errorf("expected JSON schema strings to be passed through, got %v", ollamaFormat(`{"type": "object"}`))
+	}
+	if got := ollamaFormat(map[string]any{"name": "example"}); got != "json" {
+		t.Errorf("expected example values to use JSON mode, got %v", got)
+	}
+	if got := ollamaFormat(struct{ Name string }{}); got != "json" {
+		t.Errorf("expected Go values to use JSON mode, got %v", got)
+	}
+}
+
+func TestOllamaClientStructuredOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		format, ok := req["format"].(map[string]any)
+		if !ok || format["type"] != "object" {
+			t.Errorf("expected the schema as format, got %v", req["format"])
+		}
+		if req["stream"] != false {
+			t.Errorf("expected a non-streaming request, got %v", req["stream"])
+		}
+		if options, _ := req["options"].(map[string]any); options["num_predict"] != float64(256) {
+			t.Errorf("expected max tokens as num_predict, got %v", req["options"])
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"model":             "llama3.1",
+			"message":           map[string]string{"role": "assistant", "content": `{"name": "Alice"}`},
+			"done":              true,
+			"done_reason":       "stop",
+			"prompt_eval_count": 12,
+			"eval_count":        5,
+		})
+	}))
+	defer server.Close()
+
+	client := NewOllamaClient(NewLLMConfig().WithBaseURL(server.URL + "/v1").WithModel("llama3.1").WithMaxTokens(256))
+
+	schema := map[string]any{"type": "object", "properties": map[string]any{"name": map[string]any{"type": "string"}}}
+	resp, err := client.ChatWithStructuredOutput(context.Background(), []types.Message{NewUserMessage("Who?")}, schema)
+	if err != nil {
+		t.Fatalf("structured chat failed: %v", err)
+	}
+	if resp.Content != `{"name": "Alice"}` {
+		t.Errorf("unexpected content %q", resp.Content)
+	}
+	if resp.TokensUsed == nil || resp.TokensUsed.TotalTokens != 17 {
+		t.Errorf("expected token usage from eval counts, got %+v", resp.TokensUsed)
+	}
+}
@@ -14,6 +14,8 @@ import (
 type OpenAIClient struct {
 	client *openai.Client
 	config Config
+	// quirks adapts requests to a hosted provider, see NewGroqClient
+	quirks *providerQuirks
 }
 
 // NewOpenAIClient creates a new OpenAI client.
@@ -132,6 +134,19 @@ func (c *OpenAIClient) ChatWithStructuredOutput(ctx context.Context, messages []
 	return response, nil
 }
 
+// ChatWithTools sends a chat completion request offering tools to the model.
+func (c *OpenAIClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	req := c.buildChatRequest(messages, false, nil)
+	req.Tools = toOpenAITools(tools)
+
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("openai tool call completion failed: %w", err)
+	}
+
+	return openAIToolResponse(resp)
+}
+
 // Close cleans up resources (no-op for OpenAI client).
 func (c *OpenAIClient) Close() error {
 	return nil
@@ -141,8 +156,10 @@ func (c *OpenAIClient) buildChatRequest(messages []types.Message, structuredOutp
 	openaiMessages := make([]openai.ChatCompletionMessage, len(messages))
 	for i, msg := range messages {
 		openaiMessages[i] = openai.ChatCompletionMessage{
-			Role:    string(msg.Role),
-			Content: msg.Content,
+			Role:       string(msg.Role),
+			Content:    msg.Content,
+			ToolCalls:  toOpenAIToolCalls(msg.ToolCalls),
+			ToolCallID: msg.ToolCallID,
 		}
 	}
 
@@ -178,6 +195,10 @@ func (c *OpenAIClient) buildChatRequest(messages []types.Message, structuredOutp
 		}
 	}
 
+	if c.quirks != nil {
+		c.quirks.apply(&req)
+	}
+
 	return req
 }
 
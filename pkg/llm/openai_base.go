@@ -79,8 +79,15 @@ func (b *BaseOpenAIClient) ConvertMessagesToOpenAIFormat(messages []types.Messag
 			})
 		case RoleAssistant:
 			openaiMessages = append(openaiMessages, openai.ChatCompletionMessage{
-				Role:    openai.ChatMessageRoleAssistant,
-				Content: content,
+				Role:      openai.ChatMessageRoleAssistant,
+				Content:   content,
+				ToolCalls: toOpenAIToolCalls(m.ToolCalls),
+			})
+		case RoleTool:
+			openaiMessages = append(openaiMessages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				Content:    content,
+				ToolCallID: m.ToolCallID,
 			})
 		}
 	}
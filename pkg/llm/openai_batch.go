@@ -0,0 +1,533 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// DefaultOpenAIBaseURL is the base URL of the OpenAI API.
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// openAIBatchEndpoint is the endpoint batch requests are sent to.
+const openAIBatchEndpoint = "/v1/chat/completions"
+
+// OpenAIBatchConfig holds configuration for the OpenAI Batch API client.
+type OpenAIBatchConfig struct {
+	*LLMConfig
+	// MaxBatchSize submits the pending requests as soon as this many are queued
+	// (default: 50000, the API limit)
+	MaxBatchSize int
+	// FlushInterval is how long the first queued request waits for others to join
+	// its batch (default: 1 minute)
+	FlushInterval time.Duration
+	// PollInterval is how often the status of a submitted batch is checked
+	// (default: 1 minute)
+	PollInterval time.Duration
+	// CompletionWindow is the time OpenAI has to complete a batch (default: 24h)
+	CompletionWindow string
+}
+
+// DefaultOpenAIBatchConfig returns the default batch configuration.
+func DefaultOpenAIBatchConfig() *OpenAIBatchConfig {
+	return &OpenAIBatchConfig{
+		LLMConfig:        NewLLMConfig(),
+		MaxBatchSize:     50000,
+		FlushInterval:    time.Minute,
+		PollInterval:     time.Minute,
+		CompletionWindow: "24h",
+	}
+}
+
+// OpenAIBatchClient implements the Client interface on top of the OpenAI Batch
+// API, which costs half as much as synchronous requests but may take up to the
+// completion window to answer. Requests made within FlushInterval of each other
+// are submitted as one batch, and each call blocks until its batch completes.
+//
+// It is meant for offline bulk loads: ingest many episodes concurrently so their
+// extraction prompts share batches, and route only the extraction stages to the
+// batch client, for example
+//
+//	llm.NewRouter(realtimeClient).Route(batchClient, "extract_nodes", "extract_edges")
+type OpenAIBatchClient struct {
+	config     *OpenAIBatchConfig
+	baseURL    string
+	httpClient *http.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	pending []*batchRequest
+	timer   *time.Timer
+	nextID  int
+}
+
+// batchRequest is a request waiting for its batch.
+type batchRequest struct {
+	customID string
+	body     openAIBatchBody
+	result   chan batchResult
+}
+
+// batchResult is the outcome of one request of a batch.
+type batchResult struct {
+	response *types.Response
+	err      error
+}
+
+// openAIBatchBody is the chat completion request of one batch line. Azure OpenAI
+// uses the same wire format, so its message and response types are shared.
+type openAIBatchBody struct {
+	Model          string               `json:"model"`
+	Messages       []azureOpenAIMessage `json:"messages"`
+	MaxTokens      int                  `json:"max_tokens,omitempty"`
+	Temperature    float64              `json:"temperature,omitempty"`
+	TopP           float64              `json:"top_p,omitempty"`
+	ResponseFormat *azureResponseFormat `json:"response_format,omitempty"`
+	Tools          []azureTool          `json:"tools,omitempty"`
+}
+
+// openAIBatchInputLine is one line of the batch input file.
+type openAIBatchInputLine struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     openAIBatchBody `json:"body"`
+}
+
+// openAIBatchOutputLine is one line of the batch output or error file.
+type openAIBatchOutputLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int                 `json:"status_code"`
+		Body       azureOpenAIResponse `json:"body"`
+	} `json:"response"`
+	Error *azureOpenAIError `json:"error"`
+}
+
+// openAIBatch is the batch object returned by the API.
+type openAIBatch struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	OutputFileID string `json:"output_file_id"`
+	ErrorFileID  string `json:"error_file_id"`
+	Errors       *struct {
+		Data []azureOpenAIError `json:"data"`
+	} `json:"errors"`
+}
+
+// NewOpenAIBatchClient creates a new OpenAI Batch API client. An empty BaseURL
+// uses DefaultOpenAIBaseURL.
+func NewOpenAIBatchClient(config *OpenAIBatchConfig) (*OpenAIBatchClient, error) {
+	if config == nil {
+		config = DefaultOpenAIBatchConfig()
+	}
+	if config.LLMConfig == nil {
+		config.LLMConfig = NewLLMConfig()
+	}
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("API key is required for the OpenAI Batch API")
+	}
+	if config.Model == "" {
+		config.Model = DefaultModel
+	}
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = 50000
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Minute
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Minute
+	}
+	if config.CompletionWindow == "" {
+		config.CompletionWindow = "24h"
+	}
+
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	if err := validateBaseURL(baseURL); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &OpenAIBatchClient{
+		config:     config,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		ctx:        ctx,
+		cancel:     cancel,
+	}, nil
+}
+
+// Chat implements the Client interface. It blocks until the batch containing
+// the request completes.
+func (c *OpenAIBatchClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return c.enqueue(ctx, messages, nil)
+}
+
+// ChatWithStructuredOutput implements the Client interface using JSON mode,
+// with the schema described in an additional user message.
+func (c *OpenAIBatchClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	modifiedMessages := make([]types.Message, len(messages), len(messages)+1)
+	copy(modifiedMessages, messages)
+	modifiedMessages = append(modifiedMessages, types.Message{
+		Role:    RoleUser,
+		Content: fmt.Sprintf("Please respond with valid JSON that matches this schema: %s", string(schemaBytes)),
+	})
+
+	return c.enqueue(ctx, modifiedMessages, &azureResponseFormat{Type: "json_object"})
+}
+
+// ChatWithTools implements the Client interface using function calling. Each
+// round of tool calls waits for a batch, so this is only practical for
+// offline workloads.
+func (c *OpenAIBatchClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return c.enqueue(ctx, messages, nil, tools...)
+}
+
+// enqueue adds a request to the pending batch and waits for its result.
+func (c *OpenAIBatchClient) enqueue(ctx context.Context, messages []types.Message, format *azureResponseFormat, tools ...types.Tool) (*types.Response, error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no messages provided")
+	}
+
+	body := openAIBatchBody{
+		Model:          c.config.Model,
+		Messages:       toAzureMessages(messages),
+		MaxTokens:      c.config.MaxTokens,
+		Temperature:    float64(c.config.Temperature),
+		TopP:           float64(c.config.TopP),
+		ResponseFormat: format,
+		Tools:          toAzureTools(tools),
+	}
+
+	req := &batchRequest{body: body, result: make(chan batchResult, 1)}
+
+	c.mu.Lock()
+	if c.ctx.Err() != nil {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("batch client is closed")
+	}
+	c.nextID++
+	req.customID = fmt.Sprintf("request-%d", c.nextID)
+	c.pending = append(c.pending, req)
+	if len(c.pending) >= c.config.MaxBatchSize {
+		c.flushLocked()
+	} else if c.timer == nil {
+		c.timer = time.AfterFunc(c.config.FlushInterval, c.flush)
+	}
+	c.mu.Unlock()
+
+	select {
+	case result := <-req.result:
+		return result.response, result.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("context cancelled waiting for batch result: %w", ctx.Err())
+	}
+}
+
+// flush submits the pending requests.
+func (c *OpenAIBatchClient) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+}
+
+// flushLocked submits the pending requests as a new batch. Callers must hold c.mu.
+func (c *OpenAIBatchClient) flushLocked() {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if len(c.pending) == 0 {
+		return
+	}
+	requests := c.pending
+	c.pending = nil
+	go c.runBatch(requests)
+}
+
+// runBatch submits requests as one batch, waits for it to finish and delivers
+// the results.
+func (c *OpenAIBatchClient) runBatch(requests []*batchRequest) {
+	results, err := c.executeBatch(c.ctx, requests)
+	for _, req := range requests {
+		if err != nil {
+			req.result <- batchResult{err: err}
+			continue
+		}
+		result, ok := results[req.customID]
+		if !ok {
+			result = batchResult{err: fmt.Errorf("batch returned no result for %s", req.customID)}
+		}
+		req.result <- result
+	}
+}
+
+// executeBatch uploads the input file, creates the batch, polls it until it
+// reaches a final status and downloads its results, keyed by custom ID.
+func (c *OpenAIBatchClient) executeBatch(ctx context.Context, requests []*batchRequest) (map[string]batchResult, error) {
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, req := range requests {
+		line := openAIBatchInputLine{
+			CustomID: req.customID,
+			Method:   http.MethodPost,
+			URL:      openAIBatchEndpoint,
+			Body:     req.body,
+		}
+		if err := encoder.Encode(line); err != nil {
+			return nil, fmt.Errorf("failed to encode batch request: %w", err)
+		}
+	}
+
+	fileID, err := c.uploadBatchFile(ctx, input.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var batch openAIBatch
+	err = c.doJSON(ctx, http.MethodPost, "/batches", map[string]string{
+		"input_file_id":     fileID,
+		"endpoint":          openAIBatchEndpoint,
+		"completion_window": c.config.CompletionWindow,
+	}, &batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	for !isFinalBatchStatus(batch.Status) {
+		select {
+		case <-time.After(c.config.PollInterval):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for batch %s: %w", batch.ID, ctx.Err())
+		}
+		if err := c.doJSON(ctx, http.MethodGet, "/batches/"+batch.ID, nil, &batch); err != nil {
+			return nil, fmt.Errorf("failed to get status of batch %s: %w", batch.ID, err)
+		}
+	}
+
+	// Expired batches still return the results completed in time
+	if batch.Status != "completed" && batch.Status != "expired" {
+		message := batch.Status
+		if batch.Errors != nil && len(batch.Errors.Data) > 0 {
+			message = fmt.Sprintf("%s: %s", batch.Status, batch.Errors.Data[0].Message)
+		}
+		return nil, fmt.Errorf("batch %s did not complete (%s)", batch.ID, message)
+	}
+
+	results := make(map[string]batchResult, len(requests))
+	for _, outputFileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if outputFileID == "" {
+			continue
+		}
+		if err := c.readBatchResults(ctx, outputFileID, results); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// isFinalBatchStatus reports whether a batch with status will not change any more.
+func isFinalBatchStatus(status string) bool {
+	switch status {
+	case "completed", "failed", "expired", "cancelled":
+		return true
+	}
+	return false
+}
+
+// uploadBatchFile uploads a JSONL batch input file and returns its file ID.
+func (c *OpenAIBatchClient) uploadBatchFile(ctx context.Context, content []byte) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("purpose", "batch"); err != nil {
+		return "", fmt.Errorf("failed to write upload form: %w", err)
+	}
+	part, err := writer.CreateFormFile("file", "batch.jsonl")
+	if err != nil {
+		return "", fmt.Errorf("failed to write upload form: %w", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return "", fmt.Errorf("failed to write upload form: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to write upload form: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/files", &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(httpReq, &file); err != nil {
+		return "", fmt.Errorf("failed to upload batch file: %w", err)
+	}
+	return file.ID, nil
+}
+
+// readBatchResults downloads an output or error file and adds its lines to results.
+func (c *OpenAIBatchClient) readBatchResults(ctx context.Context, fileID string, results map[string]batchResult) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/files/"+fileID+"/content", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to download batch results: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to download batch results: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var line openAIBatchOutputLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return fmt.Errorf("failed to unmarshal batch result: %w", err)
+		}
+		results[line.CustomID] = batchResultFromLine(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read batch results: %w", err)
+	}
+	return nil
+}
+
+// batchResultFromLine converts one output line into a response or error.
+func batchResultFromLine(line openAIBatchOutputLine) batchResult {
+	if line.Error != nil {
+		return batchResult{err: fmt.Errorf("batch request failed: %s", line.Error.Message)}
+	}
+	if line.Response == nil {
+		return batchResult{err: fmt.Errorf("batch request returned no response")}
+	}
+
+	body := line.Response.Body
+	if line.Response.StatusCode == http.StatusTooManyRequests {
+		return batchResult{err: NewRateLimitError("openai batch request rate limited")}
+	}
+	if line.Response.StatusCode != http.StatusOK {
+		message := fmt.Sprintf("status %d", line.Response.StatusCode)
+		if body.Error != nil {
+			message = body.Error.Message
+		}
+		return batchResult{err: fmt.Errorf("batch request failed: %s", message)}
+	}
+	if len(body.Choices) == 0 {
+		return batchResult{err: fmt.Errorf("no choices in response")}
+	}
+
+	choice := body.Choices[0]
+	response := &types.Response{
+		Content:      choice.Message.Content,
+		FinishReason: choice.FinishReason,
+		Model:        body.Model,
+		ToolCalls:    fromAzureToolCalls(choice.Message.ToolCalls),
+	}
+	if body.Usage != nil && body.Usage.TotalTokens > 0 {
+		response.TokensUsed = &types.TokenUsage{
+			PromptTokens:     body.Usage.PromptTokens,
+			CompletionTokens: body.Usage.CompletionTokens,
+			TotalTokens:      body.Usage.TotalTokens,
+		}
+	}
+	return batchResult{response: response}
+}
+
+// doJSON sends a JSON request to path and decodes the JSON response into out.
+func (c *OpenAIBatchClient) doJSON(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		reqBody, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(reqBody)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if in != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	return c.do(httpReq, out)
+}
+
+// do authorizes and sends req, decoding the JSON response into out.
+func (c *OpenAIBatchClient) do(req *http.Request, out any) error {
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return NewRateLimitError(fmt.Sprintf("openai rate limit exceeded: %s", string(body)))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// Close stops waiting for submitted batches; their callers receive an error.
+// Requests that were not submitted yet fail as well.
+func (c *OpenAIBatchClient) Close() error {
+	c.mu.Lock()
+	c.cancel()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	for _, req := range pending {
+		req.result <- batchResult{err: fmt.Errorf("batch client is closed")}
+	}
+	return nil
+}
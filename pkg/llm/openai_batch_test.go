@@ -0,0 +1,203 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// fakeBatchServer emulates the files and batches endpoints of the OpenAI API.
+// Each batch completes on its second status check; requests whose last message
+// contains "fail" end up in the error file.
+type fakeBatchServer struct {
+	mu      sync.Mutex
+	inputs  map[string][]openAIBatchInputLine
+	polls   map[string]int
+	outputs map[string]string
+	batches int
+}
+
+func newFakeBatchServer(t *testing.T) (*fakeBatchServer, *httptest.Server) {
+	f := &fakeBatchServer{
+		inputs:  make(map[string][]openAIBatchInputLine),
+		polls:   make(map[string]int),
+		outputs: make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /files", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("purpose") != "batch" {
+			t.Errorf("upload purpose = %q, want batch", r.FormValue("purpose"))
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("missing upload file: %v", err)
+		}
+		var lines []openAIBatchInputLine
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var line openAIBatchInputLine
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("invalid input line: %v", err)
+			}
+			lines = append(lines, line)
+		}
+
+		f.mu.Lock()
+		fileID := fmt.Sprintf("file-%d", len(f.inputs)+1)
+		f.inputs[fileID] = lines
+		f.mu.Unlock()
+		fmt.Fprintf(w, `{"id":%q}`, fileID)
+	})
+	mux.HandleFunc("POST /batches", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["endpoint"] != "/v1/chat/completions" || req["completion_window"] != "24h" {
+			t.Errorf("unexpected batch request %v", req)
+		}
+
+		f.mu.Lock()
+		f.batches++
+		batchID := "batch-" + strings.TrimPrefix(req["input_file_id"], "file-")
+		f.mu.Unlock()
+		fmt.Fprintf(w, `{"id":%q,"status":"validating"}`, batchID)
+	})
+	mux.HandleFunc("GET /batches/{id}", func(w http.ResponseWriter, r *http.Request) {
+		batchID := r.PathValue("id")
+		suffix := strings.TrimPrefix(batchID, "batch-")
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.polls[batchID]++
+		if f.polls[batchID] < 2 {
+			fmt.Fprintf(w, `{"id":%q,"status":"in_progress"}`, batchID)
+			return
+		}
+
+		var output, errors strings.Builder
+		for _, line := range f.inputs["file-"+suffix] {
+			last := line.Body.Messages[len(line.Body.Messages)-1].Content
+			if strings.Contains(last, "fail") {
+				fmt.Fprintf(&errors, `{"custom_id":%q,"response":null,"error":{"code":"bad","message":"request failed"}}`+"\n", line.CustomID)
+				continue
+			}
+			fmt.Fprintf(&output, `{"custom_id":%q,"response":{"status_code":200,"body":{"model":%q,"choices":[{"message":{"role":"assistant","content":%q},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}},"error":null}`+"\n",
+				line.CustomID, line.Body.Model, "echo: "+last)
+		}
+		f.outputs["out-"+suffix] = output.String()
+		f.outputs["err-"+suffix] = errors.String()
+		fmt.Fprintf(w, `{"id":%q,"status":"completed","output_file_id":"out-%s","error_file_id":"err-%s"}`, batchID, suffix, suffix)
+	})
+	mux.HandleFunc("GET /files/{id}/content", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		fmt.Fprint(w, f.outputs[r.PathValue("id")])
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func newTestBatchClient(t *testing.T, baseURL string) *OpenAIBatchClient {
+	client, err := NewOpenAIBatchClient(&OpenAIBatchConfig{
+		LLMConfig:     &LLMConfig{APIKey: "test-key", Model: "gpt-4o-mini", BaseURL: baseURL},
+		FlushInterval: 20 * time.Millisecond,
+		PollInterval:  5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewOpenAIBatchClient() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestOpenAIBatchClientCoalescesConcurrentRequests(t *testing.T) {
+	fake, server := newFakeBatchServer(t)
+	client := newTestBatchClient(t, server.URL)
+
+	prompts := []string{"alpha", "beta", "gamma", "please fail"}
+	responses := make([]*types.Response, len(prompts))
+	errs := make([]error, len(prompts))
+
+	var wg sync.WaitGroup
+	for i, prompt := range prompts {
+		wg.Add(1)
+		go func(i int, prompt string) {
+			defer wg.Done()
+			responses[i], errs[i] = client.Chat(context.Background(), []types.Message{NewUserMessage(prompt)})
+		}(i, prompt)
+	}
+	wg.Wait()
+
+	for i, prompt := range prompts[:3] {
+		if errs[i] != nil {
+			t.Fatalf("Chat(%q) error = %v", prompt, errs[i])
+		}
+		if responses[i].Content != "echo: "+prompt {
+			t.Errorf("Chat(%q) = %q, want the response to its own request", prompt, responses[i].Content)
+		}
+		if responses[i].TokensUsed == nil || responses[i].TokensUsed.TotalTokens != 5 {
+			t.Errorf("Chat(%q) token usage = %+v, want 5 total tokens", prompt, responses[i].TokensUsed)
+		}
+	}
+	if errs[3] == nil || !strings.Contains(errs[3].Error(), "request failed") {
+		t.Errorf("failing request error = %v, want the error from the error file", errs[3])
+	}
+	if fake.batches != 1 {
+		t.Errorf("submitted %d batches, want the concurrent requests in one batch", fake.batches)
+	}
+}
+
+func TestOpenAIBatchClientStructuredOutputUsesJSONMode(t *testing.T) {
+	fake, server := newFakeBatchServer(t)
+	client := newTestBatchClient(t, server.URL)
+
+	resp, err := client.ChatWithStructuredOutput(context.Background(), []types.Message{NewUserMessage("extract")}, map[string]any{"type": "object"})
+	if err != nil {
+		t.Fatalf("ChatWithStructuredOutput() error = %v", err)
+	}
+	if !strings.Contains(resp.Content, "matches this schema") {
+		t.Errorf("ChatWithStructuredOutput() should describe the schema in the prompt, got %q", resp.Content)
+	}
+	format := fake.inputs["file-1"][0].Body.ResponseFormat
+	if format == nil || format.Type != "json_object" {
+		t.Errorf("response_format = %+v, want json_object", format)
+	}
+}
+
+func TestOpenAIBatchClientHonoursCallerContext(t *testing.T) {
+	client, err := NewOpenAIBatchClient(&OpenAIBatchConfig{
+		LLMConfig:     &LLMConfig{APIKey: "test-key", BaseURL: "http://127.0.0.1:1"},
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewOpenAIBatchClient() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.Chat(ctx, []types.Message{NewUserMessage("hello")}); err == nil {
+		t.Error("Chat() should fail once the caller's context is done")
+	}
+}
+
+func TestNewOpenAIBatchClientRequiresAPIKey(t *testing.T) {
+	if _, err := NewOpenAIBatchClient(&OpenAIBatchConfig{LLMConfig: &LLMConfig{}}); err == nil {
+		t.Error("NewOpenAIBatchClient() should require an API key")
+	}
+}
@@ -154,6 +154,22 @@ func (c *OpenAIGenericClient) ChatWithStructuredOutput(ctx context.Context, mess
 	}, nil
 }
 
+// ChatWithTools implements the Client interface using function calling.
+func (c *OpenAIGenericClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	req := c.BuildChatRequest(c.ConvertMessagesToOpenAIFormat(messages), c.GetModelForSize(ModelSizeMedium), 0)
+	req.Tools = toOpenAITools(tools)
+
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		if strings.Contains(err.Error(), "rate limit") || strings.Contains(err.Error(), "rate_limit") {
+			return nil, NewRateLimitError(err.Error())
+		}
+		return nil, fmt.Errorf("openai tool call completion failed: %w", err)
+	}
+
+	return openAIToolResponse(resp)
+}
+
 // generateResponseWithEnhancedRetry implements the Python-style retry logic with error feedback
 func (c *OpenAIGenericClient) generateResponseWithEnhancedRetry(
 	ctx context.Context,
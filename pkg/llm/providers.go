@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Hosted OpenAI-compatible providers.
+const (
+	GroqBaseURL          = "https://api.groq.com/openai/v1"
+	DefaultGroqModel     = "llama-3.3-70b-versatile"
+	TogetherBaseURL      = "https://api.together.xyz/v1"
+	DefaultTogetherModel = "meta-llama/Llama-3.3-70B-Instruct-Turbo"
+)
+
+// groqMaxCompletionTokens is the largest max_tokens Groq accepts per model;
+// larger values are rejected with a 400.
+var groqMaxCompletionTokens = map[string]int{
+	"llama-3.3-70b-versatile": 32768,
+	"llama-3.1-8b-instant":    131072,
+	"openai/gpt-oss-120b":     65536,
+	"openai/gpt-oss-20b":      65536,
+}
+
+// togetherContextWindows is the context length of Together models. Together
+// rejects requests whose prompt plus max_tokens exceeds it.
+var togetherContextWindows = map[string]int{
+	"meta-llama/llama-3.3-70b-instruct-turbo":      131072,
+	"meta-llama/llama-3.2-3b-instruct-turbo":       131072,
+	"meta-llama/meta-llama-3.1-8b-instruct-turbo":  131072,
+	"meta-llama/meta-llama-3.1-70b-instruct-turbo": 131072,
+	"qwen/qwen2.5-7b-instruct-turbo":               32768,
+	"qwen/qwen2.5-72b-instruct-turbo":              32768,
+	"qwen/qwen2.5-coder-32b-instruct":              32768,
+	"mistralai/mixtral-8x7b-instruct-v0.1":         32768,
+	"mistralai/mixtral-8x22b-instruct-v0.1":        65536,
+	"deepseek-ai/deepseek-v3":                      131072,
+}
+
+// providerQuirks adjusts requests to the constraints of a provider.
+type providerQuirks struct {
+	// maxCompletionTokens caps max_tokens, by lowercase model name
+	maxCompletionTokens map[string]int
+	// contextWindows caps max_tokens to the room the prompt leaves, by lowercase model name
+	contextWindows map[string]int
+	// jsonWordRequired ensures a message mentions JSON when JSON mode is on
+	jsonWordRequired bool
+	// noStopWithJSON drops stop sequences in JSON mode
+	noStopWithJSON bool
+}
+
+// NewGroqClient creates a client for Groq. An empty model uses DefaultGroqModel.
+// max_tokens is capped to the model's limit, and JSON mode requests drop stop
+// sequences and always mention JSON, as Groq requires.
+func NewGroqClient(apiKey string, config Config) (*OpenAIClient, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = GroqBaseURL
+	}
+	if config.Model == "" {
+		config.Model = DefaultGroqModel
+	}
+
+	client, err := NewOpenAIClient(apiKey, config)
+	if err != nil {
+		return nil, err
+	}
+	client.quirks = &providerQuirks{
+		maxCompletionTokens: groqMaxCompletionTokens,
+		jsonWordRequired:    true,
+		noStopWithJSON:      true,
+	}
+	return client, nil
+}
+
+// NewTogetherClient creates a client for Together AI. An empty model uses
+// DefaultTogetherModel. For known models, max_tokens is reduced so that it fits
+// in the context window next to the prompt.
+func NewTogetherClient(apiKey string, config Config) (*OpenAIClient, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = TogetherBaseURL
+	}
+	if config.Model == "" {
+		config.Model = DefaultTogetherModel
+	}
+
+	client, err := NewOpenAIClient(apiKey, config)
+	if err != nil {
+		return nil, err
+	}
+	client.quirks = &providerQuirks{
+		contextWindows: togetherContextWindows,
+	}
+	return client, nil
+}
+
+// apply adjusts req to the provider's constraints.
+func (q *providerQuirks) apply(req *openai.ChatCompletionRequest) {
+	model := strings.ToLower(req.Model)
+
+	if limit, ok := q.maxCompletionTokens[model]; ok && req.MaxTokens > limit {
+		req.MaxTokens = limit
+	}
+
+	if window, ok := q.contextWindows[model]; ok && req.MaxTokens > 0 {
+		promptTokens := 0
+		for _, msg := range req.Messages {
+			promptTokens += GetTokenCount(msg.Content) + 4
+		}
+		// Keep a margin as the estimate is approximate
+		room := window - promptTokens - promptTokens/10
+		if room < 1 {
+			room = 1
+		}
+		if req.MaxTokens > room {
+			req.MaxTokens = room
+		}
+	}
+
+	if req.ResponseFormat == nil {
+		return
+	}
+	if q.noStopWithJSON {
+		req.Stop = nil
+	}
+	if q.jsonWordRequired && len(req.Messages) > 0 {
+		mentioned := false
+		for _, msg := range req.Messages {
+			if strings.Contains(strings.ToLower(msg.Content), "json") {
+				mentioned = true
+				break
+			}
+		}
+		if !mentioned {
+			req.Messages[len(req.Messages)-1].Content += "\n\nRespond in JSON."
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestNewGroqClientAppliesQuirks(t *testing.T) {
+	maxTokens := 100000
+	client, err := NewGroqClient("test-key", Config{MaxTokens: &maxTokens, Stop: []string{"END"}})
+	if err != nil {
+		t.Fatalf("NewGroqClient() error = %v", err)
+	}
+	if client.config.BaseURL != GroqBaseURL || client.config.Model != DefaultGroqModel {
+		t.Errorf("NewGroqClient() config = %+v, want the Groq defaults", client.config)
+	}
+
+	req := client.buildChatRequest([]types.Message{NewSystemMessage("Extract entities.")}, true, nil)
+	if req.MaxTokens != 32768 {
+		t.Errorf("max_tokens = %d, want it capped to the model limit", req.MaxTokens)
+	}
+	if req.Stop != nil {
+		t.Errorf("stop = %v, want stop sequences dropped in JSON mode", req.Stop)
+	}
+	if !strings.Contains(strings.ToLower(req.Messages[0].Content), "json") {
+		t.Errorf("JSON mode request should mention JSON, got %q", req.Messages[0].Content)
+	}
+
+	req = client.buildChatRequest([]types.Message{NewUserMessage("Hello")}, false, nil)
+	if len(req.Stop) != 1 {
+		t.Errorf("stop = %v, want stop sequences kept outside JSON mode", req.Stop)
+	}
+}
+
+func TestNewTogetherClientFitsMaxTokensInContext(t *testing.T) {
+	maxTokens := DefaultMaxTokens
+	client, err := NewTogetherClient("test-key", Config{Model: "Qwen/Qwen2.5-72B-Instruct-Turbo", MaxTokens: &maxTokens})
+	if err != nil {
+		t.Fatalf("NewTogetherClient() error = %v", err)
+	}
+	if client.config.BaseURL != TogetherBaseURL {
+		t.Errorf("base URL = %q, want %q", client.config.BaseURL, TogetherBaseURL)
+	}
+
+	short := client.buildChatRequest([]types.Message{NewUserMessage("Hello")}, false, nil)
+	if short.MaxTokens != DefaultMaxTokens {
+		t.Errorf("max_tokens = %d, want %d when the prompt is short", short.MaxTokens, DefaultMaxTokens)
+	}
+
+	long := client.buildChatRequest([]types.Message{NewUserMessage(strings.Repeat("word ", 30000))}, false, nil)
+	if long.MaxTokens >= DefaultMaxTokens || long.MaxTokens < 1 {
+		t.Errorf("max_tokens = %d, want it reduced to fit next to a long prompt", long.MaxTokens)
+	}
+}
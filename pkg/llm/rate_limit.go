@@ -0,0 +1,238 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// RateLimitConfig holds the budgets enforced by a RateLimiter. Zero values
+// disable the corresponding limit.
+type RateLimitConfig struct {
+	// RequestsPerMinute caps the number of requests started per minute
+	RequestsPerMinute int
+	// TokensPerMinute caps prompt plus completion tokens per minute. Prompt tokens
+	// are estimated before the request and corrected with the reported usage.
+	TokensPerMinute int
+	// MaxConcurrency caps the number of requests in flight (default: 10)
+	MaxConcurrency int
+}
+
+// DefaultRateLimitConfig returns a configuration limiting concurrency only.
+func DefaultRateLimitConfig() *RateLimitConfig {
+	return &RateLimitConfig{
+		MaxConcurrency: 10,
+	}
+}
+
+// RateLimiter enforces request, token and concurrency budgets shared by every
+// client it is attached to. Budgets refill continuously over each minute.
+type RateLimiter struct {
+	config *RateLimitConfig
+	slots  chan struct{}
+
+	mu         sync.Mutex
+	requests   float64
+	tokens     float64
+	lastRefill time.Time
+	now        func() time.Time
+}
+
+// NewRateLimiter creates a rate limiter. A nil config uses DefaultRateLimitConfig.
+func NewRateLimiter(config *RateLimitConfig) *RateLimiter {
+	if config == nil {
+		config = DefaultRateLimitConfig()
+	}
+
+	limiter := &RateLimiter{
+		config:   config,
+		requests: float64(config.RequestsPerMinute),
+		tokens:   float64(config.TokensPerMinute),
+		now:      time.Now,
+	}
+	limiter.lastRefill = limiter.now()
+	if config.MaxConcurrency > 0 {
+		limiter.slots = make(chan struct{}, config.MaxConcurrency)
+	}
+	return limiter
+}
+
+// Acquire waits until a request estimated to use tokens fits the budgets and a
+// concurrency slot is free. The returned release function must be called with
+// the tokens the request actually used (or the estimate if unknown) once it
+// completes.
+func (l *RateLimiter) Acquire(ctx context.Context, tokens int) (func(usedTokens int), error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("context cancelled waiting for LLM concurrency slot: %w", ctx.Err())
+		}
+	}
+
+	reserved, err := l.reserve(ctx, tokens)
+	if err != nil {
+		if l.slots != nil {
+			<-l.slots
+		}
+		return nil, err
+	}
+
+	var once sync.Once
+	return func(usedTokens int) {
+		once.Do(func() {
+			if l.config.TokensPerMinute > 0 && usedTokens != reserved {
+				// Settle the difference; overruns delay later requests
+				l.mu.Lock()
+				l.tokens -= float64(usedTokens - reserved)
+				l.mu.Unlock()
+			}
+			if l.slots != nil {
+				<-l.slots
+			}
+		})
+	}, nil
+}
+
+// reserve waits until one request and tokens fit the per-minute budgets and
+// deducts them. It returns the number of tokens reserved, which is capped at the
+// per-minute budget so that oversized requests can still run.
+func (l *RateLimiter) reserve(ctx context.Context, tokens int) (int, error) {
+	if tokens < 0 {
+		tokens = 0
+	}
+	if l.config.TokensPerMinute > 0 && tokens > l.config.TokensPerMinute {
+		tokens = l.config.TokensPerMinute
+	}
+
+	for {
+		l.mu.Lock()
+		l.refill()
+
+		var wait time.Duration
+		if l.config.RequestsPerMinute > 0 && l.requests < 1 {
+			wait = max(wait, refillDelay(1-l.requests, l.config.RequestsPerMinute))
+		}
+		if l.config.TokensPerMinute > 0 && l.tokens < float64(tokens) {
+			wait = max(wait, refillDelay(float64(tokens)-l.tokens, l.config.TokensPerMinute))
+		}
+		if wait == 0 {
+			if l.config.RequestsPerMinute > 0 {
+				l.requests--
+			}
+			if l.config.TokensPerMinute > 0 {
+				l.tokens -= float64(tokens)
+			}
+			l.mu.Unlock()
+			return tokens, nil
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return 0, fmt.Errorf("context cancelled waiting for LLM rate limit: %w", ctx.Err())
+		}
+	}
+}
+
+// refill adds the budget accrued since the last refill. Callers must hold l.mu.
+func (l *RateLimiter) refill() {
+	now := l.now()
+	elapsed := now.Sub(l.lastRefill).Minutes()
+	l.lastRefill = now
+	if elapsed <= 0 {
+		return
+	}
+	if perMinute := float64(l.config.RequestsPerMinute); perMinute > 0 {
+		l.requests = min(perMinute, l.requests+elapsed*perMinute)
+	}
+	if perMinute := float64(l.config.TokensPerMinute); perMinute > 0 {
+		l.tokens = min(perMinute, l.tokens+elapsed*perMinute)
+	}
+}
+
+// refillDelay returns how long a per-minute budget takes to accrue missing units.
+func refillDelay(missing float64, perMinute int) time.Duration {
+	delay := time.Duration(missing / float64(perMinute) * float64(time.Minute))
+	if delay < time.Millisecond {
+		delay = time.Millisecond
+	}
+	return delay
+}
+
+// RateLimitedClient wraps a Client so that its calls respect the budgets of a
+// RateLimiter. Wrap the client passed to predicato.NewClient so that the node
+// and edge extraction goroutines share one budget, and place it inside a
+// RetryClient so retries are limited too.
+type RateLimitedClient struct {
+	client  Client
+	limiter *RateLimiter
+}
+
+// NewRateLimitedClient creates a rate limited client with its own RateLimiter.
+func NewRateLimitedClient(client Client, config *RateLimitConfig) *RateLimitedClient {
+	return NewRateLimitedClientWithLimiter(client, NewRateLimiter(config))
+}
+
+// NewRateLimitedClientWithLimiter creates a rate limited client sharing limiter,
+// for example to put several models of one provider account under one budget.
+func NewRateLimitedClientWithLimiter(client Client, limiter *RateLimiter) *RateLimitedClient {
+	return &RateLimitedClient{
+		client:  client,
+		limiter: limiter,
+	}
+}
+
+// Chat implements the Client interface.
+func (c *RateLimitedClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return c.limit(ctx, messages, func() (*types.Response, error) {
+		return c.client.Chat(ctx, messages)
+	})
+}
+
+// ChatWithStructuredOutput implements the Client interface.
+func (c *RateLimitedClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	return c.limit(ctx, messages, func() (*types.Response, error) {
+		return c.client.ChatWithStructuredOutput(ctx, messages, schema)
+	})
+}
+
+// ChatWithTools implements the Client interface.
+func (c *RateLimitedClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return c.limit(ctx, messages, func() (*types.Response, error) {
+		return c.client.ChatWithTools(ctx, messages, tools)
+	})
+}
+
+// limit runs call once the limiter admits a request for messages.
+func (c *RateLimitedClient) limit(ctx context.Context, messages []types.Message, call func() (*types.Response, error)) (*types.Response, error) {
+	estimate := EstimateTokensFromMessages(messages)
+	release, err := c.limiter.Acquire(ctx, estimate)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := call()
+
+	used := estimate
+	if resp != nil && resp.TokensUsed != nil && resp.TokensUsed.TotalTokens > 0 {
+		used = resp.TokensUsed.TotalTokens
+	}
+	release(used)
+
+	return resp, err
+}
+
+// Limiter returns the rate limiter of the client.
+func (c *RateLimitedClient) Limiter() *RateLimiter {
+	return c.limiter
+}
+
+// Close implements the Client interface.
+func (c *RateLimitedClient) Close() error {
+	return c.client.Close()
+}
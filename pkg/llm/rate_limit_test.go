@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// concurrencyClient records the peak number of concurrent calls.
+type concurrencyClient struct {
+	active atomic.Int32
+	peak   atomic.Int32
+	tokens int
+}
+
+func (c *concurrencyClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	active := c.active.Add(1)
+	defer c.active.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if active <= peak || c.peak.CompareAndSwap(peak, active) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return &types.Response{Content: "ok", TokensUsed: &types.TokenUsage{TotalTokens: c.tokens}}, nil
+}
+
+func (c *concurrencyClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	return c.Chat(ctx, messages)
+}
+
+func (c *concurrencyClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return c.Chat(ctx, messages)
+}
+
+func (c *concurrencyClient) Close() error {
+	return nil
+}
+
+func TestRateLimitedClientConcurrency(t *testing.T) {
+	inner := &concurrencyClient{tokens: 10}
+	client := NewRateLimitedClient(inner, &RateLimitConfig{MaxConcurrency: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Chat(context.Background(), []types.Message{NewUserMessage("hi")}); err != nil {
+				t.Errorf("chat failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak := inner.peak.Load(); peak > 2 {
+		t.Errorf("expected at most 2 concurrent calls, got %d", peak)
+	}
+}
+
+func TestRateLimiterRequestBudget(t *testing.T) {
+	limiter := NewRateLimiter(&RateLimitConfig{RequestsPerMinute: 2})
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	limiter.lastRefill = now
+
+	for i := 0; i < 2; i++ {
+		release, err := limiter.Acquire(context.Background(), 0)
+		if err != nil {
+			t.Fatalf("expected request %d to fit the budget: %v", i, err)
+		}
+		release(0)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Acquire(ctx, 0); err == nil {
+		t.Fatal("expected the third request to wait for the budget")
+	}
+
+	// Half a minute later one request has accrued
+	now = now.Add(30 * time.Second)
+	if _, err := limiter.Acquire(context.Background(), 0); err != nil {
+		t.Errorf("expected a request after the budget refilled: %v", err)
+	}
+}
+
+func TestRateLimiterTokenBudget(t *testing.T) {
+	limiter := NewRateLimiter(&RateLimitConfig{TokensPerMinute: 100})
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	limiter.lastRefill = now
+
+	release, err := limiter.Acquire(context.Background(), 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The response used more tokens than estimated
+	release(90)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Acquire(ctx, 20); err == nil {
+		t.Fatal("expected the reported usage to exhaust the token budget")
+	}
+
+	now = now.Add(6 * time.Second)
+	if _, err := limiter.Acquire(context.Background(), 20); err != nil {
+		t.Errorf("expected tokens to refill: %v", err)
+	}
+
+	// Oversized requests are capped at the budget rather than blocking forever
+	now = now.Add(time.Minute)
+	if _, err := limiter.Acquire(context.Background(), 1000); err != nil {
+		t.Errorf("expected an oversized request to run on a full budget: %v", err)
+	}
+}
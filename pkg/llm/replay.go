@@ -0,0 +1,201 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// ErrNoRecording is returned by a ReplayClient without a live client when a
+// request has not been recorded.
+var ErrNoRecording = errors.New("no recorded response for request")
+
+var (
+	replayUUIDPattern      = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	replayTimestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+)
+
+// ReplayClient records the responses of a live client to a file and replays
+// them, so that tests exercising the LLM (for example AddEpisode integration
+// tests) run without API keys and give the same results every time.
+//
+// Requests are matched on their method, messages and schema, with UUIDs and
+// timestamps masked since they change from run to run. Identical requests are
+// replayed in the order they were recorded.
+type ReplayClient struct {
+	path string
+	live Client
+
+	mu        sync.Mutex
+	recording map[string]*replayEntry
+	// replayed counts how often each key was replayed in this session
+	replayed map[string]int
+}
+
+// replayEntry holds the recorded responses of one request.
+type replayEntry struct {
+	Method    string            `json:"method"`
+	Messages  []types.Message   `json:"messages"`
+	Schema    json.RawMessage   `json:"schema,omitempty"`
+	Responses []*types.Response `json:"responses"`
+}
+
+// NewReplayClient loads the recording at recordingPath, if it exists. Requests
+// missing from it are sent to live and recorded; with a nil live client they
+// fail with ErrNoRecording. Run once with a live client to record, then commit
+// the file and run CI without one.
+func NewReplayClient(recordingPath string, live Client) (*ReplayClient, error) {
+	client := &ReplayClient{
+		path:      recordingPath,
+		live:      live,
+		recording: make(map[string]*replayEntry),
+		replayed:  make(map[string]int),
+	}
+
+	data, err := os.ReadFile(recordingPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &client.recording); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal recording: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+// Chat implements the Client interface.
+func (c *ReplayClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return c.replay("chat", messages, nil, func() (*types.Response, error) {
+		return c.live.Chat(ctx, messages)
+	})
+}
+
+// ChatWithStructuredOutput implements the Client interface.
+func (c *ReplayClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	return c.replay("structured_output", messages, schema, func() (*types.Response, error) {
+		return c.live.ChatWithStructuredOutput(ctx, messages, schema)
+	})
+}
+
+// ChatWithTools implements the Client interface. The offered tools are part of
+// the request key, and recorded responses keep their tool calls.
+func (c *ReplayClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return c.replay("tools", messages, tools, func() (*types.Response, error) {
+		return c.live.ChatWithTools(ctx, messages, tools)
+	})
+}
+
+// replay returns the next recorded response of the request, or calls live and
+// records its response once every recorded one has been replayed.
+func (c *ReplayClient) replay(method string, messages []types.Message, schema any, live func() (*types.Response, error)) (*types.Response, error) {
+	var schemaJSON json.RawMessage
+	if schema != nil {
+		data, err := json.Marshal(schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal schema: %w", err)
+		}
+		schemaJSON = data
+	}
+	key := replayKey(method, messages, schemaJSON)
+
+	c.mu.Lock()
+	entry := c.recording[key]
+	index := c.replayed[key]
+	if entry != nil && index < len(entry.Responses) {
+		c.replayed[key]++
+		resp := *entry.Responses[index]
+		c.mu.Unlock()
+		return &resp, nil
+	}
+	c.mu.Unlock()
+
+	if c.live == nil {
+		return nil, fmt.Errorf("%w (%s with %d messages); record it by running with a live client", ErrNoRecording, method, len(messages))
+	}
+
+	resp, err := live()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry = c.recording[key]
+	if entry == nil {
+		entry = &replayEntry{Method: method, Messages: messages, Schema: schemaJSON}
+		c.recording[key] = entry
+	}
+	recorded := *resp
+	entry.Responses = append(entry.Responses, &recorded)
+	c.replayed[key] = len(entry.Responses)
+
+	if err := c.saveLocked(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replayKey hashes a request with UUIDs and timestamps masked.
+func replayKey(method string, messages []types.Message, schema json.RawMessage) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	for _, msg := range messages {
+		hash.Write([]byte{0})
+		hash.Write([]byte(msg.Role))
+		hash.Write([]byte{0})
+		hash.Write([]byte(normalizeReplayText(msg.Content)))
+		for _, call := range msg.ToolCalls {
+			hash.Write([]byte{0})
+			hash.Write([]byte(call.Name))
+			hash.Write([]byte(normalizeReplayText(call.Arguments)))
+		}
+	}
+	hash.Write([]byte{0})
+	hash.Write(schema)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// normalizeReplayText masks the parts of a prompt that differ between runs.
+func normalizeReplayText(text string) string {
+	text = replayUUIDPattern.ReplaceAllString(text, "<uuid>")
+	return replayTimestampPattern.ReplaceAllString(text, "<timestamp>")
+}
+
+// saveLocked writes the recording atomically. Callers must hold c.mu.
+func (c *ReplayClient) saveLocked() error {
+	data, err := json.MarshalIndent(c.recording, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		return fmt.Errorf("failed to save recording: %w", err)
+	}
+	return nil
+}
+
+// Close implements the Client interface, closing the live client if any.
+func (c *ReplayClient) Close() error {
+	if c.live == nil {
+		return nil
+	}
+	return c.live.Close()
+}
@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// countingClient answers with the number of requests it has served.
+type countingClient struct {
+	calls int
+}
+
+func (c *countingClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	c.calls++
+	return &types.Response{Content: fmt.Sprintf("answer %d", c.calls), Model: "live"}, nil
+}
+
+func (c *countingClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	c.calls++
+	return &types.Response{Content: fmt.Sprintf(`{"answer":%d}`, c.calls), Model: "live"}, nil
+}
+
+func (c *countingClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return c.Chat(ctx, messages)
+}
+
+func (c *countingClient) Close() error {
+	return nil
+}
+
+func TestReplayClientRecordsThenReplays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "recording.json")
+	ctx := context.Background()
+	prompt := []types.Message{NewUserMessage("Episode 0b7a1c52-3f6e-4b8e-9a59-0c4f1c9c0e11 at 2025-03-01T10:00:00Z")}
+	schema := map[string]any{"type": "object"}
+
+	live := &countingClient{}
+	recorder, err := NewReplayClient(path, live)
+	if err != nil {
+		t.Fatalf("NewReplayClient() error = %v", err)
+	}
+	first, _ := recorder.Chat(ctx, prompt)
+	second, _ := recorder.Chat(ctx, prompt)
+	structured, _ := recorder.ChatWithStructuredOutput(ctx, prompt, schema)
+	if live.calls != 3 {
+		t.Fatalf("recording made %d live calls, want 3", live.calls)
+	}
+
+	// Replay without a live client; UUIDs and timestamps differ from the recording
+	replayer, err := NewReplayClient(path, nil)
+	if err != nil {
+		t.Fatalf("NewReplayClient() error = %v", err)
+	}
+	rerun := []types.Message{NewUserMessage("Episode 5d0c2f0e-8a7b-4e7c-b1d3-2f9e7a6b5c4d at 2026-01-15T08:30:00Z")}
+
+	for _, want := range []string{first.Content, second.Content} {
+		got, err := replayer.Chat(ctx, rerun)
+		if err != nil {
+			t.Fatalf("Chat() replay error = %v", err)
+		}
+		if got.Content != want {
+			t.Errorf("Chat() replayed %q, want %q", got.Content, want)
+		}
+	}
+	got, err := replayer.ChatWithStructuredOutput(ctx, rerun, schema)
+	if err != nil {
+		t.Fatalf("ChatWithStructuredOutput() replay error = %v", err)
+	}
+	if got.Content != structured.Content {
+		t.Errorf("ChatWithStructuredOutput() replayed %q, want %q", got.Content, structured.Content)
+	}
+
+	// A third identical chat was never recorded
+	if _, err := replayer.Chat(ctx, rerun); !errors.Is(err, ErrNoRecording) {
+		t.Errorf("Chat() past the recording error = %v, want ErrNoRecording", err)
+	}
+	if _, err := replayer.Chat(ctx, []types.Message{NewUserMessage("something else")}); !errors.Is(err, ErrNoRecording) {
+		t.Errorf("Chat() for an unrecorded prompt error = %v, want ErrNoRecording", err)
+	}
+}
+
+func TestReplayClientExtendsRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.json")
+	ctx := context.Background()
+
+	first := &countingClient{}
+	recorder, _ := NewReplayClient(path, first)
+	recorder.Chat(ctx, []types.Message{NewUserMessage("known")})
+
+	second := &countingClient{}
+	extender, err := NewReplayClient(path, second)
+	if err != nil {
+		t.Fatalf("NewReplayClient() error = %v", err)
+	}
+	extender.Chat(ctx, []types.Message{NewUserMessage("known")})
+	extender.Chat(ctx, []types.Message{NewUserMessage("new")})
+	if second.calls != 1 {
+		t.Errorf("live client called %d times, want only for the unrecorded prompt", second.calls)
+	}
+
+	replayer, _ := NewReplayClient(path, nil)
+	if _, err := replayer.Chat(ctx, []types.Message{NewUserMessage("new")}); err != nil {
+		t.Errorf("Chat() should replay the prompt recorded by the second run, got %v", err)
+	}
+}
@@ -143,6 +143,45 @@ func (r *RetryClient) ChatWithStructuredOutput(ctx context.Context, messages []t
 	return nil, fmt.Errorf("failed after %d retries: %w", r.config.MaxRetries, lastErr)
 }
 
+// ChatWithTools implements the Client interface with retry logic
+func (r *RetryClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= r.config.MaxRetries; attempt++ {
+		// If this is a retry, wait with exponential backoff
+		if attempt > 0 {
+			delay := r.calculateDelay(attempt)
+			select {
+			case <-time.After(delay):
+				// Continue with retry
+			case <-ctx.Done():
+				return nil, fmt.Errorf("context cancelled during retry backoff: %w", ctx.Err())
+			}
+		}
+
+		// Make the LLM call
+		result, err := r.client.ChatWithTools(ctx, messages, tools)
+		if err == nil {
+			return result, nil
+		}
+
+		// Store the error
+		lastErr = err
+
+		// Check if the error is retryable
+		if !isRetryableError(err) {
+			// Non-retryable error, fail immediately
+			return nil, err
+		}
+
+		// Log retry attempt (in production, this should use a logger)
+		// For now, we just continue to retry
+	}
+
+	// All retries exhausted
+	return nil, fmt.Errorf("failed after %d retries: %w", r.config.MaxRetries, lastErr)
+}
+
 // Close implements the Client interface
 func (r *RetryClient) Close() error {
 	return r.client.Close()
@@ -37,6 +37,10 @@ func (m *mockClient) ChatWithStructuredOutput(ctx context.Context, messages []ty
 	return &types.Response{Content: `{"status": "success"}`}, nil
 }
 
+func (m *mockClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return m.Chat(ctx, messages)
+}
+
 func (m *mockClient) Close() error {
 	return nil
 }
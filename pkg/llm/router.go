@@ -95,6 +95,20 @@ func (r *RouterClient) ChatWithStructuredOutput(ctx context.Context, messages []
 	return resp, nil
 }
 
+// ChatWithTools implements Client with routing and fallback
+func (r *RouterClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	primary, _, fallback := r.getClientForContext(ctx)
+
+	resp, err := primary.ChatWithTools(ctx, messages, tools)
+	if err != nil {
+		if fallback != nil {
+			return fallback.ChatWithTools(ctx, messages, tools)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
 // Close closes all providers
 func (r *RouterClient) Close() error {
 	var errs []string
@@ -0,0 +1,327 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	jsonrepair "github.com/kaptinlin/jsonrepair"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// Response metadata keys set by StructuredOutputClient.
+const (
+	// MetadataStructuredOutputAttempts is the number of model calls made
+	MetadataStructuredOutputAttempts = "structured_output_attempts"
+	// MetadataStructuredOutputRepairs is the number of re-prompts with validation errors
+	MetadataStructuredOutputRepairs = "structured_output_repairs"
+	// MetadataJSONRepaired reports whether jsonrepair had to fix the final response
+	MetadataJSONRepaired = "json_repaired"
+)
+
+// StructuredOutputConfig holds configuration for structured output validation.
+type StructuredOutputConfig struct {
+	// MaxRepairAttempts is how many times the model is re-prompted with the
+	// validation errors of its response (default: 2)
+	MaxRepairAttempts int
+	// DisableJSONRepair returns an error instead of falling back to jsonrepair
+	// when the last attempt is still invalid
+	DisableJSONRepair bool
+}
+
+// DefaultStructuredOutputConfig returns the default structured output configuration.
+func DefaultStructuredOutputConfig() *StructuredOutputConfig {
+	return &StructuredOutputConfig{
+		MaxRepairAttempts: 2,
+	}
+}
+
+// StructuredOutputClient wraps a Client and validates the responses of
+// ChatWithStructuredOutput. Responses that are not valid JSON or do not match the
+// schema are sent back to the model together with the validation errors; if the
+// model cannot fix them, jsonrepair is tried as a last resort.
+//
+// The schema may be a JSON schema (a map, json.RawMessage or JSON string) or a Go
+// value whose type the response must unmarshal into. Attempt and repair counts
+// are reported in the response metadata.
+type StructuredOutputClient struct {
+	client Client
+	config *StructuredOutputConfig
+}
+
+// NewStructuredOutputClient creates a new structured output validating wrapper.
+func NewStructuredOutputClient(client Client, config *StructuredOutputConfig) *StructuredOutputClient {
+	if config == nil {
+		config = DefaultStructuredOutputConfig()
+	}
+	if config.MaxRepairAttempts < 0 {
+		config.MaxRepairAttempts = 0
+	}
+
+	return &StructuredOutputClient{
+		client: client,
+		config: config,
+	}
+}
+
+// Chat implements the Client interface without validation.
+func (c *StructuredOutputClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return c.client.Chat(ctx, messages)
+}
+
+// ChatWithTools implements the Client interface without validation.
+func (c *StructuredOutputClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return c.client.ChatWithTools(ctx, messages, tools)
+}
+
+// ChatWithStructuredOutput implements the Client interface, re-prompting the
+// model until its response validates against schema.
+func (c *StructuredOutputClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	workingMessages := make([]types.Message, len(messages))
+	copy(workingMessages, messages)
+
+	usage := &types.TokenUsage{}
+	var resp *types.Response
+	var validationErr error
+
+	attempts := 0
+	for repairs := 0; ; repairs++ {
+		var err error
+		resp, err = c.client.ChatWithStructuredOutput(ctx, workingMessages, schema)
+		attempts++
+		if err != nil {
+			return nil, err
+		}
+		addTokenUsage(usage, resp.TokensUsed)
+
+		var content string
+		content, validationErr = validateStructuredOutput(resp.Content, schema)
+		if validationErr == nil {
+			resp.Content = content
+			return withStructuredOutputMetadata(resp, usage, attempts, repairs, false), nil
+		}
+
+		if repairs >= c.config.MaxRepairAttempts {
+			break
+		}
+
+		// Show the model its answer and what is wrong with it
+		workingMessages = append(workingMessages,
+			NewAssistantMessage(resp.Content),
+			NewUserMessage(fmt.Sprintf(
+				"The previous response is invalid: %s. "+
+					"Respond again with only valid JSON that fixes these errors and matches the requested schema.",
+				validationErr)),
+		)
+	}
+
+	if !c.config.DisableJSONRepair {
+		repaired, err := jsonrepair.JSONRepair(ExtractJSONFromResponse(RemoveThinkTags(resp.Content)))
+		if err == nil {
+			if content, err := validateStructuredOutput(repaired, schema); err == nil {
+				resp.Content = content
+				return withStructuredOutputMetadata(resp, usage, attempts, attempts-1, true), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("structured output still invalid after %d attempts: %w", attempts, validationErr)
+}
+
+// Close implements the Client interface.
+func (c *StructuredOutputClient) Close() error {
+	return c.client.Close()
+}
+
+// withStructuredOutputMetadata records the attempt counts and the total token
+// usage of all attempts on resp.
+func withStructuredOutputMetadata(resp *types.Response, usage *types.TokenUsage, attempts, repairs int, repaired bool) *types.Response {
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]interface{})
+	}
+	resp.Metadata[MetadataStructuredOutputAttempts] = attempts
+	resp.Metadata[MetadataStructuredOutputRepairs] = repairs
+	resp.Metadata[MetadataJSONRepaired] = repaired
+	if usage.TotalTokens > 0 {
+		resp.TokensUsed = usage
+	}
+	return resp
+}
+
+// addTokenUsage adds usage to total.
+func addTokenUsage(total, usage *types.TokenUsage) {
+	if usage == nil {
+		return
+	}
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+}
+
+// validateStructuredOutput extracts the JSON document from content and checks it
+// against schema. It returns the extracted JSON.
+func validateStructuredOutput(content string, schema any) (string, error) {
+	content = ExtractJSONFromResponse(RemoveThinkTags(content))
+
+	var value any
+	if err := json.Unmarshal([]byte(content), &value); err != nil {
+		return "", fmt.Errorf("response is not valid JSON (%v)", err)
+	}
+
+	if jsonSchema, ok := asJSONSchema(schema); ok {
+		if errs := validateJSONSchema(value, jsonSchema, "$"); len(errs) > 0 {
+			return "", fmt.Errorf("%s", strings.Join(errs, "; "))
+		}
+		return content, nil
+	}
+
+	switch schema.(type) {
+	case nil, map[string]any, json.RawMessage, []byte, string:
+		// Example values and free-form descriptions only require valid JSON
+		return content, nil
+	}
+
+	// Otherwise the response must decode into the schema's Go type
+	schemaType := reflect.TypeOf(schema)
+	for schemaType.Kind() == reflect.Pointer {
+		schemaType = schemaType.Elem()
+	}
+	if err := json.Unmarshal([]byte(content), reflect.New(schemaType).Interface()); err != nil {
+		return "", fmt.Errorf("response does not match the expected structure (%v)", err)
+	}
+	return content, nil
+}
+
+// asJSONSchema returns schema as a decoded JSON schema, if it is one.
+func asJSONSchema(schema any) (map[string]any, bool) {
+	var raw []byte
+	switch s := schema.(type) {
+	case map[string]any:
+		return s, isJSONSchema(s)
+	case json.RawMessage:
+		raw = s
+	case []byte:
+		raw = s
+	case string:
+		raw = []byte(s)
+	default:
+		return nil, false
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(raw, &decoded); err != nil || !isJSONSchema(decoded) {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// validateJSONSchema checks value against the subset of JSON schema used for LLM
+// responses: type, properties, required, items and enum. It returns one message
+// per violation, prefixed with the JSON path of the offending value.
+func validateJSONSchema(value any, schema map[string]any, path string) []string {
+	var errs []string
+
+	if expected := schemaTypes(schema["type"]); len(expected) > 0 && !matchesJSONType(value, expected) {
+		return []string{fmt.Sprintf("%s should be %s, got %s", path, strings.Join(expected, " or "), jsonTypeName(value))}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Sprintf("%s should be one of %v, got %v", path, enum, value))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				if key, ok := name.(string); ok {
+					if _, present := v[key]; !present {
+						errs = append(errs, fmt.Sprintf("%s is missing required property %q", path, key))
+					}
+				}
+			}
+		}
+		if properties, ok := schema["properties"].(map[string]any); ok {
+			keys := make([]string, 0, len(properties))
+			for key := range properties {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				propertySchema, ok := properties[key].(map[string]any)
+				if propertyValue, present := v[key]; ok && present {
+					errs = append(errs, validateJSONSchema(propertyValue, propertySchema, path+"."+key)...)
+				}
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				errs = append(errs, validateJSONSchema(item, items, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+
+	return errs
+}
+
+// schemaTypes returns the allowed types of a JSON schema "type" keyword.
+func schemaTypes(value any) []string {
+	switch t := value.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		names := make([]string, 0, len(t))
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// matchesJSONType reports whether value is one of the JSON schema types.
+func matchesJSONType(value any, expected []string) bool {
+	actual := jsonTypeName(value)
+	for _, name := range expected {
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTypeName returns the JSON schema type of a decoded JSON value.
+func jsonTypeName(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// scriptedClient returns the scripted structured responses in order and records
+// the messages of each call.
+type scriptedClient struct {
+	responses []string
+	calls     [][]types.Message
+}
+
+func (c *scriptedClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return c.ChatWithStructuredOutput(ctx, messages, nil)
+}
+
+func (c *scriptedClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	content := c.responses[len(c.calls)]
+	c.calls = append(c.calls, messages)
+	return &types.Response{Content: content, TokensUsed: &types.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}}, nil
+}
+
+func (c *scriptedClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return c.Chat(ctx, messages)
+}
+
+func (c *scriptedClient) Close() error {
+	return nil
+}
+
+var entitySchema = map[string]any{
+	"type":     "object",
+	"required": []any{"entities"},
+	"properties": map[string]any{
+		"entities": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type":       "object",
+				"required":   []any{"name"},
+				"properties": map[string]any{"name": map[string]any{"type": "string"}},
+			},
+		},
+	},
+}
+
+func TestStructuredOutputClientRepromptsWithValidationErrors(t *testing.T) {
+	inner := &scriptedClient{responses: []string{
+		`{"entities": [{"name": 42}]}`,
+		`{"entities": [{"name": "Alice"}]}`,
+	}}
+	client := NewStructuredOutputClient(inner, nil)
+
+	resp, err := client.ChatWithStructuredOutput(context.Background(), []types.Message{NewUserMessage("extract")}, entitySchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != `{"entities": [{"name": "Alice"}]}` {
+		t.Errorf("unexpected content %q", resp.Content)
+	}
+	if resp.Metadata[MetadataStructuredOutputAttempts] != 2 || resp.Metadata[MetadataStructuredOutputRepairs] != 1 {
+		t.Errorf("unexpected metadata %v", resp.Metadata)
+	}
+	if resp.TokensUsed.TotalTokens != 30 {
+		t.Errorf("expected usage of both attempts, got %+v", resp.TokensUsed)
+	}
+
+	repairPrompt := inner.calls[1][len(inner.calls[1])-1].Content
+	if !strings.Contains(repairPrompt, "$.entities[0].name should be string") {
+		t.Errorf("expected the validation error in the repair prompt, got %q", repairPrompt)
+	}
+}
+
+func TestStructuredOutputClientFallsBackToJSONRepair(t *testing.T) {
+	inner := &scriptedClient{responses: []string{
+		`{"entities": [{"name": "Alice"}`,
+		"```json\n{\"entities\": [{\"name\": \"Alice\"},]}\n```",
+	}}
+	client := NewStructuredOutputClient(inner, &StructuredOutputConfig{MaxRepairAttempts: 1})
+
+	resp, err := client.ChatWithStructuredOutput(context.Background(), []types.Message{NewUserMessage("extract")}, entitySchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Metadata[MetadataJSONRepaired] != true || resp.Metadata[MetadataStructuredOutputAttempts] != 2 {
+		t.Errorf("unexpected metadata %v", resp.Metadata)
+	}
+
+	inner = &scriptedClient{responses: []string{`not json`}}
+	client = NewStructuredOutputClient(inner, &StructuredOutputConfig{MaxRepairAttempts: 0, DisableJSONRepair: true})
+	if _, err := client.ChatWithStructuredOutput(context.Background(), []types.Message{NewUserMessage("extract")}, entitySchema); err == nil {
+		t.Error("expected an error when repair is disabled")
+	}
+}
+
+func TestValidateStructuredOutputGoType(t *testing.T) {
+	type entity struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	if _, err := validateStructuredOutput(`{"name": "Alice", "age": 30}`, &entity{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := validateStructuredOutput(`{"name": "Alice", "age": "thirty"}`, entity{}); err == nil {
+		t.Error("expected a type mismatch to be reported")
+	}
+	if _, err := validateStructuredOutput(`{"anything": true}`, "Return the entities as JSON"); err != nil {
+		t.Errorf("expected free-form schemas to only require JSON, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// WithTask returns a context carrying a task hint, such as "extract_nodes" or
+// "dedupe_edges", for the LLM requests made with it. The ingestion pipeline sets
+// the hint for each stage (see the usage package for the task names).
+func WithTask(ctx context.Context, task string) context.Context {
+	return context.WithValue(ctx, types.ContextKeyOperation, task)
+}
+
+// TaskFromContext returns the task hint of ctx, or "" if there is none.
+func TaskFromContext(ctx context.Context) string {
+	task, _ := ctx.Value(types.ContextKeyOperation).(string)
+	return task
+}
+
+// Router dispatches each request to the client configured for its task hint,
+// so that pipeline stages can use different models: for example a large model
+// for entity and edge extraction and a small, cheap model for deduplication
+// judgments and summaries. Requests without a hint, or with a hint that has no
+// route, go to the default client.
+//
+// Unlike RouterClient, which routes on user-supplied usage tags, Router routes
+// on the pipeline stage making the request.
+type Router struct {
+	defaultClient Client
+	routes        map[string]Client
+}
+
+// NewRouter creates a router sending unrouted requests to defaultClient.
+func NewRouter(defaultClient Client) *Router {
+	return &Router{
+		defaultClient: defaultClient,
+		routes:        make(map[string]Client),
+	}
+}
+
+// Route sends the requests of tasks to client. It returns the router for chaining.
+func (r *Router) Route(client Client, tasks ...string) *Router {
+	for _, task := range tasks {
+		r.routes[task] = client
+	}
+	return r
+}
+
+// ClientFor returns the client handling requests for task.
+func (r *Router) ClientFor(task string) Client {
+	if client, ok := r.routes[task]; ok {
+		return client
+	}
+	return r.defaultClient
+}
+
+// Chat implements the Client interface.
+func (r *Router) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return r.ClientFor(TaskFromContext(ctx)).Chat(ctx, messages)
+}
+
+// ChatWithStructuredOutput implements the Client interface.
+func (r *Router) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	return r.ClientFor(TaskFromContext(ctx)).ChatWithStructuredOutput(ctx, messages, schema)
+}
+
+// ChatWithTools implements the Client interface.
+func (r *Router) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return r.ClientFor(TaskFromContext(ctx)).ChatWithTools(ctx, messages, tools)
+}
+
+// Close closes the default client and every routed client once.
+func (r *Router) Close() error {
+	closed := make(map[Client]bool)
+	var errs []error
+	for _, client := range append([]Client{r.defaultClient}, r.routeClients()...) {
+		if closed[client] {
+			continue
+		}
+		closed[client] = true
+		if err := client.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to close routed clients: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// routeClients returns the clients of all routes.
+func (r *Router) routeClients() []Client {
+	clients := make([]Client, 0, len(r.routes))
+	for _, client := range r.routes {
+		clients = append(clients, client)
+	}
+	return clients
+}
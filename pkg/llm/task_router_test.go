@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// namedClient answers with its name and counts Close calls.
+type namedClient struct {
+	name     string
+	closed   int
+	closeErr error
+}
+
+func (c *namedClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return &types.Response{Content: c.name, Model: c.name}, nil
+}
+
+func (c *namedClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	return &types.Response{Content: c.name, Model: c.name}, nil
+}
+
+func (c *namedClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return &types.Response{Content: c.name, Model: c.name}, nil
+}
+
+func (c *namedClient) Close() error {
+	c.closed++
+	return c.closeErr
+}
+
+func TestRouterDispatchesOnTaskHint(t *testing.T) {
+	large := &namedClient{name: "large"}
+	small := &namedClient{name: "small"}
+	router := NewRouter(large).Route(small, "dedupe_nodes", "dedupe_edges")
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"no hint", context.Background(), "large"},
+		{"unrouted task", WithTask(context.Background(), "extract_nodes"), "large"},
+		{"routed task", WithTask(context.Background(), "dedupe_edges"), "small"},
+		{"innermost hint wins", WithTask(WithTask(context.Background(), "dedupe_nodes"), "extract_edges"), "large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := router.Chat(tt.ctx, nil)
+			if err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+			if resp.Content != tt.want {
+				t.Errorf("Chat() routed to %q, want %q", resp.Content, tt.want)
+			}
+
+			resp, err = router.ChatWithStructuredOutput(tt.ctx, nil, nil)
+			if err != nil {
+				t.Fatalf("ChatWithStructuredOutput() error = %v", err)
+			}
+			if resp.Content != tt.want {
+				t.Errorf("ChatWithStructuredOutput() routed to %q, want %q", resp.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouterClosesEachClientOnce(t *testing.T) {
+	large := &namedClient{name: "large"}
+	small := &namedClient{name: "small", closeErr: errors.New("boom")}
+	router := NewRouter(large).Route(small, "dedupe_nodes", "dedupe_edges").Route(large, "extract_nodes")
+
+	if err := router.Close(); err == nil {
+		t.Error("Close() should report the error of the small client")
+	}
+	if large.closed != 1 || small.closed != 1 {
+		t.Errorf("Close() closed large %d times and small %d times, want once each", large.closed, small.closed)
+	}
+}
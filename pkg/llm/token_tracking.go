@@ -195,6 +195,28 @@ func (c *TokenTrackingClient) ChatWithStructuredOutput(ctx context.Context, mess
 	return resp, nil
 }
 
+// ChatWithTools implements Client
+func (c *TokenTrackingClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	resp, err := c.client.ChatWithTools(ctx, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.TokensUsed != nil {
+		// Use model from response if available
+		model := resp.Model
+		if model == "" {
+			model = "unknown"
+		}
+
+		if err := c.tracker.AddUsage(ctx, resp.TokensUsed, model); err != nil {
+			fmt.Printf("Warning: Failed to log token usage: %v\n", err)
+		}
+	}
+
+	return resp, nil
+}
+
 // Close implements Client
 func (c *TokenTrackingClient) Close() error {
 	return c.client.Close()
@@ -0,0 +1,139 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// DefaultMaxToolRounds is the number of tool calling rounds RunTools allows
+// when none is given.
+const DefaultMaxToolRounds = 5
+
+// ToolHandler executes a tool call and returns its result for the model.
+type ToolHandler func(ctx context.Context, call types.ToolCall) (string, error)
+
+// RunTools sends messages to client offering tools, executes the tool calls the
+// model makes with handler and sends back their results, until the model
+// answers without calling tools. Handler errors are passed to the model as the
+// tool result so it can recover. It fails if the model is still calling tools
+// after maxRounds rounds (DefaultMaxToolRounds when not positive).
+//
+// For example, answering a question with graph search as a tool:
+//
+//	search := types.Tool{
+//		Name:        "search_graph",
+//		Description: "Search the knowledge graph for facts",
+//		Parameters: map[string]any{
+//			"type":       "object",
+//			"properties": map[string]any{"query": map[string]any{"type": "string"}},
+//			"required":   []string{"query"},
+//		},
+//	}
+//	resp, err := llm.RunTools(ctx, client, messages, []types.Tool{search}, handler, 0)
+func RunTools(ctx context.Context, client Client, messages []types.Message, tools []types.Tool, handler ToolHandler, maxRounds int) (*types.Response, error) {
+	if maxRounds <= 0 {
+		maxRounds = DefaultMaxToolRounds
+	}
+
+	conversation := make([]types.Message, len(messages))
+	copy(conversation, messages)
+
+	for round := 0; ; round++ {
+		resp, err := client.ChatWithTools(ctx, conversation, tools)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.ToolCalls) == 0 {
+			return resp, nil
+		}
+		if round >= maxRounds {
+			return nil, fmt.Errorf("model still calling tools after %d rounds", maxRounds)
+		}
+
+		conversation = append(conversation, types.Message{
+			Role:      RoleAssistant,
+			Content:   resp.Content,
+			ToolCalls: resp.ToolCalls,
+		})
+		for _, call := range resp.ToolCalls {
+			result, err := handler(ctx, call)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				result = fmt.Sprintf("Error: %v", err)
+			}
+			conversation = append(conversation, NewToolMessage(call.ID, result))
+		}
+	}
+}
+
+// toOpenAITools converts tools to go-openai function tools.
+func toOpenAITools(tools []types.Tool) []openai.Tool {
+	if len(tools) == 0 {
+		return nil
+	}
+	openaiTools := make([]openai.Tool, 0, len(tools))
+	for _, tool := range tools {
+		openaiTools = append(openaiTools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	return openaiTools
+}
+
+// toOpenAIToolCalls converts the tool calls of an assistant message.
+func toOpenAIToolCalls(calls []types.ToolCall) []openai.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	openaiCalls := make([]openai.ToolCall, 0, len(calls))
+	for _, call := range calls {
+		openaiCalls = append(openaiCalls, openai.ToolCall{
+			ID:   call.ID,
+			Type: openai.ToolTypeFunction,
+			Function: openai.FunctionCall{
+				Name:      call.Name,
+				Arguments: call.Arguments,
+			},
+		})
+	}
+	return openaiCalls
+}
+
+// openAIToolResponse converts a chat completion that may contain tool calls.
+func openAIToolResponse(resp openai.ChatCompletionResponse) (*types.Response, error) {
+	if len(resp.Choices) == 0 {
+		return nil, NewEmptyResponseError("no choices returned from API")
+	}
+
+	choice := resp.Choices[0]
+	response := &types.Response{
+		Content:      choice.Message.Content,
+		FinishReason: string(choice.FinishReason),
+		Model:        resp.Model,
+	}
+	for _, call := range choice.Message.ToolCalls {
+		response.ToolCalls = append(response.ToolCalls, types.ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	if resp.Usage.TotalTokens > 0 {
+		response.TokensUsed = &types.TokenUsage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// toolScriptClient answers ChatWithTools with the scripted responses in order
+// and records the conversation of each call.
+type toolScriptClient struct {
+	responses []*types.Response
+	calls     [][]types.Message
+}
+
+func (c *toolScriptClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return nil, errors.New("unexpected chat")
+}
+
+func (c *toolScriptClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	return nil, errors.New("unexpected structured output")
+}
+
+func (c *toolScriptClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	resp := c.responses[len(c.calls)]
+	c.calls = append(c.calls, messages)
+	return resp, nil
+}
+
+func (c *toolScriptClient) Close() error {
+	return nil
+}
+
+var searchTool = types.Tool{
+	Name:        "search_graph",
+	Description: "Search the knowledge graph for facts",
+	Parameters: map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"query": map[string]any{"type": "string"}},
+	},
+}
+
+func TestRunToolsExecutesCallsUntilAnswer(t *testing.T) {
+	client := &toolScriptClient{responses: []*types.Response{
+		{ToolCalls: []types.ToolCall{
+			{ID: "call_1", Name: "search_graph", Arguments: `{"query":"Alice"}`},
+			{ID: "call_2", Name: "search_graph", Arguments: `{"query":"broken"}`},
+		}},
+		{Content: "Alice works at Acme."},
+	}}
+	handler := func(ctx context.Context, call types.ToolCall) (string, error) {
+		if strings.Contains(call.Arguments, "broken") {
+			return "", errors.New("search failed")
+		}
+		return "Alice WORKS_AT Acme", nil
+	}
+
+	resp, err := RunTools(context.Background(), client, []types.Message{NewUserMessage("Where does Alice work?")}, []types.Tool{searchTool}, handler, 0)
+	if err != nil {
+		t.Fatalf("RunTools() error = %v", err)
+	}
+	if resp.Content != "Alice works at Acme." {
+		t.Errorf("RunTools() = %q, want the final answer", resp.Content)
+	}
+
+	second := client.calls[1]
+	if len(second) != 4 {
+		t.Fatalf("second request has %d messages, want question, tool calls and two results", len(second))
+	}
+	if len(second[1].ToolCalls) != 2 || second[1].Role != RoleAssistant {
+		t.Errorf("second request should replay the assistant tool calls, got %+v", second[1])
+	}
+	if second[2].Role != RoleTool || second[2].ToolCallID != "call_1" || second[2].Content != "Alice WORKS_AT Acme" {
+		t.Errorf("first tool result = %+v", second[2])
+	}
+	if second[3].ToolCallID != "call_2" || !strings.Contains(second[3].Content, "search failed") {
+		t.Errorf("failed tool call should be reported to the model, got %+v", second[3])
+	}
+}
+
+func TestRunToolsStopsAfterMaxRounds(t *testing.T) {
+	loop := &types.Response{ToolCalls: []types.ToolCall{{ID: "call", Name: "search_graph", Arguments: "{}"}}}
+	client := &toolScriptClient{responses: []*types.Response{loop, loop, loop}}
+	handler := func(ctx context.Context, call types.ToolCall) (string, error) { return "nothing", nil }
+
+	if _, err := RunTools(context.Background(), client, []types.Message{NewUserMessage("?")}, []types.Tool{searchTool}, handler, 2); err == nil {
+		t.Error("RunTools() should fail when the model keeps calling tools")
+	}
+	if len(client.calls) != 3 {
+		t.Errorf("RunTools() made %d requests, want 3", len(client.calls))
+	}
+}
+
+func TestOpenAIGenericClientChatWithTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Tools []struct {
+				Type     string `json:"type"`
+				Function struct {
+					Name string `json:"name"`
+				} `json:"function"`
+			} `json:"tools"`
+			Messages []map[string]any `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if len(req.Tools) != 1 || req.Tools[0].Type != "function" || req.Tools[0].Function.Name != "search_graph" {
+			t.Errorf("unexpected tools %+v", req.Tools)
+		}
+		if last := req.Messages[len(req.Messages)-1]; last["role"] != "tool" || last["tool_call_id"] != "call_0" {
+			t.Errorf("expected the tool result as last message, got %v", last)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"model":"gpt-4o-mini","choices":[{"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"search_graph","arguments":"{\"query\":\"Bob\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":20,"completion_tokens":8,"total_tokens":28}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewOpenAIGenericClient(&LLMConfig{APIKey: "test-key", Model: "gpt-4o-mini", BaseURL: server.URL + "/v1"})
+	if err != nil {
+		t.Fatalf("NewOpenAIGenericClient() error = %v", err)
+	}
+
+	messages := []types.Message{
+		NewUserMessage("Who knows Alice?"),
+		{Role: RoleAssistant, ToolCalls: []types.ToolCall{{ID: "call_0", Name: "search_graph", Arguments: `{"query":"Alice"}`}}},
+		NewToolMessage("call_0", "Alice KNOWS Bob"),
+	}
+	resp, err := client.ChatWithTools(context.Background(), messages, []types.Tool{searchTool})
+	if err != nil {
+		t.Fatalf("ChatWithTools() error = %v", err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "call_1" || resp.ToolCalls[0].Arguments != `{"query":"Bob"}` {
+		t.Errorf("unexpected tool calls %+v", resp.ToolCalls)
+	}
+	if resp.FinishReason != "tool_calls" || resp.TokensUsed == nil || resp.TokensUsed.TotalTokens != 28 {
+		t.Errorf("unexpected response metadata %+v", resp)
+	}
+}
+
+func TestOllamaClientChatWithTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if len(req.Tools) != 1 || req.Tools[0].Function.Name != "search_graph" {
+			t.Errorf("unexpected tools %+v", req.Tools)
+		}
+		if last := req.Messages[len(req.Messages)-1]; last.Role != "tool" || last.ToolName != "search_graph" {
+			t.Errorf("expected the tool result named after its call, got %+v", last)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"model": "llama3.1",
+			"message": map[string]any{
+				"role":       "assistant",
+				"content":    "",
+				"tool_calls": []any{map[string]any{"function": map[string]any{"name": "search_graph", "arguments": map[string]any{"query": "Bob"}}}},
+			},
+			"done": true,
+		})
+	}))
+	defer server.Close()
+
+	client := NewOllamaClient(NewLLMConfig().WithBaseURL(server.URL).WithModel("llama3.1"))
+	messages := []types.Message{
+		NewUserMessage("Who knows Alice?"),
+		{Role: RoleAssistant, ToolCalls: []types.ToolCall{{ID: "call_0", Name: "search_graph", Arguments: `{"query":"Alice"}`}}},
+		NewToolMessage("call_0", "Alice KNOWS Bob"),
+	}
+	resp, err := client.ChatWithTools(context.Background(), messages, []types.Tool{searchTool})
+	if err != nil {
+		t.Fatalf("ChatWithTools() error = %v", err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "search_graph" || resp.ToolCalls[0].Arguments != `{"query":"Bob"}` {
+		t.Errorf("unexpected tool calls %+v", resp.ToolCalls)
+	}
+}
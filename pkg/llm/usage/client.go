@@ -0,0 +1,78 @@
+package usage
+
+import (
+	"context"
+
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// TrackingClient wraps an llm.Client and records the usage of every successful
+// request in a Tracker.
+type TrackingClient struct {
+	client  llm.Client
+	tracker *Tracker
+}
+
+// NewTrackingClient creates a usage tracking wrapper. A nil tracker creates a new one.
+func NewTrackingClient(client llm.Client, tracker *Tracker) *TrackingClient {
+	if tracker == nil {
+		tracker = NewTracker()
+	}
+	return &TrackingClient{
+		client:  client,
+		tracker: tracker,
+	}
+}
+
+// Chat implements the llm.Client interface.
+func (c *TrackingClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	resp, err := c.client.Chat(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	c.record(ctx, resp)
+	return resp, nil
+}
+
+// ChatWithStructuredOutput implements the llm.Client interface.
+func (c *TrackingClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	resp, err := c.client.ChatWithStructuredOutput(ctx, messages, schema)
+	if err != nil {
+		return nil, err
+	}
+	c.record(ctx, resp)
+	return resp, nil
+}
+
+// ChatWithTools implements the llm.Client interface.
+func (c *TrackingClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	resp, err := c.client.ChatWithTools(ctx, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	c.record(ctx, resp)
+	return resp, nil
+}
+
+// record adds the usage reported by resp to the tracker.
+func (c *TrackingClient) record(ctx context.Context, resp *types.Response) {
+	if resp == nil {
+		return
+	}
+	model := resp.Model
+	if model == "" {
+		model = "unknown"
+	}
+	c.tracker.Record(ctx, model, resp.TokensUsed)
+}
+
+// Tracker returns the tracker the client records into.
+func (c *TrackingClient) Tracker() *Tracker {
+	return c.tracker
+}
+
+// Close implements the llm.Client interface.
+func (c *TrackingClient) Close() error {
+	return c.client.Close()
+}
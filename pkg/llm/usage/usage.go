@@ -0,0 +1,186 @@
+// Package usage accumulates LLM token usage and estimated cost per episode,
+// group and operation type.
+//
+// Requests are attributed using values stored in the context: wrap the LLM
+// client with NewTrackingClient, and tag the context of each call with
+// WithEpisode and WithOperation.
+package usage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/soundprediction/go-predicato/pkg/cost"
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// Operation types of the ingestion pipeline. They double as llm.Router task hints.
+const (
+	OperationExtractNodes       = "extract_nodes"
+	OperationDedupeNodes        = "dedupe_nodes"
+	OperationExtractAttributes  = "extract_attributes"
+	OperationExtractEdges       = "extract_edges"
+	OperationDedupeEdges        = "dedupe_edges"
+	OperationExtractEdgeDates   = "extract_edge_dates"
+	OperationInvalidateEdges    = "invalidate_edges"
+	OperationBuildCommunities   = "build_communities"
+	OperationRefreshSummaries   = "refresh_summaries"
+	OperationReclassifyEntities = "reclassify_entities"
+	OperationRerank             = "rerank"
+	OperationExpandQuery        = "expand_query"
+	OperationAnswer             = "answer"
+	OperationRedact             = "redact"
+	// OperationOther is used for requests without an operation type
+	OperationOther = "other"
+)
+
+// WithOperation returns a context attributing LLM requests to operation. The
+// operation is also the llm.Router task hint of the requests.
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return llm.WithTask(ctx, operation)
+}
+
+// WithEpisode returns a context attributing LLM requests to an episode and its group.
+func WithEpisode(ctx context.Context, episodeID, groupID string) context.Context {
+	ctx = context.WithValue(ctx, types.ContextKeyEpisodeID, episodeID)
+	return context.WithValue(ctx, types.ContextKeyGroupID, groupID)
+}
+
+// OperationFromContext returns the operation type of ctx, or OperationOther.
+func OperationFromContext(ctx context.Context) string {
+	if task := llm.TaskFromContext(ctx); task != "" {
+		return task
+	}
+	return OperationOther
+}
+
+// usageKey identifies the requests aggregated together by a Tracker.
+type usageKey struct {
+	episodeID string
+	groupID   string
+	operation string
+	model     string
+}
+
+// Tracker accumulates token usage and estimated cost in memory. It is safe for
+// concurrent use.
+type Tracker struct {
+	mu             sync.Mutex
+	usage          map[usageKey]*types.LLMUsage
+	costCalculator *cost.CostCalculator
+}
+
+// NewTracker creates a new usage tracker using the default pricing.
+func NewTracker() *Tracker {
+	return NewTrackerWithCalculator(cost.NewCostCalculator())
+}
+
+// NewTrackerWithCalculator creates a new usage tracker using custom pricing.
+func NewTrackerWithCalculator(calculator *cost.CostCalculator) *Tracker {
+	return &Tracker{
+		usage:          make(map[usageKey]*types.LLMUsage),
+		costCalculator: calculator,
+	}
+}
+
+// Record adds one request of model using tokens, attributed to the episode,
+// group and operation stored in ctx.
+func (t *Tracker) Record(ctx context.Context, model string, tokens *types.TokenUsage) {
+	key := usageKey{
+		operation: OperationFromContext(ctx),
+		model:     model,
+	}
+	if v, ok := ctx.Value(types.ContextKeyEpisodeID).(string); ok {
+		key.episodeID = v
+	}
+	if v, ok := ctx.Value(types.ContextKeyGroupID).(string); ok {
+		key.groupID = v
+	}
+
+	entry := types.LLMUsage{Requests: 1}
+	if tokens != nil {
+		entry.PromptTokens = tokens.PromptTokens
+		entry.CompletionTokens = tokens.CompletionTokens
+		entry.TotalTokens = tokens.TotalTokens
+		entry.CostUSD = t.costCalculator.CalculateCost(model, tokens.PromptTokens, tokens.CompletionTokens)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	total, ok := t.usage[key]
+	if !ok {
+		total = &types.LLMUsage{}
+		t.usage[key] = total
+	}
+	addUsage(total, entry)
+}
+
+// Summary returns the usage of all recorded requests.
+func (t *Tracker) Summary() *types.LLMUsageSummary {
+	return t.summarize(func(usageKey) bool { return true })
+}
+
+// EpisodeSummary returns the usage of the requests made for episodeID.
+func (t *Tracker) EpisodeSummary(episodeID string) *types.LLMUsageSummary {
+	return t.summarize(func(key usageKey) bool { return key.episodeID == episodeID })
+}
+
+// GroupSummary returns the usage of the requests made for groupID.
+func (t *Tracker) GroupSummary(groupID string) *types.LLMUsageSummary {
+	return t.summarize(func(key usageKey) bool { return key.groupID == groupID })
+}
+
+// Reset discards all recorded usage.
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage = make(map[usageKey]*types.LLMUsage)
+}
+
+// summarize aggregates the usage of the keys matching include.
+func (t *Tracker) summarize(include func(usageKey) bool) *types.LLMUsageSummary {
+	summary := &types.LLMUsageSummary{
+		ByEpisode:   make(map[string]types.LLMUsage),
+		ByGroup:     make(map[string]types.LLMUsage),
+		ByOperation: make(map[string]types.LLMUsage),
+		ByModel:     make(map[string]types.LLMUsage),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, usage := range t.usage {
+		if !include(key) {
+			continue
+		}
+		addUsage(&summary.Total, *usage)
+		addToBreakdown(summary.ByOperation, key.operation, *usage)
+		addToBreakdown(summary.ByModel, key.model, *usage)
+		if key.episodeID != "" {
+			addToBreakdown(summary.ByEpisode, key.episodeID, *usage)
+		}
+		if key.groupID != "" {
+			addToBreakdown(summary.ByGroup, key.groupID, *usage)
+		}
+	}
+
+	return summary
+}
+
+// addToBreakdown adds usage to the entry of breakdown at name.
+func addToBreakdown(breakdown map[string]types.LLMUsage, name string, usage types.LLMUsage) {
+	total := breakdown[name]
+	addUsage(&total, usage)
+	breakdown[name] = total
+}
+
+// addUsage adds usage to total.
+func addUsage(total *types.LLMUsage, usage types.LLMUsage) {
+	total.Requests += usage.Requests
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+	total.CostUSD += usage.CostUSD
+}
@@ -0,0 +1,102 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	resp *types.Response
+	err  error
+}
+
+func (f *fakeClient) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return f.resp, f.err
+}
+
+func (f *fakeClient) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	return f.resp, f.err
+}
+
+func (f *fakeClient) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return f.resp, f.err
+}
+
+func (f *fakeClient) Close() error {
+	return nil
+}
+
+func TestTrackerAttributesUsageFromContext(t *testing.T) {
+	tracker := NewTracker()
+
+	ctx := WithEpisode(context.Background(), "ep-1", "group-a")
+	tracker.Record(WithOperation(ctx, OperationExtractNodes), "gpt-4o-mini",
+		&types.TokenUsage{PromptTokens: 1_000_000, CompletionTokens: 0, TotalTokens: 1_000_000})
+	tracker.Record(WithOperation(ctx, OperationDedupeEdges), "gpt-4o-mini",
+		&types.TokenUsage{PromptTokens: 0, CompletionTokens: 1_000_000, TotalTokens: 1_000_000})
+	tracker.Record(WithEpisode(context.Background(), "ep-2", "group-b"), "gpt-4o-mini",
+		&types.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15})
+
+	summary := tracker.Summary()
+	assert.Equal(t, 3, summary.Total.Requests)
+	assert.Equal(t, 2_000_015, summary.Total.TotalTokens)
+	assert.InDelta(t, 0.75, summary.Total.CostUSD, 1e-4)
+
+	assert.InDelta(t, 0.15, summary.ByOperation[OperationExtractNodes].CostUSD, 1e-9)
+	assert.InDelta(t, 0.60, summary.ByOperation[OperationDedupeEdges].CostUSD, 1e-9)
+	assert.Equal(t, 1, summary.ByOperation[OperationOther].Requests)
+	assert.Equal(t, 2, summary.ByGroup["group-a"].Requests)
+	assert.Equal(t, 15, summary.ByEpisode["ep-2"].TotalTokens)
+	assert.Equal(t, 3, summary.ByModel["gpt-4o-mini"].Requests)
+
+	episode := tracker.EpisodeSummary("ep-1")
+	assert.Equal(t, 2, episode.Total.Requests)
+	assert.Len(t, episode.ByOperation, 2)
+	assert.NotContains(t, episode.ByEpisode, "ep-2")
+
+	group := tracker.GroupSummary("group-b")
+	assert.Equal(t, 15, group.Total.TotalTokens)
+
+	tracker.Reset()
+	assert.Zero(t, tracker.Summary().Total.Requests)
+}
+
+func TestTrackingClientRecordsSuccessfulRequests(t *testing.T) {
+	base := &fakeClient{resp: &types.Response{
+		Content:    "ok",
+		Model:      "gpt-4o",
+		TokensUsed: &types.TokenUsage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120},
+	}}
+	client := NewTrackingClient(base, nil)
+
+	ctx := WithOperation(context.Background(), OperationExtractEdges)
+	_, err := client.Chat(ctx, nil)
+	require.NoError(t, err)
+	_, err = client.ChatWithStructuredOutput(ctx, nil, map[string]any{})
+	require.NoError(t, err)
+
+	base.err = errors.New("boom")
+	_, err = client.Chat(ctx, nil)
+	require.Error(t, err)
+
+	summary := client.Tracker().Summary()
+	assert.Equal(t, 2, summary.ByOperation[OperationExtractEdges].Requests)
+	assert.Equal(t, 240, summary.ByModel["gpt-4o"].TotalTokens)
+	assert.Greater(t, summary.Total.CostUSD, 0.0)
+}
+
+func TestTrackingClientCountsRequestsWithoutUsage(t *testing.T) {
+	client := NewTrackingClient(&fakeClient{resp: &types.Response{Content: "ok"}}, nil)
+
+	_, err := client.Chat(context.Background(), nil)
+	require.NoError(t, err)
+
+	summary := client.Tracker().Summary()
+	assert.Equal(t, 1, summary.ByModel["unknown"].Requests)
+	assert.Zero(t, summary.Total.TotalTokens)
+}
@@ -63,3 +63,15 @@ func EstimateTokensFromMessages(messages []types.Message) int {
 	}
 	return totalTokens
 }
+
+// PromptTokenLimit returns the estimated prompt tokens that fit in a context
+// window of contextWindow tokens, leaving room for the completion (a quarter of
+// the window, at most DefaultMaxTokens) and a margin for estimation error. It
+// returns 0, meaning no limit, when contextWindow is not positive.
+func PromptTokenLimit(contextWindow int) int {
+	if contextWindow <= 0 {
+		return 0
+	}
+	limit := contextWindow - min(contextWindow/4, DefaultMaxTokens)
+	return max(limit*9/10, 1)
+}
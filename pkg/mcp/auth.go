@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKey is a bearer token accepted by the HTTP transports. Once a server has
+// API keys, requests without a valid key are rejected.
+type APIKey struct {
+	// Name identifies the key in logs and sessions.
+	Name string `json:"name"`
+	// Key is the bearer token.
+	Key string `json:"key"`
+	// Groups are the graph groups the key may access; empty allows every
+	// group. The tools enforce them with the key of KeyFromContext.
+	Groups []string `json:"groups,omitempty"`
+	// RequestsPerMinute caps the messages sent with the key. Zero is
+	// unlimited.
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+}
+
+// apiKey is an API key with its rate limit.
+type apiKey struct {
+	*APIKey
+
+	mu         sync.Mutex
+	requests   float64
+	lastRefill time.Time
+}
+
+type keyContextKey struct{}
+
+// KeyFromContext returns the API key a request was authenticated with, or
+// nil over stdio or when the server has no API keys.
+func KeyFromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(keyContextKey{}).(*APIKey)
+	return key
+}
+
+// LoadAPIKeys reads API keys from a JSON file holding an array of APIKey.
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys %s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// SetAPIKeys makes the HTTP transports require one of keys as a bearer
+// token. Keys must have distinct names and tokens.
+func (s *Server) SetAPIKeys(keys []APIKey) error {
+	byToken := make(map[[sha256.Size]byte]*apiKey, len(keys))
+	names := make(map[string]bool, len(keys))
+	for i := range keys {
+		key := keys[i]
+		if key.Name == "" || key.Key == "" {
+			return fmt.Errorf("API key %d has no name or key", i)
+		}
+		if names[key.Name] {
+			return fmt.Errorf("duplicate API key name %q", key.Name)
+		}
+		names[key.Name] = true
+		hash := sha256.Sum256([]byte(key.Key))
+		if byToken[hash] != nil {
+			return fmt.Errorf("API key %q has the same key as %q", key.Name, byToken[hash].Name)
+		}
+		byToken[hash] = &apiKey{APIKey: &key, requests: float64(key.RequestsPerMinute), lastRefill: time.Now()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = byToken
+	return nil
+}
+
+// authenticate returns the API key of the bearer token of r. It returns nil
+// and true when the server has no API keys, and false when the token is
+// missing or unknown.
+func (s *Server) authenticate(r *http.Request) (*apiKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.keys) == 0 {
+		return nil, true
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, false
+	}
+	// Keys are looked up by hash, so that the lookup does not compare the
+	// token with the keys byte by byte
+	key := s.keys[sha256.Sum256([]byte(strings.TrimSpace(token)))]
+	return key, key != nil
+}
+
+// allow takes a request from the budget of the key, returning how long to
+// wait when it is exhausted. Budgets refill continuously over each minute.
+func (k *apiKey) allow() (time.Duration, bool) {
+	if k == nil || k.RequestsPerMinute <= 0 {
+		return 0, true
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	perMinute := float64(k.RequestsPerMinute)
+	k.requests = min(perMinute, k.requests+now.Sub(k.lastRefill).Minutes()*perMinute)
+	k.lastRefill = now
+	if k.requests < 1 {
+		return time.Duration((1 - k.requests) / perMinute * float64(time.Minute)), false
+	}
+	k.requests--
+	return 0, true
+}
+
+// withAuth authenticates and rate limits the requests of next, adding their
+// API key to their context.
+func (h *httpHandler) withAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, ok := h.server.authenticate(r)
+		if !ok {
+			h.server.metrics.observeRejected("unauthorized")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+			http.Error(w, "missing or invalid API key", http.StatusUnauthorized)
+			return
+		}
+		if key == nil {
+			next(w, r)
+			return
+		}
+
+		// Only messages count against the budget, not opening event streams
+		if r.Method == http.MethodPost {
+			if wait, ok := key.allow(); !ok {
+				h.server.metrics.observeRejected("rate_limited")
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), keyContextKey{}, key.APIKey)))
+	}
+}
+
+// keyName returns the name of the API key of ctx, or "" without one.
+func keyName(ctx context.Context) string {
+	if key := KeyFromContext(ctx); key != nil {
+		return key.Name
+	}
+	return ""
+}
@@ -0,0 +1,360 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// SessionHeader is the header carrying the session ID of the streamable
+	// HTTP transport.
+	SessionHeader = "Mcp-Session-Id"
+
+	// maxMessageSize limits the size of the messages POSTed by clients
+	maxMessageSize = 32 << 20
+
+	// keepAliveInterval is the interval of the comments keeping SSE streams
+	// open through proxies
+	keepAliveInterval = 30 * time.Second
+
+	// sessionIdleTimeout is how long a session of the streamable HTTP
+	// transport lasts without requests
+	sessionIdleTimeout = time.Hour
+
+	// maxSessions limits the sessions of the streamable HTTP transport; the
+	// least recently used session is ended to open another
+	maxSessions = 10000
+)
+
+// streamableSession is a session of the streamable HTTP transport.
+type streamableSession struct {
+	// key is the name of the API key that opened the session
+	key      string
+	lastUsed time.Time
+}
+
+// sseSession is a client connected to the HTTP+SSE transport.
+type sseSession struct {
+	ctx      context.Context
+	key      string
+	messages chan []byte
+}
+
+// route is an HTTP route served besides MCP.
+type route struct {
+	pattern string
+	handler http.Handler
+}
+
+// httpHandler serves MCP over HTTP.
+type httpHandler struct {
+	server *Server
+	mux    *http.ServeMux
+
+	mu          sync.Mutex
+	sessions    map[string]*streamableSession
+	sseSessions map[string]*sseSession
+}
+
+// Handler returns an HTTP handler serving MCP on two transports:
+//
+//   - the streamable HTTP transport at /mcp, where each message is POSTed
+//     and its response returned in the body of the HTTP response
+//   - the HTTP+SSE transport of older clients at /sse, which streams an
+//     endpoint event with the URL to POST messages to, then the responses as
+//     message events
+//
+// Requests from browser pages of other hosts are rejected, so that web pages
+// cannot reach a server listening on localhost. With API keys, requests must
+// carry one as a bearer token, and sessions can only be used with the key
+// that opened them.
+func (s *Server) Handler() http.Handler {
+	h := &httpHandler{
+		server:      s,
+		mux:         http.NewServeMux(),
+		sessions:    make(map[string]*streamableSession),
+		sseSessions: make(map[string]*sseSession),
+	}
+	h.mux.HandleFunc("/mcp", h.withAuth(h.handleStreamable))
+	h.mux.HandleFunc("GET /sse", h.withAuth(h.handleSSE))
+	h.mux.HandleFunc("POST /messages", h.withAuth(h.handleSSEMessage))
+	s.mu.RLock()
+	for _, route := range s.routes {
+		h.mux.Handle(route.pattern, route.handler)
+	}
+	s.mu.RUnlock()
+	return h
+}
+
+// Handle adds a route served by the handlers Handler returns afterwards,
+// such as health probes or MetricsHandler. The route is served without API
+// keys, so that probes and scrapers need none.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = append(s.routes, route{pattern: pattern, handler: handler})
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !allowedOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// handleStreamable serves the streamable HTTP transport. A session ID is
+// returned with the response to initialize, and other requests must carry
+// it; requests carrying an unknown, terminated or expired session ID are
+// rejected, so that clients initialize again.
+func (h *httpHandler) handleStreamable(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.Header.Get(SessionHeader)
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodDelete:
+		if !h.endSession(sessionID, keyName(r.Context())) {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		// The server sends no requests or notifications of its own, so it
+		// offers no stream on GET
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	message, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read message: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case isInitialize(message):
+		w.Header().Set(SessionHeader, h.openSession(keyName(r.Context())))
+	case sessionID == "":
+		http.Error(w, "missing session ID", http.StatusBadRequest)
+		return
+	case !h.useSession(sessionID, keyName(r.Context())):
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+
+	response := h.server.HandleMessage(r.Context(), message)
+	if response == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response)
+}
+
+// handleSSE opens the event stream of a client of the HTTP+SSE transport.
+func (h *httpHandler) handleSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sessionID := newSessionID()
+	session := &sseSession{ctx: r.Context(), key: keyName(r.Context()), messages: make(chan []byte, 16)}
+	h.mu.Lock()
+	h.sseSessions[sessionID] = session
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.sseSessions, sessionID)
+		h.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// The endpoint is relative to the stream, so that it also works when the
+	// handler is mounted under a prefix
+	fmt.Fprintf(w, "event: endpoint\ndata: messages?sessionId=%s\n\n", sessionID)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case message := <-session.messages:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", message)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// handleSSEMessage handles a message POSTed by a client of the HTTP+SSE
+// transport, sending the response on its event stream.
+func (h *httpHandler) handleSSEMessage(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	session := h.sseSessions[r.URL.Query().Get("sessionId")]
+	h.mu.Unlock()
+	if session == nil || session.key != keyName(r.Context()) {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+
+	message, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read message: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+
+	// Tools run as long as the stream is open, not as long as this request
+	go func() {
+		response := h.server.HandleMessage(session.ctx, message)
+		if response == nil {
+			return
+		}
+		select {
+		case session.messages <- response:
+		case <-session.ctx.Done():
+		}
+	}()
+}
+
+// openSession opens a session of the streamable HTTP transport with the API
+// key named key, ending the expired sessions and, at maxSessions, the least
+// recently used one.
+func (h *httpHandler) openSession(key string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	var oldestID string
+	for id, session := range h.sessions {
+		if now.Sub(session.lastUsed) > sessionIdleTimeout {
+			delete(h.sessions, id)
+		} else if oldestID == "" || session.lastUsed.Before(h.sessions[oldestID].lastUsed) {
+			oldestID = id
+		}
+	}
+	if len(h.sessions) >= maxSessions {
+		delete(h.sessions, oldestID)
+	}
+
+	sessionID := newSessionID()
+	h.sessions[sessionID] = &streamableSession{key: key, lastUsed: now}
+	return sessionID
+}
+
+// useSession reports whether sessionID is an unexpired session of the
+// streamable HTTP transport opened with the API key named key, keeping it
+// open for another sessionIdleTimeout.
+func (h *httpHandler) useSession(sessionID, key string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	session, ok := h.sessions[sessionID]
+	if !ok || session.key != key {
+		return false
+	}
+	now := time.Now()
+	if now.Sub(session.lastUsed) > sessionIdleTimeout {
+		delete(h.sessions, sessionID)
+		return false
+	}
+	session.lastUsed = now
+	return true
+}
+
+// endSession terminates a session of the streamable HTTP transport opened
+// with the API key named key, reporting whether it existed.
+func (h *httpHandler) endSession(sessionID, key string) bool {
+	if !h.useSession(sessionID, key) {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.sessions, sessionID)
+	return true
+}
+
+// ListenAndServe serves MCP over HTTP on addr until ctx is done.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	server := &http.Server{Addr: addr, Handler: s.Handler()}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("failed to serve MCP over HTTP: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		// SSE streams stay open until their clients leave, so close them
+		// when the graceful shutdown times out
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			server.Close()
+		}
+		return ctx.Err()
+	}
+}
+
+// isInitialize reports whether message is an initialize request.
+func isInitialize(message []byte) bool {
+	var request Request
+	return json.Unmarshal(message, &request) == nil && request.Method == "initialize"
+}
+
+// newSessionID returns a random session ID.
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// allowedOrigin reports whether the Origin of r, if any, is the host of r or
+// a loopback address.
+func allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	requestHost, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		requestHost = r.Host
+	}
+	return host == requestHost
+}
@@ -0,0 +1,355 @@
+// Package mcp serves tools over the Model Context Protocol, so that MCP
+// clients such as Claude Desktop or Cursor can call them. Messages are
+// JSON-RPC 2.0, exchanged over stdio with ServeStdio or over HTTP with
+// Handler, which serves both the streamable HTTP transport and the older
+// HTTP+SSE transport.
+//
+// The input schema of each tool is generated from the Go struct of its
+// arguments:
+//
+//	server := mcp.NewServer("predicato", "1.0.0", logger)
+//	mcp.AddTool(server, "add_memory", "Add an episode to memory.",
+//		func(ctx context.Context, input *AddMemoryRequest) (*ToolResponse, error) {
+//			...
+//		})
+//	err := server.ServeStdio(ctx, os.Stdin, os.Stdout)
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// LatestProtocolVersion is the latest MCP protocol version supported.
+const LatestProtocolVersion = "2025-06-18"
+
+// ProtocolVersions are the MCP protocol versions supported, latest first.
+var ProtocolVersions = []string{LatestProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// ToolHandler calls a tool with its JSON arguments, returning its result.
+type ToolHandler func(ctx context.Context, arguments json.RawMessage) (any, error)
+
+// Tool is a tool served by a Server.
+type Tool struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	InputSchema *Schema `json:"inputSchema"`
+
+	handler ToolHandler
+}
+
+// Server dispatches MCP requests to its tools.
+type Server struct {
+	name    string
+	version string
+	logger  *slog.Logger
+
+	mu     sync.RWMutex
+	tools  []*Tool
+	keys   map[[sha256.Size]byte]*apiKey
+	routes []route
+
+	metrics metrics
+}
+
+// NewServer creates a server reporting name and version to clients. A nil
+// logger discards the logs.
+func NewServer(name, version string, logger *slog.Logger) *Server {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	return &Server{name: name, version: version, logger: logger}
+}
+
+// AddTool adds a tool calling handler with its arguments decoded into In,
+// and returns it so that its generated input schema can be refined, for
+// example with an enum. The result of handler is returned to the client as
+// JSON text; an error is returned as a tool error the model can see.
+func AddTool[In, Out any](s *Server, name, description string, handler func(ctx context.Context, input *In) (Out, error)) *Tool {
+	tool := &Tool{Name: name, Description: description, InputSchema: SchemaFor[In]()}
+	s.AddToolHandler(tool, func(ctx context.Context, arguments json.RawMessage) (any, error) {
+		input := new(In)
+		if len(arguments) > 0 && string(arguments) != "null" {
+			if err := json.Unmarshal(arguments, input); err != nil {
+				return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid arguments for tool %s: %v", name, err)}
+			}
+		}
+		return handler(ctx, input)
+	})
+	return tool
+}
+
+// AddToolHandler adds a tool calling handler with its raw arguments,
+// replacing any tool of the same name.
+func (s *Server) AddToolHandler(tool *Tool, handler ToolHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tool.handler = handler
+	s.tools = slices.DeleteFunc(s.tools, func(t *Tool) bool { return t.Name == tool.Name })
+	s.tools = append(s.tools, tool)
+}
+
+// Tools returns the tools of the server, in the order they were added.
+func (s *Server) Tools() []*Tool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.tools)
+}
+
+// tool returns the tool named name, or nil.
+func (s *Server) tool(name string) *Tool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, tool := range s.tools {
+		if tool.Name == name {
+			return tool
+		}
+	}
+	return nil
+}
+
+// Request is a JSON-RPC request, or a notification when it has no ID.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// isNotification reports whether the request expects no response.
+func (r *Request) isNotification() bool {
+	return len(r.ID) == 0
+}
+
+// Response is a JSON-RPC response.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// Content is an item of the content of a tool result.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// CallToolResult is the result of a tools/call request.
+type CallToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// HandleMessage handles a JSON-RPC message, a single message or a batch,
+// and returns the response to send back, or nil when there is none.
+func (s *Server) HandleMessage(ctx context.Context, message []byte) []byte {
+	message = bytes.TrimSpace(message)
+	if len(message) > 0 && message[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(message, &batch); err != nil {
+			return marshalResponse(errorResponse(nil, CodeParseError, fmt.Sprintf("failed to parse batch: %v", err)))
+		}
+		var responses []*Response
+		for _, raw := range batch {
+			if response := s.handle(ctx, raw); response != nil {
+				responses = append(responses, response)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		data, err := json.Marshal(responses)
+		if err != nil {
+			return marshalResponse(errorResponse(nil, CodeInternalError, fmt.Sprintf("failed to marshal responses: %v", err)))
+		}
+		return data
+	}
+
+	if response := s.handle(ctx, message); response != nil {
+		return marshalResponse(response)
+	}
+	return nil
+}
+
+// handle handles a single JSON-RPC message. Responses sent by the client
+// are ignored, since the server sends no requests.
+func (s *Server) handle(ctx context.Context, message []byte) *Response {
+	var request Request
+	if err := json.Unmarshal(message, &request); err != nil {
+		return errorResponse(nil, CodeParseError, fmt.Sprintf("failed to parse message: %v", err))
+	}
+	if request.Method == "" {
+		if request.isNotification() {
+			return errorResponse(nil, CodeInvalidRequest, "message has no method")
+		}
+		return nil
+	}
+
+	result, err := s.dispatch(ctx, &request)
+	if request.isNotification() {
+		if err != nil {
+			s.logger.Warn("Failed to handle MCP notification", "method", request.Method, "error", err)
+		}
+		return nil
+	}
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		return &Response{JSONRPC: "2.0", ID: request.ID, Error: rpcErr}
+	}
+	return &Response{JSONRPC: "2.0", ID: request.ID, Result: result}
+}
+
+// dispatch calls the method of request.
+func (s *Server) dispatch(ctx context.Context, request *Request) (any, error) {
+	switch request.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+			ClientInfo      struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"clientInfo"`
+		}
+		if err := unmarshalParams(request.Params, &params); err != nil {
+			return nil, err
+		}
+		s.logger.Info("MCP client connected", "client", params.ClientInfo.Name, "client_version", params.ClientInfo.Version, "protocol_version", params.ProtocolVersion)
+		return map[string]any{
+			"protocolVersion": negotiateVersion(params.ProtocolVersion),
+			"capabilities": map[string]any{
+				"tools": map[string]any{"listChanged": false},
+			},
+			"serverInfo": map[string]any{"name": s.name, "version": s.version},
+		}, nil
+
+	case "ping":
+		return map[string]any{}, nil
+
+	case "tools/list":
+		return map[string]any{"tools": s.Tools()}, nil
+
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := unmarshalParams(request.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.callTool(ctx, params.Name, params.Arguments)
+
+	default:
+		// notifications/initialized, notifications/cancelled and other
+		// notifications need no handling
+		if request.isNotification() {
+			return nil, nil
+		}
+		return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", request.Method)}
+	}
+}
+
+// callTool calls the tool named name. Errors of the tool are returned in the
+// result, so that the model can see them.
+func (s *Server) callTool(ctx context.Context, name string, arguments json.RawMessage) (*CallToolResult, error) {
+	tool := s.tool(name)
+	if tool == nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("unknown tool: %s", name)}
+	}
+
+	start := time.Now()
+	output, err := tool.handler(ctx, arguments)
+	s.metrics.observeToolCall(name, time.Since(start), err != nil || failed(output))
+	if err != nil {
+		if rpcErr, ok := err.(*Error); ok {
+			return nil, rpcErr
+		}
+		s.logger.Warn("MCP tool failed", "tool", name, "error", err)
+		return &CallToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+
+	text, ok := output.(string)
+	if !ok {
+		data, err := json.Marshal(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result of tool %s: %w", name, err)
+		}
+		text = string(data)
+	}
+	return &CallToolResult{Content: []Content{{Type: "text", Text: text}}}, nil
+}
+
+// failed reports whether the result of a tool reports a failure with a
+// Failed method, for results carrying their errors to the model as content.
+func failed(output any) bool {
+	f, ok := output.(interface{ Failed() bool })
+	return ok && f.Failed()
+}
+
+// negotiateVersion returns the version requested by the client when it is
+// supported, or the latest one.
+func negotiateVersion(requested string) string {
+	if slices.Contains(ProtocolVersions, requested) {
+		return requested
+	}
+	return LatestProtocolVersion
+}
+
+// unmarshalParams decodes the params of a request into v.
+func unmarshalParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+	}
+	return nil
+}
+
+// errorResponse returns a JSON-RPC error response.
+func errorResponse(id json.RawMessage, code int, message string) *Response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &Response{JSONRPC: "2.0", ID: id, Error: &Error{Code: code, Message: message}}
+}
+
+// marshalResponse marshals response, which only fails on results that are
+// not JSON.
+func marshalResponse(response *Response) []byte {
+	data, err := json.Marshal(response)
+	if err != nil {
+		data, _ = json.Marshal(errorResponse(response.ID, CodeInternalError, fmt.Sprintf("failed to marshal response: %v", err)))
+	}
+	return data
+}
@@ -0,0 +1,323 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type echoRequest struct {
+	Text   string   `json:"text" description:"Text to echo"`
+	Times  int      `json:"times,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	Mode   string   `json:"mode,omitempty" enum:"upper,lower"`
+	Ignore string   `json:"-"`
+}
+
+func newEchoServer() *Server {
+	server := NewServer("test", "1.0.0", nil)
+	AddTool(server, "echo", "Echo text.", func(ctx context.Context, input *echoRequest) (map[string]any, error) {
+		if input.Text == "fail" {
+			return nil, errors.New("echo failed")
+		}
+		return map[string]any{"text": strings.Repeat(input.Text, max(input.Times, 1))}, nil
+	})
+	return server
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema := SchemaFor[echoRequest]()
+	if schema.Type != "object" || len(schema.Properties) != 4 {
+		t.Fatalf("SchemaFor() = %+v", schema)
+	}
+	if !reflect.DeepEqual(schema.Required, []string{"text"}) {
+		t.Errorf("Required = %v, want [text]", schema.Required)
+	}
+	if p := schema.Properties["text"]; p.Type != "string" || p.Description != "Text to echo" {
+		t.Errorf("text = %+v", p)
+	}
+	if p := schema.Properties["times"]; p.Type != "integer" {
+		t.Errorf("times = %+v", p)
+	}
+	if p := schema.Properties["tags"]; p.Type != "array" || p.Items.Type != "string" {
+		t.Errorf("tags = %+v", p)
+	}
+	if p := schema.Properties["mode"]; !reflect.DeepEqual(p.Enum, []string{"upper", "lower"}) {
+		t.Errorf("mode = %+v", p)
+	}
+
+	if empty := SchemaFor[struct{}](); empty.Properties == nil {
+		t.Errorf("SchemaFor(struct{}) has no properties")
+	}
+}
+
+func TestHandleMessage(t *testing.T) {
+	server := newEchoServer()
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"initialize", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`, `"protocolVersion":"2024-11-05"`},
+		{"unknown version", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`, `"protocolVersion":"` + LatestProtocolVersion + `"`},
+		{"list", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, `"required":["text"]`},
+		{"call", `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{"text":"ab","times":2}}}`, `"text":"{\"text\":\"abab\"}"`},
+		{"tool error", `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo","arguments":{"text":"fail"}}}`, `"isError":true`},
+		{"unknown tool", `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"nope"}}`, `"code":-32602`},
+		{"bad arguments", `{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"echo","arguments":{"times":"x"}}}`, `"code":-32602`},
+		{"unknown method", `{"jsonrpc":"2.0","id":7,"method":"resources/list"}`, `"code":-32601`},
+		{"parse error", `{`, `"code":-32700`},
+		{"batch", `[{"jsonrpc":"2.0","id":8,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"}]`, `[{"jsonrpc":"2.0","id":8,"result":{}}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(server.HandleMessage(ctx, []byte(tt.message))); !strings.Contains(got, tt.want) {
+				t.Errorf("HandleMessage() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if got := server.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)); got != nil {
+		t.Errorf("HandleMessage(notification) = %s, want nil", got)
+	}
+}
+
+func TestServeStdio(t *testing.T) {
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n\n" +
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	var out strings.Builder
+	if err := newEchoServer().ServeStdio(context.Background(), in, &out); err != nil {
+		t.Fatalf("ServeStdio() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("ServeStdio() wrote %q, want 2 responses", out.String())
+	}
+	for _, line := range lines {
+		var response Response
+		if err := json.Unmarshal([]byte(line), &response); err != nil || response.Error != nil {
+			t.Errorf("response %s: %v", line, err)
+		}
+	}
+}
+
+func TestStreamableHTTP(t *testing.T) {
+	handler := newEchoServer().Handler().(*httpHandler)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	post := func(sessionID, body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			req.Header.Set(SessionHeader, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST /mcp error = %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	sessionID := resp.Header.Get(SessionHeader)
+	if resp.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("initialize = %d, session %q", resp.StatusCode, sessionID)
+	}
+	if resp := post(sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`); resp.StatusCode != http.StatusAccepted {
+		t.Errorf("notification = %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	if resp := post("unknown", `{"jsonrpc":"2.0","id":2,"method":"ping"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown session = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if resp := post("", `{"jsonrpc":"2.0","id":2,"method":"ping"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("missing session = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	// Idle sessions expire, and opening a session beyond maxSessions ends the
+	// least recently used one
+	handler.sessions[sessionID].lastUsed = time.Now().Add(-2 * sessionIdleTimeout)
+	if resp := post(sessionID, `{"jsonrpc":"2.0","id":2,"method":"ping"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expired session = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	for i := range maxSessions {
+		handler.openSession(fmt.Sprint(i))
+	}
+	newest := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`).Header.Get(SessionHeader)
+	if len(handler.sessions) != maxSessions {
+		t.Errorf("sessions = %d, want %d", len(handler.sessions), maxSessions)
+	}
+	if resp := post(newest, `{"jsonrpc":"2.0","id":2,"method":"ping"}`); resp.StatusCode != http.StatusOK {
+		t.Errorf("newest session = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(`{}`))
+	req.Header.Set("Origin", "https://evil.example")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /mcp error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("foreign origin = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestSSE(t *testing.T) {
+	ts := httptest.NewServer(newEchoServer().Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/sse")
+	if err != nil {
+		t.Fatalf("GET /sse error = %v", err)
+	}
+	defer resp.Body.Close()
+	events := bufio.NewReader(resp.Body)
+
+	// readData returns the data of the next event
+	readData := func() string {
+		for {
+			line, err := events.ReadString('\n')
+			if err != nil {
+				t.Fatalf("failed to read event: %v", err)
+			}
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				return strings.TrimSpace(data)
+			}
+		}
+	}
+
+	endpoint := readData()
+	if !strings.HasPrefix(endpoint, "messages?sessionId=") {
+		t.Fatalf("endpoint = %q", endpoint)
+	}
+	post, err := http.Post(ts.URL+"/"+endpoint, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	if err != nil || post.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST %s = %v, %v", endpoint, post, err)
+	}
+	post.Body.Close()
+	if got := readData(); got != `{"jsonrpc":"2.0","id":1,"result":{}}` {
+		t.Errorf("message = %s", got)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	server := NewServer("test", "1.0.0", nil)
+	AddTool(server, "whoami", "Name the API key.", func(ctx context.Context, input *struct{}) (string, error) {
+		return KeyFromContext(ctx).Name, nil
+	})
+	if err := server.SetAPIKeys([]APIKey{{Name: "a", Key: "key-a", RequestsPerMinute: 2}, {Name: "b", Key: "key-b"}}); err != nil {
+		t.Fatalf("SetAPIKeys() error = %v", err)
+	}
+	if err := server.SetAPIKeys([]APIKey{{Name: "a", Key: "x"}, {Name: "b", Key: "x"}}); err == nil {
+		t.Errorf("SetAPIKeys() with duplicate keys succeeded")
+	}
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	post := func(token, sessionID, body string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if sessionID != "" {
+			req.Header.Set(SessionHeader, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST /mcp error = %v", err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"whoami"}}`
+
+	if resp, _ := post("", "", call); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("no key = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if resp, _ := post("wrong", "", call); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong key = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	resp, _ := post("key-b", "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	if _, body := post("key-b", resp.Header.Get(SessionHeader), call); !strings.Contains(body, `"text":"b"`) {
+		t.Errorf("key b = %s", body)
+	}
+
+	// Sessions belong to the key that opened them
+	resp, _ = post("key-a", "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	sessionID := resp.Header.Get(SessionHeader)
+	if resp, _ := post("key-b", sessionID, call); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("session of another key = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	// Key a allows two messages per minute, initialize included
+	if resp, _ := post("key-a", sessionID, call); resp.StatusCode != http.StatusOK {
+		t.Errorf("key a = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if resp, _ := post("key-a", sessionID, call); resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("key a over its rate limit = %d, Retry-After %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	server := newEchoServer()
+	server.AddMetrics(func() []Metric {
+		return []Metric{{Name: "queue_depth", Help: "Queued items.", Type: "gauge", Samples: []Sample{
+			{Labels: map[string]string{"group": `a"b`}, Value: 3},
+		}}}
+	})
+	server.Handle("GET /healthz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	if err := server.SetAPIKeys([]APIKey{{Name: "a", Key: "key-a"}}); err != nil {
+		t.Fatalf("SetAPIKeys() error = %v", err)
+	}
+	server.Handle("GET /metrics", server.MetricsHandler())
+	server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"text":"fail"}}}`))
+
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	// Routes added with Handle need no API key
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /healthz = %v, %v", resp, err)
+	}
+	resp.Body.Close()
+	if resp, err := http.Post(ts.URL+"/mcp", "application/json", strings.NewReader(`{}`)); err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("POST /mcp without key = %v, %v", resp, err)
+	}
+
+	resp, err = http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		"# TYPE mcp_tool_calls_total counter\n",
+		`mcp_tool_calls_total{tool="echo"} 1` + "\n",
+		`mcp_tool_errors_total{tool="echo"} 1` + "\n",
+		`mcp_http_rejected_requests_total{reason="unauthorized"} 1` + "\n",
+		`queue_depth{group="a\"b"} 3` + "\n",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric is a metric written in the Prometheus text format by
+// MetricsHandler.
+type Metric struct {
+	// Name is the name of the metric, such as "mcp_tool_calls_total".
+	Name string
+	// Help describes the metric.
+	Help string
+	// Type is the Prometheus type of the metric: counter or gauge.
+	Type string
+	// Samples are the values of the metric, one per set of labels.
+	Samples []Sample
+}
+
+// Sample is a value of a metric for a set of labels.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// toolStats counts the calls of a tool.
+type toolStats struct {
+	calls   int
+	errors  int
+	seconds float64
+}
+
+// metrics records the activity of a server.
+type metrics struct {
+	mu       sync.Mutex
+	tools    map[string]*toolStats
+	rejected map[string]int
+	collect  []func() []Metric
+}
+
+// observeToolCall records a call of the tool named name.
+func (m *metrics) observeToolCall(name string, duration time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tools == nil {
+		m.tools = make(map[string]*toolStats)
+	}
+	stats := m.tools[name]
+	if stats == nil {
+		stats = &toolStats{}
+		m.tools[name] = stats
+	}
+	stats.calls++
+	stats.seconds += duration.Seconds()
+	if failed {
+		stats.errors++
+	}
+}
+
+// observeRejected records an HTTP request rejected for reason.
+func (m *metrics) observeRejected(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rejected == nil {
+		m.rejected = make(map[string]int)
+	}
+	m.rejected[reason]++
+}
+
+// AddMetrics adds a function collecting metrics of the application serving
+// the tools, such as queue depths, to those of MetricsHandler.
+func (s *Server) AddMetrics(collect func() []Metric) {
+	s.metrics.mu.Lock()
+	defer s.metrics.mu.Unlock()
+	s.metrics.collect = append(s.metrics.collect, collect)
+}
+
+// Metrics returns the metrics of the server: the calls, failures and
+// duration of each tool and the HTTP requests rejected for a missing API key
+// or a rate limit, followed by those of the functions added with
+// AddMetrics. A call fails when its tool returns an error or a result whose
+// Failed method returns true.
+func (s *Server) Metrics() []Metric {
+	calls := Metric{Name: "mcp_tool_calls_total", Help: "Tool calls.", Type: "counter"}
+	failures := Metric{Name: "mcp_tool_errors_total", Help: "Tool calls that failed.", Type: "counter"}
+	seconds := Metric{Name: "mcp_tool_call_seconds_total", Help: "Time spent in tool calls.", Type: "counter"}
+	rejected := Metric{Name: "mcp_http_rejected_requests_total", Help: "HTTP requests rejected before reaching the server.", Type: "counter"}
+
+	// Every tool is reported, so that rates start from zero
+	tools := s.Tools()
+	s.metrics.mu.Lock()
+	for _, tool := range tools {
+		stats := s.metrics.tools[tool.Name]
+		if stats == nil {
+			stats = &toolStats{}
+		}
+		labels := map[string]string{"tool": tool.Name}
+		calls.Samples = append(calls.Samples, Sample{Labels: labels, Value: float64(stats.calls)})
+		failures.Samples = append(failures.Samples, Sample{Labels: labels, Value: float64(stats.errors)})
+		seconds.Samples = append(seconds.Samples, Sample{Labels: labels, Value: stats.seconds})
+	}
+	for _, reason := range []string{"unauthorized", "rate_limited"} {
+		rejected.Samples = append(rejected.Samples, Sample{Labels: map[string]string{"reason": reason}, Value: float64(s.metrics.rejected[reason])})
+	}
+	collect := slices.Clone(s.metrics.collect)
+	s.metrics.mu.Unlock()
+
+	all := []Metric{calls, failures, seconds, rejected}
+	for _, c := range collect {
+		all = append(all, c()...)
+	}
+	return all
+}
+
+// MetricsHandler returns an HTTP handler serving the metrics of the server
+// in the Prometheus text format.
+func (s *Server) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := WriteMetrics(w, s.Metrics()); err != nil {
+			s.logger.Warn("Failed to write metrics", "error", err)
+		}
+	})
+}
+
+// WriteMetrics writes metrics to w in the Prometheus text format.
+func WriteMetrics(w io.Writer, metrics []Metric) error {
+	out := bufio.NewWriter(w)
+	for _, metric := range metrics {
+		fmt.Fprintf(out, "# HELP %s %s\n", metric.Name, helpEscaper.Replace(metric.Help))
+		fmt.Fprintf(out, "# TYPE %s %s\n", metric.Name, metric.Type)
+		for _, sample := range metric.Samples {
+			out.WriteString(metric.Name)
+			if len(sample.Labels) > 0 {
+				out.WriteByte('{')
+				for i, name := range slices.Sorted(maps.Keys(sample.Labels)) {
+					if i > 0 {
+						out.WriteByte(',')
+					}
+					fmt.Fprintf(out, "%s=\"%s\"", name, labelEscaper.Replace(sample.Labels[name]))
+				}
+				out.WriteByte('}')
+			}
+			fmt.Fprintf(out, " %s\n", strconv.FormatFloat(sample.Value, 'g', -1, 64))
+		}
+	}
+	return out.Flush()
+}
+
+// helpEscaper and labelEscaper escape help texts and label values.
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
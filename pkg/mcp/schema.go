@@ -0,0 +1,143 @@
+package mcp
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is the JSON schema of the arguments of a tool.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+// SchemaFor returns the JSON schema of T, which must be a struct for the
+// arguments of a tool. Properties are named by the json tags of the fields,
+// and fields without omitempty are required. The description tag of a field
+// describes its property, and its enum tag lists the allowed values,
+// separated by commas:
+//
+//	type SearchRequest struct {
+//		Query string `json:"query" description:"Search query"`
+//		Scope string `json:"scope,omitempty" enum:"nodes,facts"`
+//	}
+func SchemaFor[T any]() *Schema {
+	schema := schemaOf(reflect.TypeFor[T](), nil)
+	if schema.Type == "object" && schema.Properties == nil {
+		// Clients expect the properties of tools without arguments
+		schema.Properties = map[string]*Schema{}
+	}
+	return schema
+}
+
+// schemaOf returns the JSON schema of t. seen holds the structs being
+// described, to stop at recursive types.
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Bytes are encoded in base64
+			return &Schema{Type: "string"}
+		}
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] || t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+			return &Schema{}
+		}
+		if seen == nil {
+			seen = make(map[reflect.Type]bool)
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		schema := &Schema{Type: "object"}
+		addFields(schema, t, seen)
+		return schema
+	default:
+		// Interfaces accept any value
+		return &Schema{}
+	}
+}
+
+// addFields adds the properties of the exported fields of the struct t to
+// schema, including the fields of embedded structs without a json name.
+func addFields(schema *Schema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addFields(schema, embedded, seen)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := schemaOf(field.Type, seen)
+		property.Description = field.Tag.Get("description")
+		if enum := field.Tag.Get("enum"); enum != "" {
+			property.Enum = strings.Split(enum, ",")
+		}
+		if schema.Properties == nil {
+			schema.Properties = make(map[string]*Schema)
+		}
+		schema.Properties[name] = property
+
+		optional := field.Type.Kind() == reflect.Pointer
+		for _, option := range strings.Split(options, ",") {
+			optional = optional || option == "omitempty" || option == "omitzero"
+		}
+		if !optional {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ServeStdio serves MCP over stdio: newline-delimited JSON-RPC messages are
+// read from in and the responses written to out, until in is closed or ctx
+// is done. Requests are handled concurrently, so that pings are answered
+// while a slow tool runs. Nothing else may be written to out, so logs must go
+// to stderr.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					readErr <- fmt.Errorf("failed to read MCP message: %w", err)
+				}
+				return
+			}
+		}
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		writeMu  sync.Mutex
+		writeErr error
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			// The handlers are done writing once they have returned
+			wg.Wait()
+			if writeErr != nil {
+				return writeErr
+			}
+			return ctx.Err()
+		case line, ok := <-lines:
+			if !ok {
+				// Let the pending requests answer before returning
+				wg.Wait()
+				select {
+				case err := <-readErr:
+					return err
+				default:
+					return writeErr
+				}
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				response := s.HandleMessage(ctx, line)
+				if response == nil {
+					return
+				}
+				writeMu.Lock()
+				defer writeMu.Unlock()
+				if _, err := out.Write(append(response, '\n')); err != nil && writeErr == nil {
+					writeErr = fmt.Errorf("failed to write MCP response: %w", err)
+					cancel()
+				}
+			}()
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/mcp"
+)
+
+// healthCheckTimeout bounds the database check of /healthz, so that probes
+// fail rather than hang when the database is unreachable
+const healthCheckTimeout = 5 * time.Second
+
+// registerProbes serves the health and readiness probes and the metrics
+// next to MCP over HTTP
+func (s *Server) registerProbes(server *mcp.Server) {
+	server.Handle("GET /healthz", http.HandlerFunc(s.handleHealth))
+	server.Handle("GET /readyz", http.HandlerFunc(s.handleReady))
+	server.AddMetrics(s.queueMetrics)
+	server.Handle("GET /metrics", server.MetricsHandler())
+}
+
+// handleHealth reports whether the database is reachable
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	if err := s.client.GetDriver().HealthCheck(ctx); err != nil {
+		s.logger.Warn("Health check failed", "error", err)
+		writeProbe(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status": "unhealthy",
+			"error":  err.Error(),
+		})
+		return
+	}
+	writeProbe(w, http.StatusOK, map[string]interface{}{"status": "healthy"})
+}
+
+// handleReady reports whether the episode queue has room for more episodes,
+// so that load balancers send add_memory elsewhere while it drains
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	pending := s.queue.Pending()
+	response := map[string]interface{}{
+		"status":          "ready",
+		"queued_episodes": pending,
+		"threshold":       s.config.ReadyQueueThreshold,
+	}
+	if pending >= s.config.ReadyQueueThreshold {
+		response["status"] = "not ready"
+		writeProbe(w, http.StatusServiceUnavailable, response)
+		return
+	}
+	writeProbe(w, http.StatusOK, response)
+}
+
+// writeProbe writes the JSON response of a probe
+func writeProbe(w http.ResponseWriter, status int, response map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// queueMetrics returns the metrics of the episode queue of each group
+func (s *Server) queueMetrics() []mcp.Metric {
+	queued := mcp.Metric{Name: "mcp_episodes_queued", Help: "Episodes waiting to be added.", Type: "gauge"}
+	processing := mcp.Metric{Name: "mcp_episodes_processing", Help: "Episodes being added.", Type: "gauge"}
+	processed := mcp.Metric{Name: "mcp_episodes_processed_total", Help: "Queued episodes added.", Type: "counter"}
+	failed := mcp.Metric{Name: "mcp_episodes_failed_total", Help: "Queued episodes that failed to be added.", Type: "counter"}
+
+	for _, status := range s.queue.Status("") {
+		labels := map[string]string{"group_id": status.GroupID}
+		inProgress := 0.0
+		if status.Processing != "" {
+			inProgress = 1
+		}
+		queued.Samples = append(queued.Samples, mcp.Sample{Labels: labels, Value: float64(status.Queued)})
+		processing.Samples = append(processing.Samples, mcp.Sample{Labels: labels, Value: inProgress})
+		processed.Samples = append(processed.Samples, mcp.Sample{Labels: labels, Value: float64(status.Processed)})
+		failed.Samples = append(failed.Samples, mcp.Sample{Labels: labels, Value: float64(status.Failed)})
+	}
+	return []mcp.Metric{queued, processing, processed, failed}
+}
@@ -0,0 +1,176 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// DefaultEpisodeQueueSize is the number of episodes a group can have waiting
+// before add_memory rejects more
+const DefaultEpisodeQueueSize = 100
+
+// EpisodeQueue adds episodes in the background, so that add_memory returns
+// before the slow LLM extraction. Each group has its own worker adding its
+// episodes one at a time in the order they were queued, since episodes of a
+// group deduplicate against each other; groups are processed concurrently.
+type EpisodeQueue struct {
+	ctx     context.Context
+	add     func(ctx context.Context, episode types.Episode) error
+	size    int
+	logger  *slog.Logger
+	workers sync.WaitGroup
+
+	mu     sync.Mutex
+	groups map[string]*groupQueue
+}
+
+// groupQueue holds the episodes waiting to be added to a group.
+type groupQueue struct {
+	episodes   []types.Episode
+	processing string
+	running    bool
+	processed  int
+	failed     int
+	lastError  string
+}
+
+// QueueStatus is the status of the episode queue of a group.
+type QueueStatus struct {
+	GroupID    string `json:"group_id"`
+	Queued     int    `json:"queued"`
+	Processing string `json:"processing,omitempty"`
+	Processed  int    `json:"processed"`
+	Failed     int    `json:"failed"`
+	LastError  string `json:"last_error,omitempty"`
+}
+
+// NewEpisodeQueue creates a queue adding episodes with add until ctx is
+// done, holding at most size waiting episodes per group.
+func NewEpisodeQueue(ctx context.Context, add func(ctx context.Context, episode types.Episode) error, size int, logger *slog.Logger) *EpisodeQueue {
+	if size <= 0 {
+		size = DefaultEpisodeQueueSize
+	}
+	return &EpisodeQueue{
+		ctx:    ctx,
+		add:    add,
+		size:   size,
+		logger: logger,
+		groups: make(map[string]*groupQueue),
+	}
+}
+
+// Enqueue queues episode for its group and returns its position in the
+// queue, starting the worker of the group if it is idle. It fails when the
+// queue of the group is full, so that clients back off instead of piling up
+// episodes.
+func (q *EpisodeQueue) Enqueue(episode types.Episode) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("episode queue is closed: %w", err)
+	}
+	group := q.groups[episode.GroupID]
+	if group == nil {
+		group = &groupQueue{}
+		q.groups[episode.GroupID] = group
+	}
+	if len(group.episodes) >= q.size {
+		return 0, fmt.Errorf("episode queue of group '%s' is full (%d episodes waiting), retry later", episode.GroupID, len(group.episodes))
+	}
+	group.episodes = append(group.episodes, episode)
+
+	if !group.running {
+		group.running = true
+		q.workers.Add(1)
+		go q.work(episode.GroupID, group)
+	}
+	return len(group.episodes), nil
+}
+
+// work adds the episodes of a group until its queue is empty.
+func (q *EpisodeQueue) work(groupID string, group *groupQueue) {
+	defer q.workers.Done()
+	for {
+		q.mu.Lock()
+		if len(group.episodes) == 0 || q.ctx.Err() != nil {
+			group.running = false
+			group.processing = ""
+			q.mu.Unlock()
+			return
+		}
+		episode := group.episodes[0]
+		group.episodes = group.episodes[1:]
+		group.processing = episode.Name
+		q.mu.Unlock()
+
+		start := time.Now()
+		err := q.add(q.ctx, episode)
+
+		q.mu.Lock()
+		if err != nil {
+			group.failed++
+			group.lastError = fmt.Sprintf("episode '%s': %v", episode.Name, err)
+		} else {
+			group.processed++
+		}
+		q.mu.Unlock()
+
+		if err != nil {
+			q.logger.Error("Failed to add queued episode", "name", episode.Name, "group_id", groupID, "error", err)
+		} else {
+			q.logger.Info("Queued episode added", "name", episode.Name, "group_id", groupID, "duration", time.Since(start))
+		}
+	}
+}
+
+// Status returns the status of the queue of groupID, or of every group that
+// has queued episodes when groupID is empty.
+func (q *EpisodeQueue) Status(groupID string) []QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var statuses []QueueStatus
+	for id, group := range q.groups {
+		if groupID != "" && id != groupID {
+			continue
+		}
+		statuses = append(statuses, QueueStatus{
+			GroupID:    id,
+			Queued:     len(group.episodes),
+			Processing: group.processing,
+			Processed:  group.processed,
+			Failed:     group.failed,
+			LastError:  group.lastError,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].GroupID < statuses[j].GroupID })
+	return statuses
+}
+
+// Pending returns the number of episodes queued or being added.
+func (q *EpisodeQueue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending := 0
+	for _, group := range q.groups {
+		pending += len(group.episodes)
+		if group.processing != "" {
+			pending++
+		}
+	}
+	return pending
+}
+
+// Wait waits until the workers are done: the queue is empty or its context
+// is done.
+func (q *EpisodeQueue) Wait() {
+	q.workers.Wait()
+}
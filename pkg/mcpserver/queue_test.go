@@ -0,0 +1,215 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// recordingAdder adds episodes by recording them, holding those named in
+// block until release is closed.
+type recordingAdder struct {
+	mu       sync.Mutex
+	added    map[string][]string
+	inFlight map[string]int
+	overlap  bool
+	block    map[string]bool
+	release  chan struct{}
+	fail     map[string]error
+}
+
+func newRecordingAdder() *recordingAdder {
+	return &recordingAdder{
+		added:    make(map[string][]string),
+		inFlight: make(map[string]int),
+		block:    make(map[string]bool),
+		release:  make(chan struct{}),
+		fail:     make(map[string]error),
+	}
+}
+
+func (a *recordingAdder) add(ctx context.Context, episode types.Episode) error {
+	a.mu.Lock()
+	a.inFlight[episode.GroupID]++
+	if a.inFlight[episode.GroupID] > 1 {
+		a.overlap = true
+	}
+	block := a.block[episode.Name]
+	a.mu.Unlock()
+
+	defer func() {
+		a.mu.Lock()
+		a.inFlight[episode.GroupID]--
+		a.mu.Unlock()
+	}()
+	if block {
+		select {
+		case <-a.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.fail[episode.Name]; err != nil {
+		return err
+	}
+	a.added[episode.GroupID] = append(a.added[episode.GroupID], episode.Name)
+	return nil
+}
+
+func (a *recordingAdder) addedTo(groupID string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.added[groupID])
+}
+
+func newTestQueue(ctx context.Context, adder *recordingAdder, size int) *EpisodeQueue {
+	return NewEpisodeQueue(ctx, adder.add, size, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// waitForStatus polls the status of groupID until ok accepts it.
+func waitForStatus(t *testing.T, q *EpisodeQueue, groupID string, ok func(QueueStatus) bool) QueueStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		statuses := q.Status(groupID)
+		if len(statuses) == 1 && ok(statuses[0]) {
+			return statuses[0]
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Status(%q) = %+v, timed out", groupID, statuses)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEpisodeQueueAddsGroupEpisodesInOrder(t *testing.T) {
+	adder := newRecordingAdder()
+	q := newTestQueue(context.Background(), adder, 0)
+
+	var want []string
+	for i := 0; i < 20; i++ {
+		for _, group := range []string{"a", "b"} {
+			name := fmt.Sprintf("%s-%d", group, i)
+			if group == "a" {
+				want = append(want, name)
+			}
+			if _, err := q.Enqueue(types.Episode{Name: name, GroupID: group}); err != nil {
+				t.Fatalf("Enqueue(%s) error = %v", name, err)
+			}
+		}
+	}
+	q.Wait()
+
+	if got := adder.addedTo("a"); !slices.Equal(got, want) {
+		t.Errorf("added to a = %v, want %v", got, want)
+	}
+	if got := adder.addedTo("b"); len(got) != 20 {
+		t.Errorf("added %d episodes to b, want 20", len(got))
+	}
+	if adder.overlap {
+		t.Error("episodes of a group were added concurrently")
+	}
+	if pending := q.Pending(); pending != 0 {
+		t.Errorf("Pending() = %d after Wait, want 0", pending)
+	}
+	statuses := q.Status("")
+	if len(statuses) != 2 || statuses[0].GroupID != "a" || statuses[0].Processed != 20 || statuses[1].Processed != 20 {
+		t.Errorf("Status() = %+v, want 20 processed in a and b", statuses)
+	}
+}
+
+func TestEpisodeQueueStatus(t *testing.T) {
+	adder := newRecordingAdder()
+	adder.block["first"] = true
+	adder.fail["broken"] = errors.New("llm unavailable")
+	q := newTestQueue(context.Background(), adder, 2)
+
+	if _, err := q.Enqueue(types.Episode{Name: "first", GroupID: "g"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	waitForStatus(t, q, "g", func(s QueueStatus) bool { return s.Processing == "first" })
+
+	for i, name := range []string{"broken", "third"} {
+		position, err := q.Enqueue(types.Episode{Name: name, GroupID: "g"})
+		if err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", name, err)
+		}
+		if position != i+1 {
+			t.Errorf("Enqueue(%s) position = %d, want %d", name, position, i+1)
+		}
+	}
+	if _, err := q.Enqueue(types.Episode{Name: "fourth", GroupID: "g"}); err == nil || !strings.Contains(err.Error(), "full") {
+		t.Errorf("Enqueue() on a full queue error = %v", err)
+	}
+	if _, err := q.Enqueue(types.Episode{Name: "other", GroupID: "h"}); err != nil {
+		t.Errorf("Enqueue() to another group error = %v, want the queues bounded per group", err)
+	}
+
+	status := waitForStatus(t, q, "g", func(s QueueStatus) bool { return true })
+	if status.Queued != 2 || status.Processing != "first" || status.Processed != 0 {
+		t.Errorf("Status() while adding = %+v, want first processing and 2 queued", status)
+	}
+	if pending := q.Pending(); pending < 3 {
+		t.Errorf("Pending() = %d, want at least the 3 episodes of g", pending)
+	}
+
+	close(adder.release)
+	q.Wait()
+	status = waitForStatus(t, q, "g", func(s QueueStatus) bool { return true })
+	want := QueueStatus{GroupID: "g", Processed: 2, Failed: 1, LastError: "episode 'broken': llm unavailable"}
+	if status != want {
+		t.Errorf("Status() after adding = %+v, want %+v", status, want)
+	}
+	if got := adder.addedTo("g"); !slices.Equal(got, []string{"first", "third"}) {
+		t.Errorf("added = %v, want [first third]", got)
+	}
+}
+
+func TestEpisodeQueueShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	adder := newRecordingAdder()
+	adder.block["first"] = true
+	q := newTestQueue(ctx, adder, 0)
+
+	for _, name := range []string{"first", "second"} {
+		if _, err := q.Enqueue(types.Episode{Name: name, GroupID: "g"}); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", name, err)
+		}
+	}
+	waitForStatus(t, q, "g", func(s QueueStatus) bool { return s.Processing == "first" })
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		q.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait() did not return after the context was canceled")
+	}
+
+	if _, err := q.Enqueue(types.Episode{Name: "late", GroupID: "g"}); err == nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("Enqueue() after shutdown error = %v, want context.Canceled", err)
+	}
+	if got := adder.addedTo("g"); len(got) != 0 {
+		t.Errorf("added %v after shutdown, want nothing", got)
+	}
+	status := waitForStatus(t, q, "g", func(s QueueStatus) bool { return true })
+	if status.Queued != 1 || status.Processing != "" || status.Failed != 1 {
+		t.Errorf("Status() after shutdown = %+v, want second left queued and first failed", status)
+	}
+}
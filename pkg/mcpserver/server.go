@@ -0,0 +1,302 @@
+// Package mcpserver serves a Predicato graph as memory for AI agents over the
+// Model Context Protocol. It is shared by the mcp-server command and the
+// predicato mcp subcommand, which only differ in how they are configured and
+// build their client:
+//
+//	server := mcpserver.New(client, &mcpserver.Config{GroupID: "default", Transport: "stdio"}, logger)
+//	if err := server.Initialize(ctx); err != nil {
+//		...
+//	}
+//	err := server.Run(ctx)
+//
+// Over HTTP, the server requires API keys unless it only listens on a
+// loopback address, restricts requests to their groups, and serves health
+// and readiness probes and Prometheus metrics.
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+
+	"github.com/soundprediction/go-predicato"
+	"github.com/soundprediction/go-predicato/pkg/mcp"
+	"github.com/soundprediction/go-predicato/pkg/search/recipes"
+)
+
+// Config holds the configuration of the MCP server, besides that of its
+// Predicato client.
+type Config struct {
+	// Version is the server version reported to MCP clients
+	Version string
+
+	// GroupID is the group of requests naming none
+	GroupID string
+	// AllowedGroupIDs are the groups requests may name besides GroupID, so
+	// that one server can serve several projects or agents. Empty allows
+	// any group.
+	AllowedGroupIDs []string
+
+	// DestroyGraph clears GroupID when the server initializes
+	DestroyGraph bool
+	// UseCustomEntities enables entity extraction with predefined entity
+	// types
+	UseCustomEntities bool
+
+	// Transport is stdio, or sse or http for HTTP serving both the
+	// streamable HTTP and SSE transports on Host and Port
+	Transport string
+	Host      string
+	Port      int
+
+	// APIKeysFile is a JSON file of the API keys the HTTP transport requires
+	// as bearer tokens, each with the groups it may use and a rate limit.
+	// It is required unless Host is a loopback address.
+	APIKeysFile string
+
+	// SearchRecipe names the predefined search configuration used by the
+	// search tools when a request names none
+	SearchRecipe string
+
+	// EpisodeQueueSize is the number of episodes a group can have waiting
+	// to be added before add_memory rejects more
+	EpisodeQueueSize int
+
+	// ReadyQueueThreshold is the number of queued episodes, across groups,
+	// from which /readyz reports the server not ready
+	ReadyQueueThreshold int
+}
+
+// Validate checks the configuration.
+func (c *Config) Validate() error {
+	if c.GroupID == "" {
+		return fmt.Errorf("group ID is required")
+	}
+	switch c.Transport {
+	case "stdio":
+	case "sse", "http":
+		if c.Port <= 0 || c.Port > 65535 {
+			return fmt.Errorf("invalid port: %d", c.Port)
+		}
+	default:
+		return fmt.Errorf("unsupported transport: %s", c.Transport)
+	}
+	if c.SearchRecipe != "" {
+		if _, err := recipes.Get(c.SearchRecipe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Server serves the tools of a Predicato client over MCP.
+type Server struct {
+	config *Config
+	client *predicato.Client
+	logger *slog.Logger
+	queue  *EpisodeQueue
+}
+
+// New creates a server serving the graph of client.
+func New(client *predicato.Client, config *Config, logger *slog.Logger) *Server {
+	return &Server{
+		config: config,
+		client: client,
+		logger: logger,
+	}
+}
+
+// Initialize sets up the MCP server and Predicato client
+func (s *Server) Initialize(ctx context.Context) error {
+	s.logger.Info("Initializing Predicato MCP server...")
+
+	// Verify the client is ready
+	if s.client == nil {
+		return fmt.Errorf("predicato client not initialized")
+	}
+
+	// Initialize graph indices and constraints
+	s.logger.Info("Initializing graph indices and constraints...")
+	err := s.client.CreateIndices(ctx)
+	if err != nil {
+		s.logger.Error("Failed to initialize graph indices", "error", err)
+		return fmt.Errorf("failed to initialize graph indices: %w", err)
+	}
+	s.logger.Info("Graph indices and constraints initialized successfully")
+
+	// Clear graph if requested
+	if s.config.DestroyGraph {
+		s.logger.Warn("Graph destruction requested - clearing all data for group", "group_id", s.config.GroupID)
+
+		err := s.client.ClearGraph(ctx, s.config.GroupID)
+		if err != nil {
+			s.logger.Error("Failed to clear graph during initialization", "error", err)
+			return fmt.Errorf("failed to clear graph: %w", err)
+		}
+
+		s.logger.Info("Graph cleared successfully during initialization")
+	}
+
+	s.logger.Info("Predicato client initialized successfully")
+	s.logger.Info("MCP server configuration",
+		"group_id", s.config.GroupID,
+		"allowed_group_ids", s.config.AllowedGroupIDs,
+		"transport", s.config.Transport,
+		"custom_entities", s.config.UseCustomEntities,
+		"episode_queue_size", s.config.EpisodeQueueSize,
+		"ready_queue_threshold", s.config.ReadyQueueThreshold,
+	)
+
+	return nil
+}
+
+// RegisterTools registers all MCP tools with the MCP server
+func (s *Server) RegisterTools(server *mcp.Server) {
+	// Register add_memory tool
+	mcp.AddTool(server, "add_memory",
+		"Add an episode to memory. This is the primary way to add information to the graph. "+
+			"Episodes are queued and added in the background, in order for each group; see get_queue_status.",
+		s.AddMemoryTool)
+
+	// Register get_queue_status tool
+	mcp.AddTool(server, "get_queue_status",
+		"Get the number of episodes queued by add_memory and not yet added, and the errors of failed ones.",
+		s.GetQueueStatusTool)
+
+	// Register search_memory_nodes tool
+	mcp.AddTool(server, "search_memory_nodes",
+		"Search the graph memory for relevant node summaries.",
+		s.SearchMemoryNodesTool).InputSchema.Properties["search_recipe"].Enum = recipes.Names()
+
+	// Register search_memory_facts tool
+	mcp.AddTool(server, "search_memory_facts",
+		"Search the graph memory for relevant facts.",
+		s.SearchMemoryFactsTool).InputSchema.Properties["search_recipe"].Enum = recipes.Names()
+
+	// Register list_saved_searches tool
+	mcp.AddTool(server, "list_saved_searches",
+		"List the saved searches of a group, usable as saved_search in the search tools.",
+		s.ListSavedSearchesTool)
+
+	// Register add_triplet tool
+	mcp.AddTool(server, "add_triplet",
+		"Assert a fact between two entities directly, without extracting it from an episode. "+
+			"The entities are matched against existing ones, and the fact may invalidate facts it contradicts.",
+		s.AddTripletTool)
+
+	// Register invalidate_fact tool
+	mcp.AddTool(server, "invalidate_fact",
+		"Retract a fact that no longer holds. The fact is marked invalid from now on rather than deleted.",
+		s.InvalidateFactTool)
+
+	// Register delete_entity_edge tool
+	mcp.AddTool(server, "delete_entity_edge",
+		"Delete an entity edge from the graph memory.",
+		s.DeleteEntityEdgeTool)
+
+	// Register delete_episode tool
+	mcp.AddTool(server, "delete_episode",
+		"Delete an episode from the graph memory.",
+		s.DeleteEpisodeTool)
+
+	// Register get_entity_edge tool
+	mcp.AddTool(server, "get_entity_edge",
+		"Get an entity edge from the graph memory by its UUID.",
+		s.GetEntityEdgeTool)
+
+	// Register get_entity tool
+	mcp.AddTool(server, "get_entity",
+		"Get an entity node by its UUID with its summary, attributes and most connected neighbors, "+
+			"with the facts relating them to it.",
+		s.GetEntityTool)
+
+	// Register traverse tool
+	mcp.AddTool(server, "traverse",
+		"Walk the graph breadth-first from an entity node, returning the nodes reached within depth hops "+
+			"and the facts between them. edge_types restricts the walk to relations with those names.",
+		s.TraverseTool)
+
+	// Register get_episodes tool
+	mcp.AddTool(server, "get_episodes",
+		"Get the most recent memory episodes for a specific group.",
+		s.GetEpisodesTool)
+
+	// Register clear_graph tool
+	mcp.AddTool(server, "clear_graph",
+		"Clear all data from the graph memory.",
+		s.ClearGraphTool)
+}
+
+// Run serves MCP over the configured transport until ctx is done or, over
+// stdio, the client closes stdin
+func (s *Server) Run(ctx context.Context) error {
+	s.logger.Info("Starting MCP server", "transport", s.config.Transport)
+
+	s.queue = NewEpisodeQueue(ctx, s.addEpisode, s.config.EpisodeQueueSize, s.logger)
+	server := mcp.NewServer("predicato", s.config.Version, s.logger)
+	s.RegisterTools(server)
+
+	var err error
+	switch s.config.Transport {
+	case "stdio":
+		// stdout carries the protocol, so logs go to stderr
+		s.logger.Info("MCP server is ready to accept requests on stdio")
+		err = server.ServeStdio(ctx, os.Stdin, os.Stdout)
+	case "sse", "http":
+		if err := s.setAPIKeys(server); err != nil {
+			return err
+		}
+		s.registerProbes(server)
+		address := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+		s.logger.Info("MCP server is ready to accept requests",
+			"streamable_http", "http://"+address+"/mcp",
+			"sse", "http://"+address+"/sse",
+			"metrics", "http://"+address+"/metrics",
+		)
+		err = server.ListenAndServe(ctx, address)
+	default:
+		return fmt.Errorf("unsupported transport: %s", s.config.Transport)
+	}
+
+	// Finish adding the queued episodes when the client goes away, unless
+	// the server is interrupted
+	if pending := s.queue.Pending(); pending > 0 && ctx.Err() == nil {
+		s.logger.Info("Waiting for queued episodes to be added", "pending", pending)
+	}
+	s.queue.Wait()
+	return err
+}
+
+// setAPIKeys makes the HTTP transport require the configured API keys. It
+// refuses to serve beyond loopback addresses without any, since anyone
+// reaching the port could then read and clear every group.
+func (s *Server) setAPIKeys(server *mcp.Server) error {
+	if s.config.APIKeysFile == "" {
+		if !isLoopback(s.config.Host) {
+			return fmt.Errorf("refusing to serve MCP over HTTP on %s without API keys, set MCP_API_KEYS_FILE or listen on localhost", s.config.Host)
+		}
+		s.logger.Warn("MCP server accepts requests without API keys, since it only listens on localhost", "host", s.config.Host)
+		return nil
+	}
+
+	keys, err := mcp.LoadAPIKeys(s.config.APIKeysFile)
+	if err != nil {
+		return err
+	}
+	if err := server.SetAPIKeys(keys); err != nil {
+		return fmt.Errorf("invalid API keys in %s: %w", s.config.APIKeysFile, err)
+	}
+	s.logger.Info("MCP server requires API keys", "keys", len(keys))
+	return nil
+}
+
+// isLoopback reports whether host only accepts local connections.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,34 @@
+package mcpserver
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/mcp"
+)
+
+func TestSetAPIKeysRefusesPublicHostWithoutKeys(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, test := range []struct {
+		host    string
+		wantErr bool
+	}{
+		{host: "localhost"},
+		{host: "127.0.0.1"},
+		{host: "::1"},
+		{host: "0.0.0.0", wantErr: true},
+		{host: "", wantErr: true},
+		{host: "example.com", wantErr: true},
+	} {
+		s := New(nil, &Config{GroupID: "default", Transport: "http", Host: test.host, Port: 3000}, logger)
+		err := s.setAPIKeys(mcp.NewServer("test", "1.0.0", logger))
+		if (err != nil) != test.wantErr {
+			t.Errorf("setAPIKeys() on %q: error %v, want error %v", test.host, err, test.wantErr)
+		}
+		if err != nil && !strings.Contains(err.Error(), "without API keys") {
+			t.Errorf("setAPIKeys() on %q: unexpected error %v", test.host, err)
+		}
+	}
+}
@@ -0,0 +1,1267 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/soundprediction/go-predicato/pkg/mcp"
+	"github.com/soundprediction/go-predicato/pkg/search/recipes"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// maxTraverseDepth caps the depth of the traverse tool, since the number of
+// nodes reached grows quickly with each hop
+const maxTraverseDepth = 5
+
+// Tool request/response types
+
+// AddMemoryRequest represents the parameters for adding memory
+type AddMemoryRequest struct {
+	Name              string `json:"name" description:"Name or title of the episode"`
+	EpisodeBody       string `json:"episode_body" description:"The content of the episode to add"`
+	GroupID           string `json:"group_id,omitempty" description:"Group ID to add the episode to (default: the server group)"`
+	Source            string `json:"source,omitempty" enum:"text,json,message" description:"Type of the content (default: text)"`
+	SourceDescription string `json:"source_description,omitempty" description:"Description of the source of the episode"`
+	UUID              string `json:"uuid,omitempty" description:"Custom UUID for the episode"`
+}
+
+// SearchRequest represents search parameters
+type SearchRequest struct {
+	Query          string   `json:"query" description:"Search query"`
+	Limit          int      `json:"limit,omitempty" description:"Maximum number of results (default: 10)"`
+	GroupIDs       []string `json:"group_ids,omitempty" description:"Group IDs to search (default: the server group)"`
+	MaxNodes       int      `json:"max_nodes,omitempty" description:"Maximum number of nodes, overriding limit"`
+	MaxFacts       int      `json:"max_facts,omitempty" description:"Maximum number of facts, overriding limit"`
+	CenterNodeUUID string   `json:"center_node_uuid,omitempty" description:"UUID of a node to rank results by their distance to"`
+	Entity         string   `json:"entity,omitempty" description:"Single entity type to filter results by"`
+	Cursor         string   `json:"cursor,omitempty" description:"next_cursor of the previous page"`
+	// SearchRecipe names a predefined search configuration, such as
+	// "node_hybrid_mmr", instead of the default one of the tool
+	SearchRecipe string `json:"search_recipe,omitempty" description:"Predefined search configuration to use instead of the default"`
+	// SavedSearch names a search configuration saved in the first group,
+	// used instead of SearchRecipe
+	SavedSearch string `json:"saved_search,omitempty" description:"Name of a saved search to use instead of search_recipe"`
+	// BFSOriginNodeUUIDs are known nodes to expand the search from by
+	// breadth-first search, up to BFSMaxDepth hops along the relations
+	// named in BFSEdgeNames (all relations when empty)
+	BFSOriginNodeUUIDs []string `json:"bfs_origin_node_uuids,omitempty" description:"UUIDs of known nodes to expand the search from by breadth-first search"`
+	BFSMaxDepth        int      `json:"bfs_max_depth,omitempty" description:"Maximum number of hops from the origin nodes"`
+	BFSEdgeNames       []string `json:"bfs_edge_names,omitempty" description:"Relation names the expansion may follow, e.g. WORKS_AT (default: all)"`
+}
+
+// ListSavedSearchesRequest represents parameters for listing saved searches
+type ListSavedSearchesRequest struct {
+	GroupID string `json:"group_id,omitempty" description:"Group ID to list the saved searches of (default: the server group)"`
+}
+
+// GetEpisodesRequest represents parameters for retrieving episodes
+type GetEpisodesRequest struct {
+	GroupID string `json:"group_id,omitempty" description:"Group ID to get episodes from (default: the server group)"`
+	LastN   int    `json:"last_n,omitempty" description:"Number of recent episodes to get (default: 10)"`
+}
+
+// GetQueueStatusRequest represents parameters for getting the episode queue status
+type GetQueueStatusRequest struct {
+	GroupID string `json:"group_id,omitempty" description:"Group ID to get the queue status of (default: all groups)"`
+}
+
+// ClearGraphRequest represents parameters for clearing the graph
+type ClearGraphRequest struct {
+	GroupID string `json:"group_id,omitempty" description:"Group ID to clear (default: all groups)"`
+}
+
+// UUIDRequest represents a simple UUID parameter
+type UUIDRequest struct {
+	UUID string `json:"uuid" description:"UUID of the entity edge or episode"`
+}
+
+// GetEntityRequest represents parameters for getting an entity node
+type GetEntityRequest struct {
+	UUID         string `json:"uuid" description:"UUID of the entity node"`
+	GroupID      string `json:"group_id,omitempty" description:"Group ID of the node (default: the server group)"`
+	MaxNeighbors int    `json:"max_neighbors,omitempty" description:"Maximum number of neighbors, most connected first (default: 10)"`
+}
+
+// TraverseRequest represents parameters for walking the graph from a node
+type TraverseRequest struct {
+	UUID      string   `json:"uuid" description:"UUID of the entity node to start from"`
+	GroupID   string   `json:"group_id,omitempty" description:"Group ID of the node (default: the server group)"`
+	Depth     int      `json:"depth,omitempty" description:"Maximum number of hops from the start node (default: 2, at most 5)"`
+	EdgeTypes []string `json:"edge_types,omitempty" description:"Relation names to follow, e.g. WORKS_AT (default: all)"`
+	MaxNodes  int      `json:"max_nodes,omitempty" description:"Maximum number of nodes to visit, start node included (default: 50)"`
+}
+
+// AddTripletRequest represents parameters for asserting a fact between two entities
+type AddTripletRequest struct {
+	SourceName    string `json:"source_name" description:"Name of the subject entity"`
+	SourceSummary string `json:"source_summary,omitempty" description:"Summary of the subject entity"`
+	Relation      string `json:"relation" description:"Relation name in SCREAMING_SNAKE_CASE, e.g. WORKS_AT"`
+	Fact          string `json:"fact" description:"The fact in natural language, e.g. Alice works at Acme"`
+	TargetName    string `json:"target_name" description:"Name of the object entity"`
+	TargetSummary string `json:"target_summary,omitempty" description:"Summary of the object entity"`
+	ValidAt       string `json:"valid_at,omitempty" description:"RFC 3339 time the fact became true (default: now)"`
+	GroupID       string `json:"group_id,omitempty" description:"Group ID to add the fact to (default: the server group)"`
+}
+
+// InvalidateFactRequest represents parameters for retracting a fact
+type InvalidateFactRequest struct {
+	EdgeUUID string `json:"edge_uuid" description:"UUID of the entity edge holding the fact"`
+	Reason   string `json:"reason,omitempty" description:"Why the fact no longer holds"`
+	GroupID  string `json:"group_id,omitempty" description:"Group ID of the fact (default: the server group)"`
+}
+
+// Response types
+
+// ToolResponse is a generic response wrapper
+type ToolResponse struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// Failed reports whether the tool failed, so that the failure is counted in
+// the metrics
+func (r *ToolResponse) Failed() bool {
+	return !r.Success
+}
+
+// AddMemoryTool handles adding episodes to memory
+// This is the primary way to add information to the graph.
+// Returns immediately and processes the episode addition.
+func (s *Server) AddMemoryTool(ctx context.Context, input *AddMemoryRequest) (*ToolResponse, error) {
+	// Validate required fields
+	if input.Name == "" {
+		return &ToolResponse{
+			Success: false,
+			Error:   "Name is required",
+		}, nil
+	}
+	if input.EpisodeBody == "" {
+		return &ToolResponse{
+			Success: false,
+			Error:   "EpisodeBody is required",
+		}, nil
+	}
+
+	// Set defaults
+	if input.Source == "" {
+		input.Source = "text"
+	}
+	groupID, err := s.groupID(ctx, input.GroupID)
+	if err != nil {
+		return &ToolResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	input.GroupID = groupID
+
+	episodeType, err := sourceEpisodeType(input.Source, input.EpisodeBody)
+	if err != nil {
+		return &ToolResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// Create episode
+	episode := types.Episode{
+		ID:        input.UUID, // Will be generated if empty
+		Name:      input.Name,
+		Content:   input.EpisodeBody,
+		Reference: time.Now(),
+		CreatedAt: time.Now(),
+		GroupID:   input.GroupID,
+		Metadata: map[string]interface{}{
+			"source":                           input.Source,
+			types.SourceDescriptionMetadataKey: input.SourceDescription,
+			types.EpisodeTypeMetadataKey:       string(episodeType),
+		},
+	}
+
+	// Queue the episode, since extraction can take longer than MCP clients
+	// wait for a response
+	position, err := s.queue.Enqueue(episode)
+	if err != nil {
+		s.logger.Warn("Failed to queue episode", "name", input.Name, "group_id", input.GroupID, "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to queue episode: %v", err),
+		}, nil
+	}
+
+	s.logger.Info("Episode queued", "name", input.Name, "group_id", input.GroupID, "position", position)
+	return &ToolResponse{
+		Success: true,
+		Message: fmt.Sprintf("Episode '%s' queued for processing (position: %d)", input.Name, position),
+		Data: map[string]interface{}{
+			"group_id": input.GroupID,
+			"position": position,
+		},
+	}, nil
+}
+
+// sourceEpisodeType maps the source of add_memory to the EpisodeType
+// choosing the extraction prompts, like the Python server: text episodes are
+// read as prose, message episodes as "speaker: message" lines, and json
+// episodes, which must be valid JSON, by their keys and values.
+func sourceEpisodeType(source, body string) (types.EpisodeType, error) {
+	switch source {
+	case "text":
+		return types.DocumentEpisodeType, nil
+	case "message":
+		return types.MessageEpisodeType, nil
+	case "json":
+		if !json.Valid([]byte(body)) {
+			return "", fmt.Errorf("episode_body is not valid JSON")
+		}
+		return types.JSONEpisodeType, nil
+	default:
+		return "", fmt.Errorf("unknown source '%s', expected text, json or message", source)
+	}
+}
+
+// addEpisode adds a queued episode to the graph
+func (s *Server) addEpisode(ctx context.Context, episode types.Episode) error {
+	// TODO: Add support for custom entities when s.config.UseCustomEntities is true
+	_, err := s.client.Add(ctx, []types.Episode{episode}, nil)
+	return err
+}
+
+// GetQueueStatusTool reports the episodes queued by add_memory
+func (s *Server) GetQueueStatusTool(ctx context.Context, input *GetQueueStatusRequest) (*ToolResponse, error) {
+	if input.GroupID != "" && !s.groupAllowed(ctx, input.GroupID) {
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("group '%s' is not allowed", input.GroupID),
+		}, nil
+	}
+
+	// Only report the groups the request may use
+	statuses := []QueueStatus{}
+	for _, status := range s.queue.Status(input.GroupID) {
+		if s.groupAllowed(ctx, status.GroupID) {
+			statuses = append(statuses, status)
+		}
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		pending += status.Queued
+		if status.Processing != "" {
+			pending++
+		}
+	}
+	return &ToolResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d episodes pending", pending),
+		Data: map[string]interface{}{
+			"groups": statuses,
+		},
+	}, nil
+}
+
+// SearchMemoryNodesTool handles searching for nodes
+// These contain a summary of all of a node's relationships with other nodes.
+func (s *Server) SearchMemoryNodesTool(ctx context.Context, input *SearchRequest) (*ToolResponse, error) {
+	// Validate required fields
+	if input.Query == "" {
+		return &ToolResponse{
+			Success: false,
+			Error:   "Query is required",
+		}, nil
+	}
+
+	// Set defaults - support both Limit and MaxNodes for compatibility
+	limit := input.Limit
+	if input.MaxNodes > 0 {
+		limit = input.MaxNodes
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	// Use provided group_ids or fall back to default
+	groupIDs, err := s.groupIDs(ctx, input.GroupIDs)
+	if err != nil {
+		return &ToolResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// Create search configuration based on whether center node is specified
+	searchConfig, err := s.requestSearchConfig(ctx, input, groupIDs[0])
+	if err != nil {
+		return &ToolResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if searchConfig == nil {
+		searchConfig = &types.SearchConfig{
+			CenterNodeDistance: 2,
+			MinScore:           0.0,
+			IncludeEdges:       false,
+			Rerank:             true,
+			NodeConfig: &types.NodeSearchConfig{
+				SearchMethods: []string{"bm25", "cosine_similarity"},
+				Reranker:      "rrf",
+				MinScore:      0.0,
+			},
+		}
+	}
+	searchConfig.Limit = limit
+	searchConfig.Cursor = input.Cursor
+	if searchConfig.Filters == nil {
+		searchConfig.Filters = &types.SearchFilters{}
+	}
+	searchConfig.Filters.GroupIDs = groupIDs
+	applyBFSOrigins(searchConfig, input)
+
+	// Apply entity filtering if specified (similar to Python's entity parameter)
+	if input.Entity != "" {
+		// Add entity type filtering logic here when supported by the Go client
+		s.logger.Info("Entity filtering requested", "entity", input.Entity)
+	}
+
+	// Perform search
+	results, err := s.client.Search(ctx, input.Query, searchConfig)
+	if err != nil {
+		s.logger.Error("Failed to search nodes", "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to search nodes: %v", err),
+		}, nil
+	}
+
+	if len(results.Nodes) == 0 {
+		return &ToolResponse{
+			Success: true,
+			Message: "No relevant nodes found",
+			Data: map[string]interface{}{
+				"nodes": []interface{}{},
+			},
+		}, nil
+	}
+
+	// Format results to match Python format
+	nodeResults := make([]map[string]interface{}, len(results.Nodes))
+	for i, node := range results.Nodes {
+		nodeResults[i] = nodeResult(node)
+	}
+
+	return &ToolResponse{
+		Success: true,
+		Message: "Nodes retrieved successfully",
+		Data: map[string]interface{}{
+			"nodes":       nodeResults,
+			"next_cursor": results.NextCursor,
+		},
+	}, nil
+}
+
+// SearchMemoryFactsTool handles searching for facts (edges)
+// Search the graph memory for relevant facts.
+func (s *Server) SearchMemoryFactsTool(ctx context.Context, input *SearchRequest) (*ToolResponse, error) {
+	// Validate required fields
+	if input.Query == "" {
+		return &ToolResponse{
+			Success: false,
+			Error:   "Query is required",
+		}, nil
+	}
+
+	// Set defaults - support both Limit and MaxFacts for compatibility
+	limit := input.Limit
+	if input.MaxFacts > 0 {
+		limit = input.MaxFacts
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	// Validate limit parameter
+	if limit <= 0 {
+		return &ToolResponse{
+			Success: false,
+			Error:   "limit must be a positive integer",
+		}, nil
+	}
+
+	// Use provided group_ids or fall back to default
+	groupIDs, err := s.groupIDs(ctx, input.GroupIDs)
+	if err != nil {
+		return &ToolResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// Create search configuration focused on edges
+	searchConfig, err := s.requestSearchConfig(ctx, input, groupIDs[0])
+	if err != nil {
+		return &ToolResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if searchConfig == nil {
+		searchConfig = &types.SearchConfig{
+			CenterNodeDistance: 2,
+			MinScore:           0.0,
+			IncludeEdges:       true,
+			Rerank:             true,
+			EdgeConfig: &types.EdgeSearchConfig{
+				SearchMethods: []string{"bm25", "cosine_similarity"},
+				Reranker:      "rrf",
+				MinScore:      0.0,
+			},
+		}
+	}
+	searchConfig.Limit = limit
+	searchConfig.Cursor = input.Cursor
+	if searchConfig.Filters == nil {
+		searchConfig.Filters = &types.SearchFilters{}
+	}
+	searchConfig.Filters.GroupIDs = groupIDs
+	applyBFSOrigins(searchConfig, input)
+
+	// Perform search
+	results, err := s.client.Search(ctx, input.Query, searchConfig)
+	if err != nil {
+		s.logger.Error("Failed to search facts", "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to search facts: %v", err),
+		}, nil
+	}
+
+	if len(results.Edges) == 0 {
+		return &ToolResponse{
+			Success: true,
+			Message: "No relevant facts found",
+			Data: map[string]interface{}{
+				"facts": []interface{}{},
+			},
+		}, nil
+	}
+
+	// Format results to match Python format
+	facts := make([]map[string]interface{}, len(results.Edges))
+	for i, edge := range results.Edges {
+		facts[i] = map[string]interface{}{
+			"uuid":       edge.Uuid,
+			"type":       string(edge.Type),
+			"source_id":  edge.SourceID,
+			"target_id":  edge.TargetID,
+			"name":       edge.Name,
+			"summary":    edge.Summary,
+			"strength":   edge.Strength,
+			"group_id":   edge.GroupID,
+			"created_at": edge.CreatedAt.Format(time.RFC3339),
+			"updated_at": edge.UpdatedAt.Format(time.RFC3339),
+			"valid_from": edge.ValidFrom.Format(time.RFC3339),
+			"metadata":   edge.Metadata,
+		}
+		if edge.ValidTo != nil {
+			facts[i]["valid_to"] = edge.ValidTo.Format(time.RFC3339)
+		}
+	}
+
+	return &ToolResponse{
+		Success: true,
+		Message: "Facts retrieved successfully",
+		Data: map[string]interface{}{
+			"facts":       facts,
+			"next_cursor": results.NextCursor,
+		},
+	}, nil
+}
+
+// DeleteEntityEdgeTool handles deleting entity edges
+func (s *Server) DeleteEntityEdgeTool(ctx context.Context, input *UUIDRequest) (*ToolResponse, error) {
+	if input.UUID == "" {
+		return &ToolResponse{
+			Success: false,
+			Error:   "UUID is required",
+		}, nil
+	}
+
+	// Try to get the edge first to check if it exists and get its group_id
+	edge, err := s.client.GetEdge(ctx, input.UUID)
+	if err != nil {
+		s.logger.Error("Failed to get entity edge for deletion", "uuid", input.UUID, "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to get entity edge: %v", err),
+		}, nil
+	}
+	if !s.groupAllowed(ctx, edge.GroupID) {
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Entity edge with UUID %s is not in an allowed group", input.UUID),
+		}, nil
+	}
+
+	// Delete the edge using the driver
+	err = s.client.GetDriver().DeleteEdge(ctx, edge.Uuid, edge.GroupID)
+	if err != nil {
+		s.logger.Error("Failed to delete entity edge", "uuid", input.UUID, "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to delete entity edge: %v", err),
+		}, nil
+	}
+
+	s.logger.Info("Entity edge deleted successfully", "uuid", input.UUID)
+	return &ToolResponse{
+		Success: true,
+		Message: fmt.Sprintf("Entity edge with UUID %s deleted successfully", input.UUID),
+	}, nil
+}
+
+// DeleteEpisodeTool handles deleting episodes
+func (s *Server) DeleteEpisodeTool(ctx context.Context, input *UUIDRequest) (*ToolResponse, error) {
+	if input.UUID == "" {
+		return &ToolResponse{
+			Success: false,
+			Error:   "UUID is required",
+		}, nil
+	}
+
+	// Try to get the node first to check if it exists and get its group_id
+	episode, err := s.client.GetNode(ctx, input.UUID)
+	if err != nil {
+		s.logger.Error("Failed to get episode for deletion", "uuid", input.UUID, "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to get episode: %v", err),
+		}, nil
+	}
+	if !s.groupAllowed(ctx, episode.GroupID) {
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Episode with UUID %s is not in an allowed group", input.UUID),
+		}, nil
+	}
+
+	// Delete the episode
+	err = s.client.RemoveEpisode(ctx, input.UUID)
+	if err != nil {
+		s.logger.Error("Failed to delete episode", "uuid", input.UUID, "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to delete episode: %v", err),
+		}, nil
+	}
+
+	s.logger.Info("Episode deleted successfully", "uuid", input.UUID)
+	return &ToolResponse{
+		Success: true,
+		Message: fmt.Sprintf("Episode with UUID %s deleted successfully", input.UUID),
+	}, nil
+}
+
+// GetEntityEdgeTool handles getting entity edges by UUID
+func (s *Server) GetEntityEdgeTool(ctx context.Context, input *UUIDRequest) (*ToolResponse, error) {
+	if input.UUID == "" {
+		return &ToolResponse{
+			Success: false,
+			Error:   "UUID is required",
+		}, nil
+	}
+
+	// Get edge using Predicato client
+	edge, err := s.client.GetEdge(ctx, input.UUID)
+	if err != nil {
+		s.logger.Error("Failed to get entity edge", "uuid", input.UUID, "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to get entity edge: %v", err),
+		}, nil
+	}
+	if !s.groupAllowed(ctx, edge.GroupID) {
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Entity edge with UUID %s is not in an allowed group", input.UUID),
+		}, nil
+	}
+
+	// Format edge result
+	result := map[string]interface{}{
+		"uuid":       edge.Uuid,
+		"type":       string(edge.Type),
+		"source_id":  edge.SourceID,
+		"target_id":  edge.TargetID,
+		"name":       edge.Name,
+		"summary":    edge.Summary,
+		"strength":   edge.Strength,
+		"group_id":   edge.GroupID,
+		"created_at": edge.CreatedAt.Format(time.RFC3339),
+		"updated_at": edge.UpdatedAt.Format(time.RFC3339),
+		"valid_from": edge.ValidFrom.Format(time.RFC3339),
+		"metadata":   edge.Metadata,
+	}
+	if edge.ValidTo != nil {
+		result["valid_to"] = edge.ValidTo.Format(time.RFC3339)
+	}
+
+	return &ToolResponse{
+		Success: true,
+		Message: "Entity edge retrieved successfully",
+		Data:    result,
+	}, nil
+}
+
+// GetEntityTool handles getting an entity node with its summary, attributes
+// and most connected neighbors, with the facts relating them to it
+func (s *Server) GetEntityTool(ctx context.Context, input *GetEntityRequest) (*ToolResponse, error) {
+	if input.UUID == "" {
+		return &ToolResponse{
+			Success: false,
+			Error:   "UUID is required",
+		}, nil
+	}
+
+	groupID, err := s.groupID(ctx, input.GroupID)
+	if err != nil {
+		return &ToolResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	maxNeighbors := input.MaxNeighbors
+	if maxNeighbors <= 0 {
+		maxNeighbors = 10
+	}
+
+	graph := s.client.GetDriver()
+	node, err := graph.GetNode(ctx, input.UUID, groupID)
+	if err != nil {
+		s.logger.Error("Failed to get entity", "uuid", input.UUID, "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to get entity: %v", err),
+		}, nil
+	}
+
+	neighbors, err := graph.GetNodeNeighbors(ctx, node.Uuid, groupID)
+	if err != nil {
+		s.logger.Error("Failed to get entity neighbors", "uuid", input.UUID, "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to get entity neighbors: %v", err),
+		}, nil
+	}
+	sort.SliceStable(neighbors, func(i, j int) bool { return neighbors[i].EdgeCount > neighbors[j].EdgeCount })
+	if len(neighbors) > maxNeighbors {
+		neighbors = neighbors[:maxNeighbors]
+	}
+
+	neighborIDs := make([]string, len(neighbors))
+	for i, neighbor := range neighbors {
+		neighborIDs[i] = neighbor.NodeUUID
+	}
+	neighborNodes := make(map[string]*types.Node, len(neighbors))
+	if len(neighborIDs) > 0 {
+		nodes, err := graph.GetNodes(ctx, neighborIDs, groupID)
+		if err != nil {
+			s.logger.Error("Failed to get entity neighbors", "uuid", input.UUID, "error", err)
+			return &ToolResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Failed to get entity neighbors: %v", err),
+			}, nil
+		}
+		for _, n := range nodes {
+			neighborNodes[n.Uuid] = n
+		}
+	}
+
+	neighborResults := make([]map[string]interface{}, 0, len(neighbors))
+	for _, neighbor := range neighbors {
+		n, ok := neighborNodes[neighbor.NodeUUID]
+		if !ok {
+			continue
+		}
+		edges, err := graph.GetBetweenNodes(ctx, node.Uuid, n.Uuid, groupID)
+		if err != nil {
+			s.logger.Warn("Failed to get facts between entities", "source", node.Uuid, "target", n.Uuid, "error", err)
+		}
+		facts := make([]map[string]interface{}, len(edges))
+		for i, edge := range edges {
+			facts[i] = factResult(edge)
+		}
+		neighborResults = append(neighborResults, map[string]interface{}{
+			"uuid":       n.Uuid,
+			"name":       n.Name,
+			"summary":    n.Summary,
+			"edge_count": neighbor.EdgeCount,
+			"facts":      facts,
+		})
+	}
+
+	result := nodeResult(node)
+	result["neighbors"] = neighborResults
+
+	return &ToolResponse{
+		Success: true,
+		Message: "Entity retrieved successfully",
+		Data:    result,
+	}, nil
+}
+
+// TraverseTool handles walking the graph breadth-first from an entity node
+// along entity edges, optionally only those with the given relation names
+func (s *Server) TraverseTool(ctx context.Context, input *TraverseRequest) (*ToolResponse, error) {
+	if input.UUID == "" {
+		return &ToolResponse{
+			Success: false,
+			Error:   "UUID is required",
+		}, nil
+	}
+
+	groupID, err := s.groupID(ctx, input.GroupID)
+	if err != nil {
+		return &ToolResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	depth := input.Depth
+	if depth <= 0 {
+		depth = 2
+	}
+	depth = min(depth, maxTraverseDepth)
+	maxNodes := input.MaxNodes
+	if maxNodes <= 0 {
+		maxNodes = 50
+	}
+
+	// Only entity edges are followed, so that the walk does not wander
+	// through episodes and communities
+	edgeTypes := []types.EdgeType{types.EntityEdgeType}
+	if len(input.EdgeTypes) > 0 {
+		edgeTypes = make([]types.EdgeType, len(input.EdgeTypes))
+		for i, name := range input.EdgeTypes {
+			edgeTypes[i] = types.EdgeType(name)
+		}
+	}
+
+	graph := s.client.GetDriver()
+	start, err := graph.GetNode(ctx, input.UUID, groupID)
+	if err != nil {
+		s.logger.Error("Failed to get start node", "uuid", input.UUID, "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to get start node: %v", err),
+		}, nil
+	}
+
+	startResult := nodeResult(start)
+	startResult["depth"] = 0
+	nodes := []map[string]interface{}{startResult}
+	visited := map[string]bool{start.Uuid: true}
+	var edges []map[string]interface{}
+	seenEdges := make(map[string]bool)
+	truncated := false
+
+	frontier := []string{start.Uuid}
+	for hop := 1; hop <= depth && len(frontier) > 0; hop++ {
+		var next []string
+		for _, nodeUUID := range frontier {
+			related, err := graph.GetRelatedNodes(ctx, nodeUUID, groupID, edgeTypes)
+			if err != nil {
+				s.logger.Error("Failed to traverse graph", "uuid", nodeUUID, "error", err)
+				return &ToolResponse{
+					Success: false,
+					Error:   fmt.Sprintf("Failed to traverse graph: %v", err),
+				}, nil
+			}
+
+			for _, node := range related {
+				if !visited[node.Uuid] {
+					if len(nodes) >= maxNodes {
+						truncated = true
+						continue
+					}
+					visited[node.Uuid] = true
+					result := nodeResult(node)
+					result["depth"] = hop
+					nodes = append(nodes, result)
+					next = append(next, node.Uuid)
+				}
+
+				between, err := graph.GetBetweenNodes(ctx, nodeUUID, node.Uuid, groupID)
+				if err != nil {
+					s.logger.Warn("Failed to get facts between entities", "source", nodeUUID, "target", node.Uuid, "error", err)
+					continue
+				}
+				for _, edge := range between {
+					if seenEdges[edge.Uuid] || (len(input.EdgeTypes) > 0 && !slices.Contains(input.EdgeTypes, edge.Name)) {
+						continue
+					}
+					seenEdges[edge.Uuid] = true
+					edges = append(edges, factResult(edge))
+				}
+			}
+		}
+		frontier = next
+	}
+
+	message := fmt.Sprintf("Visited %d nodes within %d hops", len(nodes), depth)
+	if truncated {
+		message += fmt.Sprintf(" (stopped at max_nodes %d)", maxNodes)
+	}
+
+	return &ToolResponse{
+		Success: true,
+		Message: message,
+		Data: map[string]interface{}{
+			"nodes":     nodes,
+			"facts":     edges,
+			"truncated": truncated,
+		},
+	}, nil
+}
+
+// AddTripletTool handles asserting a fact directly, without extracting it
+// from an episode. The entities are deduplicated against the graph and the
+// fact may invalidate contradicting ones, as for extracted facts.
+func (s *Server) AddTripletTool(ctx context.Context, input *AddTripletRequest) (*ToolResponse, error) {
+	if input.SourceName == "" || input.TargetName == "" || input.Relation == "" || input.Fact == "" {
+		return &ToolResponse{
+			Success: false,
+			Error:   "source_name, relation, fact and target_name are required",
+		}, nil
+	}
+
+	groupID, err := s.groupID(ctx, input.GroupID)
+	if err != nil {
+		return &ToolResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	now := time.Now().UTC()
+	validAt := now
+	if input.ValidAt != "" {
+		validAt, err = time.Parse(time.RFC3339, input.ValidAt)
+		if err != nil {
+			return &ToolResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Invalid valid_at: %v", err),
+			}, nil
+		}
+	}
+
+	newEntity := func(name, summary string) *types.Node {
+		return &types.Node{
+			Uuid:       uuid.NewString(),
+			Name:       name,
+			Type:       types.EntityNodeType,
+			GroupID:    groupID,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+			ValidFrom:  now,
+			EntityType: "Entity",
+			Summary:    summary,
+		}
+	}
+	source := newEntity(input.SourceName, input.SourceSummary)
+	target := newEntity(input.TargetName, input.TargetSummary)
+
+	edge := types.NewEntityEdge(uuid.NewString(), source.Uuid, target.Uuid, groupID, input.Relation, types.EntityEdgeType)
+	edge.Fact = input.Fact
+	edge.Summary = input.Fact
+	edge.UpdatedAt = now
+	edge.ValidFrom = validAt
+	edge.ValidAt = &validAt
+
+	results, err := s.client.AddTriplet(ctx, source, edge, target, true)
+	if err != nil {
+		s.logger.Error("Failed to add triplet", "relation", input.Relation, "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to add triplet: %v", err),
+		}, nil
+	}
+
+	nodes := make([]map[string]interface{}, len(results.Nodes))
+	for i, node := range results.Nodes {
+		nodes[i] = nodeResult(node)
+	}
+	facts := make([]map[string]interface{}, len(results.Edges))
+	for i, e := range results.Edges {
+		facts[i] = factResult(e)
+		if e.ValidTo != nil {
+			facts[i]["valid_to"] = e.ValidTo.Format(time.RFC3339)
+		}
+	}
+
+	return &ToolResponse{
+		Success: true,
+		Message: "Triplet added successfully",
+		Data: map[string]interface{}{
+			"nodes": nodes,
+			"facts": facts,
+		},
+	}, nil
+}
+
+// InvalidateFactTool handles retracting a fact. The edge is expired rather
+// than deleted, so that the graph still answers what was known before.
+func (s *Server) InvalidateFactTool(ctx context.Context, input *InvalidateFactRequest) (*ToolResponse, error) {
+	if input.EdgeUUID == "" {
+		return &ToolResponse{
+			Success: false,
+			Error:   "edge_uuid is required",
+		}, nil
+	}
+
+	groupID, err := s.groupID(ctx, input.GroupID)
+	if err != nil {
+		return &ToolResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	edge, err := s.client.InvalidateFact(ctx, groupID, input.EdgeUUID, input.Reason)
+	if err != nil {
+		s.logger.Error("Failed to invalidate fact", "uuid", input.EdgeUUID, "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to invalidate fact: %v", err),
+		}, nil
+	}
+
+	result := factResult(edge)
+	result["valid_to"] = edge.ValidTo.Format(time.RFC3339)
+	return &ToolResponse{
+		Success: true,
+		Message: fmt.Sprintf("Fact with UUID %s invalidated successfully", input.EdgeUUID),
+		Data:    result,
+	}, nil
+}
+
+// GetEpisodesTool handles getting recent episodes
+// Get the most recent memory episodes for a specific group.
+func (s *Server) GetEpisodesTool(ctx context.Context, input *GetEpisodesRequest) (*ToolResponse, error) {
+	s.logger.Info("Get episodes requested", "group_id", input.GroupID, "last_n", input.LastN)
+
+	// Set default values
+	groupID, err := s.groupID(ctx, input.GroupID)
+	if err != nil {
+		return &ToolResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	limit := input.LastN
+	if limit <= 0 {
+		limit = 10 // Default to 10 episodes
+	}
+
+	// Use the Predicato client to retrieve episodes
+	episodeNodes, err := s.client.GetEpisodes(ctx, groupID, limit)
+	if err != nil {
+		s.logger.Error("Failed to retrieve episodes", "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to retrieve episodes: %v", err),
+		}, nil
+	}
+
+	if len(episodeNodes) == 0 {
+		return &ToolResponse{
+			Success: true,
+			Message: fmt.Sprintf("No episodes found for group %s", groupID),
+			Data: map[string]interface{}{
+				"episodes": []interface{}{},
+				"total":    0,
+				"group_id": groupID,
+			},
+		}, nil
+	}
+
+	// Convert nodes to episode format (matching Python's format)
+	var episodes []map[string]interface{}
+	for _, node := range episodeNodes {
+		episode := map[string]interface{}{
+			"uuid":       node.Uuid,
+			"name":       node.Name,
+			"content":    node.Content,
+			"group_id":   node.GroupID,
+			"created_at": node.CreatedAt.Format(time.RFC3339),
+		}
+
+		// Add episode type if available
+		if node.EpisodeType != "" {
+			episode["episode_type"] = string(node.EpisodeType)
+		}
+
+		// Add reference time if available
+		if !node.Reference.IsZero() {
+			episode["reference"] = node.Reference.Format(time.RFC3339)
+		}
+
+		// Add metadata if available
+		if node.Metadata != nil {
+			episode["metadata"] = node.Metadata
+		}
+
+		// Add summary if available
+		if node.Summary != "" {
+			episode["summary"] = node.Summary
+		}
+
+		episodes = append(episodes, episode)
+	}
+
+	s.logger.Info("Retrieved episodes", "count", len(episodes))
+
+	// Return format that matches Python version - direct list or structured response
+	if len(episodes) == 1 && input.LastN == 1 {
+		// For single episode requests, return just the episodes array (like Python)
+		return &ToolResponse{
+			Success: true,
+			Message: fmt.Sprintf("Retrieved %d episodes", len(episodes)),
+			Data:    episodes, // Direct array
+		}, nil
+	}
+
+	return &ToolResponse{
+		Success: true,
+		Message: fmt.Sprintf("Retrieved %d episodes", len(episodes)),
+		Data: map[string]interface{}{
+			"episodes": episodes,
+			"total":    len(episodes),
+			"group_id": groupID,
+		},
+	}, nil
+}
+
+// ClearGraphTool handles clearing the entire graph
+// Clear all data from the graph memory and rebuild indices.
+func (s *Server) ClearGraphTool(ctx context.Context, input *ClearGraphRequest) (*ToolResponse, error) {
+	s.logger.Info("Clear graph requested", "group_id", input.GroupID)
+
+	// Set default group ID (use all groups if not specified, like Python
+	// version, unless other groups than the allowed ones must be kept)
+	groupID := input.GroupID
+	if groupID == "" && s.groupsRestricted(ctx) {
+		groupID = s.defaultGroupID(ctx)
+	}
+	if groupID != "" && !s.groupAllowed(ctx, groupID) {
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("group '%s' is not allowed", groupID),
+		}, nil
+	}
+
+	// Warn about the destructive operation
+	if groupID == "" {
+		s.logger.Warn("Clearing ALL data from graph")
+	} else {
+		s.logger.Warn("Clearing data from graph", "group_id", groupID)
+	}
+
+	// Use the Predicato client to clear the graph
+	err := s.client.ClearGraph(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to clear graph", "error", err, "group_id", groupID)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to clear graph: %v", err),
+		}, nil
+	}
+
+	// Rebuild indices like Python version
+	err = s.client.CreateIndices(ctx)
+	if err != nil {
+		s.logger.Error("Failed to rebuild indices after clearing graph", "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Graph cleared but failed to rebuild indices: %v", err),
+		}, nil
+	}
+
+	if groupID == "" {
+		s.logger.Info("Graph cleared successfully and indices rebuilt")
+		return &ToolResponse{
+			Success: true,
+			Message: "Graph cleared successfully and indices rebuilt",
+		}, nil
+	} else {
+		s.logger.Info("Graph cleared successfully", "group_id", groupID)
+		return &ToolResponse{
+			Success: true,
+			Message: fmt.Sprintf("Graph cleared successfully for group '%s' and indices rebuilt", groupID),
+			Data: map[string]interface{}{
+				"group_id": groupID,
+				"cleared":  true,
+			},
+		}, nil
+	}
+}
+
+// ListSavedSearchesTool handles listing the saved searches of a group
+func (s *Server) ListSavedSearchesTool(ctx context.Context, input *ListSavedSearchesRequest) (*ToolResponse, error) {
+	groupID, err := s.groupID(ctx, input.GroupID)
+	if err != nil {
+		return &ToolResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	searches, err := s.client.ListSavedSearches(ctx, groupID)
+	if err != nil {
+		s.logger.Error("Failed to list saved searches", "error", err)
+		return &ToolResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to list saved searches: %v", err),
+		}, nil
+	}
+
+	names := make([]string, 0, len(searches))
+	for _, saved := range searches {
+		names = append(names, saved.Name)
+	}
+	return &ToolResponse{
+		Success: true,
+		Message: fmt.Sprintf("Found %d saved searches", len(names)),
+		Data: map[string]interface{}{
+			"saved_searches": names,
+			"group_id":       groupID,
+		},
+	}, nil
+}
+
+// groupAllowed reports whether a request may use groupID: the server group
+// or any group of the allowlist, and over HTTP any group of its API key.
+// Without an allowlist and API key groups every group is allowed.
+func (s *Server) groupAllowed(ctx context.Context, groupID string) bool {
+	if key := mcp.KeyFromContext(ctx); key != nil && len(key.Groups) > 0 && !slices.Contains(key.Groups, groupID) {
+		return false
+	}
+	return len(s.config.AllowedGroupIDs) == 0 || groupID == s.config.GroupID || slices.Contains(s.config.AllowedGroupIDs, groupID)
+}
+
+// groupsRestricted reports whether a request may only use some groups.
+func (s *Server) groupsRestricted(ctx context.Context) bool {
+	key := mcp.KeyFromContext(ctx)
+	return len(s.config.AllowedGroupIDs) > 0 || (key != nil && len(key.Groups) > 0)
+}
+
+// defaultGroupID returns the group of a request naming none: the server
+// group, or the first group of its API key when the key may not use the
+// server group.
+func (s *Server) defaultGroupID(ctx context.Context) string {
+	if key := mcp.KeyFromContext(ctx); key != nil && len(key.Groups) > 0 && !slices.Contains(key.Groups, s.config.GroupID) {
+		return key.Groups[0]
+	}
+	return s.config.GroupID
+}
+
+// groupID returns the group named by a request, or its default group when
+// it names none.
+func (s *Server) groupID(ctx context.Context, requested string) (string, error) {
+	if requested == "" {
+		requested = s.defaultGroupID(ctx)
+	}
+	if !s.groupAllowed(ctx, requested) {
+		return "", fmt.Errorf("group '%s' is not allowed", requested)
+	}
+	return requested, nil
+}
+
+// groupIDs returns the groups named by a request, or its default group when
+// it names none.
+func (s *Server) groupIDs(ctx context.Context, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		requested = []string{s.defaultGroupID(ctx)}
+	}
+	for _, groupID := range requested {
+		if !s.groupAllowed(ctx, groupID) {
+			return nil, fmt.Errorf("group '%s' is not allowed", groupID)
+		}
+	}
+	return requested, nil
+}
+
+// nodeResult formats a node for tool results, with labels like the Python
+// server
+func nodeResult(node *types.Node) map[string]interface{} {
+	return map[string]interface{}{
+		"uuid":       node.Uuid,
+		"name":       node.Name,
+		"summary":    node.Summary,
+		"labels":     []string{string(node.Type)},
+		"group_id":   node.GroupID,
+		"created_at": node.CreatedAt.Format(time.RFC3339),
+		"attributes": node.Metadata,
+	}
+}
+
+// factResult formats an edge between two entities for tool results
+func factResult(edge *types.Edge) map[string]interface{} {
+	return map[string]interface{}{
+		"uuid":      edge.Uuid,
+		"name":      edge.Name,
+		"summary":   edge.Summary,
+		"source_id": edge.SourceID,
+		"target_id": edge.TargetID,
+	}
+}
+
+// requestSearchConfig returns the configuration of the saved search named by
+// the request in groupID, or else of its search recipe.
+func (s *Server) requestSearchConfig(ctx context.Context, input *SearchRequest, groupID string) (*types.SearchConfig, error) {
+	if input.SavedSearch != "" {
+		return s.client.SavedSearchConfig(ctx, groupID, input.SavedSearch)
+	}
+	return s.recipeSearchConfig(input.SearchRecipe)
+}
+
+// applyBFSOrigins makes the search expand from the origin nodes of the
+// request, adding breadth-first search to the node and edge searches that
+// search at all.
+func applyBFSOrigins(config *types.SearchConfig, input *SearchRequest) {
+	if len(input.BFSOriginNodeUUIDs) == 0 {
+		return
+	}
+	config.BFSOriginNodeUUIDs = input.BFSOriginNodeUUIDs
+	config.BFSMaxDepth = input.BFSMaxDepth
+	config.BFSEdgeNames = input.BFSEdgeNames
+	if config.NodeConfig != nil && len(config.NodeConfig.SearchMethods) > 0 && !slices.Contains(config.NodeConfig.SearchMethods, "bfs") {
+		config.NodeConfig.SearchMethods = append(slices.Clip(config.NodeConfig.SearchMethods), "bfs")
+	}
+	if config.EdgeConfig != nil && len(config.EdgeConfig.SearchMethods) > 0 && !slices.Contains(config.EdgeConfig.SearchMethods, "bfs") {
+		config.EdgeConfig.SearchMethods = append(slices.Clip(config.EdgeConfig.SearchMethods), "bfs")
+	}
+}
+
+// recipeSearchConfig returns the search recipe named by the request, or by
+// the server configuration when the request names none. It returns nil when
+// neither names a recipe.
+func (s *Server) recipeSearchConfig(name string) (*types.SearchConfig, error) {
+	if name == "" {
+		name = s.config.SearchRecipe
+	}
+	if name == "" {
+		return nil, nil
+	}
+	return recipes.Get(name)
+}
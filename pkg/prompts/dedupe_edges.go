@@ -26,6 +26,22 @@ func (d *DedupeEdgesVersions) Edge() PromptVersion        { return d.EdgePrompt
 func (d *DedupeEdgesVersions) EdgeList() PromptVersion    { return d.EdgeListPrompt }
 func (d *DedupeEdgesVersions) ResolveEdge() PromptVersion { return d.ResolveEdgePrompt }
 
+// updatePrompt replaces the prompt of the given name with the result of
+// update, reporting whether it exists.
+func (d *DedupeEdgesVersions) updatePrompt(name string, update func(PromptVersion) PromptVersion) bool {
+	switch name {
+	case "edge":
+		d.EdgePrompt = update(d.EdgePrompt)
+	case "edge_list":
+		d.EdgeListPrompt = update(d.EdgeListPrompt)
+	case "resolve_edge":
+		d.ResolveEdgePrompt = update(d.ResolveEdgePrompt)
+	default:
+		return false
+	}
+	return true
+}
+
 // dedupeEdgePrompt determines if edges are duplicates or contradictory.
 // Uses TSV format for episodes and facts to reduce token usage and improve LLM parsing.
 func dedupeEdgePrompt(context map[string]interface{}) ([]types.Message, error) {
@@ -43,17 +59,17 @@ func dedupeEdgePrompt(context map[string]interface{}) ([]types.Message, error) {
 		}
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
 
-	newFactTSV, err := ToPromptCSV(newFact, ensureASCII)
+	newFactTSV, err := ToPromptContext(context, newFact, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal new fact: %w", err)
 	}
 
-	existingFactsTSV, err := ToPromptCSV(existingFacts, ensureASCII)
+	existingFactsTSV, err := ToPromptContext(context, existingFacts, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal existing facts: %w", err)
 	}
@@ -72,7 +88,7 @@ func dedupeEdgePrompt(context map[string]interface{}) ([]types.Message, error) {
 %s
 </EXISTING FACTS>
 
-Note: PREVIOUS MESSAGES, NEW FACT, and EXISTING FACTS are provided in TSV (tab-separated values) format.
+Note: PREVIOUS MESSAGES, NEW FACT, and EXISTING FACTS are provided in %s format.
 
 Task:
 You have TWO separate lists of facts. Each list uses 'idx' as its index field, starting from 0.
@@ -107,7 +123,7 @@ duplicated_facts: []int
 contradicted_facts: []int
 fact_type: string
 </SCHEMA>
-`, previousEpisodesTSV, episodeContent, newFactTSV, existingFactsTSV)
+`, previousEpisodesTSV, episodeContent, newFactTSV, existingFactsTSV, contextFormatName(context))
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -129,7 +145,7 @@ func dedupeEdgeListPrompt(context map[string]interface{}) ([]types.Message, erro
 		}
 	}
 
-	edgesTSV, err := ToPromptCSV(edges, ensureASCII)
+	edgesTSV, err := ToPromptContext(context, edges, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal edges: %w", err)
 	}
@@ -137,12 +153,12 @@ func dedupeEdgeListPrompt(context map[string]interface{}) ([]types.Message, erro
 	userPrompt := fmt.Sprintf(`
 Given the following edges, identify unique facts and remove duplicates.
 
-Edges are provided in TSV (tab-separated values) format:
+Edges are provided in %s format:
 %s
 
 Task:
 Return a list of unique facts, removing any duplicates.
-`, edgesTSV)
+`, contextFormatName(context), edgesTSV)
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -166,19 +182,19 @@ func resolveEdgePrompt(context map[string]interface{}) ([]types.Message, error)
 		}
 	}
 
-	existingEdgesTSV, err := ToPromptCSV(existingEdges, ensureASCII)
+	existingEdgesTSV, err := ToPromptContext(context, existingEdges, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal existing edges: %w", err)
 	}
 
-	edgeInvalidationCandidatesTSV, err := ToPromptCSV(edgeInvalidationCandidates, ensureASCII)
+	edgeInvalidationCandidatesTSV, err := ToPromptContext(context, edgeInvalidationCandidates, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal edge invalidation candidates: %w", err)
 	}
 
 	// Filter out fact_type_description to reduce redundancy
 	filteredEdgeTypes := filterEdgeTypes(edgeTypes)
-	edgeTypesTSV, err := ToPromptCSV(filteredEdgeTypes, ensureASCII)
+	edgeTypesTSV, err := ToPromptContext(context, filteredEdgeTypes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal edge types: %w", err)
 	}
@@ -200,10 +216,10 @@ func resolveEdgePrompt(context map[string]interface{}) ([]types.Message, error)
 %s
 </FACT TYPES>
 
-Note: EXISTING FACTS, FACT INVALIDATION CANDIDATES, and FACT TYPES are provided in TSV (tab-separated values) format.
+Note: EXISTING FACTS, FACT INVALIDATION CANDIDATES, and FACT TYPES are provided in %s format.
 
 Task:
-You have THREE separate lists: NEW FACT (string), EXISTING FACTS (TSV format with 'id' and 'fact' columns), and FACT INVALIDATION CANDIDATES (TSV format with 'id' field).
+You have THREE separate lists: NEW FACT (string), EXISTING FACTS (with 'id' and 'fact' columns), and FACT INVALIDATION CANDIDATES (with 'id' field).
 
 1. DUPLICATE DETECTION:
    - If the NEW FACT represents identical factual information as any fact in EXISTING FACTS, identify which ones.
@@ -245,7 +261,7 @@ duplicate_facts\tcontradicted_facts\tfact_type
 </EXAMPLE>
 
 Provide only the TSV header and data row. Finish your response with a new line.
-`, newEdge, existingEdgesTSV, edgeInvalidationCandidatesTSV, edgeTypesTSV)
+`, newEdge, existingEdgesTSV, edgeInvalidationCandidatesTSV, edgeTypesTSV, contextFormatName(context))
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -56,6 +56,22 @@ func (d *DedupeNodesVersions) Node() types.PromptVersion     { return d.NodeProm
 func (d *DedupeNodesVersions) NodeList() types.PromptVersion { return d.NodeListPrompt }
 func (d *DedupeNodesVersions) Nodes() types.PromptVersion    { return d.NodesPrompt }
 
+// updatePrompt replaces the prompt of the given name with the result of
+// update, reporting whether it exists.
+func (d *DedupeNodesVersions) updatePrompt(name string, update func(PromptVersion) PromptVersion) bool {
+	switch name {
+	case "node":
+		d.NodePrompt = update(d.NodePrompt)
+	case "node_list":
+		d.NodeListPrompt = update(d.NodeListPrompt)
+	case "nodes":
+		d.NodesPrompt = update(d.NodesPrompt)
+	default:
+		return false
+	}
+	return true
+}
+
 // nodePrompt determines if a new entity is a duplicate of existing entities.
 // Note: If entity_type is an array (e.g., ["Entity", "ANATOMY"]), only the last (most specific)
 // element will be used in the TSV output (e.g., "ANATOMY").
@@ -75,25 +91,25 @@ func nodePrompt(context map[string]interface{}) ([]types.Message, error) {
 		}
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
 
 	// Filter out entity_type_description to reduce redundancy
 	filteredExtractedNode := filterNodes(extractedNode)
-	extractedNodeTSV, err := ToPromptCSV(filteredExtractedNode, ensureASCII)
+	extractedNodeTSV, err := ToPromptContext(context, filteredExtractedNode, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal extracted node: %w", err)
 	}
 
-	entityTypeDescriptionTSV, err := ToPromptCSV(entityTypeDescription, ensureASCII)
+	entityTypeDescriptionTSV, err := ToPromptContext(context, entityTypeDescription, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal entity type description: %w", err)
 	}
 
 	filteredExistingNodes := filterNodes(existingNodes)
-	existingNodesTSV, err := ToPromptCSV(filteredExistingNodes, ensureASCII)
+	existingNodesTSV, err := ToPromptContext(context, filteredExistingNodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal existing nodes: %w", err)
 	}
@@ -116,7 +132,7 @@ func nodePrompt(context map[string]interface{}) ([]types.Message, error) {
 %s
 </EXISTING ENTITIES>
 
-The NEW ENTITY and EXISTING ENTITIES are provided in TSV (tab-separated values) format.
+The NEW ENTITY and EXISTING ENTITIES are provided in %s format.
 Given the above EXISTING ENTITIES and their attributes, MESSAGE, and PREVIOUS MESSAGES; Determine if the NEW ENTITY extracted from the conversation
 is a duplicate entity of one of the EXISTING ENTITIES.
 
@@ -135,7 +151,7 @@ Do NOT mark entities as duplicates if:
 
 Also return the full name of the NEW ENTITY (whether it is the name of the NEW ENTITY, a node it
 is a duplicate of, or a combination of the two).
-`, previousEpisodesTSV, episodeContent, extractedNodeTSV, entityTypeDescriptionTSV, existingNodesTSV)
+`, previousEpisodesTSV, episodeContent, extractedNodeTSV, entityTypeDescriptionTSV, existingNodesTSV, contextFormatName(context))
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -161,20 +177,20 @@ func nodesPrompt(context map[string]interface{}) ([]types.Message, error) {
 		}
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
 
 	// Filter out entity_type_description to reduce redundancy
 	filteredExtractedNodes := filterNodes(extractedNodes)
-	extractedNodesTSV, err := ToPromptCSV(filteredExtractedNodes, ensureASCII)
+	extractedNodesTSV, err := ToPromptContext(context, filteredExtractedNodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal extracted nodes: %w", err)
 	}
 
 	filteredExistingNodes := filterNodes(existingNodes)
-	existingNodesTSV, err := ToPromptCSV(filteredExistingNodes, ensureASCII)
+	existingNodesTSV, err := ToPromptContext(context, filteredExistingNodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal existing nodes: %w", err)
 	}
@@ -189,11 +205,14 @@ func nodesPrompt(context map[string]interface{}) ([]types.Message, error) {
 
 
 Each of the following ENTITIES were extracted from the CURRENT MESSAGE.
-ENTITIES and EXISTING ENTITIES are provided in TSV (tab-separated values) format with the following columns:
+ENTITIES and EXISTING ENTITIES are provided in %s format with the following columns:
 - id: integer id of the entity
 - name: name of the entity
 - entity_type: ontological classification of the entity
 - Additional columns may include entity attributes
+EXISTING ENTITIES may also have the following columns:
+- neighbors: names of the entities connected to the entity in the graph
+- facts: recent facts about the entity in the graph
 
 <ENTITIES>
 %s
@@ -210,6 +229,7 @@ Entities should only be considered duplicates if they refer to the *same real-wo
 Do NOT mark entities as duplicates if:
 - They are related but distinct.
 - They have similar names or purposes but refer to separate instances or concepts.
+- Their neighbors and facts contradict what the CURRENT MESSAGE says about the entity, for example two people of the same name working at different companies.
 
 Task:
 Your response will be in TSV.
@@ -220,6 +240,7 @@ For every entity, return an object with the following quantities:
 	- "name": the best full name for the entity (preserve the original name unless a duplicate has a more complete name),
 	- "duplicate_idx": the idx of the EXISTING ENTITY that is the best duplicate match, or -1 if there is no duplicate,
 	- "duplicates": a sorted list of all idx values from EXISTING ENTITIES that refer to duplicates (deduplicate the list, use [] when none or unsure)
+	- "confidence": a number from 0 to 1 giving how confident you are in duplicate_idx (use 1 when certain, and when duplicate_idx is -1 and the entity clearly has no duplicate)
 
 - Only use idx values that appear in EXISTING ENTITIES.
 - Never fabricate entities or indices.
@@ -229,20 +250,21 @@ id: string
 name: string
 duplicate_idx: int
 duplicates: list[int]
+confidence: float
 </SCHEMA>
 
 - Refer to the EXAMPLE
 <EXAMPLE>
-id\tname\tduplicate_idx\tduplicates
-0\t"anterior compartment of the lower leg"\t-1\t[]
-1\t"tibialis anterior"\t-1\t[],
-2\t"extensor hallucis longus"\t-1\t[],
-3\t"anterior tibialis"\t1\t[1]
+id\tname\tduplicate_idx\tduplicates\tconfidence
+0\t"anterior compartment of the lower leg"\t-1\t[]\t1.0
+1\t"tibialis anterior"\t-1\t[]\t1.0
+2\t"extensor hallucis longus"\t-1\t[]\t0.9
+3\t"anterior tibialis"\t1\t[1]\t0.95
 
 </EXAMPLE>
 
 Finish your response with a new line
-`, previousEpisodesTSV, episodeContent, extractedNodesTSV, existingNodesTSV)
+`, previousEpisodesTSV, episodeContent, contextFormatName(context), extractedNodesTSV, existingNodesTSV)
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -267,7 +289,7 @@ func nodeListPrompt(context map[string]interface{}) ([]types.Message, error) {
 
 	// Filter out entity_type_description to reduce redundancy
 	filteredNodes := filterNodes(nodes)
-	nodesTSV, err := ToPromptCSV(filteredNodes, ensureASCII)
+	nodesTSV, err := ToPromptContext(context, filteredNodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal nodes: %w", err)
 	}
@@ -275,7 +297,7 @@ func nodeListPrompt(context map[string]interface{}) ([]types.Message, error) {
 	userPrompt := fmt.Sprintf(`
 Given the following context, deduplicate a list of nodes.
 
-Nodes are provided in TSV (tab-separated values) format:
+Nodes are provided in %s format:
 %s
 
 Task:
@@ -299,7 +321,7 @@ where
 - summary: "Brief summary of the node summaries that appear in the list of names."
 
 conclude your response with a new line
-`, nodesTSV)
+`, contextFormatName(context), nodesTSV)
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
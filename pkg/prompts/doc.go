@@ -31,6 +31,14 @@ Usage:
 		// handle error
 	}
 
+Prompts can be replaced with text/template templates of their system and user
+messages, checked against the context keys the prompt is called with:
+
+	err := library.Register("extract_nodes.extract_text", prompts.PromptTemplate{
+		System: "You extract clinical entities from medical notes.",
+		User:   "{{tsv .entity_types}}\n\n{{.episode_content}}",
+	})
+
 The prompts are organized into different categories with versioned implementations
 to support different use cases and backwards compatibility.
 */
@@ -37,6 +37,24 @@ func (e *EvalVersions) EvalPrompt() PromptVersion            { return e.evalProm
 func (e *EvalVersions) QueryExpansion() PromptVersion        { return e.queryExpansionPrompt }
 func (e *EvalVersions) EvalAddEpisodeResults() PromptVersion { return e.evalAddEpisodePrompt }
 
+// updatePrompt replaces the prompt of the given name with the result of
+// update, reporting whether it exists.
+func (e *EvalVersions) updatePrompt(name string, update func(PromptVersion) PromptVersion) bool {
+	switch name {
+	case "qa_prompt":
+		e.qaPrompt = update(e.qaPrompt)
+	case "eval_prompt":
+		e.evalPrompt = update(e.evalPrompt)
+	case "query_expansion":
+		e.queryExpansionPrompt = update(e.queryExpansionPrompt)
+	case "eval_add_episode_results":
+		e.evalAddEpisodePrompt = update(e.evalAddEpisodePrompt)
+	default:
+		return false
+	}
+	return true
+}
+
 // queryExpansionPrompt rephrases questions into queries used in a database retrieval system.
 // Uses TSV format for query data to reduce token usage and improve LLM parsing.
 func queryExpansionPrompt(context map[string]interface{}) ([]types.Message, error) {
@@ -50,7 +68,7 @@ func queryExpansionPrompt(context map[string]interface{}) ([]types.Message, erro
 		}
 	}
 
-	queryTSV, err := ToPromptCSV(query, ensureASCII)
+	queryTSV, err := ToPromptContext(context, query, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal query: %w", err)
 	}
@@ -62,8 +80,8 @@ that maintains the relevant context?
 %s
 </QUESTION>
 
-Note: Query data is provided in TSV (tab-separated values) format.
-`, queryTSV)
+Note: Query data is provided in %s format.
+`, queryTSV, contextFormatName(context))
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -87,12 +105,12 @@ func qaPrompt(context map[string]interface{}) ([]types.Message, error) {
 		}
 	}
 
-	entitySummariesTSV, err := ToPromptCSV(entitySummaries, ensureASCII)
+	entitySummariesTSV, err := ToPromptContext(context, entitySummaries, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal entity summaries: %w", err)
 	}
 
-	factsTSV, err := ToPromptCSV(facts, ensureASCII)
+	factsTSV, err := ToPromptContext(context, facts, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal facts: %w", err)
 	}
@@ -101,7 +119,7 @@ func qaPrompt(context map[string]interface{}) ([]types.Message, error) {
 Your task is to briefly answer the question in the way that you think Alice would answer the question.
 You are given the following entity summaries and facts to help you determine the answer to your question.
 
-Note: ENTITY_SUMMARIES and FACTS are provided in TSV (tab-separated values) format.
+Note: ENTITY_SUMMARIES and FACTS are provided in %s format.
 
 <ENTITY_SUMMARIES>
 %s
@@ -112,7 +130,7 @@ Note: ENTITY_SUMMARIES and FACTS are provided in TSV (tab-separated values) form
 <QUESTION>
 %v
 </QUESTION>
-`, entitySummariesTSV, factsTSV, query)
+`, contextFormatName(context), entitySummariesTSV, factsTSV, query)
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -167,17 +185,17 @@ than a candidate graph building result based on the same messages.`
 		}
 	}
 
-	previousMessagesTSV, err := ToPromptCSV(previousMessages, ensureASCII)
+	previousMessagesTSV, err := ToPromptContext(context, previousMessages, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous messages: %w", err)
 	}
 
-	baselineTSV, err := ToPromptCSV(baseline, ensureASCII)
+	baselineTSV, err := ToPromptContext(context, baseline, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal baseline: %w", err)
 	}
 
-	candidateTSV, err := ToPromptCSV(candidate, ensureASCII)
+	candidateTSV, err := ToPromptContext(context, candidate, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal candidate: %w", err)
 	}
@@ -204,8 +222,8 @@ BASELINE extraction are nearly identical in quality, return True. Add your reaso
 %s
 </CANDIDATE>
 
-Note: PREVIOUS MESSAGES, BASELINE, and CANDIDATE are provided in TSV (tab-separated values) format.
-`, previousMessagesTSV, message, baselineTSV, candidateTSV)
+Note: PREVIOUS MESSAGES, BASELINE, and CANDIDATE are provided in %s format.
+`, previousMessagesTSV, message, baselineTSV, candidateTSV, contextFormatName(context))
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -0,0 +1,80 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/soundprediction/go-predicato/pkg/llm"
+)
+
+// DefaultExampleTokenBudget is the default estimated size of the few-shot
+// examples added to a prompt.
+const DefaultExampleTokenBudget = 1000
+
+// PromptExample is a few-shot example of a prompt: an input, such as an
+// excerpt of a clinical note, and the response expected for it in the
+// format the prompt asks for.
+type PromptExample struct {
+	Input  string
+	Output string
+}
+
+// AddExamples adds few-shot examples to the prompt of the given name, such
+// as "extract_nodes.extract_text". The examples are added to the last
+// message of the prompt in the order they were added, leaving out those
+// exceeding the token budget of the library. Examples must be added before
+// the library is used.
+func (l *LibraryImpl) AddExamples(name string, examples ...PromptExample) error {
+	if _, ok := promptContextKeys[name]; !ok {
+		return fmt.Errorf("unknown prompt %q", name)
+	}
+	if l.examples == nil {
+		l.examples = make(map[string][]PromptExample)
+	}
+	l.examples[name] = append(l.examples[name], examples...)
+	return nil
+}
+
+// SetExampleTokenBudget sets the estimated tokens of the examples added to
+// each prompt, 0 for DefaultExampleTokenBudget.
+func (l *LibraryImpl) SetExampleTokenBudget(tokens int) {
+	l.exampleTokens = tokens
+}
+
+// examplesSection returns the examples of the prompt of the given name
+// fitting the token budget, formatted to be added to the prompt, or an empty
+// string when there are none.
+func (l *LibraryImpl) examplesSection(name string) string {
+	budget := l.exampleTokens
+	if budget <= 0 {
+		budget = DefaultExampleTokenBudget
+	}
+
+	var section strings.Builder
+	for _, example := range l.examples[name] {
+		formatted := fmt.Sprintf(`<EXAMPLE>
+<INPUT>
+%s
+</INPUT>
+<OUTPUT>
+%s
+</OUTPUT>
+</EXAMPLE>
+`, strings.TrimSpace(example.Input), strings.TrimSpace(example.Output))
+		tokens := llm.GetTokenCount(formatted)
+		if tokens > budget {
+			continue
+		}
+		budget -= tokens
+		section.WriteString(formatted)
+	}
+	if section.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`
+
+<EXAMPLES>
+The following examples show the expected responses for inputs of this domain:
+%s</EXAMPLES>
+`, section.String())
+}
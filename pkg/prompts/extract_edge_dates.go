@@ -20,7 +20,20 @@ type ExtractEdgeDatesVersions struct {
 
 func (e *ExtractEdgeDatesVersions) ExtractDates() PromptVersion { return e.ExtractDatesPrompt }
 
-// extractDatesPrompt extracts dates from edges.
+// updatePrompt replaces the prompt of the given name with the result of
+// update, reporting whether it exists.
+func (e *ExtractEdgeDatesVersions) updatePrompt(name string, update func(PromptVersion) PromptVersion) bool {
+	switch name {
+	case "extract_dates":
+		e.ExtractDatesPrompt = update(e.ExtractDatesPrompt)
+	default:
+		return false
+	}
+	return true
+}
+
+// extractDatesPrompt extracts the valid_at and invalid_at dates of edges, given
+// as id and fact, from the episode they were extracted from.
 // Uses TSV format for episodes and edges to reduce token usage and improve LLM parsing.
 func extractDatesPrompt(context map[string]interface{}) ([]types.Message, error) {
 	sysPrompt := `You are an expert temporal information extractor that identifies valid_at and invalid_at dates for relationships from text.`
@@ -37,12 +50,12 @@ func extractDatesPrompt(context map[string]interface{}) ([]types.Message, error)
 		}
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
 
-	edgesTSV, err := ToPromptCSV(edges, ensureASCII)
+	edgesTSV, err := ToPromptContext(context, edges, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal edges: %w", err)
 	}
@@ -54,32 +67,49 @@ func extractDatesPrompt(context map[string]interface{}) ([]types.Message, error)
 <CURRENT MESSAGE>
 %v
 </CURRENT MESSAGE>
+<REFERENCE TIMESTAMP>
+%v
+</REFERENCE TIMESTAMP>
 <EDGES>
 %s
 </EDGES>
-<REFERENCE TIME>
-%v
-</REFERENCE TIME>
-
-Note: PREVIOUS MESSAGES and EDGES are provided in TSV (tab-separated values) format.
 
-Extract temporal information (valid_at and invalid_at dates) for the given edges based on the messages.
+Note: PREVIOUS MESSAGES and EDGES are provided in %s format.
 
-Guidelines:
-1. Use ISO 8601 format with Z suffix (UTC)
-2. If the relationship is ongoing, set valid_at to reference time
-3. If the relationship has ended, set invalid_at to the end time
-4. Leave empty string if no temporal information is available
-5. Use reference time to resolve relative temporal expressions
+IMPORTANT: Only extract time information if it is part of the provided fact. Otherwise ignore the time mentioned.
+Make sure to do your best to determine the dates if only the relative time is mentioned (eg 10 years ago, 2 mins ago) based on the provided reference timestamp.
+If the relationship is not of spanning nature, but you are still able to determine the dates, set the valid_at only.
 
-Return the results in TSV (tab-separated values) format with the following structure:
+Definitions:
+- valid_at: The date and time when the relationship described by the edge fact became true or was established.
+- invalid_at: The date and time when the relationship described by the edge fact stopped being true or ended.
 
-valid_at	invalid_at
-2024-01-01T00:00:00Z
-	2024-12-31T23:59:59Z
+Task:
+Analyze the CURRENT MESSAGE and determine if it contains temporal information about the relationship described by each edge fact.
 
-Output ONLY the TSV data with a header row. Include exactly one data row with the dates. Use empty strings (not null) for missing dates.
-`, previousEpisodesTSV, episodeContent, edgesTSV, referenceTime)
+Guidelines:
+1. Use ISO 8601 format (YYYY-MM-DDTHH:MM:SS.SSSSSSZ) for datetimes.
+2. Use the reference timestamp as the current time when determining the valid_at and invalid_at dates.
+3. If the fact is written in the present tense, use the reference timestamp for the valid_at date.
+4. If no temporal information is found that establishes or changes the relationship, leave the fields empty.
+5. Do not infer dates from related events. Only use dates that are directly stated to establish or change the relationship.
+6. For relative time mentions directly related to the relationship, calculate the actual datetime based on the reference timestamp
+   (eg "since 2019" starts on 2019-01-01T00:00:00Z, "until last March" ends on March 1 of the year before the reference timestamp
+   if the reference timestamp is in March or earlier).
+7. If only a date is mentioned without a specific time, use 00:00:00 (midnight) for that date.
+8. If only year is mentioned, use January 1st of that year at 00:00:00.
+9. Always include the time zone offset (use Z for UTC if no specific time zone is mentioned).
+10. A fact that is stated to have ended without a start date has an empty valid_at and a set invalid_at.
+
+Return the results in %s format with one row per edge, identified by the id of the edge:
+
+id	valid_at	invalid_at
+0	2019-01-01T00:00:00Z	
+1		2024-03-01T00:00:00Z
+2		
+
+Output ONLY the TSV data with a header row. Use empty strings (not null) for missing dates.
+`, previousEpisodesTSV, episodeContent, referenceTime, edgesTSV, contextFormatName(context), contextFormatName(context))
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
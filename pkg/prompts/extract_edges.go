@@ -11,6 +11,7 @@ import (
 // ExtractEdgesPrompt defines the interface for extract edges prompts.
 type ExtractEdgesPrompt interface {
 	Edge() PromptVersion
+	EdgeJSON() PromptVersion
 	Reflexion() PromptVersion
 	ExtractAttributes() PromptVersion
 }
@@ -18,14 +19,34 @@ type ExtractEdgesPrompt interface {
 // ExtractEdgesVersions holds all versions of extract edges prompts.
 type ExtractEdgesVersions struct {
 	EdgePrompt              PromptVersion
+	EdgeJSONPrompt          PromptVersion
 	ReflexionPrompt         PromptVersion
 	ExtractAttributesPrompt PromptVersion
 }
 
 func (e *ExtractEdgesVersions) Edge() PromptVersion              { return e.EdgePrompt }
+func (e *ExtractEdgesVersions) EdgeJSON() PromptVersion          { return e.EdgeJSONPrompt }
 func (e *ExtractEdgesVersions) Reflexion() PromptVersion         { return e.ReflexionPrompt }
 func (e *ExtractEdgesVersions) ExtractAttributes() PromptVersion { return e.ExtractAttributesPrompt }
 
+// updatePrompt replaces the prompt of the given name with the result of
+// update, reporting whether it exists.
+func (e *ExtractEdgesVersions) updatePrompt(name string, update func(PromptVersion) PromptVersion) bool {
+	switch name {
+	case "edge":
+		e.EdgePrompt = update(e.EdgePrompt)
+	case "edge_json":
+		e.EdgeJSONPrompt = update(e.EdgeJSONPrompt)
+	case "reflexion":
+		e.ReflexionPrompt = update(e.ReflexionPrompt)
+	case "extract_attributes":
+		e.ExtractAttributesPrompt = update(e.ExtractAttributesPrompt)
+	default:
+		return false
+	}
+	return true
+}
+
 // filterEdgeTypes removes the fact_type_description field from edge types
 // to reduce redundancy in prompts.
 func filterEdgeTypes(edgeTypes interface{}) interface{} {
@@ -69,17 +90,17 @@ func edgePrompt(context map[string]interface{}) ([]types.Message, error) {
 
 	// Filter out fact_type_description to reduce redundancy
 	filteredEdgeTypes := filterEdgeTypes(edgeTypes)
-	edgeTypesTSV, err := ToPromptCSV(filteredEdgeTypes, ensureASCII)
+	edgeTypesTSV, err := ToPromptContext(context, filteredEdgeTypes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal edge types: %w", err)
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
 
-	nodesTSV, err := ToPromptCSV(nodes, ensureASCII)
+	nodesTSV, err := ToPromptContext(context, nodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal nodes: %w", err)
 	}
@@ -105,7 +126,7 @@ func edgePrompt(context map[string]interface{}) ([]types.Message, error) {
 %v  # ISO 8601 (UTC); used to resolve relative time mentions
 </REFERENCE_TIME>
 
-Note: FACT TYPES, PREVIOUS_MESSAGES, and ENTITIES are provided in TSV (tab-separated values) format.
+Note: FACT TYPES, PREVIOUS_MESSAGES, and ENTITIES are provided in %s format.
 
 # TASK
 Extract all factual relationships between the given ENTITIES based on the CURRENT MESSAGE.
@@ -141,7 +162,119 @@ You may use information from the PREVIOUS MESSAGES only to disambiguate referenc
 5. The 'fact_text' should quote or closely paraphrase the original source sentence(s).
 6. Use 'REFERENCE_TIME' to resolve vague or relative temporal expressions (e.g., "last week").
 7. Do **not** hallucinate or infer temporal bounds from unrelated events.
-8. Format your response in a TSV table, with the schema:
+8. Set 'confidence' to a number from 0 to 1 giving how confident you are that the episode states the fact (use 1 when it is stated explicitly, lower values when it is implied or hedged).
+9. When the CURRENT MESSAGE states that a fact no longer holds or is false (e.g., "Alice no longer works at Acme"), emit the fact itself (Alice WORKS_AT Acme) with 'negated' set to true, rather than a new fact such as LEFT or NOT_WORKS_AT. Set 'negated' to false for every other fact.
+10. Format your response in a TSV table, with the schema:
+
+<SCHEMA>
+source_id: int 
+relation_type: string 
+target_id: int 
+fact: string 
+summary: string 
+valid_at: string 
+invalid_at: string 
+confidence: float 
+negated: bool 
+</SCHEMA>
+
+11. Refer to the EXAMPLE; end with a new line
+
+<EXAMPLE>
+source_id\trelation_type\ttarget_id\tfact\tsummary\tvalid_at\tinvalid_at\tconfidence\tnegated
+0\t"CAUSES"\t2\t"If that pressure is not relieved\tpermanent facial nerve palsy can ensue"\t"Acute Facial Palsy (AFP) causes facial nerve palsy"\t"2025-09-27T00:00:00Z"\tnull\t0.9\tfalse
+
+</EXAMPLE>
+`, edgeTypesTSV, previousEpisodesTSV, episodeContent, nodesTSV, referenceTime, contextFormatName(context), customPrompt)
+	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
+	return []types.Message{
+		llm.NewSystemMessage(sysPrompt),
+		llm.NewUserMessage(userPrompt),
+	}, nil
+}
+
+// edgeJSONPrompt extracts fact triples from JSON, reading facts from its keys
+// and values rather than from conversational sentences.
+// Uses TSV format for edge types and entities to reduce token usage and improve LLM parsing.
+func edgeJSONPrompt(context map[string]interface{}) ([]types.Message, error) {
+	sysPrompt := `You are an expert fact extractor that extracts fact triples from JSON.
+1. Facts are read from the structure of the JSON: keys name the relation between an object and its values.
+2. Treat the REFERENCE TIME as the time the JSON was recorded. All temporal information should be extracted relative to this time.`
+
+	edgeTypes := context["edge_types"]
+	episodeContent := context["episode_content"]
+	nodes := context["nodes"]
+	referenceTime := context["reference_time"]
+	customPrompt := context["custom_prompt"]
+
+	ensureASCII := false
+	if val, ok := context["ensure_ascii"]; ok {
+		if b, ok := val.(bool); ok {
+			ensureASCII = b
+		}
+	}
+
+	// Filter out fact_type_description to reduce redundancy
+	filteredEdgeTypes := filterEdgeTypes(edgeTypes)
+	edgeTypesTSV, err := ToPromptContext(context, filteredEdgeTypes, ensureASCII)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal edge types: %w", err)
+	}
+
+	nodesTSV, err := ToPromptContext(context, nodes, ensureASCII)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal nodes: %w", err)
+	}
+
+	userPrompt := fmt.Sprintf(`
+<FACT TYPES>
+%s
+</FACT TYPES>
+
+<JSON>
+%v
+</JSON>
+
+<ENTITIES>
+%s
+</ENTITIES>
+
+<REFERENCE_TIME>
+%v  # ISO 8601 (UTC); used to resolve relative time mentions
+</REFERENCE_TIME>
+
+Note: FACT TYPES and ENTITIES are provided in %s format.
+
+# TASK
+Extract all factual relationships between the given ENTITIES stated by the JSON.
+Only extract facts that:
+- involve two DISTINCT ENTITIES from the ENTITIES list,
+- are given by a key linking the object of one entity to the value or nested object of another,
+    or by the same object listing both entities under related keys.
+- The FACT TYPES provide a list of the most important types of facts, make sure to extract facts of these types
+- The FACT TYPES are not an exhaustive list, extract all facts from the JSON even if they do not fit into one
+    of the FACT TYPES
+- The FACT TYPES each contain their fact_type_signature which represents the source and target entity types.
+
+%v
+
+# DATETIME RULES
+
+- Use ISO 8601 with "Z" suffix (UTC) (e.g., 2025-04-30T00:00:00Z).
+- Take 'valid_at' and 'invalid_at' from date properties of the object stating the fact (e.g., "start_date", "end_date").
+- Leave both fields 'null' if the object has no such property.
+
+# EXTRACTION RULES
+
+1. Only emit facts where both the subject and object match IDs in ENTITIES.
+2. Each fact must involve two **distinct** entities.
+3. Use a SCREAMING_SNAKE_CASE string as the 'relation_type', derived from the key when it names the relation (e.g., "employer" gives WORKS_AT).
+4. Do not emit duplicate or semantically redundant facts.
+5. The 'fact' should state the relationship as a sentence naming both entities.
+6. Do **not** extract facts from keys that only hold identifiers, counts or formatting data.
+7. Set 'confidence' to a number from 0 to 1 giving how confident you are that the JSON states the fact (use 1 when a key states it explicitly, lower values when it is inferred from the structure).
+8. When the JSON states that a fact no longer holds (e.g., "former_employer" or "active": false), emit the fact itself with 'negated' set to true. Set 'negated' to false for every other fact.
+9. Format your response in a TSV table, with the schema:
 
 <SCHEMA>
 source_id: int 
@@ -151,16 +284,18 @@ fact: string
 summary: string 
 valid_at: string 
 invalid_at: string 
+confidence: float 
+negated: bool 
 </SCHEMA>
 
-9. Refer to the EXAMPLE; end with a new line
+10. Refer to the EXAMPLE; end with a new line
 
 <EXAMPLE>
-source_id\trelation_type\ttarget_id\tfact\tsummary\tvalid_at\tinvalid_at
-0\t"CAUSES"\t2\t"If that pressure is not relieved\tpermanent facial nerve palsy can ensue"\t"Acute Facial Palsy (AFP) causes facial nerve palsy"\t"2025-09-27T00:00:00Z"\tnull
+source_id\trelation_type\ttarget_id\tfact\tsummary\tvalid_at\tinvalid_at\tconfidence\tnegated
+0\t"WORKS_AT"\t1\t"Alice Smith works at Acme Corp"\t"Alice Smith is employed by Acme Corp"\t"2021-03-01T00:00:00Z"\tnull\t1\tfalse
 
 </EXAMPLE>
-`, edgeTypesTSV, previousEpisodesTSV, episodeContent, nodesTSV, referenceTime, customPrompt)
+`, edgeTypesTSV, episodeContent, nodesTSV, referenceTime, contextFormatName(context), customPrompt)
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -185,12 +320,12 @@ func extractEdgesReflexionPrompt(context map[string]interface{}) ([]types.Messag
 		}
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
 
-	nodesTSV, err := ToPromptCSV(nodes, ensureASCII)
+	nodesTSV, err := ToPromptContext(context, nodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal nodes: %w", err)
 	}
@@ -211,11 +346,11 @@ func extractEdgesReflexionPrompt(context map[string]interface{}) ([]types.Messag
 %v
 </EXTRACTED FACTS>
 
-Note: PREVIOUS MESSAGES and EXTRACTED ENTITIES are provided in TSV (tab-separated values) format.
+Note: PREVIOUS MESSAGES and EXTRACTED ENTITIES are provided in %s format.
 
 Given the above MESSAGES, list of EXTRACTED ENTITIES entities, and list of EXTRACTED FACTS;
 determine if any facts haven't been extracted.
-`, previousEpisodesTSV, episodeContent, nodesTSV, extractedFacts)
+`, previousEpisodesTSV, episodeContent, nodesTSV, extractedFacts, contextFormatName(context))
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -261,6 +396,7 @@ Guidelines:
 func NewExtractEdgesVersions() *ExtractEdgesVersions {
 	return &ExtractEdgesVersions{
 		EdgePrompt:              NewPromptVersion(edgePrompt),
+		EdgeJSONPrompt:          NewPromptVersion(edgeJSONPrompt),
 		ReflexionPrompt:         NewPromptVersion(extractEdgesReflexionPrompt),
 		ExtractAttributesPrompt: NewPromptVersion(extractEdgesAttributesPrompt),
 	}
@@ -3,6 +3,7 @@ package prompts
 import (
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/soundprediction/go-predicato/pkg/llm"
 	"github.com/soundprediction/go-predicato/pkg/types"
@@ -28,6 +29,18 @@ func filterEntityTypes(entityTypes interface{}) interface{} {
 	return entityTypes
 }
 
+// excludedEntityTypesInstruction returns the instruction not to extract the
+// entity types listed in context["excluded_entity_types"], or an empty string.
+func excludedEntityTypesInstruction(context map[string]interface{}) string {
+	excluded, _ := context["excluded_entity_types"].([]string)
+	if len(excluded) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`Do NOT extract entities of the following types: %s.
+Skip such entities entirely instead of classifying them as another entity type.
+`, strings.Join(excluded, ", "))
+}
+
 // ExtractNodesPrompt defines the interface for extract nodes prompts.
 type ExtractNodesPrompt interface {
 	ExtractMessage() PromptVersion
@@ -35,6 +48,7 @@ type ExtractNodesPrompt interface {
 	ExtractText() PromptVersion
 	Reflexion() PromptVersion
 	ClassifyNodes() PromptVersion
+	ReclassifyNodes() PromptVersion
 	ExtractAttributes() PromptVersion
 	ExtractSummary() PromptVersion
 	ExtractAttributesBatch() PromptVersion
@@ -47,6 +61,7 @@ type ExtractNodesVersions struct {
 	extractTextPrompt            PromptVersion
 	reflexionPrompt              PromptVersion
 	classifyNodesPrompt          PromptVersion
+	reclassifyNodesPrompt        PromptVersion
 	extractAttributesPrompt      PromptVersion
 	extractSummaryPrompt         PromptVersion
 	extractAttributesBatchPrompt PromptVersion
@@ -57,12 +72,41 @@ func (e *ExtractNodesVersions) ExtractJSON() PromptVersion       { return e.extr
 func (e *ExtractNodesVersions) ExtractText() PromptVersion       { return e.extractTextPrompt }
 func (e *ExtractNodesVersions) Reflexion() PromptVersion         { return e.reflexionPrompt }
 func (e *ExtractNodesVersions) ClassifyNodes() PromptVersion     { return e.classifyNodesPrompt }
+func (e *ExtractNodesVersions) ReclassifyNodes() PromptVersion   { return e.reclassifyNodesPrompt }
 func (e *ExtractNodesVersions) ExtractAttributes() PromptVersion { return e.extractAttributesPrompt }
 func (e *ExtractNodesVersions) ExtractSummary() PromptVersion    { return e.extractSummaryPrompt }
 func (e *ExtractNodesVersions) ExtractAttributesBatch() PromptVersion {
 	return e.extractAttributesBatchPrompt
 }
 
+// updatePrompt replaces the prompt of the given name with the result of
+// update, reporting whether it exists.
+func (e *ExtractNodesVersions) updatePrompt(name string, update func(PromptVersion) PromptVersion) bool {
+	switch name {
+	case "extract_message":
+		e.extractMessagePrompt = update(e.extractMessagePrompt)
+	case "extract_json":
+		e.extractJSONPrompt = update(e.extractJSONPrompt)
+	case "extract_text":
+		e.extractTextPrompt = update(e.extractTextPrompt)
+	case "reflexion":
+		e.reflexionPrompt = update(e.reflexionPrompt)
+	case "classify_nodes":
+		e.classifyNodesPrompt = update(e.classifyNodesPrompt)
+	case "reclassify_nodes":
+		e.reclassifyNodesPrompt = update(e.reclassifyNodesPrompt)
+	case "extract_attributes":
+		e.extractAttributesPrompt = update(e.extractAttributesPrompt)
+	case "extract_summary":
+		e.extractSummaryPrompt = update(e.extractSummaryPrompt)
+	case "extract_attributes_batch":
+		e.extractAttributesBatchPrompt = update(e.extractAttributesBatchPrompt)
+	default:
+		return false
+	}
+	return true
+}
+
 // extractMessagePrompt extracts entity nodes from conversational messages.
 // Uses TSV format for episodes and entity types to reduce token usage and improve LLM parsing.
 func extractMessagePrompt(context map[string]interface{}) ([]types.Message, error) {
@@ -84,12 +128,12 @@ Your primary task is to extract and classify the speaker and other significant e
 
 	// Filter out entity_type_description to reduce redundancy
 	filteredEntityTypes := filterEntityTypes(entityTypes)
-	entityTypesTSV, err := ToPromptCSV(filteredEntityTypes, ensureASCII)
+	entityTypesTSV, err := ToPromptContext(context, filteredEntityTypes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal entity types: %w", err)
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
@@ -106,7 +150,7 @@ Your primary task is to extract and classify the speaker and other significant e
 %v
 </CURRENT MESSAGE>
 
-Note: ENTITY TYPES and PREVIOUS MESSAGES are provided in TSV (tab-separated values) format.
+Note: ENTITY TYPES and PREVIOUS MESSAGES are provided in %s format.
 
 Instructions:
 
@@ -132,7 +176,8 @@ reference entities. Only extract distinct entities from the CURRENT MESSAGE. Don
 5. **Formatting**:
    - Be **explicit and unambiguous** in naming entities (e.g., use full names when available).
 
-%v`, entityTypesTSV, previousEpisodesTSV, episodeContent, customPrompt)
+%s
+%v`, entityTypesTSV, previousEpisodesTSV, episodeContent, contextFormatName(context), excludedEntityTypesInstruction(context), customPrompt)
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -160,7 +205,7 @@ Your primary task is to extract and classify relevant entities from JSON files`
 
 	// Filter out entity_type_description to reduce redundancy
 	filteredEntityTypes := filterEntityTypes(entityTypes)
-	entityTypesTSV, err := ToPromptCSV(filteredEntityTypes, ensureASCII)
+	entityTypesTSV, err := ToPromptContext(context, filteredEntityTypes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal entity types: %w", err)
 	}
@@ -177,7 +222,7 @@ Your primary task is to extract and classify relevant entities from JSON files`
 %v
 </JSON>
 
-Note: ENTITY TYPES are provided in TSV (tab-separated values) format.
+Note: ENTITY TYPES are provided in %s format.
 
 %v
 
@@ -188,7 +233,8 @@ Indicate the classified entity type by providing its entity_type_id.
 Guidelines:
 1. Always try to extract an entities that the JSON represents. This will often be something like a "name" or "user field
 2. Do NOT extract any properties that contain dates
-`, entityTypesTSV, sourceDescription, episodeContent, customPrompt)
+
+%s`, entityTypesTSV, sourceDescription, episodeContent, contextFormatName(context), customPrompt, excludedEntityTypesInstruction(context))
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -215,7 +261,7 @@ Your primary task is to extract and classify the speaker and other significant e
 
 	// Filter out entity_type_description to reduce redundancy
 	filteredEntityTypes := filterEntityTypes(entityTypes)
-	entityTypesTSV, err := ToPromptCSV(filteredEntityTypes, ensureASCII)
+	entityTypesTSV, err := ToPromptContext(context, filteredEntityTypes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal entity types: %w", err)
 	}
@@ -229,7 +275,7 @@ Your primary task is to extract and classify the speaker and other significant e
 %v
 </TEXT>
 
-Note: ENTITY TYPES are provided in TSV (tab-separated values) format.
+Note: ENTITY TYPES are provided in %s format.
 
 Given the above text, extract entities from the TEXT that are explicitly or implicitly mentioned.
 For each entity extracted, also determine its entity type based on the provided ENTITY TYPES and their descriptions.
@@ -243,7 +289,7 @@ Guidelines:
 2. Avoid creating nodes for relationships or actions.
 3. Avoid creating nodes for temporal information like dates, times or years (these will be added to edges later).
 4. Be as explicit as possible in your node names, using full names and avoiding abbreviations.
-5. Format your response as a TSV, with SCHEMA
+%s5. Format your response as a TSV, with SCHEMA
 
 <SCHEMA>
 entity: string
@@ -259,7 +305,7 @@ cognitive behavioral therapy\t30
 
 Use the EXAMPLE as a guide
 Finish your response with a new line
-`, entityTypesTSV, episodeContent, customPrompt)
+`, entityTypesTSV, episodeContent, contextFormatName(context), customPrompt, excludedEntityTypesInstruction(context))
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -283,7 +329,7 @@ func extractNodesReflexionPrompt(context map[string]interface{}) ([]types.Messag
 		}
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
@@ -300,19 +346,19 @@ func extractNodesReflexionPrompt(context map[string]interface{}) ([]types.Messag
 %v
 </EXTRACTED ENTITIES>
 
-Note: PREVIOUS MESSAGES are provided in TSV (tab-separated values) format.
+Note: PREVIOUS MESSAGES are provided in %s format.
 
 Given the above previous messages, current message, and list of extracted entities; determine if any entities haven't been
 extracted.
 
-Return the results in TSV (tab-separated values) format with the following structure:
+Return the results in %s format with the following structure:
 
 entity_name
 John Smith
 Acme Corp
 
 Output ONLY the TSV data with a header row. Include one row per missed entity. If no entities were missed, return only the header row.
-`, previousEpisodesTSV, episodeContent, extractedEntities)
+`, previousEpisodesTSV, episodeContent, extractedEntities, contextFormatName(context), contextFormatName(context))
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -337,14 +383,14 @@ func classifyNodesPrompt(context map[string]interface{}) ([]types.Message, error
 		}
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
 
 	// Filter out entity_type_description to reduce redundancy
 	filteredEntityTypes := filterEntityTypes(entityTypes)
-	entityTypesTSV, err := ToPromptCSV(filteredEntityTypes, ensureASCII)
+	entityTypesTSV, err := ToPromptContext(context, filteredEntityTypes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal entity types: %w", err)
 	}
@@ -365,7 +411,7 @@ func classifyNodesPrompt(context map[string]interface{}) ([]types.Message, error
 %s
 </ENTITY TYPES>
 
-Note: PREVIOUS MESSAGES and ENTITY TYPES are provided in TSV (tab-separated values) format.
+Note: PREVIOUS MESSAGES and ENTITY TYPES are provided in %s format.
 
 Given the above conversation, extracted entities, and provided entity types and their descriptions, classify the extracted entities.
 
@@ -373,7 +419,80 @@ Guidelines:
 1. Each entity must have exactly one type
 2. Only use the provided ENTITY TYPES as types, do not use additional types to classify entities.
 3. If none of the provided entity types accurately classify an extracted node, the type should be set to None
-`, previousEpisodesTSV, episodeContent, extractedEntities, entityTypesTSV)
+`, previousEpisodesTSV, episodeContent, extractedEntities, entityTypesTSV, contextFormatName(context))
+	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
+	return []types.Message{
+		llm.NewSystemMessage(sysPrompt),
+		llm.NewUserMessage(userPrompt),
+	}, nil
+}
+
+// reclassifyNodesPrompt classifies existing entities against the entity
+// types from their names and summaries, without the episodes they were
+// extracted from.
+// Uses TSV format for entities and entity types to reduce token usage.
+func reclassifyNodesPrompt(context map[string]interface{}) ([]types.Message, error) {
+	sysPrompt := `You are an AI assistant that classifies the entity nodes of a knowledge graph into entity types.`
+
+	nodes := context["nodes"]
+	entityTypes := context["entity_types"]
+
+	ensureASCII := true
+	if val, ok := context["ensure_ascii"]; ok {
+		if b, ok := val.(bool); ok {
+			ensureASCII = b
+		}
+	}
+
+	nodesTSV, err := ToPromptContext(context, nodes, ensureASCII)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal nodes: %w", err)
+	}
+
+	// Filter out entity_type_description to reduce redundancy
+	filteredEntityTypes := filterEntityTypes(entityTypes)
+	entityTypesTSV, err := ToPromptContext(context, filteredEntityTypes, ensureASCII)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal entity types: %w", err)
+	}
+
+	userPrompt := fmt.Sprintf(`
+<ENTITY TYPES>
+%s
+</ENTITY TYPES>
+
+<ENTITIES>
+%s
+</ENTITIES>
+
+Note: ENTITY TYPES and ENTITIES are provided in %s format. The entity_type column of
+each entity is the type it is currently classified as.
+
+Classify each entity into one of the ENTITY TYPES from its name and summary.
+
+Guidelines:
+1. Each entity must have exactly one type, given by its entity_type_id.
+2. Only use the provided ENTITY TYPES, do not use additional types to classify entities.
+3. Keep the current type of an entity when it is still among the ENTITY TYPES and no other type describes the entity better.
+4. Use the default Entity type when none of the other ENTITY TYPES describes the entity.
+5. Format your response as a TSV with the following schema:
+
+<SCHEMA>
+node_id: int
+entity_type_id: int
+</SCHEMA>
+
+<EXAMPLE>
+node_id	entity_type_id
+0	2
+1	0
+
+</EXAMPLE>
+
+Provide a TSV row for each entity in the ENTITIES list above.
+Use the node_id field from each entity to identify it in your TSV output.
+Finish your response with a new line.
+`, entityTypesTSV, nodesTSV, contextFormatName(context))
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -395,7 +514,7 @@ func extractNodesAttributesPrompt(context map[string]interface{}) ([]types.Messa
 		}
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
@@ -410,7 +529,7 @@ func extractNodesAttributesPrompt(context map[string]interface{}) ([]types.Messa
 %v
 </CURRENT MESSAGE>
 
-Note: PREVIOUS MESSAGES are provided in TSV (tab-separated values) format.
+Note: PREVIOUS MESSAGES are provided in %s format.
 
 Given the above MESSAGES and the following ENTITY, update any of its attributes based on the information provided
 in MESSAGES. Use the provided attribute descriptions to better understand how each attribute should be determined.
@@ -422,7 +541,7 @@ Guidelines:
 <ENTITY>
 %v
 </ENTITY>
-`, previousEpisodesTSV, episodeContent, node)
+`, previousEpisodesTSV, episodeContent, contextFormatName(context), node)
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -444,13 +563,14 @@ func extractSummaryPrompt(context map[string]interface{}) ([]types.Message, erro
 		}
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
 
 	sysPrompt := `You are a helpful assistant that extracts entity summaries from the provided text.`
 
+	summaryOpts := summaryOptions(context)
 	userPrompt := fmt.Sprintf(`
 <PREVIOUS MESSAGES>
 %s
@@ -459,21 +579,23 @@ func extractSummaryPrompt(context map[string]interface{}) ([]types.Message, erro
 %v
 </CURRENT MESSAGE>
 
-Note: PREVIOUS MESSAGES are provided in TSV (tab-separated values) format.
+Note: PREVIOUS MESSAGES are provided in %s format.
 
 Given the above MESSAGES and the following ENTITY, update the summary that combines relevant information about the entity
 from the messages and relevant information from the existing summary.
 
 Guidelines:
-1. Do not hallucinate entity summary information if they cannot be found in the current context.
+1. %s
 2. Only use the provided MESSAGES and ENTITY to set attribute values.
 3. The summary attribute represents a summary of the ENTITY, and should be updated with new information about the Entity from the MESSAGES.
-    Summaries must be no longer than 250 words.
+    %s
 
 <ENTITY>
 %v
 </ENTITY>
-`, previousEpisodesTSV, episodeContent, node)
+`, previousEpisodesTSV, episodeContent, contextFormatName(context),
+		summarySourceGuideline(summaryOpts, "Do not hallucinate entity summary information if they cannot be found in the current context.", ""),
+		summaryLengthGuideline(summaryOpts, false), node)
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -495,18 +617,19 @@ func extractAttributesBatchPrompt(context map[string]interface{}) ([]types.Messa
 		}
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
 
-	nodesTSV, err := ToPromptCSV(nodes, ensureASCII)
+	nodesTSV, err := ToPromptContext(context, nodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal nodes: %w", err)
 	}
 
 	sysPrompt := `You are a helpful assistant that extracts entity summaries and attributes from the provided text.`
 
+	summaryOpts := summaryOptions(context)
 	userPrompt := fmt.Sprintf(`
 <PREVIOUS MESSAGES>
 %s
@@ -515,27 +638,33 @@ func extractAttributesBatchPrompt(context map[string]interface{}) ([]types.Messa
 %v
 </CURRENT MESSAGE>
 
-Note: PREVIOUS MESSAGES and ENTITIES are provided in TSV (tab-separated values) format.
+Note: PREVIOUS MESSAGES and ENTITIES are provided in %s format.
 
 Given the above MESSAGES and the following ENTITIES, update the summary for each entity that combines relevant information
-from the messages and relevant information from the existing summary.
+from the messages and relevant information from the existing summary, and set the attributes described by its attribute_schema.
 
 Guidelines:
 1. Do not hallucinate entity information if they cannot be found in the current context.
-2. Only use the provided MESSAGES and ENTITIES to set summary values.
+2. %s
 3. The summary attribute represents a summary of the ENTITY, and should be updated with new information about the Entity from the MESSAGES.
-   Summaries must be no longer than 250 words.
-4. Format your response as a TSV with the following schema:
+   %s
+4. The attributes of an entity are a single-line JSON object with the attributes listed in its attribute_schema, using the
+   given types: strings, integers, numbers, booleans, ISO 8601 datetimes as strings, or arrays. Values restricted to a list
+   must be one of the listed values. Omit attributes the MESSAGES do not mention unless they are required. Use {} for
+   entities without an attribute_schema.
+5. If an entity lists violations, its previous attributes were rejected for these reasons; correct them.
+6. Format your response as a TSV with the following schema:
 
 <SCHEMA>
 node_id: int
 summary: string
+attributes: JSON object
 </SCHEMA>
 
 <EXAMPLE>
-node_id	summary
-0	John Smith is a software engineer who works at Google. He has 10 years of experience.
-1	Alice Johnson is a data scientist specializing in machine learning.
+node_id	summary	attributes
+0	John Smith is a software engineer who works at Google. He has 10 years of experience.	{"title": "software engineer", "years_of_experience": 10}
+1	Alice Johnson is a data scientist specializing in machine learning.	{}
 
 </EXAMPLE>
 
@@ -546,7 +675,10 @@ node_id	summary
 Provide a TSV row for each entity in the ENTITIES list above.
 Use the node_id field from each entity to identify it in your TSV output.
 Finish your response with a new line.
-`, previousEpisodesTSV, episodeContent, nodesTSV)
+`, previousEpisodesTSV, episodeContent, contextFormatName(context),
+		summarySourceGuideline(summaryOpts, "Only use the provided MESSAGES and ENTITIES to set summary and attribute values.",
+			"Only use the provided MESSAGES and ENTITIES to set attribute values."),
+		summaryLengthGuideline(summaryOpts, true), nodesTSV)
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -562,6 +694,7 @@ func NewExtractNodesVersions() *ExtractNodesVersions {
 		extractTextPrompt:            NewPromptVersion(extractTextPrompt),
 		reflexionPrompt:              NewPromptVersion(extractNodesReflexionPrompt),
 		classifyNodesPrompt:          NewPromptVersion(classifyNodesPrompt),
+		reclassifyNodesPrompt:        NewPromptVersion(reclassifyNodesPrompt),
 		extractAttributesPrompt:      NewPromptVersion(extractNodesAttributesPrompt),
 		extractSummaryPrompt:         NewPromptVersion(extractSummaryPrompt),
 		extractAttributesBatchPrompt: NewPromptVersion(extractAttributesBatchPrompt),
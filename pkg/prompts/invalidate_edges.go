@@ -20,6 +20,18 @@ type InvalidateEdgesVersions struct {
 
 func (i *InvalidateEdgesVersions) Invalidate() PromptVersion { return i.InvalidatePrompt }
 
+// updatePrompt replaces the prompt of the given name with the result of
+// update, reporting whether it exists.
+func (i *InvalidateEdgesVersions) updatePrompt(name string, update func(PromptVersion) PromptVersion) bool {
+	switch name {
+	case "invalidate":
+		i.InvalidatePrompt = update(i.InvalidatePrompt)
+	default:
+		return false
+	}
+	return true
+}
+
 // invalidatePrompt determines which edges should be invalidated.
 // Uses TSV format for edge data to reduce token usage and improve LLM parsing.
 func invalidatePrompt(context map[string]interface{}) ([]types.Message, error) {
@@ -37,12 +49,12 @@ func invalidatePrompt(context map[string]interface{}) ([]types.Message, error) {
 		}
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
 
-	existingEdgesTSV, err := ToPromptCSV(existingEdges, ensureASCII)
+	existingEdgesTSV, err := ToPromptContext(context, existingEdges, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal existing edges: %w", err)
 	}
@@ -61,7 +73,7 @@ func invalidatePrompt(context map[string]interface{}) ([]types.Message, error) {
 %v
 </REFERENCE TIME>
 
-EXISTING EDGES are provided in TSV (tab-separated values) format with columns including:
+EXISTING EDGES are provided in %s format with columns including:
 - id: unique identifier for the edge
 - fact: the relationship or fact represented by the edge
 - Additional columns may include source, target, dates, etc.
@@ -73,7 +85,7 @@ Edges should be invalidated if:
 2. The relationship has ended according to the message
 3. New information makes the edge no longer accurate
 
-Return the results in TSV (tab-separated values) format with the following structure:
+Return the results in %s format with the following structure:
 
 fact_id
 0
@@ -81,7 +93,7 @@ fact_id
 12
 
 Output ONLY the TSV data with a header row. Include one row per invalidated edge ID. If no edges should be invalidated, return only the header row.
-`, previousEpisodesTSV, episodeContent, existingEdgesTSV, referenceTime)
+`, previousEpisodesTSV, episodeContent, existingEdgesTSV, referenceTime, contextFormatName(context), contextFormatName(context))
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
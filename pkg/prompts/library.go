@@ -10,6 +10,23 @@ type Library interface {
 	ExtractEdgeDates() ExtractEdgeDatesPrompt
 	SummarizeNodes() SummarizeNodesPrompt
 	Eval() EvalPrompt
+	// Register replaces a prompt with a user supplied template, see
+	// PromptNames for the prompts that can be replaced
+	Register(name string, tmpl PromptTemplate) error
+	// SetContextFormat sets the format the lists given to the prompts are
+	// serialized in
+	SetContextFormat(format ContextFormat)
+	// AddExamples adds few-shot examples to a prompt, see
+	// SetExampleTokenBudget for how many of them are given
+	AddExamples(name string, examples ...PromptExample) error
+	// SetExampleTokenBudget sets the estimated tokens of the examples added
+	// to each prompt
+	SetExampleTokenBudget(tokens int)
+	// SetDomainPreamble sets a preamble appended to the system message of
+	// every prompt
+	SetDomainPreamble(preamble string)
+	// Version returns the prompt version of the library, see LatestVersion
+	Version() string
 }
 
 // LibraryImpl implements the Library interface.
@@ -22,6 +39,16 @@ type LibraryImpl struct {
 	extractEdgeDates ExtractEdgeDatesPrompt
 	summarizeNodes   SummarizeNodesPrompt
 	eval             EvalPrompt
+	version          string
+	// contextFormat, examples, exampleTokens and domainPreamble are applied
+	// to every prompt by libraryPrompt
+	contextFormat  ContextFormat
+	examples       map[string][]PromptExample
+	exampleTokens  int
+	domainPreamble string
+	// promptVersions are the versions of the prompts registered with a
+	// versioned name, by prompt name
+	promptVersions map[string]string
 }
 
 func (l *LibraryImpl) ExtractNodes() ExtractNodesPrompt         { return l.extractNodes }
@@ -35,7 +62,7 @@ func (l *LibraryImpl) Eval() EvalPrompt                         { return l.eval
 
 // NewLibrary creates a new prompt library instance.
 func NewLibrary() Library {
-	return &LibraryImpl{
+	l := &LibraryImpl{
 		extractNodes:     NewExtractNodesVersions(),
 		dedupeNodes:      NewDedupeNodesVersions(),
 		extractEdges:     NewExtractEdgesVersions(),
@@ -44,7 +71,15 @@ func NewLibrary() Library {
 		extractEdgeDates: NewExtractEdgeDatesVersions(),
 		summarizeNodes:   NewSummarizeNodesVersions(),
 		eval:             NewEvalVersions(),
+		version:          LatestVersion,
+		examples:         make(map[string][]PromptExample),
 	}
+	for name := range promptContextKeys {
+		l.updatePrompt(name, func(prompt PromptVersion) PromptVersion {
+			return &libraryPrompt{library: l, name: name, prompt: prompt}
+		})
+	}
+	return l
 }
 
 // DefaultLibrary is the default prompt library instance.
@@ -16,6 +16,7 @@ import (
 
 	"github.com/soundprediction/go-predicato/pkg/llm"
 	"github.com/soundprediction/go-predicato/pkg/types"
+	"gopkg.in/yaml.v3"
 )
 
 // ExtractedEntity represents an entity extracted from content
@@ -46,6 +47,12 @@ type EntityClassificationTriple struct {
 	EntityType *string `json:"entity_type"`
 }
 
+// ReclassifiedEntity is the entity type an existing entity is classified as
+type ReclassifiedEntity struct {
+	NodeID       int `json:"node_id" mapstructure:"node_id" csv:"node_id"`
+	EntityTypeID int `json:"entity_type_id" mapstructure:"entity_type_id" csv:"entity_type_id"`
+}
+
 // EntityClassification represents entity classifications
 type EntityClassification struct {
 	EntityClassifications []EntityClassificationTriple `json:"entity_classifications"`
@@ -60,6 +67,8 @@ type EntitySummary struct {
 type ExtractedNodeAttributes struct {
 	NodeID  int    `json:"node_id" mapstructure:"node_id" csv:"node_id"`
 	Summary string `json:"summary" mapstructure:"summary" csv:"summary"`
+	// Attributes is a JSON object of the attributes of the entity type
+	Attributes string `json:"attributes" mapstructure:"attributes" csv:"attributes"`
 }
 type ExtractedEdge struct {
 	Name      string    `json:"relation_type" mapstructure:"relation_type" csv:"relation_type"` // matches Python name
@@ -70,7 +79,11 @@ type ExtractedEdge struct {
 	Summary   string    `json:"summary,omitempty" mapstructure:"summary" csv:"summary"`
 	ValidAt   string    `json:"valid_at,omitempty" mapstructure:"valid_at" csv:"valid_at"`       // matches Python valid_at
 	InvalidAt string    `json:"invalid_at,omitempty" mapstructure:"invalid_at" csv:"invalid_at"` // matches Python invalid_at
-	// alias for Fact
+	// Confidence is the LLM's confidence from 0 to 1 that the episode states
+	// the fact, nil when the response has no confidence column
+	Confidence *float64 `json:"confidence,omitempty" mapstructure:"confidence" csv:"confidence"`
+	// Negated is true when the episode states that the fact no longer holds
+	Negated bool `json:"negated,omitempty" mapstructure:"negated" csv:"negated"`
 }
 
 // ExtractedEdges represents a list of extracted edges
@@ -89,6 +102,9 @@ type NodeDuplicate struct {
 	DuplicateIdx int    `json:"duplicate_idx" mapstructure:"duplicate_idx" csv:"duplicate_idx"`
 	Name         string `json:"name" mapstructure:"name" csv:"name"`
 	Duplicates   []int  `json:"duplicates" mapstructure:"duplicates" csv:"duplicates"`
+	// Confidence is the LLM's confidence from 0 to 1 in DuplicateIdx, nil
+	// when the response has no confidence column
+	Confidence *float64 `json:"confidence,omitempty" mapstructure:"confidence" csv:"confidence"`
 }
 
 // NodeResolutions represents node duplicate resolutions
@@ -139,6 +155,7 @@ type EdgeDates struct {
 
 // EdgeDatesTSV represents temporal information for edges from TSV format
 type EdgeDatesTSV struct {
+	ID        int    `csv:"id"`
 	ValidAt   string `csv:"valid_at"`
 	InvalidAt string `csv:"invalid_at"`
 }
@@ -213,6 +230,65 @@ func NewPromptVersion(fn types.PromptFunction) types.PromptVersion {
 	return &promptVersionImpl{fn: fn}
 }
 
+// ContextFormat is the format the entity types, facts, entities and other
+// lists given to prompts are serialized in.
+type ContextFormat string
+
+const (
+	// ContextFormatCSV serializes lists as tables, the most compact format
+	// for long lists and the default
+	ContextFormatCSV  ContextFormat = "csv"
+	ContextFormatJSON ContextFormat = "json"
+	ContextFormatYAML ContextFormat = "yaml"
+)
+
+// ContextFormatKey is the prompt context key of the ContextFormat of a call,
+// ContextFormatCSV when unset.
+const ContextFormatKey = "context_format"
+
+// ToPromptContext serializes data for a prompt in the ContextFormat of its
+// context.
+func ToPromptContext(context map[string]interface{}, data interface{}, ensureASCII bool) (string, error) {
+	switch format, _ := context[ContextFormatKey].(ContextFormat); format {
+	case ContextFormatJSON:
+		return ToPromptJSON(data, ensureASCII, 0)
+	case ContextFormatYAML:
+		return ToPromptYAML(data)
+	default:
+		return ToPromptCSV(data, ensureASCII)
+	}
+}
+
+// contextFormatName returns the name of the ContextFormat of context, as
+// given in the notes of the prompts.
+func contextFormatName(context map[string]interface{}) string {
+	switch format, _ := context[ContextFormatKey].(ContextFormat); format {
+	case ContextFormatJSON:
+		return "JSON"
+	case ContextFormatYAML:
+		return "YAML"
+	default:
+		return "TSV (tab-separated values)"
+	}
+}
+
+// ToPromptYAML serializes data to YAML for use in prompts, with the field
+// names data has in JSON.
+func ToPromptYAML(data interface{}) (string, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return "", err
+	}
+	if b, err = yaml.Marshal(value); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
 // ToPromptJSON serializes data to JSON for use in prompts.
 // When ensureASCII is false, non-ASCII characters are preserved in their original form.
 func ToPromptJSON(data interface{}, ensureASCII bool, indent int) (string, error) {
@@ -0,0 +1,299 @@
+package prompts
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// PromptTemplate is a user supplied prompt registered with Library.Register.
+// System and User are text/template templates of the system and user
+// messages, executed with the context of the prompt they replace, for
+// example {{.episode_content}}. The tsv and json template functions format
+// context values as the built-in prompts do.
+type PromptTemplate struct {
+	System string
+	User   string
+}
+
+// promptContextKeys are the context keys each replaceable prompt is called
+// with, by prompt name: those its built-in version reads, and those its
+// call sites pass, which the tests of the maintenance operations check.
+var promptContextKeys = map[string][]string{
+	"extract_nodes.extract_message":          {"entity_types", "excluded_entity_types", "previous_episodes", "episode_content", "episode_timestamp", "source_description", "custom_prompt"},
+	"extract_nodes.extract_json":             {"entity_types", "excluded_entity_types", "previous_episodes", "source_description", "episode_content", "episode_timestamp", "custom_prompt"},
+	"extract_nodes.extract_text":             {"entity_types", "excluded_entity_types", "previous_episodes", "source_description", "episode_content", "episode_timestamp", "custom_prompt"},
+	"extract_nodes.reflexion":                {"previous_episodes", "episode_content", "extracted_entities"},
+	"extract_nodes.classify_nodes":           {"entity_types", "previous_episodes", "episode_content", "extracted_entities"},
+	"extract_nodes.reclassify_nodes":         {"entity_types", "nodes"},
+	"extract_nodes.extract_attributes":       {"previous_episodes", "episode_content", "node"},
+	"extract_nodes.extract_summary":          {"previous_episodes", "episode_content", "node", "summary_options"},
+	"extract_nodes.extract_attributes_batch": {"previous_episodes", "episode_content", "nodes", "summary_options"},
+	"dedupe_nodes.node":                      {"previous_episodes", "episode_content", "extracted_node", "entity_type_description", "existing_nodes"},
+	"dedupe_nodes.node_list":                 {"nodes"},
+	"dedupe_nodes.nodes":                     {"previous_episodes", "episode_content", "extracted_nodes", "existing_nodes"},
+	"extract_edges.edge":                     {"edge_types", "previous_episodes", "episode_content", "nodes", "extracted_nodes", "reference_time", "custom_prompt"},
+	"extract_edges.edge_json":                {"edge_types", "previous_episodes", "episode_content", "nodes", "extracted_nodes", "reference_time", "custom_prompt"},
+	"extract_edges.reflexion":                {"previous_episodes", "episode_content", "nodes", "extracted_facts"},
+	"extract_edges.extract_attributes":       {"episode_content", "reference_time", "fact"},
+	"dedupe_edges.edge":                      {"previous_episodes", "episode_content", "new_fact", "existing_facts", "edges"},
+	"dedupe_edges.edge_list":                 {"edges"},
+	"dedupe_edges.resolve_edge":              {"edge_types", "new_edge", "existing_edges", "edge_invalidation_candidates"},
+	"invalidate_edges.invalidate":            {"previous_episodes", "episode_content", "new_edge", "existing_edges", "reference_time"},
+	"extract_edge_dates.extract_dates":       {"previous_episodes", "episode_content", "edges", "reference_time"},
+	"summarize_nodes.summarize_pair":         {"node_summaries"},
+	"summarize_nodes.summarize_context":      {"previous_episodes", "episode_content", "node_name", "node_summary", "attributes", "summary_options"},
+	"summarize_nodes.summary_description":    {"summary"},
+	"summarize_nodes.refresh_summaries":      {"episodes", "nodes", "summary_options"},
+	"eval.qa_prompt":                         {"query", "entity_summaries", "facts"},
+	"eval.eval_prompt":                       {"query", "answer", "response"},
+	"eval.query_expansion":                   {"query"},
+	"eval.eval_add_episode_results":          {"previous_messages", "message", "baseline", "candidate"},
+}
+
+// promptVersionPattern matches the version a prompt name may end with.
+var promptVersionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// promptGroup is implemented by the prompt groups of a library whose
+// prompts can be replaced.
+type promptGroup interface {
+	updatePrompt(name string, update func(PromptVersion) PromptVersion) bool
+}
+
+// PromptNames returns the names of the prompts Library.Register can
+// replace, sorted.
+func PromptNames() []string {
+	names := make([]string, 0, len(promptContextKeys))
+	for name := range promptContextKeys {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// PromptContextKeys returns the context keys the prompt of the given name is
+// called with, which are the variables its templates can use.
+func PromptContextKeys(name string) []string {
+	return slices.Clone(promptContextKeys[name])
+}
+
+// Register replaces the prompt of the given name, such as
+// "extract_nodes.extract_message", with tmpl. The name may end with the
+// version of the template, as in "extract_nodes.extract_message.v3", which
+// is then part of the library Version, so that what the template extracted
+// is recorded as such. It returns an error when the name is unknown, the
+// templates don't parse, or they use a variable the prompt is not called
+// with. Prompts must be registered before the library is used.
+func (l *LibraryImpl) Register(name string, tmpl PromptTemplate) error {
+	promptName, version := splitPromptName(name)
+	keys, ok := promptContextKeys[promptName]
+	if !ok {
+		return fmt.Errorf("unknown prompt %q", name)
+	}
+	prompt, err := newTemplatePrompt(name, tmpl, keys)
+	if err != nil {
+		return err
+	}
+
+	err = l.updatePrompt(promptName, func(PromptVersion) PromptVersion {
+		return &libraryPrompt{library: l, name: promptName, prompt: prompt}
+	})
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		delete(l.promptVersions, promptName)
+		return nil
+	}
+	if l.promptVersions == nil {
+		l.promptVersions = make(map[string]string)
+	}
+	l.promptVersions[promptName] = version
+	return nil
+}
+
+// splitPromptName splits a prompt name into the name of the prompt and the
+// version it ends with, if any.
+func splitPromptName(name string) (string, string) {
+	if i := strings.LastIndex(name, "."); i >= 0 && promptVersionPattern.MatchString(name[i+1:]) {
+		return name[:i], name[i+1:]
+	}
+	return name, ""
+}
+
+// SetContextFormat sets the format the entity types, facts and other lists
+// given to every prompt of the library are serialized in, unless the context
+// of a call sets ContextFormatKey.
+func (l *LibraryImpl) SetContextFormat(format ContextFormat) {
+	l.contextFormat = format
+}
+
+// SetDomainPreamble sets a preamble appended to the system message of every
+// prompt of the library, describing the domain of the episodes, for example
+// "You are extracting facts from obstetric medical records; preserve clinical
+// terminology". An empty preamble removes it.
+func (l *LibraryImpl) SetDomainPreamble(preamble string) {
+	l.domainPreamble = strings.TrimSpace(preamble)
+}
+
+// updatePrompt replaces the prompt of the given name with the result of
+// update.
+func (l *LibraryImpl) updatePrompt(name string, update func(PromptVersion) PromptVersion) error {
+	group, promptName, _ := strings.Cut(name, ".")
+	groups := map[string]interface{}{
+		"extract_nodes":      l.extractNodes,
+		"dedupe_nodes":       l.dedupeNodes,
+		"extract_edges":      l.extractEdges,
+		"dedupe_edges":       l.dedupeEdges,
+		"invalidate_edges":   l.invalidateEdges,
+		"extract_edge_dates": l.extractEdgeDates,
+		"summarize_nodes":    l.summarizeNodes,
+		"eval":               l.eval,
+	}
+	prompts, ok := groups[group].(promptGroup)
+	if !ok || !prompts.updatePrompt(promptName, update) {
+		return fmt.Errorf("prompt %q cannot be replaced", name)
+	}
+	return nil
+}
+
+// newTemplatePrompt parses tmpl into a prompt, checking that it only uses the
+// given context keys.
+func newTemplatePrompt(name string, tmpl PromptTemplate, keys []string) (PromptVersion, error) {
+	funcs := template.FuncMap{
+		"tsv": func(data interface{}) (string, error) { return ToPromptCSV(data, true) },
+		"json": func(data interface{}) (string, error) {
+			return ToPromptJSON(data, true, 0)
+		},
+	}
+	system, err := template.New(name + ".system").Funcs(funcs).Parse(tmpl.System)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse system template of prompt %s: %w", name, err)
+	}
+	user, err := template.New(name + ".user").Funcs(funcs).Parse(tmpl.User)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user template of prompt %s: %w", name, err)
+	}
+	for _, t := range []*template.Template{system, user} {
+		for _, variable := range templateVariables(t.Root, true) {
+			if !slices.Contains(keys, variable) {
+				return nil, fmt.Errorf("template of prompt %s uses unknown variable %q, expected one of %s",
+					name, variable, strings.Join(keys, ", "))
+			}
+		}
+	}
+
+	return NewPromptVersion(func(context map[string]interface{}) ([]types.Message, error) {
+		var sysPrompt, userPrompt strings.Builder
+		if err := system.Execute(&sysPrompt, context); err != nil {
+			return nil, fmt.Errorf("failed to execute system template of prompt %s: %w", name, err)
+		}
+		if err := user.Execute(&userPrompt, context); err != nil {
+			return nil, fmt.Errorf("failed to execute user template of prompt %s: %w", name, err)
+		}
+		if logger, ok := context["logger"].(*slog.Logger); ok {
+			logPrompts(logger, sysPrompt.String(), userPrompt.String())
+		}
+		return []types.Message{
+			llm.NewSystemMessage(sysPrompt.String()),
+			llm.NewUserMessage(userPrompt.String()),
+		}, nil
+	}), nil
+}
+
+// templateVariables returns the context keys used under node, as fields of
+// the context when atRoot is set, and as fields of $ anywhere.
+func templateVariables(node parse.Node, atRoot bool) []string {
+	var variables []string
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			variables = append(variables, templateVariables(child, atRoot)...)
+		}
+	case *parse.ActionNode:
+		variables = templateVariables(n.Pipe, atRoot)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			variables = append(variables, templateVariables(cmd, atRoot)...)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			variables = append(variables, templateVariables(arg, atRoot)...)
+		}
+	case *parse.FieldNode:
+		if atRoot {
+			variables = append(variables, n.Ident[0])
+		}
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			variables = append(variables, n.Ident[1])
+		}
+	case *parse.IfNode:
+		variables = branchVariables(&n.BranchNode, atRoot, atRoot)
+	case *parse.RangeNode:
+		// The context is only the dot outside of range and with
+		variables = branchVariables(&n.BranchNode, atRoot, false)
+	case *parse.WithNode:
+		variables = branchVariables(&n.BranchNode, atRoot, false)
+	}
+	return variables
+}
+
+// branchVariables returns the context keys used in an if, range or with
+// node, whose list has the context at its root when listAtRoot is set.
+func branchVariables(n *parse.BranchNode, atRoot, listAtRoot bool) []string {
+	variables := templateVariables(n.Pipe, atRoot)
+	variables = append(variables, templateVariables(n.List, listAtRoot)...)
+	return append(variables, templateVariables(n.ElseList, atRoot)...)
+}
+
+// libraryPrompt calls a prompt of a library with the context format,
+// examples and domain preamble set on the library.
+type libraryPrompt struct {
+	library *LibraryImpl
+	name    string
+	prompt  PromptVersion
+}
+
+// Call calls the prompt, setting the context format of the library unless
+// context has one, adds the domain preamble to its system message and the
+// examples of the prompt to its last message.
+func (p *libraryPrompt) Call(context map[string]interface{}) ([]types.Message, error) {
+	if _, ok := context[ContextFormatKey]; !ok && p.library.contextFormat != "" {
+		context = maps.Clone(context)
+		if context == nil {
+			context = make(map[string]interface{})
+		}
+		context[ContextFormatKey] = p.library.contextFormat
+	}
+	messages, err := p.prompt.Call(context)
+	if err != nil || len(messages) == 0 {
+		return messages, err
+	}
+	if preamble := p.library.domainPreamble; preamble != "" {
+		for i := range messages {
+			if messages[i].Role == llm.RoleSystem {
+				messages[i].Content = strings.TrimRight(messages[i].Content, "\n") + "\n\n" + preamble
+				break
+			}
+		}
+	}
+	if examples := p.library.examplesSection(p.name); examples != "" {
+		messages[len(messages)-1].Content += examples
+	}
+	return messages, nil
+}
@@ -0,0 +1,101 @@
+package prompts
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestTemplateVariables(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     []string
+	}{
+		{"fields", "{{.episode_content}} {{.node.name}}", []string{"episode_content", "node"}},
+		{"functions", "{{tsv .nodes}}", []string{"nodes"}},
+		{"range", "{{range .nodes}}{{.name}}{{end}}", []string{"nodes"}},
+		{"range else", "{{range .nodes}}{{.name}}{{else}}{{.episode_content}}{{end}}", []string{"nodes", "episode_content"}},
+		{"with", "{{with .node}}{{.summary}}{{else}}{{.nodes}}{{end}}", []string{"node", "nodes"}},
+		{"if", "{{if .custom_prompt}}{{.custom_prompt}}{{else}}{{.episode_content}}{{end}}", []string{"custom_prompt", "custom_prompt", "episode_content"}},
+		{"if in range", "{{range .nodes}}{{if .summary}}{{.name}}{{end}}{{end}}", []string{"nodes"}},
+		{"root variable", "{{range .nodes}}{{.name}} {{$.reference_time}}{{end}}", []string{"nodes", "reference_time"}},
+		{"local variable", "{{$n := .nodes}}{{range $n}}{{.name}}{{end}}", []string{"nodes"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := template.Must(template.New(tt.name).Funcs(template.FuncMap{"tsv": strings.TrimSpace}).Parse(tt.template))
+			if got := templateVariables(tmpl.Root, true); !slices.Equal(got, tt.want) {
+				t.Errorf("templateVariables(%q) = %v, want %v", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	library := NewLibrary()
+	if err := library.Register("extract_nodes.extract_text", PromptTemplate{
+		System: "You extract clinical entities.",
+		User:   "{{.episode_content}} {{.patient_id}}",
+	}); err == nil || !strings.Contains(err.Error(), `"patient_id"`) {
+		t.Errorf("Register() with an unknown variable error = %v", err)
+	}
+	if err := library.Register("extract_nodes.extract_text", PromptTemplate{
+		User: "{{range .nodes}}{{.name}}{{end}}",
+	}); err == nil {
+		t.Error("Register() with a variable of another prompt error = nil")
+	}
+	if err := library.Register("extract_nodes.extract_clinical", PromptTemplate{}); err == nil {
+		t.Error("Register() of an unknown prompt error = nil")
+	}
+	if err := library.Register("extract_nodes.extract_text", PromptTemplate{User: "{{if .patient_id}}{{end}}"}); err == nil {
+		t.Error("Register() with an unknown variable in a condition error = nil")
+	}
+
+	if err := library.Register("extract_nodes.extract_text", PromptTemplate{
+		System: "You extract clinical entities.",
+		User:   "{{range .previous_episodes}}{{.}}\n{{end}}{{.episode_content}} ({{$.source_description}})",
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	messages, err := library.ExtractNodes().ExtractText().Call(map[string]interface{}{
+		"episode_content":    "BP 120/80",
+		"previous_episodes":  []string{"Admitted"},
+		"source_description": "vitals",
+	})
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if len(messages) != 2 || messages[1].Content != "Admitted\nBP 120/80 (vitals)" {
+		t.Errorf("Call() = %+v", messages)
+	}
+	if got := library.Version(); got != LatestVersion {
+		t.Errorf("Version() = %q, want %q for an unversioned template", got, LatestVersion)
+	}
+}
+
+func TestRegisterVersionedName(t *testing.T) {
+	library := NewLibrary()
+	tmpl := PromptTemplate{User: "{{.episode_content}}"}
+	if err := library.Register("extract_nodes.extract_text.v3", tmpl); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := library.Register("extract_edges.edge.v2", PromptTemplate{User: "{{tsv .nodes}}"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	want := LatestVersion + "+extract_edges.edge.v2+extract_nodes.extract_text.v3"
+	if got := library.Version(); got != want {
+		t.Errorf("Version() = %q, want %q", got, want)
+	}
+
+	if err := library.Register("extract_nodes.extract_text", tmpl); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if got, want := library.Version(), LatestVersion+"+extract_edges.edge.v2"; got != want {
+		t.Errorf("Version() after an unversioned template = %q, want %q", got, want)
+	}
+	if err := library.Register("extract_nodes.v3", tmpl); err == nil {
+		t.Error("Register() of a versioned group error = nil")
+	}
+}
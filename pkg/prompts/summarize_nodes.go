@@ -13,6 +13,7 @@ type SummarizeNodesPrompt interface {
 	SummarizePair() types.PromptVersion
 	SummarizeContext() types.PromptVersion
 	SummaryDescription() types.PromptVersion
+	RefreshSummaries() types.PromptVersion
 }
 
 // SummarizeNodesVersions holds all versions of summarize nodes prompts.
@@ -20,6 +21,7 @@ type SummarizeNodesVersions struct {
 	summarizePairPrompt      types.PromptVersion
 	summarizeContextPrompt   types.PromptVersion
 	summaryDescriptionPrompt types.PromptVersion
+	refreshSummariesPrompt   types.PromptVersion
 }
 
 func (s *SummarizeNodesVersions) SummarizePair() types.PromptVersion { return s.summarizePairPrompt }
@@ -29,6 +31,27 @@ func (s *SummarizeNodesVersions) SummarizeContext() types.PromptVersion {
 func (s *SummarizeNodesVersions) SummaryDescription() types.PromptVersion {
 	return s.summaryDescriptionPrompt
 }
+func (s *SummarizeNodesVersions) RefreshSummaries() types.PromptVersion {
+	return s.refreshSummariesPrompt
+}
+
+// updatePrompt replaces the prompt of the given name with the result of
+// update, reporting whether it exists.
+func (s *SummarizeNodesVersions) updatePrompt(name string, update func(PromptVersion) PromptVersion) bool {
+	switch name {
+	case "summarize_pair":
+		s.summarizePairPrompt = update(s.summarizePairPrompt)
+	case "summarize_context":
+		s.summarizeContextPrompt = update(s.summarizeContextPrompt)
+	case "summary_description":
+		s.summaryDescriptionPrompt = update(s.summaryDescriptionPrompt)
+	case "refresh_summaries":
+		s.refreshSummariesPrompt = update(s.refreshSummariesPrompt)
+	default:
+		return false
+	}
+	return true
+}
 
 // summarizePairPrompt combines summaries.
 // Uses TSV format for node summaries to reduce token usage.
@@ -43,7 +66,7 @@ func summarizePairPrompt(context map[string]interface{}) ([]types.Message, error
 		}
 	}
 
-	nodeSummariesTSV, err := ToPromptCSV(nodeSummaries, ensureASCII)
+	nodeSummariesTSV, err := ToPromptContext(context, nodeSummaries, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal node summaries: %w", err)
 	}
@@ -53,9 +76,9 @@ Synthesize the information from the following two summaries into a single succin
 
 Summaries must be under 250 words.
 
-Summaries are provided in TSV (tab-separated values) format:
+Summaries are provided in %s format:
 %s
-`, nodeSummariesTSV)
+`, contextFormatName(context), nodeSummariesTSV)
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -81,16 +104,17 @@ func summarizeContextPrompt(context map[string]interface{}) ([]types.Message, er
 		}
 	}
 
-	previousEpisodesTSV, err := ToPromptCSV(previousEpisodes, ensureASCII)
+	previousEpisodesTSV, err := ToPromptContext(context, previousEpisodes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal previous episodes: %w", err)
 	}
 
-	attributesTSV, err := ToPromptCSV(attributes, ensureASCII)
+	attributesTSV, err := ToPromptContext(context, attributes, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal attributes: %w", err)
 	}
 
+	summaryOpts := summaryOptions(context)
 	userPrompt := fmt.Sprintf(`
 
 <PREVIOUS MESSAGES>
@@ -100,11 +124,10 @@ func summarizeContextPrompt(context map[string]interface{}) ([]types.Message, er
 %v
 </CURRENT MESSAGE>
 
-Note: PREVIOUS MESSAGES and ATTRIBUTES are provided in TSV (tab-separated values) format.
+Note: PREVIOUS MESSAGES and ATTRIBUTES are provided in %s format.
 
-Given the above MESSAGES and the following ENTITY name, create a summary for the ENTITY. Your summary must only use
-information from the provided MESSAGES. Your summary should also only contain information relevant to the
-provided ENTITY. Summaries must be under 250 words.
+Given the above MESSAGES and the following ENTITY name, create a summary for the ENTITY. %s
+Your summary should also only contain information relevant to the provided ENTITY. %s
 
 In addition, extract any values for the provided entity properties based on their descriptions.
 If the value of the entity property cannot be found in the current context, set the value of the property to the Python value None.
@@ -124,7 +147,9 @@ Guidelines:
 <ATTRIBUTES>
 %s
 </ATTRIBUTES>
-`, previousEpisodesTSV, episodeContent, nodeName, nodeSummary, attributesTSV)
+`, previousEpisodesTSV, episodeContent, contextFormatName(context),
+		summarySourceGuideline(summaryOpts, "Your summary must only use information from the provided MESSAGES.", ""),
+		summaryLengthGuideline(summaryOpts, false), nodeName, nodeSummary, attributesTSV)
 	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
 	return []types.Message{
 		llm.NewSystemMessage(sysPrompt),
@@ -145,7 +170,7 @@ func summaryDescriptionPrompt(context map[string]interface{}) ([]types.Message,
 		}
 	}
 
-	summaryTSV, err := ToPromptCSV(summary, ensureASCII)
+	summaryTSV, err := ToPromptContext(context, summary, ensureASCII)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal summary: %w", err)
 	}
@@ -164,11 +189,84 @@ Summary (in TSV format):
 	}, nil
 }
 
+// refreshSummariesPrompt rewrites the summaries of entities from all the
+// episodes mentioning them, ignoring their current summaries.
+// Uses TSV format for entities and episodes to reduce token usage.
+func refreshSummariesPrompt(context map[string]interface{}) ([]types.Message, error) {
+	sysPrompt := `You are a helpful assistant that writes entity summaries from the messages that mention them.`
+
+	nodes := context["nodes"]
+	episodes := context["episodes"]
+	ensureASCII := true
+	if val, ok := context["ensure_ascii"]; ok {
+		if b, ok := val.(bool); ok {
+			ensureASCII = b
+		}
+	}
+
+	nodesTSV, err := ToPromptContext(context, nodes, ensureASCII)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal nodes: %w", err)
+	}
+
+	episodesTSV, err := ToPromptContext(context, episodes, ensureASCII)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal episodes: %w", err)
+	}
+
+	summaryOpts := summaryOptions(context)
+	userPrompt := fmt.Sprintf(`
+<MESSAGES>
+%s
+</MESSAGES>
+
+<ENTITIES>
+%s
+</ENTITIES>
+
+Note: MESSAGES and ENTITIES are provided in %s format. The episode_ids column of each
+entity lists the episode_id of the MESSAGES that mention it, oldest first.
+
+Write a new summary for each entity from the MESSAGES that mention it.
+
+Guidelines:
+1. %s
+2. When MESSAGES disagree, prefer the most recent ones and mention what changed.
+3. %s
+4. Format your response as a TSV with the following schema:
+
+<SCHEMA>
+node_id: int
+summary: string
+</SCHEMA>
+
+<EXAMPLE>
+node_id	summary
+0	John Smith is a software engineer who works at Google. He has 10 years of experience.
+1	Alice Johnson is a data scientist specializing in machine learning.
+
+</EXAMPLE>
+
+Provide a TSV row for each entity in the ENTITIES list above.
+Use the node_id field from each entity to identify it in your TSV output.
+Finish your response with a new line.
+`, episodesTSV, nodesTSV, contextFormatName(context),
+		summarySourceGuideline(summaryOpts, "Only use information about the entity found in its MESSAGES. Do not hallucinate entity information.",
+			"Only use information about the entity found in its MESSAGES."),
+		summaryLengthGuideline(summaryOpts, true))
+	logPrompts(context["logger"].(*slog.Logger), sysPrompt, userPrompt)
+	return []types.Message{
+		llm.NewSystemMessage(sysPrompt),
+		llm.NewUserMessage(userPrompt),
+	}, nil
+}
+
 // NewSummarizeNodesVersions creates a new SummarizeNodesVersions instance.
 func NewSummarizeNodesVersions() *SummarizeNodesVersions {
 	return &SummarizeNodesVersions{
 		summarizePairPrompt:      NewPromptVersion(summarizePairPrompt),
 		summarizeContextPrompt:   NewPromptVersion(summarizeContextPrompt),
 		summaryDescriptionPrompt: NewPromptVersion(summaryDescriptionPrompt),
+		refreshSummariesPrompt:   NewPromptVersion(refreshSummariesPrompt),
 	}
 }
@@ -0,0 +1,91 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SummaryOptionsKey is the context key of the SummaryOptions of the prompts
+// writing entity summaries.
+const SummaryOptionsKey = "summary_options"
+
+// DefaultSummaryMaxWords is the length of entity summaries without a
+// sentence or token limit.
+const DefaultSummaryMaxWords = 250
+
+// SummaryStyle is the style entity summaries are written in.
+type SummaryStyle string
+
+const (
+	// SummaryStyleProse writes summaries as prose, the default.
+	SummaryStyleProse SummaryStyle = "prose"
+	// SummaryStyleBullets writes summaries as bullet points.
+	SummaryStyleBullets SummaryStyle = "bullets"
+)
+
+// SummaryOptions control the entity summaries written by the prompts
+// extracting, summarizing and refreshing entities.
+type SummaryOptions struct {
+	// MaxSentences and MaxTokens limit the length of summaries. Without
+	// either, summaries are limited to DefaultSummaryMaxWords words.
+	MaxSentences int `json:"max_sentences,omitempty"`
+	MaxTokens    int `json:"max_tokens,omitempty"`
+	// Style is the style of summaries, SummaryStyleProse when empty.
+	Style SummaryStyle `json:"style,omitempty"`
+	// AllowInference lets summaries include what can reasonably be inferred
+	// from the provided context. By default summaries only state what the
+	// context says.
+	AllowInference bool `json:"allow_inference,omitempty"`
+}
+
+// summaryInferenceGuideline allows summaries to include inferences.
+const summaryInferenceGuideline = "Summaries may include what can reasonably be inferred from the provided context, but do not invent facts."
+
+// summaryOptions returns the SummaryOptions set in a prompt context.
+func summaryOptions(context map[string]interface{}) SummaryOptions {
+	switch opts := context[SummaryOptionsKey].(type) {
+	case SummaryOptions:
+		return opts
+	case *SummaryOptions:
+		if opts != nil {
+			return *opts
+		}
+	}
+	return SummaryOptions{}
+}
+
+// summaryLengthGuideline returns the instructions on the length and style of
+// summaries. Summaries written in a table cell are kept on a single line.
+func summaryLengthGuideline(opts SummaryOptions, singleLine bool) string {
+	var limits []string
+	if opts.MaxSentences > 0 {
+		limits = append(limits, fmt.Sprintf("%d sentences", opts.MaxSentences))
+	}
+	if opts.MaxTokens > 0 {
+		// Tokens are about three quarters of a word
+		limits = append(limits, fmt.Sprintf("%d tokens (about %d words)", opts.MaxTokens, max(opts.MaxTokens*3/4, 1)))
+	}
+	guideline := fmt.Sprintf("Summaries must be no longer than %d words.", DefaultSummaryMaxWords)
+	if len(limits) > 0 {
+		guideline = "Summaries must be no longer than " + strings.Join(limits, " and ") + "."
+	}
+
+	if opts.Style == SummaryStyleBullets {
+		if singleLine {
+			guideline += ` Write summaries as short bullet points on a single line, each starting with "• ".`
+		} else {
+			guideline += ` Write summaries as short bullet points, one per line, each starting with "- ".`
+		}
+	}
+	return guideline
+}
+
+// summarySourceGuideline returns strict, the instruction to only use the
+// provided context, or relaxed followed by summaryInferenceGuideline when
+// summaries may include inferences.
+func summarySourceGuideline(opts SummaryOptions, strict, relaxed string) string {
+	if !opts.AllowInference {
+		return strict
+	}
+	return strings.TrimSpace(relaxed + " " + summaryInferenceGuideline)
+}
@@ -0,0 +1,53 @@
+package prompts
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// LatestVersion is the version of the prompts of NewLibrary. It changes with
+// the changes of the built-in prompts that can change what is extracted, so
+// that groups ingested with earlier prompts can keep them.
+const LatestVersion = "v1"
+
+// libraryVersions creates the library of each prompt version.
+var libraryVersions = map[string]func() Library{
+	LatestVersion: NewLibrary,
+}
+
+// Versions returns the prompt versions NewLibraryVersion can create, sorted.
+func Versions() []string {
+	versions := make([]string, 0, len(libraryVersions))
+	for version := range libraryVersions {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+// NewLibraryVersion creates the library of the given prompt version, as
+// returned by Library.Version.
+func NewLibraryVersion(version string) (Library, error) {
+	newLibrary, ok := libraryVersions[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt version %q, expected one of %s",
+			version, strings.Join(Versions(), ", "))
+	}
+	return newLibrary(), nil
+}
+
+// Version returns the prompt version of the library, followed by the
+// prompts registered with a versioned name, as in
+// "v1+extract_nodes.extract_text.v3".
+func (l *LibraryImpl) Version() string {
+	if len(l.promptVersions) == 0 {
+		return l.version
+	}
+	parts := []string{l.version}
+	for _, name := range slices.Sorted(maps.Keys(l.promptVersions)) {
+		parts = append(parts, name+"."+l.promptVersions[name])
+	}
+	return strings.Join(parts, "+")
+}
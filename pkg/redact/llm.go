@@ -0,0 +1,102 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// LLMRedactor redacts the personal information matched by a RegexRedactor,
+// then asks an LLM for the personal information the regular expressions
+// missed, such as spelled out or obfuscated emails and phone numbers.
+type LLMRedactor struct {
+	regex *RegexRedactor
+	llm   llm.Client
+}
+
+// NewLLMRedactor creates a redactor asking client for personal information,
+// deriving tokens with key as NewRegexRedactor does.
+func NewLLMRedactor(client llm.Client, key []byte) *LLMRedactor {
+	return &LLMRedactor{regex: NewRegexRedactor(key), llm: client}
+}
+
+// Redact replaces the emails, phone numbers and SSNs of text with tokens.
+func (r *LLMRedactor) Redact(ctx context.Context, text string) (string, map[string]string, error) {
+	text, tokens, err := r.regex.Redact(ctx, text)
+	if err != nil {
+		return "", nil, err
+	}
+
+	response, err := r.llm.Chat(usage.WithOperation(ctx, usage.OperationRedact), redactionMessages(text))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get LLM response for redaction: %w", err)
+	}
+
+	// Mask the longest values first, so that values containing others are
+	// masked whole
+	found := parseRedactionResponse(response.Content)
+	sort.SliceStable(found, func(i, j int) bool { return len(found[i].value) > len(found[j].value) })
+	for _, f := range found {
+		if strings.Contains(text, f.value) {
+			text = strings.ReplaceAll(text, f.value, r.regex.mask(f.kind, f.value, tokens))
+		}
+	}
+	return text, tokens, nil
+}
+
+// foundValue is personal information found by the LLM.
+type foundValue struct {
+	kind  Kind
+	value string
+}
+
+// redactionMessages returns the guardrail prompt asking for the personal
+// information of text.
+func redactionMessages(text string) []types.Message {
+	kinds := make([]string, len(Kinds))
+	for i, kind := range Kinds {
+		kinds[i] = string(kind)
+	}
+	return []types.Message{
+		llm.NewSystemMessage(`You are a privacy guardrail that finds personal information in text before it is stored.`),
+		llm.NewUserMessage(fmt.Sprintf(`Find every email address, phone number and social security number in the TEXT below, including
+ones that are spelled out or obfuscated, such as "jane at example dot com". Values already replaced by tokens like
+[EMAIL_3f9a0c21b7e4] are redacted; ignore them.
+
+<TEXT>
+%s
+</TEXT>
+
+Respond with a TSV with the columns kind and value, one row per value, where kind is one of %s and value is
+copied exactly as it appears in the TEXT. Respond with only the header row if there is none.
+
+<EXAMPLE>
+kind	value
+EMAIL	jane at example dot com
+PHONE	five five five, one two three four
+</EXAMPLE>
+`, text, strings.Join(kinds, ", "))),
+	}
+}
+
+// parseRedactionResponse parses the values listed in the TSV response of the
+// LLM, skipping rows of unknown kinds.
+func parseRedactionResponse(content string) []foundValue {
+	var found []foundValue
+	for _, line := range strings.Split(content, "\n") {
+		kind, value, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			continue
+		}
+		if k, ok := validKind(strings.TrimSpace(kind)); ok {
+			found = append(found, foundValue{kind: k, value: value})
+		}
+	}
+	return found
+}
@@ -0,0 +1,126 @@
+// Package redact masks personal information such as emails, phone numbers
+// and social security numbers in episode content before it is stored in the
+// graph or sent to the LLM.
+//
+// Each value is replaced by a token like [EMAIL_3f9a0c21b7e4], derived from
+// the value with a keyed hash, so that the same value gets the same token in
+// every episode and entities mentioning it are still deduplicated. The
+// original values of the tokens are kept outside the graph in a TokenStore,
+// from which Rehydrate restores them for authorized readers.
+package redact
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// Kind is a kind of personal information.
+type Kind string
+
+const (
+	KindEmail Kind = "EMAIL"
+	KindPhone Kind = "PHONE"
+	KindSSN   Kind = "SSN"
+)
+
+// Kinds are the kinds of personal information redacted.
+var Kinds = []Kind{KindEmail, KindPhone, KindSSN}
+
+// Redactor masks personal information in text.
+type Redactor interface {
+	// Redact returns text with personal information replaced by tokens, and
+	// the original value of each token.
+	Redact(ctx context.Context, text string) (string, map[string]string, error)
+}
+
+var (
+	// tokenPattern matches the tokens of redacted values
+	tokenPattern = regexp.MustCompile(`\[(?:EMAIL|PHONE|SSN)_[0-9a-f]{12}\]`)
+
+	// patterns match the personal information redacted by RegexRedactor, SSNs
+	// before phone numbers
+	patterns = []struct {
+		kind Kind
+		re   *regexp.Regexp
+	}{
+		{KindEmail, regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)},
+		{KindSSN, regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+		{KindPhone, regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{3}\)\s?|\b\d{3}[\s.\-]?)\d{3}[\s.\-]?\d{4}\b`)},
+	}
+)
+
+// RegexRedactor redacts emails, phone numbers and SSNs matching regular
+// expressions.
+type RegexRedactor struct {
+	key []byte
+}
+
+// NewRegexRedactor creates a redactor deriving tokens with key. Without a
+// key, the values of short tokens such as SSNs can be guessed by hashing
+// candidate values.
+func NewRegexRedactor(key []byte) *RegexRedactor {
+	return &RegexRedactor{key: key}
+}
+
+// Redact replaces the emails, phone numbers and SSNs of text with tokens.
+func (r *RegexRedactor) Redact(ctx context.Context, text string) (string, map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, p := range patterns {
+		text = p.re.ReplaceAllStringFunc(text, func(value string) string {
+			return r.mask(p.kind, value, tokens)
+		})
+	}
+	return text, tokens, nil
+}
+
+// Token returns the token of a value of the given kind.
+func (r *RegexRedactor) Token(kind Kind, value string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(string(kind) + ":" + value))
+	return "[" + string(kind) + "_" + hex.EncodeToString(mac.Sum(nil)[:6]) + "]"
+}
+
+// mask returns the token of value, recording it in tokens.
+func (r *RegexRedactor) mask(kind Kind, value string, tokens map[string]string) string {
+	token := r.Token(kind, value)
+	tokens[token] = value
+	return token
+}
+
+// Tokens returns the distinct tokens of redacted values in text.
+func Tokens(text string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, token := range tokenPattern.FindAllString(text, -1) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// Rehydrate replaces the tokens of text with their values in tokens. Tokens
+// without a value are left as they are.
+func Rehydrate(text string, tokens map[string]string) string {
+	return tokenPattern.ReplaceAllStringFunc(text, func(token string) string {
+		if value, ok := tokens[token]; ok {
+			return value
+		}
+		return token
+	})
+}
+
+// validKind reports whether kind is one of Kinds.
+func validKind(kind string) (Kind, bool) {
+	for _, k := range Kinds {
+		if strings.EqualFold(kind, string(k)) {
+			return k, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,103 @@
+package redact
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestRegexRedactor(t *testing.T) {
+	ctx := context.Background()
+	redactor := NewRegexRedactor([]byte("secret"))
+	text := "Email jane.doe@example.com or call (555) 123-4567 or +1 555.987.6543. SSN 123-45-6789. Order 12345."
+
+	redacted, tokens, err := redactor.Redact(ctx, text)
+	if err != nil {
+		t.Fatalf("Redact() error = %v", err)
+	}
+	for _, value := range []string{"jane.doe@example.com", "(555) 123-4567", "+1 555.987.6543", "123-45-6789"} {
+		if strings.Contains(redacted, value) {
+			t.Errorf("Redact() = %q, still contains %q", redacted, value)
+		}
+	}
+	if !strings.Contains(redacted, "Order 12345.") || len(tokens) != 4 || len(Tokens(redacted)) != 4 {
+		t.Errorf("Redact() = %q, %v", redacted, tokens)
+	}
+	if got := Rehydrate(redacted, tokens); got != text {
+		t.Errorf("Rehydrate() = %q, want %q", got, text)
+	}
+
+	// The same value gets the same token, and another key another token
+	again, _, _ := redactor.Redact(ctx, "jane.doe@example.com")
+	if !strings.Contains(redacted, again) {
+		t.Errorf("Redact() token %q differs from the first redaction %q", again, redacted)
+	}
+	if other, _, _ := NewRegexRedactor([]byte("other")).Redact(ctx, "jane.doe@example.com"); other == again {
+		t.Errorf("Redact() with another key gave the same token %q", other)
+	}
+}
+
+func TestLLMRedactor(t *testing.T) {
+	client := &stubLLM{response: "kind\tvalue\nEMAIL\tjane at example dot com\nNAME\tJane\n"}
+	redactor := NewLLMRedactor(client, nil)
+
+	redacted, tokens, err := redactor.Redact(context.Background(), "Jane (jane at example dot com, 123-45-6789)")
+	if err != nil {
+		t.Fatalf("Redact() error = %v", err)
+	}
+	if !strings.HasPrefix(redacted, "Jane ([EMAIL_") || strings.Contains(redacted, "123-45-6789") || len(tokens) != 2 {
+		t.Errorf("Redact() = %q, %v", redacted, tokens)
+	}
+	// The LLM sees the text after the regular expressions redacted it
+	if strings.Contains(client.prompt, "123-45-6789") {
+		t.Errorf("prompt contains a value redacted by the regular expressions")
+	}
+}
+
+func TestFileTokenStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	store, err := NewFileTokenStore(path)
+	if err != nil {
+		t.Fatalf("NewFileTokenStore() error = %v", err)
+	}
+	if err := store.SaveTokens(ctx, map[string]string{"[SSN_000000000000]": "123-45-6789"}); err != nil {
+		t.Fatalf("SaveTokens() error = %v", err)
+	}
+
+	reopened, err := NewFileTokenStore(path)
+	if err != nil {
+		t.Fatalf("NewFileTokenStore() error = %v", err)
+	}
+	values, err := reopened.LoadTokens(ctx, []string{"[SSN_000000000000]", "[EMAIL_000000000000]"})
+	if err != nil || len(values) != 1 || values["[SSN_000000000000]"] != "123-45-6789" {
+		t.Errorf("LoadTokens() = %v, %v", values, err)
+	}
+}
+
+// stubLLM responds to every request with response, recording the last
+// prompt.
+type stubLLM struct {
+	response string
+	prompt   string
+}
+
+func (s *stubLLM) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	s.prompt = messages[len(messages)-1].Content
+	return &types.Response{Content: s.response}, nil
+}
+
+func (s *stubLLM) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	return s.Chat(ctx, messages)
+}
+
+func (s *stubLLM) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return s.Chat(ctx, messages)
+}
+
+func (s *stubLLM) Close() error {
+	return nil
+}
@@ -0,0 +1,91 @@
+package redact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// TokenStore keeps the original values of tokens outside the graph.
+type TokenStore interface {
+	// SaveTokens saves the values of tokens.
+	SaveTokens(ctx context.Context, tokens map[string]string) error
+	// LoadTokens returns the values of the given tokens that are known.
+	LoadTokens(ctx context.Context, tokens []string) (map[string]string, error)
+}
+
+// FileTokenStore is a TokenStore keeping the values of tokens in a JSON file
+// readable only by its owner.
+type FileTokenStore struct {
+	path string
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewFileTokenStore opens the token store at path, creating it on the first
+// save if it does not exist.
+func NewFileTokenStore(path string) (*FileTokenStore, error) {
+	store := &FileTokenStore{path: path, tokens: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token store: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse token store %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// SaveTokens saves the values of tokens, rewriting the file when they are
+// new.
+func (s *FileTokenStore) SaveTokens(ctx context.Context, tokens map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for token, value := range tokens {
+		if s.tokens[token] != value {
+			s.tokens[token] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.tokens, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal token store: %w", err)
+	}
+	// Write a temporary file and rename it, so that a failed write leaves the
+	// previous tokens
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write token store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write token store: %w", err)
+	}
+	return nil
+}
+
+// LoadTokens returns the values of the given tokens that are known.
+func (s *FileTokenStore) LoadTokens(ctx context.Context, tokens []string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values := make(map[string]string, len(tokens))
+	for _, token := range tokens {
+		if value, ok := s.tokens[token]; ok {
+			values[token] = value
+		}
+	}
+	return values, nil
+}
@@ -25,12 +25,12 @@ var CombinedHybridSearchMMR = &SearchConfig{
 	EdgeConfig: &EdgeSearchConfig{
 		SearchMethods: []SearchMethod{BM25, CosineSimilarity},
 		Reranker:      MMRRerankType,
-		MMRLambda:     1.0,
+		MMRLambda:     relevanceOnly(),
 	},
 	NodeConfig: &NodeSearchConfig{
 		SearchMethods: []SearchMethod{BM25, CosineSimilarity},
 		Reranker:      MMRRerankType,
-		MMRLambda:     1.0,
+		MMRLambda:     relevanceOnly(),
 	},
 	EpisodeConfig: &EpisodeSearchConfig{
 		SearchMethods: []SearchMethod{BM25},
@@ -39,7 +39,7 @@ var CombinedHybridSearchMMR = &SearchConfig{
 	CommunityConfig: &CommunitySearchConfig{
 		SearchMethods: []SearchMethod{BM25, CosineSimilarity},
 		Reranker:      MMRRerankType,
-		MMRLambda:     1.0,
+		MMRLambda:     relevanceOnly(),
 	},
 }
 
@@ -95,6 +95,14 @@ var EdgeHybridSearchEpisodeMentions = &SearchConfig{
 	},
 }
 
+// EdgeHybridSearchStrength performs a hybrid search over edges with strength reranking
+var EdgeHybridSearchStrength = &SearchConfig{
+	EdgeConfig: &EdgeSearchConfig{
+		SearchMethods: []SearchMethod{BM25, CosineSimilarity},
+		Reranker:      StrengthRerankType,
+	},
+}
+
 // EdgeHybridSearchCrossEncoder performs a hybrid search over edges with cross encoder reranking
 var EdgeHybridSearchCrossEncoder = &SearchConfig{
 	EdgeConfig: &EdgeSearchConfig{
@@ -169,3 +177,9 @@ var CommunityHybridSearchCrossEncoder = &SearchConfig{
 	},
 	Limit: 3,
 }
+
+// relevanceOnly returns the MMR lambda ranking by relevance alone.
+func relevanceOnly() *float64 {
+	lambda := 1.0
+	return &lambda
+}
@@ -0,0 +1,192 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// FusionMethod selects how the RRF reranker combines the result lists of the
+// search methods.
+type FusionMethod string
+
+const (
+	// RRFFusion ranks results by reciprocal rank fusion, which only uses
+	// their ranks in each list
+	RRFFusion FusionMethod = "rrf"
+	// LinearFusion ranks results by the weighted sum of their normalized
+	// scores in each list
+	LinearFusion FusionMethod = "linear"
+)
+
+// ScoreNormalization selects how LinearFusion makes the scores of different
+// search methods comparable.
+type ScoreNormalization string
+
+const (
+	// MinMaxNormalization rescales the scores of a list to [0, 1]
+	MinMaxNormalization ScoreNormalization = "min_max"
+	// ZScoreNormalization rescales the scores of a list to mean 0 and
+	// standard deviation 1
+	ZScoreNormalization ScoreNormalization = "z_score"
+)
+
+// memberOverlapMethod labels the communities found through the nodes they
+// contain.
+const memberOverlapMethod SearchMethod = "member_overlap"
+
+// fusionOptions holds the fusion settings of a SearchConfig.
+type fusionOptions struct {
+	method        FusionMethod
+	normalization ScoreNormalization
+	weights       map[SearchMethod]float64
+}
+
+// fusion returns the fusion settings of the config with their defaults, RRF
+// and min-max normalization.
+func (c *SearchConfig) fusion() (*fusionOptions, error) {
+	fusion := &fusionOptions{
+		method:        c.FusionMethod,
+		normalization: c.ScoreNormalization,
+		weights:       c.FusionWeights,
+	}
+	switch fusion.method {
+	case "":
+		fusion.method = RRFFusion
+	case RRFFusion, LinearFusion:
+	default:
+		return nil, fmt.Errorf("unknown fusion method %q", fusion.method)
+	}
+	switch fusion.normalization {
+	case "":
+		fusion.normalization = MinMaxNormalization
+	case MinMaxNormalization, ZScoreNormalization:
+	default:
+		return nil, fmt.Errorf("unknown score normalization %q", fusion.normalization)
+	}
+	return fusion, nil
+}
+
+// weight returns the weight of method's results, 1 unless set.
+func (f *fusionOptions) weight(method SearchMethod) float64 {
+	if weight, ok := f.weights[method]; ok {
+		return weight
+	}
+	return 1
+}
+
+// linearFusion ranks the items of lists by the weighted sum of their
+// normalized scores in each list, the list at index i coming from methods[i].
+// Results of CosineSimilarity are scored by their similarity to queryVector
+// using embeddings; the drivers return no scores for the other methods, so
+// their results are scored by rank, 1/(rank+1). An item missing from a list
+// gets no score from it. Ties are ordered by UUID.
+func linearFusion[T any](lists [][]T, methods []SearchMethod, queryVector []float32, embeddings map[string][]float32, fusion *fusionOptions, uuidOf func(T) string) ([]T, []float64) {
+	byUUID := make(map[string]T)
+	var order []string
+	scores := make(map[string]float64)
+
+	for i, list := range lists {
+		uuids := make([]string, len(list))
+		for rank, item := range list {
+			uuids[rank] = uuidOf(item)
+			if _, seen := byUUID[uuids[rank]]; !seen {
+				byUUID[uuids[rank]] = item
+				order = append(order, uuids[rank])
+			}
+		}
+
+		method := methods[i]
+		normalized := normalizeScores(listScores(uuids, method, queryVector, embeddings), fusion.normalization)
+		weight := fusion.weight(method)
+		for rank, uuid := range uuids {
+			scores[uuid] += weight * normalized[rank]
+		}
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		if scores[order[i]] != scores[order[j]] {
+			return scores[order[i]] > scores[order[j]]
+		}
+		return order[i] < order[j]
+	})
+
+	items := make([]T, len(order))
+	itemScores := make([]float64, len(order))
+	for i, uuid := range order {
+		items[i] = byUUID[uuid]
+		itemScores[i] = scores[uuid]
+	}
+	return items, itemScores
+}
+
+// listScores returns the raw scores of a result list of method: the cosine
+// similarities to queryVector for CosineSimilarity results that all have
+// embeddings, and otherwise 1/(rank+1).
+func listScores(uuids []string, method SearchMethod, queryVector []float32, embeddings map[string][]float32) []float64 {
+	scores := make([]float64, len(uuids))
+	if method == CosineSimilarity && len(queryVector) > 0 {
+		similarities := true
+		for i, uuid := range uuids {
+			embedding, ok := embeddings[uuid]
+			if !ok {
+				similarities = false
+				break
+			}
+			scores[i] = CalculateCosineSimilarity(queryVector, embedding)
+		}
+		if similarities {
+			return scores
+		}
+	}
+
+	for rank := range uuids {
+		scores[rank] = 1.0 / float64(rank+1)
+	}
+	return scores
+}
+
+// normalizeScores rescales scores with normalization. Scores that are all
+// equal become 1 with min-max and 0 with z-score normalization.
+func normalizeScores(scores []float64, normalization ScoreNormalization) []float64 {
+	normalized := make([]float64, len(scores))
+	if len(scores) == 0 {
+		return normalized
+	}
+
+	switch normalization {
+	case ZScoreNormalization:
+		var mean float64
+		for _, score := range scores {
+			mean += score
+		}
+		mean /= float64(len(scores))
+
+		var variance float64
+		for _, score := range scores {
+			variance += (score - mean) * (score - mean)
+		}
+		stddev := math.Sqrt(variance / float64(len(scores)))
+		if stddev == 0 {
+			return normalized
+		}
+		for i, score := range scores {
+			normalized[i] = (score - mean) / stddev
+		}
+
+	default:
+		lowest, highest := scores[0], scores[0]
+		for _, score := range scores {
+			lowest = math.Min(lowest, score)
+			highest = math.Max(highest, score)
+		}
+		for i, score := range scores {
+			if highest == lowest {
+				normalized[i] = 1
+			} else {
+				normalized[i] = (score - lowest) / (highest - lowest)
+			}
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeScores(t *testing.T) {
+	minMax := normalizeScores([]float64{2, 4, 6}, MinMaxNormalization)
+	if want := []float64{0, 0.5, 1}; !reflect.DeepEqual(minMax, want) {
+		t.Errorf("min-max = %v, want %v", minMax, want)
+	}
+
+	zScore := normalizeScores([]float64{2, 4, 6}, ZScoreNormalization)
+	stddev := math.Sqrt(8.0 / 3.0)
+	want := []float64{-2 / stddev, 0, 2 / stddev}
+	for i := range want {
+		if math.Abs(zScore[i]-want[i]) > 1e-9 {
+			t.Errorf("z-score[%d] = %f, want %f", i, zScore[i], want[i])
+		}
+	}
+
+	if equal := normalizeScores([]float64{3, 3}, MinMaxNormalization); !reflect.DeepEqual(equal, []float64{1, 1}) {
+		t.Errorf("min-max of equal scores = %v, want [1 1]", equal)
+	}
+	if equal := normalizeScores([]float64{3, 3}, ZScoreNormalization); !reflect.DeepEqual(equal, []float64{0, 0}) {
+		t.Errorf("z-score of equal scores = %v, want [0 0]", equal)
+	}
+}
+
+func TestLinearFusionWeighsMethods(t *testing.T) {
+	lists := [][]string{{"a", "b"}, {"b", "c"}}
+	methods := []SearchMethod{BM25, CosineSimilarity}
+	queryVector := []float32{1, 0}
+	embeddings := map[string][]float32{
+		"b": {1, 0},
+		"c": {0, 1},
+	}
+	uuidOf := func(uuid string) string { return uuid }
+
+	// BM25 scores a=1, b=0 and similarity scores b=1, c=0 after
+	// normalization, so a and b tie on equal weights
+	equal := &fusionOptions{method: LinearFusion, normalization: MinMaxNormalization}
+	uuids, scores := linearFusion(lists, methods, queryVector, embeddings, equal, uuidOf)
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(uuids, want) {
+		t.Errorf("equal weights ranked %v, want %v", uuids, want)
+	}
+	if want := []float64{1, 1, 0}; !reflect.DeepEqual(scores, want) {
+		t.Errorf("equal weights scored %v, want %v", scores, want)
+	}
+
+	weighted := &fusionOptions{
+		method:        LinearFusion,
+		normalization: MinMaxNormalization,
+		weights:       map[SearchMethod]float64{CosineSimilarity: 2},
+	}
+	uuids, _ = linearFusion(lists, methods, queryVector, embeddings, weighted, uuidOf)
+	if want := []string{"b", "a", "c"}; !reflect.DeepEqual(uuids, want) {
+		t.Errorf("weighted similarity ranked %v, want %v", uuids, want)
+	}
+}
+
+func TestSearchConfigFusionDefaults(t *testing.T) {
+	fusion, err := (&SearchConfig{}).fusion()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fusion.method != RRFFusion || fusion.normalization != MinMaxNormalization {
+		t.Errorf("defaults = %s/%s, want rrf/min_max", fusion.method, fusion.normalization)
+	}
+
+	if _, err := (&SearchConfig{FusionMethod: "sum"}).fusion(); err == nil {
+		t.Error("expected an error for an unknown fusion method")
+	}
+	if _, err := (&SearchConfig{ScoreNormalization: "rank"}).fusion(); err == nil {
+		t.Error("expected an error for an unknown normalization")
+	}
+}
@@ -16,6 +16,19 @@ type BFSSearchOptions struct {
 	Limit         int
 	SearchFilters *SearchFilters
 	GroupIDs      []string
+	// EdgeNames are the relation names, such as WORKS_AT, the search may
+	// traverse; empty traverses all relations
+	EdgeNames []string
+}
+
+// bfsEdgeNameFilter returns the condition keeping the paths that only
+// traverse the relations named $bfs_edge_names.
+func bfsEdgeNameFilter(provider driver.GraphProvider) string {
+	if provider == driver.GraphProviderLadybug {
+		// Ladybug stores relations as RelatesToNode_ nodes on the path
+		return "all(x IN nodes(path) WHERE label(x) <> 'RelatesToNode_' OR x.name IN $bfs_edge_names)"
+	}
+	return "all(r IN relationships(path) WHERE type(r) <> 'RELATES_TO' OR r.name IN $bfs_edge_names)"
 }
 
 // NodeBFSSearch performs breadth-first search to find nodes connected to origin nodes
@@ -66,6 +79,11 @@ func (su *SearchUtilities) NodeBFSSearch(ctx context.Context, originNodeUUIDs []
 		filterParams["group_ids"] = options.GroupIDs
 	}
 
+	if len(options.EdgeNames) > 0 {
+		filterQueries = append(filterQueries, bfsEdgeNameFilter(provider))
+		filterParams["bfs_edge_names"] = options.EdgeNames
+	}
+
 	filterQuery := ""
 	if len(filterQueries) > 0 {
 		filterQuery = " AND " + strings.Join(filterQueries, " AND ")
@@ -82,14 +100,14 @@ func (su *SearchUtilities) NodeBFSSearch(ctx context.Context, originNodeUUIDs []
 		// Match from Episodic nodes to Entity nodes via MENTIONS
 		matchQueries = append(matchQueries, `
 			UNWIND $bfs_origin_node_uuids AS origin_uuid
-			MATCH (origin:Episodic {uuid: origin_uuid})-[:MENTIONS]->(n:Entity)
+			MATCH path = (origin:Episodic {uuid: origin_uuid})-[:MENTIONS]->(n:Entity)
 			WHERE n.group_id = origin.group_id
 		`)
 
 		// Match from Entity nodes to other Entity nodes via RELATES_TO
 		matchQueries = append(matchQueries, fmt.Sprintf(`
 			UNWIND $bfs_origin_node_uuids AS origin_uuid
-			MATCH (origin:Entity {uuid: origin_uuid})-[:RELATES_TO*2..%d]->(n:Entity)
+			MATCH path = (origin:Entity {uuid: origin_uuid})-[:RELATES_TO*2..%d]->(n:Entity)
 			WHERE n.group_id = origin.group_id
 		`, depth))
 
@@ -98,14 +116,14 @@ func (su *SearchUtilities) NodeBFSSearch(ctx context.Context, originNodeUUIDs []
 			innerDepth := (options.MaxDepth - 1) * 2
 			matchQueries = append(matchQueries, fmt.Sprintf(`
 				UNWIND $bfs_origin_node_uuids AS origin_uuid
-				MATCH (origin:Episodic {uuid: origin_uuid})-[:MENTIONS]->(:Entity)-[:RELATES_TO*2..%d]->(n:Entity)
+				MATCH path = (origin:Episodic {uuid: origin_uuid})-[:MENTIONS]->(:Entity)-[:RELATES_TO*2..%d]->(n:Entity)
 				WHERE n.group_id = origin.group_id
 			`, innerDepth))
 		}
 	} else if provider == driver.GraphProviderNeptune {
 		matchQueries = append(matchQueries, fmt.Sprintf(`
 			UNWIND $bfs_origin_node_uuids AS origin_uuid
-			MATCH (origin {uuid: origin_uuid})-[e:RELATES_TO|MENTIONS*1..%d]->(n:Entity)
+			MATCH path = (origin {uuid: origin_uuid})-[e:RELATES_TO|MENTIONS*1..%d]->(n:Entity)
 			WHERE origin:Entity OR origin:Episodic
 			AND n.group_id = origin.group_id
 		`, options.MaxDepth))
@@ -113,7 +131,7 @@ func (su *SearchUtilities) NodeBFSSearch(ctx context.Context, originNodeUUIDs []
 		// Default for Neo4j and FalkorDB
 		matchQueries = append(matchQueries, fmt.Sprintf(`
 			UNWIND $bfs_origin_node_uuids AS origin_uuid
-			MATCH (origin {uuid: origin_uuid})-[:RELATES_TO|MENTIONS*1..%d]->(n:Entity)
+			MATCH path = (origin {uuid: origin_uuid})-[:RELATES_TO|MENTIONS*1..%d]->(n:Entity)
 			WHERE n.group_id = origin.group_id
 		`, options.MaxDepth))
 	}
@@ -186,6 +204,27 @@ func (su *SearchUtilities) EdgeBFSSearch(ctx context.Context, originNodeUUIDs []
 			filterQueries = append(filterQueries, "e.edge_type IN $edge_types")
 			filterParams["edge_types"] = typeStrs
 		}
+
+		// Add edge name filters
+		if len(options.SearchFilters.EdgeNames) > 0 {
+			filterQueries = append(filterQueries, "e.name IN $edge_names")
+			filterParams["edge_names"] = options.SearchFilters.EdgeNames
+		}
+
+		// Keep edges valid at the as-of time; Ladybug stores timestamps,
+		// the other drivers RFC3339 strings
+		if asOf := options.SearchFilters.asOf; !asOf.IsZero() {
+			validFrom, validTo := "valid_from", "valid_to"
+			var asOfParam interface{} = asOf.UTC().Format(time.RFC3339)
+			if provider == driver.GraphProviderLadybug {
+				validFrom, validTo = "valid_at", "invalid_at"
+				asOfParam = asOf.UTC()
+			}
+			filterQueries = append(filterQueries,
+				"(e."+validFrom+" IS NULL OR e."+validFrom+" <= $as_of)",
+				"(e."+validTo+" IS NULL OR e."+validTo+" > $as_of)")
+			filterParams["as_of"] = asOfParam
+		}
 	}
 
 	if len(options.GroupIDs) > 0 {
@@ -193,6 +232,11 @@ func (su *SearchUtilities) EdgeBFSSearch(ctx context.Context, originNodeUUIDs []
 		filterParams["group_ids"] = options.GroupIDs
 	}
 
+	if len(options.EdgeNames) > 0 {
+		filterQueries = append(filterQueries, bfsEdgeNameFilter(provider))
+		filterParams["bfs_edge_names"] = options.EdgeNames
+	}
+
 	filterQuery := ""
 	if len(filterQueries) > 0 {
 		filterQuery = " WHERE " + strings.Join(filterQueries, " AND ")
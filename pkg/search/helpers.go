@@ -86,6 +86,12 @@ func SearchResultsToContextString(results *HybridSearchResult, ensureASCII bool)
 			})
 		}
 	}
+	for _, community := range results.Communities {
+		communityJSON = append(communityJSON, map[string]interface{}{
+			"community_name": community.Name,
+			"summary":        community.Summary,
+		})
+	}
 
 	// Convert to JSON strings with proper indentation
 	factJSONStr, err := toPromptJSON(factJSON, ensureASCII, 12)
@@ -189,6 +195,7 @@ func GetSearchConfigByName(name string) *SearchConfig {
 		"edge_hybrid_mmr":                EdgeHybridSearchMMR,
 		"edge_hybrid_node_distance":      EdgeHybridSearchNodeDistance,
 		"edge_hybrid_episode_mentions":   EdgeHybridSearchEpisodeMentions,
+		"edge_hybrid_strength":           EdgeHybridSearchStrength,
 		"edge_hybrid_cross_encoder":      EdgeHybridSearchCrossEncoder,
 		"node_hybrid_rrf":                NodeHybridSearchRRF,
 		"node_hybrid_mmr":                NodeHybridSearchMMR,
@@ -213,6 +220,7 @@ func ListAvailableSearchConfigs() []string {
 		"edge_hybrid_mmr",
 		"edge_hybrid_node_distance",
 		"edge_hybrid_episode_mentions",
+		"edge_hybrid_strength",
 		"edge_hybrid_cross_encoder",
 		"node_hybrid_rrf",
 		"node_hybrid_mmr",
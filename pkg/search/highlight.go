@@ -0,0 +1,209 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// DefaultSnippetLength is the maximum length in bytes of a highlight snippet
+// when SearchConfig.SnippetLength is zero.
+const DefaultSnippetLength = 200
+
+// highlightStopWords are query words too common to highlight.
+var highlightStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "did": true, "do": true, "does": true, "for": true,
+	"from": true, "has": true, "have": true, "how": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "the": true, "to": true,
+	"was": true, "what": true, "when": true, "where": true, "which": true,
+	"who": true, "why": true, "with": true,
+}
+
+// textWord is a word of a text and its byte range.
+type textWord struct {
+	text       string
+	start, end int
+}
+
+// textWords splits text into runs of letters and digits.
+func textWords(text string) []textWord {
+	var words []textWord
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			words = append(words, textWord{text: text[start:i], start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, textWord{text: text[start:], start: start, end: len(text)})
+	}
+	return words
+}
+
+// highlightSuffixes are English inflections ignored when matching words.
+var highlightSuffixes = []string{"ing", "ed", "es", "s"}
+
+// highlightStem removes an inflection from a lowercased word, keeping at
+// least three letters.
+func highlightStem(word string) string {
+	for _, suffix := range highlightSuffixes {
+		if stem, ok := strings.CutSuffix(word, suffix); ok && len(stem) >= 3 {
+			return stem
+		}
+	}
+	return word
+}
+
+// termMatches reports whether a lowercased word of a text matches a query
+// term: the word starts with the term, or with its stem.
+func termMatches(word, term string) bool {
+	return strings.HasPrefix(word, term) || strings.HasPrefix(highlightStem(word), highlightStem(term))
+}
+
+// queryTerms returns the distinct lowercased words of query worth
+// highlighting: single letters and stop words are skipped.
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range textWords(query) {
+		term := strings.ToLower(word.text)
+		if utf8.RuneCountInString(term) < 2 || highlightStopWords[term] || seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// HighlightText returns the snippet of text of at most maxLength bytes with
+// the most words matching a word of query, or nil if no word matches. Words
+// match case-insensitively by prefix, ignoring inflections, so "runs"
+// highlights "Running".
+func HighlightText(field, text, query string, maxLength int) *types.Highlight {
+	return highlightTerms(field, text, queryTerms(query), maxLength)
+}
+
+func highlightTerms(field, text string, terms []string, maxLength int) *types.Highlight {
+	if len(terms) == 0 || text == "" {
+		return nil
+	}
+	if maxLength <= 0 {
+		maxLength = DefaultSnippetLength
+	}
+
+	var matches []types.TextRange
+	for _, word := range textWords(text) {
+		lower := strings.ToLower(word.text)
+		for _, term := range terms {
+			if termMatches(lower, term) {
+				matches = append(matches, types.TextRange{Start: word.start, End: word.end})
+				break
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	start, end := 0, len(text)
+	if len(text) > maxLength {
+		start, end = snippetWindow(text, matches, maxLength)
+	}
+
+	highlight := &types.Highlight{
+		Field:   field,
+		Snippet: text[start:end],
+		Offset:  start,
+	}
+	for _, match := range matches {
+		if match.Start >= start && match.End <= end {
+			highlight.Matches = append(highlight.Matches, types.TextRange{Start: match.Start - start, End: match.End - start})
+		}
+	}
+	return highlight
+}
+
+// snippetWindow returns the byte range of at most maxLength bytes of text
+// covering the most matches, centered on them and cut at word boundaries.
+func snippetWindow(text string, matches []types.TextRange, maxLength int) (int, int) {
+	best, bestCount := 0, 0
+	for i := range matches {
+		count := 0
+		for j := i; j < len(matches) && matches[j].End-matches[i].Start <= maxLength; j++ {
+			count++
+		}
+		if count > bestCount {
+			best, bestCount = i, count
+		}
+	}
+	if bestCount == 0 {
+		// The first match alone is longer than a snippet
+		return matches[0].Start, matches[0].Start + alignRune(text[matches[0].Start:], maxLength)
+	}
+	last := best + bestCount - 1
+
+	margin := (maxLength - (matches[last].End - matches[best].Start)) / 2
+	start := max(0, matches[best].Start-margin)
+	end := min(len(text), start+maxLength)
+	start = max(0, end-maxLength)
+
+	if start > 0 {
+		if space := strings.IndexFunc(text[start:matches[best].Start], unicode.IsSpace); space >= 0 {
+			start += space + 1
+		} else {
+			start = matches[best].Start
+		}
+	}
+	if end < len(text) {
+		if space := strings.LastIndexFunc(text[matches[last].End:end], unicode.IsSpace); space >= 0 {
+			end = matches[last].End + space
+		} else {
+			end = matches[last].End
+		}
+	}
+	return start, end
+}
+
+// alignRune shortens n so that text[:n] ends on a rune boundary.
+func alignRune(text string, n int) int {
+	for n > 0 && n < len(text) && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return n
+}
+
+// highlightResults returns the highlights of the names and summaries of nodes
+// and communities and the facts of edges, keyed by UUID.
+func highlightResults(query string, result *HybridSearchResult, maxLength int) map[string][]types.Highlight {
+	terms := queryTerms(query)
+	highlights := make(map[string][]types.Highlight)
+	add := func(uuid string, highlight *types.Highlight) {
+		if highlight != nil {
+			highlights[uuid] = append(highlights[uuid], *highlight)
+		}
+	}
+
+	for _, nodes := range [][]*types.Node{result.Nodes, result.Communities} {
+		for _, node := range nodes {
+			add(node.Uuid, highlightTerms("name", node.Name, terms, maxLength))
+			add(node.Uuid, highlightTerms("summary", node.Summary, terms, maxLength))
+		}
+	}
+	for _, edge := range result.Edges {
+		fact := edge.Fact
+		if fact == "" {
+			fact = edge.Summary
+		}
+		add(edge.Uuid, highlightTerms("fact", fact, terms, maxLength))
+	}
+	return highlights
+}
@@ -0,0 +1,72 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestHighlightText(t *testing.T) {
+	text := "Alice is running the Berlin office of Acme."
+	highlight := HighlightText("summary", text, "who runs the Berlin office?", 0)
+	if highlight == nil {
+		t.Fatal("expected a highlight")
+	}
+	if highlight.Snippet != text || highlight.Offset != 0 {
+		t.Errorf("snippet = %q at %d, want the whole text", highlight.Snippet, highlight.Offset)
+	}
+
+	var matched []string
+	for _, match := range highlight.Matches {
+		matched = append(matched, highlight.Snippet[match.Start:match.End])
+	}
+	if want := "running Berlin office"; strings.Join(matched, " ") != want {
+		t.Errorf("matched %q, want %q", matched, want)
+	}
+
+	if HighlightText("summary", text, "the of", 0) != nil {
+		t.Error("expected no highlight for stop words only")
+	}
+	if HighlightText("summary", text, "Globex", 0) != nil {
+		t.Error("expected no highlight without matches")
+	}
+}
+
+func TestHighlightTextSnippet(t *testing.T) {
+	text := strings.Repeat("filler words here ", 20) + "the quarterly revenue of Acme grew " + strings.Repeat("and more filler ", 20)
+	highlight := HighlightText("fact", text, "Acme revenue", 60)
+	if highlight == nil {
+		t.Fatal("expected a highlight")
+	}
+	if len(highlight.Snippet) > 60 {
+		t.Errorf("snippet has %d bytes, want at most 60", len(highlight.Snippet))
+	}
+	if text[highlight.Offset:highlight.Offset+len(highlight.Snippet)] != highlight.Snippet {
+		t.Error("offset does not locate the snippet in the text")
+	}
+	if strings.HasPrefix(highlight.Snippet, " ") || strings.HasSuffix(highlight.Snippet, " ") {
+		t.Errorf("snippet %q is not cut at word boundaries", highlight.Snippet)
+	}
+	if len(highlight.Matches) != 2 {
+		t.Fatalf("matches = %v, want revenue and Acme", highlight.Matches)
+	}
+	if got := highlight.Snippet[highlight.Matches[1].Start:highlight.Matches[1].End]; got != "Acme" {
+		t.Errorf("second match = %q, want Acme", got)
+	}
+}
+
+func TestHighlightResults(t *testing.T) {
+	result := &HybridSearchResult{
+		Nodes: []*types.Node{{Uuid: "n1", Name: "Acme", Summary: "A maker of anvils."}},
+		Edges: []*types.Edge{{BaseEdge: types.BaseEdge{Uuid: "e1"}, Fact: "Wile buys anvils from Acme"}},
+	}
+	highlights := highlightResults("acme anvils", result, 0)
+
+	if fields := highlights["n1"]; len(fields) != 2 || fields[0].Field != "name" || fields[1].Field != "summary" {
+		t.Errorf("node highlights = %+v, want name and summary", fields)
+	}
+	if fields := highlights["e1"]; len(fields) != 1 || fields[0].Field != "fact" || len(fields[0].Matches) != 2 {
+		t.Errorf("edge highlights = %+v, want both words of the fact", fields)
+	}
+}
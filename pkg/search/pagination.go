@@ -0,0 +1,132 @@
+package search
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// SearchCursor marks where a page of search results ended. It is passed
+// between pages as an opaque string, see EncodeCursor and DecodeCursor.
+type SearchCursor struct {
+	// Node is the last node returned, nil when no node was returned
+	Node *CursorPosition `json:"n,omitempty"`
+	// Edge is the last edge returned, nil when no edge was returned
+	Edge *CursorPosition `json:"e,omitempty"`
+	// Community is the last community returned, nil when no community was
+	// returned
+	Community *CursorPosition `json:"c,omitempty"`
+	// Offset is the number of results per list returned by earlier pages,
+	// where lists ranked by window-dependent scores resume
+	Offset int `json:"o"`
+}
+
+// CursorPosition is the score and UUID of the last result of a page.
+// Results are ordered by descending score, then ascending UUID.
+type CursorPosition struct {
+	Score float64 `json:"s"`
+	UUID  string  `json:"u"`
+}
+
+// EncodeCursor returns the opaque string form of cursor.
+func EncodeCursor(cursor *SearchCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor parses a cursor returned by EncodeCursor. An empty string
+// decodes to nil, the first page.
+func DecodeCursor(encoded string) (*SearchCursor, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	var cursor SearchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if cursor.Offset < 0 {
+		return nil, fmt.Errorf("invalid cursor: negative offset")
+	}
+	return &cursor, nil
+}
+
+// resumesByRank reports whether results reranked by reranker resume at the
+// offset of the cursor rather than after its position. RRF and MMR scores
+// depend on the other results ranked with them, which grow with each page,
+// so the score of an earlier page marks no position in a later one.
+func resumesByRank(reranker RerankerType) bool {
+	return reranker == RRFRerankType || reranker == MMRRerankType
+}
+
+// resumeAt returns the after and skip arguments of pageResults resuming a
+// list reranked by reranker whose last result of earlier pages is position.
+func (c *SearchCursor) resumeAt(position *CursorPosition, reranker RerankerType) (*CursorPosition, int) {
+	if resumesByRank(reranker) {
+		return nil, c.Offset
+	}
+	return position, 0
+}
+
+// pageResults orders results by descending score then UUID, drops the first
+// skip and those up to and including after, and keeps at most limit. more
+// reports whether results were left out by the limit. Results without a score
+// each have no order to resume from, so they are an error.
+func pageResults[T any](items []T, scores []float64, uuidOf func(T) string, after *CursorPosition, skip, limit int) (paged []T, pagedScores []float64, last *CursorPosition, more bool, err error) {
+	if len(items) != len(scores) {
+		return nil, nil, nil, false, fmt.Errorf("cannot page %d results with %d scores", len(items), len(scores))
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		if scores[i] != scores[j] {
+			return scores[i] > scores[j]
+		}
+		return uuidOf(items[i]) < uuidOf(items[j])
+	})
+
+	for rank, i := range order {
+		if rank < skip {
+			continue
+		}
+		uuid := uuidOf(items[i])
+		if after != nil && (scores[i] > after.Score || (scores[i] == after.Score && uuid <= after.UUID)) {
+			continue
+		}
+		if len(paged) == limit {
+			more = true
+			break
+		}
+		paged = append(paged, items[i])
+		pagedScores = append(pagedScores, scores[i])
+		last = &CursorPosition{Score: scores[i], UUID: uuid}
+	}
+	if paged == nil {
+		paged, pagedScores = []T{}, []float64{}
+	}
+	return paged, pagedScores, last, more, nil
+}
+
+// pageNodes applies pageResults to nodes.
+func pageNodes(nodes []*types.Node, scores []float64, after *CursorPosition, skip, limit int) ([]*types.Node, []float64, *CursorPosition, bool, error) {
+	return pageResults(nodes, scores, func(node *types.Node) string { return node.Uuid }, after, skip, limit)
+}
+
+// pageEdges applies pageResults to edges.
+func pageEdges(edges []*types.Edge, scores []float64, after *CursorPosition, skip, limit int) ([]*types.Edge, []float64, *CursorPosition, bool, error) {
+	return pageResults(edges, scores, func(edge *types.Edge) string { return edge.Uuid }, after, skip, limit)
+}
@@ -0,0 +1,100 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := &SearchCursor{Node: &CursorPosition{Score: 1.0 / 61, UUID: "n1"}, Offset: 10}
+	encoded, err := EncodeCursor(cursor)
+	if err != nil {
+		t.Fatalf("EncodeCursor() error = %v", err)
+	}
+
+	decoded, err := DecodeCursor(encoded)
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if decoded.Node == nil || *decoded.Node != *cursor.Node || decoded.Edge != nil || decoded.Offset != 10 {
+		t.Errorf("DecodeCursor() = %+v, want %+v", decoded, cursor)
+	}
+
+	if decoded, err := DecodeCursor(""); decoded != nil || err != nil {
+		t.Errorf("DecodeCursor(\"\") = %v, %v, want the first page", decoded, err)
+	}
+	if _, err := DecodeCursor("not a cursor!"); err == nil {
+		t.Error("DecodeCursor() should reject malformed cursors")
+	}
+}
+
+func TestPageNodesIsStable(t *testing.T) {
+	// Ties are ordered by UUID whatever order the reranker returned them in
+	nodes := []*types.Node{{Uuid: "c"}, {Uuid: "a"}, {Uuid: "d"}, {Uuid: "b"}}
+	scores := []float64{0.5, 0.5, 0.9, 0.5}
+
+	var pages [][]string
+	var after *CursorPosition
+	for {
+		page, _, last, more, err := pageNodes(nodes, scores, after, 0, 2)
+		if err != nil {
+			t.Fatalf("pageNodes() error = %v", err)
+		}
+		var uuids []string
+		for _, node := range page {
+			uuids = append(uuids, node.Uuid)
+		}
+		pages = append(pages, uuids)
+		if !more {
+			break
+		}
+		after = last
+	}
+
+	want := [][]string{{"d", "a"}, {"b", "c"}}
+	if len(pages) != len(want) {
+		t.Fatalf("pages = %v, want %v", pages, want)
+	}
+	for i := range want {
+		if len(pages[i]) != len(want[i]) || pages[i][0] != want[i][0] || pages[i][1] != want[i][1] {
+			t.Errorf("page %d = %v, want %v", i, pages[i], want[i])
+		}
+	}
+}
+
+func TestPageNodesResumesByRank(t *testing.T) {
+	// The second page ranks a larger window, whose RRF scores are all lower
+	// than those of the first page; resuming after the score of the first
+	// page's last result would repeat it
+	first := []*types.Node{{Uuid: "a"}, {Uuid: "b"}, {Uuid: "c"}}
+	second := []*types.Node{{Uuid: "a"}, {Uuid: "b"}, {Uuid: "c"}, {Uuid: "d"}, {Uuid: "e"}}
+
+	cursor := &SearchCursor{}
+	after, skip := cursor.resumeAt(cursor.Node, RRFRerankType)
+	page, _, last, _, err := pageNodes(first, []float64{0.9, 0.8, 0.7}, after, skip, 2)
+	if err != nil {
+		t.Fatalf("pageNodes() error = %v", err)
+	}
+	cursor = &SearchCursor{Node: last, Offset: len(page)}
+
+	after, skip = cursor.resumeAt(cursor.Node, RRFRerankType)
+	page, _, _, _, err = pageNodes(second, []float64{0.5, 0.4, 0.3, 0.2, 0.1}, after, skip, 2)
+	if err != nil {
+		t.Fatalf("pageNodes() error = %v", err)
+	}
+	if len(page) != 2 || page[0].Uuid != "c" || page[1].Uuid != "d" {
+		t.Errorf("second page = %v, want c and d", page)
+	}
+
+	if after, skip := cursor.resumeAt(cursor.Node, CrossEncoderRerankType); after != cursor.Node || skip != 0 {
+		t.Errorf("resumeAt() = %v, %d, want the position of the cursor", after, skip)
+	}
+}
+
+func TestPageNodesRequiresAScorePerNode(t *testing.T) {
+	nodes := []*types.Node{{Uuid: "a"}, {Uuid: "b"}}
+	if _, _, _, _, err := pageNodes(nodes, []float64{0.5}, nil, 0, 2); err == nil {
+		t.Error("pageNodes() should reject nodes without a score each")
+	}
+}
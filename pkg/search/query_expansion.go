@@ -0,0 +1,181 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// listMarker matches the bullet or number an LLM may put before a query
+var listMarker = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s*`)
+
+// multiQuerySearch runs the search for query and for the queries the LLM
+// expands it to in parallel, then fuses the nodes, edges and communities
+// found for each query with RRF.
+func (s *Searcher) multiQuerySearch(ctx context.Context, query string, config *SearchConfig, filters *SearchFilters, groupID string) (*HybridSearchResult, error) {
+	cursor, err := DecodeCursor(config.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if cursor == nil {
+		cursor = &SearchCursor{}
+	}
+
+	queries := []string{query}
+	// Expansion only improves recall, so the original query is still searched
+	// when it fails
+	if expansions, err := s.expandQuery(ctx, query, config.QueryExpansion); err == nil {
+		queries = append(queries, expansions...)
+	}
+
+	// Each query returns the results of all pages up to this one, which are
+	// fused and paged below
+	subConfig := *config
+	subConfig.QueryExpansion = 0
+	subConfig.Highlight = false
+	subConfig.Cursor = ""
+	subConfig.Limit = config.Limit + cursor.Offset + 1
+
+	results := make([]*HybridSearchResult, len(queries))
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		go func(i int, q string) {
+			defer wg.Done()
+			results[i], errs[i] = s.Search(ctx, q, &subConfig, filters, groupID)
+		}(i, q)
+	}
+	wg.Wait()
+
+	nodeLists := make([][]*types.Node, len(results))
+	edgeLists := make([][]*types.Edge, len(results))
+	communityLists := make([][]*types.Node, len(results))
+	for i, result := range results {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("search for query %q failed: %w", queries[i], errs[i])
+		}
+		nodeLists[i] = result.Nodes
+		edgeLists[i] = result.Edges
+		communityLists[i] = result.Communities
+	}
+
+	nodeUUID := func(node *types.Node) string { return node.Uuid }
+	nodes, nodeScores := fuseResults(nodeLists, nodeUUID)
+	edges, edgeScores := fuseResults(edgeLists, func(edge *types.Edge) string { return edge.Uuid })
+	communities, communityScores := fuseResults(communityLists, nodeUUID)
+
+	// The fused results are ranked by RRF, so they resume at the offset
+	next := &SearchCursor{Node: cursor.Node, Edge: cursor.Edge, Community: cursor.Community, Offset: cursor.Offset + config.Limit}
+	nodes, nodeScores, lastNode, moreNodes, err := pageNodes(nodes, nodeScores, nil, cursor.Offset, config.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to page nodes: %w", err)
+	}
+	edges, edgeScores, lastEdge, moreEdges, err := pageEdges(edges, edgeScores, nil, cursor.Offset, config.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to page edges: %w", err)
+	}
+	communities, communityScores, lastCommunity, moreCommunities, err := pageNodes(communities, communityScores, nil, cursor.Offset, config.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to page communities: %w", err)
+	}
+	if lastNode != nil {
+		next.Node = lastNode
+	}
+	if lastEdge != nil {
+		next.Edge = lastEdge
+	}
+	if lastCommunity != nil {
+		next.Community = lastCommunity
+	}
+
+	result := &HybridSearchResult{
+		Nodes:           nodes,
+		Edges:           edges,
+		Communities:     communities,
+		NodeScores:      nodeScores,
+		EdgeScores:      edgeScores,
+		CommunityScores: communityScores,
+		Query:           query,
+		Total:           len(nodes) + len(edges) + len(communities),
+	}
+	if (moreNodes || moreEdges || moreCommunities) && config.Limit > 0 {
+		if result.NextCursor, err = EncodeCursor(next); err != nil {
+			return nil, err
+		}
+	}
+	if config.Highlight {
+		result.Highlights = highlightResults(query, result, config.SnippetLength)
+	}
+	return result, nil
+}
+
+// expandQuery asks the LLM for up to n paraphrases and sub-queries of query.
+func (s *Searcher) expandQuery(ctx context.Context, query string, n int) ([]string, error) {
+	prompt := fmt.Sprintf(`Generate %d alternative search queries to find the facts and entities in a knowledge graph that answer the query below.
+Use paraphrases, synonyms, spelled out abbreviations and narrower sub-questions.
+
+Query: "%s"
+
+Please respond with only the queries, one per line.`, n, query)
+
+	messages := []types.Message{
+		llm.NewSystemMessage("You are a search query expansion system. Rewrite queries to improve the recall of a search."),
+		llm.NewUserMessage(prompt),
+	}
+
+	response, err := s.llm.Chat(usage.WithOperation(ctx, usage.OperationExpandQuery), messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand query: %w", err)
+	}
+
+	return parseExpandedQueries(response.Content, query, n), nil
+}
+
+// parseExpandedQueries returns up to n distinct queries from the lines of
+// content, without list markers and quotes, and leaving out query itself.
+func parseExpandedQueries(content, query string, n int) []string {
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(query)): true}
+	var queries []string
+	for _, line := range strings.Split(content, "\n") {
+		line = listMarker.ReplaceAllString(strings.TrimSpace(line), "")
+		line = strings.TrimSpace(strings.Trim(line, `"'`))
+		key := strings.ToLower(line)
+		if line == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		queries = append(queries, line)
+		if len(queries) == n {
+			break
+		}
+	}
+	return queries
+}
+
+// fuseResults ranks the results of several searches by RRF over their
+// orders.
+func fuseResults[T any](lists [][]T, uuidOf func(T) string) ([]T, []float64) {
+	byUUID := make(map[string]T)
+	uuidLists := make([][]string, len(lists))
+	for i, list := range lists {
+		for _, item := range list {
+			uuid := uuidOf(item)
+			byUUID[uuid] = item
+			uuidLists[i] = append(uuidLists[i], uuid)
+		}
+	}
+
+	uuids, scores := RRF(uuidLists, DefaultRankConstant, 0)
+	items := make([]T, len(uuids))
+	for i, uuid := range uuids {
+		items[i] = byUUID[uuid]
+	}
+	return items, scores
+}
@@ -0,0 +1,36 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExpandedQueries(t *testing.T) {
+	content := `1. Who founded Acme?
+- "Acme founders"
+who founded acme
+
+* 2024 Acme revenue
+Acme headquarters`
+
+	got := parseExpandedQueries(content, "Who founded Acme", 3)
+	want := []string{"Who founded Acme?", "Acme founders", "2024 Acme revenue"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseExpandedQueries() = %v, want %v", got, want)
+	}
+}
+
+func TestFuseResults(t *testing.T) {
+	lists := [][]string{
+		{"a", "b"},
+		{"b", "c"},
+	}
+
+	fused, scores := fuseResults(lists, func(uuid string) string { return uuid })
+	if len(fused) != 3 || fused[0] != "b" {
+		t.Fatalf("fused = %v, want b first of 3", fused)
+	}
+	if scores[0] <= scores[1] {
+		t.Errorf("scores = %v, want b to score highest", scores)
+	}
+}
@@ -0,0 +1,99 @@
+// Package recipes selects the predefined search configurations of the search
+// package by name, ported from the search_config_recipes of Python graphiti,
+// as types.SearchConfig values ready for Client.Search.
+package recipes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/soundprediction/go-predicato/pkg/search"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// DefaultLimit is the result limit of recipes that do not set one, matching
+// the Python DEFAULT_SEARCH_LIMIT.
+const DefaultLimit = 10
+
+// Get returns a copy of the named recipe. Names are those listed by Names;
+// the Python constant names, such as EDGE_HYBRID_SEARCH_RRF, are accepted too.
+// Results a recipe does not search get a configuration without search
+// methods, so that Client.Search does not fall back to its defaults for them.
+func Get(name string) (*types.SearchConfig, error) {
+	config := search.GetSearchConfigByName(normalizeName(name))
+	if config == nil {
+		return nil, fmt.Errorf("unknown search recipe %q, available recipes: %s", name, strings.Join(Names(), ", "))
+	}
+
+	result := &types.SearchConfig{
+		Limit:      config.Limit,
+		MinScore:   config.MinScore,
+		Rerank:     true,
+		NodeConfig: &types.NodeSearchConfig{},
+		EdgeConfig: &types.EdgeSearchConfig{},
+	}
+	if result.Limit == 0 {
+		result.Limit = DefaultLimit
+	}
+
+	if config.NodeConfig != nil {
+		result.NodeConfig = &types.NodeSearchConfig{
+			SearchMethods: methodNames(config.NodeConfig.SearchMethods),
+			Reranker:      string(config.NodeConfig.Reranker),
+			MinScore:      config.NodeConfig.MinScore,
+		}
+		result.CenterNodeDistance = config.NodeConfig.MaxDepth
+		result.MMRLambda = maxLambda(nil, config.NodeConfig.MMRLambda)
+	}
+	if config.EdgeConfig != nil {
+		result.EdgeConfig = &types.EdgeSearchConfig{
+			SearchMethods: methodNames(config.EdgeConfig.SearchMethods),
+			Reranker:      string(config.EdgeConfig.Reranker),
+			MinScore:      config.EdgeConfig.MinScore,
+		}
+		result.IncludeEdges = true
+		result.CenterNodeDistance = max(result.CenterNodeDistance, config.EdgeConfig.MaxDepth)
+		result.MMRLambda = maxLambda(result.MMRLambda, config.EdgeConfig.MMRLambda)
+	}
+	if config.CommunityConfig != nil {
+		result.CommunityConfig = &types.CommunitySearchConfig{
+			SearchMethods: methodNames(config.CommunityConfig.SearchMethods),
+			Reranker:      string(config.CommunityConfig.Reranker),
+			MinScore:      config.CommunityConfig.MinScore,
+		}
+		result.MMRLambda = maxLambda(result.MMRLambda, config.CommunityConfig.MMRLambda)
+	}
+
+	return result, nil
+}
+
+// Names returns the names of all recipes.
+func Names() []string {
+	return search.ListAvailableSearchConfigs()
+}
+
+// normalizeName maps a Python recipe constant name, such as
+// COMBINED_HYBRID_SEARCH_CROSS_ENCODER, to the name of the search package
+// registry, such as combined_hybrid_cross_encoder.
+func normalizeName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Replace(name, "_hybrid_search_", "_hybrid_", 1)
+}
+
+func methodNames(methods []search.SearchMethod) []string {
+	names := make([]string, len(methods))
+	for i, method := range methods {
+		names[i] = string(method)
+	}
+	return names
+}
+
+// maxLambda returns a copy of the larger of two MMR lambdas, ignoring unset
+// ones, so that results do not share the lambdas of the recipes.
+func maxLambda(a, b *float64) *float64 {
+	if b == nil || (a != nil && *a >= *b) {
+		return a
+	}
+	lambda := *b
+	return &lambda
+}
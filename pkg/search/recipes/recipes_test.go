@@ -0,0 +1,49 @@
+package recipes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetAcceptsPythonNames(t *testing.T) {
+	config, err := Get("EDGE_HYBRID_SEARCH_CROSS_ENCODER")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if config.EdgeConfig.Reranker != "cross_encoder" {
+		t.Errorf("edge reranker = %q, want cross_encoder", config.EdgeConfig.Reranker)
+	}
+	if want := []string{"bm25", "cosine_similarity", "bfs"}; !reflect.DeepEqual(config.EdgeConfig.SearchMethods, want) {
+		t.Errorf("edge methods = %v, want %v", config.EdgeConfig.SearchMethods, want)
+	}
+	if len(config.NodeConfig.SearchMethods) != 0 {
+		t.Errorf("node methods = %v, want none for an edge recipe", config.NodeConfig.SearchMethods)
+	}
+	if config.CommunityConfig != nil {
+		t.Error("edge recipe searches communities")
+	}
+}
+
+func TestGetReturnsCopies(t *testing.T) {
+	first, err := Get("combined_hybrid_rrf")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if first.Limit != DefaultLimit {
+		t.Errorf("limit = %d, want %d", first.Limit, DefaultLimit)
+	}
+	first.Limit = 1
+	first.NodeConfig.SearchMethods[0] = "bfs"
+
+	second, _ := Get("combined_hybrid_rrf")
+	if second.Limit != DefaultLimit || second.NodeConfig.SearchMethods[0] != "bm25" {
+		t.Error("changing a recipe changed later copies")
+	}
+}
+
+func TestGetUnknownRecipe(t *testing.T) {
+	if _, err := Get("no_such_recipe"); err == nil {
+		t.Error("expected an error for an unknown recipe")
+	}
+}
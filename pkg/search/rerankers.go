@@ -2,6 +2,9 @@ package search
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
 	"math"
 	"sort"
 
@@ -52,9 +55,13 @@ func RRF(results [][]string, rankConstant int, minScore float64) ([]string, []fl
 		}
 	}
 
-	// Sort by score (descending)
+	// Sort by score (descending), then by UUID so that ties are ordered the
+	// same way on every call
 	sort.Slice(scoredUUIDs, func(i, j int) bool {
-		return scoredUUIDs[i].score > scoredUUIDs[j].score
+		if scoredUUIDs[i].score != scoredUUIDs[j].score {
+			return scoredUUIDs[i].score > scoredUUIDs[j].score
+		}
+		return scoredUUIDs[i].uuid < scoredUUIDs[j].uuid
 	})
 
 	// Extract UUIDs and scores
@@ -68,210 +75,228 @@ func RRF(results [][]string, rankConstant int, minScore float64) ([]string, []fl
 	return uuids, scoreList
 }
 
-// NodeDistanceReranker reranks nodes based on their distance from a center node
-func NodeDistanceReranker(ctx context.Context, driver driver.GraphDriver, nodeUUIDs []string, centerNodeUUID string, minScore float64) ([]string, []float64, error) {
+// NodeDistanceReranker reranks nodes by their distance from a center node,
+// found by breadth-first search over the RELATES_TO neighbors of groupID up to
+// maxDepth hops (MaxSearchDepth if not positive). A node at distance d scores
+// 1/d; the center node, if among nodeUUIDs, comes first with score 10, and
+// nodes farther than maxDepth score 0. Nodes at the same distance keep their
+// order in nodeUUIDs.
+func NodeDistanceReranker(ctx context.Context, driver driver.GraphDriver, nodeUUIDs []string, centerNodeUUID, groupID string, maxDepth int, minScore float64) ([]string, []float64, error) {
+	if maxDepth <= 0 {
+		maxDepth = MaxSearchDepth
+	}
+
 	// Filter out the center node UUID
 	filteredUUIDs := make([]string, 0, len(nodeUUIDs))
+	targets := make(map[string]bool, len(nodeUUIDs))
+	containsCenter := false
 	for _, uuid := range nodeUUIDs {
-		if uuid != centerNodeUUID {
-			filteredUUIDs = append(filteredUUIDs, uuid)
+		if uuid == centerNodeUUID {
+			containsCenter = true
+			continue
 		}
-	}
-
-	scores := make(map[string]float64)
-	scores[centerNodeUUID] = 0.0 // Center node has distance 0
-
-	// For now, implement a simple distance calculation
-	// In a full implementation, this would use graph traversal queries
-	// to find shortest paths between nodes
-
-	// Assign default distances (this is a simplified implementation)
-	for _, uuid := range filteredUUIDs {
-		if uuid != centerNodeUUID {
-			// Default distance of 1 for simplicity
-			scores[uuid] = 1.0
+		if !targets[uuid] {
+			filteredUUIDs = append(filteredUUIDs, uuid)
+			targets[uuid] = true
 		}
 	}
 
-	// Sort by distance (lower is better)
-	type uuidDistance struct {
-		uuid     string
-		distance float64
+	distances, err := nodeDistances(ctx, driver, centerNodeUUID, groupID, targets, maxDepth)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	var sortedNodes []uuidDistance
-	for _, uuid := range filteredUUIDs {
-		distance := scores[uuid]
-		if distance == 0 {
-			distance = math.Inf(1) // Infinite distance if not connected
+	// Unreached nodes have infinite distance and score 0
+	score := func(uuid string) float64 {
+		if distance, ok := distances[uuid]; ok {
+			return 1.0 / float64(distance)
 		}
-		sortedNodes = append(sortedNodes, uuidDistance{uuid: uuid, distance: distance})
+		return 0
 	}
-
-	sort.Slice(sortedNodes, func(i, j int) bool {
-		return sortedNodes[i].distance < sortedNodes[j].distance
+	sort.SliceStable(filteredUUIDs, func(i, j int) bool {
+		return score(filteredUUIDs[i]) > score(filteredUUIDs[j])
 	})
 
-	// Add center node at the beginning if it was in the original list
-	containsCenter := false
-	for _, uuid := range nodeUUIDs {
-		if uuid == centerNodeUUID {
-			containsCenter = true
-			break
-		}
-	}
-
 	var resultUUIDs []string
 	var resultScores []float64
 
 	if containsCenter {
+		// The center node is at distance 0, scored as in graphiti
 		resultUUIDs = append(resultUUIDs, centerNodeUUID)
-		resultScores = append(resultScores, 0.1) // Small positive score for center
+		resultScores = append(resultScores, 1.0/0.1)
 	}
 
-	// Add other nodes with inverted distance scores
-	for _, item := range sortedNodes {
-		score := 1.0 / (1.0 + item.distance) // Invert distance to get score
-		if score >= minScore {
-			resultUUIDs = append(resultUUIDs, item.uuid)
-			resultScores = append(resultScores, score)
+	for _, uuid := range filteredUUIDs {
+		if s := score(uuid); s >= minScore {
+			resultUUIDs = append(resultUUIDs, uuid)
+			resultScores = append(resultScores, s)
 		}
 	}
 
 	return resultUUIDs, resultScores, nil
 }
 
-// EpisodeMentionsReranker reranks nodes based on how many episodes mention them
-func EpisodeMentionsReranker(ctx context.Context, driver driver.GraphDriver, nodeUUIDs [][]string, minScore float64) ([]string, []float64, error) {
-	// Use RRF as preliminary ranking
-	sortedUUIDs, _ := RRF(nodeUUIDs, DefaultRankConstant, 0)
-
-	scores := make(map[string]float64)
-
-	// For now, assign default episode mention counts
-	// In a full implementation, this would query the database for actual episode mentions
-	for i, uuid := range sortedUUIDs {
-		// Assign decreasing scores based on position (simulating episode mentions)
-		mentionCount := float64(len(sortedUUIDs) - i)
-		scores[uuid] = mentionCount
+// nodeDistances returns the hop distances from centerNodeUUID of the nodes
+// reached within maxDepth hops, stopping early once every target is reached.
+func nodeDistances(ctx context.Context, driver driver.GraphDriver, centerNodeUUID, groupID string, targets map[string]bool, maxDepth int) (map[string]int, error) {
+	distances := map[string]int{centerNodeUUID: 0}
+	remaining := len(targets)
+	frontier := []string{centerNodeUUID}
+
+	for depth := 1; depth <= maxDepth && len(frontier) > 0 && remaining > 0; depth++ {
+		var next []string
+		for _, uuid := range frontier {
+			neighbors, err := driver.GetNodeNeighbors(ctx, uuid, groupID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get neighbors of node %s: %w", uuid, err)
+			}
+			for _, neighbor := range neighbors {
+				if _, seen := distances[neighbor.NodeUUID]; seen {
+					continue
+				}
+				distances[neighbor.NodeUUID] = depth
+				if targets[neighbor.NodeUUID] {
+					remaining--
+				}
+				next = append(next, neighbor.NodeUUID)
+			}
+		}
+		frontier = next
 	}
 
-	// Sort by mention count (descending)
-	type uuidMentions struct {
-		uuid     string
-		mentions float64
-	}
+	return distances, nil
+}
 
-	var sortedNodes []uuidMentions
-	for _, uuid := range sortedUUIDs {
-		mentions := scores[uuid]
-		if mentions >= minScore {
-			sortedNodes = append(sortedNodes, uuidMentions{uuid: uuid, mentions: mentions})
-		}
+// EpisodeMentionsReranker reranks nodes by how many episodes of groupID
+// mention them, so that frequently referenced nodes rank above one-off
+// mentions. The score of a node is its mention count; RRF over nodeUUIDs
+// orders nodes with equal counts.
+func EpisodeMentionsReranker(ctx context.Context, graphDriver driver.GraphDriver, nodeUUIDs [][]string, groupID string, minScore float64) ([]string, []float64, error) {
+	// Use RRF as preliminary ranking
+	sortedUUIDs, _ := RRF(nodeUUIDs, DefaultRankConstant, 0)
+
+	mentions, err := driver.CountEpisodeMentions(ctx, graphDriver, sortedUUIDs, groupID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	sort.Slice(sortedNodes, func(i, j int) bool {
-		return sortedNodes[i].mentions > sortedNodes[j].mentions
+	sort.SliceStable(sortedUUIDs, func(i, j int) bool {
+		return mentions[sortedUUIDs[i]] > mentions[sortedUUIDs[j]]
 	})
 
 	// Extract results
-	resultUUIDs := make([]string, len(sortedNodes))
-	resultScores := make([]float64, len(sortedNodes))
-	for i, item := range sortedNodes {
-		resultUUIDs[i] = item.uuid
-		resultScores[i] = item.mentions
+	var resultUUIDs []string
+	var resultScores []float64
+	for _, uuid := range sortedUUIDs {
+		if score := float64(mentions[uuid]); score >= minScore {
+			resultUUIDs = append(resultUUIDs, uuid)
+			resultScores = append(resultScores, score)
+		}
 	}
 
 	return resultUUIDs, resultScores, nil
 }
 
-// MaximalMarginalRelevance (MMR) reranks results to balance relevance and diversity
+// MaximalMarginalRelevance (MMR) reranks results to balance relevance and
+// diversity. It repeatedly selects the candidate maximizing
+// λ * sim(query, c) - (1-λ) * max sim(c, s) over the candidates s selected
+// before, so candidates close to a better ranked one move down. The score of
+// a candidate is its MMR value when selected; MMR values only decrease, so
+// selection stops at the first one below minScore. Ties go to the smallest
+// UUID.
 func MaximalMarginalRelevance(queryVector []float32, candidates map[string][]float32, mmrLambda float64, minScore float64) ([]string, []float64) {
-	if mmrLambda == 0 {
-		mmrLambda = DefaultMMRLambda
+	return MaximalMarginalRelevanceWithSeed(queryVector, candidates, mmrLambda, minScore, 0)
+}
+
+// lambdaOrDefault returns the MMR lambda of a search config, DefaultMMRLambda
+// when it is unset.
+func lambdaOrDefault(lambda *float64) float64 {
+	if lambda == nil {
+		return DefaultMMRLambda
 	}
+	return *lambda
+}
 
+// MaximalMarginalRelevanceWithSeed is MaximalMarginalRelevance breaking ties
+// in an order of the UUIDs derived from seed: the same seed always gives the
+// same ranking, and seed 0 orders ties by UUID.
+func MaximalMarginalRelevanceWithSeed(queryVector []float32, candidates map[string][]float32, mmrLambda float64, minScore float64, seed int64) ([]string, []float64) {
 	if len(candidates) == 0 {
 		return []string{}, []float64{}
 	}
 
-	// Convert to internal format for calculations
-	candidateVectors := make(map[string][]float32)
+	// Normalize embeddings (L2 normalization), in tie-breaking order
 	uuids := make([]string, 0, len(candidates))
-
-	for uuid, embedding := range candidates {
-		// Normalize embeddings (L2 normalization)
-		normalized := normalizeL2(embedding)
-		candidateVectors[uuid] = normalized
+	for uuid := range candidates {
 		uuids = append(uuids, uuid)
 	}
+	sortBySeed(uuids, seed)
 
-	// Normalize query vector
 	normalizedQuery := normalizeL2(queryVector)
-
-	// Calculate similarity matrix between candidates
-	similarityMatrix := make(map[string]map[string]float64)
-	for _, uuid1 := range uuids {
-		similarityMatrix[uuid1] = make(map[string]float64)
-		for _, uuid2 := range uuids {
-			if uuid1 == uuid2 {
-				similarityMatrix[uuid1][uuid2] = 1.0
-			} else {
-				sim := CalculateCosineSimilarity(candidateVectors[uuid1], candidateVectors[uuid2])
-				similarityMatrix[uuid1][uuid2] = sim
+	vectors := make([][]float32, len(uuids))
+	relevance := make([]float64, len(uuids))
+	for i, uuid := range uuids {
+		vectors[i] = normalizeL2(candidates[uuid])
+		relevance[i] = CalculateCosineSimilarity(normalizedQuery, vectors[i])
+	}
+
+	// maxSim holds the highest similarity of each candidate to the selected
+	// candidates
+	maxSim := make([]float64, len(uuids))
+	selected := make([]bool, len(uuids))
+
+	resultUUIDs := make([]string, 0, len(uuids))
+	resultScores := make([]float64, 0, len(uuids))
+	for len(resultUUIDs) < len(uuids) {
+		best, bestScore := -1, 0.0
+		for i := range uuids {
+			if selected[i] {
+				continue
+			}
+			score := mmrLambda*relevance[i] - (1-mmrLambda)*maxSim[i]
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
 			}
 		}
-	}
+		if bestScore < minScore {
+			break
+		}
 
-	// Calculate MMR scores
-	mmrScores := make(map[string]float64)
-	for _, uuid := range uuids {
-		// Query-document similarity
-		queryDocSim := CalculateCosineSimilarity(normalizedQuery, candidateVectors[uuid])
-
-		// Find maximum similarity to any other document
-		maxSim := 0.0
-		for _, otherUUID := range uuids {
-			if uuid != otherUUID {
-				sim := similarityMatrix[uuid][otherUUID]
-				if sim > maxSim {
-					maxSim = sim
-				}
+		selected[best] = true
+		resultUUIDs = append(resultUUIDs, uuids[best])
+		resultScores = append(resultScores, bestScore)
+
+		for i := range uuids {
+			if !selected[i] {
+				maxSim[i] = math.Max(maxSim[i], CalculateCosineSimilarity(vectors[i], vectors[best]))
 			}
 		}
-
-		// MMR formula: λ * query_sim - (1-λ) * max_doc_sim
-		mmr := mmrLambda*queryDocSim - (1-mmrLambda)*maxSim
-		mmrScores[uuid] = mmr
 	}
 
-	// Sort by MMR score (descending)
-	type uuidMMR struct {
-		uuid string
-		mmr  float64
+	return resultUUIDs, resultScores
+}
+
+// sortBySeed sorts uuids by UUID for seed 0, and otherwise by a hash of
+// the seed and the UUID.
+func sortBySeed(uuids []string, seed int64) {
+	if seed == 0 {
+		sort.Strings(uuids)
+		return
 	}
 
-	var sortedUUIDs []uuidMMR
+	keys := make(map[string]uint64, len(uuids))
 	for _, uuid := range uuids {
-		mmr := mmrScores[uuid]
-		if mmr >= minScore {
-			sortedUUIDs = append(sortedUUIDs, uuidMMR{uuid: uuid, mmr: mmr})
+		hash := fnv.New64a()
+		binary.Write(hash, binary.LittleEndian, seed)
+		hash.Write([]byte(uuid))
+		keys[uuid] = hash.Sum64()
+	}
+	sort.Slice(uuids, func(i, j int) bool {
+		if keys[uuids[i]] != keys[uuids[j]] {
+			return keys[uuids[i]] < keys[uuids[j]]
 		}
-	}
-
-	sort.Slice(sortedUUIDs, func(i, j int) bool {
-		return sortedUUIDs[i].mmr > sortedUUIDs[j].mmr
+		return uuids[i] < uuids[j]
 	})
-
-	// Extract results
-	resultUUIDs := make([]string, len(sortedUUIDs))
-	resultScores := make([]float64, len(sortedUUIDs))
-	for i, item := range sortedUUIDs {
-		resultUUIDs[i] = item.uuid
-		resultScores[i] = item.mmr
-	}
-
-	return resultUUIDs, resultScores
 }
 
 // normalizeL2 performs L2 normalization on a vector
@@ -316,13 +341,20 @@ func CrossEncoderReranker(ctx context.Context, query string, candidates []*types
 	return candidates, scores, nil
 }
 
-// GetEmbeddingsForNodes retrieves embeddings for the given nodes from the database
+// GetEmbeddingsForNodes retrieves embeddings for the given nodes, using the
+// name embedding and falling back to the embedding and the name_embedding
+// metadata
 func GetEmbeddingsForNodes(ctx context.Context, driver driver.GraphDriver, nodes []*types.Node) (map[string][]float32, error) {
 	embeddings := make(map[string][]float32)
 
 	for _, node := range nodes {
-		// Extract embeddings from node metadata if available
-		if node.Metadata != nil {
+		switch {
+		case len(node.NameEmbedding) > 0:
+			embeddings[node.Uuid] = node.NameEmbedding
+		case len(node.Embedding) > 0:
+			embeddings[node.Uuid] = node.Embedding
+		case node.Metadata != nil:
+			// Extract embeddings from node metadata if available
 			if embeddingData, exists := node.Metadata["name_embedding"]; exists {
 				if embedding := toFloat32Slice(embeddingData); embedding != nil {
 					embeddings[node.Uuid] = embedding
@@ -334,16 +366,22 @@ func GetEmbeddingsForNodes(ctx context.Context, driver driver.GraphDriver, nodes
 	return embeddings, nil
 }
 
-// GetEmbeddingsForEdges retrieves embeddings for the given edges from the database
+// GetEmbeddingsForEdges retrieves embeddings for the given edges, using the
+// fact embedding and falling back to the embedding metadata
 func GetEmbeddingsForEdges(ctx context.Context, driver driver.GraphDriver, edges []*types.Edge) (map[string][]float32, error) {
 	embeddings := make(map[string][]float32)
 
 	for _, edge := range edges {
+		if len(edge.FactEmbedding) > 0 {
+			embeddings[edge.Uuid] = edge.FactEmbedding
+			continue
+		}
 		// Extract embeddings from edge metadata if available
 		if edge.Metadata != nil {
-			if embeddingData, exists := edge.Metadata["name_embedding"]; exists {
-				if embedding := toFloat32Slice(embeddingData); embedding != nil {
+			for _, key := range []string{"fact_embedding", "name_embedding"} {
+				if embedding := toFloat32Slice(edge.Metadata[key]); embedding != nil {
 					embeddings[edge.Uuid] = embedding
+					break
 				}
 			}
 		}
@@ -0,0 +1,265 @@
+package search
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// neighborDriver is a GraphDriver stub serving GetNodeNeighbors from an
+// adjacency list.
+type neighborDriver struct {
+	driver.GraphDriver
+	adjacency map[string][]string
+	calls     int
+}
+
+func (d *neighborDriver) GetNodeNeighbors(ctx context.Context, nodeUUID, groupID string) ([]types.Neighbor, error) {
+	d.calls++
+	var neighbors []types.Neighbor
+	for _, uuid := range d.adjacency[nodeUUID] {
+		neighbors = append(neighbors, types.Neighbor{NodeUUID: uuid, EdgeCount: 1})
+	}
+	return neighbors, nil
+}
+
+func TestNodeDistanceReranker(t *testing.T) {
+	// center - a - b - c, d unconnected
+	graph := &neighborDriver{adjacency: map[string][]string{
+		"center": {"a"},
+		"a":      {"center", "b"},
+		"b":      {"a", "c"},
+		"c":      {"b"},
+	}}
+
+	uuids, scores, err := NodeDistanceReranker(context.Background(), graph, []string{"d", "b", "center", "a", "c"}, "center", "g1", 2, 0)
+	if err != nil {
+		t.Fatalf("NodeDistanceReranker() error = %v", err)
+	}
+
+	wantUUIDs := []string{"center", "a", "b", "d", "c"}
+	wantScores := []float64{10, 1, 0.5, 0, 0}
+	if !reflect.DeepEqual(uuids, wantUUIDs) {
+		t.Errorf("uuids = %v, want %v", uuids, wantUUIDs)
+	}
+	if !reflect.DeepEqual(scores, wantScores) {
+		t.Errorf("scores = %v, want %v", scores, wantScores)
+	}
+}
+
+func TestNodeDistanceRerankerStopsWhenTargetsReached(t *testing.T) {
+	graph := &neighborDriver{adjacency: map[string][]string{
+		"center": {"a"},
+		"a":      {"b"},
+		"b":      {"c"},
+	}}
+
+	uuids, _, err := NodeDistanceReranker(context.Background(), graph, []string{"a"}, "center", "g1", 3, 0.5)
+	if err != nil {
+		t.Fatalf("NodeDistanceReranker() error = %v", err)
+	}
+	if !reflect.DeepEqual(uuids, []string{"a"}) {
+		t.Errorf("uuids = %v, want [a]", uuids)
+	}
+	if graph.calls != 1 {
+		t.Errorf("neighbor lookups = %d, want 1", graph.calls)
+	}
+}
+
+func TestNodeDistanceRerankNodesRequiresCenter(t *testing.T) {
+	s := &Searcher{driver: &neighborDriver{}}
+	if _, _, err := s.nodeDistanceRerankNodes(context.Background(), nil, "g1", "", 0, 0, 10); err == nil {
+		t.Error("expected an error without a center node")
+	}
+}
+
+// mentionsDriver is a GraphDriver stub serving GetRelatedNodes with the given
+// number of episodic neighbors per node.
+type mentionsDriver struct {
+	driver.GraphDriver
+	mentions map[string]int
+}
+
+func (d *mentionsDriver) Provider() driver.GraphProvider {
+	return driver.GraphProviderArangoDB
+}
+
+func (d *mentionsDriver) GetRelatedNodes(ctx context.Context, nodeID, groupID string, edgeTypes []types.EdgeType) ([]*types.Node, error) {
+	var nodes []*types.Node
+	for i := 0; i < d.mentions[nodeID]; i++ {
+		nodes = append(nodes, &types.Node{Type: types.EpisodicNodeType})
+	}
+	return nodes, nil
+}
+
+func TestEpisodeMentionsReranker(t *testing.T) {
+	graph := &mentionsDriver{mentions: map[string]int{"a": 1, "b": 3, "c": 1}}
+
+	uuids, scores, err := EpisodeMentionsReranker(context.Background(), graph, [][]string{{"a", "b", "c", "d"}}, "g1", 1)
+	if err != nil {
+		t.Fatalf("EpisodeMentionsReranker() error = %v", err)
+	}
+
+	wantUUIDs := []string{"b", "a", "c"}
+	wantScores := []float64{3, 1, 1}
+	if !reflect.DeepEqual(uuids, wantUUIDs) {
+		t.Errorf("uuids = %v, want %v", uuids, wantUUIDs)
+	}
+	if !reflect.DeepEqual(scores, wantScores) {
+		t.Errorf("scores = %v, want %v", scores, wantScores)
+	}
+}
+
+func TestEpisodeMentionsRerankEdges(t *testing.T) {
+	s := &Searcher{}
+	edges := []*types.Edge{
+		{BaseEdge: types.BaseEdge{Uuid: "e1"}, Episodes: []string{"ep1"}},
+		{BaseEdge: types.BaseEdge{Uuid: "e2"}, Episodes: []string{"ep1", "ep2"}},
+	}
+
+	ranked, scores, err := s.episodeMentionsRerankEdges([][]*types.Edge{edges}, 0, 10)
+	if err != nil {
+		t.Fatalf("episodeMentionsRerankEdges() error = %v", err)
+	}
+	if len(ranked) != 2 || ranked[0].Uuid != "e2" || scores[0] != 2 {
+		t.Errorf("ranked = %v, scores = %v, want e2 first with score 2", ranked, scores)
+	}
+}
+
+func TestStrengthRerankEdges(t *testing.T) {
+	s := &Searcher{}
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	stale := &types.Edge{BaseEdge: types.BaseEdge{Uuid: "stale", CreatedAt: created}}
+	stale.Reinforce(created)
+	stale.Reinforce(created)
+	recent := &types.Edge{BaseEdge: types.BaseEdge{Uuid: "recent", CreatedAt: created}}
+	recent.Reinforce(created.Add(90 * 24 * time.Hour))
+	once := &types.Edge{BaseEdge: types.BaseEdge{Uuid: "once", CreatedAt: created.Add(90 * 24 * time.Hour)}}
+
+	now := created.Add(90 * 24 * time.Hour)
+	ranked, scores, err := s.strengthRerankEdges([][]*types.Edge{{once, stale, recent}}, now, 0, 10)
+	if err != nil {
+		t.Fatalf("strengthRerankEdges() error = %v", err)
+	}
+	var uuids []string
+	for _, edge := range ranked {
+		uuids = append(uuids, edge.Uuid)
+	}
+	// stale was reinforced twice three half-lives ago: 3 / 8
+	if want := []string{"recent", "once", "stale"}; !reflect.DeepEqual(uuids, want) {
+		t.Errorf("ranked = %v, want %v", uuids, want)
+	}
+	if math.Abs(scores[2]-0.375) > 1e-9 || stale.Reinforcements() != 2 {
+		t.Errorf("stale score = %v, reinforcements = %d, want 0.375 and 2", scores[2], stale.Reinforcements())
+	}
+}
+
+func TestMaximalMarginalRelevanceDiversifies(t *testing.T) {
+	query := []float32{1, 1, 0}
+	candidates := map[string][]float32{
+		"a":         {1, 0.2, 0},
+		"duplicate": {1, 0.19, 0},
+		"other":     {0.2, 1, 0.5},
+	}
+
+	relevant, _ := MaximalMarginalRelevance(query, candidates, 1, -1)
+	if want := []string{"a", "duplicate", "other"}; !reflect.DeepEqual(relevant, want) {
+		t.Errorf("lambda 1 order = %v, want %v", relevant, want)
+	}
+
+	diverse, scores := MaximalMarginalRelevance(query, candidates, 0.5, -1)
+	if want := []string{"a", "other", "duplicate"}; !reflect.DeepEqual(diverse, want) {
+		t.Errorf("lambda 0.5 order = %v, want %v", diverse, want)
+	}
+	for i := 1; i < len(scores); i++ {
+		if scores[i] > scores[i-1] {
+			t.Errorf("scores %v are not decreasing", scores)
+		}
+	}
+}
+
+func TestMaximalMarginalRelevanceLambdaZero(t *testing.T) {
+	// Lambda 0 ranks by diversity alone, so the irrelevant candidate is not
+	// moved behind the relevant one
+	query := []float32{1, 1, 0}
+	candidates := map[string][]float32{
+		"a": {0, 0, 1},
+		"b": {1, 1, 0},
+	}
+
+	if uuids, _ := MaximalMarginalRelevance(query, candidates, 0, -1); !reflect.DeepEqual(uuids, []string{"a", "b"}) {
+		t.Errorf("lambda 0 order = %v, want [a b]", uuids)
+	}
+	if uuids, _ := MaximalMarginalRelevance(query, candidates, lambdaOrDefault(nil), -1); !reflect.DeepEqual(uuids, []string{"b", "a"}) {
+		t.Errorf("default lambda order = %v, want [b a]", uuids)
+	}
+}
+
+func TestMaximalMarginalRelevanceMinScore(t *testing.T) {
+	query := []float32{1, 0}
+	candidates := map[string][]float32{
+		"a":         {1, 0},
+		"duplicate": {1, 0},
+	}
+
+	uuids, _ := MaximalMarginalRelevance(query, candidates, 0.5, 0.1)
+	if !reflect.DeepEqual(uuids, []string{"a"}) {
+		t.Errorf("uuids = %v, want [a]", uuids)
+	}
+}
+
+func TestRRFBreaksTiesByUUID(t *testing.T) {
+	// c, b and a score the same in every call
+	for i := 0; i < 20; i++ {
+		uuids, _ := RRF([][]string{{"c", "x"}, {"b", "y"}, {"a", "z"}}, DefaultRankConstant, 0)
+		if want := []string{"a", "b", "c", "x", "y", "z"}; !reflect.DeepEqual(uuids, want) {
+			t.Fatalf("RRF() = %v, want %v", uuids, want)
+		}
+	}
+}
+
+func TestRRFRerankNodesIsStable(t *testing.T) {
+	s := &Searcher{}
+	results := [][]*types.Node{{{Uuid: "c"}, {Uuid: "d"}}, {{Uuid: "a"}, {Uuid: "b"}}}
+	for i := 0; i < 20; i++ {
+		nodes, _, err := s.rrfRerankNodes(results, 10)
+		if err != nil {
+			t.Fatalf("rrfRerankNodes() error = %v", err)
+		}
+		var uuids []string
+		for _, node := range nodes {
+			uuids = append(uuids, node.Uuid)
+		}
+		if want := []string{"a", "c", "b", "d"}; !reflect.DeepEqual(uuids, want) {
+			t.Fatalf("rrfRerankNodes() = %v, want %v", uuids, want)
+		}
+	}
+}
+
+func TestMaximalMarginalRelevanceSeed(t *testing.T) {
+	query := []float32{1, 0}
+	candidates := map[string][]float32{
+		"a": {0, 1},
+		"b": {0, 1},
+		"c": {0, 1},
+		"d": {0, 1},
+	}
+
+	byUUID, _ := MaximalMarginalRelevanceWithSeed(query, candidates, 1, -1, 0)
+	if want := []string{"a", "b", "c", "d"}; !reflect.DeepEqual(byUUID, want) {
+		t.Errorf("seed 0 order = %v, want %v", byUUID, want)
+	}
+
+	seeded, _ := MaximalMarginalRelevanceWithSeed(query, candidates, 1, -1, 42)
+	for i := 0; i < 10; i++ {
+		again, _ := MaximalMarginalRelevanceWithSeed(query, candidates, 1, -1, 42)
+		if !reflect.DeepEqual(again, seeded) {
+			t.Fatalf("seed 42 order changed from %v to %v", seeded, again)
+		}
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/soundprediction/go-predicato/pkg/crossencoder"
 	"github.com/soundprediction/go-predicato/pkg/driver"
 	"github.com/soundprediction/go-predicato/pkg/embedder"
 	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
 	"github.com/soundprediction/go-predicato/pkg/types"
 )
 
@@ -30,6 +32,9 @@ const (
 	CrossEncoderRerankType    RerankerType = "cross_encoder"
 	NodeDistanceRerankType    RerankerType = "node_distance"
 	EpisodeMentionsRerankType RerankerType = "episode_mentions"
+	// StrengthRerankType ranks edges by their recency-weighted strength,
+	// which grows each time their fact is extracted again
+	StrengthRerankType RerankerType = "strength"
 )
 
 type SearchConfig struct {
@@ -39,22 +44,78 @@ type SearchConfig struct {
 	CommunityConfig *CommunitySearchConfig `json:"community_config,omitempty"`
 	Limit           int                    `json:"limit"`
 	MinScore        float64                `json:"min_score"`
+	// Cursor resumes a search after the page that returned it as NextCursor
+	Cursor string `json:"cursor,omitempty"`
+	// CenterNodeUUID is the node the NodeDistanceRerankType reranker measures
+	// graph distances from
+	CenterNodeUUID string `json:"center_node_uuid,omitempty"`
+	// QueryExpansion is the number of paraphrases and sub-queries the LLM
+	// generates for the query. They are searched together with the query and
+	// the results fused with RRF. Zero disables query expansion.
+	QueryExpansion int `json:"query_expansion,omitempty"`
+	// AsOf searches the graph as it was believed at that time: only edges
+	// valid then, valid_from <= AsOf < valid_to, are returned. Zero searches
+	// all edges.
+	AsOf time.Time `json:"as_of,omitempty"`
+	// FusionMethod selects how the RRF reranker combines the results of the
+	// search methods (default: RRFFusion)
+	FusionMethod FusionMethod `json:"fusion_method,omitempty"`
+	// ScoreNormalization makes the scores of the search methods comparable
+	// for LinearFusion (default: MinMaxNormalization)
+	ScoreNormalization ScoreNormalization `json:"score_normalization,omitempty"`
+	// FusionWeights weighs the results of each search method in
+	// LinearFusion; methods not listed weigh 1
+	FusionWeights map[SearchMethod]float64 `json:"fusion_weights,omitempty"`
+	// Highlight returns the snippets of the results matching the query words
+	// in HybridSearchResult.Highlights
+	Highlight bool `json:"highlight,omitempty"`
+	// SnippetLength is the maximum length in bytes of a highlight snippet
+	// (default: DefaultSnippetLength)
+	SnippetLength int `json:"snippet_length,omitempty"`
 }
 
 type NodeSearchConfig struct {
 	SearchMethods []SearchMethod `json:"search_methods"`
 	Reranker      RerankerType   `json:"reranker"`
 	MinScore      float64        `json:"min_score"`
-	MMRLambda     float64        `json:"mmr_lambda"`
-	MaxDepth      int            `json:"max_depth"`
+	// MMRLambda weighs relevance against diversity for MMRRerankType
+	// (default: DefaultMMRLambda)
+	MMRLambda *float64 `json:"mmr_lambda,omitempty"`
+	MaxDepth  int      `json:"max_depth"`
+	// MMRSeed orders the candidates of equal MMR value; the same seed
+	// always gives the same ranking (default: by UUID)
+	MMRSeed int64 `json:"mmr_seed,omitempty"`
+	// BFSOriginNodeUUIDs are the nodes BreadthFirstSearch starts from. Empty
+	// starts from the results of the other search methods
+	BFSOriginNodeUUIDs []string `json:"bfs_origin_node_uuids,omitempty"`
+	// BFSMaxDepth is the maximum number of hops of BreadthFirstSearch
+	// (default: MaxDepth)
+	BFSMaxDepth int `json:"bfs_max_depth,omitempty"`
+	// BFSEdgeNames are the relation names BreadthFirstSearch may traverse;
+	// empty traverses all relations
+	BFSEdgeNames []string `json:"bfs_edge_names,omitempty"`
 }
 
 type EdgeSearchConfig struct {
 	SearchMethods []SearchMethod `json:"search_methods"`
 	Reranker      RerankerType   `json:"reranker"`
 	MinScore      float64        `json:"min_score"`
-	MMRLambda     float64        `json:"mmr_lambda"`
-	MaxDepth      int            `json:"max_depth"`
+	// MMRLambda weighs relevance against diversity for MMRRerankType
+	// (default: DefaultMMRLambda)
+	MMRLambda *float64 `json:"mmr_lambda,omitempty"`
+	MaxDepth  int      `json:"max_depth"`
+	// MMRSeed orders the candidates of equal MMR value; the same seed
+	// always gives the same ranking (default: by UUID)
+	MMRSeed int64 `json:"mmr_seed,omitempty"`
+	// BFSOriginNodeUUIDs are the nodes BreadthFirstSearch starts from. Empty
+	// starts from the results of the other search methods
+	BFSOriginNodeUUIDs []string `json:"bfs_origin_node_uuids,omitempty"`
+	// BFSMaxDepth is the maximum number of hops of BreadthFirstSearch
+	// (default: MaxDepth)
+	BFSMaxDepth int `json:"bfs_max_depth,omitempty"`
+	// BFSEdgeNames are the relation names BreadthFirstSearch may traverse;
+	// empty traverses all relations
+	BFSEdgeNames []string `json:"bfs_edge_names,omitempty"`
 }
 
 type EpisodeSearchConfig struct {
@@ -63,28 +124,70 @@ type EpisodeSearchConfig struct {
 	MinScore      float64        `json:"min_score"`
 }
 
+// CommunitySearchConfig configures the search for Community nodes. Besides
+// the search methods, communities containing the nodes found by the node
+// search are ranked by how many of those nodes they contain.
 type CommunitySearchConfig struct {
 	SearchMethods []SearchMethod `json:"search_methods"`
 	Reranker      RerankerType   `json:"reranker"`
 	MinScore      float64        `json:"min_score"`
-	MMRLambda     float64        `json:"mmr_lambda"`
+	// MMRLambda weighs relevance against diversity for MMRRerankType
+	// (default: DefaultMMRLambda)
+	MMRLambda *float64 `json:"mmr_lambda,omitempty"`
+	// MMRSeed orders the candidates of equal MMR value (default: by UUID)
+	MMRSeed int64 `json:"mmr_seed,omitempty"`
 }
 
+// SearchFilters restricts the results of a search. The filters are passed to
+// the driver and applied by the database query.
 type SearchFilters struct {
+	// GroupIDs are searched instead of the group ID of the search when set
 	GroupIDs    []string         `json:"group_ids,omitempty"`
 	NodeTypes   []types.NodeType `json:"node_types,omitempty"`
 	EdgeTypes   []types.EdgeType `json:"edge_types,omitempty"`
 	EntityTypes []string         `json:"entity_types,omitempty"`
-	TimeRange   *types.TimeRange `json:"time_range,omitempty"`
+	// EdgeNames keeps edges with one of these relation names
+	EdgeNames []string `json:"edge_names,omitempty"`
+	// TimeRange filters on creation time; a zero bound is open
+	TimeRange *types.TimeRange `json:"time_range,omitempty"`
+	// ValidAt keeps edges valid at some time in the range; a zero bound is open
+	ValidAt *types.TimeRange `json:"valid_at,omitempty"`
+	// MinConfidence drops edges whose confidence is below it; edges without
+	// a confidence are kept
+	MinConfidence float64 `json:"min_confidence,omitempty"`
+	// CommunityUUIDs keeps the nodes that are members of one of these
+	// communities, and the edges between such members
+	CommunityUUIDs []string `json:"community_uuids,omitempty"`
+
+	// asOf is SearchConfig.AsOf
+	asOf time.Time
+	// members are the UUIDs of the members of the CommunityUUIDs
+	members map[string]bool
+}
+
+// groupIDs returns the groups searched for groupID.
+func (f *SearchFilters) groupIDs(groupID string) []string {
+	if len(f.GroupIDs) > 0 {
+		return f.GroupIDs
+	}
+	return []string{groupID}
 }
 
 type HybridSearchResult struct {
-	Nodes      []*types.Node `json:"nodes"`
-	Edges      []*types.Edge `json:"edges"`
-	NodeScores []float64     `json:"node_scores"`
-	EdgeScores []float64     `json:"edge_scores"`
-	Query      string        `json:"query"`
-	Total      int           `json:"total"`
+	Nodes           []*types.Node `json:"nodes"`
+	Edges           []*types.Edge `json:"edges"`
+	Communities     []*types.Node `json:"communities,omitempty"`
+	NodeScores      []float64     `json:"node_scores"`
+	EdgeScores      []float64     `json:"edge_scores"`
+	CommunityScores []float64     `json:"community_scores,omitempty"`
+	Query           string        `json:"query"`
+	Total           int           `json:"total"`
+	// NextCursor fetches the next page when set as SearchConfig.Cursor, empty
+	// on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Highlights are the snippets of each result matching the query, keyed
+	// by UUID, when SearchConfig.Highlight is set
+	Highlights map[string][]types.Highlight `json:"highlights,omitempty"`
 }
 
 type Searcher struct {
@@ -112,19 +215,52 @@ func (s *Searcher) Search(ctx context.Context, query string, config *SearchConfi
 	if strings.TrimSpace(query) == "" {
 		return &HybridSearchResult{}, nil
 	}
+	if filters == nil {
+		filters = &SearchFilters{}
+	}
+	if !config.AsOf.IsZero() {
+		asOfFilters := *filters
+		asOfFilters.asOf = config.AsOf
+		filters = &asOfFilters
+	}
+	if len(filters.CommunityUUIDs) > 0 && filters.members == nil {
+		members, err := s.communityMembers(ctx, filters.CommunityUUIDs, filters.groupIDs(groupID))
+		if err != nil {
+			return nil, err
+		}
+		memberFilters := *filters
+		memberFilters.members = members
+		filters = &memberFilters
+	}
+	fusion, err := config.fusion()
+	if err != nil {
+		return nil, err
+	}
+	if config.QueryExpansion > 0 && s.llm != nil {
+		return s.multiQuerySearch(ctx, query, config, filters, groupID)
+	}
+
+	cursor, err := DecodeCursor(config.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if cursor == nil {
+		cursor = &SearchCursor{}
+	}
+	// Later pages rank the results of earlier pages again, plus one result to
+	// tell whether another page follows
+	window := config.Limit + cursor.Offset + 1
 
 	// Generate query embedding if needed for semantic search
 	var queryVector []float32
 	needsEmbedding := s.needsEmbedding(config)
 
 	if needsEmbedding {
-		vectors, err := s.embedder.Embed(ctx, []string{strings.ReplaceAll(query, "\n", " ")})
+		vector, err := s.embedder.EmbedQuery(ctx, strings.ReplaceAll(query, "\n", " "))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create query embedding: %w", err)
 		}
-		if len(vectors) > 0 {
-			queryVector = vectors[0]
-		}
+		queryVector = vector
 	}
 
 	// Perform searches concurrently
@@ -132,35 +268,92 @@ func (s *Searcher) Search(ctx context.Context, query string, config *SearchConfi
 	edgeResults := make([]*types.Edge, 0)
 	nodeScores := make([]float64, 0)
 	edgeScores := make([]float64, 0)
+	var communityResults []*types.Node
+	var communityScores []float64
+	// memberNodes are the ranked nodes before paging, whose communities the
+	// community search looks up
+	var memberNodes []*types.Node
+
+	next := &SearchCursor{Node: cursor.Node, Edge: cursor.Edge, Community: cursor.Community, Offset: cursor.Offset + config.Limit}
+	hasMore := false
 
 	// Node search
 	if config.NodeConfig != nil {
-		nodes, scores, err := s.searchNodes(ctx, query, queryVector, config.NodeConfig, filters, groupID, config.Limit)
+		nodes, scores, err := s.searchNodes(ctx, query, queryVector, config.NodeConfig, fusion, filters, groupID, config.CenterNodeUUID, window)
+		if err != nil {
+			return nil, fmt.Errorf("node search failed: %w", err)
+		}
+		memberNodes = nodes
+		after, skip := cursor.resumeAt(cursor.Node, config.NodeConfig.Reranker)
+		nodes, scores, last, more, err := pageNodes(nodes, scores, after, skip, config.Limit)
 		if err != nil {
 			return nil, fmt.Errorf("node search failed: %w", err)
 		}
 		nodeResults = nodes
 		nodeScores = scores
+		if last != nil {
+			next.Node = last
+		}
+		hasMore = hasMore || more
 	}
 
 	// Edge search
 	if config.EdgeConfig != nil {
-		edges, scores, err := s.searchEdges(ctx, query, queryVector, config.EdgeConfig, filters, groupID, config.Limit)
+		edges, scores, err := s.searchEdges(ctx, query, queryVector, config.EdgeConfig, fusion, filters, groupID, config.CenterNodeUUID, window)
+		if err != nil {
+			return nil, fmt.Errorf("edge search failed: %w", err)
+		}
+		after, skip := cursor.resumeAt(cursor.Edge, config.EdgeConfig.Reranker)
+		edges, scores, last, more, err := pageEdges(edges, scores, after, skip, config.Limit)
 		if err != nil {
 			return nil, fmt.Errorf("edge search failed: %w", err)
 		}
 		edgeResults = edges
 		edgeScores = scores
+		if last != nil {
+			next.Edge = last
+		}
+		hasMore = hasMore || more
+	}
+
+	// Community search
+	if config.CommunityConfig != nil {
+		communities, scores, err := s.searchCommunities(ctx, query, queryVector, config.CommunityConfig, fusion, filters, groupID, memberNodes, window)
+		if err != nil {
+			return nil, fmt.Errorf("community search failed: %w", err)
+		}
+		after, skip := cursor.resumeAt(cursor.Community, config.CommunityConfig.Reranker)
+		communities, scores, last, more, err := pageNodes(communities, scores, after, skip, config.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("community search failed: %w", err)
+		}
+		communityResults = communities
+		communityScores = scores
+		if last != nil {
+			next.Community = last
+		}
+		hasMore = hasMore || more
 	}
 
-	return &HybridSearchResult{
-		Nodes:      nodeResults,
-		Edges:      edgeResults,
-		NodeScores: nodeScores,
-		EdgeScores: edgeScores,
-		Query:      query,
-		Total:      len(nodeResults) + len(edgeResults),
-	}, nil
+	result := &HybridSearchResult{
+		Nodes:           nodeResults,
+		Edges:           edgeResults,
+		Communities:     communityResults,
+		NodeScores:      nodeScores,
+		EdgeScores:      edgeScores,
+		CommunityScores: communityScores,
+		Query:           query,
+		Total:           len(nodeResults) + len(edgeResults) + len(communityResults),
+	}
+	if hasMore && config.Limit > 0 {
+		if result.NextCursor, err = EncodeCursor(next); err != nil {
+			return nil, err
+		}
+	}
+	if config.Highlight {
+		result.Highlights = highlightResults(query, result, config.SnippetLength)
+	}
+	return result, nil
 }
 
 func (s *Searcher) needsEmbedding(config *SearchConfig) bool {
@@ -200,9 +393,14 @@ func (s *Searcher) needsEmbedding(config *SearchConfig) bool {
 	return false
 }
 
-func (s *Searcher) searchNodes(ctx context.Context, query string, queryVector []float32, config *NodeSearchConfig, filters *SearchFilters, groupID string, limit int) ([]*types.Node, []float64, error) {
+func (s *Searcher) searchNodes(ctx context.Context, query string, queryVector []float32, config *NodeSearchConfig, fusion *fusionOptions, filters *SearchFilters, groupID, centerNodeUUID string, limit int) ([]*types.Node, []float64, error) {
 	searchResults := make([][]*types.Node, 0)
-	var bfsOriginNodes []string
+	// methods holds the search method of each list of searchResults
+	var methods []SearchMethod
+	// BFS starts from the configured origins, or else from the nodes found
+	// by the other methods
+	bfsOriginNodes := config.BFSOriginNodeUUIDs
+	collectOrigins := len(bfsOriginNodes) == 0
 
 	// Execute different search methods
 	for _, method := range config.SearchMethods {
@@ -213,9 +411,12 @@ func (s *Searcher) searchNodes(ctx context.Context, query string, queryVector []
 				return nil, nil, fmt.Errorf("BM25 node search failed: %w", err)
 			}
 			searchResults = append(searchResults, nodes)
+			methods = append(methods, method)
 			// Collect UUIDs for BFS
-			for _, node := range nodes {
-				bfsOriginNodes = append(bfsOriginNodes, node.Uuid)
+			if collectOrigins {
+				for _, node := range nodes {
+					bfsOriginNodes = append(bfsOriginNodes, node.Uuid)
+				}
 			}
 
 		case CosineSimilarity:
@@ -227,9 +428,12 @@ func (s *Searcher) searchNodes(ctx context.Context, query string, queryVector []
 				return nil, nil, fmt.Errorf("similarity node search failed: %w", err)
 			}
 			searchResults = append(searchResults, nodes)
+			methods = append(methods, method)
 			// Collect UUIDs for BFS
-			for _, node := range nodes {
-				bfsOriginNodes = append(bfsOriginNodes, node.Uuid)
+			if collectOrigins {
+				for _, node := range nodes {
+					bfsOriginNodes = append(bfsOriginNodes, node.Uuid)
+				}
 			}
 
 		case BreadthFirstSearch:
@@ -250,7 +454,10 @@ func (s *Searcher) searchNodes(ctx context.Context, query string, queryVector []
 	if hasBFS && len(bfsOriginNodes) > 0 {
 		// Create search utilities for BFS
 		searchUtils := NewSearchUtilities(s.driver)
-		maxDepth := config.MaxDepth
+		maxDepth := config.BFSMaxDepth
+		if maxDepth == 0 {
+			maxDepth = config.MaxDepth
+		}
 		if maxDepth == 0 {
 			maxDepth = MaxSearchDepth
 		}
@@ -259,7 +466,8 @@ func (s *Searcher) searchNodes(ctx context.Context, query string, queryVector []
 			MaxDepth:      maxDepth,
 			Limit:         limit * 2,
 			SearchFilters: filters,
-			GroupIDs:      []string{groupID},
+			GroupIDs:      filters.groupIDs(groupID),
+			EdgeNames:     config.BFSEdgeNames,
 		}
 
 		bfsNodes, err := searchUtils.NodeBFSSearch(ctx, bfsOriginNodes, bfsOptions)
@@ -268,16 +476,28 @@ func (s *Searcher) searchNodes(ctx context.Context, query string, queryVector []
 		}
 		if len(bfsNodes) > 0 {
 			searchResults = append(searchResults, bfsNodes)
+			methods = append(methods, BreadthFirstSearch)
+		}
+	}
+
+	if filters.members != nil {
+		for i, nodes := range searchResults {
+			searchResults[i] = filterNodesByMembers(nodes, filters.members)
 		}
 	}
 
 	// Combine and rerank results
-	return s.rerankNodes(ctx, query, queryVector, searchResults, config, limit)
+	return s.rerankNodes(ctx, query, queryVector, searchResults, methods, config, fusion, groupID, centerNodeUUID, limit)
 }
 
-func (s *Searcher) searchEdges(ctx context.Context, query string, queryVector []float32, config *EdgeSearchConfig, filters *SearchFilters, groupID string, limit int) ([]*types.Edge, []float64, error) {
+func (s *Searcher) searchEdges(ctx context.Context, query string, queryVector []float32, config *EdgeSearchConfig, fusion *fusionOptions, filters *SearchFilters, groupID, centerNodeUUID string, limit int) ([]*types.Edge, []float64, error) {
 	searchResults := make([][]*types.Edge, 0)
-	var bfsOriginNodes []string
+	// methods holds the search method of each list of searchResults
+	var methods []SearchMethod
+	// BFS starts from the configured origins, or else from the source nodes
+	// of the edges found by the other methods
+	bfsOriginNodes := config.BFSOriginNodeUUIDs
+	collectOrigins := len(bfsOriginNodes) == 0
 
 	// Execute different search methods
 	for _, method := range config.SearchMethods {
@@ -288,9 +508,12 @@ func (s *Searcher) searchEdges(ctx context.Context, query string, queryVector []
 				return nil, nil, fmt.Errorf("BM25 edge search failed: %w", err)
 			}
 			searchResults = append(searchResults, edges)
+			methods = append(methods, method)
 			// Collect source node UUIDs for BFS
-			for _, edge := range edges {
-				bfsOriginNodes = append(bfsOriginNodes, edge.SourceID)
+			if collectOrigins {
+				for _, edge := range edges {
+					bfsOriginNodes = append(bfsOriginNodes, edge.SourceID)
+				}
 			}
 
 		case CosineSimilarity:
@@ -302,9 +525,12 @@ func (s *Searcher) searchEdges(ctx context.Context, query string, queryVector []
 				return nil, nil, fmt.Errorf("similarity edge search failed: %w", err)
 			}
 			searchResults = append(searchResults, edges)
+			methods = append(methods, method)
 			// Collect source node UUIDs for BFS
-			for _, edge := range edges {
-				bfsOriginNodes = append(bfsOriginNodes, edge.SourceID)
+			if collectOrigins {
+				for _, edge := range edges {
+					bfsOriginNodes = append(bfsOriginNodes, edge.SourceID)
+				}
 			}
 
 		case BreadthFirstSearch:
@@ -325,7 +551,10 @@ func (s *Searcher) searchEdges(ctx context.Context, query string, queryVector []
 	if hasBFS && len(bfsOriginNodes) > 0 {
 		// Create search utilities for BFS
 		searchUtils := NewSearchUtilities(s.driver)
-		maxDepth := config.MaxDepth
+		maxDepth := config.BFSMaxDepth
+		if maxDepth == 0 {
+			maxDepth = config.MaxDepth
+		}
 		if maxDepth == 0 {
 			maxDepth = MaxSearchDepth
 		}
@@ -334,7 +563,8 @@ func (s *Searcher) searchEdges(ctx context.Context, query string, queryVector []
 			MaxDepth:      maxDepth,
 			Limit:         limit * 2,
 			SearchFilters: filters,
-			GroupIDs:      []string{groupID},
+			GroupIDs:      filters.groupIDs(groupID),
+			EdgeNames:     config.BFSEdgeNames,
 		}
 
 		bfsEdges, err := searchUtils.EdgeBFSSearch(ctx, bfsOriginNodes, bfsOptions)
@@ -343,11 +573,158 @@ func (s *Searcher) searchEdges(ctx context.Context, query string, queryVector []
 		}
 		if len(bfsEdges) > 0 {
 			searchResults = append(searchResults, bfsEdges)
+			methods = append(methods, BreadthFirstSearch)
+		}
+	}
+
+	if filters.MinConfidence > 0 {
+		for i, edges := range searchResults {
+			searchResults[i] = filterEdgesByConfidence(edges, filters.MinConfidence)
+		}
+	}
+	if filters.members != nil {
+		for i, edges := range searchResults {
+			searchResults[i] = filterEdgesByMembers(edges, filters.members)
 		}
 	}
 
 	// Combine and rerank results
-	return s.rerankEdges(ctx, query, queryVector, searchResults, config, limit)
+	return s.rerankEdges(ctx, query, queryVector, searchResults, methods, config, fusion, groupID, centerNodeUUID, limit)
+}
+
+// filterEdgesByConfidence drops the edges whose confidence is below
+// minConfidence, keeping the edges without a confidence.
+func filterEdgesByConfidence(edges []*types.Edge, minConfidence float64) []*types.Edge {
+	filtered := make([]*types.Edge, 0, len(edges))
+	for _, edge := range edges {
+		if confidence, ok := edge.Confidence(); ok && confidence < minConfidence {
+			continue
+		}
+		filtered = append(filtered, edge)
+	}
+	return filtered
+}
+
+// communityMembers returns the UUIDs of the members of the communities of
+// groupIDs with the given UUIDs.
+func (s *Searcher) communityMembers(ctx context.Context, communityUUIDs, groupIDs []string) (map[string]bool, error) {
+	members := make(map[string]bool)
+	for _, groupID := range groupIDs {
+		groupMembers, err := driver.CommunityMembers(ctx, s.driver, groupID, communityUUIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get community members: %w", err)
+		}
+		for _, uuids := range groupMembers {
+			for _, uuid := range uuids {
+				members[uuid] = true
+			}
+		}
+	}
+	return members, nil
+}
+
+// filterNodesByMembers keeps the nodes that are members.
+func filterNodesByMembers(nodes []*types.Node, members map[string]bool) []*types.Node {
+	filtered := make([]*types.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if members[node.Uuid] {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+// filterEdgesByMembers keeps the edges whose source and target are members.
+func filterEdgesByMembers(edges []*types.Edge, members map[string]bool) []*types.Edge {
+	filtered := make([]*types.Edge, 0, len(edges))
+	for _, edge := range edges {
+		if members[edge.SourceID] && members[edge.TargetID] {
+			filtered = append(filtered, edge)
+		}
+	}
+	return filtered
+}
+
+// searchCommunities finds Community nodes by name and name embedding, adds
+// the communities of memberNodes and reranks them together.
+func (s *Searcher) searchCommunities(ctx context.Context, query string, queryVector []float32, config *CommunitySearchConfig, fusion *fusionOptions, filters *SearchFilters, groupID string, memberNodes []*types.Node, limit int) ([]*types.Node, []float64, error) {
+	searchResults := make([][]*types.Node, 0)
+	var methods []SearchMethod
+	communityFilters := &SearchFilters{
+		GroupIDs:  filters.GroupIDs,
+		NodeTypes: []types.NodeType{types.CommunityNodeType},
+		TimeRange: filters.TimeRange,
+	}
+
+	for _, method := range config.SearchMethods {
+		switch method {
+		case BM25:
+			communities, err := s.nodeFulltextSearch(ctx, query, communityFilters, groupID, limit*2)
+			if err != nil {
+				return nil, nil, fmt.Errorf("BM25 community search failed: %w", err)
+			}
+			searchResults = append(searchResults, communities)
+			methods = append(methods, method)
+
+		case CosineSimilarity:
+			if len(queryVector) == 0 {
+				continue
+			}
+			communities, err := s.nodeSimilaritySearch(ctx, queryVector, communityFilters, groupID, limit*2, config.MinScore)
+			if err != nil {
+				return nil, nil, fmt.Errorf("similarity community search failed: %w", err)
+			}
+			searchResults = append(searchResults, communities)
+			methods = append(methods, method)
+		}
+	}
+
+	if len(memberNodes) > 0 {
+		communities, err := s.communitiesByMemberOverlap(ctx, memberNodes, limit*2)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(communities) > 0 {
+			searchResults = append(searchResults, communities)
+			methods = append(methods, memberOverlapMethod)
+		}
+	}
+
+	nodeConfig := &NodeSearchConfig{
+		Reranker:  config.Reranker,
+		MinScore:  config.MinScore,
+		MMRLambda: config.MMRLambda,
+		MMRSeed:   config.MMRSeed,
+	}
+	return s.rerankNodes(ctx, query, queryVector, searchResults, methods, nodeConfig, fusion, groupID, "", limit)
+}
+
+// communitiesByMemberOverlap returns the communities of the given entity
+// nodes, ordered by how many of the nodes each contains.
+func (s *Searcher) communitiesByMemberOverlap(ctx context.Context, nodes []*types.Node, limit int) ([]*types.Node, error) {
+	var communities []*types.Node
+	members := make(map[string]int)
+	for _, node := range nodes {
+		if node.Type == types.EpisodicNodeType || node.Type == types.CommunityNodeType {
+			continue
+		}
+		community, err := s.driver.GetExistingCommunity(ctx, node.Uuid, node.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get community of node %s: %w", node.Uuid, err)
+		}
+		if community == nil {
+			continue
+		}
+		if members[community.Uuid] == 0 {
+			communities = append(communities, community)
+		}
+		members[community.Uuid]++
+	}
+
+	sort.SliceStable(communities, func(i, j int) bool {
+		return members[communities[i].Uuid] > members[communities[j].Uuid]
+	})
+	return communities[:min(limit, len(communities))], nil
 }
 
 func (s *Searcher) nodeFulltextSearch(ctx context.Context, query string, filters *SearchFilters, groupID string, limit int) ([]*types.Node, error) {
@@ -357,15 +734,21 @@ func (s *Searcher) nodeFulltextSearch(ctx context.Context, query string, filters
 		Limit:       limit,
 		UseFullText: true,
 		NodeTypes:   filters.NodeTypes,
+		TimeRange:   filters.TimeRange,
+		GroupIDs:    filters.GroupIDs,
+		EntityTypes: filters.EntityTypes,
 	})
 }
 
 func (s *Searcher) nodeSimilaritySearch(ctx context.Context, queryVector []float32, filters *SearchFilters, groupID string, limit int, minScore float64) ([]*types.Node, error) {
 	// This would use vector similarity search
 	return s.driver.SearchNodesByVector(ctx, queryVector, groupID, &driver.VectorSearchOptions{
-		Limit:     limit,
-		MinScore:  minScore,
-		NodeTypes: filters.NodeTypes,
+		Limit:       limit,
+		MinScore:    minScore,
+		NodeTypes:   filters.NodeTypes,
+		TimeRange:   filters.TimeRange,
+		GroupIDs:    filters.GroupIDs,
+		EntityTypes: filters.EntityTypes,
 	})
 }
 
@@ -374,6 +757,11 @@ func (s *Searcher) edgeFulltextSearch(ctx context.Context, query string, filters
 		Limit:       limit,
 		UseFullText: true,
 		EdgeTypes:   filters.EdgeTypes,
+		TimeRange:   filters.TimeRange,
+		GroupIDs:    filters.GroupIDs,
+		EdgeNames:   filters.EdgeNames,
+		ValidAt:     filters.ValidAt,
+		AsOf:        filters.asOf,
 	})
 }
 
@@ -382,34 +770,46 @@ func (s *Searcher) edgeSimilaritySearch(ctx context.Context, queryVector []float
 		Limit:     limit,
 		MinScore:  minScore,
 		EdgeTypes: filters.EdgeTypes,
+		TimeRange: filters.TimeRange,
+		GroupIDs:  filters.GroupIDs,
+		EdgeNames: filters.EdgeNames,
+		ValidAt:   filters.ValidAt,
+		AsOf:      filters.asOf,
 	})
 }
 
-func (s *Searcher) rerankNodes(ctx context.Context, query string, queryVector []float32, searchResults [][]*types.Node, config *NodeSearchConfig, limit int) ([]*types.Node, []float64, error) {
+func (s *Searcher) rerankNodes(ctx context.Context, query string, queryVector []float32, searchResults [][]*types.Node, methods []SearchMethod, config *NodeSearchConfig, fusion *fusionOptions, groupID, centerNodeUUID string, limit int) ([]*types.Node, []float64, error) {
 	if len(searchResults) == 0 {
 		return []*types.Node{}, []float64{}, nil
 	}
 
-	// Create node map for deduplication
-	nodeMap := make(map[string]*types.Node)
+	// Deduplicate in order of first appearance, so that rerankers see the
+	// candidates in the same order on every call
+	seen := make(map[string]bool)
+	var nodes []*types.Node
 	for _, results := range searchResults {
 		for _, node := range results {
-			nodeMap[node.Uuid] = node
+			if !seen[node.Uuid] {
+				seen[node.Uuid] = true
+				nodes = append(nodes, node)
+			}
 		}
 	}
 
-	nodes := make([]*types.Node, 0, len(nodeMap))
-	for _, node := range nodeMap {
-		nodes = append(nodes, node)
-	}
-
 	switch config.Reranker {
 	case RRFRerankType:
+		if fusion.method == LinearFusion {
+			return s.linearFusionRerankNodes(ctx, queryVector, searchResults, methods, nodes, fusion, limit)
+		}
 		return s.rrfRerankNodes(searchResults, limit)
 	case MMRRerankType:
-		return s.mmrRerankNodes(ctx, queryVector, nodes, config.MMRLambda, config.MinScore, limit)
+		return s.mmrRerankNodes(ctx, queryVector, nodes, lambdaOrDefault(config.MMRLambda), config.MMRSeed, config.MinScore, limit)
 	case CrossEncoderRerankType:
 		return s.crossEncoderRerankNodes(ctx, query, nodes, config.MinScore, limit)
+	case NodeDistanceRerankType:
+		return s.nodeDistanceRerankNodes(ctx, searchResults, groupID, centerNodeUUID, config.MaxDepth, config.MinScore, limit)
+	case EpisodeMentionsRerankType:
+		return s.episodeMentionsRerankNodes(ctx, searchResults, groupID, config.MinScore, limit)
 	default:
 		// Default to simple score-based ranking
 		scores := make([]float64, len(nodes))
@@ -420,31 +820,40 @@ func (s *Searcher) rerankNodes(ctx context.Context, query string, queryVector []
 	}
 }
 
-func (s *Searcher) rerankEdges(ctx context.Context, query string, queryVector []float32, searchResults [][]*types.Edge, config *EdgeSearchConfig, limit int) ([]*types.Edge, []float64, error) {
+func (s *Searcher) rerankEdges(ctx context.Context, query string, queryVector []float32, searchResults [][]*types.Edge, methods []SearchMethod, config *EdgeSearchConfig, fusion *fusionOptions, groupID, centerNodeUUID string, limit int) ([]*types.Edge, []float64, error) {
 	if len(searchResults) == 0 {
 		return []*types.Edge{}, []float64{}, nil
 	}
 
-	// Create edge map for deduplication
-	edgeMap := make(map[string]*types.Edge)
+	// Deduplicate in order of first appearance, so that rerankers see the
+	// candidates in the same order on every call
+	seen := make(map[string]bool)
+	var edges []*types.Edge
 	for _, results := range searchResults {
 		for _, edge := range results {
-			edgeMap[edge.Uuid] = edge
+			if !seen[edge.Uuid] {
+				seen[edge.Uuid] = true
+				edges = append(edges, edge)
+			}
 		}
 	}
 
-	edges := make([]*types.Edge, 0, len(edgeMap))
-	for _, edge := range edgeMap {
-		edges = append(edges, edge)
-	}
-
 	switch config.Reranker {
 	case RRFRerankType:
+		if fusion.method == LinearFusion {
+			return s.linearFusionRerankEdges(ctx, queryVector, searchResults, methods, edges, fusion, limit)
+		}
 		return s.rrfRerankEdges(searchResults, limit)
 	case MMRRerankType:
-		return s.mmrRerankEdges(ctx, queryVector, edges, config.MMRLambda, config.MinScore, limit)
+		return s.mmrRerankEdges(ctx, queryVector, edges, lambdaOrDefault(config.MMRLambda), config.MMRSeed, config.MinScore, limit)
 	case CrossEncoderRerankType:
 		return s.crossEncoderRerankEdges(ctx, query, edges, config.MinScore, limit)
+	case NodeDistanceRerankType:
+		return s.nodeDistanceRerankEdges(ctx, searchResults, groupID, centerNodeUUID, config.MaxDepth, config.MinScore, limit)
+	case EpisodeMentionsRerankType:
+		return s.episodeMentionsRerankEdges(searchResults, config.MinScore, limit)
+	case StrengthRerankType:
+		return s.strengthRerankEdges(searchResults, time.Now(), config.MinScore, limit)
 	default:
 		// Default to simple score-based ranking
 		scores := make([]float64, len(edges))
@@ -486,7 +895,10 @@ func (s *Searcher) rrfRerankNodes(searchResults [][]*types.Node, limit int) ([]*
 	}
 
 	sort.Slice(nodeScores, func(i, j int) bool {
-		return nodeScores[i].score > nodeScores[j].score
+		if nodeScores[i].score != nodeScores[j].score {
+			return nodeScores[i].score > nodeScores[j].score
+		}
+		return nodeScores[i].node.Uuid < nodeScores[j].node.Uuid
 	})
 
 	// Extract results
@@ -531,7 +943,10 @@ func (s *Searcher) rrfRerankEdges(searchResults [][]*types.Edge, limit int) ([]*
 	}
 
 	sort.Slice(edgeScores, func(i, j int) bool {
-		return edgeScores[i].score > edgeScores[j].score
+		if edgeScores[i].score != edgeScores[j].score {
+			return edgeScores[i].score > edgeScores[j].score
+		}
+		return edgeScores[i].edge.Uuid < edgeScores[j].edge.Uuid
 	})
 
 	// Extract results
@@ -546,8 +961,208 @@ func (s *Searcher) rrfRerankEdges(searchResults [][]*types.Edge, limit int) ([]*
 	return edges, scores, nil
 }
 
+// linearFusionRerankNodes ranks nodes by the weighted sum of their
+// normalized scores for each search method.
+func (s *Searcher) linearFusionRerankNodes(ctx context.Context, queryVector []float32, searchResults [][]*types.Node, methods []SearchMethod, nodes []*types.Node, fusion *fusionOptions, limit int) ([]*types.Node, []float64, error) {
+	embeddings, err := GetEmbeddingsForNodes(ctx, s.driver, nodes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get node embeddings: %w", err)
+	}
+
+	ranked, scores := linearFusion(searchResults, methods, queryVector, embeddings, fusion, func(node *types.Node) string { return node.Uuid })
+	return ranked[:min(limit, len(ranked))], scores[:min(limit, len(scores))], nil
+}
+
+// linearFusionRerankEdges ranks edges by the weighted sum of their
+// normalized scores for each search method.
+func (s *Searcher) linearFusionRerankEdges(ctx context.Context, queryVector []float32, searchResults [][]*types.Edge, methods []SearchMethod, edges []*types.Edge, fusion *fusionOptions, limit int) ([]*types.Edge, []float64, error) {
+	embeddings, err := GetEmbeddingsForEdges(ctx, s.driver, edges)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get edge embeddings: %w", err)
+	}
+
+	ranked, scores := linearFusion(searchResults, methods, queryVector, embeddings, fusion, func(edge *types.Edge) string { return edge.Uuid })
+	return ranked[:min(limit, len(ranked))], scores[:min(limit, len(scores))], nil
+}
+
+// Node distance reranking
+func (s *Searcher) nodeDistanceRerankNodes(ctx context.Context, searchResults [][]*types.Node, groupID, centerNodeUUID string, maxDepth int, minScore float64, limit int) ([]*types.Node, []float64, error) {
+	if centerNodeUUID == "" {
+		return nil, nil, fmt.Errorf("node distance reranking requires a center node UUID")
+	}
+
+	// Use RRF as the preliminary ranking, kept among nodes at the same distance
+	nodeMap := make(map[string]*types.Node)
+	uuidLists := make([][]string, len(searchResults))
+	for i, results := range searchResults {
+		for _, node := range results {
+			nodeMap[node.Uuid] = node
+			uuidLists[i] = append(uuidLists[i], node.Uuid)
+		}
+	}
+	rrfUUIDs, _ := RRF(uuidLists, DefaultRankConstant, 0)
+
+	uuids, distanceScores, err := NodeDistanceReranker(ctx, s.driver, rrfUUIDs, centerNodeUUID, groupID, maxDepth, minScore)
+	if err != nil {
+		return nil, nil, fmt.Errorf("node distance reranking failed: %w", err)
+	}
+
+	nodes := make([]*types.Node, 0, min(limit, len(uuids)))
+	scores := make([]float64, 0, min(limit, len(uuids)))
+	for i := 0; i < min(limit, len(uuids)); i++ {
+		nodes = append(nodes, nodeMap[uuids[i]])
+		scores = append(scores, distanceScores[i])
+	}
+
+	return nodes, scores, nil
+}
+
+// nodeDistanceRerankEdges ranks edges by the distance of their source node
+// from the center node.
+func (s *Searcher) nodeDistanceRerankEdges(ctx context.Context, searchResults [][]*types.Edge, groupID, centerNodeUUID string, maxDepth int, minScore float64, limit int) ([]*types.Edge, []float64, error) {
+	if centerNodeUUID == "" {
+		return nil, nil, fmt.Errorf("node distance reranking requires a center node UUID")
+	}
+
+	// Use RRF as the preliminary ranking, kept among edges at the same distance
+	edgeMap := make(map[string]*types.Edge)
+	uuidLists := make([][]string, len(searchResults))
+	for i, results := range searchResults {
+		for _, edge := range results {
+			edgeMap[edge.Uuid] = edge
+			uuidLists[i] = append(uuidLists[i], edge.Uuid)
+		}
+	}
+	rrfUUIDs, _ := RRF(uuidLists, DefaultRankConstant, 0)
+
+	// Group the edges by source node in RRF order
+	var sourceUUIDs []string
+	edgesBySource := make(map[string][]*types.Edge)
+	for _, uuid := range rrfUUIDs {
+		edge := edgeMap[uuid]
+		if _, ok := edgesBySource[edge.SourceID]; !ok {
+			sourceUUIDs = append(sourceUUIDs, edge.SourceID)
+		}
+		edgesBySource[edge.SourceID] = append(edgesBySource[edge.SourceID], edge)
+	}
+
+	rankedSources, sourceScores, err := NodeDistanceReranker(ctx, s.driver, sourceUUIDs, centerNodeUUID, groupID, maxDepth, minScore)
+	if err != nil {
+		return nil, nil, fmt.Errorf("node distance reranking failed: %w", err)
+	}
+
+	edges := make([]*types.Edge, 0, limit)
+	scores := make([]float64, 0, limit)
+	for i, sourceUUID := range rankedSources {
+		for _, edge := range edgesBySource[sourceUUID] {
+			if len(edges) == limit {
+				return edges, scores, nil
+			}
+			edges = append(edges, edge)
+			scores = append(scores, sourceScores[i])
+		}
+	}
+
+	return edges, scores, nil
+}
+
+// Episode mentions reranking
+func (s *Searcher) episodeMentionsRerankNodes(ctx context.Context, searchResults [][]*types.Node, groupID string, minScore float64, limit int) ([]*types.Node, []float64, error) {
+	nodeMap := make(map[string]*types.Node)
+	uuidLists := make([][]string, len(searchResults))
+	for i, results := range searchResults {
+		for _, node := range results {
+			nodeMap[node.Uuid] = node
+			uuidLists[i] = append(uuidLists[i], node.Uuid)
+		}
+	}
+
+	uuids, mentionScores, err := EpisodeMentionsReranker(ctx, s.driver, uuidLists, groupID, minScore)
+	if err != nil {
+		return nil, nil, fmt.Errorf("episode mentions reranking failed: %w", err)
+	}
+
+	nodes := make([]*types.Node, 0, min(limit, len(uuids)))
+	scores := make([]float64, 0, min(limit, len(uuids)))
+	for i := 0; i < min(limit, len(uuids)); i++ {
+		nodes = append(nodes, nodeMap[uuids[i]])
+		scores = append(scores, mentionScores[i])
+	}
+
+	return nodes, scores, nil
+}
+
+// episodeMentionsRerankEdges ranks edges by the number of episodes they were
+// extracted from, using RRF to order edges with equal counts.
+func (s *Searcher) episodeMentionsRerankEdges(searchResults [][]*types.Edge, minScore float64, limit int) ([]*types.Edge, []float64, error) {
+	edgeMap := make(map[string]*types.Edge)
+	uuidLists := make([][]string, len(searchResults))
+	for i, results := range searchResults {
+		for _, edge := range results {
+			edgeMap[edge.Uuid] = edge
+			uuidLists[i] = append(uuidLists[i], edge.Uuid)
+		}
+	}
+	rrfUUIDs, _ := RRF(uuidLists, DefaultRankConstant, 0)
+
+	sort.SliceStable(rrfUUIDs, func(i, j int) bool {
+		return len(edgeMap[rrfUUIDs[i]].Episodes) > len(edgeMap[rrfUUIDs[j]].Episodes)
+	})
+
+	edges := make([]*types.Edge, 0, min(limit, len(rrfUUIDs)))
+	scores := make([]float64, 0, min(limit, len(rrfUUIDs)))
+	for _, uuid := range rrfUUIDs {
+		if len(edges) == limit {
+			break
+		}
+		edge := edgeMap[uuid]
+		if score := float64(len(edge.Episodes)); score >= minScore {
+			edges = append(edges, edge)
+			scores = append(scores, score)
+		}
+	}
+
+	return edges, scores, nil
+}
+
+// strengthRerankEdges ranks edges by their strength at now (see
+// types.EntityEdge.StrengthAt); RRF orders edges of equal strength.
+func (s *Searcher) strengthRerankEdges(searchResults [][]*types.Edge, now time.Time, minScore float64, limit int) ([]*types.Edge, []float64, error) {
+	edgeMap := make(map[string]*types.Edge)
+	uuidLists := make([][]string, len(searchResults))
+	for i, results := range searchResults {
+		for _, edge := range results {
+			edgeMap[edge.Uuid] = edge
+			uuidLists[i] = append(uuidLists[i], edge.Uuid)
+		}
+	}
+	rrfUUIDs, _ := RRF(uuidLists, DefaultRankConstant, 0)
+
+	strengths := make(map[string]float64, len(rrfUUIDs))
+	for _, uuid := range rrfUUIDs {
+		strengths[uuid] = edgeMap[uuid].StrengthAt(now)
+	}
+	sort.SliceStable(rrfUUIDs, func(i, j int) bool {
+		return strengths[rrfUUIDs[i]] > strengths[rrfUUIDs[j]]
+	})
+
+	edges := make([]*types.Edge, 0, min(limit, len(rrfUUIDs)))
+	scores := make([]float64, 0, min(limit, len(rrfUUIDs)))
+	for _, uuid := range rrfUUIDs {
+		if len(edges) == limit {
+			break
+		}
+		if score := strengths[uuid]; score >= minScore {
+			edges = append(edges, edgeMap[uuid])
+			scores = append(scores, score)
+		}
+	}
+
+	return edges, scores, nil
+}
+
 // MMR (Maximal Marginal Relevance) reranking
-func (s *Searcher) mmrRerankNodes(ctx context.Context, queryVector []float32, nodes []*types.Node, lambda float64, minScore float64, limit int) ([]*types.Node, []float64, error) {
+func (s *Searcher) mmrRerankNodes(ctx context.Context, queryVector []float32, nodes []*types.Node, lambda float64, seed int64, minScore float64, limit int) ([]*types.Node, []float64, error) {
 	if len(queryVector) == 0 {
 		return nodes[:min(limit, len(nodes))], make([]float64, min(limit, len(nodes))), nil
 	}
@@ -568,7 +1183,7 @@ func (s *Searcher) mmrRerankNodes(ctx context.Context, queryVector []float32, no
 	}
 
 	// Apply MMR reranking
-	mmrUUIDs, mmrScores := MaximalMarginalRelevance(queryVector, embeddings, lambda, minScore)
+	mmrUUIDs, mmrScores := MaximalMarginalRelevanceWithSeed(queryVector, embeddings, lambda, minScore, seed)
 
 	// Create node map for lookup
 	nodeMap := make(map[string]*types.Node)
@@ -593,7 +1208,7 @@ func (s *Searcher) mmrRerankNodes(ctx context.Context, queryVector []float32, no
 	return resultNodes, resultScores, nil
 }
 
-func (s *Searcher) mmrRerankEdges(ctx context.Context, queryVector []float32, edges []*types.Edge, lambda float64, minScore float64, limit int) ([]*types.Edge, []float64, error) {
+func (s *Searcher) mmrRerankEdges(ctx context.Context, queryVector []float32, edges []*types.Edge, lambda float64, seed int64, minScore float64, limit int) ([]*types.Edge, []float64, error) {
 	if len(queryVector) == 0 {
 		return edges[:min(limit, len(edges))], make([]float64, min(limit, len(edges))), nil
 	}
@@ -614,7 +1229,7 @@ func (s *Searcher) mmrRerankEdges(ctx context.Context, queryVector []float32, ed
 	}
 
 	// Apply MMR reranking
-	mmrUUIDs, mmrScores := MaximalMarginalRelevance(queryVector, embeddings, lambda, minScore)
+	mmrUUIDs, mmrScores := MaximalMarginalRelevanceWithSeed(queryVector, embeddings, lambda, minScore, seed)
 
 	// Create edge map for lookup
 	edgeMap := make(map[string]*types.Edge)
@@ -747,7 +1362,7 @@ Consider semantic relevance, topical alignment, and contextual importance.`
 			llm.NewUserMessage(prompt),
 		}
 
-		response, err := s.llm.Chat(ctx, messages)
+		response, err := s.llm.Chat(usage.WithOperation(ctx, usage.OperationRerank), messages)
 		if err != nil {
 			// On error, assign default scores
 			for j := range batch {
@@ -791,7 +1406,10 @@ Consider semantic relevance, topical alignment, and contextual importance.`
 
 	// Sort by score (descending)
 	sort.Slice(nodeScores, func(i, j int) bool {
-		return nodeScores[i].score > nodeScores[j].score
+		if nodeScores[i].score != nodeScores[j].score {
+			return nodeScores[i].score > nodeScores[j].score
+		}
+		return nodeScores[i].node.Uuid < nodeScores[j].node.Uuid
 	})
 
 	// Filter by minimum score and apply limit
@@ -915,7 +1533,7 @@ Consider semantic relevance, relationship importance, and contextual significanc
 			llm.NewUserMessage(prompt),
 		}
 
-		response, err := s.llm.Chat(ctx, messages)
+		response, err := s.llm.Chat(usage.WithOperation(ctx, usage.OperationRerank), messages)
 		if err != nil {
 			// On error, assign default scores
 			for j := range batch {
@@ -959,7 +1577,10 @@ Consider semantic relevance, relationship importance, and contextual significanc
 
 	// Sort by score (descending)
 	sort.Slice(edgeScores, func(i, j int) bool {
-		return edgeScores[i].score > edgeScores[j].score
+		if edgeScores[i].score != edgeScores[j].score {
+			return edgeScores[i].score > edgeScores[j].score
+		}
+		return edgeScores[i].edge.Uuid < edgeScores[j].edge.Uuid
 	})
 
 	// Filter by minimum score and apply limit
@@ -0,0 +1,148 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// communityDriver is a GraphDriver stub serving GetExistingCommunity from a
+// map of member node UUID to community UUID.
+type communityDriver struct {
+	driver.GraphDriver
+	communityOf map[string]string
+}
+
+func (d *communityDriver) GetExistingCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
+	uuid, ok := d.communityOf[entityUUID]
+	if !ok {
+		return nil, nil
+	}
+	return &types.Node{Uuid: uuid, Type: types.CommunityNodeType}, nil
+}
+
+func TestCommunitiesByMemberOverlap(t *testing.T) {
+	s := &Searcher{driver: &communityDriver{communityOf: map[string]string{
+		"a": "c1",
+		"b": "c2",
+		"c": "c2",
+		"e": "c3",
+	}}}
+	nodes := []*types.Node{
+		{Uuid: "a", Type: types.EntityNodeType},
+		{Uuid: "b", Type: types.EntityNodeType},
+		{Uuid: "c", Type: types.EntityNodeType},
+		{Uuid: "d", Type: types.EntityNodeType},
+		{Uuid: "e", Type: types.EpisodicNodeType},
+	}
+
+	communities, err := s.communitiesByMemberOverlap(context.Background(), nodes, 10)
+	if err != nil {
+		t.Fatalf("communitiesByMemberOverlap() error = %v", err)
+	}
+
+	var uuids []string
+	for _, community := range communities {
+		uuids = append(uuids, community.Uuid)
+	}
+	if want := []string{"c2", "c1"}; !reflect.DeepEqual(uuids, want) {
+		t.Errorf("communities = %v, want %v", uuids, want)
+	}
+}
+
+// bfsDriver is a GraphDriver stub recording the BFS queries it runs.
+type bfsDriver struct {
+	driver.GraphDriver
+	queries []string
+	params  []map[string]interface{}
+}
+
+func (d *bfsDriver) Provider() driver.GraphProvider {
+	return driver.GraphProviderNeo4j
+}
+
+func (d *bfsDriver) SearchNodes(ctx context.Context, query, groupID string, options *driver.SearchOptions) ([]*types.Node, error) {
+	return []*types.Node{{Uuid: "found", Name: "Found"}}, nil
+}
+
+func (d *bfsDriver) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	d.queries = append(d.queries, cypherQuery)
+	d.params = append(d.params, kwargs)
+	return []map[string]interface{}{{"uuid": "neighbor", "group_id": "g", "name": "Neighbor"}}, nil, nil, nil
+}
+
+func TestSearchNodesExpandsFromBFSOrigins(t *testing.T) {
+	d := &bfsDriver{}
+	s := &Searcher{driver: d}
+	fusion, err := (&SearchConfig{}).fusion()
+	if err != nil {
+		t.Fatalf("fusion() error = %v", err)
+	}
+	config := &NodeSearchConfig{
+		SearchMethods:      []SearchMethod{BM25, BreadthFirstSearch},
+		Reranker:           RRFRerankType,
+		MaxDepth:           3,
+		BFSOriginNodeUUIDs: []string{"seed"},
+		BFSMaxDepth:        2,
+		BFSEdgeNames:       []string{"WORKS_AT"},
+	}
+
+	nodes, _, err := s.searchNodes(context.Background(), "query", nil, config, fusion, &SearchFilters{}, "g", "", 10)
+	if err != nil {
+		t.Fatalf("searchNodes() error = %v", err)
+	}
+	if len(nodes) != 2 {
+		t.Errorf("searchNodes() returned %d nodes, want the BM25 and BFS results", len(nodes))
+	}
+
+	if len(d.queries) != 1 {
+		t.Fatalf("ran %d BFS queries, want 1", len(d.queries))
+	}
+	if origins := d.params[0]["bfs_origin_node_uuids"]; !reflect.DeepEqual(origins, []string{"seed"}) {
+		t.Errorf("BFS origins = %v, want the configured seed", origins)
+	}
+	if !reflect.DeepEqual(d.params[0]["bfs_edge_names"], []string{"WORKS_AT"}) {
+		t.Errorf("BFS edge names = %v, want [WORKS_AT]", d.params[0]["bfs_edge_names"])
+	}
+	if !strings.Contains(d.queries[0], "*1..2]") || !strings.Contains(d.queries[0], "r.name IN $bfs_edge_names") {
+		t.Errorf("BFS query does not honor the depth and edge names:\n%s", d.queries[0])
+	}
+}
+
+func TestFilterByCommunityMembers(t *testing.T) {
+	members := map[string]bool{"a": true, "b": true}
+
+	nodes := filterNodesByMembers([]*types.Node{{Uuid: "a"}, {Uuid: "c"}, {Uuid: "b"}}, members)
+	if len(nodes) != 2 || nodes[0].Uuid != "a" || nodes[1].Uuid != "b" {
+		t.Errorf("filterNodesByMembers() = %v, want a and b", nodes)
+	}
+
+	inside := types.NewEntityEdge("inside", "a", "b", "g", "KNOWS", types.EntityEdgeType)
+	outside := types.NewEntityEdge("outside", "a", "c", "g", "KNOWS", types.EntityEdgeType)
+	edges := filterEdgesByMembers([]*types.Edge{inside, outside}, members)
+	if len(edges) != 1 || edges[0].Uuid != "inside" {
+		t.Errorf("filterEdgesByMembers() = %v, want the edge between members", edges)
+	}
+}
+
+func TestFilterEdgesByConfidence(t *testing.T) {
+	confident := types.NewEntityEdge("confident", "a", "b", "g", "WORKS_AT", types.EntityEdgeType)
+	confident.SetConfidence(0.9)
+	doubtful := types.NewEntityEdge("doubtful", "a", "c", "g", "WORKS_AT", types.EntityEdgeType)
+	doubtful.SetConfidence(0.3)
+	unscored := types.NewEntityEdge("unscored", "a", "d", "g", "WORKS_AT", types.EntityEdgeType)
+
+	filtered := filterEdgesByConfidence([]*types.Edge{confident, doubtful, unscored}, 0.5)
+
+	var uuids []string
+	for _, edge := range filtered {
+		uuids = append(uuids, edge.Uuid)
+	}
+	if want := []string{"confident", "unscored"}; !reflect.DeepEqual(uuids, want) {
+		t.Errorf("filterEdgesByConfidence() = %v, want %v", uuids, want)
+	}
+}
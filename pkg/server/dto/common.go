@@ -27,6 +27,22 @@ type FactResult struct {
 	InvalidAt    *time.Time `json:"invalid_at,omitempty"`
 	CreatedAt    time.Time  `json:"created_at"`
 	Score        *float64   `json:"score,omitempty"`
+	// Highlights are the snippets matching the query, when requested
+	Highlights []Highlight `json:"highlights,omitempty"`
+}
+
+// Highlight represents a snippet of a result field matching the query
+type Highlight struct {
+	Field   string      `json:"field"`
+	Snippet string      `json:"snippet"`
+	Offset  int         `json:"offset"`
+	Matches []TextRange `json:"matches"`
+}
+
+// TextRange represents the byte range [start, end) of a match in a snippet
+type TextRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 // ErrorResponse represents an error response
@@ -7,12 +7,17 @@ type SearchQuery struct {
 	Query    string   `json:"query" binding:"required"`
 	GroupIDs []string `json:"group_ids,omitempty"`
 	MaxFacts int      `json:"max_facts,omitempty"`
+	// Cursor is the next_cursor of the previous page
+	Cursor string `json:"cursor,omitempty"`
+	// Highlight returns the snippets of each fact matching the query
+	Highlight bool `json:"highlight,omitempty"`
 }
 
 // SearchResults represents search results
 type SearchResults struct {
-	Facts []FactResult `json:"facts"`
-	Total int          `json:"total"`
+	Facts      []FactResult `json:"facts"`
+	Total      int          `json:"total"`
+	NextCursor string       `json:"next_cursor,omitempty"`
 }
 
 // GetMemoryRequest represents a request to get memory
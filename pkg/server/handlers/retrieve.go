@@ -57,6 +57,8 @@ func (h *RetrieveHandler) Search(c *gin.Context) {
 		MinScore:     0.0,
 		IncludeEdges: true,
 		Rerank:       true,
+		Cursor:       req.Cursor,
+		Highlight:    req.Highlight,
 	}
 
 	// Perform the search using predicato
@@ -89,6 +91,7 @@ func (h *RetrieveHandler) Search(c *gin.Context) {
 		if node.ValidTo != nil {
 			fact.InvalidAt = node.ValidTo
 		}
+		fact.Highlights = highlightsToDTO(searchResults.Highlights[node.Uuid])
 
 		facts = append(facts, fact)
 	}
@@ -108,14 +111,16 @@ func (h *RetrieveHandler) Search(c *gin.Context) {
 		if edge.ValidTo != nil {
 			fact.InvalidAt = edge.ValidTo
 		}
+		fact.Highlights = highlightsToDTO(searchResults.Highlights[edge.Uuid])
 
 		facts = append(facts, fact)
 	}
 
 	// Create response
 	results := dto.SearchResults{
-		Facts: facts,
-		Total: len(facts),
+		Facts:      facts,
+		Total:      len(facts),
+		NextCursor: searchResults.NextCursor,
 	}
 
 	c.JSON(http.StatusOK, results)
@@ -391,3 +396,21 @@ func (h *RetrieveHandler) edgeToFactDescription(edge *types.Edge) string {
 	}
 	return edge.SourceID + " " + string(edge.Type) + " " + edge.TargetID
 }
+
+// highlightsToDTO converts the highlights of a search result
+func highlightsToDTO(highlights []types.Highlight) []dto.Highlight {
+	var result []dto.Highlight
+	for _, highlight := range highlights {
+		matches := make([]dto.TextRange, 0, len(highlight.Matches))
+		for _, match := range highlight.Matches {
+			matches = append(matches, dto.TextRange{Start: match.Start, End: match.End})
+		}
+		result = append(result, dto.Highlight{
+			Field:   highlight.Field,
+			Snippet: highlight.Snippet,
+			Offset:  highlight.Offset,
+			Matches: matches,
+		})
+	}
+	return result
+}
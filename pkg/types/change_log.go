@@ -0,0 +1,81 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+)
+
+// EpisodeChangeLogKey is the metadata key of the EpisodeChangeLog of an
+// episode node.
+const EpisodeChangeLogKey = "change_log"
+
+// EpisodeChangeLog records the entities and entity edges that ingesting an
+// episode created or modified, so that the ingestion can be rolled back.
+type EpisodeChangeLog struct {
+	// CreatedNodes and CreatedEdges are the UUIDs of the entities and entity
+	// edges created by the episode
+	CreatedNodes []string `json:"created_nodes,omitempty"`
+	CreatedEdges []string `json:"created_edges,omitempty"`
+	// ModifiedNodes and ModifiedEdges are the UUIDs of the existing entities
+	// and entity edges the episode was resolved to
+	ModifiedNodes []string `json:"modified_nodes,omitempty"`
+	ModifiedEdges []string `json:"modified_edges,omitempty"`
+	// InvalidatedEdges are the validity windows of the existing entity edges
+	// invalidated by the episode, as they were before
+	InvalidatedEdges []EdgeValidity `json:"invalidated_edges,omitempty"`
+}
+
+// EdgeValidity is the validity window of an entity edge.
+type EdgeValidity struct {
+	Uuid      string     `json:"uuid"`
+	ValidTo   *time.Time `json:"valid_to,omitempty"`
+	InvalidAt *time.Time `json:"invalid_at,omitempty"`
+	ExpiredAt *time.Time `json:"expired_at,omitempty"`
+}
+
+// Validity returns the validity window of the edge.
+func (e *EntityEdge) Validity() EdgeValidity {
+	return EdgeValidity{
+		Uuid:      e.Uuid,
+		ValidTo:   e.ValidTo,
+		InvalidAt: e.InvalidAt,
+		ExpiredAt: e.ExpiredAt,
+	}
+}
+
+// ChangeLog returns the EpisodeChangeLog recorded in the metadata of an
+// episode node, or nil when it has none.
+func (n *Node) ChangeLog() (*EpisodeChangeLog, error) {
+	var data []byte
+	switch value := n.Metadata[EpisodeChangeLogKey].(type) {
+	case nil:
+		return nil, nil
+	case *EpisodeChangeLog:
+		return value, nil
+	case string:
+		data = []byte(value)
+	default:
+		// Metadata read back from the graph holds the decoded JSON
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return nil, fmt.Errorf("failed to marshal change log: %w", err)
+		}
+	}
+
+	var changeLog EpisodeChangeLog
+	if err := json.Unmarshal(data, &changeLog); err != nil {
+		return nil, fmt.Errorf("failed to parse change log of episode %s: %w", n.Uuid, err)
+	}
+	return &changeLog, nil
+}
+
+// SetChangeLog records changeLog in the metadata of an episode node.
+func (n *Node) SetChangeLog(changeLog *EpisodeChangeLog) {
+	n.Metadata = maps.Clone(n.Metadata)
+	if n.Metadata == nil {
+		n.Metadata = make(map[string]interface{})
+	}
+	n.Metadata[EpisodeChangeLogKey] = changeLog
+}
@@ -5,3 +5,48 @@ type Neighbor struct {
 	NodeUUID  string `json:"node_uuid"`
 	EdgeCount int    `json:"edge_count"`
 }
+
+// CommunitySummaryEmbeddingKey is the metadata key of the embedding of the
+// summary of a community node.
+const CommunitySummaryEmbeddingKey = "summary_embedding"
+
+// EpisodeCommunityKey and EpisodeCommunityNameKey are the metadata keys of
+// the UUID and name of the community an episode was tagged with.
+const (
+	EpisodeCommunityKey     = "community_uuid"
+	EpisodeCommunityNameKey = "community_name"
+)
+
+// SummaryEmbedding returns the embedding of the summary of a community node
+// recorded in its metadata, or nil when it has none.
+func (n *Node) SummaryEmbedding() []float32 {
+	switch value := n.Metadata[CommunitySummaryEmbeddingKey].(type) {
+	case []float32:
+		return value
+	case []float64:
+		embedding := make([]float32, len(value))
+		for i, v := range value {
+			embedding[i] = float32(v)
+		}
+		return embedding
+	case []interface{}:
+		// Metadata read back from the graph holds the decoded JSON
+		embedding := make([]float32, 0, len(value))
+		for _, v := range value {
+			f, ok := v.(float64)
+			if !ok {
+				return nil
+			}
+			embedding = append(embedding, float32(f))
+		}
+		return embedding
+	}
+	return nil
+}
+
+// Community returns the UUID of the community an episode node was tagged
+// with, or "" when it has none.
+func (n *Node) Community() string {
+	uuid, _ := n.Metadata[EpisodeCommunityKey].(string)
+	return uuid
+}
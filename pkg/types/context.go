@@ -9,4 +9,7 @@ const (
 	ContextKeyIngestionSource ContextKey = "ingestion_source"
 	ContextKeySystemCall      ContextKey = "system_call"
 	ContextKeyUsage           ContextKey = "usage"
+	ContextKeyOperation       ContextKey = "operation"
+	ContextKeyEpisodeID       ContextKey = "episode_id"
+	ContextKeyGroupID         ContextKey = "group_id"
 )
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"math"
 	"time"
 )
 
@@ -15,6 +17,8 @@ const (
 	GraphProviderFalkorDB GraphProvider = "falkordb"
 	GraphProviderLadybug     GraphProvider = "ladybug"
 	GraphProviderNeptune  GraphProvider = "neptune"
+	GraphProviderArangoDB GraphProvider = "arangodb"
+	GraphProviderSurrealDB GraphProvider = "surrealdb"
 )
 
 // EdgeOperations provides methods for edge-related database operations
@@ -298,6 +302,125 @@ func (e *EntityEdge) GenerateEmbedding(ctx context.Context, embedder interface{}
 	return nil
 }
 
+// EdgeConfidenceKey is the metadata key of the confidence of an edge.
+const EdgeConfidenceKey = "confidence"
+
+// Confidence returns the confidence from 0 to 1 of the LLM that extracted
+// the edge, and whether the edge has one.
+func (e *EntityEdge) Confidence() (float64, bool) {
+	return metadataFloat(e.Metadata[EdgeConfidenceKey])
+}
+
+// metadataFloat converts a number of the metadata, which is a float64 once
+// decoded from JSON.
+func metadataFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case float32:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	case json.Number:
+		value, err := number.Float64()
+		return value, err == nil
+	}
+	return 0, false
+}
+
+// SetConfidence records the confidence of the edge, clamped to [0, 1].
+func (e *EntityEdge) SetConfidence(confidence float64) {
+	confidence = min(max(confidence, 0), 1)
+	e.Metadata = maps.Clone(e.Metadata)
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]interface{})
+	}
+	e.Metadata[EdgeConfidenceKey] = confidence
+}
+
+// EdgeNegatedKey is the metadata key marking an extracted edge as stating
+// that its fact no longer holds.
+const EdgeNegatedKey = "negated"
+
+// Negated reports whether the edge was extracted from a statement that its
+// fact no longer holds, so that it expires the fact rather than adding it.
+func (e *EntityEdge) Negated() bool {
+	negated, _ := e.Metadata[EdgeNegatedKey].(bool)
+	return negated
+}
+
+// SetNegated marks the edge as stating that its fact no longer holds.
+func (e *EntityEdge) SetNegated() {
+	e.Metadata = maps.Clone(e.Metadata)
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]interface{})
+	}
+	e.Metadata[EdgeNegatedKey] = true
+}
+
+// Metadata keys of the reinforcement of an edge: the number of times its
+// fact was extracted again from later episodes, its strength when it was
+// last reinforced, and when that was.
+const (
+	EdgeReinforcementsKey = "reinforcements"
+	EdgeStrengthKey       = "strength"
+	EdgeReinforcedAtKey   = "reinforced_at"
+)
+
+// EdgeStrengthHalfLife is the time over which the strength of an edge that
+// is not reinforced halves.
+const EdgeStrengthHalfLife = 30 * 24 * time.Hour
+
+// Reinforcements returns the number of times the fact of the edge was
+// extracted again from later episodes.
+func (e *EntityEdge) Reinforcements() int {
+	reinforcements, _ := metadataFloat(e.Metadata[EdgeReinforcementsKey])
+	return int(reinforcements)
+}
+
+// ReinforcedAt returns when the edge was last reinforced, or created.
+func (e *EntityEdge) ReinforcedAt() time.Time {
+	switch reinforcedAt := e.Metadata[EdgeReinforcedAtKey].(type) {
+	case time.Time:
+		return reinforcedAt
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, reinforcedAt); err == nil {
+			return t
+		}
+	}
+	return e.CreatedAt
+}
+
+// StrengthAt returns the strength of the edge at t: 1 when created, plus 1
+// on each reinforcement, halving every EdgeStrengthHalfLife since the last.
+func (e *EntityEdge) StrengthAt(t time.Time) float64 {
+	strength, ok := metadataFloat(e.Metadata[EdgeStrengthKey])
+	if !ok {
+		strength = 1
+	}
+	elapsed := max(t.Sub(e.ReinforcedAt()), 0)
+	return strength * math.Exp2(-float64(elapsed)/float64(EdgeStrengthHalfLife))
+}
+
+// Reinforce records that the fact of the edge was extracted again from an
+// episode at t, incrementing its reinforcements and strength.
+func (e *EntityEdge) Reinforce(t time.Time) {
+	if last := e.ReinforcedAt(); t.Before(last) {
+		t = last
+	}
+	strength := e.StrengthAt(t) + 1
+	reinforcements := e.Reinforcements() + 1
+
+	e.Metadata = maps.Clone(e.Metadata)
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]interface{})
+	}
+	e.Metadata[EdgeReinforcementsKey] = reinforcements
+	e.Metadata[EdgeStrengthKey] = strength
+	e.Metadata[EdgeReinforcedAtKey] = t.UTC().Format(time.RFC3339Nano)
+	e.Strength = strength
+}
+
 // NewEntityEdge creates a new EntityEdge with backward compatibility
 func NewEntityEdge(id, sourceID, targetID, groupID, name string, edgeType EdgeType) *EntityEdge {
 	now := time.Now()
@@ -13,6 +13,26 @@ type Role string
 type Message struct {
 	Role    Role   `json:"role"`
 	Content string `json:"content"`
+	// ToolCalls are the tool calls requested by an assistant message
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID is the call a tool message answers
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// Tool describes a function the model may call.
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON schema of the function arguments
+	Parameters any `json:"parameters,omitempty"`
+}
+
+// ToolCall is a request by the model to call a tool.
+type ToolCall struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Arguments is the JSON encoded arguments object
+	Arguments string `json:"arguments"`
 }
 
 // Response represents a chat completion response.
@@ -22,6 +42,8 @@ type Response struct {
 	FinishReason string                 `json:"finish_reason,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
 	Model        string                 `json:"model,omitempty"`
+	// ToolCalls are the tools the model wants called before it answers
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 
 // TokenUsage represents token usage statistics.
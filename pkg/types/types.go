@@ -69,8 +69,27 @@ const (
 	DocumentEpisodeType EpisodeType = "document"
 	// EventEpisodeType for events or actions.
 	EventEpisodeType EpisodeType = "event"
+	// JSONEpisodeType for structured JSON content.
+	JSONEpisodeType EpisodeType = "json"
+	// MessageEpisodeType for chat messages formatted as "speaker: message",
+	// extracted with the message prompt.
+	MessageEpisodeType EpisodeType = "message"
 )
 
+// EpisodeTypeMetadataKey is the Episode metadata key marking the
+// EpisodeType of its content.
+const EpisodeTypeMetadataKey = "episode_type"
+
+// SourceDescriptionMetadataKey is the Episode metadata key describing the
+// source of its content, such as "CRM export", given to the extraction
+// prompts.
+const SourceDescriptionMetadataKey = "source_description"
+
+// PromptVersionMetadataKey is the metadata key of the prompt version that
+// extracted an episode and the entities and relationships it created or
+// updated.
+const PromptVersionMetadataKey = "prompt_version"
+
 // Episode represents a temporal data unit to be processed.
 type Episode struct {
 	ID               string
@@ -84,6 +103,18 @@ type Episode struct {
 	ContentEmbedding []float32
 }
 
+// Type returns the EpisodeType marked in the metadata of the episode, or
+// ConversationEpisodeType when it is unmarked.
+func (e Episode) Type() EpisodeType {
+	if episodeType, ok := e.Metadata[EpisodeTypeMetadataKey].(string); ok && episodeType != "" {
+		return EpisodeType(episodeType)
+	}
+	if episodeType, ok := e.Metadata[EpisodeTypeMetadataKey].(EpisodeType); ok && episodeType != "" {
+		return episodeType
+	}
+	return ConversationEpisodeType
+}
+
 // SearchConfig holds configuration for search operations.
 type SearchConfig struct {
 	// Limit is the maximum number of results to return.
@@ -96,12 +127,62 @@ type SearchConfig struct {
 	IncludeEdges bool
 	// Rerank determines if results should be reranked.
 	Rerank bool
+	// MMRLambda weighs relevance against diversity for the "mmr" reranker,
+	// from 0 (most diverse) to 1 (most relevant). Nil uses 0.5.
+	MMRLambda *float64
+	// MMRSeed orders the results of equal MMR value for the "mmr" reranker;
+	// the same seed always gives the same ranking. Zero orders them by UUID.
+	MMRSeed int64
 	// Filters for constraining search results.
 	Filters *SearchFilters
 	// NodeConfig holds configuration for node search.
 	NodeConfig *NodeSearchConfig
 	// EdgeConfig holds configuration for edge search.
 	EdgeConfig *EdgeSearchConfig
+	// CommunityConfig holds configuration for community search. Communities
+	// are only searched when it is set.
+	CommunityConfig *CommunitySearchConfig
+	// Cursor resumes the search after the page that returned it as
+	// SearchResults.NextCursor. Empty for the first page.
+	Cursor string
+	// CenterNodeUUID is the node the "node_distance" reranker ranks results
+	// by graph distance from, up to CenterNodeDistance hops.
+	CenterNodeUUID string
+	// QueryExpansion is the number of alternative queries an LLM generates to
+	// search together with the query. Zero disables query expansion.
+	QueryExpansion int
+	// AsOf searches the graph as it was believed at that time, keeping only
+	// edges valid then. Zero searches all edges.
+	AsOf time.Time
+	// FusionMethod combines the results of the search methods for the "rrf"
+	// reranker: "rrf" (default) or "linear" for a weighted sum of
+	// normalized scores.
+	FusionMethod string
+	// ScoreNormalization normalizes the scores of each search method for
+	// "linear" fusion: "min_max" (default) or "z_score".
+	ScoreNormalization string
+	// FusionWeights weighs each search method, such as "bm25", in "linear"
+	// fusion. Methods not listed weigh 1.
+	FusionWeights map[string]float64
+	// Highlight returns the snippets of node names and summaries and edge
+	// facts matching the query words in SearchResults.Highlights.
+	Highlight bool
+	// SnippetLength is the maximum length in bytes of a highlight snippet.
+	// Zero uses 200.
+	SnippetLength int
+	// BFSOriginNodeUUIDs are the nodes the "bfs" search method expands from.
+	// Empty expands from the results of the other search methods.
+	BFSOriginNodeUUIDs []string
+	// BFSMaxDepth is the maximum number of hops of the "bfs" search method.
+	// Zero uses CenterNodeDistance.
+	BFSMaxDepth int
+	// BFSEdgeNames are the relation names, such as "WORKS_AT", the "bfs"
+	// search method may traverse. Empty traverses all relations.
+	BFSEdgeNames []string
+	// CanonicalizeDuplicates replaces entities linked by IS_DUPLICATE_OF
+	// edges to the entity they duplicate with that entity in the results,
+	// and points edges at it.
+	CanonicalizeDuplicates bool
 }
 
 // NodeSearchConfig holds configuration for node search operations.
@@ -124,6 +205,16 @@ type EdgeSearchConfig struct {
 	MinScore float64
 }
 
+// CommunitySearchConfig holds configuration for community search operations.
+type CommunitySearchConfig struct {
+	// SearchMethods defines which search methods to use.
+	SearchMethods []string
+	// Reranker defines which reranking method to use.
+	Reranker string
+	// MinScore is the minimum score for results.
+	MinScore float64
+}
+
 // SearchFilters holds filters for search operations.
 type SearchFilters struct {
 	// GroupIDs to include in search.
@@ -132,10 +223,20 @@ type SearchFilters struct {
 	NodeTypes []NodeType
 	// EdgeTypes to include.
 	EdgeTypes []EdgeType
-	// EntityTypes to include.
+	// EntityTypes to include, matched against entity types and node labels.
 	EntityTypes []string
-	// TimeRange for temporal filtering.
+	// EdgeNames are the relation names of edges to include.
+	EdgeNames []string
+	// TimeRange for temporal filtering on creation time; a zero bound is open.
 	TimeRange *TimeRange
+	// ValidAt keeps edges valid at some time in the range; a zero bound is open.
+	ValidAt *TimeRange
+	// MinConfidence drops edges whose confidence is below it. Edges without
+	// a confidence are kept.
+	MinConfidence float64
+	// CommunityUUIDs keeps the nodes that are members of one of these
+	// communities, and the edges between such members.
+	CommunityUUIDs []string
 }
 
 // TimeRange represents a time range for filtering.
@@ -150,10 +251,50 @@ type SearchResults struct {
 	Nodes []*Node
 	// Edges found in the search.
 	Edges []*Edge
+	// Communities found in the search, when SearchConfig.CommunityConfig is set.
+	Communities []*Node
 	// Query used for the search.
 	Query string
 	// Total number of results found (before limit).
 	Total int
+	// NextCursor fetches the next page when passed as SearchConfig.Cursor.
+	// Empty when there are no more results.
+	NextCursor string
+	// Highlights are the snippets of each result matching the query, keyed
+	// by node or edge UUID, when SearchConfig.Highlight is set.
+	Highlights map[string][]Highlight
+}
+
+// Highlight is a snippet of a search result field around the query words it
+// matches.
+type Highlight struct {
+	// Field is the matched field: "name", "summary" or "fact".
+	Field string `json:"field"`
+	// Snippet is the part of the field around the matches.
+	Snippet string `json:"snippet"`
+	// Offset is the byte offset of Snippet in the field.
+	Offset int `json:"offset"`
+	// Matches are the byte ranges of the matched words in Snippet.
+	Matches []TextRange `json:"matches"`
+}
+
+// TextRange is the byte range [Start, End) of a text.
+type TextRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// AnswerResult holds an answer synthesized from the knowledge graph.
+type AnswerResult struct {
+	// Answer to the question, citing nodes and edges as [uuid].
+	Answer string `json:"answer"`
+	// NodeUUIDs are the nodes cited by the answer, in order of first citation.
+	NodeUUIDs []string `json:"node_uuids"`
+	// EdgeUUIDs are the edges cited by the answer, in order of first citation.
+	EdgeUUIDs []string `json:"edge_uuids"`
+	// Nodes and Edges are the subgraph the answer was synthesized from.
+	Nodes []*Node `json:"nodes"`
+	Edges []*Edge `json:"edges"`
 }
 
 // ExtractedEntity represents an entity extracted from content.
@@ -188,6 +329,37 @@ type AddEpisodeResults struct {
 	Communities []*Node `json:"communities"`
 	// CommunityEdges are the edges connecting communities to entities.
 	CommunityEdges []*Edge `json:"community_edges"`
+	// Usage is the LLM usage recorded for the episode ID by the client, including
+	// earlier ingestions of the same episode ID.
+	Usage *LLMUsageSummary `json:"usage,omitempty"`
+}
+
+// LLMUsage aggregates the token usage and estimated cost of LLM requests.
+type LLMUsage struct {
+	// Requests is the number of LLM requests made.
+	Requests int `json:"requests"`
+	// PromptTokens is the number of tokens sent to the model.
+	PromptTokens int `json:"prompt_tokens"`
+	// CompletionTokens is the number of tokens generated by the model.
+	CompletionTokens int `json:"completion_tokens"`
+	// TotalTokens is the sum of prompt and completion tokens.
+	TotalTokens int `json:"total_tokens"`
+	// CostUSD is the estimated cost in US dollars.
+	CostUSD float64 `json:"cost_usd"`
+}
+
+// LLMUsageSummary breaks LLM usage down by episode, group, operation and model.
+type LLMUsageSummary struct {
+	// Total is the usage of all requests.
+	Total LLMUsage `json:"total"`
+	// ByEpisode is keyed by episode ID; requests made outside an episode are omitted.
+	ByEpisode map[string]LLMUsage `json:"by_episode"`
+	// ByGroup is keyed by group ID; requests made outside a group are omitted.
+	ByGroup map[string]LLMUsage `json:"by_group"`
+	// ByOperation is keyed by operation type, such as extract_nodes or dedupe_edges.
+	ByOperation map[string]LLMUsage `json:"by_operation"`
+	// ByModel is keyed by model name.
+	ByModel map[string]LLMUsage `json:"by_model"`
 }
 
 // AddBulkEpisodeResults represents the result of adding multiple episodes to the knowledge graph.
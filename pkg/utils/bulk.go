@@ -170,6 +170,10 @@ type BulkProcessingResult struct {
 type DedupeNodesResult struct {
 	NodesByEpisode map[string][]*types.Node
 	UUIDMap        map[string]string
+	// DuplicatePairs are, per episode, the resolved entities the LLM found to
+	// duplicate another existing entity without merging them, for
+	// IS_DUPLICATE_OF edges
+	DuplicatePairs map[string][]NodePair
 }
 
 // DedupeEdgesResult represents the result of edge deduplication
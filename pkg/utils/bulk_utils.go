@@ -10,6 +10,7 @@ import (
 	"github.com/soundprediction/go-predicato/pkg/driver"
 	"github.com/soundprediction/go-predicato/pkg/embedder"
 	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
 	"github.com/soundprediction/go-predicato/pkg/prompts"
 	"github.com/soundprediction/go-predicato/pkg/types"
 )
@@ -334,12 +335,16 @@ func extractFromSingleEpisode(
 		"previous_episodes":     episodeTuple.PreviousEpisodes,
 	}
 
-	entityMessages, err := clients.Prompts.ExtractNodes().ExtractMessage().Call(promptContext)
+	entityPrompt := clients.Prompts.ExtractNodes().ExtractMessage()
+	if episodeTuple.Episode.Type() == types.JSONEpisodeType {
+		entityPrompt = clients.Prompts.ExtractNodes().ExtractJSON()
+	}
+	entityMessages, err := entityPrompt.Call(promptContext)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create entity extraction prompt: %w", err)
 	}
 
-	entityResponse, err := clients.LLM.Chat(ctx, entityMessages)
+	entityResponse, err := clients.LLM.Chat(usage.WithOperation(ctx, usage.OperationExtractNodes), entityMessages)
 	prompts.LogResponses(logger, *entityResponse)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract entities: %w", err)
@@ -371,12 +376,16 @@ func extractFromSingleEpisode(
 		"edge_types":      edgeTypes,
 	}
 
-	edgeMessages, err := clients.Prompts.ExtractEdges().Edge().Call(edgeContext)
+	edgePrompt := clients.Prompts.ExtractEdges().Edge()
+	if episodeTuple.Episode.Type() == types.JSONEpisodeType {
+		edgePrompt = clients.Prompts.ExtractEdges().EdgeJSON()
+	}
+	edgeMessages, err := edgePrompt.Call(edgeContext)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create edge extraction prompt: %w", err)
 	}
 
-	edgeResponse, err := clients.LLM.Chat(ctx, edgeMessages)
+	edgeResponse, err := clients.LLM.Chat(usage.WithOperation(ctx, usage.OperationExtractEdges), edgeMessages)
 	prompts.LogResponses(logger, *edgeResponse)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract edges: %w \nprompt: %s \nresponse: \n %s", err, edgeMessages[1].Content, edgeResponse.Content)
@@ -430,6 +439,7 @@ func DedupeNodesBulk(
 		return &DedupeNodesResult{
 			NodesByEpisode: make(map[string][]*types.Node),
 			UUIDMap:        make(map[string]string),
+			DuplicatePairs: make(map[string][]NodePair),
 		}, nil
 	}
 
@@ -437,7 +447,7 @@ func DedupeNodesBulk(
 	type firstPassResult struct {
 		resolvedNodes  []*types.Node
 		uuidMap        map[string]string
-		duplicatePairs interface{} // Can be []NodePair from maintenance or any compatible type
+		duplicatePairs interface{} // []NodePair, or any type when duplicates are not tracked
 	}
 
 	firstPassResults := make([]firstPassResult, len(extractedNodesByEpisode))
@@ -634,9 +644,43 @@ func DedupeNodesBulk(
 		nodesByEpisode[resolution.episodeUUID] = dedupedNodes
 	}
 
+	// Map the duplicate pairs of pass 1 to the canonical nodes
+	nodesByUUID := make(map[string]*types.Node, len(canonicalNodes))
+	for _, resolution := range episodeResolutions {
+		for _, node := range resolution.resolvedNodes {
+			nodesByUUID[node.Uuid] = node
+		}
+	}
+	for uuid, node := range canonicalNodes {
+		nodesByUUID[uuid] = node
+	}
+	canonicalNode := func(node *types.Node) *types.Node {
+		uuid := node.Uuid
+		if mapped, ok := compressedMap[uuid]; ok {
+			uuid = mapped
+		}
+		if canonical, ok := nodesByUUID[uuid]; ok {
+			return canonical
+		}
+		return node
+	}
+	duplicatePairs := make(map[string][]NodePair)
+	for i, result := range firstPassResults {
+		pairs, _ := result.duplicatePairs.([]NodePair)
+		for _, pair := range pairs {
+			source := canonicalNode(pair.Source)
+			target := canonicalNode(pair.Target)
+			if source.Uuid != target.Uuid {
+				episodeUUID := episodeResolutions[i].episodeUUID
+				duplicatePairs[episodeUUID] = append(duplicatePairs[episodeUUID], NodePair{Source: source, Target: target})
+			}
+		}
+	}
+
 	return &DedupeNodesResult{
 		NodesByEpisode: nodesByEpisode,
 		UUIDMap:        compressedMap,
+		DuplicatePairs: duplicatePairs,
 	}, nil
 }
 
@@ -650,6 +694,8 @@ func DedupeEdgesBulk(
 	embedder embedder.Client,
 	logger *slog.Logger,
 ) (*DedupeEdgesResult, error) {
+	ctx = usage.WithOperation(ctx, usage.OperationDedupeEdges)
+
 	if len(extractedEdges) == 0 {
 		return &DedupeEdgesResult{
 			EdgesByEpisode: make(map[string][]*types.Edge),
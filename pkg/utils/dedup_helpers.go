@@ -20,6 +20,8 @@ const (
 	FuzzyJaccardThreshold = 0.9
 	MinHashPermutations   = 32
 	MinHashBandSize       = 4
+	// JaroWinklerThreshold is the Jaro-Winkler similarity of near-exact names
+	JaroWinklerThreshold = 0.95
 )
 
 var (
@@ -201,6 +203,74 @@ func JaccardSimilarity(a, b []string) float64 {
 	return float64(intersection) / float64(union)
 }
 
+// JaroWinklerSimilarity returns the Jaro-Winkler similarity from 0 to 1 of
+// two strings, compared rune by rune.
+func JaroWinklerSimilarity(a, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 && len(s2) == 0 {
+		return 1.0
+	}
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0.0
+	}
+
+	// Runes match when equal and within the match window of each other
+	window := max(max(len(s1), len(s2))/2-1, 0)
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	matches := 0
+	for i := range s1 {
+		for j := max(0, i-window); j < min(len(s2), i+window+1); j++ {
+			if !matched2[j] && s1[i] == s2[j] {
+				matched1[i], matched2[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0.0
+	}
+
+	// Count the matched runes out of order
+	transpositions := 0
+	j := 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[j] {
+			j++
+		}
+		if s1[i] != s2[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+
+	// Boost strings sharing a prefix of up to four runes
+	prefix := 0
+	for prefix < min(4, min(len(s1), len(s2))) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// NearExactNames reports whether two entity names are equal once normalized,
+// or specific enough and of Jaro-Winkler similarity JaroWinklerThreshold or
+// more once normalized for fuzzy matching.
+func NearExactNames(a, b string) bool {
+	if NormalizeStringExact(a) == NormalizeStringExact(b) {
+		return true
+	}
+	fuzzyA, fuzzyB := normalizeNameForFuzzy(a), normalizeNameForFuzzy(b)
+	return HasHighEntropy(fuzzyA) && HasHighEntropy(fuzzyB) &&
+		JaroWinklerSimilarity(fuzzyA, fuzzyB) >= JaroWinklerThreshold
+}
+
 // NodePair represents a pair of duplicate nodes
 type NodePair struct {
 	Source *types.Node
@@ -235,25 +305,30 @@ func BuildCandidateIndexes(existingNodes []*types.Node) *DedupCandidateIndexes {
 	}
 
 	for _, candidate := range existingNodes {
-		normalized := NormalizeStringExact(candidate.Name)
-		indexes.NormalizedExisting[normalized] = append(indexes.NormalizedExisting[normalized], candidate)
-		indexes.NodesByUUID[candidate.Uuid] = candidate
-
-		shingles := CachedShingles(normalizeNameForFuzzy(candidate.Name))
-		indexes.ShinglesByCandidate[candidate.Uuid] = shingles
-
-		signature := MinHashSignature(shingles)
-		bands := LSHBands(signature)
-		for bandIndex, band := range bands {
-			// Create a key for this band
-			bandKey := fmt.Sprintf("%d:%v", bandIndex, band)
-			indexes.LSHBuckets[bandKey] = append(indexes.LSHBuckets[bandKey], candidate.Uuid)
-		}
+		indexes.add(candidate)
 	}
 
 	return indexes
 }
 
+// add indexes candidate by its exact and fuzzy normalized name
+func (indexes *DedupCandidateIndexes) add(candidate *types.Node) {
+	normalized := NormalizeStringExact(candidate.Name)
+	indexes.NormalizedExisting[normalized] = append(indexes.NormalizedExisting[normalized], candidate)
+	indexes.NodesByUUID[candidate.Uuid] = candidate
+
+	shingles := CachedShingles(normalizeNameForFuzzy(candidate.Name))
+	indexes.ShinglesByCandidate[candidate.Uuid] = shingles
+
+	signature := MinHashSignature(shingles)
+	bands := LSHBands(signature)
+	for bandIndex, band := range bands {
+		// Create a key for this band
+		bandKey := fmt.Sprintf("%d:%v", bandIndex, band)
+		indexes.LSHBuckets[bandKey] = append(indexes.LSHBuckets[bandKey], candidate.Uuid)
+	}
+}
+
 // ResolveWithSimilarity attempts deterministic resolution using exact name hits and fuzzy MinHash comparisons
 func ResolveWithSimilarity(
 	extractedNodes []*types.Node,
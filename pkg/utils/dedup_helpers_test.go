@@ -0,0 +1,25 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJaroWinklerSimilarity(t *testing.T) {
+	assert.InDelta(t, 0.961, utils.JaroWinklerSimilarity("martha", "marhta"), 0.001)
+	assert.InDelta(t, 0.840, utils.JaroWinklerSimilarity("dwayne", "duane"), 0.001)
+	assert.InDelta(t, 0.813, utils.JaroWinklerSimilarity("dixon", "dicksonx"), 0.001)
+	assert.Equal(t, 1.0, utils.JaroWinklerSimilarity("", ""))
+	assert.Equal(t, 0.0, utils.JaroWinklerSimilarity("abc", ""))
+	assert.Equal(t, 0.0, utils.JaroWinklerSimilarity("abc", "xyz"))
+}
+
+func TestNearExactNames(t *testing.T) {
+	assert.True(t, utils.NearExactNames("Acme  Corp", "acme corp"))
+	assert.True(t, utils.NearExactNames("Tibialis Anterior", "Tibialis Anterior."))
+	assert.True(t, utils.NearExactNames("Jonathan Smith", "Jonathon Smith"))
+	assert.False(t, utils.NearExactNames("Alice", "Alicia"), "short names need more than similarity")
+	assert.False(t, utils.NearExactNames("Acme Corporation", "Apex Corporation"))
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// Statuses of the episodes of the ingestion queue.
+const (
+	StagedStatusPending  = "pending"
+	StagedStatusIngested = "ingested"
+	StagedStatusFailed   = "failed"
+)
+
+// StagedEpisode is the graph write of an episode processed with deferred
+// graph ingestion, queued in DuckDB until it is replayed into the graph.
+type StagedEpisode struct {
+	Episode *types.Node `json:"episode"`
+	// Source is the source of the episode, linked to it on replay by an edge
+	// with SourceEdgeUUID so that replays are idempotent
+	Source         string        `json:"source,omitempty"`
+	SourceEdgeUUID string        `json:"source_edge_uuid,omitempty"`
+	EpisodicEdges  []*types.Edge `json:"episodic_edges,omitempty"`
+	Nodes          []*types.Node `json:"nodes,omitempty"`
+	Edges          []*types.Edge `json:"edges,omitempty"`
+
+	// Attempts is the number of failed replays of the episode
+	Attempts  int    `json:"-"`
+	LastError string `json:"-"`
+}
+
+// createQueueTable creates the ingestion queue table
+func (w *DuckDBWriter) createQueueTable(ctx context.Context) error {
+	_, err := w.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS ingestion_queue (
+			episode_id VARCHAR PRIMARY KEY,
+			group_id VARCHAR,
+			payload VARCHAR,
+			status VARCHAR,
+			attempts INTEGER,
+			last_error VARCHAR,
+			staged_at TIMESTAMP,
+			updated_at TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion_queue table: %w", err)
+	}
+	return nil
+}
+
+// StageEpisode writes the nodes and edges of an episode to their tables and
+// queues the episode for replay. Staging an episode again replaces it and
+// resets its attempts.
+func (w *DuckDBWriter) StageEpisode(ctx context.Context, staged *StagedEpisode) error {
+	episodeID := staged.Episode.Uuid
+	if err := w.WriteEpisode(ctx, staged.Episode); err != nil {
+		return err
+	}
+	if err := w.WriteEntityNodes(ctx, staged.Nodes, episodeID); err != nil {
+		return err
+	}
+	if err := w.WriteEntityEdges(ctx, staged.Edges, episodeID); err != nil {
+		return err
+	}
+	if err := w.WriteEpisodicEdges(ctx, staged.EpisodicEdges, episodeID); err != nil {
+		return err
+	}
+
+	// Queue the episode last, so that it is only replayed once fully staged
+	payload, err := json.Marshal(staged)
+	if err != nil {
+		return fmt.Errorf("failed to marshal staged episode: %w", err)
+	}
+	now := time.Now()
+	_, err = w.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO ingestion_queue (
+			episode_id, group_id, payload, status, attempts, last_error, staged_at, updated_at
+		) VALUES (?, ?, ?, ?, 0, NULL, ?, ?)
+	`, episodeID, staged.Episode.GroupID, string(payload), StagedStatusPending, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to queue episode %s: %w", episodeID, err)
+	}
+	return nil
+}
+
+// PendingEpisodes returns up to limit queued episodes not yet replayed, in
+// staging order.
+func (w *DuckDBWriter) PendingEpisodes(ctx context.Context, limit int) ([]*StagedEpisode, error) {
+	// The payload is cast since queues created with a JSON column scan as maps
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT payload::VARCHAR, attempts, last_error
+		FROM ingestion_queue
+		WHERE status = ?
+		ORDER BY staged_at, episode_id
+		LIMIT ?
+	`, StagedStatusPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ingestion queue: %w", err)
+	}
+	defer rows.Close()
+
+	var staged []*StagedEpisode
+	for rows.Next() {
+		var payload string
+		var attempts int
+		var lastError sql.NullString
+		if err := rows.Scan(&payload, &attempts, &lastError); err != nil {
+			return nil, fmt.Errorf("failed to scan ingestion queue: %w", err)
+		}
+		episode := &StagedEpisode{}
+		if err := json.Unmarshal([]byte(payload), episode); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal staged episode: %w", err)
+		}
+		episode.Attempts = attempts
+		episode.LastError = lastError.String
+		staged = append(staged, episode)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ingestion queue: %w", err)
+	}
+	return staged, nil
+}
+
+// MarkIngested records that a queued episode was replayed into the graph.
+func (w *DuckDBWriter) MarkIngested(ctx context.Context, episodeID string) error {
+	_, err := w.db.ExecContext(ctx, `
+		UPDATE ingestion_queue SET status = ?, last_error = NULL, updated_at = ?
+		WHERE episode_id = ?
+	`, StagedStatusIngested, time.Now(), episodeID)
+	if err != nil {
+		return fmt.Errorf("failed to mark episode %s ingested: %w", episodeID, err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed replay of a queued episode. The episode is no
+// longer pending once it has failed maxAttempts times.
+func (w *DuckDBWriter) MarkFailed(ctx context.Context, episodeID string, cause error, maxAttempts int) error {
+	_, err := w.db.ExecContext(ctx, `
+		UPDATE ingestion_queue
+		SET attempts = attempts + 1,
+			last_error = ?,
+			status = CASE WHEN attempts + 1 >= ? THEN ? ELSE ? END,
+			updated_at = ?
+		WHERE episode_id = ?
+	`, cause.Error(), maxAttempts, StagedStatusFailed, StagedStatusPending, time.Now(), episodeID)
+	if err != nil {
+		return fmt.Errorf("failed to mark episode %s failed: %w", episodeID, err)
+	}
+	return nil
+}
+
+// RetryFailed queues the episodes that failed too many times again, and
+// returns their number.
+func (w *DuckDBWriter) RetryFailed(ctx context.Context) (int64, error) {
+	result, err := w.db.ExecContext(ctx, `
+		UPDATE ingestion_queue SET status = ?, attempts = 0, updated_at = ?
+		WHERE status = ?
+	`, StagedStatusPending, time.Now(), StagedStatusFailed)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue failed episodes: %w", err)
+	}
+	return result.RowsAffected()
+}
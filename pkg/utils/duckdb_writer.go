@@ -128,7 +128,7 @@ func (w *DuckDBWriter) createTables(ctx context.Context) error {
 		return fmt.Errorf("failed to create episodic_edges table: %w", err)
 	}
 
-	return nil
+	return w.createQueueTable(ctx)
 }
 
 // WriteEpisode writes an episode node to DuckDB
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -133,3 +134,62 @@ func TestDuckDBWriter(t *testing.T) {
 
 	t.Logf("Successfully wrote and verified data in DuckDB: %s", tmpFile)
 }
+
+func TestDuckDBIngestionQueue(t *testing.T) {
+	tmpFile := "./test_queue.duckdb"
+	defer os.Remove(tmpFile)
+
+	writer, err := NewDuckDBWriter(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create DuckDB writer: %v", err)
+	}
+	defer writer.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	for _, id := range []string{"episode-1", "episode-2"} {
+		staged := &StagedEpisode{
+			Episode: &types.Node{
+				Uuid:      id,
+				Name:      "Test Episode",
+				Type:      types.EpisodicNodeType,
+				GroupID:   "test-group",
+				Content:   "This is test content",
+				CreatedAt: now,
+			},
+			Nodes: []*types.Node{{Uuid: "node-" + id, Name: "Alice", Type: types.EntityNodeType, GroupID: "test-group"}},
+		}
+		if err := writer.StageEpisode(ctx, staged); err != nil {
+			t.Fatalf("Failed to stage episode: %v", err)
+		}
+	}
+
+	pending, err := writer.PendingEpisodes(ctx, 10)
+	if err != nil {
+		t.Fatalf("Failed to read pending episodes: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Episode.Uuid != "episode-1" || len(pending[0].Nodes) != 1 {
+		t.Fatalf("Expected both staged episodes with their nodes, got %+v", pending)
+	}
+
+	if err := writer.MarkIngested(ctx, "episode-1"); err != nil {
+		t.Fatalf("Failed to mark episode ingested: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := writer.MarkFailed(ctx, "episode-2", fmt.Errorf("graph unavailable"), 2); err != nil {
+			t.Fatalf("Failed to mark episode failed: %v", err)
+		}
+	}
+	if pending, err = writer.PendingEpisodes(ctx, 10); err != nil || len(pending) != 0 {
+		t.Fatalf("Expected no pending episodes, got %d (err %v)", len(pending), err)
+	}
+
+	requeued, err := writer.RetryFailed(ctx)
+	if err != nil || requeued != 1 {
+		t.Fatalf("Expected 1 requeued episode, got %d (err %v)", requeued, err)
+	}
+	pending, err = writer.PendingEpisodes(ctx, 10)
+	if err != nil || len(pending) != 1 || pending[0].Attempts != 0 || pending[0].LastError != "graph unavailable" {
+		t.Fatalf("Expected episode-2 pending again, got %+v (err %v)", pending, err)
+	}
+}
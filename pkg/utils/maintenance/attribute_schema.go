@@ -0,0 +1,264 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Attribute types of an attributeField, as described to the LLM.
+const (
+	attributeTypeString   = "string"
+	attributeTypeInteger  = "integer"
+	attributeTypeNumber   = "number"
+	attributeTypeBoolean  = "boolean"
+	attributeTypeDatetime = "datetime"
+	attributeTypeArray    = "array"
+)
+
+// attributeField describes an attribute of an entity type, read from the tags
+// of a struct field: json names the attribute, description documents it,
+// required:"true" makes it mandatory and enum:"a,b" restricts its values.
+type attributeField struct {
+	Name        string
+	Type        string
+	Items       string // element type of arrays
+	Description string
+	Required    bool
+	Enum        []string
+}
+
+// attributeSchema is the list of attributes of an entity type.
+type attributeSchema []attributeField
+
+// entityAttributeSchema returns the attribute schema of an entity type
+// definition, or nil when it is not a struct.
+func entityAttributeSchema(entityType interface{}) attributeSchema {
+	t := reflect.TypeOf(entityType)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var schema attributeSchema
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		attribute := attributeField{
+			Name:        name,
+			Type:        attributeType(field.Type),
+			Description: field.Tag.Get("description"),
+			Required:    field.Tag.Get("required") == "true",
+		}
+		if attribute.Type == attributeTypeArray {
+			attribute.Items = attributeType(field.Type.Elem())
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			for _, value := range strings.Split(enum, ",") {
+				attribute.Enum = append(attribute.Enum, strings.TrimSpace(value))
+			}
+		}
+		schema = append(schema, attribute)
+	}
+	return schema
+}
+
+// attributeType returns the attribute type of a Go type. Types without a
+// JSON scalar counterpart are described as strings.
+func attributeType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return attributeTypeDatetime
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return attributeTypeBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return attributeTypeInteger
+	case reflect.Float32, reflect.Float64:
+		return attributeTypeNumber
+	case reflect.Slice, reflect.Array:
+		return attributeTypeArray
+	default:
+		return attributeTypeString
+	}
+}
+
+// describe returns a one-line description of the schema for prompts.
+func (s attributeSchema) describe() string {
+	parts := make([]string, 0, len(s))
+	for _, field := range s {
+		kind := field.Type
+		if field.Type == attributeTypeArray {
+			kind = fmt.Sprintf("array of %s", field.Items)
+		}
+		if field.Required {
+			kind += ", required"
+		}
+		if len(field.Enum) > 0 {
+			kind += ", one of " + strings.Join(field.Enum, "|")
+		}
+		part := fmt.Sprintf("%s (%s)", field.Name, kind)
+		if field.Description != "" {
+			part += ": " + field.Description
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// validate parses the JSON object of extracted attributes and checks it
+// against the schema. It returns the valid attributes converted to their
+// types, skipping unknown and empty ones, and a description of each
+// violation.
+func (s attributeSchema) validate(raw string) (map[string]interface{}, []string) {
+	values := make(map[string]interface{})
+	raw = strings.TrimSpace(raw)
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &values); err != nil {
+			values = make(map[string]interface{})
+			violations := []string{fmt.Sprintf("attributes must be a JSON object: %v", err)}
+			for _, field := range s {
+				if field.Required {
+					violations = append(violations, fmt.Sprintf("%s is required", field.Name))
+				}
+			}
+			return nil, violations
+		}
+	}
+
+	attributes := make(map[string]interface{})
+	var violations []string
+	for _, field := range s {
+		value, ok := values[field.Name]
+		if !ok || value == nil || value == "" {
+			if field.Required {
+				violations = append(violations, fmt.Sprintf("%s is required", field.Name))
+			}
+			continue
+		}
+
+		typed, err := field.convert(value)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("%s %v", field.Name, err))
+			continue
+		}
+		attributes[field.Name] = typed
+	}
+	return attributes, violations
+}
+
+// convert converts an attribute value decoded from JSON to the type of the
+// field, accepting numbers and booleans written as strings.
+func (f attributeField) convert(value interface{}) (interface{}, error) {
+	if f.Type != attributeTypeArray {
+		return convertAttributeValue(f.Type, f.Enum, value)
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be an array of %s", f.Items)
+	}
+	converted := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		typed, err := convertAttributeValue(f.Items, f.Enum, item)
+		if err != nil {
+			return nil, fmt.Errorf("items %w", err)
+		}
+		converted = append(converted, typed)
+	}
+	return converted, nil
+}
+
+// convertAttributeValue converts a scalar value to an attribute type and
+// checks it against enum.
+func convertAttributeValue(kind string, enum []string, value interface{}) (interface{}, error) {
+	var typed interface{}
+	switch kind {
+	case attributeTypeInteger:
+		switch v := value.(type) {
+		case float64:
+			if v != math.Trunc(v) {
+				return nil, fmt.Errorf("must be an integer, got %v", v)
+			}
+			typed = int64(v)
+		case string:
+			n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("must be an integer, got %q", v)
+			}
+			typed = n
+		default:
+			return nil, fmt.Errorf("must be an integer, got %v", value)
+		}
+	case attributeTypeNumber:
+		switch v := value.(type) {
+		case float64:
+			typed = v
+		case string:
+			n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("must be a number, got %q", v)
+			}
+			typed = n
+		default:
+			return nil, fmt.Errorf("must be a number, got %v", value)
+		}
+	case attributeTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			typed = v
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("must be a boolean, got %q", v)
+			}
+			typed = b
+		default:
+			return nil, fmt.Errorf("must be a boolean, got %v", value)
+		}
+	case attributeTypeDatetime:
+		v, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be an ISO 8601 date or datetime, got %v", value)
+		}
+		at, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			if at, err = time.Parse(time.DateOnly, v); err != nil {
+				return nil, fmt.Errorf("must be an ISO 8601 date or datetime, got %q", v)
+			}
+		}
+		typed = at.UTC().Format(time.RFC3339)
+	default:
+		v, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a string, got %v", value)
+		}
+		typed = v
+	}
+
+	if len(enum) > 0 && !slices.Contains(enum, fmt.Sprint(typed)) {
+		return nil, fmt.Errorf("must be one of %s, got %v", strings.Join(enum, "|"), typed)
+	}
+	return typed, nil
+}
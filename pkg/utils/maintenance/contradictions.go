@@ -0,0 +1,96 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// Metadata keys recorded on edges invalidated by a contradicting edge.
+const (
+	invalidatedByKey        = "invalidated_by"
+	invalidatedByEpisodeKey = "invalidated_by_episode"
+	invalidatedAtKey        = "invalidated_at"
+)
+
+// invalidationReasonKey is the metadata key of the reason an edge was
+// retracted by RetractEdge.
+const invalidationReasonKey = "invalidation_reason"
+
+// EdgeContradiction is an edge the resolver invalidated because a newer edge
+// contradicted it.
+type EdgeContradiction struct {
+	// Invalidated is the edge that was expired
+	Invalidated *types.Edge
+	// InvalidatedBy is the contradicting edge, nil if it no longer exists
+	InvalidatedBy *types.Edge
+	// InvalidatedAt is when the resolver invalidated the edge
+	InvalidatedAt time.Time
+	// ValidTo is the end of validity given to the invalidated edge
+	ValidTo *time.Time
+	// EpisodeUUID is the episode whose ingestion invalidated the edge
+	EpisodeUUID string
+}
+
+// DetectContradictions returns the edges of a group invalidated by
+// contradicting edges at or after since, oldest first, with the contradicting
+// edges and the episodes responsible, to audit why facts expired. Only
+// invalidations recorded by ResolveExtractedEdges are reported, for the
+// Neo4j, Memgraph and Ladybug drivers.
+func (eo *EdgeOperations) DetectContradictions(ctx context.Context, groupID string, since time.Time) ([]*EdgeContradiction, error) {
+	metadataByUUID, err := driver.ListInvalidatedEdgeMetadata(ctx, eo.driver, groupID, invalidatedByKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var contradictions []*EdgeContradiction
+	invalidatedBy := make(map[*EdgeContradiction]string)
+	var uuids []string
+	for uuid, metadata := range metadataByUUID {
+		contradiction := &EdgeContradiction{Invalidated: &types.Edge{BaseEdge: types.BaseEdge{Uuid: uuid, GroupID: groupID}}}
+		if at, ok := metadata[invalidatedAtKey].(string); ok {
+			contradiction.InvalidatedAt, _ = time.Parse(time.RFC3339, at)
+		}
+		if contradiction.InvalidatedAt.Before(since) {
+			continue
+		}
+		contradiction.EpisodeUUID, _ = metadata[invalidatedByEpisodeKey].(string)
+		by, _ := metadata[invalidatedByKey].(string)
+
+		contradictions = append(contradictions, contradiction)
+		invalidatedBy[contradiction] = by
+		uuids = append(uuids, uuid, by)
+	}
+	if len(contradictions) == 0 {
+		return []*EdgeContradiction{}, nil
+	}
+
+	edges, err := eo.driver.GetEdges(ctx, uuids, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contradicting edges: %w", err)
+	}
+	edgesByUUID := make(map[string]*types.Edge, len(edges))
+	for _, edge := range edges {
+		edgesByUUID[edge.Uuid] = edge
+	}
+
+	for _, contradiction := range contradictions {
+		if edge, ok := edgesByUUID[contradiction.Invalidated.Uuid]; ok {
+			contradiction.Invalidated = edge
+			contradiction.ValidTo = edge.ValidTo
+		}
+		contradiction.InvalidatedBy = edgesByUUID[invalidatedBy[contradiction]]
+	}
+
+	sort.Slice(contradictions, func(i, j int) bool {
+		if !contradictions[i].InvalidatedAt.Equal(contradictions[j].InvalidatedAt) {
+			return contradictions[i].InvalidatedAt.Before(contradictions[j].InvalidatedAt)
+		}
+		return contradictions[i].Invalidated.Uuid < contradictions[j].Invalidated.Uuid
+	})
+	return contradictions, nil
+}
@@ -5,18 +5,30 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
-	"reflect"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/soundprediction/go-predicato/pkg/driver"
 	"github.com/soundprediction/go-predicato/pkg/embedder"
 	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
 	"github.com/soundprediction/go-predicato/pkg/prompts"
 	"github.com/soundprediction/go-predicato/pkg/types"
 	"github.com/soundprediction/go-predicato/pkg/utils"
 )
 
+const (
+	// DefaultEdgeDedupeCandidateLimit is the number of existing edges most
+	// similar to an extracted edge that are sent to the LLM for deduplication,
+	// for each of the duplicate and invalidation candidate lists
+	DefaultEdgeDedupeCandidateLimit = 20
+	// DefaultEdgeDedupeBatchSize is the number of candidates of each list in a
+	// single edge dedupe prompt
+	DefaultEdgeDedupeBatchSize = 10
+)
+
 // EdgeOperations provides edge-related maintenance operations
 type EdgeOperations struct {
 	driver   driver.GraphDriver
@@ -24,6 +36,14 @@ type EdgeOperations struct {
 	embedder embedder.Client
 	prompts  prompts.Library
 	logger   *slog.Logger
+	// maxPromptTokens bounds the estimated size of extraction prompts, 0 for no limit
+	maxPromptTokens int
+	// invalidationPolicies are the invalidation policies by edge name
+	invalidationPolicies map[string]InvalidationPolicy
+	// dedupeCandidateLimit and dedupeBatchSize bound the edge dedupe prompts,
+	// 0 for the defaults
+	dedupeCandidateLimit int
+	dedupeBatchSize      int
 }
 
 // NewEdgeOperations creates a new EdgeOperations instance
@@ -42,6 +62,23 @@ func (eo *EdgeOperations) SetLogger(logger *slog.Logger) {
 	eo.logger = logger
 }
 
+// SetMaxPromptTokens bounds the estimated size of extraction prompts, see
+// llm.PromptTokenLimit. Previous episodes are dropped from prompts over the
+// limit, oldest first, and if that is not enough the edges are extracted from
+// overlapping windows of the entity list. 0 disables the limit.
+func (eo *EdgeOperations) SetMaxPromptTokens(maxTokens int) {
+	eo.maxPromptTokens = maxTokens
+}
+
+// SetDedupeLimits sets the number of existing edges most similar to an
+// extracted edge considered when resolving it, and the number of them sent in
+// each dedupe prompt. 0 keeps DefaultEdgeDedupeCandidateLimit and
+// DefaultEdgeDedupeBatchSize.
+func (eo *EdgeOperations) SetDedupeLimits(candidateLimit, batchSize int) {
+	eo.dedupeCandidateLimit = candidateLimit
+	eo.dedupeBatchSize = batchSize
+}
+
 // BuildEpisodicEdges creates episodic edges from entity nodes to an episode
 func (eo *EdgeOperations) BuildEpisodicEdges(ctx context.Context, entityNodes []*types.Node, episodeUUID string, createdAt time.Time) ([]*types.Edge, error) {
 	if len(entityNodes) == 0 {
@@ -84,13 +121,14 @@ func (eo *EdgeOperations) BuildDuplicateOfEdges(ctx context.Context, episode *ty
 			pair.Source.Uuid,
 			pair.Target.Uuid,
 			episode.GroupID,
-			"IS_DUPLICATE_OF",
+			driver.DuplicateOfEdgeName,
 			types.EntityEdgeType,
 		)
 		edge.Summary = fact
 		edge.Fact = fact
 		edge.UpdatedAt = createdAt
 		edge.ValidFrom = createdAt
+		edge.Episodes = []string{episode.Uuid}
 		edge.SourceIDs = []string{episode.Uuid}
 
 		duplicateEdges = append(duplicateEdges, edge)
@@ -101,6 +139,8 @@ func (eo *EdgeOperations) BuildDuplicateOfEdges(ctx context.Context, episode *ty
 
 // ExtractEdges extracts relationship edges from episode content using LLM
 func (eo *EdgeOperations) ExtractEdges(ctx context.Context, episode *types.Node, nodes []*types.Node, previousEpisodes []*types.Node, edgeTypeMap map[string][][]string, edgeTypes map[string]interface{}, groupID string) ([]*types.Edge, error) {
+	ctx = usage.WithOperation(ctx, usage.OperationExtractEdges)
+
 	start := time.Now()
 
 	if len(nodes) == 0 {
@@ -119,6 +159,35 @@ func (eo *EdgeOperations) ExtractEdges(ctx context.Context, episode *types.Node,
 		}
 	}
 
+	previousEpisodeContents := make([]string, len(previousEpisodes))
+	for i, ep := range previousEpisodes {
+		previousEpisodeContents[i] = ep.Summary
+	}
+
+	edges, err := eo.extractEdgesForNodes(ctx, episode, nodes, previousEpisodeContents, edgeTypesContext, groupID)
+	if err != nil {
+		return []*types.Edge{}, err
+	}
+
+	// Date the facts from the temporal expressions of the episode
+	temporalOps := NewTemporalOperations(eo.llm, eo.prompts, eo.logger)
+	validAts, invalidAts, err := temporalOps.ExtractEdgesDates(ctx, edges, episode, previousEpisodes)
+	if err != nil {
+		eo.logger.Warn("Failed to extract edge dates, keeping extracted dates", "episode", episode.Uuid, "error", err)
+	} else {
+		for i, edge := range edges {
+			applyEdgeDates(edge, validAts[i], invalidAts[i])
+		}
+	}
+
+	log.Printf("Extracted %d edges in %v", len(edges), time.Since(start))
+	return edges, nil
+}
+
+// extractEdgesForNodes extracts the edges between nodes. When the prompt
+// exceeds the token budget even without previous episodes, the edges are
+// extracted in windows of fewer nodes, see extractEdgesInWindows.
+func (eo *EdgeOperations) extractEdgesForNodes(ctx context.Context, episode *types.Node, nodes []*types.Node, previousEpisodeContents []string, edgeTypesContext []map[string]interface{}, groupID string) ([]*types.Edge, error) {
 	// Prepare context for LLM
 	// Note: Data is passed as slices for TSV formatting in prompts
 	nodeContexts := make([]map[string]interface{}, len(nodes))
@@ -130,11 +199,6 @@ func (eo *EdgeOperations) ExtractEdges(ctx context.Context, episode *types.Node,
 		}
 	}
 
-	previousEpisodeContents := make([]string, len(previousEpisodes))
-	for i, ep := range previousEpisodes {
-		previousEpisodeContents[i] = ep.Summary
-	}
-
 	promptContext := map[string]interface{}{
 		"episode_content":   episode.Content,
 		"nodes":             nodeContexts,
@@ -146,11 +210,23 @@ func (eo *EdgeOperations) ExtractEdges(ctx context.Context, episode *types.Node,
 		"logger":            eo.logger,
 	}
 
-	// Extract edges using LLM
-	messages, err := eo.prompts.ExtractEdges().Edge().Call(promptContext)
+	// Extract edges using LLM, reading JSON episodes by their keys and values
+	prompt := eo.prompts.ExtractEdges().Edge()
+	if episode.EpisodeType == types.JSONEpisodeType {
+		prompt = eo.prompts.ExtractEdges().EdgeJSON()
+	}
+	messages, fits, err := buildPromptWithinBudget(prompt, promptContext, eo.maxPromptTokens, eo.logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create prompt: %w", err)
 	}
+	// Windows of two nodes are as large as the prompt for two nodes
+	if !fits && len(nodes) > 2 {
+		eo.logger.Info("Splitting entities of edge extraction prompt over token budget",
+			"episode", episode.Uuid,
+			"entities", len(nodes),
+			"max_prompt_tokens", eo.maxPromptTokens)
+		return eo.extractEdgesInWindows(ctx, episode, nodes, previousEpisodeContents, edgeTypesContext, groupID)
+	}
 
 	// Create CSV parser function for ExtractedEdge
 	csvParser := func(csvContent string) ([]*prompts.ExtractedEdge, error) {
@@ -178,15 +254,13 @@ func (eo *EdgeOperations) ExtractEdges(ctx context.Context, episode *types.Node,
 				fmt.Printf("\nFailed LLM edge extraction response:\n%v\n\n", badResp.Response)
 			}
 		}
-		return []*types.Edge{}, fmt.Errorf("failed to unmarshal extracted edges: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal extracted edges: %w", err)
 	}
 
 	// Convert to ExtractedEdges struct
 	var extractedEdges prompts.ExtractedEdges
 	extractedEdges.Edges = extractedEdgeSlice
 
-	log.Printf("Extracted %d edges in %v", len(extractedEdges.Edges), time.Since(start))
-
 	if len(extractedEdges.Edges) == 0 {
 		return []*types.Edge{}, nil
 	}
@@ -204,37 +278,9 @@ func (eo *EdgeOperations) ExtractEdges(ctx context.Context, episode *types.Node,
 		sourceNode := nodes[edgeData.SourceID]
 		targetNode := nodes[edgeData.TargetID]
 
-		// Parse temporal information
-		var validAt time.Time
-		var validTo *time.Time
-
-		if edgeData.ValidAt != "" {
-			// Strip any surrounding quotes (can happen with double JSON encoding)
-			cleanValidAt := strings.Trim(edgeData.ValidAt, "\"")
-			if parsed, err := time.Parse(time.RFC3339, strings.ReplaceAll(cleanValidAt, "Z", "+00:00")); err == nil {
-				validAt = parsed.UTC()
-			} else if cleanValidAt == "null" {
-				validAt = episode.ValidFrom
-			} else {
-				log.Printf("Warning: failed to parse valid_at date '%s': %v", cleanValidAt, err)
-				validAt = episode.ValidFrom
-			}
-		} else {
-			validAt = episode.ValidFrom
-		}
-
-		if edgeData.InvalidAt != "" {
-			// Strip any surrounding quotes (can happen with double JSON encoding)
-			cleanInvalidAt := strings.Trim(edgeData.InvalidAt, "\"")
-			if parsed, err := time.Parse(time.RFC3339, strings.ReplaceAll(cleanInvalidAt, "Z", "+00:00")); err == nil {
-				parsedUTC := parsed.UTC()
-				validTo = &parsedUTC
-			} else if cleanInvalidAt == "null" {
-				validTo = nil
-			} else {
-				log.Printf("Warning: failed to parse invalid_at date '%s': %v", cleanInvalidAt, err)
-			}
-		}
+		// Parse temporal information; edges without a valid time are valid from the episode
+		validAt := parseEdgeDate(edgeData.ValidAt)
+		invalidAt := parseEdgeDate(edgeData.InvalidAt)
 
 		edge := types.NewEntityEdge(
 			utils.GenerateUUID(),
@@ -247,9 +293,16 @@ func (eo *EdgeOperations) ExtractEdges(ctx context.Context, episode *types.Node,
 		edge.Summary = edgeData.Summary
 		edge.Fact = edgeData.Fact
 		edge.UpdatedAt = time.Now().UTC()
-		edge.ValidFrom = validAt
-		edge.ValidTo = validTo
+		edge.ValidFrom = episode.ValidFrom
+		applyEdgeDates(edge, validAt, invalidAt)
+		edge.Episodes = []string{episode.Uuid}
 		edge.SourceIDs = []string{episode.Uuid}
+		if edgeData.Confidence != nil {
+			edge.SetConfidence(*edgeData.Confidence)
+		}
+		if edgeData.Negated {
+			edge.SetNegated()
+		}
 
 		edges = append(edges, edge)
 		log.Printf("Created edge: %s from %s to %s", edge.Name, sourceNode.Name, targetNode.Name)
@@ -258,123 +311,96 @@ func (eo *EdgeOperations) ExtractEdges(ctx context.Context, episode *types.Node,
 	return edges, nil
 }
 
-// GetBetweenNodes retrieves edges between two specific nodes using the proper Ladybug query pattern
-func (eo *EdgeOperations) GetBetweenNodes(ctx context.Context, sourceNodeID, targetNodeID string) ([]*types.Edge, error) {
-	query := `
-		MATCH (a:Entity {uuid: $source_uuid})-[:RELATES_TO]->(rel:RelatesToNode_)-[:RELATES_TO]->(b:Entity {uuid: $target_uuid})
-		RETURN rel.uuid AS uuid, rel.name AS name, rel.fact AS fact, rel.group_id AS group_id,
-		       rel.created_at AS created_at, rel.valid_at AS valid_at, rel.invalid_at AS invalid_at,
-		       rel.expired_at AS expired_at, rel.episodes AS episodes, rel.attributes AS attributes,
-		       a.uuid AS source_id, b.uuid AS target_id
-		UNION
-		MATCH (a:Entity {uuid: $target_uuid})-[:RELATES_TO]->(rel:RelatesToNode_)-[:RELATES_TO]->(b:Entity {uuid: $source_uuid})
-		RETURN rel.uuid AS uuid, rel.name AS name, rel.fact AS fact, rel.group_id AS group_id,
-		       rel.created_at AS created_at, rel.valid_at AS valid_at, rel.invalid_at AS invalid_at,
-		       rel.expired_at AS expired_at, rel.episodes AS episodes, rel.attributes AS attributes,
-		       a.uuid AS source_id, b.uuid AS target_id
-	`
-
-	params := map[string]interface{}{
-		"source_uuid": sourceNodeID,
-		"target_uuid": targetNodeID,
-	}
-
-	result, _, _, err := eo.driver.ExecuteQuery(query, params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute GetBetweenNodes query: %w", err)
+// edgeExtractionChunks is the number of chunks nodes are split into when they
+// do not fit in one edge extraction prompt. Each window holds two chunks, so
+// four chunks give windows of half the nodes.
+const edgeExtractionChunks = 4
+
+// extractEdgesInWindows extracts the edges between nodes too many for one
+// prompt. Nodes are split in chunks and the edges are extracted from a window
+// of each pair of chunks, so every pair of nodes shares a prompt. An edge
+// found in several windows is kept from the first window holding both of its
+// nodes.
+func (eo *EdgeOperations) extractEdgesInWindows(ctx context.Context, episode *types.Node, nodes []*types.Node, previousEpisodeContents []string, edgeTypesContext []map[string]interface{}, groupID string) ([]*types.Edge, error) {
+	chunks := edgeExtractionChunks
+	if len(nodes) < chunks {
+		chunks = len(nodes)
+	}
+	chunkOf := make(map[string]int, len(nodes))
+	bounds := make([]int, chunks+1)
+	for c := 0; c <= chunks; c++ {
+		bounds[c] = c * len(nodes) / chunks
+	}
+	for c := 0; c < chunks; c++ {
+		for _, node := range nodes[bounds[c]:bounds[c+1]] {
+			chunkOf[node.Uuid] = c
+		}
 	}
 
-	// Convert result to Edge objects
 	var edges []*types.Edge
-	if result != nil {
-		// Handle different result types based on driver implementation
-		switch records := result.(type) {
-		case []map[string]interface{}:
-			for _, record := range records {
-				edge, err := eo.convertRecordToEdge(record)
-				if err != nil {
-					log.Printf("Warning: failed to convert record to edge: %v", err)
-					continue
+	for i := 0; i < chunks; i++ {
+		for j := i + 1; j < chunks; j++ {
+			window := make([]*types.Node, 0, bounds[i+1]-bounds[i]+bounds[j+1]-bounds[j])
+			window = append(window, nodes[bounds[i]:bounds[i+1]]...)
+			window = append(window, nodes[bounds[j]:bounds[j+1]]...)
+
+			windowEdges, err := eo.extractEdgesForNodes(ctx, episode, window, previousEpisodeContents, edgeTypesContext, groupID)
+			if err != nil {
+				return nil, err
+			}
+			for _, edge := range windowEdges {
+				first, second := firstEdgeWindow(chunkOf[edge.SourceNodeID], chunkOf[edge.TargetNodeID])
+				if first == i && second == j {
+					edges = append(edges, edge)
 				}
-				edges = append(edges, edge)
 			}
-		default:
-			// Try to handle Neo4j/Memgraph []*db.Record using reflection
-			edges = eo.parseNeo4jEdgeRecords(result)
 		}
 	}
-
 	return edges, nil
 }
 
-// convertRecordToEdge converts a database record to an Edge object
-func (eo *EdgeOperations) convertRecordToEdge(record map[string]interface{}) (*types.Edge, error) {
-	edge := &types.Edge{}
-
-	// Extract basic fields
-	if uuid, ok := record["uuid"].(string); ok {
-		edge.Uuid = uuid
-	} else {
-		return nil, fmt.Errorf("missing or invalid uuid field")
-	}
-
-	if name, ok := record["name"].(string); ok {
-		edge.Name = name
-	}
-
-	if fact, ok := record["fact"].(string); ok {
-		edge.Summary = fact
-	}
-
-	if groupID, ok := record["group_id"].(string); ok {
-		edge.GroupID = groupID
-	}
-
-	// Extract source and target IDs
-	if sourceID, ok := record["source_id"].(string); ok {
-		edge.SourceID = sourceID
-	}
-	if targetID, ok := record["target_id"].(string); ok {
-		edge.TargetID = targetID
-	}
-
-	// Extract timestamps
-	if createdAt, ok := record["created_at"].(time.Time); ok {
-		edge.CreatedAt = createdAt
-	}
-	if updatedAt, ok := record["updated_at"].(time.Time); ok {
-		edge.UpdatedAt = updatedAt
-	}
-	if validFrom, ok := record["valid_from"].(time.Time); ok {
-		edge.ValidFrom = validFrom
-	}
-	if validTo, ok := record["valid_to"].(time.Time); ok {
-		edge.ValidTo = &validTo
+// firstEdgeWindow returns the chunks of the first window of
+// extractEdgesInWindows holding nodes of chunks a and b.
+func firstEdgeWindow(a, b int) (int, int) {
+	if a > b {
+		a, b = b, a
 	}
-
-	// Set edge type - assume EntityEdge for relationships from RelatesToNode_
-	edge.Type = types.EntityEdgeType
-
-	// Extract source IDs if present
-	if sourceIDs, ok := record["source_ids"].([]interface{}); ok {
-		strSourceIDs := make([]string, len(sourceIDs))
-		for i, id := range sourceIDs {
-			if strID, ok := id.(string); ok {
-				strSourceIDs[i] = strID
-			}
-		}
-		edge.SourceIDs = strSourceIDs
+	switch {
+	case a != b:
+		return a, b
+	case a == 0:
+		return 0, 1
+	default:
+		return 0, a
 	}
+}
 
-	return edge, nil
+// GetBetweenNodes retrieves the entity edges of groupID between two nodes, in
+// either direction.
+func (eo *EdgeOperations) GetBetweenNodes(ctx context.Context, sourceNodeID, targetNodeID, groupID string) ([]*types.Edge, error) {
+	return driver.EdgesBetween(ctx, eo.driver, groupID, sourceNodeID, targetNodeID)
 }
 
-// ResolveExtractedEdges resolves newly extracted edges with existing ones in the graph
+// ResolveExtractedEdges resolves newly extracted edges with existing ones in the graph.
+// Negated edges are not resolved but expire the existing edges they negate,
+// which are returned with the invalidated edges; the resolved edges follow
+// the order of the other extracted edges.
 func (eo *EdgeOperations) ResolveExtractedEdges(ctx context.Context, extractedEdges []*types.Edge, episode *types.Node, entities []*types.Node, createEmbeddings bool, edgeTypes map[string]interface{}) ([]*types.Edge, []*types.Edge, error) {
+	ctx = usage.WithOperation(ctx, usage.OperationDedupeEdges)
+
 	if len(extractedEdges) == 0 {
 		return []*types.Edge{}, []*types.Edge{}, nil
 	}
 
+	var negatedEdges []*types.Edge
+	extractedEdges = slices.DeleteFunc(slices.Clone(extractedEdges), func(edge *types.Edge) bool {
+		if edge.Negated() {
+			negatedEdges = append(negatedEdges, edge)
+			return true
+		}
+		return false
+	})
+
 	// Create entity UUID to node mapping for quick lookup
 	entityMap := make(map[string]*types.Node)
 	for _, entity := range entities {
@@ -384,15 +410,24 @@ func (eo *EdgeOperations) ResolveExtractedEdges(ctx context.Context, extractedEd
 	resolvedEdges := make([]*types.Edge, 0, len(extractedEdges))
 	invalidatedEdges := make([]*types.Edge, 0)
 
-	// Process each extracted edge
-	for _, extractedEdge := range extractedEdges {
-		// Create embeddings for the edge
-		if err := eo.createEdgeEmbedding(ctx, extractedEdge); err != nil {
-			log.Printf("Warning: failed to create embedding for edge: %v", err)
+	for _, negatedEdge := range negatedEdges {
+		existingEdges, err := eo.GetBetweenNodes(ctx, negatedEdge.SourceID, negatedEdge.TargetID, negatedEdge.GroupID)
+		if err != nil {
+			log.Printf("Warning: failed to get edges negated by %s: %v", negatedEdge.Name, err)
+			continue
 		}
+		invalidatedEdges = append(invalidatedEdges, expireNegatedEdges(negatedEdge, existingEdges, episode)...)
+	}
 
+	// Create embeddings for the extracted edges
+	if err := eo.createEdgeEmbeddings(ctx, extractedEdges); err != nil {
+		log.Printf("Warning: failed to create embeddings for edges: %v", err)
+	}
+
+	// Process each extracted edge
+	for _, extractedEdge := range extractedEdges {
 		// Get existing edges between the same nodes
-		existingEdges, err := eo.GetBetweenNodes(ctx, extractedEdge.SourceID, extractedEdge.TargetID)
+		existingEdges, err := eo.GetBetweenNodes(ctx, extractedEdge.SourceID, extractedEdge.TargetID, extractedEdge.GroupID)
 		if err != nil {
 			log.Printf("Warning: failed to get existing edges: %v", err)
 			existingEdges = []*types.Edge{}
@@ -413,20 +448,18 @@ func (eo *EdgeOperations) ResolveExtractedEdges(ctx context.Context, extractedEd
 			resolvedEdge = extractedEdge
 		}
 
-		// If the edge is a duplicate, add episode to existing edge
+		// If the edge is a duplicate, add episode to existing edge and
+		// reinforce it
 		if resolvedEdge != extractedEdge && episode != nil {
-			// Add episode to source IDs if not already present
-			found := false
-			for _, sourceID := range resolvedEdge.SourceIDs {
-				if sourceID == episode.Uuid {
-					found = true
-					break
-				}
-			}
-			if !found {
-				resolvedEdge.SourceIDs = append(resolvedEdge.SourceIDs, episode.Uuid)
+			if !slices.Contains(resolvedEdge.Episodes, episode.Uuid) {
 				resolvedEdge.UpdatedAt = time.Now().UTC()
+				reinforcedAt := episode.ValidFrom
+				if reinforcedAt.IsZero() {
+					reinforcedAt = resolvedEdge.UpdatedAt
+				}
+				resolvedEdge.Reinforce(reinforcedAt)
 			}
+			RecordEdgeProvenance(resolvedEdge, episode.Uuid, nil)
 		}
 
 		resolvedEdges = append(resolvedEdges, resolvedEdge)
@@ -436,10 +469,8 @@ func (eo *EdgeOperations) ResolveExtractedEdges(ctx context.Context, extractedEd
 	if createEmbeddings {
 		// Create embeddings for all resolved and invalidated edges
 		allEdges := append(resolvedEdges, invalidatedEdges...)
-		for _, edge := range allEdges {
-			if err := eo.createEdgeEmbedding(ctx, edge); err != nil {
-				log.Printf("Warning: failed to create embedding for edge: %v", err)
-			}
+		if err := eo.createEdgeEmbeddings(ctx, allEdges); err != nil {
+			log.Printf("Warning: failed to create embeddings for edges: %v", err)
 		}
 	}
 
@@ -447,20 +478,67 @@ func (eo *EdgeOperations) ResolveExtractedEdges(ctx context.Context, extractedEd
 	return resolvedEdges, invalidatedEdges, nil
 }
 
-// createEdgeEmbedding creates an embedding for an edge based on its summary
-func (eo *EdgeOperations) createEdgeEmbedding(ctx context.Context, edge *types.Edge) error {
-	if edge.Summary == "" {
-		return nil
+// expireNegatedEdges expires the edges of existingEdges negated by
+// negatedEdge: those with exactly its relation name and direction that are
+// still valid.
+// They become invalid at the end of validity of negatedEdge, or else at the
+// time of the episode stating the negation.
+func expireNegatedEdges(negatedEdge *types.Edge, existingEdges []*types.Edge, episode *types.Node) []*types.Edge {
+	validTo := time.Now().UTC()
+	if negatedEdge.ValidTo != nil {
+		validTo = *negatedEdge.ValidTo
+	} else if episode != nil && !episode.ValidFrom.IsZero() {
+		validTo = episode.ValidFrom
 	}
+
+	now := time.Now().UTC()
+	var expired []*types.Edge
+	for _, edge := range existingEdges {
+		if edge.SourceID != negatedEdge.SourceID || edge.TargetID != negatedEdge.TargetID ||
+			edge.Name != negatedEdge.Name {
+			continue
+		}
+		// Skip edges that already ended, or only began after the negation
+		if (edge.ValidTo != nil && !edge.ValidTo.After(validTo)) || edge.ValidFrom.After(validTo) {
+			continue
+		}
+		edgeCopy := *edge
+		invalidateEdge(&edgeCopy, negatedEdge, validTo, now, episode)
+		expired = append(expired, &edgeCopy)
+	}
+	return expired
+}
+
+// createEdgeEmbeddings creates embeddings for edges based on their summaries,
+// in a single batched request
+func (eo *EdgeOperations) createEdgeEmbeddings(ctx context.Context, edges []*types.Edge) error {
 	if eo.embedder == nil {
 		return nil
 	}
-	embedding, err := eo.embedder.EmbedSingle(ctx, edge.Summary)
+
+	var pending []*types.Edge
+	var texts []string
+	for _, edge := range edges {
+		if edge.Summary != "" {
+			pending = append(pending, edge)
+			texts = append(texts, edge.Summary)
+		}
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	embeddings, err := eo.embedder.Embed(ctx, texts)
 	if err != nil {
-		return fmt.Errorf("failed to create embedding: %w", err)
+		return fmt.Errorf("failed to create embeddings: %w", err)
+	}
+	if len(embeddings) != len(texts) {
+		return fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embeddings))
 	}
 
-	edge.Embedding = embedding
+	for i, edge := range pending {
+		edge.Embedding = embeddings[i]
+	}
 	return nil
 }
 
@@ -512,31 +590,18 @@ func (eo *EdgeOperations) searchRelatedEdges(ctx context.Context, extractedEdge
 	return relatedEdges, nil
 }
 
-// resolveExtractedEdge resolves a single extracted edge against existing edges
+// resolveExtractedEdge resolves a single extracted edge against existing edges.
+// The candidates are pruned to the ones most similar to the extracted edge and
+// split across several dedupe prompts, whose verdicts are merged.
 func (eo *EdgeOperations) resolveExtractedEdge(ctx context.Context, extractedEdge *types.Edge, relatedEdges []*types.Edge, existingEdges []*types.Edge, episode *types.Node, edgeTypes map[string]interface{}) (*types.Edge, []*types.Edge, error) {
+	relatedEdges = eo.pruneEdgeCandidates(extractedEdge, relatedEdges)
+	existingEdges = eo.pruneEdgeCandidates(extractedEdge, existingEdges)
 	if len(relatedEdges) == 0 && len(existingEdges) == 0 {
 		return extractedEdge, []*types.Edge{}, nil
 	}
 
 	start := time.Now()
 
-	// Prepare context for LLM deduplication
-	relatedEdgesContext := make([]map[string]interface{}, len(relatedEdges))
-	for i, edge := range relatedEdges {
-		relatedEdgesContext[i] = map[string]interface{}{
-			"id":   edge.Uuid,
-			"fact": edge.Summary,
-		}
-	}
-
-	invalidationCandidatesContext := make([]map[string]interface{}, len(existingEdges))
-	for i, edge := range existingEdges {
-		invalidationCandidatesContext[i] = map[string]interface{}{
-			"id":   i,
-			"fact": edge.Summary,
-		}
-	}
-
 	// Build edge_types_context for deduplication prompt
 	// Note: This context is simpler than the extraction context - it only includes name and description
 	// Equivalent to Python (lines 497-507):
@@ -563,6 +628,127 @@ func (eo *EdgeOperations) resolveExtractedEdge(ctx context.Context, extractedEdg
 		}
 	}
 
+	// Merge the verdicts of the batches in order, so that the most similar
+	// duplicate wins
+	batchSize := eo.dedupeBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultEdgeDedupeBatchSize
+	}
+	var edgeDuplicate prompts.EdgeDuplicate
+	for offset := 0; offset < len(relatedEdges) || offset < len(existingEdges); offset += batchSize {
+		verdict, err := eo.dedupeEdgeBatch(ctx, extractedEdge, edgeBatch(relatedEdges, offset, batchSize), edgeBatch(existingEdges, offset, batchSize), edgeTypesContext)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		edgeDuplicate.DuplicateFacts = append(edgeDuplicate.DuplicateFacts, verdict.DuplicateFacts...)
+		edgeDuplicate.ContradictedFacts = append(edgeDuplicate.ContradictedFacts, verdict.ContradictedFacts...)
+		if verdict.FactType != "" && (edgeDuplicate.FactType == "" || strings.ToUpper(edgeDuplicate.FactType) == "DEFAULT") {
+			edgeDuplicate.FactType = verdict.FactType
+		}
+	}
+
+	// Process duplicate facts - find edges by UUID
+	resolvedEdge := extractedEdge
+	for _, duplicateFactUUID := range edgeDuplicate.DuplicateFacts {
+		// Find the edge with matching UUID in relatedEdges
+		for _, edge := range relatedEdges {
+			if edge.Uuid == duplicateFactUUID {
+				resolvedEdge = edge
+				break
+			}
+		}
+		if resolvedEdge != extractedEdge {
+			break // Found a duplicate, stop searching
+		}
+	}
+
+	// Update fact type if specified, before the invalidation policy of the edge type applies
+	if edgeDuplicate.FactType != "" && strings.ToUpper(edgeDuplicate.FactType) != "DEFAULT" {
+		resolvedEdge.Name = edgeDuplicate.FactType
+	}
+
+	// Process contradicted facts (invalidation candidates) - find edges by UUID
+	var invalidatedEdges []*types.Edge
+	for _, contradictedFactUUID := range edgeDuplicate.ContradictedFacts {
+		// Find the edge with matching UUID in existingEdges
+		for _, edge := range existingEdges {
+			if edge.Uuid == contradictedFactUUID {
+				// Apply temporal logic for invalidation
+				invalidatedEdge := eo.resolveEdgeContradictions(ctx, resolvedEdge, []*types.Edge{edge}, episode)
+				invalidatedEdges = append(invalidatedEdges, invalidatedEdge...)
+				break
+			}
+		}
+	}
+
+	// Handle temporal invalidation logic
+	now := time.Now().UTC()
+	if resolvedEdge.ValidTo != nil && resolvedEdge.ValidTo.Before(now) {
+		// Edge is already expired, don't modify expiration
+	}
+
+	log.Printf("Resolved edge %s in %v", extractedEdge.Name, time.Since(start))
+	return resolvedEdge, invalidatedEdges, nil
+}
+
+// pruneEdgeCandidates returns the candidates most similar to extractedEdge by
+// embedding, at most the dedupe candidate limit. Candidates without embeddings
+// rank after the others, and all candidates keep their order when
+// extractedEdge has no embedding.
+func (eo *EdgeOperations) pruneEdgeCandidates(extractedEdge *types.Edge, candidates []*types.Edge) []*types.Edge {
+	limit := eo.dedupeCandidateLimit
+	if limit <= 0 {
+		limit = DefaultEdgeDedupeCandidateLimit
+	}
+	if len(candidates) <= limit {
+		return candidates
+	}
+
+	pruned := slices.Clone(candidates)
+	if len(extractedEdge.Embedding) > 0 {
+		scores := make(map[*types.Edge]float64, len(pruned))
+		for _, edge := range pruned {
+			scores[edge] = -2 // below any cosine similarity
+			if len(edge.Embedding) == len(extractedEdge.Embedding) {
+				scores[edge] = utils.CalculateCosineSimilarity(extractedEdge.Embedding, edge.Embedding)
+			}
+		}
+		sort.SliceStable(pruned, func(i, j int) bool {
+			return scores[pruned[i]] > scores[pruned[j]]
+		})
+	}
+	return pruned[:limit]
+}
+
+// edgeBatch returns the batch of edges starting at offset, empty past the end.
+func edgeBatch(edges []*types.Edge, offset, size int) []*types.Edge {
+	if offset >= len(edges) {
+		return nil
+	}
+	return edges[offset:min(offset+size, len(edges))]
+}
+
+// dedupeEdgeBatch asks the LLM which of relatedEdges duplicate extractedEdge
+// and which of existingEdges it contradicts.
+func (eo *EdgeOperations) dedupeEdgeBatch(ctx context.Context, extractedEdge *types.Edge, relatedEdges, existingEdges []*types.Edge, edgeTypesContext []map[string]interface{}) (*prompts.EdgeDuplicate, error) {
+	// Prepare context for LLM deduplication
+	relatedEdgesContext := make([]map[string]interface{}, len(relatedEdges))
+	for i, edge := range relatedEdges {
+		relatedEdgesContext[i] = map[string]interface{}{
+			"id":   edge.Uuid,
+			"fact": edge.Summary,
+		}
+	}
+
+	invalidationCandidatesContext := make([]map[string]interface{}, len(existingEdges))
+	for i, edge := range existingEdges {
+		invalidationCandidatesContext[i] = map[string]interface{}{
+			"id":   edge.Uuid,
+			"fact": edge.Summary,
+		}
+	}
+
 	// Note: Data is passed as slices for TSV formatting in prompts
 	promptContext := map[string]interface{}{
 		"existing_edges":               relatedEdgesContext,
@@ -576,8 +762,7 @@ func (eo *EdgeOperations) resolveExtractedEdge(ctx context.Context, extractedEdg
 	// Use LLM to resolve duplicates and contradictions
 	messages, err := eo.prompts.DedupeEdges().ResolveEdge().Call(promptContext)
 	if err != nil {
-		log.Printf("Warning: failed to create dedupe prompt: %v", err)
-		return extractedEdge, []*types.Edge{}, nil
+		return nil, fmt.Errorf("failed to create dedupe prompt: %w", err)
 	}
 
 	// Create CSV parser function for EdgeDuplicateTSV
@@ -606,68 +791,28 @@ func (eo *EdgeOperations) resolveExtractedEdge(ctx context.Context, extractedEdg
 				fmt.Printf("\nFailed LLM edge resolution response:\n%v\n\n", badResp.Response)
 			}
 		}
-		log.Printf("Warning: failed to parse edge deduplication TSV: %v", err)
-		return extractedEdge, []*types.Edge{}, nil
+		return nil, fmt.Errorf("failed to parse edge deduplication TSV: %w", err)
 	}
 
 	if len(edgeDuplicateTSVSlice) == 0 {
-		log.Printf("Warning: empty edge deduplication response")
-		return extractedEdge, []*types.Edge{}, nil
+		return nil, fmt.Errorf("empty edge deduplication response")
 	}
 
 	// Convert TSV result to EdgeDuplicate
-	edgeDuplicateTSV := &edgeDuplicateTSVSlice[0]
-	var edgeDuplicate prompts.EdgeDuplicate
-	edgeDuplicate.FactType = edgeDuplicateTSV.FactType
-	edgeDuplicate.DuplicateFacts = edgeDuplicateTSV.DuplicateFacts
-	edgeDuplicate.ContradictedFacts = edgeDuplicateTSV.ContradictedFacts
-
-	// Process duplicate facts - find edges by UUID
-	resolvedEdge := extractedEdge
-	for _, duplicateFactUUID := range edgeDuplicate.DuplicateFacts {
-		// Find the edge with matching UUID in relatedEdges
-		for _, edge := range relatedEdges {
-			if edge.Uuid == duplicateFactUUID {
-				resolvedEdge = edge
-				break
-			}
-		}
-		if resolvedEdge != extractedEdge {
-			break // Found a duplicate, stop searching
-		}
-	}
-
-	// Process contradicted facts (invalidation candidates) - find edges by UUID
-	var invalidatedEdges []*types.Edge
-	for _, contradictedFactUUID := range edgeDuplicate.ContradictedFacts {
-		// Find the edge with matching UUID in existingEdges
-		for _, edge := range existingEdges {
-			if edge.Uuid == contradictedFactUUID {
-				// Apply temporal logic for invalidation
-				invalidatedEdge := eo.resolveEdgeContradictions(resolvedEdge, []*types.Edge{edge})
-				invalidatedEdges = append(invalidatedEdges, invalidatedEdge...)
-				break
-			}
-		}
-	}
-
-	// Update fact type if specified
-	if edgeDuplicate.FactType != "" && strings.ToUpper(edgeDuplicate.FactType) != "DEFAULT" {
-		resolvedEdge.Name = edgeDuplicate.FactType
-	}
-
-	// Handle temporal invalidation logic
-	now := time.Now().UTC()
-	if resolvedEdge.ValidTo != nil && resolvedEdge.ValidTo.Before(now) {
-		// Edge is already expired, don't modify expiration
-	}
-
-	log.Printf("Resolved edge %s in %v", extractedEdge.Name, time.Since(start))
-	return resolvedEdge, invalidatedEdges, nil
+	edgeDuplicateTSV := edgeDuplicateTSVSlice[0]
+	return &prompts.EdgeDuplicate{
+		FactType:          edgeDuplicateTSV.FactType,
+		DuplicateFacts:    edgeDuplicateTSV.DuplicateFacts,
+		ContradictedFacts: edgeDuplicateTSV.ContradictedFacts,
+	}, nil
 }
 
 // resolveEdgeContradictions handles temporal contradictions between edges
-func (eo *EdgeOperations) resolveEdgeContradictions(resolvedEdge *types.Edge, invalidationCandidates []*types.Edge) []*types.Edge {
+// according to the invalidation policy of resolvedEdge. It returns the
+// existing edges updated by the contradictions. Invalidated edges record the
+// edge that invalidated them, episode and the time of invalidation in their
+// metadata for DetectContradictions.
+func (eo *EdgeOperations) resolveEdgeContradictions(ctx context.Context, resolvedEdge *types.Edge, invalidationCandidates []*types.Edge, episode *types.Node) []*types.Edge {
 	if len(invalidationCandidates) == 0 {
 		return []*types.Edge{}
 	}
@@ -686,103 +831,63 @@ func (eo *EdgeOperations) resolveEdgeContradictions(resolvedEdge *types.Edge, in
 			continue
 		}
 
-		// Invalidate edge if the new edge becomes valid after this one
-		if edge.ValidFrom.Before(resolvedEdge.ValidFrom) {
-			edgeCopy := *edge
-			validTo := resolvedEdge.ValidFrom
-			edgeCopy.ValidTo = &validTo
-			edgeCopy.UpdatedAt = now
-			invalidatedEdges = append(invalidatedEdges, &edgeCopy)
+		edgeCopy := *edge
+
+		// Edges that became valid at the same time as the new edge are only
+		// resolved by confidence: the less confident edge is expired
+		if edge.ValidFrom.Equal(resolvedEdge.ValidFrom) {
+			switch compareConfidence(resolvedEdge, edge) {
+			case 1:
+				invalidateEdge(&edgeCopy, resolvedEdge, resolvedEdge.ValidFrom, now, episode)
+				invalidatedEdges = append(invalidatedEdges, &edgeCopy)
+			case -1:
+				invalidateEdge(resolvedEdge, edge, resolvedEdge.ValidFrom, now, episode)
+			}
+			continue
+		}
+
+		// Only edges that became valid before the new edge contradict it
+		if !edge.ValidFrom.Before(resolvedEdge.ValidFrom) {
+			continue
+		}
+
+		switch eo.resolveContradiction(ctx, resolvedEdge, edge, episode) {
+		case expireExisting:
+			invalidateEdge(&edgeCopy, resolvedEdge, resolvedEdge.ValidFrom, now, episode)
+		case expireExtracted:
+			invalidateEdge(resolvedEdge, edge, resolvedEdge.ValidFrom, now, episode)
+			continue
+		case keepBoth:
+			flagContradiction(&edgeCopy, resolvedEdge.Uuid, now)
+			flagContradiction(resolvedEdge, edge.Uuid, now)
 		}
+		invalidatedEdges = append(invalidatedEdges, &edgeCopy)
 	}
 
 	return invalidatedEdges
 }
 
-// FilterExistingDuplicateOfEdges filters out duplicate node pairs that already have IS_DUPLICATE_OF edges using proper Ladybug query
+// FilterExistingDuplicateOfEdges filters out duplicate node pairs that already
+// have IS_DUPLICATE_OF edges, looked up in the group of each pair.
 func (eo *EdgeOperations) FilterExistingDuplicateOfEdges(ctx context.Context, duplicateNodePairs []NodePair) ([]NodePair, error) {
 	if len(duplicateNodePairs) == 0 {
 		return []NodePair{}, nil
 	}
 
-	// Prepare parameters exactly like Python implementation
-	duplicateNodeUUIDs := make([]map[string]interface{}, len(duplicateNodePairs))
-	for i, pair := range duplicateNodePairs {
-		duplicateNodeUUIDs[i] = map[string]interface{}{
-			"src": pair.Source.Uuid,
-			"dst": pair.Target.Uuid,
-		}
-	}
-
-	query := `
-		UNWIND $duplicate_node_uuids AS duplicate
-		MATCH (n:Entity {uuid: duplicate.src})-[:RELATES_TO]->(e:RelatesToNode_ {name: 'IS_DUPLICATE_OF'})-[:RELATES_TO]->(m:Entity {uuid: duplicate.dst})
-		RETURN DISTINCT
-			n.uuid AS source_uuid,
-			m.uuid AS target_uuid
-	`
-
-	params := map[string]interface{}{
-		"duplicate_node_uuids": duplicateNodeUUIDs,
-	}
-
-	result, _, _, err := eo.driver.ExecuteQuery(query, params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute FilterExistingDuplicateOfEdges query: %w", err)
+	sourcesByGroup := make(map[string][]string)
+	for _, pair := range duplicateNodePairs {
+		sourcesByGroup[pair.Source.GroupID] = append(sourcesByGroup[pair.Source.GroupID], pair.Source.Uuid)
 	}
 
 	// Create a set of existing duplicate pairs
 	existingPairs := make(map[string]bool)
-	if result != nil {
-		switch records := result.(type) {
-		case []map[string]interface{}:
-			// Ladybug format
-			for _, record := range records {
-				if sourceUUID, ok := record["source_uuid"].(string); ok {
-					if targetUUID, ok := record["target_uuid"].(string); ok {
-						key := fmt.Sprintf("%s-%s", sourceUUID, targetUUID)
-						existingPairs[key] = true
-					}
-				}
-			}
-		default:
-			// Try Neo4j/Memgraph format using reflection
-			value := reflect.ValueOf(result)
-			if value.Kind() == reflect.Slice {
-				for i := 0; i < value.Len(); i++ {
-					record := value.Index(i)
-
-					// Call Get() method on the record
-					getMethod := record.MethodByName("Get")
-					if !getMethod.IsValid() {
-						continue
-					}
-
-					// Get source_uuid
-					sourceResults := getMethod.Call([]reflect.Value{reflect.ValueOf("source_uuid")})
-					if len(sourceResults) == 0 {
-						continue
-					}
-
-					// Get target_uuid
-					targetResults := getMethod.Call([]reflect.Value{reflect.ValueOf("target_uuid")})
-					if len(targetResults) == 0 {
-						continue
-					}
-
-					sourceInterface := sourceResults[0].Interface()
-					targetInterface := targetResults[0].Interface()
-
-					if sourceUUID, ok := sourceInterface.(string); ok {
-						if targetUUID, ok := targetInterface.(string); ok {
-							key := fmt.Sprintf("%s-%s", sourceUUID, targetUUID)
-							existingPairs[key] = true
-						}
-					}
-				}
-			} else {
-				log.Printf("Warning: unexpected result type from FilterExistingDuplicateOfEdges query: %T", result)
-			}
+	for groupID, sources := range sourcesByGroup {
+		pairs, err := driver.DuplicateOfPairs(ctx, eo.driver, groupID, sources)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find existing IS_DUPLICATE_OF edges: %w", err)
+		}
+		for _, pair := range pairs {
+			existingPairs[pair[0]+"-"+pair[1]] = true
 		}
 	}
 
@@ -800,68 +905,3 @@ func (eo *EdgeOperations) FilterExistingDuplicateOfEdges(ctx context.Context, du
 
 	return filteredPairs, nil
 }
-
-// parseNeo4jEdgeRecords parses Neo4j/Memgraph driver records into edges using reflection.
-// This handles the []*db.Record type returned by Memgraph's ExecuteQuery for edge queries.
-func (eo *EdgeOperations) parseNeo4jEdgeRecords(result interface{}) []*types.Edge {
-	var edges []*types.Edge
-
-	// Use reflection to handle Neo4j driver records
-	value := reflect.ValueOf(result)
-	if value.Kind() != reflect.Slice {
-		log.Printf("Warning: expected slice for Neo4j records, got %T", result)
-		return edges
-	}
-
-	// Iterate through records
-	for i := 0; i < value.Len(); i++ {
-		record := value.Index(i)
-
-		// Get Keys and Values from the record
-		keysMethod := record.MethodByName("Keys")
-		valuesField := record.FieldByName("Values")
-
-		if !keysMethod.IsValid() || !valuesField.IsValid() {
-			continue
-		}
-
-		// Call Keys() method
-		keysResult := keysMethod.Call([]reflect.Value{})
-		if len(keysResult) < 1 {
-			continue
-		}
-
-		keys := keysResult[0]
-		if keys.Kind() != reflect.Slice {
-			continue
-		}
-
-		// Get Values field
-		values := valuesField
-		if values.Kind() != reflect.Slice {
-			continue
-		}
-
-		// Build map from keys and values
-		recordMap := make(map[string]interface{})
-		for j := 0; j < keys.Len() && j < values.Len(); j++ {
-			keyVal := keys.Index(j)
-			valueVal := values.Index(j)
-
-			if key, ok := keyVal.Interface().(string); ok {
-				recordMap[key] = valueVal.Interface()
-			}
-		}
-
-		// Convert record map to edge
-		edge, err := eo.convertRecordToEdge(recordMap)
-		if err != nil {
-			log.Printf("Warning: failed to convert Neo4j record to edge: %v", err)
-			continue
-		}
-
-		edges = append(edges, edge)
-	}
-
-	return edges
-}
@@ -0,0 +1,147 @@
+package maintenance
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// betweenDriver is a Neo4j GraphDriver holding entity edges, which answers
+// the queries matching RELATES_TO relationships between two entities with
+// their UUIDs, and records the parameters of the queries.
+type betweenDriver struct {
+	driver.GraphDriver
+	edges  []*types.Edge
+	params []map[string]interface{}
+}
+
+func (d *betweenDriver) Provider() driver.GraphProvider {
+	return driver.GraphProviderNeo4j
+}
+
+func (d *betweenDriver) ExecuteQuery(cypherQuery string, kwargs map[string]interface{}) (interface{}, interface{}, interface{}, error) {
+	d.params = append(d.params, kwargs)
+	records := []map[string]interface{}{}
+	if !strings.Contains(cypherQuery, "-[rel:RELATES_TO]-") {
+		return records, nil, nil, nil
+	}
+	for _, edge := range d.edges {
+		between := (edge.SourceID == kwargs["source_uuid"] && edge.TargetID == kwargs["target_uuid"]) ||
+			(edge.SourceID == kwargs["target_uuid"] && edge.TargetID == kwargs["source_uuid"])
+		if between && edge.GroupID == kwargs["group_id"] {
+			records = append(records, map[string]interface{}{"uuid": edge.Uuid})
+		}
+	}
+	return records, nil, nil, nil
+}
+
+func (d *betweenDriver) GetEdges(ctx context.Context, edgeIDs []string, groupID string) ([]*types.Edge, error) {
+	var edges []*types.Edge
+	for _, edge := range d.edges {
+		if slices.Contains(edgeIDs, edge.Uuid) {
+			edges = append(edges, edge)
+		}
+	}
+	return edges, nil
+}
+
+func TestExpireNegatedEdges(t *testing.T) {
+	episodeTime := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	before := episodeTime.AddDate(-1, 0, 0)
+	after := episodeTime.AddDate(1, 0, 0)
+	episode := &types.Node{Uuid: "ep", ValidFrom: episodeTime}
+
+	edge := func(uuid, source, target, name string, validFrom time.Time, validTo *time.Time) *types.Edge {
+		edge := types.NewEntityEdge(uuid, source, target, "g", name, types.EntityEdgeType)
+		edge.ValidFrom = validFrom
+		edge.ValidTo = validTo
+		return edge
+	}
+	negated := edge("negation", "alice", "acme", "WORKS_AT", episodeTime, nil)
+	negated.SetNegated()
+
+	tests := []struct {
+		name    string
+		edge    *types.Edge
+		expired bool
+	}{
+		{"same relation and direction", edge("match", "alice", "acme", "WORKS_AT", before, nil), true},
+		{"ending after the negation", edge("later-end", "alice", "acme", "WORKS_AT", before, &after), true},
+		{"reverse direction", edge("reverse", "acme", "alice", "WORKS_AT", before, nil), false},
+		{"other relation", edge("other", "alice", "acme", "LIVES_IN", before, nil), false},
+		{"relation in another case", edge("case", "alice", "acme", "works_at", before, nil), false},
+		{"other target", edge("target", "alice", "globex", "WORKS_AT", before, nil), false},
+		{"already ended", edge("ended", "alice", "acme", "WORKS_AT", before.AddDate(-1, 0, 0), &before), false},
+		{"beginning after the negation", edge("future", "alice", "acme", "WORKS_AT", after, nil), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expired := expireNegatedEdges(negated, []*types.Edge{tt.edge}, episode)
+			if !tt.expired {
+				if len(expired) != 0 {
+					t.Errorf("expireNegatedEdges() expired %s", tt.edge.Uuid)
+				}
+				return
+			}
+			if len(expired) != 1 {
+				t.Fatalf("expireNegatedEdges() expired %d edges, want %s", len(expired), tt.edge.Uuid)
+			}
+			if expired[0] == tt.edge {
+				t.Error("expireNegatedEdges() modified the existing edge instead of a copy")
+			}
+			if expired[0].ValidTo == nil || !expired[0].ValidTo.Equal(episodeTime) {
+				t.Errorf("ValidTo = %v, want the time of the episode", expired[0].ValidTo)
+			}
+			if by := expired[0].Metadata[invalidatedByKey]; by != "negation" {
+				t.Errorf("invalidated by %v, want the negated edge", by)
+			}
+			if by := expired[0].Metadata[invalidatedByEpisodeKey]; by != "ep" {
+				t.Errorf("invalidated by episode %v, want ep", by)
+			}
+		})
+	}
+
+	// The negated edge's own end of validity takes precedence
+	negated.ValidTo = &before
+	expired := expireNegatedEdges(negated, []*types.Edge{edge("match", "alice", "acme", "WORKS_AT", before.AddDate(-1, 0, 0), nil)}, episode)
+	if len(expired) != 1 || !expired[0].ValidTo.Equal(before) {
+		t.Errorf("expireNegatedEdges() = %v, want the edge expired when the negated fact ended", expired)
+	}
+}
+
+func TestResolveExtractedEdgesExpiresNegatedEdges(t *testing.T) {
+	d := &betweenDriver{edges: []*types.Edge{
+		types.NewEntityEdge("works", "alice", "acme", "g", "WORKS_AT", types.EntityEdgeType),
+		types.NewEntityEdge("employs", "acme", "alice", "g", "WORKS_AT", types.EntityEdgeType),
+		types.NewEntityEdge("lives", "alice", "acme", "g", "LIVES_IN", types.EntityEdgeType),
+		types.NewEntityEdge("other-group", "alice", "acme", "other", "WORKS_AT", types.EntityEdgeType),
+	}}
+	eo := NewEdgeOperations(d, nil, nil, nil)
+	eo.SetLogger(discardLogger)
+
+	negated := types.NewEntityEdge("negation", "alice", "acme", "g", "WORKS_AT", types.EntityEdgeType)
+	negated.SetNegated()
+	episode := &types.Node{Uuid: "ep", ValidFrom: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	resolved, invalidated, err := eo.ResolveExtractedEdges(context.Background(), []*types.Edge{negated}, episode, nil, false, nil)
+	if err != nil {
+		t.Fatalf("ResolveExtractedEdges() error = %v", err)
+	}
+	if len(resolved) != 0 {
+		t.Errorf("resolved %d edges, want the negated edge left out", len(resolved))
+	}
+	var uuids []string
+	for _, edge := range invalidated {
+		uuids = append(uuids, edge.Uuid)
+	}
+	if !slices.Equal(uuids, []string{"works"}) {
+		t.Errorf("invalidated %v, want [works]", uuids)
+	}
+	if len(d.params) != 1 || d.params[0]["source_uuid"] != "alice" || d.params[0]["target_uuid"] != "acme" || d.params[0]["group_id"] != "g" {
+		t.Errorf("queries = %v, want the edges of g between alice and acme", d.params)
+	}
+}
@@ -0,0 +1,71 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+)
+
+// IntegrityReport lists the integrity issues found in a group.
+type IntegrityReport struct {
+	GroupID string `json:"group_id"`
+	// DanglingEdges are the UUIDs of entity edges with a missing or deleted
+	// endpoint
+	DanglingEdges []string `json:"dangling_edges"`
+	// DanglingMentions are MENTIONS edges from a deleted episode or to a
+	// deleted entity
+	DanglingMentions []driver.Mention `json:"dangling_mentions"`
+	// MissingEmbeddings are the UUIDs of entity and community nodes without a
+	// name embedding; Client.ReembedGraph regenerates them
+	MissingEmbeddings []string `json:"missing_embeddings"`
+	// DuplicateUUIDs are UUIDs shared by several nodes
+	DuplicateUUIDs []string `json:"duplicate_uuids"`
+	// Repaired is set once RepairIntegrity removed the dangling edges and
+	// mentions
+	Repaired bool `json:"repaired"`
+}
+
+// IssueCount returns the number of issues in the report.
+func (r *IntegrityReport) IssueCount() int {
+	return len(r.DanglingEdges) + len(r.DanglingMentions) + len(r.MissingEmbeddings) + len(r.DuplicateUUIDs)
+}
+
+// CheckIntegrity checks a group for entity edges and MENTIONS edges whose
+// endpoints are missing or deleted, nodes missing embeddings and duplicate
+// UUIDs. Pass the report to RepairIntegrity to remove the dangling edges.
+func (mu *MaintenanceUtils) CheckIntegrity(ctx context.Context, groupID string) (*IntegrityReport, error) {
+	issues, err := driver.FindIntegrityIssues(ctx, mu.driver, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check integrity: %w", err)
+	}
+
+	report := &IntegrityReport{
+		GroupID:           groupID,
+		DanglingEdges:     issues.DanglingEdges,
+		DanglingMentions:  issues.DanglingMentions,
+		MissingEmbeddings: issues.MissingEmbeddings,
+		DuplicateUUIDs:    issues.DuplicateUUIDs,
+	}
+	log.Printf("Found %d integrity issues in group %s", report.IssueCount(), groupID)
+	return report, nil
+}
+
+// RepairIntegrity removes the dangling entity edges and MENTIONS edges of a
+// report, tombstoning them when the driver soft-deletes. Missing embeddings
+// and duplicate UUIDs are only reported.
+func (mu *MaintenanceUtils) RepairIntegrity(ctx context.Context, report *IntegrityReport) error {
+	issues := &driver.IntegrityIssues{
+		DanglingEdges:    report.DanglingEdges,
+		DanglingMentions: report.DanglingMentions,
+	}
+	if err := driver.RepairIntegrityIssues(ctx, mu.driver, report.GroupID, issues); err != nil {
+		return fmt.Errorf("failed to repair integrity: %w", err)
+	}
+
+	report.Repaired = true
+	log.Printf("Removed %d dangling edges and %d dangling mentions in group %s",
+		len(report.DanglingEdges), len(report.DanglingMentions), report.GroupID)
+	return nil
+}
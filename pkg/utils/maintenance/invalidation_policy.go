@@ -0,0 +1,213 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils"
+)
+
+// InvalidationPolicy decides what happens when an extracted edge contradicts
+// an older existing edge.
+type InvalidationPolicy string
+
+const (
+	// InvalidationPolicyExpireOld expires the older edge. It is the default.
+	InvalidationPolicyExpireOld InvalidationPolicy = "expire-old"
+	// InvalidationPolicyKeepBoth keeps both edges valid and flags each with
+	// the UUID of the other in its "contradicts" metadata.
+	InvalidationPolicyKeepBoth InvalidationPolicy = "keep-both-with-flag"
+	// InvalidationPolicyPreferConfidence expires the edge with the lower
+	// confidence, read from the "confidence" metadata of the edges or their
+	// strength. The older edge is expired on ties.
+	InvalidationPolicyPreferConfidence InvalidationPolicy = "prefer-higher-confidence"
+	// InvalidationPolicyAskLLM asks the LLM whether the episode invalidates
+	// the older edge, and keeps both edges flagged otherwise.
+	InvalidationPolicyAskLLM InvalidationPolicy = "ask-llm"
+)
+
+// contradictsKey is the metadata key of the edges an edge contradicts.
+const contradictsKey = "contradicts"
+
+// contradictionOutcome is the resolution of a contradiction between an
+// extracted edge and an older existing edge.
+type contradictionOutcome int
+
+const (
+	expireExisting contradictionOutcome = iota
+	expireExtracted
+	keepBoth
+)
+
+// SetInvalidationPolicies sets the invalidation policy of each edge type, by
+// edge name. Edges of other types use InvalidationPolicyExpireOld.
+func (eo *EdgeOperations) SetInvalidationPolicies(policies map[string]InvalidationPolicy) {
+	eo.invalidationPolicies = maps.Clone(policies)
+}
+
+// invalidationPolicy returns the policy for edges named edgeName.
+func (eo *EdgeOperations) invalidationPolicy(edgeName string) InvalidationPolicy {
+	switch policy := eo.invalidationPolicies[edgeName]; policy {
+	case InvalidationPolicyKeepBoth, InvalidationPolicyPreferConfidence, InvalidationPolicyAskLLM:
+		return policy
+	case "", InvalidationPolicyExpireOld:
+	default:
+		eo.logger.Warn("Unknown edge invalidation policy, expiring the old edge",
+			"edge_name", edgeName,
+			"policy", policy)
+	}
+	return InvalidationPolicyExpireOld
+}
+
+// resolveContradiction applies the invalidation policy of the extracted edge
+// to its contradiction with the older existing edge. Failures to ask the LLM
+// fall back to expiring the existing edge.
+func (eo *EdgeOperations) resolveContradiction(ctx context.Context, extracted, existing *types.Edge, episode *types.Node) contradictionOutcome {
+	switch eo.invalidationPolicy(extracted.Name) {
+	case InvalidationPolicyKeepBoth:
+		return keepBoth
+	case InvalidationPolicyPreferConfidence:
+		if edgeConfidence(existing) > edgeConfidence(extracted) {
+			return expireExtracted
+		}
+	case InvalidationPolicyAskLLM:
+		invalidates, err := eo.askInvalidation(ctx, extracted, existing, episode)
+		if err != nil {
+			eo.logger.Warn("Failed to ask the LLM about a contradiction, expiring the old edge",
+				"edge_uuid", existing.Uuid,
+				"error", err)
+			break
+		}
+		if !invalidates {
+			return keepBoth
+		}
+	}
+	return expireExisting
+}
+
+// askInvalidation asks the LLM whether the episode of the extracted edge
+// invalidates the existing edge.
+func (eo *EdgeOperations) askInvalidation(ctx context.Context, extracted, existing *types.Edge, episode *types.Node) (bool, error) {
+	ctx = usage.WithOperation(ctx, usage.OperationInvalidateEdges)
+
+	content := "New fact: " + extracted.Fact
+	if episode != nil && episode.Content != "" {
+		content = episode.Content + "\n\n" + content
+	}
+	messages, err := eo.prompts.InvalidateEdges().Invalidate().Call(map[string]interface{}{
+		"previous_episodes": []string{},
+		"episode_content":   content,
+		"existing_edges":    []map[string]interface{}{{"id": 0, "fact": existing.Fact}},
+		"reference_time":    extracted.ValidFrom,
+		"ensure_ascii":      true,
+		"logger":            eo.logger,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to create invalidation prompt: %w", err)
+	}
+
+	csvParser := func(csvContent string) ([]*prompts.InvalidatedEdgesTSV, error) {
+		return utils.DuckDbUnmarshalCSV[prompts.InvalidatedEdgesTSV](csvContent, '\t')
+	}
+	invalidated, _, err := llm.GenerateCSVResponse[prompts.InvalidatedEdgesTSV](ctx, eo.llm, eo.logger, messages, csvParser, 3)
+	if err != nil {
+		return false, fmt.Errorf("failed to ask for invalidated edges: %w", err)
+	}
+	return slices.ContainsFunc(invalidated, func(edge prompts.InvalidatedEdgesTSV) bool { return edge.FactID == 0 }), nil
+}
+
+// edgeConfidence returns the confidence of edge, or its strength.
+func edgeConfidence(edge *types.Edge) float64 {
+	if confidence, ok := edge.Confidence(); ok {
+		return confidence
+	}
+	return edge.Strength
+}
+
+// compareConfidence returns 1 when the LLM was more confident in a than in
+// b, -1 when it was less confident and 0 when either has no confidence or
+// they are equal.
+func compareConfidence(a, b *types.Edge) int {
+	confidenceA, okA := a.Confidence()
+	confidenceB, okB := b.Confidence()
+	switch {
+	case !okA || !okB:
+		return 0
+	case confidenceA > confidenceB:
+		return 1
+	case confidenceA < confidenceB:
+		return -1
+	}
+	return 0
+}
+
+// invalidateEdge expires edge at validTo because of the contradicting edge by,
+// recording the contradiction in its metadata.
+func invalidateEdge(edge, by *types.Edge, validTo, now time.Time, episode *types.Node) {
+	edge.ValidTo = &validTo
+	edge.UpdatedAt = now
+	edge.Metadata = maps.Clone(edge.Metadata)
+	if edge.Metadata == nil {
+		edge.Metadata = make(map[string]interface{})
+	}
+	edge.Metadata[invalidatedByKey] = by.Uuid
+	edge.Metadata[invalidatedAtKey] = now.Format(time.RFC3339)
+	if episode != nil {
+		edge.Metadata[invalidatedByEpisodeKey] = episode.Uuid
+	}
+}
+
+// RetractEdge expires edge at now because it was retracted rather than
+// contradicted, recording reason in its metadata.
+func RetractEdge(edge *types.Edge, reason string, now time.Time) {
+	edge.ValidTo = &now
+	edge.InvalidAt = &now
+	edge.ExpiredAt = &now
+	edge.UpdatedAt = now
+	edge.Metadata = maps.Clone(edge.Metadata)
+	if edge.Metadata == nil {
+		edge.Metadata = make(map[string]interface{})
+	}
+	edge.Metadata[invalidatedAtKey] = now.Format(time.RFC3339)
+	if reason != "" {
+		edge.Metadata[invalidationReasonKey] = reason
+	}
+}
+
+// RestoreEdgeValidity restores the validity window edge had before the
+// episode with UUID episodeUUID invalidated it, removing the record of the
+// invalidation from its metadata.
+func RestoreEdgeValidity(edge *types.Edge, validity types.EdgeValidity, episodeUUID string) {
+	edge.ValidTo = validity.ValidTo
+	edge.InvalidAt = validity.InvalidAt
+	edge.ExpiredAt = validity.ExpiredAt
+	edge.UpdatedAt = time.Now().UTC()
+	if by, _ := edge.Metadata[invalidatedByEpisodeKey].(string); by == episodeUUID {
+		edge.Metadata = maps.Clone(edge.Metadata)
+		delete(edge.Metadata, invalidatedByKey)
+		delete(edge.Metadata, invalidatedByEpisodeKey)
+		delete(edge.Metadata, invalidatedAtKey)
+	}
+}
+
+// flagContradiction records in the metadata of edge that it contradicts the
+// edge with UUID other.
+func flagContradiction(edge *types.Edge, other string, now time.Time) {
+	edge.UpdatedAt = now
+	edge.Metadata = maps.Clone(edge.Metadata)
+	if edge.Metadata == nil {
+		edge.Metadata = make(map[string]interface{})
+	}
+	contradicts := metadataStrings(edge.Metadata[contradictsKey])
+	if !slices.Contains(contradicts, other) {
+		contradicts = append(contradicts, other)
+	}
+	edge.Metadata[contradictsKey] = contradicts
+}
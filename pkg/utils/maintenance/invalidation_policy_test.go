@@ -0,0 +1,116 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// answerLLM is an LLM client answering every chat with content, or failing
+// with err.
+type answerLLM struct {
+	llm.Client
+	content string
+	err     error
+	calls   int
+}
+
+func (c *answerLLM) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &types.Response{Content: c.content}, nil
+}
+
+func TestInvalidationPolicy(t *testing.T) {
+	eo := NewEdgeOperations(nil, nil, nil, prompts.NewLibrary())
+	eo.SetLogger(discardLogger)
+	eo.SetInvalidationPolicies(map[string]InvalidationPolicy{
+		"WORKS_AT":  InvalidationPolicyKeepBoth,
+		"LIVES_IN":  InvalidationPolicyExpireOld,
+		"DIAGNOSED": InvalidationPolicyPreferConfidence,
+		"TREATS":    InvalidationPolicyAskLLM,
+		"KNOWS":     "expire-newest",
+	})
+
+	tests := []struct {
+		edgeName string
+		want     InvalidationPolicy
+	}{
+		{"WORKS_AT", InvalidationPolicyKeepBoth},
+		{"LIVES_IN", InvalidationPolicyExpireOld},
+		{"DIAGNOSED", InvalidationPolicyPreferConfidence},
+		{"TREATS", InvalidationPolicyAskLLM},
+		{"KNOWS", InvalidationPolicyExpireOld},
+		{"OWNS", InvalidationPolicyExpireOld},
+	}
+	for _, tt := range tests {
+		if got := eo.invalidationPolicy(tt.edgeName); got != tt.want {
+			t.Errorf("invalidationPolicy(%s) = %s, want %s", tt.edgeName, got, tt.want)
+		}
+	}
+}
+
+func TestResolveContradiction(t *testing.T) {
+	confident := func(confidence float64) func(*types.Edge) {
+		return func(edge *types.Edge) { edge.SetConfidence(confidence) }
+	}
+
+	tests := []struct {
+		name      string
+		policy    InvalidationPolicy
+		extracted func(*types.Edge)
+		existing  func(*types.Edge)
+		answer    *answerLLM
+		want      contradictionOutcome
+	}{
+		{name: "no policy", want: expireExisting},
+		{name: "expire old", policy: InvalidationPolicyExpireOld, want: expireExisting},
+		{name: "unknown policy", policy: "expire-newest", want: expireExisting},
+		{name: "keep both", policy: InvalidationPolicyKeepBoth, want: keepBoth},
+		{name: "existing more confident", policy: InvalidationPolicyPreferConfidence, extracted: confident(0.4), existing: confident(0.9), want: expireExtracted},
+		{name: "extracted more confident", policy: InvalidationPolicyPreferConfidence, extracted: confident(0.9), existing: confident(0.4), want: expireExisting},
+		{name: "equally confident", policy: InvalidationPolicyPreferConfidence, extracted: confident(0.5), existing: confident(0.5), want: expireExisting},
+		{name: "existing stronger", policy: InvalidationPolicyPreferConfidence, existing: func(edge *types.Edge) { edge.Strength = 2 }, want: expireExtracted},
+		{name: "llm invalidates", policy: InvalidationPolicyAskLLM, answer: &answerLLM{content: "fact_id\n0"}, want: expireExisting},
+		{name: "llm keeps", policy: InvalidationPolicyAskLLM, answer: &answerLLM{content: "fact_id\n1"}, want: keepBoth},
+		{name: "llm fails", policy: InvalidationPolicyAskLLM, answer: &answerLLM{err: errors.New("rate limited")}, want: expireExisting},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer := tt.answer
+			if answer == nil {
+				answer = &answerLLM{err: errors.New("unexpected LLM call")}
+			}
+			eo := NewEdgeOperations(nil, answer, nil, prompts.NewLibrary())
+			eo.SetLogger(discardLogger)
+			if tt.policy != "" {
+				eo.SetInvalidationPolicies(map[string]InvalidationPolicy{"WORKS_AT": tt.policy})
+			}
+
+			extracted := types.NewEntityEdge("new", "alice", "globex", "g", "WORKS_AT", types.EntityEdgeType)
+			extracted.Fact = "Alice works at Globex"
+			existing := types.NewEntityEdge("old", "alice", "acme", "g", "WORKS_AT", types.EntityEdgeType)
+			existing.Fact = "Alice works at Acme"
+			if tt.extracted != nil {
+				tt.extracted(extracted)
+			}
+			if tt.existing != nil {
+				tt.existing(existing)
+			}
+
+			episode := &types.Node{Uuid: "ep", Content: "Alice moved to Globex."}
+			if got := eo.resolveContradiction(context.Background(), extracted, existing, episode); got != tt.want {
+				t.Errorf("resolveContradiction() = %d, want %d", got, tt.want)
+			}
+			if tt.policy != InvalidationPolicyAskLLM && answer.calls != 0 {
+				t.Errorf("policy %q asked the LLM", tt.policy)
+			}
+		})
+	}
+}
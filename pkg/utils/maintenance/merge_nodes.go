@@ -0,0 +1,164 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils"
+)
+
+// mergedUUIDsKey is the metadata key listing the UUIDs of the nodes merged
+// into a node.
+const mergedUUIDsKey = "merged_uuids"
+
+// MergeNodes merges the duplicate entities of a group into the canonical
+// entity. The RELATES_TO and MENTIONS edges of the duplicates are redirected
+// to the canonical entity, dropping those stating a fact it already has,
+// their summaries, attributes and sources are merged into it, and the
+// duplicates are deleted, or tombstoned when the driver soft-deletes. The
+// canonical entity records the merged UUIDs in its "merged_uuids" metadata,
+// and with soft deletes each tombstoned duplicate also keeps an
+// IS_DUPLICATE_OF edge to it. The merge runs in a single transaction, so a
+// failure leaves the entities unchanged. It returns the updated canonical
+// entity.
+func (no *NodeOperations) MergeNodes(ctx context.Context, groupID, canonicalUUID string, duplicateUUIDs []string) (*types.Node, error) {
+	merger, ok := driver.AsNodeMerger(no.driver)
+	if !ok {
+		return nil, driver.ErrNodeMergeUnsupported
+	}
+
+	canonical, err := no.driver.GetNode(ctx, canonicalUUID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get canonical node %s: %w", canonicalUUID, err)
+	}
+
+	var duplicates []*types.Node
+	for _, uuid := range duplicateUUIDs {
+		if uuid == canonicalUUID || slices.ContainsFunc(duplicates, func(node *types.Node) bool { return node.Uuid == uuid }) {
+			continue
+		}
+		duplicate, err := no.driver.GetNode(ctx, uuid, groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get duplicate node %s: %w", uuid, err)
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+	if len(duplicates) == 0 {
+		return canonical, nil
+	}
+
+	softDeletes := false
+	if softDeleter, ok := driver.AsSoftDeleter(no.driver); ok {
+		softDeletes = softDeleter.SoftDeleteEnabled()
+	}
+
+	var uuids []string
+	var provenance []*types.Edge
+	for _, duplicate := range duplicates {
+		mergeNodeInto(canonical, duplicate)
+		uuids = append(uuids, duplicate.Uuid)
+		// Tombstoning the duplicate tombstones its edges, so the provenance
+		// edge is added afterwards
+		if softDeletes {
+			provenance = append(provenance, duplicateOfEdge(duplicate, canonical))
+		}
+	}
+	canonical.UpdatedAt = time.Now().UTC()
+	if err := merger.MergeNodes(ctx, canonical, uuids, provenance); err != nil {
+		return nil, err
+	}
+
+	if no.nameIndexes != nil {
+		no.nameIndexes.Forget(groupID)
+	}
+
+	no.logger.Info("Merged duplicate nodes",
+		"canonical_uuid", canonical.Uuid,
+		"merged_nodes", len(duplicates))
+	return canonical, nil
+}
+
+// mergeNodeInto merges the summary, entity type, metadata and sources of
+// duplicate into canonical. Values of canonical win over those of duplicate.
+func mergeNodeInto(canonical, duplicate *types.Node) {
+	if duplicate.Summary != "" && !strings.Contains(canonical.Summary, duplicate.Summary) {
+		if canonical.Summary == "" {
+			canonical.Summary = duplicate.Summary
+		} else {
+			canonical.Summary += "\n" + duplicate.Summary
+		}
+	}
+	if canonical.EntityType == "" || canonical.EntityType == "Entity" {
+		if duplicate.EntityType != "" {
+			canonical.EntityType = duplicate.EntityType
+		}
+	}
+	if !duplicate.CreatedAt.IsZero() && (canonical.CreatedAt.IsZero() || duplicate.CreatedAt.Before(canonical.CreatedAt)) {
+		canonical.CreatedAt = duplicate.CreatedAt
+	}
+
+	if canonical.Metadata == nil {
+		canonical.Metadata = make(map[string]interface{})
+	}
+	for key, value := range duplicate.Metadata {
+		if _, ok := canonical.Metadata[key]; !ok && key != mergedUUIDsKey {
+			canonical.Metadata[key] = value
+		}
+	}
+	merged := metadataStrings(canonical.Metadata[mergedUUIDsKey])
+	for _, uuid := range append([]string{duplicate.Uuid}, metadataStrings(duplicate.Metadata[mergedUUIDsKey])...) {
+		if !slices.Contains(merged, uuid) {
+			merged = append(merged, uuid)
+		}
+	}
+	canonical.Metadata[mergedUUIDsKey] = merged
+
+	for _, sourceID := range duplicate.SourceIDs {
+		if !slices.Contains(canonical.SourceIDs, sourceID) {
+			canonical.SourceIDs = append(canonical.SourceIDs, sourceID)
+		}
+	}
+}
+
+// metadataStrings returns a metadata list of strings, which decodes from JSON
+// as []interface{}.
+func metadataStrings(value interface{}) []string {
+	switch values := value.(type) {
+	case []string:
+		return slices.Clone(values)
+	case []interface{}:
+		strs := make([]string, 0, len(values))
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	}
+	return nil
+}
+
+// duplicateOfEdge returns the IS_DUPLICATE_OF edge from duplicate to canonical.
+func duplicateOfEdge(duplicate, canonical *types.Node) *types.Edge {
+	fact := fmt.Sprintf("%s is a duplicate of %s", duplicate.Name, canonical.Name)
+	now := time.Now().UTC()
+
+	edge := types.NewEntityEdge(
+		utils.GenerateUUID(),
+		duplicate.Uuid,
+		canonical.Uuid,
+		canonical.GroupID,
+		"IS_DUPLICATE_OF",
+		types.EntityEdgeType,
+	)
+	edge.Summary = fact
+	edge.Fact = fact
+	edge.UpdatedAt = now
+	edge.ValidFrom = now
+	return edge
+}
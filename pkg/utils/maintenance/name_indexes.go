@@ -0,0 +1,64 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils"
+)
+
+// NameIndexes holds a utils.NameIndex of the entity names of each group,
+// loaded from the graph on first use. Entities created by ResolveExtractedNodes
+// are added as they are resolved; entities written by other processes are only
+// seen after Forget.
+type NameIndexes struct {
+	driver driver.GraphDriver
+
+	mu     sync.Mutex
+	groups map[string]*utils.NameIndex
+}
+
+// NewNameIndexes creates an empty cache of name indexes over the graph of driver.
+func NewNameIndexes(driver driver.GraphDriver) *NameIndexes {
+	return &NameIndexes{
+		driver: driver,
+		groups: make(map[string]*utils.NameIndex),
+	}
+}
+
+// Get returns the name index of groupID, loading the group's entities on
+// first use.
+func (n *NameIndexes) Get(ctx context.Context, groupID string) (*utils.NameIndex, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if index, ok := n.groups[groupID]; ok {
+		return index, nil
+	}
+
+	nodes, err := n.driver.GetEntityNodesByGroup(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load entities of group %s: %w", groupID, err)
+	}
+	entities := make([]*types.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Type == "" || node.Type == types.EntityNodeType {
+			entities = append(entities, node)
+		}
+	}
+
+	index := utils.NewNameIndex(entities)
+	n.groups[groupID] = index
+	return index, nil
+}
+
+// Forget drops the index of groupID, so that it is loaded again on next use,
+// for example after entities were merged or deleted.
+func (n *NameIndexes) Forget(groupID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.groups, groupID)
+}
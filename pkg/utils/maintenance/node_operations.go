@@ -2,15 +2,19 @@ package maintenance
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
+	"maps"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/soundprediction/go-predicato/pkg/driver"
 	"github.com/soundprediction/go-predicato/pkg/embedder"
 	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
 	"github.com/soundprediction/go-predicato/pkg/prompts"
 	"github.com/soundprediction/go-predicato/pkg/types"
 	"github.com/soundprediction/go-predicato/pkg/utils"
@@ -19,6 +23,21 @@ import (
 const (
 	// MaxAttributeExtractionBatchSize is the maximum number of nodes to process in a single LLM call
 	MaxAttributeExtractionBatchSize = 24
+	// maxAttributeValidationRetries is the number of times nodes whose
+	// attributes violate their entity type are prompted again
+	maxAttributeValidationRetries = 2
+	// dedupeSimilarNodesLimit is the number of similar existing nodes searched
+	// per extracted node
+	dedupeSimilarNodesLimit = 10
+	// dedupeSimilarNodesMinScore is the cosine similarity an existing node needs
+	// to become a deduplication candidate, the Python DEFAULT_MIN_SCORE
+	dedupeSimilarNodesMinScore = 0.6
+	// dedupeNearExactMinScore is the cosine similarity of name embeddings
+	// near-exact names need to be resolved without the LLM
+	dedupeNearExactMinScore = 0.9
+	// dedupeEntityFactsLimit is the number of facts of each existing entity
+	// shown to the LLM when deduplicating
+	dedupeEntityFactsLimit = 5
 )
 
 // NodeOperations provides node-related maintenance operations
@@ -28,6 +47,19 @@ type NodeOperations struct {
 	embedder embedder.Client
 	prompts  prompts.Library
 	logger   *slog.Logger
+	// maxPromptTokens bounds the estimated size of extraction prompts, 0 for no limit
+	maxPromptTokens int
+	// nameIndexes resolves nodes by name before the LLM when set
+	nameIndexes *NameIndexes
+	// mergeReviewThreshold is the LLM duplicate confidence below which
+	// duplicates are queued for review instead of merged, 0 to merge all
+	mergeReviewThreshold float64
+	// maxReflexionIterations is the number of extra extraction passes for
+	// entities the reflexion step finds missing
+	maxReflexionIterations int
+	// summaryOptions control the length, style and sources of the summaries
+	// written by the LLM
+	summaryOptions prompts.SummaryOptions
 }
 
 // NewNodeOperations creates a new NodeOperations instance
@@ -38,6 +70,8 @@ func NewNodeOperations(driver driver.GraphDriver, llm llm.Client, embedder embed
 		embedder: embedder,
 		prompts:  prompts,
 		logger:   slog.Default(), // Use default logger, can be overridden
+
+		maxReflexionIterations: utils.GetMaxReflexionIterations(),
 	}
 }
 
@@ -46,30 +80,51 @@ func (no *NodeOperations) SetLogger(logger *slog.Logger) {
 	no.logger = logger
 }
 
+// SetMaxPromptTokens bounds the estimated size of extraction prompts, see
+// llm.PromptTokenLimit. Previous episodes are dropped from prompts over the
+// limit, oldest first. 0 disables the limit.
+func (no *NodeOperations) SetMaxPromptTokens(maxTokens int) {
+	no.maxPromptTokens = maxTokens
+}
+
+// SetNameIndexes makes ResolveExtractedNodes look extracted nodes up in the
+// MinHash/LSH name indexes first. Nodes with a unique exact or near-exact name
+// match are resolved without the LLM, and the other name matches are added to
+// the LLM's candidates. nil disables the name indexes.
+func (no *NodeOperations) SetNameIndexes(indexes *NameIndexes) {
+	no.nameIndexes = indexes
+}
+
+// SetMergeReviewThreshold makes ResolveExtractedNodes keep an extracted node
+// apart from the existing node the LLM judged it a duplicate of when the LLM's
+// confidence is below threshold, and store the pair as a driver.PendingMerge
+// for a human to approve or reject. Verdicts without a confidence are applied.
+// 0 applies every verdict.
+func (no *NodeOperations) SetMergeReviewThreshold(threshold float64) {
+	no.mergeReviewThreshold = threshold
+}
+
+// SetSummaryOptions sets the length, style and strictness of the entity
+// summaries written by ExtractAttributesFromNodes and RefreshSummaries.
+func (no *NodeOperations) SetSummaryOptions(opts prompts.SummaryOptions) {
+	no.summaryOptions = opts
+}
+
+// SetMaxReflexionIterations sets the number of extra passes ExtractNodes makes
+// after asking the LLM which entities the previous passes missed. It defaults
+// to the MAX_REFLEXION_ITERATIONS environment variable; 0 disables reflexion.
+func (no *NodeOperations) SetMaxReflexionIterations(iterations int) {
+	no.maxReflexionIterations = iterations
+}
+
 // ExtractNodes extracts entity nodes from episode content using LLM
 func (no *NodeOperations) ExtractNodes(ctx context.Context, episode *types.Node, previousEpisodes []*types.Node, entityTypes map[string]interface{}, excludedEntityTypes []string) ([]*types.Node, error) {
+	ctx = usage.WithOperation(ctx, usage.OperationExtractNodes)
+
 	start := time.Now()
 
 	// Prepare entity types context
-	entityTypesContext := []map[string]interface{}{
-		{
-			"entity_type_id":          0,
-			"entity_type_name":        "Entity",
-			"entity_type_description": "Default classification. Use this entity type if the entity is not one of the other listed types.",
-		},
-	}
-
-	if entityTypes != nil {
-		id := 1
-		for typeName := range entityTypes {
-			entityTypesContext = append(entityTypesContext, map[string]interface{}{
-				"entity_type_id":          id,
-				"entity_type_name":        typeName,
-				"entity_type_description": fmt.Sprintf("custom type: %s", typeName),
-			})
-			id++
-		}
-	}
+	entityTypesContext := buildEntityTypesContext(entityTypes)
 
 	// Prepare previous episodes content
 	previousEpisodeContents := make([]string, len(previousEpisodes))
@@ -85,95 +140,47 @@ func (no *NodeOperations) ExtractNodes(ctx context.Context, episode *types.Node,
 		"previous_episodes":  previousEpisodeContents,
 		"custom_prompt":      "",
 		"entity_types":       entityTypesContext,
-		"source_description": string(episode.EpisodeType),
+		"source_description": episodeSourceDescription(episode),
 		"ensure_ascii":       true,
 		"logger":             no.logger,
 	}
+	// Excluded types stay listed so that entities the LLM extracts anyway are
+	// classified with them and dropped below, rather than as another type
+	if len(excludedEntityTypes) > 0 {
+		promptContext["excluded_entity_types"] = excludedEntityTypes
+	}
 
-	// Extract entities with reflexion
-	entitiesMissed := true
-	reflexionIterations := 0
-	maxReflexionIterations := utils.GetMaxReflexionIterations()
-
-	var extractedEntities prompts.ExtractedEntities
-
-	for entitiesMissed && reflexionIterations <= maxReflexionIterations {
-		// Choose the appropriate extraction method based on episode source
-		var messages []types.Message
-		var err error
-
-		switch strings.ToLower(string(episode.EpisodeType)) {
-		case "message":
-			messages, err = no.prompts.ExtractNodes().ExtractMessage().Call(promptContext)
-		case "text":
-			messages, err = no.prompts.ExtractNodes().ExtractText().Call(promptContext)
-		case "json":
-			messages, err = no.prompts.ExtractNodes().ExtractJSON().Call(promptContext)
-		default:
-			messages, err = no.prompts.ExtractNodes().ExtractText().Call(promptContext)
-		}
-
+	// Extract entities, then extract again the entities reflexion finds missing
+	extracted, err := no.extractEntities(ctx, episode, promptContext)
+	if err != nil {
+		return nil, err
+	}
+	for iteration := 0; iteration < no.maxReflexionIterations; iteration++ {
+		missedEntities, err := no.extractNodesReflexion(ctx, episode, previousEpisodes, prompts.ExtractedEntities{ExtractedEntities: extracted})
 		if err != nil {
-			return nil, fmt.Errorf("failed to create extraction prompt: %w", err)
+			log.Printf("Warning: reflexion failed: %v", err)
+			break
 		}
-
-		// Create CSV parser function for ExtractedEntity
-		csvParser := func(csvContent string) ([]*prompts.ExtractedEntity, error) {
-			return utils.DuckDbUnmarshalCSV[prompts.ExtractedEntity](csvContent, '\t')
+		if len(missedEntities) == 0 {
+			break
 		}
 
-		// Use GenerateCSVResponse for robust CSV parsing with retries
-		extractedEntitySlice, badResp, err := llm.GenerateCSVResponse[prompts.ExtractedEntity](
-			ctx,
-			no.llm,
-			no.logger,
-			messages,
-			csvParser,
-			3, // maxRetries
-		)
-
-		if err != nil {
-			// Log detailed error information
-			if badResp != nil {
-				no.logger.Error("Failed to extract entities from CSV",
-					"error", badResp.Error,
-					"response_length", len(badResp.Response),
-					"num_messages", len(badResp.Messages))
-				if badResp.Response != "" {
-					fmt.Printf("\nFailed LLM response:\n%v\n\n", badResp.Response)
-				}
-			}
-			return nil, fmt.Errorf("failed to extract entities from csv: %w", err)
+		customPrompt := "Make sure that the following entities are extracted:"
+		for _, entity := range missedEntities {
+			customPrompt += fmt.Sprintf("\n%s,", entity)
 		}
+		promptContext["custom_prompt"] = customPrompt
 
-		// Convert to ExtractedEntities struct
-		extractedEntities.ExtractedEntities = extractedEntitySlice
-
-		reflexionIterations++
-		if reflexionIterations < maxReflexionIterations {
-			// Run reflexion to check for missed entities
-			missedEntities, err := no.extractNodesReflexion(ctx, episode, previousEpisodes, extractedEntities)
-			if err != nil {
-				log.Printf("Warning: reflexion failed: %v", err)
-				break
-			}
-
-			entitiesMissed = len(missedEntities) > 0
-			if entitiesMissed {
-				customPrompt := "Make sure that the following entities are extracted:"
-				for _, entity := range missedEntities {
-					customPrompt += fmt.Sprintf("\n%s,", entity)
-				}
-				promptContext["custom_prompt"] = customPrompt
-			}
-		} else {
-			entitiesMissed = false
+		reextracted, err := no.extractEntities(ctx, episode, promptContext)
+		if err != nil {
+			return nil, err
 		}
+		extracted = mergeExtractedEntities(extracted, reextracted)
 	}
 
 	// Filter out empty entity names
 	var filteredEntities []prompts.ExtractedEntity
-	for _, entity := range extractedEntities.ExtractedEntities {
+	for _, entity := range extracted {
 		if strings.TrimSpace(entity.Name) != "" {
 			filteredEntities = append(filteredEntities, entity)
 		}
@@ -192,19 +199,13 @@ func (no *NodeOperations) ExtractNodes(ctx context.Context, episode *types.Node,
 			entityTypeName = "Entity"
 		}
 
-		// Check if this entity type should be excluded
-		if len(excludedEntityTypes) > 0 {
-			excluded := false
-			for _, excludedType := range excludedEntityTypes {
-				if entityTypeName == excludedType {
-					excluded = true
-					break
-				}
-			}
-			if excluded {
-				log.Printf("Excluding entity %s of type %s", extractedEntity.Name, entityTypeName)
-				continue
-			}
+		// Drop the entities the LLM still classified with an excluded type
+		if slices.Contains(excludedEntityTypes, entityTypeName) {
+			no.logger.Info("Dropped extracted entity of excluded type",
+				"entity", extractedEntity.Name,
+				"entity_type", entityTypeName,
+				"episode", episode.Uuid)
+			continue
 		}
 
 		node := &types.Node{
@@ -227,6 +228,114 @@ func (no *NodeOperations) ExtractNodes(ctx context.Context, episode *types.Node,
 	return extractedNodes, nil
 }
 
+// buildEntityTypesContext lists the entity types for classification prompts,
+// identified by their index, after the default Entity type with ID 0.
+func buildEntityTypesContext(entityTypes map[string]interface{}) []map[string]interface{} {
+	entityTypesContext := []map[string]interface{}{
+		{
+			"entity_type_id":          0,
+			"entity_type_name":        "Entity",
+			"entity_type_description": "Default classification. Use this entity type if the entity is not one of the other listed types.",
+		},
+	}
+
+	typeNames := slices.Sorted(maps.Keys(entityTypes))
+	for _, typeName := range typeNames {
+		entityTypesContext = append(entityTypesContext, map[string]interface{}{
+			"entity_type_id":          len(entityTypesContext),
+			"entity_type_name":        typeName,
+			"entity_type_description": fmt.Sprintf("custom type: %s", typeName),
+		})
+	}
+	return entityTypesContext
+}
+
+// episodeSourceDescription returns the source description of an episode,
+// or its type when it has none.
+func episodeSourceDescription(episode *types.Node) string {
+	if description, ok := episode.Metadata[types.SourceDescriptionMetadataKey].(string); ok && description != "" {
+		return description
+	}
+	return string(episode.EpisodeType)
+}
+
+// extractEntities runs one entity extraction pass over an episode, with the
+// prompt matching the episode source.
+func (no *NodeOperations) extractEntities(ctx context.Context, episode *types.Node, promptContext map[string]interface{}) ([]prompts.ExtractedEntity, error) {
+	var prompt prompts.PromptVersion
+	switch strings.ToLower(string(episode.EpisodeType)) {
+	case string(types.MessageEpisodeType):
+		prompt = no.prompts.ExtractNodes().ExtractMessage()
+	case "text":
+		prompt = no.prompts.ExtractNodes().ExtractText()
+	case string(types.JSONEpisodeType):
+		prompt = no.prompts.ExtractNodes().ExtractJSON()
+	default:
+		prompt = no.prompts.ExtractNodes().ExtractText()
+	}
+
+	messages, fits, err := buildPromptWithinBudget(prompt, promptContext, no.maxPromptTokens, no.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create extraction prompt: %w", err)
+	}
+	if !fits {
+		no.logger.Warn("Entity extraction prompt exceeds the token budget without previous episodes",
+			"episode", episode.Uuid,
+			"max_prompt_tokens", no.maxPromptTokens)
+	}
+
+	// Create CSV parser function for ExtractedEntity
+	csvParser := func(csvContent string) ([]*prompts.ExtractedEntity, error) {
+		return utils.DuckDbUnmarshalCSV[prompts.ExtractedEntity](csvContent, '\t')
+	}
+
+	// Use GenerateCSVResponse for robust CSV parsing with retries
+	extractedEntitySlice, badResp, err := llm.GenerateCSVResponse[prompts.ExtractedEntity](
+		ctx,
+		no.llm,
+		no.logger,
+		messages,
+		csvParser,
+		3, // maxRetries
+	)
+
+	if err != nil {
+		// Log detailed error information
+		if badResp != nil {
+			no.logger.Error("Failed to extract entities from CSV",
+				"error", badResp.Error,
+				"response_length", len(badResp.Response),
+				"num_messages", len(badResp.Messages))
+			if badResp.Response != "" {
+				fmt.Printf("\nFailed LLM response:\n%v\n\n", badResp.Response)
+			}
+		}
+		return nil, fmt.Errorf("failed to extract entities from csv: %w", err)
+	}
+	return extractedEntitySlice, nil
+}
+
+// mergeExtractedEntities adds the entities of a reflexion pass to those
+// extracted before, keeping the classification of the latest pass for
+// entities extracted twice.
+func mergeExtractedEntities(extracted, reextracted []prompts.ExtractedEntity) []prompts.ExtractedEntity {
+	index := make(map[string]int, len(extracted))
+	merged := slices.Clone(extracted)
+	for i, entity := range merged {
+		index[strings.ToLower(strings.TrimSpace(entity.Name))] = i
+	}
+	for _, entity := range reextracted {
+		key := strings.ToLower(strings.TrimSpace(entity.Name))
+		if i, ok := index[key]; ok {
+			merged[i] = entity
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, entity)
+	}
+	return merged
+}
+
 // extractNodesReflexion performs reflexion to identify missed entities
 func (no *NodeOperations) extractNodesReflexion(ctx context.Context, episode *types.Node, previousEpisodes []*types.Node, extractedEntities prompts.ExtractedEntities) ([]string, error) {
 	// Get entity names
@@ -243,14 +352,14 @@ func (no *NodeOperations) extractNodesReflexion(ctx context.Context, episode *ty
 
 	// Prepare context for reflexion
 	promptContext := map[string]interface{}{
-		"episode_content":    episode.Summary,
+		"episode_content":    episode.Content,
 		"previous_episodes":  previousEpisodeContents,
 		"extracted_entities": entityNames,
 		"ensure_ascii":       true,
 		"logger":             no.logger,
 	}
 
-	messages, err := no.prompts.ExtractNodes().Reflexion().Call(promptContext)
+	messages, _, err := buildPromptWithinBudget(no.prompts.ExtractNodes().Reflexion(), promptContext, no.maxPromptTokens, no.logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create reflexion prompt: %w", err)
 	}
@@ -286,10 +395,142 @@ func (no *NodeOperations) extractNodesReflexion(ctx context.Context, episode *ty
 
 // ResolveExtractedNodes resolves newly extracted nodes against existing ones in the graph
 func (no *NodeOperations) ResolveExtractedNodes(ctx context.Context, extractedNodes []*types.Node, episode *types.Node, previousEpisodes []*types.Node, entityTypes map[string]interface{}) ([]*types.Node, map[string]string, []NodePair, error) {
+	ctx = usage.WithOperation(ctx, usage.OperationDedupeNodes)
+
 	if len(extractedNodes) == 0 {
 		return []*types.Node{}, make(map[string]string), []NodePair{}, nil
 	}
+	if no.nameIndexes == nil {
+		return no.resolveExtractedNodesWithLLM(ctx, extractedNodes, nil, episode, previousEpisodes, entityTypes)
+	}
+
+	resolvedNodes, uuidMap, nodeDuplicates, pending, nameCandidates := no.resolveByNameIndex(ctx, extractedNodes)
+	if len(nodeDuplicates) > 0 {
+		edgeOps := NewEdgeOperations(no.driver, no.llm, no.embedder, no.prompts)
+		filteredDuplicates, err := edgeOps.FilterExistingDuplicateOfEdges(ctx, nodeDuplicates)
+		if err != nil {
+			log.Printf("Warning: failed to filter existing duplicate edges: %v", err)
+		} else {
+			nodeDuplicates = filteredDuplicates
+		}
+	}
+
+	if len(pending) > 0 {
+		llmResolved, llmUUIDMap, llmDuplicates, err := no.resolveExtractedNodesWithLLM(ctx, pending, nameCandidates, episode, previousEpisodes, entityTypes)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		resolvedNodes = append(resolvedNodes, llmResolved...)
+		for uuid, resolvedUUID := range llmUUIDMap {
+			uuidMap[uuid] = resolvedUUID
+		}
+		nodeDuplicates = append(nodeDuplicates, llmDuplicates...)
+	}
+
+	// Index the new entities so that later episodes resolve to them
+	newNodes := make(map[string][]*types.Node)
+	for _, node := range pending {
+		if resolvedUUID, ok := uuidMap[node.Uuid]; !ok || resolvedUUID == node.Uuid {
+			newNodes[node.GroupID] = append(newNodes[node.GroupID], node)
+		}
+	}
+	for groupID, nodes := range newNodes {
+		if index, err := no.nameIndexes.Get(ctx, groupID); err == nil {
+			index.Add(nodes...)
+		}
+	}
 
+	return resolvedNodes, uuidMap, nodeDuplicates, nil
+}
+
+// resolveByNameIndex resolves the extracted nodes with a unique exact or
+// near-exact name match in the name index of their group. It returns the
+// nodes left for the LLM with their name matches, keyed by node UUID. Nodes
+// of groups whose index cannot be loaded are all left for the LLM.
+func (no *NodeOperations) resolveByNameIndex(ctx context.Context, extractedNodes []*types.Node) ([]*types.Node, map[string]string, []NodePair, []*types.Node, map[string][]*types.Node) {
+	var resolvedNodes []*types.Node
+	uuidMap := make(map[string]string)
+	var nodeDuplicates []NodePair
+	var pending []*types.Node
+	nameCandidates := make(map[string][]*types.Node)
+
+	// Name indexes are per group
+	byGroup := make(map[string][]*types.Node)
+	var groupIDs []string
+	for _, node := range extractedNodes {
+		if _, ok := byGroup[node.GroupID]; !ok {
+			groupIDs = append(groupIDs, node.GroupID)
+		}
+		byGroup[node.GroupID] = append(byGroup[node.GroupID], node)
+	}
+
+	for _, groupID := range groupIDs {
+		nodes := byGroup[groupID]
+		index, err := no.nameIndexes.Get(ctx, groupID)
+		if err != nil {
+			log.Printf("Warning: failed to load name index: %v", err)
+			pending = append(pending, nodes...)
+			continue
+		}
+
+		state := index.Resolve(nodes)
+		for _, pair := range state.DuplicatePairs {
+			nodeDuplicates = append(nodeDuplicates, NodePair{Source: pair.Source, Target: pair.Target})
+		}
+		unresolved := make(map[int]bool, len(state.UnresolvedIndices))
+		for _, idx := range state.UnresolvedIndices {
+			unresolved[idx] = true
+		}
+		for idx, node := range nodes {
+			if unresolved[idx] || state.ResolvedNodes[idx] == nil {
+				pending = append(pending, node)
+				nameCandidates[node.Uuid] = index.Candidates(node.Name, dedupeSimilarNodesLimit)
+				continue
+			}
+			resolvedNodes = append(resolvedNodes, state.ResolvedNodes[idx])
+			uuidMap[node.Uuid] = state.ResolvedNodes[idx].Uuid
+		}
+	}
+
+	log.Printf("Resolved %d of %d nodes by name", len(resolvedNodes), len(extractedNodes))
+	return resolvedNodes, uuidMap, nodeDuplicates, pending, nameCandidates
+}
+
+// needsMergeReview reports whether the LLM's confidence in a duplicate is too
+// low to merge without review.
+func (no *NodeOperations) needsMergeReview(resolution prompts.NodeDuplicate) bool {
+	return no.mergeReviewThreshold > 0 && resolution.Confidence != nil && *resolution.Confidence < no.mergeReviewThreshold
+}
+
+// queuePendingMerge stores the pair of extractedNode and its probable
+// duplicate existingNode for review. Failures are logged, leaving the nodes
+// apart.
+func (no *NodeOperations) queuePendingMerge(ctx context.Context, extractedNode, existingNode *types.Node, score float64) {
+	err := driver.AddPendingMerge(ctx, no.driver, &driver.PendingMerge{
+		GroupID:       extractedNode.GroupID,
+		NodeUUID:      extractedNode.Uuid,
+		NodeName:      extractedNode.Name,
+		CanonicalUUID: existingNode.Uuid,
+		CanonicalName: existingNode.Name,
+		Score:         score,
+	})
+	if err != nil {
+		no.logger.Warn("Failed to queue duplicate for review",
+			"node_uuid", extractedNode.Uuid,
+			"canonical_uuid", existingNode.Uuid,
+			"error", err)
+		return
+	}
+	no.logger.Info("Queued duplicate for review",
+		"node", extractedNode.Name,
+		"canonical", existingNode.Name,
+		"confidence", score)
+}
+
+// resolveExtractedNodesWithLLM resolves extractedNodes against the existing
+// nodes found by name and embedding search and against nameCandidates, keyed
+// by extracted node UUID, by asking the LLM.
+func (no *NodeOperations) resolveExtractedNodesWithLLM(ctx context.Context, extractedNodes []*types.Node, nameCandidates map[string][]*types.Node, episode *types.Node, previousEpisodes []*types.Node, entityTypes map[string]interface{}) ([]*types.Node, map[string]string, []NodePair, error) {
 	// Search for existing nodes that might be duplicates
 	var candidateNodes []*types.Node
 	searchResults := make(map[string][]*types.Node)
@@ -311,6 +552,104 @@ func (no *NodeOperations) ResolveExtractedNodes(ctx context.Context, extractedNo
 		candidateNodes = append(candidateNodes, nodes...)
 	}
 
+	// Add the nodes with similar names, searched for all nodes at once
+	similarNodes, nameEmbeddings := no.searchSimilarNodes(ctx, extractedNodes)
+	for uuid, nodes := range similarNodes {
+		searchResults[uuid] = append(searchResults[uuid], nodes...)
+		candidateNodes = append(candidateNodes, nodes...)
+	}
+	for uuid, nodes := range nameCandidates {
+		searchResults[uuid] = append(searchResults[uuid], nodes...)
+		candidateNodes = append(candidateNodes, nodes...)
+	}
+
+	// Nodes with a single near-exact candidate are resolved without the LLM
+	resolvedNodes, uuidMap, nodeDuplicates, pending := resolveNearExactNames(extractedNodes, searchResults, nameEmbeddings)
+	if len(pending) > 0 {
+		llmResolved, llmUUIDMap, llmDuplicates, err := no.dedupeNodesWithLLM(ctx, pending, candidateNodes, episode, previousEpisodes, entityTypes)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		resolvedNodes = append(resolvedNodes, llmResolved...)
+		maps.Copy(uuidMap, llmUUIDMap)
+		nodeDuplicates = append(nodeDuplicates, llmDuplicates...)
+	}
+
+	log.Printf("Resolved %d nodes, found %d duplicates", len(resolvedNodes), len(nodeDuplicates))
+
+	// Filter duplicates using edge operations to remove those that already have IS_DUPLICATE_OF edges
+	edgeOps := NewEdgeOperations(no.driver, no.llm, no.embedder, no.prompts)
+	filteredDuplicates, err := edgeOps.FilterExistingDuplicateOfEdges(ctx, nodeDuplicates)
+	if err != nil {
+		log.Printf("Warning: failed to filter existing duplicate edges: %v", err)
+		filteredDuplicates = nodeDuplicates
+	}
+
+	return resolvedNodes, uuidMap, filteredDuplicates, nil
+}
+
+// resolveNearExactNames resolves the extracted nodes whose candidates, keyed
+// by extracted node UUID, include a single entity with a near-exact name
+// (utils.NearExactNames) and a compatible entity type. When the name
+// embeddings of both are known, they must also be similar. It returns the
+// nodes left for the LLM.
+func resolveNearExactNames(extractedNodes []*types.Node, candidates map[string][]*types.Node, nameEmbeddings map[string][]float32) ([]*types.Node, map[string]string, []NodePair, []*types.Node) {
+	var resolvedNodes []*types.Node
+	uuidMap := make(map[string]string)
+	var nodeDuplicates []NodePair
+	var pending []*types.Node
+	for _, node := range extractedNodes {
+		var match *types.Node
+		ambiguous := false
+		for _, candidate := range candidates[node.Uuid] {
+			if candidate.Uuid == node.Uuid || (match != nil && candidate.Uuid == match.Uuid) ||
+				!nearExactMatch(node, candidate, nameEmbeddings[node.Uuid]) {
+				continue
+			}
+			if match != nil {
+				ambiguous = true
+				break
+			}
+			match = candidate
+		}
+		if match == nil || ambiguous {
+			pending = append(pending, node)
+			continue
+		}
+		resolvedNodes = append(resolvedNodes, match)
+		uuidMap[node.Uuid] = match.Uuid
+		nodeDuplicates = append(nodeDuplicates, NodePair{Source: node, Target: match})
+	}
+	if len(resolvedNodes) > 0 {
+		log.Printf("Resolved %d of %d nodes by near-exact name", len(resolvedNodes), len(extractedNodes))
+	}
+	return resolvedNodes, uuidMap, nodeDuplicates, pending
+}
+
+// nearExactMatch reports whether candidate is a near-exact match of node:
+// names equal once normalized, or near-exact names of similar embeddings when
+// both are known, and entity types that do not differ.
+func nearExactMatch(node, candidate *types.Node, nameEmbedding []float32) bool {
+	if node.EntityType != "" && candidate.EntityType != "" && node.EntityType != "Entity" &&
+		candidate.EntityType != "Entity" && node.EntityType != candidate.EntityType {
+		return false
+	}
+	if utils.NormalizeStringExact(node.Name) == utils.NormalizeStringExact(candidate.Name) {
+		return true
+	}
+	if !utils.NearExactNames(node.Name, candidate.Name) {
+		return false
+	}
+	if len(nameEmbedding) > 0 && len(candidate.NameEmbedding) == len(nameEmbedding) {
+		return utils.CalculateCosineSimilarity(nameEmbedding, candidate.NameEmbedding) >= dedupeNearExactMinScore
+	}
+	return true
+}
+
+// dedupeNodesWithLLM resolves extractedNodes against candidateNodes by asking
+// the LLM, with the facts of the candidates in the graph. When the LLM fails,
+// the nodes are left unresolved.
+func (no *NodeOperations) dedupeNodesWithLLM(ctx context.Context, extractedNodes []*types.Node, candidateNodes []*types.Node, episode *types.Node, previousEpisodes []*types.Node, entityTypes map[string]interface{}) ([]*types.Node, map[string]string, []NodePair, error) {
 	// Remove duplicates from candidate nodes
 	candidateMap := make(map[string]*types.Node)
 	for _, node := range candidateNodes {
@@ -349,6 +688,7 @@ func (no *NodeOperations) ResolveExtractedNodes(ctx context.Context, extractedNo
 		}
 	}
 
+	existingFacts := no.existingEntityFacts(ctx, existingNodes)
 	existingNodesContext := make([]map[string]interface{}, len(existingNodes))
 	for i, node := range existingNodes {
 		existingNodesContext[i] = map[string]interface{}{
@@ -361,6 +701,18 @@ func (no *NodeOperations) ResolveExtractedNodes(ctx context.Context, extractedNo
 		for k, v := range node.Metadata {
 			existingNodesContext[i][k] = v
 		}
+		// Add the neighbors and facts of the entity in the graph
+		if facts := existingFacts[node.Uuid]; len(facts) > 0 {
+			var neighbors, factTexts []string
+			for _, fact := range facts {
+				if fact.Neighbor != "" && !slices.Contains(neighbors, fact.Neighbor) {
+					neighbors = append(neighbors, fact.Neighbor)
+				}
+				factTexts = append(factTexts, fact.Fact)
+			}
+			existingNodesContext[i]["neighbors"] = strings.Join(neighbors, ", ")
+			existingNodesContext[i]["facts"] = strings.Join(factTexts, "; ")
+		}
 	}
 
 	// Prepare previous episodes content
@@ -438,6 +790,14 @@ func (no *NodeOperations) ResolveExtractedNodes(ctx context.Context, extractedNo
 			resolvedNode = extractedNode
 		}
 
+		// Keep uncertain duplicates apart and queue them for review
+		if resolvedNode != extractedNode && no.needsMergeReview(resolution) {
+			no.queuePendingMerge(ctx, extractedNode, resolvedNode, *resolution.Confidence)
+			resolvedNodes = append(resolvedNodes, extractedNode)
+			uuidMap[extractedNode.Uuid] = extractedNode.Uuid
+			continue
+		}
+
 		resolvedNodes = append(resolvedNodes, resolvedNode)
 		uuidMap[extractedNode.Uuid] = resolvedNode.Uuid
 
@@ -453,17 +813,30 @@ func (no *NodeOperations) ResolveExtractedNodes(ctx context.Context, extractedNo
 		}
 	}
 
-	log.Printf("Resolved %d nodes, found %d duplicates", len(resolvedNodes), len(nodeDuplicates))
+	return resolvedNodes, uuidMap, nodeDuplicates, nil
+}
 
-	// Filter duplicates using edge operations to remove those that already have IS_DUPLICATE_OF edges
-	edgeOps := NewEdgeOperations(no.driver, no.llm, no.embedder, no.prompts)
-	filteredDuplicates, err := edgeOps.FilterExistingDuplicateOfEdges(ctx, nodeDuplicates)
-	if err != nil {
-		log.Printf("Warning: failed to filter existing duplicate edges: %v", err)
-		filteredDuplicates = nodeDuplicates
+// existingEntityFacts returns the most recent facts of the entities of nodes
+// in the graph, keyed by entity UUID. Failures are logged, leaving the
+// entities without facts.
+func (no *NodeOperations) existingEntityFacts(ctx context.Context, nodes []*types.Node) map[string][]driver.EntityFact {
+	facts := make(map[string][]driver.EntityFact)
+	byGroup := make(map[string][]string)
+	for _, node := range nodes {
+		byGroup[node.GroupID] = append(byGroup[node.GroupID], node.Uuid)
 	}
-
-	return resolvedNodes, uuidMap, filteredDuplicates, nil
+	for groupID, uuids := range byGroup {
+		groupFacts, err := driver.EntityFacts(ctx, no.driver, groupID, uuids, dedupeEntityFactsLimit)
+		if errors.Is(err, driver.ErrEntityFactsUnsupported) {
+			return facts
+		}
+		if err != nil {
+			log.Printf("Warning: failed to get facts of deduplication candidates: %v", err)
+			continue
+		}
+		maps.Copy(facts, groupFacts)
+	}
+	return facts
 }
 
 func bypassResolveExtractedNodes(ctx context.Context, nodes []*types.Node) ([]*types.Node, map[string]string, []NodePair, error) {
@@ -471,8 +844,15 @@ func bypassResolveExtractedNodes(ctx context.Context, nodes []*types.Node) ([]*t
 
 }
 
-// ExtractAttributesFromNodes extracts and updates attributes for nodes using LLM in batches
+// ExtractAttributesFromNodes extracts and updates summaries and attributes for
+// nodes using LLM in batches. The attributes of a node are validated against
+// the struct defining its entity type in entityTypes; nodes with invalid
+// attributes are prompted again with the violations, up to
+// maxAttributeValidationRetries times. Valid attributes are stored in the node
+// metadata converted to their types, and invalid ones are dropped.
 func (no *NodeOperations) ExtractAttributesFromNodes(ctx context.Context, nodes []*types.Node, episode *types.Node, previousEpisodes []*types.Node, entityTypes map[string]interface{}) ([]*types.Node, error) {
+	ctx = usage.WithOperation(ctx, usage.OperationExtractAttributes)
+
 	if len(nodes) == 0 {
 		return nodes, nil
 	}
@@ -485,8 +865,16 @@ func (no *NodeOperations) ExtractAttributesFromNodes(ctx context.Context, nodes
 		previousEpisodeContents[i] = ep.Summary
 	}
 
-	// Map to store all extracted attributes by original node index
+	schemas := make(map[string]attributeSchema)
+	for name, entityType := range entityTypes {
+		if schema := entityAttributeSchema(entityType); len(schema) > 0 {
+			schemas[name] = schema
+		}
+	}
+
+	// Maps to store all extracted summaries and validated attributes by original node index
 	allExtractedMap := make(map[int]*prompts.ExtractedNodeAttributes)
+	allAttributesMap := make(map[int]map[string]interface{})
 
 	// Process nodes in batches
 	for batchStart := 0; batchStart < len(nodes); batchStart += MaxAttributeExtractionBatchSize {
@@ -508,104 +896,233 @@ func (no *NodeOperations) ExtractAttributesFromNodes(ctx context.Context, nodes
 				"entity_types": []string{"Entity", node.EntityType},
 				"attributes":   node.Metadata,
 			}
+			if schema, ok := schemas[node.EntityType]; ok {
+				nodesContext[i]["attribute_schema"] = schema.describe()
+			}
 		}
 
-		// Prepare context for batch LLM call
-		promptContext := map[string]interface{}{
-			"nodes":             nodesContext,
-			"episode_content":   episode.Content,
-			"previous_episodes": previousEpisodeContents,
-			"ensure_ascii":      true,
-			"logger":            no.logger,
-		}
-
-		// Call batch extraction prompt
-		messages, err := no.prompts.ExtractNodes().ExtractAttributesBatch().Call(promptContext)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create batch extraction prompt: %w", err)
-		}
-
-		// Create CSV parser function for ExtractedNodeAttributes
-		csvParser := func(csvContent string) ([]*prompts.ExtractedNodeAttributes, error) {
-			return utils.DuckDbUnmarshalCSV[prompts.ExtractedNodeAttributes](csvContent, '\t')
-		}
+		pending := nodesContext
+		for attempt := 0; len(pending) > 0; attempt++ {
+			extractedAttributesSlice, err := no.extractAttributesBatch(ctx, pending, episode, previousEpisodeContents)
+			if err != nil {
+				return nil, err
+			}
 
-		// Use GenerateCSVResponse for robust CSV parsing with retries
-		extractedAttributesSlice, badResp, err := llm.GenerateCSVResponse[prompts.ExtractedNodeAttributes](
-			ctx,
-			no.llm,
-			no.logger,
-			messages,
-			csvParser,
-			3, // maxRetries
-		)
+			// Nodes whose attributes violate their schema are prompted again
+			var retry []map[string]interface{}
+			for _, extracted := range extractedAttributesSlice {
+				if extracted.NodeID < 0 || extracted.NodeID >= len(batchNodes) {
+					continue
+				}
+				globalIndex := batchStart + extracted.NodeID
+				if extracted.Summary != "" || allExtractedMap[globalIndex] == nil {
+					allExtractedMap[globalIndex] = &extracted
+				}
 
-		if err != nil {
-			// Log detailed error information
-			if badResp != nil {
-				no.logger.Error("Failed to extract batch attributes from CSV",
-					"error", badResp.Error,
-					"response_length", len(badResp.Response),
-					"num_messages", len(badResp.Messages))
-				if badResp.Response != "" {
-					fmt.Printf("\nFailed LLM response:\n%v\n\n", badResp.Response)
+				schema, ok := schemas[batchNodes[extracted.NodeID].EntityType]
+				if !ok {
+					continue
+				}
+				attributes, violations := schema.validate(extracted.Attributes)
+				if attributes != nil {
+					allAttributesMap[globalIndex] = attributes
+				}
+				if len(violations) == 0 {
+					continue
+				}
+				if attempt < maxAttributeValidationRetries {
+					nodeContext := maps.Clone(nodesContext[extracted.NodeID])
+					nodeContext["violations"] = strings.Join(violations, "; ")
+					retry = append(retry, nodeContext)
+				} else {
+					no.logger.Warn("Dropping invalid entity attributes",
+						"node", batchNodes[extracted.NodeID].Name,
+						"entity_type", batchNodes[extracted.NodeID].EntityType,
+						"violations", violations)
 				}
 			}
-			return nil, fmt.Errorf("failed to parse batch extraction TSV: %w", err)
-		}
-
-		// Store extracted attributes with global node index
-		for _, extracted := range extractedAttributesSlice {
-			globalIndex := batchStart + extracted.NodeID
-			allExtractedMap[globalIndex] = &extracted
+			pending = retry
 		}
 	}
 
-	// Update all nodes with extracted summaries
+	// Update all nodes with extracted summaries and attributes
 	var updatedNodes []*types.Node
 	for i, node := range nodes {
 		updatedNode := *node // Copy the node
 		updatedNode.UpdatedAt = time.Now().UTC()
 
 		if extracted, ok := allExtractedMap[i]; ok {
-			updatedNode.Summary = extracted.Summary
+			if extracted.Summary != "" {
+				updatedNode.Summary = extracted.Summary
+			}
 		} else {
 			log.Printf("Warning: no extraction result for node %d (%s), keeping original", i, node.Name)
 		}
+		if attributes, ok := allAttributesMap[i]; ok && len(attributes) > 0 {
+			updatedNode.Metadata = maps.Clone(node.Metadata)
+			if updatedNode.Metadata == nil {
+				updatedNode.Metadata = make(map[string]interface{})
+			}
+			maps.Copy(updatedNode.Metadata, attributes)
+		}
 
 		updatedNodes = append(updatedNodes, &updatedNode)
 	}
 
 	// Create embeddings for all updated nodes
-	for _, node := range updatedNodes {
-		if err := no.createNodeEmbedding(ctx, node); err != nil {
-			log.Printf("Warning: failed to create embedding for node %s: %v", node.Name, err)
-		}
+	if err := no.createNodeEmbeddings(ctx, updatedNodes); err != nil {
+		log.Printf("Warning: failed to create embeddings for %d nodes: %v", len(updatedNodes), err)
 	}
 
 	log.Printf("Successfully extracted attributes for %d entities", len(updatedNodes))
 	return updatedNodes, nil
 }
 
-// createNodeEmbedding creates an embedding for a node based on its name and summary
-func (no *NodeOperations) createNodeEmbedding(ctx context.Context, node *types.Node) error {
-	// Create text for embedding from name and summary
-	if node == nil {
-		return nil
+// extractAttributesBatch prompts for the summaries and attributes of the
+// nodes described by nodesContext.
+func (no *NodeOperations) extractAttributesBatch(ctx context.Context, nodesContext []map[string]interface{}, episode *types.Node, previousEpisodeContents []string) ([]prompts.ExtractedNodeAttributes, error) {
+	// Prepare context for batch LLM call
+	promptContext := map[string]interface{}{
+		"nodes":                   nodesContext,
+		"episode_content":         episode.Content,
+		"previous_episodes":       previousEpisodeContents,
+		prompts.SummaryOptionsKey: no.summaryOptions,
+		"ensure_ascii":            true,
+		"logger":                  no.logger,
 	}
-	text := node.Name
-	if node.Summary != "" {
-		text += " " + node.Summary
+
+	// Call batch extraction prompt
+	messages, err := no.prompts.ExtractNodes().ExtractAttributesBatch().Call(promptContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch extraction prompt: %w", err)
 	}
 
-	embedding, err := no.embedder.EmbedSingle(ctx, text)
+	// Create CSV parser function for ExtractedNodeAttributes
+	csvParser := func(csvContent string) ([]*prompts.ExtractedNodeAttributes, error) {
+		return utils.DuckDbUnmarshalCSV[prompts.ExtractedNodeAttributes](csvContent, '\t')
+	}
+
+	// Use GenerateCSVResponse for robust CSV parsing with retries
+	extractedAttributesSlice, badResp, err := llm.GenerateCSVResponse[prompts.ExtractedNodeAttributes](
+		ctx,
+		no.llm,
+		no.logger,
+		messages,
+		csvParser,
+		3, // maxRetries
+	)
+
 	if err != nil {
-		return fmt.Errorf("failed to create embedding: %w", err)
+		// Log detailed error information
+		if badResp != nil {
+			no.logger.Error("Failed to extract batch attributes from CSV",
+				"error", badResp.Error,
+				"response_length", len(badResp.Response),
+				"num_messages", len(badResp.Messages))
+			if badResp.Response != "" {
+				fmt.Printf("\nFailed LLM response:\n%v\n\n", badResp.Response)
+			}
+		}
+		return nil, fmt.Errorf("failed to parse batch extraction TSV: %w", err)
+	}
+	return extractedAttributesSlice, nil
+}
+
+// searchSimilarNodes returns the existing entities whose embeddings are most
+// similar to the name of each extracted node, and the name embeddings, keyed
+// by extracted node UUID. The names are embedded in one request and searched
+// with one bulk vector search per group. Failures are logged, leaving only
+// the text candidates.
+func (no *NodeOperations) searchSimilarNodes(ctx context.Context, extractedNodes []*types.Node) (map[string][]*types.Node, map[string][]float32) {
+	similar := make(map[string][]*types.Node)
+	nameEmbeddings := make(map[string][]float32)
+	if no.embedder == nil {
+		return similar, nameEmbeddings
 	}
 
-	node.Embedding = embedding
+	names := make([]string, len(extractedNodes))
+	for i, node := range extractedNodes {
+		names[i] = node.Name
+	}
+	embeddings, err := no.embedder.Embed(ctx, names)
+	if err != nil || len(embeddings) != len(names) {
+		log.Printf("Warning: failed to embed node names for deduplication: %v", err)
+		return similar, nameEmbeddings
+	}
+	for i, node := range extractedNodes {
+		nameEmbeddings[node.Uuid] = embeddings[i]
+	}
 
-	nameEmbedding, _ := no.embedder.EmbedSingle(ctx, node.Name)
-	node.NameEmbedding = nameEmbedding
+	// Bulk searches are scoped to a single group
+	byGroup := make(map[string][]int)
+	var groupIDs []string
+	for i, node := range extractedNodes {
+		if _, ok := byGroup[node.GroupID]; !ok {
+			groupIDs = append(groupIDs, node.GroupID)
+		}
+		byGroup[node.GroupID] = append(byGroup[node.GroupID], i)
+	}
+
+	for _, groupID := range groupIDs {
+		indexes := byGroup[groupID]
+		groupEmbeddings := make([][]float32, len(indexes))
+		for i, index := range indexes {
+			groupEmbeddings[i] = embeddings[index]
+		}
+
+		results, err := driver.SearchNodesByEmbeddings(ctx, no.driver, groupEmbeddings, groupID, &driver.VectorSearchOptions{
+			Limit:     dedupeSimilarNodesLimit,
+			MinScore:  dedupeSimilarNodesMinScore,
+			NodeTypes: []types.NodeType{types.EntityNodeType},
+		})
+		if err != nil {
+			log.Printf("Warning: failed to search for similar nodes: %v", err)
+			continue
+		}
+		for i, index := range indexes {
+			similar[extractedNodes[index].Uuid] = results[i]
+		}
+	}
+	return similar, nameEmbeddings
+}
+
+// createNodeEmbeddings creates the embedding of each node, based on its name
+// and summary, and its name embedding, in a single batched request
+func (no *NodeOperations) createNodeEmbeddings(ctx context.Context, nodes []*types.Node) error {
+	var pending []*types.Node
+	for _, node := range nodes {
+		if node != nil {
+			pending = append(pending, node)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// Embedding texts first, then name texts
+	texts := make([]string, 0, 2*len(pending))
+	for _, node := range pending {
+		text := node.Name
+		if node.Summary != "" {
+			text += " " + node.Summary
+		}
+		texts = append(texts, text)
+	}
+	for _, node := range pending {
+		texts = append(texts, node.Name)
+	}
+
+	embeddings, err := no.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to create embeddings: %w", err)
+	}
+	if len(embeddings) != len(texts) {
+		return fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embeddings))
+	}
+
+	for i, node := range pending {
+		node.Embedding = embeddings[i]
+		node.NameEmbedding = embeddings[len(pending)+i]
+	}
 	return nil
 }
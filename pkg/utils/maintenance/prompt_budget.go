@@ -0,0 +1,38 @@
+package maintenance
+
+import (
+	"log/slog"
+
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// buildPromptWithinBudget calls prompt with promptContext. While the estimated
+// prompt exceeds maxTokens, it drops the oldest entry of
+// promptContext["previous_episodes"] and builds the prompt again. It reports
+// whether the returned prompt fits; a maxTokens of 0 disables the check.
+func buildPromptWithinBudget(prompt prompts.PromptVersion, promptContext map[string]interface{}, maxTokens int, logger *slog.Logger) ([]types.Message, bool, error) {
+	for {
+		messages, err := prompt.Call(promptContext)
+		if err != nil {
+			return nil, false, err
+		}
+
+		estimate := llm.EstimateTokensFromMessages(messages)
+		if maxTokens <= 0 || estimate <= maxTokens {
+			return messages, true, nil
+		}
+
+		previous, _ := promptContext["previous_episodes"].([]string)
+		if len(previous) == 0 {
+			return messages, false, nil
+		}
+		// Previous episodes are in chronological order, keep the most recent
+		promptContext["previous_episodes"] = previous[1:]
+		logger.Debug("Trimmed previous episode from prompt over token budget",
+			"estimated_tokens", estimate,
+			"max_prompt_tokens", maxTokens,
+			"remaining_previous_episodes", len(previous)-1)
+	}
+}
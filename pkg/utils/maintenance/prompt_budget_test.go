@@ -0,0 +1,195 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// episodesPrompt lists the previous episodes, then the episode content.
+var episodesPrompt = prompts.NewPromptVersion(func(context map[string]interface{}) ([]types.Message, error) {
+	previous, _ := context["previous_episodes"].([]string)
+	content := strings.Join(append(slices.Clone(previous), context["episode_content"].(string)), "\n")
+	return []types.Message{{Role: llm.RoleUser, Content: content}}, nil
+})
+
+// episodesPromptTokens returns the estimated tokens of episodesPrompt with
+// the previous episodes and the current one.
+func episodesPromptTokens(t *testing.T, previous []string) int {
+	t.Helper()
+	messages, err := episodesPrompt.Call(map[string]interface{}{
+		"episode_content":   "current one",
+		"previous_episodes": previous,
+	})
+	if err != nil {
+		t.Fatalf("episodesPrompt.Call() error = %v", err)
+	}
+	return llm.EstimateTokensFromMessages(messages)
+}
+
+func TestBuildPromptWithinBudget(t *testing.T) {
+	all := episodesPromptTokens(t, []string{"first one", "second one", "third one"})
+	lastTwo := episodesPromptTokens(t, []string{"second one", "third one"})
+	none := episodesPromptTokens(t, nil)
+	if !(none < lastTwo && lastTwo < all) {
+		t.Fatalf("estimates %d, %d, %d do not grow with the previous episodes", none, lastTwo, all)
+	}
+
+	tests := []struct {
+		name          string
+		maxTokens     int
+		wantPrevious  []string
+		wantFits      bool
+		wantLastEntry string
+	}{
+		{"no limit", 0, []string{"first one", "second one", "third one"}, true, "current one"},
+		{"fits", all, []string{"first one", "second one", "third one"}, true, "current one"},
+		{"drops oldest", lastTwo, []string{"second one", "third one"}, true, "current one"},
+		{"drops all", none, []string{}, true, "current one"},
+		{"over without previous", none - 1, []string{}, false, "current one"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promptContext := map[string]interface{}{
+				"episode_content":   "current one",
+				"previous_episodes": []string{"first one", "second one", "third one"},
+			}
+			messages, fits, err := buildPromptWithinBudget(episodesPrompt, promptContext, tt.maxTokens, discardLogger)
+			if err != nil {
+				t.Fatalf("buildPromptWithinBudget() error = %v", err)
+			}
+			if fits != tt.wantFits {
+				t.Errorf("fits = %v, want %v", fits, tt.wantFits)
+			}
+			if got := promptContext["previous_episodes"].([]string); !slices.Equal(got, tt.wantPrevious) {
+				t.Errorf("previous_episodes = %v, want %v", got, tt.wantPrevious)
+			}
+			if want := strings.Join(append(slices.Clone(tt.wantPrevious), tt.wantLastEntry), "\n"); messages[0].Content != want {
+				t.Errorf("prompt = %q, want %q", messages[0].Content, want)
+			}
+			if tt.wantFits && tt.maxTokens > 0 && llm.EstimateTokensFromMessages(messages) > tt.maxTokens {
+				t.Errorf("prompt of %d tokens does not fit %d", llm.EstimateTokensFromMessages(messages), tt.maxTokens)
+			}
+		})
+	}
+}
+
+// nodesLibrary is a prompt library whose edge extraction prompt lists the
+// id and name of each node on a line.
+type nodesLibrary struct {
+	prompts.Library
+}
+
+func (l *nodesLibrary) ExtractEdges() prompts.ExtractEdgesPrompt {
+	return &nodesExtractEdges{l.Library.ExtractEdges()}
+}
+
+type nodesExtractEdges struct {
+	prompts.ExtractEdgesPrompt
+}
+
+func (p *nodesExtractEdges) Edge() prompts.PromptVersion {
+	return prompts.NewPromptVersion(func(context map[string]interface{}) ([]types.Message, error) {
+		var lines []string
+		for _, node := range context["nodes"].([]map[string]interface{}) {
+			lines = append(lines, fmt.Sprintf("node\t%d\t%s", node["id"], node["name"]))
+		}
+		return []types.Message{{Role: llm.RoleUser, Content: strings.Join(lines, "\n")}}, nil
+	})
+}
+
+// pairsLLM is an LLM client extracting an edge between every pair of nodes
+// of a nodesLibrary prompt, and recording the size of each prompt.
+type pairsLLM struct {
+	llm.Client
+	prompts []int
+}
+
+func (c *pairsLLM) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	var ids, names []string
+	for _, line := range strings.Split(messages[0].Content, "\n") {
+		fields := strings.Split(line, "\t")
+		ids = append(ids, fields[1])
+		names = append(names, fields[2])
+	}
+	c.prompts = append(c.prompts, llm.EstimateTokensFromMessages(messages))
+
+	rows := []string{"relation_type\tfact\tsource_id\ttarget_id"}
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			rows = append(rows, fmt.Sprintf("KNOWS\t%s knows %s\t%s\t%s", names[i], names[j], ids[i], ids[j]))
+		}
+	}
+	return &types.Response{Content: strings.Join(rows, "\n")}, nil
+}
+
+// edgesPromptTokens returns the estimated tokens of the nodesLibrary edge
+// extraction prompt for nodes.
+func edgesPromptTokens(t *testing.T, nodes []*types.Node) int {
+	t.Helper()
+	nodeContexts := make([]map[string]interface{}, len(nodes))
+	for i, node := range nodes {
+		nodeContexts[i] = map[string]interface{}{"id": i, "name": node.Name}
+	}
+	messages, err := (&nodesExtractEdges{}).Edge().Call(map[string]interface{}{"nodes": nodeContexts})
+	if err != nil {
+		t.Fatalf("Edge().Call() error = %v", err)
+	}
+	return llm.EstimateTokensFromMessages(messages)
+}
+
+func TestExtractEdgesForNodesOverBudgetCoversEveryPair(t *testing.T) {
+	var nodes []*types.Node
+	for i := 0; i < 9; i++ {
+		nodes = append(nodes, &types.Node{Uuid: fmt.Sprintf("n%d", i), Name: fmt.Sprintf("N%d", i), Type: types.EntityNodeType})
+	}
+	// The 9 nodes do not fit in the budget, the windows of 4 and 5 nodes of
+	// the 4 chunks of 2, 2, 2 and 3 nodes do
+	budget := edgesPromptTokens(t, nodes[:5])
+	if edgesPromptTokens(t, nodes) <= budget {
+		t.Fatal("expected the prompt of 9 nodes to be over the budget")
+	}
+	client := &pairsLLM{}
+	eo := NewEdgeOperations(nil, client, nil, &nodesLibrary{prompts.NewLibrary()})
+	eo.SetLogger(discardLogger)
+	eo.SetMaxPromptTokens(budget)
+
+	episode := &types.Node{Uuid: "ep", Type: types.EpisodicNodeType}
+	edges, err := eo.extractEdgesForNodes(context.Background(), episode, nodes, nil, nil, "g")
+	if err != nil {
+		t.Fatalf("extractEdgesForNodes() error = %v", err)
+	}
+	if len(client.prompts) != 6 {
+		t.Errorf("extracted edges in %d prompts, want a window per pair of the 4 chunks", len(client.prompts))
+	}
+	for _, tokens := range client.prompts {
+		if tokens > budget {
+			t.Errorf("prompt of %d tokens is over the budget", tokens)
+		}
+	}
+
+	found := make(map[string]int)
+	for _, edge := range edges {
+		found[edge.SourceNodeID+"-"+edge.TargetNodeID]++
+	}
+	for i := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+			if pair := nodes[i].Uuid + "-" + nodes[j].Uuid; found[pair] != 1 {
+				t.Errorf("edge %s extracted %d times, want once", pair, found[pair])
+			}
+		}
+	}
+	if len(edges) != 36 {
+		t.Errorf("extracted %d edges, want 36", len(edges))
+	}
+}
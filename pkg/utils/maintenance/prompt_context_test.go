@@ -0,0 +1,228 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// errPromptRecorded stops an operation once its prompt context is recorded.
+var errPromptRecorded = errors.New("prompt recorded")
+
+// recordingLibrary is a prompt library recording the context keys each
+// prompt is called with.
+type recordingLibrary struct {
+	prompts.Library
+	calls map[string][]string
+}
+
+func newRecordingLibrary() *recordingLibrary {
+	return &recordingLibrary{Library: prompts.NewLibrary(), calls: make(map[string][]string)}
+}
+
+func (l *recordingLibrary) prompt(name string) types.PromptVersion {
+	return prompts.NewPromptVersion(func(context map[string]interface{}) ([]types.Message, error) {
+		for key := range context {
+			if !slices.Contains(l.calls[name], key) {
+				l.calls[name] = append(l.calls[name], key)
+			}
+		}
+		return nil, errPromptRecorded
+	})
+}
+
+func (l *recordingLibrary) ExtractNodes() prompts.ExtractNodesPrompt {
+	return &recordingExtractNodes{l.Library.ExtractNodes(), l}
+}
+
+func (l *recordingLibrary) DedupeNodes() prompts.DedupeNodesPrompt {
+	return &recordingDedupeNodes{l.Library.DedupeNodes(), l}
+}
+
+func (l *recordingLibrary) ExtractEdges() prompts.ExtractEdgesPrompt {
+	return &recordingExtractEdges{l.Library.ExtractEdges(), l}
+}
+
+func (l *recordingLibrary) DedupeEdges() prompts.DedupeEdgesPrompt {
+	return &recordingDedupeEdges{l.Library.DedupeEdges(), l}
+}
+
+func (l *recordingLibrary) InvalidateEdges() prompts.InvalidateEdgesPrompt {
+	return &recordingInvalidateEdges{l.Library.InvalidateEdges(), l}
+}
+
+func (l *recordingLibrary) ExtractEdgeDates() prompts.ExtractEdgeDatesPrompt {
+	return &recordingExtractEdgeDates{l.Library.ExtractEdgeDates(), l}
+}
+
+func (l *recordingLibrary) SummarizeNodes() prompts.SummarizeNodesPrompt {
+	return &recordingSummarizeNodes{l.Library.SummarizeNodes(), l}
+}
+
+type recordingExtractNodes struct {
+	prompts.ExtractNodesPrompt
+	l *recordingLibrary
+}
+
+func (p *recordingExtractNodes) ExtractMessage() prompts.PromptVersion {
+	return p.l.prompt("extract_nodes.extract_message")
+}
+
+func (p *recordingExtractNodes) ExtractJSON() prompts.PromptVersion {
+	return p.l.prompt("extract_nodes.extract_json")
+}
+
+func (p *recordingExtractNodes) ExtractText() prompts.PromptVersion {
+	return p.l.prompt("extract_nodes.extract_text")
+}
+
+func (p *recordingExtractNodes) Reflexion() prompts.PromptVersion {
+	return p.l.prompt("extract_nodes.reflexion")
+}
+
+func (p *recordingExtractNodes) ReclassifyNodes() prompts.PromptVersion {
+	return p.l.prompt("extract_nodes.reclassify_nodes")
+}
+
+func (p *recordingExtractNodes) ExtractAttributesBatch() prompts.PromptVersion {
+	return p.l.prompt("extract_nodes.extract_attributes_batch")
+}
+
+type recordingDedupeNodes struct {
+	prompts.DedupeNodesPrompt
+	l *recordingLibrary
+}
+
+func (p *recordingDedupeNodes) Nodes() types.PromptVersion {
+	return p.l.prompt("dedupe_nodes.nodes")
+}
+
+type recordingExtractEdges struct {
+	prompts.ExtractEdgesPrompt
+	l *recordingLibrary
+}
+
+func (p *recordingExtractEdges) Edge() prompts.PromptVersion {
+	return p.l.prompt("extract_edges.edge")
+}
+
+func (p *recordingExtractEdges) EdgeJSON() prompts.PromptVersion {
+	return p.l.prompt("extract_edges.edge_json")
+}
+
+type recordingDedupeEdges struct {
+	prompts.DedupeEdgesPrompt
+	l *recordingLibrary
+}
+
+func (p *recordingDedupeEdges) ResolveEdge() prompts.PromptVersion {
+	return p.l.prompt("dedupe_edges.resolve_edge")
+}
+
+type recordingInvalidateEdges struct {
+	prompts.InvalidateEdgesPrompt
+	l *recordingLibrary
+}
+
+func (p *recordingInvalidateEdges) Invalidate() prompts.PromptVersion {
+	return p.l.prompt("invalidate_edges.invalidate")
+}
+
+type recordingExtractEdgeDates struct {
+	prompts.ExtractEdgeDatesPrompt
+	l *recordingLibrary
+}
+
+func (p *recordingExtractEdgeDates) ExtractDates() prompts.PromptVersion {
+	return p.l.prompt("extract_edge_dates.extract_dates")
+}
+
+type recordingSummarizeNodes struct {
+	prompts.SummarizeNodesPrompt
+	l *recordingLibrary
+}
+
+func (p *recordingSummarizeNodes) RefreshSummaries() types.PromptVersion {
+	return p.l.prompt("summarize_nodes.refresh_summaries")
+}
+
+// episodeDriver is a GraphDriver without optional capabilities, whose nodes
+// are all mentioned by episode.
+type episodeDriver struct {
+	driver.GraphDriver
+	episode *types.Node
+}
+
+func (d *episodeDriver) Provider() driver.GraphProvider {
+	return driver.GraphProviderFalkorDB
+}
+
+func (d *episodeDriver) GetRelatedNodes(ctx context.Context, nodeID, groupID string, edgeTypes []types.EdgeType) ([]*types.Node, error) {
+	return []*types.Node{d.episode}, nil
+}
+
+// TestPromptContextKeys checks that the operations only call prompts with
+// the context keys prompts.PromptContextKeys lists, which are those the
+// templates given to Library.Register may use.
+func TestPromptContextKeys(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	episode := &types.Node{Uuid: "ep", Type: types.EpisodicNodeType, Content: "Alice joined Acme.", GroupID: "g", ValidFrom: now}
+	previous := []*types.Node{{Uuid: "ep0", Type: types.EpisodicNodeType, Summary: "Alice met Bob.", GroupID: "g"}}
+	nodes := []*types.Node{
+		{Uuid: "alice", Name: "Alice", Type: types.EntityNodeType, EntityType: "Person", GroupID: "g"},
+		{Uuid: "acme", Name: "Acme", Type: types.EntityNodeType, EntityType: "Company", GroupID: "g"},
+	}
+	edge := types.NewEntityEdge("works", "alice", "acme", "g", "WORKS_AT", types.EntityEdgeType)
+	edge.Fact = "Alice works at Acme"
+	edge.Summary = edge.Fact
+	edge.ValidFrom = now
+	existing := types.NewEntityEdge("worked", "alice", "acme", "g", "WORKS_AT", types.EntityEdgeType)
+	existing.Fact = "Alice worked at Acme"
+	existing.Summary = existing.Fact
+
+	library := newRecordingLibrary()
+	d := &episodeDriver{episode: episode}
+	no := NewNodeOperations(d, nil, nil, library)
+	eo := NewEdgeOperations(d, nil, nil, library)
+	to := NewTemporalOperations(nil, library, no.logger)
+	entityTypes := map[string]interface{}{"Person": nil, "Company": nil}
+
+	for _, episodeType := range []types.EpisodeType{types.MessageEpisodeType, types.EpisodeType("text"), types.JSONEpisodeType} {
+		typed := *episode
+		typed.EpisodeType = episodeType
+		no.ExtractNodes(ctx, &typed, previous, entityTypes, []string{"Company"})
+		eo.extractEdgesForNodes(ctx, &typed, nodes, []string{"Alice met Bob."}, nil, "g")
+	}
+	no.extractNodesReflexion(ctx, episode, previous, prompts.ExtractedEntities{ExtractedEntities: []prompts.ExtractedEntity{{Name: "Alice"}}})
+	no.dedupeNodesWithLLM(ctx, nodes[:1], nodes[1:], episode, previous, entityTypes)
+	no.extractAttributesBatch(ctx, []map[string]interface{}{{"id": 0, "name": "Alice"}}, episode, []string{"Alice met Bob."})
+	no.refreshSummaryBatch(ctx, "g", nodes, 5)
+	no.reclassifyEntityBatch(ctx, "g", nodes, buildEntityTypesContext(entityTypes), true)
+	eo.dedupeEdgeBatch(ctx, edge, []*types.Edge{existing}, []*types.Edge{existing}, nil)
+	eo.askInvalidation(ctx, edge, existing, episode)
+	to.ExtractEdgesDates(ctx, []*types.Edge{edge}, episode, previous)
+	to.GetEdgeContradictions(ctx, edge, []*types.Edge{existing})
+
+	internal := []string{"ensure_ascii", "logger", prompts.ContextFormatKey}
+	for _, name := range prompts.PromptNames() {
+		keys, called := library.calls[name]
+		if !called {
+			continue
+		}
+		for _, key := range keys {
+			if !slices.Contains(internal, key) && !slices.Contains(prompts.PromptContextKeys(name), key) {
+				t.Errorf("prompt %s is called with %q, which PromptContextKeys does not list", name, key)
+			}
+		}
+	}
+	if len(library.calls) != 13 {
+		t.Errorf("recorded %d prompts, want 13: %v", len(library.calls), library.calls)
+	}
+}
@@ -0,0 +1,99 @@
+package maintenance
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// RecordNodeProvenance records that chunks of an episode support a node: the
+// episode is added to its source IDs and the chunks to its episode_chunks
+// metadata.
+func RecordNodeProvenance(node *types.Node, episodeUUID string, chunks []int) {
+	if !slices.Contains(node.SourceIDs, episodeUUID) {
+		node.SourceIDs = append(node.SourceIDs, episodeUUID)
+	}
+	node.Metadata = withEpisodeChunks(node.Metadata, episodeUUID, chunks)
+}
+
+// RecordEdgeProvenance records that chunks of an episode support an edge: the
+// episode is added to its episodes and source IDs and the chunks to its
+// episode_chunks metadata.
+func RecordEdgeProvenance(edge *types.Edge, episodeUUID string, chunks []int) {
+	if !slices.Contains(edge.Episodes, episodeUUID) {
+		edge.Episodes = append(edge.Episodes, episodeUUID)
+	}
+	if !slices.Contains(edge.SourceIDs, episodeUUID) {
+		edge.SourceIDs = append(edge.SourceIDs, episodeUUID)
+	}
+	edge.Metadata = withEpisodeChunks(edge.Metadata, episodeUUID, chunks)
+}
+
+// RemoveNodeProvenance removes an episode from the source IDs and
+// episode_chunks metadata of a node.
+func RemoveNodeProvenance(node *types.Node, episodeUUID string) {
+	node.SourceIDs = slices.DeleteFunc(node.SourceIDs, func(id string) bool { return id == episodeUUID })
+	node.Metadata = withoutEpisodeChunks(node.Metadata, episodeUUID)
+}
+
+// RemoveEdgeProvenance removes an episode from the episodes, source IDs and
+// episode_chunks metadata of an edge.
+func RemoveEdgeProvenance(edge *types.Edge, episodeUUID string) {
+	isEpisode := func(id string) bool { return id == episodeUUID }
+	edge.Episodes = slices.DeleteFunc(edge.Episodes, isEpisode)
+	edge.SourceIDs = slices.DeleteFunc(edge.SourceIDs, isEpisode)
+	edge.Metadata = withoutEpisodeChunks(edge.Metadata, episodeUUID)
+}
+
+// withoutEpisodeChunks returns a copy of metadata without the episode_chunks
+// of the episode.
+func withoutEpisodeChunks(metadata map[string]interface{}, episodeUUID string) map[string]interface{} {
+	episodeChunks := driver.EpisodeChunks(metadata)
+	if _, ok := episodeChunks[episodeUUID]; !ok {
+		return metadata
+	}
+	delete(episodeChunks, episodeUUID)
+
+	updated := maps.Clone(metadata)
+	if len(episodeChunks) == 0 {
+		delete(updated, driver.EpisodeChunksKey)
+	} else {
+		updated[driver.EpisodeChunksKey] = episodeChunks
+	}
+	return updated
+}
+
+// withEpisodeChunks returns a copy of metadata with chunks added to the
+// episode_chunks of the episode. Metadata is copied because extracted and
+// resolved records may share it.
+func withEpisodeChunks(metadata map[string]interface{}, episodeUUID string, chunks []int) map[string]interface{} {
+	if len(chunks) == 0 {
+		return metadata
+	}
+
+	episodeChunks := driver.EpisodeChunks(metadata)
+	merged := append(episodeChunks[episodeUUID], chunks...)
+	slices.Sort(merged)
+	episodeChunks[episodeUUID] = slices.Compact(merged)
+
+	updated := maps.Clone(metadata)
+	if updated == nil {
+		updated = make(map[string]interface{})
+	}
+	updated[driver.EpisodeChunksKey] = episodeChunks
+	return updated
+}
+
+// ChunkOffsets returns the [start, end) byte offsets of chunks in the content
+// made by joining them with separator.
+func ChunkOffsets(chunks []string, separator string) [][2]int {
+	offsets := make([][2]int, len(chunks))
+	start := 0
+	for i, chunk := range chunks {
+		offsets[i] = [2]int{start, start + len(chunk)}
+		start += len(chunk) + len(separator)
+	}
+	return offsets
+}
@@ -0,0 +1,313 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// pruneEpisodeBatchSize is the number of episodes the Pruner reads at once.
+const pruneEpisodeBatchSize = 100
+
+// RetentionPolicy configures what a Pruner removes from a group. Zero values
+// disable the corresponding step.
+type RetentionPolicy struct {
+	// EpisodeTTL is the age after which episodes are deleted, by valid time
+	EpisodeTTL time.Duration
+	// RemoveOrphanedEntities deletes the entities no episode mentions any more
+	RemoveOrphanedEntities bool
+	// EdgeReinforcementWindow expires the entity edges that no episode created
+	// or reinforced within the window
+	EdgeReinforcementWindow time.Duration
+	// MinConfidence expires the valid entity edges whose confidence is below
+	// it. Edges without a confidence are kept
+	MinConfidence float64
+}
+
+// PruneResult reports what a Pruner removed from a group.
+type PruneResult struct {
+	GroupID         string
+	EpisodesDeleted int
+	EntitiesDeleted int
+	EdgesExpired    int
+}
+
+// Pruner applies retention policies to groups: it deletes episodes older than
+// a TTL, optionally archiving them first, removes the entities left without
+// mentions and expires edges that were not reinforced recently or that the
+// LLM was not confident in.
+type Pruner struct {
+	driver        driver.GraphDriver
+	logger        *slog.Logger
+	defaultPolicy RetentionPolicy
+	policies      map[string]RetentionPolicy
+	archive       io.Writer
+}
+
+// NewPruner creates a Pruner applying defaultPolicy to the groups without a
+// policy of their own.
+func NewPruner(driver driver.GraphDriver, defaultPolicy RetentionPolicy) *Pruner {
+	return &Pruner{
+		driver:        driver,
+		logger:        slog.Default(),
+		defaultPolicy: defaultPolicy,
+		policies:      make(map[string]RetentionPolicy),
+	}
+}
+
+// SetLogger sets a custom logger for the Pruner
+func (p *Pruner) SetLogger(logger *slog.Logger) {
+	p.logger = logger
+}
+
+// SetGroupPolicy sets the retention policy of a group.
+func (p *Pruner) SetGroupPolicy(groupID string, policy RetentionPolicy) {
+	p.policies[groupID] = policy
+}
+
+// SetArchive makes the Pruner write each episode it deletes to w as a line of
+// JSON before deleting it. nil deletes episodes without archiving them.
+func (p *Pruner) SetArchive(w io.Writer) {
+	p.archive = w
+}
+
+// Policy returns the retention policy of a group.
+func (p *Pruner) Policy(groupID string) RetentionPolicy {
+	if policy, ok := p.policies[groupID]; ok {
+		return policy
+	}
+	return p.defaultPolicy
+}
+
+// PruneAll prunes every group of the graph.
+func (p *Pruner) PruneAll(ctx context.Context) ([]*PruneResult, error) {
+	groupIDs, err := p.driver.GetAllGroupIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group IDs: %w", err)
+	}
+
+	results := make([]*PruneResult, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		result, err := p.Prune(ctx, groupID)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Prune applies the retention policy of a group: expired episodes are deleted
+// first, so that the entities they alone mentioned are removed as orphans,
+// then stale and low-confidence edges are expired.
+func (p *Pruner) Prune(ctx context.Context, groupID string) (*PruneResult, error) {
+	policy := p.Policy(groupID)
+	now := time.Now().UTC()
+	result := &PruneResult{GroupID: groupID}
+
+	if policy.EpisodeTTL > 0 {
+		deleted, err := p.pruneEpisodes(ctx, groupID, now.Add(-policy.EpisodeTTL))
+		result.EpisodesDeleted = deleted
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if policy.RemoveOrphanedEntities {
+		deleted, err := p.pruneOrphanedEntities(ctx, groupID)
+		result.EntitiesDeleted = deleted
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if policy.EdgeReinforcementWindow > 0 {
+		expired, err := p.expireStaleEdges(ctx, groupID, now.Add(-policy.EdgeReinforcementWindow), now)
+		result.EdgesExpired = expired
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if policy.MinConfidence > 0 {
+		expired, err := p.expireLowConfidenceEdges(ctx, groupID, policy.MinConfidence, now)
+		result.EdgesExpired += expired
+		if err != nil {
+			return result, err
+		}
+	}
+
+	p.logger.Info("Pruned group",
+		"group_id", groupID,
+		"episodes_deleted", result.EpisodesDeleted,
+		"entities_deleted", result.EntitiesDeleted,
+		"edges_expired", result.EdgesExpired)
+	return result, nil
+}
+
+// pruneEpisodes deletes the episodes of a group valid before cutoff, archiving
+// them first when an archive is set.
+func (p *Pruner) pruneEpisodes(ctx context.Context, groupID string, cutoff time.Time) (int, error) {
+	deleted := 0
+	seen := make(map[string]bool)
+	for {
+		episodes, err := p.driver.RetrieveEpisodes(ctx, cutoff, []string{groupID}, pruneEpisodeBatchSize, nil)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to retrieve episodes: %w", err)
+		}
+
+		// Episodes the driver keeps returning could not be deleted
+		progress := false
+		for _, episode := range episodes {
+			if seen[episode.Uuid] || !episode.ValidFrom.Before(cutoff) {
+				continue
+			}
+			seen[episode.Uuid] = true
+			progress = true
+
+			if p.archive != nil {
+				if err := json.NewEncoder(p.archive).Encode(episode); err != nil {
+					return deleted, fmt.Errorf("failed to archive episode %s: %w", episode.Uuid, err)
+				}
+			}
+			if err := p.driver.DeleteNode(ctx, episode.Uuid, groupID); err != nil {
+				return deleted, fmt.Errorf("failed to delete episode %s: %w", episode.Uuid, err)
+			}
+			deleted++
+		}
+		if !progress {
+			return deleted, nil
+		}
+	}
+}
+
+// pruneOrphanedEntities deletes the entities of a group no episode mentions.
+func (p *Pruner) pruneOrphanedEntities(ctx context.Context, groupID string) (int, error) {
+	entities, err := p.driver.GetEntityNodesByGroup(ctx, groupID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get entities: %w", err)
+	}
+	uuids := make([]string, len(entities))
+	for i, entity := range entities {
+		uuids[i] = entity.Uuid
+	}
+	mentions, err := driver.CountEpisodeMentions(ctx, p.driver, uuids, groupID)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, uuid := range uuids {
+		if mentions[uuid] > 0 {
+			continue
+		}
+		if err := p.driver.DeleteNode(ctx, uuid, groupID); err != nil {
+			return deleted, fmt.Errorf("failed to delete entity %s: %w", uuid, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// expireStaleEdges expires the valid entity edges of a group last created or
+// reinforced before cutoff. An edge is reinforced when it is updated or when
+// a newer episode supports it.
+func (p *Pruner) expireStaleEdges(ctx context.Context, groupID string, cutoff, now time.Time) (int, error) {
+	// Edges created after the cutoff were reinforced within the window
+	edges, err := p.driver.GetEdgesInTimeRange(ctx, time.Time{}, cutoff, groupID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get edges: %w", err)
+	}
+
+	var candidates []*types.Edge
+	episodeSet := make(map[string]bool)
+	for _, edge := range edges {
+		if edge.Type != "" && edge.Type != types.EntityEdgeType {
+			continue
+		}
+		if edge.ExpiredAt != nil || edge.ValidTo != nil || lastReinforced(edge, nil).After(cutoff) {
+			continue
+		}
+		candidates = append(candidates, edge)
+		for _, uuid := range slices.Concat(edge.Episodes, edge.SourceIDs) {
+			episodeSet[uuid] = true
+		}
+	}
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+
+	episodeTimes := make(map[string]time.Time, len(episodeSet))
+	if len(episodeSet) > 0 {
+		episodeUUIDs := make([]string, 0, len(episodeSet))
+		for uuid := range episodeSet {
+			episodeUUIDs = append(episodeUUIDs, uuid)
+		}
+		episodes, err := p.driver.GetNodes(ctx, episodeUUIDs, groupID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get supporting episodes: %w", err)
+		}
+		for _, episode := range episodes {
+			episodeTimes[episode.Uuid] = episode.ValidFrom
+		}
+	}
+
+	var stale []string
+	for _, edge := range candidates {
+		if !lastReinforced(edge, episodeTimes).After(cutoff) {
+			stale = append(stale, edge.Uuid)
+		}
+	}
+	if err := driver.ExpireEdges(ctx, p.driver, groupID, stale, now); err != nil {
+		return 0, err
+	}
+	return len(stale), nil
+}
+
+// expireLowConfidenceEdges expires the valid entity edges of a group whose
+// confidence is below minConfidence.
+func (p *Pruner) expireLowConfidenceEdges(ctx context.Context, groupID string, minConfidence float64, now time.Time) (int, error) {
+	edges, err := p.driver.GetEdgesInTimeRange(ctx, time.Time{}, now, groupID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get edges: %w", err)
+	}
+
+	var lowConfidence []string
+	for _, edge := range edges {
+		if edge.Type != "" && edge.Type != types.EntityEdgeType {
+			continue
+		}
+		if edge.ExpiredAt != nil || edge.ValidTo != nil {
+			continue
+		}
+		if confidence, ok := edge.Confidence(); ok && confidence < minConfidence {
+			lowConfidence = append(lowConfidence, edge.Uuid)
+		}
+	}
+	if err := driver.ExpireEdges(ctx, p.driver, groupID, lowConfidence, now); err != nil {
+		return 0, err
+	}
+	return len(lowConfidence), nil
+}
+
+// lastReinforced returns the latest of the creation and update times of edge
+// and the valid times of its supporting episodes found in episodeTimes.
+func lastReinforced(edge *types.Edge, episodeTimes map[string]time.Time) time.Time {
+	last := edge.CreatedAt
+	if edge.UpdatedAt.After(last) {
+		last = edge.UpdatedAt
+	}
+	for _, uuid := range slices.Concat(edge.Episodes, edge.SourceIDs) {
+		if at, ok := episodeTimes[uuid]; ok && at.After(last) {
+			last = at
+		}
+	}
+	return last
+}
@@ -0,0 +1,160 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils"
+)
+
+// DefaultReclassifyEntitiesBatchSize is the number of entities classified in
+// a single prompt by ReclassifyEntities.
+const DefaultReclassifyEntitiesBatchSize = 50
+
+// ReclassifyEntitiesOptions configures ReclassifyEntities.
+type ReclassifyEntitiesOptions struct {
+	// BatchSize is the number of entities per prompt, 0 for
+	// DefaultReclassifyEntitiesBatchSize
+	BatchSize int
+	// FromTypes restricts the reclassification to the entities currently of
+	// these types, for example "Entity" to only type untyped entities. All
+	// entities are reclassified when empty.
+	FromTypes []string
+	// DryRun classifies the entities without saving their new types
+	DryRun bool
+}
+
+// ReclassifyEntities classifies the existing entities of a group against
+// entityTypes from their names and summaries, and saves the entities whose
+// type changed, for example after new entity types were added, without
+// re-ingesting the episodes. Entities are classified and saved batch by
+// batch, so an error leaves the earlier batches reclassified. It returns the
+// entities whose type changed, with their new type.
+func (no *NodeOperations) ReclassifyEntities(ctx context.Context, groupID string, entityTypes map[string]interface{}, opts *ReclassifyEntitiesOptions) ([]*types.Node, error) {
+	ctx = usage.WithOperation(ctx, usage.OperationReclassifyEntities)
+
+	if opts == nil {
+		opts = &ReclassifyEntitiesOptions{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultReclassifyEntitiesBatchSize
+	}
+
+	nodes, err := no.driver.GetEntityNodesByGroup(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entities: %w", err)
+	}
+	var candidates []*types.Node
+	for _, node := range nodes {
+		if len(opts.FromTypes) == 0 || slices.Contains(opts.FromTypes, entityTypeName(node)) {
+			candidates = append(candidates, node)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Uuid < candidates[j].Uuid })
+
+	entityTypesContext := buildEntityTypesContext(entityTypes)
+	retyped := make([]*types.Node, 0)
+	for start := 0; start < len(candidates); start += batchSize {
+		end := min(start+batchSize, len(candidates))
+		batch, err := no.reclassifyEntityBatch(ctx, groupID, candidates[start:end], entityTypesContext, opts.DryRun)
+		if err != nil {
+			return retyped, err
+		}
+		retyped = append(retyped, batch...)
+	}
+
+	no.logger.Info("Reclassified entities",
+		"group_id", groupID,
+		"entities", len(candidates),
+		"retyped", len(retyped),
+		"dry_run", opts.DryRun)
+	return retyped, nil
+}
+
+// reclassifyEntityBatch classifies nodes in one prompt and saves those whose
+// type changed, unless dryRun is set.
+func (no *NodeOperations) reclassifyEntityBatch(ctx context.Context, groupID string, nodes []*types.Node, entityTypesContext []map[string]interface{}, dryRun bool) ([]*types.Node, error) {
+	nodesContext := make([]map[string]interface{}, len(nodes))
+	for i, node := range nodes {
+		nodesContext[i] = map[string]interface{}{
+			"node_id":     i,
+			"name":        node.Name,
+			"entity_type": entityTypeName(node),
+			"summary":     node.Summary,
+		}
+	}
+
+	messages, err := no.prompts.ExtractNodes().ReclassifyNodes().Call(map[string]interface{}{
+		"nodes":        nodesContext,
+		"entity_types": entityTypesContext,
+		"ensure_ascii": true,
+		"logger":       no.logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reclassify prompt: %w", err)
+	}
+
+	csvParser := func(csvContent string) ([]*prompts.ReclassifiedEntity, error) {
+		return utils.DuckDbUnmarshalCSV[prompts.ReclassifiedEntity](csvContent, '\t')
+	}
+	classified, badResp, err := llm.GenerateCSVResponse[prompts.ReclassifiedEntity](ctx, no.llm, no.logger, messages, csvParser, 3)
+	if err != nil {
+		if badResp != nil {
+			no.logger.Error("Failed to parse entity classifications",
+				"error", badResp.Error,
+				"response_length", len(badResp.Response))
+		}
+		return nil, fmt.Errorf("failed to reclassify entities: %w", err)
+	}
+
+	now := time.Now().UTC()
+	var retyped []*types.Node
+	formerTypes := make(map[string]string)
+	for _, classification := range classified {
+		if classification.NodeID < 0 || classification.NodeID >= len(nodes) ||
+			classification.EntityTypeID < 0 || classification.EntityTypeID >= len(entityTypesContext) {
+			continue
+		}
+		node := nodes[classification.NodeID]
+		typeName := entityTypesContext[classification.EntityTypeID]["entity_type_name"].(string)
+		if typeName == entityTypeName(node) || formerTypes[node.Uuid] != "" {
+			continue
+		}
+
+		updatedNode := *node
+		updatedNode.EntityType = typeName
+		updatedNode.UpdatedAt = now
+		updatedNode.Metadata = maps.Clone(node.Metadata)
+		retyped = append(retyped, &updatedNode)
+		formerTypes[node.Uuid] = entityTypeName(node)
+	}
+	if dryRun || len(retyped) == 0 {
+		return retyped, nil
+	}
+
+	if err := no.driver.UpsertNodes(ctx, retyped); err != nil {
+		return nil, fmt.Errorf("failed to save reclassified entities: %w", err)
+	}
+	if err := driver.RemoveEntityLabels(ctx, no.driver, groupID, formerTypes); err != nil {
+		return nil, err
+	}
+	return retyped, nil
+}
+
+// entityTypeName returns the entity type of node, "Entity" when it has none.
+func entityTypeName(node *types.Node) string {
+	if node.EntityType == "" {
+		return "Entity"
+	}
+	return node.EntityType
+}
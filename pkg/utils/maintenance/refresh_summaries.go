@@ -0,0 +1,228 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils"
+)
+
+// summaryRefreshedAtKey is the metadata key recording when RefreshSummaries
+// last regenerated the summary of an entity.
+const summaryRefreshedAtKey = "summary_refreshed_at"
+
+const (
+	// DefaultRefreshSummariesBatchSize is the number of entities whose
+	// summaries are regenerated in a single prompt
+	DefaultRefreshSummariesBatchSize = 10
+	// DefaultRefreshSummariesMaxEpisodes is the number of most recent
+	// episodes mentioning an entity its summary is regenerated from
+	DefaultRefreshSummariesMaxEpisodes = 20
+)
+
+// RefreshSummariesOptions configures RefreshSummaries.
+type RefreshSummariesOptions struct {
+	// BatchSize is the number of entities per prompt, 0 for
+	// DefaultRefreshSummariesBatchSize
+	BatchSize int
+	// MaxEpisodes is the number of most recent mentioning episodes used per
+	// entity, 0 for DefaultRefreshSummariesMaxEpisodes
+	MaxEpisodes int
+	// Force refreshes every entity, not only those updated since their last
+	// refresh
+	Force bool
+}
+
+// RefreshSummaries regenerates the summaries of the entities of a group from
+// the episodes mentioning them, instead of the incremental updates made
+// during ingestion. Only entities updated since their last refresh are
+// reprocessed unless opts.Force is set; the time of the refresh is recorded in
+// their "summary_refreshed_at" metadata. Entities are summarized in batches
+// and saved batch by batch, so an error leaves the earlier batches refreshed.
+// It returns the refreshed entities.
+func (no *NodeOperations) RefreshSummaries(ctx context.Context, groupID string, opts *RefreshSummariesOptions) ([]*types.Node, error) {
+	ctx = usage.WithOperation(ctx, usage.OperationRefreshSummaries)
+
+	if opts == nil {
+		opts = &RefreshSummariesOptions{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultRefreshSummariesBatchSize
+	}
+	maxEpisodes := opts.MaxEpisodes
+	if maxEpisodes <= 0 {
+		maxEpisodes = DefaultRefreshSummariesMaxEpisodes
+	}
+
+	nodes, err := no.driver.GetEntityNodesByGroup(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entities: %w", err)
+	}
+	var stale []*types.Node
+	for _, node := range nodes {
+		if opts.Force || summaryStale(node) {
+			stale = append(stale, node)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].Uuid < stale[j].Uuid })
+
+	refreshed := make([]*types.Node, 0, len(stale))
+	for start := 0; start < len(stale); start += batchSize {
+		end := min(start+batchSize, len(stale))
+		batch, err := no.refreshSummaryBatch(ctx, groupID, stale[start:end], maxEpisodes)
+		if err != nil {
+			return refreshed, err
+		}
+		refreshed = append(refreshed, batch...)
+	}
+
+	no.logger.Info("Refreshed entity summaries",
+		"group_id", groupID,
+		"entities", len(nodes),
+		"refreshed", len(refreshed))
+	return refreshed, nil
+}
+
+// refreshSummaryBatch regenerates and saves the summaries of nodes in one
+// prompt. Entities no episode mentions keep their summaries.
+func (no *NodeOperations) refreshSummaryBatch(ctx context.Context, groupID string, nodes []*types.Node, maxEpisodes int) ([]*types.Node, error) {
+	episodeIDs := make(map[string]int)
+	var episodesContext []map[string]interface{}
+	nodesContext := make([]map[string]interface{}, 0, len(nodes))
+	for i, node := range nodes {
+		episodes, err := no.mentioningEpisodes(ctx, node.Uuid, groupID, maxEpisodes)
+		if err != nil {
+			return nil, err
+		}
+		if len(episodes) == 0 {
+			continue
+		}
+
+		ids := make([]int, 0, len(episodes))
+		for _, episode := range episodes {
+			id, ok := episodeIDs[episode.Uuid]
+			if !ok {
+				id = len(episodesContext)
+				episodeIDs[episode.Uuid] = id
+				episodesContext = append(episodesContext, map[string]interface{}{
+					"episode_id": id,
+					"content":    episode.Content,
+				})
+			}
+			ids = append(ids, id)
+		}
+		nodesContext = append(nodesContext, map[string]interface{}{
+			"node_id":      i,
+			"name":         node.Name,
+			"entity_types": []string{"Entity", node.EntityType},
+			"episode_ids":  ids,
+		})
+	}
+
+	summaries := make(map[int]string)
+	if len(nodesContext) > 0 {
+		messages, err := no.prompts.SummarizeNodes().RefreshSummaries().Call(map[string]interface{}{
+			"nodes":                   nodesContext,
+			"episodes":                episodesContext,
+			prompts.SummaryOptionsKey: no.summaryOptions,
+			"ensure_ascii":            true,
+			"logger":                  no.logger,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create refresh summaries prompt: %w", err)
+		}
+
+		csvParser := func(csvContent string) ([]*prompts.ExtractedNodeAttributes, error) {
+			return utils.DuckDbUnmarshalCSV[prompts.ExtractedNodeAttributes](csvContent, '\t')
+		}
+		extracted, badResp, err := llm.GenerateCSVResponse[prompts.ExtractedNodeAttributes](ctx, no.llm, no.logger, messages, csvParser, 3)
+		if err != nil {
+			if badResp != nil {
+				no.logger.Error("Failed to parse refreshed summaries",
+					"error", badResp.Error,
+					"response_length", len(badResp.Response))
+			}
+			return nil, fmt.Errorf("failed to refresh summaries: %w", err)
+		}
+		for _, summary := range extracted {
+			if summary.Summary != "" {
+				summaries[summary.NodeID] = summary.Summary
+			}
+		}
+	}
+
+	// Updates made after the refresh, even within the same second, are later
+	// than the truncated refresh time
+	now := time.Now().UTC().Truncate(time.Second)
+	updated := make([]*types.Node, 0, len(nodes))
+	var resummarized []*types.Node
+	for i, node := range nodes {
+		updatedNode := *node
+		updatedNode.UpdatedAt = now
+		updatedNode.Metadata = maps.Clone(node.Metadata)
+		if updatedNode.Metadata == nil {
+			updatedNode.Metadata = make(map[string]interface{})
+		}
+		updatedNode.Metadata[summaryRefreshedAtKey] = now.Format(time.RFC3339)
+		if summary, ok := summaries[i]; ok {
+			updatedNode.Summary = summary
+			resummarized = append(resummarized, &updatedNode)
+		}
+		updated = append(updated, &updatedNode)
+	}
+
+	if no.embedder != nil {
+		if err := no.createNodeEmbeddings(ctx, resummarized); err != nil {
+			no.logger.Warn("Failed to embed refreshed summaries", "error", err)
+		}
+	}
+	if err := no.driver.UpsertNodes(ctx, updated); err != nil {
+		return nil, fmt.Errorf("failed to save refreshed summaries: %w", err)
+	}
+	return updated, nil
+}
+
+// mentioningEpisodes returns the most recent episodes mentioning an entity,
+// oldest first.
+func (no *NodeOperations) mentioningEpisodes(ctx context.Context, nodeUUID, groupID string, limit int) ([]*types.Node, error) {
+	related, err := no.driver.GetRelatedNodes(ctx, nodeUUID, groupID, []types.EdgeType{types.EpisodicEdgeType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get episodes mentioning %s: %w", nodeUUID, err)
+	}
+
+	var episodes []*types.Node
+	for _, node := range related {
+		if node.Type == types.EpisodicNodeType {
+			episodes = append(episodes, node)
+		}
+	}
+	sort.Slice(episodes, func(i, j int) bool {
+		if !episodes[i].ValidFrom.Equal(episodes[j].ValidFrom) {
+			return episodes[i].ValidFrom.Before(episodes[j].ValidFrom)
+		}
+		return episodes[i].Uuid < episodes[j].Uuid
+	})
+	if len(episodes) > limit {
+		episodes = episodes[len(episodes)-limit:]
+	}
+	return episodes, nil
+}
+
+// summaryStale reports whether node was updated since RefreshSummaries last
+// refreshed its summary.
+func summaryStale(node *types.Node) bool {
+	refreshedAt, ok := node.Metadata[summaryRefreshedAtKey].(string)
+	if !ok {
+		return true
+	}
+	at, err := time.Parse(time.RFC3339, refreshedAt)
+	return err != nil || node.UpdatedAt.After(at)
+}
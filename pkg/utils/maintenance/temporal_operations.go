@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
 	"github.com/soundprediction/go-predicato/pkg/prompts"
 	"github.com/soundprediction/go-predicato/pkg/types"
 	"github.com/soundprediction/go-predicato/pkg/utils"
@@ -32,6 +33,27 @@ func NewTemporalOperations(llm llm.Client, prompts prompts.Library, logger *slog
 
 // ExtractEdgeDates extracts temporal information for an edge from episode context
 func (to *TemporalOperations) ExtractEdgeDates(ctx context.Context, edge *types.Edge, currentEpisode *types.Node, previousEpisodes []*types.Node) (*time.Time, *time.Time, error) {
+	validAts, invalidAts, err := to.ExtractEdgesDates(ctx, []*types.Edge{edge}, currentEpisode, previousEpisodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return validAts[0], invalidAts[0], nil
+}
+
+// ExtractEdgesDates extracts the times at which the facts of edges became and
+// stopped being true from the episode they were extracted from, in one
+// prompt. Relative expressions such as "since 2019" or "until last March" are
+// resolved against the valid time of the episode. The returned slices are
+// indexed like edges and hold nil where the text gives no time.
+func (to *TemporalOperations) ExtractEdgesDates(ctx context.Context, edges []*types.Edge, currentEpisode *types.Node, previousEpisodes []*types.Node) ([]*time.Time, []*time.Time, error) {
+	ctx = usage.WithOperation(ctx, usage.OperationExtractEdgeDates)
+
+	validAts := make([]*time.Time, len(edges))
+	invalidAts := make([]*time.Time, len(edges))
+	if len(edges) == 0 {
+		return validAts, invalidAts, nil
+	}
+
 	start := time.Now()
 
 	// Prepare previous episodes content
@@ -40,13 +62,26 @@ func (to *TemporalOperations) ExtractEdgeDates(ctx context.Context, edge *types.
 		previousEpisodeContents[i] = ep.Summary
 	}
 
+	edgesContext := make([]map[string]interface{}, len(edges))
+	for i, edge := range edges {
+		fact := edge.Fact
+		if fact == "" {
+			fact = edge.Summary
+		}
+		edgesContext[i] = map[string]interface{}{
+			"id":   i,
+			"fact": fact,
+		}
+	}
+
 	// Prepare context for LLM
 	promptContext := map[string]interface{}{
-		"edge_fact":           edge.Summary,
-		"current_episode":     currentEpisode.Summary,
-		"previous_episodes":   previousEpisodeContents,
-		"reference_timestamp": currentEpisode.ValidFrom.Format(time.RFC3339),
-		"ensure_ascii":        true,
+		"edges":             edgesContext,
+		"episode_content":   currentEpisode.Content,
+		"previous_episodes": previousEpisodeContents,
+		"reference_time":    currentEpisode.ValidFrom.Format(time.RFC3339),
+		"ensure_ascii":      true,
+		"logger":            to.logger,
 	}
 
 	// Extract dates using LLM
@@ -75,47 +110,47 @@ func (to *TemporalOperations) ExtractEdgeDates(ctx context.Context, edge *types.
 		return nil, nil, fmt.Errorf("failed to extract edge dates: %w", err)
 	}
 
-	// We expect exactly one row with the dates
-	if len(edgeDatesSlice) == 0 {
-		return nil, nil, nil
+	for _, edgeDates := range edgeDatesSlice {
+		if edgeDates.ID < 0 || edgeDates.ID >= len(edges) {
+			continue
+		}
+		validAt := parseEdgeDate(edgeDates.ValidAt)
+		invalidAt := parseEdgeDate(edgeDates.InvalidAt)
+		if validAt != nil && invalidAt != nil && invalidAt.Before(*validAt) {
+			log.Printf("Warning: ignoring invalid_at %v before valid_at %v for edge %s", invalidAt, validAt, edges[edgeDates.ID].Uuid)
+			invalidAt = nil
+		}
+		validAts[edgeDates.ID] = validAt
+		invalidAts[edgeDates.ID] = invalidAt
 	}
 
-	edgeDates := edgeDatesSlice[0]
-	var validAt *time.Time
-	var invalidAt *time.Time
+	log.Printf("Extracted dates of %d edges in %v", len(edges), time.Since(start))
+	return validAts, invalidAts, nil
+}
 
-	// Parse valid_at date
-	if edgeDates.ValidAt != "" {
-		// Strip any surrounding quotes (can happen with double JSON encoding)
-		cleanValidAt := strings.Trim(edgeDates.ValidAt, "\"")
-		parsed, err := time.Parse(time.RFC3339, strings.ReplaceAll(cleanValidAt, "Z", "+00:00"))
-		if err != nil {
-			log.Printf("Warning: failed to parse valid_at date '%s': %v", cleanValidAt, err)
-		} else {
-			utcTime := parsed.UTC()
-			validAt = &utcTime
-		}
+// parseEdgeDate parses a date extracted by the LLM, as an ISO 8601 datetime or
+// date. It returns nil for empty or unparsable dates.
+func parseEdgeDate(value string) *time.Time {
+	// Strip any surrounding quotes (can happen with double JSON encoding)
+	value = strings.Trim(strings.TrimSpace(value), "\"")
+	if value == "" || value == "null" {
+		return nil
 	}
 
-	// Parse invalid_at date
-	if edgeDates.InvalidAt != "" {
-		// Strip any surrounding quotes (can happen with double JSON encoding)
-		cleanInvalidAt := strings.Trim(edgeDates.InvalidAt, "\"")
-		parsed, err := time.Parse(time.RFC3339, strings.ReplaceAll(cleanInvalidAt, "Z", "+00:00"))
-		if err != nil {
-			log.Printf("Warning: failed to parse invalid_at date '%s': %v", cleanInvalidAt, err)
-		} else {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", time.DateOnly} {
+		if parsed, err := time.Parse(layout, value); err == nil {
 			utcTime := parsed.UTC()
-			invalidAt = &utcTime
+			return &utcTime
 		}
 	}
-
-	log.Printf("Extracted edge dates in %v", time.Since(start))
-	return validAt, invalidAt, nil
+	log.Printf("Warning: failed to parse edge date '%s'", value)
+	return nil
 }
 
 // GetEdgeContradictions identifies edges that contradict a new edge
 func (to *TemporalOperations) GetEdgeContradictions(ctx context.Context, newEdge *types.Edge, existingEdges []*types.Edge) ([]*types.Edge, error) {
+	ctx = usage.WithOperation(ctx, usage.OperationInvalidateEdges)
+
 	if len(existingEdges) == 0 {
 		return []*types.Edge{}, nil
 	}
@@ -188,25 +223,17 @@ func (to *TemporalOperations) ExtractAndSaveEdgeDates(ctx context.Context, edges
 
 	log.Printf("Extracting dates for %d edges", len(edges))
 
-	var updatedEdges []*types.Edge
-
-	for _, edge := range edges {
-		// Extract dates for this edge
-		validAt, invalidAt, err := to.ExtractEdgeDates(ctx, edge, currentEpisode, previousEpisodes)
-		if err != nil {
-			log.Printf("Warning: failed to extract dates for edge %s: %v", edge.Uuid, err)
-			updatedEdges = append(updatedEdges, edge) // Use original edge if extraction fails
-			continue
-		}
+	validAts, invalidAts, err := to.ExtractEdgesDates(ctx, edges, currentEpisode, previousEpisodes)
+	if err != nil {
+		log.Printf("Warning: failed to extract edge dates: %v", err)
+		return edges, nil // Use original edges if extraction fails
+	}
 
+	updatedEdges := make([]*types.Edge, 0, len(edges))
+	for i, edge := range edges {
 		// Create updated edge with new temporal information
 		updatedEdge := *edge // Copy the edge
-		if validAt != nil {
-			updatedEdge.ValidFrom = *validAt
-		}
-		if invalidAt != nil {
-			updatedEdge.ValidTo = invalidAt
-		}
+		applyEdgeDates(&updatedEdge, validAts[i], invalidAts[i])
 		updatedEdge.UpdatedAt = time.Now().UTC()
 
 		updatedEdges = append(updatedEdges, &updatedEdge)
@@ -216,6 +243,19 @@ func (to *TemporalOperations) ExtractAndSaveEdgeDates(ctx context.Context, edges
 	return updatedEdges, nil
 }
 
+// applyEdgeDates sets the extracted valid and invalid times of an edge,
+// keeping its current times where none was extracted.
+func applyEdgeDates(edge *types.Edge, validAt, invalidAt *time.Time) {
+	if validAt != nil {
+		edge.ValidFrom = *validAt
+		edge.ValidAt = validAt
+	}
+	if invalidAt != nil {
+		edge.ValidTo = invalidAt
+		edge.InvalidAt = invalidAt
+	}
+}
+
 // ValidateEdgeTemporalConsistency checks if edge temporal information is consistent
 func (to *TemporalOperations) ValidateEdgeTemporalConsistency(edge *types.Edge) error {
 	// Check if ValidTo is after ValidFrom
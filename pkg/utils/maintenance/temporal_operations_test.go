@@ -0,0 +1,94 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+func TestParseEdgeDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"2019-03-01T12:30:00Z", "2019-03-01T12:30:00Z"},
+		{"2019-03-01T12:30:00+02:00", "2019-03-01T10:30:00Z"},
+		{"2019-03-01T12:30:00.250Z", "2019-03-01T12:30:00.25Z"},
+		{"2019-03-01T12:30:00", "2019-03-01T12:30:00Z"},
+		{"2019-03-01", "2019-03-01T00:00:00Z"},
+		{` "2019-03-01" `, "2019-03-01T00:00:00Z"},
+		{"", ""},
+		{"null", ""},
+		{"since 2019", ""},
+	}
+	for _, tt := range tests {
+		got := parseEdgeDate(tt.value)
+		switch {
+		case tt.want == "" && got != nil:
+			t.Errorf("parseEdgeDate(%q) = %v, want nil", tt.value, got)
+		case tt.want != "" && (got == nil || got.Format(time.RFC3339Nano) != tt.want):
+			t.Errorf("parseEdgeDate(%q) = %v, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestExtractEdgesDates(t *testing.T) {
+	episodeTime := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	episode := &types.Node{Uuid: "ep", Content: "Alice has worked at Acme since 2019. She left Globex in 2018, after joining it in 2015.", ValidFrom: episodeTime}
+	var edges []*types.Edge
+	for _, fact := range []string{"Alice works at Acme", "Alice worked at Globex", "Alice knows Bob"} {
+		edge := types.NewEntityEdge(fact, "alice", "other", "g", "RELATES_TO", types.EntityEdgeType)
+		edge.Fact = fact
+		edge.ValidFrom = episodeTime
+		edges = append(edges, edge)
+	}
+
+	// Edge 2 ends before it starts and id 7 is not an edge
+	answer := &answerLLM{content: "id\tvalid_at\tinvalid_at\n" +
+		"0\t2019-01-01\t\n" +
+		"1\t2015-01-01\t2018-01-01\n" +
+		"2\t2020-01-01\t2010-01-01\n" +
+		"7\t2000-01-01\t\n"}
+	to := NewTemporalOperations(answer, prompts.NewLibrary(), discardLogger)
+	validAts, invalidAts, err := to.ExtractEdgesDates(context.Background(), edges, episode, nil)
+	if err != nil {
+		t.Fatalf("ExtractEdgesDates() error = %v", err)
+	}
+	if len(validAts) != 3 || len(invalidAts) != 3 {
+		t.Fatalf("ExtractEdgesDates() returned %d and %d dates, want 3", len(validAts), len(invalidAts))
+	}
+
+	date := func(year int) time.Time { return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC) }
+	if validAts[0] == nil || !validAts[0].Equal(date(2019)) || invalidAts[0] != nil {
+		t.Errorf("edge 0 dates = %v, %v, want 2019 and nil", validAts[0], invalidAts[0])
+	}
+	if validAts[1] == nil || !validAts[1].Equal(date(2015)) || invalidAts[1] == nil || !invalidAts[1].Equal(date(2018)) {
+		t.Errorf("edge 1 dates = %v, %v, want 2015 and 2018", validAts[1], invalidAts[1])
+	}
+	if validAts[2] == nil || !validAts[2].Equal(date(2020)) || invalidAts[2] != nil {
+		t.Errorf("edge 2 dates = %v, %v, want 2020 and the earlier invalid_at dropped", validAts[2], invalidAts[2])
+	}
+
+	for i, edge := range edges {
+		applyEdgeDates(edge, validAts[i], invalidAts[i])
+	}
+	if !edges[0].ValidFrom.Equal(date(2019)) || edges[0].ValidTo != nil {
+		t.Errorf("edge 0 valid from %v to %v, want from 2019", edges[0].ValidFrom, edges[0].ValidTo)
+	}
+	if edges[1].ValidTo == nil || !edges[1].ValidTo.Equal(date(2018)) || edges[1].InvalidAt == nil {
+		t.Errorf("edge 1 valid to %v, invalid at %v, want 2018", edges[1].ValidTo, edges[1].InvalidAt)
+	}
+
+	// Edges without extracted dates keep the time of the episode
+	applyEdgeDates(edges[2], nil, nil)
+	if !edges[2].ValidFrom.Equal(date(2020)) {
+		t.Errorf("edge 2 valid from %v after applying no dates, want unchanged", edges[2].ValidFrom)
+	}
+
+	if validAts, _, err := to.ExtractEdgesDates(context.Background(), nil, episode, nil); err != nil || len(validAts) != 0 || answer.calls != 1 {
+		t.Errorf("ExtractEdgesDates() of no edges = %v, %v after %d LLM calls, want no call", validAts, err, answer.calls)
+	}
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// NameIndex is a MinHash/LSH index over entity names that finds approximate
+// duplicates of a name without comparing it to every entity. Entities can be
+// added at any time. A NameIndex is safe for concurrent use.
+type NameIndex struct {
+	mu      sync.RWMutex
+	indexes *DedupCandidateIndexes
+}
+
+// NewNameIndex returns an index of the names of nodes.
+func NewNameIndex(nodes []*types.Node) *NameIndex {
+	index := &NameIndex{indexes: BuildCandidateIndexes(nil)}
+	index.Add(nodes...)
+	return index
+}
+
+// Add indexes nodes not indexed yet.
+func (ix *NameIndex) Add(nodes ...*types.Node) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for _, node := range nodes {
+		if node == nil || ix.indexes.NodesByUUID[node.Uuid] != nil {
+			continue
+		}
+		ix.indexes.ExistingNodes = append(ix.indexes.ExistingNodes, node)
+		ix.indexes.add(node)
+	}
+}
+
+// Len returns the number of indexed nodes.
+func (ix *NameIndex) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.indexes.ExistingNodes)
+}
+
+// Resolve resolves extractedNodes to indexed nodes with a unique exact name
+// match or a fuzzy match of Jaccard similarity FuzzyJaccardThreshold or more,
+// as ResolveWithSimilarity. The nodes left for the LLM are listed in
+// UnresolvedIndices.
+func (ix *NameIndex) Resolve(extractedNodes []*types.Node) *DedupResolutionState {
+	state := &DedupResolutionState{
+		ResolvedNodes: make([]*types.Node, len(extractedNodes)),
+		UUIDMap:       make(map[string]string),
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	ResolveWithSimilarity(extractedNodes, ix.indexes, state)
+	return state
+}
+
+// Candidates returns up to limit indexed nodes whose names may be duplicates
+// of name: exact matches first, then the nodes sharing an LSH band with it by
+// descending Jaccard similarity of their shingles.
+func (ix *NameIndex) Candidates(name string, limit int) []*types.Node {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var candidates []*types.Node
+	for _, node := range ix.indexes.NormalizedExisting[NormalizeStringExact(name)] {
+		if !seen[node.Uuid] {
+			seen[node.Uuid] = true
+			candidates = append(candidates, node)
+		}
+	}
+
+	shingles := CachedShingles(normalizeNameForFuzzy(name))
+	scores := make(map[string]float64)
+	var fuzzy []string
+	for bandIndex, band := range LSHBands(MinHashSignature(shingles)) {
+		bandKey := fmt.Sprintf("%d:%v", bandIndex, band)
+		for _, uuid := range ix.indexes.LSHBuckets[bandKey] {
+			if seen[uuid] {
+				continue
+			}
+			seen[uuid] = true
+			scores[uuid] = JaccardSimilarity(shingles, ix.indexes.ShinglesByCandidate[uuid])
+			fuzzy = append(fuzzy, uuid)
+		}
+	}
+	sort.Slice(fuzzy, func(i, j int) bool {
+		if scores[fuzzy[i]] != scores[fuzzy[j]] {
+			return scores[fuzzy[i]] > scores[fuzzy[j]]
+		}
+		return fuzzy[i] < fuzzy[j]
+	})
+	for _, uuid := range fuzzy {
+		candidates = append(candidates, ix.indexes.NodesByUUID[uuid])
+	}
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
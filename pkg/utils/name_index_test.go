@@ -0,0 +1,51 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameIndex(t *testing.T) {
+	index := utils.NewNameIndex([]*types.Node{
+		{Uuid: "acme", Name: "Acme Corporation Limited"},
+		{Uuid: "globex", Name: "Globex Industries"},
+	})
+	assert.Equal(t, 2, index.Len())
+
+	t.Run("resolves exact and near-exact names", func(t *testing.T) {
+		extracted := []*types.Node{
+			{Uuid: "new-acme", Name: "acme corporation  limited"},
+			{Uuid: "new-initech", Name: "Initech Software"},
+		}
+
+		state := index.Resolve(extracted)
+		require.NotNil(t, state.ResolvedNodes[0])
+		assert.Equal(t, "acme", state.ResolvedNodes[0].Uuid)
+		assert.Equal(t, "acme", state.UUIDMap["new-acme"])
+		assert.Equal(t, []int{1}, state.UnresolvedIndices)
+		require.Len(t, state.DuplicatePairs, 1)
+		assert.Equal(t, "new-acme", state.DuplicatePairs[0].Source.Uuid)
+	})
+
+	t.Run("adds nodes incrementally", func(t *testing.T) {
+		index.Add(&types.Node{Uuid: "initech", Name: "Initech Software"})
+		index.Add(&types.Node{Uuid: "initech", Name: "Initech Software"})
+		assert.Equal(t, 3, index.Len())
+
+		state := index.Resolve([]*types.Node{{Uuid: "new-initech", Name: "Initech Software"}})
+		require.NotNil(t, state.ResolvedNodes[0])
+		assert.Equal(t, "initech", state.ResolvedNodes[0].Uuid)
+	})
+
+	t.Run("finds candidates by name", func(t *testing.T) {
+		candidates := index.Candidates("Globex Industries", 5)
+		require.NotEmpty(t, candidates)
+		assert.Equal(t, "globex", candidates[0].Uuid)
+
+		assert.Empty(t, index.Candidates("Umbrella Pharmaceuticals", 5))
+	})
+}
@@ -4,14 +4,19 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/soundprediction/go-predicato/pkg/community"
 	"github.com/soundprediction/go-predicato/pkg/driver"
 	"github.com/soundprediction/go-predicato/pkg/embedder"
 	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/redact"
 	"github.com/soundprediction/go-predicato/pkg/search"
 	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils"
 	"github.com/soundprediction/go-predicato/pkg/utils/maintenance"
 )
 
@@ -31,6 +36,10 @@ func (w *driverWrapper) Provider() types.GraphProvider {
 		return types.GraphProviderFalkorDB
 	case driver.GraphProviderNeptune:
 		return types.GraphProviderNeptune
+	case driver.GraphProviderArangoDB:
+		return types.GraphProviderArangoDB
+	case driver.GraphProviderSurrealDB:
+		return types.GraphProviderSurrealDB
 	default:
 		return types.GraphProviderLadybug // default fallback
 	}
@@ -44,8 +53,12 @@ type nodeOpsWrapper struct {
 // ResolveExtractedNodes wraps maintenance.NodeOperations.ResolveExtractedNodes to match the interface
 func (w *nodeOpsWrapper) ResolveExtractedNodes(ctx context.Context, extractedNodes []*types.Node, episode *types.Node, previousEpisodes []*types.Node, entityTypes map[string]interface{}) ([]*types.Node, map[string]string, interface{}, error) {
 	nodes, uuidMap, pairs, err := w.NodeOperations.ResolveExtractedNodes(ctx, extractedNodes, episode, previousEpisodes, entityTypes)
-	// Return pairs as interface{} to satisfy the interface
-	return nodes, uuidMap, pairs, err
+	// Return pairs as []utils.NodePair so that DedupeNodesBulk keeps them
+	duplicates := make([]utils.NodePair, len(pairs))
+	for i, pair := range pairs {
+		duplicates[i] = utils.NodePair(pair)
+	}
+	return nodes, uuidMap, duplicates, err
 }
 
 // Predicato is the main interface for interacting with temporal knowledge graphs.
@@ -57,6 +70,11 @@ type Predicato interface {
 	// Options parameter is optional and can be nil for default behavior.
 	Add(ctx context.Context, episodes []types.Episode, options *AddEpisodeOptions) (*types.AddBulkEpisodeResults, error)
 
+	// AddBulk adds episodes in a single pass: extracted concurrently,
+	// deduplicated against each other and the graph together, and written at
+	// once. This is equivalent to the Python add_episode_bulk method.
+	AddBulk(ctx context.Context, episodes []types.Episode, options *AddEpisodeOptions) (*types.AddBulkEpisodeResults, error)
+
 	// AddEpisode processes and adds a single episode to the knowledge graph.
 	// This is equivalent to the Python add_episode method.
 	AddEpisode(ctx context.Context, episode types.Episode, options *AddEpisodeOptions) (*types.AddEpisodeResults, error)
@@ -104,6 +122,14 @@ type Client struct {
 	community *community.Builder
 	config    *Config
 	logger    *slog.Logger
+	usage     *usage.Tracker
+	// nameIndexes are the entity name indexes used by deduplication, nil
+	// unless Config.DedupNameIndex is set
+	nameIndexes *maintenance.NameIndexes
+	// stagingQueues are the DuckDB databases of deferred graph ingestion by
+	// path, kept open because a DuckDB file can only be opened once
+	stagingMu     sync.Mutex
+	stagingQueues map[string]*utils.DuckDBWriter
 }
 
 // Config holds configuration for the Predicato client.
@@ -118,6 +144,62 @@ type Config struct {
 	EntityTypes map[string]interface{}
 	EdgeTypes   map[string]interface{}
 	EdgeMap     map[string]map[string][]interface{}
+	// EdgeInvalidationPolicies sets, by edge type name as used in EdgeTypes
+	// and EdgeMap, what happens when a new edge of the type contradicts an
+	// older one. Edge types without a policy expire the older edge, see
+	// maintenance.InvalidationPolicy.
+	EdgeInvalidationPolicies map[string]maintenance.InvalidationPolicy
+	// LLMContextWindow is the context window of the LLM in tokens. When set,
+	// extraction prompts are trimmed or split to fit in it instead of being
+	// rejected by the provider. 0 disables prompt budgeting.
+	LLMContextWindow int
+	// DisableEmbeddingValidation turns off the check that the embeddings written
+	// to a group all have the dimensions recorded in its embedding metadata.
+	DisableEmbeddingValidation bool
+	// DedupNameIndex keeps a MinHash/LSH index of the entity names of each
+	// group in memory. Deduplication resolves extracted entities with a
+	// unique exact or near-exact name match through it without the LLM, and
+	// adds the other name matches to the LLM's candidates. The index of a
+	// group is loaded on first use and does not see entities written by
+	// other processes.
+	DedupNameIndex bool
+	// DedupReviewThreshold is the confidence, from 0 to 1, the LLM needs for
+	// an extracted entity to be merged into an existing duplicate. Less
+	// confident duplicates are kept apart and queued as pending merges, see
+	// ListPendingMerges, ApproveMerge and RejectMerge. 0 merges every
+	// duplicate.
+	DedupReviewThreshold float64
+	// CommunityResolution is the resolution of the Leiden community detection
+	// run by UpdateCommunities: higher values give more, smaller communities.
+	// 0 uses community.DefaultResolution.
+	CommunityResolution float64
+	// Prompts is the prompt library used for extraction and deduplication,
+	// for example with prompts replaced by Library.Register for a domain.
+	// nil uses prompts.NewLibrary().
+	Prompts prompts.Library
+	// PinPromptVersions pins each group to the prompt version of Prompts the
+	// first time it is ingested, and ingests its later episodes with the
+	// prompts of that version even after the built-in prompts change, see
+	// Client.PinPromptVersion. Neo4j, Memgraph and Ladybug store the pins.
+	PinPromptVersions bool
+	// DomainPreamble is appended to the system message of the extraction,
+	// deduplication and summarization prompts to describe the domain of the
+	// episodes, for example "You are extracting facts from obstetric medical
+	// records; preserve clinical terminology", instead of replacing every
+	// prompt with Library.Register.
+	DomainPreamble string
+	// SummaryOptions control the length, style and strictness of the entity
+	// summaries written during ingestion and by RefreshSummaries. The zero
+	// value writes prose summaries of up to prompts.DefaultSummaryMaxWords
+	// words from the provided context only.
+	SummaryOptions prompts.SummaryOptions
+	// Redactor masks personal information such as emails, phone numbers and
+	// SSNs in episode content before it is stored in episode nodes or sent to
+	// the LLM, for example redact.NewRegexRedactor. nil stores content as is.
+	Redactor redact.Redactor
+	// RedactionTokens keeps the values of the tokens of redacted content
+	// outside the graph, for Client.Rehydrate. nil discards them.
+	RedactionTokens redact.TokenStore
 }
 
 // AddEpisodeOptions holds options for adding a single episode.
@@ -133,15 +215,44 @@ type AddEpisodeOptions struct {
 	EdgeTypes map[string]interface{}
 	// EdgeTypeMap mapping of entity pairs to edge types
 	EdgeTypeMap map[string]map[string][]interface{}
-	// OverwriteExisting whether to overwrite an existing episode with the same UUID
-	// Default behavior is false (skip if exists)
+	// OverwriteExisting whether to overwrite an existing episode with the same UUID.
+	// The entities and relationships only the previous version supported are
+	// removed before the episode is reprocessed, so corrected documents don't
+	// leave stale facts behind. Default behavior is false (skip if exists)
 	OverwriteExisting  bool
 	GenerateEmbeddings bool
 	MaxCharacters      int
+	// MaxConcurrentEpisodes is the number of episodes Add processes at once
+	// (default: 1). Raise it when the LLM client batches requests, such as
+	// llm.OpenAIBatchClient, so that the episodes' prompts share batches. Entities
+	// first seen by concurrently processed episodes may not be deduplicated
+	// against each other.
+	MaxConcurrentEpisodes int
+	// MaxReflexionIterations is the number of extra entity extraction passes
+	// made after asking the LLM which entities were missed. 0 uses the
+	// MAX_REFLEXION_ITERATIONS environment variable (default: 0, no reflexion).
+	MaxReflexionIterations int
+	// DeferGraphIngestion stages the graph write of the episode in the DuckDB
+	// database at DuckDBPath instead of writing it to the graph. Entities and
+	// relationships are still extracted and resolved against the graph; an
+	// IngestWorker replays the staged writes later. Communities are not
+	// updated until then, and entities of staged episodes are not
+	// deduplicated against each other. AddBulk does not support it.
+	DeferGraphIngestion bool
+	DuckDBPath          string
+	// TagCommunity records the community whose summary is most similar to the
+	// episode content in the episode metadata, see Node.Community. Episodes
+	// less similar than CommunityTagMinScore to every community are not
+	// tagged.
+	TagCommunity bool
+	// CommunityTagMinScore is the cosine similarity an episode needs with a
+	// community summary to be tagged with it. 0 uses
+	// DefaultCommunityTagMinScore.
+	CommunityTagMinScore float64
 }
 
 // NewClient creates a new Predicato client with the provided configuration.
-func NewClient(driver driver.GraphDriver, llmClient llm.Client, embedderClient embedder.Client, config *Config, logger *slog.Logger) *Client {
+func NewClient(graphDriver driver.GraphDriver, llmClient llm.Client, embedderClient embedder.Client, config *Config, logger *slog.Logger) *Client {
 	if config == nil {
 		config = &Config{
 			GroupID:  "default",
@@ -155,18 +266,46 @@ func NewClient(driver driver.GraphDriver, llmClient llm.Client, embedderClient e
 		logger = slog.Default()
 	}
 
-	searcher := search.NewSearcher(driver, embedderClient, llmClient)
-	communityBuilder := community.NewBuilder(driver, llmClient, embedderClient)
+	// Track token usage and cost of every LLM request made through the client
+	usageTracker := usage.NewTracker()
+	if llmClient != nil {
+		llmClient = usage.NewTrackingClient(llmClient, usageTracker)
+	}
 
-	return &Client{
-		driver:    driver,
+	// Reject embeddings whose dimensions differ from those stored for their group
+	if embedderClient != nil && !config.DisableEmbeddingValidation {
+		graphDriver = driver.NewEmbeddingGuard(graphDriver, embedder.ModelName(embedderClient))
+	}
+
+	if config.Prompts == nil {
+		config.Prompts = prompts.NewLibrary()
+	}
+	if config.DomainPreamble != "" {
+		config.Prompts.SetDomainPreamble(config.DomainPreamble)
+	}
+
+	searcher := search.NewSearcher(graphDriver, embedderClient, llmClient)
+	communityBuilder := community.NewBuilder(graphDriver, llmClient, embedderClient)
+	if config.CommunityResolution > 0 {
+		communityBuilder.SetResolution(config.CommunityResolution)
+	}
+	communityBuilder.SetMaxPromptTokens(llm.PromptTokenLimit(config.LLMContextWindow))
+	communityBuilder.SetDomainPreamble(config.DomainPreamble)
+
+	client := &Client{
+		driver:    graphDriver,
 		llm:       llmClient,
 		embedder:  embedderClient,
 		searcher:  searcher,
 		community: communityBuilder,
 		config:    config,
 		logger:    logger,
+		usage:     usageTracker,
 	}
+	if config.DedupNameIndex {
+		client.nameIndexes = maintenance.NewNameIndexes(graphDriver)
+	}
+	return client
 }
 
 // GetDriver returns the underlying graph driver
@@ -184,6 +323,12 @@ func (c *Client) GetEmbedder() embedder.Client {
 	return c.embedder
 }
 
+// GetUsage returns the token usage and estimated cost of the LLM requests made
+// by the client, broken down by episode, group, operation and model.
+func (c *Client) GetUsage() *types.LLMUsageSummary {
+	return c.usage.Summary()
+}
+
 // GetCommunityBuilder returns the community builder
 func (c *Client) GetCommunityBuilder() *community.Builder {
 	return c.community
@@ -191,9 +336,9 @@ func (c *Client) GetCommunityBuilder() *community.Builder {
 
 var (
 	// ErrNodeNotFound is returned when a node is not found.
-	ErrNodeNotFound = errors.New("node not found")
+	ErrNodeNotFound = driver.ErrNodeNotFound
 	// ErrEdgeNotFound is returned when an edge is not found.
-	ErrEdgeNotFound = errors.New("edge not found")
+	ErrEdgeNotFound = driver.ErrEdgeNotFound
 	// ErrInvalidEpisode is returned when an episode is invalid.
 	ErrInvalidEpisode = errors.New("invalid episode")
 )
@@ -85,11 +85,11 @@ func (m *MockGraphDriver) BuildCommunities(ctx context.Context, groupID string)
 	return nil
 }
 
-func (m *MockGraphDriver) GetExistingCommunity(ctx context.Context, entityUUID string) (*types.Node, error) {
+func (m *MockGraphDriver) GetExistingCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
 	return nil, nil
 }
 
-func (m *MockGraphDriver) FindModalCommunity(ctx context.Context, entityUUID string) (*types.Node, error) {
+func (m *MockGraphDriver) FindModalCommunity(ctx context.Context, entityUUID, groupID string) (*types.Node, error) {
 	return nil, nil
 }
 
@@ -141,6 +141,10 @@ func (m *MockGraphDriver) GetAossClient() interface{} {
 	return nil
 }
 
+func (m *MockGraphDriver) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
 // MockLLMClient is a mock LLM implementation for testing
 type MockLLMClient struct{}
 
@@ -173,6 +177,14 @@ func (m *MockEmbedderClient) EmbedSingle(ctx context.Context, text string) ([]fl
 	return make([]float32, 1536), nil
 }
 
+func (m *MockEmbedderClient) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	return make([]float32, 1536), nil
+}
+
+func (m *MockEmbedderClient) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	return make([]float32, 1536), nil
+}
+
 func (m *MockEmbedderClient) Dimensions() int {
 	return 1536
 }
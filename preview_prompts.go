@@ -0,0 +1,212 @@
+package predicato
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/llm"
+	"github.com/soundprediction/go-predicato/pkg/llm/usage"
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/types"
+	"github.com/soundprediction/go-predicato/pkg/utils/maintenance"
+)
+
+// errPromptPreview is returned by the previewLLM instead of a response.
+var errPromptPreview = errors.New("prompt preview: LLM not called")
+
+// PreviewPromptsOptions configures PreviewPrompts.
+type PreviewPromptsOptions struct {
+	// Options are the options the episode would be added with.
+	Options *AddEpisodeOptions
+	// Entities are the names of the entities to render the prompts of the
+	// stages after entity extraction with, since no entities are extracted
+	// without the LLM. Without them only the extraction prompts are rendered.
+	Entities []string
+}
+
+// PromptPreview is a prompt that adding an episode would send to the LLM.
+type PromptPreview struct {
+	// Stage is the ingestion stage of the prompt, such as
+	// usage.OperationExtractNodes.
+	Stage string
+	// Chunk is the index of the chunk of the episode the prompt is for, or
+	// -1 for prompts about the whole episode.
+	Chunk    int
+	Messages []types.Message
+	// Tokens is the estimated size of the messages.
+	Tokens int
+}
+
+// PreviewPrompts renders the prompts that adding episode would send to the
+// LLM for entity extraction, deduplication, relationship extraction and
+// attribute extraction, without calling the LLM or writing to the graph, to
+// debug the formatting and truncation of their context. The graph is read for
+// previous episodes and deduplication candidates as AddEpisode does.
+func (c *Client) PreviewPrompts(ctx context.Context, episode types.Episode, opts *PreviewPromptsOptions) ([]PromptPreview, error) {
+	if opts == nil {
+		opts = &PreviewPromptsOptions{}
+	}
+	options := opts.Options
+	if options == nil {
+		options = &AddEpisodeOptions{}
+	}
+	maxCharacters := 2048
+	if options.MaxCharacters > 0 {
+		maxCharacters = options.MaxCharacters
+	}
+
+	content, err := c.redactContent(ctx, episode.Content)
+	if err != nil {
+		return nil, err
+	}
+	episode.Content = content
+	chunks, err := c.prepareAndValidateEpisode(&episode, options, maxCharacters)
+	if err != nil {
+		return nil, err
+	}
+	previousEpisodes, err := c.getPreviousEpisodesForContext(ctx, episode, options)
+	if err != nil {
+		return nil, err
+	}
+	library, err := c.previewPromptLibrary(ctx, episode.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder := &previewLLM{}
+	maxPromptTokens := llm.PromptTokenLimit(c.config.LLMContextWindow)
+	nodeOps := maintenance.NewNodeOperations(c.driver, recorder, c.embedder, library)
+	nodeOps.SetLogger(c.logger)
+	nodeOps.SetMaxPromptTokens(maxPromptTokens)
+	nodeOps.SetSummaryOptions(c.config.SummaryOptions)
+	edgeOps := maintenance.NewEdgeOperations(c.driver, recorder, c.embedder, library)
+	edgeOps.SetLogger(c.logger)
+	edgeOps.SetMaxPromptTokens(maxPromptTokens)
+
+	episodeNode := &types.Node{
+		Uuid:        episode.ID,
+		Name:        episode.Name,
+		Type:        types.EpisodicNodeType,
+		EpisodeType: episode.Type(),
+		GroupID:     episode.GroupID,
+		Metadata:    episode.Metadata,
+		ValidFrom:   episode.Reference,
+		CreatedAt:   episode.CreatedAt,
+	}
+
+	var previews []PromptPreview
+	for i, chunk := range chunks {
+		chunkNode := *episodeNode
+		chunkNode.Content = chunk
+		if _, err := nodeOps.ExtractNodes(ctx, &chunkNode, previousEpisodes, options.EntityTypes, options.ExcludedEntityTypes); err != nil && !errors.Is(err, errPromptPreview) {
+			return nil, fmt.Errorf("failed to preview entity extraction prompts: %w", err)
+		}
+		previews = append(previews, recorder.previews(i)...)
+	}
+	if len(opts.Entities) == 0 {
+		return previews, nil
+	}
+
+	episodeNode.Content = episode.Content
+	nodes := make([]*types.Node, len(opts.Entities))
+	for i, name := range opts.Entities {
+		nodes[i] = &types.Node{
+			Uuid:       fmt.Sprintf("preview-entity-%d", i),
+			Name:       name,
+			Type:       types.EntityNodeType,
+			EntityType: "Entity",
+			GroupID:    episode.GroupID,
+			ValidFrom:  episode.Reference,
+			CreatedAt:  episode.CreatedAt,
+		}
+	}
+
+	if _, _, _, err := nodeOps.ResolveExtractedNodes(ctx, nodes, episodeNode, previousEpisodes, options.EntityTypes); err != nil && !errors.Is(err, errPromptPreview) {
+		return nil, fmt.Errorf("failed to preview entity deduplication prompts: %w", err)
+	}
+	if _, err := edgeOps.ExtractEdges(ctx, episodeNode, nodes, previousEpisodes,
+		edgeTypeMapFromOptions(options), options.EdgeTypes, episode.GroupID); err != nil && !errors.Is(err, errPromptPreview) {
+		return nil, fmt.Errorf("failed to preview relationship extraction prompts: %w", err)
+	}
+	if _, err := nodeOps.ExtractAttributesFromNodes(ctx, nodes, episodeNode, previousEpisodes, options.EntityTypes); err != nil && !errors.Is(err, errPromptPreview) {
+		return nil, fmt.Errorf("failed to preview attribute extraction prompts: %w", err)
+	}
+	return append(previews, recorder.previews(-1)...), nil
+}
+
+// previewPromptLibrary returns the prompt library the episodes of a group
+// would be ingested with, without pinning the group.
+func (c *Client) previewPromptLibrary(ctx context.Context, groupID string) (prompts.Library, error) {
+	if !c.config.PinPromptVersions {
+		return c.config.Prompts, nil
+	}
+	version, err := driver.GetPromptVersion(ctx, c.driver, groupID)
+	if errors.Is(err, driver.ErrPromptVersionUnsupported) || version == "" || version == c.config.Prompts.Version() {
+		return c.config.Prompts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.newPromptLibraryVersion(version)
+}
+
+// previewLLM is an llm.Client recording the prompts it is sent instead of
+// calling an LLM.
+type previewLLM struct {
+	mu       sync.Mutex
+	recorded []PromptPreview
+}
+
+// record records a request, unless it is a retry continuing an earlier
+// request with the previous response, and returns errPromptPreview.
+func (p *previewLLM) record(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	if slices.ContainsFunc(messages, func(m types.Message) bool { return m.Role == llm.RoleAssistant }) {
+		return nil, errPromptPreview
+	}
+
+	tokens := 0
+	for _, message := range messages {
+		tokens += llm.GetTokenCount(message.Content)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.recorded = append(p.recorded, PromptPreview{
+		Stage:    usage.OperationFromContext(ctx),
+		Messages: slices.Clone(messages),
+		Tokens:   tokens,
+	})
+	return nil, errPromptPreview
+}
+
+// previews returns the prompts recorded since the last call, for the given
+// chunk.
+func (p *previewLLM) previews(chunk int) []PromptPreview {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	recorded := p.recorded
+	p.recorded = nil
+	for i := range recorded {
+		recorded[i].Chunk = chunk
+	}
+	return recorded
+}
+
+func (p *previewLLM) Chat(ctx context.Context, messages []types.Message) (*types.Response, error) {
+	return p.record(ctx, messages)
+}
+
+func (p *previewLLM) ChatWithStructuredOutput(ctx context.Context, messages []types.Message, schema any) (*types.Response, error) {
+	return p.record(ctx, messages)
+}
+
+func (p *previewLLM) ChatWithTools(ctx context.Context, messages []types.Message, tools []types.Tool) (*types.Response, error) {
+	return p.record(ctx, messages)
+}
+
+func (p *previewLLM) Close() error {
+	return nil
+}
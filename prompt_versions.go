@@ -0,0 +1,127 @@
+package predicato
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/prompts"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// GetPromptVersion returns the prompt version a group is pinned to, or "" if
+// it is not pinned. An empty groupID uses the client's group.
+func (c *Client) GetPromptVersion(ctx context.Context, groupID string) (string, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	return driver.GetPromptVersion(ctx, c.driver, groupID)
+}
+
+// PinPromptVersion pins a group to a prompt version, for example to upgrade
+// it to prompts.LatestVersion. With Config.PinPromptVersions, the episodes
+// of the group are then ingested with the prompts of that version. An empty
+// groupID uses the client's group.
+func (c *Client) PinPromptVersion(ctx context.Context, groupID, version string) error {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	if version != c.config.Prompts.Version() {
+		if _, err := prompts.NewLibraryVersion(version); err != nil {
+			return err
+		}
+	}
+	return driver.SetPromptVersion(ctx, c.driver, groupID, version)
+}
+
+// promptLibrary returns the prompt library the episodes of a group are
+// ingested with: Config.Prompts, or with Config.PinPromptVersions the library
+// of the version the group is pinned to. Groups not pinned yet are pinned to
+// the version of Config.Prompts.
+func (c *Client) promptLibrary(ctx context.Context, groupID string) (prompts.Library, error) {
+	if !c.config.PinPromptVersions {
+		return c.config.Prompts, nil
+	}
+
+	version, err := driver.GetPromptVersion(ctx, c.driver, groupID)
+	if errors.Is(err, driver.ErrPromptVersionUnsupported) {
+		return c.config.Prompts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch version {
+	case "":
+		if err := driver.SetPromptVersion(ctx, c.driver, groupID, c.config.Prompts.Version()); err != nil {
+			return nil, err
+		}
+		return c.config.Prompts, nil
+	case c.config.Prompts.Version():
+		return c.config.Prompts, nil
+	}
+
+	library, err := c.newPromptLibraryVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the prompts group %s is pinned to: %w", groupID, err)
+	}
+	return library, nil
+}
+
+// newPromptLibraryVersion creates the library of a prompt version with the
+// domain preamble of the client.
+func (c *Client) newPromptLibraryVersion(version string) (prompts.Library, error) {
+	library, err := prompts.NewLibraryVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	if c.config.DomainPreamble != "" {
+		library.SetDomainPreamble(c.config.DomainPreamble)
+	}
+	return library, nil
+}
+
+// bulkPromptLibrary returns the prompt library the episodes of batch are
+// ingested with, which must be the same for all their groups.
+func (c *Client) bulkPromptLibrary(ctx context.Context, batch []*bulkEpisode) (prompts.Library, error) {
+	var library prompts.Library
+	seen := make(map[string]bool)
+	for _, e := range batch {
+		if seen[e.episode.GroupID] {
+			continue
+		}
+		seen[e.episode.GroupID] = true
+
+		groupLibrary, err := c.promptLibrary(ctx, e.episode.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		if library == nil {
+			library = groupLibrary
+		} else if groupLibrary.Version() != library.Version() {
+			return nil, fmt.Errorf("episodes of groups pinned to prompt versions %s and %s cannot be added together",
+				library.Version(), groupLibrary.Version())
+		}
+	}
+	return library, nil
+}
+
+// recordPromptVersion records the prompt version that extracted nodes and
+// edges in their metadata.
+func recordPromptVersion(version string, nodes []*types.Node, edges []*types.Edge) {
+	for _, node := range nodes {
+		node.Metadata = maps.Clone(node.Metadata)
+		if node.Metadata == nil {
+			node.Metadata = make(map[string]interface{})
+		}
+		node.Metadata[types.PromptVersionMetadataKey] = version
+	}
+	for _, edge := range edges {
+		edge.Metadata = maps.Clone(edge.Metadata)
+		if edge.Metadata == nil {
+			edge.Metadata = make(map[string]interface{})
+		}
+		edge.Metadata[types.PromptVersionMetadataKey] = version
+	}
+}
@@ -0,0 +1,43 @@
+package predicato
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/soundprediction/go-predicato/pkg/redact"
+)
+
+// redactContent masks the personal information of episode content with
+// Config.Redactor before it is stored or sent to the LLM, saving the values
+// of its tokens to Config.RedactionTokens.
+func (c *Client) redactContent(ctx context.Context, content string) (string, error) {
+	if c.config.Redactor == nil {
+		return content, nil
+	}
+	redacted, tokens, err := c.config.Redactor.Redact(ctx, content)
+	if err != nil {
+		return "", fmt.Errorf("failed to redact episode content: %w", err)
+	}
+	if len(tokens) > 0 && c.config.RedactionTokens != nil {
+		if err := c.config.RedactionTokens.SaveTokens(ctx, tokens); err != nil {
+			return "", fmt.Errorf("failed to save redaction tokens: %w", err)
+		}
+	}
+	return redacted, nil
+}
+
+// Rehydrate restores the personal information redacted from text, such as
+// the content of an episode or a fact, from Config.RedactionTokens. Tokens
+// without a saved value are left as they are. Only call it for readers
+// authorized to see the personal information.
+func (c *Client) Rehydrate(ctx context.Context, text string) (string, error) {
+	tokens := redact.Tokens(text)
+	if len(tokens) == 0 || c.config.RedactionTokens == nil {
+		return text, nil
+	}
+	values, err := c.config.RedactionTokens.LoadTokens(ctx, tokens)
+	if err != nil {
+		return "", fmt.Errorf("failed to load redaction tokens: %w", err)
+	}
+	return redact.Rehydrate(text, values), nil
+}
@@ -18,8 +18,23 @@ func (c *Client) Search(ctx context.Context, query string, config *types.SearchC
 
 	// Convert types.SearchConfig to search.SearchConfig
 	searchConfig := &search.SearchConfig{
-		Limit:    config.Limit,
-		MinScore: config.MinScore,
+		Limit:          config.Limit,
+		MinScore:       config.MinScore,
+		Cursor:         config.Cursor,
+		CenterNodeUUID: config.CenterNodeUUID,
+		QueryExpansion: config.QueryExpansion,
+		AsOf:           config.AsOf,
+
+		FusionMethod:       search.FusionMethod(config.FusionMethod),
+		ScoreNormalization: search.ScoreNormalization(config.ScoreNormalization),
+		Highlight:          config.Highlight,
+		SnippetLength:      config.SnippetLength,
+	}
+	if len(config.FusionWeights) > 0 {
+		searchConfig.FusionWeights = make(map[search.SearchMethod]float64, len(config.FusionWeights))
+		for method, weight := range config.FusionWeights {
+			searchConfig.FusionWeights[convertSearchMethods([]string{method})[0]] = weight
+		}
 	}
 
 	// Convert node config if present
@@ -28,8 +43,13 @@ func (c *Client) Search(ctx context.Context, query string, config *types.SearchC
 			SearchMethods: convertSearchMethods(config.NodeConfig.SearchMethods),
 			Reranker:      convertReranker(config.NodeConfig.Reranker),
 			MinScore:      config.NodeConfig.MinScore,
-			MMRLambda:     0.5, // Default MMR lambda
+			MMRLambda:     config.MMRLambda,
+			MMRSeed:       config.MMRSeed,
 			MaxDepth:      config.CenterNodeDistance,
+
+			BFSOriginNodeUUIDs: config.BFSOriginNodeUUIDs,
+			BFSMaxDepth:        config.BFSMaxDepth,
+			BFSEdgeNames:       config.BFSEdgeNames,
 		}
 	} else {
 		// Default: use all search methods for comprehensive results
@@ -37,8 +57,13 @@ func (c *Client) Search(ctx context.Context, query string, config *types.SearchC
 			SearchMethods: []search.SearchMethod{search.CosineSimilarity, search.BM25, search.BreadthFirstSearch},
 			Reranker:      search.RRFRerankType,
 			MinScore:      0.0,
-			MMRLambda:     0.5,
+			MMRLambda:     config.MMRLambda,
+			MMRSeed:       config.MMRSeed,
 			MaxDepth:      config.CenterNodeDistance,
+
+			BFSOriginNodeUUIDs: config.BFSOriginNodeUUIDs,
+			BFSMaxDepth:        config.BFSMaxDepth,
+			BFSEdgeNames:       config.BFSEdgeNames,
 		}
 	}
 
@@ -48,21 +73,56 @@ func (c *Client) Search(ctx context.Context, query string, config *types.SearchC
 			SearchMethods: convertSearchMethods(config.EdgeConfig.SearchMethods),
 			Reranker:      convertReranker(config.EdgeConfig.Reranker),
 			MinScore:      config.EdgeConfig.MinScore,
-			MMRLambda:     0.5, // Default MMR lambda
+			MMRLambda:     config.MMRLambda,
+			MMRSeed:       config.MMRSeed,
 			MaxDepth:      config.CenterNodeDistance,
+
+			BFSOriginNodeUUIDs: config.BFSOriginNodeUUIDs,
+			BFSMaxDepth:        config.BFSMaxDepth,
+			BFSEdgeNames:       config.BFSEdgeNames,
 		}
 	} else {
 		searchConfig.EdgeConfig = &search.EdgeSearchConfig{
 			SearchMethods: []search.SearchMethod{search.CosineSimilarity, search.BM25, search.BreadthFirstSearch},
 			Reranker:      search.RRFRerankType,
 			MinScore:      0.0,
-			MMRLambda:     0.5,
+			MMRLambda:     config.MMRLambda,
+			MMRSeed:       config.MMRSeed,
 			MaxDepth:      config.CenterNodeDistance,
+
+			BFSOriginNodeUUIDs: config.BFSOriginNodeUUIDs,
+			BFSMaxDepth:        config.BFSMaxDepth,
+			BFSEdgeNames:       config.BFSEdgeNames,
 		}
 	}
 
-	// Create search filters
+	// Convert community config if present; communities are not searched by default
+	if config.CommunityConfig != nil {
+		searchConfig.CommunityConfig = &search.CommunitySearchConfig{
+			SearchMethods: convertSearchMethods(config.CommunityConfig.SearchMethods),
+			Reranker:      convertReranker(config.CommunityConfig.Reranker),
+			MinScore:      config.CommunityConfig.MinScore,
+			MMRLambda:     config.MMRLambda,
+			MMRSeed:       config.MMRSeed,
+		}
+	}
+
+	// Convert search filters; the driver applies all but MinConfidence and
+	// CommunityUUIDs in its queries
 	filters := &search.SearchFilters{}
+	if config.Filters != nil {
+		filters = &search.SearchFilters{
+			GroupIDs:       config.Filters.GroupIDs,
+			NodeTypes:      config.Filters.NodeTypes,
+			EdgeTypes:      config.Filters.EdgeTypes,
+			EntityTypes:    config.Filters.EntityTypes,
+			EdgeNames:      config.Filters.EdgeNames,
+			TimeRange:      config.Filters.TimeRange,
+			ValidAt:        config.Filters.ValidAt,
+			MinConfidence:  config.Filters.MinConfidence,
+			CommunityUUIDs: config.Filters.CommunityUUIDs,
+		}
+	}
 
 	// Perform the search
 	result, err := c.searcher.Search(ctx, query, searchConfig, filters, c.config.GroupID)
@@ -72,10 +132,19 @@ func (c *Client) Search(ctx context.Context, query string, config *types.SearchC
 
 	// Convert back to types.SearchResults
 	searchResults := &types.SearchResults{
-		Nodes: result.Nodes,
-		Edges: result.Edges,
-		Query: result.Query,
-		Total: result.Total,
+		Nodes:       result.Nodes,
+		Edges:       result.Edges,
+		Communities: result.Communities,
+		Query:       result.Query,
+		Total:       result.Total,
+		NextCursor:  result.NextCursor,
+		Highlights:  result.Highlights,
+	}
+
+	if config.CanonicalizeDuplicates {
+		if err := c.canonicalizeDuplicates(ctx, searchResults); err != nil {
+			return nil, err
+		}
 	}
 
 	return searchResults, nil
@@ -91,6 +160,12 @@ func (c *Client) GetEdge(ctx context.Context, edgeID string) (*types.Edge, error
 	return c.driver.GetEdge(ctx, edgeID, c.config.GroupID)
 }
 
+// GetEvidence retrieves the passages of the episodes supporting an edge,
+// oldest first, for citing the sources of a fact.
+func (c *Client) GetEvidence(ctx context.Context, edgeID string) ([]*driver.Evidence, error) {
+	return driver.GetEvidence(ctx, c.driver, edgeID, c.config.GroupID)
+}
+
 // GetStats retrieves statistics about the knowledge graph.
 func (c *Client) GetStats(ctx context.Context) (*driver.GraphStats, error) {
 	return c.driver.GetStats(ctx, c.config.GroupID)
@@ -205,6 +280,8 @@ func convertReranker(reranker string) search.RerankerType {
 		return search.NodeDistanceRerankType
 	case "episode_mentions":
 		return search.EpisodeMentionsRerankType
+	case "strength":
+		return search.StrengthRerankType
 	default:
 		return search.RRFRerankType // Default fallback
 	}
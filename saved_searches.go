@@ -0,0 +1,93 @@
+package predicato
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/soundprediction/go-predicato/pkg/driver"
+	"github.com/soundprediction/go-predicato/pkg/types"
+)
+
+// SaveSearch stores config, including its filters, as the saved search name
+// of a group, replacing any saved search of that name. An empty groupID uses
+// the client's group.
+func (c *Client) SaveSearch(ctx context.Context, groupID, name string, config *types.SearchConfig) error {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	if config == nil {
+		return fmt.Errorf("search configuration is required")
+	}
+
+	saved := *config
+	saved.Cursor = ""
+	return driver.SaveSearch(ctx, c.driver, &driver.SavedSearch{
+		GroupID: groupID,
+		Name:    name,
+		Config:  &saved,
+	})
+}
+
+// GetSavedSearch returns the saved search name of a group, or nil if there is
+// none.
+func (c *Client) GetSavedSearch(ctx context.Context, groupID, name string) (*driver.SavedSearch, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	return driver.GetSavedSearch(ctx, c.driver, groupID, name)
+}
+
+// ListSavedSearches returns the saved searches of a group ordered by name.
+func (c *Client) ListSavedSearches(ctx context.Context, groupID string) ([]*driver.SavedSearch, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	return driver.ListSavedSearches(ctx, c.driver, groupID)
+}
+
+// DeleteSavedSearch deletes the saved search name of a group.
+func (c *Client) DeleteSavedSearch(ctx context.Context, groupID, name string) error {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	return driver.DeleteSavedSearch(ctx, c.driver, groupID, name)
+}
+
+// SavedSearchConfig returns a copy of the configuration of the saved search
+// name of a group, ready for Search. Saved searches without group filters are
+// scoped to their group.
+func (c *Client) SavedSearchConfig(ctx context.Context, groupID, name string) (*types.SearchConfig, error) {
+	if groupID == "" {
+		groupID = c.config.GroupID
+	}
+	saved, err := driver.GetSavedSearch(ctx, c.driver, groupID, name)
+	if err != nil {
+		return nil, err
+	}
+	if saved == nil {
+		return nil, fmt.Errorf("saved search %q not found in group %q", name, groupID)
+	}
+
+	config := &types.SearchConfig{}
+	if saved.Config != nil {
+		*config = *saved.Config
+	}
+	filters := &types.SearchFilters{}
+	if config.Filters != nil {
+		*filters = *config.Filters
+	}
+	if len(filters.GroupIDs) == 0 {
+		filters.GroupIDs = []string{groupID}
+	}
+	config.Filters = filters
+	return config, nil
+}
+
+// SearchSaved runs query with the saved search name of a group.
+func (c *Client) SearchSaved(ctx context.Context, groupID, name, query string) (*types.SearchResults, error) {
+	config, err := c.SavedSearchConfig(ctx, groupID, name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Search(ctx, query, config)
+}